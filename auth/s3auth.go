@@ -161,6 +161,10 @@ type s3Tenant struct {
 	cfg  *db.TenantConfig
 }
 
+func init() {
+	db.RegisterBackend("s3", db.BackendFunc(S3TenantFromEnv))
+}
+
 // S3TenantFromEnv constructs an s3 tenant from the environment.
 func S3TenantFromEnv(ctx context.Context, bucket string) (db.Tenant, error) {
 	key, err := aws.AmbientKey("s3", s3.DeriveForBucket(bucket))
@@ -92,11 +92,33 @@ func (b *BucketFS) Put(where string, contents []byte) (string, error) {
 	return b.put(where, contents)
 }
 
+// PutNew is like Put, but it only creates the object
+// if there is no object at the key 'where' yet;
+// otherwise it returns an error matching fs.ErrExist.
+func (b *BucketFS) PutNew(where string, contents []byte) (string, error) {
+	where = path.Clean(where)
+	if !fs.ValidPath(where) {
+		return "", badpath("s3 PUT", where)
+	}
+	_, base := path.Split(where)
+	if base == "." {
+		return "", badpath("s3 PUT", where)
+	}
+	return b.putIf(where, contents, true)
+}
+
 func (b *BucketFS) put(where string, contents []byte) (string, error) {
+	return b.putIf(where, contents, false)
+}
+
+func (b *BucketFS) putIf(where string, contents []byte, excl bool) (string, error) {
 	req, err := http.NewRequestWithContext(b.Ctx, http.MethodPut, uri(b.Key, b.Bucket, where), nil)
 	if err != nil {
 		return "", err
 	}
+	if excl {
+		req.Header.Set("If-None-Match", "*")
+	}
 	b.Key.SignV4(req, contents)
 	client := b.Client
 	if client == nil {
@@ -107,6 +129,9 @@ func (b *BucketFS) put(where string, contents []byte) (string, error) {
 		return "", err
 	}
 	defer res.Body.Close()
+	if excl && res.StatusCode == http.StatusPreconditionFailed {
+		return "", &fs.PathError{Op: "s3 PUT", Path: where, Err: fs.ErrExist}
+	}
 	if res.StatusCode != 200 {
 		return "", fmt.Errorf("s3 PUT: %s %s", res.Status, extractMessage(res.Body))
 	}
@@ -143,7 +168,11 @@ func (b *BucketFS) OpenRange(name, etag string, start, width int64) (io.ReadClos
 		Path:   name,
 		ETag:   etag,
 	}
-	return r.RangeReader(start, width)
+	ctx := b.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return r.rangeReader(ctx, start, width)
 }
 
 // Open implements fs.FS.Open
@@ -426,7 +455,7 @@ func (f *File) Read(p []byte) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	f.body, err = f.Reader.RangeReader(f.pos, f.Size()-f.pos)
+	f.body, err = f.Reader.rangeReader(f.ctx, f.pos, f.Size()-f.pos)
 	if err != nil {
 		return 0, err
 	}
@@ -435,6 +464,15 @@ func (f *File) Read(p []byte) (int, error) {
 	return n, err
 }
 
+// ReadAt implements io.ReaderAt
+//
+// Unlike Reader.ReadAt, requests made by
+// File.ReadAt (including retries) are bound
+// to the context of the parent bucket.
+func (f *File) ReadAt(dst []byte, off int64) (int, error) {
+	return f.Reader.readAt(f.ctx, dst, off)
+}
+
 type inputParquet struct {
 	XMLName xml.Name `xml:"Parquet"`
 }
@@ -227,32 +227,6 @@ func Open(k *aws.SigningKey, bucket, object string, contents bool) (*File, error
 	return f, nil
 }
 
-func flakyDo(cl *http.Client, req *http.Request) (*http.Response, error) {
-	hasBody := req.Body != nil
-	if cl == nil {
-		cl = &DefaultClient
-	}
-	res, err := cl.Do(req)
-	if err == nil && (res.StatusCode != 500 && res.StatusCode != 503) {
-		return res, err
-	}
-	if hasBody && req.GetBody == nil {
-		// can't re-do this request because
-		// we can't rewind the Body reader
-		return res, err
-	}
-	if res != nil {
-		res.Body.Close()
-	}
-	if hasBody {
-		req.Body, err = req.GetBody()
-		if err != nil {
-			return nil, fmt.Errorf("req.GetBody: %w", err)
-		}
-	}
-	return cl.Do(req)
-}
-
 func (f *File) open(k *aws.SigningKey, bucket, object string, contents bool) error {
 	body, err := f.Reader.open(k, bucket, object, true)
 	if err != nil {
@@ -349,7 +323,11 @@ func (r *Reader) WriteTo(w io.Writer) (int64, error) {
 // It is the caller's responsibility to call Close()
 // on the returned io.ReadCloser.
 func (r *Reader) RangeReader(off, width int64) (io.ReadCloser, error) {
-	req, err := http.NewRequest("GET", uri(r.Key, r.Bucket, r.Path), nil)
+	return r.rangeReader(context.Background(), off, width)
+}
+
+func (r *Reader) rangeReader(ctx context.Context, off, width int64) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", uri(r.Key, r.Bucket, r.Path), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -377,13 +355,65 @@ func (r *Reader) RangeReader(off, width int64) (io.ReadCloser, error) {
 }
 
 // ReadAt implements io.ReaderAt
+//
+// If the response body is interrupted partway
+// through the read, ReadAt retries the read
+// starting from the first byte it has not yet
+// received rather than from off.
 func (r *Reader) ReadAt(dst []byte, off int64) (int, error) {
-	rd, err := r.RangeReader(off, int64(len(dst)))
-	if err != nil {
-		return 0, err
+	return r.readAt(context.Background(), dst, off)
+}
+
+func (r *Reader) readAt(ctx context.Context, dst []byte, off int64) (int, error) {
+	n := 0
+	for attempt := 1; ; attempt++ {
+		rd, err := r.rangeReader(ctx, off+int64(n), int64(len(dst)-n))
+		if err != nil {
+			return n, err
+		}
+		m, err := readFull(rd, dst[n:])
+		rd.Close()
+		n += m
+		if err == nil {
+			return n, nil
+		}
+		if err == io.EOF {
+			// the object is shorter than the requested range
+			if n == 0 {
+				return 0, io.EOF
+			}
+			return n, io.ErrUnexpectedEOF
+		}
+		if ctx.Err() != nil {
+			return n, err
+		}
+		if attempt == maxAttempts {
+			return n, fmt.Errorf("s3.Reader.ReadAt: %w after %d attempts: %w", ErrRetriesExhausted, attempt, err)
+		}
+		if stop := pause(ctx, attempt); stop != nil {
+			return n, fmt.Errorf("s3.Reader.ReadAt: %w (last error: %v)", stop, err)
+		}
+	}
+}
+
+// readFull is like io.ReadFull, except that it
+// returns io.EOF whenever rd ends cleanly before
+// dst is full, so that a short object can be
+// distinguished from a truncated response body
+// (which the http client reports as io.ErrUnexpectedEOF)
+func readFull(rd io.Reader, dst []byte) (int, error) {
+	n := 0
+	for n < len(dst) {
+		m, err := rd.Read(dst[n:])
+		n += m
+		if err != nil {
+			if n == len(dst) && err == io.EOF {
+				err = nil
+			}
+			return n, err
+		}
 	}
-	defer rd.Close()
-	return io.ReadFull(rd, dst)
+	return n, nil
 }
 
 // BucketRegion returns the region associated
@@ -0,0 +1,148 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	// maxAttempts is the maximum number of
+	// times that a request is attempted
+	// before we give up on it
+	maxAttempts = 5
+	// backoffBase is the upper bound on the
+	// delay before the first retry; the bound
+	// doubles on each subsequent retry
+	// until it reaches backoffMax
+	backoffBase = 50 * time.Millisecond
+	backoffMax  = 2 * time.Second
+)
+
+// ErrRetriesExhausted is returned from operations
+// that failed with a retryable error more times
+// than this package is willing to retry them.
+var ErrRetriesExhausted = errors.New("s3: retries exhausted")
+
+// retryable returns whether or not a response
+// with the given status code is likely to be
+// transient and should therefore be retried
+// (S3 responds with 503 SlowDown when throttling)
+func retryable(status int) bool {
+	switch status {
+	case http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+		http.StatusTooManyRequests:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before the
+// given retry attempt (starting at 1)
+// using exponential backoff with "full jitter"
+func backoff(attempt int) time.Duration {
+	d := backoffMax
+	if attempt < 16 {
+		d = min(backoffBase<<(attempt-1), backoffMax)
+	}
+	return time.Duration(rand.Int63n(int64(d))) + 1
+}
+
+// pause waits for the given retry attempt's
+// backoff delay, or returns an error if ctx
+// is canceled or its deadline would expire
+// before the delay elapses
+func pause(ctx context.Context, attempt int) error {
+	d := backoff(attempt)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return context.DeadlineExceeded
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// giveUp produces the error returned when
+// a request has failed too many times, or when
+// the request context precludes retrying it
+func giveUp(req *http.Request, attempts int, stop, last error) error {
+	if stop != nil {
+		return fmt.Errorf("s3 %s %s: %w after %d attempt(s) (last error: %v)", req.Method, req.URL.Path, stop, attempts, last)
+	}
+	return fmt.Errorf("s3 %s %s: %w after %d attempts: %w", req.Method, req.URL.Path, ErrRetriesExhausted, attempts, last)
+}
+
+// discard drains (a bounded amount of) the
+// response body before closing it so that
+// the underlying connection can be returned
+// to the client's connection pool
+func discard(res *http.Response) {
+	io.CopyN(io.Discard, res.Body, 4096)
+	res.Body.Close()
+}
+
+// flakyDo performs req using cl (or DefaultClient
+// if cl is nil), retrying connection errors and
+// retryable status codes with exponential backoff.
+// Retries respect the deadline of the request context.
+func flakyDo(cl *http.Client, req *http.Request) (*http.Response, error) {
+	hasBody := req.Body != nil
+	if cl == nil {
+		cl = &DefaultClient
+	}
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		res, err := cl.Do(req)
+		if err == nil && !retryable(res.StatusCode) {
+			return res, nil
+		}
+		if ctx.Err() != nil || (hasBody && req.GetBody == nil) {
+			// can't re-do this request because either
+			// the caller is no longer interested or
+			// we can't rewind the Body reader
+			return res, err
+		}
+		if err == nil {
+			err = fmt.Errorf("%s %q", res.Status, extractMessage(res.Body))
+			discard(res)
+		}
+		if attempt == maxAttempts {
+			return nil, giveUp(req, attempt, nil, err)
+		}
+		if stop := pause(ctx, attempt); stop != nil {
+			return nil, giveUp(req, attempt, stop, err)
+		}
+		if hasBody {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("req.GetBody: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/SnellerInc/sneller/aws"
+)
+
+// flakyServer serves a single object with the
+// given contents; each request is passed to fail
+// first, which may write its own response and
+// return true to indicate that it has done so
+func flakyServer(t *testing.T, contents []byte, fail func(w http.ResponseWriter, r *http.Request, n int64) bool) (*File, *int64) {
+	var count int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&count, 1)
+		if fail(w, r, n) {
+			return
+		}
+		var start, end int64
+		_, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end)
+		if err != nil {
+			t.Errorf("bad range %q", r.Header.Get("Range"))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		end = min(end+1, int64(len(contents)))
+		w.Header().Set("Content-Length", fmt.Sprint(end-start))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(contents[start:end])
+	}))
+	t.Cleanup(srv.Close)
+	k := aws.DeriveKey(srv.URL, "id", "secret", "us-east-1", "s3")
+	f := NewFile(k, "testbucket", "object", "etag", int64(len(contents)))
+	f.Client = srv.Client()
+	return f, &count
+}
+
+func TestReadRetry(t *testing.T) {
+	contents := []byte("the quick brown fox jumps over the lazy dog")
+	f, count := flakyServer(t, contents, func(w http.ResponseWriter, r *http.Request, n int64) bool {
+		if n <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("<Error><Message>Please reduce your request rate.</Message></Error>"))
+			return true
+		}
+		return false
+	})
+	buf := make([]byte, 5)
+	n, err := f.ReadAt(buf, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 || string(buf) != "quick" {
+		t.Errorf("got %q", buf[:n])
+	}
+	if c := atomic.LoadInt64(count); c != 3 {
+		t.Errorf("%d requests; expected 3", c)
+	}
+}
+
+func TestReadRetryExhausted(t *testing.T) {
+	f, count := flakyServer(t, []byte("contents"), func(w http.ResponseWriter, r *http.Request, n int64) bool {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+	_, err := f.ReadAt(make([]byte, 4), 0)
+	if !errors.Is(err, ErrRetriesExhausted) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if c := atomic.LoadInt64(count); c != maxAttempts {
+		t.Errorf("%d requests; expected %d", c, maxAttempts)
+	}
+}
+
+func TestReadRetryDeadline(t *testing.T) {
+	f, _ := flakyServer(t, []byte("contents"), func(w http.ResponseWriter, r *http.Request, n int64) bool {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	f.ctx = ctx
+	start := time.Now()
+	_, err := f.ReadAt(make([]byte, 4), 0)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("took %s to give up", d)
+	}
+}
+
+func TestReadAtResume(t *testing.T) {
+	contents := bytes.Repeat([]byte("0123456789"), 100)
+	var ranges []string
+	f, _ := flakyServer(t, contents, func(w http.ResponseWriter, r *http.Request, n int64) bool {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if n == 1 {
+			// promise the whole range, but
+			// only deliver some of it
+			w.Header().Set("Content-Length", "500")
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(contents[100:200])
+			return true
+		}
+		return false
+	})
+	buf := make([]byte, 500)
+	n, err := f.ReadAt(buf, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(buf) || !bytes.Equal(buf, contents[100:600]) {
+		t.Error("contents not equal")
+	}
+	want := []string{"bytes=100-599", "bytes=200-599"}
+	if len(ranges) != len(want) {
+		t.Fatalf("got ranges %q; want %q", ranges, want)
+	}
+	for i := range want {
+		if ranges[i] != want[i] {
+			t.Errorf("range %d: got %q, want %q", i, ranges[i], want[i])
+		}
+	}
+
+	// reading past the end of the object
+	// should not trigger retries
+	ranges = ranges[:0]
+	n, err = f.ReadAt(buf, 900)
+	if n != 100 || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got %d, %v", n, err)
+	}
+	if len(ranges) != 1 {
+		t.Errorf("got ranges %q", ranges)
+	}
+}
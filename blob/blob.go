@@ -0,0 +1,66 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// Package blob defines the storage interface
+// used to cache the contents of blobs that are
+// read while executing queries.
+//
+// The default implementation lives in
+// tenant/dcache and stores entries in files;
+// this package provides an in-memory implementation
+// for environments without a writable filesystem.
+//
+// The package also provides URL, which reads
+// blobs over HTTP(S) using range requests,
+// Bytes, which holds a blob in memory,
+// and Concat, which joins blobs end to end.
+package blob
+
+import (
+	"io"
+	"time"
+)
+
+// Interface is implemented by blobs
+// that can be read in whole or in part.
+type Interface interface {
+	io.ReaderAt
+	// Open returns the contents of the blob.
+	Open() (io.ReadCloser, error)
+	// Range returns the contents of the blob
+	// starting at off and of length width; if
+	// width is negative, the range extends to
+	// the end of the blob.
+	Range(off, width int64) (io.ReadCloser, error)
+	// Stat describes the blob.
+	Stat() (Info, error)
+}
+
+// Info describes a blob.
+type Info struct {
+	// ETag identifies the contents of the blob,
+	// or is the empty string if the blob has no ETag.
+	// The ETag only changes when the contents
+	// of the blob change.
+	ETag string
+	// Size is the size of the blob,
+	// or -1 if it is not known.
+	Size int64
+	// LastModified is the time at which the
+	// blob was last modified, if it is known.
+	LastModified time.Time
+	// Ranges is set if parts of the blob
+	// can be read with Range and ReadAt.
+	Ranges bool
+}
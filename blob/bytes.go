@@ -0,0 +1,75 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blob
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+var _ Interface = Bytes(nil)
+
+// Bytes is a blob that is held in memory,
+// which is mostly useful for tests:
+//
+//	var b blob.Interface = blob.Bytes(buf)
+//
+// The contents of the slice must not be
+// modified while the blob is in use.
+type Bytes []byte
+
+// ETag returns the ETag of b, which is
+// derived from its contents, so that equal
+// contents always have the same ETag.
+func (b Bytes) ETag() string {
+	h := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(h[:]) + `"`
+}
+
+// Stat returns the size and ETag of b.
+func (b Bytes) Stat() (Info, error) {
+	return Info{
+		ETag:   b.ETag(),
+		Size:   int64(len(b)),
+		Ranges: true,
+	}, nil
+}
+
+// Open returns the contents of b.
+func (b Bytes) Open() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+// Range returns the contents of b starting at off
+// and of length width; like a range request, the
+// range is truncated at the end of b.
+func (b Bytes) Range(off, width int64) (io.ReadCloser, error) {
+	if off < 0 || off > int64(len(b)) {
+		return nil, fmt.Errorf("blob: range start %d out of bounds for size %d", off, len(b))
+	}
+	end := int64(len(b))
+	if width >= 0 && width < end-off {
+		end = off + width
+	}
+	return io.NopCloser(bytes.NewReader(b[off:end])), nil
+}
+
+// ReadAt implements io.ReaderAt.
+func (b Bytes) ReadAt(p []byte, off int64) (int, error) {
+	return bytes.NewReader(b).ReadAt(p, off)
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blob
+
+import (
+	"io"
+	"math"
+	"testing"
+)
+
+func TestBytes(t *testing.T) {
+	const text = "hello, world"
+	var b Interface = Bytes(text)
+
+	info, err := b.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != int64(len(text)) || !info.Ranges {
+		t.Errorf("unexpected info %+v", info)
+	}
+	// the ETag depends only on the contents
+	other, _ := Bytes([]byte(text)).Stat()
+	if info.ETag == "" || info.ETag != other.ETag {
+		t.Errorf("ETags %s and %s differ", info.ETag, other.ETag)
+	}
+	changed, _ := Bytes(text + "!").Stat()
+	if changed.ETag == info.ETag {
+		t.Error("ETag did not change with the contents")
+	}
+
+	read := func(rc io.ReadCloser, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		buf, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf)
+	}
+	if got := read(b.Open()); got != text {
+		t.Errorf("Open: got %q", got)
+	}
+	ranges := []struct {
+		off, width int64
+		want       string
+	}{
+		{0, 5, "hello"},
+		{7, -1, "world"},
+		{7, 100, "world"},
+		{7, math.MaxInt64, "world"},
+		{12, 1, ""},
+	}
+	for _, r := range ranges {
+		if got := read(b.Range(r.off, r.width)); got != r.want {
+			t.Errorf("Range(%d, %d): got %q, want %q", r.off, r.width, got, r.want)
+		}
+	}
+	if _, err := b.Range(13, 1); err == nil {
+		t.Error("Range past the end: expected an error")
+	}
+
+	p := make([]byte, 5)
+	n, err := b.ReadAt(p, 7)
+	if n != 5 || err != nil || string(p) != "world" {
+		t.Errorf("ReadAt: got %d, %v, %q", n, err, p[:n])
+	}
+	n, err = b.ReadAt(p, 10)
+	if n != 2 || err != io.EOF {
+		t.Errorf("ReadAt at the end: got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blob
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Slack is the number of bytes of addressable
+// memory that must follow the data returned
+// from Cache.Get and Cache.Put, so that assembly
+// code may perform wide unaligned loads past the
+// end of the data.
+const Slack = 16
+
+var (
+	// ErrNotFound is returned by Cache.Get
+	// when there is no entry for a blob.
+	ErrNotFound = errors.New("blob: cache entry not found")
+	// ErrFull is returned by Cache.Put when
+	// there is not enough space for a new entry.
+	ErrFull = errors.New("blob: cache full")
+)
+
+// Cache is the interface implemented by
+// stores that hold cached blob contents.
+//
+// Callers are expected to serialize accesses
+// to any one id, so that concurrent misses
+// for the same blob result in a single fill;
+// implementations need not coordinate fills
+// of the same id themselves.
+type Cache interface {
+	// Get returns the cached contents of
+	// the blob with the given id. The returned
+	// slice has length size and at least Slack
+	// additional bytes of capacity. The caller
+	// must call release once it is done with
+	// the data.
+	//
+	// Get returns ErrNotFound if the entry is
+	// not present or holds fewer than size bytes.
+	// Any other error indicates that the cache
+	// could not be accessed.
+	Get(id string, size int64) (data []byte, release func(), err error)
+
+	// Put allocates a new entry of size bytes
+	// for the blob with the given id. The caller
+	// fills buf and then calls commit exactly once;
+	// commit(true) makes the entry visible to
+	// subsequent calls to Get, and commit(false)
+	// discards it. Entries marked as ephemeral
+	// are preferred candidates for eviction.
+	//
+	// The error returned from commit is informational;
+	// buf must not be used after commit has been called
+	// regardless of the result.
+	//
+	// The capacity of buf is at least size+Slack.
+	Put(id string, size int64, ephemeral bool) (buf []byte, commit func(ok bool) error, err error)
+}
+
+// Memory is a Cache that keeps entries
+// in memory. Memory holds at most a fixed
+// number of bytes; least-recently-used entries
+// are evicted to make room for new ones.
+//
+// Entries that are evicted while they are
+// still being read remain valid for their
+// readers, but are no longer accounted for
+// by Memory.
+type Memory struct {
+	limit int64
+
+	lock    sync.Mutex
+	used    int64 // bytes in entries + pending fills
+	entries map[string]*list.Element
+	lru     list.List // front = most recently used
+}
+
+type memEntry struct {
+	id   string
+	data []byte
+}
+
+// NewMemory constructs a Memory cache
+// that holds at most limit bytes.
+func NewMemory(limit int64) *Memory {
+	return &Memory{
+		limit:   limit,
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Used returns the number of bytes currently
+// accounted for by the cache, including the
+// space reserved by fills that have not yet
+// been committed.
+func (m *Memory) Used() int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.used
+}
+
+// Len returns the number of entries in the cache.
+func (m *Memory) Len() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.entries)
+}
+
+// Get implements Cache.Get
+func (m *Memory) Get(id string, size int64) ([]byte, func(), error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	elem := m.entries[id]
+	if elem == nil {
+		return nil, nil, ErrNotFound
+	}
+	ent := elem.Value.(*memEntry)
+	if int64(len(ent.data)) < size {
+		return nil, nil, ErrNotFound
+	}
+	m.lru.MoveToFront(elem)
+	return ent.data[:size], func() {}, nil
+}
+
+// Put implements Cache.Put
+func (m *Memory) Put(id string, size int64, ephemeral bool) ([]byte, func(bool) error, error) {
+	if size < 0 {
+		return nil, nil, fmt.Errorf("blob: invalid entry size %d", size)
+	}
+	m.lock.Lock()
+	if !m.reserve(size) {
+		m.lock.Unlock()
+		return nil, nil, ErrFull
+	}
+	m.lock.Unlock()
+	buf := make([]byte, size, size+Slack)
+	committed := false
+	commit := func(ok bool) error {
+		if committed {
+			panic("blob: Memory entry committed twice")
+		}
+		committed = true
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		if !ok {
+			m.used -= size
+			return nil
+		}
+		if elem := m.entries[id]; elem != nil {
+			m.remove(elem)
+		}
+		ent := &memEntry{id: id, data: buf}
+		if ephemeral {
+			m.entries[id] = m.lru.PushBack(ent)
+		} else {
+			m.entries[id] = m.lru.PushFront(ent)
+		}
+		return nil
+	}
+	return buf, commit, nil
+}
+
+// reserve accounts for size bytes,
+// evicting entries as necessary;
+// m.lock must be held
+func (m *Memory) reserve(size int64) bool {
+	if size > m.limit {
+		// don't evict anything for
+		// an entry that can never fit
+		return false
+	}
+	for m.used+size > m.limit {
+		back := m.lru.Back()
+		if back == nil {
+			return false
+		}
+		m.remove(back)
+	}
+	m.used += size
+	return true
+}
+
+// remove evicts an entry; m.lock must be held
+func (m *Memory) remove(elem *list.Element) {
+	ent := m.lru.Remove(elem).(*memEntry)
+	delete(m.entries, ent.id)
+	m.used -= int64(len(ent.data))
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blob
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func fill(t *testing.T, m *Memory, id string, size int64, ephemeral bool) {
+	t.Helper()
+	buf, commit, err := m.Put(id, size, ephemeral)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(buf)) != size || int64(cap(buf)) < size+Slack {
+		t.Fatalf("len=%d cap=%d for size %d", len(buf), cap(buf), size)
+	}
+	for i := range buf {
+		buf[i] = id[0]
+	}
+	if err := commit(true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func present(m *Memory, id string, size int64) bool {
+	data, release, err := m.Get(id, size)
+	if err != nil {
+		return false
+	}
+	defer release()
+	return bytes.Equal(data, bytes.Repeat([]byte{id[0]}, int(size)))
+}
+
+func TestMemory(t *testing.T) {
+	m := NewMemory(300)
+	fill(t, m, "a", 100, false)
+	fill(t, m, "b", 100, false)
+	if !present(m, "a", 100) || !present(m, "b", 100) {
+		t.Fatal("missing entries")
+	}
+	if _, _, err := m.Get("c", 100); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of missing entry returned %v", err)
+	}
+	if _, _, err := m.Get("a", 101); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of short entry returned %v", err)
+	}
+
+	// an aborted fill should not be accounted for
+	_, commit, err := m.Put("c", 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := m.Used(); u != 300 {
+		t.Fatalf("used = %d with a pending fill", u)
+	}
+	commit(false)
+	if u := m.Used(); u != 200 {
+		t.Fatalf("used = %d after aborted fill", u)
+	}
+	if present(m, "c", 100) {
+		t.Fatal("aborted fill is visible")
+	}
+
+	// ephemeral entries should be evicted
+	// before the least-recently-used entry
+	fill(t, m, "e", 100, true)
+	present(m, "a", 100)
+	fill(t, m, "d", 100, false)
+	if present(m, "e", 100) {
+		t.Error("ephemeral entry was not evicted")
+	}
+	fill(t, m, "f", 100, false)
+	if present(m, "b", 100) {
+		t.Error("least-recently-used entry was not evicted")
+	}
+	if !present(m, "a", 100) || !present(m, "d", 100) || !present(m, "f", 100) {
+		t.Error("recently-used entries were evicted")
+	}
+	if m.Len() != 3 || m.Used() != 300 {
+		t.Errorf("len = %d, used = %d", m.Len(), m.Used())
+	}
+
+	if _, _, err := m.Put("g", 301, false); !errors.Is(err, ErrFull) {
+		t.Errorf("Put larger than the cache returned %v", err)
+	}
+	if m.Len() != 3 {
+		t.Errorf("Put larger than the cache evicted entries")
+	}
+}
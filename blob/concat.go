@@ -0,0 +1,222 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Concat returns a blob whose contents are the
+// contents of each of the parts, in order.
+//
+// The size of the blob is the sum of the sizes
+// of the parts, and its ETag is a hash of the
+// ETags of the parts, so it only changes when
+// one of the parts changes. If any of the parts
+// has no ETag, neither does the result.
+//
+// The parts are not examined until the blob
+// is first used; every part must report its
+// size in Stat.
+func Concat(parts ...Interface) Interface {
+	return &concat{parts: parts}
+}
+
+type concat struct {
+	parts []Interface
+
+	once  sync.Once
+	info  Info
+	start []int64 // start[i] is the offset of parts[i]; start[len(parts)] is the size
+	err   error
+}
+
+func (c *concat) init() error {
+	c.once.Do(func() {
+		h := sha256.New()
+		c.start = make([]int64, len(c.parts)+1)
+		c.info.Ranges = true
+		etags := true
+		for i := range c.parts {
+			info, err := c.parts[i].Stat()
+			if err != nil {
+				c.err = err
+				return
+			}
+			if info.Size < 0 {
+				c.err = fmt.Errorf("blob.Concat: size of part %d unknown", i)
+				return
+			}
+			c.start[i+1] = c.start[i] + info.Size
+			if info.ETag == "" {
+				etags = false
+			}
+			// length-prefix each ETag so that
+			// the hash is unambiguous
+			var lenbuf [binary.MaxVarintLen64]byte
+			h.Write(lenbuf[:binary.PutUvarint(lenbuf[:], uint64(len(info.ETag)))])
+			h.Write([]byte(info.ETag))
+			if info.LastModified.After(c.info.LastModified) {
+				c.info.LastModified = info.LastModified
+			}
+			c.info.Ranges = c.info.Ranges && info.Ranges
+		}
+		c.info.Size = c.start[len(c.parts)]
+		if etags {
+			c.info.ETag = `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+		}
+	})
+	return c.err
+}
+
+// Stat returns the combined size and ETag of the parts.
+func (c *concat) Stat() (Info, error) {
+	if err := c.init(); err != nil {
+		return Info{}, err
+	}
+	return c.info, nil
+}
+
+// part returns the index of the part containing off
+func (c *concat) part(off int64) int {
+	// the last part starting at or before off;
+	// this skips over empty parts
+	return sort.Search(len(c.parts), func(i int) bool {
+		return c.start[i+1] > off
+	})
+}
+
+// ReadAt implements io.ReaderAt.
+func (c *concat) ReadAt(p []byte, off int64) (int, error) {
+	if err := c.init(); err != nil {
+		return 0, err
+	}
+	if off < 0 {
+		return 0, fmt.Errorf("blob.Concat: negative offset %d", off)
+	}
+	nn := 0
+	for len(p) > 0 && off < c.info.Size {
+		i := c.part(off)
+		rel := off - c.start[i]
+		want := min(int64(len(p)), c.start[i+1]-off)
+		n, err := c.parts[i].ReadAt(p[:want], rel)
+		nn += n
+		p = p[n:]
+		off += int64(n)
+		if int64(n) == want {
+			// a part may return io.EOF along
+			// with the last bytes it holds
+			continue
+		}
+		if err == nil {
+			if n == 0 {
+				return nn, io.ErrNoProgress
+			}
+			// a short read without an error;
+			// keep reading from the new offset
+			continue
+		}
+		if err == io.EOF {
+			return nn, fmt.Errorf("blob.Concat: part %d: %w", i, io.ErrUnexpectedEOF)
+		}
+		return nn, err
+	}
+	if len(p) > 0 {
+		return nn, io.EOF
+	}
+	return nn, nil
+}
+
+// Open returns the contents of the blob.
+func (c *concat) Open() (io.ReadCloser, error) {
+	return c.Range(0, -1)
+}
+
+// Range returns the contents of the blob starting
+// at off and of length width; like a range request,
+// the range is truncated at the end of the blob.
+// The parts are opened one at a time as the
+// returned reader reaches them.
+func (c *concat) Range(off, width int64) (io.ReadCloser, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	if off < 0 || off > c.info.Size {
+		return nil, fmt.Errorf("blob: range start %d out of bounds for size %d", off, c.info.Size)
+	}
+	end := c.info.Size
+	if width >= 0 && width < end-off {
+		end = off + width
+	}
+	return &concatReader{c: c, off: off, end: end}, nil
+}
+
+// concatReader reads a range of a concat
+// by reading ranges of each of its parts
+type concatReader struct {
+	c        *concat
+	off, end int64
+	cur      io.ReadCloser
+	curend   int64 // end of the range of cur
+}
+
+func (r *concatReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if r.off >= r.end {
+				return 0, io.EOF
+			}
+			i := r.c.part(r.off)
+			r.curend = min(r.end, r.c.start[i+1])
+			rc, err := r.c.parts[i].Range(r.off-r.c.start[i], r.curend-r.off)
+			if err != nil {
+				return 0, err
+			}
+			r.cur = rc
+		}
+		n, err := r.cur.Read(p[:min(int64(len(p)), r.curend-r.off)])
+		r.off += int64(n)
+		if err == io.EOF || r.off == r.curend {
+			cerr := r.cur.Close()
+			r.cur = nil
+			if r.off < r.curend {
+				return n, io.ErrUnexpectedEOF
+			}
+			if cerr != nil {
+				return n, cerr
+			}
+			if n == 0 {
+				continue
+			}
+			return n, nil
+		}
+		return n, err
+	}
+}
+
+func (r *concatReader) Close() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
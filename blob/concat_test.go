@@ -0,0 +1,146 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blob
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// shortReads is a blob that returns at most
+// two bytes from each call to ReadAt
+type shortReads struct {
+	Bytes
+}
+
+func (s shortReads) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) > 2 {
+		p = p[:2]
+	}
+	n, err := s.Bytes.ReadAt(p, off)
+	if err == io.EOF && n == len(p) {
+		err = nil
+	}
+	return n, err
+}
+
+func TestConcat(t *testing.T) {
+	parts := []string{"hello", "", ", ", "world"}
+	const text = "hello, world"
+	blobs := make([]Interface, len(parts))
+	for i := range parts {
+		blobs[i] = Bytes(parts[i])
+	}
+	// the middle part returns short reads
+	blobs[2] = shortReads{Bytes(parts[2])}
+	b := Concat(blobs...)
+
+	info, err := b.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != int64(len(text)) || !info.Ranges {
+		t.Errorf("unexpected info %+v", info)
+	}
+	// the ETag depends on the ETags of the parts,
+	// not just on the contents
+	same, _ := Concat(Bytes("hello"), Bytes(""), Bytes(", "), Bytes("world")).Stat()
+	if info.ETag == "" || info.ETag != same.ETag {
+		t.Errorf("ETags %s and %s differ", info.ETag, same.ETag)
+	}
+	split, _ := Concat(Bytes("hello, "), Bytes("world")).Stat()
+	if split.ETag == info.ETag {
+		t.Error("ETag did not change with the parts")
+	}
+	whole, _ := Bytes(text).Stat()
+	if whole.ETag == info.ETag {
+		t.Error("ETag should differ from the ETag of a single part")
+	}
+
+	read := func(rc io.ReadCloser, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rc.Close()
+		buf, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf)
+	}
+	if got := read(b.Open()); got != text {
+		t.Errorf("Open: got %q", got)
+	}
+	for off := 0; off <= len(text); off++ {
+		for end := off; end <= len(text); end++ {
+			// a range straddling every boundary
+			got := read(b.Range(int64(off), int64(end-off)))
+			if want := text[off:end]; got != want {
+				t.Errorf("Range(%d, %d): got %q, want %q", off, end-off, got, want)
+			}
+			p := make([]byte, end-off)
+			n, err := b.ReadAt(p, int64(off))
+			if err != nil || n != len(p) || string(p) != text[off:end] {
+				t.Errorf("ReadAt(%d, %d): got %d, %v, %q", off, end-off, n, err, p[:n])
+			}
+		}
+	}
+	if got := read(b.Range(3, -1)); got != text[3:] {
+		t.Errorf("Range(3, -1): got %q", got)
+	}
+	if _, err := b.Range(13, 1); err == nil {
+		t.Error("Range past the end: expected an error")
+	}
+	p := make([]byte, 5)
+	n, err := b.ReadAt(p, 10)
+	if n != 2 || err != io.EOF || string(p[:n]) != "ld" {
+		t.Errorf("ReadAt at the end: got %d, %v", n, err)
+	}
+}
+
+// truncated is a blob that reports
+// a larger size than it holds
+type truncated struct {
+	Bytes
+}
+
+func (t truncated) Stat() (Info, error) {
+	info, err := t.Bytes.Stat()
+	info.Size += 3
+	return info, err
+}
+
+func TestConcatTruncatedPart(t *testing.T) {
+	b := Concat(Bytes("abc"), truncated{Bytes("def")}, Bytes("ghi"))
+	p := make([]byte, 6)
+	n, err := b.ReadAt(p, 2)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadAt: got %d, %v", n, err)
+	}
+	if string(p[:n]) != "cdef" {
+		t.Errorf("ReadAt: got %q", p[:n])
+	}
+	rc, err := b.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	buf, err := io.ReadAll(rc)
+	if !errors.Is(err, io.ErrUnexpectedEOF) || string(buf) != "abcdef" {
+		t.Errorf("Open: got %q, %v", buf, err)
+	}
+}
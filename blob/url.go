@@ -0,0 +1,265 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blob
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrNoRanges is returned from URL.ReadAt and
+// URL.Range when the server does not honor
+// range requests for the blob.
+var ErrNoRanges = errors.New("blob: server does not support range requests")
+
+// URL is a blob that is read over HTTP(S),
+// such as an object shared with a presigned URL.
+//
+// The URL is passed to the server unchanged,
+// so the query string of a presigned URL
+// (including its signature) is preserved.
+// Since the query string may contain credentials,
+// errors only mention the scheme, host and path.
+type URL struct {
+	// Value is the URL of the blob.
+	Value string
+	// Info describes the blob as
+	// returned by StatURL.
+	//
+	// The ETag is the value of the ETag header
+	// returned by the server; unlike the URL of
+	// a presigned object, which changes each time
+	// the object is signed, it only changes when
+	// the contents of the blob change.
+	Info Info
+	// Client is the client used to make
+	// requests; if Client is nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+}
+
+var _ Interface = &URL{}
+
+// Stat returns u.Info.
+func (u *URL) Stat() (Info, error) {
+	return u.Info, nil
+}
+
+// StatURL returns a URL for the blob at rawurl
+// with its Info populated from the server response.
+//
+// StatURL makes a GET request for the first byte
+// of the blob rather than a HEAD request, since
+// presigned URLs are only valid for the method
+// they were signed for.
+func StatURL(client *http.Client, rawurl string) (*URL, error) {
+	u := &URL{Value: rawurl, Client: client}
+	req, err := u.request()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	res, err := u.client().Do(req)
+	if err != nil {
+		return nil, u.errorf("%w", redact(err))
+	}
+	defer res.Body.Close()
+	u.Info.ETag = res.Header.Get("ETag")
+	if lm := res.Header.Get("Last-Modified"); lm != "" {
+		u.Info.LastModified, _ = http.ParseTime(lm)
+	}
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		size, ok := contentRangeSize(res.Header.Get("Content-Range"))
+		if !ok {
+			return nil, u.errorf("unexpected Content-Range %q", res.Header.Get("Content-Range"))
+		}
+		u.Info.Size = size
+		u.Info.Ranges = true
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the first byte of an empty blob
+		// is not a satisfiable range
+		size, ok := contentRangeSize(res.Header.Get("Content-Range"))
+		if !ok || size != 0 {
+			return nil, u.errorf("unexpected status %s", res.Status)
+		}
+		u.Info.Size = 0
+		u.Info.Ranges = true
+	case http.StatusOK:
+		u.Info.Size = res.ContentLength
+	default:
+		return nil, u.errorf("unexpected status %s", res.Status)
+	}
+	return u, nil
+}
+
+// contentRangeSize returns the complete length
+// of the blob from a Content-Range header
+// (i.e. "bytes 0-0/1234" or "bytes */1234")
+func contentRangeSize(hdr string) (int64, bool) {
+	rest, ok := strings.CutPrefix(hdr, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	_, total, ok := strings.Cut(rest, "/")
+	if !ok {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+func (u *URL) client() *http.Client {
+	if u.Client == nil {
+		return http.DefaultClient
+	}
+	return u.Client
+}
+
+// String returns the URL without its query string.
+func (u *URL) String() string {
+	p, err := url.Parse(u.Value)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	p.RawQuery = ""
+	p.Fragment = ""
+	p.User = nil
+	return p.String()
+}
+
+func (u *URL) errorf(f string, args ...any) error {
+	return fmt.Errorf("%s: "+f, append([]any{u.String()}, args...)...)
+}
+
+// redact removes the URL from an error returned
+// by http.Client.Do so that the query string
+// of the URL is not included in error messages
+func redact(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return uerr.Err
+	}
+	return err
+}
+
+func (u *URL) request() (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, u.Value, nil)
+	if err != nil {
+		return nil, u.errorf("%w", redact(err))
+	}
+	return req, nil
+}
+
+// Open returns the contents of the blob
+// from the beginning; it does not require
+// the server to honor range requests.
+func (u *URL) Open() (io.ReadCloser, error) {
+	req, err := u.request()
+	if err != nil {
+		return nil, err
+	}
+	res, err := u.client().Do(req)
+	if err != nil {
+		return nil, u.errorf("%w", redact(err))
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, u.errorf("unexpected status %s", res.Status)
+	}
+	if u.Info.ETag != "" && res.Header.Get("ETag") != u.Info.ETag {
+		res.Body.Close()
+		return nil, u.errorf("ETag changed from %s to %s", u.Info.ETag, res.Header.Get("ETag"))
+	}
+	return res.Body, nil
+}
+
+// Range returns the contents of the blob
+// starting at off and of length width; if width
+// is negative, the range extends to the end
+// of the blob.
+//
+// If the blob has an ETag, the request is made
+// conditional on the ETag, so that the ranges
+// of a blob that is replaced while it is being
+// read are not silently combined.
+func (u *URL) Range(off, width int64) (io.ReadCloser, error) {
+	if !u.Info.Ranges {
+		return nil, ErrNoRanges
+	}
+	req, err := u.request()
+	if err != nil {
+		return nil, err
+	}
+	if width < 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", off))
+	} else {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+width-1))
+	}
+	if u.Info.ETag != "" {
+		req.Header.Set("If-Match", u.Info.ETag)
+	}
+	res, err := u.client().Do(req)
+	if err != nil {
+		return nil, u.errorf("%w", redact(err))
+	}
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		return res.Body, nil
+	case http.StatusOK:
+		// the server ignored the Range header
+		res.Body.Close()
+		return nil, ErrNoRanges
+	case http.StatusPreconditionFailed:
+		res.Body.Close()
+		return nil, u.errorf("ETag %s no longer matches", u.Info.ETag)
+	default:
+		res.Body.Close()
+		return nil, u.errorf("unexpected status %s", res.Status)
+	}
+}
+
+// ReadAt implements io.ReaderAt using
+// range requests.
+func (u *URL) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	width := int64(len(p))
+	if u.Info.Size >= 0 {
+		if off >= u.Info.Size {
+			return 0, io.EOF
+		}
+		width = min(width, u.Info.Size-off)
+	}
+	rc, err := u.Range(off, width)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	n, err := io.ReadFull(rc, p[:width])
+	if err == nil && int64(n) < int64(len(p)) {
+		err = io.EOF
+	}
+	return n, err
+}
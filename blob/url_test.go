@@ -0,0 +1,142 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blob
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// presigned is a query string like
+// the one of an S3 presigned URL
+const presigned = "X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Signature=abc%2Fdef&X-Amz-Expires=60"
+
+func blobServer(t *testing.T, contents *[]byte, ranges bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.RawQuery != presigned {
+			t.Errorf("query string %q not passed through", r.URL.RawQuery)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body := *contents
+		w.Header().Set("ETag", `"`+string(rune('a'+len(body)%26))+`"`)
+		if !ranges {
+			r.Header.Del("Range")
+			r.Header.Del("If-Match")
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(body))
+	}))
+}
+
+func TestURL(t *testing.T) {
+	contents := []byte("0123456789abcdefghij")
+	srv := blobServer(t, &contents, true)
+	defer srv.Close()
+
+	u, err := StatURL(srv.Client(), srv.URL+"/obj?"+presigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !u.Info.Ranges || u.Info.Size != int64(len(contents)) || u.Info.ETag == "" {
+		t.Fatalf("unexpected info %+v", u.Info)
+	}
+	if strings.Contains(u.String(), "Signature") {
+		t.Fatalf("String() = %q includes the query string", u.String())
+	}
+	buf := make([]byte, 5)
+	n, err := u.ReadAt(buf, 3)
+	if err != nil || n != 5 || string(buf) != "34567" {
+		t.Fatalf("ReadAt: %d %q %v", n, buf, err)
+	}
+	n, err = u.ReadAt(buf, 17)
+	if !errors.Is(err, io.EOF) || n != 3 || string(buf[:n]) != "hij" {
+		t.Fatalf("ReadAt at the end: %d %q %v", n, buf[:n], err)
+	}
+	rc, err := u.Range(10, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rest, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(rest) != "abcdefghij" {
+		t.Fatalf("Range: %q %v", rest, err)
+	}
+
+	// replacing the blob changes its ETag,
+	// so subsequent range requests fail
+	contents = []byte("something else")
+	_, err = u.ReadAt(buf, 0)
+	if err == nil || !strings.Contains(err.Error(), "ETag") {
+		t.Fatalf("ReadAt after the ETag changed returned %v", err)
+	}
+	if strings.Contains(err.Error(), "Signature") {
+		t.Fatalf("error %q includes the query string", err)
+	}
+}
+
+func TestURLNoRanges(t *testing.T) {
+	contents := []byte("0123456789")
+	srv := blobServer(t, &contents, false)
+	defer srv.Close()
+
+	u, err := StatURL(srv.Client(), srv.URL+"/obj?"+presigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Info.Ranges || u.Info.Size != int64(len(contents)) {
+		t.Fatalf("unexpected info %+v", u.Info)
+	}
+	_, err = u.ReadAt(make([]byte, 2), 0)
+	if !errors.Is(err, ErrNoRanges) {
+		t.Fatalf("ReadAt returned %v", err)
+	}
+	rc, err := u.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || !bytes.Equal(all, contents) {
+		t.Fatalf("Open: %q %v", all, err)
+	}
+}
+
+func TestURLEmpty(t *testing.T) {
+	var contents []byte
+	srv := blobServer(t, &contents, true)
+	defer srv.Close()
+
+	u, err := StatURL(srv.Client(), srv.URL+"/obj?"+presigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Info.Size != 0 {
+		t.Fatalf("unexpected info %+v", u.Info)
+	}
+	n, err := u.ReadAt(make([]byte, 1), 0)
+	if n != 0 || !errors.Is(err, io.EOF) {
+		t.Fatalf("ReadAt: %d %v", n, err)
+	}
+}
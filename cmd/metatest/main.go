@@ -19,10 +19,12 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
@@ -67,7 +69,7 @@ func getTestsNames(testDir string) []string {
 	return result
 }
 
-func runTestAllSingletons(testDir, crashDir string, count, timeoutSec, par int, vmFence bool) {
+func runTestAllSingletons(testDir, crashDir string, count, timeoutSec, par int, vmFence, bcRecord bool) {
 	testNames := getTestsNames(testDir)
 	nTests := len(testNames)
 	counter := uint32(0)
@@ -81,7 +83,7 @@ func runTestAllSingletons(testDir, crashDir string, count, timeoutSec, par int,
 			currentCount := atomic.AddUint32(&counter, 1)
 			info := fmt.Sprintf("Singleton %v/%v", currentCount, totalCount)
 			fileID := fmt.Sprintf("s%02v", i)
-			callGoTest(testDir, crashDir, []string{testNames[i]}, count, timeoutSec, vmFence, fileID, info)
+			callGoTest(testDir, crashDir, []string{testNames[i]}, count, timeoutSec, vmFence, bcRecord, fileID, info)
 		}
 	}
 
@@ -97,7 +99,7 @@ func runTestAllSingletons(testDir, crashDir string, count, timeoutSec, par int,
 	wg.Wait()
 }
 
-func runTestAllPairs(testDir, crashDir string, count, timeoutSec, par int, vmFence bool) {
+func runTestAllPairs(testDir, crashDir string, count, timeoutSec, par int, vmFence, bcRecord bool) {
 	testNames := getTestsNames(testDir)
 	nTests := len(testNames)
 	counter := uint32(0)
@@ -122,7 +124,7 @@ func runTestAllPairs(testDir, crashDir string, count, timeoutSec, par int, vmFen
 			currentCount := atomic.AddUint32(&counter, 1)
 			info := fmt.Sprintf("Pair %v/%v", currentCount, totalCount)
 			fileID := fmt.Sprintf("p%02v-%02v", i, j)
-			callGoTest(testDir, crashDir, []string{testNames[i], testNames[j]}, count, timeoutSec, vmFence, fileID, info)
+			callGoTest(testDir, crashDir, []string{testNames[i], testNames[j]}, count, timeoutSec, vmFence, bcRecord, fileID, info)
 		}
 	}
 
@@ -141,7 +143,11 @@ func runTestAllPairs(testDir, crashDir string, count, timeoutSec, par int, vmFen
 
 // callGoTest invokes a "go test -run <testNames> -count <count> -timeout <timeoutSec>s" and
 // saves issues to file in crashDir
-func callGoTest(testDir, crashDir string, testNames []string, count, timeoutSec int, vmFence bool, fileID, info string) {
+//
+// When bcRecord is set, the opcode executions that were in flight
+// when a test crashed are kept in crashDir/repro.<fileID> and can be
+// replayed with "go test -run TestBCRepro -bcrepro=<file>" (see vm/bc_repro_test.go)
+func callGoTest(testDir, crashDir string, testNames []string, count, timeoutSec int, vmFence, bcRecord bool, fileID, info string) {
 	// create arguments for the cmd
 	args := []string{"test"}
 	nTests := len(testNames)
@@ -157,6 +163,18 @@ func callGoTest(testDir, crashDir string, testNames []string, count, timeoutSec
 	}
 	args = append(args, "-count", fmt.Sprintf("%v", count))
 	args = append(args, "-timeout", fmt.Sprintf("%vs", timeoutSec))
+	reproDir := ""
+	if bcRecord {
+		dir, err := filepath.Abs(filepath.Join(crashDir, "repro."+fileID))
+		if err != nil {
+			exitf(err)
+		}
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			exitf(err)
+		}
+		reproDir = dir
+		args = append(args, "-args", "-bcrecord="+reproDir)
+	}
 	cmd := exec.Command("go", args...)
 
 	var outBuffer bytes.Buffer
@@ -167,6 +185,7 @@ func callGoTest(testDir, crashDir string, testNames []string, count, timeoutSec
 	_ = cmd.Run() //ignore the error, it is handled when parsing outBuffer
 
 	// process the results of the cmd
+	crashed := false
 	resultLines := strings.Split(outBuffer.String(), "\n")
 	if len(resultLines) > 1 {
 		if (resultLines[0] == "PASS") && strings.HasPrefix(resultLines[1], "ok") {
@@ -174,6 +193,7 @@ func callGoTest(testDir, crashDir string, testNames []string, count, timeoutSec
 		} else if strings.HasPrefix(resultLines[0], "panic: test timed out after") {
 			// no issues, just a timeout
 		} else {
+			crashed = true
 			if _, err := os.Stat(crashDir); errors.Is(err, os.ErrNotExist) {
 				if err = os.MkdirAll(crashDir, os.ModePerm); err != nil {
 					exitf(err)
@@ -188,6 +208,11 @@ func callGoTest(testDir, crashDir string, testNames []string, count, timeoutSec
 			if _, err := file.WriteString(fmt.Sprintf("%v: cmd=%v\n\n%v", info, cmd.String(), outBuffer.String())); err != nil {
 				exitf(err)
 			}
+			if reproDir != "" {
+				if err := writeRepros(file, reproDir); err != nil {
+					exitf(err)
+				}
+			}
 			if err := file.Close(); err != nil {
 				exitf(err)
 			}
@@ -197,6 +222,31 @@ func callGoTest(testDir, crashDir string, testNames []string, count, timeoutSec
 			log.Printf("%v: %vCrash in: %v; saved %v%v", info, colorRed, testNames, file.Name(), colorReset)
 		}
 	}
+	if reproDir != "" {
+		if crashed {
+			os.Remove(reproDir) // only if empty
+		} else {
+			os.RemoveAll(reproDir)
+		}
+	}
+}
+
+// writeRepros lists the opcode executions recorded in reproDir
+func writeRepros(w io.Writer, reproDir string) error {
+	entries, err := os.ReadDir(reproDir)
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "\nin-flight opcode executions:\n"); err != nil {
+		return err
+	}
+	for i := range entries {
+		file := filepath.Join(reproDir, entries[i].Name())
+		if _, err := fmt.Fprintf(w, "go test -run TestBCRepro -bcrepro=%v\n", file); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 var (
@@ -207,6 +257,7 @@ var (
 	dashTimeout  int    // timeout seconds
 	dashPar      int    // parallelism
 	dashVMFence  bool   // use vmFence
+	dashBCRecord bool   // record in-flight opcode executions
 )
 
 func init() {
@@ -217,6 +268,7 @@ func init() {
 	flag.IntVar(&dashTimeout, "timeout", 60, "timeout in seconds")
 	flag.IntVar(&dashPar, "par", 16, "parallelism")
 	flag.BoolVar(&dashVMFence, "vmfence", false, "whether to use vmfence")
+	flag.BoolVar(&dashBCRecord, "bcrecord", false, "whether to keep the opcode executions in flight during a crash for replay (vm tests only)")
 }
 
 func main() {
@@ -231,10 +283,11 @@ func main() {
 	log.Printf("retrieved param -timeout %v", dashTimeout)
 	log.Printf("retrieved param -par %v", dashPar)
 	log.Printf("retrieved param -vmfence %v", dashVMFence)
+	log.Printf("retrieved param -bcrecord %v", dashBCRecord)
 
 	if dashPair {
-		runTestAllPairs(dashTestDir, dashCrashDir, dashCount, dashTimeout, dashPar, dashVMFence)
+		runTestAllPairs(dashTestDir, dashCrashDir, dashCount, dashTimeout, dashPar, dashVMFence, dashBCRecord)
 	} else {
-		runTestAllSingletons(dashTestDir, dashCrashDir, dashCount, dashTimeout, dashPar, dashVMFence)
+		runTestAllSingletons(dashTestDir, dashCrashDir, dashCount, dashTimeout, dashPar, dashVMFence, dashBCRecord)
 	}
 }
@@ -0,0 +1,165 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/SnellerInc/sneller/db"
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ints"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+	"github.com/SnellerInc/sneller/plan"
+	"github.com/SnellerInc/sneller/vm"
+
+	"golang.org/x/sys/cpu"
+)
+
+// compactEnv plans queries of a single object
+// passed to the rewrite function of db.Config.Compact,
+// so that the rows that are kept are read from exactly
+// the object that is replaced
+type compactEnv struct {
+	idx  *blockfmt.Index
+	desc *blockfmt.Descriptor
+}
+
+func (e *compactEnv) Index(expr.Node) (plan.Index, error) {
+	return e.idx, nil
+}
+
+func (e *compactEnv) Stat(_ expr.Node, h *plan.Hints) (*plan.Input, error) {
+	return &plan.Input{
+		Descs: []plan.Descriptor{{
+			Descriptor: *e.desc,
+			Blocks:     ints.Intervals{{Start: 0, End: len(e.desc.Trailer.Blocks)}},
+		}},
+		Fields: h.Fields,
+	}, nil
+}
+
+// entry point for 'sdb compact ...'
+func compact(creds db.Tenant, dbname, tblname string) {
+	if !cpu.X86.HasAVX512 {
+		vm.SetOptimizationLevel(vm.OptimizationLevelNone)
+	}
+	c := db.Config{
+		Align:         1024 * 1024, // maximum alignment with current span size
+		RangeMultiple: 100,         // metadata once every 100MB
+		GCMinimumAge:  5 * time.Minute,
+	}
+	if dashv {
+		c.Logf = logf
+	}
+	rootfs := root(creds)
+	rewrite := func(idx *blockfmt.Index, desc *blockfmt.Descriptor) (blockfmt.Input, error) {
+		// the deleted rows are excluded
+		// from the results of the query
+		q := &expr.Query{
+			Body: &expr.Select{
+				Columns: []expr.Binding{expr.Bind(expr.Star{}, "")},
+				From:    &expr.Table{Binding: expr.Bind(mkpath(dbname, tblname), "")},
+			},
+		}
+		tree, err := plan.New(q, &compactEnv{idx: idx, desc: desc})
+		if err != nil {
+			return blockfmt.Input{}, err
+		}
+		encodeFS(tree, rootfs)
+		staged, err := os.CreateTemp("", "sdb-compact-*.ion")
+		if err != nil {
+			return blockfmt.Input{}, err
+		}
+		os.Remove(staged.Name())
+		ep := plan.ExecParams{
+			FS:      rootfs,
+			Plan:    tree,
+			Output:  staged,
+			Runner:  runner(os.TempDir(), rootfs),
+			Context: context.Background(),
+		}
+		err = plan.Exec(&ep)
+		var size int64
+		if err == nil {
+			size, err = staged.Seek(0, io.SeekCurrent)
+		}
+		if err == nil && size > 0 {
+			_, err = staged.Seek(0, io.SeekStart)
+		}
+		if err != nil || size == 0 {
+			staged.Close()
+			return blockfmt.Input{}, err
+		}
+		// the staged file is anonymous, so the input
+		// is named after the object it replaces; it is
+		// packed directly, so it is not recorded in
+		// the list of inputs of the table
+		return blockfmt.Input{
+			Path: desc.Path,
+			ETag: desc.ETag,
+			Size: size,
+			R:    staged,
+			F:    blockfmt.UnsafeION(),
+		}, nil
+	}
+	err := c.Compact(creds, dbname, tblname, rewrite)
+	if err != nil {
+		exitf("compact %s/%s: %s", dbname, tblname, err)
+	}
+}
+
+func mkpath(dbname, tblname string) expr.Node {
+	return &expr.Dot{Inner: expr.Ident(dbname), Field: tblname}
+}
+
+func init() {
+	addApplet(applet{
+		name: "compact",
+		help: "<db> <table>",
+		desc: `remove deleted rows from a table
+The command
+  $ sdb compact <db> <table>
+rewrites the data of <table> without the rows that
+have been deleted with
+  $ sdb query "DELETE FROM <db>.<table> WHERE ..."
+and removes the record of the deletions from the index.
+Until a table is compacted, the deleted rows are still
+stored in the table, and queries exclude them as they
+are scanned.
+
+Each object that holds deleted rows is rewritten:
+the rows that are kept are staged in a temporary file
+and packed into a new object in the same partition.
+The new objects replace the old objects in a single
+update of the index.
+The replaced objects are removed by the next sync or
+gc once they are older than 5 minutes.
+
+If the index is modified concurrently (for example,
+by a concurrent sync), the command fails and the
+table is left unchanged.
+`,
+		run: func(args []string) bool {
+			if len(args) != 3 {
+				return false
+			}
+			compact(creds(), args[1], args[2])
+			return true
+		},
+	})
+}
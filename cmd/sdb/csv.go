@@ -0,0 +1,165 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync/atomic"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ints"
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+	"github.com/SnellerInc/sneller/plan"
+	"github.com/SnellerInc/sneller/vm"
+	"github.com/SnellerInc/sneller/xsv"
+)
+
+// csvFormat is the ObjectInfo.Format
+// of inputs that are read as CSV
+const csvFormat = "csv"
+
+// csvHint returns the hint used for
+// read_file() when -csv is specified
+func csvHint(file string) *xsv.Hint {
+	if file == "" {
+		return &xsv.Hint{Header: true}
+	}
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		exitf("reading CSV hints: %s", err)
+	}
+	hint, err := xsv.ParseHint(buf)
+	if err != nil {
+		exitf("parsing CSV hints: %s", err)
+	}
+	return hint
+}
+
+// handle read_file('path/to/file.csv')
+//
+// The input consists of a single block
+// without a sparse index, so it can't be
+// filtered before it is read.
+func readCSV(root fs.FS, args []expr.Node, hint *plan.Hints) (*plan.Input, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("read_file() should have 1 argument")
+	}
+	str, ok := args[0].(expr.String)
+	if !ok {
+		return nil, fmt.Errorf("read_file() should have a string argument")
+	}
+	info, err := fs.Stat(root, string(str))
+	if err != nil {
+		return nil, err
+	}
+	const shift = 20
+	tr := blockfmt.Trailer{
+		Version:    1,
+		Offset:     info.Size(),
+		BlockShift: shift,
+		Blocks: []blockfmt.Blockdesc{{
+			Chunks: int(info.Size()>>shift) + 1,
+		}},
+	}
+	return &plan.Input{
+		Descs: []plan.Descriptor{{
+			Descriptor: blockfmt.Descriptor{
+				ObjectInfo: blockfmt.ObjectInfo{
+					Path:   string(str),
+					Size:   info.Size(),
+					Format: csvFormat,
+				},
+				Trailer: tr,
+			},
+			Blocks: ints.Intervals{{Start: 0, End: 1}},
+		}},
+		Fields: hint.Fields,
+	}, nil
+}
+
+// csvTable implements vm.Table by
+// converting a CSV file to ion rows
+type csvTable struct {
+	root fs.FS
+	path string
+	hint *xsv.Hint
+}
+
+func (c *csvTable) WriteChunks(dst vm.QuerySink, parallel int) error {
+	f, err := c.root.Open(c.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// the records must be read sequentially
+	return vm.SplitInput(dst, 1, func(w io.Writer) error {
+		cn := ion.Chunker{W: w, Align: vm.PageSize}
+		ch := &xsv.CsvChopper{
+			SkipRecords: c.hint.SkipRecords,
+			Separator:   c.hint.Separator,
+			Quote:       c.hint.Quote,
+		}
+		err := xsv.Convert(f, &cn, ch, c.hint, nil)
+		if err == nil {
+			err = cn.Flush()
+		}
+		if errors.Is(err, io.EOF) {
+			// the output was closed early
+			return nil
+		}
+		return err
+	})
+}
+
+// csvRunner is a plan.Runner that
+// reads the inputs produced by readCSV
+// and passes everything else to Runner
+type csvRunner struct {
+	plan.Runner
+	root fs.FS
+	hint *xsv.Hint
+}
+
+func (r *csvRunner) Run(dst vm.QuerySink, src *plan.Input, ep *plan.ExecParams) error {
+	n := 0
+	for i := range src.Descs {
+		if src.Descs[i].Format == csvFormat {
+			n++
+		}
+	}
+	if n == 0 {
+		if r.Runner == nil {
+			return fmt.Errorf("cannot read tables from %T", r.root)
+		}
+		return r.Runner.Run(dst, src, ep)
+	}
+	if n != len(src.Descs) {
+		return fmt.Errorf("cannot mix CSV files and packed tables in one input")
+	}
+	for i := range src.Descs {
+		d := &src.Descs[i]
+		tbl := &csvTable{root: r.root, path: d.Path, hint: r.hint}
+		if err := tbl.WriteChunks(dst, ep.Parallel); err != nil {
+			return fmt.Errorf("reading %s: %w", d.Path, err)
+		}
+		atomic.AddInt64(&ep.Stats.BytesScanned, d.ObjectInfo.Size)
+	}
+	return nil
+}
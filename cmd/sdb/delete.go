@@ -0,0 +1,60 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"github.com/SnellerInc/sneller/db"
+	"github.com/SnellerInc/sneller/expr"
+)
+
+// deleteRows handles
+//
+//	sdb query "DELETE FROM db.table WHERE ..."
+func deleteRows(creds db.Tenant, q *expr.Query) {
+	sel := q.Body.(*expr.Select)
+	tbl := sel.From.(*expr.Table)
+	p, ok := expr.FlatPath(tbl.Expr)
+	if !ok || len(p) != 2 {
+		exitf("DELETE: invalid table %s (expected <db>.<table>)", expr.ToString(tbl.Expr))
+	}
+	where := sel.Where
+	if tbl.Explicit() {
+		// the condition is stored relative
+		// to the row, so strip the table binding
+		where = expr.Rewrite(unqualify(tbl.Result()), where)
+	}
+	var c db.Config
+	if dashv {
+		c.Logf = logf
+	}
+	err := c.Delete(creds, p[0], p[1], where)
+	if err != nil {
+		exitf("DELETE FROM %s.%s: %s", p[0], p[1], err)
+	}
+}
+
+// unqualify rewrites bind.x to x
+type unqualify string
+
+func (u unqualify) Rewrite(e expr.Node) expr.Node {
+	if d, ok := e.(*expr.Dot); ok {
+		if id, ok := d.Inner.(expr.Ident); ok && string(id) == string(u) {
+			return expr.Ident(d.Field)
+		}
+	}
+	return e
+}
+
+func (u unqualify) Walk(e expr.Node) expr.Rewriter { return u }
@@ -46,6 +46,9 @@ func human(size int64) string {
 func describeTrailer(t *blockfmt.Trailer, compsize int64) {
 	size := t.Decompressed()
 	fmt.Printf("\ttrailer: %d blocks, %d bytes decompressed (%.2fx compression, %s)\n", len(t.Blocks), size, float64(size)/float64(compsize), t.Algo)
+	if t.Dict != "" {
+		fmt.Printf("\tdictionary: %s\n", t.Dict)
+	}
 	names := t.Sparse.FieldNames()
 	for i := range names {
 		ti := t.Sparse.Get(strings.Split(names[i], "."))
@@ -124,6 +127,9 @@ func describe(creds db.Tenant, dbname, table string) {
 	}
 	nindirect := len(descs)
 	descs = append(descs, idx.Inline...)
+	if d := idx.Dictionary; d != nil {
+		fmt.Printf("dictionary: %s version %d (%s)\n", d.ID(), d.Version, human(int64(len(d.Data))))
+	}
 	describeDescs(ofs, descs, nindirect)
 }
 
@@ -0,0 +1,55 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"github.com/SnellerInc/sneller/db"
+)
+
+// entry point for 'sdb drop ...'
+func drop(creds db.Tenant, dbname, tblname string) {
+	var c db.Config
+	if dashv {
+		c.Logf = logf
+	}
+	err := c.Drop(creds, dbname, tblname)
+	if err != nil {
+		exitf("drop %s/%s: %s", dbname, tblname, err)
+	}
+}
+
+func init() {
+	addApplet(applet{
+		name: "drop",
+		help: "<db> <table>",
+		desc: `remove a table
+The command
+  $ sdb drop <db> <table>
+removes the definition, the index, and all of
+the packed objects of <table>.
+
+If the index is modified concurrently (for example,
+by a concurrent sync), the command fails and the
+table is left unchanged.
+`,
+		run: func(args []string) bool {
+			if len(args) != 3 {
+				return false
+			}
+			drop(creds(), args[1], args[2])
+			return true
+		},
+	})
+}
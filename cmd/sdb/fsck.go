@@ -0,0 +1,99 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/SnellerInc/sneller/db"
+)
+
+// entry point for 'sdb fsck ...'
+func fsck(args []string) {
+	var dashd, dashc bool
+	flags := flag.NewFlagSet(args[0], flag.ExitOnError)
+	flags.BoolVar(&dashd, "d", false, "decode and validate every packed object")
+	flags.BoolVar(&dashc, "c", false, "verify the checksums of compressed data (implies -d)")
+	flags.Parse(args[1:])
+	args = flags.Args()
+	if len(args) != 2 {
+		flags.Usage()
+		return
+	}
+	dbname, table := args[0], args[1]
+	creds := creds()
+	ofs := root(creds)
+	idx, err := db.OpenIndex(ofs, dbname, table, creds.Key())
+	if err != nil {
+		exitf("opening index: %s", err)
+	}
+	conf := db.FsckConfig{
+		Decode:    dashd || dashc,
+		Checksums: dashc,
+	}
+	if dashv {
+		conf.Logf = logf
+	}
+	r, err := conf.Run(ofs, dbname, idx)
+	if err != nil {
+		exitf("fsck %s/%s: %s", dbname, table, err)
+	}
+	for i := range r.Errors {
+		fmt.Fprintf(os.Stderr, "%s\n", &r.Errors[i])
+	}
+	for _, p := range r.Orphans {
+		fmt.Fprintf(os.Stderr, "%s: not referenced by the index\n", p)
+	}
+	if dashv {
+		logf("checked %d objects: %d errors, %d orphans", r.Objects, len(r.Errors), len(r.Orphans))
+	}
+	if !r.OK() {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	addApplet(applet{
+		name: "fsck",
+		help: "[-d] [-c] <db> <table>",
+		desc: `check the integrity of a table against its index
+The command
+  $ sdb fsck <db> <table>
+loads the index file associated with the provided
+db and table and checks that every object pointed to
+by the index exists and has the size and ETag that
+are recorded in the index. Objects in the table
+directory that are older than the index but are not
+pointed to by the index are reported as orphans.
+
+With -d, every packed object is also decoded and
+validated (see also: validate). With -c, the checksums
+of the compressed data are verified while decoding,
+for compression algorithms that store checksums.
+
+Any problems discovered are reported on stderr,
+and the command exits with a non-zero status.
+
+NOTE: -d and -c read every byte of data in a table.
+It may take a long time for this command to run on large tables.
+`,
+		run: func(args []string) bool {
+			fsck(args)
+			return true
+		},
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/SnellerInc/sneller/db"
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+// intoTable is the destination
+// of 'sdb query -into <db>.<table>'
+type intoTable struct {
+	tenant    db.Tenant
+	db, table string
+	f         *os.File // staged query output
+}
+
+// parseInto returns the database and
+// table named by the -into flag or by
+// the INTO clause of a query
+func parseInto(target string) (string, string) {
+	e, err := expr.ParsePath(target)
+	if err == nil {
+		if p, ok := expr.FlatPath(e); ok && len(p) == 2 {
+			return p[0], p[1]
+		}
+	}
+	exitf("invalid INTO target %q (expected <db>.<table>)", target)
+	return "", ""
+}
+
+// openInto prepares to write query results
+// into the new table db.table; the results are
+// staged in a temporary file inside tmpdir
+func openInto(tenant db.Tenant, dbname, table, tmpdir string) *intoTable {
+	ofs := outfs(tenant)
+	for _, p := range []string{
+		db.IndexPath(dbname, table),
+		db.DefinitionPath(dbname, table),
+	} {
+		_, err := fs.Stat(ofs, p)
+		if err == nil {
+			exitf("table %s.%s already exists", dbname, table)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			exitf("%s", err)
+		}
+	}
+	f, err := os.CreateTemp(tmpdir, "sdb-into-*.ion")
+	if err != nil {
+		exitf("creating temporary output: %s", err)
+	}
+	os.Remove(f.Name())
+	return &intoTable{
+		tenant: tenant,
+		db:     dbname,
+		table:  table,
+		f:      f,
+	}
+}
+
+func (t *intoTable) Write(p []byte) (int, error) {
+	return t.f.Write(p)
+}
+
+// commit packs the staged query output into
+// the table and writes the table index
+func (t *intoTable) commit() {
+	defer t.f.Close()
+	info, err := t.f.Stat()
+	if err != nil {
+		exitf("%s", err)
+	}
+	if _, err := t.f.Seek(0, io.SeekStart); err != nil {
+		exitf("%s", err)
+	}
+	c := db.Config{
+		Align:         1024 * 1024, // maximum alignment with current span size
+		RangeMultiple: 100,         // metadata once every 100MB
+		GCMinimumAge:  5 * time.Minute,
+	}
+	if dashv {
+		c.Logf = logf
+		c.Verbose = true
+	}
+	// the staged file is anonymous, so
+	// name the input after the table
+	err = c.Append(t.tenant, t.db, t.table, []blockfmt.Input{{
+		Path: "query/" + t.db + "." + t.table + ".ion",
+		ETag: time.Now().UTC().Format(time.RFC3339Nano),
+		Size: info.Size(),
+		R:    t.f,
+		F:    blockfmt.UnsafeION(),
+	}})
+	if err != nil {
+		exitf("writing table %s.%s: %s", t.db, t.table, err)
+	}
+}
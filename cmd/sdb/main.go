@@ -23,7 +23,7 @@ import (
 	"slices"
 	"strings"
 
-	"github.com/SnellerInc/sneller/auth"
+	_ "github.com/SnellerInc/sneller/auth" // registers the s3:// backend
 	"github.com/SnellerInc/sneller/db"
 
 	"golang.org/x/exp/maps"
@@ -55,7 +55,7 @@ func defaultRoot() string {
 func init() {
 	flag.BoolVar(&dashv, "v", false, "verbose")
 	flag.BoolVar(&dashh, "h", false, "show usage help")
-	flag.StringVar(&rootpath, "root", defaultRoot(), "file system root (either directory path or s3 bucket)")
+	flag.StringVar(&rootpath, "root", defaultRoot(), "file system root (a directory path or a URL such as s3://bucket)")
 }
 
 func exitf(f string, args ...interface{}) {
@@ -94,14 +94,11 @@ func creds() db.Tenant {
 	if rootpath == "" {
 		exitf("-root not specified")
 	}
-	if bucket, ok := strings.CutPrefix(rootpath, "s3://"); ok {
-		t, err := auth.S3TenantFromEnv(context.Background(), bucket)
-		if err != nil {
-			exitf("deriving tenant creds: %s", err)
-		}
-		return t
+	t, err := db.OpenTenant(context.Background(), rootpath)
+	if err != nil {
+		exitf("deriving tenant creds: %s", err)
 	}
-	return db.NewLocalTenantFromPath(rootpath)
+	return t
 }
 
 type packed interface {
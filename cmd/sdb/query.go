@@ -25,6 +25,9 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
 	"strings"
 	"time"
 
@@ -35,10 +38,12 @@ import (
 	"github.com/SnellerInc/sneller/expr/partiql"
 	"github.com/SnellerInc/sneller/ints"
 	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/ion/arrow"
 	"github.com/SnellerInc/sneller/ion/blockfmt"
 	"github.com/SnellerInc/sneller/plan"
 	"github.com/SnellerInc/sneller/tenant/dcache"
 	"github.com/SnellerInc/sneller/vm"
+	"github.com/SnellerInc/sneller/xsv"
 
 	"golang.org/x/sys/cpu"
 )
@@ -91,6 +96,7 @@ func readFile(root fs.FS, args []expr.Node, hint *plan.Hints) (*plan.Input, erro
 
 type cmdlineEnv struct {
 	root fs.FS
+	csv  *xsv.Hint // read_file() reads CSV if non-nil
 
 	plan.Env // fallback environment
 }
@@ -107,6 +113,14 @@ func (c *cmdlineEnv) Index(e expr.Node) (plan.Index, error) {
 
 func (c *cmdlineEnv) Stat(tbl expr.Node, h *plan.Hints) (*plan.Input, error) {
 	if b, ok := tbl.(*expr.Builtin); ok && strings.EqualFold(b.Text, "read_file") {
+		if len(b.Args) == 1 {
+			if str, ok := b.Args[0].(expr.String); ok && isURL(string(str)) {
+				return readURL(string(str), h)
+			}
+		}
+		if c.csv != nil {
+			return readCSV(c.root, b.Args, h)
+		}
 		return readFile(c.root, b.Args, h)
 	}
 	return c.Env.Stat(tbl, h)
@@ -170,19 +184,49 @@ func query(args []string) bool {
 	var dashf string
 	var dasho string
 	var dashv bool
+	var dashS bool
 	var dashfmt string
 	var dashtmp string
 	var dashtrace string
 	var dashtracefmt string
+	var dashportable bool
+	var dashtimeout time.Duration
+	var dashj int
+	var dashprefetch int
+	var dashblocksize int64
+	var dashcsv bool
+	var dashcsvhints string
+	var dashinto string
+	var dashprofile string
+	var dashheapprofile string
+	var dashtap string
+	var dashtapops string
+	var dashnonfinite string
+	var dashmissing string
 
 	flags := flag.NewFlagSet(args[0], flag.ExitOnError)
 	flags.StringVar(&dashf, "f", "", "sql input source (\"-\" implies stdin)")
 	flags.StringVar(&dasho, "o", "-", "output (\"-\" implies stdout)")
 	flags.BoolVar(&dashv, "v", false, "verbose diagnostics")
+	flags.BoolVar(&dashS, "S", false, "print the time spent in each query operator")
 	flags.StringVar(&dashtrace, "trace", "", "trace output file (\"-\" implies stderr)")
 	flags.StringVar(&dashtracefmt, "tracefmt", "text", "trace output (text, graphviz)")
-	flags.StringVar(&dashfmt, "fmt", "ion", "output format (json, ion, ...)")
+	flags.StringVar(&dashfmt, "fmt", "ion", "output format (json, ion, arrow)")
 	flags.StringVar(&dashtmp, "tmp", os.TempDir(), "cache directory")
+	flags.BoolVar(&dashportable, "portable", false, "use the portable interpreter instead of AVX-512 (slow)")
+	flags.DurationVar(&dashtimeout, "timeout", 0, "abort the query after the given duration (0 means no timeout)")
+	flags.IntVar(&dashj, "j", 0, "maximum number of threads used by the query (0 means GOMAXPROCS)")
+	flags.IntVar(&dashprefetch, "prefetch", 0, "number of blocks fetched ahead of the blocks being scanned (negative disables prefetching)")
+	flags.Int64Var(&dashblocksize, "blocksize", 0, "maximum decompressed bytes of a block scanned by one thread (0 never divides blocks)")
+	flags.BoolVar(&dashcsv, "csv", false, "read_file() reads CSV files with a header row")
+	flags.StringVar(&dashcsvhints, "csvhints", "", "CSV hints file for read_file() (implies -csv)")
+	flags.StringVar(&dashinto, "into", "", "write the results into a new table <db>.<table> instead of the output")
+	flags.StringVar(&dashprofile, "profile", "", "write a pprof CPU profile of the query execution to a file")
+	flags.StringVar(&dashheapprofile, "heapprofile", "", "write a pprof heap profile to a file once the query has completed")
+	flags.StringVar(&dashtap, "tap", "", "write the output of each query operator to <dir>/op<id>.ion (see -S for the ids)")
+	flags.StringVar(&dashtapops, "tapops", "", "comma-separated ids of the operators written by -tap (default all)")
+	flags.StringVar(&dashnonfinite, "nonfinite", "order", "handling of NaN and infinities in ORDER BY and aggregates (order, null, error)")
+	flags.StringVar(&dashmissing, "missing", "omit", "handling of MISSING result columns in -fmt json output (omit, null)")
 	flags.Parse(args[1:])
 	args = flags.Args()
 
@@ -203,27 +247,6 @@ func query(args []string) bool {
 		return false
 	}
 
-	var stdout io.Writer
-	if dasho == "-" {
-		stdout = os.Stdout
-	} else {
-		f, err := os.Create(dasho)
-		if err != nil {
-			exitf("creating -o: %s", err)
-		}
-		stdout = f
-		defer f.Close()
-	}
-
-	switch dashfmt {
-	case "ion":
-		// leave as-is
-	case "json":
-		stdout = ion.NewJSONWriter(stdout, '\n')
-	default:
-		exitf("unsupported output format %q", dashfmt)
-	}
-
 	sneller.CanVMOpen = true
 	q, err := partiql.Parse(sql)
 	if err != nil {
@@ -242,24 +265,97 @@ func query(args []string) bool {
 	if err != nil {
 		exitf("%s", err)
 	}
+	nonfinite, err := expr.ParseNonFinite(dashnonfinite)
+	if err != nil {
+		exitf("-nonfinite: %s", err)
+	}
+	q = expr.ApplyNonFinite(q, nonfinite)
+	if q.Delete {
+		deleteRows(creds(), q)
+		return true
+	}
+	if q.Into != nil {
+		// SELECT ... INTO db.table and
+		// CREATE TABLE db.table AS SELECT ...
+		// are equivalent to -into db.table
+		if dashinto != "" {
+			exitf("-into cannot be combined with an INTO clause")
+		}
+		dashinto = expr.ToString(q.Into)
+		q.Into = nil
+	}
 
 	tenant := creds()
 	rootfs := root(tenant)
+	var stdout io.Writer
+	var into *intoTable
+	if dashinto != "" {
+		if dasho != "-" || dashfmt != "ion" {
+			exitf("INTO cannot be combined with -o or -fmt")
+		}
+		dbname, table := parseInto(dashinto)
+		into = openInto(tenant, dbname, table, dashtmp)
+		stdout = into
+	} else if dasho == "-" {
+		stdout = os.Stdout
+	} else {
+		f, err := os.Create(dasho)
+		if err != nil {
+			exitf("creating -o: %s", err)
+		}
+		stdout = f
+		defer f.Close()
+	}
+
+	var jw *ion.JSONWriter
+	switch dashfmt {
+	case "ion":
+		// leave as-is
+	case "json":
+		jw = ion.NewJSONWriter(stdout, '\n')
+		stdout = jw
+	case "arrow":
+		aw := arrow.NewWriter(stdout)
+		defer func() {
+			if err := aw.Close(); err != nil {
+				exitf("writing arrow output: %s", err)
+			}
+		}()
+		stdout = aw
+	default:
+		exitf("unsupported output format %q", dashfmt)
+	}
+
 	run := runner(dashtmp, rootfs)
 	env := &cmdlineEnv{root: rootfs, Env: tenantEnv(rootfs)}
+	if dashcsv || dashcsvhints != "" {
+		env.csv = csvHint(dashcsvhints)
+		run = &csvRunner{Runner: run, root: rootfs, hint: env.csv}
+	}
+	run = &urlRunner{Runner: run}
 	tree, err := plan.New(q, env)
 	if err != nil {
 		exitf("planning query: %s", err)
 	}
-	if enc, ok := rootfs.(interface {
-		Encode(*ion.Buffer, *ion.Symtab) error
-	}); ok {
-		var buf ion.Buffer
-		var st ion.Symtab
-		if err := enc.Encode(&buf, &st); err != nil {
-			exitf("encoding file system: %s", err)
+	encodeFS(tree, rootfs)
+	switch dashmissing {
+	case "omit", "null":
+		if jw == nil {
+			break
 		}
-		tree.Data, _, _ = ion.ReadDatum(&st, buf.Bytes())
+		if len(tree.Results) == 0 {
+			if dashmissing == "null" {
+				exitf("-missing null requires a query with known result columns (not SELECT *)")
+			}
+			break
+		}
+		jw.Columns = make([]string, len(tree.Results))
+		for i := range tree.Results {
+			jw.Columns[i] = tree.Results[i].Result()
+		}
+		jw.OmitMissing = dashmissing == "omit"
+	default:
+		exitf("-missing: unsupported value %q", dashmissing)
 	}
 
 	if dashtrace != "" {
@@ -282,40 +378,168 @@ func query(args []string) bool {
 		vm.Trace(w, gv)
 	}
 
-	if !cpu.X86.HasAVX512 {
-		exitf("cannot execute query without AVX512 support")
+	if dashportable {
+		vm.SetOptimizationLevel(vm.OptimizationLevelNone)
+	} else if !cpu.X86.HasAVX512 {
+		exitf("cannot execute query without AVX512 support (use -portable to run without it)")
 	}
 
 	vm.Errorf = func(f string, args ...any) {
 		fmt.Fprintf(os.Stderr, f, args...)
 	}
 
+	ctx := context.Background()
+	if dashtimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dashtimeout)
+		defer cancel()
+	}
+	// stopProfile is called explicitly before exiting
+	// since exitf doesn't run deferred functions
+	stopProfile := func() {}
+	if dashprofile != "" {
+		f, err := os.Create(dashprofile)
+		if err != nil {
+			exitf("creating -profile: %s", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			exitf("starting CPU profile: %s", err)
+		}
+		stopProfile = func() {
+			pprof.StopCPUProfile()
+			if err := f.Close(); err != nil {
+				exitf("writing -profile: %s", err)
+			}
+		}
+	}
+	var tap *plan.Tap
+	if dashtap != "" {
+		tap = &plan.Tap{Dir: dashtap, Ops: parseOps(dashtapops)}
+	} else if dashtapops != "" {
+		exitf("-tapops requires -tap")
+	}
 	start := time.Now()
 	ep := plan.ExecParams{
-		FS:     rootfs,
-		Plan:   tree,
-		Output: stdout,
-		Runner: run,
+		FS:        rootfs,
+		Plan:      tree,
+		Output:    stdout,
+		Runner:    run,
+		Context:   ctx,
+		Profile:   dashS,
+		Parallel:  dashj,
+		Prefetch:  dashprefetch,
+		BlockSize: dashblocksize,
+		Tap:       tap,
 	}
 	err = plan.Exec(&ep)
+	stopProfile()
+	if errors.Is(err, context.DeadlineExceeded) {
+		printStats(&ep.Stats, time.Since(start))
+		exitf("query timed out after %s", dashtimeout)
+	}
 	if err != nil {
 		exitf("%s", err)
 	}
+	if into != nil {
+		into.commit()
+	}
+	if dashheapprofile != "" {
+		writeHeapProfile(dashheapprofile)
+	}
 	if dashv {
-		stats := ep.Stats
-		elapsed := time.Since(start)
-		rate := (float64(stats.BytesScanned) / float64(elapsed)) * 1000.0 / 1024.0 // bytes/ns ~= GB/s -> GiB/s*/
-		fmt.Fprintf(os.Stderr, "%d bytes (%s) scanned in %s %.3gGiB/s\n",
-			stats.BytesScanned, human(stats.BytesScanned), elapsed, rate)
+		printStats(&ep.Stats, time.Since(start))
+		printFields(&ep.Stats)
+	}
+	if dashS {
+		printProfile(&ep.Stats)
 	}
 	return true
 }
 
+// parseOps parses the -tapops list of operator ids
+func parseOps(list string) []int {
+	if list == "" {
+		return nil
+	}
+	var ids []int
+	for _, str := range strings.Split(list, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(str))
+		if err != nil || id < 0 {
+			exitf("invalid -tapops operator id %q", str)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// writeHeapProfile writes a pprof heap profile
+// reflecting the allocations made up to this point
+func writeHeapProfile(path string) {
+	f, err := os.Create(path)
+	if err != nil {
+		exitf("creating -heapprofile: %s", err)
+	}
+	defer f.Close()
+	// get up-to-date statistics
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		exitf("writing -heapprofile: %s", err)
+	}
+}
+
+// encodeFS stores the description of rootfs
+// in tree.Data for runners that open the
+// file system from the query plan
+func encodeFS(tree *plan.Tree, rootfs fs.FS) {
+	if enc, ok := rootfs.(interface {
+		Encode(*ion.Buffer, *ion.Symtab) error
+	}); ok {
+		var buf ion.Buffer
+		var st ion.Symtab
+		if err := enc.Encode(&buf, &st); err != nil {
+			exitf("encoding file system: %s", err)
+		}
+		tree.Data, _, _ = ion.ReadDatum(&st, buf.Bytes())
+	}
+}
+
+func printStats(stats *plan.ExecStats, elapsed time.Duration) {
+	rate := (float64(stats.BytesScanned) / float64(elapsed)) * 1000.0 / 1024.0 // bytes/ns ~= GB/s -> GiB/s*/
+	fmt.Fprintf(os.Stderr, "%d bytes (%s) scanned in %s %.3gGiB/s\n",
+		stats.BytesScanned, human(stats.BytesScanned), elapsed, rate)
+}
+
+// printFields prints the fields read from
+// each table, so that a query that reads every
+// field of a table (i.e. with SELECT *) stands out
+func printFields(stats *plan.ExecStats) {
+	for i := range stats.Tables {
+		t := &stats.Tables[i]
+		if t.AllFields {
+			fmt.Fprintf(os.Stderr, "warning: all fields of %s are read\n", t.Table)
+			continue
+		}
+		fields := strings.Join(t.Fields, ", ")
+		if fields == "" {
+			fields = "(none)"
+		}
+		fmt.Fprintf(os.Stderr, "fields read from %s: %s\n", t.Table, fields)
+	}
+}
+
+func printProfile(stats *plan.ExecStats) {
+	fmt.Fprintf(os.Stderr, "%4s %12s %14s  %s\n", "id", "rows", "time", "operator")
+	for i := range stats.Ops {
+		op := &stats.Ops[i]
+		fmt.Fprintf(os.Stderr, "%4d %12d %14s  %s\n", op.ID, op.Rows, op.Time, op.Name)
+	}
+}
+
 func init() {
 	addApplet(applet{
 		run:  query,
 		name: "query",
-		help: "[-v] [-o output] [-fmt json|ion] [-f query.sql]",
+		help: "[-v] [-S] [-profile cpu.pprof] [-heapprofile heap.pprof] [-portable] [-timeout duration] [-csv] [-csvhints hints.json] [-o output] [-fmt json|ion|arrow] [-into db.table] [-f query.sql]",
 		desc: `run a query locally
 The command
   $ sdb query <sql-text>
@@ -326,9 +550,72 @@ builtin function that can interpret zion packfiles, or it can
 read tables directly from the hierarchy rooted at the path specified
 by -root. (Note that read_file also reads files relative to -root.)
 
+read_file() also reads ion or NDJSON from an http:// or https://
+URL, such as a presigned S3 URL, without any table definition.
+If the server supports range requests, NDJSON is fetched and
+converted in parallel; otherwise the URL is read sequentially.
+For example,
+  $ sdb query -fmt json \
+      "SELECT COUNT(*) FROM read_file('https://bucket.s3.amazonaws.com/data.json?X-Amz-Signature=...')"
+
 The -fmt flag can be used to change the output of the query engine.
 The default behavior is to produce binary ion data, but -fmt=json can
-be specified in order to produce JSON data.
+be specified in order to produce JSON data, and -fmt=arrow produces
+an Apache Arrow IPC stream.
+
+The -csv flag makes read_file() read CSV files instead
+of packfiles. The first record of each file must contain the
+column names, and the type of each column is inferred from
+the records that follow it. The -csvhints flag specifies
+a file with CSV hints (in the same format as the hints of a
+table definition) to use a different separator or quote
+character, to disable type inference with "all_strings",
+or to list the fields explicitly. For example,
+  $ echo '{"header": true, "separator": ";"}' > hints.json
+  $ sdb query -csvhints hints.json -fmt json \
+      "SELECT COUNT(*) FROM read_file('data.csv')"
+
+The -portable flag runs the query using the portable
+interpreter rather than AVX-512 assembly. This is much
+slower, but allows queries to run on machines without
+AVX-512 support.
+
+The -S flag prints a profile of the query to stderr
+once it has completed. For each operator of the query
+plan, the profile lists the number of rows written into
+the operator and the time spent processing those rows,
+summed across threads. Since rows flow from the inputs
+of the plan towards its output, the time spent in an
+operator includes the time spent in the operators
+with lower ids that consume its output.
+
+The -profile flag writes a CPU profile of the execution
+of the query (excluding parsing and planning) to the
+given file, and the -heapprofile flag writes a heap profile
+to the given file once the query has completed. Both files
+are in the pprof format and can be inspected with
+  $ go tool pprof cpu.pprof
+
+The -timeout flag limits the wall-clock time of the query
+(for example, -timeout=30s). When the timeout expires, the
+query is aborted, the number of bytes scanned so far is
+printed, and the command exits with an error.
+
+The -into flag writes the results of the query into a new
+table instead of the output, so that they can be queried later.
+The results are packed and indexed like the inputs of any other
+table, so subsequent queries of the new table perform the same as
+queries of tables populated with "sync". The table must not exist yet.
+A query of the form
+  SELECT ... INTO db.table FROM ...
+is equivalent to the same query without the INTO clause and -into db.table.
+
+A statement of the form
+  DELETE FROM db.table WHERE condition
+deletes the rows of the table for which the condition is TRUE.
+Subsequent queries of the table exclude the deleted rows
+immediately, but the rows are only removed from storage
+by "sdb compact".
 `,
 	})
 }
@@ -0,0 +1,80 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/SnellerInc/sneller/db"
+)
+
+// entry point for 'sdb rebalance ...'
+func rebalance(args []string) {
+	var dasht int64
+	flags := flag.NewFlagSet(args[0], flag.ExitOnError)
+	flags.Int64Var(&dasht, "t", 1*giga, "target compressed size of each object in bytes")
+	flags.Parse(args[1:])
+	args = flags.Args()
+	if len(args) != 2 {
+		flags.Usage()
+		return
+	}
+	if dasht <= 0 {
+		exitf("rebalance: invalid target size %d", dasht)
+	}
+	dbname, tblname := args[0], args[1]
+	c := db.Config{
+		GCMinimumAge: 5 * time.Minute,
+	}
+	if dashv {
+		c.Logf = logf
+	}
+	err := c.Rebalance(creds(), dbname, tblname, dasht)
+	if err != nil {
+		exitf("rebalance %s/%s: %s", dbname, tblname, err)
+	}
+}
+
+func init() {
+	addApplet(applet{
+		name: "rebalance",
+		help: "[-t target-bytes] <db> <table>",
+		desc: `rewrite the objects of a table to a uniform size
+The command
+  $ sdb rebalance -t <target-bytes> <db> <table>
+rewrites the packed objects of <table> so that each
+object holds about <target-bytes> of compressed data
+(1GiB by default). The compressed blocks of the objects
+are copied as-is, so the rows of the table do not change.
+
+The new objects replace the old objects in a single
+update of the index, so queries see either the old
+objects or the new objects. The replaced objects are
+removed by the next sync or gc once they are older
+than 5 minutes.
+
+If the index is modified concurrently (for example,
+by a concurrent sync), the command fails and the
+table is left unchanged. Objects that already have the
+target size are not rewritten, so an interrupted
+rebalance can simply be run again.
+`,
+		run: func(args []string) bool {
+			rebalance(args)
+			return true
+		},
+	})
+}
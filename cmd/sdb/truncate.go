@@ -0,0 +1,66 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"time"
+
+	"github.com/SnellerInc/sneller/db"
+)
+
+// entry point for 'sdb truncate ...'
+func truncate(creds db.Tenant, dbname, tblname string) {
+	c := db.Config{
+		GCMinimumAge: 5 * time.Minute,
+	}
+	if dashv {
+		c.Logf = logf
+	}
+	err := c.Truncate(creds, dbname, tblname)
+	if err != nil {
+		exitf("truncate %s/%s: %s", dbname, tblname, err)
+	}
+}
+
+func init() {
+	addApplet(applet{
+		name: "truncate",
+		help: "<db> <table>",
+		desc: `remove all the data from a table
+The command
+  $ sdb truncate <db> <table>
+atomically replaces the index of <table> with
+an empty index. The definition of the table and
+the list of ingested inputs are kept, so only
+objects that are added after the truncation are
+ingested by a subsequent sync.
+
+The packed objects that belonged to the table
+are marked for deletion and removed by the next
+sync or gc once they are older than 5 minutes.
+
+If the index is modified concurrently (for example,
+by a concurrent sync), the command fails and the
+table is left unchanged.
+`,
+		run: func(args []string) bool {
+			if len(args) != 3 {
+				return false
+			}
+			truncate(creds(), args[1], args[2])
+			return true
+		},
+	})
+}
@@ -0,0 +1,376 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"github.com/SnellerInc/sneller/blob"
+	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/ints"
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+	"github.com/SnellerInc/sneller/jsonrl"
+	"github.com/SnellerInc/sneller/plan"
+	"github.com/SnellerInc/sneller/vm"
+)
+
+// urlFormat is the ObjectInfo.Format
+// of inputs that are read from a URL
+const urlFormat = "url"
+
+// urlPartSize is the size of the ranges
+// of NDJSON that are read in parallel
+const urlPartSize = 8 << 20
+
+// urlReadAhead is the number of bytes past the
+// end of a part that are requested along with
+// the part in order to read the record that
+// begins within the part and ends after it;
+// longer records are read with further requests
+// of urlReadAhead bytes
+const urlReadAhead = 256 << 10
+
+// isURL returns whether read_file(path)
+// reads from a URL rather than from -root
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// handle read_file('https://...')
+//
+// Like readCSV, the input consists of a single
+// block without a sparse index.
+func readURL(rawurl string, hint *plan.Hints) (*plan.Input, error) {
+	u, err := blob.StatURL(http.DefaultClient, rawurl)
+	if err != nil {
+		return nil, err
+	}
+	size := max(u.Info.Size, 0)
+	const shift = 20
+	tr := blockfmt.Trailer{
+		Version:    1,
+		Offset:     size,
+		BlockShift: shift,
+		Blocks: []blockfmt.Blockdesc{{
+			Chunks: int(size>>shift) + 1,
+		}},
+	}
+	return &plan.Input{
+		Descs: []plan.Descriptor{{
+			Descriptor: blockfmt.Descriptor{
+				ObjectInfo: blockfmt.ObjectInfo{
+					Path:         rawurl,
+					ETag:         u.Info.ETag,
+					LastModified: date.FromTime(u.Info.LastModified),
+					Size:         size,
+					Format:       urlFormat,
+				},
+				Trailer: tr,
+			},
+			Blocks: ints.Intervals{{Start: 0, End: 1}},
+		}},
+		Fields: hint.Fields,
+	}, nil
+}
+
+// urlTable implements vm.Table by converting
+// the ion or NDJSON at a URL to ion rows
+type urlTable struct {
+	blob *blob.URL
+}
+
+func (u *urlTable) WriteChunks(dst vm.QuerySink, parallel int) error {
+	if u.blob.Info.Ranges && u.blob.Info.Size > urlPartSize {
+		var hdr [4]byte
+		_, err := u.blob.ReadAt(hdr[:], 0)
+		if errors.Is(err, blob.ErrNoRanges) {
+			return u.sequential(dst)
+		}
+		if err != nil {
+			return err
+		}
+		if !ion.IsBVM(hdr[:]) {
+			if parallel <= 0 {
+				parallel = runtime.GOMAXPROCS(0)
+			}
+			return u.parallel(dst, parallel)
+		}
+	}
+	// ion can't be split without reading it,
+	// and servers that don't support range
+	// requests can only be read from the start
+	return u.sequential(dst)
+}
+
+func (u *urlTable) sequential(dst vm.QuerySink) error {
+	rc, err := u.blob.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return vm.SplitInput(dst, 1, func(w io.Writer) error {
+		cn := ion.Chunker{W: w, Align: vm.PageSize}
+		br := bufio.NewReader(rc)
+		hdr, _ := br.Peek(4)
+		if ion.IsBVM(hdr) {
+			_, err = cn.ReadFrom(br, nil)
+		} else {
+			err = jsonrl.Convert(br, &cn, nil, nil)
+			if err == nil {
+				err = cn.Flush()
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			// the output was closed early
+			return nil
+		}
+		return err
+	})
+}
+
+// parallel converts parts of urlPartSize bytes
+// of NDJSON concurrently; each part consists of
+// the records that begin within its range
+func (u *urlTable) parallel(dst vm.QuerySink, parallel int) error {
+	parts := (u.blob.Info.Size + urlPartSize - 1) / urlPartSize
+	parallel = int(min(int64(parallel), parts))
+	var next atomic.Int64
+	return vm.SplitInput(dst, parallel, func(w io.Writer) error {
+		cn := ion.Chunker{W: w, Align: vm.PageSize}
+		for {
+			i := next.Add(1) - 1
+			if i >= parts {
+				break
+			}
+			err := u.convertPart(&cn, i*urlPartSize, min((i+1)*urlPartSize, u.blob.Info.Size))
+			if errors.Is(err, io.EOF) {
+				return nil // the output was closed early
+			}
+			if err != nil {
+				return err
+			}
+		}
+		err := cn.Flush()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	})
+}
+
+// convertPart converts the records that
+// begin within [start, end) into cn
+func (u *urlTable) convertPart(cn *ion.Chunker, start, end int64) error {
+	off := start
+	if start > 0 {
+		// read the byte before the part to see
+		// whether the part begins a record
+		off--
+	}
+	rc := &rangeReader{
+		open:  u.blob.Range,
+		pos:   off,
+		limit: min(end+urlReadAhead, u.blob.Info.Size),
+		size:  u.blob.Info.Size,
+	}
+	defer rc.Close()
+	lr := &lineReader{src: bufio.NewReader(rc), pos: off, end: end}
+	if start > 0 {
+		// skip the record that began in the
+		// previous part (or the newline that
+		// terminated it)
+		if err := lr.skipLine(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+	return jsonrl.Convert(lr, cn, nil, nil)
+}
+
+// rangeReader reads an object starting at pos
+// with range requests; the first request reads
+// up to limit, and each subsequent request
+// reads up to urlReadAhead more bytes
+type rangeReader struct {
+	open  func(off, width int64) (io.ReadCloser, error)
+	rc    io.ReadCloser
+	pos   int64 // offset of the next byte to read
+	limit int64 // end of the current request
+	size  int64 // size of the object
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	for {
+		if r.rc == nil {
+			if r.pos >= r.size {
+				return 0, io.EOF
+			}
+			if r.pos >= r.limit {
+				r.limit = min(r.pos+urlReadAhead, r.size)
+			}
+			rc, err := r.open(r.pos, r.limit-r.pos)
+			if err != nil {
+				return 0, err
+			}
+			r.rc = rc
+		}
+		n, err := r.rc.Read(p)
+		r.pos += int64(n)
+		if errors.Is(err, io.EOF) {
+			r.rc.Close()
+			r.rc = nil
+			if r.pos < r.limit {
+				return n, io.ErrUnexpectedEOF
+			}
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+func (r *rangeReader) Close() error {
+	if r.rc == nil {
+		return nil
+	}
+	err := r.rc.Close()
+	r.rc = nil
+	return err
+}
+
+// lineReader reads from src until it has
+// passed the end of the line that contains
+// the byte at end-1
+type lineReader struct {
+	src  *bufio.Reader
+	pos  int64 // offset of the next byte of src
+	end  int64
+	last byte // last byte read from src
+	done bool
+}
+
+func (l *lineReader) skipLine() error {
+	line, err := l.src.ReadSlice('\n')
+	for errors.Is(err, bufio.ErrBufferFull) {
+		l.pos += int64(len(line))
+		line, err = l.src.ReadSlice('\n')
+	}
+	l.pos += int64(len(line))
+	l.last = '\n'
+	return err
+}
+
+func (l *lineReader) Read(p []byte) (int, error) {
+	if l.done {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if l.pos < l.end {
+		if rem := l.end - l.pos; rem < int64(len(p)) {
+			p = p[:rem]
+		}
+		n, err := l.src.Read(p)
+		if n > 0 {
+			l.pos += int64(n)
+			l.last = p[n-1]
+		}
+		return n, err
+	}
+	if l.last == '\n' {
+		// the next line begins
+		// in the next part
+		l.done = true
+		return 0, io.EOF
+	}
+	// finish the current line
+	buf, err := l.src.Peek(min(len(p), l.src.Size()))
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		buf = buf[:i+1]
+		l.done = true
+	} else if len(buf) == 0 {
+		return 0, err
+	}
+	n := copy(p, buf)
+	l.src.Discard(n)
+	l.pos += int64(n)
+	l.last = buf[n-1]
+	return n, nil
+}
+
+// urlRunner is a plan.Runner that reads
+// the inputs produced by readURL and passes
+// everything else to Runner
+type urlRunner struct {
+	plan.Runner
+}
+
+func (r *urlRunner) Run(dst vm.QuerySink, src *plan.Input, ep *plan.ExecParams) error {
+	n := 0
+	for i := range src.Descs {
+		if src.Descs[i].Format == urlFormat {
+			n++
+		}
+	}
+	if n == 0 {
+		if r.Runner == nil {
+			return fmt.Errorf("cannot read tables without a file system root")
+		}
+		return r.Runner.Run(dst, src, ep)
+	}
+	if n != len(src.Descs) {
+		return fmt.Errorf("cannot mix URLs and packed tables in one input")
+	}
+	for i := range src.Descs {
+		d := &src.Descs[i]
+		u, err := urlBlob(d)
+		if err != nil {
+			return err
+		}
+		tbl := &urlTable{blob: u}
+		if err := tbl.WriteChunks(dst, ep.Parallel); err != nil {
+			return fmt.Errorf("reading %s: %w", u, err)
+		}
+		atomic.AddInt64(&ep.Stats.BytesScanned, d.ObjectInfo.Size)
+	}
+	return nil
+}
+
+// urlBlob returns the blob read by d,
+// checking that its ETag has not changed
+// since the query was planned
+func urlBlob(d *plan.Descriptor) (*blob.URL, error) {
+	u, err := blob.StatURL(http.DefaultClient, d.Path)
+	if err != nil {
+		return nil, err
+	}
+	if u.Info.ETag != d.ETag {
+		return nil, fmt.Errorf("%s: ETag changed from %s to %s", u, d.ETag, u.Info.ETag)
+	}
+	return u, nil
+}
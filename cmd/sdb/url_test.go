@@ -0,0 +1,121 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readPart returns the bytes of the records
+// of data that begin within [start, end)
+func readPart(t *testing.T, data []byte, start, end int64) []byte {
+	off := start
+	if start > 0 {
+		off--
+	}
+	lr := &lineReader{src: bufio.NewReader(bytes.NewReader(data[off:])), pos: off, end: end}
+	if start > 0 {
+		if err := lr.skipLine(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			t.Fatal(err)
+		}
+	}
+	out, err := io.ReadAll(lr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestLineReaderParts(t *testing.T) {
+	var lines []string
+	for i := 0; i < 50; i++ {
+		lines = append(lines, fmt.Sprintf(`{"x": %d, "y": %q}`, i, strings.Repeat("a", i*i)))
+	}
+	// a line longer than the bufio.Reader buffer
+	lines = append(lines, fmt.Sprintf(`{"long": %q}`, strings.Repeat("b", 10000)))
+	lines = append(lines, `{"x": "last"}`)
+	text := strings.Join(lines, "\n")
+	for _, data := range [][]byte{[]byte(text), []byte(text + "\n")} {
+		size := int64(len(data))
+		for _, part := range []int64{1, 2, 7, 64, 1000, 4096, 5000, size} {
+			var got []byte
+			for start := int64(0); start < size; start += part {
+				got = append(got, readPart(t, data, start, min(start+part, size))...)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("part size %d: got %d bytes, want %d", part, len(got), len(data))
+			}
+		}
+	}
+}
+
+func TestRangeReaderBounded(t *testing.T) {
+	lines := []string{
+		`{"x": 0}`,
+		// a record much longer than the read-ahead
+		fmt.Sprintf(`{"long": %q}`, strings.Repeat("b", 3*urlReadAhead)),
+		`{"x": 1}`,
+	}
+	data := []byte(strings.Join(lines, "\n") + "\n")
+	size := int64(len(data))
+	var widths []int64
+	open := func(off, width int64) (io.ReadCloser, error) {
+		if width <= 0 || off+width > size {
+			t.Fatalf("bad range [%d, %d)", off, off+width)
+		}
+		widths = append(widths, width)
+		return io.NopCloser(bytes.NewReader(data[off : off+width])), nil
+	}
+	const part = 4096
+	var got []byte
+	for start := int64(0); start < size; start += part {
+		end := min(start+part, size)
+		off := max(start-1, 0)
+		rc := &rangeReader{open: open, pos: off, limit: min(end+urlReadAhead, size), size: size}
+		lr := &lineReader{src: bufio.NewReader(rc), pos: off, end: end}
+		if start > 0 {
+			if err := lr.skipLine(); err != nil {
+				if errors.Is(err, io.EOF) {
+					rc.Close()
+					continue
+				}
+				t.Fatal(err)
+			}
+		}
+		out, err := io.ReadAll(lr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rc.Close()
+		got = append(got, out...)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("got %d bytes, want %d", len(got), len(data))
+	}
+	for _, w := range widths {
+		if w > part+urlReadAhead+1 {
+			t.Fatalf("requested %d bytes", w)
+		}
+	}
+}
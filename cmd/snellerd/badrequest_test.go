@@ -158,15 +158,17 @@ func TestBadRequest(t *testing.T) {
 	// particular set of queries is 400
 	// plus some particular error text
 	queries := []struct {
-		text, match string
+		text, extra, match string
 	}{
-		{"SELECT 3||x FROM parking", "ill-typed"},
-		{"SELECT LEAST(TRIM(x)) FROM parking WHERE x = 3", "ill-typed"},
+		{"SELECT 3||x FROM parking", "", "ill-typed"},
+		{"SELECT LEAST(TRIM(x), [1, 2]) FROM parking WHERE x = 3", "", "ill-typed"},
+		{"SELECT x FROM parking ORDER BY x LIMIT 1", "&nonfinite=skip", "unknown non-finite mode"},
 	}
 
 	cl := http.DefaultClient
 	for i := range queries {
 		r := rqe.getQuery("default", queries[i].text)
+		r.URL.RawQuery += queries[i].extra
 		res, err := cl.Do(r)
 		if err != nil {
 			t.Fatal(err)
@@ -17,12 +17,14 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -33,9 +35,11 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/SnellerInc/sneller/db"
 	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/ion/arrow"
 	"github.com/SnellerInc/sneller/ion/blockfmt"
 	"github.com/SnellerInc/sneller/tenant"
 
@@ -292,6 +296,619 @@ func TestQueryError(t *testing.T) {
 	t.Logf("error message: %s", msg)
 }
 
+func TestQueryTimeout(t *testing.T) {
+	tt := testdirEnviron(t)
+	peersock := listen(t)
+	s := server{
+		logger:    testlogger(t),
+		sandbox:   tenant.CanSandbox(),
+		cachedir:  t.TempDir(),
+		cgroot:    os.Getenv("CGROOT"),
+		tenantcmd: []string{"./snellerd-test-binary", "worker"},
+		peers:     makePeers(t, peersock.Addr().(*net.TCPAddr)),
+		auth:      testAuth{tt},
+		// the timeout expires as soon as the
+		// query has been handed to the tenant
+		queryTimeout: time.Nanosecond,
+	}
+	httpsock := listen(t)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.aboutToServe = (&wg).Done
+	go s.Serve(httpsock, peersock)
+	wg.Wait()
+	defer s.Close()
+
+	rq := &requester{
+		t:    t,
+		host: "http://" + httpsock.Addr().String(),
+	}
+	query := `SELECT COUNT(*), SUM(trip_distance) FROM default.taxi`
+	run := func(t *testing.T, accept string) []byte {
+		r := rq.getQuery("default", query)
+		r.Header.Set("Accept", accept)
+		res, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		buf, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("status code %d %s", res.StatusCode, buf)
+		}
+		timing := strings.Join(res.Trailer.Values("Server-Timing"), ", ")
+		if !strings.Contains(timing, "error") || !strings.Contains(timing, "scanned") {
+			t.Errorf("unexpected Server-Timing trailer %q", timing)
+		}
+		return buf
+	}
+	t.Run("ion", func(t *testing.T) {
+		buf := run(t, "application/ion")
+		var st ion.Symtab
+		var annotations []string
+		for len(buf) > 0 {
+			var err error
+			if ion.IsBVM(buf) {
+				buf, err = st.Unmarshal(buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			if ion.TypeOf(buf) != ion.AnnotationType {
+				// partial results may precede the error
+				buf = buf[ion.SizeOf(buf):]
+				continue
+			}
+			sym, body, rest, err := ion.ReadAnnotation(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			annotations = append(annotations, st.Get(sym))
+			d, _, err := ion.ReadDatum(&st, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s, err := d.Struct()
+			if err != nil {
+				t.Fatal(err)
+			}
+			field := "error"
+			if st.Get(sym) == "query_error" {
+				field = "error_message"
+			}
+			f, ok := s.FieldByName(field)
+			if !ok {
+				t.Fatalf("%s::%s missing %q", st.Get(sym), d.JSON(), field)
+			}
+			if msg, _ := f.String(); !strings.Contains(msg, "timed out") {
+				t.Errorf("%s::%s: unexpected error", st.Get(sym), d.JSON())
+			}
+			if _, ok := s.FieldByName("scanned"); !ok {
+				t.Errorf("%s::%s: missing stats", st.Get(sym), d.JSON())
+			}
+			buf = rest
+		}
+		if !slices.Equal(annotations, []string{"query_error", "final_status"}) {
+			t.Errorf("got annotations %v", annotations)
+		}
+	})
+	t.Run("ndjson", func(t *testing.T) {
+		buf := run(t, "application/x-ndjson")
+		lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+		if len(lines) < 2 {
+			t.Fatalf("got %d lines: %s", len(lines), buf)
+		}
+		// partial results may precede the error
+		lines = lines[len(lines)-2:]
+		var qerr struct {
+			Error struct {
+				Message string `json:"error_message"`
+				Scanned *int64 `json:"scanned"`
+			} `json:"$ion_annotation$query_error"`
+		}
+		if err := json.Unmarshal([]byte(lines[0]), &qerr); err != nil {
+			t.Fatal(err)
+		}
+		if qerr.Error.Message != "query timed out" || qerr.Error.Scanned == nil {
+			t.Errorf("unexpected error line %s", lines[0])
+		}
+		var status struct {
+			Status struct {
+				Error   string `json:"error"`
+				Scanned *int64 `json:"scanned"`
+			} `json:"$sneller_final_status$"`
+		}
+		if err := json.Unmarshal([]byte(lines[1]), &status); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(status.Status.Error, "timed out") || status.Status.Scanned == nil {
+			t.Errorf("unexpected status line %s", lines[1])
+		}
+	})
+	t.Run("json-array", func(t *testing.T) {
+		buf := run(t, "application/json")
+		var out []map[string]any
+		if err := json.Unmarshal(buf, &out); err != nil {
+			t.Fatalf("%s: %s", buf, err)
+		}
+		if len(out) == 0 {
+			t.Fatalf("got %s", buf)
+		}
+		qerr, ok := out[len(out)-1]["$ion_annotation$query_error"].(map[string]any)
+		if !ok || qerr["error_message"] != "query timed out" || qerr["scanned"] == nil {
+			t.Errorf("unexpected output %s", buf)
+		}
+	})
+}
+
+func TestQueryLimit(t *testing.T) {
+	tt := testdirEnviron(t)
+	peersock := listen(t)
+	s := server{
+		logger:     testlogger(t),
+		sandbox:    tenant.CanSandbox(),
+		cachedir:   t.TempDir(),
+		cgroot:     os.Getenv("CGROOT"),
+		tenantcmd:  []string{"./snellerd-test-binary", "worker"},
+		peers:      makePeers(t, peersock.Addr().(*net.TCPAddr)),
+		auth:       testAuth{tt},
+		maxQueries: 1,
+	}
+	httpsock := listen(t)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.aboutToServe = (&wg).Done
+	go s.Serve(httpsock, peersock)
+	wg.Wait()
+	defer s.Close()
+
+	rq := &requester{
+		t:    t,
+		host: "http://" + httpsock.Addr().String(),
+	}
+	run := func() (*http.Response, string) {
+		res, err := http.DefaultClient.Do(rq.getQuery("default", `SELECT COUNT(*) FROM default.taxi`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		buf, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res, string(buf)
+	}
+	// occupy the only slot of the tenant
+	release, err := s.limit.acquire(context.Background(), tt.ID(), 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, body := run()
+	if res.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status code %d %s", res.StatusCode, body)
+	}
+	if res.Header.Get("Retry-After") == "" || !strings.Contains(body, "too many concurrent queries") {
+		t.Errorf("unexpected response %v %q", res.Header, body)
+	}
+	release()
+	res, body = run()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status code %d %s", res.StatusCode, body)
+	}
+}
+
+func TestPreparedQuery(t *testing.T) {
+	tt := testdirEnviron(t)
+	peersock := listen(t)
+	s := server{
+		logger:    testlogger(t),
+		sandbox:   tenant.CanSandbox(),
+		cachedir:  t.TempDir(),
+		cgroot:    os.Getenv("CGROOT"),
+		tenantcmd: []string{"./snellerd-test-binary", "worker"},
+		peers:     makePeers(t, peersock.Addr().(*net.TCPAddr)),
+		auth:      testAuth{tt},
+		prepared:  preparedStatements{max: 1},
+	}
+	httpsock := listen(t)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.aboutToServe = (&wg).Done
+	go s.Serve(httpsock, peersock)
+	wg.Wait()
+	defer s.Close()
+
+	rq := &requester{
+		t:    t,
+		host: "http://" + httpsock.Addr().String(),
+	}
+	run := func(query, params string) (int, string) {
+		t.Helper()
+		req := rq.getQueryJSON("default", query)
+		if params != "" {
+			req.URL.RawQuery += "&params=" + url.QueryEscape(params)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		buf, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, strings.TrimSpace(string(buf))
+	}
+	status, want := run(`SELECT COUNT(*) FROM taxi WHERE fare_amount > 10 AND payment_type = 'CASH'`, "")
+	if status != http.StatusOK {
+		t.Fatalf("status code %d %s", status, want)
+	}
+	status, body := run(`PREPARE fares (FLOAT, STRING) AS SELECT COUNT(*) FROM taxi WHERE fare_amount > ? AND payment_type = ?`, "")
+	if status != http.StatusNoContent {
+		t.Fatalf("PREPARE: status code %d %s", status, body)
+	}
+	for _, tc := range []struct {
+		query, params string
+	}{
+		{query: `EXECUTE fares USING 10, 'CASH'`},
+		{query: `EXECUTE fares`, params: `[10, "CASH"]`},
+		{query: `SELECT COUNT(*) FROM taxi WHERE fare_amount > ? AND payment_type = ?`, params: `[10, "CASH"]`},
+	} {
+		status, body := run(tc.query, tc.params)
+		if status != http.StatusOK || body != want {
+			t.Errorf("%s %s: got %d %s, want %s", tc.query, tc.params, status, body, want)
+		}
+	}
+	for _, tc := range []struct {
+		query, params, msg string
+	}{
+		{query: `EXECUTE fares USING 'ten', 'CASH'`, msg: "expected a value of type FLOAT"},
+		{query: `EXECUTE fares`, params: `[10]`, msg: "query has 2 parameters but 1 values were provided"},
+		{query: `EXECUTE fares USING 10, 'CASH'`, params: `[10, "CASH"]`, msg: "cannot pass params"},
+		{query: `EXECUTE other`, msg: `prepared statement "other" does not exist`},
+		{query: `SELECT COUNT(*) FROM taxi WHERE fare_amount > ?`, params: `{"x": 1}`, msg: "params must be a JSON array"},
+		{query: `PREPARE other AS SELECT COUNT(*) FROM taxi`, msg: "too many prepared statements (limit 1)"},
+		{query: `DEALLOCATE other`, msg: `prepared statement "other" does not exist`},
+	} {
+		status, body := run(tc.query, tc.params)
+		if status != http.StatusBadRequest || !strings.Contains(body, tc.msg) {
+			t.Errorf("%s %s: got %d %s, want %q", tc.query, tc.params, status, body, tc.msg)
+		}
+	}
+	status, body = run(`DEALLOCATE fares`, "")
+	if status != http.StatusNoContent {
+		t.Fatalf("DEALLOCATE: status code %d %s", status, body)
+	}
+	status, body = run(`EXECUTE fares USING 10, 'CASH'`, "")
+	if status != http.StatusBadRequest || !strings.Contains(body, `prepared statement "fares" does not exist`) {
+		t.Errorf("EXECUTE after DEALLOCATE: got %d %s", status, body)
+	}
+	status, body = run(`PREPARE other AS SELECT COUNT(*) FROM taxi`, "")
+	if status != http.StatusNoContent {
+		t.Errorf("PREPARE after DEALLOCATE: status code %d %s", status, body)
+	}
+}
+
+func TestQueryPagination(t *testing.T) {
+	tt := testdirEnviron(t)
+	peersock := listen(t)
+	s := server{
+		logger:    testlogger(t),
+		sandbox:   tenant.CanSandbox(),
+		cachedir:  t.TempDir(),
+		cgroot:    os.Getenv("CGROOT"),
+		tenantcmd: []string{"./snellerd-test-binary", "worker"},
+		peers:     makePeers(t, peersock.Addr().(*net.TCPAddr)),
+		auth:      testAuth{tt},
+	}
+	httpsock := listen(t)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.aboutToServe = (&wg).Done
+	go s.Serve(httpsock, peersock)
+	wg.Wait()
+	defer s.Close()
+
+	rq := &requester{
+		t:    t,
+		host: "http://" + httpsock.Addr().String(),
+	}
+	// run returns the rows of the query as sorted
+	// JSON text along with the response
+	run := func(query, extra string) (*http.Response, []string, string) {
+		t.Helper()
+		req := rq.getQueryJSON("default", query)
+		req.URL.RawQuery += extra
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		buf, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusOK {
+			return res, nil, strings.TrimSpace(string(buf))
+		}
+		var rows []json.RawMessage
+		if err := json.Unmarshal(buf, &rows); err != nil {
+			t.Fatalf("%s: %s", err, buf)
+		}
+		var out []string
+		for i := range rows {
+			out = append(out, string(rows[i]))
+		}
+		return res, out, ""
+	}
+
+	const query = `SELECT tpep_pickup_datetime, trip_distance FROM taxi WHERE passenger_count = 1`
+	_, want, msg := run(query, "")
+	if msg != "" {
+		t.Fatal(msg)
+	}
+	if len(want) < 10 {
+		t.Fatalf("only %d rows", len(want))
+	}
+	const size = 500
+	var got []string
+	var first []string
+	cursor := ""
+	firstCursor := ""
+	for pages := 0; ; pages++ {
+		extra := "&page_size=" + strconv.Itoa(size)
+		if cursor != "" {
+			extra += "&cursor=" + cursor
+		}
+		res, rows, msg := run(query, extra)
+		if msg != "" {
+			t.Fatalf("page %d: status %d %s", pages, res.StatusCode, msg)
+		}
+		if len(rows) > size {
+			t.Fatalf("page %d: %d rows", pages, len(rows))
+		}
+		got = append(got, rows...)
+		// the cursor is a trailer that is
+		// only present if there are more rows
+		cursor = res.Trailer.Get("X-Sneller-Next-Cursor")
+		if pages == 0 {
+			first = rows
+			firstCursor = cursor
+		}
+		if cursor == "" {
+			break
+		}
+		if len(rows) != size {
+			t.Fatalf("page %d: %d rows and a cursor", pages, len(rows))
+		}
+	}
+	if firstCursor == "" {
+		t.Fatal("no cursor returned for the first page")
+	}
+	cursor = firstCursor
+	// the concatenated pages are exactly the
+	// rows of the query, each one exactly once
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("got %d rows from pages, want %d", len(got), len(want))
+	}
+	// pages are reproducible
+	_, again, _ := run(query, "&page_size="+strconv.Itoa(size))
+	if !slices.Equal(again, first) {
+		t.Errorf("first page changed: %v != %v", again, first)
+	}
+
+	for _, tc := range []struct {
+		query, extra string
+		status       int
+		msg          string
+	}{
+		{
+			query:  query + ` ORDER BY trip_distance`,
+			extra:  "&page_size=10",
+			status: http.StatusBadRequest,
+			msg:    "pagination is not supported for queries with ORDER BY",
+		},
+		{
+			query:  `SELECT COUNT(*) FROM taxi`,
+			extra:  "&page_size=10",
+			status: http.StatusBadRequest,
+			msg:    "pagination is not supported for queries with GROUP BY, DISTINCT or aggregates",
+		},
+		{
+			query:  query + ` LIMIT 10`,
+			extra:  "&page_size=10",
+			status: http.StatusBadRequest,
+			msg:    "pagination is not supported for queries with LIMIT or OFFSET",
+		},
+		{
+			query:  query + ` AND VendorID IN (SELECT DISTINCT VendorID FROM taxi LIMIT 1)`,
+			extra:  "&page_size=10",
+			status: http.StatusBadRequest,
+			msg:    "pagination is not supported for queries with sub-queries",
+		},
+		{
+			query:  query,
+			extra:  "&page_size=0",
+			status: http.StatusBadRequest,
+			msg:    "page_size must be between 1 and",
+		},
+		{
+			query:  query,
+			extra:  "&cursor=" + cursor,
+			status: http.StatusBadRequest,
+			msg:    "'cursor' requires 'page_size'",
+		},
+		{
+			query:  query,
+			extra:  "&page_size=10&cursor=xyz",
+			status: http.StatusBadRequest,
+			msg:    "invalid cursor",
+		},
+		{
+			// a cursor issued for a different query
+			query:  query + ` AND trip_distance > 1`,
+			extra:  "&page_size=10&cursor=" + cursor,
+			status: http.StatusConflict,
+			msg:    "cursor does not match this query",
+		},
+	} {
+		res, _, msg := run(tc.query, tc.extra)
+		if res.StatusCode != tc.status || !strings.Contains(msg, tc.msg) {
+			t.Errorf("%s %s: got %d %s, want %d %q", tc.query, tc.extra, res.StatusCode, msg, tc.status, tc.msg)
+		}
+	}
+}
+
+func TestQueryDebug(t *testing.T) {
+	tt := testdirEnviron(t)
+	peersock := listen(t)
+	s := server{
+		logger:    testlogger(t),
+		sandbox:   tenant.CanSandbox(),
+		cachedir:  t.TempDir(),
+		cgroot:    os.Getenv("CGROOT"),
+		tenantcmd: []string{"./snellerd-test-binary", "worker"},
+		peers:     makePeers(t, peersock.Addr().(*net.TCPAddr)),
+		auth:      testAuth{tt},
+	}
+	httpsock := listen(t)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.aboutToServe = (&wg).Done
+	go s.Serve(httpsock, peersock)
+	wg.Wait()
+	defer s.Close()
+
+	rq := &requester{
+		t:    t,
+		host: "http://" + httpsock.Addr().String(),
+	}
+	query := `SELECT COUNT(*) FROM default.taxi`
+	run := func(t *testing.T, accept string, debug bool) (int, []byte) {
+		r := rq.getQuery("default", query)
+		if debug {
+			r.URL.RawQuery += "&debug"
+		}
+		r.Header.Set("Accept", accept)
+		res, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		buf, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode, buf
+	}
+	type debugInfo struct {
+		Version  string   `json:"version"`
+		PlanHash string   `json:"plan_hash"`
+		ETags    []string `json:"etags"`
+	}
+	check := func(t *testing.T, info *debugInfo) {
+		if info.Version == "" {
+			t.Error("missing version")
+		}
+		if len(info.PlanHash) != 64 {
+			t.Errorf("unexpected plan_hash %q", info.PlanHash)
+		}
+		if len(info.ETags) == 0 {
+			t.Error("missing etags")
+		}
+	}
+	t.Run("ion", func(t *testing.T) {
+		for _, debug := range []bool{false, true} {
+			status, buf := run(t, "application/ion", debug)
+			if status != http.StatusOK {
+				t.Fatalf("status code %d %s", status, buf)
+			}
+			var st ion.Symtab
+			var final *ion.Struct
+			for len(buf) > 0 {
+				var err error
+				if ion.IsBVM(buf) {
+					buf, err = st.Unmarshal(buf)
+					if err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				if ion.TypeOf(buf) != ion.AnnotationType {
+					buf = buf[ion.SizeOf(buf):]
+					continue
+				}
+				sym, body, rest, err := ion.ReadAnnotation(buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if st.Get(sym) == "final_status" {
+					d, _, err := ion.ReadDatum(&st, body)
+					if err != nil {
+						t.Fatal(err)
+					}
+					s, err := d.Struct()
+					if err != nil {
+						t.Fatal(err)
+					}
+					final = &s
+				}
+				buf = rest
+			}
+			if final == nil {
+				t.Fatal("missing final_status")
+			}
+			var info debugInfo
+			if err := json.Unmarshal([]byte(final.Datum().JSON()), &info); err != nil {
+				t.Fatal(err)
+			}
+			if !debug {
+				if info.Version != "" || info.PlanHash != "" || info.ETags != nil {
+					t.Errorf("unexpected debug information without opt-in: %+v", info)
+				}
+				continue
+			}
+			check(t, &info)
+		}
+	})
+	t.Run("ndjson", func(t *testing.T) {
+		status, buf := run(t, "application/x-ndjson", false)
+		if status != http.StatusOK {
+			t.Fatalf("status code %d %s", status, buf)
+		}
+		if bytes.Contains(buf, []byte("$sneller_final_status$")) {
+			t.Errorf("unexpected final status without opt-in: %s", buf)
+		}
+		status, buf = run(t, "application/x-ndjson", true)
+		if status != http.StatusOK {
+			t.Fatalf("status code %d %s", status, buf)
+		}
+		lines := strings.Split(strings.TrimSpace(string(buf)), "\n")
+		var final struct {
+			Status *debugInfo `json:"$sneller_final_status$"`
+		}
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &final); err != nil {
+			t.Fatal(err)
+		}
+		if final.Status == nil {
+			t.Fatalf("missing final status: %s", buf)
+		}
+		check(t, final.Status)
+	})
+	t.Run("json", func(t *testing.T) {
+		status, buf := run(t, "application/json", true)
+		if status != http.StatusBadRequest {
+			t.Errorf("got status code %d %s", status, buf)
+		}
+	})
+}
+
 // test the server running on a tmpfs that
 // has been populated with some test tables
 func TestSimpleFS(t *testing.T) {
@@ -640,4 +1257,85 @@ func TestSimpleFS(t *testing.T) {
 			checkTiming(t, res)
 		})
 	}
+
+	// get coverage of Arrow responses
+	t.Run("arrow", func(t *testing.T) {
+		r := rq.getQuery("", `SELECT Ticket FROM default.parking WHERE Route = '2A75' AND IssueTime <= 1100`)
+		r.Header.Set("Accept", arrow.MIMEType)
+		res, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("status %s: %s", res.Status, got)
+		}
+		if ct := res.Header.Get("Content-Type"); ct != arrow.MIMEType {
+			t.Errorf("Content-Type %q", ct)
+		}
+		// the stream starts with the schema message
+		// and ends with the end-of-stream marker
+		eos := []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}
+		if !bytes.HasPrefix(got, eos[:4]) || !bytes.HasSuffix(got, eos) {
+			t.Errorf("unexpected stream %x", got)
+		}
+		checkTiming(t, res)
+	})
+
+	// get coverage of ion responses with a fixed schema
+	t.Run("schema", func(t *testing.T) {
+		r := rq.getQuery("", `SELECT Ticket FROM default.parking WHERE Route = '2A75' AND IssueTime <= 1100`)
+		r.URL.RawQuery += "&schema"
+		res, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("status %s: %s", res.Status, got)
+		}
+		var st ion.Symtab
+		d, rest, err := ion.ReadDatum(&st, got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lbl, _, err := d.Annotation(); err != nil || lbl != "schema" {
+			t.Fatalf("stream starts with %v", d)
+		}
+		// the rows follow without a new symbol table
+		rows := 0
+		for !ion.IsBVM(rest) {
+			d, rest, err = ion.ReadDatum(&st, rest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !d.IsStruct() {
+				t.Fatalf("unexpected row %v", d)
+			}
+			rows++
+		}
+		if rows != 3 {
+			t.Errorf("got %d rows", rows)
+		}
+		checkTiming(t, res)
+
+		r = rq.getQuery("", `SELECT * FROM default.parking LIMIT 1`)
+		r.URL.RawQuery += "&schema"
+		res, err = http.DefaultClient.Do(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("SELECT *: status %s", res.Status)
+		}
+	})
 }
@@ -15,8 +15,10 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -33,6 +35,7 @@ import (
 	"github.com/SnellerInc/sneller/expr"
 	"github.com/SnellerInc/sneller/expr/partiql"
 	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/ion/arrow"
 	"github.com/SnellerInc/sneller/plan"
 	"github.com/SnellerInc/sneller/plan/pir"
 	"github.com/SnellerInc/sneller/tenant"
@@ -89,6 +92,7 @@ const queryKillTimeout = 15 * time.Minute
 // example invocation:
 // curl -v -H 'Authorization: sneller' -H 'Accept: application/ion' 'http://localhost:8080/query?database=sf1-new&query=SELECT%20%2A%20FROM%20nation%20LIMIT%2010'
 // curl -v -X POST -H 'Authorization: sneller' -H 'Accept: application/ion' --data-raw 'SELECT * FROM nation LIMIT 10' 'http://localhost:8080/query?database=sf1-new'
+// curl -v -X POST -H 'Authorization: sneller' -H 'Accept: application/ion' --data-raw 'SELECT * FROM nation WHERE n_name = ?' 'http://localhost:8080/query?database=sf1-new&params=%5B%22FRANCE%22%5D'
 func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	start := time.Now()
@@ -137,6 +141,12 @@ func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 		encodingFormat = tnproto.OutputChunkedIon
 	case "application/json":
 		encodingFormat = tnproto.OutputChunkedJSONArray
+	case arrow.MIMEType:
+		if explicitJSON {
+			http.Error(w, fmt.Sprintf("can't request JSON and explicitly accept %q", acceptHeader), http.StatusBadRequest)
+			return
+		}
+		encodingFormat = tnproto.OutputChunkedArrow
 	case "", "*/*":
 		if explicitJSON {
 			encodingFormat = tnproto.OutputChunkedJSON
@@ -155,6 +165,36 @@ func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "cannot return stats with normal JSON output (try NDJSON)", http.StatusBadRequest)
 		return
 	}
+	if encodingFormat == tnproto.OutputChunkedArrow && statsOptIn {
+		http.Error(w, "cannot return stats with Arrow output", http.StatusBadRequest)
+		return
+	}
+	debugOptIn := r.URL.Query().Has("debug")
+	if debugOptIn && (encodingFormat == tnproto.OutputChunkedJSONArray || encodingFormat == tnproto.OutputChunkedArrow) {
+		http.Error(w, "cannot return debug information with normal JSON or Arrow output (try NDJSON)", http.StatusBadRequest)
+		return
+	}
+	if r.URL.Query().Has("schema") {
+		if encodingFormat != tnproto.OutputChunkedIon {
+			http.Error(w, "can only return a fixed schema with ion output", http.StatusBadRequest)
+			return
+		}
+		encodingFormat = tnproto.OutputChunkedIonSchema
+	}
+	pg, err := parsePage(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	nonFinite := expr.NonFiniteOrder
+	if mode := r.URL.Query().Get("nonfinite"); mode != "" {
+		nonFinite, err = expr.ParseNonFinite(mode)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
 	defaultDatabase := r.URL.Query().Get("database")
 	parsedQuery, err := partiql.Parse(query)
@@ -167,6 +207,58 @@ func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	params, err := parseParams(r.URL.Query().Get("params"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if parsedQuery.Prepare != "" {
+		if len(params) > 0 {
+			http.Error(w, "cannot pass params to PREPARE", http.StatusBadRequest)
+			return
+		}
+		if !isHeadRequest {
+			err = s.prepared.put(tenantID, parsedQuery)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if parsedQuery.Deallocate != "" {
+		if len(params) > 0 {
+			http.Error(w, "cannot pass params to DEALLOCATE", http.StatusBadRequest)
+			return
+		}
+		var ok bool
+		if isHeadRequest {
+			_, ok = s.prepared.get(tenantID, parsedQuery.Deallocate)
+		} else {
+			ok = s.prepared.remove(tenantID, parsedQuery.Deallocate)
+		}
+		if !ok {
+			http.Error(w, fmt.Sprintf("prepared statement %q does not exist", parsedQuery.Deallocate), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	parsedQuery, err = s.bindQuery(tenantID, parsedQuery, params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	parsedQuery = expr.ApplyNonFinite(parsedQuery, nonFinite)
+
+	if pg != nil {
+		if err := checkPageable(parsedQuery); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
 
 	normalized := parsedQuery.Text()
 	redacted := parsedQuery.Text()
@@ -178,13 +270,17 @@ func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 	hash = sha256.Sum256([]byte(tenantID + string(creds.Key()[:])))
 	copy(key[:], hash[:])
 
-	// determine scan limit
+	// determine scan and concurrency limits
 	maxScan := uint64(DefaultMaxScan)
+	maxQueries := s.maxQueries
 	if ct, ok := creds.(db.TenantConfigurable); ok {
 		cfg := ct.Config()
 		if cfg != nil && cfg.MaxScanBytes > 0 {
 			maxScan = cfg.MaxScanBytes
 		}
+		if cfg != nil && cfg.MaxConcurrentQueries != 0 {
+			maxQueries = cfg.MaxConcurrentQueries
+		}
 	}
 
 	planEnv, err := sneller.Environ(creds, defaultDatabase)
@@ -200,7 +296,9 @@ func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 
 	var tree *plan.Tree
 	start = time.Now()
-	if len(endPoints) == 0 {
+	if len(endPoints) == 0 || pg != nil {
+		// pages are only consistent when
+		// the query is executed in one place
 		tree, err = plan.New(parsedQuery, planEnv)
 	} else {
 		splitter := s.newSplitter(id, key, endPoints)
@@ -215,6 +313,17 @@ func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	tree.ID = queryID
+	if pg != nil {
+		if err := tree.CheckPage(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tree.Page = pg.plan()
+	}
+	if encodingFormat == tnproto.OutputChunkedIonSchema && len(tree.Results) == 0 {
+		http.Error(w, "a fixed schema requires a query with known result columns (not SELECT *)", http.StatusBadRequest)
+		return
+	}
 	// TODO: clean this up
 	if enc, ok := planEnv.Root.(interface {
 		Encode(*ion.Buffer, *ion.Symtab) error
@@ -238,12 +347,29 @@ func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 
 	planHash, newestBlobTime := planEnv.CacheValues()
 
+	var dbg *queryDebug
+	if debugOptIn {
+		dbg = newQueryDebug(tree, planHash)
+	}
+
+	var ph []byte
+	if pg != nil {
+		ph = pageHash(tenantID, normalized, planHash)
+		if err := pg.check(ph); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+	}
+
 	// hash the tenant/query/plan/format to an eTag
 	hasher := sha256.New()
 	hasher.Write([]byte(tenantID))
 	io.WriteString(hasher, normalized)
 	hasher.Write(planHash)
 	hasher.Write([]byte{byte(encodingFormat)})
+	if pg != nil {
+		pg.writeHash(hasher)
+	}
 	eTag := `"` + base64.RawStdEncoding.EncodeToString(hasher.Sum(nil)) + `"`
 
 	// Add the ETag to the response
@@ -290,6 +416,20 @@ func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 	if sendTrailer {
 		w.Header().Add("Trailer", "Server-Timing")
 	}
+	if pg != nil {
+		// the cursor for the next page is only
+		// known once the page has been produced
+		w.Header().Add("Trailer", "X-Sneller-Next-Cursor")
+	}
+
+	release, err := s.limit.acquire(ctx, tenantID, maxQueries, s.maxQueued)
+	if err != nil {
+		w.Header().Del("Trailer")
+		tooManyQueries(w, err)
+		s.logger.Printf("tenant %s query ID %s rejected: %s", tenantID, queryID, err)
+		return
+	}
+	defer release()
 
 	conn := &delayedHijack{
 		laddr: s.bound,
@@ -313,15 +453,28 @@ func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 			if sendTrailer {
 				setError(w)
 			}
-			if encodingFormat == tnproto.OutputChunkedIon {
+			if encodingFormat == tnproto.OutputChunkedIon || encodingFormat == tnproto.OutputChunkedIonSchema {
 				writeError(w, "error dispatching query")
 			}
 		}
 		s.logger.Printf("tenant %s query ID %s %q execution failed (do): %v", tenantID, queryID, redacted, err)
 		return
 	}
+	if s.queryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.queryTimeout)
+		defer cancel()
+	}
 	go func() {
-		<-r.Context().Done()
+		<-ctx.Done()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && r.Context().Err() == nil {
+			// let the tenant stop the query itself so that
+			// it terminates the output and reports the
+			// statistics collected so far
+			if tnproto.Timeout(rc) == nil {
+				return
+			}
+		}
 		rc.Close()
 	}()
 	s.logger.Printf("tenant %s query ID %s plan transfer took %s", tenantID, queryID, time.Since(startrun))
@@ -342,6 +495,26 @@ func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 			s.logger.Printf("tenant %s query ID %s canceled after %s", tenantID, queryID, time.Since(startrun))
 			return
 		}
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// the tenant has already terminated the output
+			// with a query_error::{...} including the partial
+			// statistics; add the trailers for the formats
+			// that can still be appended to
+			elapsed := time.Since(startrun)
+			if sendTrailer {
+				setTiming(w, elapsed, &stats)
+			}
+			errtext := fmt.Sprintf("query timed out after %s", s.queryTimeout)
+			switch encodingFormat {
+			case tnproto.OutputChunkedIon, tnproto.OutputChunkedIonSchema:
+				writeErrorStatusIon(w, errtext, &stats, dbg)
+			case tnproto.OutputChunkedJSON:
+				writeErrorStatusJSON(w, errtext, &stats, dbg)
+			}
+			s.logger.Printf("tenant %s query ID %s timed out after %s bytes %d hits %d misses %d",
+				tenantID, queryID, elapsed, stats.BytesScanned, stats.CacheHits, stats.CacheMisses)
+			return
+		}
 		s.logger.Printf("tenant %s query ID %s %q execution failed (check): %v", tenantID, queryID, redacted, err)
 		if deadlined && isTimeout(err) {
 			s.logger.Printf("tenant %s query ID %s killing tenant worker %s due to timeout", tenantID, queryID, id)
@@ -353,16 +526,26 @@ func (s *server) queryHandler(w http.ResponseWriter, r *http.Request) {
 	if sendTrailer {
 		setTiming(w, elapsed, &stats)
 	}
+	if pg != nil && stats.Next != nil {
+		w.Header().Set("X-Sneller-Next-Cursor", pg.next(ph, stats.Next))
+	}
 	switch encodingFormat {
-	case tnproto.OutputChunkedIon:
-		writeStatusIon(w, &stats, tree.Results, tree.ResultTypes)
+	case tnproto.OutputChunkedIon, tnproto.OutputChunkedIonSchema:
+		writeStatusIon(w, &stats, tree.Results, tree.ResultTypes, dbg)
 	case tnproto.OutputChunkedJSON:
-		if statsOptIn {
-			writeStatusJSON(w, &stats, tree.Results, tree.ResultTypes)
+		if statsOptIn || debugOptIn {
+			writeStatusJSON(w, &stats, tree.Results, tree.ResultTypes, dbg)
 		}
 	}
 	s.logger.Printf("tenant %s query ID %s duration %s bytes %d hits %d misses %d",
 		tenantID, queryID, elapsed, stats.BytesScanned, stats.CacheHits, stats.CacheMisses)
+	// flag the queries that read every field of a
+	// table, which are usually accidental (SELECT *)
+	for i := range stats.Tables {
+		if stats.Tables[i].AllFields {
+			s.logger.Printf("tenant %s query ID %s read all fields of %s", tenantID, queryID, stats.Tables[i].Table)
+		}
+	}
 }
 
 // satisfied by net.Conn and friends
@@ -456,7 +639,37 @@ func writeError(w http.ResponseWriter, errtext string) {
 	w.Write(tmp.Bytes())
 }
 
-func writeStatusIon(w http.ResponseWriter, stats *plan.ExecStats, results []expr.Binding, types []expr.TypeSet) {
+// writeErrorStatusIon is writeError with the
+// statistics collected before the error
+func writeErrorStatusIon(w http.ResponseWriter, errtext string, stats *plan.ExecStats, dbg *queryDebug) {
+	var tmp ion.Buffer
+	var st ion.Symtab
+	resultsym := st.Intern("final_status")
+	errsym := st.Intern("error")
+	hitsym := st.Intern("hits")
+	misssym := st.Intern("misses")
+	scansym := st.Intern("scanned")
+	tmp.BeginAnnotation(1)
+	tmp.BeginField(resultsym)
+	tmp.BeginStruct(-1)
+	tmp.BeginField(errsym)
+	tmp.WriteString(errtext)
+	tmp.BeginField(hitsym)
+	tmp.WriteInt(stats.CacheHits)
+	tmp.BeginField(misssym)
+	tmp.WriteInt(stats.CacheMisses)
+	tmp.BeginField(scansym)
+	tmp.WriteInt(stats.BytesScanned)
+	dbg.writeIon(&tmp, &st)
+	tmp.EndStruct()
+	tmp.EndAnnotation()
+	split := tmp.Size()
+	st.Marshal(&tmp, true)
+	w.Write(tmp.Bytes()[split:])
+	w.Write(tmp.Bytes()[:split])
+}
+
+func writeStatusIon(w http.ResponseWriter, stats *plan.ExecStats, results []expr.Binding, types []expr.TypeSet, dbg *queryDebug) {
 	var tmp ion.Buffer
 	var st ion.Symtab
 	resultsym := st.Intern("final_status")
@@ -481,6 +694,7 @@ func writeStatusIon(w http.ResponseWriter, stats *plan.ExecStats, results []expr
 		tmp.WriteUint(uint64(types[i]))
 	}
 	tmp.EndStruct()
+	dbg.writeIon(&tmp, &st)
 
 	tmp.EndStruct()
 	tmp.EndAnnotation()
@@ -491,13 +705,15 @@ func writeStatusIon(w http.ResponseWriter, stats *plan.ExecStats, results []expr
 	w.Write(tmp.Bytes()[:split])
 }
 
-func writeStatusJSON(w http.ResponseWriter, stats *plan.ExecStats, results []expr.Binding, types []expr.TypeSet) {
+func writeStatusJSON(w http.ResponseWriter, stats *plan.ExecStats, results []expr.Binding, types []expr.TypeSet, dbg *queryDebug) {
+	status := map[string]any{
+		"hits":    stats.CacheHits,
+		"misses":  stats.CacheMisses,
+		"scanned": stats.BytesScanned,
+	}
+	dbg.addJSON(status)
 	result := map[string]any{
-		"$sneller_final_status$": map[string]any{
-			"hits":    stats.CacheHits,
-			"misses":  stats.CacheMisses,
-			"scanned": stats.BytesScanned,
-		},
+		"$sneller_final_status$": status,
 	}
 
 	if len(results) > 0 && len(types) > 0 {
@@ -510,3 +726,77 @@ func writeStatusJSON(w http.ResponseWriter, stats *plan.ExecStats, results []exp
 
 	json.NewEncoder(w).Encode(&result)
 }
+
+// writeErrorStatusJSON is the JSON equivalent
+// of writeErrorStatusIon; unlike writeStatusJSON,
+// it is written regardless of the stats opt-in
+// so that clients can tell a timeout apart
+// from other query errors
+func writeErrorStatusJSON(w http.ResponseWriter, errtext string, stats *plan.ExecStats, dbg *queryDebug) {
+	status := map[string]any{
+		"error":   errtext,
+		"hits":    stats.CacheHits,
+		"misses":  stats.CacheMisses,
+		"scanned": stats.BytesScanned,
+	}
+	dbg.addJSON(status)
+	result := map[string]any{
+		"$sneller_final_status$": status,
+	}
+	json.NewEncoder(w).Encode(&result)
+}
+
+// queryDebug is the information added to the
+// final status of a query when the 'debug' query
+// parameter is present, so that a query can be
+// matched with the exact code and data it ran on
+type queryDebug struct {
+	version  string
+	planHash string
+	etags    []string
+}
+
+// newQueryDebug returns the debug information
+// for tree; planHash is the hash of the planning
+// environment that also identifies the query
+// results in the ETag of the response
+func newQueryDebug(tree *plan.Tree, planHash []byte) *queryDebug {
+	return &queryDebug{
+		version:  version,
+		planHash: hex.EncodeToString(planHash),
+		etags:    tree.ETags(),
+	}
+}
+
+// writeIon writes the fields of d into
+// the current structure, if d is non-nil
+func (d *queryDebug) writeIon(dst *ion.Buffer, st *ion.Symtab) {
+	if d == nil {
+		return
+	}
+	dst.BeginField(st.Intern("version"))
+	dst.WriteString(d.version)
+	dst.BeginField(st.Intern("plan_hash"))
+	dst.WriteString(d.planHash)
+	dst.BeginField(st.Intern("etags"))
+	dst.BeginList(-1)
+	for i := range d.etags {
+		dst.WriteString(d.etags[i])
+	}
+	dst.EndList()
+}
+
+// addJSON adds the fields of d to
+// status, if d is non-nil
+func (d *queryDebug) addJSON(status map[string]any) {
+	if d == nil {
+		return
+	}
+	etags := d.etags
+	if etags == nil {
+		etags = []string{}
+	}
+	status["version"] = d.version
+	status["plan_hash"] = d.planHash
+	status["etags"] = etags
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// errTooManyQueries is returned by limiter.acquire
+// when a tenant already runs the maximum number
+// of concurrent queries
+type errTooManyQueries struct {
+	max int
+	// err is the context error if the
+	// query timed out while it was queued
+	err error
+}
+
+func (e *errTooManyQueries) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("too many concurrent queries (limit %d): %s while queued", e.max, e.err)
+	}
+	return fmt.Sprintf("too many concurrent queries (limit %d)", e.max)
+}
+
+func (e *errTooManyQueries) Unwrap() error { return e.err }
+
+// tooManyQueries writes the response for a query
+// rejected by the limiter; the query may be retried
+// once the other queries of the tenant have finished
+func tooManyQueries(w http.ResponseWriter, err error) {
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, err.Error()+"; retry later", http.StatusTooManyRequests)
+}
+
+// limiter is a semaphore keyed by tenant ID
+// that limits the number of concurrent queries
+// of each tenant
+//
+// The zero value of limiter is ready to use.
+type limiter struct {
+	lock    sync.Mutex
+	tenants map[string]*tenantQueries
+}
+
+type tenantQueries struct {
+	running int
+	// waiting holds the queued queries in FIFO order;
+	// a slot is handed to a queued query by closing
+	// its channel
+	waiting []chan struct{}
+}
+
+// acquire waits for one of the max query slots of tenant.
+// If max queries are already running, the query is queued
+// if fewer than queue queries are already waiting and rejected
+// otherwise. A queued query waits at most until ctx is done.
+//
+// If max is zero or negative, acquire does not limit
+// the number of queries.
+//
+// On success, the returned function must be
+// called to release the slot. The error is always
+// an *errTooManyQueries.
+func (l *limiter) acquire(ctx context.Context, tenant string, max, queue int) (func(), error) {
+	if max <= 0 {
+		return func() {}, nil
+	}
+	l.lock.Lock()
+	if l.tenants == nil {
+		l.tenants = make(map[string]*tenantQueries)
+	}
+	tq := l.tenants[tenant]
+	if tq == nil {
+		tq = &tenantQueries{}
+		l.tenants[tenant] = tq
+	}
+	release := func() { l.release(tenant, tq) }
+	if tq.running < max && len(tq.waiting) == 0 {
+		tq.running++
+		l.lock.Unlock()
+		return release, nil
+	}
+	if len(tq.waiting) >= queue {
+		l.lock.Unlock()
+		return nil, &errTooManyQueries{max: max}
+	}
+	ready := make(chan struct{})
+	tq.waiting = append(tq.waiting, ready)
+	l.lock.Unlock()
+
+	select {
+	case <-ready:
+		return release, nil
+	case <-ctx.Done():
+	}
+	l.lock.Lock()
+	i := slices.Index(tq.waiting, ready)
+	if i >= 0 {
+		tq.waiting = slices.Delete(tq.waiting, i, i+1)
+	}
+	l.lock.Unlock()
+	if i < 0 {
+		// we were handed a slot concurrently
+		release()
+	}
+	return nil, &errTooManyQueries{max: max, err: ctx.Err()}
+}
+
+func (l *limiter) release(tenant string, tq *tenantQueries) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if len(tq.waiting) > 0 {
+		// hand the slot to the next query
+		close(tq.waiting[0])
+		tq.waiting = slices.Delete(tq.waiting, 0, 1)
+		return
+	}
+	tq.running--
+	if tq.running == 0 {
+		delete(l.tenants, tenant)
+	}
+}
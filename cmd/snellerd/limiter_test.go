@@ -0,0 +1,91 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	var l limiter
+	ctx := context.Background()
+	mustAcquire := func(tenant string, max, queue int) func() {
+		t.Helper()
+		release, err := l.acquire(ctx, tenant, max, queue)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return release
+	}
+	r0 := mustAcquire("a", 2, 1)
+	r1 := mustAcquire("a", 2, 1)
+	// other tenants have their own slots
+	mustAcquire("b", 2, 1)()
+
+	// the third query is queued
+	got := make(chan func())
+	go func() {
+		release, err := l.acquire(ctx, "a", 2, 1)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- release
+	}()
+	for {
+		l.lock.Lock()
+		n := len(l.tenants["a"].waiting)
+		l.lock.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// and the fourth is rejected
+	_, err := l.acquire(ctx, "a", 2, 1)
+	var tmq *errTooManyQueries
+	if !errors.As(err, &tmq) || tmq.max != 2 || tmq.err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	select {
+	case <-got:
+		t.Fatal("acquired a slot while the tenant is at the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+	r0()
+	r2 := <-got
+
+	// a queued query gives up when its context is done
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(tctx, "a", 2, 1)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &tmq) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	r1()
+	r2()
+	l.lock.Lock()
+	n := len(l.tenants)
+	l.lock.Unlock()
+	if n != 0 {
+		t.Errorf("%d tenants left after releasing all slots", n)
+	}
+	// no limit
+	for i := 0; i < 10; i++ {
+		mustAcquire("a", 0, 0)
+	}
+}
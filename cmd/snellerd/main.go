@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"github.com/SnellerInc/sneller"
+	"github.com/SnellerInc/sneller/vm"
 
 	"golang.org/x/sys/cpu"
 )
@@ -30,12 +31,22 @@ var version = "development"
 // This enables unit test specific behavior.
 var testmode = false
 
-func main() {
+// checkCPU exits if the query engine cannot
+// run on this machine. When portable is set,
+// the vm is switched to the (much slower) portable
+// interpreter instead, which does not require AVX-512.
+func checkCPU(portable bool) {
+	if portable {
+		vm.SetOptimizationLevel(vm.OptimizationLevelNone)
+		return
+	}
 	if !cpu.X86.HasAVX512 {
-		fmt.Fprintln(os.Stderr, "CPU doesn't support AVX-512")
+		fmt.Fprintln(os.Stderr, "CPU doesn't support AVX-512 (use -portable to run without it)")
 		os.Exit(1)
 	}
+}
 
+func main() {
 	args := os.Args[1:]
 	if len(args) > 0 {
 		switch args[0] {
@@ -72,6 +83,8 @@ func main() {
 			runDaemon(args)
 		case "worker":
 			runWorker(args)
+		case "filter":
+			runFilter(args)
 		default:
 			fmt.Fprintf(os.Stderr, "invalid sub-command '%v'\n", subCommand)
 			os.Exit(1)
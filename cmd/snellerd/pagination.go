@@ -0,0 +1,191 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/plan"
+)
+
+// maxPageSize is the largest number of
+// rows that may be requested with page_size
+const maxPageSize = 1 << 20
+
+// errStaleCursor is returned by page.check when
+// a cursor was issued for a different query or
+// for a different snapshot of the data
+var errStaleCursor = errors.New("cursor does not match this query or the data has changed")
+
+// pageCursor is the decoded form of the
+// opaque cursor passed in the 'cursor'
+// query parameter
+type pageCursor struct {
+	// Hash is the hash of the tenant, the query
+	// and the data the cursor was issued for
+	Hash []byte `json:"h"`
+	// Blob and Row are the scan position
+	// of the first row of the page
+	// (see plan.ScanPosition)
+	Blob int   `json:"b"`
+	Row  int64 `json:"r"`
+}
+
+// page describes the page of results
+// requested from a query
+type page struct {
+	size   int64
+	cursor *pageCursor // nil for the first page
+}
+
+// parsePage parses the 'page_size' and 'cursor'
+// query parameters; it returns a nil page if
+// pagination was not requested
+func parsePage(values url.Values) (*page, error) {
+	sizetext := values.Get("page_size")
+	cursortext := values.Get("cursor")
+	if sizetext == "" {
+		if cursortext != "" {
+			return nil, fmt.Errorf("'cursor' requires 'page_size'")
+		}
+		return nil, nil
+	}
+	size, err := strconv.ParseInt(sizetext, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing page_size: %w", err)
+	}
+	if size <= 0 || size > maxPageSize {
+		return nil, fmt.Errorf("page_size must be between 1 and %d", maxPageSize)
+	}
+	p := &page{size: size}
+	if cursortext != "" {
+		buf, err := base64.RawURLEncoding.DecodeString(cursortext)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		p.cursor = new(pageCursor)
+		if json.Unmarshal(buf, p.cursor) != nil || len(p.cursor.Hash) != sha256.Size ||
+			p.cursor.Blob < 0 || p.cursor.Row < 0 {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+	}
+	return p, nil
+}
+
+// plan returns the plan.Page for p
+func (p *page) plan() *plan.Page {
+	pg := &plan.Page{Size: p.size}
+	if p.cursor != nil {
+		pg.Start = plan.ScanPosition{Blob: p.cursor.Blob, Row: p.cursor.Row}
+	}
+	return pg
+}
+
+// checkPageable returns an error if the rows of q
+// do not have a stable order that pagination can
+// rely on
+func checkPageable(q *expr.Query) error {
+	if q.Explain != expr.ExplainNone || q.Describe || q.Delete || q.Into != nil {
+		return fmt.Errorf("pagination is only supported for SELECT queries")
+	}
+	sel, ok := q.Body.(*expr.Select)
+	if !ok {
+		return fmt.Errorf("pagination is not supported for UNION queries")
+	}
+	if sel.OrderBy != nil {
+		return fmt.Errorf("pagination is not supported for queries with ORDER BY; " +
+			"remove ORDER BY or use LIMIT and OFFSET instead")
+	}
+	if sel.Limit != nil || sel.Offset != nil {
+		return fmt.Errorf("pagination is not supported for queries with LIMIT or OFFSET")
+	}
+	if sel.GroupBy != nil || sel.HasDistinct() || hasAggregate(sel) {
+		return fmt.Errorf("pagination is not supported for queries with GROUP BY, DISTINCT or aggregates")
+	}
+	return nil
+}
+
+// hasAggregate returns whether the output
+// columns of s contain an aggregate
+func hasAggregate(s *expr.Select) bool {
+	found := false
+	visit := expr.WalkFunc(func(e expr.Node) bool {
+		if found {
+			return false
+		}
+		if _, ok := e.(*expr.Select); ok {
+			return false
+		}
+		if _, ok := e.(*expr.Aggregate); ok {
+			found = true
+			return false
+		}
+		return true
+	})
+	for i := range s.Columns {
+		expr.Walk(visit, s.Columns[i].Expr)
+	}
+	return found || s.Having != nil || s.Qualify != nil
+}
+
+// pageHash returns the hash identifying the
+// results that a cursor may continue:
+// the tenant, the query text and the hash
+// of the data it is executed on
+func pageHash(tenantID, query string, planHash []byte) []byte {
+	h := sha256.New()
+	io.WriteString(h, tenantID)
+	h.Write([]byte{0})
+	io.WriteString(h, query)
+	h.Write(planHash)
+	return h.Sum(nil)
+}
+
+// check returns errStaleCursor if the cursor
+// of p was not issued for the results identified
+// by hash
+func (p *page) check(hash []byte) error {
+	if p.cursor != nil && !bytes.Equal(p.cursor.Hash, hash) {
+		return errStaleCursor
+	}
+	return nil
+}
+
+// writeHash writes the page size and the
+// position of the page to h
+func (p *page) writeHash(h io.Writer) {
+	pg := p.plan()
+	fmt.Fprintf(h, "page:%d:%d:%d", pg.Size, pg.Start.Blob, pg.Start.Row)
+}
+
+// next returns the cursor for the page
+// that begins at the scan position pos
+func (p *page) next(hash []byte, pos *plan.ScanPosition) string {
+	buf, _ := json.Marshal(&pageCursor{
+		Hash: hash,
+		Blob: pos.Blob,
+		Row:  pos.Row,
+	})
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
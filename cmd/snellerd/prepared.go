@@ -0,0 +1,152 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// defaultMaxPrepared is the default maximum
+// number of prepared statements of each tenant
+const defaultMaxPrepared = 1000
+
+// preparedStatements holds the statements
+// prepared by 'PREPARE name AS ...' keyed by
+// tenant ID and statement name
+//
+// Only the parsed query is stored; the query
+// is planned each time it is executed.
+// The statements live in the memory of this
+// process, so they are not visible to other
+// snellerd instances and are lost on restart.
+//
+// The zero value of preparedStatements is ready to use.
+type preparedStatements struct {
+	// max, if positive, is the maximum number of
+	// statements of each tenant; otherwise the
+	// maximum is defaultMaxPrepared
+	max int
+
+	lock    sync.Mutex
+	tenants map[string]map[string]*expr.Query
+}
+
+// put stores the statement q prepared by tenant,
+// replacing the statement of the same name
+func (p *preparedStatements) put(tenant string, q *expr.Query) error {
+	max := p.max
+	if max <= 0 {
+		max = defaultMaxPrepared
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	stmts := p.tenants[tenant]
+	if stmts == nil {
+		if p.tenants == nil {
+			p.tenants = make(map[string]map[string]*expr.Query)
+		}
+		stmts = make(map[string]*expr.Query)
+		p.tenants[tenant] = stmts
+	}
+	if _, ok := stmts[q.Prepare]; !ok && len(stmts) >= max {
+		return fmt.Errorf("too many prepared statements (limit %d)", max)
+	}
+	prep := q.Clone()
+	prep.Prepare = ""
+	stmts[q.Prepare] = prep
+	return nil
+}
+
+// get returns the statement name prepared by tenant
+func (p *preparedStatements) get(tenant, name string) (*expr.Query, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	q, ok := p.tenants[tenant][name]
+	return q, ok
+}
+
+// remove removes the statement name prepared
+// by tenant and returns whether it existed
+func (p *preparedStatements) remove(tenant, name string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	stmts := p.tenants[tenant]
+	if _, ok := stmts[name]; !ok {
+		return false
+	}
+	delete(stmts, name)
+	if len(stmts) == 0 {
+		delete(p.tenants, tenant)
+	}
+	return true
+}
+
+// parseParams parses the JSON array of
+// parameter values passed in the 'params'
+// query parameter
+func parseParams(str string) ([]expr.Constant, error) {
+	if str == "" {
+		return nil, nil
+	}
+	var st ion.Symtab
+	d, err := ion.FromJSON(&st, json.NewDecoder(strings.NewReader(str)))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse params: %w", err)
+	}
+	lst, err := d.List()
+	if err != nil {
+		return nil, fmt.Errorf("params must be a JSON array")
+	}
+	var params []expr.Constant
+	err = lst.Each(func(d ion.Datum) error {
+		c, ok := expr.AsConstant(d)
+		if !ok {
+			return fmt.Errorf("cannot use %s as a parameter", d.Type())
+		}
+		params = append(params, c)
+		return nil
+	})
+	return params, err
+}
+
+// bindQuery returns the query to run for q:
+// the prepared statement executed by q with its
+// parameters bound to the values in its USING
+// clause or to params, or q itself with its
+// parameters bound to params
+func (s *server) bindQuery(tenant string, q *expr.Query, params []expr.Constant) (*expr.Query, error) {
+	if q.Execute != "" {
+		prep, ok := s.prepared.get(tenant, q.Execute)
+		if !ok {
+			return nil, fmt.Errorf("prepared statement %q does not exist", q.Execute)
+		}
+		if len(q.Using) > 0 {
+			if len(params) > 0 {
+				return nil, fmt.Errorf("cannot pass params to EXECUTE ... USING")
+			}
+			params = q.Using
+		}
+		q = prep
+	} else if len(params) == 0 && len(q.Params()) == 0 {
+		return q, nil
+	}
+	return q.Bind(params)
+}
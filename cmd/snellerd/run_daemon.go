@@ -41,10 +41,16 @@ func runDaemon(args []string) {
 	cgroupRoot := daemonCmd.String("cgroot", "", "delegated cgroup root for tenant processes")
 	peerExec := daemonCmd.String("x", "", "command to exec for fetching peers")
 	debugSock := daemonCmd.Int("debug", -1, "file descriptor to listen on for pprof debug activity")
+	portable := daemonCmd.Bool("portable", false, "use the portable interpreter instead of AVX-512 (slow)")
+	queryTimeout := daemonCmd.Duration("query-timeout", 0, "maximum execution time of a query (0 means no timeout)")
+	maxQueries := daemonCmd.Int("max-queries", 0, "maximum number of concurrent queries per tenant (0 means no limit)")
+	maxQueued := daemonCmd.Int("max-queued", 0, "maximum number of queries per tenant waiting for one of the -max-queries slots")
+	maxPrepared := daemonCmd.Int("max-prepared", defaultMaxPrepared, "maximum number of prepared statements per tenant")
 
 	if daemonCmd.Parse(args) != nil {
 		os.Exit(1)
 	}
+	checkCPU(*portable)
 	logger := log.New(os.Stdout, "", log.Lshortfile)
 
 	// if -debug=fd is provided, make /debug/pprof/* available
@@ -63,6 +69,14 @@ func runDaemon(args []string) {
 		sandbox:   tenant.CanSandbox(),
 		tenantcmd: []string{exe, "worker"},
 		peers:     noPeers{},
+
+		queryTimeout: *queryTimeout,
+		maxQueries:   *maxQueries,
+		maxQueued:    *maxQueued,
+		prepared:     preparedStatements{max: *maxPrepared},
+	}
+	if *portable {
+		server.tenantcmd = append(server.tenantcmd, "-portable")
 	}
 	httpl, err := net.Listen("tcp", *daemonEndpoint)
 	if err != nil {
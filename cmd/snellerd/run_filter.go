@@ -0,0 +1,192 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/expr/partiql"
+	"github.com/SnellerInc/sneller/ints"
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+	"github.com/SnellerInc/sneller/jsonrl"
+	"github.com/SnellerInc/sneller/plan"
+	"github.com/SnellerInc/sneller/vm"
+)
+
+// streamTable is the name of the table
+// that refers to the input of the filter
+const streamTable = "stdin"
+
+// streamFormat is the ObjectInfo.Format
+// of the input produced by streamEnv.Stat
+const streamFormat = "stream"
+
+// streamEnv is a plan.Env with a single
+// table (streamTable) that is read from a stream
+type streamEnv struct{}
+
+func (streamEnv) Stat(tbl expr.Node, h *plan.Hints) (*plan.Input, error) {
+	if id, ok := tbl.(expr.Ident); !ok || string(id) != streamTable {
+		return nil, fmt.Errorf("unknown table %s (the input is the table %s)", expr.ToString(tbl), streamTable)
+	}
+	// the input consists of a single block
+	// of unknown size without a sparse index
+	tr := blockfmt.Trailer{
+		Version:    1,
+		BlockShift: 20,
+		Blocks:     []blockfmt.Blockdesc{{Chunks: 1}},
+	}
+	return &plan.Input{
+		Descs: []plan.Descriptor{{
+			Descriptor: blockfmt.Descriptor{
+				ObjectInfo: blockfmt.ObjectInfo{
+					Path:   streamTable,
+					Format: streamFormat,
+				},
+				Trailer: tr,
+			},
+			Blocks: ints.Intervals{{Start: 0, End: 1}},
+		}},
+		Fields: h.Fields,
+	}, nil
+}
+
+// streamReader implements vm.Table by converting
+// the ion or NDJSON read from a stream to ion rows
+// as it is read, so the stream is never held in memory
+type streamReader struct {
+	src  io.Reader
+	read atomic.Bool
+	// n is the number of bytes read from src
+	n atomic.Int64
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	n, err := s.src.Read(p)
+	s.n.Add(int64(n))
+	return n, err
+}
+
+func (s *streamReader) WriteChunks(dst vm.QuerySink, parallel int) error {
+	if s.read.Swap(true) {
+		return fmt.Errorf("the table %s can only be read once", streamTable)
+	}
+	// the stream has to be read sequentially
+	return vm.SplitInput(dst, 1, func(w io.Writer) error {
+		cn := ion.Chunker{W: w, Align: vm.PageSize}
+		br := bufio.NewReader(s)
+		hdr, _ := br.Peek(4)
+		var err error
+		if ion.IsBVM(hdr) {
+			// ReadFrom keeps track of the symbol
+			// tables that appear in the stream
+			_, err = cn.ReadFrom(br, nil)
+		} else {
+			err = jsonrl.Convert(br, &cn, nil, nil)
+			if err == nil {
+				err = cn.Flush()
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			// the output was closed early
+			return nil
+		}
+		return err
+	})
+}
+
+// streamRunner is a plan.Runner that reads
+// the input produced by streamEnv.Stat from src
+type streamRunner struct {
+	src *streamReader
+}
+
+func (r *streamRunner) Run(dst vm.QuerySink, src *plan.Input, ep *plan.ExecParams) error {
+	for i := range src.Descs {
+		if src.Descs[i].Format != streamFormat {
+			return fmt.Errorf("cannot read %s", src.Descs[i].Path)
+		}
+	}
+	start := r.src.n.Load()
+	err := r.src.WriteChunks(dst, ep.Parallel)
+	atomic.AddInt64(&ep.Stats.BytesScanned, r.src.n.Load()-start)
+	return err
+}
+
+// filter executes query on the rows of
+// ion or NDJSON read from src and writes
+// the results to dst as ion
+func filter(dst io.Writer, src io.Reader, query []byte) error {
+	q, err := partiql.Parse(query)
+	if err != nil {
+		return fmt.Errorf("parsing query: %w", err)
+	}
+	if err := q.Check(); err != nil {
+		return err
+	}
+	tree, err := plan.New(q, streamEnv{})
+	if err != nil {
+		return fmt.Errorf("planning query: %w", err)
+	}
+	return plan.Exec(&plan.ExecParams{
+		Plan:   tree,
+		Output: dst,
+		Runner: &streamRunner{src: &streamReader{src: src}},
+	})
+}
+
+// runFilter executes a query on the rows
+// read from stdin and writes the results
+// to stdout, i.e.
+//
+//	cat data.json | snellerd filter 'SELECT ... FROM stdin'
+func runFilter(args []string) {
+	filterCmd := flag.NewFlagSet("filter", flag.ExitOnError)
+	format := filterCmd.String("fmt", "json", "output format (json, ion)")
+	portable := filterCmd.Bool("portable", false, "use the portable interpreter instead of AVX-512 (slow)")
+	if filterCmd.Parse(args) != nil {
+		os.Exit(1)
+	}
+	if filterCmd.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "usage: snellerd filter [-fmt json|ion] [-portable] <query>\n")
+		os.Exit(1)
+	}
+	checkCPU(*portable)
+
+	// the results are written as they are produced
+	// rather than once all of the input has been read
+	var dst io.Writer = os.Stdout
+	switch *format {
+	case "json":
+		dst = ion.NewJSONWriter(os.Stdout, '\n')
+	case "ion":
+	default:
+		fmt.Fprintf(os.Stderr, "unsupported output format %q\n", *format)
+		os.Exit(1)
+	}
+	err := filter(dst, os.Stdin, []byte(filterCmd.Arg(0)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+func TestFilter(t *testing.T) {
+	// two ion streams, each with its own symbol table,
+	// one after the other as they would be if the
+	// output of two commands were concatenated
+	var ionin bytes.Buffer
+	for _, fields := range [][]string{{"x", "y"}, {"y", "z", "x"}} {
+		var buf ion.Buffer
+		var st ion.Symtab
+		for i, f := range fields {
+			buf.BeginStruct(-1)
+			buf.BeginField(st.Intern(f))
+			buf.WriteInt(int64(i))
+			buf.EndStruct()
+		}
+		var hdr ion.Buffer
+		st.Marshal(&hdr, true)
+		ionin.Write(hdr.Bytes())
+		ionin.Write(buf.Bytes())
+	}
+	var ndjson strings.Builder
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&ndjson, "{\"n\": %d, \"s\": \"row %d\"}\n", i, i)
+	}
+
+	testcases := []struct {
+		input  io.Reader
+		query  string
+		expect string
+	}{
+		{
+			input:  &ionin,
+			query:  "SELECT x FROM stdin WHERE x IS NOT MISSING",
+			expect: "{\"x\": 0}\n{\"x\": 2}\n",
+		},
+		{
+			input:  strings.NewReader(ndjson.String()),
+			query:  "SELECT COUNT(*), SUM(n) FROM stdin WHERE s LIKE 'row 1%'",
+			expect: "{\"count\": 1111, \"sum\": 1514596}\n",
+		},
+		{
+			input:  strings.NewReader(""),
+			query:  "SELECT COUNT(*) FROM stdin",
+			expect: "{\"count\": 0}\n",
+		},
+	}
+	for i := range testcases {
+		tc := &testcases[i]
+		var out bytes.Buffer
+		err := filter(ion.NewJSONWriter(&out, '\n'), tc.input, []byte(tc.query))
+		if err != nil {
+			t.Fatalf("%s: %s", tc.query, err)
+		}
+		if out.String() != tc.expect {
+			t.Errorf("%s: got %q, want %q", tc.query, out.String(), tc.expect)
+		}
+	}
+
+	err := filter(io.Discard, strings.NewReader("{}"), []byte("SELECT * FROM other"))
+	if err == nil || !strings.Contains(err.Error(), "unknown table") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
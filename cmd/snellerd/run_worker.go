@@ -26,6 +26,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -52,9 +53,11 @@ func runWorker(args []string) {
 	workerTenant := workerCmd.String("t", "", "tenant identifier")
 	workerControlSocket := workerCmd.Int("c", -1, "control socket")
 	eventfd := workerCmd.Int("e", -1, "eventfd")
+	portable := workerCmd.Bool("portable", false, "use the portable interpreter instead of AVX-512 (slow)")
 	if workerCmd.Parse(args) != nil {
 		os.Exit(1)
 	}
+	checkCPU(*portable)
 
 	if *workerControlSocket == -1 {
 		panic("no control socket file descriptor")
@@ -97,6 +100,7 @@ func runWorker(args []string) {
 	run := sneller.TenantRunner{
 		Events: evfd,
 	}
+	spilldir := ""
 	if cachedir := os.Getenv("CACHEDIR"); cachedir != "" {
 		info, err := os.Stat(cachedir)
 		if err != nil || !info.IsDir() {
@@ -110,6 +114,14 @@ func runWorker(args []string) {
 				return ucred.Uid == 0
 			}
 			debug.Path(filepath.Join(cachedir, "debug.sock"), ok, logger)
+
+			// high-cardinality aggregates spill
+			// their groups next to the cache
+			spilldir = filepath.Join(cachedir, "spill")
+			if err := os.MkdirAll(spilldir, 0750); err != nil {
+				logger.Printf("cannot create spill dir: %s", err)
+				spilldir = ""
+			}
 		}
 	}
 
@@ -138,10 +150,31 @@ func runWorker(args []string) {
 		s3fs.Client = s3client
 		return s3fs, nil
 	}
+	// QUERY_THREADS, if set, caps the number
+	// of threads used to execute each query
+	threads := 0
+	if str := os.Getenv("QUERY_THREADS"); str != "" {
+		threads, err = strconv.Atoi(str)
+		if err != nil || threads < 0 {
+			logger.Printf("ignoring invalid QUERY_THREADS %q", str)
+			threads = 0
+		}
+	}
+	// PREFETCH, if set, is the number of blocks
+	// fetched ahead of the blocks being scanned
+	if str := os.Getenv("PREFETCH"); str != "" {
+		run.Prefetch, err = strconv.Atoi(str)
+		if err != nil || run.Prefetch < 0 {
+			logger.Printf("ignoring invalid PREFETCH %q", str)
+			run.Prefetch = 0
+		}
+	}
 	srv := tnproto.Server{
 		Server: plan.Server{
-			Runner: &run,
-			InitFS: initfs,
+			Runner:   &run,
+			InitFS:   initfs,
+			Threads:  threads,
+			SpillDir: spilldir,
 		},
 		Logf: logger.Printf,
 	}
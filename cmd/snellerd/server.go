@@ -46,6 +46,21 @@ type server struct {
 	peers peerlist
 	auth  auth.Provider
 
+	// queryTimeout, if non-zero, is the maximum
+	// wall-clock time for executing a query
+	queryTimeout time.Duration
+
+	// maxQueries, if positive, is the default maximum
+	// number of concurrent queries of each tenant;
+	// up to maxQueued more queries wait for a slot
+	// and the rest are rejected
+	maxQueries, maxQueued int
+	limit                 limiter
+
+	// prepared holds the statements prepared
+	// by each tenant with PREPARE
+	prepared preparedStatements
+
 	// when we encounter an error
 	// listing peers, we fall back to
 	// this list (assuming it is non-nil)
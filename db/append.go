@@ -0,0 +1,57 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+// Append packs the inputs in lst into the table
+// db/table and commits them to the table index,
+// creating the index if it does not exist yet.
+// The inputs are converted exactly as they would
+// be by Sync, but Append does not consult the
+// input patterns of the table definition, so it
+// can be used to populate a table with data that
+// isn't stored in object storage (for example, the
+// results of a query). Inputs that have already
+// been ingested into the table are skipped.
+//
+// Append cannot be used with tables that are
+// partitioned on components of the input path.
+//
+// Like Sync, Append returns ErrBuildAgain if the
+// index is being rebuilt by a scan; in that case
+// none of the inputs have been read, and the caller
+// should call Append again.
+func (c *Config) Append(who Tenant, db, table string, lst []blockfmt.Input) error {
+	st, err := c.open(db, table, who)
+	if err != nil {
+		return err
+	}
+	for i := range st.def.Partitions {
+		if st.def.Partitions[i].Expr == "" {
+			return fmt.Errorf("append to %s/%s: table is partitioned by input path", db, table)
+		}
+	}
+	ti := &tableInfo{state: *st}
+	return ti.append(context.Background(), []partition{{
+		prepend: -1,
+		lst:     lst,
+	}})
+}
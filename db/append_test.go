@@ -468,3 +468,71 @@ func TestAppendBadScan(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestConfigAppend(t *testing.T) {
+	checkFiles(t)
+	tmpdir := t.TempDir()
+	dfs := newDirFS(t, tmpdir)
+	owner := newTenant(dfs)
+	c := Config{Align: 1024}
+
+	input := func() []blockfmt.Input {
+		f, err := os.Open("../testdata/parking.10n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []blockfmt.Input{{
+			Path: "results/parking.10n",
+			ETag: "etag0",
+			Size: info.Size(),
+			R:    f,
+			F:    blockfmt.UnsafeION(),
+		}}
+	}
+	err := c.Append(owner, "default", "results", input())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIndex(dfs, "default", "results", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Objects() != 1 {
+		t.Fatalf("got %d objects", idx.Objects())
+	}
+	checkContents(t, idx, dfs)
+	checkNoGarbage(t, dfs, "db/default/results", idx)
+
+	// the same input should not be ingested twice
+	lst := input()
+	err = c.Append(owner, "default", "results", lst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lst[0].R.Close()
+	idx, err = OpenIndex(dfs, "default", "results", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Objects() != 1 {
+		t.Errorf("got %d objects after re-appending", idx.Objects())
+	}
+
+	// tables partitioned by input path are rejected
+	err = WriteDefinition(dfs, "default", "parts", &Definition{
+		Partitions: []Partition{{Field: "region"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lst = input()
+	err = c.Append(owner, "default", "parts", lst)
+	lst[0].R.Close()
+	if err == nil {
+		t.Error("expected an error appending to a table partitioned by path")
+	}
+}
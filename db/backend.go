@@ -0,0 +1,131 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Backend is an object store that can be
+// used as the root of a Tenant.
+//
+// Backends are registered under a URL scheme
+// with RegisterBackend and are looked up by
+// OpenTenant.
+type Backend interface {
+	// Tenant should return a Tenant whose root
+	// is the location described by root, which
+	// is the URL with the "scheme://" prefix removed.
+	Tenant(ctx context.Context, root string) (Tenant, error)
+}
+
+// BackendFunc is a function that implements Backend.
+type BackendFunc func(ctx context.Context, root string) (Tenant, error)
+
+// Tenant implements Backend.Tenant
+func (f BackendFunc) Tenant(ctx context.Context, root string) (Tenant, error) {
+	return f(ctx, root)
+}
+
+var (
+	backendLock sync.RWMutex
+	backends    = map[string]Backend{
+		"file": BackendFunc(func(_ context.Context, root string) (Tenant, error) {
+			return NewLocalTenantFromPath(root), nil
+		}),
+	}
+)
+
+// RegisterBackend registers b as the Backend
+// for URLs beginning with "scheme://".
+// The "file" scheme is registered by default.
+// RegisterBackend panics if a backend has
+// already been registered for scheme.
+func RegisterBackend(scheme string, b Backend) {
+	backendLock.Lock()
+	defer backendLock.Unlock()
+	if _, ok := backends[scheme]; ok {
+		panic("db.RegisterBackend: duplicate scheme " + scheme)
+	}
+	backends[scheme] = b
+}
+
+// unregisterBackend removes the backend for scheme;
+// it is used by tests to undo RegisterBackend
+func unregisterBackend(scheme string) {
+	backendLock.Lock()
+	defer backendLock.Unlock()
+	delete(backends, scheme)
+}
+
+// Schemes returns the sorted list of
+// schemes that have a registered Backend.
+func Schemes() []string {
+	backendLock.RLock()
+	defer backendLock.RUnlock()
+	out := make([]string, 0, len(backends))
+	for s := range backends {
+		out = append(out, s)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// OpenTenant returns a Tenant rooted at the
+// location given by url using the Backend
+// registered for the scheme of url.
+// If url does not have a scheme, it is
+// interpreted as a local directory path.
+func OpenTenant(ctx context.Context, url string) (Tenant, error) {
+	scheme, root, ok := strings.Cut(url, "://")
+	if !ok {
+		scheme, root = "file", url
+	}
+	backendLock.RLock()
+	b, ok := backends[scheme]
+	backendLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no backend for scheme %q (have %s)",
+			scheme, strings.Join(Schemes(), ", "))
+	}
+	return b.Tenant(ctx, root)
+}
+
+// ETagMatcher can be implemented by an InputFS
+// for which ETags are not always byte-for-byte
+// identical when they refer to the same contents
+// (for example, some S3-compatible stores do not
+// return the same ETag from an upload that they
+// return from a subsequent HEAD request).
+type ETagMatcher interface {
+	// MatchETag should return whether or not an
+	// object with the ETag got is the same object
+	// as the one that was expected to have ETag want.
+	MatchETag(want, got string) bool
+}
+
+// matchETag compares ETags using the
+// semantics of infs if it implements ETagMatcher
+// and exact string comparison otherwise
+func matchETag(infs InputFS, want, got string) bool {
+	if m, ok := infs.(ETagMatcher); ok {
+		return m.MatchETag(want, got)
+	}
+	return want == got
+}
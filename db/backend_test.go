@@ -0,0 +1,95 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOpenTenant(t *testing.T) {
+	dir := t.TempDir()
+	for _, url := range []string{dir, "file://" + dir} {
+		tenant, err := OpenTenant(context.Background(), url)
+		if err != nil {
+			t.Fatalf("%s: %s", url, err)
+		}
+		root, err := tenant.Root()
+		if err != nil {
+			t.Fatal(err)
+		}
+		dfs, ok := root.(*DirFS)
+		if !ok {
+			t.Fatalf("%s: root is %T", url, root)
+		}
+		if dfs.Root != dir {
+			t.Errorf("%s: root is %q", url, dfs.Root)
+		}
+	}
+
+	var got string
+	t.Cleanup(func() { unregisterBackend("test") })
+	RegisterBackend("test", BackendFunc(func(_ context.Context, root string) (Tenant, error) {
+		got = root
+		return NewLocalTenantFromPath(dir), nil
+	}))
+	if !slices.Contains(Schemes(), "test") {
+		t.Errorf("scheme missing from %v", Schemes())
+	}
+	_, err := OpenTenant(context.Background(), "test://bucket/prefix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "bucket/prefix" {
+		t.Errorf("backend got root %q", got)
+	}
+
+	_, err = OpenTenant(context.Background(), "nope://bucket")
+	if err == nil || !strings.Contains(err.Error(), `"nope"`) {
+		t.Errorf("unexpected error for unknown scheme: %v", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("duplicate RegisterBackend did not panic")
+		}
+	}()
+	RegisterBackend("file", BackendFunc(nil))
+}
+
+func TestMatchETag(t *testing.T) {
+	dfs := NewDirFS(t.TempDir())
+	if matchETag(dfs, `"abc"`, "abc") {
+		t.Error("DirFS should compare ETags exactly")
+	}
+	s3fs := &S3FS{}
+	for _, tc := range []struct {
+		want, got string
+		match     bool
+	}{
+		{`"abc"`, `"abc"`, true},
+		{`"abc"`, "abc", true},
+		{`W/"abc"`, `"abc"`, true},
+		{`"ABC-2"`, `"abc-2"`, true},
+		{`"abc"`, `"abd"`, false},
+		{`"abc-2"`, `"abc"`, false},
+	} {
+		if got := matchETag(s3fs, tc.want, tc.got); got != tc.match {
+			t.Errorf("matchETag(%s, %s) = %v", tc.want, tc.got, got)
+		}
+	}
+}
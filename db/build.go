@@ -76,7 +76,7 @@ func getInfo(dst OutputFS, fp string, out blockfmt.Uploader) (string, time.Time,
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("getting ETag: %w", err)
 	}
-	if e, ok := out.(etagger); ok && e.ETag() != etag {
+	if e, ok := out.(etagger); ok && !matchETag(dst, e.ETag(), etag) {
 		return "", time.Time{}, fmt.Errorf("etag %s from Stat disagrees with etag %s", etag, e.ETag())
 	}
 	return etag, lm, nil
@@ -73,8 +73,9 @@ type RetentionPolicy struct {
 }
 
 // A Partition defines a synthetic field that is
-// generated from parts of an input URI and used
-// to partition table data.
+// generated from parts of an input URI or from
+// an expression over each row and used to
+// partition table data.
 type Partition struct {
 	// Field is the name of the partition field. If
 	// this field conflicts with a field in the
@@ -92,6 +93,20 @@ type Partition struct {
 	// determine the input URI part that will be
 	// used to determine the value.
 	Value string `json:"value,omitempty"`
+	// Expr, if non-empty, is a PartiQL expression
+	// that is evaluated for each row during ingestion
+	// to produce the value for the partition field,
+	// for example "DATE_TRUNC(DAY, ts)". Expr is
+	// mutually exclusive with Type and Value.
+	//
+	// Rows for which Expr evaluates to NULL or
+	// MISSING are placed in a partition with
+	// a NULL partition field, which cannot be
+	// skipped by queries filtering on the fields
+	// referenced by Expr. Expression partitions
+	// follow the template partitions in the path
+	// of the packed objects.
+	Expr string `json:"expr,omitempty"`
 }
 
 // Definition describes the set of input files
@@ -115,6 +130,12 @@ type Definition struct {
 	// to skip scanning the source bucket(s) for matching
 	// objects when the first objects are inserted into the table.
 	SkipBackfill bool `json:"skip_backfill,omitempty"`
+	// HotFields is a list of top-level fields
+	// that are expected to be queried often;
+	// their data is compressed for cheaper
+	// decompression at the expense of the
+	// compression ratio.
+	HotFields []string `json:"hot_fields,omitempty"`
 }
 
 // just pick an upper limit to prevent DoS
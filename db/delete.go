@@ -0,0 +1,289 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+// Delete deletes the rows of a table
+// for which where evaluates to TRUE.
+//
+// The rows are not removed from the packed
+// objects of the table. Instead, a tombstone
+// is added to the index, and queries exclude
+// the matching rows as they are scanned
+// (see blockfmt.Tombstone). The tombstone
+// applies to the objects of the table at
+// the time of the call to Delete, so rows that
+// are ingested later are not deleted. The rows
+// are removed from the objects when the table
+// is compacted with Compact.
+//
+// The tombstone is committed with a single write
+// of the index, so every query planned after
+// Delete returns excludes the deleted rows.
+// Delete fails without modifying the table if
+// the index is updated concurrently.
+func (c *Config) Delete(who Tenant, db, table string, where expr.Node) error {
+	err := checkDelete(where)
+	if err != nil {
+		return err
+	}
+	st, err := c.open(db, table, who)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	idx, err := st.index(ctx)
+	if err != nil {
+		return err
+	}
+	descs, err := idx.Indirect.Search(st.ofs, nil)
+	if err != nil {
+		return err
+	}
+	descs = append(descs, idx.Inline...)
+	objects := make([]string, len(descs))
+	for i := range descs {
+		objects[i] = descs[i].Path
+	}
+	slices.Sort(objects)
+	now := date.Now().Truncate(time.Microsecond)
+	idx.Tombstones = append(idx.Tombstones, blockfmt.Tombstone{
+		Created: now,
+		Where:   where,
+		Objects: objects,
+	})
+	// queries cached by the time the index
+	// was created have to be invalidated
+	idx.Created = now
+	return st.flush(ctx, idx)
+}
+
+// deleted returns whether the rows of the object
+// at path p are deleted by any of the tombstones
+// of idx, in which case the object must not be
+// merged with objects that aren't covered by
+// exactly the same tombstones
+func deleted(idx *blockfmt.Index, p string) bool {
+	for i := range idx.Tombstones {
+		if idx.Tombstones[i].Covers(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDelete checks that a Delete
+// condition depends only on the row
+func checkDelete(where expr.Node) error {
+	if where == nil {
+		return fmt.Errorf("delete: missing condition")
+	}
+	var err error
+	visit := expr.WalkFunc(func(e expr.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch e := e.(type) {
+		case *expr.Select:
+			err = fmt.Errorf("delete: sub-queries are not supported")
+		case *expr.Aggregate:
+			err = fmt.Errorf("delete: aggregate %s is not supported", expr.ToString(e))
+		case *expr.Param:
+			err = fmt.Errorf("delete: parameter %s is not bound", expr.ToString(e))
+		}
+		return err == nil
+	})
+	expr.Walk(visit, where)
+	if err != nil {
+		return err
+	}
+	return expr.Check(where)
+}
+
+// Compact removes the rows deleted by Delete
+// from the objects of a table and removes the
+// tombstones from the index.
+//
+// Compact calls rewrite for each object of the table
+// that is covered by a tombstone, and rewrite returns
+// an input containing the rows of the object that have
+// not been deleted, or an input with a nil R if every
+// row of the object has been deleted. (Typically, the
+// input holds the results of a query of just that
+// object, since queries exclude the deleted rows.)
+// Each input is packed into a new object in the same
+// partition as the object it replaces, so compacting
+// a table does not change its partitioning or the
+// sizes of the objects that are not covered by a
+// tombstone. The objects are replaced in a single write
+// of the index, and the objects that are replaced are
+// marked for deletion. If the index is updated while
+// rewrite is running, Compact fails without modifying
+// the table.
+//
+// The column statistics of the table are only
+// recomputed if every object of the table has been
+// rewritten; otherwise they still describe the rows
+// that have been deleted.
+//
+// Compact does nothing if the table has no tombstones.
+func (c *Config) Compact(who Tenant, db, table string, rewrite func(idx *blockfmt.Index, desc *blockfmt.Descriptor) (blockfmt.Input, error)) error {
+	st, err := c.open(db, table, who)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	idx, err := st.index(ctx)
+	if err != nil {
+		return err
+	}
+	if len(idx.Tombstones) == 0 {
+		return nil
+	}
+	descs, err := idx.Indirect.Search(st.ofs, nil)
+	if err != nil {
+		return err
+	}
+	descs = append(descs, idx.Inline...)
+	dict := st.dictionary(idx)
+	expiry := date.Now().Add(st.conf.GCMinimumAge)
+	quarantine := func(p string) {
+		idx.ToDelete = append(idx.ToDelete, blockfmt.Quarantined{
+			Expiry: expiry,
+			Path:   p,
+		})
+	}
+	var stats *blockfmt.Stats
+	if st.conf.CollectStats {
+		stats = new(blockfmt.Stats)
+	}
+	uncovered := 0
+	kept := make([]blockfmt.Descriptor, 0, len(descs))
+	for i := range descs {
+		if !deleted(idx, descs[i].Path) {
+			kept = append(kept, descs[i])
+			uncovered++
+			continue
+		}
+		in, err := rewrite(idx, &descs[i])
+		if err != nil {
+			st.invalidate()
+			return err
+		}
+		quarantine(descs[i].Path)
+		if in.R == nil {
+			// every row was deleted
+			continue
+		}
+		name, ok := st.partitionFor(descs[i].Path)
+		if !ok {
+			in.R.Close()
+			return fmt.Errorf("compact %s/%s: unexpected object path %s", db, table, descs[i].Path)
+		}
+		part := partition{
+			name:    name,
+			prepend: -1,
+			cons:    descs[i].Trailer.Sparse.Constants(),
+			lst:     []blockfmt.Input{in},
+		}
+		var out blockfmt.Descriptor
+		var schema blockfmt.Schema
+		err = st.forcePart(ctx, nil, &out, &part, dict, &schema, stats)
+		if err != nil {
+			st.invalidate()
+			return fmt.Errorf("compact %s/%s: rewriting %s: %w", db, table, descs[i].Path, err)
+		}
+		kept = append(kept, out)
+	}
+	for i := range idx.Indirect.Refs {
+		quarantine(idx.Indirect.Refs[i].Path)
+	}
+	idx.Indirect = blockfmt.IndirectTree{}
+	idx.Inline = kept
+	idx.Tombstones = nil
+	if uncovered == 0 {
+		idx.Stats = blockfmt.Stats{}
+		if stats != nil {
+			idx.Stats.Merge(stats)
+		}
+	}
+	idx.Created = date.Now().Truncate(time.Microsecond)
+	return st.refill(ctx, idx)
+}
+
+// byTombstones groups descs by the set of
+// tombstones of idx that cover them,
+// preserving the order of descs
+func byTombstones(idx *blockfmt.Index, descs []blockfmt.Descriptor) [][]blockfmt.Descriptor {
+	if len(idx.Tombstones) == 0 {
+		return [][]blockfmt.Descriptor{descs}
+	}
+	var keys []string
+	groups := make(map[string][]blockfmt.Descriptor)
+	var key []byte
+	for i := range descs {
+		key = key[:0]
+		for j := range idx.Tombstones {
+			if idx.Tombstones[j].Covers(descs[i].Path) {
+				key = binary.AppendUvarint(key, uint64(j))
+			}
+		}
+		if _, ok := groups[string(key)]; !ok {
+			keys = append(keys, string(key))
+		}
+		groups[string(key)] = append(groups[string(key)], descs[i])
+	}
+	out := make([][]blockfmt.Descriptor, len(keys))
+	for i := range keys {
+		out[i] = groups[keys[i]]
+	}
+	return out
+}
+
+// replaceObjects replaces the objects in old with
+// the objects in new in the tombstones of idx that
+// cover old, which must all be covered by the same
+// tombstones (see byTombstones)
+func replaceObjects(idx *blockfmt.Index, old, new []blockfmt.Descriptor) {
+	gone := make(map[string]bool, len(old))
+	for i := range old {
+		gone[old[i].Path] = true
+	}
+	for i := range idx.Tombstones {
+		t := &idx.Tombstones[i]
+		if !t.Covers(old[0].Path) {
+			continue
+		}
+		t.Objects = slices.DeleteFunc(t.Objects, func(p string) bool {
+			return gone[p]
+		})
+		for j := range new {
+			t.Objects = append(t.Objects, new[j].Path)
+		}
+		slices.Sort(t.Objects)
+		t.Objects = slices.Compact(t.Objects)
+	}
+}
@@ -0,0 +1,317 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+func TestDelete(t *testing.T) {
+	dfs, owner, c := setupTruncate(t)
+	before, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, where := range []expr.Node{
+		expr.Compare(expr.Equals, &expr.Param{Index: 1}, expr.Integer(1)),
+		expr.Compare(expr.Equals, expr.Ident("x"), &expr.Aggregate{Op: expr.OpCount, Inner: expr.Star{}}),
+	} {
+		err := c.Delete(owner, "default", "taxi", where)
+		if err == nil {
+			t.Errorf("Delete(%s) succeeded", expr.ToString(where))
+		}
+	}
+
+	vendor := expr.Compare(expr.Equals, expr.Ident("VendorID"), expr.String("VTS"))
+	fare := expr.Compare(expr.Greater, expr.Ident("fare_amount"), expr.Integer(100))
+	for _, where := range []expr.Node{vendor, fare} {
+		err = c.Delete(owner, "default", "taxi", where)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Tombstones) != 2 {
+		t.Fatalf("got %d tombstones", len(idx.Tombstones))
+	}
+	if !idx.Created.After(before.Created) {
+		t.Errorf("index creation time %s not updated", idx.Created)
+	}
+	if idx.Objects() != before.Objects() {
+		t.Errorf("%d objects after delete; expected %d", idx.Objects(), before.Objects())
+	}
+	del := idx.Deleted()
+	if len(del) != 2 || !del[0].Where.Equals(vendor) || !del[1].Where.Equals(fare) {
+		t.Fatalf("unexpected tombstones %v", del)
+	}
+	// tombstones cover every object
+	// present when they were created
+	paths := objectPaths(t, dfs, idx)
+	for i := range del {
+		if !slices.Equal(del[i].Objects, paths) {
+			t.Errorf("tombstone %d covers %v; expected %v", i, del[i].Objects, paths)
+		}
+	}
+}
+
+// objectPaths returns the sorted paths
+// of all the objects in idx
+func objectPaths(t *testing.T, dfs *DirFS, idx *blockfmt.Index) []string {
+	descs, err := idx.Indirect.Search(dfs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	descs = append(descs, idx.Inline...)
+	paths := make([]string, len(descs))
+	for i := range descs {
+		paths[i] = descs[i].Path
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+func appendRows(t *testing.T, c *Config, owner Tenant, name, rows string) {
+	err := c.Append(owner, "default", "taxi", []blockfmt.Input{{
+		Path: name,
+		ETag: name,
+		Size: int64(len(rows)),
+		R:    io.NopCloser(strings.NewReader(rows)),
+		F:    blockfmt.MustSuffixToFormat(".json"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteScope(t *testing.T) {
+	dfs, owner, c := setupTruncate(t)
+	where := expr.Compare(expr.Equals, expr.Ident("VendorID"), expr.String("VTS"))
+	err := c.Delete(owner, "default", "taxi", where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	covered := objectPaths(t, dfs, before)
+
+	// rows ingested after the delete
+	// must not be deleted by it
+	appendRows(t, c, owner, "later.json", `{"VendorID": "VTS", "fare_amount": 3}`+"\n")
+	idx, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Tombstones) != 1 {
+		t.Fatalf("got %d tombstones", len(idx.Tombstones))
+	}
+	if !slices.Equal(idx.Tombstones[0].Objects, covered) {
+		t.Errorf("tombstone covers %v after append; expected %v", idx.Tombstones[0].Objects, covered)
+	}
+	paths := objectPaths(t, dfs, idx)
+	if len(paths) != len(covered)+1 {
+		t.Fatalf("got objects %v; expected %v plus one", paths, covered)
+	}
+	for _, p := range covered {
+		// covered objects must not be
+		// merged with the new rows
+		if _, ok := slices.BinarySearch(paths, p); !ok {
+			t.Errorf("covered object %s was replaced", p)
+		}
+	}
+	for _, p := range paths {
+		if _, ok := slices.BinarySearch(covered, p); !ok && deleted(idx, p) {
+			t.Errorf("new object %s is covered by the tombstone", p)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dfs, owner, c := setupTruncate(t)
+	// without tombstones there is nothing to do
+	err := c.Compact(owner, "default", "taxi", func(*blockfmt.Index, *blockfmt.Descriptor) (blockfmt.Input, error) {
+		t.Fatal("rewrite called without tombstones")
+		return blockfmt.Input{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the original objects are covered by both
+	// tombstones, the second object only by the
+	// second one, and the last one by neither
+	vendor := expr.Compare(expr.Equals, expr.Ident("VendorID"), expr.String("VTS"))
+	fare := expr.Compare(expr.Greater, expr.Ident("fare_amount"), expr.Integer(100))
+	err = c.Delete(owner, "default", "taxi", vendor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRows(t, c, owner, "second.json", `{"VendorID": "CMT", "fare_amount": 200}`+"\n")
+	err = c.Delete(owner, "default", "taxi", fare)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRows(t, c, owner, "third.json", `{"VendorID": "VTS", "fare_amount": 300}`+"\n")
+	before, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := before.Tombstones[1].Objects
+	kept := slices.DeleteFunc(objectPaths(t, dfs, before), func(p string) bool {
+		_, ok := slices.BinarySearch(old, p)
+		return ok
+	})
+	if len(kept) != 1 {
+		t.Fatalf("unexpected uncovered objects %v", kept)
+	}
+
+	var rewritten []string
+	rewrite := func(idx *blockfmt.Index, desc *blockfmt.Descriptor) (blockfmt.Input, error) {
+		rewritten = append(rewritten, desc.Path)
+		if len(idx.Tombstones) != 2 {
+			return blockfmt.Input{}, fmt.Errorf("%d tombstones", len(idx.Tombstones))
+		}
+		if len(rewritten) > 1 {
+			// every row was deleted
+			return blockfmt.Input{}, nil
+		}
+		// stand-in for the rows of the
+		// object that haven't been deleted
+		rows := `{"VendorID": "CMT", "fare_amount": 3}` + "\n"
+		return blockfmt.Input{
+			Path: desc.Path,
+			ETag: desc.ETag,
+			Size: int64(len(rows)),
+			R:    io.NopCloser(strings.NewReader(rows)),
+			F:    blockfmt.MustSuffixToFormat(".json"),
+		}, nil
+	}
+	err = c.Compact(owner, "default", "taxi", rewrite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(rewritten)
+	if !slices.Equal(rewritten, old) {
+		t.Errorf("rewrote %v; expected %v", rewritten, old)
+	}
+	idx, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Tombstones) != 0 {
+		t.Errorf("%d tombstones after compaction", len(idx.Tombstones))
+	}
+	paths := objectPaths(t, dfs, idx)
+	if len(paths) != 2 {
+		t.Fatalf("unexpected objects after compaction: %v", paths)
+	}
+	for _, p := range paths {
+		if _, ok := slices.BinarySearch(old, p); ok {
+			t.Errorf("object %s not rewritten", p)
+		}
+	}
+	if _, ok := slices.BinarySearch(paths, kept[0]); !ok {
+		t.Errorf("uncovered object %s not kept", kept[0])
+	}
+	// the statistics still include the
+	// object that hasn't been rewritten
+	if idx.Stats.Rows != before.Stats.Rows {
+		t.Errorf("%d rows in statistics after compaction; expected %d", idx.Stats.Rows, before.Stats.Rows)
+	}
+	for _, p := range old {
+		found := false
+		for i := range idx.ToDelete {
+			found = found || idx.ToDelete[i].Path == p
+		}
+		if !found {
+			t.Errorf("%s not marked for deletion", p)
+		}
+	}
+	// the inputs are retained,
+	// so nothing is ingested again
+	noScan(t, c, owner, "default", "taxi")
+}
+
+func TestCompactAll(t *testing.T) {
+	dfs, owner, c := setupTruncate(t)
+	where := expr.Compare(expr.Equals, expr.Ident("VendorID"), expr.String("VTS"))
+	err := c.Delete(owner, "default", "taxi", where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	err = c.Compact(owner, "default", "taxi", func(_ *blockfmt.Index, desc *blockfmt.Descriptor) (blockfmt.Input, error) {
+		n++
+		rows := `{"VendorID": "CMT", "fare_amount": 3}` + "\n"
+		return blockfmt.Input{
+			Path: desc.Path,
+			ETag: desc.ETag,
+			Size: int64(len(rows)),
+			R:    io.NopCloser(strings.NewReader(rows)),
+			F:    blockfmt.MustSuffixToFormat(".json"),
+		}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every object has been rewritten,
+	// so the statistics are recomputed
+	if idx.Objects() != n || idx.Stats.Rows != int64(n) {
+		t.Errorf("%d objects and %d rows in statistics after rewriting %d objects", idx.Objects(), idx.Stats.Rows, n)
+	}
+}
+
+func TestCompactConflict(t *testing.T) {
+	dfs, owner, c := setupTruncate(t)
+	where := expr.Compare(expr.Equals, expr.Ident("VendorID"), expr.String("VTS"))
+	err := c.Delete(owner, "default", "taxi", where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Compact(owner, "default", "taxi", func(*blockfmt.Index, *blockfmt.Descriptor) (blockfmt.Input, error) {
+		// a row deleted while compacting
+		// must not be lost
+		err := c.Delete(owner, "default", "taxi", expr.Compare(expr.Equals, expr.Ident("VendorID"), expr.String("CMT")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return blockfmt.Input{}, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "synchronization violation") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	idx, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Tombstones) != 2 || idx.Objects() == 0 {
+		t.Errorf("unexpected index state after conflict: %d tombstones, %d objects", len(idx.Tombstones), idx.Objects())
+	}
+}
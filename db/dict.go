@@ -0,0 +1,103 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"runtime/trace"
+	"slices"
+
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+// dictSamples is the maximum number of
+// chunks sampled to train a dictionary
+const dictSamples = 16
+
+var errEnoughSamples = errors.New("enough samples")
+
+func (c *Config) trainsDictionary() bool {
+	return c.DictSize > 0 && c.comp() == "zstd"
+}
+
+// dictionary returns the dictionary used to compress
+// new objects added to idx, or nil if there is none
+func (st *tableState) dictionary(idx *blockfmt.Index) *blockfmt.Dictionary {
+	if idx == nil || st.conf.comp() != "zstd" {
+		return nil
+	}
+	return idx.Dictionary
+}
+
+// sampler is an io.Writer that
+// collects copies of decoded chunks
+type sampler struct {
+	samples [][]byte
+}
+
+func (s *sampler) Write(p []byte) (int, error) {
+	s.samples = append(s.samples, slices.Clone(p))
+	if len(s.samples) >= dictSamples {
+		return len(p), errEnoughSamples
+	}
+	return len(p), nil
+}
+
+func (st *tableState) sample(d *blockfmt.Descriptor, s *sampler) error {
+	f, err := open(st.ofs, d.Path, d.ETag, d.Size)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var dec blockfmt.Decoder
+	dec.Set(&d.Trailer)
+	_, err = dec.Copy(s, io.LimitReader(f, d.Trailer.Offset))
+	if errors.Is(err, errEnoughSamples) {
+		err = nil
+	}
+	return err
+}
+
+// trainDictionary sets idx.Dictionary to a dictionary
+// trained from the chunks of the objects in descs
+//
+// the objects in descs remain compressed without
+// a dictionary; only subsequently-written objects
+// use it, so failing to train a dictionary
+// is logged rather than returned
+func (st *tableState) trainDictionary(ctx context.Context, idx *blockfmt.Index, descs []*blockfmt.Descriptor) {
+	defer trace.StartRegion(ctx, "train-dictionary").End()
+	var s sampler
+	for _, d := range descs {
+		if len(s.samples) >= dictSamples {
+			break
+		}
+		err := st.sample(d, &s)
+		if err != nil {
+			st.logf("sampling %s for dictionary: %s", d.Path, err)
+			return
+		}
+	}
+	dict, err := blockfmt.TrainDictionary(s.samples, st.conf.DictSize)
+	if err != nil {
+		st.logf("training dictionary: %s", err)
+		return
+	}
+	dict.Version = 1
+	idx.Dictionary = dict
+	st.logf("trained dictionary %s (%d bytes) from %d chunks", dict.ID(), len(dict.Data), len(s.samples))
+}
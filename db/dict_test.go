@@ -0,0 +1,117 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+func TestSyncDictionary(t *testing.T) {
+	checkFiles(t)
+	tmpdir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(tmpdir, "a-prefix"), 0750)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldname, err := filepath.Abs("../testdata/parking.10n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := func(name string) {
+		err := os.Symlink(oldname, filepath.Join(tmpdir, "a-prefix", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	link("parking0.10n")
+
+	dfs := newDirFS(t, tmpdir)
+	err = WriteDefinition(dfs, "default", "parking", &Definition{
+		Inputs: []Input{
+			{Pattern: "file://a-prefix/*.10n"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := newTenant(dfs)
+	c := Config{
+		Align:    1024,
+		Algo:     "zstd",
+		DictSize: 4096,
+		Fallback: func(_ string) blockfmt.RowFormat {
+			return blockfmt.UnsafeION()
+		},
+		Logf: t.Logf,
+	}
+	err = c.Sync(owner, "default", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIndex(dfs, "default", "parking", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict := idx.Dictionary
+	if dict == nil {
+		t.Fatal("no dictionary trained")
+	}
+	if dict.Version != 1 {
+		t.Errorf("dictionary version %d", dict.Version)
+	}
+	// the objects used to train the dictionary
+	// are not compressed with it
+	if len(idx.Inline) != 1 || idx.Inline[0].Trailer.Dict != "" {
+		t.Fatalf("unexpected inline %v", idx.Inline)
+	}
+	plain := idx.Inline[0].Path
+
+	// new data is compressed with the dictionary,
+	// and the old data remains readable
+	link("parking1.10n")
+	err = c.Sync(owner, "default", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err = OpenIndex(dfs, "default", "parking", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Dictionary == nil || idx.Dictionary.ID() != dict.ID() {
+		t.Fatal("dictionary not preserved")
+	}
+	withdict := 0
+	for i := range idx.Inline {
+		switch idx.Inline[i].Trailer.Dict {
+		case "":
+			if idx.Inline[i].Path != plain {
+				t.Errorf("%s not compressed with the dictionary", idx.Inline[i].Path)
+			}
+		case dict.ID():
+			withdict++
+		default:
+			t.Errorf("%s: unexpected dictionary %s", idx.Inline[i].Path, idx.Inline[i].Trailer.Dict)
+		}
+	}
+	if withdict == 0 {
+		t.Fatal("no objects compressed with the dictionary")
+	}
+	// the packed objects are validated
+	// by newDirFS when the test completes
+}
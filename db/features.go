@@ -26,6 +26,14 @@ func (c *Config) SetFeatures(lst []string) {
 			c.Algo = "zstd"
 		case "iguana-v0":
 			c.Algo = "zion+iguana_v0"
+		case "check-utf8":
+			c.CheckUTF8 = true
+		case "stats":
+			c.CollectStats = true
+		case "no-string-ranges":
+			c.DisableStringRanges = true
+		case "empty-as-missing":
+			c.EmptyAsMissing = true
 		}
 	}
 }
@@ -0,0 +1,276 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/SnellerInc/sneller/fsutil"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+// FsckConfig is a configuration for
+// checking the integrity of a table.
+type FsckConfig struct {
+	// Decode, if set, causes every packed
+	// object pointed to by the index to be
+	// decoded and validated with blockfmt.Validate.
+	// Note that decoding reads every byte
+	// of data in the table.
+	Decode bool
+	// Checksums, if set along with Decode,
+	// causes the checksums of the compressed
+	// frames to be verified while decoding.
+	// (See blockfmt.ValidateChecksums.)
+	Checksums bool
+
+	// Logf, if non-nil, is a callback used for logging
+	// detailed information about each object checked.
+	Logf func(f string, args ...interface{})
+}
+
+func (c *FsckConfig) logf(f string, args ...interface{}) {
+	// let `go vet` know this is printf-like
+	if false {
+		_ = fmt.Sprintf(f, args...)
+	}
+	if c.Logf != nil {
+		c.Logf(f, args...)
+	}
+}
+
+// FsckError describes a problem with
+// an object pointed to by an index.
+type FsckError struct {
+	// Path is the path of the object.
+	Path string
+	// Err is the problem with the object.
+	Err error
+}
+
+func (e *FsckError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FsckError) Unwrap() error { return e.Err }
+
+var (
+	// ErrETagMismatch indicates that the ETag
+	// of an object does not match the ETag
+	// recorded in the index.
+	ErrETagMismatch = errors.New("ETag mismatch")
+	// ErrSizeMismatch indicates that the size
+	// of an object does not match the size
+	// recorded in the index.
+	ErrSizeMismatch = errors.New("size mismatch")
+	// ErrCorrupt indicates that an object
+	// could not be decoded successfully.
+	ErrCorrupt = errors.New("corrupt object")
+)
+
+// FsckReport is the result of FsckConfig.Run.
+type FsckReport struct {
+	// Objects is the number of objects
+	// pointed to by the index that were checked.
+	Objects int
+	// Errors is the list of problems found
+	// with the objects pointed to by the index.
+	Errors []FsckError
+	// Orphans is the list of objects in the
+	// table directory that were created by
+	// Sync but are not pointed to by the index.
+	// (These objects are candidates for GC;
+	// see GCConfig.)
+	Orphans []string
+}
+
+// OK returns true if r contains no errors
+// and no orphaned objects.
+func (r *FsckReport) OK() bool {
+	return len(r.Errors) == 0 && len(r.Orphans) == 0
+}
+
+func (r *FsckReport) errorf(p string, err error, f string, args ...interface{}) {
+	r.Errors = append(r.Errors, FsckError{
+		Path: p,
+		Err:  fmt.Errorf("%w: "+f, append([]interface{}{err}, args...)...),
+	})
+}
+
+// check checks that the object described by info
+// exists and has the expected ETag and size
+// and returns the fs.FileInfo for the object
+// if it exists
+func (c *FsckConfig) check(ifs InputFS, info *blockfmt.ObjectInfo, r *FsckReport) fs.FileInfo {
+	c.logf("checking %s", info.Path)
+	r.Objects++
+	fi, err := fs.Stat(ifs, info.Path)
+	if err != nil {
+		r.Errors = append(r.Errors, FsckError{Path: info.Path, Err: err})
+		return nil
+	}
+	if info.Size != 0 && fi.Size() != info.Size {
+		r.errorf(info.Path, ErrSizeMismatch, "size is %d; index has %d", fi.Size(), info.Size)
+	}
+	if info.ETag != "" {
+		etag, err := ifs.ETag(info.Path, fi)
+		if err != nil {
+			r.Errors = append(r.Errors, FsckError{Path: info.Path, Err: err})
+		} else if etag != info.ETag {
+			r.errorf(info.Path, ErrETagMismatch, "ETag is %s; index has %s", etag, info.ETag)
+		}
+	}
+	return fi
+}
+
+func (c *FsckConfig) decode(ifs InputFS, d *blockfmt.Descriptor, r *FsckReport) {
+	f, err := ifs.Open(d.Path)
+	if err != nil {
+		r.Errors = append(r.Errors, FsckError{Path: d.Path, Err: err})
+		return
+	}
+	defer f.Close()
+	var diag bytes.Buffer
+	if c.Checksums {
+		blockfmt.ValidateChecksums(f, &d.Trailer, &diag)
+	} else {
+		blockfmt.Validate(f, &d.Trailer, &diag)
+	}
+	if diag.Len() > 0 {
+		lines := strings.Split(strings.TrimSpace(diag.String()), "\n")
+		r.errorf(d.Path, ErrCorrupt, "%s", strings.Join(lines, "; "))
+	}
+}
+
+// Run checks the integrity of the table described
+// by idx within the provided database name.
+//
+// Every object pointed to by idx is checked
+// for existence, and its ETag and size are compared
+// against the ETag and size recorded in idx.
+// If c.Decode is set, the packed objects are
+// additionally decoded and validated.
+// Objects in the table directory that were created by
+// Sync before idx was written and that are not pointed
+// to by idx are reported as orphans.
+//
+// Problems with the objects are recorded in the returned
+// FsckReport. Run only returns an error if the index
+// or the table directory could not be traversed.
+func (c *FsckConfig) Run(ifs InputFS, dbname string, idx *blockfmt.Index) (*FsckReport, error) {
+	r := &FsckReport{}
+	used := make(map[string]struct{})
+	for i := range idx.ToDelete {
+		used[idx.ToDelete[i].Path] = struct{}{}
+	}
+	// check the indirect refs first; if any of them
+	// are unusable, we cannot enumerate the descriptors
+	// that they contain
+	n := len(r.Errors)
+	for i := range idx.Indirect.Refs {
+		ref := &idx.Indirect.Refs[i].ObjectInfo
+		used[ref.Path] = struct{}{}
+		c.check(ifs, ref, r)
+	}
+	var descs []blockfmt.Descriptor
+	if len(r.Errors) == n {
+		var err error
+		descs, err = idx.Indirect.Search(ifs, nil)
+		if err != nil {
+			return nil, fmt.Errorf("reading indirect descriptors: %w", err)
+		}
+	}
+	descs = append(descs, idx.Inline...)
+	for i := range descs {
+		used[descs[i].Path] = struct{}{}
+		n := len(r.Errors)
+		if c.check(ifs, &descs[i].ObjectInfo, r) == nil || !c.Decode {
+			continue
+		}
+		if len(r.Errors) > n {
+			// don't bother decoding objects
+			// that are already known to be bad
+			continue
+		}
+		c.decode(ifs, &descs[i], r)
+	}
+	idx.Inputs.Backing = &readOnly{ifs}
+	err := idx.Inputs.EachFile(func(p string) {
+		used[p] = struct{}{}
+		c.logf("checking %s", p)
+		r.Objects++
+		_, err := fs.Stat(ifs, p)
+		if err != nil {
+			r.Errors = append(r.Errors, FsckError{Path: p, Err: err})
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading inputs: %w", err)
+	}
+	err = c.orphans(ifs, path.Join("db", dbname, idx.Name), idx, used, r)
+	if err != nil {
+		return nil, fmt.Errorf("scanning for orphans: %w", err)
+	}
+	return r, nil
+}
+
+// orphans adds every object under dir that has
+// a name produced by Sync, is older than idx,
+// and is not present in used to r.Orphans
+func (c *FsckConfig) orphans(ifs InputFS, dir string, idx *blockfmt.Index, used map[string]struct{}, r *FsckReport) error {
+	patterns := []string{"packed-*", "inputs-*", "indirect-*"}
+	matches := func(name string) bool {
+		for _, pat := range patterns {
+			if ok, err := path.Match(pat, name); err == nil && ok {
+				return true
+			}
+		}
+		return false
+	}
+	walk := func(p string, d fsutil.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !matches(d.Name()) {
+			return nil
+		}
+		if _, ok := used[p]; ok {
+			return nil
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		// objects newer than the index may
+		// belong to a Sync that is in progress
+		if !idx.Created.IsZero() && !info.ModTime().Before(idx.Created.Time()) {
+			return nil
+		}
+		c.logf("orphan %s", p)
+		r.Orphans = append(r.Orphans, p)
+		return nil
+	}
+	return fsutil.WalkDir(ifs, dir, "", "", walk)
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+func TestFsck(t *testing.T) {
+	checkFiles(t)
+	tmpdir := t.TempDir()
+	for _, dir := range []string{
+		filepath.Join(tmpdir, "a-prefix/foo"),
+		filepath.Join(tmpdir, "a-prefix/bar"),
+	} {
+		err := os.MkdirAll(dir, 0750)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldname, err := filepath.Abs("../testdata/parking.10n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, newname := range []string{
+		"a-prefix/foo/parking.10n",
+		"a-prefix/bar/parking.10n",
+	} {
+		err = os.Symlink(oldname, filepath.Join(tmpdir, newname))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// don't use newDirFS, since we are going
+	// to deliberately corrupt the packfiles
+	dfs := NewDirFS(tmpdir)
+	defer dfs.Close()
+	err = WriteDefinition(dfs, "default", "parking", &Definition{
+		Inputs: []Input{
+			{Pattern: "file://a-prefix/{pre}/*.10n"},
+		},
+		Partitions: []Partition{
+			{Field: "pre"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := newTenant(dfs)
+	c := Config{
+		Align: 1024,
+		// iguana-compressed buckets have no
+		// checksums; zstd-compressed buckets do
+		Algo: "zion",
+		Fallback: func(_ string) blockfmt.RowFormat {
+			return blockfmt.UnsafeION()
+		},
+		Logf: t.Logf,
+	}
+	err = c.Sync(owner, "default", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIndex(dfs, "default", "parking", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Inline) != 2 {
+		t.Fatalf("expected 2 inline objects; got %d", len(idx.Inline))
+	}
+	conf := FsckConfig{
+		Decode:    true,
+		Checksums: true,
+		Logf:      t.Logf,
+	}
+	fsck := func() *FsckReport {
+		t.Helper()
+		r, err := conf.Run(dfs, "default", idx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range r.Errors {
+			t.Logf("error: %s", &r.Errors[i])
+		}
+		return r
+	}
+	r := fsck()
+	if !r.OK() {
+		t.Fatalf("fresh table: errors %v orphans %v", r.Errors, r.Orphans)
+	}
+	// both inline objects
+	if r.Objects < 2 {
+		t.Errorf("only checked %d objects?", r.Objects)
+	}
+
+	// objects that are older than the index
+	// and not referenced by it are orphans;
+	// newer objects are ignored
+	orphan := "db/default/parking/foo/packed-orphan.ion.zst"
+	pending := "db/default/parking/bar/packed-pending.ion.zst"
+	for _, p := range []string{orphan, pending} {
+		_, err := dfs.WriteFile(p, []byte("xyz"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := idx.Created.Time().Add(-time.Hour)
+	err = os.Chtimes(filepath.Join(tmpdir, orphan), old, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = fsck()
+	if len(r.Errors) != 0 {
+		t.Fatal("unexpected errors")
+	}
+	if !slices.Equal(r.Orphans, []string{orphan}) {
+		t.Fatalf("orphans: %v", r.Orphans)
+	}
+
+	// corrupt the first object without
+	// changing its size
+	obj := idx.Inline[0].Path
+	buf, err := fs.ReadFile(dfs, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf[64] ^= 0x5a
+	etag, err := dfs.WriteFile(obj, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = fsck()
+	if len(r.Errors) != 1 || r.Errors[0].Path != obj || !errors.Is(&r.Errors[0], ErrETagMismatch) {
+		t.Fatalf("unexpected errors %v", r.Errors)
+	}
+	// now pretend the index agrees with
+	// the ETag so that the object is decoded
+	idx.Inline[0].ETag = etag
+	r = fsck()
+	if len(r.Errors) != 1 || r.Errors[0].Path != obj || !errors.Is(&r.Errors[0], ErrCorrupt) {
+		t.Fatalf("unexpected errors %v", r.Errors)
+	}
+
+	// remove the other object entirely
+	obj = idx.Inline[1].Path
+	err = dfs.Remove(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = fsck()
+	if len(r.Errors) != 2 || r.Errors[1].Path != obj || !errors.Is(&r.Errors[1], fs.ErrNotExist) {
+		t.Fatalf("unexpected errors %v", r.Errors)
+	}
+}
@@ -83,6 +83,15 @@ func (c *collector) init(parts []Partition) error {
 				return fmt.Errorf("duplicate partition name %q", field)
 			}
 		}
+		if parts[i].Expr != "" {
+			if parts[i].Type != "" || parts[i].Value != "" {
+				return fmt.Errorf("partition %q: expr cannot be combined with type or value", field)
+			}
+			if _, err := parsePartitionExpr(parts[i].Expr); err != nil {
+				return fmt.Errorf("partition %q: %w", field, err)
+			}
+			continue
+		}
 		// ensure the field name can be used to
 		// reference a capture group if a template
 		// was not provided
@@ -128,6 +137,9 @@ func (c *collector) match(glob, path string) ([]byte, error) {
 	// build the path prefix
 	c.buf = c.buf[:0]
 	for i := range c.def {
+		if c.def[i].Expr != "" {
+			continue // see splitRows
+		}
 		if len(c.buf) > 0 {
 			c.buf = append(c.buf, '/')
 		}
@@ -156,6 +168,9 @@ func (c *collector) part(glob, path string) (*partition, error) {
 	// templates again...
 	var cons []ion.Field
 	for i := range c.def {
+		if c.def[i].Expr != "" {
+			continue
+		}
 		val, err := c.expand(c.def[i].Field, c.def[i].Value)
 		if err != nil {
 			// should already have been checked,
@@ -123,6 +123,23 @@ func TestCollector(t *testing.T) {
 	bad([]Partition{
 		{Field: "!@#$"},
 	}, `cannot use field name "!@#$" as value template`)
+	// expression partitions are computed
+	// from rows; see TestSyncExprPartition
+	good([]Partition{
+		{Field: "x"},
+		{Field: "day", Expr: "DATE_TRUNC(DAY, ts)"},
+	}, "a/b.json", "{x}/*.json", "a", []ion.Field{
+		{Label: "x", Datum: ion.String("a")},
+	})
+	bad([]Partition{
+		{Field: "day", Expr: "DATE_TRUNC(DAY, ts)", Type: "date"},
+	}, `partition "day": expr cannot be combined with type or value`)
+	bad([]Partition{
+		{Field: "n", Expr: "COUNT(*)"},
+	}, `partition "n": expr "COUNT(*)": not a row expression`)
+	bad([]Partition{
+		{Field: "n", Expr: "x, y"},
+	}, `partition "n": invalid expr "x, y"`)
 }
 
 func TestCheckSegment(t *testing.T) {
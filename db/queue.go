@@ -245,7 +245,7 @@ func open(infs InputFS, name, etag string, size int64) (fs.File, error) {
 		f.Close()
 		return nil, fmt.Errorf("getting ETag: %w", err)
 	}
-	if etag != gotEtag {
+	if !matchETag(infs, etag, gotEtag) {
 		f.Close()
 		return nil, fmt.Errorf("%w: %s -> %s", errETagChanged, etag, gotEtag)
 	}
@@ -0,0 +1,127 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+// Rebalance rewrites the packed objects of a table
+// so that each object holds about target bytes of
+// compressed data. If target is less than or equal
+// to zero, the TargetMergeSize of the Config is used.
+//
+// The rows of the table are not modified: the
+// compressed blocks of the packed objects are copied
+// as-is into new objects (see blockfmt.IndexConfig.Rebalance),
+// so the schema and statistics of the table remain
+// valid. Objects covered by different tombstones (see
+// Delete) are never merged, and the tombstones are
+// updated to cover the new objects. The new objects replace the old
+// objects in a single write of the index, so queries
+// see either the old objects or the new objects,
+// and the old objects are marked for deletion.
+// If the index is updated while Rebalance is running,
+// Rebalance fails without modifying the table.
+//
+// Objects that already have the right size are
+// not rewritten, and objects written by Rebalance
+// are not referenced until the index is written,
+// so an interrupted Rebalance can simply be run
+// again; the objects it left behind are removed
+// by the garbage collector.
+func (c *Config) Rebalance(who Tenant, db, table string, target int64) error {
+	st, err := c.open(db, table, who)
+	if err != nil {
+		return err
+	}
+	if target <= 0 {
+		target = int64(st.conf.targetMerge())
+	}
+	// objects of at least the target size must
+	// not be merged again when the index is flushed
+	st.conf.TargetMergeSize = int(target)
+
+	ctx := context.Background()
+	idx, err := st.index(ctx)
+	if err != nil {
+		return err
+	}
+	descs, err := idx.Indirect.Search(st.ofs, nil)
+	if err != nil {
+		return err
+	}
+	descs = append(descs, idx.Inline...)
+	conf := st.indexConfig()
+	// objects covered by different tombstones are
+	// rebalanced separately, so that no object mixes
+	// deleted rows with rows that aren't deleted
+	var lst []blockfmt.Descriptor
+	var rm []blockfmt.Quarantined
+	for _, group := range byTombstones(idx, descs) {
+		out, gone, err := conf.Rebalance(st.ofs, group)
+		if err != nil {
+			st.invalidate()
+			return fmt.Errorf("rebalance %s/%s: %w", db, table, err)
+		}
+		if len(gone) > 0 {
+			replaceObjects(idx, group, out)
+		}
+		lst = append(lst, out...)
+		rm = append(rm, gone...)
+	}
+	if len(rm) == 0 {
+		// already balanced
+		return nil
+	}
+	expiry := date.Now().Add(st.conf.GCMinimumAge)
+	for i := range idx.Indirect.Refs {
+		idx.ToDelete = append(idx.ToDelete, blockfmt.Quarantined{
+			Expiry: expiry,
+			Path:   idx.Indirect.Refs[i].Path,
+		})
+	}
+	idx.ToDelete = append(idx.ToDelete, rm...)
+	idx.Indirect = blockfmt.IndirectTree{}
+	idx.Inline = lst
+	idx.Created = date.Now().Truncate(time.Microsecond)
+
+	return st.refill(ctx, idx)
+}
+
+// refill moves objects from the inline list of idx
+// into the indirect tree until the inline list has
+// its usual size and then writes the index; it is
+// used after replacing the whole indirect tree
+func (st *tableState) refill(ctx context.Context, idx *blockfmt.Index) error {
+	// flush only moves half of the list
+	conf := st.outputConfig(idx)
+	dir := path.Join("db", st.db, st.table)
+	for n := len(idx.Inline) + 1; len(idx.Inline) < n; {
+		n = len(idx.Inline)
+		err := conf.SyncOutputs(idx, st.ofs, dir)
+		if err != nil {
+			st.invalidate()
+			return err
+		}
+	}
+	return st.flush(ctx, idx)
+}
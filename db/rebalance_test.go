@@ -0,0 +1,108 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"testing"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+func TestRebalance(t *testing.T) {
+	dfs, owner, c := setupTruncate(t)
+	where := expr.Compare(expr.Equals, expr.Ident("VendorID"), expr.String("VTS"))
+	err := c.Delete(owner, "default", "taxi", where)
+	if err != nil {
+		t.Fatal(err)
+	}
+	descs := func(idx *blockfmt.Index) []blockfmt.Descriptor {
+		t.Helper()
+		lst, _, _, err := idx.Descs(dfs, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return lst
+	}
+	before, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := descs(before)
+	blocks, size := 0, int64(0)
+	for i := range old {
+		blocks += len(old[i].Trailer.Blocks)
+		size += old[i].Trailer.Offset
+	}
+	t.Logf("%d objects, %d blocks, %d bytes", len(old), blocks, size)
+
+	target := size / int64(2*len(old)+1)
+	err = c.Rebalance(owner, "default", "taxi", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lst := descs(idx)
+	if len(lst) <= len(old) {
+		t.Fatalf("%d objects after rebalance; expected more than %d", len(lst), len(old))
+	}
+	newblocks, newsize := 0, int64(0)
+	for i := range lst {
+		if i < len(lst)-1 && lst[i].Trailer.Offset < target {
+			t.Errorf("object %d: size %d below target %d", i, lst[i].Trailer.Offset, target)
+		}
+		newblocks += len(lst[i].Trailer.Blocks)
+		newsize += lst[i].Trailer.Offset
+	}
+	if newblocks != blocks || newsize != size {
+		t.Errorf("%d blocks, %d bytes after rebalance", newblocks, newsize)
+	}
+	// the rest of the index is unchanged
+	if len(idx.Tombstones) != 1 {
+		t.Errorf("%d tombstones after rebalance", len(idx.Tombstones))
+	}
+	if idx.Stats.Rows != before.Stats.Rows {
+		t.Errorf("%d rows in statistics after rebalance; expected %d", idx.Stats.Rows, before.Stats.Rows)
+	}
+	deleted := make(map[string]bool)
+	for i := range idx.ToDelete {
+		deleted[idx.ToDelete[i].Path] = true
+	}
+	for i := range old {
+		if !deleted[old[i].Path] {
+			t.Errorf("%s not marked for deletion", old[i].Path)
+		}
+	}
+	// the inputs are retained,
+	// so nothing is ingested again
+	noScan(t, c, owner, "default", "taxi")
+
+	// the table is balanced,
+	// so rebalancing again does nothing
+	err = c.Rebalance(owner, "default", "taxi", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Created.Equal(idx.Created) {
+		t.Error("index rewritten by a second rebalance")
+	}
+}
@@ -37,6 +37,21 @@ func (s *S3FS) URL(name, etag string) (string, error) {
 	return s3.URL(s.Key, s.Bucket, name)
 }
 
+// MatchETag implements ETagMatcher.
+//
+// Some S3-compatible object stores (for example Ceph RGW)
+// return ETags with and without the surrounding quotes
+// or with different hex digit case depending on the API
+// call that produced them, so ETags are compared modulo
+// quoting, the weak validator prefix, and case.
+func (s *S3FS) MatchETag(want, got string) bool {
+	return strings.EqualFold(trimETag(want), trimETag(got))
+}
+
+func trimETag(etag string) string {
+	return strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+}
+
 // Encode implements plan.UploadFS
 func (s *S3FS) Encode(dst *ion.Buffer, st *ion.Symtab) error {
 	dst.BeginStruct(-1)
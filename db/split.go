@@ -0,0 +1,469 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"runtime/trace"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/expr/partiql"
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+	"github.com/SnellerInc/sneller/vm"
+)
+
+// parsePartitionExpr parses the expression of
+// an expression partition (see Partition.Expr)
+func parsePartitionExpr(str string) (expr.Node, error) {
+	q, err := partiql.Parse([]byte("SELECT " + str + " FROM t"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing expr %q: %w", str, err)
+	}
+	s, ok := q.Body.(*expr.Select)
+	if !ok || q.With != nil || q.Into != nil || q.Explain != expr.ExplainNone ||
+		len(s.Columns) != 1 || s.Distinct || s.DistinctExpr != nil ||
+		s.Where != nil || s.GroupBy != nil || s.Having != nil || s.Qualify != nil ||
+		s.OrderBy != nil || s.Limit != nil || s.Offset != nil {
+		return nil, fmt.Errorf("invalid expr %q", str)
+	}
+	if t, ok := s.From.(*expr.Table); !ok || !expr.IsIdentifier(t.Expr, "t") {
+		return nil, fmt.Errorf("invalid expr %q", str)
+	}
+	e := s.Columns[0].Expr
+	if err := expr.Check(e); err != nil {
+		return nil, fmt.Errorf("expr %q: %w", str, err)
+	}
+	// the expression must be computable from
+	// the fields of a single row
+	ok = true
+	expr.Walk(expr.WalkFunc(func(e expr.Node) bool {
+		switch e.(type) {
+		case *expr.Aggregate, *expr.Select:
+			ok = false
+		}
+		return ok
+	}), e)
+	if !ok {
+		return nil, fmt.Errorf("expr %q: not a row expression", str)
+	}
+	return e, nil
+}
+
+// partitionValues computes the values of the
+// expression partitions for each row of a chunk
+//
+// The expressions are compiled into a projection
+// once, rather than being evaluated for each row.
+type partitionValues struct {
+	proj   *vm.Projection
+	fields []string
+	out    bytes.Buffer // output of proj
+	st     ion.Symtab   // symbol table of out
+	tmp    ion.Buffer
+	vals   []ion.Datum // len(fields) values per row
+}
+
+func newPartitionValues(fields []string, exprs []expr.Node) (*partitionValues, error) {
+	sel := make(vm.Selection, len(exprs))
+	for i := range exprs {
+		sel[i] = expr.Bind(exprs[i], fields[i])
+	}
+	p := &partitionValues{fields: fields}
+	proj, err := vm.NewProjection(sel, p)
+	if err != nil {
+		return nil, err
+	}
+	p.proj = proj
+	return p, nil
+}
+
+// Open implements vm.QuerySink.Open
+func (p *partitionValues) Open() (io.WriteCloser, error) {
+	return p, nil
+}
+
+// Write collects the output of p.proj
+func (p *partitionValues) Write(b []byte) (int, error) {
+	return p.out.Write(b)
+}
+
+// Close implements vm.QuerySink.Close
+// and io.WriteCloser.Close
+func (p *partitionValues) Close() error { return nil }
+
+// compute computes the values of the expressions
+// for each of the rows in buf, which is a chunk
+// that is preceded by the symbol table st, and
+// returns len(p.fields) values for each row;
+// MISSING values are returned as NULL
+func (p *partitionValues) compute(st *ion.Symtab, buf []byte) ([]ion.Datum, error) {
+	p.out.Reset()
+	w, err := p.proj.Open()
+	if err != nil {
+		return nil, err
+	}
+	// each chunk is projected on its own, so it
+	// has to begin with a complete symbol table
+	if !ion.IsBVM(buf) {
+		p.tmp.Reset()
+		st.Marshal(&p.tmp, true)
+		if _, err := w.Write(p.tmp.Bytes()); err != nil {
+			w.Close()
+			return nil, err
+		}
+	}
+	_, err = w.Write(buf)
+	err2 := w.Close()
+	if err == nil {
+		err = err2
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.vals = p.vals[:0]
+	out := p.out.Bytes()
+	var d ion.Datum
+	for len(out) > 0 {
+		d, out, err = ion.ReadDatum(&p.st, out)
+		if err != nil {
+			return nil, err
+		}
+		if d.IsEmpty() {
+			continue // symbol table or nop pad
+		}
+		row, err := d.Struct()
+		if err != nil {
+			return nil, err
+		}
+		for i := range p.fields {
+			v := ion.Null
+			if f, ok := row.FieldByName(p.fields[i]); ok {
+				v = f.Datum
+			}
+			if ts, err := v.Timestamp(); err == nil {
+				// use the same precision
+				// as a timestamp constant
+				v = ion.Timestamp(ts)
+			}
+			p.vals = append(p.vals, v)
+		}
+	}
+	return p.vals, nil
+}
+
+// segmentFor returns the path segment
+// for the partition value d
+func segmentFor(d ion.Datum) (string, error) {
+	switch d.Type() {
+	case ion.NullType:
+		return "null", nil
+	case ion.StringType:
+		s, _ := d.String()
+		seg := url.PathEscape(s)
+		if seg == "null" {
+			// don't collide with the NULL partition
+			seg = "%6Eull"
+		}
+		if !checkSegment([]byte(seg)) {
+			return "", fmt.Errorf("bad path segment: %s", seg)
+		}
+		return seg, nil
+	case ion.IntType:
+		i, _ := d.Int()
+		return strconv.FormatInt(i, 10), nil
+	case ion.UintType:
+		u, _ := d.Uint()
+		return strconv.FormatUint(u, 10), nil
+	case ion.BoolType:
+		b, _ := d.Bool()
+		return strconv.FormatBool(b), nil
+	case ion.TimestampType:
+		ts, _ := d.Timestamp()
+		t := ts.Time()
+		if t.Equal(t.Truncate(24 * time.Hour)) {
+			return t.Format("2006-01-02"), nil
+		}
+		return t.Format("2006-01-02T15:04:05.999999Z"), nil
+	}
+	return "", fmt.Errorf("unsupported partition value of type %s", d.Type())
+}
+
+// splitRows splits the rows of each of parts by the
+// values of the expression partitions of the table,
+// returning one partition for each distinct set of
+// values. The rows of each resulting partition are
+// staged in a temporary file that is removed once
+// the partition has been converted.
+//
+// Since each resulting partition is packed into
+// its own objects, with the values of the expression
+// partitions as constants, and its rows carry the
+// fields the expressions are computed from, queries
+// with predicates on either one skip the partitions
+// that cannot match (see blockfmt.Index.Descs).
+func (st *tableState) splitRows(ctx context.Context, idx *blockfmt.Index, parts []partition) ([]partition, error) {
+	defer trace.StartRegion(ctx, "split-rows").End()
+	s := &splitter{
+		st:  st,
+		ind: make(map[string]int),
+	}
+	var exprs []expr.Node
+	for _, p := range st.def.Partitions {
+		if p.Expr == "" {
+			continue
+		}
+		e, err := parsePartitionExpr(p.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("partition %q: %w", p.Field, err)
+		}
+		s.fields = append(s.fields, p.Field)
+		exprs = append(exprs, e)
+	}
+	var err error
+	s.vals, err = newPartitionValues(s.fields, exprs)
+	if err != nil {
+		return nil, fmt.Errorf("compiling partition expressions: %w", err)
+	}
+	defer s.vals.proj.Close()
+	for i := range parts {
+		s.from = &parts[i]
+		for j := range parts[i].lst {
+			err := s.convert(&parts[i].lst[j])
+			if err != nil {
+				// close everything we haven't already closed
+				for _, in := range parts[i].lst[j+1:] {
+					in.R.Close()
+				}
+				for _, p := range parts[i+1:] {
+					for _, in := range p.lst {
+						in.R.Close()
+					}
+				}
+				s.abort()
+				return nil, &errUpdateFailed{err: err}
+			}
+		}
+	}
+	out := make([]partition, len(s.parts))
+	for i, p := range s.parts {
+		if err := p.flush(); err != nil {
+			s.abort()
+			return nil, err
+		}
+		prepend := -1
+		if idx != nil {
+			prepend = st.findPrepend(idx, p.name)
+			if prepend >= 0 {
+				st.deleteInline(idx, prepend)
+			}
+		}
+		out[i] = partition{
+			name:    p.name,
+			prepend: prepend,
+			cons:    p.cons,
+			lst: []blockfmt.Input{{
+				Path: p.f.Name(),
+				Size: p.size,
+				R:    tmpInput{p.f},
+				F:    blockfmt.UnsafeION(),
+			}},
+		}
+	}
+	return out, nil
+}
+
+// splitter is the state of splitRows
+type splitter struct {
+	st     *tableState
+	fields []string
+	vals   *partitionValues
+
+	from  *partition // partition being split
+	parts []*splitPart
+	ind   map[string]int // index into parts
+
+	syms ion.Symtab // symbol table of Write
+	keys []ion.Datum
+	name strings.Builder
+}
+
+// splitPart is a partition produced by splitRows
+type splitPart struct {
+	name string
+	cons []ion.Field
+	f    *os.File
+	cn   ion.Chunker
+	size int64
+}
+
+func (p *splitPart) Write(b []byte) (int, error) {
+	n, err := p.f.Write(b)
+	p.size += int64(n)
+	return n, err
+}
+
+func (p *splitPart) flush() error {
+	if err := p.cn.Flush(); err != nil {
+		return err
+	}
+	_, err := p.f.Seek(0, io.SeekStart)
+	return err
+}
+
+// convert converts in and writes each
+// of its rows to its partition
+func (s *splitter) convert(in *blockfmt.Input) error {
+	s.syms.Reset()
+	cn := ion.Chunker{
+		W:          s,
+		Align:      s.st.conf.align(),
+		RangeAlign: s.st.conf.flushMeta(),
+		CheckUTF8:  s.st.conf.CheckUTF8,
+	}
+	err := in.F.Convert(in.R, &cn, nil)
+	if err == nil {
+		err = cn.Flush()
+	}
+	err2 := in.R.Close()
+	if err == nil {
+		err = err2
+	}
+	if err != nil {
+		in.Err = err
+		return fmt.Errorf("%s: %w", in.Path, err)
+	}
+	return nil
+}
+
+// Write implements io.Writer so that
+// the splitter can receive the output
+// of an ion.Chunker
+func (s *splitter) Write(buf []byte) (int, error) {
+	orig := len(buf)
+	vals, err := s.vals.compute(&s.syms, buf)
+	if err != nil {
+		return 0, err
+	}
+	n := len(s.fields)
+	var d ion.Datum
+	for len(buf) > 0 {
+		d, buf, err = ion.ReadDatum(&s.syms, buf)
+		if err != nil {
+			return orig - len(buf), err
+		}
+		if d.IsEmpty() || d.IsNull() {
+			continue // symbol table or nop pad
+		}
+		if _, err := d.Struct(); err != nil {
+			return orig - len(buf), err
+		}
+		if len(vals) < n {
+			return orig - len(buf), fmt.Errorf("missing partition values for row %s", d)
+		}
+		p, err := s.part(vals[:n])
+		vals = vals[n:]
+		if err != nil {
+			return orig - len(buf), err
+		}
+		d.Encode(&p.cn.Buffer, &p.cn.Symbols)
+		if err := p.cn.Commit(); err != nil {
+			return orig - len(buf), err
+		}
+	}
+	return orig, nil
+}
+
+// part returns the partition of a row
+// given the values of its expression partitions
+func (s *splitter) part(vals []ion.Datum) (*splitPart, error) {
+	s.keys = s.keys[:0]
+	s.name.Reset()
+	s.name.WriteString(s.from.name)
+	for i, v := range vals {
+		seg, err := segmentFor(v)
+		if err != nil {
+			return nil, fmt.Errorf("partition %q: %w", s.fields[i], err)
+		}
+		if s.name.Len() > 0 {
+			s.name.WriteByte('/')
+		}
+		s.name.WriteString(seg)
+		s.keys = append(s.keys, v)
+	}
+	name := s.name.String()
+	if i, ok := s.ind[name]; ok {
+		return s.parts[i], nil
+	}
+	f, err := os.CreateTemp("", "split.*.ion")
+	if err != nil {
+		return nil, err
+	}
+	cons := append([]ion.Field(nil), s.from.cons...)
+	for i := range s.keys {
+		cons = append(cons, ion.Field{Label: s.fields[i], Datum: s.keys[i]})
+	}
+	p := &splitPart{
+		name: name,
+		cons: cons,
+		f:    f,
+	}
+	p.cn = ion.Chunker{
+		W:          p,
+		Align:      s.st.conf.align(),
+		RangeAlign: s.st.conf.flushMeta(),
+	}
+	s.ind[name] = len(s.parts)
+	s.parts = append(s.parts, p)
+	return p, nil
+}
+
+func (s *splitter) abort() {
+	for i := range s.parts {
+		tmpInput{s.parts[i].f}.Close()
+	}
+	s.parts = nil
+}
+
+// tmpInput is a temporary file
+// that is removed when it is closed
+type tmpInput struct {
+	*os.File
+}
+
+func (t tmpInput) Close() error {
+	err := t.File.Close()
+	os.Remove(t.Name())
+	return err
+}
+
+// hasExprPartitions returns whether any
+// of parts is an expression partition
+func hasExprPartitions(parts []Partition) bool {
+	for i := range parts {
+		if parts[i].Expr != "" {
+			return true
+		}
+	}
+	return false
+}
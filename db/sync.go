@@ -47,6 +47,10 @@ const DefaultMinMerge = 50 * 1024 * 1024
 // DefaultTargetMergeSize is the default target size for compacted packfiles.
 const DefaultTargetMergeSize = 1 * giga
 
+// DefaultAlign is the default alignment
+// of the blocks of new data.
+const DefaultAlign = 1024 * 1024
+
 // DefaultRangeMultiple is the default
 // multiple of the chunk alignment at which
 // we write out metadata.
@@ -156,6 +160,47 @@ type Config struct {
 	// how this value is used.
 	GCMinimumAge time.Duration
 
+	// CheckUTF8, if true, causes ion input objects
+	// containing strings that are not valid UTF-8
+	// to be rejected rather than ingested.
+	// See blockfmt.Converter.CheckUTF8.
+	CheckUTF8 bool
+
+	// EmptyAsMissing, if true, causes fields with
+	// empty string values to be dropped from the rows
+	// during ingestion, so that they are MISSING
+	// (and thus match IS NULL) in queries.
+	// See blockfmt.Converter.EmptyAsMissing.
+	EmptyAsMissing bool
+
+	// CollectStats, if true, enables the collection
+	// of column statistics (blockfmt.Index.Stats)
+	// during ingestion. The statistics of each column
+	// take about 1KB in the index, and the index is
+	// read by every query, so they are disabled by default.
+	// Statistics that have already been collected
+	// are retained but are no longer updated
+	// once CollectStats is disabled.
+	CollectStats bool
+
+	// DisableStringRanges, if true, disables the
+	// collection of the ranges of string values
+	// of each object (see blockfmt.Converter.StringRanges)
+	// during ingestion, which reduces ingestion latency.
+	DisableStringRanges bool
+
+	// DictSize, if non-zero, is the maximum size
+	// of a zstd dictionary that is trained from
+	// the first data ingested into each table and
+	// then used to compress the data blocks written
+	// by subsequent ingestions (see blockfmt.Dictionary).
+	// Dictionaries are only used when Algo is "zstd";
+	// they mostly benefit tables that receive many
+	// small, similar objects. Tables that already
+	// have a dictionary keep using it if DictSize
+	// is later set to zero.
+	DictSize int
+
 	// InputMinimumAge is the mininum time
 	// that an input file leaf should be left
 	// around after it is no longer referenced.
@@ -349,6 +394,11 @@ func (st *tableState) findPrepend(idx *blockfmt.Index, part string) int {
 			// anything prior is also too big
 			break
 		}
+		if deleted(idx, idx.Inline[i].Path) {
+			// the new rows must not be
+			// covered by the tombstones
+			break
+		}
 		return i
 	}
 	return -1
@@ -611,7 +661,7 @@ func (c *Config) align() int {
 	if c.Align > 0 {
 		return c.Align
 	}
-	return 1024 * 1024
+	return DefaultAlign
 }
 
 func (c *Config) comp() string {
@@ -890,12 +940,7 @@ func (st *tableState) flush(ctx context.Context, idx *blockfmt.Index) (err error
 	if err != nil {
 		return err
 	}
-	c := blockfmt.IndexConfig{
-		MaxInlined:    st.conf.maxInlineBytes(),
-		TargetSize:    int64(st.conf.targetMerge()),
-		TargetRefSize: st.conf.TargetRefSize,
-		Expiry:        st.conf.GCMinimumAge,
-	}
+	c := st.outputConfig(idx)
 	trace.WithRegion(ctx, "flush-outputs", func() {
 		err = c.SyncOutputs(idx, st.ofs, dir)
 	})
@@ -906,6 +951,27 @@ func (st *tableState) flush(ctx context.Context, idx *blockfmt.Index) (err error
 	return err
 }
 
+func (st *tableState) indexConfig() blockfmt.IndexConfig {
+	return blockfmt.IndexConfig{
+		MaxInlined:    st.conf.maxInlineBytes(),
+		TargetSize:    int64(st.conf.targetMerge()),
+		TargetRefSize: st.conf.TargetRefSize,
+		Expiry:        st.conf.GCMinimumAge,
+	}
+}
+
+// outputConfig returns the configuration used
+// to move the objects of idx into the indirect tree
+func (st *tableState) outputConfig(idx *blockfmt.Index) blockfmt.IndexConfig {
+	c := st.indexConfig()
+	if len(idx.Tombstones) > 0 {
+		// merging objects could mix rows deleted
+		// by a tombstone with rows it doesn't cover
+		c.TargetSize = 0
+	}
+	return c
+}
+
 func suffixForComp(c string) string {
 	if c == "zstd" {
 		return ".ion.zst"
@@ -934,8 +1000,27 @@ func (e *errUpdateFailed) Unwrap() error {
 }
 
 func (st *tableState) force(ctx context.Context, idx *blockfmt.Index, parts []partition) error {
+	inputs := parts
+	if hasExprPartitions(st.def.Partitions) {
+		var err error
+		parts, err = st.splitRows(ctx, idx, parts)
+		if err != nil {
+			var ferr *errUpdateFailed
+			if errors.As(err, &ferr) {
+				st.updateFailed(ctx, idx == nil, inputs)
+			}
+			return err
+		}
+	}
+	dict := st.dictionary(idx)
 	extra := make([]blockfmt.Descriptor, 0, len(parts))
+	written := make([]*blockfmt.Descriptor, len(parts))
 	errs := make([]error, len(parts))
+	schemas := make([]blockfmt.Schema, len(parts))
+	var stats []blockfmt.Stats
+	if st.conf.CollectStats {
+		stats = make([]blockfmt.Stats, len(parts))
+	}
 	var wg sync.WaitGroup
 	wg.Add(len(parts))
 	for i := range parts {
@@ -947,9 +1032,14 @@ func (st *tableState) force(ctx context.Context, idx *blockfmt.Index, parts []pa
 			extra = extra[:len(extra)+1]
 			dst = &extra[len(extra)-1]
 		}
+		written[i] = dst
+		var stat *blockfmt.Stats
+		if stats != nil {
+			stat = &stats[i]
+		}
 		go func(i int) {
 			defer wg.Done()
-			errs[i] = st.forcePart(ctx, prepend, dst, &parts[i])
+			errs[i] = st.forcePart(ctx, prepend, dst, &parts[i], dict, &schemas[i], stat)
 		}(i)
 	}
 	wg.Wait()
@@ -960,32 +1050,52 @@ func (st *tableState) force(ctx context.Context, idx *blockfmt.Index, parts []pa
 		}
 	}
 	if ferr != nil {
-		st.updateFailed(ctx, idx == nil, parts)
+		st.updateFailed(ctx, idx == nil, inputs)
 		return ferr
 	}
 	if idx == nil {
 		idx = new(blockfmt.Index)
-		for i := range parts {
-			for j := range parts[i].lst {
-				idx.Inputs.Append(parts[i].lst[j].Path, parts[i].lst[j].ETag, 1)
+		for i := range inputs {
+			for j := range inputs[i].lst {
+				idx.Inputs.Append(inputs[i].lst[j].Path, inputs[i].lst[j].ETag, 1)
 			}
 		}
 	}
+	if dict == nil && idx.Dictionary == nil && st.conf.trainsDictionary() {
+		st.trainDictionary(ctx, idx, written)
+	}
 	idx.Algo = "zstd"
 	idx.Created = date.Now().Truncate(time.Microsecond)
 	idx.Inline = append(idx.Inline, extra...)
+	for i := range schemas {
+		idx.Schema.Merge(&schemas[i])
+	}
+	for i := range stats {
+		idx.Stats.Merge(&stats[i])
+	}
 	return st.flush(ctx, idx)
 }
 
-func (st *tableState) forcePart(ctx context.Context, prepend, dst *blockfmt.Descriptor, part *partition) error {
+// forcePart converts part into a new packfile described by dst
+// (compressing it with dict, if it is non-nil)
+// and adds the fields of the converted rows to schema
+// and (if it is non-nil) their column statistics to stats
+func (st *tableState) forcePart(ctx context.Context, prepend, dst *blockfmt.Descriptor, part *partition, dict *blockfmt.Dictionary, schema *blockfmt.Schema, stats *blockfmt.Stats) error {
 	defer trace.StartRegion(ctx, "force-part").End()
 	c := blockfmt.Converter{
 		Inputs:              part.lst,
 		Align:               st.conf.align(),
 		FlushMeta:           st.conf.flushMeta(),
 		Comp:                st.conf.comp(),
+		Dict:                dict,
+		HotFields:           st.def.HotFields,
 		Constants:           part.cons,
 		MinInputBytesPerCPU: st.conf.MinInputBytesPerCPU,
+		CheckUTF8:           st.conf.CheckUTF8,
+		EmptyAsMissing:      st.conf.EmptyAsMissing,
+		Schema:              schema,
+		Stats:               stats,
+		StringRanges:        !st.conf.DisableStringRanges,
 	}
 
 	if prepend != nil {
@@ -998,6 +1108,7 @@ func (st *tableState) forcePart(ctx context.Context, prepend, dst *blockfmt.Desc
 		// that way we can use server-side copy for some prepends
 		c.Prepend.R = f
 		c.Prepend.Trailer = &prepend.Trailer
+		c.Prepend.Rows = prepend.Rows
 	}
 
 	name := "packed-" + uuid() + suffixForComp(c.Comp)
@@ -1012,6 +1123,9 @@ func (st *tableState) forcePart(ctx context.Context, prepend, dst *blockfmt.Desc
 		abort(out)
 		return &errUpdateFailed{err: err}
 	}
+	if err := c.StatsErr(); err != nil {
+		st.logf("collecting statistics for %s: %s", part.name, err)
+	}
 	etag, lastmod, err := getInfo(st.ofs, fp, out)
 	if err != nil {
 		return err
@@ -1025,6 +1139,7 @@ func (st *tableState) forcePart(ctx context.Context, prepend, dst *blockfmt.Desc
 			Size:         out.Size(),
 		},
 		Trailer: *c.Trailer(),
+		Rows:    c.Rows(),
 	}
 	return nil
 }
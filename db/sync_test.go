@@ -27,11 +27,14 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/SnellerInc/sneller/compr"
 	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/expr/partiql"
 	"github.com/SnellerInc/sneller/ion"
 	"github.com/SnellerInc/sneller/ion/blockfmt"
 )
@@ -629,3 +632,435 @@ func TestSyncRetention(t *testing.T) {
 		t.Errorf("unexpected results: want %s, got %s", want, got)
 	}
 }
+
+func TestSyncCheckUTF8(t *testing.T) {
+	checkFiles(t)
+	tmpdir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(tmpdir, "a-prefix"), 0750)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dfs := newDirFS(t, tmpdir)
+	err = WriteDefinition(dfs, "default", "utf8", &Definition{
+		Inputs: []Input{
+			{Pattern: "file://a-prefix/*.10n"},
+		},
+		Features: []string{"check-utf8"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// write an ion object with one field
+	// named label containing str
+	write := func(name, label, str string) {
+		var st ion.Symtab
+		var buf, out ion.Buffer
+		buf.BeginStruct(-1)
+		buf.BeginField(st.Intern(label))
+		buf.WriteString(str)
+		buf.EndStruct()
+		st.Marshal(&out, true)
+		out.UnsafeAppend(buf.Bytes())
+		_, err := dfs.WriteFile(name, out.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a-prefix/good.10n", "s", "ok")
+	write("a-prefix/bad-string.10n", "s", "bad\xff")
+	write("a-prefix/bad-symbol.10n", "bad\xff", "ok")
+
+	var lock sync.Mutex
+	var logs []string
+	owner := newTenant(dfs)
+	c := Config{
+		Align: 1024,
+		Fallback: func(_ string) blockfmt.RowFormat {
+			return blockfmt.UnsafeION()
+		},
+		Logf: func(f string, args ...any) {
+			lock.Lock()
+			defer lock.Unlock()
+			logs = append(logs, fmt.Sprintf(f, args...))
+		},
+	}
+	// the first sync fails on the bad objects,
+	// and the next one ingests the good object
+	// after the bad ones have been rejected
+	for i := 0; i < 3; i++ {
+		err = c.Sync(owner, "default", "*")
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIndex(dfs, "default", "utf8", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.Inputs.Backing = dfs
+	for _, name := range []string{"good", "bad-string", "bad-symbol"} {
+		if !contains(t, idx, "file://a-prefix/"+name+".10n") {
+			t.Errorf("index does not contain %s", name)
+		}
+	}
+	descs, err := idx.Indirect.Search(dfs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := 0
+	for i := range idx.Inline {
+		rows += idx.Inline[i].Trailer.Blocks[0].Chunks
+	}
+	for i := range descs {
+		rows += descs[i].Trailer.Blocks[0].Chunks
+	}
+	if rows != 1 {
+		t.Errorf("got %d rows; expected only the good row", rows)
+	}
+	for _, name := range []string{"bad-string", "bad-symbol"} {
+		found := slices.ContainsFunc(logs, func(s string) bool {
+			return strings.Contains(s, "rejecting object") &&
+				strings.Contains(s, name) &&
+				strings.Contains(s, "invalid UTF-8")
+		})
+		if !found {
+			t.Errorf("%s was not rejected; logs:\n%s", name, strings.Join(logs, "\n"))
+		}
+	}
+}
+
+func TestSyncExprPartition(t *testing.T) {
+	checkFiles(t)
+	tmpdir := t.TempDir()
+	dfs := newDirFS(t, tmpdir)
+	err := WriteDefinition(dfs, "default", "events", &Definition{
+		Inputs: []Input{
+			{Pattern: "file://a-prefix/{name}/*.json"},
+		},
+		Partitions: []Partition{{
+			Field: "name",
+		}, {
+			Field: "day",
+			Expr:  "DATE_TRUNC(DAY, ts)",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, rows ...string) {
+		_, err := dfs.WriteFile(name, []byte(strings.Join(rows, "\n")))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a-prefix/x/0.json",
+		`{"ts": "2021-01-01T10:00:00Z", "n": 0}`,
+		`{"ts": "2021-01-02T11:00:00Z", "n": 1}`,
+		`{"ts": "2021-01-01T12:00:00Z", "n": 2}`,
+		`{"n": 3}`,
+	)
+	write("a-prefix/y/0.json",
+		`{"ts": "2021-01-03T00:00:00Z", "n": 4}`,
+	)
+	owner := newTenant(dfs)
+	c := Config{
+		Align: 1024,
+		Logf:  t.Logf,
+	}
+	err = c.Sync(owner, "default", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rows of a new file for an existing
+	// partition are merged into it
+	write("a-prefix/x/1.json",
+		`{"ts": "2021-01-02T23:00:00Z", "n": 5}`,
+	)
+	err = c.Sync(owner, "default", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIndex(dfs, "default", "events", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.Inputs.Backing = dfs
+	checkContents(t, idx, dfs)
+	checkNoGarbage(t, dfs, "db/default/events", idx)
+
+	var got []string
+	for i := range idx.Inline {
+		p, _ := path.Split(idx.Inline[i].Path)
+		got = append(got, strings.TrimPrefix(p, "db/default/events/"))
+	}
+	slices.Sort(got)
+	want := []string{"x/2021-01-01/", "x/2021-01-02/", "x/null/", "y/2021-01-03/"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got partitions %v, want %v", got, want)
+	}
+
+	descs := func(where string) []string {
+		q, err := partiql.Parse([]byte("SELECT * FROM t WHERE " + where))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f blockfmt.Filter
+		f.Compile(q.Body.(*expr.Select).Where)
+		lst, _, _, err := idx.Descs(dfs, &f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for i := range lst {
+			p, _ := path.Split(lst[i].Path)
+			out = append(out, strings.TrimPrefix(p, "db/default/events/"))
+		}
+		slices.Sort(out)
+		return out
+	}
+	run := []struct {
+		where string
+		want  []string
+	}{
+		// rows without ts can't be excluded
+		{"ts >= `2021-01-02T00:00:00Z` AND ts < `2021-01-03T00:00:00Z`", []string{"x/2021-01-02/", "x/null/"}},
+		{"ts >= `2021-01-02T12:00:00Z`", []string{"x/2021-01-02/", "x/null/", "y/2021-01-03/"}},
+		{"ts < `2021-01-01T00:00:00Z`", []string{"x/null/"}},
+		{"day = `2021-01-01T00:00:00Z`", []string{"x/2021-01-01/"}},
+		{"day >= `2021-01-02T00:00:00Z`", []string{"x/2021-01-02/", "y/2021-01-03/"}},
+		{"name = 'y' AND ts >= `2021-01-01T00:00:00Z`", []string{"y/2021-01-03/"}},
+	}
+	for i := range run {
+		got := descs(run[i].where)
+		if !slices.Equal(got, run[i].want) {
+			t.Errorf("%s: got %v, want %v", run[i].where, got, run[i].want)
+		}
+	}
+}
+
+func TestSyncSchema(t *testing.T) {
+	checkFiles(t)
+	tmpdir := t.TempDir()
+	dfs := newDirFS(t, tmpdir)
+	err := WriteDefinition(dfs, "default", "events", &Definition{
+		Inputs: []Input{
+			{Pattern: "file://a-prefix/*.json"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, rows ...string) {
+		_, err := dfs.WriteFile(name, []byte(strings.Join(rows, "\n")))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a-prefix/0.json",
+		`{"id": 0, "user": {"name": "a", "age": 30}}`,
+		`{"id": "one", "user": {"name": "b"}}`,
+		`{"id": 2, "tags": ["x"]}`,
+	)
+	owner := newTenant(dfs)
+	c := Config{
+		Align: 1024,
+		Logf:  t.Logf,
+	}
+	err = c.Sync(owner, "default", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the rows of the first file are merged
+	// into the new packfile, but they must
+	// not be counted twice
+	write("a-prefix/1.json",
+		`{"id": 3, "user": null}`,
+	)
+	err = c.Sync(owner, "default", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIndex(dfs, "default", "events", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	type field struct {
+		path, types string
+		count       int64
+	}
+	var got []field
+	for i := range idx.Schema.Fields {
+		f := &idx.Schema.Fields[i]
+		got = append(got, field{f.Path, f.Types(), f.Total()})
+	}
+	want := []field{
+		{"id", "int|string", 4},
+		{"tags", "list", 1},
+		{"user", "null|struct", 3},
+		{"user.age", "int", 1},
+		{"user.name", "string", 2},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got schema %v, want %v", got, want)
+	}
+}
+
+func TestSyncStats(t *testing.T) {
+	checkFiles(t)
+	tmpdir := t.TempDir()
+	dfs := newDirFS(t, tmpdir)
+	for _, table := range []string{"events", "nostats"} {
+		def := &Definition{
+			Inputs: []Input{
+				{Pattern: "file://a-prefix/*.json"},
+			},
+		}
+		if table == "events" {
+			def.Features = []string{"stats"}
+		}
+		err := WriteDefinition(dfs, "default", table, def)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(name string, rows ...string) {
+		_, err := dfs.WriteFile(name, []byte(strings.Join(rows, "\n")))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a-prefix/0.json",
+		`{"id": 0, "user": {"name": "a"}}`,
+		`{"id": 1, "user": {"name": "b"}}`,
+		`{"id": 2, "user": null}`,
+	)
+	owner := newTenant(dfs)
+	c := Config{
+		Align: 1024,
+		Logf:  t.Logf,
+	}
+	err := c.Sync(owner, "default", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the statistics are updated incrementally;
+	// the rows of the first file are merged into
+	// the new packfile, but they must not be counted twice
+	write("a-prefix/1.json",
+		`{"id": 1, "user": {"name": "c"}}`,
+		`{"id": 7}`,
+	)
+	err = c.Sync(owner, "default", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := OpenIndex(dfs, "default", "events", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Stats.Rows != 5 {
+		t.Errorf("got %d rows, want 5", idx.Stats.Rows)
+	}
+	type column struct {
+		path         string
+		count, nulls int64
+		min, max     string
+		distinct     int64
+	}
+	str := func(d ion.Datum) string {
+		if d.IsEmpty() {
+			return ""
+		}
+		return d.JSON()
+	}
+	var got []column
+	for i := range idx.Stats.Columns {
+		c := &idx.Stats.Columns[i]
+		got = append(got, column{c.Path, c.Count, c.Nulls, str(c.Min()), str(c.Max()), c.Distinct()})
+	}
+	want := []column{
+		{"id", 5, 0, "0", "7", 4},
+		{"user", 3, 1, "", "", 0},
+		{"user.name", 3, 0, `"a"`, `"c"`, 3},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got stats %v, want %v", got, want)
+	}
+
+	idx, err = OpenIndex(dfs, "default", "nostats", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Stats.Rows != 0 || len(idx.Stats.Columns) != 0 {
+		t.Errorf("statistics collected without the stats feature: %+v", idx.Stats)
+	}
+	if len(idx.Schema.Fields) == 0 {
+		t.Error("no schema without the stats feature")
+	}
+}
+
+func TestSyncRowCounts(t *testing.T) {
+	checkFiles(t)
+	tmpdir := t.TempDir()
+	dfs := newDirFS(t, tmpdir)
+	err := WriteDefinition(dfs, "default", "events", &Definition{
+		Inputs: []Input{
+			{Pattern: "file://a-prefix/*.json"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, rows ...string) {
+		_, err := dfs.WriteFile(name, []byte(strings.Join(rows, "\n")))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	owner := newTenant(dfs)
+	c := Config{
+		Align: 1024,
+		Logf:  t.Logf,
+	}
+	check := func(want int64) {
+		t.Helper()
+		idx, err := OpenIndex(dfs, "default", "events", owner.Key())
+		if err != nil {
+			t.Fatal(err)
+		}
+		total := int64(0)
+		for i := range idx.Inline {
+			if idx.Inline[i].Rows == 0 {
+				t.Fatalf("no row count for %s", idx.Inline[i].Path)
+			}
+			total += idx.Inline[i].Rows
+		}
+		if total != want {
+			t.Errorf("got %d rows, want %d", total, want)
+		}
+	}
+	write("a-prefix/0.json",
+		`{"id": 0}`,
+		`{"id": 1}`,
+		`{"id": 2}`,
+	)
+	err = c.Sync(owner, "default", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(3)
+	// the rows of the first packfile are
+	// prepended to the new packfile
+	write("a-prefix/1.json",
+		`{"id": 3}`,
+		`{"id": 4}`,
+	)
+	err = c.Sync(owner, "default", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(5)
+}
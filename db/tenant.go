@@ -49,6 +49,11 @@ type TenantConfig struct {
 	// allowed to be scanned for each query. If
 	// this is 0, there is no limit.
 	MaxScanBytes uint64
+	// MaxConcurrentQueries, if non-zero, overrides
+	// the maximum number of queries that the tenant
+	// may run concurrently. A negative value means
+	// there is no limit.
+	MaxConcurrentQueries int
 }
 
 // TenantConfigurable is a tenant that may provide
@@ -0,0 +1,186 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/fsutil"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+// Truncate removes all of the data from a table.
+//
+// The index is replaced with an index that has
+// no objects in a single write, so a concurrent
+// reader observes either the old index or the
+// empty one. The packed objects belonging to the
+// old index are marked for deletion and removed
+// by a later garbage-collection pass once they are
+// older than c.GCMinimumAge. The list of ingested
+// inputs is preserved, so objects that were already
+// ingested are not ingested again by a subsequent sync.
+//
+// Truncate fails without modifying the table if
+// the index is updated concurrently (for example,
+// by a concurrent call to Sync).
+func (c *Config) Truncate(who Tenant, db, table string) error {
+	st, err := c.open(db, table, who)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	idx, err := st.index(ctx)
+	if err != nil {
+		return err
+	}
+	err = st.truncate(idx)
+	if err != nil {
+		return err
+	}
+	return st.flush(ctx, idx)
+}
+
+// truncate moves every packed object referenced
+// by idx into idx.ToDelete and resets idx.Stats
+func (st *tableState) truncate(idx *blockfmt.Index) error {
+	// the descriptors are only reachable through
+	// the indirect refs, so they have to be listed
+	// before the tree is discarded
+	descs, err := idx.Indirect.Search(st.ofs, nil)
+	if err != nil {
+		return err
+	}
+	expiry := date.Now().Add(st.conf.GCMinimumAge)
+	quarantine := func(p string) {
+		idx.ToDelete = append(idx.ToDelete, blockfmt.Quarantined{
+			Expiry: expiry,
+			Path:   p,
+		})
+	}
+	for i := range descs {
+		quarantine(descs[i].Path)
+	}
+	for i := range idx.Indirect.Refs {
+		quarantine(idx.Indirect.Refs[i].Path)
+	}
+	for i := range idx.Inline {
+		quarantine(idx.Inline[i].Path)
+	}
+	idx.Inline = nil
+	idx.Indirect = blockfmt.IndirectTree{}
+	idx.Stats = blockfmt.Stats{}
+	idx.Created = date.Now().Truncate(time.Microsecond)
+	return nil
+}
+
+// Drop removes a table entirely, including its
+// definition, its index, and all of the objects
+// stored under the table prefix.
+//
+// The definition is removed first so that no
+// new sync can recreate the table. If the index
+// has been updated concurrently by the time it
+// is removed, the definition is restored and
+// Drop returns an error without removing
+// anything else. Objects are removed only after
+// the index has been removed, so a failure
+// while removing objects leaves garbage that
+// can be removed by calling Drop again.
+func (c *Config) Drop(who Tenant, db, table string) error {
+	st, err := c.open(db, table, who)
+	if err != nil {
+		return err
+	}
+	rmfs, ok := st.ofs.(RemoveFS)
+	if !ok {
+		return fmt.Errorf("root %T does not support removing files", st.ofs)
+	}
+	_, err = st.index(context.Background())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	dpath := DefinitionPath(db, table)
+	def, err := fs.ReadFile(st.ofs, dpath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if def != nil {
+		err = rmfs.Remove(dpath)
+		if err != nil {
+			return err
+		}
+	}
+	err = st.removeIndex(rmfs)
+	if err != nil {
+		if def != nil {
+			if _, err2 := st.ofs.WriteFile(dpath, def); err2 != nil {
+				return fmt.Errorf("%w (restoring definition: %s)", err, err2)
+			}
+		}
+		return err
+	}
+	dir := path.Clean(TablePrefix(db, table))
+	walk := func(p string, d fsutil.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		err = rmfs.Remove(p)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		st.logf("removed %s", p)
+		return nil
+	}
+	return fsutil.WalkDir(rmfs, dir, "", "", walk)
+}
+
+// removeIndex removes the index if and only if
+// it has not been changed since it was loaded
+// by st.index; see also st.writeIndex
+func (st *tableState) removeIndex(rmfs RemoveFS) error {
+	defer st.invalidate()
+	idp := IndexPath(st.db, st.table)
+	info, err := fs.Stat(st.ofs, idp)
+	if st.cache.etag == "" {
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("synchronization violation detected: fs.Stat for %s produced %v", idp, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("removeIndex: %w", err)
+	}
+	etag, err := st.ofs.ETag(idp, info)
+	if err != nil {
+		return fmt.Errorf("removeIndex: determining etag: %w", err)
+	}
+	if st.cache.etag != etag {
+		return fmt.Errorf("synchronization violation detected: found etag %s -> %s", st.cache.etag, etag)
+	}
+	return rmfs.Remove(idp)
+}
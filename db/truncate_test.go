@@ -0,0 +1,205 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+func setupTruncate(t *testing.T) (*DirFS, *testTenant, *Config) {
+	checkFiles(t)
+	tmpdir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(tmpdir, "b-prefix"), 0750)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dfs := newDirFS(t, tmpdir)
+	err = WriteDefinition(dfs, "default", "taxi", &Definition{
+		Inputs: []Input{
+			{Pattern: "file://b-prefix/*.block"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldname, err := filepath.Abs("../testdata/nyc-taxi.block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		newname := filepath.Join(tmpdir, "b-prefix", fmt.Sprintf("nyc-taxi%d.block", i))
+		err = os.Symlink(oldname, newname)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	owner := newTenant(dfs)
+	c := &Config{
+		Align: 1024,
+		Fallback: func(_ string) blockfmt.RowFormat {
+			return blockfmt.UnsafeION()
+		},
+		Logf:          t.Logf,
+		MaxScanBytes:  2 * 1024 * 1024,
+		RangeMultiple: 4,
+		GCMinimumAge:  1 * time.Millisecond,
+		CollectStats:  true,
+	}
+	fullScan(t, c, owner, "default", "taxi", 4)
+	return dfs, owner, c
+}
+
+func TestTruncate(t *testing.T) {
+	dfs, owner, c := setupTruncate(t)
+	idx, err := OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Objects() == 0 {
+		t.Fatal("no objects after scan")
+	}
+	packed := idx.Inline[0].Path
+	if idx.Stats.Rows == 0 {
+		t.Error("no statistics after scan")
+	}
+
+	err = c.Truncate(owner, "default", "taxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err = OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := idx.Objects(); n != 0 {
+		t.Errorf("%d objects after truncate", n)
+	}
+	if idx.Stats.Rows != 0 {
+		t.Errorf("%d rows in statistics after truncate", idx.Stats.Rows)
+	}
+	found := false
+	for i := range idx.ToDelete {
+		if idx.ToDelete[i].Path == packed {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("%s not marked for deletion", packed)
+	}
+	// the inputs are retained,
+	// so nothing is ingested again
+	noScan(t, c, owner, "default", "taxi")
+
+	// the quarantined objects are
+	// removed by the next gc pass
+	time.Sleep(2 * time.Millisecond)
+	conf := GCConfig{Logf: t.Logf, Precise: true}
+	if !conf.preciseGC(dfs, idx) {
+		t.Error("preciseGC did nothing")
+	}
+	_, err = fs.Stat(dfs, packed)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("stat %s: %v", packed, err)
+	}
+}
+
+func TestTruncateConflict(t *testing.T) {
+	dfs, owner, c := setupTruncate(t)
+	st, err := c.open("default", "taxi", owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, err := st.index(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := idx.Objects()
+
+	// update the index behind st's back
+	err = c.Truncate(owner, "default", "taxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.truncate(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.flush(context.Background(), idx)
+	if err == nil || !strings.Contains(err.Error(), "synchronization violation") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := st.removeIndex(dfs); err == nil {
+		t.Fatal("removeIndex succeeded with a stale etag")
+	}
+	idx, err = OpenIndex(dfs, "default", "taxi", owner.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Objects() != 0 || len(idx.ToDelete) < objects {
+		t.Errorf("unexpected index state after conflict: %d objects, %d to delete", idx.Objects(), len(idx.ToDelete))
+	}
+}
+
+func TestDrop(t *testing.T) {
+	dfs, owner, c := setupTruncate(t)
+	err := c.Drop(owner, "default", "taxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{
+		IndexPath("default", "taxi"),
+		DefinitionPath("default", "taxi"),
+	} {
+		_, err := fs.Stat(dfs, p)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("stat %s: %v", p, err)
+		}
+	}
+	tables, err := Tables(dfs, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 0 {
+		t.Errorf("tables after drop: %v", tables)
+	}
+	matches, err := fs.Glob(dfs, "db/default/taxi/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range matches {
+		info, err := fs.Stat(dfs, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.IsDir() {
+			t.Errorf("file %s not removed", m)
+		}
+	}
+	// dropping a table that doesn't exist is a no-op
+	err = c.Drop(owner, "default", "taxi")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
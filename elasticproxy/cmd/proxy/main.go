@@ -15,7 +15,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -38,24 +40,32 @@ type config struct {
 	proxy_http.Config
 	LogFolder string `json:"logFolder,omitempty"`
 
+	// names is shared with the configuration
+	// of the same tenant after a reload
+	names *logNames
+
+	ReverseProxy http.HandlerFunc
+}
+
+// logNames generates unique names for log files
+type logNames struct {
 	syncMutex        sync.Mutex
 	lastTimestamp    string
 	timestampCounter int
-
-	ReverseProxy http.HandlerFunc
 }
 
 func (c *config) baseName(t time.Time) string {
-	c.syncMutex.Lock()
-	defer c.syncMutex.Unlock()
+	n := c.names
+	n.syncMutex.Lock()
+	defer n.syncMutex.Unlock()
 
 	timestamp := t.Format("20060102-1504")
-	if timestamp != c.lastTimestamp {
-		c.timestampCounter = 0
-		c.lastTimestamp = timestamp
+	if timestamp != n.lastTimestamp {
+		n.timestampCounter = 0
+		n.lastTimestamp = timestamp
 	}
-	name := fmt.Sprintf("%s-%03d", timestamp, c.timestampCounter)
-	c.timestampCounter++
+	name := fmt.Sprintf("%s-%03d", timestamp, n.timestampCounter)
+	n.timestampCounter++
 	return name
 }
 
@@ -81,6 +91,7 @@ func main() {
 	endpoint := flag.String("endpoint", "localhost:8888", "Default endpoint (only for non-TLS mode)")
 	memcacheEndpoint := flag.String("memcache", "", "Optional memcache address")
 	verboseFlag := flag.Bool("v", false, "Verbose logging")
+	reload := flag.Duration("reload", 0, "Interval for checking the configuration file for changes (0 disables; SIGHUP always reloads)")
 	flag.Parse()
 
 	if *verboseFlag {
@@ -88,10 +99,20 @@ func main() {
 		verbose = true
 	}
 
-	tenants, err := loadTenantConfiguration(*configFile)
+	var check func(tenantConfig) error
+	if *useTLS {
+		check = func(tenants tenantConfig) error {
+			if _, ok := tenants["*"]; ok {
+				return errors.New("cannot use host '*' in TLS mode")
+			}
+			return nil
+		}
+	}
+	tenants, err := newTenantStore(*configFile, check)
 	if err != nil {
 		log.Fatalf("can't load %q: %v", *configFile, err)
 	}
+	go tenants.watch(*reload)
 
 	var memcacheClient *memcache.Client
 	if *memcacheEndpoint != "" {
@@ -100,14 +121,12 @@ func main() {
 
 	withTenantConfig := func(f func(t *config, c *proxy_http.HandlerContext) bool) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			tenantID := r.Host
-			t, ok := tenants[r.Host]
+			// t is used for the entire request,
+			// even if the configuration is reloaded
+			t, tenantID, ok := tenants.lookup(r.Host)
 			if !ok {
-				if t, ok = tenants["*"]; !ok {
-					tenantID = "*"
-					w.WriteHeader(http.StatusNotFound)
-					return
-				}
+				w.WriteHeader(http.StatusNotFound)
+				return
 			}
 
 			client := &http.Client{
@@ -177,18 +196,20 @@ func main() {
 
 	var l net.Listener
 	if *useTLS {
-		var hosts []string
-		for host := range tenants {
-			if host == "*" {
-				log.Fatal("cannot use host '*' in TLS mode")
-			}
-			hosts = append(hosts, host)
+		for host := range tenants.tenants() {
 			verboseLog("listening on https://%s", host)
 		}
 		certManager := autocert.Manager{
-			Prompt:     autocert.AcceptTOS,
-			HostPolicy: autocert.HostWhitelist(hosts...),
-			Cache:      autocert.DirCache("certs"),
+			Prompt: autocert.AcceptTOS,
+			// only request certificates for the hosts
+			// in the current configuration
+			HostPolicy: func(_ context.Context, host string) error {
+				if _, ok := tenants.tenants()[host]; !ok {
+					return fmt.Errorf("host %q not configured", host)
+				}
+				return nil
+			},
+			Cache: autocert.DirCache("certs"),
 		}
 		l = certManager.Listener()
 	} else {
@@ -237,6 +258,7 @@ func loadTenantConfiguration(path string) (tenantConfig, error) {
 
 	// create a reverse-proxy for the underlying Elastic endpoint
 	for _, t := range tenants {
+		t.names = new(logNames)
 		if t.LogFolder != "" {
 			err := os.MkdirAll(t.LogFolder, 0755)
 			if err != nil {
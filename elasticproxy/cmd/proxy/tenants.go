@@ -0,0 +1,147 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// tenantStore holds the tenant configuration
+// loaded from a configuration file.
+//
+// Reloading the file replaces the whole
+// configuration atomically, so a request
+// that has already looked up its tenant keeps
+// using that tenant (and its reverse proxy)
+// until it completes, and new requests see
+// the new configuration.
+type tenantStore struct {
+	path string
+	// check, if non-nil, rejects
+	// configurations that cannot be used
+	check func(tenantConfig) error
+
+	current atomic.Pointer[tenantConfig]
+
+	// modification time and size of the
+	// file that was loaded most recently
+	modTime time.Time
+	size    int64
+}
+
+func newTenantStore(path string, check func(tenantConfig) error) (*tenantStore, error) {
+	s := &tenantStore{path: path, check: check}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// tenants returns the current configuration,
+// which must not be modified
+func (s *tenantStore) tenants() tenantConfig {
+	return *s.current.Load()
+}
+
+// lookup returns the configuration for host,
+// falling back to the "*" tenant
+func (s *tenantStore) lookup(host string) (*config, string, bool) {
+	tenants := s.tenants()
+	if t, ok := tenants[host]; ok {
+		return t, host, true
+	}
+	t, ok := tenants["*"]
+	return t, "*", ok
+}
+
+// reload loads the configuration file and
+// swaps it in. If the file cannot be loaded,
+// the current configuration is kept.
+func (s *tenantStore) reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	tenants, err := loadTenantConfiguration(s.path)
+	if err != nil {
+		return err
+	}
+	if s.check != nil {
+		if err := s.check(tenants); err != nil {
+			return err
+		}
+	}
+	if prev := s.current.Load(); prev != nil {
+		for id, t := range tenants {
+			// keep numbering the log files of a
+			// tenant where the previous configuration
+			// left off, so that requests that are
+			// still running don't overwrite them
+			if old, ok := (*prev)[id]; ok && old.LogFolder == t.LogFolder {
+				t.names = old.names
+			}
+		}
+	}
+	s.modTime, s.size = info.ModTime(), info.Size()
+	s.current.Store(&tenants)
+	return nil
+}
+
+// changed returns true if the configuration
+// file has been modified since it was loaded
+func (s *tenantStore) changed() bool {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		// keep the current configuration
+		// while the file is being replaced
+		return false
+	}
+	return !info.ModTime().Equal(s.modTime) || info.Size() != s.size
+}
+
+// watch reloads the configuration whenever the
+// process receives SIGHUP and, if interval is
+// positive, whenever the configuration file
+// changes (which is checked every interval).
+// watch does not return.
+func (s *tenantStore) watch(interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	var tick <-chan time.Time
+	if interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		tick = t.C
+	}
+	for {
+		select {
+		case <-hup:
+		case <-tick:
+			if !s.changed() {
+				continue
+			}
+		}
+		if err := s.reload(); err != nil {
+			log.Printf("can't reload %q (keeping the current configuration): %v", s.path, err)
+			continue
+		}
+		verboseLog("reloaded %q (%d tenants)", s.path, len(s.tenants()))
+	}
+}
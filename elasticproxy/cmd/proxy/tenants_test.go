@@ -0,0 +1,108 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTenantStoreReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	logs := filepath.Join(dir, "logs")
+	write := func(text string, mod time.Time) {
+		if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write(`{"a.example.com": {"logFolder": "`+logs+`", "mapping": {}}}`, start)
+
+	check := func(tenants tenantConfig) error {
+		if _, ok := tenants["bad"]; ok {
+			return errors.New("bad tenant")
+		}
+		return nil
+	}
+	s, err := newTenantStore(path, check)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.changed() {
+		t.Fatal("configuration changed right after loading it")
+	}
+	a, id, ok := s.lookup("a.example.com")
+	if !ok || id != "a.example.com" {
+		t.Fatalf("lookup(a.example.com) = %q, %v", id, ok)
+	}
+	if _, _, ok := s.lookup("b.example.com"); ok {
+		t.Fatal("found b.example.com without a \"*\" tenant")
+	}
+	first := a.baseName(start)
+
+	// add a tenant and a fallback tenant
+	write(`{"a.example.com": {"logFolder": "`+logs+`", "mapping": {}},
+ "*": {"mapping": {}}}`, start.Add(time.Minute))
+	if !s.changed() {
+		t.Fatal("changed() = false after modifying the file")
+	}
+	if err := s.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if s.changed() {
+		t.Fatal("changed() = true after reloading")
+	}
+	a2, _, ok := s.lookup("a.example.com")
+	if !ok {
+		t.Fatal("a.example.com missing after reload")
+	}
+	if a2 == a {
+		t.Fatal("reload did not replace the tenant configuration")
+	}
+	// a request that looked up a before the
+	// reload keeps using the old configuration,
+	// but log files are not overwritten
+	if a.names != a2.names {
+		t.Fatal("log names are not shared across reloads")
+	}
+	if second := a2.baseName(start); second == first {
+		t.Fatalf("log name %q reused after reload", second)
+	}
+	if _, id, ok := s.lookup("b.example.com"); !ok || id != "*" {
+		t.Fatalf("lookup(b.example.com) = %q, %v after reload", id, ok)
+	}
+
+	// configurations that can't be loaded
+	// or are rejected are not swapped in
+	for _, text := range []string{
+		`{"a.example.com": `,
+		`{"bad": {"mapping": {}}}`,
+	} {
+		write(text, start.Add(2*time.Minute))
+		if err := s.reload(); err == nil {
+			t.Fatalf("reloading %s: expected an error", text)
+		}
+		if got, _, ok := s.lookup("a.example.com"); !ok || got != a2 {
+			t.Fatalf("configuration replaced by %s", text)
+		}
+	}
+}
@@ -14,19 +14,59 @@
 
 package elastic_proxy
 
+import (
+	"fmt"
+	"math/bits"
+)
+
+// the range of precisions of APPROX_COUNT_DISTINCT
+const (
+	minHLLPrecision = 4
+	maxHLLPrecision = 16
+)
+
 type aggsCardinality struct {
 	fieldMetricAgg
+	PrecisionThreshold *int64 `json:"precision_threshold"`
 }
 
 func (f *aggsCardinality) transform(subBucket string, c *aggsGenerateContext) error {
+	exprs := []expression{ParseExprFieldName(c.context, f.Field)}
+	if f.PrecisionThreshold != nil {
+		if *f.PrecisionThreshold < 0 {
+			return fmt.Errorf("invalid precision_threshold %d", *f.PrecisionThreshold)
+		}
+		precision, _ := NewJSONLiteral(precisionFromThreshold(*f.PrecisionThreshold))
+		exprs = append(exprs, &exprJSONLiteral{Context: c.context, Value: precision})
+	}
 	c.addProjection(subBucket, &exprFunction{
 		Context: c.context,
-		Name:    "COUNT",
-		Exprs:   []expression{&exprOperator1{Context: c.context, Operator: "DISTINCT", Expr1: ParseExprFieldName(c.context, f.Field)}},
+		Name:    "APPROX_COUNT_DISTINCT",
+		Exprs:   exprs,
 	})
 	return nil
 }
 
+// precisionFromThreshold returns the precision of
+// the HyperLogLog sketch that Elasticsearch uses for
+// a precision_threshold, which is the number of distinct
+// values below which the count is expected to be
+// close to accurate, clamped to the precisions
+// supported by APPROX_COUNT_DISTINCT
+func precisionFromThreshold(threshold int64) int {
+	// the sketch is sized for a hash table of 4-byte
+	// entries with a load factor of 0.75
+	entries := (threshold*4 + 2) / 3
+	precision := bits.Len64(uint64(entries * 4))
+	if precision < minHLLPrecision {
+		return minHLLPrecision
+	}
+	if precision > maxHLLPrecision {
+		return maxHLLPrecision
+	}
+	return precision
+}
+
 func (f *aggsCardinality) process(c *aggsProcessContext) (any, error) {
 	v, _ := c.result()
 	if v == nil {
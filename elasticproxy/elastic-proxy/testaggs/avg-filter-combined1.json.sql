@@ -6,7 +6,7 @@ WITH
 
   "$bucket:%0" AS
     (SELECT AVG("$source"."price") AS "avg_overall_price",
-            COUNT(DISTINCT "$source"."type") AS "total_types"
+            APPROX_COUNT_DISTINCT("$source"."type") AS "total_types"
      FROM "$source"
     ),
 
@@ -0,0 +1,25 @@
+WITH
+  "$source" AS
+    (SELECT *
+     FROM "table" AS "$source"
+    ),
+
+  "$bucket:regional%0" AS
+    (SELECT "$source"."region" AS "$key:regional%0",
+            COUNT(*) AS "$doc_count",
+            APPROX_COUNT_DISTINCT("$source"."dest_ip",16) AS "destinations",
+            APPROX_COUNT_DISTINCT("$source"."source_ip",10) AS "sources"
+     FROM "$source"
+     GROUP BY "$source"."region"
+     ORDER BY "$doc_count" DESC
+     LIMIT 10
+    )
+
+SELECT
+  (SELECT COUNT(*)
+   FROM "$source"
+  ) AS "$total_count",
+
+  (SELECT *
+   FROM "$bucket:regional%0"
+  ) AS "$bucket:regional%0"
\ No newline at end of file
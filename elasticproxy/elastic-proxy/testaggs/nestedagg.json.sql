@@ -32,7 +32,7 @@ WITH
     (SELECT "$source"."region" AS "$key:regional%0",
             "$source"."source_ip" AS "$key:regional:src%0",
             COUNT(*) AS "$doc_count",
-            COUNT(DISTINCT "$source"."host") AS "hosts"
+            APPROX_COUNT_DISTINCT("$source"."host") AS "hosts"
      FROM "$source"
      WHERE ("$source"."region" IN (SELECT "$selection"."$key:regional%0"
      FROM "$bucket:regional%0" AS "$selection"))
@@ -8,7 +8,7 @@ WITH
   "$bucket:region%0" AS
     (SELECT "$source"."region" AS "$key:region%0",
             COUNT(*) AS "$doc_count",
-            COUNT(DISTINCT "$source"."source_ip") AS "unique_ips"
+            APPROX_COUNT_DISTINCT("$source"."source_ip") AS "unique_ips"
      FROM "$source"
      GROUP BY "$source"."region"
      ORDER BY "$doc_count" DESC
@@ -8,8 +8,8 @@ WITH
   "$bucket:2%0" AS
     (SELECT ((30 * WIDTH_BUCKET(("$source"."FlightDelayMin" + 15),0,30000,1000)) - 30) AS "$key:2%0",
             COUNT(*) AS "$doc_count",
-            COUNT(DISTINCT "$source"."DestCountry") AS "destCountries",
-            COUNT(DISTINCT "$source"."OriginCountry") AS "origCountries"
+            APPROX_COUNT_DISTINCT("$source"."DestCountry") AS "destCountries",
+            APPROX_COUNT_DISTINCT("$source"."OriginCountry") AS "origCountries"
      FROM "$source"
      GROUP BY ((30 * WIDTH_BUCKET(("$source"."FlightDelayMin" + 15),0,30000,1000)) - 30)
      ORDER BY "$key:2%0" ASC
@@ -7,8 +7,8 @@ WITH
   "$bucket:resource_id%0" AS
     (SELECT "$source"."OriginCountry" AS "$key:resource_id%0",
             COUNT(*) AS "$doc_count",
-            COUNT(DISTINCT "$source"."DestAirportID") AS "dest_airport_count",
-            COUNT(DISTINCT "$source"."OriginAirportID") AS "orig_airport_count"
+            APPROX_COUNT_DISTINCT("$source"."DestAirportID") AS "dest_airport_count",
+            APPROX_COUNT_DISTINCT("$source"."OriginAirportID") AS "orig_airport_count"
      FROM "$source"
      GROUP BY "$source"."OriginCountry"
      ORDER BY "$doc_count" DESC
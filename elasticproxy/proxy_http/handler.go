@@ -15,6 +15,9 @@
 package proxy_http
 
 import (
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -32,6 +35,10 @@ type HandlerContext struct {
 	Mapping *mappingEntry // currently selected mapping for an index
 	Cache   MappingCache
 
+	// Translations caches the SQL
+	// translation of Elastic queries
+	Translations TranslationCache
+
 	// function performing verbose logging
 	VerboseLog func(string, ...any)
 
@@ -52,19 +59,23 @@ type HandlerContext struct {
 	}
 }
 
-var dummyCache DummyCache
+var (
+	dummyCache            DummyCache
+	dummyTranslationCache DummyTranslationCache
+)
 
 // NewHandlerContext creates a new context based on the ElasticSearch configuration and the handled request.
 func NewHandlerContext(config *Config, client *http.Client, w http.ResponseWriter, r *http.Request, verbose bool, verboseLog func(format string, v ...any)) *HandlerContext {
 	return &HandlerContext{
-		Config:     config,
-		Logging:    newLogging(r),
-		Client:     client,
-		Request:    r,
-		Writer:     w,
-		Cache:      dummyCache,
-		Verbose:    verbose,
-		VerboseLog: verboseLog,
+		Config:       config,
+		Logging:      newLogging(r),
+		Client:       client,
+		Request:      r,
+		Writer:       w,
+		Cache:        dummyCache,
+		Translations: dummyTranslationCache,
+		Verbose:      verbose,
+		VerboseLog:   verboseLog,
 	}
 }
 
@@ -85,9 +96,44 @@ func (c *HandlerContext) SelectIndex(index string) bool {
 			c.Memcache.ExpirationTime)
 	}
 
+	if c.Memcache.Client != nil && c.Translations == dummyTranslationCache {
+		c.Translations = NewMemcacheTranslationCache(
+			c.Memcache.Client,
+			c.Memcache.TenantID,
+			c.Memcache.Secret,
+			c.Memcache.ExpirationTime)
+	}
+
 	return true
 }
 
+// translationKey returns the key used to cache the SQL
+// translation of the current request with the given body.
+//
+// The key covers the endpoint, the query parameters and
+// the body of the request as well as the mapping of the
+// selected index, so changing the mapping invalidates
+// previously cached translations.
+func (c *HandlerContext) translationKey(body []byte) (string, error) {
+	mapping, err := json.Marshal(c.Mapping)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha512.New()
+	for _, part := range [][]byte{
+		[]byte(c.Request.URL.Path),
+		[]byte(c.Request.URL.RawQuery),
+		mapping,
+		body,
+	} {
+		fmt.Fprintf(h, "%d:", len(part))
+		h.Write(part)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func (c *HandlerContext) AddHeader(k, v string) {
 	c.Writer.Header().Add(k, v)
 }
@@ -179,12 +179,29 @@ func execute(c *HandlerContext, pq *proxyQuery, alwaysDetermineCount bool) error
 		TypeMapping:            c.Mapping.TypeMapping,
 	}
 
-	// step 2: generate SQL
-	sqlExpr, err := pq.ej.SQL(&qc)
+	// step 2: generate SQL (unless it has been cached)
+	key, err := c.translationKey(pq.body)
 	if err != nil {
 		return err
 	}
-	c.Logging.SQL = elastic_proxy.PrintExprPretty(sqlExpr)
+	c.Logging.SQL, err = c.Translations.Fetch(key)
+	if err != nil {
+		c.VerboseLog("cannot fetch SQL translation from cache: %s", err)
+	}
+	if c.Logging.SQL == "" {
+		sqlExpr, err := pq.ej.SQL(&qc)
+		if err != nil {
+			return err
+		}
+		c.Logging.SQL = elastic_proxy.PrintExprPretty(sqlExpr)
+
+		err = c.Translations.Store(key, c.Logging.SQL)
+		if err != nil {
+			c.VerboseLog("cannot store SQL translation in cache: %s", err)
+		}
+	} else {
+		c.VerboseLog("fetched SQL translation from cache")
+	}
 
 	tokenLast4 := c.Config.Sneller.Token
 	if len(tokenLast4) > 4 {
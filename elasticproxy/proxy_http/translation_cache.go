@@ -0,0 +1,127 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package proxy_http
+
+import (
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"golang.org/x/crypto/hkdf"
+)
+
+// TranslationCache is an interface to cache
+// the SQL translation of Elastic queries
+//
+// Key is constructed by HandlerContext from the request
+// and the mapping of the selected index.
+type TranslationCache interface {
+	// Store saves the SQL text for given key.
+	Store(key string, sql string) error
+
+	// Fetch loads the SQL text for given key.
+	// It returns "", nil if no entry was found.
+	Fetch(key string) (string, error)
+}
+
+// DummyTranslationCache is a TranslationCache that does not
+// support storing and always fetches nothing.
+type DummyTranslationCache struct{}
+
+func (d DummyTranslationCache) Store(key string, sql string) error {
+	return nil
+}
+
+func (d DummyTranslationCache) Fetch(key string) (string, error) {
+	return "", nil
+}
+
+// MemcacheTranslationCache is a TranslationCache backed by memcached
+type MemcacheTranslationCache struct {
+	client            *memcache.Client
+	tenantID          string
+	secret            []byte // input entropy for key creation
+	defaultExpiration int32  // default expiration time; see memcache.Item.Expiration
+}
+
+// NewMemcacheTranslationCache creates new MemcacheTranslationCache instance.
+func NewMemcacheTranslationCache(client *memcache.Client, tenantID string, secret string, defaultExpiration int) *MemcacheTranslationCache {
+	return &MemcacheTranslationCache{
+		client:            client,
+		tenantID:          tenantID,
+		secret:            []byte(secret),
+		defaultExpiration: int32(defaultExpiration),
+	}
+}
+
+// key calculates value for use as memcache.Item.Key;
+// the tenant ID is part of the key, so tenants
+// never share entries
+func (m *MemcacheTranslationCache) key(key string) string {
+	strid := fmt.Sprintf("%s:%s", m.tenantID, key)
+	hash := sha512.Sum512([]byte(strid))
+	return fmt.Sprintf("ep:sql:%x", hash)
+}
+
+func (m *MemcacheTranslationCache) keysrc() io.Reader {
+	return hkdf.New(sha512.New, m.secret, nil, nil)
+}
+
+func (m *MemcacheTranslationCache) Store(key string, sql string) error {
+	box, err := encrypt([]byte(sql), m.keysrc())
+	if err != nil {
+		return err
+	}
+
+	serialized, err := json.Marshal(box)
+	if err != nil {
+		return err
+	}
+
+	item := &memcache.Item{
+		Key:        m.key(key),
+		Value:      serialized,
+		Expiration: m.defaultExpiration,
+	}
+
+	return m.client.Set(item)
+}
+
+func (m *MemcacheTranslationCache) Fetch(key string) (string, error) {
+	v, err := m.client.Get(m.key(key))
+	if err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	box := new(aeadBox)
+	err = json.Unmarshal(v.Value, box)
+	if err != nil {
+		return "", err
+	}
+
+	sql, err := box.decrypt(m.keysrc())
+	if err != nil {
+		return "", err
+	}
+
+	return string(sql), nil
+}
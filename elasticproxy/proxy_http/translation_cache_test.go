@@ -0,0 +1,104 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package proxy_http
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMemcacheTranslationCache(t *testing.T) {
+	client := memcached(t)
+	cache := NewMemcacheTranslationCache(client, "Test", t.Name(), 0)
+	sql := `SELECT COUNT(*) FROM "db"."table"`
+
+	t.Run("get nonexisting", func(t *testing.T) {
+		got, err := cache.Fetch("foobar")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != "" {
+			t.Errorf("no value should be returned")
+		}
+	})
+
+	t.Run("store and load", func(t *testing.T) {
+		err := cache.Store("foobar", sql)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := cache.Fetch("foobar")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != sql {
+			t.Errorf("got %q, want %q", got, sql)
+		}
+	})
+
+	t.Run("other tenant", func(t *testing.T) {
+		other := NewMemcacheTranslationCache(client, "Other", t.Name(), 0)
+		got, err := other.Fetch("foobar")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != "" {
+			t.Errorf("entry of another tenant should not be returned")
+		}
+	})
+}
+
+func TestTranslationKey(t *testing.T) {
+	mapping := &mappingEntry{
+		Sources: []mappingEntrySource{{Database: "db", Table: "table"}},
+	}
+
+	key := func(url, body string, m *mappingEntry) string {
+		c := &HandlerContext{
+			Request: httptest.NewRequest("POST", url, nil),
+			Mapping: m,
+		}
+		k, err := c.translationKey([]byte(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	const body = `{"query": {"match_all": {}}}`
+	base := key("/index/_search", body, mapping)
+	if k := key("/index/_search", body, mapping); k != base {
+		t.Errorf("identical requests should have the same key")
+	}
+
+	changed := &mappingEntry{
+		Sources:         mapping.Sources,
+		IgnoreTotalHits: true,
+	}
+	for _, k := range []string{
+		key("/index/_search", `{"query": {"match_none": {}}}`, mapping),
+		key("/index/_count", body, mapping),
+		key("/index/_search?size=0", body, mapping),
+		key("/index/_search", body, changed),
+	} {
+		if k == base {
+			t.Errorf("different requests should have different keys")
+		}
+	}
+}
@@ -19,15 +19,18 @@ package expr
 
 import (
 	"bytes"
-	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"math"
-	"net"
+	"net/netip"
+	"regexp"
+	"slices"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/SnellerInc/sneller/date"
 	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/regexp2"
 )
 
 func mismatch(want, got int) error {
@@ -118,6 +121,21 @@ const (
 	IsSubnetOf
 	Substring
 	SplitPart
+	RegexpExtract
+	RegexpLike
+	Replace
+	Translate
+	Soundex
+	Difference
+	ConcatWs   // sql:CONCAT_WS
+	ToHex      // sql:TO_HEX
+	FromHex    // sql:FROM_HEX
+	ToBase64   // sql:TO_BASE64
+	FromBase64 // sql:FROM_BASE64
+	ToJSON     // sql:TO_JSON
+
+	NullIfNonFinite // sql:NULLIF_NONFINITE
+	AssertFinite    // sql:ASSERT_FINITE
 
 	BitCount
 
@@ -156,6 +174,7 @@ const (
 	Atan
 	Atan2
 
+	Modulo // sql:MOD
 	Pmod
 
 	Least
@@ -175,6 +194,11 @@ const (
 
 	DateBin
 
+	// DateAddMonthClamp is DateAddMonth, except that
+	// the day of the month is clamped to the last day
+	// of the resulting month (see INTERVAL literals)
+	DateAddMonthClamp
+
 	DateDiffMicrosecond
 	DateDiffMillisecond
 	DateDiffSecond
@@ -194,9 +218,11 @@ const (
 	DateExtractDay
 	DateExtractDOW // sql:DATE_EXTRACT_DOW
 	DateExtractDOY // sql:DATE_EXTRACT_DOY
+	DateExtractWeek
 	DateExtractMonth
 	DateExtractQuarter
 	DateExtractYear
+	DateExtractEpoch
 
 	DateTruncMicrosecond
 	DateTruncMillisecond
@@ -224,6 +250,9 @@ const (
 	ArrayPosition
 	ArraySum
 
+	Object        // OBJECT(key, value, ...) constructs a structure
+	MapFromArrays // MAP_FROM_ARRAYS(keys, values) constructs a structure
+
 	VectorInnerProduct   // sql:INNER_PRODUCT
 	VectorL1Distance     // sql:L1_DISTANCE
 	VectorL2Distance     // sql:L2_DISTANCE
@@ -235,6 +264,10 @@ const (
 	// used by query planner:
 	InSubquery        // matches IN (SELECT ...)
 	InReplacement     // IN_REPLACEMENT(x, id)
+	AnySubquery       // matches x <op> ANY (SELECT ...) as ANY_SUBQUERY(op, x, (SELECT ...))
+	AllSubquery       // matches x <op> ALL (SELECT ...) as ALL_SUBQUERY(op, x, (SELECT ...))
+	AnyReplacement    // ANY_REPLACEMENT(op, x, id)
+	AllReplacement    // ALL_REPLACEMENT(op, x, id)
 	HashReplacement   // HASH_REPLACEMENT(id, kind, k, x)
 	ScalarReplacement // SCALAR_REPLACEMENT(id)
 	StructReplacement // STRUCT_REPLACEMENT(id)
@@ -249,6 +282,17 @@ const (
 	AssertIonType
 
 	PartitionValue // PARTITION_VALUE(int) is used as a placeholder during query planning
+	Tombstone      // TOMBSTONE(int, x) is used as a placeholder for the deletion predicate x during query planning
+
+	CollateCI // x COLLATE ci is a GROUP BY key compared case-insensitively; sql:COLLATE_CI
+
+	DecimalToFloat // DECIMAL_TO_FLOAT(x) converts a decimal to a float (see vm.IsExactSum); sql:DECIMAL_TO_FLOAT
+
+	IsJSON       // x IS JSON tests whether x is a string holding valid JSON; sql:IS_JSON
+	IsJSONObject // x IS JSON OBJECT; sql:IS_JSON_OBJECT
+	IsJSONArray  // x IS JSON ARRAY; sql:IS_JSON_ARRAY
+
+	Overlaps // (s1, e1) OVERLAPS (s2, e2) tests whether two periods of time overlap
 
 	Unspecified // catch-all for opaque built-ins; sql:UNKNOWN
 	maxBuiltin
@@ -333,6 +377,8 @@ func (b BuiltinOp) TimePart() (Timepart, bool) {
 		return DOW, true
 	case DateExtractDOY:
 		return DOY, true
+	case DateExtractWeek:
+		return Week, true
 	case DateExtractMonth:
 		return Month, true
 	case DateExtractQuarter:
@@ -431,14 +477,14 @@ func checkIsSubnetOf(h Hint, args []Node) error {
 		return errtype(args[1], "not a string but a %T", args[1])
 	}
 	if nArgs == 2 {
-		if _, _, err := net.ParseCIDR(string(arg0)); err != nil {
+		if _, err := netip.ParsePrefix(string(arg0)); err != nil {
 			return errtype(args[0], "%s", err)
 		}
 	} else {
-		if net.ParseIP(string(arg0)) == nil {
+		if _, err := netip.ParseAddr(string(arg0)); err != nil {
 			return errtype(args[0], "not an IP address")
 		}
-		if net.ParseIP(string(arg1)) == nil {
+		if _, err := netip.ParseAddr(string(arg1)); err != nil {
 			return errtype(args[1], "not an IP address")
 		}
 		if !TypeOf(args[2], h).AnyOf(StringType) {
@@ -448,25 +494,36 @@ func checkIsSubnetOf(h Hint, args []Node) error {
 	return nil
 }
 
+// subnetRange returns the first and the last address of the subnet p
+func subnetRange(p netip.Prefix) (first, last netip.Addr) {
+	first = p.Masked().Addr()
+	ip := first.AsSlice()
+	for i := p.Bits(); i < len(ip)*8; i++ {
+		ip[i/8] |= 0x80 >> (i % 8)
+	}
+	last, _ = netip.AddrFromSlice(ip)
+	return first, last
+}
+
+// compareIP compares a and b as IPv6 addresses;
+// IPv4 addresses are compared as the equivalent
+// IPv4-mapped IPv6 addresses (::ffff:a.b.c.d)
+func compareIP(a, b netip.Addr) int {
+	a16, b16 := a.As16(), b.As16()
+	return bytes.Compare(a16[:], b16[:])
+}
+
 func simplifyIsSubnetOf(h Hint, args []Node) Node {
-	if len(args) == 2 { // first argument is a CIDR subnet e.g. 192.1.2.3/8
+	if len(args) == 2 { // first argument is a CIDR subnet e.g. 192.1.2.3/8 or 2001:db8::/32
 		arg0, ok := args[0].(String)
 		if !ok {
 			return nil // found an error: let checkIsSubnetOf handle this
 		}
-		_, ipv4Net, err := net.ParseCIDR(string(arg0))
+		prefix, err := netip.ParsePrefix(string(arg0))
 		if err != nil {
 			return nil // found an error: let checkIsSubnetOf handle this
 		}
-		mask := binary.BigEndian.Uint32(ipv4Net.Mask)
-		start := binary.BigEndian.Uint32(ipv4Net.IP)
-		finish := (start & mask) | (mask ^ 0xffffffff)
-
-		minIP := make(net.IP, 4)
-		binary.BigEndian.PutUint32(minIP, start)
-		maxIP := make(net.IP, 4)
-		binary.BigEndian.PutUint32(maxIP, finish)
-
+		minIP, maxIP := subnetRange(prefix)
 		arg1 := missingUnless(args[1], h, StringType)
 		return Call(IsSubnetOf, Node(String(minIP.String())), Node(String(maxIP.String())), arg1)
 	} else if len(args) == 3 { // first and second argument are an IP address
@@ -478,20 +535,16 @@ func simplifyIsSubnetOf(h Hint, args []Node) Node {
 		if !ok {
 			return nil // found an invalid IP address: let checkIsSubnetOf handle this
 		}
-		minIP := net.ParseIP(string(arg0))
-		if minIP == nil {
+		minIP, err := netip.ParseAddr(string(arg0))
+		if err != nil {
 			return nil // found an invalid IP address: let checkIsSubnetOf handle this
 		}
-		maxIP := net.ParseIP(string(arg1))
-		if maxIP == nil {
+		maxIP, err := netip.ParseAddr(string(arg1))
+		if err != nil {
 			return nil // found an invalid IP address: let checkIsSubnetOf handle this
 		}
-
-		switch bytes.Compare(minIP.To4(), maxIP.To4()) {
-		case 0: // min == max: simplify to trivial str cmp
-			return Compare(Equals, args[0], args[1])
-		case 1: // min > max has no solutions
-			return Bool(false)
+		if compareIP(minIP, maxIP) > 0 {
+			return Bool(false) // min > max has no solutions
 		}
 	}
 	return nil
@@ -551,15 +604,64 @@ func checkSubstring(h Hint, args []Node) error {
 	return nil
 }
 
+// maxRoundDigits is the largest number of digits
+// accepted by ROUND(x, digits) and friends; 10^digits
+// must be representable as an integer
+const maxRoundDigits = 18
+
+// checkRound checks ROUND, FLOOR, CEIL, etc.,
+// which accept an optional constant number
+// of decimal digits to round to
+func checkRound(h Hint, args []Node) error {
+	nArgs := len(args)
+	if nArgs != 1 && nArgs != 2 {
+		return errsyntaxf("got %d args; need 1 or 2", nArgs)
+	}
+	if !TypeOf(args[0], h).AnyOf(NumericType) {
+		return errtype(args[0], "not compatible with type %s", NumericType)
+	}
+	if nArgs == 2 {
+		digits, ok := args[1].(Integer)
+		if !ok {
+			return errtype(args[1], "number of digits must be a constant integer")
+		}
+		if digits < -maxRoundDigits || digits > maxRoundDigits {
+			return errtype(args[1], "number of digits must be between %d and %d", -maxRoundDigits, maxRoundDigits)
+		}
+	}
+	return nil
+}
+
+// SplitPartRegexp returns whether the delimiter
+// of SPLIT_PART is a regular expression rather
+// than a single character
+func SplitPartRegexp(delim String) bool {
+	return len(delim) != 1
+}
+
 func checkSplitPart(h Hint, args []Node) error {
 	nArgs := len(args)
 	if nArgs != 3 {
 		return errsyntaxf("SPLIT_PART expects 3 arguments, but found %d", nArgs)
 	}
-	if str, ok := args[1].(String); !ok {
+	str, ok := args[1].(String)
+	if !ok {
 		return errsyntaxf("SPLIT_PART argument 1 is not a string")
-	} else if len(str) != 1 {
-		return errsyntaxf("SPLIT_PART only accepts single-character delimiters")
+	}
+	if len(str) == 0 {
+		return errsyntaxf("SPLIT_PART delimiter cannot be empty")
+	}
+	if SplitPartRegexp(str) {
+		if err := regexp2.IsSupported(string(str)); err != nil {
+			return errsyntaxf("SPLIT_PART: %s", err)
+		}
+		rx, err := regexp.Compile(string(str))
+		if err != nil {
+			return errsyntaxf("SPLIT_PART: %s", err)
+		}
+		if _, err := regexp2.NewDsDelim(rx, regexp2.MaxNodesAutomaton); err != nil {
+			return errsyntaxf("SPLIT_PART: %s", err)
+		}
 	}
 	if !TypeOf(args[2], h).AnyOf(NumericType) {
 		return errtype(args[2], "not a integer")
@@ -567,10 +669,266 @@ func checkSplitPart(h Hint, args []Node) error {
 	return nil
 }
 
+func checkRegexpExtract(h Hint, args []Node) error {
+	nArgs := len(args)
+	if nArgs != 2 && nArgs != 3 {
+		return errsyntaxf("REGEXP_EXTRACT expects 2 or 3 arguments, but found %d", nArgs)
+	}
+	if !TypeOf(args[0], h).AnyOf(StringType) {
+		return errtype(args[0], "not a string")
+	}
+	pattern, ok := args[1].(String)
+	if !ok {
+		return errsyntaxf("REGEXP_EXTRACT argument 1 is not a string")
+	}
+	if err := regexp2.IsSupported(string(pattern)); err != nil {
+		return errsyntaxf("REGEXP_EXTRACT: %s", err)
+	}
+	rx, err := regexp.Compile(string(pattern))
+	if err != nil {
+		return errsyntaxf("REGEXP_EXTRACT: %s", err)
+	}
+	if nArgs == 3 {
+		group, ok := args[2].(Integer)
+		if !ok {
+			return errsyntaxf("REGEXP_EXTRACT argument 2 is not an integer")
+		}
+		if group < 0 || int64(group) > int64(rx.NumSubexp()) {
+			return errsyntaxf("REGEXP_EXTRACT group %d out of range; pattern has %d groups", group, rx.NumSubexp())
+		}
+	}
+	return nil
+}
+
+// regexpLikeOp returns the StringMatchOp
+// that corresponds to the flags of REGEXP_LIKE;
+// as in Postgres, the last of 'c' (case-sensitive)
+// and 'i' (case-insensitive) takes precedence
+func regexpLikeOp(flags String) (StringMatchOp, bool) {
+	op := RegexpMatch
+	for _, c := range flags {
+		switch c {
+		case 'c':
+			op = RegexpMatch
+		case 'i':
+			op = RegexpMatchCi
+		default:
+			return 0, false
+		}
+	}
+	return op, true
+}
+
+func checkRegexpLike(h Hint, args []Node) error {
+	nArgs := len(args)
+	if nArgs != 2 && nArgs != 3 {
+		return errsyntaxf("REGEXP_LIKE expects 2 or 3 arguments, but found %d", nArgs)
+	}
+	pattern, ok := args[1].(String)
+	if !ok {
+		return errsyntaxf("REGEXP_LIKE argument 1 is not a string")
+	}
+	op := RegexpMatch
+	if nArgs == 3 {
+		flags, ok := args[2].(String)
+		if !ok {
+			return errsyntaxf("REGEXP_LIKE argument 2 is not a string")
+		}
+		op, ok = regexpLikeOp(flags)
+		if !ok {
+			return errsyntaxf("REGEXP_LIKE: invalid flags %q", string(flags))
+		}
+	}
+	// REGEXP_LIKE is checked exactly like
+	// the equivalent ~ or ~* expression
+	return (&StringMatch{Op: op, Expr: args[0], Pattern: string(pattern)}).check(h)
+}
+
+// simplifyRegexpLike rewrites REGEXP_LIKE(x, pattern[, flags])
+// into the equivalent x ~ pattern or x ~* pattern
+func simplifyRegexpLike(h Hint, args []Node) Node {
+	if len(args) != 2 && len(args) != 3 {
+		return nil
+	}
+	pattern, ok := args[1].(String)
+	if !ok {
+		return nil
+	}
+	op := RegexpMatch
+	if len(args) == 3 {
+		flags, ok := args[2].(String)
+		if !ok {
+			return nil
+		}
+		op, ok = regexpLikeOp(flags)
+		if !ok {
+			return nil
+		}
+	}
+	return &StringMatch{Op: op, Expr: args[0], Pattern: string(pattern)}
+}
+
+// checkBase64 checks the arguments of TO_BASE64
+// and FROM_BASE64; the optional second argument
+// selects the alphabet (see Base64URL)
+func checkBase64(op BuiltinOp, arg TypeSet) func(Hint, []Node) error {
+	return func(h Hint, args []Node) error {
+		if len(args) != 1 && len(args) != 2 {
+			return errsyntaxf("%s expects 1 or 2 arguments, but found %d", op, len(args))
+		}
+		if !TypeOf(args[0], h).AnyOf(arg) {
+			return errtype(args[0], "not compatible with type %s", arg)
+		}
+		if len(args) == 2 {
+			if _, err := Base64URL(op, args[1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Base64URL returns whether the alphabet argument
+// of TO_BASE64 or FROM_BASE64 selects the URL-safe
+// alphabet ('url') rather than the standard one ('standard')
+func Base64URL(op BuiltinOp, alphabet Node) (bool, error) {
+	str, ok := alphabet.(String)
+	if !ok {
+		return false, errsyntaxf("%s alphabet %s is not a string", op, ToString(alphabet))
+	}
+	switch {
+	case strings.EqualFold(string(str), "standard"):
+		return false, nil
+	case strings.EqualFold(string(str), "url"):
+		return true, nil
+	}
+	return false, errsyntaxf("%s: unknown alphabet %q; expected 'standard' or 'url'", op, string(str))
+}
+
+// checkToJSON checks the arguments of TO_JSON;
+// the optional second argument selects the
+// formatting of the text (see JSONPretty)
+func checkToJSON(h Hint, args []Node) error {
+	if len(args) != 1 && len(args) != 2 {
+		return errsyntaxf("TO_JSON expects 1 or 2 arguments, but found %d", len(args))
+	}
+	if len(args) == 2 {
+		if _, err := JSONPretty(args[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JSONPretty returns whether the format argument
+// of TO_JSON selects indented text ('pretty')
+// rather than compact text ('compact')
+func JSONPretty(format Node) (bool, error) {
+	str, ok := format.(String)
+	if !ok {
+		return false, errsyntaxf("TO_JSON format %s is not a string", ToString(format))
+	}
+	switch {
+	case strings.EqualFold(string(str), "compact"):
+		return false, nil
+	case strings.EqualFold(string(str), "pretty"):
+		return true, nil
+	}
+	return false, errsyntaxf("TO_JSON: unknown format %q; expected 'compact' or 'pretty'", string(str))
+}
+
+// simplifyNullIfNonFinite folds NULLIF_NONFINITE
+// of constants and of values that cannot be floats
+func simplifyNullIfNonFinite(h Hint, args []Node) Node {
+	if f, ok := args[0].(Float); ok {
+		if !finite(float64(f)) {
+			return Null{}
+		}
+		return f
+	}
+	if _, ok := args[0].(Constant); ok || !TypeOf(args[0], h).AnyOf(FloatType) {
+		return args[0]
+	}
+	return nil
+}
+
+// simplifyAssertFinite folds ASSERT_FINITE of
+// values that are known not to be NaN or infinite
+func simplifyAssertFinite(h Hint, args []Node) Node {
+	if f, ok := args[0].(Float); ok {
+		if !finite(float64(f)) {
+			return nil // fails when the query is executed
+		}
+		return f
+	}
+	if _, ok := args[0].(Constant); ok || !TypeOf(args[0], h).AnyOf(FloatType) {
+		return args[0]
+	}
+	return nil
+}
+
+func finite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+func checkConcat(h Hint, args []Node) error {
+	if len(args) == 0 {
+		return errsyntaxf("expects at least one argument")
+	}
+	return variadicArgs(StringType)(h, args)
+}
+
+func checkConcatWs(h Hint, args []Node) error {
+	if len(args) < 2 {
+		return errsyntaxf("expects a separator and at least one argument")
+	}
+	if !TypeOf(args[0], h).AnyOf(StringType) {
+		return errtype(args[0], "separator is not a string")
+	}
+	// NULL and MISSING arguments are skipped
+	return variadicArgs(StringType|NullType|MissingType)(h, args[1:])
+}
+
+// simplifyConcatWs drops the NULL and MISSING
+// constant arguments of CONCAT_WS and folds it
+// when all of its arguments are constant
+func simplifyConcatWs(h Hint, args []Node) Node {
+	rest := make([]Node, 0, len(args))
+	rest = append(rest, args[0])
+	strs := true
+	for _, arg := range args[1:] {
+		switch arg.(type) {
+		case Null, Missing:
+			continue
+		case String:
+		default:
+			strs = false
+		}
+		rest = append(rest, arg)
+	}
+	if sep, ok := args[0].(String); ok && strs {
+		parts := make([]string, len(rest)-1)
+		for i := range parts {
+			parts[i] = string(rest[i+1].(String))
+		}
+		return String(strings.Join(parts, string(sep)))
+	}
+	if len(rest) == 1 || len(rest) == len(args) {
+		return nil
+	}
+	return Call(ConcatWs, rest...)
+}
+
 var unaryStringArgs = fixedArgs(StringType)
-var variadicNumeric = variadicArgs(NumericType)
 var fixedTime = fixedArgs(TimeType)
 
+func checkLeastGreatest(h Hint, args []Node) error {
+	if len(args) == 0 {
+		return errsyntaxf("expects at least one argument")
+	}
+	return variadicArgs(SortableType)(h, args)
+}
+
 func simplifyDateTrunc(part Timepart) func(Hint, []Node) Node {
 	return func(h Hint, args []Node) Node {
 		if len(args) != 1 {
@@ -610,6 +968,40 @@ func checkInSubquery(h Hint, args []Node) error {
 	return nil
 }
 
+func checkCmpOp(fn string, arg Node) error {
+	op, ok := arg.(Integer)
+	if !ok || op < Integer(Equals) || op > Integer(GreaterEquals) {
+		return errsyntaxf("first argument to %s is %q", fn, arg)
+	}
+	return nil
+}
+
+func checkQuantifiedSubquery(h Hint, args []Node) error {
+	if len(args) != 3 {
+		return errsyntaxf("ANY or ALL must follow a comparison")
+	}
+	if err := checkCmpOp("ANY_SUBQUERY", args[0]); err != nil {
+		return err
+	}
+	if _, ok := args[2].(*Select); !ok {
+		return errsyntaxf("third argument to ANY_SUBQUERY is %q", args[2])
+	}
+	return nil
+}
+
+func checkQuantifiedReplacement(h Hint, args []Node) error {
+	if len(args) != 3 {
+		return mismatch(3, len(args))
+	}
+	if err := checkCmpOp("ANY_REPLACEMENT", args[0]); err != nil {
+		return err
+	}
+	if _, ok := args[2].(Integer); !ok {
+		return errsyntaxf("third argument to ANY_REPLACEMENT is %q", args[2])
+	}
+	return nil
+}
+
 func checkInReplacement(h Hint, args []Node) error {
 	if len(args) != 2 {
 		return mismatch(2, len(args))
@@ -704,6 +1096,96 @@ func checkArraySum(h Hint, args []Node) error {
 	return nil
 }
 
+func checkObject(h Hint, args []Node) error {
+	if len(args)&1 != 0 {
+		return errsyntaxf("OBJECT expects an even number of arguments, but found %d", len(args))
+	}
+	keys := make([]string, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		str, ok := args[i].(String)
+		if !ok {
+			return errtype(args[i], "OBJECT keys must be constant strings; %s is not (dynamic keys are not supported)", ToString(args[i]))
+		}
+		keys = append(keys, string(str))
+	}
+	return checkKeys("OBJECT", keys)
+}
+
+func checkMapFromArrays(h Hint, args []Node) error {
+	if len(args) != 2 {
+		return errsyntaxf("MAP_FROM_ARRAYS expects two arguments, but found %d", len(args))
+	}
+	keys, err := constKeys("MAP_FROM_ARRAYS", args[0])
+	if err != nil {
+		return err
+	}
+	if !TypeOf(args[1], h).AnyOf(ListType) {
+		return errtype(args[1], "second argument to MAP_FROM_ARRAYS must be a list")
+	}
+	return checkKeys("MAP_FROM_ARRAYS", keys)
+}
+
+// checkKeys checks that the structure
+// field names are unique
+func checkKeys(fn string, keys []string) error {
+	for i := range keys {
+		if slices.Contains(keys[:i], keys[i]) {
+			return errsyntaxf("%s: duplicate key %q", fn, keys[i])
+		}
+	}
+	return nil
+}
+
+// constKeys returns the strings in the constant
+// list n (or MAKE_LIST of strings), which are the
+// keys of the structure constructed by fn
+func constKeys(fn string, n Node) ([]string, error) {
+	var items []Node
+	list := false
+	switch l := n.(type) {
+	case *List:
+		for i := range l.Values {
+			items = append(items, l.Values[i])
+		}
+		list = true
+	case *Builtin:
+		items, list = l.Args, l.Func == MakeList
+	}
+	if !list {
+		return nil, errtype(n, "the keys of %s must be a constant list of strings; %s is not (dynamic keys are not supported)", fn, ToString(n))
+	}
+	keys := make([]string, len(items))
+	for i := range items {
+		str, ok := items[i].(String)
+		if !ok {
+			return nil, errtype(items[i], "the keys of %s must be constant strings; key %d (%s) is not (dynamic keys are not supported)", fn, i, ToString(items[i]))
+		}
+		keys[i] = string(str)
+	}
+	return keys, nil
+}
+
+// OBJECT(k0, v0, ...) -> MAKE_STRUCT(k0, v0, ...)
+func simplifyObject(h Hint, args []Node) Node {
+	if checkObject(h, args) != nil {
+		return nil
+	}
+	return Call(MakeStruct, args...)
+}
+
+// MAP_FROM_ARRAYS([k0, k1, ...], v) -> MAKE_STRUCT(k0, v[0], k1, v[1], ...)
+func simplifyMapFromArrays(h Hint, args []Node) Node {
+	if checkMapFromArrays(h, args) != nil {
+		return nil
+	}
+	keys, _ := constKeys("MAP_FROM_ARRAYS", args[0])
+	fields := make([]Node, 0, 2*len(keys))
+	for i := range keys {
+		fields = append(fields, String(keys[i]), &Index{Inner: args[1], Offset: i})
+	}
+	return Call(MakeStruct, fields...)
+}
+
 func checkVectorOp(funcName string) func(h Hint, args []Node) error {
 	return func(h Hint, args []Node) error {
 		if len(args) != 2 {
@@ -988,6 +1470,14 @@ var (
 	dateAddMonth   = adjtime(adjpart(Month))
 	dateAddQuarter = adjtime(adjpart(Quarter))
 	dateAddYear    = adjtime(adjpart(Year))
+
+	dateAddMonthClamp = adjtime(func(x int64, val date.Time) date.Time {
+		year, month := val.Year(), val.Month()+int(x)
+		// day 0 of the following month is
+		// the last day of the month
+		last := date.Date(year, month+1, 0, 0, 0, 0, 0).Day()
+		return date.Date(year, month, min(val.Day(), last), val.Hour(), val.Minute(), val.Second(), val.Nanosecond())
+	})
 )
 
 func missingIfNaN(x float64) Node {
@@ -1079,7 +1569,7 @@ func exp10(x float64) float64 {
 }
 
 var builtinInfo = [maxBuiltin]binfo{
-	Concat:               {check: fixedArgs(StringType, StringType), private: true, ret: StringType | MissingType},
+	Concat:               {check: checkConcat, ret: StringType | MissingType},
 	Trim:                 {check: checkTrim(Trim), ret: StringType | MissingType},
 	Ltrim:                {check: checkTrim(Ltrim), ret: StringType | MissingType},
 	Rtrim:                {check: checkTrim(Rtrim), ret: StringType | MissingType},
@@ -1092,6 +1582,20 @@ var builtinInfo = [maxBuiltin]binfo{
 	IsSubnetOf:           {check: checkIsSubnetOf, ret: LogicalType, simplify: simplifyIsSubnetOf},
 	Substring:            {check: checkSubstring, ret: StringType | MissingType},
 	SplitPart:            {check: checkSplitPart, ret: StringType | MissingType},
+	RegexpExtract:        {check: checkRegexpExtract, ret: StringType | MissingType},
+	RegexpLike:           {check: checkRegexpLike, ret: LogicalType, simplify: simplifyRegexpLike},
+	Replace:              {check: fixedArgs(StringType, StringType, StringType), ret: StringType | MissingType},
+	Translate:            {check: fixedArgs(StringType, StringType, StringType), ret: StringType | MissingType},
+	Soundex:              {check: fixedArgs(StringType), ret: StringType | MissingType},
+	Difference:           {check: fixedArgs(StringType, StringType), ret: IntegerType | MissingType},
+	ConcatWs:             {check: checkConcatWs, ret: StringType | MissingType, simplify: simplifyConcatWs},
+	ToHex:                {check: fixedArgs(StringType | BlobType), ret: StringType | MissingType},
+	FromHex:              {check: unaryStringArgs, ret: BlobType | MissingType},
+	ToBase64:             {check: checkBase64(ToBase64, StringType|BlobType), ret: StringType | MissingType},
+	FromBase64:           {check: checkBase64(FromBase64, StringType), ret: BlobType | MissingType},
+	ToJSON:               {check: checkToJSON, ret: StringType | MissingType},
+	NullIfNonFinite:      {check: fixedArgs(AnyType), simplify: simplifyNullIfNonFinite, ret: AnyType},
+	AssertFinite:         {check: fixedArgs(AnyType), simplify: simplifyAssertFinite, ret: AnyType},
 	EqualsCI:             {ret: LogicalType, private: true},
 	EqualsFuzzy:          {check: checkEqualsContainsFuzzy, ret: LogicalType},
 	EqualsFuzzyUnicode:   {check: checkEqualsContainsFuzzy, ret: LogicalType},
@@ -1101,11 +1605,11 @@ var builtinInfo = [maxBuiltin]binfo{
 	BitCount:  {check: fixedArgs(NumericType), ret: IntegerType | MissingType},
 	Abs:       {check: fixedArgs(NumericType), ret: NumericType},
 	Sign:      {check: fixedArgs(NumericType), ret: NumericType},
-	Round:     {check: fixedArgs(NumericType), ret: FloatType | MissingType, simplify: simplifyRound},
-	RoundEven: {check: fixedArgs(NumericType), ret: FloatType | MissingType, simplify: simplifyRoundEven},
-	Trunc:     {check: fixedArgs(NumericType), ret: FloatType | MissingType, simplify: simplifyTrunc},
-	Floor:     {check: fixedArgs(NumericType), ret: FloatType | MissingType, simplify: simplifyFloor},
-	Ceil:      {check: fixedArgs(NumericType), ret: FloatType | MissingType, simplify: simplifyCeil},
+	Round:     {check: checkRound, ret: FloatType | MissingType, simplify: simplifyRound},
+	RoundEven: {check: checkRound, ret: FloatType | MissingType, simplify: simplifyRoundEven},
+	Trunc:     {check: checkRound, ret: FloatType | MissingType, simplify: simplifyTrunc},
+	Floor:     {check: checkRound, ret: FloatType | MissingType, simplify: simplifyFloor},
+	Ceil:      {check: checkRound, ret: FloatType | MissingType, simplify: simplifyCeil},
 	Sqrt:      {check: fixedArgs(NumericType), ret: FloatType | MissingType, simplify: mathfunc(math.Sqrt)},
 	Cbrt:      {check: fixedArgs(NumericType), ret: FloatType | MissingType},
 	Exp:       {check: fixedArgs(NumericType), ret: FloatType | MissingType, simplify: mathfunc(math.Exp)},
@@ -1129,10 +1633,11 @@ var builtinInfo = [maxBuiltin]binfo{
 	Acos:      {check: fixedArgs(NumericType), ret: FloatType | MissingType, simplify: mathfunc(math.Acos)},
 	Atan:      {check: fixedArgs(NumericType), ret: FloatType | MissingType, simplify: mathfunc(math.Atan)},
 	Atan2:     {check: fixedArgs(NumericType, NumericType), ret: FloatType | MissingType, simplify: mathfunc2(math.Atan2)},
+	Modulo:    {check: fixedArgs(NumericType, NumericType), ret: NumericType | MissingType, simplify: simplifyModulo},
 	Pmod:      {check: fixedArgs(NumericType, NumericType), ret: NumericType | MissingType, simplify: simplifyPmod},
 
-	Least:       {check: variadicNumeric, ret: NumericType | MissingType, simplify: mathfuncreduce(math.Min)},
-	Greatest:    {check: variadicNumeric, ret: NumericType | MissingType, simplify: mathfuncreduce(math.Max)},
+	Least:       {check: checkLeastGreatest, ret: SortableType | MissingType, simplify: mathfuncreduce(math.Min)},
+	Greatest:    {check: checkLeastGreatest, ret: SortableType | MissingType, simplify: mathfuncreduce(math.Max)},
 	WidthBucket: {check: fixedArgs(NumericType, NumericType, NumericType, NumericType), ret: NumericType | MissingType},
 
 	DateAddMicrosecond:     {check: fixedArgs(IntegerType, TimeType), private: true, ret: TimeType | MissingType, simplify: dateAddMicrosecond},
@@ -1146,6 +1651,7 @@ var builtinInfo = [maxBuiltin]binfo{
 	DateAddQuarter:         {check: fixedArgs(IntegerType, TimeType), private: true, ret: TimeType | MissingType, simplify: dateAddQuarter},
 	DateAddYear:            {check: fixedArgs(IntegerType, TimeType), private: true, ret: TimeType | MissingType, simplify: dateAddYear},
 	DateBin:                {check: fixedArgs(IntegerType, TimeType, TimeType), ret: TimeType | MissingType, simplify: simplifyDateBin},
+	DateAddMonthClamp:      {check: fixedArgs(IntegerType, TimeType), private: true, ret: TimeType | MissingType, simplify: dateAddMonthClamp},
 	DateDiffMicrosecond:    {check: fixedArgs(TimeType, TimeType), private: true, ret: IntegerType | MissingType},
 	DateDiffMillisecond:    {check: fixedArgs(TimeType, TimeType), private: true, ret: IntegerType | MissingType},
 	DateDiffSecond:         {check: fixedArgs(TimeType, TimeType), private: true, ret: IntegerType | MissingType},
@@ -1164,9 +1670,11 @@ var builtinInfo = [maxBuiltin]binfo{
 	DateExtractDay:         {check: fixedArgs(TimeType), private: true, ret: IntegerType | MissingType},
 	DateExtractDOW:         {check: fixedArgs(TimeType), private: true, ret: IntegerType | MissingType},
 	DateExtractDOY:         {check: fixedArgs(TimeType), private: true, ret: IntegerType | MissingType},
+	DateExtractWeek:        {check: fixedArgs(TimeType), private: true, ret: IntegerType | MissingType},
 	DateExtractMonth:       {check: fixedArgs(TimeType), private: true, ret: IntegerType | MissingType},
 	DateExtractQuarter:     {check: fixedArgs(TimeType), private: true, ret: IntegerType | MissingType},
 	DateExtractYear:        {check: fixedArgs(TimeType), private: true, ret: IntegerType | MissingType},
+	DateExtractEpoch:       {check: fixedArgs(TimeType), private: true, ret: FloatType | MissingType},
 	DateTruncMicrosecond:   {check: fixedTime, private: true, ret: TimeType | MissingType, simplify: simplifyDateTrunc(Microsecond)},
 	DateTruncMillisecond:   {check: fixedTime, private: true, ret: TimeType | MissingType, simplify: simplifyDateTrunc(Millisecond)},
 	DateTruncSecond:        {check: fixedTime, private: true, ret: TimeType | MissingType, simplify: simplifyDateTrunc(Second)},
@@ -1192,6 +1700,9 @@ var builtinInfo = [maxBuiltin]binfo{
 	ArrayPosition: {check: checkArrayPosition, ret: UnsignedType | MissingType},
 	ArraySum:      {check: checkArraySum, ret: FloatType | MissingType},
 
+	Object:        {check: checkObject, ret: StructType, simplify: simplifyObject},
+	MapFromArrays: {check: checkMapFromArrays, ret: StructType, simplify: simplifyMapFromArrays},
+
 	VectorInnerProduct:   {check: checkVectorOp("INNER_PRODUCT"), ret: FloatType | MissingType},
 	VectorL1Distance:     {check: checkVectorOp("L1_DISTANCE"), ret: FloatType | MissingType},
 	VectorL2Distance:     {check: checkVectorOp("L2_DISTANCE"), ret: FloatType | MissingType},
@@ -1199,6 +1710,10 @@ var builtinInfo = [maxBuiltin]binfo{
 
 	InSubquery:        {check: checkInSubquery, private: true, ret: LogicalType},
 	InReplacement:     {check: checkInReplacement, private: true, ret: LogicalType},
+	AnySubquery:       {check: checkQuantifiedSubquery, private: true, ret: LogicalType},
+	AllSubquery:       {check: checkQuantifiedSubquery, private: true, ret: LogicalType},
+	AnyReplacement:    {check: checkQuantifiedReplacement, private: true, ret: LogicalType},
+	AllReplacement:    {check: checkQuantifiedReplacement, private: true, ret: LogicalType},
 	HashReplacement:   {check: checkHashReplacement, private: true, ret: AnyType},
 	ScalarReplacement: {check: checkScalarReplacement, private: true, ret: AnyType},
 	ListReplacement:   {check: checkScalarReplacement, private: true, ret: ListType},
@@ -1214,6 +1729,13 @@ var builtinInfo = [maxBuiltin]binfo{
 	TableGlob:      {check: checkTableGlob, ret: AnyType, isTable: true},
 	TablePattern:   {check: checkTablePattern, ret: AnyType, isTable: true},
 	PartitionValue: {ret: AnyType, private: true},
+	Tombstone:      {check: fixedArgs(IntegerType, LogicalType), ret: LogicalType, private: true},
+	CollateCI:      {check: fixedArgs(AnyType), ret: AnyType, private: true, text: collateText, simplify: simplifyCollate},
+	DecimalToFloat: {check: fixedArgs(AnyType), ret: FloatType | NullType | MissingType, private: true},
+	IsJSON:         {check: fixedArgs(AnyType), ret: BoolType, private: true, text: isJSONText(IsJSON), simplify: simplifyIsJSON(IsJSON)},
+	IsJSONObject:   {check: fixedArgs(AnyType), ret: BoolType, private: true, text: isJSONText(IsJSONObject), simplify: simplifyIsJSON(IsJSONObject)},
+	IsJSONArray:    {check: fixedArgs(AnyType), ret: BoolType, private: true, text: isJSONText(IsJSONArray), simplify: simplifyIsJSON(IsJSONArray)},
+	Overlaps:       {check: fixedArgs(TimeType, TimeType, TimeType, TimeType), ret: LogicalType, private: true, text: overlapsText, simplify: simplifyOverlaps},
 }
 
 // JSONTypeBits returns a unique bit pattern
@@ -1254,6 +1776,132 @@ func simplifyTypeBit(h Hint, args []Node) Node {
 	return nil
 }
 
+func collateText(args []Node, dst *strings.Builder, redact bool) {
+	args[0].text(dst, redact)
+	dst.WriteString(" COLLATE ci")
+}
+
+func simplifyCollate(h Hint, args []Node) Node {
+	if len(args) != 1 {
+		return nil
+	}
+	// constants and MISSING have only one value,
+	// so there is nothing to fold
+	switch args[0].(type) {
+	case Constant, Missing:
+		return args[0]
+	}
+	return nil
+}
+
+// isJSONText returns the text function
+// of the IS JSON builtin op
+func isJSONText(op BuiltinOp) func([]Node, *strings.Builder, bool) {
+	return func(args []Node, dst *strings.Builder, redact bool) {
+		args[0].text(dst, redact)
+		switch op {
+		case IsJSONObject:
+			dst.WriteString(" IS JSON OBJECT")
+		case IsJSONArray:
+			dst.WriteString(" IS JSON ARRAY")
+		default:
+			dst.WriteString(" IS JSON")
+		}
+	}
+}
+
+func overlapsText(args []Node, dst *strings.Builder, redact bool) {
+	dst.WriteByte('(')
+	args[0].text(dst, redact)
+	dst.WriteString(", ")
+	args[1].text(dst, redact)
+	dst.WriteString(") OVERLAPS (")
+	args[2].text(dst, redact)
+	dst.WriteString(", ")
+	args[3].text(dst, redact)
+	dst.WriteByte(')')
+}
+
+// TimeOverlaps returns whether the periods of time
+// from s1 to e1 and from s2 to e2 overlap, which
+// is the result of (s1, e1) OVERLAPS (s2, e2).
+//
+// Each period starts at the earlier of its two
+// timestamps and ends just before the later one,
+// unless they are equal, in which case the period
+// is just that instant. The periods overlap if one
+// of them starts within the other, or if they start
+// at the same time.
+func TimeOverlaps(s1, e1, s2, e2 date.Time) bool {
+	if e1.Before(s1) {
+		s1, e1 = e1, s1
+	}
+	if e2.Before(s2) {
+		s2, e2 = e2, s2
+	}
+	switch {
+	case s1.After(s2):
+		return s1.Before(e2)
+	case s2.After(s1):
+		return s2.Before(e1)
+	}
+	return true
+}
+
+func simplifyOverlaps(h Hint, args []Node) Node {
+	if len(args) != 4 {
+		return nil
+	}
+	var ts [4]date.Time
+	for i := range args {
+		t, ok := args[i].(*Timestamp)
+		if !ok {
+			return nil
+		}
+		ts[i] = t.Value
+	}
+	return Bool(TimeOverlaps(ts[0], ts[1], ts[2], ts[3]))
+}
+
+// ValidJSON returns whether buf is a valid JSON
+// text whose top-level value is accepted by op,
+// which is one of IsJSON, IsJSONObject, or IsJSONArray
+func ValidJSON(op BuiltinOp, buf []byte) bool {
+	if !json.Valid(buf) {
+		return false
+	}
+	// valid JSON has at least one non-space byte
+	first := bytes.TrimLeft(buf, " \t\r\n")[0]
+	switch op {
+	case IsJSONObject:
+		return first == '{'
+	case IsJSONArray:
+		return first == '['
+	}
+	return true
+}
+
+// simplifyIsJSON returns the simplifier
+// of the IS JSON builtin op
+func simplifyIsJSON(op BuiltinOp) func(Hint, []Node) Node {
+	return func(h Hint, args []Node) Node {
+		if len(args) != 1 {
+			return nil
+		}
+		switch arg := args[0].(type) {
+		case String:
+			return Bool(ValidJSON(op, []byte(arg)))
+		case Constant, Missing:
+			// only strings can hold JSON
+			return Bool(false)
+		}
+		if !TypeOf(args[0], h).AnyOf(StringType) {
+			return Bool(false)
+		}
+		return nil
+	}
+}
+
 func (b *Builtin) isTable() bool {
 	i := b.info()
 	return i == nil || i.isTable
@@ -1263,6 +1911,9 @@ func (b *Builtin) info() *binfo {
 	if b.Func >= 0 && b.Func < Unspecified {
 		return &builtinInfo[b.Func]
 	}
+	if u := lookupScalar(b.Text); u != nil {
+		return &u.info
+	}
 	return nil
 }
 
@@ -2,7 +2,7 @@ package expr
 
 // Code generated automatically; DO NOT EDIT
 
-var builtin2Name = [126]string{
+var builtin2Name = [157]string{
 	"CONCAT",                   // Concat
 	"TRIM",                     // Trim
 	"LTRIM",                    // Ltrim
@@ -21,6 +21,20 @@ var builtin2Name = [126]string{
 	"IS_SUBNET_OF",             // IsSubnetOf
 	"SUBSTRING",                // Substring
 	"SPLIT_PART",               // SplitPart
+	"REGEXP_EXTRACT",           // RegexpExtract
+	"REGEXP_LIKE",              // RegexpLike
+	"REPLACE",                  // Replace
+	"TRANSLATE",                // Translate
+	"SOUNDEX",                  // Soundex
+	"DIFFERENCE",               // Difference
+	"CONCAT_WS",                // ConcatWs
+	"TO_HEX",                   // ToHex
+	"FROM_HEX",                 // FromHex
+	"TO_BASE64",                // ToBase64
+	"FROM_BASE64",              // FromBase64
+	"TO_JSON",                  // ToJSON
+	"NULLIF_NONFINITE",         // NullIfNonFinite
+	"ASSERT_FINITE",            // AssertFinite
 	"BIT_COUNT",                // BitCount
 	"ABS",                      // Abs
 	"SIGN",                     // Sign
@@ -53,6 +67,7 @@ var builtin2Name = [126]string{
 	"ACOS",                     // Acos
 	"ATAN",                     // Atan
 	"ATAN2",                    // Atan2
+	"MOD",                      // Modulo
 	"PMOD",                     // Pmod
 	"LEAST",                    // Least
 	"GREATEST",                 // Greatest
@@ -68,6 +83,7 @@ var builtin2Name = [126]string{
 	"DATE_ADD_QUARTER",         // DateAddQuarter
 	"DATE_ADD_YEAR",            // DateAddYear
 	"DATE_BIN",                 // DateBin
+	"DATE_ADD_MONTH_CLAMP",     // DateAddMonthClamp
 	"DATE_DIFF_MICROSECOND",    // DateDiffMicrosecond
 	"DATE_DIFF_MILLISECOND",    // DateDiffMillisecond
 	"DATE_DIFF_SECOND",         // DateDiffSecond
@@ -86,9 +102,11 @@ var builtin2Name = [126]string{
 	"DATE_EXTRACT_DAY",         // DateExtractDay
 	"DATE_EXTRACT_DOW",         // DateExtractDOW
 	"DATE_EXTRACT_DOY",         // DateExtractDOY
+	"DATE_EXTRACT_WEEK",        // DateExtractWeek
 	"DATE_EXTRACT_MONTH",       // DateExtractMonth
 	"DATE_EXTRACT_QUARTER",     // DateExtractQuarter
 	"DATE_EXTRACT_YEAR",        // DateExtractYear
+	"DATE_EXTRACT_EPOCH",       // DateExtractEpoch
 	"DATE_TRUNC_MICROSECOND",   // DateTruncMicrosecond
 	"DATE_TRUNC_MILLISECOND",   // DateTruncMillisecond
 	"DATE_TRUNC_SECOND",        // DateTruncSecond
@@ -111,6 +129,8 @@ var builtin2Name = [126]string{
 	"ARRAY_SIZE",               // ArraySize
 	"ARRAY_POSITION",           // ArrayPosition
 	"ARRAY_SUM",                // ArraySum
+	"OBJECT",                   // Object
+	"MAP_FROM_ARRAYS",          // MapFromArrays
 	"INNER_PRODUCT",            // VectorInnerProduct
 	"L1_DISTANCE",              // VectorL1Distance
 	"L2_DISTANCE",              // VectorL2Distance
@@ -119,6 +139,10 @@ var builtin2Name = [126]string{
 	"TABLE_PATTERN",            // TablePattern
 	"IN_SUBQUERY",              // InSubquery
 	"IN_REPLACEMENT",           // InReplacement
+	"ANY_SUBQUERY",             // AnySubquery
+	"ALL_SUBQUERY",             // AllSubquery
+	"ANY_REPLACEMENT",          // AnyReplacement
+	"ALL_REPLACEMENT",          // AllReplacement
 	"HASH_REPLACEMENT",         // HashReplacement
 	"SCALAR_REPLACEMENT",       // ScalarReplacement
 	"STRUCT_REPLACEMENT",       // StructReplacement
@@ -129,6 +153,13 @@ var builtin2Name = [126]string{
 	"TYPE_BIT",                 // TypeBit
 	"ASSERT_ION_TYPE",          // AssertIonType
 	"PARTITION_VALUE",          // PartitionValue
+	"TOMBSTONE",                // Tombstone
+	"COLLATE_CI",               // CollateCI
+	"DECIMAL_TO_FLOAT",         // DecimalToFloat
+	"IS_JSON",                  // IsJSON
+	"IS_JSON_OBJECT",           // IsJSONObject
+	"IS_JSON_ARRAY",            // IsJSONArray
+	"OVERLAPS",                 // Overlaps
 }
 
 func name2Builtin(s string) BuiltinOp {
@@ -171,6 +202,34 @@ func name2Builtin(s string) BuiltinOp {
 		return Substring
 	case "SPLIT_PART":
 		return SplitPart
+	case "REGEXP_EXTRACT":
+		return RegexpExtract
+	case "REGEXP_LIKE":
+		return RegexpLike
+	case "REPLACE":
+		return Replace
+	case "TRANSLATE":
+		return Translate
+	case "SOUNDEX":
+		return Soundex
+	case "DIFFERENCE":
+		return Difference
+	case "CONCAT_WS":
+		return ConcatWs
+	case "TO_HEX":
+		return ToHex
+	case "FROM_HEX":
+		return FromHex
+	case "TO_BASE64":
+		return ToBase64
+	case "FROM_BASE64":
+		return FromBase64
+	case "TO_JSON":
+		return ToJSON
+	case "NULLIF_NONFINITE":
+		return NullIfNonFinite
+	case "ASSERT_FINITE":
+		return AssertFinite
 	case "BIT_COUNT":
 		return BitCount
 	case "ABS":
@@ -239,6 +298,8 @@ func name2Builtin(s string) BuiltinOp {
 		return Atan
 	case "ATAN2":
 		return Atan2
+	case "MOD":
+		return Modulo
 	case "PMOD":
 		return Pmod
 	case "LEAST":
@@ -269,6 +330,8 @@ func name2Builtin(s string) BuiltinOp {
 		return DateAddYear
 	case "DATE_BIN":
 		return DateBin
+	case "DATE_ADD_MONTH_CLAMP":
+		return DateAddMonthClamp
 	case "DATE_DIFF_MICROSECOND":
 		return DateDiffMicrosecond
 	case "DATE_DIFF_MILLISECOND":
@@ -305,12 +368,16 @@ func name2Builtin(s string) BuiltinOp {
 		return DateExtractDOW
 	case "DATE_EXTRACT_DOY":
 		return DateExtractDOY
+	case "DATE_EXTRACT_WEEK":
+		return DateExtractWeek
 	case "DATE_EXTRACT_MONTH":
 		return DateExtractMonth
 	case "DATE_EXTRACT_QUARTER":
 		return DateExtractQuarter
 	case "DATE_EXTRACT_YEAR":
 		return DateExtractYear
+	case "DATE_EXTRACT_EPOCH":
+		return DateExtractEpoch
 	case "DATE_TRUNC_MICROSECOND":
 		return DateTruncMicrosecond
 	case "DATE_TRUNC_MILLISECOND":
@@ -355,6 +422,10 @@ func name2Builtin(s string) BuiltinOp {
 		return ArrayPosition
 	case "ARRAY_SUM":
 		return ArraySum
+	case "OBJECT":
+		return Object
+	case "MAP_FROM_ARRAYS":
+		return MapFromArrays
 	case "INNER_PRODUCT":
 		return VectorInnerProduct
 	case "L1_DISTANCE":
@@ -371,6 +442,14 @@ func name2Builtin(s string) BuiltinOp {
 		return InSubquery
 	case "IN_REPLACEMENT":
 		return InReplacement
+	case "ANY_SUBQUERY":
+		return AnySubquery
+	case "ALL_SUBQUERY":
+		return AllSubquery
+	case "ANY_REPLACEMENT":
+		return AnyReplacement
+	case "ALL_REPLACEMENT":
+		return AllReplacement
 	case "HASH_REPLACEMENT":
 		return HashReplacement
 	case "SCALAR_REPLACEMENT":
@@ -391,8 +470,22 @@ func name2Builtin(s string) BuiltinOp {
 		return AssertIonType
 	case "PARTITION_VALUE":
 		return PartitionValue
+	case "TOMBSTONE":
+		return Tombstone
+	case "COLLATE_CI":
+		return CollateCI
+	case "DECIMAL_TO_FLOAT":
+		return DecimalToFloat
+	case "IS_JSON":
+		return IsJSON
+	case "IS_JSON_OBJECT":
+		return IsJSONObject
+	case "IS_JSON_ARRAY":
+		return IsJSONArray
+	case "OVERLAPS":
+		return Overlaps
 	}
 	return Unspecified
 }
 
-// checksum: 5ab82de4e9716a9be1d64c0692e6674b
+// checksum: e821f09343e917ffc8b293d533062d7b
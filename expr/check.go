@@ -268,6 +268,21 @@ func (s *StringMatch) check(h Hint) error {
 		if err := regexp2.IsSupported(s.Pattern); err != nil {
 			return errsyntax(s, err.Error())
 		}
+		typ := regexp2.Regexp
+		if s.Op == RegexpMatchCi {
+			typ = regexp2.RegexpCi
+		}
+		if _, err := regexp2.Compile(s.Pattern, typ); err != nil {
+			return errsyntax(s, err.Error())
+		}
+	}
+	if s.Op == SimilarTo {
+		if err := regexp2.IsSupported(s.Pattern); err != nil {
+			return errsyntax(s, err.Error())
+		}
+		if _, err := regexp2.Compile(s.Pattern, regexp2.SimilarTo); err != nil {
+			return errsyntax(s, err.Error())
+		}
 	}
 	return nil
 }
@@ -325,6 +340,45 @@ func (a *Aggregate) check(h Hint) error {
 	} else if a.Inner == nil {
 		return errsyntax(a, "aggregate needs an argument")
 	}
+	if len(a.OrderBy) > 0 && !a.Op.Ordered() {
+		return errsyntax(a, "aggregate does not accept ORDER BY")
+	}
+	if a.Op == OpStringAgg && a.Role != AggregateRoleMerge &&
+		!TypeOf(a.Inner, h).Contains(ion.StringType) {
+		return errtype(a.Inner, "STRING_AGG argument is never a string")
+	}
+	if a.Op.Bivariate() && a.Role != AggregateRoleMerge {
+		args, ok := a.Pair()
+		if !ok {
+			return errsyntaxf("%s: accepts 2 arguments", a.Op)
+		}
+		for i := range args {
+			if !numeric(args[i], h) {
+				return errtype(args[i], "%s argument is never a number", a.Op)
+			}
+		}
+	}
+	if a.Op == OpUser {
+		if _, ok := LookupAggregate(a.Name); !ok {
+			return errsyntaxf("unknown aggregate function %q", a.Name)
+		}
+		if a.Over != nil {
+			return errsyntaxf("%s: cannot be used as a window function", a.Name)
+		}
+	}
+	if a.Op == OpTopK {
+		if a.K <= 0 {
+			return errsyntaxf("TOPK: k must be positive")
+		}
+		if size := a.TopKSketchSize(); size < a.K || size > TopKMaxSketchSize {
+			return errsyntaxf("TOPK: sketch size %d must be between %d and %d", size, a.K, TopKMaxSketchSize)
+		}
+	}
+	if a.Op == OpMode {
+		if size := a.TopKSketchSize(); size <= 0 || size > TopKMaxSketchSize {
+			return errsyntaxf("MODE: sketch size %d must be between 1 and %d", size, TopKMaxSketchSize)
+		}
+	}
 	return nil
 }
 
@@ -340,13 +394,17 @@ func (c *Case) check(h Hint) error {
 func (c *Cast) check(h Hint) error {
 	ft := TypeOf(c.From, h)
 	switch c.To {
-	case SymbolType, DecimalType:
+	case SymbolType:
 		return errsyntaxf("unsupported cast %q", c)
 	case StringType:
 		if ft&(StringType|IntegerType) == 0 {
 			return errtype(c, "unsupported cast will never succeed")
 		}
-	case StructType, ListType, TimeType:
+	case TimeType:
+		if ft&(TimeType|IntegerType) == 0 {
+			return errtype(c, "unsupported cast will never succeed")
+		}
+	case StructType, ListType, DecimalType:
 		// for each of these types, we only support
 		// no-op casting, so if we can determine statically
 		// that we will be doing a meaningful cast, then return
@@ -358,20 +416,51 @@ func (c *Cast) check(h Hint) error {
 	return nil
 }
 
+// hasAggregate returns whether e contains an
+// aggregate (or window) function outside of
+// a sub-query
+func hasAggregate(e Node) bool {
+	found := false
+	visit := WalkFunc(func(e Node) bool {
+		if found {
+			return false
+		}
+		if _, ok := e.(*Select); ok {
+			return false
+		}
+		if _, ok := e.(*Aggregate); ok {
+			found = true
+			return false
+		}
+		return true
+	})
+	Walk(visit, e)
+	return found
+}
+
 func (s *Select) check(h Hint) error {
 	star := false
 
-	// 1. '*' can be the only column in SELECT
+	// 1. '*' can appear only once in SELECT,
+	// and it can only be mixed with expressions
+	// that are computed for each row
 	for i := range s.Columns {
 		if _, ok := s.Columns[i].Expr.(Star); ok {
+			if star {
+				return fmt.Errorf("'*' cannot appear more than once")
+			}
 			star = true
-			if len(s.Columns) > 1 {
-				return fmt.Errorf("'*' cannot be mixed with other values")
+		}
+	}
+	if star && len(s.Columns) > 1 {
+		for i := range s.Columns {
+			if hasAggregate(s.Columns[i].Expr) {
+				return fmt.Errorf("'*' cannot be mixed with aggregate functions")
 			}
 		}
 	}
 
-	// 2. there is sole '*'
+	// 2. there is '*'
 	if star {
 		if s.From == nil {
 			return fmt.Errorf("'*' without FROM is not allowed")
@@ -399,9 +488,80 @@ func (s *Select) check(h Hint) error {
 		return fmt.Errorf("negative OFFSET %d is not supported", *s.Offset)
 	}
 
+	// 4. QUALIFY filters the results of window functions
+	if s.Qualify != nil {
+		return s.checkQualify()
+	}
+
 	return nil
 }
 
+// checkQualify checks that the QUALIFY clause
+// references at least one window function and
+// otherwise only references the outputs of GROUP BY
+// (the grouping columns and aggregates), either
+// directly or through the output columns of s
+func (s *Select) checkQualify() error {
+	aliases := make(map[string]Node)
+	for i := range s.Columns {
+		if s.Columns[i].Explicit() {
+			aliases[s.Columns[i].Result()] = s.Columns[i].Expr
+		}
+	}
+	grouped := func(e Node) bool {
+		for i := range s.GroupBy {
+			if e.Equals(s.GroupBy[i].Expr) {
+				return true
+			}
+			if id, ok := e.(Ident); ok && s.GroupBy[i].Explicit() && string(id) == s.GroupBy[i].Result() {
+				return true
+			}
+		}
+		return false
+	}
+	window := false
+	var err error
+	var walk func(n Node, alias bool)
+	walk = func(n Node, alias bool) {
+		Walk(WalkFunc(func(e Node) bool {
+			if e == nil || err != nil {
+				return false
+			}
+			switch e := e.(type) {
+			case *Select:
+				// sub-queries are evaluated independently
+				return false
+			case *Aggregate:
+				if e.Over != nil {
+					window = true
+				} else if s.GroupBy == nil {
+					err = errsyntax(e, "cannot be used in QUALIFY without GROUP BY")
+				}
+				return false
+			}
+			if grouped(e) {
+				return false
+			}
+			if id, ok := e.(Ident); ok && !alias {
+				if x, ok := aliases[string(id)]; ok {
+					walk(x, true)
+					return false
+				}
+			}
+			if IsPath(e) {
+				err = errsyntax(e, "cannot be used in QUALIFY; only window functions and grouping outputs are allowed")
+				return false
+			}
+			return true
+		}), n)
+	}
+	walk(s.Qualify, false)
+	if err == nil && !window {
+		err = errsyntax(s.Qualify, "QUALIFY requires a window function")
+	}
+	return err
+}
+
 func (d *Dot) check(h Hint) error {
 	it := TypeOf(d.Inner, h)
 	if !it.Contains(ion.StructType) {
@@ -442,15 +602,21 @@ func (i *Index) check(h Hint) error {
 			return 0, false
 		}
 	}
-	if i.Offset < 0 {
-		return errtype(i, "cannot perform negative index operation")
-	}
 	t := TypeOf(i.Inner, h)
 	if t&ListType == 0 {
 		return errtype(i.Inner, "cannot index non-list value")
 	}
-	if llen, ok := listLen(i.Inner); ok && i.Offset >= llen {
-		return errtype(i, "cannot index a list of length %d at offset %d", llen, i.Offset)
+	if llen, ok := listLen(i.Inner); ok {
+		if off, ok := listOffset(i.Offset, llen); !ok || off >= llen {
+			return errtype(i, "cannot index a list of length %d at offset %d", llen, i.Offset)
+		}
+	}
+	return nil
+}
+
+func (s *Slice) check(h Hint) error {
+	if !TypeOf(s.Inner, h).AnyOf(ListType) {
+		return errtype(s.Inner, "cannot slice non-list value")
 	}
 	return nil
 }
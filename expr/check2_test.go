@@ -142,12 +142,16 @@ func TestCheckInvalidQuery(t *testing.T) {
 			`cannot use "UNPIVOT table AT val" in non-table position`,
 		},
 		{
-			`SELECT *, test FROM table`,
-			`'*' cannot be mixed with other values`,
+			`SELECT *, COUNT(*) FROM table`,
+			`'*' cannot be mixed with aggregate functions`,
+		},
+		{
+			`SELECT *, ROW_NUMBER() OVER (ORDER BY x) AS n FROM table`,
+			`'*' cannot be mixed with aggregate functions`,
 		},
 		{
 			`SELECT *, *, * FROM table`,
-			`'*' cannot be mixed with other values`,
+			`'*' cannot appear more than once`,
 		},
 		{
 			`SELECT * FROM table GROUP BY field`,
@@ -193,6 +197,130 @@ func TestCheckInvalidQuery(t *testing.T) {
 			`SELECT 'test'.test`,
 			`cannot use '.' operator on non-struct type`,
 		},
+		{
+			`SELECT REGEXP_EXTRACT(x, y) FROM table`,
+			`REGEXP_EXTRACT argument 1 is not a string`,
+		},
+		{
+			`SELECT REGEXP_EXTRACT(x, '(a') FROM table`,
+			`missing closing \)`,
+		},
+		{
+			`SELECT REGEXP_EXTRACT(x, '(a)(b)', 3) FROM table`,
+			`group 3 out of range`,
+		},
+		{
+			`SELECT REGEXP_EXTRACT(x, '(a)', n) FROM table`,
+			`argument 2 is not an integer`,
+		},
+		{
+			`SELECT REGEXP_LIKE(x, y) FROM table`,
+			`REGEXP_LIKE argument 1 is not a string`,
+		},
+		{
+			`SELECT REGEXP_LIKE(x, '(a') FROM table`,
+			`missing closing \)`,
+		},
+		{
+			`SELECT REGEXP_LIKE(x, 'a', 'x') FROM table`,
+			`invalid flags "x"`,
+		},
+		{
+			`SELECT x !~* '(a' FROM table`,
+			`missing closing \)`,
+		},
+		{
+			`SELECT SPLIT_PART(x, '', 1) FROM table`,
+			`delimiter cannot be empty`,
+		},
+		{
+			`SELECT SPLIT_PART(x, '[,;', 1) FROM table`,
+			`missing closing \]`,
+		},
+		{
+			`SELECT SPLIT_PART(x, '\\b,', 1) FROM table`,
+			`unsupported assertion`,
+		},
+		{
+			`SELECT FROM_HEX(1) FROM table`,
+			`not compatible with type string`,
+		},
+		{
+			`SELECT TO_BASE64(x, 'hex') FROM table`,
+			`unknown alphabet "hex"`,
+		},
+		{
+			`SELECT FROM_BASE64(x, y) FROM table`,
+			`alphabet y is not a string`,
+		},
+		{
+			`SELECT TO_BASE64(x, 'url', 1) FROM table`,
+			`TO_BASE64 expects 1 or 2 arguments, but found 3`,
+		},
+		{
+			`SELECT TO_JSON(x, 'indented') FROM table`,
+			`unknown format "indented"`,
+		},
+		{
+			`SELECT TO_JSON() FROM table`,
+			`TO_JSON expects 1 or 2 arguments, but found 0`,
+		},
+		{
+			`SELECT REPLACE(x, 'a') FROM table`,
+			`got 2 args; need 3`,
+		},
+		{
+			`SELECT TRANSLATE(x, 'abc', 1) FROM table`,
+			`not compatible with type string`,
+		},
+		{
+			`SELECT SOUNDEX(x, 'a') FROM table`,
+			`got 2 args; need 1`,
+		},
+		{
+			`SELECT DIFFERENCE(x, 1) FROM table`,
+			`not compatible with type string`,
+		},
+		{
+			`SELECT CONCAT() FROM table`,
+			`expects at least one argument`,
+		},
+		{
+			`SELECT CONCAT_WS(',') FROM table`,
+			`expects a separator and at least one argument`,
+		},
+		{
+			`SELECT CONCAT_WS(1, x, y) FROM table`,
+			`separator is not a string`,
+		},
+		{
+			`SELECT CORR(x, 'y') FROM table`,
+			`CORR argument is never a number`,
+		},
+		{
+			`SELECT * FROM table WHERE x ~ '[[:bogus:]]'`,
+			`invalid character class range: .\[:bogus:\]`,
+		},
+		{
+			`SELECT * FROM table WHERE x SIMILAR TO '[^[:bogus:]]%'`,
+			`invalid character class range: .\[:bogus:\]`,
+		},
+		{
+			`SELECT x FROM table QUALIFY x > 0`,
+			`"x" cannot be used in QUALIFY`,
+		},
+		{
+			`SELECT x AS y, ROW_NUMBER() OVER (ORDER BY x) AS rn FROM table QUALIFY rn = 1 AND y > 0`,
+			`"x" cannot be used in QUALIFY`,
+		},
+		{
+			`SELECT x, COUNT(*) FROM table GROUP BY x QUALIFY COUNT(*) > 1`,
+			`QUALIFY requires a window function`,
+		},
+		{
+			`SELECT x, ROW_NUMBER() OVER (ORDER BY x) AS rn FROM table QUALIFY rn < COUNT(*)`,
+			`"COUNT\(\*\)" cannot be used in QUALIFY without GROUP BY`,
+		},
 	}
 	for i := range testcases {
 		i := i
@@ -206,6 +334,11 @@ func TestCheckValidQuery(t *testing.T) {
 	testcases := []testcaseError{
 		{query: `SELECT * FROM TABLE_GLOB(a) ++ TABLE_GLOB(b)`},
 		{query: `SELECT OCTET_LENGTH('foo') = 3`},
+		{query: `SELECT * FROM table WHERE x ~ '^[^[:digit:]][[:alpha:]]+$'`},
+		{query: `SELECT * FROM table WHERE x SIMILAR TO '[^[:space:]]%[[:punct:]]'`},
+		{query: `SELECT x, ROW_NUMBER() OVER (PARTITION BY y ORDER BY z DESC) AS rn FROM table QUALIFY rn = 1`},
+		{query: `SELECT x FROM table QUALIFY ROW_NUMBER() OVER (PARTITION BY y ORDER BY z DESC) <= 3`},
+		{query: `SELECT x, COUNT(*) AS c FROM table GROUP BY x QUALIFY RANK() OVER (ORDER BY COUNT(*) DESC) <= 3 AND c > 1 AND x <> 'foo'`},
 	}
 
 	for i := range testcases {
@@ -54,8 +54,9 @@ func TestCheckExpressions(t *testing.T) {
 			kind: &TypeError{},
 		},
 		{
-			expr: &Cast{From: path("x"), To: DecimalType},
-			kind: &SyntaxError{},
+			// only decimals can be cast to DECIMAL
+			expr: &Cast{From: Integer(1), To: DecimalType},
+			kind: &TypeError{},
 		},
 		{
 			expr: &Cast{From: path("y"), To: SymbolType},
@@ -134,9 +135,14 @@ func TestCheckExpressions(t *testing.T) {
 			"SIZE expects",
 		},
 		{
-			&Index{Inner: Ident("z"), Offset: -1},
+			&Index{Inner: &List{Values: []Constant{Null{}, Null{}}}, Offset: -3},
+			&TypeError{},
+			"cannot index",
+		},
+		{
+			&Slice{Inner: String("xyz"), From: 1, ToEnd: true},
 			&TypeError{},
-			"negative",
+			"cannot slice",
 		},
 		{
 			&Index{Inner: &List{Values: []Constant{Null{}, Null{}}}, Offset: 3},
@@ -166,6 +172,54 @@ func TestCheckExpressions(t *testing.T) {
 			nil,
 			"value 512 is not a supported Ion type",
 		},
+		{
+			// SELECT OBJECT('a', x, 'b')
+			Call(Object, String("a"), path("x"), String("b")),
+			&SyntaxError{},
+			"even number of arguments",
+		},
+		{
+			// SELECT OBJECT(k, x)
+			Call(Object, path("k"), path("x")),
+			&TypeError{},
+			"keys must be constant strings",
+		},
+		{
+			// SELECT OBJECT('a', x, 'a', y)
+			Call(Object, String("a"), path("x"), String("a"), path("y")),
+			&SyntaxError{},
+			"duplicate key",
+		},
+		{
+			// SELECT MAP_FROM_ARRAYS(k, v)
+			Call(MapFromArrays, path("k"), path("v")),
+			&TypeError{},
+			"keys of MAP_FROM_ARRAYS must be a constant list of strings; k is not",
+		},
+		{
+			// SELECT MAP_FROM_ARRAYS(['a', k], v)
+			Call(MapFromArrays, Call(MakeList, String("a"), path("k")), path("v")),
+			&TypeError{},
+			"key 1 (k) is not",
+		},
+		{
+			// SELECT MAP_FROM_ARRAYS(['a', 'b'], 3)
+			Call(MapFromArrays, Call(MakeList, String("a"), String("b")), Integer(3)),
+			&TypeError{},
+			"must be a list",
+		},
+		{
+			// SELECT LEAST()
+			Call(Least),
+			&SyntaxError{},
+			"at least one argument",
+		},
+		{
+			// SELECT GREATEST(x, [1, 2])
+			Call(Greatest, path("x"), Call(MakeList, Integer(1), Integer(2))),
+			&TypeError{},
+			"not compatible",
+		},
 	}
 	for i := range testcases {
 		err := Check(testcases[i].expr)
@@ -205,6 +259,10 @@ func TestCheckValidExpressions(t *testing.T) {
 			// regression test: nullptr dereference on NaN
 			expr: Div(path("x"), NaN),
 		},
+		{
+			// GREATEST(1, 'a', x)
+			expr: Call(Greatest, Integer(1), String("a"), path("x")),
+		},
 	}
 	for i := range testcases {
 		tc := &testcases[i]
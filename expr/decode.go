@@ -90,6 +90,8 @@ func getEmpty(name string) (composite, bool) {
 		return &Dot{}, true
 	case "index":
 		return &Index{}, true
+	case "slice":
+		return &Slice{}, true
 	case "cmp":
 		return &Comparison{}, true
 	case "stringmatch":
@@ -114,6 +116,8 @@ func getEmpty(name string) (composite, bool) {
 		return &Join{}, true
 	case "missing":
 		return Missing{}, true
+	case "param":
+		return &Param{}, true
 	case "table":
 		return &Table{}, true
 	case "case":
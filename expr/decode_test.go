@@ -49,6 +49,7 @@ func TestDecodeFailures(t *testing.T) {
 		"list",
 		"unpivot",
 		"union",
+		"param",
 	}
 
 	var buf ion.Buffer
@@ -0,0 +1,315 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package expr
+
+import (
+	"slices"
+)
+
+// JoinUsing produces the join of left and right
+// on the columns cols (JOIN ... USING (cols)):
+// the On predicate of the join compares each of
+// the columns of left with the same column of right.
+//
+// A column of left is resolved in the left-most table
+// when left is a join on the same column, otherwise
+// the columns of left have to be known (see NaturalJoin).
+func JoinUsing(kind JoinKind, left From, right Binding, cols []string) (*Join, error) {
+	if len(cols) == 0 {
+		return nil, errsyntaxf("USING requires at least one column")
+	}
+	if right.Result() == "" {
+		return nil, errsyntaxf("USING requires a name for the joined table %s", ToString(right.Expr))
+	}
+	var on Node
+	for i, c := range cols {
+		if slices.Contains(cols[:i], c) {
+			return nil, errsyntaxf("column %q appears twice in USING", c)
+		}
+		lhs, err := joinColumn(left, c)
+		if err != nil {
+			return nil, err
+		}
+		eq := Compare(Equals, lhs, tableColumn(&right, c))
+		if on == nil {
+			on = eq
+		} else {
+			on = And(on, eq)
+		}
+	}
+	return &Join{
+		Kind:  kind,
+		Left:  left,
+		Right: right,
+		On:    on,
+		Using: slices.Clone(cols),
+	}, nil
+}
+
+// NaturalJoin produces the NATURAL JOIN of left and right,
+// which is the JoinUsing of all the columns that
+// left and right have in common.
+//
+// Since tables have no fixed set of columns, the columns
+// are only known when both sides are sub-queries that
+// select a list of named columns (or joins of those).
+// A NATURAL JOIN without common columns is a CROSS JOIN.
+func NaturalJoin(kind JoinKind, left From, right Binding) (*Join, error) {
+	lcols, lok := fromColumns(left)
+	rcols, rok := bindingColumns(&right)
+	if !lok || !rok {
+		return nil, errsyntaxf("NATURAL JOIN requires sub-queries with known columns; use JOIN ... USING instead")
+	}
+	var common []string
+	for i := range lcols {
+		if slices.ContainsFunc(rcols, func(c usingColumn) bool { return c.name == lcols[i].name }) {
+			common = append(common, lcols[i].name)
+		}
+	}
+	if len(common) == 0 {
+		if kind != InnerJoin {
+			return nil, errsyntaxf("NATURAL %s without common columns", kind)
+		}
+		return &Join{Kind: CrossJoin, Left: left, Right: right}, nil
+	}
+	j, err := JoinUsing(kind, left, right, common)
+	if err != nil {
+		return nil, err
+	}
+	j.Natural = true
+	return j, nil
+}
+
+// usingColumn is an output column of a FROM clause
+type usingColumn struct {
+	name string
+	expr Node
+}
+
+func tableColumn(b *Binding, name string) Node {
+	return &Dot{Inner: Ident(b.Result()), Field: name}
+}
+
+// mergedColumn returns the value of the
+// USING column name of j, which is the
+// column of the side of the join that
+// is always present
+func mergedColumn(j *Join, name string) (Node, error) {
+	left, err := joinColumn(j.Left, name)
+	if err != nil {
+		return nil, err
+	}
+	right := tableColumn(&j.Right, name)
+	switch j.Kind {
+	case RightJoin:
+		return right, nil
+	case FullJoin:
+		return Coalesce([]Node{left, right}), nil
+	default:
+		return left, nil
+	}
+}
+
+// joinColumn returns the column name of f
+func joinColumn(f From, name string) (Node, error) {
+	switch f := f.(type) {
+	case *Table:
+		if f.Result() == "" {
+			return nil, errsyntaxf("USING requires a name for the table %s", ToString(f.Expr))
+		}
+		return tableColumn(&f.Binding, name), nil
+	case *Join:
+		if slices.Contains(f.Using, name) {
+			return mergedColumn(f, name)
+		}
+		cols, ok := fromColumns(f)
+		if !ok {
+			return nil, errsyntaxf("cannot determine which table of %s has the USING column %q", ToString(f), name)
+		}
+		var found Node
+		for i := range cols {
+			if cols[i].name == name {
+				if found != nil {
+					return nil, errsyntaxf("USING column %q is ambiguous", name)
+				}
+				found = cols[i].expr
+			}
+		}
+		if found == nil {
+			return nil, errsyntaxf("USING column %q not found in %s", name, ToString(f))
+		}
+		return found, nil
+	default:
+		return nil, errsyntaxf("unexpected FROM expression %s", ToString(f))
+	}
+}
+
+// fromColumns returns the output columns of f
+// (with each USING column once) if they are known
+func fromColumns(f From) ([]usingColumn, bool) {
+	switch f := f.(type) {
+	case *Table:
+		return bindingColumns(&f.Binding)
+	case *Join:
+		lcols, lok := fromColumns(f.Left)
+		rcols, rok := bindingColumns(&f.Right)
+		if !lok || !rok {
+			return nil, false
+		}
+		var out []usingColumn
+		for _, name := range f.Using {
+			col, err := mergedColumn(f, name)
+			if err != nil {
+				return nil, false
+			}
+			out = append(out, usingColumn{name: name, expr: col})
+		}
+		for _, cols := range [][]usingColumn{lcols, rcols} {
+			for i := range cols {
+				if !slices.Contains(f.Using, cols[i].name) {
+					out = append(out, cols[i])
+				}
+			}
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
+
+// bindingColumns returns the columns of the table
+// bound by b if b is a sub-query with named columns
+func bindingColumns(b *Binding) ([]usingColumn, bool) {
+	sel, ok := b.Expr.(*Select)
+	if !ok || b.Result() == "" {
+		return nil, false
+	}
+	out := make([]usingColumn, 0, len(sel.Columns))
+	for i := range sel.Columns {
+		if sel.Columns[i].Expr == (Star{}) {
+			return nil, false
+		}
+		name := sel.Columns[i].Result()
+		if name == "" {
+			return nil, false
+		}
+		out = append(out, usingColumn{name: name, expr: tableColumn(b, name)})
+	}
+	return out, true
+}
+
+// ResolveUsing resolves the references to the
+// USING columns of the joins in the FROM clause of s:
+// an unqualified reference to a USING column is
+// replaced with the column of the joined tables
+// (so the column is produced only once), and
+// SELECT * is expanded into the columns of the
+// joined tables if they are known.
+func ResolveUsing(s *Select) error {
+	merged := make(map[string]Node)
+	for f := s.From; f != nil; {
+		j, ok := f.(*Join)
+		if !ok {
+			break
+		}
+		for _, name := range j.Using {
+			if _, ok := merged[name]; ok {
+				continue // the outer join takes precedence
+			}
+			col, err := mergedColumn(j, name)
+			if err != nil {
+				return err
+			}
+			merged[name] = col
+		}
+		f = j.Left
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	for _, t := range s.From.Tables() {
+		delete(merged, t.Result())
+	}
+	if len(s.Columns) == 1 && s.Columns[0].Expr == (Star{}) {
+		if cols, ok := fromColumns(s.From); ok {
+			s.Columns = make([]Binding, len(cols))
+			for i := range cols {
+				s.Columns[i] = Bind(Copy(cols[i].expr), cols[i].name)
+			}
+		}
+	}
+	r := &usingRewriter{merged: merged}
+	aliases := make(map[string]bool)
+	rebind := func(b *Binding) {
+		if b.Expr == (Star{}) {
+			return
+		}
+		// keep the name of the output column
+		name, before := b.Result(), ToString(b.Expr)
+		b.Expr = Rewrite(r, b.Expr)
+		if ToString(b.Expr) != before {
+			b.As(name)
+		}
+	}
+	for i := range s.Columns {
+		if s.Columns[i].Explicit() {
+			aliases[s.Columns[i].Result()] = true
+		}
+		rebind(&s.Columns[i])
+	}
+	for i := range s.GroupBy {
+		rebind(&s.GroupBy[i])
+	}
+	for i := range s.DistinctExpr {
+		s.DistinctExpr[i] = Rewrite(r, s.DistinctExpr[i])
+	}
+	if s.Where != nil {
+		s.Where = Rewrite(r, s.Where)
+	}
+	if s.Having != nil {
+		s.Having = Rewrite(r, s.Having)
+	}
+	if s.Qualify != nil {
+		s.Qualify = Rewrite(r, s.Qualify)
+	}
+	for i := range s.OrderBy {
+		// ORDER BY may refer to the output columns
+		if id, ok := s.OrderBy[i].Column.(Ident); ok && aliases[string(id)] {
+			continue
+		}
+		s.OrderBy[i].Column = Rewrite(r, s.OrderBy[i].Column)
+	}
+	return nil
+}
+
+type usingRewriter struct {
+	merged map[string]Node
+}
+
+func (u *usingRewriter) Walk(e Node) Rewriter {
+	if _, ok := e.(*Select); ok {
+		return nil // a different scope
+	}
+	return u
+}
+
+func (u *usingRewriter) Rewrite(e Node) Node {
+	if id, ok := e.(Ident); ok {
+		if col, ok := u.merged[string(id)]; ok {
+			return Copy(col)
+		}
+	}
+	return e
+}
@@ -0,0 +1,395 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package expr
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Diagnostic describes a likely mistake
+// in a query that was found by Lint.
+//
+// Unlike the errors returned by Check,
+// diagnostics do not prevent a query
+// from being executed.
+type Diagnostic struct {
+	// Node is the expression that
+	// the diagnostic refers to.
+	Node    Node
+	Message string
+
+	// Position, Line and Column locate the
+	// first token of Node in the query text;
+	// Line and Column are 0 if the position
+	// of the diagnostic is not known
+	// (see partiql.Lint).
+	Position int // offset in the input string
+	Line     int // line
+	Column   int // column
+	Length   int // length of the token in the input string
+}
+
+func (d *Diagnostic) String() string {
+	if d.Line > 0 && d.Column > 0 {
+		return fmt.Sprintf("at %d:%d: %s", d.Line, d.Column, d.Message)
+	}
+	return d.Message
+}
+
+// LintSchema provides the fields of tables to Lint.
+type LintSchema interface {
+	// HasField returns whether the rows of table
+	// contain the field at path (or a field nested
+	// inside it); ok is false if the fields of
+	// the table are not known.
+	HasField(table Node, path []string) (has, ok bool)
+}
+
+// Lint returns the likely mistakes in q:
+//   - comparisons that are always TRUE or FALSE
+//     (or never TRUE, like comparisons with NULL)
+//   - GROUP BY columns that are not part of the SELECT list
+//   - cross joins of tables without a join condition
+//   - references to fields that do not exist
+//     in a table according to schema, if it is non-nil
+//
+// The query should have passed Check.
+func Lint(q *Query, schema LintSchema) []Diagnostic {
+	l := &linter{schema: schema}
+	for i := range q.With {
+		l.ctes = append(l.ctes, q.With[i].Table)
+	}
+	for i := range q.With {
+		l.lintSelect(q.With[i].As, nil)
+	}
+	if q.Body != nil && !q.Describe {
+		l.lintStep(q.Body, nil)
+	}
+	return l.out
+}
+
+type linter struct {
+	schema LintSchema
+	ctes   []string
+	out    []Diagnostic
+}
+
+func (l *linter) add(n Node, f string, args ...any) {
+	l.out = append(l.out, Diagnostic{
+		Node:    n,
+		Message: fmt.Sprintf(f, args...),
+	})
+}
+
+// lintStep lints the body of a query
+// or the operand of a set operation;
+// outer holds the names of the tables
+// of the enclosing queries
+func (l *linter) lintStep(n Node, outer []string) {
+	switch n := n.(type) {
+	case *Select:
+		l.lintSelect(n, outer)
+	case *Union:
+		l.lintStep(n.Left, outer)
+		l.lintStep(n.Right, outer)
+	}
+}
+
+// parts calls fn for each expression of s
+// that belongs to s itself rather than to
+// one of its sub-queries; sub-queries are
+// passed to sub instead
+func (s *Select) parts(fn func(Node), sub func(*Select)) {
+	var visit WalkFunc
+	visit = func(e Node) bool {
+		if q, ok := e.(*Select); ok {
+			sub(q)
+			return false
+		}
+		fn(e)
+		return true
+	}
+	walk := func(n Node) {
+		if n != nil {
+			Walk(visit, n)
+		}
+	}
+	switch f := s.From.(type) {
+	case *Table:
+		walk(f.Expr)
+	case *Join:
+		walk(f)
+	}
+	walk(s.Where)
+	walk(s.Having)
+	for i := range s.GroupBy {
+		walk(s.GroupBy[i].Expr)
+	}
+	for i := range s.Columns {
+		walk(s.Columns[i].Expr)
+	}
+	walk(s.Qualify)
+	for i := range s.OrderBy {
+		walk(s.OrderBy[i].Column)
+	}
+	for i := range s.DistinctExpr {
+		walk(s.DistinctExpr[i])
+	}
+}
+
+func (l *linter) lintSelect(s *Select, outer []string) {
+	tables := outer
+	if s.From != nil {
+		for _, b := range s.From.Tables() {
+			tables = append(slices.Clip(tables), b.Result())
+		}
+	}
+	var subs []*Select
+	s.parts(func(e Node) {
+		if c, ok := e.(*Comparison); ok {
+			l.lintComparison(c)
+		}
+	}, func(q *Select) {
+		subs = append(subs, q)
+	})
+	l.lintGroupBy(s)
+	l.lintCrossJoin(s)
+	l.lintFields(s, outer)
+	for _, q := range subs {
+		l.lintSelect(q, tables)
+	}
+}
+
+func isConstant(e Node) bool {
+	_, ok := e.(Constant)
+	return ok
+}
+
+func (l *linter) lintComparison(c *Comparison) {
+	switch c.Op {
+	case Equals, NotEquals, Less, LessEquals, Greater, GreaterEquals:
+	default:
+		return
+	}
+	if c.Left == (Null{}) || c.Right == (Null{}) {
+		l.add(c, "%s is never TRUE; use IS NULL or IS NOT NULL to test for NULL", ToString(c))
+		return
+	}
+	if isConstant(c.Left) && isConstant(c.Right) {
+		if b, ok := Simplify(c, NoHint).(Bool); ok {
+			l.add(c, "comparison of constants %s is always %s", ToString(c), ToString(b))
+		}
+		return
+	}
+	if Equivalent(c.Left, c.Right) {
+		result := "TRUE"
+		if c.Op == NotEquals || c.Op == Less || c.Op == Greater {
+			result = "FALSE"
+		}
+		l.add(c, "%s compares %s with itself and is always %s (or NULL or MISSING)",
+			ToString(c), ToString(c.Left), result)
+		return
+	}
+	if b, ok := Simplify(c, NoHint).(Bool); ok {
+		l.add(c, "%s is always %s", ToString(c), ToString(b))
+	}
+}
+
+// grouped returns whether e references
+// the value of the GROUP BY binding g
+func grouped(e Node, g *Binding) bool {
+	found := false
+	Walk(WalkFunc(func(n Node) bool {
+		if found || n == nil {
+			return false
+		}
+		if _, ok := n.(*Select); ok {
+			return false
+		}
+		if Equivalent(n, g.Expr) || (g.Explicit() && n == Ident(g.Result())) {
+			found = true
+			return false
+		}
+		return true
+	}), e)
+	return found
+}
+
+func (l *linter) lintGroupBy(s *Select) {
+	for i := range s.GroupBy {
+		g := &s.GroupBy[i]
+		found := false
+		for j := range s.Columns {
+			c := &s.Columns[j]
+			if _, ok := c.Expr.(Star); ok || grouped(c.Expr, g) ||
+				(c.Result() != "" && c.Result() == g.Result()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			l.add(g.Expr, "GROUP BY column %s does not appear in the SELECT list", ToString(g.Expr))
+		}
+	}
+}
+
+// references returns whether e references
+// any of the tables (as the root of a path)
+func references(e Node, tables []string) bool {
+	found := false
+	Walk(WalkFunc(func(n Node) bool {
+		if found {
+			return false
+		}
+		if p, ok := FlatPath(n); ok {
+			found = slices.Contains(tables, p[0])
+			return false
+		}
+		return true
+	}), e)
+	return found
+}
+
+func (l *linter) lintCrossJoin(s *Select) {
+	j, ok := s.From.(*Join)
+	if !ok {
+		return
+	}
+	// check each join of the FROM clause,
+	// from the last one to the first one
+	for ; j != nil; j, _ = j.Left.(*Join) {
+		if j.Kind != CrossJoin {
+			continue
+		}
+		var left []string
+		for _, b := range j.Left.Tables() {
+			left = append(left, b.Result())
+		}
+		if references(j.Right.Expr, left) {
+			// FROM t, t.list AS x unnests t.list
+			continue
+		}
+		right := []string{j.Right.Result()}
+		joined := false
+		for _, c := range conjuncts(s.Where, nil) {
+			if references(c, left) && references(c, right) {
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			l.add(j.Right.Expr, "%s is joined with every row of %s; add a join condition or use CROSS JOIN",
+				ToString(j.Right.Expr), strings.Join(left, ", "))
+		}
+	}
+}
+
+// conjuncts appends the terms of
+// the conjunction e to lst
+func conjuncts(e Node, lst []Node) []Node {
+	if e == nil {
+		return lst
+	}
+	if a, ok := e.(*Logical); ok && a.Op == OpAnd {
+		return conjuncts(a.Right, conjuncts(a.Left, lst))
+	}
+	return append(lst, e)
+}
+
+// lintFields checks the fields referenced by s
+// when it selects from a single table
+func (l *linter) lintFields(s *Select, outer []string) {
+	t, ok := s.From.(*Table)
+	if !ok || l.schema == nil {
+		return
+	}
+	if p, ok := FlatPath(t.Expr); !ok || (len(p) == 1 && slices.Contains(l.ctes, p[0])) {
+		return
+	}
+	alias := t.Result()
+	// the output columns may be referenced
+	// by GROUP BY, HAVING, QUALIFY and ORDER BY
+	var names []string
+	for i := range s.Columns {
+		if s.Columns[i].Explicit() {
+			names = append(names, s.Columns[i].Result())
+		}
+	}
+	for i := range s.GroupBy {
+		if s.GroupBy[i].Explicit() {
+			names = append(names, s.GroupBy[i].Result())
+		}
+	}
+	seen := make(map[string]bool)
+	check := func(e Node) {
+		p, ok := FlatPath(e)
+		if !ok {
+			return
+		}
+		if p[0] == alias && len(p) > 1 {
+			p = p[1:]
+		} else if slices.Contains(outer, p[0]) || slices.Contains(names, p[0]) {
+			return
+		}
+		key := strings.Join(p, ".")
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		if has, ok := l.schema.HasField(t.Expr, p); ok && !has {
+			l.add(e, "field %s does not exist in table %s", key, ToString(t.Expr))
+		}
+	}
+	visit := func(n Node) {
+		if n == nil {
+			return
+		}
+		// only the outermost path of a.b.c is checked
+		Walk(WalkFunc(func(e Node) bool {
+			if _, ok := e.(*Select); ok {
+				return false
+			}
+			if IsPath(e) {
+				for {
+					if i, ok := e.(*Index); ok {
+						e = i.Inner
+						continue
+					}
+					break
+				}
+				check(e)
+				return false
+			}
+			return true
+		}), n)
+	}
+	visit(s.Where)
+	visit(s.Having)
+	for i := range s.GroupBy {
+		visit(s.GroupBy[i].Expr)
+	}
+	for i := range s.Columns {
+		visit(s.Columns[i].Expr)
+	}
+	visit(s.Qualify)
+	for i := range s.OrderBy {
+		visit(s.OrderBy[i].Column)
+	}
+	for i := range s.DistinctExpr {
+		visit(s.DistinctExpr[i])
+	}
+}
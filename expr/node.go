@@ -201,6 +201,34 @@ const (
 	// aggregates.
 	OpSystemDatashapeMerge
 
+	// OpStringAgg corresponds to STRING_AGG(expr, sep [ORDER BY ...])
+	OpStringAgg
+
+	// OpTopK corresponds to TOPK(expr, k [, size])
+	OpTopK
+
+	// OpCovarPop corresponds to COVAR_POP(x, y)
+	OpCovarPop
+
+	// OpCovarSamp corresponds to COVAR_SAMP(x, y)
+	OpCovarSamp
+
+	// OpCorr corresponds to CORR(x, y)
+	OpCorr
+
+	// OpRegrSlope corresponds to REGR_SLOPE(y, x)
+	OpRegrSlope
+
+	// OpRegrIntercept corresponds to REGR_INTERCEPT(y, x)
+	OpRegrIntercept
+
+	// OpMode corresponds to MODE(expr [, size])
+	OpMode
+
+	// OpUser corresponds to a user-defined aggregate
+	// function (see RegisterAggregate) named Aggregate.Name
+	OpUser
+
 	// anchor for the last aggregate operator
 	maxAggregateOp
 )
@@ -211,6 +239,23 @@ const (
 	ApproxCountDistinctDefaultPrecision = 11
 )
 
+// StringAggDefaultMaxLength is the default maximum
+// length (in bytes) of a STRING_AGG result.
+const StringAggDefaultMaxLength = 1024 * 1024
+
+const (
+	// TopKDefaultSketchFactor determines the default
+	// size of the TOPK sketch, which is
+	// TopKDefaultSketchFactor * k
+	TopKDefaultSketchFactor = 10
+	// TopKMaxSketchSize is the maximum
+	// number of values tracked by TOPK
+	TopKMaxSketchSize = 1 << 16
+	// ModeDefaultSketchSize is the default
+	// number of values tracked by MODE
+	ModeDefaultSketchSize = 1024
+)
+
 func (a AggregateOp) defaultResult() string {
 	switch a {
 	case OpCount, OpCountDistinct, OpSumCount, OpApproxCountDistinct:
@@ -237,6 +282,22 @@ func (a AggregateOp) defaultResult() string {
 		return "rank"
 	case OpDenseRank:
 		return "dense_rank"
+	case OpStringAgg:
+		return "string_agg"
+	case OpTopK:
+		return "topk"
+	case OpCovarPop:
+		return "covar_pop"
+	case OpCovarSamp:
+		return "covar_samp"
+	case OpCorr:
+		return "corr"
+	case OpRegrSlope:
+		return "regr_slope"
+	case OpRegrIntercept:
+		return "regr_intercept"
+	case OpMode:
+		return "mode"
 	default:
 		return ""
 	}
@@ -294,6 +355,24 @@ func (a AggregateOp) String() string {
 		return "SNELLER_DATASHAPE"
 	case OpSystemDatashapeMerge:
 		return "SNELLER_DATASHAPE_MERGE"
+	case OpStringAgg:
+		return "STRING_AGG"
+	case OpTopK:
+		return "TOPK"
+	case OpCovarPop:
+		return "COVAR_POP"
+	case OpCovarSamp:
+		return "COVAR_SAMP"
+	case OpCorr:
+		return "CORR"
+	case OpRegrSlope:
+		return "REGR_SLOPE"
+	case OpRegrIntercept:
+		return "REGR_INTERCEPT"
+	case OpMode:
+		return "MODE"
+	case OpUser:
+		return "USER"
 	default:
 		return fmt.Sprintf("<AggregateOp=%d>", int(a))
 	}
@@ -305,13 +384,34 @@ func (a AggregateOp) private() bool {
 		OpApproxMedian, OpApproxPercentile,
 		OpMin, OpMax, OpEarliest, OpLatest,
 		OpBitAnd, OpBitOr, OpBitXor, OpBoolAnd, OpBoolOr,
-		OpApproxCountDistinct, OpSystemDatashape, OpRowNumber, OpRank, OpDenseRank:
+		OpApproxCountDistinct, OpSystemDatashape, OpRowNumber, OpRank, OpDenseRank,
+		OpStringAgg, OpTopK,
+		OpCovarPop, OpCovarSamp, OpCorr, OpRegrSlope, OpRegrIntercept,
+		OpMode:
 		return false
 	}
 
 	return true
 }
 
+// Bivariate returns whether or not the aggregate
+// accepts a pair of inputs (CORR, COVAR_POP, etc.);
+// the inputs are the two elements of the Inner MAKE_LIST
+func (a AggregateOp) Bivariate() bool {
+	switch a {
+	case OpCovarPop, OpCovarSamp, OpCorr, OpRegrSlope, OpRegrIntercept:
+		return true
+	default:
+		return false
+	}
+}
+
+// Ordered returns whether or not the aggregate
+// accepts an ORDER BY clause inside its argument list
+func (a AggregateOp) Ordered() bool {
+	return a == OpStringAgg
+}
+
 // WindowOnly returns whether or no the aggregate op
 // is only valid when used with a window function
 func (a AggregateOp) WindowOnly() bool {
@@ -326,7 +426,7 @@ func (a AggregateOp) WindowOnly() bool {
 // AcceptDistinct returns true if the aggregate can be used with DISTINCT keyword.
 func (a AggregateOp) AcceptDistinct() bool {
 	switch a {
-	case OpCount, OpMin, OpMax, OpCountDistinct, OpEarliest, OpLatest:
+	case OpCount, OpMin, OpMax, OpCountDistinct, OpEarliest, OpLatest, OpSum, OpAvg:
 		return true
 	}
 
@@ -404,6 +504,43 @@ type Aggregate struct {
 	Over *Window
 	// Filter is an optional filtering expression
 	Filter Node
+	// OrderBy is the optional ordering of the
+	// inputs for ordered aggregates (STRING_AGG)
+	//
+	// For AggregateRoleMerge, only the orderings
+	// are meaningful; the inputs were already
+	// evaluated by the partial aggregate.
+	OrderBy []Order
+	// Separator is the separator for STRING_AGG
+	Separator string
+	// MaxLength is the maximum length of the
+	// STRING_AGG result; zero means StringAggDefaultMaxLength
+	MaxLength int
+	// K is the number of values produced by TOPK
+	K int
+	// SketchSize is the number of distinct values
+	// tracked by TOPK; zero means TopKDefaultSketchFactor*K
+	SketchSize int
+	// Name is the name of the user-defined
+	// aggregate function of OpUser
+	Name string
+	// Distinct is set for SUM(DISTINCT ...)
+	// and AVG(DISTINCT ...), which aggregate
+	// each distinct value of Inner only once.
+	// (COUNT(DISTINCT ...) is OpCountDistinct.)
+	Distinct bool
+}
+
+// TopKSketchSize returns the effective
+// sketch size of an OpTopK or OpMode aggregate.
+func (a *Aggregate) TopKSketchSize() int {
+	if a.SketchSize != 0 {
+		return a.SketchSize
+	}
+	if a.Op == OpMode {
+		return ModeDefaultSketchSize
+	}
+	return min(a.K*TopKDefaultSketchFactor, TopKMaxSketchSize)
 }
 
 func (a *Aggregate) Equals(e Node) bool {
@@ -424,6 +561,18 @@ func (a *Aggregate) Equals(e Node) bool {
 	if ea.Misc != a.Misc {
 		return false
 	}
+	if ea.Separator != a.Separator || ea.MaxLength != a.MaxLength {
+		return false
+	}
+	if ea.K != a.K || ea.SketchSize != a.SketchSize {
+		return false
+	}
+	if ea.Name != a.Name || ea.Distinct != a.Distinct {
+		return false
+	}
+	if !slices.EqualFunc(a.OrderBy, ea.OrderBy, Order.Equals) {
+		return false
+	}
 	if (a.Filter != nil) != (ea.Filter != nil) {
 		return false
 	}
@@ -452,6 +601,10 @@ func (a *Aggregate) Encode(dst *ion.Buffer, st *ion.Symtab) {
 	dst.WriteUint(uint64(a.Op))
 	dst.BeginField(st.Intern("role"))
 	dst.WriteUint(uint64(a.Role))
+	if a.Distinct {
+		dst.BeginField(st.Intern("distinct"))
+		dst.WriteBool(true)
+	}
 	switch a.Op {
 	case OpApproxCountDistinct:
 		dst.BeginField(st.Intern("precision"))
@@ -459,6 +612,32 @@ func (a *Aggregate) Encode(dst *ion.Buffer, st *ion.Symtab) {
 	case OpApproxPercentile, OpApproxMedian:
 		dst.BeginField(st.Intern("misc"))
 		dst.WriteFloat64(float64(a.Misc))
+	case OpStringAgg:
+		dst.BeginField(st.Intern("separator"))
+		dst.WriteString(a.Separator)
+		if a.MaxLength != 0 {
+			dst.BeginField(st.Intern("max_length"))
+			dst.WriteInt(int64(a.MaxLength))
+		}
+	case OpTopK:
+		dst.BeginField(st.Intern("k"))
+		dst.WriteInt(int64(a.K))
+		if a.SketchSize != 0 {
+			dst.BeginField(st.Intern("sketch_size"))
+			dst.WriteInt(int64(a.SketchSize))
+		}
+	case OpMode:
+		if a.SketchSize != 0 {
+			dst.BeginField(st.Intern("sketch_size"))
+			dst.WriteInt(int64(a.SketchSize))
+		}
+	case OpUser:
+		dst.BeginField(st.Intern("name"))
+		dst.WriteString(a.Name)
+	}
+	if len(a.OrderBy) > 0 {
+		dst.BeginField(st.Intern("order_by"))
+		EncodeOrder(a.OrderBy, dst, st)
 	}
 	if a.Inner != nil {
 		dst.BeginField(st.Intern("inner"))
@@ -499,6 +678,10 @@ func (a *Aggregate) SetField(f ion.Field) error {
 			return err
 		}
 		a.Role = AggregateRole(r)
+	case "distinct":
+		var err error
+		a.Distinct, err = f.Bool()
+		return err
 	case "inner":
 		var err error
 		a.Inner, err = Decode(f.Datum)
@@ -538,6 +721,36 @@ func (a *Aggregate) SetField(f ion.Field) error {
 			return err
 		}
 		a.Misc = float32(p)
+	case "separator":
+		var err error
+		a.Separator, err = f.String()
+		return err
+	case "max_length":
+		n, err := f.Int()
+		if err != nil {
+			return err
+		}
+		a.MaxLength = int(n)
+	case "k":
+		n, err := f.Int()
+		if err != nil {
+			return err
+		}
+		a.K = int(n)
+	case "sketch_size":
+		n, err := f.Int()
+		if err != nil {
+			return err
+		}
+		a.SketchSize = int(n)
+	case "name":
+		var err error
+		a.Name, err = f.String()
+		return err
+	case "order_by":
+		var err error
+		a.OrderBy, err = decodeOrder(f.Datum)
+		return err
 	default:
 		return errUnexpectedField
 	}
@@ -548,7 +761,11 @@ func (a *Aggregate) text(dst *strings.Builder, redact bool) {
 	if a.Op == OpCountDistinct {
 		dst.WriteString("COUNT(DISTINCT ")
 	} else {
-		dst.WriteString(a.Op.String())
+		if a.Op == OpUser {
+			dst.WriteString(a.Name)
+		} else {
+			dst.WriteString(a.Op.String())
+		}
 		// Role is assigned to an aggregate during the planning phase,
 		// so this wan't break SQL of original expressions.
 		switch a.Role {
@@ -558,10 +775,19 @@ func (a *Aggregate) text(dst *strings.Builder, redact bool) {
 			dst.WriteString(".MERGE")
 		}
 		dst.WriteByte('(')
+		if a.Distinct {
+			dst.WriteString("DISTINCT ")
+		}
 	}
 
 	if a.Inner != nil {
-		a.Inner.text(dst, redact)
+		if args, ok := a.Pair(); ok {
+			args[0].text(dst, redact)
+			dst.WriteString(", ")
+			args[1].text(dst, redact)
+		} else {
+			a.Inner.text(dst, redact)
+		}
 	}
 
 	switch a.Op {
@@ -572,6 +798,32 @@ func (a *Aggregate) text(dst *strings.Builder, redact bool) {
 
 	case OpApproxPercentile:
 		fmt.Fprintf(dst, ", %v", a.Misc)
+
+	case OpStringAgg:
+		dst.WriteString(", ")
+		String(a.Separator).text(dst, redact)
+		if a.MaxLength != 0 {
+			fmt.Fprintf(dst, ", %d", a.MaxLength)
+		}
+
+	case OpTopK:
+		fmt.Fprintf(dst, ", %d", a.K)
+		if a.SketchSize != 0 {
+			fmt.Fprintf(dst, ", %d", a.SketchSize)
+		}
+
+	case OpMode:
+		if a.SketchSize != 0 {
+			fmt.Fprintf(dst, ", %d", a.SketchSize)
+		}
+	}
+	for i := range a.OrderBy {
+		if i == 0 {
+			dst.WriteString(" ORDER BY ")
+		} else {
+			dst.WriteString(", ")
+		}
+		a.OrderBy[i].text(dst, redact)
 	}
 	dst.WriteByte(')')
 
@@ -621,6 +873,9 @@ func (a *Aggregate) walk(v Visitor) {
 	if a.Filter != nil {
 		Walk(v, a.Filter)
 	}
+	for i := range a.OrderBy {
+		Walk(v, a.OrderBy[i].Column)
+	}
 }
 
 func (a *Aggregate) rewrite(r Rewriter) Node {
@@ -638,6 +893,9 @@ func (a *Aggregate) rewrite(r Rewriter) Node {
 	if a.Filter != nil {
 		a.Filter = Rewrite(r, a.Filter)
 	}
+	for i := range a.OrderBy {
+		a.OrderBy[i].Column = Rewrite(r, a.OrderBy[i].Column)
+	}
 	return a
 }
 
@@ -654,14 +912,49 @@ func (a *Aggregate) typeof(h Hint) TypeSet {
 		return TimeType | NullType
 	case OpSystemDatashape:
 		return StructType
+	case OpStringAgg:
+		return StringType | NullType
+	case OpTopK:
+		return ListType | NullType
+	case OpMode:
+		// structures and lists are not counted,
+		// and symbols are counted as strings
+		t := TypeOf(a.Inner, h) &^ (MissingType | StructType | ListType)
+		if t&SymbolType != 0 {
+			t = (t &^ SymbolType) | StringType
+		}
+		return t | NullType
+	case OpCovarPop, OpCovarSamp, OpCorr, OpRegrSlope, OpRegrIntercept:
+		return FloatType | NullType
+	case OpUser:
+		return AnyType
 	default:
 		return NumericType | NullType
 	}
 }
 
+// Pair returns the two inputs of a bivariate aggregate
+// (see AggregateOp.Bivariate).
+func (a *Aggregate) Pair() ([]Node, bool) {
+	if !a.Op.Bivariate() || a.Role == AggregateRoleMerge {
+		return nil, false
+	}
+	switch in := a.Inner.(type) {
+	case *Builtin:
+		if in.Func == MakeList && len(in.Args) == 2 {
+			return in.Args, true
+		}
+	case *List:
+		if len(in.Values) == 2 {
+			return []Node{in.Values[0], in.Values[1]}, true
+		}
+	}
+	return nil, false
+}
+
 // IsDistinct returns if the aggregate has DISTINCT clause.
 func (a *Aggregate) IsDistinct() bool {
-	return a.Op == OpCountDistinct
+	return a.Op == OpCountDistinct || a.Distinct
 }
 
 // Count produces the COUNT(e) aggregate
@@ -1278,6 +1571,8 @@ func (d *Dot) simplify(h Hint) Node {
 //	Inner '[' Offset ']'
 //
 // The Inner value within Index should be list-typed.
+// A negative Offset counts from the end of the list,
+// so Inner[-1] is the last element of the list.
 type Index struct {
 	Inner  Node
 	Offset int // offset is constant for now
@@ -1317,20 +1612,31 @@ func (i *Index) SetField(f ion.Field) (err error) {
 // [ v ][0] -> v
 func (i *Index) simplify(h Hint) Node {
 	if b, ok := i.Inner.(*Builtin); ok && b.Func == MakeList {
-		if i.Offset < len(b.Args) {
-			return b.Args[i.Offset]
+		if off, ok := listOffset(i.Offset, len(b.Args)); ok && off < len(b.Args) {
+			return b.Args[off]
 		}
 		return Missing{}
 	}
 	if l, ok := i.Inner.(*List); ok {
-		if i.Offset < len(l.Values) {
-			return l.Values[i.Offset]
+		if off, ok := listOffset(i.Offset, len(l.Values)); ok && off < len(l.Values) {
+			return l.Values[off]
 		}
 		return Missing{}
 	}
 	return i
 }
 
+// listOffset resolves the offset i into a list
+// of n elements, where negative offsets count
+// from the end of the list; it returns false if
+// the offset is before the start of the list
+func listOffset(i, n int) (int, bool) {
+	if i < 0 {
+		i += n
+	}
+	return i, i >= 0
+}
+
 func (i *Index) Equals(x Node) bool {
 	i2, ok := x.(*Index)
 	return ok && i.Offset == i2.Offset &&
@@ -1346,6 +1652,133 @@ func (i *Index) rewrite(r Rewriter) Node {
 	return i
 }
 
+// Slice represents the '[:]' infix operator, i.e.
+//
+//	Inner '[' From ':' To ']'
+//
+// which yields the list of the elements of Inner
+// from offset From up to (but excluding) offset To.
+// Negative offsets count from the end of the list,
+// and ToEnd indicates that To was omitted, so the
+// slice extends to the end of the list.
+//
+// A slice is MISSING if Inner is not a list or if
+// either offset is out of range; a slice where From
+// is not before To is an empty list.
+type Slice struct {
+	Inner    Node
+	From, To int
+	ToEnd    bool
+}
+
+func (s *Slice) text(dst *strings.Builder, redact bool) {
+	s.Inner.text(dst, redact)
+	if s.ToEnd {
+		fmt.Fprintf(dst, "[%d:]", s.From)
+	} else {
+		fmt.Fprintf(dst, "[%d:%d]", s.From, s.To)
+	}
+}
+
+func (s *Slice) Encode(dst *ion.Buffer, st *ion.Symtab) {
+	dst.BeginStruct(-1)
+	settype(dst, st, "slice")
+	dst.BeginField(st.Intern("inner"))
+	s.Inner.Encode(dst, st)
+	dst.BeginField(st.Intern("from"))
+	dst.WriteInt(int64(s.From))
+	if s.ToEnd {
+		dst.BeginField(st.Intern("to_end"))
+		dst.WriteBool(true)
+	} else {
+		dst.BeginField(st.Intern("to"))
+		dst.WriteInt(int64(s.To))
+	}
+	dst.EndStruct()
+}
+
+func (s *Slice) SetField(f ion.Field) (err error) {
+	switch f.Label {
+	case "inner":
+		s.Inner, err = Decode(f.Datum)
+	case "from", "to":
+		var v int64
+		v, err = f.Int()
+		if err == nil {
+			if f.Label == "from" {
+				s.From = int(v)
+			} else {
+				s.To = int(v)
+			}
+		}
+	case "to_end":
+		s.ToEnd, err = f.Bool()
+	default:
+		return errUnexpectedField
+	}
+	return err
+}
+
+// Bounds resolves the offsets of the slice
+// for a list of n elements; it returns false
+// if the result of the slice is MISSING
+func (s *Slice) Bounds(n int) (from, to int, ok bool) {
+	from, ok = listOffset(s.From, n)
+	if !ok || from > n {
+		return 0, 0, false
+	}
+	to = n
+	if !s.ToEnd {
+		to, ok = listOffset(s.To, n)
+		if !ok || to > n {
+			return 0, 0, false
+		}
+	}
+	return from, max(from, to), true
+}
+
+// [a, b, c][1:] -> [b, c]
+func (s *Slice) simplify(h Hint) Node {
+	if b, ok := s.Inner.(*Builtin); ok && b.Func == MakeList {
+		from, to, ok := s.Bounds(len(b.Args))
+		if !ok {
+			return Missing{}
+		}
+		args := append([]Node(nil), b.Args[from:to]...)
+		if l := simplifyMakeList(h, args); l != nil {
+			return l
+		}
+		return Call(MakeList, args...)
+	}
+	if l, ok := s.Inner.(*List); ok {
+		from, to, ok := s.Bounds(len(l.Values))
+		if !ok {
+			return Missing{}
+		}
+		return &List{Values: l.Values[from:to]}
+	}
+	return s
+}
+
+func (s *Slice) typeof(h Hint) TypeSet {
+	return ListType | MissingType
+}
+
+func (s *Slice) Equals(x Node) bool {
+	s2, ok := x.(*Slice)
+	return ok && s.From == s2.From && s.ToEnd == s2.ToEnd &&
+		(s.ToEnd || s.To == s2.To) && s.Inner.Equals(s2.Inner)
+}
+
+func (s *Slice) walk(v Visitor) {
+	Walk(v, s.Inner)
+}
+
+func (s *Slice) rewrite(r Rewriter) Node {
+	s.Inner = Rewrite(r, s.Inner)
+	return s
+}
+
 // Star represents the '*' path component
 type Star struct{}
 
@@ -1537,6 +1970,16 @@ func Between(val, lo, hi Node) *Logical {
 	}
 }
 
+// BetweenSymmetric yields an expression equivalent to
+//
+//	<val> BETWEEN SYMMETRIC <lo> AND <hi>
+//
+// which is BETWEEN with the bounds swapped
+// if <lo> is greater than <hi>
+func BetweenSymmetric(val, lo, hi Node) *Logical {
+	return Between(val, Call(Least, lo, hi), Call(Greatest, lo, hi))
+}
+
 // Member is an implementation of IN
 // that compares against a list of constant
 // values, i.e. MEMBER(x, 3, 'foo', ['x', 1.5])
@@ -3068,6 +3511,14 @@ func Coalesce(nodes []Node) *Case {
 	return c
 }
 
+// Nvl2 implements NVL2(a, b, c);
+// it is transformed into an equivalent CASE expression:
+//
+//	CASE WHEN a IS NOT NULL THEN b ELSE c
+func Nvl2(a, b, c Node) Node {
+	return IfThenElse(Is(a, IsNotNull), b, c)
+}
+
 // NullIf implements SQL NULLIF(a, b);
 // it is transformed into an equivalent CASE expression:
 //
@@ -3162,7 +3613,7 @@ func (c *Cast) text(dst *strings.Builder, redact bool) {
 
 func (c *Cast) typeof(h Hint) TypeSet {
 	ft := TypeOf(c.From, h)
-	if ft&c.To == 0 {
+	if ft&converts(c.To) == 0 {
 		return MissingType
 	}
 	out := c.To
@@ -3639,6 +4090,12 @@ func AsConstant(d ion.Datum) (Constant, bool) {
 			return Integer(int64(d)), true
 		}
 		return (*Rational)(big.NewRat(0, 0).SetUint64(d)), true
+	case ion.DecimalType:
+		r, err := d.Rat()
+		if err != nil {
+			return nil, false
+		}
+		return (*Rational)(r), true
 	case ion.StringType, ion.SymbolType:
 		d, _ := d.String()
 		return String(d), true
@@ -3802,6 +4259,18 @@ const (
 
 	// UNION with duplicates
 	UnionAll
+
+	// INTERSECT without duplicates
+	Intersect
+
+	// INTERSECT with duplicates
+	IntersectAll
+
+	// EXCEPT without duplicates
+	Except
+
+	// EXCEPT with duplicates
+	ExceptAll
 )
 
 func (t UnionType) String() string {
@@ -3810,12 +4279,21 @@ func (t UnionType) String() string {
 		return "UNION"
 	case UnionAll:
 		return "UNION ALL"
+	case Intersect:
+		return "INTERSECT"
+	case IntersectAll:
+		return "INTERSECT ALL"
+	case Except:
+		return "EXCEPT"
+	case ExceptAll:
+		return "EXCEPT ALL"
 	default:
 		return fmt.Sprintf("<UnionType=%d>", int(t))
 	}
 }
 
-// Union describes a single pair of expressions connected by UNION keyword
+// Union describes a single pair of expressions connected
+// by a set operator (UNION, INTERSECT or EXCEPT)
 type Union struct {
 	Type  UnionType
 	Left  Node
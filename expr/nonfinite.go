@@ -0,0 +1,147 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NonFinite selects how a query handles
+// NaN and infinite floats in the keys of
+// ORDER BY and in the inputs of numeric
+// aggregates (see ApplyNonFinite).
+type NonFinite int
+
+const (
+	// NonFiniteOrder is the default: NaN and
+	// infinities are ordered like other numbers,
+	// with NaN greater than +Inf, and numeric
+	// aggregates produce NaN or an infinity as
+	// IEEE 754 arithmetic does.
+	NonFiniteOrder NonFinite = iota
+	// NonFiniteNull treats NaN and infinities as NULL,
+	// so they are sorted with the NULLs and
+	// skipped by aggregates.
+	NonFiniteNull
+	// NonFiniteError makes the query fail
+	// when it encounters NaN or an infinity.
+	NonFiniteError
+)
+
+func (n NonFinite) String() string {
+	switch n {
+	case NonFiniteOrder:
+		return "order"
+	case NonFiniteNull:
+		return "null"
+	case NonFiniteError:
+		return "error"
+	default:
+		return fmt.Sprintf("<NonFinite=%d>", int(n))
+	}
+}
+
+// ParseNonFinite parses "order", "null" or "error".
+func ParseNonFinite(s string) (NonFinite, error) {
+	for n := NonFiniteOrder; n <= NonFiniteError; n++ {
+		if strings.EqualFold(s, n.String()) {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown non-finite mode %q; expected 'order', 'null' or 'error'", s)
+}
+
+// ApplyNonFinite returns q rewritten so that
+// the keys of ORDER BY (including those of
+// window functions and ordered aggregates) and
+// the inputs of numeric aggregates are handled
+// according to mode: with NonFiniteNull they are
+// wrapped in NULLIF_NONFINITE, and with NonFiniteError
+// they are wrapped in ASSERT_FINITE.
+//
+// q is returned as-is for NonFiniteOrder;
+// otherwise q is not modified.
+func ApplyNonFinite(q *Query, mode NonFinite) *Query {
+	var op BuiltinOp
+	switch mode {
+	case NonFiniteNull:
+		op = NullIfNonFinite
+	case NonFiniteError:
+		op = AssertFinite
+	default:
+		return q
+	}
+	q = q.Clone()
+	r := &nonFiniteRewriter{op: op}
+	for i := range q.With {
+		q.With[i].As = Rewrite(r, q.With[i].As).(*Select)
+	}
+	if q.Body != nil && !q.Describe {
+		q.Body = Rewrite(r, q.Body)
+	}
+	return q
+}
+
+type nonFiniteRewriter struct {
+	op BuiltinOp
+}
+
+func (r *nonFiniteRewriter) Walk(Node) Rewriter { return r }
+
+// wrap returns e wrapped in r.op
+func (r *nonFiniteRewriter) wrap(e Node) Node {
+	switch e := e.(type) {
+	case Constant:
+		// constants are left alone
+		return e
+	case *Builtin:
+		if e.Func == r.op {
+			return e
+		}
+	}
+	return Call(r.op, e)
+}
+
+func (r *nonFiniteRewriter) order(lst []Order) {
+	for i := range lst {
+		lst[i].Column = r.wrap(lst[i].Column)
+	}
+}
+
+func (r *nonFiniteRewriter) Rewrite(e Node) Node {
+	switch e := e.(type) {
+	case *Select:
+		r.order(e.OrderBy)
+	case *Aggregate:
+		if e.Over != nil {
+			r.order(e.Over.OrderBy)
+		}
+		r.order(e.OrderBy)
+		if e.Role == AggregateRoleMerge {
+			break
+		}
+		switch e.Op {
+		case OpSum, OpAvg, OpMin, OpMax, OpVariancePop, OpStdDevPop,
+			OpApproxPercentile, OpApproxMedian:
+			e.Inner = r.wrap(e.Inner)
+		default:
+			if args, ok := e.Pair(); ok {
+				e.Inner = Call(MakeList, r.wrap(args[0]), r.wrap(args[1]))
+			}
+		}
+	}
+	return e
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package expr_test
+
+import (
+	"testing"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/expr/partiql"
+)
+
+func TestApplyNonFinite(t *testing.T) {
+	testcases := []struct {
+		query string
+		mode  expr.NonFinite
+		want  string
+	}{
+		{
+			query: "SELECT x FROM t ORDER BY x DESC LIMIT 3",
+			mode:  expr.NonFiniteNull,
+			want:  "SELECT x FROM t ORDER BY NULLIF_NONFINITE(x) DESC NULLS FIRST LIMIT 3",
+		},
+		{
+			query: "SELECT x FROM t ORDER BY x DESC LIMIT 3",
+			mode:  expr.NonFiniteOrder,
+			want:  "SELECT x FROM t ORDER BY x DESC NULLS FIRST LIMIT 3",
+		},
+		{
+			query: "SELECT SUM(x), AVG(y + 1), COUNT(*), CORR(x, y) FROM t",
+			mode:  expr.NonFiniteNull,
+			want:  "SELECT SUM(NULLIF_NONFINITE(x)), AVG(NULLIF_NONFINITE(y + 1)), COUNT(*), CORR(NULLIF_NONFINITE(x), NULLIF_NONFINITE(y)) FROM t",
+		},
+		{
+			query: "SELECT g, MAX(x) FROM t GROUP BY g",
+			mode:  expr.NonFiniteError,
+			want:  "SELECT g, MAX(ASSERT_FINITE(x)) FROM t GROUP BY g",
+		},
+		{
+			// ordinals are wrapped like the column they refer to
+			query: "SELECT ROW_NUMBER() OVER (ORDER BY x) FROM t ORDER BY 1 LIMIT 1",
+			mode:  expr.NonFiniteNull,
+			want:  "SELECT ROW_NUMBER() OVER (ORDER BY NULLIF_NONFINITE(x) ASC NULLS FIRST) FROM t ORDER BY NULLIF_NONFINITE(ROW_NUMBER() OVER (ORDER BY NULLIF_NONFINITE(x) ASC NULLS FIRST)) ASC NULLS FIRST LIMIT 1",
+		},
+		{
+			query: "WITH c AS (SELECT MIN(x) AS m FROM t) SELECT * FROM c",
+			mode:  expr.NonFiniteNull,
+			want:  "WITH c AS (SELECT MIN(NULLIF_NONFINITE(x)) AS m FROM t) SELECT * FROM c",
+		},
+	}
+	for i := range testcases {
+		tc := &testcases[i]
+		q, err := partiql.Parse([]byte(tc.query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := q.Text()
+		got := expr.ApplyNonFinite(q, tc.mode)
+		if text := got.Text(); text != tc.want {
+			t.Errorf("got  %s\nwant %s", text, tc.want)
+		}
+		if q.Text() != before {
+			t.Errorf("%s: query was modified", tc.query)
+		}
+		// applying the mode twice doesn't change the query
+		if text := expr.ApplyNonFinite(got, tc.mode).Text(); text != tc.want {
+			t.Errorf("applied twice: got %s", text)
+		}
+	}
+}
+
+func TestParseNonFinite(t *testing.T) {
+	for _, mode := range []expr.NonFinite{expr.NonFiniteOrder, expr.NonFiniteNull, expr.NonFiniteError} {
+		got, err := expr.ParseNonFinite(mode.String())
+		if err != nil || got != mode {
+			t.Errorf("%s: got %s, %v", mode, got, err)
+		}
+	}
+	if _, err := expr.ParseNonFinite("skip"); err == nil {
+		t.Error("expected an error")
+	}
+}
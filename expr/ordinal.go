@@ -0,0 +1,40 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package expr
+
+// ResolveOrdinals resolves the integer literals
+// in the ORDER BY clause of s to the (one-based)
+// positions of the columns of s: the literal is
+// replaced with a copy of the expression of the
+// column, so ORDER BY 2 sorts the same way as
+// writing the second column out in ORDER BY.
+func ResolveOrdinals(s *Select) error {
+	for i := range s.OrderBy {
+		n, ok := s.OrderBy[i].Column.(Integer)
+		if !ok {
+			continue
+		}
+		if n < 1 || int64(n) > int64(len(s.Columns)) {
+			return errsyntaxf("ORDER BY position %d is not in the select list", n)
+		}
+		for j := range s.Columns[:n] {
+			if s.Columns[j].Expr == (Star{}) {
+				return errsyntaxf("ORDER BY position %d cannot be used with SELECT *", n)
+			}
+		}
+		s.OrderBy[i].Column = Copy(s.Columns[n-1].Expr)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package expr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// Param is a query parameter (a '?' placeholder)
+// that is replaced with a value by Query.Bind.
+type Param struct {
+	// Index is the position of the parameter
+	// in the query text, starting at 1.
+	Index int
+	// Declared is the type declared for the
+	// parameter by 'PREPARE name (type, ...) AS',
+	// or zero if the type is inferred from
+	// the value bound to the parameter.
+	Declared TypeSet
+}
+
+func (p *Param) text(dst *strings.Builder, redact bool) {
+	dst.WriteByte('?')
+}
+
+func (p *Param) Type() TypeSet {
+	if p.Declared == 0 {
+		return AnyType
+	}
+	return p.Declared
+}
+
+func (p *Param) Equals(e Node) bool {
+	p2, ok := e.(*Param)
+	return ok && p.Index == p2.Index && p.Declared == p2.Declared
+}
+
+func (p *Param) walk(v Visitor) {}
+
+func (p *Param) Encode(dst *ion.Buffer, st *ion.Symtab) {
+	dst.BeginStruct(-1)
+	settype(dst, st, "param")
+	dst.BeginField(st.Intern("index"))
+	dst.WriteInt(int64(p.Index))
+	dst.BeginField(st.Intern("declared"))
+	dst.WriteInt(int64(p.Declared))
+	dst.EndStruct()
+}
+
+func (p *Param) SetField(f ion.Field) error {
+	switch f.Label {
+	case "index":
+		i, err := f.Int()
+		if err != nil {
+			return err
+		}
+		p.Index = int(i)
+	case "declared":
+		t, err := f.Int()
+		if err != nil {
+			return err
+		}
+		p.Declared = TypeSet(t)
+	default:
+		return errUnexpectedField
+	}
+	return nil
+}
+
+// typeName returns the name of the declared
+// type of p as it appears in a PREPARE statement
+func (p *Param) typeName() string {
+	return (&Cast{To: p.Declared}).TargetTypeName()
+}
+
+// accepts returns whether c can be bound to p
+func (p *Param) accepts(c Constant) bool {
+	t := TypeSet(1) << c.Datum().Type()
+	switch {
+	case p.Declared == 0, t == NullType:
+		return true
+	case p.Declared&(FloatType|DecimalType) != 0:
+		// integers are accepted as FLOAT or DECIMAL
+		return t&(p.Declared|IntegerType) != 0
+	}
+	return t&p.Declared != 0
+}
+
+// Params returns the parameters of q
+// ordered by their Index.
+func (q *Query) Params() []*Param {
+	var params []*Param
+	walk := WalkFunc(func(e Node) bool {
+		if p, ok := e.(*Param); ok {
+			params = append(params, p)
+		}
+		return true
+	})
+	for i := range q.With {
+		Walk(walk, q.With[i].As)
+	}
+	if q.Into != nil {
+		Walk(walk, q.Into)
+	}
+	if q.Body != nil {
+		Walk(walk, q.Body)
+	}
+	ordered := make([]*Param, len(params))
+	for _, p := range params {
+		if p.Index < 1 || p.Index > len(ordered) || ordered[p.Index-1] != nil {
+			// not produced by the parser
+			return params
+		}
+		ordered[p.Index-1] = p
+	}
+	return ordered
+}
+
+type bindrw struct {
+	args []Constant
+}
+
+func (b *bindrw) Walk(e Node) Rewriter { return b }
+
+func (b *bindrw) Rewrite(e Node) Node {
+	if p, ok := e.(*Param); ok {
+		return b.args[p.Index-1]
+	}
+	return e
+}
+
+// Bind returns a copy of q in which each parameter
+// is replaced with the argument at its position.
+//
+// Bind returns an error if the number of arguments
+// does not match the number of parameters, if an
+// argument does not have the declared type of its
+// parameter, or if the query does not type-check
+// with the arguments in place. (The type of a
+// parameter without a declared type is inferred
+// from its argument, so an argument that cannot be
+// used where its parameter appears is rejected.)
+func (q *Query) Bind(args []Constant) (*Query, error) {
+	params := q.Params()
+	if len(args) != len(params) {
+		return nil, fmt.Errorf("query has %d parameters but %d values were provided", len(params), len(args))
+	}
+	for i, p := range params {
+		if p.Index != i+1 {
+			return nil, fmt.Errorf("unexpected parameter index %d", p.Index)
+		}
+		if !p.accepts(args[i]) {
+			return nil, errtype(args[i], "parameter %d: expected a value of type %s", p.Index, p.typeName())
+		}
+	}
+	ret := q.Clone()
+	rw := &bindrw{args: args}
+	for i := range ret.With {
+		ret.With[i].As = Rewrite(rw, ret.With[i].As).(*Select)
+	}
+	ret.Into = Rewrite(rw, ret.Into)
+	ret.Body = Rewrite(rw, ret.Body)
+	if err := ret.Check(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -36,7 +36,7 @@ const eof = -1
 func init() {
 	expr.IsKeyword = func(x string) bool {
 		term, _ := lookupKeyword([]byte(x))
-		return term != -1
+		return term != -1 && !nonReserved(term)
 	}
 }
 
@@ -60,6 +60,40 @@ type scanner struct {
 	// to produce the same time exactly,
 	// so we can't call time.Now() more than once)
 	now *expr.Timestamp
+
+	// params is the number of '?' parameters
+	params int
+	// spans holds the position of the first token
+	// of the nodes recorded by at, if it is non-nil
+	spans map[expr.Node]span
+}
+
+// span is the position of a token in the query text
+type span struct {
+	pos, end int
+}
+
+// at records that the first token of n spans
+// from pos to end, if positions are recorded
+// (see Lint); the position of an identifier is
+// that of the first path that starts with it
+func (s *scanner) at(n expr.Node, pos, end int) expr.Node {
+	if s.spans == nil {
+		return n
+	}
+	switch n.(type) {
+	case expr.Ident, *expr.Dot, *expr.Comparison, *expr.Builtin:
+		if _, ok := s.spans[n]; !ok {
+			s.spans[n] = span{pos: pos, end: end}
+		}
+	}
+	return n
+}
+
+// param returns the next '?' parameter
+func (s *scanner) param() *expr.Param {
+	s.params++
+	return &expr.Param{Index: s.params}
 }
 
 func (s *scanner) utcnow() *expr.Timestamp {
@@ -235,6 +269,7 @@ func isspace(x byte) bool {
 
 func (s *scanner) Lex(l *yySymType) int {
 	s.lastsym = s.lex(l)
+	l.end = s.pos
 	return s.lastsym
 }
 
@@ -246,6 +281,7 @@ func (s *scanner) lex(l *yySymType) int {
 	if s.err != nil || s.pos >= len(s.from) {
 		return eof
 	}
+	l.pos = s.pos
 	b := s.peek()
 	if isdigit(b) {
 		return s.lexNumber(l)
@@ -343,7 +379,7 @@ func (s *scanner) lex(l *yySymType) int {
 		s.notkw = false
 		s.pos++
 		return int(b)
-	case ',', '*', '-', '/', '%', ':', '&', '^', '[', ']', '(', ')', '{', '}':
+	case ',', '*', '-', '/', '%', ':', '&', '^', '[', ']', '(', ')', '{', '}', '?':
 		// literal operators
 		s.notkw = false
 		s.pos++
@@ -397,6 +433,17 @@ func (s *scanner) lexIdent(l *yySymType) int {
 		s.pos++
 	}
 	wordend := s.pos == len(s.from) || issep(s.from[s.pos])
+	if s.notkw && wordend && s.lastsym == AS {
+		// the query following 'PREPARE name AS'
+		// or 'CREATE TABLE name AS' begins with
+		// SELECT or WITH (see as_identifier)
+		word := s.from[startpos:s.pos]
+		if bytes.EqualFold(word, []byte("SELECT")) || bytes.EqualFold(word, []byte("WITH")) {
+			l.str = string(word)
+			term, _ := lookupKeyword(word)
+			return term
+		}
+	}
 	if !s.notkw && wordend {
 		// don't perform string allocation if we have a keyword
 		term, enum := lookupKeyword(s.from[startpos:s.pos])
@@ -409,6 +456,8 @@ func (s *scanner) lexIdent(l *yySymType) int {
 			if term == AS {
 				s.chompws()
 				s.notkw = true
+			} else if nonReserved(term) {
+				l.str = string(s.from[startpos:s.pos])
 			}
 			return term
 		}
@@ -418,6 +467,20 @@ func (s *scanner) lexIdent(l *yySymType) int {
 	return ID
 }
 
+// nonReserved returns true if the keyword term can
+// also be used as an identifier (see identifier in
+// partiql.y), in which case its text is needed
+func nonReserved(term int) bool {
+	switch term {
+	case OBJECT, ARRAY, PREPARE, EXECUTE, DEALLOCATE,
+		DATE, TIME, TIMESTAMP, INTERVAL,
+		FETCH, NEXT, ROWS, ONLY, TIES,
+		DELETE, CREATE, TABLE:
+		return true
+	}
+	return false
+}
+
 // lexNumber lexes a number-like thing
 // (NOTE: this is too permissive; we do the actual
 // checking for valid numbers at parse time)
@@ -434,7 +497,7 @@ func (s *scanner) lexNumber(l *yySymType) int {
 			return false
 
 		// operators
-		case '(', ')', '[', ']', '{', '}', '*', '/', '%', '&', '!', '^', '~', '|', ',':
+		case '(', ')', '[', ']', '{', '}', '*', '/', '%', '&', '!', '^', '~', '|', ',', ':':
 			return false
 
 		case '-', '+':
@@ -642,12 +705,25 @@ func (e *LexerError) Error() string {
 
 var exprstar = expr.Star{}
 
-func toAggregate(op expr.AggregateOp, distinct bool, args []expr.Node, filter expr.Node, over *expr.Window) (*expr.Aggregate, error) {
+// toAggregate builds the aggregate op; name is
+// the name of the function for expr.OpUser
+func toAggregate(op expr.AggregateOp, name string, distinct bool, args []expr.Node, order []expr.Order, filter expr.Node, over *expr.Window) (*expr.Aggregate, error) {
+	fn := op.String()
+	if op == expr.OpUser {
+		fn = name
+	}
+	if len(order) > 0 && !op.Ordered() {
+		return nil, fmt.Errorf("%s: does not accept ORDER BY", fn)
+	}
 	agg, err := toAggregateAux(op, distinct, args, filter, over)
 	if err != nil {
-		return nil, fmt.Errorf("%v: %s", op, err)
+		return nil, fmt.Errorf("%s: %s", fn, err)
+	}
+	if op == expr.OpUser {
+		agg.Name = name
 	}
 
+	agg.OrderBy = order
 	return agg, nil
 }
 
@@ -682,12 +758,30 @@ func toAggregateAux(op expr.AggregateOp, distinct bool, args []expr.Node, filter
 		return createApproxCountDistinct(body, args, filter, over)
 	case expr.OpApproxPercentile:
 		return createApproxPercentile(body, args, filter, over)
+	case expr.OpStringAgg:
+		return createStringAgg(body, args, filter, over)
+	case expr.OpTopK:
+		return createTopK(body, args, filter, over)
+	case expr.OpMode:
+		return createMode(body, args, filter, over)
+	case expr.OpUser:
+		if over != nil {
+			return nil, fmt.Errorf("cannot be used as a window function")
+		}
+		if body == nil || len(args) > 0 {
+			return nil, fmt.Errorf("accepts 1 argument")
+		}
+		return &expr.Aggregate{Op: op, Inner: body, Filter: filter}, nil
+	case expr.OpCovarPop, expr.OpCovarSamp, expr.OpCorr, expr.OpRegrSlope, expr.OpRegrIntercept:
+		return createBivariate(op, body, args, filter, over)
 	default:
 		if len(args) > 0 {
 			return nil, fmt.Errorf("does not accept arguments")
 		}
-
-		return &expr.Aggregate{Op: op, Inner: body, Over: over, Filter: filter}, nil
+		// DISTINCT does not change the results
+		// of the other aggregates that accept it
+		distinct = distinct && (op == expr.OpSum || op == expr.OpAvg)
+		return &expr.Aggregate{Op: op, Inner: body, Over: over, Filter: filter, Distinct: distinct}, nil
 	}
 }
 
@@ -718,6 +812,99 @@ func createApproxCountDistinct(body expr.Node, args []expr.Node, filter expr.Nod
 		Filter:    filter}, nil
 }
 
+func createStringAgg(body expr.Node, args []expr.Node, filter expr.Node, over *expr.Window) (*expr.Aggregate, error) {
+	if over != nil {
+		return nil, fmt.Errorf("cannot be used as a window function")
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("accepts a separator and an optional maximum length")
+	}
+	sep, ok := args[0].(expr.String)
+	if !ok {
+		return nil, fmt.Errorf("separator has to be a constant string")
+	}
+	maxlen := 0
+	if len(args) == 2 {
+		n, ok := args[1].(expr.Integer)
+		if !ok || n <= 0 {
+			return nil, fmt.Errorf("maximum length has to be a positive constant integer")
+		}
+		maxlen = int(n)
+	}
+	return &expr.Aggregate{
+		Op:        expr.OpStringAgg,
+		Inner:     body,
+		Filter:    filter,
+		Separator: string(sep),
+		MaxLength: maxlen,
+	}, nil
+}
+
+func createTopK(body expr.Node, args []expr.Node, filter expr.Node, over *expr.Window) (*expr.Aggregate, error) {
+	if over != nil {
+		return nil, fmt.Errorf("cannot be used as a window function")
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("accepts k and an optional sketch size")
+	}
+	k, ok := args[0].(expr.Integer)
+	if !ok || k <= 0 || k > expr.TopKMaxSketchSize {
+		return nil, fmt.Errorf("k has to be a constant integer in range [1, %d]", expr.TopKMaxSketchSize)
+	}
+	size := 0
+	if len(args) == 2 {
+		n, ok := args[1].(expr.Integer)
+		if !ok || n < k || n > expr.TopKMaxSketchSize {
+			return nil, fmt.Errorf("sketch size has to be a constant integer in range [%d, %d]", k, expr.TopKMaxSketchSize)
+		}
+		size = int(n)
+	}
+	return &expr.Aggregate{
+		Op:         expr.OpTopK,
+		Inner:      body,
+		Filter:     filter,
+		K:          int(k),
+		SketchSize: size,
+	}, nil
+}
+
+func createMode(body expr.Node, args []expr.Node, filter expr.Node, over *expr.Window) (*expr.Aggregate, error) {
+	if over != nil {
+		return nil, fmt.Errorf("cannot be used as a window function")
+	}
+	if len(args) > 1 {
+		return nil, fmt.Errorf("accepts an optional sketch size")
+	}
+	size := 0
+	if len(args) == 1 {
+		n, ok := args[0].(expr.Integer)
+		if !ok || n <= 0 || n > expr.TopKMaxSketchSize {
+			return nil, fmt.Errorf("sketch size has to be a constant integer in range [1, %d]", expr.TopKMaxSketchSize)
+		}
+		size = int(n)
+	}
+	return &expr.Aggregate{
+		Op:         expr.OpMode,
+		Inner:      body,
+		Filter:     filter,
+		SketchSize: size,
+	}, nil
+}
+
+func createBivariate(op expr.AggregateOp, body expr.Node, args []expr.Node, filter expr.Node, over *expr.Window) (*expr.Aggregate, error) {
+	if over != nil {
+		return nil, fmt.Errorf("cannot be used as a window function")
+	}
+	if body == nil || len(args) != 1 {
+		return nil, fmt.Errorf("accepts 2 arguments")
+	}
+	return &expr.Aggregate{
+		Op:     op,
+		Inner:  expr.Call(expr.MakeList, body, args[0]),
+		Filter: filter,
+	}, nil
+}
+
 func createApproxPercentile(body expr.Node, args []expr.Node, filter expr.Node, over *expr.Window) (*expr.Aggregate, error) {
 	if len(args) != 1 {
 		return nil, fmt.Errorf("accepts 1 argument")
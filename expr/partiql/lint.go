@@ -0,0 +1,43 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package partiql
+
+import "github.com/SnellerInc/sneller/expr"
+
+// Lint parses and checks the query text in
+// and returns the diagnostics produced by
+// expr.Lint, located in the text of the query.
+// Syntax and type errors are returned as errors.
+func Lint(in []byte, schema expr.LintSchema) ([]expr.Diagnostic, error) {
+	s := &scanner{from: in, spans: make(map[expr.Node]span)}
+	q, err := parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.Check(); err != nil {
+		return nil, err
+	}
+	d := expr.Lint(q, schema)
+	for i := range d {
+		// nodes rewritten by the parser
+		// or by Check have no position
+		if sp, ok := s.spans[d[i].Node]; ok {
+			d[i].Position = sp.pos
+			d[i].Length = sp.end - sp.pos
+			d[i].Line, d[i].Column, _ = s.position(sp.pos)
+		}
+	}
+	return d, nil
+}
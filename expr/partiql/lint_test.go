@@ -0,0 +1,84 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package partiql
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/expr"
+)
+
+// lintFields is a LintSchema where
+// every table has the same fields
+type lintFields []string
+
+func (l lintFields) HasField(table expr.Node, path []string) (bool, bool) {
+	return slices.Contains(l, strings.Join(path, ".")), true
+}
+
+func TestLint(t *testing.T) {
+	schema := lintFields{"x", "y", "k", "items"}
+	testcases := []struct {
+		query string
+		want  []string
+	}{
+		{
+			query: "SELECT * FROM t WHERE x = NULL",
+			want:  []string{"at 1:23: x = NULL is never TRUE; use IS NULL or IS NOT NULL to test for NULL"},
+		},
+		{
+			query: "SELECT *\nFROM t\nWHERE 1 = 2",
+			want:  []string{"at 3:7: comparison of constants 1 = 2 is always FALSE"},
+		},
+		{
+			query: "SELECT * FROM t WHERE x <> x",
+			want:  []string{"at 1:23: x <> x compares x with itself and is always FALSE (or NULL or MISSING)"},
+		},
+		{
+			query: "SELECT COUNT(*) FROM t GROUP BY y",
+			want:  []string{"at 1:33: GROUP BY column y does not appear in the SELECT list"},
+		},
+		{
+			query: "SELECT * FROM a, b",
+			want:  []string{"at 1:18: b is joined with every row of a; add a join condition or use CROSS JOIN"},
+		},
+		{
+			query: "SELECT x, bogus FROM t",
+			want:  []string{"at 1:11: field bogus does not exist in table t"},
+		},
+		// no diagnostics:
+		{query: "SELECT * FROM a, b WHERE a.k = b.k"},
+		{query: "SELECT i FROM t, t.items AS i"},
+		{query: "SELECT y, COUNT(*) AS c FROM t GROUP BY y ORDER BY c DESC"},
+		{query: "SELECT x FROM t AS o WHERE EXISTS (SELECT k FROM u WHERE u.k = o.x)"},
+		{query: "WITH c AS (SELECT x FROM t) SELECT whatever FROM c"},
+	}
+	for i := range testcases {
+		tc := &testcases[i]
+		d, err := Lint([]byte(tc.query), schema)
+		if err != nil {
+			t.Fatalf("%q: %s", tc.query, err)
+		}
+		var got []string
+		for j := range d {
+			got = append(got, d[j].String())
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%q:\ngot  %q\nwant %q", tc.query, got, tc.want)
+		}
+	}
+}
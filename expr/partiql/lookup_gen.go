@@ -46,41 +46,45 @@ func lookupKeyword(word []byte) (int, int) {
 			}
 		}
 	case 3:
-		switch asciiUpper(word[1]) {
-		case 'A':
-			if asciiUpper(word[0]) == 'M' && asciiUpper(word[2]) == 'X' {
-				return AGGREGATE, int(expr.OpMax)
+		switch asciiUpper(word[2]) {
+		case 'C':
+			if asciiUpper(word[0]) == 'A' && asciiUpper(word[1]) == 'S' {
+				return ASC, -1
 			}
-		case 'I':
-			if asciiUpper(word[0]) == 'M' && asciiUpper(word[2]) == 'N' {
-				return AGGREGATE, int(expr.OpMin)
+		case 'D':
+			if asciiUpper(word[0]) == 'A' && asciiUpper(word[1]) == 'N' {
+				return AND, -1
+			}
+			if asciiUpper(word[0]) == 'E' && asciiUpper(word[1]) == 'N' {
+				return END, -1
+			}
+		case 'G':
+			if asciiUpper(word[0]) == 'A' && asciiUpper(word[1]) == 'V' {
+				return AGGREGATE, int(expr.OpAvg)
 			}
 		case 'L':
-			if asciiUpper(word[0]) == 'A' && asciiUpper(word[2]) == 'L' {
+			if asciiUpper(word[0]) == 'A' && asciiUpper(word[1]) == 'L' {
 				return ALL, -1
 			}
-		case 'N':
-			if asciiUpper(word[0]) == 'A' && asciiUpper(word[2]) == 'D' {
-				return AND, -1
+		case 'M':
+			if asciiUpper(word[0]) == 'S' && asciiUpper(word[1]) == 'U' {
+				return AGGREGATE, int(expr.OpSum)
 			}
-			if asciiUpper(word[0]) == 'E' && asciiUpper(word[2]) == 'D' {
-				return END, -1
+		case 'N':
+			if asciiUpper(word[0]) == 'M' && asciiUpper(word[1]) == 'I' {
+				return AGGREGATE, int(expr.OpMin)
 			}
-		case 'O':
-			if asciiUpper(word[0]) == 'N' && asciiUpper(word[2]) == 'T' {
+		case 'T':
+			if asciiUpper(word[0]) == 'N' && asciiUpper(word[1]) == 'O' {
 				return NOT, -1
 			}
-		case 'S':
-			if asciiUpper(word[0]) == 'A' && asciiUpper(word[2]) == 'C' {
-				return ASC, -1
-			}
-		case 'U':
-			if asciiUpper(word[0]) == 'S' && asciiUpper(word[2]) == 'M' {
-				return AGGREGATE, int(expr.OpSum)
+		case 'W':
+			if asciiUpper(word[0]) == 'R' && asciiUpper(word[1]) == 'O' {
+				return ROWS, -1
 			}
-		case 'V':
-			if asciiUpper(word[0]) == 'A' && asciiUpper(word[2]) == 'G' {
-				return AGGREGATE, int(expr.OpAvg)
+		case 'X':
+			if asciiUpper(word[0]) == 'M' && asciiUpper(word[1]) == 'A' {
+				return AGGREGATE, int(expr.OpMax)
 			}
 		}
 	case 4:
@@ -100,6 +104,9 @@ func lookupKeyword(word []byte) (int, int) {
 			if equalASCIILetters4([4]byte(word), [4]byte{'D', 'E', 'S', 'C'}) {
 				return DESC, -1
 			}
+			if equalASCIILetters4([4]byte(word), [4]byte{'D', 'A', 'T', 'E'}) {
+				return DATE, -1
+			}
 		case 'E':
 			if equalASCIILetters4([4]byte(word), [4]byte{'E', 'L', 'S', 'E'}) {
 				return ELSE, -1
@@ -127,6 +134,9 @@ func lookupKeyword(word []byte) (int, int) {
 				return LAST, -1
 			}
 		case 'N':
+			if equalASCIILetters4([4]byte(word), [4]byte{'N', 'E', 'X', 'T'}) {
+				return NEXT, -1
+			}
 			if equalASCIILetters4([4]byte(word), [4]byte{'N', 'U', 'L', 'L'}) {
 				return NULL, -1
 			}
@@ -134,19 +144,37 @@ func lookupKeyword(word []byte) (int, int) {
 			if equalASCIILetters4([4]byte(word), [4]byte{'O', 'V', 'E', 'R'}) {
 				return OVER, -1
 			}
+			if equalASCIILetters4([4]byte(word), [4]byte{'O', 'N', 'L', 'Y'}) {
+				return ONLY, -1
+			}
 		case 'R':
+			if equalASCIILetters4([4]byte(word), [4]byte{'R', 'O', 'W', 'S'}) {
+				return ROWS, -1
+			}
 			if equalASCIILetters4([4]byte(word), [4]byte{'R', 'A', 'N', 'K'}) {
 				return AGGREGATE, int(expr.OpRank)
 			}
 		case 'T':
-			if equalASCIILetters4([4]byte(word), [4]byte{'T', 'R', 'U', 'E'}) {
-				return TRUE, -1
-			}
-			if equalASCIILetters4([4]byte(word), [4]byte{'T', 'H', 'E', 'N'}) {
-				return THEN, -1
-			}
-			if equalASCIILetters4([4]byte(word), [4]byte{'T', 'R', 'I', 'M'}) {
-				return TRIM, -1
+			switch asciiUpper(word[2]) {
+			case 'E':
+				if asciiUpper(word[1]) == 'I' && asciiUpper(word[3]) == 'S' {
+					return TIES, -1
+				}
+				if asciiUpper(word[1]) == 'H' && asciiUpper(word[3]) == 'N' {
+					return THEN, -1
+				}
+			case 'I':
+				if asciiUpper(word[1]) == 'R' && asciiUpper(word[3]) == 'M' {
+					return TRIM, -1
+				}
+			case 'M':
+				if asciiUpper(word[1]) == 'I' && asciiUpper(word[3]) == 'E' {
+					return TIME, -1
+				}
+			case 'U':
+				if asciiUpper(word[1]) == 'R' && asciiUpper(word[3]) == 'E' {
+					return TRUE, -1
+				}
 			}
 		case 'W':
 			if equalASCIILetters4([4]byte(word), [4]byte{'W', 'H', 'E', 'N'}) {
@@ -158,6 +186,10 @@ func lookupKeyword(word []byte) (int, int) {
 		}
 	case 5:
 		switch asciiUpper(word[0]) {
+		case 'A':
+			if equalASCIILetters5([5]byte(word), [5]byte{'A', 'R', 'R', 'A', 'Y'}) {
+				return ARRAY, -1
+			}
 		case 'C':
 			if equalASCIILetters5([5]byte(word), [5]byte{'C', 'R', 'O', 'S', 'S'}) {
 				return CROSS, -1
@@ -170,6 +202,9 @@ func lookupKeyword(word []byte) (int, int) {
 				return AGGREGATE, int(expr.OpBoolAnd)
 			}
 		case 'F':
+			if equalASCIILetters5([5]byte(word), [5]byte{'F', 'E', 'T', 'C', 'H'}) {
+				return FETCH, -1
+			}
 			if equalASCIILetters5([5]byte(word), [5]byte{'F', 'A', 'L', 'S', 'E'}) {
 				return FALSE, -1
 			}
@@ -203,10 +238,17 @@ func lookupKeyword(word []byte) (int, int) {
 			if equalASCIILetters5([5]byte(word), [5]byte{'R', 'I', 'G', 'H', 'T'}) {
 				return RIGHT, -1
 			}
+		case 'T':
+			if equalASCIILetters5([5]byte(word), [5]byte{'T', 'A', 'B', 'L', 'E'}) {
+				return TABLE, -1
+			}
 		case 'U':
 			if equalASCIILetters5([5]byte(word), [5]byte{'U', 'N', 'I', 'O', 'N'}) {
 				return UNION, -1
 			}
+			if equalASCIILetters5([5]byte(word), [5]byte{'U', 'S', 'I', 'N', 'G'}) {
+				return USING, -1
+			}
 		case 'V':
 			if equalASCIILetters5([5]byte(word), [5]byte{'V', 'A', 'L', 'U', 'E'}) {
 				return VALUE, -1
@@ -223,13 +265,20 @@ func lookupKeyword(word []byte) (int, int) {
 				return AGGREGATE, int(expr.OpBitOr)
 			}
 		case 'C':
-			if equalASCIILetters6([6]byte(word), [6]byte{'C', 'O', 'N', 'C', 'A', 'T'}) {
-				return CONCAT, -1
+			if equalASCIILetters6([6]byte(word), [6]byte{'C', 'R', 'E', 'A', 'T', 'E'}) {
+				return CREATE, -1
+			}
+		case 'D':
+			if equalASCIILetters6([6]byte(word), [6]byte{'D', 'E', 'L', 'E', 'T', 'E'}) {
+				return DELETE, -1
 			}
 		case 'E':
 			if equalASCIILetters6([6]byte(word), [6]byte{'E', 'X', 'I', 'S', 'T', 'S'}) {
 				return EXISTS, -1
 			}
+			if equalASCIILetters6([6]byte(word), [6]byte{'E', 'X', 'C', 'E', 'P', 'T'}) {
+				return EXCEPT, -1
+			}
 			if equalASCIILetters6([6]byte(word), [6]byte{'E', 'S', 'C', 'A', 'P', 'E'}) {
 				return ESCAPE, -1
 			}
@@ -253,6 +302,9 @@ func lookupKeyword(word []byte) (int, int) {
 			if equalASCIILetters6([6]byte(word), [6]byte{'O', 'F', 'F', 'S', 'E', 'T'}) {
 				return OFFSET, -1
 			}
+			if equalASCIILetters6([6]byte(word), [6]byte{'O', 'B', 'J', 'E', 'C', 'T'}) {
+				return OBJECT, -1
+			}
 		case 'S':
 			if equalASCIILetters6([6]byte(word), [6]byte{'S', 'E', 'L', 'E', 'C', 'T'}) {
 				return SELECT, -1
@@ -266,43 +318,67 @@ func lookupKeyword(word []byte) (int, int) {
 			}
 		}
 	case 7:
-		switch asciiUpper(word[3]) {
-		case 'D':
-			if equalASCIILetters7([7]byte(word), [7]byte{'L', 'E', 'A', 'D', 'I', 'N', 'G'}) {
-				return LEADING, -1
+		switch asciiUpper(word[0]) {
+		case 'B':
+			switch asciiUpper(word[4]) {
+			case 'A':
+				if equalASCII(word, []byte("BIT_AND")) {
+					return AGGREGATE, int(expr.OpBitAnd)
+				}
+			case 'E':
+				if equalASCIILetters7([7]byte(word), [7]byte{'B', 'E', 'T', 'W', 'E', 'E', 'N'}) {
+					return BETWEEN, -1
+				}
+			case 'X':
+				if equalASCII(word, []byte("BIT_XOR")) {
+					return AGGREGATE, int(expr.OpBitXor)
+				}
+			case '_':
+				if equalASCII(word, []byte("BOOL_OR")) {
+					return AGGREGATE, int(expr.OpBoolOr)
+				}
 			}
-		case 'I':
-			if equalASCIILetters7([7]byte(word), [7]byte{'S', 'I', 'M', 'I', 'L', 'A', 'R'}) {
-				return SIMILAR, -1
+		case 'C':
+			if equalASCIILetters7([7]byte(word), [7]byte{'C', 'O', 'L', 'L', 'A', 'T', 'E'}) {
+				return COLLATE, -1
 			}
-			if equalASCIILetters7([7]byte(word), [7]byte{'U', 'N', 'P', 'I', 'V', 'O', 'T'}) {
-				return UNPIVOT, -1
+		case 'E':
+			if equalASCIILetters7([7]byte(word), [7]byte{'E', 'X', 'T', 'R', 'A', 'C', 'T'}) {
+				return EXTRACT, -1
 			}
-		case 'L':
 			if equalASCIILetters7([7]byte(word), [7]byte{'E', 'X', 'P', 'L', 'A', 'I', 'N'}) {
 				return EXPLAIN, -1
 			}
-			if equalASCII(word, []byte("BOOL_OR")) {
-				return AGGREGATE, int(expr.OpBoolOr)
+			if equalASCIILetters7([7]byte(word), [7]byte{'E', 'X', 'E', 'C', 'U', 'T', 'E'}) {
+				return EXECUTE, -1
 			}
-		case 'R':
-			if equalASCIILetters7([7]byte(word), [7]byte{'E', 'X', 'T', 'R', 'A', 'C', 'T'}) {
-				return EXTRACT, -1
+		case 'L':
+			if equalASCIILetters7([7]byte(word), [7]byte{'L', 'E', 'A', 'D', 'I', 'N', 'G'}) {
+				return LEADING, -1
 			}
-		case 'S':
+		case 'M':
 			if equalASCIILetters7([7]byte(word), [7]byte{'M', 'I', 'S', 'S', 'I', 'N', 'G'}) {
 				return MISSING, -1
 			}
-		case 'W':
-			if equalASCIILetters7([7]byte(word), [7]byte{'B', 'E', 'T', 'W', 'E', 'E', 'N'}) {
-				return BETWEEN, -1
+		case 'N':
+			if equalASCIILetters7([7]byte(word), [7]byte{'N', 'A', 'T', 'U', 'R', 'A', 'L'}) {
+				return NATURAL, -1
+			}
+		case 'P':
+			if equalASCIILetters7([7]byte(word), [7]byte{'P', 'R', 'E', 'P', 'A', 'R', 'E'}) {
+				return PREPARE, -1
 			}
-		case '_':
-			if equalASCII(word, []byte("BIT_AND")) {
-				return AGGREGATE, int(expr.OpBitAnd)
+		case 'Q':
+			if equalASCIILetters7([7]byte(word), [7]byte{'Q', 'U', 'A', 'L', 'I', 'F', 'Y'}) {
+				return QUALIFY, -1
 			}
-			if equalASCII(word, []byte("BIT_XOR")) {
-				return AGGREGATE, int(expr.OpBitXor)
+		case 'S':
+			if equalASCIILetters7([7]byte(word), [7]byte{'S', 'I', 'M', 'I', 'L', 'A', 'R'}) {
+				return SIMILAR, -1
+			}
+		case 'U':
+			if equalASCIILetters7([7]byte(word), [7]byte{'U', 'N', 'P', 'I', 'V', 'O', 'T'}) {
+				return UNPIVOT, -1
 			}
 		}
 	case 8:
@@ -316,19 +392,36 @@ func lookupKeyword(word []byte) (int, int) {
 				return COALESCE, -1
 			}
 		case 'D':
-			if equalASCII(word, []byte("DATE_ADD")) {
-				return DATE_ADD, -1
-			}
-			if equalASCII(word, []byte("DATE_BIN")) {
-				return DATE_BIN, -1
-			}
-			if equalASCIILetters8([8]byte(word), [8]byte{'D', 'I', 'S', 'T', 'I', 'N', 'C', 'T'}) {
-				return DISTINCT, -1
+			switch asciiUpper(word[5]) {
+			case 'A':
+				if equalASCII(word, []byte("DATE_ADD")) {
+					return DATE_ADD, -1
+				}
+			case 'B':
+				if equalASCII(word, []byte("DATE_BIN")) {
+					return DATE_BIN, -1
+				}
+			case 'I':
+				if equalASCIILetters8([8]byte(word), [8]byte{'D', 'E', 'S', 'C', 'R', 'I', 'B', 'E'}) {
+					return DESCRIBE, -1
+				}
+			case 'N':
+				if equalASCIILetters8([8]byte(word), [8]byte{'D', 'I', 'S', 'T', 'I', 'N', 'C', 'T'}) {
+					return DISTINCT, -1
+				}
 			}
 		case 'E':
 			if equalASCIILetters8([8]byte(word), [8]byte{'E', 'A', 'R', 'L', 'I', 'E', 'S', 'T'}) {
 				return AGGREGATE, int(expr.OpEarliest)
 			}
+		case 'I':
+			if equalASCIILetters8([8]byte(word), [8]byte{'I', 'N', 'T', 'E', 'R', 'V', 'A', 'L'}) {
+				return INTERVAL, -1
+			}
+		case 'O':
+			if equalASCIILetters8([8]byte(word), [8]byte{'O', 'V', 'E', 'R', 'L', 'A', 'P', 'S'}) {
+				return OVERLAPS, -1
+			}
 		case 'T':
 			if equalASCIILetters8([8]byte(word), [8]byte{'T', 'R', 'A', 'I', 'L', 'I', 'N', 'G'}) {
 				return TRAILING, -1
@@ -339,30 +432,50 @@ func lookupKeyword(word []byte) (int, int) {
 			}
 		}
 	case 9:
-		if equalASCII(word, []byte("DATE_DIFF")) {
-			return DATE_DIFF, -1
-		}
-		if equalASCIILetters9([9]byte(word), [9]byte{'P', 'A', 'R', 'T', 'I', 'T', 'I', 'O', 'N'}) {
-			return PARTITION, -1
+		switch asciiUpper(word[0]) {
+		case 'D':
+			if equalASCII(word, []byte("DATE_DIFF")) {
+				return DATE_DIFF, -1
+			}
+		case 'I':
+			if equalASCIILetters9([9]byte(word), [9]byte{'I', 'N', 'T', 'E', 'R', 'S', 'E', 'C', 'T'}) {
+				return INTERSECT, -1
+			}
+		case 'P':
+			if equalASCIILetters9([9]byte(word), [9]byte{'P', 'A', 'R', 'T', 'I', 'T', 'I', 'O', 'N'}) {
+				return PARTITION, -1
+			}
+		case 'S':
+			if equalASCIILetters9([9]byte(word), [9]byte{'S', 'Y', 'M', 'M', 'E', 'T', 'R', 'I', 'C'}) {
+				return SYMMETRIC, -1
+			}
+		case 'T':
+			if equalASCIILetters9([9]byte(word), [9]byte{'T', 'I', 'M', 'E', 'S', 'T', 'A', 'M', 'P'}) {
+				return TIMESTAMP, -1
+			}
 		}
 	case 10:
-		switch asciiUpper(word[1]) {
+		switch asciiUpper(word[2]) {
 		case 'A':
-			if equalASCII(word, []byte("DATE_TRUNC")) {
-				return DATE_TRUNC, -1
+			if equalASCIILetters10([10]byte(word), [10]byte{'D', 'E', 'A', 'L', 'L', 'O', 'C', 'A', 'T', 'E'}) {
+				return DEALLOCATE, -1
 			}
-		case 'E':
+		case 'D':
+			if equalASCII(word, []byte("STDDEV_POP")) {
+				return AGGREGATE, int(expr.OpStdDevPop)
+			}
+		case 'N':
 			if equalASCII(word, []byte("DENSE_RANK")) {
 				return AGGREGATE, int(expr.OpDenseRank)
 			}
-		case 'O':
+		case 'T':
+			if equalASCII(word, []byte("DATE_TRUNC")) {
+				return DATE_TRUNC, -1
+			}
+		case 'W':
 			if equalASCII(word, []byte("ROW_NUMBER")) {
 				return AGGREGATE, int(expr.OpRowNumber)
 			}
-		case 'T':
-			if equalASCII(word, []byte("STDDEV_POP")) {
-				return AGGREGATE, int(expr.OpStdDevPop)
-			}
 		}
 	case 12:
 		if equalASCII(word, []byte("VARIANCE_POP")) {
@@ -441,4 +554,13 @@ func equalASCIILetters9(anyCase [9]byte, upperCaseLetters [9]byte) bool {
 	return true
 }
 
-// checksum: e31bf3b2a31f75afe8eebbceb182f14b
+func equalASCIILetters10(anyCase [10]byte, upperCaseLetters [10]byte) bool {
+	for i := range upperCaseLetters {
+		if (upperCaseLetters[i]^anyCase[i])&0xdf != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// checksum: ff3b4ff9b805d63c110f8ae5b66ef055
@@ -20,7 +20,9 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/SnellerInc/sneller/date"
 	"github.com/SnellerInc/sneller/expr"
 )
 
@@ -46,7 +48,10 @@ func dropParser(p *yyParserImpl) {
 // and returns the result, or an error if one
 // is encountered.
 func Parse(in []byte) (*expr.Query, error) {
-	s := &scanner{from: in}
+	return parse(&scanner{from: in})
+}
+
+func parse(s *scanner) (*expr.Query, error) {
 	p := newParser()
 	ret := p.Parse(s)
 	dropParser(p)
@@ -67,9 +72,19 @@ func Parse(in []byte) (*expr.Query, error) {
 // (the type name following AS inside a CAST
 // has no grammatical significance anyway)
 func buildCast(inner expr.Node, id string) (expr.Node, bool) {
+	ts, ok := typeNamed(id)
+	if !ok {
+		return nil, false
+	}
+	return &expr.Cast{From: inner, To: ts}, true
+}
+
+// typeNamed returns the type named
+// by id in CAST and PREPARE
+func typeNamed(id string) (expr.TypeSet, bool) {
 	var ts expr.TypeSet
 	switch strings.ToUpper(id) {
-	case "INTEGER":
+	case "INTEGER", "BIGINT":
 		ts = expr.IntegerType
 	case "FLOAT":
 		ts = expr.FloatType
@@ -92,9 +107,37 @@ func buildCast(inner expr.Node, id string) (expr.Node, bool) {
 	case "SYMBOL":
 		ts = expr.SymbolType
 	default:
+		return 0, false
+	}
+	return ts, true
+}
+
+// buildCollate applies a GROUP BY collation;
+// the only supported collation is "ci", which
+// compares strings case-insensitively
+func buildCollate(inner expr.Node, id string) (expr.Node, bool) {
+	if !strings.EqualFold(id, "ci") {
 		return nil, false
 	}
-	return &expr.Cast{From: inner, To: ts}, true
+	return expr.Call(expr.CollateCI, inner), true
+}
+
+// buildIsJSON builds 'e IS JSON [OBJECT|ARRAY]';
+// like CAST types, the words following IS
+// are identifiers rather than keywords
+func buildIsJSON(e expr.Node, id, typ string) (expr.Node, error) {
+	if !strings.EqualFold(id, "JSON") {
+		return nil, fmt.Errorf("unexpected IS %s", id)
+	}
+	switch strings.ToUpper(typ) {
+	case "":
+		return expr.Call(expr.IsJSON, e), nil
+	case "OBJECT":
+		return expr.Call(expr.IsJSONObject, e), nil
+	case "ARRAY":
+		return expr.Call(expr.IsJSONArray, e), nil
+	}
+	return nil, fmt.Errorf("bad IS JSON type %q", typ)
 }
 
 // weekday parses a weekday from string
@@ -177,15 +220,22 @@ func timePartFor(id, fn string) (expr.Timepart, bool) {
 		if part == expr.DOW || part == expr.DOY {
 			return 0, false
 		}
-	case "EXTRACT":
-		if part == expr.Week {
-			return 0, false
-		}
 	}
 
 	return part, ok
 }
 
+// dateExtract builds EXTRACT(id FROM from)
+func dateExtract(id string, from expr.Node) (expr.Node, bool) {
+	// EPOCH is not a part of a timestamp,
+	// so it is not a valid expr.Timepart
+	if strings.EqualFold(id, "EPOCH") {
+		return expr.Call(expr.DateExtractEpoch, from), true
+	}
+	part, ok := timePartFor(id, "EXTRACT")
+	return expr.DateExtract(part, from), ok
+}
+
 func parseIntervalQuantity(s string) (int64, error) {
 	return strconv.ParseInt(s, 10, 64)
 }
@@ -208,15 +258,45 @@ func parseIntervalPart(s string) (expr.Timepart, bool) {
 	return 0, false
 }
 
+// parseCalendarPart parses the parts of an
+// interval that do not have a fixed width
+func parseCalendarPart(s string) (months int64, ok bool) {
+	switch strings.ToUpper(s) {
+	case "MONTH", "MONTHS":
+		return 1, true
+	case "YEAR", "YEARS":
+		return 12, true
+	}
+	return 0, false
+}
+
 func parseInterval(s string) (int64, error) {
+	iv, err := parseIntervalLiteral(s, false)
+	return iv.micros, err
+}
+
+// interval is the value of an INTERVAL literal
+type interval struct {
+	months int64 // calendar months
+	micros int64 // fixed-width remainder
+}
+
+func (i interval) neg() interval {
+	return interval{months: -i.months, micros: -i.micros}
+}
+
+// parseIntervalLiteral parses an interval;
+// months and years are accepted only if
+// calendar is true
+func parseIntervalLiteral(s string, calendar bool) (interval, error) {
 	fields := strings.Fields(s)
 	i := 0
 
 	if len(fields) == 0 {
-		return 0, fmt.Errorf("invalid interval %q: interval cannot be empty", s)
+		return interval{}, fmt.Errorf("invalid interval %q: interval cannot be empty", s)
 	}
 
-	interval := int64(0)
+	var ret interval
 
 	// Parse <int> <string> pairs
 	for {
@@ -225,24 +305,208 @@ func parseInterval(s string) (int64, error) {
 		}
 
 		if i+2 > len(fields) {
-			return 0, fmt.Errorf("invalid interval %q", s)
+			return interval{}, fmt.Errorf("invalid interval %q", s)
 		}
 
 		quantity, quantityErr := parseIntervalQuantity(fields[i])
 		if quantityErr != nil {
-			return 0, fmt.Errorf("invalid interval %q: %q is not a valid quantity", s, fields[i])
+			return interval{}, fmt.Errorf("invalid interval %q: %q is not a valid quantity", s, fields[i])
+		}
+
+		if months, ok := parseCalendarPart(fields[i+1]); ok && calendar {
+			ret.months += quantity * months
+			i += 2
+			continue
 		}
 
 		part, partOk := parseIntervalPart(fields[i+1])
 		if !partOk {
-			return 0, fmt.Errorf("invalid interval %q: %q is not a valid interval part", s, fields[i+1])
+			return interval{}, fmt.Errorf("invalid interval %q: %q is not a valid interval part", s, fields[i+1])
 		}
 
-		interval += quantity * int64(expr.TimePartMultiplier[part])
+		ret.micros += quantity * int64(expr.TimePartMultiplier[part])
 		i += 2
 	}
 
-	return interval, nil
+	return ret, nil
+}
+
+// timestampLiteral produces the timestamp
+// of 'TIMESTAMP str'
+func timestampLiteral(str string) (expr.Node, error) {
+	t, ok := date.Parse([]byte(str))
+	if !ok {
+		return nil, fmt.Errorf("invalid TIMESTAMP literal %q", str)
+	}
+	return &expr.Timestamp{Value: t.Truncate(time.Microsecond)}, nil
+}
+
+// addInterval adds an INTERVAL to a timestamp.
+// The months are added first (clamping the day of
+// the month to the last day of the resulting month),
+// and then the fixed-width remainder is added.
+func addInterval(ts expr.Node, iv interval) expr.Node {
+	if iv.months != 0 {
+		ts = expr.Call(expr.DateAddMonthClamp, expr.Integer(iv.months), ts)
+	}
+	if iv.micros != 0 || iv.months == 0 {
+		ts = expr.DateAdd(expr.Microsecond, expr.Integer(iv.micros), ts)
+	}
+	return ts
+}
+
+// contextualAggregates are the aggregates whose names
+// are not keywords, so that they can still be used as
+// identifiers; they are only aggregates when they are
+// called (see funcall)
+var contextualAggregates = map[string]expr.AggregateOp{
+	"STRING_AGG":     expr.OpStringAgg,
+	"GROUP_CONCAT":   expr.OpStringAgg,
+	"TOPK":           expr.OpTopK,
+	"COVAR_POP":      expr.OpCovarPop,
+	"COVAR_SAMP":     expr.OpCovarSamp,
+	"CORR":           expr.OpCorr,
+	"REGR_SLOPE":     expr.OpRegrSlope,
+	"REGR_INTERCEPT": expr.OpRegrIntercept,
+	"MODE":           expr.OpMode,
+}
+
+// funcall produces a call to the function named fn,
+// or to the aggregate named fn if it is one of
+// contextualAggregates or a user-defined aggregate
+// (see expr.RegisterAggregate); only aggregates
+// accept DISTINCT, ORDER BY, FILTER and OVER
+func funcall(fn string, distinct bool, args []expr.Node, order []expr.Order, filter expr.Node, over *expr.Window) (expr.Node, error) {
+	name := strings.ToUpper(fn)
+	op, ok := contextualAggregates[name]
+	if !ok {
+		if _, ok = expr.LookupAggregate(name); ok {
+			op = expr.OpUser
+		}
+	}
+	if ok {
+		agg, err := toAggregate(op, name, distinct, args, order, filter, over)
+		if err != nil {
+			return nil, err
+		}
+		return agg, nil
+	}
+	if distinct || order != nil || filter != nil || over != nil {
+		return nil, fmt.Errorf("%s is not an aggregate function", fn)
+	}
+	return call(fn, args)
+}
+
+// call produces a call to the function named fn;
+// the aliases of COALESCE and CASE used by other
+// SQL dialects are rewritten to their canonical form
+func call(fn string, args []expr.Node) (expr.Node, error) {
+	switch name := strings.ToUpper(fn); name {
+	case "IFNULL", "NVL":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%s expects 2 arguments, but found %d", name, len(args))
+		}
+		return expr.Coalesce(args), nil
+	case "NVL2":
+		if len(args) != 3 {
+			return nil, fmt.Errorf("%s expects 3 arguments, but found %d", name, len(args))
+		}
+		return expr.Nvl2(args[0], args[1], args[2]), nil
+	}
+	op := expr.CallByName(fn, args...)
+	if op.Private() {
+		return nil, fmt.Errorf("cannot use reserved builtin %q", fn)
+	}
+	return op, nil
+}
+
+// lateralJoin produces the CROSS JOIN of left and
+// the sub-query sub introduced by word, as in
+//
+//	FROM t, LATERAL (SELECT ...) AS alias
+//
+// (LATERAL is not a keyword, so that it can
+// still be used as an identifier everywhere else)
+func lateralJoin(left expr.From, word string, sub *expr.Select, alias string) (expr.From, error) {
+	if !strings.EqualFold(word, "LATERAL") {
+		return nil, fmt.Errorf("unexpected sub-query after %s", word)
+	}
+	return &expr.Join{
+		Kind:    expr.CrossJoin,
+		Left:    left,
+		Right:   expr.Bind(sub, alias),
+		Lateral: true,
+	}, nil
+}
+
+// crossJoin produces the CROSS JOIN of left and right;
+// if right is UNNEST(list), it is unnested just like
+// 'FROM t, list', except that list may begin with the
+// name of an un-aliased table on the left (as in
+// 'FROM t, UNNEST(t.list) AS x'), which is stripped
+// since the fields of such a table are unqualified
+func crossJoin(left expr.From, right expr.Binding) (expr.From, error) {
+	b, ok := right.Expr.(*expr.Builtin)
+	if !ok || b.Func != expr.Unspecified || !strings.EqualFold(b.Text, "UNNEST") {
+		return &expr.Join{Kind: expr.CrossJoin, Left: left, Right: right}, nil
+	}
+	if len(b.Args) != 1 {
+		return nil, fmt.Errorf("UNNEST expects 1 argument, but found %d", len(b.Args))
+	}
+	list := b.Args[0]
+	if t := leftmostTable(left); t != nil && !t.Explicit() {
+		path, ok := expr.FlatPath(list)
+		if ok && len(path) > 1 && path[0] == expr.DefaultBinding(t.Expr) {
+			list = expr.MakePath(path[1:])
+		}
+	}
+	var as string
+	if right.Explicit() {
+		as = right.Result()
+	}
+	return &expr.Join{Kind: expr.CrossJoin, Left: left, Right: expr.Bind(list, as)}, nil
+}
+
+// leftmostTable returns the table at
+// the beginning of a chain of joins
+func leftmostTable(f expr.From) *expr.Table {
+	for {
+		switch t := f.(type) {
+		case *expr.Table:
+			return t
+		case *expr.Join:
+			f = t.Left
+		default:
+			return nil
+		}
+	}
+}
+
+// quantifiedSubquery is the sub-query
+// of a quantified comparison, as in
+//
+//	x > ALL (SELECT ...)
+//	x = ANY (SELECT ...)
+type quantifiedSubquery struct {
+	fn  expr.BuiltinOp // AnySubquery or AllSubquery
+	sel *expr.Select
+}
+
+// anySubquery produces the sub-query sel quantified
+// with word, which must be ANY or SOME
+// (they are not keywords, so that they can
+// still be used as identifiers everywhere else)
+func anySubquery(word string, sel *expr.Select) (quantifiedSubquery, error) {
+	if !strings.EqualFold(word, "ANY") && !strings.EqualFold(word, "SOME") {
+		return quantifiedSubquery{}, fmt.Errorf("unexpected sub-query after %s", word)
+	}
+	return quantifiedSubquery{fn: expr.AnySubquery, sel: sel}, nil
+}
+
+// quantified produces the comparison x <op> q
+// as ANY_SUBQUERY(op, x, q) or ALL_SUBQUERY(op, x, q)
+func quantified(op expr.CmpOp, x expr.Node, q quantifiedSubquery) expr.Node {
+	return expr.Call(q.fn, expr.Integer(op), x, q.sel)
 }
 
 func exists(s *expr.Select) expr.Node {
@@ -260,6 +524,18 @@ func exists(s *expr.Select) expr.Node {
 	return expr.Is(s, expr.IsNotMissing)
 }
 
+// fetchLimit returns the LIMIT of a query
+// given its LIMIT and FETCH clauses
+func fetchLimit(limit, fetch *expr.Integer) (*expr.Integer, error) {
+	if fetch == nil {
+		return limit, nil
+	}
+	if limit != nil {
+		return nil, fmt.Errorf("LIMIT cannot be combined with FETCH")
+	}
+	return fetch, nil
+}
+
 // decodeDistinct inteprets the node list collected by `maybe_toplevel_distinct`
 // as inputs for `expr.Select`. The matching is as follows:
 // if nodes == nil   then SELECT ...
@@ -317,22 +593,33 @@ type unionItem struct {
 }
 
 func buildUnion(n expr.Node, unions []unionItem) expr.Node {
-	switch len(unions) {
-	case 0:
-		return n
-	case 1:
-		return &expr.Union{
-			Type:  unions[0].typ,
-			Left:  n,
-			Right: unions[0].sel,
+	// INTERSECT binds more tightly than UNION and EXCEPT,
+	// so 'a EXCEPT b INTERSECT c' is 'a EXCEPT (b INTERSECT c)';
+	// otherwise set operations are left-associative,
+	// so 'a EXCEPT b EXCEPT c' is '(a EXCEPT b) EXCEPT c'
+	var outer *expr.Union
+	for i := range unions {
+		typ := unions[i].typ
+		intersect := typ == expr.Intersect || typ == expr.IntersectAll
+		if intersect && outer != nil {
+			outer.Right = &expr.Union{
+				Type:  typ,
+				Left:  outer.Right,
+				Right: unions[i].sel,
+			}
+			continue
 		}
-	default:
-		return &expr.Union{
-			Type:  unions[0].typ,
+		u := &expr.Union{
+			Type:  typ,
 			Left:  n,
-			Right: buildUnion(unions[0].sel, unions[1:]),
+			Right: unions[i].sel,
+		}
+		n = u
+		if !intersect {
+			outer = u
 		}
 	}
+	return n
 }
 
 func buildQuery(explain string, with []expr.CTE, selinto selectWithInto, unions []unionItem) (*expr.Query, error) {
@@ -348,3 +635,47 @@ func buildQuery(explain string, with []expr.CTE, selinto selectWithInto, unions
 		Body:    buildUnion(selinto.sel, unions),
 	}, nil
 }
+
+// paramTypes returns the types named in
+// 'PREPARE name (type, ...) AS'
+func paramTypes(ids []string) ([]expr.TypeSet, error) {
+	types := make([]expr.TypeSet, len(ids))
+	for i := range ids {
+		ts, ok := typeNamed(ids[i])
+		if !ok || ts == expr.MissingType {
+			return nil, fmt.Errorf("bad PREPARE parameter type %q", ids[i])
+		}
+		types[i] = ts
+	}
+	return types, nil
+}
+
+// buildPrepare turns q into 'PREPARE name AS q'
+// and declares the types of its parameters;
+// if any types are declared, every parameter
+// must have one
+func buildPrepare(q *expr.Query, name string, types []expr.TypeSet) error {
+	params := q.Params()
+	if len(types) > 0 && len(types) != len(params) {
+		return fmt.Errorf("PREPARE %s: %d types declared for %d parameters", name, len(types), len(params))
+	}
+	for i := range types {
+		params[i].Declared = types[i]
+	}
+	q.Prepare = name
+	return nil
+}
+
+// buildUsing returns the values of
+// 'EXECUTE name USING value, ...'
+func buildUsing(values []expr.Node) ([]expr.Constant, error) {
+	using := make([]expr.Constant, len(values))
+	for i := range values {
+		c, ok := expr.Simplify(values[i], expr.NoHint).(expr.Constant)
+		if !ok {
+			return nil, fmt.Errorf("EXECUTE parameter %s is not a constant", expr.ToString(values[i]))
+		}
+		using[i] = c
+	}
+	return using, nil
+}
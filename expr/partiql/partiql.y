@@ -55,30 +55,41 @@ import (
     values   []expr.Node
     orders   []expr.Order
     unions   []unionItem
+    strs     []string
+    types    []expr.TypeSet
+    quant    quantifiedSubquery
+    pos      int
+    end      int
 }
 
 %token ERROR EOF
-%left UNION
-%token SELECT FROM WHERE GROUP ORDER BY HAVING LIMIT OFFSET WITH INTO EXPLAIN
+%left UNION EXCEPT
+%left INTERSECT
+%token <str> SELECT WITH
+%token FROM WHERE GROUP ORDER BY HAVING QUALIFY LIMIT OFFSET INTO EXPLAIN
 %token DISTINCT ALL AS EXISTS NULLS FIRST LAST ASC DESC UNPIVOT AT
-%token PARTITION
+%token PARTITION COLLATE DESCRIBE USING
+%token <str> PREPARE EXECUTE DEALLOCATE
+%token <str> DELETE CREATE TABLE
+%token <str> FETCH NEXT ROWS ONLY TIES
 %token VALUE
 %token LEADING TRAILING BOTH
 %right COALESCE NULLIF EXTRACT DATE_TRUNC
 %right CAST UTCNOW
 %right DATE_ADD DATE_BIN DATE_DIFF EARLIEST LATEST
-%left JOIN LEFT RIGHT CROSS INNER OUTER FULL
+%left JOIN LEFT RIGHT CROSS INNER OUTER FULL NATURAL
 %left ON
 %left APPROX_COUNT_DISTINCT
 %token <integer> AGGREGATE
 %token <str> ID
-%token <empty> '(' ',' ')' '[' ']' '{' '}'
+%token <empty> '(' ',' ')' '[' ']' '{' '}' '?'
 %token <empty> NULL TRUE FALSE MISSING
 
 %left OR
 %left AND
 %right '!' '~' NOT
 %left BETWEEN CASE WHEN THEN ELSE END TO TRIM
+%token SYMMETRIC OVERLAPS
 %left <empty> EQ NE LT LE GT GE
 %left <empty> SIMILAR REGEXP_MATCH_CI ILIKE LIKE IN IS OVER FILTER ESCAPE
 %left <empty> '|'
@@ -90,35 +101,42 @@ import (
 %left <empty> CONCAT APPEND
 %left NEGATION_PRECEDENCE
 %nonassoc <empty> '.'
+// OBJECT or ARRAY following 'expr IS JSON'
+// is the JSON type rather than an alias
+%nonassoc <str> OBJECT ARRAY
 
 %token <expr> NUMBER ION
+%token <str> DATE TIME TIMESTAMP INTERVAL
 %token <str> STRING
 
 %type <query> query
 %type <expr> expr datum datum_or_parens maybe_into
-%type <expr> where_expr having_expr case_optional_expr case_optional_else parenthesized_expr
+%type <expr> where_expr having_expr qualify_expr case_optional_expr case_optional_else parenthesized_expr
 %type <expr> optional_filter
 %type <expr> unpivot unpivot_source
 %type <with> maybe_cte_bindings cte_bindings
 %type <yesno> ascdesc nullslast maybe_distinct
-%type <str> identifier
+%type <str> identifier as_identifier implicit_alias json_type maybe_alias
 %type <integer> literal_int
 %type <sel> select_stmt
 %type <selinto> select_with_into_stmt
-%type <bindings> group_expr binding_list
-%type <bind> value_binding
+%type <bindings> group_expr group_list binding_list
+%type <bind> value_binding group_binding
 %type <from> from_expr lhs_from_expr
 %type <values> partition_expr value_list any_value_list field_value_list field_value_pair agg_value_list maybe_toplevel_distinct
 %type <order> order_one_col
 %type <orders> order_expr order_cols
 %type <jk> join_kind
+%type <strs> using_list
 %type <exprint> limit_expr
-%type <exprint> offset_expr
+%type <exprint> offset_expr fetch_expr fetch_count
 %type <limbs> case_limbs
 %type <wind> maybe_window
 %type <integer> trim_type
 %type <str> maybe_explain
 %type <unions> maybe_union
+%type <types> maybe_param_types
+%type <quant> quantified_subquery
 %start query
 
 %%
@@ -133,20 +151,107 @@ maybe_explain maybe_cte_bindings select_with_into_stmt maybe_union
 
   yylex.(*scanner).result = query
 }
+| DESCRIBE expr
+{
+  yylex.(*scanner).result = &expr.Query{Describe: true, Body: $2}
+}
+| PREPARE identifier maybe_param_types AS maybe_cte_bindings select_with_into_stmt maybe_union
+{
+  query, err := buildQuery("", $5, $6, $7)
+  if err == nil {
+    err = buildPrepare(query, $2, $3)
+  }
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  yylex.(*scanner).result = query
+}
+| DELETE FROM value_binding WHERE expr
+{
+  yylex.(*scanner).result = &expr.Query{
+    Delete: true,
+    Body: &expr.Select{
+      Columns: []expr.Binding{expr.Bind(expr.Star{}, "")},
+      From:    &expr.Table{Binding: $3},
+      Where:   $5,
+    },
+  }
+}
+| DELETE FROM value_binding
+{
+  yylex.Error("DELETE requires a WHERE clause")
+}
+| CREATE TABLE datum AS maybe_cte_bindings select_stmt maybe_union
+{
+  query, err := buildQuery("", $5, selectWithInto{sel: $6}, $7)
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  query.Create = true
+  query.Into = $3
+  yylex.(*scanner).result = query
+}
+| EXECUTE identifier
+{
+  yylex.(*scanner).result = &expr.Query{Execute: $2}
+}
+| EXECUTE identifier USING value_list
+{
+  using, err := buildUsing($4)
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  yylex.(*scanner).result = &expr.Query{Execute: $2, Using: using}
+}
+| DEALLOCATE identifier
+{
+  yylex.(*scanner).result = &expr.Query{Deallocate: $2}
+}
+
+maybe_param_types:
+'(' using_list ')'
+{
+  types, err := paramTypes($2)
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  $$ = types
+}
+| { $$ = nil }
 
 select_with_into_stmt:
-SELECT maybe_toplevel_distinct binding_list maybe_into from_expr where_expr group_expr having_expr order_expr limit_expr offset_expr
+SELECT maybe_toplevel_distinct binding_list maybe_into from_expr where_expr group_expr having_expr qualify_expr order_expr limit_expr offset_expr fetch_expr
 {
     distinct, distinctExpr := decodeDistinct($2)
-    $$.sel = &expr.Select{Distinct: distinct, DistinctExpr: distinctExpr, Columns: $3, From: $5, Where: $6, GroupBy: $7, Having: $8, OrderBy: $9, Limit: $10, Offset: $11}
+    limit, err := fetchLimit($11, $13)
+    if err != nil {
+      yylex.Error(err.Error())
+    }
+    $$.sel = &expr.Select{Distinct: distinct, DistinctExpr: distinctExpr, Columns: $3, From: $5, Where: $6, GroupBy: $7, Having: $8, Qualify: $9, OrderBy: $10, Limit: limit, Offset: $12}
+    if err := expr.ResolveUsing($$.sel); err != nil {
+      yylex.Error(err.Error())
+    }
+    if err := expr.ResolveOrdinals($$.sel); err != nil {
+      yylex.Error(err.Error())
+    }
     $$.into = $4
 }
 
 select_stmt:
-SELECT maybe_toplevel_distinct binding_list from_expr where_expr group_expr having_expr order_expr limit_expr offset_expr
+SELECT maybe_toplevel_distinct binding_list from_expr where_expr group_expr having_expr qualify_expr order_expr limit_expr offset_expr fetch_expr
 {
     distinct, distinctExpr := decodeDistinct($2)
-    $$ = &expr.Select{Distinct: distinct, DistinctExpr: distinctExpr, Columns: $3, From: $4, Where: $5, GroupBy: $6, Having: $7, OrderBy: $8, Limit: $9, Offset: $10}
+    limit, err := fetchLimit($10, $12)
+    if err != nil {
+      yylex.Error(err.Error())
+    }
+    $$ = &expr.Select{Distinct: distinct, DistinctExpr: distinctExpr, Columns: $3, From: $4, Where: $5, GroupBy: $6, Having: $7, Qualify: $8, OrderBy: $9, Limit: limit, Offset: $11}
+    if err := expr.ResolveUsing($$); err != nil {
+      yylex.Error(err.Error())
+    }
+    if err := expr.ResolveOrdinals($$); err != nil {
+      yylex.Error(err.Error())
+    }
 }
 
 maybe_explain:
@@ -170,6 +275,22 @@ maybe_union:
     $$ = append($$, unionItem{typ: expr.UnionAll, sel: $3})
     $$ = append($$, $4...)
   }
+| INTERSECT select_stmt maybe_union {
+    $$ = append($$, unionItem{typ: expr.Intersect, sel: $2})
+    $$ = append($$, $3...)
+  }
+| INTERSECT ALL select_stmt maybe_union {
+    $$ = append($$, unionItem{typ: expr.IntersectAll, sel: $3})
+    $$ = append($$, $4...)
+  }
+| EXCEPT select_stmt maybe_union {
+    $$ = append($$, unionItem{typ: expr.Except, sel: $2})
+    $$ = append($$, $3...)
+  }
+| EXCEPT ALL select_stmt maybe_union {
+    $$ = append($$, unionItem{typ: expr.ExceptAll, sel: $3})
+    $$ = append($$, $4...)
+  }
 
 cte_bindings:
 WITH identifier AS '(' select_stmt ')' { $$ = []expr.CTE{{Table: $2, As: $5}} } |
@@ -179,15 +300,15 @@ cte_bindings ',' identifier AS '(' select_stmt ')' { $$ = append($1, expr.CTE{Ta
 // a value expression plus a binding
 // (with an optional AS)
 value_binding:
-expr AS identifier { $$ = expr.Bind($1, $3) } |
-expr identifier { $$ = expr.Bind($1, $2) } |
+expr AS as_identifier { $$ = expr.Bind($1, $3) } |
+expr implicit_alias { $$ = expr.Bind($1, $2) } |
 expr { $$ = expr.Bind($1, "") } |
 '*' { $$ = expr.Bind(expr.Star{}, "") } |
 unpivot { $$ = expr.Bind($1, "") }
 
 // match exactly a single datum
 datum:
-identifier { $$ = expr.Ident($1) } |
+identifier { $$ = yylex.(*scanner).at(expr.Ident($1), $<pos>1, $<end>1) } |
 NUMBER { $$ = $1 } |
 TRUE { $$ = expr.Bool(true) } |
 FALSE { $$ = expr.Bool(false) } |
@@ -195,11 +316,15 @@ NULL { $$ = expr.Null{} } |
 MISSING { $$ = expr.Missing{} } |
 STRING { $$ = expr.String($1) } |
 ION { $$ = $1 } |
+'?' { $$ = yylex.(*scanner).param() } |
 '{' field_value_list '}' { $$ = expr.Call(expr.MakeStruct, $2...) } |
 '[' any_value_list ']' { $$ = expr.Call(expr.MakeList, $2...) } |
-datum '.' identifier { $$ = &expr.Dot{Inner: $1, Field: $3} } |
+datum '.' identifier { $$ = yylex.(*scanner).at(&expr.Dot{Inner: $1, Field: $3}, $<pos>1, $<end>1) } |
 datum '[' literal_int ']' { $$ = &expr.Index{Inner: $1, Offset: $3} } |
-datum '[' STRING ']' { $$ = &expr.Dot{Inner: $1, Field: $3} }
+datum '[' literal_int ':' literal_int ']' { $$ = &expr.Slice{Inner: $1, From: $3, To: $5} } |
+datum '[' literal_int ':' ']' { $$ = &expr.Slice{Inner: $1, From: $3, ToEnd: true} } |
+datum '[' ':' literal_int ']' { $$ = &expr.Slice{Inner: $1, To: $4} } |
+datum '[' STRING ']' { $$ = yylex.(*scanner).at(&expr.Dot{Inner: $1, Field: $3}, $<pos>1, $<end>1) }
 
 // datum_or_parens is guaranteed to
 // avoid shift-reduce conflicts with BETWEEN,
@@ -235,15 +360,15 @@ datum_or_parens
 }
 | AGGREGATE '(' ')' optional_filter maybe_window
 {
-  agg, err := toAggregate(expr.AggregateOp($1), false, nil, $4, $5)
+  agg, err := toAggregate(expr.AggregateOp($1), "", false, nil, nil, $4, $5)
   if err != nil {
     yylex.Error(err.Error())
   }
   $$ = agg
 }
-| AGGREGATE '(' maybe_distinct agg_value_list ')' optional_filter maybe_window
+| AGGREGATE '(' maybe_distinct agg_value_list order_expr ')' optional_filter maybe_window
 {
-  agg, err := toAggregate(expr.AggregateOp($1), $3, $4, $6, $7)
+  agg, err := toAggregate(expr.AggregateOp($1), "", $3, $4, $5, $7, $8)
   if err != nil {
     yylex.Error(err.Error())
   }
@@ -311,11 +436,11 @@ datum_or_parens
 }
 | EXTRACT '(' ID FROM expr ')'
 {
-  part, ok := timePartFor($3, "EXTRACT")
+  node, ok := dateExtract($3, $5)
   if !ok {
     yylex.Error(__yyfmt__.Sprintf("bad EXTRACT part %q", $3))
   }
-  $$ = expr.DateExtract(part, $5)
+  $$ = node
 }
 | UTCNOW '(' ')'
 {
@@ -353,21 +478,21 @@ datum_or_parens
   }
   $$ = node
 }
-| identifier '(' ')'
+| identifier '(' ')' optional_filter maybe_window
 {
-  op := expr.CallByName($1)
-  if op.Private() {
-    yylex.Error(__yyfmt__.Sprintf("cannot use reserved builtin %q", $1))
+  node, err := funcall($1, false, nil, nil, $4, $5)
+  if err != nil {
+    yylex.Error(err.Error())
   }
-  $$ = op
+  $$ = node
 }
-| identifier '(' value_list ')'
+| identifier '(' maybe_distinct value_list order_expr ')' optional_filter maybe_window
 {
-  op := expr.CallByName($1, $3...)
-  if op.Private() {
-    yylex.Error(__yyfmt__.Sprintf("cannot use reserved builtin %q", $1))
+  node, err := funcall($1, $3, $4, $5, $7, $8)
+  if err != nil {
+    yylex.Error(err.Error())
   }
-  $$ = op
+  $$ = node
 }
 | expr IN '(' select_stmt ')'
 {
@@ -413,6 +538,38 @@ datum_or_parens
 {
   $$ = expr.Sub($1, $3)
 }
+| expr '+' INTERVAL STRING
+{
+  iv, err := parseIntervalLiteral($4, true)
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  $$ = addInterval($1, iv)
+}
+| expr '-' INTERVAL STRING
+{
+  iv, err := parseIntervalLiteral($4, true)
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  $$ = addInterval($1, iv.neg())
+}
+| TIMESTAMP STRING
+{
+  ts, err := timestampLiteral($2)
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  $$ = ts
+}
+| DATE STRING
+{
+  yylex.Error("DATE literals are not supported; use TIMESTAMP")
+}
+| TIME STRING
+{
+  yylex.Error("TIME literals are not supported; use TIMESTAMP")
+}
 | expr '*' expr
 {
   $$ = expr.Mul($1, $3)
@@ -467,32 +624,64 @@ datum_or_parens
 }
 | expr EQ expr
 {
-  $$ = expr.Compare(expr.Equals, $1, $3)
+  $$ = yylex.(*scanner).at(expr.Compare(expr.Equals, $1, $3), $<pos>1, $<end>1)
+}
+| expr EQ quantified_subquery
+{
+  $$ = yylex.(*scanner).at(quantified(expr.Equals, $1, $3), $<pos>1, $<end>1)
 }
 | expr NE expr
 {
-  $$ = expr.Compare(expr.NotEquals, $1, $3)
+  $$ = yylex.(*scanner).at(expr.Compare(expr.NotEquals, $1, $3), $<pos>1, $<end>1)
+}
+| expr NE quantified_subquery
+{
+  $$ = yylex.(*scanner).at(quantified(expr.NotEquals, $1, $3), $<pos>1, $<end>1)
 }
 | expr LT expr
 {
-  $$ = expr.Compare(expr.Less, $1, $3)
+  $$ = yylex.(*scanner).at(expr.Compare(expr.Less, $1, $3), $<pos>1, $<end>1)
+}
+| expr LT quantified_subquery
+{
+  $$ = yylex.(*scanner).at(quantified(expr.Less, $1, $3), $<pos>1, $<end>1)
 }
 | expr LE expr
 {
-  $$ = expr.Compare(expr.LessEquals, $1, $3)
+  $$ = yylex.(*scanner).at(expr.Compare(expr.LessEquals, $1, $3), $<pos>1, $<end>1)
+}
+| expr LE quantified_subquery
+{
+  $$ = yylex.(*scanner).at(quantified(expr.LessEquals, $1, $3), $<pos>1, $<end>1)
 }
 | expr GT expr
 {
-  $$ = expr.Compare(expr.Greater, $1, $3)
+  $$ = yylex.(*scanner).at(expr.Compare(expr.Greater, $1, $3), $<pos>1, $<end>1)
+}
+| expr GT quantified_subquery
+{
+  $$ = yylex.(*scanner).at(quantified(expr.Greater, $1, $3), $<pos>1, $<end>1)
 }
 | expr GE expr
 {
-  $$ = expr.Compare(expr.GreaterEquals, $1, $3)
+  $$ = yylex.(*scanner).at(expr.Compare(expr.GreaterEquals, $1, $3), $<pos>1, $<end>1)
+}
+| expr GE quantified_subquery
+{
+  $$ = yylex.(*scanner).at(quantified(expr.GreaterEquals, $1, $3), $<pos>1, $<end>1)
 }
 | expr BETWEEN datum_or_parens AND datum_or_parens
 {
   $$ = expr.Between($1, $3, $5)
 }
+| expr BETWEEN SYMMETRIC datum_or_parens AND datum_or_parens
+{
+  $$ = expr.BetweenSymmetric($1, $4, $6)
+}
+| '(' expr ',' expr ')' OVERLAPS '(' expr ',' expr ')'
+{
+  $$ = expr.Call(expr.Overlaps, $2, $4, $8, $10)
+}
 | expr NOT LIKE STRING
 {
   $$ = &expr.Not{Expr: &expr.StringMatch{Op: expr.Like, Expr: $1, Pattern: $4}}
@@ -569,6 +758,38 @@ datum_or_parens
 {
   $$ = &expr.IsKey{Key: expr.IsNotFalse, Expr: $1}
 }
+| expr IS ID %prec IS
+{
+  nod, err := buildIsJSON($1, $3, "")
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  $$ = nod
+}
+| expr IS ID json_type
+{
+  nod, err := buildIsJSON($1, $3, $4)
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  $$ = nod
+}
+| expr IS NOT ID %prec IS
+{
+  nod, err := buildIsJSON($1, $4, "")
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  $$ = &expr.Not{Expr: nod}
+}
+| expr IS NOT ID json_type
+{
+  nod, err := buildIsJSON($1, $4, $5)
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  $$ = &expr.Not{Expr: nod}
+}
 
 // match (binding)+
 binding_list:
@@ -633,9 +854,71 @@ lhs_from_expr { $$ = $1 } |
 
 lhs_from_expr:
 FROM value_binding { $$ = &expr.Table{Binding: $2} } |
-lhs_from_expr cross_symbol value_binding { $$ = &expr.Join{Kind: expr.CrossJoin, Left: $1, Right: $3} } |
+lhs_from_expr cross_symbol value_binding
+{
+  j, err := crossJoin($1, $3)
+  if err != nil {
+    yylex.Error(err.Error())
+    $$ = $1
+  } else {
+    $$ = j
+  }
+} |
+lhs_from_expr cross_symbol identifier '(' select_stmt ')' maybe_alias
+{
+  j, err := lateralJoin($1, $3, $5, $7)
+  if err != nil {
+    yylex.Error(err.Error())
+    $$ = $1
+  } else {
+    $$ = j
+  }
+} |
 lhs_from_expr join_kind value_binding ON expr
-{ $$ = &expr.Join{Kind: $2, Left: $1, Right: $3, On: $5 } }
+{ $$ = &expr.Join{Kind: $2, Left: $1, Right: $3, On: $5 } } |
+lhs_from_expr join_kind value_binding USING '(' using_list ')'
+{
+  j, err := expr.JoinUsing($2, $1, $3, $6)
+  if err != nil {
+    yylex.Error(err.Error())
+    $$ = $1
+  } else {
+    $$ = j
+  }
+} |
+lhs_from_expr NATURAL join_kind value_binding
+{
+  j, err := expr.NaturalJoin($3, $1, $4)
+  if err != nil {
+    yylex.Error(err.Error())
+    $$ = $1
+  } else {
+    $$ = j
+  }
+}
+
+quantified_subquery:
+ALL '(' select_stmt ')'
+{
+  $$ = quantifiedSubquery{fn: expr.AllSubquery, sel: $3}
+}
+| identifier '(' select_stmt ')'
+{
+  q, err := anySubquery($1, $3)
+  if err != nil {
+    yylex.Error(err.Error())
+  }
+  $$ = q
+}
+
+maybe_alias:
+AS as_identifier { $$ = $2 } |
+implicit_alias { $$ = $1 } |
+{ $$ = "" }
+
+using_list:
+identifier { $$ = []string{$1} } |
+using_list ',' identifier { $$ = append($1, $3) }
 
 literal_int:
 NUMBER { var idxerr error; $$, idxerr = toint($1); if idxerr != nil { yylex.Error(idxerr.Error()) } }
@@ -646,8 +929,45 @@ NUMBER { var idxerr error; $$, idxerr = toint($1); if idxerr != nil { yylex.Erro
 // conflict between compound expressions;
 // they are automatically left-associative
 
+json_type:
+OBJECT { $$ = $1 } |
+ARRAY { $$ = $1 }
+
+// identifier includes the keywords
+// that are not reserved words
 identifier:
-ID { $$ = $1 }
+implicit_alias { $$ = $1 } |
+FETCH { $$ = $1 }
+
+// an identifier that can follow an expression
+// or a table without AS; FETCH is not one of them,
+// since it begins the FETCH clause there
+implicit_alias:
+ID { $$ = $1 } |
+OBJECT { $$ = $1 } |
+ARRAY { $$ = $1 } |
+PREPARE { $$ = $1 } |
+EXECUTE { $$ = $1 } |
+DEALLOCATE { $$ = $1 } |
+DATE { $$ = $1 } |
+TIME { $$ = $1 } |
+TIMESTAMP { $$ = $1 } |
+INTERVAL { $$ = $1 } |
+NEXT { $$ = $1 } |
+ROWS { $$ = $1 } |
+ONLY { $$ = $1 } |
+TIES { $$ = $1 } |
+DELETE { $$ = $1 } |
+CREATE { $$ = $1 } |
+TABLE { $$ = $1 }
+
+// an identifier following AS; a query
+// that follows AS begins with SELECT or WITH,
+// which are lexed as keywords there
+as_identifier:
+identifier { $$ = $1 } |
+SELECT { $$ = $1 } |
+WITH { $$ = $1 }
 
 case_optional_else:
 { $$ = nil } |
@@ -673,9 +993,38 @@ having_expr:
 { $$ = nil } |
 HAVING expr { $$ = $2 }
 
+qualify_expr:
+{ $$ = nil } |
+QUALIFY expr { $$ = $2 }
+
 group_expr:
 { $$ = nil } |
-GROUP BY binding_list { $$ = $3 }
+GROUP BY group_list { $$ = $3 }
+
+group_list:
+group_binding { $$ = []expr.Binding{$1} } |
+group_list ',' group_binding { $$ = append($1, $3) }
+
+// a GROUP BY binding may specify
+// a collation for its value
+group_binding:
+value_binding { $$ = $1 } |
+expr COLLATE ID
+{
+  nod, ok := buildCollate($1, $3)
+  if !ok {
+    yylex.Error(__yyfmt__.Sprintf("unknown collation %q", $3))
+  }
+  $$ = expr.Bind(nod, "")
+} |
+expr COLLATE ID AS as_identifier
+{
+  nod, ok := buildCollate($1, $3)
+  if !ok {
+    yylex.Error(__yyfmt__.Sprintf("unknown collation %q", $3))
+  }
+  $$ = expr.Bind(nod, $5)
+}
 
 // match optional NULLS FIRST / NULLS LAST
 nullslast:
@@ -707,12 +1056,33 @@ LIMIT literal_int { n := expr.Integer($2); $$ = &n }
 
 offset_expr:
 { $$ = nil } |
-OFFSET literal_int { n := expr.Integer($2); $$ = &n }
+OFFSET literal_int maybe_rows { n := expr.Integer($2); $$ = &n }
+
+maybe_rows:
+ROWS {} | {}
+
+// FETCH {FIRST|NEXT} [n] {ROW|ROWS} ONLY
+// is the standard spelling of LIMIT n
+fetch_expr:
+{ $$ = nil } |
+FETCH first_or_next fetch_count ROWS ONLY { $$ = $3 } |
+FETCH first_or_next fetch_count ROWS WITH TIES
+{
+  yylex.Error("FETCH ... WITH TIES is not supported")
+  $$ = nil
+}
+
+first_or_next:
+FIRST {} | NEXT {}
+
+fetch_count:
+literal_int { n := expr.Integer($1); $$ = &n } |
+{ n := expr.Integer(1); $$ = &n }
 
 unpivot:
-UNPIVOT unpivot_source AS identifier AT identifier { /*Cloning, as the buffer gets overwritten*/ as := $4; at := $6; $$ = &expr.Unpivot{ TupleRef: $2, As: &as, At: &at } } |
-UNPIVOT unpivot_source AT identifier AS identifier { /*Cloning, as the buffer gets overwritten*/ as := $6; at := $4; $$ = &expr.Unpivot{ TupleRef: $2, As: &as, At: &at } } |
-UNPIVOT unpivot_source AS identifier { /*Cloning, as the buffer gets overwritten*/ as := $4; $$ = &expr.Unpivot{ TupleRef: $2, As: &as, At: nil } } |
+UNPIVOT unpivot_source AS as_identifier AT identifier { /*Cloning, as the buffer gets overwritten*/ as := $4; at := $6; $$ = &expr.Unpivot{ TupleRef: $2, As: &as, At: &at } } |
+UNPIVOT unpivot_source AT identifier AS as_identifier { /*Cloning, as the buffer gets overwritten*/ as := $6; at := $4; $$ = &expr.Unpivot{ TupleRef: $2, As: &as, At: &at } } |
+UNPIVOT unpivot_source AS as_identifier { /*Cloning, as the buffer gets overwritten*/ as := $4; $$ = &expr.Unpivot{ TupleRef: $2, As: &as, At: nil } } |
 UNPIVOT unpivot_source AT identifier { /*Cloning, as the buffer gets overwritten*/ at := $4; $$ = &expr.Unpivot{ TupleRef: $2, As: nil, At: &at } }
 
 unpivot_source:
@@ -46,100 +46,131 @@ type yySymType struct {
 	values   []expr.Node
 	orders   []expr.Order
 	unions   []unionItem
+	strs     []string
+	types    []expr.TypeSet
+	quant    quantifiedSubquery
+	pos      int
+	end      int
 }
 
 const ERROR = 57346
 const EOF = 57347
 const UNION = 57348
-const SELECT = 57349
-const FROM = 57350
-const WHERE = 57351
-const GROUP = 57352
-const ORDER = 57353
-const BY = 57354
-const HAVING = 57355
-const LIMIT = 57356
-const OFFSET = 57357
-const WITH = 57358
-const INTO = 57359
-const EXPLAIN = 57360
-const DISTINCT = 57361
-const ALL = 57362
-const AS = 57363
-const EXISTS = 57364
-const NULLS = 57365
-const FIRST = 57366
-const LAST = 57367
-const ASC = 57368
-const DESC = 57369
-const UNPIVOT = 57370
-const AT = 57371
-const PARTITION = 57372
-const VALUE = 57373
-const LEADING = 57374
-const TRAILING = 57375
-const BOTH = 57376
-const COALESCE = 57377
-const NULLIF = 57378
-const EXTRACT = 57379
-const DATE_TRUNC = 57380
-const CAST = 57381
-const UTCNOW = 57382
-const DATE_ADD = 57383
-const DATE_BIN = 57384
-const DATE_DIFF = 57385
-const EARLIEST = 57386
-const LATEST = 57387
-const JOIN = 57388
-const LEFT = 57389
-const RIGHT = 57390
-const CROSS = 57391
-const INNER = 57392
-const OUTER = 57393
-const FULL = 57394
-const ON = 57395
-const APPROX_COUNT_DISTINCT = 57396
-const AGGREGATE = 57397
-const ID = 57398
-const NULL = 57399
-const TRUE = 57400
-const FALSE = 57401
-const MISSING = 57402
-const OR = 57403
-const AND = 57404
-const NOT = 57405
-const BETWEEN = 57406
-const CASE = 57407
-const WHEN = 57408
-const THEN = 57409
-const ELSE = 57410
-const END = 57411
-const TO = 57412
-const TRIM = 57413
-const EQ = 57414
-const NE = 57415
-const LT = 57416
-const LE = 57417
-const GT = 57418
-const GE = 57419
-const SIMILAR = 57420
-const REGEXP_MATCH_CI = 57421
-const ILIKE = 57422
-const LIKE = 57423
-const IN = 57424
-const IS = 57425
-const OVER = 57426
-const FILTER = 57427
-const ESCAPE = 57428
-const SHIFT_LEFT_LOGICAL = 57429
-const SHIFT_RIGHT_ARITHMETIC = 57430
-const SHIFT_RIGHT_LOGICAL = 57431
-const CONCAT = 57432
-const APPEND = 57433
-const NEGATION_PRECEDENCE = 57434
-const NUMBER = 57435
-const ION = 57436
-const STRING = 57437
+const EXCEPT = 57349
+const INTERSECT = 57350
+const SELECT = 57351
+const WITH = 57352
+const FROM = 57353
+const WHERE = 57354
+const GROUP = 57355
+const ORDER = 57356
+const BY = 57357
+const HAVING = 57358
+const QUALIFY = 57359
+const LIMIT = 57360
+const OFFSET = 57361
+const INTO = 57362
+const EXPLAIN = 57363
+const DISTINCT = 57364
+const ALL = 57365
+const AS = 57366
+const EXISTS = 57367
+const NULLS = 57368
+const FIRST = 57369
+const LAST = 57370
+const ASC = 57371
+const DESC = 57372
+const UNPIVOT = 57373
+const AT = 57374
+const PARTITION = 57375
+const COLLATE = 57376
+const DESCRIBE = 57377
+const USING = 57378
+const PREPARE = 57379
+const EXECUTE = 57380
+const DEALLOCATE = 57381
+const DELETE = 57382
+const CREATE = 57383
+const TABLE = 57384
+const FETCH = 57385
+const NEXT = 57386
+const ROWS = 57387
+const ONLY = 57388
+const TIES = 57389
+const VALUE = 57390
+const LEADING = 57391
+const TRAILING = 57392
+const BOTH = 57393
+const COALESCE = 57394
+const NULLIF = 57395
+const EXTRACT = 57396
+const DATE_TRUNC = 57397
+const CAST = 57398
+const UTCNOW = 57399
+const DATE_ADD = 57400
+const DATE_BIN = 57401
+const DATE_DIFF = 57402
+const EARLIEST = 57403
+const LATEST = 57404
+const JOIN = 57405
+const LEFT = 57406
+const RIGHT = 57407
+const CROSS = 57408
+const INNER = 57409
+const OUTER = 57410
+const FULL = 57411
+const NATURAL = 57412
+const ON = 57413
+const APPROX_COUNT_DISTINCT = 57414
+const AGGREGATE = 57415
+const ID = 57416
+const NULL = 57417
+const TRUE = 57418
+const FALSE = 57419
+const MISSING = 57420
+const OR = 57421
+const AND = 57422
+const NOT = 57423
+const BETWEEN = 57424
+const CASE = 57425
+const WHEN = 57426
+const THEN = 57427
+const ELSE = 57428
+const END = 57429
+const TO = 57430
+const TRIM = 57431
+const SYMMETRIC = 57432
+const OVERLAPS = 57433
+const EQ = 57434
+const NE = 57435
+const LT = 57436
+const LE = 57437
+const GT = 57438
+const GE = 57439
+const SIMILAR = 57440
+const REGEXP_MATCH_CI = 57441
+const ILIKE = 57442
+const LIKE = 57443
+const IN = 57444
+const IS = 57445
+const OVER = 57446
+const FILTER = 57447
+const ESCAPE = 57448
+const SHIFT_LEFT_LOGICAL = 57449
+const SHIFT_RIGHT_ARITHMETIC = 57450
+const SHIFT_RIGHT_LOGICAL = 57451
+const CONCAT = 57452
+const APPEND = 57453
+const NEGATION_PRECEDENCE = 57454
+const OBJECT = 57455
+const ARRAY = 57456
+const NUMBER = 57457
+const ION = 57458
+const DATE = 57459
+const TIME = 57460
+const TIMESTAMP = 57461
+const INTERVAL = 57462
+const STRING = 57463
 
 var yyToknames = [...]string{
 	"$end",
@@ -148,16 +179,19 @@ var yyToknames = [...]string{
 	"ERROR",
 	"EOF",
 	"UNION",
+	"EXCEPT",
+	"INTERSECT",
 	"SELECT",
+	"WITH",
 	"FROM",
 	"WHERE",
 	"GROUP",
 	"ORDER",
 	"BY",
 	"HAVING",
+	"QUALIFY",
 	"LIMIT",
 	"OFFSET",
-	"WITH",
 	"INTO",
 	"EXPLAIN",
 	"DISTINCT",
@@ -172,6 +206,20 @@ var yyToknames = [...]string{
 	"UNPIVOT",
 	"AT",
 	"PARTITION",
+	"COLLATE",
+	"DESCRIBE",
+	"USING",
+	"PREPARE",
+	"EXECUTE",
+	"DEALLOCATE",
+	"DELETE",
+	"CREATE",
+	"TABLE",
+	"FETCH",
+	"NEXT",
+	"ROWS",
+	"ONLY",
+	"TIES",
 	"VALUE",
 	"LEADING",
 	"TRAILING",
@@ -194,6 +242,7 @@ var yyToknames = [...]string{
 	"INNER",
 	"OUTER",
 	"FULL",
+	"NATURAL",
 	"ON",
 	"APPROX_COUNT_DISTINCT",
 	"AGGREGATE",
@@ -205,6 +254,7 @@ var yyToknames = [...]string{
 	"']'",
 	"'{'",
 	"'}'",
+	"'?'",
 	"NULL",
 	"TRUE",
 	"FALSE",
@@ -222,6 +272,8 @@ var yyToknames = [...]string{
 	"END",
 	"TO",
 	"TRIM",
+	"SYMMETRIC",
+	"OVERLAPS",
 	"EQ",
 	"NE",
 	"LT",
@@ -252,8 +304,14 @@ var yyToknames = [...]string{
 	"APPEND",
 	"NEGATION_PRECEDENCE",
 	"'.'",
+	"OBJECT",
+	"ARRAY",
 	"NUMBER",
 	"ION",
+	"DATE",
+	"TIME",
+	"TIMESTAMP",
+	"INTERVAL",
 	"STRING",
 	"':'",
 }
@@ -273,420 +331,616 @@ var yyExca = [...]int8{
 
 const yyPrivate = 57344
 
-const yyLast = 2001
+const yyLast = 3254
 
 var yyAct = [...]int16{
-	25, 383, 205, 379, 184, 352, 368, 324, 245, 300,
-	280, 28, 218, 125, 134, 211, 207, 331, 206, 330,
-	23, 24, 76, 77, 78, 79, 80, 81, 82, 299,
-	295, 101, 71, 72, 73, 75, 74, 76, 77, 78,
-	79, 80, 81, 82, 114, 115, 116, 118, 298, 123,
-	294, 126, 240, 239, 237, 236, 234, 189, 128, 72,
-	73, 75, 74, 76, 77, 78, 79, 80, 81, 82,
-	159, 142, 143, 144, 145, 146, 147, 148, 149, 150,
-	151, 152, 153, 154, 133, 158, 20, 137, 41, 160,
-	161, 162, 163, 164, 165, 11, 13, 172, 173, 18,
-	156, 155, 207, 185, 186, 187, 166, 120, 62, 81,
-	82, 297, 194, 185, 68, 233, 232, 200, 73, 75,
-	74, 76, 77, 78, 79, 80, 81, 82, 246, 301,
-	185, 238, 157, 170, 214, 122, 78, 79, 80, 81,
-	82, 305, 185, 251, 183, 252, 231, 131, 217, 169,
-	171, 168, 167, 235, 47, 139, 140, 119, 229, 85,
-	87, 83, 84, 69, 98, 273, 272, 181, 70, 71,
-	72, 73, 75, 74, 76, 77, 78, 79, 80, 81,
-	82, 248, 213, 139, 253, 212, 210, 174, 177, 178,
-	176, 209, 14, 12, 48, 175, 267, 57, 385, 56,
-	201, 52, 50, 51, 53, 304, 303, 179, 204, 255,
-	293, 343, 275, 61, 276, 255, 277, 215, 255, 268,
-	282, 138, 339, 12, 274, 292, 136, 57, 230, 56,
-	279, 52, 50, 51, 53, 241, 243, 244, 242, 255,
-	254, 278, 283, 284, 269, 261, 262, 296, 49, 55,
-	54, 216, 306, 307, 208, 132, 309, 310, 193, 312,
-	313, 314, 66, 316, 317, 255, 318, 319, 390, 224,
-	226, 227, 223, 225, 65, 228, 65, 365, 49, 55,
-	54, 222, 260, 259, 258, 10, 332, 302, 141, 130,
-	323, 270, 271, 129, 113, 112, 111, 110, 109, 108,
-	107, 106, 12, 65, 105, 335, 104, 103, 102, 337,
-	99, 60, 334, 315, 311, 192, 191, 190, 188, 327,
-	58, 348, 289, 329, 139, 287, 354, 290, 356, 328,
-	288, 351, 291, 286, 359, 285, 358, 361, 202, 321,
-	322, 362, 363, 364, 360, 396, 203, 355, 397, 398,
-	59, 22, 16, 19, 7, 17, 3, 367, 6, 325,
-	380, 369, 372, 371, 21, 370, 377, 326, 353, 281,
-	333, 384, 381, 185, 378, 63, 219, 386, 263, 136,
-	22, 9, 388, 389, 42, 15, 220, 2, 195, 182,
-	221, 384, 394, 382, 196, 197, 198, 31, 32, 38,
-	37, 33, 39, 34, 35, 36, 247, 124, 127, 357,
-	349, 350, 135, 8, 180, 395, 391, 29, 12, 48,
-	5, 4, 57, 117, 56, 27, 52, 50, 51, 53,
-	121, 250, 100, 45, 44, 64, 30, 1, 0, 0,
-	0, 0, 40, 42, 0, 0, 0, 0, 0, 46,
-	0, 0, 0, 0, 0, 0, 31, 32, 38, 37,
-	33, 39, 34, 35, 36, 43, 266, 0, 0, 0,
-	0, 0, 0, 49, 55, 54, 29, 12, 48, 0,
-	0, 57, 0, 56, 0, 52, 50, 51, 53, 0,
-	0, 0, 45, 44, 0, 30, 0, 0, 0, 0,
-	0, 40, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 265, 264, 0, 0,
-	0, 0, 0, 0, 43, 26, 97, 96, 0, 86,
-	95, 94, 49, 55, 54, 0, 0, 0, 0, 88,
-	89, 90, 91, 92, 93, 85, 87, 83, 84, 69,
-	98, 0, 0, 0, 70, 71, 72, 73, 75, 74,
-	76, 77, 78, 79, 80, 81, 82, 42, 0, 0,
+	141, 235, 329, 551, 13, 545, 261, 536, 37, 518,
+	363, 127, 245, 514, 497, 456, 360, 108, 433, 466,
+	213, 385, 290, 430, 36, 72, 287, 10, 241, 134,
+	260, 409, 14, 124, 126, 129, 130, 149, 408, 358,
+	318, 92, 94, 90, 91, 76, 105, 351, 350, 137,
+	77, 78, 79, 80, 82, 81, 83, 84, 85, 86,
+	87, 88, 89, 238, 135, 281, 280, 278, 277, 237,
+	236, 271, 268, 389, 140, 267, 218, 177, 158, 159,
+	160, 161, 162, 163, 164, 166, 168, 169, 170, 171,
+	172, 176, 174, 145, 173, 123, 178, 182, 184, 186,
+	188, 190, 319, 122, 200, 201, 121, 238, 288, 289,
+	214, 215, 216, 85, 86, 87, 88, 89, 132, 223,
+	252, 357, 27, 88, 89, 356, 153, 63, 238, 291,
+	69, 70, 230, 270, 192, 75, 83, 84, 85, 86,
+	87, 88, 89, 269, 361, 251, 229, 429, 214, 279,
+	249, 175, 366, 296, 212, 297, 355, 274, 214, 179,
+	240, 443, 254, 256, 258, 239, 390, 243, 253, 265,
+	242, 131, 321, 530, 132, 327, 543, 532, 266, 78,
+	79, 80, 82, 81, 83, 84, 85, 86, 87, 88,
+	89, 146, 300, 449, 148, 276, 198, 155, 79, 80,
+	82, 81, 83, 84, 85, 86, 87, 88, 89, 507,
+	293, 300, 349, 298, 197, 199, 196, 195, 180, 180,
+	180, 180, 180, 180, 146, 312, 275, 131, 327, 326,
+	214, 210, 128, 316, 300, 299, 210, 450, 320, 428,
+	422, 418, 323, 412, 324, 210, 406, 405, 328, 404,
+	314, 313, 365, 209, 234, 233, 183, 185, 187, 189,
+	191, 365, 246, 348, 317, 315, 344, 337, 231, 339,
+	322, 341, 325, 222, 387, 300, 347, 487, 207, 462,
+	335, 306, 307, 305, 352, 353, 228, 202, 205, 206,
+	204, 208, 336, 304, 338, 203, 340, 367, 368, 523,
+	228, 370, 371, 303, 373, 374, 375, 354, 377, 378,
+	74, 379, 380, 359, 300, 500, 146, 286, 505, 465,
+	410, 388, 343, 364, 362, 383, 282, 284, 285, 283,
+	382, 437, 439, 440, 436, 438, 393, 441, 434, 343,
+	346, 345, 273, 539, 435, 272, 214, 395, 264, 157,
+	400, 391, 139, 504, 120, 119, 118, 117, 403, 116,
+	115, 114, 113, 112, 399, 413, 402, 111, 110, 401,
+	416, 330, 109, 106, 376, 396, 372, 397, 221, 398,
+	220, 219, 427, 217, 502, 437, 439, 440, 407, 438,
+	262, 441, 479, 474, 442, 473, 566, 146, 80, 82,
+	81, 83, 84, 85, 86, 87, 88, 89, 477, 475,
+	565, 563, 453, 478, 476, 457, 458, 560, 552, 501,
+	459, 460, 461, 448, 557, 68, 147, 452, 333, 444,
+	445, 511, 468, 447, 128, 454, 334, 525, 526, 469,
+	471, 558, 548, 446, 263, 128, 564, 128, 259, 481,
+	392, 464, 244, 472, 156, 71, 330, 394, 546, 257,
+	515, 255, 483, 494, 154, 537, 496, 146, 482, 498,
+	499, 486, 484, 9, 414, 365, 467, 431, 146, 503,
+	411, 495, 247, 387, 308, 214, 67, 3, 457, 4,
+	7, 8, 5, 6, 150, 152, 151, 506, 128, 516,
+	520, 12, 522, 509, 73, 508, 519, 556, 559, 432,
+	138, 2, 521, 224, 527, 211, 529, 561, 455, 292,
+	524, 133, 136, 451, 386, 528, 517, 540, 510, 249,
+	488, 520, 11, 250, 143, 534, 533, 519, 125, 547,
+	538, 542, 544, 295, 549, 107, 342, 1, 553, 0,
+	550, 554, 0, 0, 555, 470, 0, 0, 562, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 480, 330,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 248, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 531, 0, 0, 52, 53, 54,
+	60, 61, 62, 0, 56, 57, 58, 59, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 49, 0, 246, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 104, 103, 0,
+	93, 102, 101, 0, 330, 0, 0, 0, 0, 0,
+	0, 330, 95, 96, 97, 98, 99, 100, 92, 94,
+	90, 91, 76, 105, 0, 0, 0, 77, 78, 79,
+	80, 82, 81, 83, 84, 85, 86, 87, 88, 89,
+	28, 0, 50, 51, 0, 0, 64, 65, 66, 55,
+	0, 0, 52, 53, 54, 60, 61, 62, 38, 56,
+	57, 58, 59, 0, 225, 226, 227, 17, 18, 24,
+	23, 19, 25, 20, 21, 22, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 15, 49,
+	33, 0, 0, 48, 0, 47, 0, 46, 42, 40,
+	41, 43, 0, 0, 0, 35, 34, 0, 16, 0,
+	0, 0, 0, 0, 26, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 32,
+	0, 0, 0, 0, 0, 0, 0, 50, 51, 39,
+	45, 30, 31, 29, 55, 44, 28, 0, 0, 0,
+	0, 0, 144, 0, 0, 0, 0, 0, 52, 53,
+	54, 60, 61, 62, 38, 56, 57, 58, 59, 0,
+	0, 0, 0, 17, 18, 24, 23, 19, 25, 20,
+	21, 22, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 15, 49, 33, 0, 0, 48,
+	0, 47, 0, 46, 42, 40, 41, 43, 0, 0,
+	0, 35, 34, 248, 16, 0, 0, 0, 0, 0,
+	26, 0, 0, 0, 0, 0, 52, 53, 54, 60,
+	61, 62, 0, 56, 57, 58, 59, 0, 0, 0,
+	0, 0, 0, 0, 0, 32, 142, 0, 0, 0,
+	0, 0, 0, 50, 51, 39, 45, 30, 31, 29,
+	55, 44, 0, 49, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 104, 103, 0, 93,
+	102, 101, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 95, 96, 97, 98, 99, 100, 92, 94, 90,
+	91, 76, 105, 0, 0, 0, 77, 78, 79, 80,
+	82, 81, 83, 84, 85, 86, 87, 88, 89, 28,
+	0, 50, 51, 0, 0, 64, 65, 66, 55, 0,
+	0, 52, 53, 54, 60, 61, 62, 38, 56, 57,
+	58, 59, 0, 0, 0, 0, 17, 18, 24, 23,
+	19, 25, 20, 21, 22, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 15, 49, 33,
+	0, 0, 48, 0, 47, 0, 46, 42, 40, 41,
+	43, 0, 0, 0, 35, 34, 0, 16, 0, 0,
+	0, 0, 0, 26, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 128, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 32, 294,
+	0, 0, 0, 28, 0, 0, 50, 51, 39, 45,
+	30, 31, 29, 55, 44, 52, 53, 54, 60, 61,
+	62, 38, 56, 57, 58, 59, 0, 0, 0, 0,
+	17, 18, 24, 23, 19, 25, 20, 21, 22, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 15, 49, 33, 0, 0, 48, 0, 47, 0,
+	46, 42, 40, 41, 43, 0, 0, 0, 35, 34,
+	0, 16, 0, 0, 0, 0, 0, 26, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	31, 32, 38, 37, 33, 39, 34, 35, 36, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	29, 12, 48, 0, 0, 57, 0, 56, 0, 52,
-	50, 51, 53, 0, 0, 0, 45, 44, 0, 30,
-	0, 0, 0, 0, 0, 40, 0, 0, 0, 0,
-	0, 22, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 42, 0, 43, 249,
-	0, 0, 0, 0, 0, 0, 49, 55, 54, 31,
-	32, 38, 37, 33, 39, 34, 35, 36, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 29,
-	12, 48, 0, 0, 57, 0, 56, 0, 52, 50,
-	51, 53, 0, 0, 0, 45, 44, 0, 30, 0,
-	0, 0, 0, 0, 40, 42, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 31, 32,
-	38, 37, 33, 39, 34, 35, 36, 43, 0, 0,
-	0, 0, 0, 0, 0, 49, 55, 54, 29, 12,
-	48, 0, 199, 57, 0, 56, 0, 52, 50, 51,
-	53, 0, 0, 0, 45, 44, 0, 30, 0, 0,
-	0, 0, 0, 40, 42, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 31, 32, 38,
-	37, 33, 39, 34, 35, 36, 43, 0, 0, 0,
-	0, 0, 0, 0, 49, 55, 54, 29, 12, 48,
-	0, 0, 57, 0, 56, 0, 52, 50, 51, 53,
-	0, 0, 0, 45, 44, 0, 30, 392, 393, 0,
-	0, 0, 40, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 32, 0, 0, 181, 0, 28, 0, 0,
+	50, 51, 39, 45, 30, 31, 29, 55, 44, 52,
+	53, 54, 60, 61, 62, 38, 56, 57, 58, 59,
+	0, 0, 0, 0, 17, 18, 24, 23, 19, 25,
+	20, 21, 22, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 15, 49, 33, 0, 0,
+	48, 0, 47, 0, 46, 42, 40, 41, 43, 0,
+	0, 0, 35, 34, 0, 16, 0, 0, 0, 0,
+	0, 26, 0, 0, 0, 0, 0, 541, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 43, 0, 0, 0, 0,
-	0, 0, 0, 49, 55, 54, 0, 0, 0, 97,
-	96, 0, 86, 95, 94, 67, 0, 0, 0, 0,
-	0, 0, 88, 89, 90, 91, 92, 93, 85, 87,
-	83, 84, 69, 98, 0, 0, 0, 70, 71, 72,
-	73, 75, 74, 76, 77, 78, 79, 80, 81, 82,
-	12, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 387, 0, 0, 0, 0, 0, 0,
-	0, 0, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 376, 0, 0, 0, 0, 0, 0,
-	0, 0, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 375, 0, 0, 0, 0, 0, 0,
-	0, 0, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 374, 0, 0, 0, 0, 0, 0,
-	0, 0, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 373, 0, 0, 0, 0, 0, 0,
-	0, 0, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 366, 0, 0, 0, 0, 0, 0,
-	0, 0, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 347, 0, 0, 0, 0, 0, 0,
-	0, 0, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 346, 0, 0, 0, 0, 0, 0,
-	0, 0, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 345, 0, 0, 0, 0, 0, 0,
-	0, 0, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 344, 0, 0, 0, 0, 0, 0,
-	0, 0, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 342, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 97, 96, 0, 86, 95, 94, 0,
-	0, 0, 0, 0, 0, 0, 88, 89, 90, 91,
-	92, 93, 85, 87, 83, 84, 69, 98, 0, 0,
-	0, 70, 71, 72, 73, 75, 74, 76, 77, 78,
-	79, 80, 81, 82, 341, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 97, 96, 0, 86, 95, 94,
-	0, 0, 0, 0, 0, 0, 0, 88, 89, 90,
-	91, 92, 93, 85, 87, 83, 84, 69, 98, 0,
-	0, 0, 70, 71, 72, 73, 75, 74, 76, 77,
-	78, 79, 80, 81, 82, 340, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 97, 96, 0, 86, 95,
-	94, 0, 0, 0, 0, 0, 0, 0, 88, 89,
-	90, 91, 92, 93, 85, 87, 83, 84, 69, 98,
-	0, 0, 0, 70, 71, 72, 73, 75, 74, 76,
-	77, 78, 79, 80, 81, 82, 338, 0, 0, 0,
-	0, 0, 0, 0, 0, 97, 96, 0, 86, 95,
-	94, 0, 0, 0, 0, 0, 0, 0, 88, 89,
-	90, 91, 92, 93, 85, 87, 83, 84, 69, 98,
-	320, 0, 0, 70, 71, 72, 73, 75, 74, 76,
-	77, 78, 79, 80, 81, 82, 97, 96, 0, 86,
-	95, 94, 0, 0, 336, 0, 0, 0, 0, 88,
-	89, 90, 91, 92, 93, 85, 87, 83, 84, 69,
-	98, 0, 0, 0, 70, 71, 72, 73, 75, 74,
-	76, 77, 78, 79, 80, 81, 82, 0, 0, 0,
-	97, 96, 0, 86, 95, 94, 0, 0, 0, 0,
-	0, 0, 0, 88, 89, 90, 91, 92, 93, 85,
-	87, 83, 84, 69, 98, 0, 0, 0, 70, 71,
-	72, 73, 75, 74, 76, 77, 78, 79, 80, 81,
-	82, 97, 96, 257, 86, 95, 94, 0, 0, 308,
-	0, 0, 0, 0, 88, 89, 90, 91, 92, 93,
-	85, 87, 83, 84, 69, 98, 0, 0, 0, 70,
-	71, 72, 73, 75, 74, 76, 77, 78, 79, 80,
-	81, 82, 0, 0, 0, 0, 0, 0, 0, 0,
-	97, 96, 0, 86, 95, 94, 0, 0, 0, 0,
-	0, 0, 0, 88, 89, 90, 91, 92, 93, 85,
-	87, 83, 84, 69, 98, 0, 0, 0, 70, 71,
-	72, 73, 75, 74, 76, 77, 78, 79, 80, 81,
-	82, 256, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 97, 96, 0, 86, 95, 94, 0, 0, 0,
-	0, 0, 0, 0, 88, 89, 90, 91, 92, 93,
-	85, 87, 83, 84, 69, 98, 0, 0, 0, 70,
-	71, 72, 73, 75, 74, 76, 77, 78, 79, 80,
-	81, 82, 97, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 96, 0, 86, 95, 94, 0, 0,
-	0, 0, 0, 0, 0, 88, 89, 90, 91, 92,
-	93, 85, 87, 83, 84, 69, 98, 0, 0, 0,
-	70, 71, 72, 73, 75, 74, 76, 77, 78, 79,
-	80, 81, 82, 86, 95, 94, 0, 0, 0, 0,
-	0, 0, 0, 88, 89, 90, 91, 92, 93, 85,
-	87, 83, 84, 69, 98, 0, 0, 0, 70, 71,
-	72, 73, 75, 74, 76, 77, 78, 79, 80, 81,
-	82,
+	52, 53, 54, 60, 61, 62, 32, 56, 57, 58,
+	59, 28, 0, 0, 50, 51, 39, 45, 30, 31,
+	29, 55, 44, 52, 53, 54, 60, 61, 62, 38,
+	56, 57, 58, 59, 0, 0, 0, 49, 17, 18,
+	24, 23, 19, 25, 20, 21, 22, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 15,
+	49, 33, 0, 0, 48, 0, 47, 0, 46, 42,
+	40, 41, 43, 0, 0, 0, 35, 34, 0, 16,
+	0, 0, 0, 0, 0, 26, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 50, 51, 0, 0, 64,
+	65, 66, 55, 0, 0, 0, 0, 0, 0, 0,
+	32, 0, 0, 0, 0, 28, 0, 0, 50, 51,
+	39, 45, 30, 31, 29, 55, 44, 52, 53, 54,
+	60, 61, 62, 38, 56, 57, 58, 59, 0, 0,
+	0, 0, 17, 18, 24, 23, 19, 25, 20, 21,
+	22, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 15, 49, 33, 0, 0, 48, 0,
+	47, 0, 46, 42, 40, 41, 43, 0, 0, 0,
+	35, 34, 0, 16, 0, 0, 0, 0, 0, 26,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 32, 0, 0, 0, 0, 28,
+	0, 0, 50, 51, 39, 45, 30, 31, 29, 167,
+	44, 52, 53, 54, 60, 61, 62, 38, 56, 57,
+	58, 59, 0, 0, 0, 0, 17, 18, 24, 23,
+	19, 25, 20, 21, 22, 52, 53, 54, 60, 61,
+	62, 38, 56, 57, 58, 59, 0, 15, 49, 33,
+	0, 0, 48, 0, 47, 0, 46, 42, 40, 41,
+	43, 0, 0, 0, 35, 34, 0, 16, 0, 0,
+	0, 0, 49, 26, 0, 0, 0, 0, 52, 53,
+	54, 60, 61, 62, 38, 56, 57, 58, 59, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 32, 0,
+	0, 0, 0, 0, 0, 0, 50, 51, 39, 45,
+	30, 31, 29, 165, 44, 49, 194, 0, 0, 48,
+	0, 47, 0, 46, 42, 40, 41, 43, 0, 0,
+	50, 51, 0, 0, 64, 65, 66, 55, 0, 0,
+	0, 193, 0, 0, 0, 52, 53, 54, 60, 61,
+	62, 38, 56, 57, 58, 59, 0, 0, 52, 53,
+	54, 60, 61, 62, 38, 56, 57, 58, 59, 0,
+	0, 0, 0, 50, 51, 39, 45, 64, 65, 66,
+	55, 44, 49, 194, 0, 0, 48, 0, 47, 0,
+	46, 42, 40, 41, 43, 49, 0, 311, 0, 48,
+	0, 47, 0, 46, 42, 40, 41, 43, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	50, 51, 39, 45, 64, 65, 66, 55, 44, 0,
+	0, 0, 0, 50, 51, 39, 45, 64, 65, 66,
+	55, 44, 310, 309, 489, 490, 0, 0, 0, 0,
+	0, 0, 0, 104, 103, 0, 93, 102, 101, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 95, 96,
+	97, 98, 99, 100, 92, 94, 90, 91, 76, 105,
+	0, 0, 0, 77, 78, 79, 80, 82, 81, 83,
+	84, 85, 86, 87, 88, 89, 0, 0, 0, 0,
+	0, 0, 104, 103, 0, 93, 102, 101, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 95, 96, 97,
+	98, 99, 100, 92, 94, 90, 91, 76, 105, 0,
+	0, 0, 77, 78, 79, 80, 82, 81, 83, 84,
+	85, 86, 87, 88, 89, 535, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 104, 103, 0, 93, 102,
+	101, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	95, 96, 97, 98, 99, 100, 92, 94, 90, 91,
+	76, 105, 0, 0, 0, 77, 78, 79, 80, 82,
+	81, 83, 84, 85, 86, 87, 88, 89, 513, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 104,
+	103, 0, 93, 102, 101, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 95, 96, 97, 98, 99, 100,
+	92, 94, 90, 91, 76, 105, 0, 0, 0, 77,
+	78, 79, 80, 82, 81, 83, 84, 85, 86, 87,
+	88, 89, 512, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 104, 103, 0, 93, 102, 101, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 95, 96, 97,
+	98, 99, 100, 92, 94, 90, 91, 76, 105, 0,
+	0, 0, 77, 78, 79, 80, 82, 81, 83, 84,
+	85, 86, 87, 88, 89, 493, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 104, 103, 0, 93, 102,
+	101, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	95, 96, 97, 98, 99, 100, 92, 94, 90, 91,
+	76, 105, 0, 0, 0, 77, 78, 79, 80, 82,
+	81, 83, 84, 85, 86, 87, 88, 89, 492, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 104, 103,
+	0, 93, 102, 101, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 95, 96, 97, 98, 99, 100, 92,
+	94, 90, 91, 76, 105, 0, 0, 0, 77, 78,
+	79, 80, 82, 81, 83, 84, 85, 86, 87, 88,
+	89, 491, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 104, 103, 0, 93, 102, 101, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 95, 96, 97, 98,
+	99, 100, 92, 94, 90, 91, 76, 105, 0, 0,
+	0, 77, 78, 79, 80, 82, 81, 83, 84, 85,
+	86, 87, 88, 89, 485, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 104, 103, 0, 93, 102, 101,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 95,
+	96, 97, 98, 99, 100, 92, 94, 90, 91, 76,
+	105, 0, 0, 0, 77, 78, 79, 80, 82, 81,
+	83, 84, 85, 86, 87, 88, 89, 463, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 104, 103, 0,
+	93, 102, 101, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 95, 96, 97, 98, 99, 100, 92, 94,
+	90, 91, 76, 105, 0, 0, 0, 77, 78, 79,
+	80, 82, 81, 83, 84, 85, 86, 87, 88, 89,
+	426, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	104, 103, 0, 93, 102, 101, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 95, 96, 97, 98, 99,
+	100, 92, 94, 90, 91, 76, 105, 0, 0, 0,
+	77, 78, 79, 80, 82, 81, 83, 84, 85, 86,
+	87, 88, 89, 425, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 104, 103, 0, 93, 102, 101, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 95, 96,
+	97, 98, 99, 100, 92, 94, 90, 91, 76, 105,
+	0, 0, 0, 77, 78, 79, 80, 82, 81, 83,
+	84, 85, 86, 87, 88, 89, 424, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 104, 103, 0, 93,
+	102, 101, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 95, 96, 97, 98, 99, 100, 92, 94, 90,
+	91, 76, 105, 0, 0, 0, 77, 78, 79, 80,
+	82, 81, 83, 84, 85, 86, 87, 88, 89, 423,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 104,
+	103, 0, 93, 102, 101, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 95, 96, 97, 98, 99, 100,
+	92, 94, 90, 91, 76, 105, 0, 0, 0, 77,
+	78, 79, 80, 82, 81, 83, 84, 85, 86, 87,
+	88, 89, 421, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 104, 103, 0, 93, 102, 101, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 95, 96,
+	97, 98, 99, 100, 92, 94, 90, 91, 76, 105,
+	0, 0, 0, 77, 78, 79, 80, 82, 81, 83,
+	84, 85, 86, 87, 88, 89, 420, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 104, 103, 0,
+	93, 102, 101, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 95, 96, 97, 98, 99, 100, 92, 94,
+	90, 91, 76, 105, 0, 0, 0, 77, 78, 79,
+	80, 82, 81, 83, 84, 85, 86, 87, 88, 89,
+	419, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 104, 103, 0, 93, 102, 101, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 95, 96, 97, 98,
+	99, 100, 92, 94, 90, 91, 76, 105, 0, 0,
+	0, 77, 78, 79, 80, 82, 81, 83, 84, 85,
+	86, 87, 88, 89, 417, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 104, 103, 0, 93, 102, 101,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 95,
+	96, 97, 98, 99, 100, 92, 94, 90, 91, 76,
+	105, 0, 0, 0, 77, 78, 79, 80, 82, 81,
+	83, 84, 85, 86, 87, 88, 89, 104, 103, 0,
+	93, 102, 101, 0, 0, 415, 0, 0, 0, 0,
+	0, 0, 95, 96, 97, 98, 99, 100, 92, 94,
+	90, 91, 76, 105, 381, 0, 0, 77, 78, 79,
+	80, 82, 81, 83, 84, 85, 86, 87, 88, 89,
+	384, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	104, 103, 0, 93, 102, 101, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 95, 96, 97, 98, 99,
+	100, 92, 94, 90, 91, 76, 105, 0, 0, 0,
+	77, 78, 79, 80, 82, 81, 83, 84, 85, 86,
+	87, 88, 89, 0, 0, 0, 0, 0, 0, 0,
+	104, 103, 0, 93, 102, 101, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 95, 96, 97, 98, 99,
+	100, 92, 94, 90, 91, 76, 105, 302, 0, 0,
+	77, 78, 79, 80, 82, 81, 83, 84, 85, 86,
+	87, 88, 89, 104, 103, 0, 93, 102, 101, 0,
+	0, 369, 0, 0, 0, 0, 0, 0, 95, 96,
+	97, 98, 99, 100, 92, 94, 90, 91, 76, 105,
+	0, 0, 0, 77, 78, 79, 80, 82, 81, 83,
+	84, 85, 86, 87, 88, 89, 0, 0, 0, 0,
+	104, 103, 0, 93, 102, 101, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 95, 96, 97, 98, 99,
+	100, 92, 94, 90, 91, 76, 105, 0, 0, 0,
+	77, 78, 79, 80, 82, 81, 83, 84, 85, 86,
+	87, 88, 89, 301, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 104, 103, 0, 93, 102, 101,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 95,
+	96, 97, 98, 99, 100, 92, 94, 90, 91, 76,
+	105, 0, 0, 0, 77, 78, 79, 80, 82, 81,
+	83, 84, 85, 86, 87, 88, 89, 232, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 104, 103,
+	0, 93, 102, 101, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 95, 96, 97, 98, 99, 100, 92,
+	94, 90, 91, 76, 105, 331, 332, 0, 77, 78,
+	79, 80, 82, 81, 83, 84, 85, 86, 87, 88,
+	89, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 52, 53, 54, 60, 61, 62, 38,
+	56, 57, 58, 59, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 104, 103, 0, 93,
+	102, 101, 0, 0, 0, 0, 0, 0, 0, 0,
+	49, 95, 96, 97, 98, 99, 100, 92, 94, 90,
+	91, 76, 105, 0, 0, 0, 77, 78, 79, 80,
+	82, 81, 83, 84, 85, 86, 87, 88, 89, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 103, 0, 93, 102, 101, 0, 50, 51,
+	0, 0, 64, 65, 66, 55, 95, 96, 97, 98,
+	99, 100, 92, 94, 90, 91, 76, 105, 0, 0,
+	0, 77, 78, 79, 80, 82, 81, 83, 84, 85,
+	86, 87, 88, 89, 93, 102, 101, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 95, 96, 97, 98,
+	99, 100, 92, 94, 90, 91, 76, 105, 0, 0,
+	0, 77, 78, 79, 80, 82, 81, 83, 84, 85,
+	86, 87, 88, 89,
 }
 
 var yyPact = [...]int16{
-	338, -1000, 342, 333, 374, 227, 246, 246, 379, 336,
-	246, 332, -1000, -1000, -1000, 344, 421, 267, 329, 254,
-	379, 373, 336, 245, -1000, 844, -1000, -1000, -1000, 253,
-	742, 251, 250, 249, 247, 244, 243, 242, 241, 240,
-	239, 238, 237, 742, 742, 742, 742, 47, 624, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -62, 742, 236, 232,
-	373, -1000, 379, 421, 371, 421, 167, 246, -1000, 231,
-	742, 742, 742, 742, 742, 742, 742, 742, 742, 742,
-	742, 742, 742, -12, -13, 53, -28, -43, 742, 742,
-	742, 742, 742, 742, 137, 62, 742, 742, 123, 148,
-	69, 1814, 742, 742, 742, 262, -56, 261, 260, 259,
-	199, 362, 683, 373, -1000, 1892, 1892, 317, 1814, 246,
-	-95, 195, -1000, 1814, 128, -1000, -99, 124, 1814, 742,
-	373, 192, -1000, 218, 367, 223, 421, -1000, 47, -1000,
-	-1000, 624, -65, -39, 19, -80, -80, -80, 32, 32,
-	2, 2, 2, -1000, -1000, 21, 20, -57, -1000, -1000,
-	72, 72, 72, 72, 72, 72, 84, -58, -59, 52,
-	-60, -61, 1892, 1854, -1000, 171, -1000, -1000, -1000, 34,
-	545, -1000, 68, 742, 181, 1814, 1773, 1722, 226, 225,
-	224, 188, 370, -1000, 458, 742, -1000, -1000, -1000, -1000,
-	160, 185, 246, 246, -1000, 105, 104, -1000, -1000, -1000,
-	-62, 742, -1000, 742, 157, 182, -1000, 367, 359, 742,
-	421, 421, -1000, 289, -1000, 287, 279, 276, 286, -1000,
-	166, 151, -63, -83, -1000, 137, 16, -47, -84, -1000,
-	-1000, -1000, -1000, -1000, -1000, 36, 230, 147, 1814, -1000,
-	63, 742, 742, 1673, -1000, 742, 742, 258, 742, 742,
-	742, 257, 742, 742, -1000, 742, 742, 1632, -1000, -1000,
-	310, 319, -1000, -1000, -1000, 1814, 1814, -1000, -1000, 359,
-	346, 355, 1814, -1000, 266, -1000, -1000, -1000, 283, -1000,
-	277, -1000, -1000, -1000, -1000, -1000, -1000, -94, -96, -1000,
-	-1000, 229, 361, 34, 742, -1000, 1588, 1814, 742, 1814,
-	1547, 163, 1497, 1446, 1395, 152, 1344, 1294, 1244, 1194,
-	742, 246, 246, 346, 357, 742, 421, 742, -1000, -1000,
-	-1000, -1000, 306, 742, 36, 1814, 742, 1814, -1000, -1000,
-	742, 742, 742, 219, -1000, -1000, -1000, -1000, 1144, -1000,
-	-1000, 357, 347, 353, 1814, 216, 1814, 357, 350, 1094,
-	-1000, 1814, 1044, 994, 944, 742, -1000, 347, 345, -9,
-	742, 139, 742, -1000, -1000, -1000, -1000, 894, 345, -1000,
-	-9, -1000, 210, -1000, 791, -1000, 207, -1000, -1000, -1000,
-	742, 322, -1000, -1000, -1000, -1000, 324, -1000, -1000,
+	452, -1000, 491, 1276, 1508, 475, 383, 1508, 1508, 431,
+	495, 234, 1508, 3019, -1000, 298, 1276, 297, 293, 292,
+	288, 287, 286, 285, 284, 282, 281, 280, 279, -34,
+	-37, -45, 1276, 1068, 1276, 1276, 40, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -76, 1276, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, 277, -1000, -1000, -1000, 791, 1631, 390,
+	-1000, 1508, 488, 442, 1508, 430, 274, 1276, 1276, 1276,
+	1276, 1276, 1276, 1484, 1380, 1276, 1276, 1276, 1276, 1276,
+	-46, -48, 53, -49, -63, 1172, 1172, 1172, 1172, 1172,
+	1172, 1551, 106, 1276, 1276, 204, 214, 60, 3019, 1276,
+	1276, 1276, 309, -64, 307, 306, 304, 196, 675, 209,
+	489, -1000, -1000, -1000, -1000, 191, 2941, -1000, 442, 3124,
+	3124, 1508, -71, 84, -1000, -113, 91, 3019, 428, 1508,
+	470, 859, -1000, -1000, 1276, 96, -1000, 1276, -1000, -1000,
+	438, 436, 425, 791, 319, 420, 273, 1068, 61, 79,
+	278, 13, 13, 13, -12, -65, -12, -68, -5, -5,
+	-5, -1000, -1000, 27, 17, -69, -1000, -1000, -67, -1000,
+	270, 267, -67, -1000, -67, -1000, -67, -1000, -67, -1000,
+	-67, -1000, 69, 1618, 1068, -72, -73, 51, -74, -75,
+	3124, 3084, -1000, 243, -1000, -1000, -1000, -24, 14, 964,
+	-1000, 59, 1276, 158, 3019, 2887, 2833, 227, 217, 207,
+	206, 473, -1000, 1696, 1276, -1000, -1000, -1000, 14, 1276,
+	188, -1000, 1276, 791, -1000, -39, -27, 93, -1000, -1000,
+	-76, 1276, -1000, 1276, 491, 152, -1000, 1276, 3046, -1000,
+	404, 3019, 491, 199, 488, 489, 488, 489, 488, 489,
+	246, -1000, 266, 265, 489, 186, 135, -1000, -1000, -92,
+	-93, -1000, 223, 489, 1618, 68, 3019, 9, 5, -101,
+	-1000, -1000, -1000, -1000, -1000, -1000, -24, -1000, -1000, -1000,
+	30, 249, 247, 3019, -1000, 55, 1276, 1276, 2786, -1000,
+	1276, 1276, 302, 1276, 1276, 1276, 300, 1276, 1276, -1000,
+	1276, 1276, 2743, 30, 238, -1000, 2693, 263, -1000, -6,
+	87, -1000, -1000, 3019, 3019, 495, -1000, 1508, 3019, -1000,
+	-1000, -1000, -1000, 3046, 1508, 489, -1000, 488, -1000, 488,
+	-1000, 488, 472, 791, 1631, 1276, 489, 172, -1000, -1000,
+	-1000, -1000, 170, 169, -1000, 1618, -102, -109, -1000, -1000,
+	-1000, 245, 468, 166, 1276, 459, -1000, 2640, 3019, 1276,
+	3019, 2597, 164, 2544, 2490, 2436, 163, 2382, 2329, 2276,
+	2223, 1276, -1000, 162, 46, 465, 268, 791, 82, -1000,
+	-1000, 488, -1000, 398, 419, 488, -1000, -1000, -1000, 465,
+	-1000, 40, 116, 160, -1000, -1000, -1000, -1000, -1000, -1000,
+	394, 1276, 14, 3019, 1276, 1276, 3019, -1000, -1000, 1276,
+	1276, 1276, 203, -1000, -1000, -1000, -1000, 2170, 14, 244,
+	463, 1276, 791, 791, 322, -1000, 332, -1000, 330, 346,
+	345, 329, -1000, -1000, -1000, 1508, 3046, -1000, 463, -1000,
+	-1000, 461, 457, 2117, 30, 201, -1000, 1745, 3019, 2064,
+	2011, 1958, 1276, -1000, 30, 1276, 453, 455, 3019, -1000,
+	240, 348, 791, -1000, -1000, -1000, 290, -1000, 255, -1000,
+	-1000, -1000, 453, 132, 1276, -1000, -1000, 1276, 405, -1000,
+	-1000, -1000, -1000, -1000, 1905, -1000, 1852, 443, 1276, 791,
+	223, 1276, 224, -1000, -1000, -1000, 443, -1000, 199, -1000,
+	-1000, 410, -1000, 1276, 461, 1276, 3019, 97, -1000, -1000,
+	570, 100, 3019, 1508, 461, -1000, -1000, 1798, 447, 3019,
+	791, 269, 1253, 99, 447, -1000, 439, -27, -1000, 418,
+	-1000, 3046, -1000, -1000, 439, 375, -27, -1000, 3046, -1000,
+	375, -1000, 397, 372, -1000, -1000, -27, -1000, -1000, -1000,
+	-1000, 366, -1000, 400, -1000, 349, -1000,
 }
 
 var yyPgo = [...]int16{
-	0, 437, 0, 154, 11, 435, 12, 7, 432, 431,
-	430, 8, 425, 423, 421, 420, 416, 415, 414, 88,
-	2, 86, 413, 10, 20, 21, 14, 412, 409, 4,
-	408, 407, 13, 406, 352, 1, 5, 393, 390, 6,
-	3, 389, 9, 388, 387, 192, 386,
+	0, 547, 0, 24, 32, 546, 23, 14, 13, 545,
+	543, 538, 22, 534, 533, 27, 532, 530, 528, 146,
+	122, 2, 8, 26, 527, 1, 11, 25, 19, 526,
+	30, 6, 9, 21, 524, 523, 20, 522, 521, 29,
+	519, 126, 15, 10, 518, 18, 12, 7, 5, 3,
+	517, 515, 16, 513, 511, 37, 510, 159, 509, 508,
+	507,
 }
 
 var yyR1 = [...]int8{
-	0, 1, 22, 21, 44, 44, 44, 5, 5, 14,
-	14, 45, 45, 45, 15, 15, 25, 25, 25, 25,
-	25, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 4, 4, 10, 10, 18, 18,
-	34, 34, 34, 2, 2, 2, 2, 2, 2, 2,
+	0, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	56, 56, 27, 26, 54, 54, 54, 5, 5, 15,
+	15, 55, 55, 55, 55, 55, 55, 55, 16, 16,
+	31, 31, 31, 31, 31, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 4, 4, 11, 11, 19, 19, 41, 41,
+	41, 2, 2, 2, 2, 2, 2, 2, 2, 2,
 	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
 	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
 	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
 	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
 	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
 	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
-	2, 2, 2, 24, 24, 29, 29, 33, 33, 33,
-	30, 30, 30, 31, 31, 31, 32, 28, 28, 42,
-	42, 38, 38, 38, 38, 38, 38, 38, 46, 46,
-	26, 26, 27, 27, 27, 20, 19, 9, 9, 41,
-	41, 8, 8, 11, 11, 6, 6, 7, 7, 23,
-	23, 17, 17, 17, 16, 16, 16, 35, 37, 37,
-	36, 36, 39, 39, 40, 40, 12, 12, 12, 12,
-	13, 43, 43, 43,
+	2, 2, 2, 2, 2, 2, 2, 2, 2, 2,
+	2, 2, 2, 2, 2, 2, 2, 2, 30, 30,
+	36, 36, 40, 40, 40, 37, 37, 37, 38, 38,
+	38, 39, 35, 35, 52, 52, 45, 45, 45, 45,
+	45, 45, 45, 58, 58, 33, 33, 34, 34, 34,
+	34, 34, 34, 57, 57, 24, 24, 24, 46, 46,
+	25, 23, 23, 20, 20, 22, 22, 22, 22, 22,
+	22, 22, 22, 22, 22, 22, 22, 22, 22, 22,
+	22, 22, 21, 21, 21, 10, 10, 51, 51, 9,
+	9, 12, 12, 6, 6, 7, 7, 8, 8, 28,
+	28, 29, 29, 32, 32, 32, 18, 18, 18, 17,
+	17, 17, 42, 44, 44, 43, 43, 47, 47, 48,
+	48, 59, 59, 49, 49, 49, 60, 60, 50, 50,
+	13, 13, 13, 13, 14, 53, 53, 53,
 }
 
 var yyR2 = [...]int8{
-	0, 4, 11, 10, 1, 3, 0, 2, 0, 1,
-	0, 0, 3, 4, 6, 7, 3, 2, 1, 1,
-	1, 1, 1, 1, 1, 1, 1, 1, 1, 3,
-	3, 3, 4, 4, 1, 3, 1, 1, 1, 0,
-	5, 1, 0, 1, 5, 7, 5, 4, 6, 6,
-	8, 8, 8, 9, 6, 6, 3, 4, 6, 6,
-	7, 3, 4, 5, 5, 4, 3, 3, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 3, 2,
-	5, 3, 5, 3, 4, 3, 3, 3, 3, 3,
-	3, 3, 3, 5, 4, 6, 4, 6, 5, 4,
-	4, 2, 2, 3, 3, 3, 4, 3, 4, 3,
-	4, 3, 4, 1, 3, 1, 3, 1, 1, 3,
-	1, 3, 0, 1, 3, 0, 3, 3, 0, 5,
-	0, 1, 2, 2, 3, 2, 3, 2, 1, 2,
-	1, 0, 2, 3, 5, 1, 1, 0, 2, 4,
-	5, 0, 1, 0, 5, 0, 2, 0, 2, 0,
-	3, 0, 2, 2, 0, 1, 1, 3, 3, 1,
-	0, 3, 0, 2, 0, 2, 6, 6, 4, 4,
-	1, 1, 1, 1,
+	0, 4, 2, 7, 5, 3, 7, 2, 4, 2,
+	3, 0, 13, 12, 1, 3, 0, 2, 0, 1,
+	0, 0, 3, 4, 3, 4, 3, 4, 6, 7,
+	3, 2, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 3, 3, 3, 4, 6, 5,
+	5, 4, 1, 3, 1, 1, 1, 0, 5, 1,
+	0, 1, 5, 8, 5, 4, 6, 6, 8, 8,
+	8, 9, 6, 6, 3, 4, 6, 6, 7, 5,
+	8, 5, 5, 4, 3, 3, 3, 3, 3, 3,
+	3, 3, 4, 4, 2, 2, 2, 3, 3, 3,
+	3, 3, 2, 5, 3, 5, 3, 4, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 5, 6, 11, 4, 6, 4, 6, 5,
+	4, 4, 2, 2, 3, 3, 3, 4, 3, 4,
+	3, 4, 3, 4, 3, 4, 4, 5, 1, 3,
+	1, 3, 1, 1, 3, 1, 3, 0, 1, 3,
+	0, 3, 3, 0, 5, 0, 1, 2, 2, 3,
+	2, 3, 2, 1, 2, 1, 0, 2, 3, 7,
+	5, 7, 4, 4, 4, 2, 1, 0, 1, 3,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 0, 2, 4, 5, 0,
+	1, 0, 5, 0, 2, 0, 2, 0, 2, 0,
+	3, 1, 3, 1, 3, 5, 0, 2, 2, 0,
+	1, 1, 3, 3, 1, 0, 3, 0, 2, 0,
+	3, 1, 0, 0, 5, 6, 1, 1, 1, 0,
+	6, 6, 4, 4, 1, 1, 1, 1,
 }
 
 var yyChk = [...]int16{
-	-1000, -1, -44, 18, -14, -15, 16, 21, -22, 7,
-	58, -19, 56, -19, -45, 6, -34, 19, -19, 21,
-	-21, 20, 7, -24, -25, -2, 104, -12, -4, 55,
-	74, 35, 36, 39, 41, 42, 43, 38, 37, 40,
-	80, -19, 22, 103, 72, 71, 28, -3, 57, 111,
-	65, 66, 64, 67, 113, 112, 62, 60, 53, 21,
-	57, -45, -21, -34, -5, 58, 17, 21, -19, 91,
-	96, 97, 98, 99, 101, 100, 102, 103, 104, 105,
-	106, 107, 108, 89, 90, 87, 71, 88, 81, 82,
-	83, 84, 85, 86, 73, 72, 69, 68, 92, 57,
-	-8, -2, 57, 57, 57, 57, 57, 57, 57, 57,
-	57, 57, 57, 57, -2, -2, -2, -13, -2, 110,
-	60, -10, -21, -2, -31, -32, 113, -30, -2, 57,
-	57, -21, -45, -24, -26, -27, 8, -25, -3, -19,
-	-19, 57, -2, -2, -2, -2, -2, -2, -2, -2,
-	-2, -2, -2, -2, -2, 113, 113, 79, 113, 113,
-	-2, -2, -2, -2, -2, -2, -4, 90, 89, 87,
-	71, 88, -2, -2, 64, 72, 67, 65, 66, 59,
-	-18, 19, -41, 75, -29, -2, -2, -2, 56, 113,
-	56, 56, 56, 59, -2, -43, 32, 33, 34, 59,
-	-29, -21, 21, 29, -19, -20, 113, 111, 59, 63,
-	58, 114, 61, 58, -29, -21, 59, -26, -6, 9,
-	-46, -38, 58, 49, 46, 50, 47, 48, 52, -25,
-	-21, -29, 95, 95, 113, 69, 113, 113, 79, 113,
-	113, 64, 67, 65, 66, -11, 94, -33, -2, 104,
-	-9, 75, 77, -2, 59, 58, 58, 21, 58, 58,
-	58, 57, 58, 8, 59, 58, 8, -2, 59, 59,
-	-19, -19, 61, 61, -32, -2, -2, 59, 59, -6,
-	-23, 10, -2, -25, -25, 46, 46, 46, 51, 46,
-	51, 46, 59, 59, 113, 113, -4, 95, 95, 113,
-	-42, 93, 57, 59, 58, 78, -2, -2, 76, -2,
-	-2, 56, -2, -2, -2, 56, -2, -2, -2, -2,
-	8, 29, 21, -23, -7, 13, 12, 53, 46, 46,
-	113, 113, 57, 9, -11, -2, 76, -2, 59, 59,
-	58, 58, 58, 59, 59, 59, 59, 59, -2, -19,
-	-19, -7, -36, 11, -2, -24, -2, -28, 30, -2,
-	-42, -2, -2, -2, -2, 58, 59, -36, -39, 14,
-	12, -36, 12, 59, 59, 59, 59, -2, -39, -40,
-	15, -20, -37, -35, -2, 59, -29, 59, -40, -20,
-	58, -16, 26, 27, -35, -17, 23, 24, 25,
+	-1000, -1, -54, 35, 37, 40, 41, 38, 39, 21,
+	-15, -16, 10, -2, -4, 73, 93, 52, 53, 56,
+	58, 59, 60, 55, 54, 57, 99, -20, 25, 138,
+	136, 137, 124, 75, 91, 90, -3, -22, 43, 134,
+	84, 85, 83, 86, 140, 135, 82, 80, 78, 74,
+	132, 133, 37, 38, 39, 139, 44, 45, 46, 47,
+	40, 41, 42, -20, 136, 137, 138, 11, 42, -20,
+	-20, 24, -27, 9, 76, -20, 112, 117, 118, 119,
+	120, 122, 121, 123, 124, 125, 126, 127, 128, 129,
+	110, 111, 108, 90, 109, 102, 103, 104, 105, 106,
+	107, 92, 91, 88, 87, 113, 75, -9, -2, 75,
+	75, 75, 75, 75, 75, 75, 75, 75, 75, 75,
+	75, 140, 140, 140, -2, -11, -2, -26, 9, -2,
+	-2, 131, 78, -38, -39, 140, -37, -2, -56, 75,
+	-31, -2, 125, -13, 31, -3, -20, 36, -20, -55,
+	6, 8, 7, -41, 22, -20, 24, 75, -2, -2,
+	-2, -2, -2, -2, -2, 139, -2, 139, -2, -2,
+	-2, -2, -2, 140, 140, 98, 140, 140, -2, -57,
+	-20, 23, -2, -57, -2, -57, -2, -57, -2, -57,
+	-2, -57, -4, 100, 75, 111, 110, 108, 90, 109,
+	-2, -2, 83, 91, 86, 84, 85, 74, 77, -19,
+	22, -51, 94, -36, -2, -2, -2, 74, 140, 74,
+	74, 74, 77, -2, -53, 49, 50, 51, 77, -19,
+	-26, 77, 76, -41, -20, -25, 141, 140, 134, 81,
+	76, 141, 79, 76, 24, -46, -20, 12, 24, -22,
+	-14, -2, 24, -36, -26, 23, -26, 23, -26, 23,
+	-30, -31, 71, 24, 75, -26, -36, 140, 140, 116,
+	116, 140, 75, 75, 88, -4, -2, 140, 140, 98,
+	140, 140, 83, 86, 84, 85, 74, -23, 132, 133,
+	-12, 115, -40, -2, 125, -10, 94, 96, -2, 77,
+	76, 76, 24, 76, 76, 76, 75, 76, 11, 77,
+	76, 11, -2, -12, -36, 77, -2, -30, 79, 141,
+	-25, 79, -39, -2, -2, -15, 77, 76, -2, -21,
+	-20, 9, 10, 24, 32, -15, -55, -26, -55, -26,
+	-55, -26, -5, 76, 20, 75, 75, -26, 77, 77,
+	140, 140, -26, -26, -4, 88, 116, 116, 140, -23,
+	-52, 114, 75, -43, 76, 14, 97, -2, -2, 95,
+	-2, -2, 74, -2, -2, -2, 74, -2, -2, -2,
+	-2, 11, -52, -43, 77, -33, -34, 11, -25, 79,
+	79, -27, -20, -21, -20, -26, -55, -55, -55, -33,
+	-31, -3, -36, -26, 77, 77, 77, -4, 140, 140,
+	75, 12, 77, -2, 15, 95, -2, 77, 77, 76,
+	76, 76, 77, 77, 77, 77, 77, -2, 77, 101,
+	-6, 12, -58, -45, 70, 76, 66, 63, 67, 64,
+	65, 69, -31, 79, -55, 32, 24, -55, -6, 77,
+	77, -35, 33, -2, -12, -44, -42, -2, -2, -2,
+	-2, -2, 76, 77, -12, 75, -28, 13, -2, -31,
+	-20, -31, -45, 63, 63, 63, 68, 63, 68, 63,
+	-20, -21, -28, -43, 15, 77, -52, 76, -17, 29,
+	30, 77, 77, 77, -2, -52, -2, -7, 16, 15,
+	75, 71, 36, -31, 63, 63, -7, 77, -36, -42,
+	-18, 26, 77, 76, -8, 17, -2, -29, -32, -31,
+	-2, -26, -2, 75, -8, 27, 28, -2, -43, -2,
+	76, 34, 77, -46, -43, 77, -47, 18, -32, 74,
+	-24, 24, -22, 77, -47, -48, 19, -25, 24, -21,
+	-48, -49, 43, -25, -21, -49, -60, 27, 44, -59,
+	45, -50, -25, 45, 46, 10, 47,
 }
 
 var yyDef = [...]int16{
-	6, -2, 10, 4, 0, 9, 0, 0, 11, 42,
-	0, 0, 146, 5, 1, 0, 0, 41, 0, 0,
-	11, 0, 42, 8, 113, 18, 19, 20, 43, 0,
-	151, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 21, 0, 0, 0, 0, 0, 34, 0, 22,
-	23, 24, 25, 26, 27, 28, 125, 122, 0, 0,
-	0, 12, 11, 0, 141, 0, 0, 0, 17, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	16, -2, 20, 0, 0, 0, 0, 0, 0, 14,
+	0, 19, 0, 2, 61, 0, 219, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 35, 0, 203,
+	201, 202, 0, 0, 0, 0, 52, 193, 194, 36,
+	37, 38, 39, 40, 41, 42, 43, 160, 157, 195,
+	196, 197, 198, 199, 200, 204, 205, 206, 207, 208,
+	209, 210, 211, 11, 201, 202, 203, 0, 0, 7,
+	9, 0, 21, 60, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 39,
-	0, 152, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 79, 101, 102, 0, 180, 0,
-	0, 0, 36, 37, 0, 123, 0, 0, 120, 0,
-	0, 0, 13, 141, 155, 140, 0, 114, 7, 21,
-	16, 0, 66, 67, 68, 69, 70, 71, 72, 73,
-	74, 75, 76, 77, 78, 81, 83, 0, 85, 86,
-	87, 88, 89, 90, 91, 92, 0, 0, 0, 0,
-	0, 0, 103, 104, 105, 0, 107, 109, 111, 153,
-	0, 38, 147, 0, 0, 115, 0, 0, 0, 0,
-	0, 0, 0, 56, 0, 0, 181, 182, 183, 61,
-	0, 0, 0, 0, 31, 0, 0, 145, 35, 29,
-	0, 0, 30, 0, 0, 0, 14, 155, 159, 0,
-	0, 0, 138, 0, 131, 0, 0, 0, 0, 142,
-	0, 0, 0, 0, 84, 0, 94, 96, 0, 99,
-	100, 106, 108, 110, 112, 130, 0, 0, 117, 118,
-	0, 0, 0, 0, 47, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 57, 0, 0, 0, 62, 65,
-	178, 179, 32, 33, 124, 126, 121, 40, 15, 159,
-	157, 0, 156, 143, 0, 139, 132, 133, 0, 135,
-	0, 137, 63, 64, 80, 82, 93, 0, 0, 98,
-	44, 0, 0, 153, 0, 46, 0, 148, 0, 116,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 157, 170, 0, 0, 0, 134, 136,
-	95, 97, 128, 0, 130, 119, 0, 149, 48, 49,
-	0, 0, 0, 0, 54, 55, 58, 59, 0, 176,
-	177, 170, 172, 0, 158, 160, 144, 170, 0, 0,
-	45, 150, 0, 0, 0, 0, 60, 172, 174, 0,
-	0, 0, 0, 154, 50, 51, 52, 0, 174, 2,
-	0, 173, 171, 169, 164, 129, 127, 53, 3, 175,
-	0, 161, 165, 166, 168, 167, 0, 162, 163,
+	0, 0, 0, 0, 0, 0, 57, 0, 220, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 57,
+	0, 94, 95, 96, 102, 0, 55, 54, 60, 132,
+	133, 0, 0, 0, 158, 0, 0, 155, 0, 0,
+	5, 32, 33, 34, 0, 0, 35, 0, 15, 1,
+	0, 0, 0, 0, 59, 0, 0, 0, 84, 85,
+	86, 87, 88, 89, 90, 204, 91, 204, 97, 98,
+	99, 100, 101, 104, 106, 0, 108, 109, 110, 111,
+	35, 0, 112, 113, 114, 115, 116, 117, 118, 119,
+	120, 121, 0, 0, 0, 0, 0, 0, 0, 0,
+	134, 135, 136, 0, 138, 140, 142, 144, 221, 0,
+	56, 215, 0, 0, 150, 0, 0, 0, 0, 0,
+	0, 0, 74, 0, 0, 265, 266, 267, 221, 0,
+	0, 53, 0, 0, 46, 0, 0, 0, 190, 44,
+	0, 0, 45, 0, 20, 0, 188, 0, 0, 31,
+	0, 264, 20, 8, 21, 0, 21, 0, 21, 0,
+	18, 148, 0, 0, 0, 0, 0, 92, 93, 0,
+	0, 107, 57, 0, 0, 0, 55, 125, 127, 0,
+	130, 131, 137, 139, 141, 143, 146, 145, 191, 192,
+	165, 0, 245, 152, 153, 0, 0, 0, 0, 65,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 75,
+	0, 0, 0, 165, 245, 83, 0, 176, 47, 0,
+	0, 51, 159, 161, 156, 0, 10, 0, 4, 30,
+	212, 213, 214, 0, 0, 0, 22, 21, 24, 21,
+	26, 21, 176, 0, 0, 0, 0, 0, 81, 82,
+	103, 105, 0, 0, 122, 0, 0, 0, 129, 147,
+	62, 0, 0, 0, 0, 0, 64, 0, 216, 0,
+	151, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 79, 0, 0, 223, 175, 0, 0, 49,
+	50, 21, 189, 262, 263, 21, 23, 25, 27, 223,
+	149, 17, 0, 0, 28, 184, 183, 123, 126, 128,
+	163, 0, 221, 154, 0, 0, 217, 66, 67, 0,
+	0, 0, 0, 72, 73, 76, 77, 0, 221, 0,
+	229, 0, 0, 0, 0, 173, 0, 166, 0, 0,
+	0, 0, 177, 48, 3, 0, 0, 6, 229, 58,
+	29, 245, 0, 0, 165, 246, 244, 239, 218, 0,
+	0, 0, 0, 78, 165, 0, 225, 0, 224, 178,
+	35, 0, 0, 174, 167, 168, 0, 170, 0, 172,
+	260, 261, 225, 0, 0, 222, 63, 0, 236, 240,
+	241, 68, 69, 70, 0, 80, 0, 227, 0, 0,
+	57, 0, 0, 182, 169, 171, 227, 164, 162, 243,
+	242, 0, 71, 0, 245, 0, 226, 230, 231, 233,
+	32, 0, 180, 0, 245, 237, 238, 0, 247, 228,
+	0, 0, 187, 0, 247, 124, 249, 0, 232, 234,
+	179, 0, 186, 181, 249, 253, 0, 248, 0, 185,
+	253, 13, 0, 252, 235, 12, 259, 256, 257, 250,
+	251, 0, 258, 0, 254, 0, 255,
 }
 
-var yyTok1 = [...]int8{
+var yyTok1 = [...]uint8{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 70, 3, 3, 3, 106, 98, 3,
-	57, 59, 104, 102, 58, 103, 110, 105, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 114, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 89, 3, 3, 3, 127, 119, 3,
+	75, 77, 125, 123, 76, 124, 131, 126, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 141, 3,
+	3, 3, 3, 82, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 60, 3, 61, 97, 3, 3, 3, 3, 3,
+	3, 78, 3, 79, 118, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 62, 96, 63, 71,
+	3, 3, 3, 80, 117, 81, 90,
 }
 
-var yyTok2 = [...]int8{
+var yyTok2 = [...]uint8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
 	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
-	52, 53, 54, 55, 56, 64, 65, 66, 67, 68,
-	69, 72, 73, 74, 75, 76, 77, 78, 79, 80,
-	81, 82, 83, 84, 85, 86, 87, 88, 89, 90,
-	91, 92, 93, 94, 95, 99, 100, 101, 107, 108,
-	109, 111, 112, 113,
+	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
+	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
+	72, 73, 74, 83, 84, 85, 86, 87, 88, 91,
+	92, 93, 94, 95, 96, 97, 98, 99, 100, 101,
+	102, 103, 104, 105, 106, 107, 108, 109, 110, 111,
+	112, 113, 114, 115, 116, 120, 121, 122, 128, 129,
+	130, 132, 133, 134, 135, 136, 137, 138, 139, 140,
 }
 
 var yyTok3 = [...]int8{
@@ -1032,7 +1286,7 @@ yydefault:
 
 	case 1:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:128
+//line partiql.y:146
 		{
 			query, err := buildQuery(yyDollar[1].str, yyDollar[2].with, yyDollar[3].selinto, yyDollar[4].unions)
 			if err != nil {
@@ -1042,303 +1296,463 @@ yydefault:
 			yylex.(*scanner).result = query
 		}
 	case 2:
-		yyDollar = yyS[yypt-11 : yypt+1]
-//line partiql.y:139
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line partiql.y:155
+		{
+			yylex.(*scanner).result = &expr.Query{Describe: true, Body: yyDollar[2].expr}
+		}
+	case 3:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line partiql.y:159
+		{
+			query, err := buildQuery("", yyDollar[5].with, yyDollar[6].selinto, yyDollar[7].unions)
+			if err == nil {
+				err = buildPrepare(query, yyDollar[2].str, yyDollar[3].types)
+			}
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yylex.(*scanner).result = query
+		}
+	case 4:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line partiql.y:170
+		{
+			yylex.(*scanner).result = &expr.Query{
+				Delete: true,
+				Body: &expr.Select{
+					Columns: []expr.Binding{expr.Bind(expr.Star{}, "")},
+					From:    &expr.Table{Binding: yyDollar[3].bind},
+					Where:   yyDollar[5].expr,
+				},
+			}
+		}
+	case 5:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:181
+		{
+			yylex.Error("DELETE requires a WHERE clause")
+		}
+	case 6:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line partiql.y:185
+		{
+			query, err := buildQuery("", yyDollar[5].with, selectWithInto{sel: yyDollar[6].sel}, yyDollar[7].unions)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			query.Create = true
+			query.Into = yyDollar[3].expr
+			yylex.(*scanner).result = query
+		}
+	case 7:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line partiql.y:195
+		{
+			yylex.(*scanner).result = &expr.Query{Execute: yyDollar[2].str}
+		}
+	case 8:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line partiql.y:199
+		{
+			using, err := buildUsing(yyDollar[4].values)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yylex.(*scanner).result = &expr.Query{Execute: yyDollar[2].str, Using: using}
+		}
+	case 9:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line partiql.y:207
+		{
+			yylex.(*scanner).result = &expr.Query{Deallocate: yyDollar[2].str}
+		}
+	case 10:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:213
+		{
+			types, err := paramTypes(yyDollar[2].strs)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yyVAL.types = types
+		}
+	case 11:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line partiql.y:220
+		{
+			yyVAL.types = nil
+		}
+	case 12:
+		yyDollar = yyS[yypt-13 : yypt+1]
+//line partiql.y:224
 		{
 			distinct, distinctExpr := decodeDistinct(yyDollar[2].values)
-			yyVAL.selinto.sel = &expr.Select{Distinct: distinct, DistinctExpr: distinctExpr, Columns: yyDollar[3].bindings, From: yyDollar[5].from, Where: yyDollar[6].expr, GroupBy: yyDollar[7].bindings, Having: yyDollar[8].expr, OrderBy: yyDollar[9].orders, Limit: yyDollar[10].exprint, Offset: yyDollar[11].exprint}
+			limit, err := fetchLimit(yyDollar[11].exprint, yyDollar[13].exprint)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yyVAL.selinto.sel = &expr.Select{Distinct: distinct, DistinctExpr: distinctExpr, Columns: yyDollar[3].bindings, From: yyDollar[5].from, Where: yyDollar[6].expr, GroupBy: yyDollar[7].bindings, Having: yyDollar[8].expr, Qualify: yyDollar[9].expr, OrderBy: yyDollar[10].orders, Limit: limit, Offset: yyDollar[12].exprint}
+			if err := expr.ResolveUsing(yyVAL.selinto.sel); err != nil {
+				yylex.Error(err.Error())
+			}
+			if err := expr.ResolveOrdinals(yyVAL.selinto.sel); err != nil {
+				yylex.Error(err.Error())
+			}
 			yyVAL.selinto.into = yyDollar[4].expr
 		}
-	case 3:
-		yyDollar = yyS[yypt-10 : yypt+1]
-//line partiql.y:147
+	case 13:
+		yyDollar = yyS[yypt-12 : yypt+1]
+//line partiql.y:242
 		{
 			distinct, distinctExpr := decodeDistinct(yyDollar[2].values)
-			yyVAL.sel = &expr.Select{Distinct: distinct, DistinctExpr: distinctExpr, Columns: yyDollar[3].bindings, From: yyDollar[4].from, Where: yyDollar[5].expr, GroupBy: yyDollar[6].bindings, Having: yyDollar[7].expr, OrderBy: yyDollar[8].orders, Limit: yyDollar[9].exprint, Offset: yyDollar[10].exprint}
+			limit, err := fetchLimit(yyDollar[10].exprint, yyDollar[12].exprint)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yyVAL.sel = &expr.Select{Distinct: distinct, DistinctExpr: distinctExpr, Columns: yyDollar[3].bindings, From: yyDollar[4].from, Where: yyDollar[5].expr, GroupBy: yyDollar[6].bindings, Having: yyDollar[7].expr, Qualify: yyDollar[8].expr, OrderBy: yyDollar[9].orders, Limit: limit, Offset: yyDollar[11].exprint}
+			if err := expr.ResolveUsing(yyVAL.sel); err != nil {
+				yylex.Error(err.Error())
+			}
+			if err := expr.ResolveOrdinals(yyVAL.sel); err != nil {
+				yylex.Error(err.Error())
+			}
 		}
-	case 4:
+	case 14:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:153
+//line partiql.y:258
 		{
 			yyVAL.str = "default"
 		}
-	case 5:
+	case 15:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:154
+//line partiql.y:259
 		{
 			yyVAL.str = yyDollar[3].str
 		}
-	case 6:
+	case 16:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:155
+//line partiql.y:260
 		{
 			yyVAL.str = ""
 		}
-	case 7:
+	case 17:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:158
+//line partiql.y:263
 		{
 			yyVAL.expr = yyDollar[2].expr
 		}
-	case 8:
+	case 18:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:158
+//line partiql.y:263
 		{
 			yyVAL.expr = nil
 		}
-	case 9:
+	case 19:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:161
+//line partiql.y:266
 		{
 			yyVAL.with = yyDollar[1].with
 		}
-	case 10:
+	case 20:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:161
+//line partiql.y:266
 		{
 			yyVAL.with = nil
 		}
-	case 11:
+	case 21:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:164
+//line partiql.y:269
 		{
 			yyVAL.unions = []unionItem{}
 		}
-	case 12:
+	case 22:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:165
+//line partiql.y:270
 		{
 			yyVAL.unions = append(yyVAL.unions, unionItem{typ: expr.UnionDistinct, sel: yyDollar[2].sel})
 			yyVAL.unions = append(yyVAL.unions, yyDollar[3].unions...)
 		}
-	case 13:
+	case 23:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:169
+//line partiql.y:274
 		{
 			yyVAL.unions = append(yyVAL.unions, unionItem{typ: expr.UnionAll, sel: yyDollar[3].sel})
 			yyVAL.unions = append(yyVAL.unions, yyDollar[4].unions...)
 		}
-	case 14:
+	case 24:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:278
+		{
+			yyVAL.unions = append(yyVAL.unions, unionItem{typ: expr.Intersect, sel: yyDollar[2].sel})
+			yyVAL.unions = append(yyVAL.unions, yyDollar[3].unions...)
+		}
+	case 25:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line partiql.y:282
+		{
+			yyVAL.unions = append(yyVAL.unions, unionItem{typ: expr.IntersectAll, sel: yyDollar[3].sel})
+			yyVAL.unions = append(yyVAL.unions, yyDollar[4].unions...)
+		}
+	case 26:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:286
+		{
+			yyVAL.unions = append(yyVAL.unions, unionItem{typ: expr.Except, sel: yyDollar[2].sel})
+			yyVAL.unions = append(yyVAL.unions, yyDollar[3].unions...)
+		}
+	case 27:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line partiql.y:290
+		{
+			yyVAL.unions = append(yyVAL.unions, unionItem{typ: expr.ExceptAll, sel: yyDollar[3].sel})
+			yyVAL.unions = append(yyVAL.unions, yyDollar[4].unions...)
+		}
+	case 28:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line partiql.y:175
+//line partiql.y:296
 		{
 			yyVAL.with = []expr.CTE{{Table: yyDollar[2].str, As: yyDollar[5].sel}}
 		}
-	case 15:
+	case 29:
 		yyDollar = yyS[yypt-7 : yypt+1]
-//line partiql.y:176
+//line partiql.y:297
 		{
 			yyVAL.with = append(yyDollar[1].with, expr.CTE{Table: yyDollar[3].str, As: yyDollar[6].sel})
 		}
-	case 16:
+	case 30:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:182
+//line partiql.y:303
 		{
 			yyVAL.bind = expr.Bind(yyDollar[1].expr, yyDollar[3].str)
 		}
-	case 17:
+	case 31:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:183
+//line partiql.y:304
 		{
 			yyVAL.bind = expr.Bind(yyDollar[1].expr, yyDollar[2].str)
 		}
-	case 18:
+	case 32:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:184
+//line partiql.y:305
 		{
 			yyVAL.bind = expr.Bind(yyDollar[1].expr, "")
 		}
-	case 19:
+	case 33:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:185
+//line partiql.y:306
 		{
 			yyVAL.bind = expr.Bind(expr.Star{}, "")
 		}
-	case 20:
+	case 34:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:186
+//line partiql.y:307
 		{
 			yyVAL.bind = expr.Bind(yyDollar[1].expr, "")
 		}
-	case 21:
+	case 35:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:190
+//line partiql.y:311
 		{
-			yyVAL.expr = expr.Ident(yyDollar[1].str)
+			yyVAL.expr = yylex.(*scanner).at(expr.Ident(yyDollar[1].str), yyDollar[1].pos, yyDollar[1].end)
 		}
-	case 22:
+	case 36:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:191
+//line partiql.y:312
 		{
 			yyVAL.expr = yyDollar[1].expr
 		}
-	case 23:
+	case 37:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:192
+//line partiql.y:313
 		{
 			yyVAL.expr = expr.Bool(true)
 		}
-	case 24:
+	case 38:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:193
+//line partiql.y:314
 		{
 			yyVAL.expr = expr.Bool(false)
 		}
-	case 25:
+	case 39:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:194
+//line partiql.y:315
 		{
 			yyVAL.expr = expr.Null{}
 		}
-	case 26:
+	case 40:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:195
+//line partiql.y:316
 		{
 			yyVAL.expr = expr.Missing{}
 		}
-	case 27:
+	case 41:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:196
+//line partiql.y:317
 		{
 			yyVAL.expr = expr.String(yyDollar[1].str)
 		}
-	case 28:
+	case 42:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:197
+//line partiql.y:318
 		{
 			yyVAL.expr = yyDollar[1].expr
 		}
-	case 29:
+	case 43:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:319
+		{
+			yyVAL.expr = yylex.(*scanner).param()
+		}
+	case 44:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:198
+//line partiql.y:320
 		{
 			yyVAL.expr = expr.Call(expr.MakeStruct, yyDollar[2].values...)
 		}
-	case 30:
+	case 45:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:199
+//line partiql.y:321
 		{
 			yyVAL.expr = expr.Call(expr.MakeList, yyDollar[2].values...)
 		}
-	case 31:
+	case 46:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:200
+//line partiql.y:322
 		{
-			yyVAL.expr = &expr.Dot{Inner: yyDollar[1].expr, Field: yyDollar[3].str}
+			yyVAL.expr = yylex.(*scanner).at(&expr.Dot{Inner: yyDollar[1].expr, Field: yyDollar[3].str}, yyDollar[1].pos, yyDollar[1].end)
 		}
-	case 32:
+	case 47:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:201
+//line partiql.y:323
 		{
 			yyVAL.expr = &expr.Index{Inner: yyDollar[1].expr, Offset: yyDollar[3].integer}
 		}
-	case 33:
+	case 48:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line partiql.y:324
+		{
+			yyVAL.expr = &expr.Slice{Inner: yyDollar[1].expr, From: yyDollar[3].integer, To: yyDollar[5].integer}
+		}
+	case 49:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line partiql.y:325
+		{
+			yyVAL.expr = &expr.Slice{Inner: yyDollar[1].expr, From: yyDollar[3].integer, ToEnd: true}
+		}
+	case 50:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line partiql.y:326
+		{
+			yyVAL.expr = &expr.Slice{Inner: yyDollar[1].expr, To: yyDollar[4].integer}
+		}
+	case 51:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:202
+//line partiql.y:327
 		{
-			yyVAL.expr = &expr.Dot{Inner: yyDollar[1].expr, Field: yyDollar[3].str}
+			yyVAL.expr = yylex.(*scanner).at(&expr.Dot{Inner: yyDollar[1].expr, Field: yyDollar[3].str}, yyDollar[1].pos, yyDollar[1].end)
 		}
-	case 34:
+	case 52:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:214
+//line partiql.y:339
 		{
 			yyVAL.expr = yyDollar[1].expr
 		}
-	case 35:
+	case 53:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:215
+//line partiql.y:340
 		{
 			yyVAL.expr = yyDollar[2].expr
 		}
-	case 36:
+	case 54:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:218
+//line partiql.y:343
 		{
 			yyVAL.expr = yyDollar[1].sel
 		}
-	case 37:
+	case 55:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:219
+//line partiql.y:344
 		{
 			yyVAL.expr = yyDollar[1].expr
 		}
-	case 38:
+	case 56:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:222
+//line partiql.y:347
 		{
 			yyVAL.yesno = true
 		}
-	case 39:
+	case 57:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:222
+//line partiql.y:347
 		{
 			yyVAL.yesno = false
 		}
-	case 40:
+	case 58:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:225
+//line partiql.y:350
 		{
 			yyVAL.values = yyDollar[4].values
 		}
-	case 41:
+	case 59:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:226
+//line partiql.y:351
 		{
 			yyVAL.values = []expr.Node{}
 		}
-	case 42:
+	case 60:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:227
+//line partiql.y:352
 		{
 			yyVAL.values = nil
 		}
-	case 43:
+	case 61:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:233
+//line partiql.y:358
 		{
 			yyVAL.expr = yyDollar[1].expr
 		}
-	case 44:
+	case 62:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:237
+//line partiql.y:362
 		{
-			agg, err := toAggregate(expr.AggregateOp(yyDollar[1].integer), false, nil, yyDollar[4].expr, yyDollar[5].wind)
+			agg, err := toAggregate(expr.AggregateOp(yyDollar[1].integer), "", false, nil, nil, yyDollar[4].expr, yyDollar[5].wind)
 			if err != nil {
 				yylex.Error(err.Error())
 			}
 			yyVAL.expr = agg
 		}
-	case 45:
-		yyDollar = yyS[yypt-7 : yypt+1]
-//line partiql.y:245
+	case 63:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line partiql.y:370
 		{
-			agg, err := toAggregate(expr.AggregateOp(yyDollar[1].integer), yyDollar[3].yesno, yyDollar[4].values, yyDollar[6].expr, yyDollar[7].wind)
+			agg, err := toAggregate(expr.AggregateOp(yyDollar[1].integer), "", yyDollar[3].yesno, yyDollar[4].values, yyDollar[5].orders, yyDollar[7].expr, yyDollar[8].wind)
 			if err != nil {
 				yylex.Error(err.Error())
 			}
 			yyVAL.expr = agg
 		}
-	case 46:
+	case 64:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:253
+//line partiql.y:378
 		{
 			yyVAL.expr = createCase(yyDollar[2].expr, yyDollar[3].limbs, yyDollar[4].expr)
 		}
-	case 47:
+	case 65:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:257
+//line partiql.y:382
 		{
 			yyVAL.expr = expr.Coalesce(yyDollar[3].values)
 		}
-	case 48:
+	case 66:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line partiql.y:261
+//line partiql.y:386
 		{
 			yyVAL.expr = expr.NullIf(yyDollar[3].expr, yyDollar[5].expr)
 		}
-	case 49:
+	case 67:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line partiql.y:265
+//line partiql.y:390
 		{
 			nod, ok := buildCast(yyDollar[3].expr, yyDollar[5].str)
 			if !ok {
@@ -1346,9 +1760,9 @@ yydefault:
 			}
 			yyVAL.expr = nod
 		}
-	case 50:
+	case 68:
 		yyDollar = yyS[yypt-8 : yypt+1]
-//line partiql.y:273
+//line partiql.y:398
 		{
 			part, ok := timePartFor(yyDollar[3].str, "DATE_ADD")
 			if !ok {
@@ -1356,9 +1770,9 @@ yydefault:
 			}
 			yyVAL.expr = expr.DateAdd(part, yyDollar[5].expr, yyDollar[7].expr)
 		}
-	case 51:
+	case 69:
 		yyDollar = yyS[yypt-8 : yypt+1]
-//line partiql.y:281
+//line partiql.y:406
 		{
 			interval, err := parseInterval(yyDollar[3].str)
 			if err != nil {
@@ -1366,9 +1780,9 @@ yydefault:
 			}
 			yyVAL.expr = expr.DateBinWithInterval(interval, yyDollar[5].expr, yyDollar[7].expr)
 		}
-	case 52:
+	case 70:
 		yyDollar = yyS[yypt-8 : yypt+1]
-//line partiql.y:289
+//line partiql.y:414
 		{
 			part, ok := timePartFor(yyDollar[3].str, "DATE_DIFF")
 			if !ok {
@@ -1376,9 +1790,9 @@ yydefault:
 			}
 			yyVAL.expr = expr.DateDiff(part, yyDollar[5].expr, yyDollar[7].expr)
 		}
-	case 53:
+	case 71:
 		yyDollar = yyS[yypt-9 : yypt+1]
-//line partiql.y:297
+//line partiql.y:422
 		{
 			dow, ok := weekday(yyDollar[5].str)
 			if strings.ToUpper(yyDollar[3].str) != "WEEK" || !ok {
@@ -1386,9 +1800,9 @@ yydefault:
 			}
 			yyVAL.expr = expr.DateTruncWeekday(yyDollar[8].expr, dow)
 		}
-	case 54:
+	case 72:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line partiql.y:305
+//line partiql.y:430
 		{
 			part, ok := timePartFor(yyDollar[3].str, "DATE_TRUNC")
 			if !ok {
@@ -1396,25 +1810,25 @@ yydefault:
 			}
 			yyVAL.expr = expr.DateTrunc(part, yyDollar[5].expr)
 		}
-	case 55:
+	case 73:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line partiql.y:313
+//line partiql.y:438
 		{
-			part, ok := timePartFor(yyDollar[3].str, "EXTRACT")
+			node, ok := dateExtract(yyDollar[3].str, yyDollar[5].expr)
 			if !ok {
 				yylex.Error(__yyfmt__.Sprintf("bad EXTRACT part %q", yyDollar[3].str))
 			}
-			yyVAL.expr = expr.DateExtract(part, yyDollar[5].expr)
+			yyVAL.expr = node
 		}
-	case 56:
+	case 74:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:321
+//line partiql.y:446
 		{
 			yyVAL.expr = yylex.(*scanner).utcnow()
 		}
-	case 57:
+	case 75:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:325
+//line partiql.y:450
 		{
 			node, err := createTrimInvocation(trimBoth, yyDollar[3].expr, nil)
 			if err != nil {
@@ -1422,9 +1836,9 @@ yydefault:
 			}
 			yyVAL.expr = node
 		}
-	case 58:
+	case 76:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line partiql.y:333
+//line partiql.y:458
 		{
 			node, err := createTrimInvocation(trimBoth, yyDollar[3].expr, yyDollar[5].expr)
 			if err != nil {
@@ -1432,9 +1846,9 @@ yydefault:
 			}
 			yyVAL.expr = node
 		}
-	case 59:
+	case 77:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line partiql.y:341
+//line partiql.y:466
 		{
 			node, err := createTrimInvocation(trimBoth, yyDollar[5].expr, yyDollar[3].expr)
 			if err != nil {
@@ -1442,9 +1856,9 @@ yydefault:
 			}
 			yyVAL.expr = node
 		}
-	case 60:
+	case 78:
 		yyDollar = yyS[yypt-7 : yypt+1]
-//line partiql.y:349
+//line partiql.y:474
 		{
 			node, err := createTrimInvocation(yyDollar[3].integer, yyDollar[6].expr, yyDollar[4].expr)
 			if err != nil {
@@ -1452,509 +1866,727 @@ yydefault:
 			}
 			yyVAL.expr = node
 		}
-	case 61:
-		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:357
+	case 79:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line partiql.y:482
 		{
-			op := expr.CallByName(yyDollar[1].str)
-			if op.Private() {
-				yylex.Error(__yyfmt__.Sprintf("cannot use reserved builtin %q", yyDollar[1].str))
+			node, err := funcall(yyDollar[1].str, false, nil, nil, yyDollar[4].expr, yyDollar[5].wind)
+			if err != nil {
+				yylex.Error(err.Error())
 			}
-			yyVAL.expr = op
+			yyVAL.expr = node
 		}
-	case 62:
-		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:365
+	case 80:
+		yyDollar = yyS[yypt-8 : yypt+1]
+//line partiql.y:490
 		{
-			op := expr.CallByName(yyDollar[1].str, yyDollar[3].values...)
-			if op.Private() {
-				yylex.Error(__yyfmt__.Sprintf("cannot use reserved builtin %q", yyDollar[1].str))
+			node, err := funcall(yyDollar[1].str, yyDollar[3].yesno, yyDollar[4].values, yyDollar[5].orders, yyDollar[7].expr, yyDollar[8].wind)
+			if err != nil {
+				yylex.Error(err.Error())
 			}
-			yyVAL.expr = op
+			yyVAL.expr = node
 		}
-	case 63:
+	case 81:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:373
+//line partiql.y:498
 		{
 			yyVAL.expr = expr.Call(expr.InSubquery, yyDollar[1].expr, yyDollar[4].sel)
 		}
-	case 64:
+	case 82:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:377
+//line partiql.y:502
 		{
 			yyVAL.expr = expr.In(yyDollar[1].expr, yyDollar[4].values...)
 		}
-	case 65:
+	case 83:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:381
+//line partiql.y:506
 		{
 			yyVAL.expr = exists(yyDollar[3].sel)
 		}
-	case 66:
+	case 84:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:385
+//line partiql.y:510
 		{
 			yyVAL.expr = expr.BitOr(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 67:
+	case 85:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:389
+//line partiql.y:514
 		{
 			yyVAL.expr = expr.BitXor(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 68:
+	case 86:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:393
+//line partiql.y:518
 		{
 			yyVAL.expr = expr.BitAnd(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 69:
+	case 87:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:397
+//line partiql.y:522
 		{
 			yyVAL.expr = expr.ShiftLeftLogical(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 70:
+	case 88:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:401
+//line partiql.y:526
 		{
 			yyVAL.expr = expr.ShiftRightLogical(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 71:
+	case 89:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:405
+//line partiql.y:530
 		{
 			yyVAL.expr = expr.ShiftRightArithmetic(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 72:
+	case 90:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:409
+//line partiql.y:534
 		{
 			yyVAL.expr = expr.Add(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 73:
+	case 91:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:413
+//line partiql.y:538
 		{
 			yyVAL.expr = expr.Sub(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 74:
+	case 92:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line partiql.y:542
+		{
+			iv, err := parseIntervalLiteral(yyDollar[4].str, true)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yyVAL.expr = addInterval(yyDollar[1].expr, iv)
+		}
+	case 93:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line partiql.y:550
+		{
+			iv, err := parseIntervalLiteral(yyDollar[4].str, true)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yyVAL.expr = addInterval(yyDollar[1].expr, iv.neg())
+		}
+	case 94:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line partiql.y:558
+		{
+			ts, err := timestampLiteral(yyDollar[2].str)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yyVAL.expr = ts
+		}
+	case 95:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line partiql.y:566
+		{
+			yylex.Error("DATE literals are not supported; use TIMESTAMP")
+		}
+	case 96:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line partiql.y:570
+		{
+			yylex.Error("TIME literals are not supported; use TIMESTAMP")
+		}
+	case 97:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:417
+//line partiql.y:574
 		{
 			yyVAL.expr = expr.Mul(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 75:
+	case 98:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:421
+//line partiql.y:578
 		{
 			yyVAL.expr = expr.Div(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 76:
+	case 99:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:425
+//line partiql.y:582
 		{
 			yyVAL.expr = expr.Mod(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 77:
+	case 100:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:429
+//line partiql.y:586
 		{
 			yyVAL.expr = expr.Call(expr.Concat, yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 78:
+	case 101:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:433
+//line partiql.y:590
 		{
 			yyVAL.expr = expr.Append(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 79:
+	case 102:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:437
+//line partiql.y:594
 		{
 			yyVAL.expr = expr.Neg(yyDollar[2].expr)
 		}
-	case 80:
+	case 103:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:441
+//line partiql.y:598
 		{
 			yyVAL.expr = &expr.StringMatch{Op: expr.Ilike, Expr: yyDollar[1].expr, Pattern: yyDollar[3].str, Escape: yyDollar[5].str}
 		}
-	case 81:
+	case 104:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:445
+//line partiql.y:602
 		{
 			yyVAL.expr = &expr.StringMatch{Op: expr.Ilike, Expr: yyDollar[1].expr, Pattern: yyDollar[3].str}
 		}
-	case 82:
+	case 105:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:449
+//line partiql.y:606
 		{
 			yyVAL.expr = &expr.StringMatch{Op: expr.Like, Expr: yyDollar[1].expr, Pattern: yyDollar[3].str, Escape: yyDollar[5].str}
 		}
-	case 83:
+	case 106:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:453
+//line partiql.y:610
 		{
 			yyVAL.expr = &expr.StringMatch{Op: expr.Like, Expr: yyDollar[1].expr, Pattern: yyDollar[3].str}
 		}
-	case 84:
+	case 107:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:457
+//line partiql.y:614
 		{
 			yyVAL.expr = &expr.StringMatch{Op: expr.SimilarTo, Expr: yyDollar[1].expr, Pattern: yyDollar[4].str}
 		}
-	case 85:
+	case 108:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:461
+//line partiql.y:618
 		{
 			yyVAL.expr = &expr.StringMatch{Op: expr.RegexpMatch, Expr: yyDollar[1].expr, Pattern: yyDollar[3].str}
 		}
-	case 86:
+	case 109:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:465
+//line partiql.y:622
 		{
 			yyVAL.expr = &expr.StringMatch{Op: expr.RegexpMatchCi, Expr: yyDollar[1].expr, Pattern: yyDollar[3].str}
 		}
-	case 87:
+	case 110:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:469
+//line partiql.y:626
 		{
-			yyVAL.expr = expr.Compare(expr.Equals, yyDollar[1].expr, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*scanner).at(expr.Compare(expr.Equals, yyDollar[1].expr, yyDollar[3].expr), yyDollar[1].pos, yyDollar[1].end)
 		}
-	case 88:
+	case 111:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:473
+//line partiql.y:630
 		{
-			yyVAL.expr = expr.Compare(expr.NotEquals, yyDollar[1].expr, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*scanner).at(quantified(expr.Equals, yyDollar[1].expr, yyDollar[3].quant), yyDollar[1].pos, yyDollar[1].end)
 		}
-	case 89:
+	case 112:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:477
+//line partiql.y:634
 		{
-			yyVAL.expr = expr.Compare(expr.Less, yyDollar[1].expr, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*scanner).at(expr.Compare(expr.NotEquals, yyDollar[1].expr, yyDollar[3].expr), yyDollar[1].pos, yyDollar[1].end)
 		}
-	case 90:
+	case 113:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:481
+//line partiql.y:638
 		{
-			yyVAL.expr = expr.Compare(expr.LessEquals, yyDollar[1].expr, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*scanner).at(quantified(expr.NotEquals, yyDollar[1].expr, yyDollar[3].quant), yyDollar[1].pos, yyDollar[1].end)
 		}
-	case 91:
+	case 114:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:485
+//line partiql.y:642
 		{
-			yyVAL.expr = expr.Compare(expr.Greater, yyDollar[1].expr, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*scanner).at(expr.Compare(expr.Less, yyDollar[1].expr, yyDollar[3].expr), yyDollar[1].pos, yyDollar[1].end)
 		}
-	case 92:
+	case 115:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:489
+//line partiql.y:646
 		{
-			yyVAL.expr = expr.Compare(expr.GreaterEquals, yyDollar[1].expr, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*scanner).at(quantified(expr.Less, yyDollar[1].expr, yyDollar[3].quant), yyDollar[1].pos, yyDollar[1].end)
 		}
-	case 93:
+	case 116:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:650
+		{
+			yyVAL.expr = yylex.(*scanner).at(expr.Compare(expr.LessEquals, yyDollar[1].expr, yyDollar[3].expr), yyDollar[1].pos, yyDollar[1].end)
+		}
+	case 117:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:654
+		{
+			yyVAL.expr = yylex.(*scanner).at(quantified(expr.LessEquals, yyDollar[1].expr, yyDollar[3].quant), yyDollar[1].pos, yyDollar[1].end)
+		}
+	case 118:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:658
+		{
+			yyVAL.expr = yylex.(*scanner).at(expr.Compare(expr.Greater, yyDollar[1].expr, yyDollar[3].expr), yyDollar[1].pos, yyDollar[1].end)
+		}
+	case 119:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:662
+		{
+			yyVAL.expr = yylex.(*scanner).at(quantified(expr.Greater, yyDollar[1].expr, yyDollar[3].quant), yyDollar[1].pos, yyDollar[1].end)
+		}
+	case 120:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:666
+		{
+			yyVAL.expr = yylex.(*scanner).at(expr.Compare(expr.GreaterEquals, yyDollar[1].expr, yyDollar[3].expr), yyDollar[1].pos, yyDollar[1].end)
+		}
+	case 121:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:670
+		{
+			yyVAL.expr = yylex.(*scanner).at(quantified(expr.GreaterEquals, yyDollar[1].expr, yyDollar[3].quant), yyDollar[1].pos, yyDollar[1].end)
+		}
+	case 122:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:493
+//line partiql.y:674
 		{
 			yyVAL.expr = expr.Between(yyDollar[1].expr, yyDollar[3].expr, yyDollar[5].expr)
 		}
-	case 94:
+	case 123:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line partiql.y:678
+		{
+			yyVAL.expr = expr.BetweenSymmetric(yyDollar[1].expr, yyDollar[4].expr, yyDollar[6].expr)
+		}
+	case 124:
+		yyDollar = yyS[yypt-11 : yypt+1]
+//line partiql.y:682
+		{
+			yyVAL.expr = expr.Call(expr.Overlaps, yyDollar[2].expr, yyDollar[4].expr, yyDollar[8].expr, yyDollar[10].expr)
+		}
+	case 125:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:497
+//line partiql.y:686
 		{
 			yyVAL.expr = &expr.Not{Expr: &expr.StringMatch{Op: expr.Like, Expr: yyDollar[1].expr, Pattern: yyDollar[4].str}}
 		}
-	case 95:
+	case 126:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line partiql.y:501
+//line partiql.y:690
 		{
 			yyVAL.expr = &expr.Not{Expr: &expr.StringMatch{Op: expr.Like, Expr: yyDollar[1].expr, Pattern: yyDollar[4].str, Escape: yyDollar[6].str}}
 		}
-	case 96:
+	case 127:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:505
+//line partiql.y:694
 		{
 			yyVAL.expr = &expr.Not{Expr: &expr.StringMatch{Op: expr.Like, Expr: yyDollar[1].expr, Pattern: yyDollar[4].str}}
 		}
-	case 97:
+	case 128:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line partiql.y:509
+//line partiql.y:698
 		{
 			yyVAL.expr = &expr.Not{Expr: &expr.StringMatch{Op: expr.Ilike, Expr: yyDollar[1].expr, Pattern: yyDollar[4].str, Escape: yyDollar[6].str}}
 		}
-	case 98:
+	case 129:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:513
+//line partiql.y:702
 		{
 			yyVAL.expr = &expr.Not{Expr: &expr.StringMatch{Op: expr.SimilarTo, Expr: yyDollar[1].expr, Pattern: yyDollar[5].str}}
 		}
-	case 99:
+	case 130:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:517
+//line partiql.y:706
 		{
 			yyVAL.expr = &expr.Not{Expr: &expr.StringMatch{Op: expr.RegexpMatch, Expr: yyDollar[1].expr, Pattern: yyDollar[4].str}}
 		}
-	case 100:
+	case 131:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:521
+//line partiql.y:710
 		{
 			yyVAL.expr = &expr.Not{Expr: &expr.StringMatch{Op: expr.RegexpMatchCi, Expr: yyDollar[1].expr, Pattern: yyDollar[4].str}}
 		}
-	case 101:
+	case 132:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:525
+//line partiql.y:714
 		{
 			yyVAL.expr = &expr.Not{Expr: yyDollar[2].expr}
 		}
-	case 102:
+	case 133:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:529
+//line partiql.y:718
 		{
 			yyVAL.expr = expr.BitNot(yyDollar[2].expr)
 		}
-	case 103:
+	case 134:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:533
+//line partiql.y:722
 		{
 			yyVAL.expr = expr.And(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 104:
+	case 135:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:537
+//line partiql.y:726
 		{
 			yyVAL.expr = expr.Or(yyDollar[1].expr, yyDollar[3].expr)
 		}
-	case 105:
+	case 136:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:541
+//line partiql.y:730
 		{
 			yyVAL.expr = &expr.IsKey{Key: expr.IsNull, Expr: yyDollar[1].expr}
 		}
-	case 106:
+	case 137:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:545
+//line partiql.y:734
 		{
 			yyVAL.expr = &expr.IsKey{Key: expr.IsNotNull, Expr: yyDollar[1].expr}
 		}
-	case 107:
+	case 138:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:549
+//line partiql.y:738
 		{
 			yyVAL.expr = &expr.IsKey{Key: expr.IsMissing, Expr: yyDollar[1].expr}
 		}
-	case 108:
+	case 139:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:553
+//line partiql.y:742
 		{
 			yyVAL.expr = &expr.IsKey{Key: expr.IsNotMissing, Expr: yyDollar[1].expr}
 		}
-	case 109:
+	case 140:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:557
+//line partiql.y:746
 		{
 			yyVAL.expr = &expr.IsKey{Key: expr.IsTrue, Expr: yyDollar[1].expr}
 		}
-	case 110:
+	case 141:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:561
+//line partiql.y:750
 		{
 			yyVAL.expr = &expr.IsKey{Key: expr.IsNotTrue, Expr: yyDollar[1].expr}
 		}
-	case 111:
+	case 142:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:565
+//line partiql.y:754
 		{
 			yyVAL.expr = &expr.IsKey{Key: expr.IsFalse, Expr: yyDollar[1].expr}
 		}
-	case 112:
+	case 143:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:569
+//line partiql.y:758
 		{
 			yyVAL.expr = &expr.IsKey{Key: expr.IsNotFalse, Expr: yyDollar[1].expr}
 		}
-	case 113:
+	case 144:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:762
+		{
+			nod, err := buildIsJSON(yyDollar[1].expr, yyDollar[3].str, "")
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yyVAL.expr = nod
+		}
+	case 145:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line partiql.y:770
+		{
+			nod, err := buildIsJSON(yyDollar[1].expr, yyDollar[3].str, yyDollar[4].str)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yyVAL.expr = nod
+		}
+	case 146:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line partiql.y:778
+		{
+			nod, err := buildIsJSON(yyDollar[1].expr, yyDollar[4].str, "")
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yyVAL.expr = &expr.Not{Expr: nod}
+		}
+	case 147:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line partiql.y:786
+		{
+			nod, err := buildIsJSON(yyDollar[1].expr, yyDollar[4].str, yyDollar[5].str)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yyVAL.expr = &expr.Not{Expr: nod}
+		}
+	case 148:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:575
+//line partiql.y:796
 		{
 			yyVAL.bindings = []expr.Binding{yyDollar[1].bind}
 		}
-	case 114:
+	case 149:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:576
+//line partiql.y:797
 		{
 			yyVAL.bindings = append(yyDollar[1].bindings, yyDollar[3].bind)
 		}
-	case 115:
+	case 150:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:580
+//line partiql.y:801
 		{
 			yyVAL.values = []expr.Node{yyDollar[1].expr}
 		}
-	case 116:
+	case 151:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:581
+//line partiql.y:802
 		{
 			yyVAL.values = append(yyDollar[1].values, yyDollar[3].expr)
 		}
-	case 117:
+	case 152:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:585
+//line partiql.y:806
 		{
 			yyVAL.values = []expr.Node{yyDollar[1].expr}
 		}
-	case 118:
+	case 153:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:586
+//line partiql.y:807
 		{
 			yyVAL.values = []expr.Node{expr.Star{}}
 		}
-	case 119:
+	case 154:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:587
+//line partiql.y:808
 		{
 			yyVAL.values = append(yyDollar[1].values, yyDollar[3].expr)
 		}
-	case 120:
+	case 155:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:591
+//line partiql.y:812
 		{
 			yyVAL.values = []expr.Node{yyDollar[1].expr}
 		}
-	case 121:
+	case 156:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:592
+//line partiql.y:813
 		{
 			yyVAL.values = append(yyDollar[1].values, yyDollar[3].expr)
 		}
-	case 122:
+	case 157:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:593
+//line partiql.y:814
 		{
 			yyVAL.values = nil
 		}
-	case 123:
+	case 158:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:597
+//line partiql.y:818
 		{
 			yyVAL.values = yyDollar[1].values
 		}
-	case 124:
+	case 159:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:598
+//line partiql.y:819
 		{
 			yyVAL.values = append(yyDollar[1].values, yyDollar[3].values...)
 		}
-	case 125:
+	case 160:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:599
+//line partiql.y:820
 		{
 			yyVAL.values = nil
 		}
-	case 126:
+	case 161:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:603
+//line partiql.y:824
 		{
 			yyVAL.values = []expr.Node{expr.String(yyDollar[1].str), yyDollar[3].expr}
 		}
-	case 127:
+	case 162:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:607
+//line partiql.y:828
 		{
 			yyVAL.values = yyDollar[3].values
 		}
-	case 128:
+	case 163:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:610
+//line partiql.y:831
 		{
 			yyVAL.values = nil
 		}
-	case 129:
+	case 164:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:614
+//line partiql.y:835
 		{
 			yyVAL.wind = &expr.Window{PartitionBy: yyDollar[3].values, OrderBy: yyDollar[4].orders}
 		}
-	case 130:
+	case 165:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:617
+//line partiql.y:838
 		{
 			yyVAL.wind = nil
 		}
-	case 131:
+	case 166:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:620
+//line partiql.y:841
 		{
 			yyVAL.jk = expr.InnerJoin
 		}
-	case 132:
+	case 167:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:621
+//line partiql.y:842
 		{
 			yyVAL.jk = expr.InnerJoin
 		}
-	case 133:
+	case 168:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:622
+//line partiql.y:843
 		{
 			yyVAL.jk = expr.LeftJoin
 		}
-	case 134:
+	case 169:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:623
+//line partiql.y:844
 		{
 			yyVAL.jk = expr.LeftJoin
 		}
-	case 135:
+	case 170:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:624
+//line partiql.y:845
 		{
 			yyVAL.jk = expr.RightJoin
 		}
-	case 136:
+	case 171:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:625
+//line partiql.y:846
 		{
 			yyVAL.jk = expr.RightJoin
 		}
-	case 137:
+	case 172:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:626
+//line partiql.y:847
 		{
 			yyVAL.jk = expr.FullJoin
 		}
-	case 140:
+	case 175:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:631
+//line partiql.y:852
 		{
 			yyVAL.from = yyDollar[1].from
 		}
-	case 141:
+	case 176:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:632
+//line partiql.y:853
 		{
 			yyVAL.from = nil
 		}
-	case 142:
+	case 177:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:635
+//line partiql.y:856
 		{
 			yyVAL.from = &expr.Table{Binding: yyDollar[2].bind}
 		}
-	case 143:
+	case 178:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:636
+//line partiql.y:858
 		{
-			yyVAL.from = &expr.Join{Kind: expr.CrossJoin, Left: yyDollar[1].from, Right: yyDollar[3].bind}
+			j, err := crossJoin(yyDollar[1].from, yyDollar[3].bind)
+			if err != nil {
+				yylex.Error(err.Error())
+				yyVAL.from = yyDollar[1].from
+			} else {
+				yyVAL.from = j
+			}
 		}
-	case 144:
+	case 179:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line partiql.y:868
+		{
+			j, err := lateralJoin(yyDollar[1].from, yyDollar[3].str, yyDollar[5].sel, yyDollar[7].str)
+			if err != nil {
+				yylex.Error(err.Error())
+				yyVAL.from = yyDollar[1].from
+			} else {
+				yyVAL.from = j
+			}
+		}
+	case 180:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:638
+//line partiql.y:878
 		{
 			yyVAL.from = &expr.Join{Kind: yyDollar[2].jk, Left: yyDollar[1].from, Right: yyDollar[3].bind, On: yyDollar[5].expr}
 		}
-	case 145:
+	case 181:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line partiql.y:880
+		{
+			j, err := expr.JoinUsing(yyDollar[2].jk, yyDollar[1].from, yyDollar[3].bind, yyDollar[6].strs)
+			if err != nil {
+				yylex.Error(err.Error())
+				yyVAL.from = yyDollar[1].from
+			} else {
+				yyVAL.from = j
+			}
+		}
+	case 182:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line partiql.y:890
+		{
+			j, err := expr.NaturalJoin(yyDollar[3].jk, yyDollar[1].from, yyDollar[4].bind)
+			if err != nil {
+				yylex.Error(err.Error())
+				yyVAL.from = yyDollar[1].from
+			} else {
+				yyVAL.from = j
+			}
+		}
+	case 183:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line partiql.y:902
+		{
+			yyVAL.quant = quantifiedSubquery{fn: expr.AllSubquery, sel: yyDollar[3].sel}
+		}
+	case 184:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line partiql.y:906
+		{
+			q, err := anySubquery(yyDollar[1].str, yyDollar[3].sel)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+			yyVAL.quant = q
+		}
+	case 185:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line partiql.y:915
+		{
+			yyVAL.str = yyDollar[2].str
+		}
+	case 186:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:916
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 187:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line partiql.y:917
+		{
+			yyVAL.str = ""
+		}
+	case 188:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:920
+		{
+			yyVAL.strs = []string{yyDollar[1].str}
+		}
+	case 189:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:921
+		{
+			yyVAL.strs = append(yyDollar[1].strs, yyDollar[3].str)
+		}
+	case 190:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:641
+//line partiql.y:924
 		{
 			var idxerr error
 			yyVAL.integer, idxerr = toint(yyDollar[1].expr)
@@ -1962,239 +2594,480 @@ yydefault:
 				yylex.Error(idxerr.Error())
 			}
 		}
-	case 146:
+	case 191:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:650
+//line partiql.y:933
 		{
 			yyVAL.str = yyDollar[1].str
 		}
-	case 147:
+	case 192:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:934
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 193:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:939
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 194:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:940
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 195:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:946
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 196:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:947
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 197:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:948
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 198:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:949
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 199:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:950
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 200:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:951
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 201:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:952
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 202:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:953
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 203:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:954
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 204:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:955
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 205:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:956
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 206:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:957
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 207:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:958
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 208:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:959
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 209:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:960
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 210:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:961
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 211:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:962
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 212:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:968
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 213:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:969
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 214:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:970
+		{
+			yyVAL.str = yyDollar[1].str
+		}
+	case 215:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:653
+//line partiql.y:973
 		{
 			yyVAL.expr = nil
 		}
-	case 148:
+	case 216:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:654
+//line partiql.y:974
 		{
 			yyVAL.expr = yyDollar[2].expr
 		}
-	case 149:
+	case 217:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:657
+//line partiql.y:977
 		{
 			yyVAL.limbs = []expr.CaseLimb{{When: yyDollar[2].expr, Then: yyDollar[4].expr}}
 		}
-	case 150:
+	case 218:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:658
+//line partiql.y:978
 		{
 			yyVAL.limbs = append(yyDollar[1].limbs, expr.CaseLimb{When: yyDollar[3].expr, Then: yyDollar[5].expr})
 		}
-	case 151:
+	case 219:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:661
+//line partiql.y:981
 		{
 			yyVAL.expr = nil
 		}
-	case 152:
+	case 220:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:662
+//line partiql.y:982
 		{
 			yyVAL.expr = yyDollar[1].expr
 		}
-	case 153:
+	case 221:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:665
+//line partiql.y:985
 		{
 			yyVAL.expr = nil
 		}
-	case 154:
+	case 222:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line partiql.y:666
+//line partiql.y:986
 		{
 			yyVAL.expr = yyDollar[4].expr
 		}
-	case 155:
+	case 223:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:669
+//line partiql.y:989
 		{
 			yyVAL.expr = nil
 		}
-	case 156:
+	case 224:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:670
+//line partiql.y:990
 		{
 			yyVAL.expr = yyDollar[2].expr
 		}
-	case 157:
+	case 225:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:673
+//line partiql.y:993
 		{
 			yyVAL.expr = nil
 		}
-	case 158:
+	case 226:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:674
+//line partiql.y:994
 		{
 			yyVAL.expr = yyDollar[2].expr
 		}
-	case 159:
+	case 227:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line partiql.y:997
+		{
+			yyVAL.expr = nil
+		}
+	case 228:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line partiql.y:998
+		{
+			yyVAL.expr = yyDollar[2].expr
+		}
+	case 229:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:677
+//line partiql.y:1001
 		{
 			yyVAL.bindings = nil
 		}
-	case 160:
+	case 230:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:678
+//line partiql.y:1002
 		{
 			yyVAL.bindings = yyDollar[3].bindings
 		}
-	case 161:
+	case 231:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:1005
+		{
+			yyVAL.bindings = []expr.Binding{yyDollar[1].bind}
+		}
+	case 232:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:1006
+		{
+			yyVAL.bindings = append(yyDollar[1].bindings, yyDollar[3].bind)
+		}
+	case 233:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:1011
+		{
+			yyVAL.bind = yyDollar[1].bind
+		}
+	case 234:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:1013
+		{
+			nod, ok := buildCollate(yyDollar[1].expr, yyDollar[3].str)
+			if !ok {
+				yylex.Error(__yyfmt__.Sprintf("unknown collation %q", yyDollar[3].str))
+			}
+			yyVAL.bind = expr.Bind(nod, "")
+		}
+	case 235:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line partiql.y:1021
+		{
+			nod, ok := buildCollate(yyDollar[1].expr, yyDollar[3].str)
+			if !ok {
+				yylex.Error(__yyfmt__.Sprintf("unknown collation %q", yyDollar[3].str))
+			}
+			yyVAL.bind = expr.Bind(nod, yyDollar[5].str)
+		}
+	case 236:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:682
+//line partiql.y:1031
 		{
 			yyVAL.yesno = false
 		}
-	case 162:
+	case 237:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:683
+//line partiql.y:1032
 		{
 			yyVAL.yesno = false
 		}
-	case 163:
+	case 238:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:684
+//line partiql.y:1033
 		{
 			yyVAL.yesno = true
 		}
-	case 164:
+	case 239:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:688
+//line partiql.y:1037
 		{
 			yyVAL.yesno = false
 		}
-	case 165:
+	case 240:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:689
+//line partiql.y:1038
 		{
 			yyVAL.yesno = false
 		}
-	case 166:
+	case 241:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:690
+//line partiql.y:1039
 		{
 			yyVAL.yesno = true
 		}
-	case 167:
+	case 242:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:694
+//line partiql.y:1043
 		{
 			yyVAL.order = expr.Order{Column: yyDollar[1].expr, Desc: yyDollar[2].yesno, NullsLast: yyDollar[3].yesno}
 		}
-	case 168:
+	case 243:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:697
+//line partiql.y:1046
 		{
 			yyVAL.orders = append(yyDollar[1].orders, yyDollar[3].order)
 		}
-	case 169:
+	case 244:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:698
+//line partiql.y:1047
 		{
 			yyVAL.orders = []expr.Order{yyDollar[1].order}
 		}
-	case 170:
+	case 245:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:701
+//line partiql.y:1050
 		{
 			yyVAL.orders = nil
 		}
-	case 171:
+	case 246:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line partiql.y:702
+//line partiql.y:1051
 		{
 			yyVAL.orders = yyDollar[3].orders
 		}
-	case 172:
+	case 247:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:705
+//line partiql.y:1054
 		{
 			yyVAL.exprint = nil
 		}
-	case 173:
+	case 248:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:706
+//line partiql.y:1055
 		{
 			n := expr.Integer(yyDollar[2].integer)
 			yyVAL.exprint = &n
 		}
-	case 174:
+	case 249:
 		yyDollar = yyS[yypt-0 : yypt+1]
-//line partiql.y:709
+//line partiql.y:1058
 		{
 			yyVAL.exprint = nil
 		}
-	case 175:
-		yyDollar = yyS[yypt-2 : yypt+1]
-//line partiql.y:710
+	case 250:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line partiql.y:1059
 		{
 			n := expr.Integer(yyDollar[2].integer)
 			yyVAL.exprint = &n
 		}
-	case 176:
+	case 251:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:1062
+		{
+		}
+	case 252:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line partiql.y:1062
+		{
+		}
+	case 253:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line partiql.y:1067
+		{
+			yyVAL.exprint = nil
+		}
+	case 254:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line partiql.y:1068
+		{
+			yyVAL.exprint = yyDollar[3].exprint
+		}
+	case 255:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line partiql.y:1070
+		{
+			yylex.Error("FETCH ... WITH TIES is not supported")
+			yyVAL.exprint = nil
+		}
+	case 256:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:1076
+		{
+		}
+	case 257:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:1076
+		{
+		}
+	case 258:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line partiql.y:1079
+		{
+			n := expr.Integer(yyDollar[1].integer)
+			yyVAL.exprint = &n
+		}
+	case 259:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line partiql.y:1080
+		{
+			n := expr.Integer(1)
+			yyVAL.exprint = &n
+		}
+	case 260:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line partiql.y:713
+//line partiql.y:1083
 		{ /*Cloning, as the buffer gets overwritten*/
 			as := yyDollar[4].str
 			at := yyDollar[6].str
 			yyVAL.expr = &expr.Unpivot{TupleRef: yyDollar[2].expr, As: &as, At: &at}
 		}
-	case 177:
+	case 261:
 		yyDollar = yyS[yypt-6 : yypt+1]
-//line partiql.y:714
+//line partiql.y:1084
 		{ /*Cloning, as the buffer gets overwritten*/
 			as := yyDollar[6].str
 			at := yyDollar[4].str
 			yyVAL.expr = &expr.Unpivot{TupleRef: yyDollar[2].expr, As: &as, At: &at}
 		}
-	case 178:
+	case 262:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:715
+//line partiql.y:1085
 		{ /*Cloning, as the buffer gets overwritten*/
 			as := yyDollar[4].str
 			yyVAL.expr = &expr.Unpivot{TupleRef: yyDollar[2].expr, As: &as, At: nil}
 		}
-	case 179:
+	case 263:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line partiql.y:716
+//line partiql.y:1086
 		{ /*Cloning, as the buffer gets overwritten*/
 			at := yyDollar[4].str
 			yyVAL.expr = &expr.Unpivot{TupleRef: yyDollar[2].expr, As: nil, At: &at}
 		}
-	case 180:
+	case 264:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:719
+//line partiql.y:1089
 		{
 			yyVAL.expr = &expr.Table{Binding: expr.Bind(yyDollar[1].expr, "")}
 		}
-	case 181:
+	case 265:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:723
+//line partiql.y:1093
 		{
 			yyVAL.integer = trimLeading
 		}
-	case 182:
+	case 266:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:724
+//line partiql.y:1094
 		{
 			yyVAL.integer = trimTrailing
 		}
-	case 183:
+	case 267:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line partiql.y:725
+//line partiql.y:1095
 		{
 			yyVAL.integer = trimBoth
 		}
@@ -45,19 +45,56 @@ func (c *CTE) Equals(other *CTE) bool {
 // Query contains a complete query.
 type Query struct {
 	Explain ExplainFormat
+	// Describe is set if the query is
+	// 'DESCRIBE table', in which case
+	// Body is the table expression.
+	Describe bool
+	// Delete is set if the query is
+	// 'DELETE FROM table WHERE cond', in which
+	// case Body is 'SELECT * FROM table WHERE cond'
+	// (i.e. the query that produces the rows
+	// that are deleted).
+	Delete bool
+	// Create is set if the query is
+	// 'CREATE TABLE table AS query', in which
+	// case Into is the table that is created
+	// and Body is the query producing its rows.
+	// Unlike SELECT ... INTO, the table is
+	// created with exactly the given name,
+	// and only if it does not exist yet.
+	Create bool
 
 	With []CTE
 	// Into, if non-nil, is the INTO
 	// portion of Body when Body is
 	// a SELECT-FROM-WHERE that includes
-	// an INTO clause.
+	// an INTO clause, or the table created
+	// by CREATE TABLE (see Create).
 	Into Node
 	// Body is the body of the query.
 	// Body can be:
 	//   - A SELECT expression
 	//   - A UNION expression
 	//   - A UNION ALL expression
+	//
+	// Body is nil if the query is an EXECUTE
+	// or DEALLOCATE statement.
 	Body Node
+
+	// Prepare, if set, is the name of the
+	// prepared statement if the query is
+	// 'PREPARE name AS query', in which case
+	// the rest of the fields describe the query
+	// that is prepared.
+	Prepare string
+	// Execute, if set, is the name of the prepared
+	// statement executed by 'EXECUTE name USING ...',
+	// and Using holds the values of its parameters.
+	Execute string
+	Using   []Constant
+	// Deallocate, if set, is the name of the
+	// prepared statement removed by 'DEALLOCATE name'.
+	Deallocate string
 }
 
 // Text returns the unredacted query text.
@@ -73,6 +110,64 @@ func (q *Query) Text() string {
 }
 
 func (q *Query) text(dst *strings.Builder, redact bool) {
+	if q.Deallocate != "" {
+		dst.WriteString("DEALLOCATE ")
+		dst.WriteString(QuoteID(q.Deallocate))
+		return
+	}
+	if q.Execute != "" {
+		dst.WriteString("EXECUTE ")
+		dst.WriteString(QuoteID(q.Execute))
+		for i := range q.Using {
+			if i == 0 {
+				dst.WriteString(" USING ")
+			} else {
+				dst.WriteString(", ")
+			}
+			q.Using[i].text(dst, redact)
+		}
+		return
+	}
+	if q.Prepare != "" {
+		dst.WriteString("PREPARE ")
+		dst.WriteString(QuoteID(q.Prepare))
+		params := q.Params()
+		declared := false
+		for i := range params {
+			declared = declared || params[i].Declared != 0
+		}
+		if declared {
+			dst.WriteString(" (")
+			for i := range params {
+				if i != 0 {
+					dst.WriteString(", ")
+				}
+				dst.WriteString(params[i].typeName())
+			}
+			dst.WriteByte(')')
+		}
+		dst.WriteString(" AS ")
+	}
+	if q.Describe {
+		dst.WriteString("DESCRIBE ")
+		q.Body.text(dst, redact)
+		return
+	}
+	if q.Delete {
+		s := q.Body.(*Select)
+		dst.WriteString("DELETE FROM ")
+		s.From.text(dst, redact)
+		dst.WriteString(" WHERE ")
+		s.Where.text(dst, redact)
+		return
+	}
+	into := q.Into
+	if q.Create {
+		dst.WriteString("CREATE TABLE ")
+		q.Into.text(dst, redact)
+		dst.WriteString(" AS ")
+		into = nil
+	}
 	switch q.Explain {
 	case ExplainDefault:
 		dst.WriteString("EXPLAIN ")
@@ -96,7 +191,7 @@ func (q *Query) text(dst *strings.Builder, redact bool) {
 	}
 	if s, ok := q.Body.(*Select); ok {
 		// do not parenthesize final SELECT
-		s.write(dst, redact, q.Into)
+		s.write(dst, redact, into)
 	} else {
 		q.Body.text(dst, redact)
 	}
@@ -114,15 +209,23 @@ func (q *Query) Redacted() string {
 // are syntactically equivalent queries,
 // or false otherwise.
 func (q *Query) Equals(other *Query) bool {
-	if len(q.With) != len(other.With) {
+	if q.Describe != other.Describe || q.Delete != other.Delete || q.Create != other.Create ||
+		len(q.With) != len(other.With) ||
+		q.Prepare != other.Prepare || q.Execute != other.Execute ||
+		q.Deallocate != other.Deallocate || len(q.Using) != len(other.Using) {
 		return false
 	}
+	for i := range q.Using {
+		if !q.Using[i].Equals(other.Using[i]) {
+			return false
+		}
+	}
 	for i := range q.With {
 		if !q.With[i].Equals(&other.With[i]) {
 			return false
 		}
 	}
-	return q.Body.Equals(other.Body)
+	return Equal(q.Body, other.Body)
 }
 
 // Encode encodes a query as an Ion structure
@@ -138,6 +241,19 @@ func (q *Query) Encode(dst *ion.Buffer, st *ion.Symtab) {
 	field("explain")
 	dst.WriteInt(int64(q.Explain))
 
+	if q.Describe {
+		field("describe")
+		dst.WriteBool(true)
+	}
+	if q.Delete {
+		field("delete")
+		dst.WriteBool(true)
+	}
+	if q.Create {
+		field("create")
+		dst.WriteBool(true)
+	}
+
 	if len(q.With) > 0 {
 		field("with")
 		dst.BeginList(-1)
@@ -151,8 +267,28 @@ func (q *Query) Encode(dst *ion.Buffer, st *ion.Symtab) {
 		field("into")
 		q.Into.Encode(dst, st)
 	}
-	field("body")
-	q.Body.Encode(dst, st)
+	if q.Body != nil {
+		field("body")
+		q.Body.Encode(dst, st)
+	}
+	if q.Prepare != "" {
+		field("prepare")
+		dst.WriteString(q.Prepare)
+	}
+	if q.Execute != "" {
+		field("execute")
+		dst.WriteString(q.Execute)
+		field("using")
+		dst.BeginList(-1)
+		for i := range q.Using {
+			q.Using[i].Encode(dst, st)
+		}
+		dst.EndList()
+	}
+	if q.Deallocate != "" {
+		field("deallocate")
+		dst.WriteString(q.Deallocate)
+	}
 	dst.EndStruct()
 }
 
@@ -169,7 +305,7 @@ func DecodeQuery(d ion.Datum) (*Query, error) {
 	if err != nil {
 		return nil, err
 	}
-	if q.Body == nil {
+	if q.Body == nil && q.Execute == "" && q.Deallocate == "" {
 		return nil, fmt.Errorf(`DecodeQuery: missing "body" field`)
 	}
 	return q, nil
@@ -185,6 +321,12 @@ func (q *Query) SetField(f ion.Field) error {
 			return err
 		}
 		q.Explain = ExplainFormat(v)
+	case "describe":
+		q.Describe, err = f.Bool()
+	case "delete":
+		q.Delete, err = f.Bool()
+	case "create":
+		q.Create, err = f.Bool()
 	case "with":
 		hastable := false
 		var table string
@@ -218,6 +360,25 @@ func (q *Query) SetField(f ion.Field) error {
 		q.Into, err = Decode(f.Datum)
 	case "body":
 		q.Body, err = Decode(f.Datum)
+	case "prepare":
+		q.Prepare, err = f.String()
+	case "execute":
+		q.Execute, err = f.String()
+	case "deallocate":
+		q.Deallocate, err = f.String()
+	case "using":
+		err = f.UnpackList(func(d ion.Datum) error {
+			node, err := Decode(d)
+			if err != nil {
+				return err
+			}
+			c, ok := node.(Constant)
+			if !ok {
+				return fmt.Errorf("DecodeQuery: expected a constant parameter value, got %T", node)
+			}
+			q.Using = append(q.Using, c)
+			return nil
+		})
 	default:
 		err = fmt.Errorf("DecodeQuery: unknown field %q", f.Label)
 	}
@@ -226,6 +387,12 @@ func (q *Query) SetField(f ion.Field) error {
 
 // CheckHint checks consistency of the whole query using a hint
 func (q *Query) CheckHint(h Hint) error {
+	if q.Execute != "" {
+		return nil // checked once the parameters are bound
+	}
+	if q.Deallocate != "" {
+		return nil
+	}
 	with := map[string]Node{}
 	for i := range q.With {
 		name := q.With[i].Table
@@ -255,10 +422,18 @@ func (q *Query) Check() error {
 // See also [Copy].
 func (q *Query) Clone() *Query {
 	ret := &Query{
-		Explain: q.Explain,
-		With:    slices.Clone(q.With),
-		Into:    Copy(q.Into),
-		Body:    Copy(q.Body),
+		Explain:  q.Explain,
+		Describe: q.Describe,
+		Delete:   q.Delete,
+		Create:   q.Create,
+		With:     slices.Clone(q.With),
+		Into:     Copy(q.Into),
+		Body:     Copy(q.Body),
+		Prepare:  q.Prepare,
+		Execute:  q.Execute,
+		Using:    slices.Clone(q.Using),
+
+		Deallocate: q.Deallocate,
 	}
 	for i := range ret.With {
 		ret.With[i].As = Copy(ret.With[i].As).(*Select)
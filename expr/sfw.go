@@ -89,6 +89,11 @@ func DefaultBinding(e Node) string {
 			e = v.Inner
 		case *Aggregate:
 			return v.Op.defaultResult()
+		case *Builtin:
+			if v.Func != CollateCI {
+				return ""
+			}
+			e = v.Args[0]
 		default:
 			return ""
 		}
@@ -228,6 +233,17 @@ type Join struct {
 	On    Node
 	Left  From    // left table expression; can be another join
 	Right Binding // right binding
+	// Using is the list of columns of
+	// JOIN ... USING (columns) or of
+	// a NATURAL JOIN; On is the equivalent
+	// equi-join predicate (see JoinUsing)
+	Using []string
+	// Natural is set for NATURAL JOIN
+	Natural bool
+	// Lateral is set for a CROSS JOIN
+	// of a LATERAL sub-query, which may
+	// reference the tables in Left
+	Lateral bool
 }
 
 func (j *Join) Tables() []Binding {
@@ -251,7 +267,10 @@ func (j *Join) rewrite(r Rewriter) Node {
 
 func (j *Join) Equals(x Node) bool {
 	xj, ok := x.(*Join)
-	if !ok || xj.Kind != j.Kind {
+	if !ok || xj.Kind != j.Kind || xj.Natural != j.Natural || xj.Lateral != j.Lateral {
+		return false
+	}
+	if !slices.Equal(j.Using, xj.Using) {
 		return false
 	}
 	if (j.On == nil) != (xj.On == nil) {
@@ -275,6 +294,22 @@ func (j *Join) Encode(dst *ion.Buffer, st *ion.Symtab) {
 		dst.BeginField(st.Intern("on"))
 		j.On.Encode(dst, st)
 	}
+	if len(j.Using) > 0 {
+		dst.BeginField(st.Intern("using"))
+		dst.BeginList(-1)
+		for i := range j.Using {
+			dst.WriteString(j.Using[i])
+		}
+		dst.EndList()
+	}
+	if j.Natural {
+		dst.BeginField(st.Intern("natural"))
+		dst.WriteBool(true)
+	}
+	if j.Lateral {
+		dst.BeginField(st.Intern("lateral"))
+		dst.WriteBool(true)
+	}
 	if j.Left != nil {
 		dst.BeginField(st.Intern("left"))
 		j.Left.Encode(dst, st)
@@ -298,6 +333,21 @@ func (j *Join) SetField(f ion.Field) error {
 	case "on":
 		j.On, err = Decode(f.Datum)
 		return err
+	case "using":
+		return f.UnpackList(func(d ion.Datum) error {
+			str, err := d.String()
+			if err != nil {
+				return err
+			}
+			j.Using = append(j.Using, str)
+			return nil
+		})
+	case "natural":
+		j.Natural, err = f.Bool()
+		return err
+	case "lateral":
+		j.Lateral, err = f.Bool()
+		return err
 	case "left":
 		e, err := Decode(f.Datum)
 		if err != nil {
@@ -329,10 +379,28 @@ func (j *Join) SetField(f ion.Field) error {
 func (j *Join) text(out *strings.Builder, redact bool) {
 	j.Left.text(out, redact)
 	out.WriteString(" ")
+	if j.Natural {
+		out.WriteString("NATURAL ")
+	}
 	out.WriteString(j.Kind.String())
 	out.WriteString(" ")
+	if j.Lateral {
+		out.WriteString("LATERAL ")
+	}
 	j.Right.text(out, redact)
-	if j.On != nil {
+	switch {
+	case j.Natural:
+		// the columns are implied
+	case len(j.Using) > 0:
+		out.WriteString(" USING (")
+		for i := range j.Using {
+			if i > 0 {
+				out.WriteString(", ")
+			}
+			out.WriteString(QuoteID(j.Using[i]))
+		}
+		out.WriteString(")")
+	case j.On != nil:
 		out.WriteString(" ON ")
 		j.On.text(out, redact)
 	}
@@ -383,6 +451,8 @@ type Select struct {
 	GroupBy []Binding
 	// HAVING clause, or nil
 	Having Node
+	// QUALIFY clause, or nil
+	Qualify Node
 	// ORDER BY clauses, or nil
 	OrderBy []Order
 	// When OrderBy is non-nil,
@@ -412,6 +482,9 @@ func (s *Select) walk(v Visitor) {
 	for i := range s.Columns {
 		walkbind(v, &s.Columns[i])
 	}
+	if s.Qualify != nil {
+		Walk(v, s.Qualify)
+	}
 	for i := range s.OrderBy {
 		Walk(v, s.OrderBy[i].Column)
 	}
@@ -448,6 +521,7 @@ func (s *Select) rewrite(r Rewriter) Node {
 	}
 	s.Where = Rewrite(r, s.Where)
 	s.Having = Rewrite(r, s.Having)
+	s.Qualify = Rewrite(r, s.Qualify)
 	for i := range s.GroupBy {
 		s.GroupBy[i] = rewritebind(r, &s.GroupBy[i])
 	}
@@ -466,6 +540,7 @@ func (s *Select) Equals(x Node) bool {
 		(s.From == nil) != (xs.From == nil) ||
 		(s.Where == nil) != (xs.Where == nil) ||
 		(s.Having == nil) != (xs.Having == nil) ||
+		(s.Qualify == nil) != (xs.Qualify == nil) ||
 		(s.Limit == nil) != (xs.Limit == nil) ||
 		(s.Offset == nil) != (xs.Offset == nil) ||
 		(s.Distinct != xs.Distinct) {
@@ -486,6 +561,9 @@ func (s *Select) Equals(x Node) bool {
 	if s.Having != nil && !s.Having.Equals(xs.Having) {
 		return false
 	}
+	if s.Qualify != nil && !s.Qualify.Equals(xs.Qualify) {
+		return false
+	}
 	if !slices.EqualFunc(s.OrderBy, xs.OrderBy, Order.Equals) {
 		return false
 	}
@@ -545,6 +623,7 @@ func (s *Select) Encode(dst *ion.Buffer, st *ion.Symtab) {
 	addfield(dst, st, "from", s.From)
 	addfield(dst, st, "where", s.Where)
 	addfield(dst, st, "having", s.Having)
+	addfield(dst, st, "qualify", s.Qualify)
 	if len(s.GroupBy) > 0 {
 		dst.BeginField(st.Intern("group_by"))
 		EncodeBindings(s.GroupBy, dst, st)
@@ -640,6 +719,10 @@ func (s *Select) write(out *strings.Builder, redact bool, into Node) {
 		out.WriteString(" HAVING ")
 		s.Having.text(out, redact)
 	}
+	if s.Qualify != nil {
+		out.WriteString(" QUALIFY ")
+		s.Qualify.text(out, redact)
+	}
 	if s.OrderBy != nil {
 		out.WriteString(" ORDER BY ")
 		for i := range s.OrderBy {
@@ -787,6 +870,8 @@ func (s *Select) SetField(f ion.Field) error {
 		s.Where, err = Decode(f.Datum)
 	case "having":
 		s.Having, err = Decode(f.Datum)
+	case "qualify":
+		s.Qualify, err = Decode(f.Datum)
 	case "group_by":
 		s.GroupBy, err = decodeBindings(f.Datum)
 	case "order_by":
@@ -18,6 +18,7 @@ import (
 	"math/big"
 	"strings"
 
+	"github.com/SnellerInc/sneller/date"
 	"github.com/SnellerInc/sneller/ion"
 )
 
@@ -60,6 +61,10 @@ const (
 	DecimalType TypeSet = (1 << ion.DecimalType)
 	SymbolType  TypeSet = (1 << ion.SymbolType)
 	NullType    TypeSet = (1 << ion.NullType)
+	BlobType    TypeSet = (1 << ion.BlobType)
+	// SortableType is the set of types that
+	// are ordered relative to one another by ORDER BY
+	SortableType TypeSet = NullType | BoolType | NumericType | TimeType | StringType | SymbolType
 )
 
 // Only returns whether or not t
@@ -481,6 +486,12 @@ func isUpper(s string) bool {
 	return strings.ToUpper(s) == s
 }
 
+// isoWeek returns the ISO 8601 week number of t
+func isoWeek(t date.Time) int {
+	_, week := t.Time().ISOWeek()
+	return week
+}
+
 func constmath(op ArithOp, left, right *big.Rat) Node {
 	out := new(big.Rat)
 	switch op {
@@ -600,7 +611,10 @@ func roundBigRat(value *big.Rat, op roundOp) *big.Rat {
 	return new(big.Rat).SetFrac(p, one)
 }
 
-func simplifyRoundOp(h Hint, args []Node, op roundOp) Node {
+func simplifyRoundOp(h Hint, args []Node, fn BuiltinOp, op roundOp) Node {
+	if len(args) == 2 {
+		return simplifyRoundDigits(h, args, fn, op)
+	}
 	if len(args) != 1 {
 		return nil
 	}
@@ -613,11 +627,88 @@ func simplifyRoundOp(h Hint, args []Node, op roundOp) Node {
 	return nil
 }
 
-func simplifyRound(h Hint, args []Node) Node     { return simplifyRoundOp(h, args, roundNearestOp) }
-func simplifyRoundEven(h Hint, args []Node) Node { return simplifyRoundOp(h, args, roundEvenOp) }
-func simplifyTrunc(h Hint, args []Node) Node     { return simplifyRoundOp(h, args, roundTruncOp) }
-func simplifyFloor(h Hint, args []Node) Node     { return simplifyRoundOp(h, args, roundFloorOp) }
-func simplifyCeil(h Hint, args []Node) Node      { return simplifyRoundOp(h, args, roundCeilOp) }
+// simplifyRoundDigits rewrites ROUND(x, digits)
+// into ROUND(x * 10^digits) / 10^digits, or into
+// ROUND(x / 10^-digits) * 10^-digits when digits
+// is negative (i.e. rounding to tens, hundreds, ...)
+func simplifyRoundDigits(h Hint, args []Node, fn BuiltinOp, op roundOp) Node {
+	digits, ok := args[1].(Integer)
+	if !ok || digits < -maxRoundDigits || digits > maxRoundDigits {
+		return nil
+	}
+	if digits == 0 {
+		if n := simplifyRoundOp(h, args[:1], fn, op); n != nil {
+			return n
+		}
+		return Call(fn, args[0])
+	}
+	neg := digits < 0
+	if neg {
+		digits = -digits
+	}
+	scale := Integer(1)
+	for i := Integer(0); i < digits; i++ {
+		scale *= 10
+	}
+
+	arg := missingUnless(args[0], h, NumericType)
+	if miss(arg, h) {
+		return Missing{}
+	}
+	if cn := asrational(arg); cn != nil {
+		r := new(big.Rat).SetInt64(int64(scale))
+		if neg {
+			return (*Rational)(r.Mul(roundBigRat(new(big.Rat).Quo(cn, r), op), r))
+		}
+		return (*Rational)(r.Quo(roundBigRat(new(big.Rat).Mul(cn, r), op), r))
+	}
+	if neg {
+		return Mul(Call(fn, Div(arg, scale)), scale)
+	}
+	// a number that is at least 2^52 / 10^digits
+	// in magnitude has no digits to round, and
+	// scaling it up and back down again would only
+	// lose precision (or overflow, for integers);
+	// anything that isn't a number is MISSING
+	limit := Float(float64(1<<52) / float64(scale))
+	return &Case{
+		Limbs: []CaseLimb{{
+			When: Compare(Less, Call(Abs, arg), limit),
+			Then: Div(Call(fn, Mul(arg, scale)), scale),
+		}, {
+			When: Compare(GreaterEquals, Call(Abs, arg), limit),
+			Then: arg,
+		}},
+		Else: Missing{},
+	}
+}
+
+func simplifyRound(h Hint, args []Node) Node {
+	return simplifyRoundOp(h, args, Round, roundNearestOp)
+}
+
+func simplifyRoundEven(h Hint, args []Node) Node {
+	return simplifyRoundOp(h, args, RoundEven, roundEvenOp)
+}
+
+func simplifyTrunc(h Hint, args []Node) Node {
+	return simplifyRoundOp(h, args, Trunc, roundTruncOp)
+}
+
+func simplifyFloor(h Hint, args []Node) Node {
+	return simplifyRoundOp(h, args, Floor, roundFloorOp)
+}
+
+func simplifyCeil(h Hint, args []Node) Node {
+	return simplifyRoundOp(h, args, Ceil, roundCeilOp)
+}
+
+func simplifyModulo(h Hint, args []Node) Node {
+	if len(args) != 2 {
+		return nil
+	}
+	return Mod(args[0], args[1]).simplify(h)
+}
 
 func simplifyPmod(h Hint, args []Node) Node {
 	if len(args) != 2 {
@@ -727,7 +818,20 @@ func (a *Arithmetic) canonical(h Hint) *Arithmetic {
 	return a
 }
 
+// istime returns whether e is known
+// to be a timestamp (or MISSING)
+func istime(e Node, h Hint) bool {
+	t := TypeOf(e, h)
+	return t&TimeType != 0 && t&^(TimeType|MissingType) == 0
+}
+
 func (a *Arithmetic) simplify(h Hint) Node {
+	// the difference of two timestamps is the
+	// interval between them in microseconds
+	if a.Op == SubOp && (istime(a.Left, h) || istime(a.Right, h)) &&
+		TypeOf(a.Left, h)&TimeType != 0 && TypeOf(a.Right, h)&TimeType != 0 {
+		return DateDiff(Microsecond, a.Right, a.Left)
+	}
 	a.Left = missingUnless(a.Left, h, NumericType)
 	a.Right = missingUnless(a.Right, h, NumericType)
 
@@ -823,6 +927,10 @@ func (a *Aggregate) simplify(h Hint) Node {
 		variance := Sub(avgSQ, Mul(avgS, avgS))
 		stddev := Call(Sqrt, variance)
 		return IfThenElse(Compare(Equals, cnt, Integer(0)), Null{}, stddev)
+	case OpCovarPop, OpCovarSamp, OpCorr, OpRegrSlope, OpRegrIntercept:
+		if n := a.bivariate(); n != nil {
+			return n
+		}
 	case OpMin, OpMax, OpSum, OpAvg:
 		a.Inner = missingUnless(a.Inner, h, NumericType)
 	}
@@ -909,6 +1017,53 @@ func (c *Case) isTrue(h Hint) Node {
 	return c.simplify(h)
 }
 
+// bivariate rewrites a bivariate aggregate into arithmetic
+// on the sums of its two arguments, like VARIANCE, or
+// returns nil if a is not a bivariate aggregate
+//
+// Only the rows where both arguments are numbers are counted.
+// For REGR_SLOPE(y, x) and REGR_INTERCEPT(y, x), the first
+// argument is the dependent variable.
+func (a *Aggregate) bivariate() Node {
+	args, ok := a.Pair()
+	if !ok {
+		return nil
+	}
+	x, y := args[0], args[1]
+	var both Node = Is(Mul(x, y), IsNotMissing)
+	if a.Filter != nil {
+		both = And(a.Filter, both)
+	}
+	sum := func(e Node) Node {
+		return &Aggregate{Op: OpSum, Inner: e, Filter: both}
+	}
+	cnt := &Aggregate{Op: OpCount, Inner: Mul(x, y), Filter: a.Filter}
+	sx, sy := sum(x), sum(y)
+	// the covariance and the variances times cnt²
+	cov := Sub(Mul(cnt, sum(Mul(x, y))), Mul(sx, sy))
+	varx := Sub(Mul(cnt, sum(Mul(x, x))), Mul(sx, sx))
+	vary := Sub(Mul(cnt, sum(Mul(y, y))), Mul(sy, sy))
+	none := Compare(Equals, cnt, Integer(0))
+	switch a.Op {
+	case OpCovarPop:
+		return IfThenElse(none, Null{}, Div(cov, Mul(cnt, cnt)))
+	case OpCovarSamp:
+		return IfThenElse(Compare(Less, cnt, Integer(2)), Null{},
+			Div(cov, Mul(cnt, Sub(cnt, Integer(1)))))
+	case OpCorr:
+		flat := Or(Compare(LessEquals, varx, Integer(0)), Compare(LessEquals, vary, Integer(0)))
+		return IfThenElse(Or(none, flat), Null{}, Div(cov, Call(Sqrt, Mul(varx, vary))))
+	case OpRegrSlope:
+		flat := Compare(LessEquals, vary, Integer(0))
+		return IfThenElse(Or(none, flat), Null{}, Div(cov, vary))
+	case OpRegrIntercept:
+		flat := Compare(LessEquals, vary, Integer(0))
+		slope := Div(cov, vary)
+		return IfThenElse(Or(none, flat), Null{}, Div(Sub(sx, Mul(sy, slope)), cnt))
+	}
+	return nil
+}
+
 // missingUnless simplifies a node
 // taking into account that the calling
 // expression will be MISSING unless
@@ -921,6 +1076,7 @@ func missingUnless(e Node, h Hint, want TypeSet) Node {
 	}
 	var match Node
 	var matchn int
+	guarded := len(c.Limbs) > 0 && isNotNullOf(c.Limbs[0].When, c.Limbs[0].Then)
 	for i := range c.Limbs {
 		t := TypeOf(c.Limbs[i].Then, h)
 		if t&want == 0 {
@@ -943,15 +1099,26 @@ func missingUnless(e Node, h Hint, want TypeSet) Node {
 		}
 		matchn++
 	}
-	// if there is only one non-missing clause,
-	// then simply evaluate that clause, since
-	// it is the only semantically meaningful one
-	if matchn == 1 {
+	// if the only non-missing clause is the first one
+	// and it is only taken when its result is not NULL
+	// (CASE WHEN x IS NOT NULL THEN x ...), then simply
+	// evaluate that clause, since x is not a member of
+	// 'want' whenever one of the other clauses is taken;
+	// otherwise the conditions still choose between
+	// the result and MISSING
+	if matchn == 1 && guarded && match == c.Limbs[0].Then {
 		return match
 	}
 	return c.simplify(h)
 }
 
+// isNotNullOf returns whether when is
+// 'then IS NOT NULL' or 'then IS NOT MISSING'
+func isNotNullOf(when, then Node) bool {
+	is, ok := when.(*IsKey)
+	return ok && (is.Key == IsNotNull || is.Key == IsNotMissing) && is.Expr.Equals(then)
+}
+
 func (c *Case) toHashLookup() (*Lookup, bool) {
 	if len(c.Limbs) < 10 {
 		// likely not profitable
@@ -1060,10 +1227,16 @@ func converts(to TypeSet) TypeSet {
 	case BoolType:
 		// we support int->bool, float->bool and bool->bool
 		return IntegerType | FloatType | BoolType
-	case FloatType, IntegerType:
+	case FloatType:
 		// we support conversion to/from
 		// floats, ints, and bools (zero = false, otherwise true)
 		return FloatType | IntegerType | BoolType
+	case IntegerType:
+		// as above, plus timestamp->epoch milliseconds
+		return FloatType | IntegerType | BoolType | TimeType
+	case TimeType:
+		// we support epoch milliseconds->timestamp
+		return TimeType | IntegerType
 	case StringType:
 		// we support int->string
 		return IntegerType | StringType | BoolType
@@ -1074,6 +1247,25 @@ func converts(to TypeSet) TypeSet {
 	}
 }
 
+// the range of epoch milliseconds that
+// CAST(... AS TIMESTAMP) accepts (years 1 through 9999)
+var (
+	minEpochMillis = epochMillis(date.Date(1, 1, 1, 0, 0, 0, 0))
+	maxEpochMillis = epochMillis(date.Date(9999, 12, 31, 23, 59, 59, 999000000))
+)
+
+// epochMillis returns the number of milliseconds
+// between the Unix epoch and t, truncating any
+// sub-millisecond part towards the earlier millisecond
+func epochMillis(t date.Time) Integer {
+	us := t.UnixMicro()
+	ms := us / 1000
+	if us%1000 < 0 {
+		ms--
+	}
+	return Integer(ms)
+}
+
 func (c *Cast) simplify(h Hint) Node {
 	// discard any part of the input expression
 	// that produces a result we cannot cast
@@ -1129,6 +1321,19 @@ func (c *Cast) simplify(h Hint) Node {
 			}
 			return Integer(0)
 		}
+		if ts, ok := c.From.(*Timestamp); ok {
+			return epochMillis(ts.Value)
+		}
+	}
+
+	// literal timestamp conversion constprop
+	if c.To == TimeType {
+		if i, ok := c.From.(Integer); ok {
+			if i < minEpochMillis || i > maxEpochMillis {
+				return Missing{}
+			}
+			return &Timestamp{Value: date.UnixMicro(int64(i) * 1000)}
+		}
 	}
 
 	// literal string conversion constprop
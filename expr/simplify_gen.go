@@ -368,6 +368,13 @@ func simplifyClass1(src *Builtin, h Hint) Node {
 				return Integer(x.Value.Day())
 			}
 		}
+	case DateExtractEpoch:
+		if len(src.Args) == 1 {
+			// (date_extract_epoch (ts x)) -> (float "float64(x.Value.UnixMicro()) / 1000000")
+			if x, ok := (src.Args[0]).(*Timestamp); ok {
+				return Float(float64(x.Value.UnixMicro()) / 1000000)
+			}
+		}
 	case DateExtractHour:
 		if len(src.Args) == 1 {
 			// (date_extract_hour (ts x)) -> (int "x.Value.Hour()")
@@ -417,6 +424,13 @@ func simplifyClass1(src *Builtin, h Hint) Node {
 				return Integer(x.Value.Second())
 			}
 		}
+	case DateExtractWeek:
+		if len(src.Args) == 1 {
+			// (date_extract_week (ts x)) -> (int "isoWeek(x.Value)")
+			if x, ok := (src.Args[0]).(*Timestamp); ok {
+				return Integer(isoWeek(x.Value))
+			}
+		}
 	case DateExtractYear:
 		if len(src.Args) == 1 {
 			// (date_extract_year (ts x)) -> (int "x.Value.Year()")
@@ -1162,4 +1176,4 @@ func simplify1(src Node, h Hint) Node {
 	return nil
 }
 
-// checksum: edc905b3969e625001fe97cd6fb5537e
+// checksum: 0370477f8eb4000d30b6ef11552ebef9
@@ -75,6 +75,16 @@ func TestSimplify(t *testing.T) {
 	testcases := []struct {
 		before, after Node
 	}{
+		{
+			// the difference of timestamps
+			Sub(path("x"), ts("2023-01-02T03:04:05Z")),
+			DateDiff(Microsecond, ts("2023-01-02T03:04:05Z"), path("x")),
+		},
+		{
+			// a timestamp minus a number is not a difference of timestamps
+			Sub(ts("2023-01-02T03:04:05Z"), Integer(5)),
+			Sub(ts("2023-01-02T03:04:05Z"), Integer(5)),
+		},
 		{
 			// Or(x, x) -> x
 			Or(Is(path("t", "x"), IsNull), Is(path("t", "x"), IsNull)),
@@ -248,6 +258,59 @@ func TestSimplify(t *testing.T) {
 			Call(Ceil, Float(-3.9)),
 			Float(-3.0),
 		},
+		{
+			Call(Round, Float(1234.5678), Integer(2)),
+			Float(1234.57),
+		},
+		{
+			Call(Round, Float(1234.5678), Integer(-2)),
+			Float(1200.0),
+		},
+		{
+			Call(Floor, Integer(-1234), Integer(-1)),
+			Float(-1240.0),
+		},
+		{
+			Call(Ceil, Float(3.21), Integer(1)),
+			Float(3.3),
+		},
+		{
+			Call(Round, path("x"), Integer(0)),
+			Call(Round, path("x")),
+		},
+		{
+			Call(Round, path("x"), Integer(2)),
+			&Case{
+				Limbs: []CaseLimb{{
+					When: Compare(Less, Call(Abs, path("x")), Float(float64(1<<52)/100)),
+					Then: Div(Call(Round, Mul(path("x"), Integer(100))), Integer(100)),
+				}, {
+					When: Compare(GreaterEquals, Call(Abs, path("x")), Float(float64(1<<52)/100)),
+					Then: path("x"),
+				}},
+				Else: Missing{},
+			},
+		},
+		{
+			Call(Round, path("x"), Integer(-3)),
+			Mul(Call(Round, Div(path("x"), Integer(1000))), Integer(1000)),
+		},
+		{
+			Call(Modulo, Integer(7), Integer(3)),
+			Integer(1),
+		},
+		{
+			Call(Modulo, Integer(7), Integer(0)),
+			Missing{},
+		},
+		{
+			Call(Modulo, path("x"), Integer(3)),
+			Mod(path("x"), Integer(3)),
+		},
+		{
+			Call(Sqrt, Integer(-4)),
+			Missing{},
+		},
 		{
 			// canonicalization:
 			//   3 < x -> x > 3
@@ -502,6 +565,27 @@ func TestSimplify(t *testing.T) {
 			&Cast{From: Integer(3), To: FloatType},
 			Float(3.0),
 		},
+		{
+			// sub-millisecond precision is truncated
+			&Cast{From: ts("2023-01-02T03:04:05.678999Z"), To: IntegerType},
+			Integer(1672628645678),
+		},
+		{
+			&Cast{From: ts("1969-12-31T23:59:59.9995Z"), To: IntegerType},
+			Integer(-1),
+		},
+		{
+			&Cast{From: Integer(1672628645678), To: TimeType},
+			ts("2023-01-02T03:04:05.678Z"),
+		},
+		{
+			&Cast{From: Integer(-62135596800001), To: TimeType},
+			Missing{},
+		},
+		{
+			&Cast{From: String("foo"), To: TimeType},
+			Missing{},
+		},
 		{
 			// expressions inside CAST should discard
 			// any portions of the calculation that
@@ -513,6 +597,15 @@ func TestSimplify(t *testing.T) {
 			DateExtract(Year, ts("2009-01-14T23:59:59Z")),
 			Integer(2009),
 		},
+		{
+			// 2010-01-03 is a Sunday in week 53 of 2009
+			DateExtract(Week, ts("2010-01-03T12:00:00Z")),
+			Integer(53),
+		},
+		{
+			Call(DateExtractEpoch, ts("1969-12-31T23:59:58.5Z")),
+			Float(-1.5),
+		},
 		{
 			DateTrunc(Month, ts("2009-01-14T23:59:59Z")),
 			ts("2009-01-01T00:00:00Z"),
@@ -598,10 +691,31 @@ func TestSimplify(t *testing.T) {
 			Call(Concat, String("xyz"), String("abc")),
 			String("xyzabc"),
 		},
+		{
+			Call(ConcatWs, String(", "), String("x"), Null{}, String("y"), Missing{}),
+			String("x, y"),
+		},
+		{
+			Call(ConcatWs, path("sep"), String("x"), Null{}, path("y")),
+			Call(ConcatWs, path("sep"), String("x"), path("y")),
+		},
+		{
+			Call(ConcatWs, String(", "), Null{}),
+			String(""),
+		},
 		{
 			Count(casen(Is(path("x"), IsNotMissing), Null{}, Missing{})),
 			Count(casen(Is(path("x"), IsNotMissing), Null{}, Missing{})),
 		},
+		{
+			// the condition still decides between y and MISSING
+			Sum(casen(path("c"), Missing{}, path("y"))),
+			Sum(casen(path("c"), Missing{}, path("y"))),
+		},
+		{
+			Add(casen(path("c"), Missing{}, path("y")), Integer(1)),
+			Add(casen(path("c"), Missing{}, path("y")), Integer(1)),
+		},
 		{
 			// COUNT(...) FILTER (WHERE false) => 0
 			&Aggregate{Op: OpCount, Inner: Star{}, Filter: Bool(false)},
@@ -836,6 +950,26 @@ func TestSimplify(t *testing.T) {
 			Call(TypeBit, Float(3.5)),
 			Integer(JSONTypeBits(ion.FloatType)),
 		},
+		{
+			Call(IsJSON, String(` {"a": [1, 2]} `)),
+			Bool(true),
+		},
+		{
+			Call(IsJSONArray, String(`{"a": [1, 2]}`)),
+			Bool(false),
+		},
+		{
+			Call(IsJSONObject, String(`{"a": }`)),
+			Bool(false),
+		},
+		{
+			Call(IsJSON, Integer(3)),
+			Bool(false),
+		},
+		{
+			Call(IsJSON, Missing{}),
+			Bool(false),
+		},
 		{
 			Is(Count(path("c")), IsNotMissing),
 			Bool(true),
@@ -992,6 +1126,21 @@ func TestSimplify(t *testing.T) {
 			&Index{Inner: mktestlist(String("a"), String("b"), String("c")), Offset: 1},
 			String("b"),
 		},
+		{
+			// ["a", "b", "c"][-1] => "c"
+			&Index{Inner: Call(MakeList, String("a"), String("b"), String("c")), Offset: -1},
+			String("c"),
+		},
+		{
+			// [x, "b", "c"][-2:] => ["b", "c"]
+			&Slice{Inner: Call(MakeList, path("x"), String("b"), String("c")), From: -2, ToEnd: true},
+			mktestlist(String("b"), String("c")),
+		},
+		{
+			// ["a", "b", "c"][0:4] => MISSING
+			&Slice{Inner: mktestlist(String("a"), String("b"), String("c")), To: 4},
+			Missing{},
+		},
 		{
 			// SELECT * FROM ... ORDER BY const1, ..., constN => drop ORDER BY
 			&Select{OrderBy: []Order{
@@ -1128,6 +1277,40 @@ func TestSimplify(t *testing.T) {
 			Mod((*Rational)(big.NewRat(8, 10)), (*Rational)(big.NewRat(43, 10))),
 			(*Rational)(big.NewRat(8, 10)),
 		},
+		{
+			Call(RegexpLike, path("x"), String("a+b")),
+			&StringMatch{Op: RegexpMatch, Expr: path("x"), Pattern: "a+b"},
+		},
+		{
+			Call(RegexpLike, path("x"), String("a+b"), String("ci")),
+			&StringMatch{Op: RegexpMatchCi, Expr: path("x"), Pattern: "a+b"},
+		},
+		{
+			Call(IsSubnetOf, String("192.168.1.7/20"), path("x")),
+			Call(IsSubnetOf, String("192.168.0.0"), String("192.168.15.255"), path("x")),
+		},
+		{
+			Call(IsSubnetOf, String("2001:db8::1/33"), path("x")),
+			Call(IsSubnetOf, String("2001:db8::"), String("2001:db8:7fff:ffff:ffff:ffff:ffff:ffff"), path("x")),
+		},
+		{
+			Call(IsSubnetOf, String("::ffff:10.0.0.0/104"), path("x")),
+			Call(IsSubnetOf, String("::ffff:10.0.0.0"), String("::ffff:10.255.255.255"), path("x")),
+		},
+		{
+			Call(IsSubnetOf, String("2001:db8::2"), String("2001:db8::1"), path("x")),
+			Bool(false),
+		},
+		{
+			// the bounds of the first period are swapped
+			Call(Overlaps, ts("2022-01-03T00:00:00Z"), ts("2022-01-01T00:00:00Z"), ts("2022-01-02T00:00:00Z"), ts("2022-01-04T00:00:00Z")),
+			Bool(true),
+		},
+		{
+			// periods that only touch do not overlap
+			Call(Overlaps, ts("2022-01-01T00:00:00Z"), ts("2022-01-02T00:00:00Z"), ts("2022-01-02T00:00:00Z"), ts("2022-01-04T00:00:00Z")),
+			Bool(false),
+		},
 	}
 
 	for i := range testcases {
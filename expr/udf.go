@@ -0,0 +1,212 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package expr
+
+import (
+	"encoding"
+	"strings"
+	"sync"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// Scalar is the implementation of a
+// user-defined scalar function.
+//
+// Scalar is called once for each row with the
+// values of the arguments; a MISSING argument
+// is passed as ion.Empty. The arguments are only
+// valid for the duration of the call. Scalar
+// should return ion.Empty to produce MISSING.
+// A returned error (or a panic) stops the query.
+type Scalar func(args []ion.Datum) (ion.Datum, error)
+
+// Signature describes the arguments and
+// the result of a user-defined scalar function.
+type Signature struct {
+	// Args is the set of types accepted
+	// by each of the arguments.
+	Args []TypeSet
+	// Ret is the set of types of the result.
+	// MissingType is always included.
+	Ret TypeSet
+}
+
+type scalarUDF struct {
+	fn   Scalar
+	info binfo
+}
+
+var (
+	scalarLock sync.RWMutex
+	scalars    = map[string]*scalarUDF{}
+)
+
+// RegisterScalar registers fn as the implementation
+// of the SQL function name with the given signature.
+// Function names are case-insensitive.
+//
+// The function must be registered in every process
+// that executes queries which call it.
+// RegisterScalar panics if name is the name of a
+// builtin function, if it has already been registered,
+// or if sig does not declare any arguments.
+func RegisterScalar(name string, sig Signature, fn Scalar) {
+	name = strings.ToUpper(name)
+	if name2Builtin(name) != Unspecified {
+		panic("expr.RegisterScalar: " + name + " is a builtin function")
+	}
+	if len(sig.Args) == 0 {
+		panic("expr.RegisterScalar: " + name + " has no arguments")
+	}
+	scalarLock.Lock()
+	defer scalarLock.Unlock()
+	if _, ok := scalars[name]; ok {
+		panic("expr.RegisterScalar: duplicate function " + name)
+	}
+	if _, ok := aggregates[name]; ok {
+		panic("expr.RegisterScalar: " + name + " is an aggregate function")
+	}
+	scalars[name] = &scalarUDF{
+		fn: fn,
+		info: binfo{
+			check: fixedArgs(sig.Args...),
+			ret:   sig.Ret | MissingType,
+		},
+	}
+}
+
+// unregisterScalar removes the function name;
+// it is used by tests to undo RegisterScalar
+func unregisterScalar(name string) {
+	scalarLock.Lock()
+	defer scalarLock.Unlock()
+	delete(scalars, strings.ToUpper(name))
+}
+
+func lookupScalar(name string) *scalarUDF {
+	scalarLock.RLock()
+	defer scalarLock.RUnlock()
+	return scalars[strings.ToUpper(name)]
+}
+
+// LookupScalar returns the implementation of
+// the user-defined scalar function name
+// (see RegisterScalar).
+func LookupScalar(name string) (Scalar, bool) {
+	u := lookupScalar(name)
+	if u == nil {
+		return nil, false
+	}
+	return u.fn, true
+}
+
+// AggregateState is the state of a user-defined
+// aggregate function. The state is marshaled
+// in order to pass the partial results of a query
+// executed by several nodes to the node that merges
+// them, so it must be able to unmarshal the result
+// of MarshalBinary into a state returned by Init.
+type AggregateState interface {
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// AggregateFunc is the implementation of a
+// user-defined aggregate function.
+//
+// The functions are called concurrently for different
+// states, but never concurrently for the same state.
+// A returned error (or a panic) stops the query.
+type AggregateFunc struct {
+	// Init returns a new state that has
+	// not accumulated any value.
+	Init func() AggregateState
+	// Accumulate adds the values of the lanes in mask to
+	// state; values[i] is the value of the argument of
+	// lane i, which is only valid if bit i of mask is set
+	// and for the duration of the call. Inactive lanes
+	// (those with a MISSING argument, filtered out by
+	// a FILTER clause, or beyond the end of the input)
+	// are never passed to Accumulate.
+	Accumulate func(state AggregateState, values []ion.Datum, mask uint16) error
+	// Merge adds the values accumulated by src to dst.
+	Merge func(dst, src AggregateState) error
+	// Finalize returns the result of the aggregate
+	// for state; it should return ion.Null if the
+	// result is unknown. Aggregates never produce
+	// MISSING, so ion.Empty also produces NULL.
+	// Structures and lists are not supported
+	// as results and stop the query.
+	Finalize func(state AggregateState) (ion.Datum, error)
+}
+
+var aggregates = map[string]*AggregateFunc{}
+
+// RegisterAggregate registers the user-defined
+// aggregate function name, which accepts one argument.
+// Function names are case-insensitive.
+// See AggregateFunc for the description of init,
+// accumulate, merge and finalize.
+//
+// The function must be registered in every process
+// that executes queries which call it.
+// RegisterAggregate panics if name is the name of
+// a builtin function, a keyword, or a function that
+// has already been registered.
+func RegisterAggregate(name string, init func() AggregateState,
+	accumulate func(state AggregateState, values []ion.Datum, mask uint16) error,
+	merge func(dst, src AggregateState) error,
+	finalize func(state AggregateState) (ion.Datum, error)) {
+	name = strings.ToUpper(name)
+	if name2Builtin(name) != Unspecified {
+		panic("expr.RegisterAggregate: " + name + " is a builtin function")
+	}
+	if IsKeyword != nil && IsKeyword(name) {
+		panic("expr.RegisterAggregate: " + name + " is a keyword")
+	}
+	scalarLock.Lock()
+	defer scalarLock.Unlock()
+	if _, ok := aggregates[name]; ok {
+		panic("expr.RegisterAggregate: duplicate function " + name)
+	}
+	if _, ok := scalars[name]; ok {
+		panic("expr.RegisterAggregate: " + name + " is a scalar function")
+	}
+	aggregates[name] = &AggregateFunc{
+		Init:       init,
+		Accumulate: accumulate,
+		Merge:      merge,
+		Finalize:   finalize,
+	}
+}
+
+// unregisterAggregate removes the function name;
+// it is used by tests to undo RegisterAggregate
+func unregisterAggregate(name string) {
+	scalarLock.Lock()
+	defer scalarLock.Unlock()
+	delete(aggregates, strings.ToUpper(name))
+}
+
+// LookupAggregate returns the implementation of
+// the user-defined aggregate function name
+// (see RegisterAggregate).
+func LookupAggregate(name string) (*AggregateFunc, bool) {
+	scalarLock.RLock()
+	defer scalarLock.RUnlock()
+	fn, ok := aggregates[strings.ToUpper(name)]
+	return fn, ok
+}
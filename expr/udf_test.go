@@ -0,0 +1,125 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package expr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+func TestRegisterScalar(t *testing.T) {
+	fn := func(args []ion.Datum) (ion.Datum, error) {
+		return args[0], nil
+	}
+	RegisterScalar("test_ident", Signature{
+		Args: []TypeSet{StringType},
+		Ret:  StringType,
+	}, fn)
+	defer unregisterScalar("test_ident")
+
+	if _, ok := LookupScalar("TEST_IDENT"); !ok {
+		t.Fatal("TEST_IDENT not found")
+	}
+	if _, ok := LookupScalar("test_other"); ok {
+		t.Fatal("unexpected function test_other")
+	}
+
+	call := CallByName("test_ident", path("x"))
+	if err := Check(call); err != nil {
+		t.Fatal(err)
+	}
+	if ts := TypeOf(call, NoHint); ts != StringType|MissingType {
+		t.Errorf("got type %s", ts)
+	}
+	var te *TypeError
+	if err := Check(CallByName("test_ident", Integer(3))); !errors.As(err, &te) {
+		t.Errorf("expected a type error, got %v", err)
+	}
+	var se *SyntaxError
+	if err := Check(CallByName("test_ident", path("x"), path("y"))); !errors.As(err, &se) {
+		t.Errorf("expected a syntax error, got %v", err)
+	}
+
+	mustPanic := func(name string, sig Signature) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("RegisterScalar(%q) did not panic", name)
+			}
+		}()
+		RegisterScalar(name, sig, fn)
+	}
+	sig := Signature{Args: []TypeSet{AnyType}, Ret: AnyType}
+	mustPanic("Test_Ident", sig)
+	mustPanic("upper", sig)
+	mustPanic("test_noargs", Signature{Ret: AnyType})
+}
+
+func TestRegisterAggregate(t *testing.T) {
+	RegisterAggregate("test_agg", nil, nil, nil, nil)
+	defer unregisterAggregate("test_agg")
+
+	if _, ok := LookupAggregate("Test_Agg"); !ok {
+		t.Fatal("TEST_AGG not found")
+	}
+	agg := &Aggregate{Op: OpUser, Name: "TEST_AGG", Inner: path("x")}
+	if err := Check(agg); err != nil {
+		t.Fatal(err)
+	}
+	if s := ToString(agg); s != "TEST_AGG(x)" {
+		t.Errorf("got %s", s)
+	}
+
+	// the name is preserved by the serialization
+	// that passes plans to other nodes
+	var buf ion.Buffer
+	var st ion.Symtab
+	agg.Encode(&buf, &st)
+	d, _, err := ion.ReadDatum(&st, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Decode(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !agg.Equals(out) {
+		t.Errorf("decoded %s", ToString(out))
+	}
+
+	var se *SyntaxError
+	unknown := &Aggregate{Op: OpUser, Name: "TEST_OTHER", Inner: path("x")}
+	if err := Check(unknown); !errors.As(err, &se) {
+		t.Errorf("expected a syntax error, got %v", err)
+	}
+
+	mustPanic := func(name string) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("RegisterAggregate(%q) did not panic", name)
+			}
+		}()
+		RegisterAggregate(name, nil, nil, nil, nil)
+	}
+	mustPanic("test_agg")
+	mustPanic("upper")
+	RegisterScalar("test_scalar", Signature{Args: []TypeSet{AnyType}, Ret: AnyType},
+		func(args []ion.Datum) (ion.Datum, error) { return args[0], nil })
+	defer unregisterScalar("test_scalar")
+	mustPanic("test_scalar")
+}
@@ -57,6 +57,11 @@ var truncQuarterPredicate = [12]byte{
 	10, // February  -> January
 }
 
+// lastDayOfMonth maps months starting from March into
+// the last day of the month (starting from zero),
+// excluding leap day
+var lastDayOfMonth = [12]uint16{30, 29, 30, 29, 30, 30, 29, 30, 29, 30, 30, 27}
+
 type Timestamp int64
 
 type DecomposedDate struct {
@@ -205,6 +210,36 @@ func (ts Timestamp) AddMonth(val int64) (Timestamp, bool) {
 	return Timestamp(unixTimeFromDateTime(dd, time)), true
 }
 
+// AddMonthClamp is AddMonth, except that the day of the
+// month is clamped to the last day of the resulting month
+// (so that adding one month to January 31st yields
+// February 28th or 29th instead of March 3rd or 2nd).
+func (ts Timestamp) AddMonthClamp(val int64) (Timestamp, bool) {
+	dd, time := dateTimeFromTimestamp(ts)
+
+	m := int64(dd.month) + val
+
+	yDiff := floorDivInt64(m, 12)
+	y := int64(dd.year) + yDiff
+
+	dd.month = uint16(m - yDiff*12)
+	dd.year = int32(y)
+
+	if last := dd.lastDay(); dd.day > last {
+		dd.day = last
+	}
+
+	return Timestamp(unixTimeFromDateTime(dd, time)), true
+}
+
+// lastDay returns the last day of the month (starting from zero)
+func (dd DecomposedDate) lastDay() uint16 {
+	if dd.month == 11 && isLeapYear(dd.year+1) {
+		return 28 // February 29th
+	}
+	return lastDayOfMonth[dd.month]
+}
+
 func (ts Timestamp) AddQuarter(val int64) (Timestamp, bool) {
 	return ts.AddMonth(val * 3)
 }
@@ -14,7 +14,10 @@
 
 package fastdate
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func testDateTimeRecomposition(t *testing.T, unixtime int64) {
 	dt, time := dateTimeFromTimestamp(Timestamp(unixtime))
@@ -62,3 +65,51 @@ func TestFastDate(t *testing.T) {
 	testDateTimeRecomposition(t, 10000000000000000)
 	testDateTimeRecomposition(t, 100000000000000000)
 }
+
+func TestAddMonthClamp(t *testing.T) {
+	// expected result: add months, and then clamp
+	// to the last day of the resulting month
+	ref := func(ts time.Time, months int) time.Time {
+		first := time.Date(ts.Year(), ts.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, months, 0)
+		last := first.AddDate(0, 1, -1).Day()
+		day := ts.Day()
+		if day > last {
+			day = last
+		}
+		return time.Date(first.Year(), first.Month(), day, ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(), time.UTC)
+	}
+	start := time.Date(1899, 12, 25, 13, 14, 15, 16000, time.UTC)
+	for day := 0; day < 3*366; day += 3 {
+		ts := start.AddDate(0, 0, day)
+		for _, months := range []int{-25, -12, -1, 0, 1, 2, 11, 12, 13, 49} {
+			got, ok := Timestamp(ts.UnixMicro()).AddMonthClamp(int64(months))
+			if !ok {
+				t.Fatalf("%s + %d months: not ok", ts, months)
+			}
+			want := ref(ts, months)
+			if int64(got) != want.UnixMicro() {
+				t.Errorf("%s + %d months: got %s, want %s", ts, months, time.UnixMicro(int64(got)).UTC(), want)
+			}
+		}
+	}
+	// the common cases, spelled out
+	for _, tc := range []struct {
+		from   string
+		months int64
+		want   string
+	}{
+		{"2023-01-31T10:00:00Z", 1, "2023-02-28T10:00:00Z"},
+		{"2024-01-31T10:00:00Z", 1, "2024-02-29T10:00:00Z"},
+		{"2024-02-29T00:00:00Z", 12, "2025-02-28T00:00:00Z"},
+		{"2023-03-31T00:00:00Z", -1, "2023-02-28T00:00:00Z"},
+		{"2023-05-31T00:00:00Z", 1, "2023-06-30T00:00:00Z"},
+		{"2023-01-15T00:00:00Z", 1, "2023-02-15T00:00:00Z"},
+	} {
+		from, _ := time.Parse(time.RFC3339, tc.from)
+		want, _ := time.Parse(time.RFC3339, tc.want)
+		got, _ := Timestamp(from.UnixMicro()).AddMonthClamp(tc.months)
+		if int64(got) != want.UnixMicro() {
+			t.Errorf("%s + %d months: got %s, want %s", tc.from, tc.months, time.UnixMicro(int64(got)).UTC(), tc.want)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// Package arrow implements conversion of
+// ion data into the Apache Arrow IPC
+// streaming format.
+//
+// Ion types are mapped to Arrow types
+// as follows:
+//
+//	bool                -> Bool
+//	int                 -> Int64
+//	float, decimal      -> Float64 (also ints mixed with floats)
+//	string, symbol      -> Utf8
+//	blob, clob          -> Binary
+//	timestamp           -> Timestamp(microsecond, "UTC")
+//	list, sexp          -> List
+//	struct              -> Struct
+//
+// Every column is nullable, and missing fields
+// are written as nulls. A column holding values of
+// more than one (incompatible) type is written
+// as a dense Union with one member per type.
+package arrow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// MIMEType is the media type
+// of an Arrow IPC stream.
+const MIMEType = "application/vnd.apache.arrow.stream"
+
+// DefaultBatchSize is the default
+// number of rows in a record batch.
+const DefaultBatchSize = 4096
+
+const (
+	metadataV5   = 4
+	continuation = 0xffffffff
+
+	// message header types
+	headerSchema      = 1
+	headerRecordBatch = 3
+
+	// type union members
+	typeNull          = 1
+	typeInt           = 2
+	typeFloatingPoint = 3
+	typeBinary        = 4
+	typeUtf8          = 5
+	typeBool          = 6
+	typeTimestamp     = 10
+	typeList          = 12
+	typeStruct        = 13
+	typeUnion         = 14
+
+	precisionDouble = 2
+	unitMicrosecond = 2
+	unionDense      = 1
+)
+
+// ErrSchemaChange is returned by Writer.Write
+// and Writer.Close when a batch holds values that
+// do not fit the schema that was already written.
+var ErrSchemaChange = errors.New("arrow: values do not fit the schema of the stream")
+
+// Writer is an io.WriteCloser that
+// converts ion structures into an
+// Arrow IPC stream. See NewWriter.
+//
+// The rows are buffered until BatchSize rows
+// have been written, and then each batch is
+// written as an Arrow record batch. The schema
+// of the stream is determined by the types of the
+// values in the first batch. If a later batch
+// contains values that don't fit that schema
+// (for example a field that wasn't present in the
+// first batch), Write returns ErrSchemaChange,
+// since a stream can only have one schema.
+// Setting BatchSize to a larger value widens the
+// schema by inspecting more rows up front.
+type Writer struct {
+	// W is the output io.Writer into
+	// which the stream is written.
+	W io.Writer
+	// BatchSize is the maximum number of rows
+	// in a record batch. If BatchSize is zero,
+	// DefaultBatchSize is used instead.
+	BatchSize int
+
+	st     ion.Symtab
+	rows   []ion.Datum
+	schema *dtype // schema of the current stream
+	out    []byte
+}
+
+// NewWriter constructs a Writer that
+// writes an Arrow IPC stream to w.
+// The caller must call Close to flush the
+// final batch and terminate the stream.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{W: w}
+}
+
+func (w *Writer) batchSize() int {
+	if w.BatchSize > 0 {
+		return w.BatchSize
+	}
+	return DefaultBatchSize
+}
+
+// Write implements io.Writer
+//
+// The buffer passed to Write must contain
+// complete ion objects. Every top-level object
+// must be a struct; top-level annotations
+// and nulls are ignored.
+func (w *Writer) Write(src []byte) (int, error) {
+	n := len(src)
+	for len(src) > 0 {
+		d, rest, err := ion.ReadDatum(&w.st, src)
+		if err != nil {
+			return 0, err
+		}
+		src = rest
+		if d.IsEmpty() || d.IsNull() || d.IsAnnotation() {
+			continue
+		}
+		if !d.IsStruct() {
+			return 0, fmt.Errorf("arrow: cannot write top-level %s", d.Type())
+		}
+		w.rows = append(w.rows, d.Clone())
+		if len(w.rows) >= w.batchSize() {
+			if err := w.flush(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close writes the buffered rows and
+// terminates the stream. It does not
+// close the underlying io.Writer.
+//
+// The stream is terminated even if the
+// buffered rows do not fit the schema,
+// in which case Close returns ErrSchemaChange.
+func (w *Writer) Close() error {
+	err := w.flush()
+	if err != nil && err != ErrSchemaChange {
+		return err
+	}
+	if w.schema == nil {
+		// an empty stream still needs a schema
+		if err := w.writeSchema(&dtype{kind: kindStruct}); err != nil {
+			return err
+		}
+	}
+	if err2 := w.eos(); err2 != nil {
+		return err2
+	}
+	return err
+}
+
+func (w *Writer) flush() error {
+	if len(w.rows) == 0 {
+		return nil
+	}
+	var t *dtype
+	for i := range w.rows {
+		t = unify(t, typeOf(w.rows[i]))
+	}
+	if w.schema == nil {
+		if err := w.writeSchema(t); err != nil {
+			return err
+		}
+	} else if !fits(w.schema, t) {
+		clear(w.rows)
+		w.rows = w.rows[:0]
+		return ErrSchemaChange
+	}
+	b := newBuilder(w.schema)
+	for i := range w.rows {
+		b.append(w.rows[i])
+	}
+	clear(w.rows)
+	w.rows = w.rows[:0]
+
+	var dst body
+	for _, c := range b.children {
+		c.encode(&dst)
+	}
+	batch := (&fbtable{}).
+		scalar(0, 8, uint64(b.length)).
+		ref(1, &dst.nodes).
+		ref(2, &dst.buffers)
+	return w.message(headerRecordBatch, batch, dst.data)
+}
+
+func (w *Writer) writeSchema(t *dtype) error {
+	w.schema = t
+	var fields fbtables
+	for i := range t.fields {
+		fields = append(fields, t.fields[i].typ.field(t.fields[i].name))
+	}
+	schema := (&fbtable{}).
+		scalar(0, 2, 0). // little-endian
+		ref(1, fields)
+	return w.message(headerSchema, schema, nil)
+}
+
+// message writes an encapsulated message
+// with the given header and body
+func (w *Writer) message(htype int, header *fbtable, body []byte) error {
+	msg := (&fbtable{}).
+		scalar(0, 2, metadataV5).
+		scalar(1, 1, uint64(htype)).
+		ref(2, header).
+		scalar(3, 8, uint64(len(body)))
+	meta := fbfinish(msg)
+	for len(meta)%8 != 0 {
+		meta = append(meta, 0)
+	}
+	w.out = binary.LittleEndian.AppendUint32(w.out[:0], continuation)
+	w.out = binary.LittleEndian.AppendUint32(w.out, uint32(len(meta)))
+	w.out = append(w.out, meta...)
+	w.out = append(w.out, body...)
+	_, err := w.W.Write(w.out)
+	return err
+}
+
+// eos writes the end-of-stream marker
+func (w *Writer) eos() error {
+	w.out = binary.LittleEndian.AppendUint32(w.out[:0], continuation)
+	w.out = binary.LittleEndian.AppendUint32(w.out, 0)
+	_, err := w.W.Write(w.out)
+	return err
+}
+
+// field returns the Field table
+// describing a column of type t
+func (t *dtype) field(name string) *fbtable {
+	if t == nil {
+		t = &dtype{kind: kindNull}
+	}
+	typ := &fbtable{}
+	var typeType int
+	var children fbtables
+	switch t.kind {
+	case kindNull:
+		typeType = typeNull
+	case kindBool:
+		typeType = typeBool
+	case kindInt:
+		typeType = typeInt
+		typ.scalar(0, 4, 64).scalar(1, 1, 1) // signed
+	case kindFloat:
+		typeType = typeFloatingPoint
+		typ.scalar(0, 2, precisionDouble)
+	case kindString:
+		typeType = typeUtf8
+	case kindBinary:
+		typeType = typeBinary
+	case kindTimestamp:
+		typeType = typeTimestamp
+		typ.scalar(0, 2, unitMicrosecond).ref(1, fbstring("UTC"))
+	case kindList:
+		typeType = typeList
+		children = append(children, t.elem.field("item"))
+	case kindStruct:
+		typeType = typeStruct
+		for i := range t.fields {
+			children = append(children, t.fields[i].typ.field(t.fields[i].name))
+		}
+	case kindUnion:
+		typeType = typeUnion
+		ids := make(fbints, len(t.members))
+		for i, m := range t.members {
+			ids[i] = int32(i)
+			children = append(children, m.field(m.kind.String()))
+		}
+		typ.scalar(0, 2, unionDense).ref(1, ids)
+	}
+	return (&fbtable{}).
+		ref(0, fbstring(name)).
+		scalar(1, 1, 1). // nullable
+		scalar(2, 1, uint64(typeType)).
+		ref(3, typ).
+		ref(5, children)
+}
@@ -0,0 +1,471 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package arrow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// fbt is a flatbuffers table (for testing)
+type fbt struct {
+	t   *testing.T
+	buf []byte
+	pos int
+}
+
+func (f fbt) u16(p int) int { return int(binary.LittleEndian.Uint16(f.buf[p:])) }
+func (f fbt) u32(p int) int { return int(binary.LittleEndian.Uint32(f.buf[p:])) }
+
+func (f fbt) aligned(p, n int) {
+	if p%n != 0 {
+		f.t.Helper()
+		f.t.Fatalf("position %d is not %d-byte aligned", p, n)
+	}
+}
+
+// field returns the position of field id (or 0)
+func (f fbt) field(id int) int {
+	f.aligned(f.pos, 4)
+	vt := f.pos - int(int32(f.u32(f.pos)))
+	f.aligned(vt, 2)
+	if 4+2*id >= f.u16(vt) {
+		return 0
+	}
+	off := f.u16(vt + 4 + 2*id)
+	if off == 0 {
+		return 0
+	}
+	return f.pos + off
+}
+
+func (f fbt) scalar(id, size int) uint64 {
+	p := f.field(id)
+	if p == 0 {
+		return 0
+	}
+	f.aligned(p, size)
+	switch size {
+	case 1:
+		return uint64(f.buf[p])
+	case 2:
+		return uint64(f.u16(p))
+	case 4:
+		return uint64(f.u32(p))
+	default:
+		return binary.LittleEndian.Uint64(f.buf[p:])
+	}
+}
+
+func (f fbt) ref(id int) int {
+	p := f.field(id)
+	if p == 0 {
+		f.t.Fatalf("missing field %d", id)
+	}
+	f.aligned(p, 4)
+	return p + f.u32(p)
+}
+
+func (f fbt) table(id int) fbt { return fbt{f.t, f.buf, f.ref(id)} }
+
+func (f fbt) str(id int) string {
+	p := f.ref(id)
+	n := f.u32(p)
+	return string(f.buf[p+4 : p+4+n])
+}
+
+// vector returns the position of the
+// first element and the number of elements
+func (f fbt) vector(id int) (int, int) {
+	if f.field(id) == 0 {
+		return 0, 0
+	}
+	p := f.ref(id)
+	return p + 4, f.u32(p)
+}
+
+func (f fbt) tables(id int) []fbt {
+	p, n := f.vector(id)
+	var out []fbt
+	for i := 0; i < n; i++ {
+		at := p + 4*i
+		out = append(out, fbt{f.t, f.buf, at + f.u32(at)})
+	}
+	return out
+}
+
+// reader decodes an Arrow IPC stream (for testing)
+type reader struct {
+	t       *testing.T
+	src     []byte
+	fields  []fbt
+	nodes   [][2]int
+	buffers [][2]int
+	body    []byte
+}
+
+// message reads the next message and returns
+// its header type and header (or false at EOS)
+func (r *reader) message() (int, fbt, bool) {
+	if binary.LittleEndian.Uint32(r.src) != continuation {
+		r.t.Fatal("missing continuation marker")
+	}
+	size := int(binary.LittleEndian.Uint32(r.src[4:]))
+	if size == 0 {
+		r.src = r.src[8:]
+		return 0, fbt{}, false
+	}
+	if size%8 != 0 {
+		r.t.Fatalf("metadata size %d not padded", size)
+	}
+	meta := r.src[8 : 8+size]
+	msg := fbt{r.t, meta, 0}
+	msg.pos = msg.u32(0)
+	if v := msg.scalar(0, 2); v != metadataV5 {
+		r.t.Fatalf("metadata version %d", v)
+	}
+	htype := int(msg.scalar(1, 1))
+	bodylen := int(msg.scalar(3, 8))
+	r.body = r.src[8+size : 8+size+bodylen]
+	r.src = r.src[8+size+bodylen:]
+	return htype, msg.table(2), true
+}
+
+// stream reads one stream and returns
+// the rows as JSON text
+func (r *reader) stream() []string {
+	htype, schema, ok := r.message()
+	if !ok || htype != headerSchema {
+		r.t.Fatalf("expected a schema, got %d", htype)
+	}
+	r.fields = schema.tables(1)
+	var rows []string
+	for {
+		htype, batch, ok := r.message()
+		if !ok {
+			return rows
+		}
+		if htype != headerRecordBatch {
+			r.t.Fatalf("unexpected header type %d", htype)
+		}
+		n := int(batch.scalar(0, 8))
+		r.nodes = r.structs(batch, 1)
+		r.buffers = r.structs(batch, 2)
+		cols := make([][]any, len(r.fields))
+		for i := range r.fields {
+			cols[i] = r.column(r.fields[i])
+			if len(cols[i]) != n {
+				r.t.Fatalf("column %d has %d rows; want %d", i, len(cols[i]), n)
+			}
+		}
+		for i := 0; i < n; i++ {
+			var sb strings.Builder
+			sb.WriteString("{")
+			for j := range r.fields {
+				if j > 0 {
+					sb.WriteString(", ")
+				}
+				name, _ := json.Marshal(r.fields[j].str(0))
+				val, _ := json.Marshal(cols[j][i])
+				fmt.Fprintf(&sb, "%s: %s", name, val)
+			}
+			sb.WriteString("}")
+			rows = append(rows, sb.String())
+		}
+		if len(r.nodes) != 0 || len(r.buffers) != 0 {
+			r.t.Fatalf("%d nodes and %d buffers left over", len(r.nodes), len(r.buffers))
+		}
+	}
+}
+
+func (r *reader) structs(f fbt, id int) [][2]int {
+	p, n := f.vector(id)
+	f.aligned(p, 8)
+	var out [][2]int
+	for i := 0; i < n; i++ {
+		at := p + 16*i
+		out = append(out, [2]int{
+			int(binary.LittleEndian.Uint64(f.buf[at:])),
+			int(binary.LittleEndian.Uint64(f.buf[at+8:])),
+		})
+	}
+	return out
+}
+
+func (r *reader) buffer() []byte {
+	b := r.buffers[0]
+	r.buffers = r.buffers[1:]
+	if b[0]%8 != 0 {
+		r.t.Fatalf("buffer offset %d not aligned", b[0])
+	}
+	return r.body[b[0] : b[0]+b[1]]
+}
+
+func bit(bitmap []byte, i int) bool {
+	return bitmap[i/8]&(1<<(i%8)) != 0
+}
+
+func (r *reader) column(f fbt) []any {
+	node := r.nodes[0]
+	r.nodes = r.nodes[1:]
+	n, nulls := node[0], node[1]
+	typ := int(f.scalar(2, 1))
+	children := f.tables(5)
+	out := make([]any, n)
+	if typ == typeNull {
+		return out
+	}
+	if typ == typeUnion {
+		types := r.buffer()
+		offsets := r.buffer()
+		members := make([][]any, len(children))
+		for i := range children {
+			members[i] = r.column(children[i])
+		}
+		for i := range out {
+			off := binary.LittleEndian.Uint32(offsets[4*i:])
+			out[i] = members[types[i]][off]
+		}
+		return out
+	}
+	validity := r.buffer()
+	valid := func(i int) bool {
+		return nulls == 0 || bit(validity, i)
+	}
+	var offsets []byte
+	offset := func(i int) int { return int(binary.LittleEndian.Uint32(offsets[4*i:])) }
+	switch typ {
+	case typeStruct:
+		cols := make([][]any, len(children))
+		for i := range children {
+			cols[i] = r.column(children[i])
+		}
+		for i := range out {
+			if !valid(i) {
+				continue
+			}
+			m := make(map[string]any)
+			for j := range children {
+				m[children[j].str(0)] = cols[j][i]
+			}
+			out[i] = m
+		}
+		return out
+	case typeList:
+		offsets = r.buffer()
+		items := r.column(children[0])
+		for i := range out {
+			if valid(i) {
+				out[i] = items[offset(i):offset(i+1)]
+			}
+		}
+		return out
+	case typeUtf8, typeBinary:
+		offsets = r.buffer()
+		data := r.buffer()
+		for i := range out {
+			if valid(i) {
+				out[i] = string(data[offset(i):offset(i+1)])
+			}
+		}
+		return out
+	}
+	data := r.buffer()
+	for i := range out {
+		if !valid(i) {
+			continue
+		}
+		switch typ {
+		case typeBool:
+			out[i] = bit(data, i)
+		case typeInt:
+			out[i] = int64(binary.LittleEndian.Uint64(data[8*i:]))
+		case typeFloatingPoint:
+			out[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+		case typeTimestamp:
+			out[i] = fmt.Sprintf("ts:%d", int64(binary.LittleEndian.Uint64(data[8*i:])))
+		default:
+			r.t.Fatalf("unexpected type %d", typ)
+		}
+	}
+	return out
+}
+
+// toIon converts NDJSON into ion, splitting the
+// output into chunks of the given number of rows
+func toIon(t *testing.T, text string, rows int) [][]byte {
+	var out [][]byte
+	var st ion.Symtab
+	var buf ion.Buffer
+	d := json.NewDecoder(strings.NewReader(text))
+	n := 0
+	for {
+		dat, err := ion.FromJSON(&st, d)
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.Size() == 0 {
+			// symbols are appended as they
+			// are encountered, so marshal
+			// the symbol table after the data
+			st.Marshal(&buf, true)
+		}
+		dat.Encode(&buf, &st)
+		n++
+		if n%rows == 0 {
+			out = append(out, prefixSymtab(&st, buf.Bytes()))
+			buf.Reset()
+		}
+	}
+	if buf.Size() > 0 {
+		out = append(out, prefixSymtab(&st, buf.Bytes()))
+	}
+	return out
+}
+
+// prefixSymtab replaces the symbol table
+// at the start of buf with the current one
+func prefixSymtab(st *ion.Symtab, buf []byte) []byte {
+	var tmp ion.Symtab
+	rest, err := tmp.Unmarshal(buf)
+	if err != nil {
+		panic(err)
+	}
+	var out ion.Buffer
+	st.Marshal(&out, true)
+	return append(out.Bytes(), rest...)
+}
+
+func TestWriter(t *testing.T) {
+	tcs := []struct {
+		name  string
+		input string
+		batch int
+		want  []string
+		err   error
+	}{
+		{
+			name:  "scalars",
+			input: `{"a": 1, "b": "x", "c": true, "d": 1.5, "e": "2023-01-02T03:04:05Z"}` + "\n" + `{"a": -2, "c": false, "d": 3, "f": null}`,
+			want: []string{
+				`{"a": 1, "b": "x", "c": true, "d": 1.5, "e": "ts:1672628645000000", "f": null}`,
+				`{"a": -2, "b": null, "c": false, "d": 3, "e": null, "f": null}`,
+			},
+		},
+		{
+			name:  "nested",
+			input: `{"l": [1, 2], "s": {"x": "y"}}` + "\n" + `{"l": [], "s": {"z": [true]}}` + "\n" + `{"l": null}`,
+			want: []string{
+				`{"l": [1,2], "s": {"x":"y","z":null}}`,
+				`{"l": [], "s": {"x":null,"z":[true]}}`,
+				`{"l": null, "s": null}`,
+			},
+		},
+		{
+			name:  "union",
+			input: `{"u": 1}` + "\n" + `{"u": "one"}` + "\n" + `{"u": null}` + "\n" + `{"u": 2.5}` + "\n" + `{"u": [1]}`,
+			want: []string{
+				`{"u": 1}`,
+				`{"u": "one"}`,
+				`{"u": null}`,
+				`{"u": 2.5}`,
+				`{"u": [1]}`,
+			},
+		},
+		{
+			// the second batch fits the schema
+			// of the first one
+			name:  "batches",
+			input: `{"a": 1.5, "b": "x"}` + "\n" + `{"a": 2}` + "\n" + `{"b": null}`,
+			batch: 1,
+			want: []string{
+				`{"a": 1.5, "b": "x"}`,
+				`{"a": 2, "b": null}`,
+				`{"a": null, "b": null}`,
+			},
+		},
+		{
+			// the second batch introduces a new
+			// field, which can't be added to the
+			// schema that was already written
+			name:  "schema-change",
+			input: `{"a": 1}` + "\n" + `{"a": 2}` + "\n" + `{"b": "x"}` + "\n" + `{"a": "y"}`,
+			batch: 2,
+			want: []string{
+				`{"a": 1}`,
+				`{"a": 2}`,
+			},
+			err: ErrSchemaChange,
+		},
+		{
+			// a larger batch sees every
+			// field before writing the schema
+			name:  "schema-widened",
+			input: `{"a": 1}` + "\n" + `{"a": 2}` + "\n" + `{"b": "x"}` + "\n" + `{"a": "y"}`,
+			want: []string{
+				`{"a": 1, "b": null}`,
+				`{"a": 2, "b": null}`,
+				`{"a": null, "b": "x"}`,
+				`{"a": "y", "b": null}`,
+			},
+		},
+		{
+			name: "empty",
+			want: nil,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := NewWriter(&out)
+			w.BatchSize = tc.batch
+			var err error
+			for _, chunk := range toIon(t, tc.input, 2) {
+				_, err = w.Write(chunk)
+				if err != nil {
+					break
+				}
+			}
+			if err2 := w.Close(); err == nil {
+				err = err2
+			}
+			if err != tc.err {
+				t.Fatalf("got error %v; want %v", err, tc.err)
+			}
+			// like other Arrow readers, read
+			// one stream up to the end-of-stream
+			// marker; nothing may follow it
+			r := &reader{t: t, src: out.Bytes()}
+			got := r.stream()
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+			}
+			if len(r.src) != 0 {
+				t.Errorf("%d bytes after the end of the stream", len(r.src))
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package arrow
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// builder accumulates the
+// values of one column
+type builder struct {
+	typ      *dtype
+	length   int
+	nulls    int
+	validity []byte  // validity bitmap
+	data     []byte  // fixed-width values, bool bitmap or variable-width bytes
+	offsets  []int32 // variable-width values, lists and dense unions
+	types    []byte  // union type ids
+	children []*builder
+	index    map[string]int // struct field name -> child
+	seen     []bool         // struct fields seen in the current row
+}
+
+func newBuilder(t *dtype) *builder {
+	if t == nil {
+		t = &dtype{kind: kindNull}
+	}
+	b := &builder{typ: t}
+	switch t.kind {
+	case kindString, kindBinary:
+		b.offsets = []int32{0}
+	case kindList:
+		b.offsets = []int32{0}
+		b.children = []*builder{newBuilder(t.elem)}
+	case kindStruct:
+		b.index = make(map[string]int, len(t.fields))
+		for i := range t.fields {
+			b.index[t.fields[i].name] = i
+			b.children = append(b.children, newBuilder(t.fields[i].typ))
+		}
+		b.seen = make([]bool, len(t.fields))
+	case kindUnion:
+		for _, m := range t.members {
+			b.children = append(b.children, newBuilder(m))
+		}
+	}
+	return b
+}
+
+func setbit(bitmap []byte, i int, v bool) []byte {
+	if i/8 >= len(bitmap) {
+		bitmap = append(bitmap, 0)
+	}
+	if v {
+		bitmap[i/8] |= 1 << (i % 8)
+	}
+	return bitmap
+}
+
+func float(d ion.Datum) float64 {
+	switch d.Type() {
+	case ion.UintType:
+		u, _ := d.Uint()
+		return float64(u)
+	case ion.IntType:
+		i, _ := d.Int()
+		return float64(i)
+	case ion.DecimalType:
+		r, err := d.Rat()
+		if err != nil {
+			return math.NaN()
+		}
+		f, _ := r.Float64()
+		return f
+	default:
+		f, _ := d.Float()
+		return f
+	}
+}
+
+// append appends d, which must fit
+// the type of the column
+func (b *builder) append(d ion.Datum) {
+	d = unwrap(d)
+	k := kindOf(d)
+	if b.typ.kind == kindUnion {
+		// unions don't have a validity bitmap,
+		// so nulls are stored in the first member
+		i := 0
+		if k != kindNull {
+			i = b.typ.member(k)
+		}
+		b.types = append(b.types, byte(i))
+		b.offsets = append(b.offsets, int32(b.children[i].length))
+		b.children[i].append(d)
+		b.length++
+		return
+	}
+	valid := k != kindNull && b.typ.kind != kindNull
+	b.validity = setbit(b.validity, b.length, valid)
+	b.length++
+	if !valid {
+		b.nulls++
+	}
+	switch b.typ.kind {
+	case kindBool:
+		v := false
+		if valid {
+			v, _ = d.Bool()
+		}
+		b.data = setbit(b.data, b.length-1, v)
+	case kindInt:
+		var v int64
+		if valid {
+			v, _ = d.Int()
+		}
+		b.data = binary.LittleEndian.AppendUint64(b.data, uint64(v))
+	case kindFloat:
+		var v float64
+		if valid {
+			v = float(d)
+		}
+		b.data = binary.LittleEndian.AppendUint64(b.data, math.Float64bits(v))
+	case kindTimestamp:
+		var v int64
+		if valid {
+			t, _ := d.Timestamp()
+			v = t.UnixMicro()
+		}
+		b.data = binary.LittleEndian.AppendUint64(b.data, uint64(v))
+	case kindString:
+		if valid {
+			s, _ := d.String()
+			b.data = append(b.data, s...)
+		}
+		b.offsets = append(b.offsets, int32(len(b.data)))
+	case kindBinary:
+		if valid {
+			buf, _ := ion.Contents(d.Raw())
+			b.data = append(b.data, buf...)
+		}
+		b.offsets = append(b.offsets, int32(len(b.data)))
+	case kindList:
+		elem := b.children[0]
+		if valid {
+			d.UnpackList(func(item ion.Datum) error {
+				elem.append(item)
+				return nil
+			})
+		}
+		b.offsets = append(b.offsets, int32(elem.length))
+	case kindStruct:
+		// struct children have the same
+		// length as the struct itself
+		clear(b.seen)
+		if valid {
+			d.UnpackStruct(func(f ion.Field) error {
+				if i, ok := b.index[f.Label]; ok && !b.seen[i] {
+					b.seen[i] = true
+					b.children[i].append(f.Datum)
+				}
+				return nil
+			})
+		}
+		for i, c := range b.children {
+			if !b.seen[i] {
+				c.append(ion.Null)
+			}
+		}
+	}
+}
+
+// body is the body of a record batch
+type body struct {
+	data    []byte
+	nodes   fbstructs
+	buffers fbstructs
+}
+
+func (b *body) buffer(buf []byte) {
+	b.buffers.add(uint64(len(b.data)), uint64(len(buf)))
+	b.data = append(b.data, buf...)
+	for len(b.data)%8 != 0 {
+		b.data = append(b.data, 0)
+	}
+}
+
+func int32s(lst []int32) []byte {
+	out := make([]byte, 0, 4*len(lst))
+	for _, i := range lst {
+		out = binary.LittleEndian.AppendUint32(out, uint32(i))
+	}
+	return out
+}
+
+// encode adds the field nodes and buffers
+// of the column and its children (in pre-order)
+func (b *builder) encode(dst *body) {
+	switch b.typ.kind {
+	case kindNull:
+		// the null type has no buffers
+		dst.nodes.add(uint64(b.length), uint64(b.length))
+		return
+	case kindUnion:
+		dst.nodes.add(uint64(b.length), 0)
+		dst.buffer(b.types)
+		dst.buffer(int32s(b.offsets))
+	default:
+		dst.nodes.add(uint64(b.length), uint64(b.nulls))
+		if b.nulls == 0 {
+			dst.buffer(nil)
+		} else {
+			dst.buffer(b.validity)
+		}
+		switch b.typ.kind {
+		case kindString, kindBinary:
+			dst.buffer(int32s(b.offsets))
+			dst.buffer(b.data)
+		case kindList:
+			dst.buffer(int32s(b.offsets))
+		case kindStruct:
+			// validity only
+		default:
+			dst.buffer(b.data)
+		}
+	}
+	for _, c := range b.children {
+		c.encode(dst)
+	}
+}
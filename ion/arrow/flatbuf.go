@@ -0,0 +1,215 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package arrow
+
+import (
+	"encoding/binary"
+)
+
+// This file implements just enough of
+// the flatbuffers encoding to produce
+// the Arrow IPC metadata.
+//
+// Objects are written front-to-back:
+// each table is preceded by its vtable,
+// and the objects referenced by a table
+// are written after it, so every offset
+// points forward as flatbuffers requires.
+
+// fbref is an object that is
+// referenced through an offset
+type fbref interface {
+	// write writes the object and
+	// returns its position
+	write(w *fbwriter) int
+}
+
+type fbfield struct {
+	size   int // inline size of a scalar; 0 for references
+	scalar uint64
+	ref    fbref
+}
+
+// fbtable is a table; fields are
+// indexed by their id in the schema
+type fbtable struct {
+	fields []fbfield
+}
+
+func (t *fbtable) set(id int, f fbfield) *fbtable {
+	for len(t.fields) <= id {
+		t.fields = append(t.fields, fbfield{})
+	}
+	t.fields[id] = f
+	return t
+}
+
+func (t *fbtable) scalar(id, size int, v uint64) *fbtable {
+	return t.set(id, fbfield{size: size, scalar: v})
+}
+
+func (t *fbtable) ref(id int, r fbref) *fbtable {
+	return t.set(id, fbfield{ref: r})
+}
+
+func (f *fbfield) inline() int {
+	if f.ref != nil {
+		return 4
+	}
+	return f.size
+}
+
+func (t *fbtable) write(w *fbwriter) int {
+	// lay out the fields in order of
+	// decreasing size so that each of
+	// them is naturally aligned
+	offsets := make([]int, len(t.fields))
+	size := 4 // soffset to the vtable
+	align := 4
+	for _, n := range []int{8, 4, 2, 1} {
+		for i := range t.fields {
+			if t.fields[i].inline() != n {
+				continue
+			}
+			size = (size + n - 1) &^ (n - 1)
+			offsets[i] = size
+			size += n
+			align = max(align, n)
+		}
+	}
+	vtable := w.pad(2)
+	w.u16(uint16(4 + 2*len(t.fields)))
+	w.u16(uint16(size))
+	for i := range offsets {
+		w.u16(uint16(offsets[i]))
+	}
+	start := w.pad(align)
+	w.u32(uint32(start - vtable))
+	w.buf = append(w.buf, make([]byte, size-4)...)
+	for i := range t.fields {
+		f := &t.fields[i]
+		at := w.buf[start+offsets[i]:]
+		switch f.size {
+		case 1:
+			at[0] = byte(f.scalar)
+		case 2:
+			binary.LittleEndian.PutUint16(at, uint16(f.scalar))
+		case 4:
+			binary.LittleEndian.PutUint32(at, uint32(f.scalar))
+		case 8:
+			binary.LittleEndian.PutUint64(at, f.scalar)
+		}
+	}
+	for i := range t.fields {
+		if r := t.fields[i].ref; r != nil {
+			w.patch(start+offsets[i], r.write(w))
+		}
+	}
+	return start
+}
+
+type fbstring string
+
+func (s fbstring) write(w *fbwriter) int {
+	pos := w.pad(4)
+	w.u32(uint32(len(s)))
+	w.buf = append(w.buf, s...)
+	w.buf = append(w.buf, 0)
+	return pos
+}
+
+// fbtables is a vector of tables
+type fbtables []*fbtable
+
+func (v fbtables) write(w *fbwriter) int {
+	pos := w.pad(4)
+	w.u32(uint32(len(v)))
+	slots := len(w.buf)
+	w.buf = append(w.buf, make([]byte, 4*len(v))...)
+	for i := range v {
+		w.patch(slots+4*i, v[i].write(w))
+	}
+	return pos
+}
+
+// fbstructs is a vector of structs
+// consisting of 8-byte fields
+type fbstructs struct {
+	count int
+	data  []byte
+}
+
+func (v *fbstructs) add(fields ...uint64) {
+	for _, f := range fields {
+		v.data = binary.LittleEndian.AppendUint64(v.data, f)
+	}
+	v.count++
+}
+
+func (v *fbstructs) write(w *fbwriter) int {
+	// the elements must be 8-byte aligned
+	for len(w.buf)%8 != 4 {
+		w.buf = append(w.buf, 0)
+	}
+	pos := len(w.buf)
+	w.u32(uint32(v.count))
+	w.buf = append(w.buf, v.data...)
+	return pos
+}
+
+// fbints is a vector of 32-bit integers
+type fbints []int32
+
+func (v fbints) write(w *fbwriter) int {
+	pos := w.pad(4)
+	w.u32(uint32(len(v)))
+	for _, i := range v {
+		w.u32(uint32(i))
+	}
+	return pos
+}
+
+type fbwriter struct {
+	buf []byte
+}
+
+// pad aligns the output and
+// returns the current position
+func (w *fbwriter) pad(align int) int {
+	for len(w.buf)%align != 0 {
+		w.buf = append(w.buf, 0)
+	}
+	return len(w.buf)
+}
+
+func (w *fbwriter) u16(v uint16) {
+	w.buf = binary.LittleEndian.AppendUint16(w.buf, v)
+}
+
+func (w *fbwriter) u32(v uint32) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, v)
+}
+
+// patch stores an offset to target at
+func (w *fbwriter) patch(at, target int) {
+	binary.LittleEndian.PutUint32(w.buf[at:], uint32(target-at))
+}
+
+// fbfinish encodes a buffer with the given root table
+func fbfinish(root *fbtable) []byte {
+	w := &fbwriter{buf: make([]byte, 4, 512)}
+	w.patch(0, root.write(w))
+	return w.buf
+}
@@ -0,0 +1,262 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package arrow
+
+import (
+	"math"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// kind is the Arrow type of a column
+type kind uint8
+
+const (
+	kindNull kind = iota
+	kindBool
+	kindInt       // signed 64-bit integer
+	kindFloat     // 64-bit float
+	kindString    // utf8
+	kindBinary    // binary
+	kindTimestamp // microseconds since the epoch (UTC)
+	kindList
+	kindStruct
+	kindUnion // dense union
+)
+
+var kindNames = [...]string{
+	kindNull:      "null",
+	kindBool:      "bool",
+	kindInt:       "int64",
+	kindFloat:     "double",
+	kindString:    "utf8",
+	kindBinary:    "binary",
+	kindTimestamp: "timestamp",
+	kindList:      "list",
+	kindStruct:    "struct",
+	kindUnion:     "union",
+}
+
+func (k kind) String() string { return kindNames[k] }
+
+// dtype is the type of a column;
+// every column is nullable
+type dtype struct {
+	kind    kind
+	fields  []field  // kindStruct
+	elem    *dtype   // kindList; nil if every list is empty
+	members []*dtype // kindUnion; one member per kind
+}
+
+type field struct {
+	name string
+	typ  *dtype
+}
+
+// unwrap returns the value of an annotation
+func unwrap(d ion.Datum) ion.Datum {
+	for d.IsAnnotation() {
+		_, v, err := d.Annotation()
+		if err != nil {
+			return ion.Null
+		}
+		d = v
+	}
+	return d
+}
+
+// kindOf returns the kind of column
+// that can hold the value d
+func kindOf(d ion.Datum) kind {
+	switch d.Type() {
+	case ion.BoolType:
+		return kindBool
+	case ion.UintType:
+		if u, _ := d.Uint(); u > math.MaxInt64 {
+			return kindFloat
+		}
+		return kindInt
+	case ion.IntType:
+		return kindInt
+	case ion.FloatType, ion.DecimalType:
+		return kindFloat
+	case ion.StringType, ion.SymbolType:
+		return kindString
+	case ion.BlobType, ion.ClobType:
+		return kindBinary
+	case ion.TimestampType:
+		return kindTimestamp
+	case ion.ListType, ion.SexpType:
+		return kindList
+	case ion.StructType:
+		return kindStruct
+	default:
+		return kindNull
+	}
+}
+
+// compatible returns whether a column
+// of kind a can hold a value of kind b
+func compatible(a, b kind) bool {
+	return a == b || (a == kindFloat && b == kindInt)
+}
+
+// typeOf returns the type of the value d
+func typeOf(d ion.Datum) *dtype {
+	d = unwrap(d)
+	t := &dtype{kind: kindOf(d)}
+	switch t.kind {
+	case kindList:
+		d.UnpackList(func(item ion.Datum) error {
+			t.elem = unify(t.elem, typeOf(item))
+			return nil
+		})
+	case kindStruct:
+		d.UnpackStruct(func(f ion.Field) error {
+			if t.index(f.Label) < 0 {
+				t.fields = append(t.fields, field{name: f.Label, typ: typeOf(f.Datum)})
+			}
+			return nil
+		})
+	}
+	return t
+}
+
+func (t *dtype) index(name string) int {
+	for i := range t.fields {
+		if t.fields[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// member returns the index of the union
+// member that can hold a value of kind k
+func (t *dtype) member(k kind) int {
+	for i := range t.members {
+		if compatible(t.members[i].kind, k) {
+			return i
+		}
+	}
+	return -1
+}
+
+// unify returns a type that can represent
+// the values of both a and b; either of them
+// may be nil, and a may be modified (but b isn't)
+func unify(a, b *dtype) *dtype {
+	if b == nil || b.kind == kindNull {
+		if a == nil {
+			return &dtype{kind: kindNull}
+		}
+		return a
+	}
+	if a == nil || a.kind == kindNull {
+		return b.clone()
+	}
+	if b.kind == kindUnion {
+		for _, m := range b.members {
+			a = unify(a, m)
+		}
+		return a
+	}
+	if a.kind == kindUnion {
+		i := a.member(b.kind)
+		if i < 0 && b.kind == kindFloat {
+			// promote an integer member
+			i = a.member(kindInt)
+		}
+		if i >= 0 {
+			a.members[i] = unify(a.members[i], b)
+		} else {
+			a.members = append(a.members, b.clone())
+		}
+		return a
+	}
+	if a.kind == kindInt && b.kind == kindFloat {
+		a.kind = kindFloat
+	}
+	if !compatible(a.kind, b.kind) {
+		return &dtype{kind: kindUnion, members: []*dtype{a, b.clone()}}
+	}
+	switch a.kind {
+	case kindList:
+		if b.elem != nil {
+			a.elem = unify(a.elem, b.elem)
+		}
+	case kindStruct:
+		for _, f := range b.fields {
+			if i := a.index(f.name); i >= 0 {
+				a.fields[i].typ = unify(a.fields[i].typ, f.typ)
+			} else {
+				a.fields = append(a.fields, field{name: f.name, typ: f.typ.clone()})
+			}
+		}
+	}
+	return a
+}
+
+func (t *dtype) clone() *dtype {
+	if t == nil {
+		return nil
+	}
+	c := &dtype{kind: t.kind, elem: t.elem.clone()}
+	for _, f := range t.fields {
+		c.fields = append(c.fields, field{name: f.name, typ: f.typ.clone()})
+	}
+	for _, m := range t.members {
+		c.members = append(c.members, m.clone())
+	}
+	return c
+}
+
+// fits returns whether every value of
+// type b can be stored in a column of type a
+func fits(a, b *dtype) bool {
+	if b == nil || b.kind == kindNull {
+		return true
+	}
+	if a == nil {
+		return false
+	}
+	if b.kind == kindUnion {
+		for _, m := range b.members {
+			if !fits(a, m) {
+				return false
+			}
+		}
+		return true
+	}
+	if a.kind == kindUnion {
+		i := a.member(b.kind)
+		return i >= 0 && fits(a.members[i], b)
+	}
+	if !compatible(a.kind, b.kind) {
+		return false
+	}
+	switch a.kind {
+	case kindList:
+		return fits(a.elem, b.elem)
+	case kindStruct:
+		for _, f := range b.fields {
+			i := a.index(f.name)
+			if i < 0 || !fits(a.fields[i].typ, f.typ) {
+				return false
+			}
+		}
+	}
+	return true
+}
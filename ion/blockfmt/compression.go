@@ -105,26 +105,20 @@ func (e *encoderNopCloser) Compress(src, dst []byte) ([]byte, error) {
 //	"zion+zstd" (equivalent to "zion")
 //	"zion+iguana_v0"
 func CompressorByName(algo string) Compressor {
-	return getCompressor(algo)
+	return getCompressor(algo, nil)
 }
 
-func getCompressor(algo string) Compressor {
+// getCompressor is CompressorByName with the
+// list of hot fields of the zion compressors
+// (see zion.WithHotFields)
+func getCompressor(algo string, hot []string) Compressor {
 	switch algo {
 	case "zion+iguana_v0":
-		e := zionEncPool.Get().(*zion.Encoder)
-		e.Reset()
-		e.Algo = zll.CompressIguanaV0
-		return &zionCompressor{enc: e}
+		return newZionCompressor(zll.CompressIguanaV0, hot)
 	case "zion+iguana_v0/specialized":
-		e := zionEncPool.Get().(*zion.Encoder)
-		e.Reset()
-		e.Algo = zll.CompressIguanaV0Specialized
-		return &zionCompressor{enc: e}
+		return newZionCompressor(zll.CompressIguanaV0Specialized, hot)
 	case "zion", "zion+zstd":
-		e := zionEncPool.Get().(*zion.Encoder)
-		e.Reset()
-		e.Algo = zll.CompressZstd
-		return &zionCompressor{enc: e}
+		return newZionCompressor(zll.CompressZstd, hot)
 	default:
 		c := compr.Compression(algo)
 		if c != nil {
@@ -134,6 +128,14 @@ func getCompressor(algo string) Compressor {
 	}
 }
 
+func newZionCompressor(algo zll.BucketAlgo, hot []string) *zionCompressor {
+	e := zionEncPool.Get().(*zion.Encoder)
+	e.Reset()
+	e.Algo = algo
+	zion.WithHotFields(hot...)(e)
+	return &zionCompressor{enc: e}
+}
+
 // CompressionWriter is a single-stream
 // io.Writer that accepts blocks from an
 // ion.Chunker and concatenates and compresses
@@ -264,7 +266,7 @@ func pickPrefix(t *Trailer, minchunks int) (index int, offset int64) {
 // consume maybe *some* of an existing object
 // without doing any heavy lifting w.r.t compression
 func (w *CompressionWriter) writeStart(r io.Reader, t *Trailer) error {
-	if t.Algo != w.Comp.Name() || 1<<t.BlockShift != w.InputAlign {
+	if t.Algo != w.Comp.Name() || t.Dict != dictID(compressorDict(w.Comp)) || 1<<t.BlockShift != w.InputAlign {
 		return nil // not directly compatible
 	}
 	j, offset := pickPrefix(t, w.MinChunksPerBlock)
@@ -387,6 +389,7 @@ func (w *CompressionWriter) Close() error {
 	}
 	finalize(&w.Trailer, w.blocks, w.MinChunksPerBlock)
 	w.Trailer.Offset = w.offset
+	w.Trailer.setDict(compressorDict(w.Comp))
 	trailer := w.Trailer.trailer(w.Comp.Name(), w.InputAlign)
 	w.offset += int64(len(trailer))
 	w.buffer = append(w.buffer, trailer...)
@@ -403,7 +406,7 @@ func (t *Trailer) trailer(compname string, align int) []byte {
 	t.Algo = compname
 	t.BlockShift = bits.TrailingZeros(uint(align))
 
-	t.Encode(&buf, &st)
+	t.encode(&buf, &st, true)
 	tail := buf.Bytes()
 	buf.Set(nil)
 	st.Marshal(&buf, true)
@@ -624,6 +627,13 @@ type Decoder struct {
 	// the input data blocks.
 	// Algo is set automatically by Decoder.Set.
 	Algo string
+	// Dict is the ID of the dictionary used
+	// to compress the input data blocks, if any.
+	// Dict is set automatically by Decoder.Set.
+	Dict string
+	// Dictionary is the dictionary with the ID Dict.
+	// Dictionary is set automatically by Decoder.Set.
+	Dictionary *Dictionary
 
 	// Fields is the dereference push-down hint
 	// for the fields that should be decompressed
@@ -641,16 +651,24 @@ type Decoder struct {
 	// data allocated via Malloc.
 	Free func([]byte)
 
+	// Checksums, if set, causes the checksums
+	// of compressed frames to be verified during
+	// decompression for compression algorithms
+	// that store them.
+	Checksums bool
+
 	decomp decompressor
 	frame  [5]byte
 	tmp    []byte
 }
 
-// Set copies the [Algo] and [BlockShift] fields
-// from [t] into [d].
+// Set copies the [Algo], [Dict], [Dictionary]
+// and [BlockShift] fields from [t] into [d].
 func (d *Decoder) Set(t *Trailer) {
 	d.BlockShift = t.BlockShift
 	d.Algo = t.Algo
+	d.Dict = t.Dict
+	d.Dictionary = t.Dictionary
 }
 
 func (d *Decoder) realloc(size int) []byte {
@@ -733,9 +751,15 @@ func (d *Decoder) setupZion(dec *zion.Decoder) {
 	} else {
 		dec.SetComponents(d.Fields)
 	}
+	dec.SetVerifyChecksums(d.Checksums)
 }
 
 func (d *Decoder) getDecomp(algo string) error {
+	if d.Dict != "" {
+		var err error
+		d.decomp, err = getDictAlgo(algo, d.Dict, d.Dictionary)
+		return err
+	}
 	d.decomp = getAlgo(algo)
 	if d.decomp == nil {
 		return fmt.Errorf("decompression %q not supported", d.Algo)
@@ -856,7 +880,7 @@ func (d *Decoder) CopyBytes(dst io.Writer, src []byte) (int64, error) {
 	// this path is performance-sensitive,
 	// so disable xxhash checking in zstd
 	// (costs about 15% of total time!)
-	if algo == "zstd" {
+	if algo == "zstd" && !d.Checksums {
 		algo = "zstd-nocrc"
 	}
 	err := d.getDecomp(algo)
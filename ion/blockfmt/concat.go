@@ -54,6 +54,7 @@ func (c *concat) add(src *Descriptor) bool {
 	if len(c.inputs) == 0 {
 		c.output.Format = src.Format
 		dt.Algo = t.Algo
+		dt.Dict = t.Dict
 		dt.Version = t.Version
 		dt.BlockShift = t.BlockShift
 		dt.Sparse = t.Sparse.Clone()
@@ -62,6 +63,7 @@ func (c *concat) add(src *Descriptor) bool {
 		// ensure trailer is compatible
 		if t.Version != dt.Version ||
 			t.Algo != dt.Algo ||
+			t.Dict != dt.Dict ||
 			t.BlockShift != dt.BlockShift ||
 			!dt.Sparse.Append(&t.Sparse) {
 			return false
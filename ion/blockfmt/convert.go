@@ -15,6 +15,7 @@
 package blockfmt
 
 import (
+	"bufio"
 	"bytes"
 	"compress/flate"
 	"compress/gzip"
@@ -216,23 +217,53 @@ type ionConverter struct{}
 
 func (i ionConverter) Name() string { return "ion" }
 
+// gzipMagic is the beginning of a gzip stream
+// (the magic number and the deflate method byte)
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
 func (i ionConverter) Convert(r io.Reader, dst *ion.Chunker, cons []ion.Field) error {
-	_, err := dst.ReadFrom(r, cons)
+	// ion data may be gzip-compressed regardless
+	// of the object name; a gzip stream cannot be
+	// confused with an ion stream, since ion data
+	// begins with a BVM or a structure
+	br := bufio.NewReader(r)
+	var rc io.Reader = br
+	var gz *gzip.Reader
+	if magic, _ := br.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		var err error
+		gz, err = gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("converting UnsafeION: %w", err)
+		}
+		rc = gz
+	}
+	_, err := dst.ReadFrom(rc, cons)
 	if err != nil {
 		return fmt.Errorf("converting UnsafeION: %w", err)
 	}
+	if gz != nil {
+		// check the integrity of the gzip stream
+		// (see jsonConverter.Convert)
+		return gz.Close()
+	}
 	return nil
 }
 
 // UnsafeION converts raw ion by
 // decoding and re-encoding it.
+// The ion data may be gzip-compressed;
+// compression is detected from the data
+// rather than from the object name.
 //
 // NOTE: UnsafeION is called UnsafeION
 // because the ion package has not been
 // hardened against arbitrary user input.
 // FIXME: harden the ion package against
 // malicious input and then rename this
-// to something else.
+// to something else. Until then, no suffix
+// in SuffixToFormat maps to UnsafeION, so
+// callers have to opt in to it explicitly
+// (for example, with db.Config.Fallback).
 func UnsafeION() RowFormat {
 	return ionConverter{}
 }
@@ -254,6 +285,16 @@ func isCompressed(r RowFormat) bool {
 // objects.
 var SuffixToFormat = make(map[string]func(hints []byte) (RowFormat, error))
 
+// xsvHints parses CSV/TSV hints; without hints
+// the first record is treated as a header and
+// the fields are inferred from it
+func xsvHints(h []byte) (*xsv.Hint, error) {
+	if h == nil {
+		return &xsv.Hint{Header: true}, nil
+	}
+	return xsv.ParseHint(h)
+}
+
 func MustSuffixToFormat(suffix string) RowFormat {
 	f := SuffixToFormat[suffix]
 	if f == nil {
@@ -322,10 +363,7 @@ func init() {
 		decName := dn
 		decomp := dc
 		SuffixToFormat[".csv"+decName] = func(h []byte) (RowFormat, error) {
-			if h == nil {
-				return nil, errors.New("CSV requires hints")
-			}
-			hints, err := xsv.ParseHint(h)
+			hints, err := xsvHints(h)
 			if err != nil {
 				return nil, err
 			}
@@ -336,6 +374,7 @@ func init() {
 				ch: &xsv.CsvChopper{
 					SkipRecords: hints.SkipRecords,
 					Separator:   hints.Separator,
+					Quote:       hints.Quote,
 				},
 			}, nil
 		}
@@ -346,16 +385,16 @@ func init() {
 		decName := dn
 		decomp := dc
 		SuffixToFormat[".tsv"+decName] = func(h []byte) (RowFormat, error) {
-			if h == nil {
-				return nil, errors.New("TSV requires hints")
-			}
-			hints, err := xsv.ParseHint(h)
+			hints, err := xsvHints(h)
 			if err != nil {
 				return nil, err
 			}
 			if hints.Separator != 0 && hints.Separator != '\t' {
 				return nil, errors.New("TSV doesn't support a custom separator")
 			}
+			if hints.Quote != 0 {
+				return nil, errors.New("TSV doesn't support quoting")
+			}
 			return &xsvConverter{
 				name:   "tsv" + decName,
 				decomp: decomp,
@@ -401,6 +440,9 @@ type Converter struct {
 		// Converter will read bytes up to Trailer.Offset.
 		R       io.ReadCloser
 		Trailer *Trailer
+		// Rows is the number of rows in the
+		// prepended data, or zero if it is not known.
+		Rows int64
 	}
 	// Constants is the list of templated constants
 	// to be inserted into the ingested data.
@@ -419,6 +461,16 @@ type Converter struct {
 	// Comp is the name of the compression
 	// algorithm used for uploaded data blocks.
 	Comp string
+	// Dict, if non-nil, is the dictionary used
+	// to compress uploaded data blocks.
+	// Only the "zstd" algorithm supports dictionaries.
+	// (Prepend may have been compressed with
+	// a different dictionary or none at all.)
+	Dict *Dictionary
+	// HotFields is the list of top-level fields
+	// that are compressed for cheaper decompression
+	// by the zion algorithms. (See zion.WithHotFields.)
+	HotFields []string
 	// Align is the pre-compression alignment
 	// of chunks written to the uploader.
 	Align int
@@ -456,9 +508,47 @@ type Converter struct {
 	// prefetching of inputs.
 	DisablePrefetch bool
 
+	// CheckUTF8, if true, causes ion inputs
+	// containing strings that are not valid UTF-8
+	// to be rejected with an *ion.UTF8Error.
+	// (JSON inputs are always validated.)
+	CheckUTF8 bool
+
+	// EmptyAsMissing, if true, causes fields with
+	// empty string values to be removed from the rows,
+	// so that they are MISSING when queried.
+	// See ion.Chunker.EmptyAsMissing.
+	EmptyAsMissing bool
+
+	// Schema, if non-nil, accumulates the fields
+	// of the rows of Inputs (but not the rows
+	// of Prepend) that are written to Output.
+	Schema *Schema
+	// Stats, if non-nil, accumulates column
+	// statistics for the same rows as Schema.
+	// The statistics are only hints, so a row
+	// that can't be added to Stats does not stop
+	// the conversion; instead, Stats is left
+	// unchanged and StatsErr returns the error.
+	Stats *Stats
+	// StringRanges, if true, causes the ranges
+	// of the string values of the fields of the
+	// rows to be recorded in the sparse index of
+	// the output, so that queries comparing a field
+	// with a string can skip the whole object.
+	// The ranges are not recorded if the rows have
+	// more than MaxStatsColumns fields with string
+	// values or if Prepend is set and the ranges
+	// of the prepended object are not known.
+	StringRanges bool
+
 	// trailer built by the writer. This is only
 	// set if the object was written successfully.
 	trailer *Trailer
+	// first error from adding a row to Stats
+	statsErr error
+	// number of rows of Inputs written to Output
+	rows int64
 }
 
 // static errors known to be fatal to decoding
@@ -501,7 +591,8 @@ func IsFatal(err error) bool {
 		}
 	}
 	var cie flate.CorruptInputError
-	return errors.As(err, &cie)
+	var uerr *ion.UTF8Error
+	return errors.As(err, &cie) || errors.As(err, &uerr)
 }
 
 func (c *Converter) parallel() int {
@@ -584,9 +675,9 @@ func (c *Converter) runSingle() error {
 	if cname == "zstd" {
 		cname = "zstd-better"
 	}
-	comp := getCompressor(cname)
-	if comp == nil {
-		return fmt.Errorf("compression %q unavailable", c.Comp)
+	comp, err := getDictCompressor(cname, c.Dict, c.HotFields)
+	if err != nil {
+		return err
 	}
 	w := &CompressionWriter{
 		Output:     c.Output,
@@ -601,11 +692,13 @@ func (c *Converter) runSingle() error {
 		w.Trailer.Sparse.consts = ion.NewStruct(nil, c.Constants)
 	}
 	cn := ion.Chunker{
-		W:          w,
-		Align:      w.InputAlign,
-		RangeAlign: c.FlushMeta,
+		W:              w,
+		Align:          w.InputAlign,
+		RangeAlign:     c.FlushMeta,
+		CheckUTF8:      c.CheckUTF8,
+		EmptyAsMissing: c.EmptyAsMissing,
 	}
-	err := c.fastPrepend(w)
+	err = c.fastPrepend(w)
 	if err != nil {
 		return err
 	}
@@ -613,6 +706,16 @@ func (c *Converter) runSingle() error {
 	if err != nil {
 		return err
 	}
+	c.rows = 0
+	var sr *stringRanges
+	if c.StringRanges {
+		sr = new(stringRanges)
+	}
+	var stats []statsCollector
+	if c.Stats != nil {
+		stats = make([]statsCollector, 1)
+	}
+	cn.OnCommit = onCommit(c.Schema, stats, sr, &c.rows)
 	ready := make([]chan struct{}, len(c.Inputs))
 	next := 1
 	inflight := int64(0) // # bytes being prefetched
@@ -673,7 +776,11 @@ func (c *Converter) runSingle() error {
 	if err != nil {
 		return err
 	}
+	c.setStrings(&w.Trailer, sr)
 	err = w.Close()
+	if err == nil {
+		c.mergeStats(stats)
+	}
 	c.trailer = &w.Trailer
 	return err
 }
@@ -811,13 +918,15 @@ func (c *Converter) runMulti(p int) error {
 	if cname == "zstd" {
 		cname = "zstd-better"
 	}
-	comp := getCompressor(cname)
+	comp := getCompressor(cname, nil)
 	if comp == nil {
 		return fmt.Errorf("compression %q unavailable", c.Comp)
 	}
 	w := &MultiWriter{
 		Output:     c.Output,
 		Algo:       c.Comp,
+		Dict:       c.Dict,
+		HotFields:  c.HotFields,
 		InputAlign: c.Align,
 		TargetSize: c.TargetSize,
 		// try to make the blocks at least
@@ -853,6 +962,19 @@ func (c *Converter) runMulti(p int) error {
 			in.R.Close()
 		}
 	}
+	var schemas []Schema
+	if c.Schema != nil {
+		schemas = make([]Schema, p)
+	}
+	var stats []statsCollector
+	if c.Stats != nil {
+		stats = make([]statsCollector, p)
+	}
+	var ranges []stringRanges
+	if c.StringRanges {
+		ranges = make([]stringRanges, p)
+	}
+	rows := make([]int64, p)
 	for i := 0; i < p; i++ {
 		wc, err := w.Open()
 		if err != nil {
@@ -861,9 +983,11 @@ func (c *Converter) runMulti(p int) error {
 		}
 		go func(i int) {
 			cn := ion.Chunker{
-				W:          wc,
-				Align:      w.InputAlign,
-				RangeAlign: c.FlushMeta,
+				W:              wc,
+				Align:          w.InputAlign,
+				RangeAlign:     c.FlushMeta,
+				CheckUTF8:      c.CheckUTF8,
+				EmptyAsMissing: c.EmptyAsMissing,
 			}
 			if i == 0 {
 				err := c.runPrepend(&cn)
@@ -873,6 +997,19 @@ func (c *Converter) runMulti(p int) error {
 					return
 				}
 			}
+			var schema *Schema
+			if schemas != nil {
+				schema = &schemas[i]
+			}
+			var stat []statsCollector
+			if stats != nil {
+				stat = stats[i : i+1]
+			}
+			var sr *stringRanges
+			if ranges != nil {
+				sr = &ranges[i]
+			}
+			cn.OnCommit = onCommit(schema, stat, sr, &rows[i])
 			for in := range startc {
 				err := in.F.Convert(in.R, &cn, slices.Clone(c.Constants))
 				err2 := in.R.Close()
@@ -919,13 +1056,129 @@ func (c *Converter) runMulti(p int) error {
 	}
 	// don't finalize unless everything
 	// up to this point succeeded
+	var sr *stringRanges
+	if ranges != nil {
+		sr = &ranges[0]
+		for i := range ranges[1:] {
+			sr.merge(&ranges[i+1])
+		}
+	}
+	c.setStrings(&w.Trailer, sr)
 	if err := w.Close(); err != nil {
 		return err
 	}
+	for i := range schemas {
+		c.Schema.Merge(&schemas[i])
+	}
+	c.mergeStats(stats)
+	c.rows = 0
+	for i := range rows {
+		c.rows += rows[i]
+	}
 	c.trailer = &w.Trailer
 	return nil
 }
 
+// statsCollector collects the statistics
+// of the rows of one stream of a Converter
+type statsCollector struct {
+	stats Stats
+	err   error
+}
+
+// add adds a row to s.stats until the first
+// error, which is recorded rather than returned
+func (s *statsCollector) add(st *ion.Symtab, rec []byte) {
+	if s.err == nil {
+		s.err = s.stats.Add(st, rec)
+	}
+}
+
+// mergeStats merges the statistics of each
+// stream into c.Stats unless any stream failed
+// to collect them, in which case c.Stats is
+// left unchanged and c.statsErr is set
+func (c *Converter) mergeStats(lst []statsCollector) {
+	for i := range lst {
+		if lst[i].err != nil {
+			c.statsErr = lst[i].err
+			return
+		}
+	}
+	for i := range lst {
+		c.Stats.Merge(&lst[i].stats)
+	}
+}
+
+// StatsErr returns the error that prevented
+// the statistics of the rows from being added
+// to Stats, or nil if they were added.
+func (c *Converter) StatsErr() error {
+	return c.statsErr
+}
+
+// onCommit returns the ion.Chunker.OnCommit
+// hook that counts each row in rows and
+// adds it to schema, stats and ranges, any
+// of which may be nil (stats holds at most
+// one collector)
+func onCommit(schema *Schema, stats []statsCollector, ranges *stringRanges, rows *int64) func(*ion.Symtab, []byte) error {
+	return func(st *ion.Symtab, rec []byte) error {
+		*rows++
+		if schema != nil {
+			if err := schema.Add(st, rec); err != nil {
+				return err
+			}
+		}
+		if ranges != nil {
+			if err := ranges.add(st, rec); err != nil {
+				return err
+			}
+		}
+		if len(stats) > 0 {
+			stats[0].add(st, rec)
+		}
+		return nil
+	}
+}
+
+// setStrings sets the string ranges of the sparse
+// index of t from the ranges collected from the rows
+// of Inputs (sr, which is nil unless StringRanges is set)
+// and those of the prepended object, whose rows are
+// not collected
+func (c *Converter) setStrings(t *Trailer, sr *stringRanges) {
+	if sr == nil || sr.overflow {
+		t.Sparse.clearStrings()
+		return
+	}
+	lst := sr.list()
+	if c.Prepend.R != nil {
+		// (the blocks of the prepended object may
+		// already have been copied by fastPrepend,
+		// but its string ranges are kept)
+		prev := &c.Prepend.Trailer.Sparse
+		if !prev.hasStrings {
+			t.Sparse.clearStrings()
+			return
+		}
+		lst = unionStrings(lst, prev.strings)
+	}
+	t.Sparse.setStrings(lst)
+}
+
 func (c *Converter) Trailer() *Trailer {
 	return c.trailer
 }
+
+// Rows returns the number of rows written
+// to Output, including the rows of Prepend.
+// Rows returns zero if the conversion did not
+// succeed or if Prepend.R was set and
+// Prepend.Rows was not.
+func (c *Converter) Rows() int64 {
+	if c.trailer == nil || (c.Prepend.R != nil && c.Prepend.Rows == 0) {
+		return 0
+	}
+	return c.Prepend.Rows + c.rows
+}
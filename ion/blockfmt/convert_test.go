@@ -88,7 +88,10 @@ func testConvertMulti(t *testing.T, algo string, meta int) {
 	if algo != "zion" && c.Trailer().Algo != algo {
 		t.Errorf("trailer algo is %q ???", c.Trailer().Algo)
 	}
-	check(t, &out)
+	rows := check(t, &out)
+	if c.Rows() != int64(rows) {
+		t.Errorf("Rows() = %d, but %d rows were written", c.Rows(), rows)
+	}
 }
 
 func TestConvertMulti(t *testing.T) {
@@ -189,7 +192,10 @@ func TestConvertSingle(t *testing.T) {
 				if algo != "zion" && c.Trailer().Algo != algo {
 					t.Errorf("trailer algo is %q ???", c.Trailer().Algo)
 				}
-				check(t, &out)
+				rows := check(t, &out)
+				if c.Rows() != int64(rows) {
+					t.Errorf("Rows() = %d, but %d rows were written", c.Rows(), rows)
+				}
 			})
 		}
 	}
@@ -218,6 +224,79 @@ func TestConvertEmpty(t *testing.T) {
 	check(t, &out)
 }
 
+// CSV without hints infers the
+// fields from the header
+func TestConvertCSVHeader(t *testing.T) {
+	text := "id,name\n1,\"a\nb\"\n2,c\n"
+	inputs := []Input{{
+		R: io.NopCloser(strings.NewReader(text)),
+		F: MustSuffixToFormat(".csv"),
+	}}
+	var out BufferUploader
+	out.PartSize = 4096
+	c := Converter{
+		Output: &out,
+		Comp:   "zstd",
+		Inputs: inputs,
+		Align:  4096,
+	}
+	err := c.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := check(t, &out); n != 2 {
+		t.Errorf("got %d rows, want 2", n)
+	}
+
+	_, err = SuffixToFormat[".tsv"]([]byte(`{"quote": "'"}`))
+	if err == nil {
+		t.Error("TSV accepted a quote character")
+	}
+}
+
+// gzip-compressed ion is detected
+// independently of the object name
+func TestConvertIONGzip(t *testing.T) {
+	// ion is only converted on request
+	for _, suff := range []string{".ion", ".ion.gz"} {
+		if SuffixToFormat[suff] != nil {
+			t.Errorf("suffix %s is registered", suff)
+		}
+	}
+	rows := -1
+	for _, compress := range []bool{false, true} {
+		f, err := os.Open("../../testdata/parking2.ion")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r io.ReadCloser = f
+		if compress {
+			r = io.NopCloser(gzipped(f))
+		}
+		var out BufferUploader
+		out.PartSize = 4096
+		c := Converter{
+			Output: &out,
+			Comp:   "zstd",
+			Inputs: []Input{{R: r, F: UnsafeION()}},
+			Align:  4096,
+		}
+		err = c.Run()
+		if err != nil {
+			t.Fatalf("gzip: %v: %s", compress, err)
+		}
+		n := check(t, &out)
+		if rows == -1 {
+			rows = n
+		} else if n != rows {
+			t.Errorf("gzip: %v: got %d rows, want %d", compress, n, rows)
+		}
+	}
+	if rows <= 0 {
+		t.Fatalf("got %d rows", rows)
+	}
+}
+
 // the error produced by trying to convert
 // an empty *.gz file should be fatal
 func TestConvertEmptyGZ(t *testing.T) {
@@ -0,0 +1,349 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blockfmt
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/SnellerInc/sneller/ion"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// MinDictionarySize is the smallest dictionary
+// size accepted by TrainDictionary.
+const MinDictionarySize = 1024
+
+// ErrUnknownDictionary is returned when decoding
+// blocks that were compressed with a dictionary
+// that is not available to the decoder.
+var ErrUnknownDictionary = errors.New("blockfmt: unknown dictionary")
+
+// Dictionary is a zstd dictionary that is shared
+// by the compressed blocks of the objects in a table.
+//
+// Blocks that are compressed with a dictionary
+// record the ID of the dictionary in Trailer.Dict.
+// The trailer at the end of each object holds
+// the dictionary itself, so an object can always
+// be decoded on its own. The trailers of the
+// descriptors in an index only hold the ID, and
+// DecodeIndex sets their Trailer.Dictionary to
+// the Dictionary of the index.
+type Dictionary struct {
+	// Version is the version of the dictionary
+	// within the index that it belongs to.
+	// Each newly-trained dictionary for a table
+	// should have a higher version than the last.
+	Version int
+	// Data is the encoded zstd dictionary.
+	// Data must not be modified once ID
+	// has been called.
+	Data []byte
+
+	idOnce sync.Once
+	id     string
+}
+
+// ID returns a string that uniquely
+// identifies the contents of d.
+func (d *Dictionary) ID() string {
+	d.idOnce.Do(func() {
+		sum := sha256.Sum256(d.Data)
+		d.id = hex.EncodeToString(sum[:16])
+	})
+	return d.id
+}
+
+// maxDictCodecs is the maximum number of
+// dictionaries with cached encoders and decoders
+const maxDictCodecs = 16
+
+// dictCodecs are the encoder and decoders
+// for one dictionary; each is created on first use
+type dictCodecs struct {
+	id   string
+	data []byte
+
+	encOnce sync.Once
+	encErr  error
+	enc     *zstd.Encoder
+
+	decOnce sync.Once
+	decErr  error
+	dec     *zstd.Decoder
+	fastdec *zstd.Decoder
+}
+
+func (c *dictCodecs) encoder() (*zstd.Encoder, error) {
+	c.encOnce.Do(func() {
+		c.enc, c.encErr = zstd.NewWriter(nil,
+			zstd.WithEncoderLevel(zstd.SpeedBetterCompression),
+			zstd.WithEncoderDict(c.data))
+	})
+	return c.enc, c.encErr
+}
+
+func (c *dictCodecs) decoder(checksums bool) (*zstd.Decoder, error) {
+	c.decOnce.Do(func() {
+		c.dec, c.decErr = zstd.NewReader(nil,
+			zstd.WithDecoderConcurrency(runtime.GOMAXPROCS(0)),
+			zstd.WithDecoderDicts(c.data))
+		if c.decErr != nil {
+			return
+		}
+		c.fastdec, c.decErr = zstd.NewReader(nil,
+			zstd.WithDecoderConcurrency(runtime.GOMAXPROCS(0)),
+			zstd.WithDecoderDicts(c.data),
+			zstd.IgnoreChecksum(true))
+	})
+	if checksums {
+		return c.dec, c.decErr
+	}
+	return c.fastdec, c.decErr
+}
+
+// codecCache holds the codecs of the most
+// recently used dictionaries, since creating
+// them is much more expensive than using them
+//
+// an evicted entry remains usable by the
+// encoders and decoders that still refer to it
+var codecCache struct {
+	lock sync.Mutex
+	lru  list.List // of *dictCodecs; front is most recent
+	byID map[string]*list.Element
+}
+
+// codecs returns the cached codecs for d
+func (d *Dictionary) codecs() *dictCodecs {
+	id := d.ID()
+	codecCache.lock.Lock()
+	defer codecCache.lock.Unlock()
+	if e, ok := codecCache.byID[id]; ok {
+		codecCache.lru.MoveToFront(e)
+		return e.Value.(*dictCodecs)
+	}
+	if codecCache.byID == nil {
+		codecCache.byID = make(map[string]*list.Element)
+	}
+	c := &dictCodecs{id: id, data: d.Data}
+	codecCache.byID[id] = codecCache.lru.PushFront(c)
+	for codecCache.lru.Len() > maxDictCodecs {
+		last := codecCache.lru.Back()
+		codecCache.lru.Remove(last)
+		delete(codecCache.byID, last.Value.(*dictCodecs).id)
+	}
+	return c
+}
+
+// dictID returns the ID of dict,
+// or "" if dict is nil
+func dictID(dict *Dictionary) string {
+	if dict == nil {
+		return ""
+	}
+	return dict.ID()
+}
+
+// Encode encodes d into dst.
+func (d *Dictionary) Encode(dst *ion.Buffer, st *ion.Symtab) {
+	dst.BeginStruct(-1)
+	dst.BeginField(st.Intern("version"))
+	dst.WriteInt(int64(d.Version))
+	dst.BeginField(st.Intern("data"))
+	dst.WriteBlob(d.Data)
+	dst.EndStruct()
+}
+
+// Decode decodes a dictionary
+// produced by Encode into d.
+// The decoded dictionary does not
+// alias the memory of v.
+func (d *Dictionary) Decode(v ion.Datum) error {
+	*d = Dictionary{}
+	err := v.UnpackStruct(func(f ion.Field) error {
+		switch f.Label {
+		case "version":
+			n, err := f.Int()
+			d.Version = int(n)
+			return err
+		case "data":
+			var err error
+			d.Data, err = f.Blob()
+			return err
+		default:
+			return fmt.Errorf("unexpected field %q", f.Label)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if len(d.Data) == 0 {
+		return fmt.Errorf("dictionary missing \"data\"")
+	}
+	return nil
+}
+
+// TrainDictionary builds a zstd dictionary of
+// at most size bytes from samples of decompressed
+// block data (i.e. chunks produced by ion.Chunker).
+// The returned dictionary has a Version of zero.
+func TrainDictionary(samples [][]byte, size int) (dict *Dictionary, err error) {
+	if size < MinDictionarySize {
+		return nil, fmt.Errorf("blockfmt.TrainDictionary: size %d below minimum %d", size, MinDictionarySize)
+	}
+	samples = slices.DeleteFunc(slices.Clone(samples), func(b []byte) bool {
+		return len(b) == 0
+	})
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("blockfmt.TrainDictionary: no samples")
+	}
+	// the dictionary content is taken from the start
+	// of each sample, since that is where each chunk
+	// stores its symbol table, which is typically
+	// identical or nearly identical across chunks
+	per := max(size/len(samples), 1)
+	var hist []byte
+	for i := range samples {
+		n := min(per, len(samples[i]), size-len(hist))
+		hist = append(hist, samples[i][:n]...)
+	}
+	if len(hist) < 8 {
+		return nil, fmt.Errorf("blockfmt.TrainDictionary: samples too small")
+	}
+	// zstd.BuildDict divides by zero when the
+	// samples yield too few sequences
+	defer func() {
+		if e := recover(); e != nil {
+			dict, err = nil, fmt.Errorf("blockfmt.TrainDictionary: samples too uniform (%v)", e)
+		}
+	}()
+	sum := sha256.Sum256(hist)
+	data, err := zstd.BuildDict(zstd.BuildDictOptions{
+		// keep the ID out of the range
+		// reserved by the zstd format
+		ID:       binary.LittleEndian.Uint32(sum[:])&0x7fffffff | 0x8000,
+		Contents: samples,
+		History:  hist,
+		Offsets:  [3]int{1, 4, 8},
+		Level:    zstd.SpeedBetterCompression,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("blockfmt.TrainDictionary: %w", err)
+	}
+	return &Dictionary{Data: data}, nil
+}
+
+// dictCompressor is a zstd Compressor
+// that uses a Dictionary
+type dictCompressor struct {
+	dict *Dictionary
+	enc  *zstd.Encoder
+}
+
+func (d *dictCompressor) Name() string { return "zstd" }
+
+func (d *dictCompressor) Compress(src, dst []byte) ([]byte, error) {
+	return d.enc.EncodeAll(src, dst), nil
+}
+
+func (d *dictCompressor) Close() error { return nil }
+
+// getDictCompressor is identical to getCompressor
+// when dict is nil; otherwise it returns a Compressor
+// that uses dict, which is only supported by zstd
+func getDictCompressor(algo string, dict *Dictionary, hot []string) (Compressor, error) {
+	if dict == nil {
+		c := getCompressor(algo, hot)
+		if c == nil {
+			return nil, fmt.Errorf("compression %q unavailable", algo)
+		}
+		return c, nil
+	}
+	if algo != "zstd" && algo != "zstd-better" {
+		return nil, fmt.Errorf("compression %q does not support dictionaries", algo)
+	}
+	enc, err := dict.codecs().encoder()
+	if err != nil {
+		return nil, err
+	}
+	return &dictCompressor{dict: dict, enc: enc}, nil
+}
+
+// compressorDict returns the dictionary
+// used by c, if any
+func compressorDict(c Compressor) *Dictionary {
+	if d, ok := c.(*dictCompressor); ok {
+		return d.dict
+	}
+	return nil
+}
+
+// setDict sets t.Dict and t.Dictionary to dict
+func (t *Trailer) setDict(dict *Dictionary) {
+	t.Dictionary = dict
+	t.Dict = ""
+	if dict != nil {
+		t.Dict = dict.ID()
+	}
+}
+
+type dictDecompressor struct {
+	dec *zstd.Decoder
+}
+
+func (d dictDecompressor) Close() error { return nil }
+
+func (d dictDecompressor) Decompress(src, dst []byte) error {
+	into := dst[:0:len(dst)]
+	ret, err := d.dec.DecodeAll(src, into)
+	if err != nil {
+		return err
+	}
+	if len(ret) != len(dst) {
+		return fmt.Errorf("expected %d bytes decompressed; got %d", len(dst), len(ret))
+	}
+	// the decoder should not have had to
+	// realloc the buffer
+	if &ret[0] != &dst[0] {
+		return fmt.Errorf("zstd decompress: output buffer realloc'd")
+	}
+	return nil
+}
+
+// getDictAlgo returns the decompressor for algo
+// using dict, which must have the given ID
+func getDictAlgo(algo, id string, dict *Dictionary) (decompressor, error) {
+	if algo != "zstd" && algo != "zstd-nocrc" {
+		return nil, fmt.Errorf("decompression %q does not support dictionaries", algo)
+	}
+	if dict == nil || dict.ID() != id {
+		return nil, fmt.Errorf("%w %s", ErrUnknownDictionary, id)
+	}
+	dec, err := dict.codecs().decoder(algo != "zstd-nocrc")
+	if err != nil {
+		return nil, err
+	}
+	return dictDecompressor{dec}, nil
+}
@@ -0,0 +1,294 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blockfmt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+type chunkCollector struct {
+	chunks [][]byte
+}
+
+func (c *chunkCollector) Write(p []byte) (int, error) {
+	c.chunks = append(c.chunks, slices.Clone(p))
+	return len(p), nil
+}
+
+func convertParking(t *testing.T, dict *Dictionary, parallel int) *BufferUploader {
+	var inputs []Input
+	for _, name := range []string{"parking2.json", "parking3.json"} {
+		f, err := os.Open("../../testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, Input{
+			R: f,
+			F: MustSuffixToFormat(".json"),
+		})
+	}
+	var out BufferUploader
+	align := 2048
+	out.PartSize = 4 * align
+	c := Converter{
+		Output:    &out,
+		Comp:      "zstd",
+		Dict:      dict,
+		Inputs:    inputs,
+		Align:     align,
+		FlushMeta: 4 * align,
+		Parallel:  parallel,
+	}
+	err := c.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ""
+	if dict != nil {
+		want = dict.ID()
+	}
+	if got := c.Trailer().Dict; got != want {
+		t.Fatalf("trailer dict %q, want %q", got, want)
+	}
+	return &out
+}
+
+func decodeAll(t *testing.T, buf []byte) [][]byte {
+	r := bytes.NewReader(buf)
+	trailer, err := ReadTrailer(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d Decoder
+	d.Set(trailer)
+	var cc chunkCollector
+	_, err = d.Copy(&cc, io.LimitReader(r, trailer.Offset))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cc.chunks
+}
+
+func TestDictionary(t *testing.T) {
+	plain := convertParking(t, nil, 1)
+	check(t, plain)
+	want := decodeAll(t, plain.Bytes())
+
+	dict, err := TrainDictionary(want, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dict.Data) > 4096+1024 {
+		t.Errorf("dictionary has %d bytes", len(dict.Data))
+	}
+
+	for _, parallel := range []int{1, 2} {
+		out := convertParking(t, dict, parallel)
+		check(t, out)
+		if parallel == 1 {
+			got := decodeAll(t, out.Bytes())
+			if len(got) != len(want) {
+				t.Fatalf("got %d chunks, want %d", len(got), len(want))
+			}
+			for i := range got {
+				if !bytes.Equal(got[i], want[i]) {
+					t.Fatalf("chunk %d not equal", i)
+				}
+			}
+		}
+		if out.Size() >= plain.Size() {
+			t.Errorf("parallel=%d: %d bytes with dictionary; %d bytes without", parallel, out.Size(), plain.Size())
+		}
+	}
+
+	// an object can be decoded on its own,
+	// since its trailer holds the dictionary
+	out := convertParking(t, dict, 1)
+	resetDictCodecs()
+	r := bytes.NewReader(out.Bytes())
+	trailer, err := ReadTrailer(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trailer.Dictionary == nil || !bytes.Equal(trailer.Dictionary.Data, dict.Data) {
+		t.Fatal("trailer does not hold the dictionary")
+	}
+	if got := decodeAll(t, out.Bytes()); len(got) != len(want) {
+		t.Fatalf("got %d chunks, want %d", len(got), len(want))
+	}
+
+	// the trailer encoded in an index only refers
+	// to the dictionary, and blocks compressed with
+	// a dictionary that isn't available can't be decoded
+	var buf ion.Buffer
+	var st ion.Symtab
+	trailer.Encode(&buf, &st)
+	var td TrailerDecoder
+	var ref Trailer
+	if err := td.Decode(datum(t, &st, buf.Bytes()), &ref); err != nil {
+		t.Fatal(err)
+	}
+	if ref.Dict != dict.ID() || ref.Dictionary != nil {
+		t.Fatalf("decoded dict %q with dictionary %v", ref.Dict, ref.Dictionary)
+	}
+	var d Decoder
+	d.Set(&ref)
+	_, err = d.Copy(io.Discard, io.NewSectionReader(r, 0, trailer.Offset))
+	if !errors.Is(err, ErrUnknownDictionary) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	// ... unless the decoder is given the dictionary
+	td = TrailerDecoder{Dictionaries: []*Dictionary{dict}}
+	ref = Trailer{}
+	if err := td.Decode(datum(t, &st, buf.Bytes()), &ref); err != nil {
+		t.Fatal(err)
+	}
+	if ref.Dictionary != dict {
+		t.Fatal("dictionary not resolved by ID")
+	}
+
+	// dictionaries aren't supported by zion
+	c := Converter{
+		Output: &BufferUploader{PartSize: 4096},
+		Comp:   "zion",
+		Dict:   dict,
+		Inputs: []Input{{
+			R: io.NopCloser(bytes.NewReader([]byte(`{"x": 1}`))),
+			F: MustSuffixToFormat(".json"),
+		}},
+		Align: 2048,
+	}
+	if err := c.Run(); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestIndexDictionary(t *testing.T) {
+	var samples [][]byte
+	for i := 0; i < 8; i++ {
+		var buf ion.Buffer
+		var st ion.Symtab
+		var rows []ion.Datum
+		for j := 0; j < 500; j++ {
+			rows = append(rows, ion.NewStruct(&st, []ion.Field{
+				{Label: "index", Datum: ion.Int(int64(i*j + j))},
+				{Label: "name", Datum: ion.String(fmt.Sprintf("sample name %d", j*j))},
+				{Label: "value", Datum: ion.String(fmt.Sprintf("value %x", i*j*7919))},
+			}).Datum())
+		}
+		st.Marshal(&buf, true)
+		for j := range rows {
+			rows[j].Encode(&buf, &st)
+		}
+		samples = append(samples, buf.Bytes())
+	}
+	dict, err := TrainDictionary(samples, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict.Version = 3
+	idx := &Index{
+		Name:       "foo",
+		Created:    date.Now().Truncate(1000),
+		Dictionary: dict,
+		Inline: []Descriptor{{
+			ObjectInfo: ObjectInfo{
+				Path:         "foo/bar",
+				ETag:         "bar",
+				LastModified: date.Now().Truncate(1000),
+				Format:       Version,
+				Size:         100,
+			},
+			Trailer: Trailer{
+				Version:    1,
+				Offset:     100,
+				Algo:       "zstd",
+				BlockShift: 20,
+				Dict:       dict.ID(),
+			},
+		}},
+	}
+	var key Key
+	buf, err := Sign(&key, idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := DecodeIndex(&key, buf, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Dictionary == nil {
+		t.Fatal("no dictionary")
+	}
+	if out.Dictionary.Version != 3 || !bytes.Equal(out.Dictionary.Data, dict.Data) {
+		t.Fatalf("got dictionary version %d with %d bytes", out.Dictionary.Version, len(out.Dictionary.Data))
+	}
+	if len(out.Inline) != 1 || out.Inline[0].Trailer.Dictionary != out.Dictionary {
+		t.Fatal("descriptor dictionary not set by DecodeIndex")
+	}
+}
+
+func TestDictCodecsEvicted(t *testing.T) {
+	resetDictCodecs()
+	defer resetDictCodecs()
+	dicts := make([]*Dictionary, maxDictCodecs+4)
+	codecs := make([]*dictCodecs, len(dicts))
+	for i := range dicts {
+		dicts[i] = &Dictionary{Data: []byte(fmt.Sprintf("dictionary %d", i))}
+		codecs[i] = dicts[i].codecs()
+	}
+	if n := codecCache.lru.Len(); n != maxDictCodecs || len(codecCache.byID) != n {
+		t.Fatalf("%d cached codecs (%d ids)", n, len(codecCache.byID))
+	}
+	// the oldest ones were evicted,
+	// and the newest ones are still cached
+	if dicts[0].codecs() == codecs[0] {
+		t.Fatal("codecs of the first dictionary not evicted")
+	}
+	last := len(dicts) - 1
+	if dicts[last].codecs() != codecs[last] {
+		t.Fatal("codecs of the last dictionary evicted")
+	}
+	// a dictionary with the same contents
+	// shares the codecs
+	same := &Dictionary{Data: slices.Clone(dicts[last].Data)}
+	if same.codecs() != codecs[last] {
+		t.Fatal("codecs not shared")
+	}
+}
+
+func resetDictCodecs() {
+	codecCache.lock.Lock()
+	defer codecCache.lock.Unlock()
+	codecCache.lru.Init()
+	codecCache.byID = nil
+}
+
+func datum(t *testing.T, st *ion.Symtab, buf []byte) ion.Datum {
+	d, _, err := ion.ReadDatum(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
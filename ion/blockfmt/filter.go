@@ -81,9 +81,32 @@ func filtintersect(left, right expr.Node) evalfn {
 	}
 }
 
+// consttime evaluates match against the
+// constant field at path in si; ok is false if
+// there is no such constant, and a constant that
+// is not a timestamp (e.g. NULL) never matches
+func consttime(si *SparseIndex, path []string, match func(t date.Time) bool) (matched, ok bool) {
+	if len(path) != 1 {
+		return false, false
+	}
+	d, ok := si.Const(path[0])
+	if !ok {
+		return false, false
+	}
+	t, err := d.Timestamp()
+	return err == nil && match(t), true
+}
+
 // filter where p <= when
 func filtbeforeeq(path []string, when date.Time) evalfn {
+	match := func(t date.Time) bool { return !t.After(when) }
 	return func(f *Filter, si *SparseIndex, rest cont) {
+		if matched, ok := consttime(si, path, match); ok {
+			if matched {
+				rest(f, 0, si.Blocks())
+			}
+			return
+		}
 		ti := si.Get(path)
 		if ti == nil {
 			rest(f, 0, si.Blocks())
@@ -99,7 +122,14 @@ func filtbeforeeq(path []string, when date.Time) evalfn {
 
 // filter where p >= when
 func filtaftereq(path []string, when date.Time) evalfn {
+	match := func(t date.Time) bool { return !t.Before(when) }
 	return func(f *Filter, si *SparseIndex, rest cont) {
+		if matched, ok := consttime(si, path, match); ok {
+			if matched {
+				rest(f, 0, si.Blocks())
+			}
+			return
+		}
 		ti := si.Get(path)
 		if ti == nil {
 			rest(f, 0, si.Blocks())
@@ -114,7 +144,14 @@ func filtaftereq(path []string, when date.Time) evalfn {
 }
 
 func filtwithin(path []string, when date.Time) evalfn {
+	match := func(t date.Time) bool { return t.Equal(when) }
 	return func(f *Filter, si *SparseIndex, rest cont) {
+		if matched, ok := consttime(si, path, match); ok {
+			if matched {
+				rest(f, 0, si.Blocks())
+			}
+			return
+		}
 		ti := si.Get(path)
 		if ti == nil {
 			rest(f, 0, si.Blocks())
@@ -128,27 +165,79 @@ func filtwithin(path []string, when date.Time) evalfn {
 	}
 }
 
-func filteqstring(p []string, str expr.String) evalfn {
-	if len(p) != 1 {
+// conststring evaluates 'x op str' against the
+// constant field at path in si; ok is false if there
+// is no such constant, and a constant that is not
+// a string or symbol never matches
+func conststring(si *SparseIndex, path []string, op expr.CmpOp, str string) (matched, ok bool) {
+	if len(path) != 1 {
+		return false, false
+	}
+	d, ok := si.Const(path[0])
+	if !ok {
+		return false, false
+	}
+	var x string
+	switch {
+	case d.IsSymbol():
+		x, _ = d.String()
+	case d.IsString():
+		b, _ := d.StringShared()
+		x = string(b)
+	default:
+		return false, true
+	}
+	switch op {
+	case expr.Equals:
+		return x == str, true
+	case expr.Less:
+		return x < str, true
+	case expr.LessEquals:
+		return x <= str, true
+	case expr.Greater:
+		return x > str, true
+	case expr.GreaterEquals:
+		return x >= str, true
+	}
+	return true, true
+}
+
+// filter where p op str; the string ranges
+// are per object, so either all of the
+// blocks or none of them match
+func filtstring(p []string, op expr.CmpOp, str expr.String) evalfn {
+	switch op {
+	case expr.Equals, expr.Less, expr.LessEquals, expr.Greater, expr.GreaterEquals:
+	default:
 		return nil
 	}
-	eq := func(s expr.String, d ion.Datum) bool {
-		if d.IsSymbol() {
-			s2, _ := d.String()
-			return string(s) == s2
+	return func(f *Filter, si *SparseIndex, rest cont) {
+		if matched, ok := conststring(si, p, op, string(str)); ok {
+			if matched {
+				rest(f, 0, si.Blocks())
+			}
+			return
 		}
-		if d.IsString() {
-			s2, _ := d.StringShared()
-			return string(s) == string(s2)
+		if si.matchString(p, op, string(str)) {
+			rest(f, 0, si.Blocks())
 		}
-		return false
 	}
-	name := p[0]
+}
+
+// filter where !(p op str)
+//
+// The string ranges cannot be used here, since
+// fields that are not strings also match; only
+// the constants are exact enough to be negated.
+func filtnotstring(p []string, op expr.CmpOp, str expr.String) evalfn {
+	if filtstring(p, op, str) == nil || len(p) != 1 {
+		return nil
+	}
 	return func(f *Filter, si *SparseIndex, rest cont) {
-		field, ok := si.consts.FieldByName(name)
-		if !ok || eq(str, field.Datum) {
-			rest(f, 0, si.Blocks())
+		if matched, ok := conststring(si, p, op, string(str)); ok && matched {
+			return
 		}
+		rest(f, 0, si.Blocks())
 	}
 }
 
@@ -214,6 +303,13 @@ func filtnegate(e expr.Node) evalfn {
 		//   (A-right AND B-left) OR (A-right AND B-right)
 		return filtintersect(&expr.Not{or.Left}, &expr.Not{or.Right})
 	}
+	if cmp, ok := e.(*expr.Comparison); ok {
+		if str, ok := cmp.Right.(expr.String); ok {
+			if p, ok := expr.FlatPath(cmp.Left); ok {
+				return filtnotstring(p, cmp.Op, str)
+			}
+		}
+	}
 	inner := filtcompile(e)
 	if inner == nil {
 		return nil
@@ -303,13 +399,13 @@ func filtcompile(e expr.Node) evalfn {
 			} else {
 				return nil
 			}
+		} else if str, ok := e.Right.(expr.String); ok {
+			return filtstring(p, e.Op, str)
 		} else if e.Op == expr.Equals {
 			// special handling for row constants
 			switch rhs := e.Right.(type) {
 			case *expr.Timestamp:
 				// continue on to timestamp handling
-			case expr.String:
-				return filteqstring(p, rhs)
 			case expr.Integer:
 				// TODO: support more than just
 				// equality comparisons
@@ -149,6 +149,12 @@ func testFilter(t testing.TB, f *Filter, si *SparseIndex, run func(filt string,
 	}, {
 		Label: "bar",
 		Datum: ion.Int(100),
+	}, {
+		Label: "day",
+		Datum: ion.Timestamp(base),
+	}, {
+		Label: "none",
+		Datum: ion.Null,
 	}})
 	minute := func(i int) string {
 		return "`" + base.Add(time.Minute*time.Duration(i)).Time().Format(time.RFC3339Nano) + "`"
@@ -199,6 +205,12 @@ func testFilter(t testing.TB, f *Filter, si *SparseIndex, run func(filt string,
 	run(sprintf("foo IN ('food', 'bar', 'baz', 'quux', 0, 1, 2, 3, 4, 5, 6)"), [][2]int{{0, 0}})
 	run(sprintf("foo = 'bar'"), [][2]int{{0, 0}})
 	run(sprintf("foo != 'bar'"), [][2]int{{0, 60}})
+	run(sprintf("foo < 'fop'"), [][2]int{{0, 60}})
+	run(sprintf("foo > 'fop'"), [][2]int{{0, 0}})
+	run(sprintf("!(foo >= 'fop')"), [][2]int{{0, 60}})
+	run(sprintf("!(foo <= 'fop')"), [][2]int{{0, 0}})
+	run(sprintf("bar < 'foo'"), [][2]int{{0, 0}})
+	run(sprintf("x < 'foo'"), [][2]int{{0, 60}})
 	run(sprintf("foo.x = 'bar'"), [][2]int{{0, 60}})
 	run(sprintf("foo = 100"), [][2]int{{0, 0}})
 	run(sprintf("bar = 100"), [][2]int{{0, 60}})
@@ -210,4 +222,12 @@ func testFilter(t testing.TB, f *Filter, si *SparseIndex, run func(filt string,
 	run(sprintf("foo = 'foo' and timestamp < %s", minute(10)), [][2]int{{0, 10}})
 	run(sprintf("foo = 'bar' and timestamp < %s", minute(10)), [][2]int{{0, 0}})
 	run(sprintf("timestamp < %s and (foo = 'foo' or foo = 'bar')", minute(10)), [][2]int{{0, 10}})
+	run(sprintf("day = %s", minute(0)), [][2]int{{0, 60}})
+	run(sprintf("day = %s", minute(1)), [][2]int{{0, 0}})
+	run(sprintf("day < %s", minute(0)), [][2]int{{0, 0}})
+	run(sprintf("day <= %s", minute(0)), [][2]int{{0, 60}})
+	run(sprintf("day > %s", minute(0)), [][2]int{{0, 0}})
+	run(sprintf("day >= %s and timestamp < %s", minute(0), minute(10)), [][2]int{{0, 10}})
+	run(sprintf("none < %s", minute(10)), [][2]int{{0, 0}})
+	run(sprintf("none = %s or foo = 'foo'", minute(10)), [][2]int{{0, 60}})
 }
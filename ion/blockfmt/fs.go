@@ -71,6 +71,18 @@ type UploadFS interface {
 	Create(path string) (Uploader, error)
 }
 
+// CreateFS can be implemented by an UploadFS
+// that can create a file only if it does not exist.
+type CreateFS interface {
+	UploadFS
+
+	// WriteNewFile is like WriteFile, but it
+	// returns an error matching fs.ErrExist
+	// instead of overwriting an existing file.
+	// The check and the write are atomic.
+	WriteNewFile(path string, buf []byte) (etag string, err error)
+}
+
 // S3FS implements UploadFS and InputFS.
 type S3FS struct {
 	s3.BucketFS
@@ -108,6 +120,11 @@ func (s *S3FS) WriteFile(path string, contents []byte) (string, error) {
 	return s.Put(path, contents)
 }
 
+// WriteNewFile implements CreateFS.WriteNewFile
+func (s *S3FS) WriteNewFile(path string, contents []byte) (string, error) {
+	return s.PutNew(path, contents)
+}
+
 // NewDirFS creates a new DirFS in dir.
 func NewDirFS(dir string) *DirFS {
 	return &DirFS{
@@ -185,6 +202,18 @@ func (d *DirFS) WriteFile(fullpath string, buf []byte) (string, error) {
 	if d.Log != nil {
 		d.Log("WriteFile %s", fullpath)
 	}
+	return d.write(fullpath, buf, false)
+}
+
+// WriteNewFile implements CreateFS.WriteNewFile
+func (d *DirFS) WriteNewFile(fullpath string, buf []byte) (string, error) {
+	if d.Log != nil {
+		d.Log("WriteNewFile %s", fullpath)
+	}
+	return d.write(fullpath, buf, true)
+}
+
+func (d *DirFS) write(fullpath string, buf []byte, excl bool) (string, error) {
 	if !fs.ValidPath(fullpath) {
 		return "", fs.ErrInvalid
 	}
@@ -210,9 +239,18 @@ func (d *DirFS) WriteFile(fullpath string, buf []byte) (string, error) {
 		os.Remove(tmp.Name())
 		return "", err
 	}
-	err = os.Rename(tmp.Name(), fullpath)
-	if err != nil {
+	if excl {
+		// unlike rename, link fails
+		// if fullpath already exists
+		err = os.Link(tmp.Name(), fullpath)
 		os.Remove(tmp.Name())
+	} else {
+		err = os.Rename(tmp.Name(), fullpath)
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	if err != nil {
 		return "", err
 	}
 	ret := blake2b.Sum256(buf)
@@ -92,6 +92,10 @@ type Descriptor struct {
 	// Trailer is the trailer that is part
 	// of the object.
 	Trailer Trailer
+	// Rows, if non-zero, is the number
+	// of rows in the object. Rows is zero
+	// if the number of rows is not known.
+	Rows int64
 }
 
 // Quarantined is an item that
@@ -164,6 +168,36 @@ type Index struct {
 	// Scanning indicates that scanning has
 	// not yet completed.
 	Scanning bool
+
+	// Schema accumulates the fields of
+	// the rows ingested into the index.
+	// Rows that are later removed from
+	// the index are not subtracted, so
+	// the counts are approximate.
+	Schema Schema
+
+	// Stats holds column statistics for
+	// the rows ingested into the index.
+	// Like Schema, the statistics are not
+	// updated when rows are removed.
+	Stats Stats
+
+	// Tombstones is the list of deletions
+	// that have not yet been applied to
+	// the objects in the index.
+	Tombstones []Tombstone
+
+	// Dictionary, if non-nil, is the dictionary
+	// used to compress newly-written objects.
+	// Objects that were written before the dictionary
+	// was trained have an empty Trailer.Dict and
+	// remain readable without it. The dictionary
+	// must not be replaced while objects compressed
+	// with it are still referenced by the index.
+	// DecodeIndex sets the Trailer.Dictionary of
+	// the descriptors that refer to it, including
+	// those returned by Indirect.Search.
+	Dictionary *Dictionary
 }
 
 const (
@@ -234,6 +268,10 @@ func Sign(key *Key, idx *Index) ([]byte, error) {
 		expiry   = st.Intern("expiry")
 		indirect = st.Intern("indirect")
 		inputs   = st.Intern("inputs")
+		schema   = st.Intern("schema")
+		stats    = st.Intern("stats")
+		tombs    = st.Intern("tombstones")
+		dict     = st.Intern("dictionary")
 	)
 	var ibuf ion.Buffer
 	buf.BeginStruct(-1)
@@ -275,6 +313,26 @@ func Sign(key *Key, idx *Index) ([]byte, error) {
 		buf.BeginField(scanning)
 		buf.WriteBool(true)
 	}
+	if len(idx.Schema.Fields) > 0 {
+		buf.BeginField(schema)
+		idx.Schema.Encode(&buf, &st)
+	}
+	if idx.Stats.Rows > 0 {
+		buf.BeginField(stats)
+		idx.Stats.Encode(&buf, &st)
+	}
+	if len(idx.Tombstones) > 0 {
+		buf.BeginField(tombs)
+		buf.BeginList(-1)
+		for i := range idx.Tombstones {
+			idx.Tombstones[i].Encode(&buf, &st)
+		}
+		buf.EndList()
+	}
+	if idx.Dictionary != nil {
+		buf.BeginField(dict)
+		idx.Dictionary.Encode(&buf, &st)
+	}
 	if len(idx.Cursors) > 0 {
 		buf.BeginField(cursors)
 		buf.BeginList(-1)
@@ -347,6 +405,7 @@ func WriteDescriptor(buf *ion.Buffer, st *ion.Symtab, desc *Descriptor) {
 		format       = st.Intern("format")
 		trailer      = st.Intern("trailer")
 		size         = st.Intern("size")
+		rows         = st.Intern("rows")
 	)
 	buf.BeginStruct(-1)
 	buf.BeginField(path)
@@ -361,6 +420,10 @@ func WriteDescriptor(buf *ion.Buffer, st *ion.Symtab, desc *Descriptor) {
 	buf.WriteString(desc.Format)
 	buf.BeginField(size)
 	buf.WriteInt(desc.Size)
+	if desc.Rows > 0 {
+		buf.BeginField(rows)
+		buf.WriteInt(desc.Rows)
+	}
 	buf.BeginField(trailer)
 	desc.Trailer.Encode(buf, st)
 	buf.EndStruct()
@@ -410,6 +473,10 @@ func (d *Descriptor) Encode(buf *ion.Buffer, st *ion.Symtab) {
 	buf.WriteString(d.Format)
 	buf.BeginField(st.Intern("size"))
 	buf.WriteInt(d.Size)
+	if d.Rows > 0 {
+		buf.BeginField(st.Intern("rows"))
+		buf.WriteInt(d.Rows)
+	}
 	buf.BeginField(st.Intern("trailer"))
 	d.Trailer.Encode(buf, st)
 	buf.EndStruct()
@@ -448,6 +515,10 @@ func (d *Descriptor) Decode(td *TrailerDecoder, v ion.Datum, opts Flag) error {
 			return nil // ignore for backwards-compat
 		case "trailer":
 			return td.Decode(f.Datum, &d.Trailer)
+		case "rows":
+			var err error
+			d.Rows, err = f.Int()
+			return err
 		}
 		ok, err := d.set(f)
 		if !ok {
@@ -606,6 +677,18 @@ func DecodeIndex(key *Key, index []byte, opts Flag) (*Index, error) {
 			})
 		case "last-scan":
 			idx.LastScan, err = f.Timestamp()
+		case "schema":
+			err = idx.Schema.Decode(f.Datum)
+		case "stats":
+			err = idx.Stats.Decode(f.Datum)
+		case "tombstones":
+			err = f.UnpackList(func(d ion.Datum) error {
+				idx.Tombstones = append(idx.Tombstones, Tombstone{})
+				return idx.Tombstones[len(idx.Tombstones)-1].Decode(d)
+			})
+		case "dictionary":
+			idx.Dictionary = new(Dictionary)
+			err = idx.Dictionary.Decode(f.Datum)
 		default:
 			err = fmt.Errorf("unexpected field %q", f.Label)
 		}
@@ -614,6 +697,12 @@ func DecodeIndex(key *Key, index []byte, opts Flag) (*Index, error) {
 	if err != nil {
 		return nil, fmt.Errorf("DecodeIndex: decoding structure: %w", err)
 	}
+	// objects compressed with a dictionary
+	// only refer to it by its ID in the index
+	if idx.Dictionary != nil {
+		td.Dictionaries = []*Dictionary{idx.Dictionary}
+		idx.Indirect.dict = idx.Dictionary
+	}
 	if !inputs.IsEmpty() {
 		err := idx.readInputs(&st, inputs, isize, idx.Algo)
 		if err != nil {
@@ -764,6 +853,30 @@ func (idx *Index) HasPartition(x string) bool {
 	return ok
 }
 
+// Rows returns the number of rows recorded
+// in idx.Stats, or false if the index has no
+// statistics. (Like the rest of the statistics,
+// the number of rows is approximate.)
+func (idx *Index) Rows() (int64, bool) {
+	return idx.Stats.Rows, idx.Stats.Rows > 0
+}
+
+// Distinct returns the estimated number of distinct
+// values of the column at path recorded in idx.Stats,
+// or false if there are no statistics for the column.
+func (idx *Index) Distinct(path []string) (int64, bool) {
+	// not idx.Stats.Column, which builds the
+	// lookup table of the shared Stats lazily
+	name := strings.Join(path, ".")
+	for i := range idx.Stats.Columns {
+		c := &idx.Stats.Columns[i]
+		if c.Path == name {
+			return c.Distinct(), c.Count > 0
+		}
+	}
+	return 0, false
+}
+
 // TimeRange returns the inclusive time range for the
 // given path expression.
 func (idx *Index) TimeRange(path []string) (min, max date.Time, ok bool) {
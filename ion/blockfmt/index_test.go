@@ -28,6 +28,7 @@ import (
 	"slices"
 
 	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/expr"
 	"github.com/SnellerInc/sneller/ion"
 )
 
@@ -118,6 +119,27 @@ func TestLargeIndexEncoding(t *testing.T) {
 		Scanning: true,
 		Cursors:  []string{"a/b/c", "x/y/z"},
 		LastScan: time0,
+		Schema: Schema{
+			Fields: []SchemaField{
+				{Path: "a", Count: [16]int64{ion.StructType: 10}},
+				{Path: "a.b", Count: [16]int64{ion.IntType: 3, ion.StringType: 7}},
+			},
+		},
+		Stats: Stats{
+			Rows: 10,
+			Columns: []ColumnStats{{
+				Path:  "a.b",
+				Count: 8,
+				Nulls: 2,
+				min:   scalar{class: classNumber, isint: true, i: -3},
+				max:   scalar{class: classString, s: "foo"},
+				ndv:   hll{0: 3, 100: 1},
+			}},
+		},
+		Tombstones: []Tombstone{{
+			Created: time0,
+			Where:   expr.Compare(expr.Equals, expr.Ident("user_id"), expr.Integer(5)),
+		}},
 		Inline: []Descriptor{
 			{
 				ObjectInfo: ObjectInfo{
@@ -128,6 +150,7 @@ func TestLargeIndexEncoding(t *testing.T) {
 					Size:         12000,
 				},
 				Trailer: tr,
+				Rows:    1500,
 			},
 		},
 		Inputs: FileTree{
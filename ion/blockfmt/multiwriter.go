@@ -40,6 +40,14 @@ type MultiWriter struct {
 	// Algo is the compression algorithm
 	// used to compress blocks.
 	Algo string
+	// Dict, if non-nil, is the dictionary
+	// used to compress blocks. Only the "zstd"
+	// algorithm supports dictionaries.
+	Dict *Dictionary
+	// HotFields is the list of top-level fields
+	// that are compressed for cheaper decompression
+	// by the zion algorithms. (See zion.WithHotFields.)
+	HotFields []string
 	// InputAlign is the expected size
 	// of input blocks that are provided
 	// to io.Write in each stream.
@@ -160,9 +168,9 @@ func (m *MultiWriter) Open() (io.WriteCloser, error) {
 
 	// allocate a starting span for this stream eagerly
 	// so that we can predict output span ordering in tests
-	c := getCompressor(m.Algo)
-	if c == nil {
-		return nil, fmt.Errorf("blockfmt: no such compression algorithm %q", m.Algo)
+	c, err := getDictCompressor(m.Algo, m.Dict, m.HotFields)
+	if err != nil {
+		return nil, fmt.Errorf("blockfmt: %w", err)
 	}
 	s := &singleStream{parent: m, tid: tid, comp: c}
 	s.curspan.partnum = m.nextpart
@@ -172,7 +180,7 @@ func (m *MultiWriter) Open() (io.WriteCloser, error) {
 
 func (m *MultiWriter) writeStart(r io.Reader, t *Trailer) error {
 	m.init()
-	if t.Algo != m.Algo || 1<<t.BlockShift != m.InputAlign {
+	if t.Algo != m.Algo || t.Dict != dictID(m.Dict) || 1<<t.BlockShift != m.InputAlign {
 		return nil // not directly compatible
 	}
 	j, offset := pickPrefix(t, m.MinChunksPerBlock)
@@ -492,12 +500,13 @@ func (m *MultiWriter) Close() error {
 		panic("race between stream Close() and MultiWriter Close()")
 	}
 	m.finalize()
-	finalcomp := getCompressor(m.Algo)
+	finalcomp := getCompressor(m.Algo, nil)
 	if finalcomp == nil {
 		return fmt.Errorf("blockfmt: no such compression algorithm %q", m.Algo)
 	}
 
 	// compute the final sparse index:
+	m.Trailer.setDict(m.Dict)
 	m.unallocated.buf = append(m.unallocated.buf, m.Trailer.trailer(finalcomp.Name(), m.InputAlign)...)
 	finalcomp.Close()
 	return m.Output.Close(m.unallocated.buf)
@@ -37,6 +37,10 @@ type IndirectTree struct {
 	// Sparse describes the intervals within refs
 	// that correspond to particular time ranges.
 	Sparse SparseIndex
+
+	// dict is the dictionary of the index,
+	// which the descriptors may refer to
+	dict *Dictionary
 }
 
 // IndirectRef references an object
@@ -228,6 +232,9 @@ func (i *IndirectTree) decode(ifs InputFS, src *IndirectRef, in []Descriptor, fi
 		return in, fmt.Errorf("IndirectTree.decode: %w", err)
 	}
 	var td TrailerDecoder
+	if i.dict != nil {
+		td.Dictionaries = []*Dictionary{i.dict}
+	}
 	err = d.UnpackStruct(func(f ion.Field) error {
 		switch f.Label {
 		case "contents":
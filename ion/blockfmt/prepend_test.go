@@ -175,6 +175,7 @@ func TestPrependSingle(t *testing.T) {
 				}
 				c.Prepend.R = io.NopCloser(io.LimitReader(br, tr.Offset))
 				c.Prepend.Trailer = tr
+				c.Prepend.Rows = int64(count)
 				err = c.Run()
 				if err != nil {
 					t.Fatal(err)
@@ -186,6 +187,9 @@ func TestPrependSingle(t *testing.T) {
 				if count2 != count*2 {
 					t.Errorf("went from %d to %d objects?", count, count2)
 				}
+				if c.Rows() != int64(count2) {
+					t.Errorf("Rows() = %d, but %d rows were written", c.Rows(), count2)
+				}
 			})
 		}
 	}
@@ -0,0 +1,288 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blockfmt
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/fsutil"
+)
+
+// piece is the range of blocks [start:end]
+// of an object that is copied into a rechunk
+type piece struct {
+	src        *Descriptor
+	start, end int
+}
+
+// rechunk wraps a list of block ranges
+// that should be copied into a new object.
+//
+// Unlike concat, rechunk can split objects,
+// so an object can be spread over more
+// than one rechunk.
+type rechunk struct {
+	pieces []piece
+	output Descriptor
+}
+
+// add returns true if block i of src was
+// added to the output, or false if src is
+// not compatible with the blocks that have
+// already been added (see concat.add)
+func (r *rechunk) add(src *Descriptor, i int) bool {
+	t := &src.Trailer
+	dt := &r.output.Trailer
+	if len(r.pieces) == 0 {
+		r.output.Format = src.Format
+		dt.Algo = t.Algo
+		dt.Dict = t.Dict
+		dt.Version = t.Version
+		dt.BlockShift = t.BlockShift
+		dt.Sparse = t.Sparse.emptyClone()
+	} else if t.Version != dt.Version ||
+		t.Algo != dt.Algo ||
+		t.Dict != dt.Dict ||
+		t.BlockShift != dt.BlockShift {
+		return false
+	}
+	if !dt.Sparse.AppendBlocks(&t.Sparse, i, i+1) {
+		return false
+	}
+	dt.Blocks = append(dt.Blocks, Blockdesc{
+		Offset: dt.Offset,
+		Chunks: t.Blocks[i].Chunks,
+	})
+	dt.Offset += t.BlockSize(i)
+	if n := len(r.pieces); n > 0 && r.pieces[n-1].src == src && r.pieces[n-1].end == i {
+		r.pieces[n-1].end++
+	} else {
+		r.pieces = append(r.pieces, piece{src: src, start: i, end: i + 1})
+	}
+	return true
+}
+
+func (r *rechunk) size() int64 { return r.output.Trailer.Offset }
+
+// whole returns the input object if r
+// consists of exactly one complete object
+func (r *rechunk) whole() *Descriptor {
+	if len(r.pieces) != 1 {
+		return nil
+	}
+	p := &r.pieces[0]
+	if p.start != 0 || p.end != len(p.src.Trailer.Blocks) {
+		return nil
+	}
+	return p.src
+}
+
+// rows returns the number of rows in the
+// output, or zero if it is not known because
+// one of the input objects has been split
+func (r *rechunk) rows() int64 {
+	n := int64(0)
+	for i := range r.pieces {
+		p := &r.pieces[i]
+		if p.start != 0 || p.end != len(p.src.Trailer.Blocks) || p.src.Rows == 0 {
+			return 0
+		}
+		n += p.src.Rows
+	}
+	return n
+}
+
+// run copies the blocks added via add
+// into the file given by name in the provided
+// filesystem and returns the descriptor of
+// the new object.
+func (r *rechunk) run(fs UploadFS, name string) (Descriptor, error) {
+	up, err := fs.Create(name)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	target := up.MinPartSize()
+	if target == 1 {
+		// this is a BufferUploader
+		target = 64 * 1024
+	}
+	var buf []byte
+	part := int64(1)
+	for i := range r.pieces {
+		p := &r.pieces[i]
+		start, _ := p.src.Trailer.BlockRange(p.start)
+		_, end := p.src.Trailer.BlockRange(p.end - 1)
+		rd, err := fsutil.OpenRange(fs, p.src.Path, p.src.ETag, start, end-start)
+		if err != nil {
+			return Descriptor{}, fmt.Errorf("blockfmt.rechunk.run: %w", err)
+		}
+		for n := end - start; n > 0; {
+			amt := min(n, int64(target-len(buf)))
+			off := len(buf)
+			if buf == nil {
+				buf = make([]byte, 0, target)
+			}
+			buf = buf[:off+int(amt)]
+			_, err = io.ReadFull(rd, buf[off:])
+			if err != nil {
+				rd.Close()
+				return Descriptor{}, err
+			}
+			n -= amt
+			if len(buf) == target {
+				err = up.Upload(part, buf)
+				if err != nil {
+					rd.Close()
+					return Descriptor{}, err
+				}
+				part++
+				// the uploader may hold on to buf
+				buf = nil
+			}
+		}
+		err = rd.Close()
+		if err != nil {
+			return Descriptor{}, err
+		}
+	}
+	// the final part can be smaller than
+	// the minimum part size, so it is
+	// prepended to the trailer
+	tail := r.output.Trailer.trailer(r.output.Trailer.Algo, 1<<r.output.Trailer.BlockShift)
+	err = up.Close(append(buf, tail...))
+	if err != nil {
+		return Descriptor{}, err
+	}
+	out := r.output
+	out.Path = name
+	out.Rows = r.rows()
+	out.ETag, err = ETag(fs, up, name)
+	out.Size = up.Size()
+	return out, err
+}
+
+// Rebalance rewrites a list of descriptors into
+// a new list of descriptors containing the same
+// blocks in the same order, but packed into objects
+// of (approximately) c.TargetSize compressed bytes,
+// and returns the new list along with the list of
+// quarantined descriptor paths that should be deleted.
+//
+// Blocks are copied as-is, so the data is not
+// decompressed or modified. Objects are split
+// and joined at block boundaries, so every object
+// produced by Rebalance holds at least c.TargetSize
+// bytes of blocks, except for the final object in
+// each directory, and objects are only joined with
+// objects in the same directory that are compatible
+// (see concat.add). Objects that already have
+// the right size are returned as-is, so calling
+// Rebalance on its own result does not write
+// any new objects.
+//
+// Objects split by Rebalance lose their row count
+// (see Descriptor.Rows).
+func (c *IndexConfig) Rebalance(fs UploadFS, lst []Descriptor) ([]Descriptor, []Quarantined, error) {
+	target := c.TargetSize
+	if target <= 0 {
+		return nil, nil, fmt.Errorf("blockfmt.Rebalance: invalid target size %d", target)
+	}
+	expiry := date.Now().Truncate(time.Microsecond).Add(c.Expiry)
+
+	// group the objects by directory,
+	// preserving the order of the objects
+	// within each directory
+	var dirs []string
+	bydir := make(map[string][]*Descriptor)
+	for i := range lst {
+		dir, _ := path.Split(lst[i].Path)
+		if _, ok := bydir[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		bydir[dir] = append(bydir[dir], &lst[i])
+	}
+
+	var chunks []*rechunk
+	var chunkdirs []string
+	for _, dir := range dirs {
+		cur := new(rechunk)
+		flush := func() {
+			if len(cur.pieces) > 0 {
+				chunks = append(chunks, cur)
+				chunkdirs = append(chunkdirs, dir)
+			}
+			cur = new(rechunk)
+		}
+		for _, d := range bydir[dir] {
+			for i := range d.Trailer.Blocks {
+				if !cur.add(d, i) {
+					flush()
+					if !cur.add(d, i) {
+						return nil, nil, fmt.Errorf("blockfmt.Rebalance: cannot add block %d of %s", i, d.Path)
+					}
+				}
+				if cur.size() >= target {
+					flush()
+				}
+			}
+		}
+		flush()
+	}
+
+	kept := make(map[*Descriptor]bool)
+	result := make([]Descriptor, len(chunks))
+	var wg sync.WaitGroup
+	errc := make(chan error, 1)
+	for i, r := range chunks {
+		if d := r.whole(); d != nil {
+			kept[d] = true
+			result[i] = *d
+			continue
+		}
+		name := path.Join(chunkdirs[i], "packed-"+uuid()+suffixForComp(r.output.Trailer.Algo))
+		wg.Add(1)
+		go func(i int, r *rechunk) {
+			defer wg.Done()
+			var err error
+			result[i], err = r.run(fs, name)
+			if err != nil {
+				select {
+				case errc <- err:
+				default:
+				}
+			}
+		}(i, r)
+	}
+	wg.Wait()
+	close(errc)
+	if err := <-errc; err != nil {
+		return nil, nil, err
+	}
+	var todelete []Quarantined
+	for i := range lst {
+		if !kept[&lst[i]] {
+			todelete = append(todelete, Quarantined{
+				Path:   lst[i].Path,
+				Expiry: expiry,
+			})
+		}
+	}
+	return result, todelete, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blockfmt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestRebalance(t *testing.T) {
+	dfs := NewDirFS(t.TempDir())
+	align := 32 * 1024
+	var descs []Descriptor
+	blocks := 0
+	for i := 0; i < 3; i++ {
+		f, err := os.Open("../../testdata/cloudtrail.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := fmt.Sprintf("db/foo/part-%d", i)
+		up, err := dfs.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := Converter{
+			Output:    up,
+			Comp:      "zion",
+			Inputs:    []Input{{R: f, F: MustSuffixToFormat(".json")}},
+			Align:     align,
+			FlushMeta: 2 * align,
+		}
+		err = c.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		etag, err := ETag(dfs, c.Output, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		descs = append(descs, Descriptor{
+			ObjectInfo: ObjectInfo{
+				Path: path,
+				ETag: etag,
+				Size: c.Output.Size(),
+			},
+			Trailer: *c.Trailer(),
+			Rows:    c.Rows(),
+		})
+		blocks += len(c.Trailer().Blocks)
+	}
+	if len(descs[0].Trailer.Blocks) < 3 {
+		t.Fatalf("only %d blocks per object", len(descs[0].Trailer.Blocks))
+	}
+	// force the data to be uploaded in parts
+	dfs.MinPartSize = align
+
+	// pick a target size that splits
+	// each of the input objects
+	c := IndexConfig{TargetSize: descs[0].Trailer.Offset / 2}
+	out, rm, err := c.Rebalance(dfs, descs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rm) != len(descs) {
+		t.Errorf("%d objects removed; expected %d", len(rm), len(descs))
+	}
+	if len(out) <= len(descs) {
+		t.Fatalf("%d objects after rebalance", len(out))
+	}
+	rows := 0
+	outblocks := 0
+	for i := range out {
+		if i < len(out)-1 && out[i].Trailer.Offset < c.TargetSize {
+			t.Errorf("object %d: size %d below target %d", i, out[i].Trailer.Offset, c.TargetSize)
+		}
+		f, err := dfs.Open(out[i].Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			t.Fatal(err)
+		}
+		trailer, err := ReadTrailer(f.(io.ReaderAt), info.Size())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trailer.Blocks) != len(out[i].Trailer.Blocks) {
+			t.Errorf("object %d: trailer has %d blocks; descriptor has %d", i, len(trailer.Blocks), len(out[i].Trailer.Blocks))
+		}
+		var errlog bytes.Buffer
+		rows += Validate(f, trailer, &errlog)
+		f.Close()
+		if errlog.Len() > 0 {
+			t.Fatal(errlog.String())
+		}
+		outblocks += len(out[i].Trailer.Blocks)
+	}
+	if rows != len(descs)*1000 {
+		t.Errorf("found %d rows", rows)
+	}
+	if outblocks != blocks {
+		t.Errorf("found %d blocks; expected %d", outblocks, blocks)
+	}
+
+	// rebalancing again should not
+	// produce any new objects
+	again, rm, err := c.Rebalance(dfs, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rm) != 0 {
+		t.Errorf("second rebalance removed %d objects", len(rm))
+	}
+	if len(again) != len(out) {
+		t.Fatalf("second rebalance produced %d objects; expected %d", len(again), len(out))
+	}
+	for i := range again {
+		if again[i].Path != out[i].Path {
+			t.Errorf("object %d: %s -> %s", i, out[i].Path, again[i].Path)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blockfmt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// MaxSchemaFields is the maximum number of
+// distinct fields tracked by a Schema;
+// fields seen after the limit has been
+// reached are not recorded.
+const MaxSchemaFields = 1000
+
+// Schema summarizes the fields of the rows
+// that have been ingested into an index.
+//
+// The zero value of Schema is an empty schema
+// that is ready to use.
+type Schema struct {
+	// Fields is the list of fields.
+	// Fields are sorted by Path
+	// after a call to Merge.
+	Fields []SchemaField
+
+	index map[string]int // path -> Fields[i]; built lazily
+	path  []byte         // scratch path for Add
+}
+
+// SchemaField is the summary
+// of one field of a Schema.
+type SchemaField struct {
+	// Path is the path of the field;
+	// the fields of nested structures
+	// are joined to their parents with '.'
+	Path string
+	// Count[t] is the number of values of
+	// the field that have ion type t.
+	//
+	// Unsigned integers are counted as
+	// ion.IntType, and symbols are
+	// counted as ion.StringType.
+	Count [16]int64
+}
+
+// Total returns the number of
+// occurrences of the field.
+func (f *SchemaField) Total() int64 {
+	n := int64(0)
+	for _, c := range f.Count {
+		n += c
+	}
+	return n
+}
+
+// Types returns the names of the types that
+// the field has had, separated by '|'
+// (for example, "int|string").
+func (f *SchemaField) Types() string {
+	var out strings.Builder
+	for t, c := range f.Count {
+		if c == 0 {
+			continue
+		}
+		if out.Len() > 0 {
+			out.WriteByte('|')
+		}
+		out.WriteString(ion.Type(t).String())
+	}
+	return out.String()
+}
+
+func schemaType(t ion.Type) ion.Type {
+	switch t {
+	case ion.UintType:
+		return ion.IntType
+	case ion.SymbolType:
+		return ion.StringType
+	}
+	return t
+}
+
+// Field returns the field of s with the
+// given path, or nil if there is no such field.
+func (s *Schema) Field(path string) *SchemaField {
+	if s.index != nil {
+		if i, ok := s.index[path]; ok {
+			return &s.Fields[i]
+		}
+		return nil
+	}
+	for i := range s.Fields {
+		if s.Fields[i].Path == path {
+			return &s.Fields[i]
+		}
+	}
+	return nil
+}
+
+func (s *Schema) field(path []byte) *SchemaField {
+	if i, ok := s.index[string(path)]; ok {
+		return &s.Fields[i]
+	}
+	if len(s.Fields) >= MaxSchemaFields {
+		return nil
+	}
+	if s.index == nil {
+		s.index = make(map[string]int)
+	}
+	s.index[string(path)] = len(s.Fields)
+	s.Fields = append(s.Fields, SchemaField{Path: string(path)})
+	return &s.Fields[len(s.Fields)-1]
+}
+
+func (s *Schema) reindex() {
+	clear(s.index)
+	if s.index == nil {
+		s.index = make(map[string]int, len(s.Fields))
+	}
+	for i := range s.Fields {
+		s.index[s.Fields[i].Path] = i
+	}
+}
+
+// Add adds the fields of the structure
+// at the start of rec, whose symbols are
+// defined in st, to the schema.
+func (s *Schema) Add(st *ion.Symtab, rec []byte) error {
+	if ion.TypeOf(rec) != ion.StructType {
+		return nil
+	}
+	if s.index == nil && len(s.Fields) > 0 {
+		s.reindex()
+	}
+	s.path = s.path[:0]
+	return s.walk(st, rec)
+}
+
+func (s *Schema) walk(st *ion.Symtab, rec []byte) error {
+	body, _ := ion.Contents(rec)
+	if body == nil {
+		return fmt.Errorf("blockfmt.Schema.Add: invalid structure")
+	}
+	prefix := len(s.path)
+	for len(body) > 0 {
+		sym, rest, err := ion.ReadLabel(body)
+		if err != nil {
+			return err
+		}
+		size := ion.SizeOf(rest)
+		if size <= 0 || size > len(rest) {
+			return fmt.Errorf("blockfmt.Schema.Add: invalid field size %d", size)
+		}
+		val := rest[:size]
+		body = rest[size:]
+		t := ion.TypeOf(val)
+		if t == ion.NullType && val[0] != 0x0f {
+			continue // nop pad
+		}
+		s.path = s.path[:prefix]
+		if prefix > 0 {
+			s.path = append(s.path, '.')
+		}
+		s.path = append(s.path, st.Get(sym)...)
+		f := s.field(s.path)
+		if f == nil {
+			continue
+		}
+		f.Count[schemaType(t)]++
+		if t == ion.StructType {
+			if err := s.walk(st, val); err != nil {
+				return err
+			}
+		}
+	}
+	s.path = s.path[:prefix]
+	return nil
+}
+
+// Merge adds the fields of from to s
+// and sorts the fields of s by Path.
+func (s *Schema) Merge(from *Schema) {
+	if s.index == nil && len(s.Fields) > 0 {
+		s.reindex()
+	}
+	for i := range from.Fields {
+		src := &from.Fields[i]
+		f := s.field([]byte(src.Path))
+		if f == nil {
+			continue
+		}
+		for t, c := range src.Count {
+			f.Count[t] += c
+		}
+	}
+	slices.SortFunc(s.Fields, func(x, y SchemaField) int {
+		return strings.Compare(x.Path, y.Path)
+	})
+	s.reindex()
+}
+
+// Encode encodes s as a list of structures
+// with a "path" field and a "types" structure
+// of type names and counts.
+func (s *Schema) Encode(dst *ion.Buffer, st *ion.Symtab) {
+	dst.BeginList(-1)
+	for i := range s.Fields {
+		f := &s.Fields[i]
+		dst.BeginStruct(-1)
+		dst.BeginField(st.Intern("path"))
+		dst.WriteString(f.Path)
+		dst.BeginField(st.Intern("types"))
+		dst.BeginStruct(-1)
+		for t, c := range f.Count {
+			if c != 0 {
+				dst.BeginField(st.Intern(ion.Type(t).String()))
+				dst.WriteInt(c)
+			}
+		}
+		dst.EndStruct()
+		dst.EndStruct()
+	}
+	dst.EndList()
+}
+
+func schemaTypeByName(name string) (ion.Type, bool) {
+	for t := ion.NullType; t <= ion.AnnotationType; t++ {
+		if t.String() == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// Decode decodes a schema encoded with Encode.
+func (s *Schema) Decode(d ion.Datum) error {
+	s.Fields = s.Fields[:0]
+	err := d.UnpackList(func(d ion.Datum) error {
+		var f SchemaField
+		err := d.UnpackStruct(func(field ion.Field) error {
+			switch field.Label {
+			case "path":
+				var err error
+				f.Path, err = field.String()
+				return err
+			case "types":
+				return field.UnpackStruct(func(field ion.Field) error {
+					t, ok := schemaTypeByName(field.Label)
+					if !ok {
+						return fmt.Errorf("unknown type %q", field.Label)
+					}
+					c, err := field.Int()
+					f.Count[t] = c
+					return err
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		s.Fields = append(s.Fields, f)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("blockfmt.Schema.Decode: %w", err)
+	}
+	s.index = nil // rebuilt on demand
+	return nil
+}
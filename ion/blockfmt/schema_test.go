@@ -0,0 +1,90 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blockfmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+func TestSchemaAdd(t *testing.T) {
+	rows := []string{
+		`{"x": 1, "y": {"z": "foo", "w": null}}`,
+		`{"x": "two", "y": {"z": 3.5}, "l": [1, 2]}`,
+		`{"x": -3, "y": "flat"}`,
+	}
+	add := func(s *Schema, rows []string) {
+		var st ion.Symtab
+		var buf ion.Buffer
+		for i := range rows {
+			d, err := ion.FromJSON(&st, json.NewDecoder(strings.NewReader(rows[i])))
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf.Reset()
+			d.Encode(&buf, &st)
+			if err := s.Add(&st, buf.Bytes()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	var s Schema
+	add(&s, rows[:2])
+	var other Schema
+	add(&other, rows[2:])
+	s.Merge(&other)
+
+	var got []string
+	for i := range s.Fields {
+		f := &s.Fields[i]
+		got = append(got, fmt.Sprintf("%s %s %d", f.Path, f.Types(), f.Total()))
+	}
+	want := []string{
+		"l list 1",
+		"x int|string 3",
+		"y string|struct 3",
+		"y.w null 1",
+		"y.z float|string 2",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if f := s.Field("y.z"); f == nil || f.Path != "y.z" {
+		t.Errorf("Field(y.z) = %v", f)
+	}
+	if f := s.Field("nope"); f != nil {
+		t.Errorf("Field(nope) = %v", f)
+	}
+
+	// fields beyond MaxSchemaFields are dropped
+	var wide Schema
+	var sb strings.Builder
+	sb.WriteString("{")
+	for i := 0; i < MaxSchemaFields+10; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, `"f%d": %d`, i, i)
+	}
+	sb.WriteString("}")
+	add(&wide, []string{sb.String()})
+	if len(wide.Fields) != MaxSchemaFields {
+		t.Errorf("got %d fields, want %d", len(wide.Fields), MaxSchemaFields)
+	}
+}
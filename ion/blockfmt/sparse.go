@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/expr"
 	"github.com/SnellerInc/sneller/ion"
 )
 
@@ -33,6 +34,14 @@ type SparseIndex struct {
 	consts  ion.Struct
 	indices []timeIndex
 	blocks  int
+
+	// strings holds the ranges of the string
+	// values of the fields in all of the blocks,
+	// sorted by path; the ranges are only known if
+	// hasStrings is set, in which case a field
+	// without a range has no string values
+	strings    []stringIndex
+	hasStrings bool
 }
 
 // Const extracts the datum associated with
@@ -47,6 +56,12 @@ func (s *SparseIndex) Const(x string) (ion.Datum, bool) {
 	return f.Datum, true
 }
 
+// Constants returns the constants
+// associated with the sparse index.
+func (s *SparseIndex) Constants() []ion.Field {
+	return s.consts.Fields(nil)
+}
+
 func (t *timeIndex) slice(i, j int) timeIndex {
 	return timeIndex{
 		path:   t.path,
@@ -66,10 +81,14 @@ func (s *SparseIndex) Slice(i, j int) SparseIndex {
 	for k := range indices {
 		indices[k] = s.indices[k].slice(i, j)
 	}
+	// the string ranges of all of the blocks
+	// also hold for any subset of the blocks
 	return SparseIndex{
-		consts:  s.consts,
-		indices: indices,
-		blocks:  j,
+		consts:     s.consts,
+		indices:    indices,
+		blocks:     j,
+		strings:    s.strings,
+		hasStrings: s.hasStrings,
 	}
 }
 
@@ -89,9 +108,11 @@ func (s *SparseIndex) Clone() SparseIndex {
 		indices[i].ranges = indices[i].ranges.Clone()
 	}
 	return SparseIndex{
-		consts:  s.consts,
-		indices: indices,
-		blocks:  s.blocks,
+		consts:     s.consts,
+		indices:    indices,
+		blocks:     s.blocks,
+		strings:    slices.Clone(s.strings),
+		hasStrings: s.hasStrings,
 	}
 }
 
@@ -135,13 +156,86 @@ func (s *SparseIndex) AppendBlocks(next *SparseIndex, i, j int) bool {
 	if !slices.EqualFunc(s.indices, next.indices, eq) {
 		return false
 	}
-	for i := range s.indices {
-		s.indices[i].ranges.appendBlocks(&next.indices[i].ranges, i, j)
+	if i < j {
+		for k := range s.indices {
+			s.indices[k].ranges.appendBlocks(&next.indices[k].ranges, i, j)
+		}
+		s.appendStrings(next)
 	}
 	s.blocks += j - i
 	return true
 }
 
+// appendStrings merges the string ranges of next
+// into s when blocks of next are appended to s
+func (s *SparseIndex) appendStrings(next *SparseIndex) {
+	switch {
+	case s.blocks == 0:
+		s.strings, s.hasStrings = next.strings, next.hasStrings
+	case !s.hasStrings || !next.hasStrings:
+		s.strings, s.hasStrings = nil, false
+	default:
+		s.strings = unionStrings(s.strings, next.strings)
+	}
+}
+
+// setStrings sets the string ranges of s
+// to lst, which must be sorted by path
+func (s *SparseIndex) setStrings(lst []stringIndex) {
+	s.strings, s.hasStrings = lst, true
+}
+
+// clearStrings forgets the string ranges of s
+func (s *SparseIndex) clearStrings() {
+	s.strings, s.hasStrings = nil, false
+}
+
+// StringRange returns the range of the string values
+// of the field at path in all of the blocks of s.
+// If prefix is true, then max is a prefix of the
+// largest string rather than the string itself.
+// If ok is false, the range is not known.
+func (s *SparseIndex) StringRange(path []string) (min, max string, prefix, ok bool) {
+	if r := s.searchString(path); r != nil {
+		return r.min, r.max, r.prefix, true
+	}
+	return "", "", false, false
+}
+
+// HasStrings returns whether the string ranges of
+// s are known, in which case a field for which
+// StringRange returns ok == false does not have
+// any string values.
+func (s *SparseIndex) HasStrings() bool { return s.hasStrings }
+
+func (s *SparseIndex) searchString(path []string) *stringIndex {
+	if !s.hasStrings {
+		return nil
+	}
+	j := sort.Search(len(s.strings), func(i int) bool {
+		return pathcmp(s.strings[i].path, path) >= 0
+	})
+	if j < len(s.strings) && slices.Equal(path, s.strings[j].path) {
+		return &s.strings[j]
+	}
+	return nil
+}
+
+// matchString returns whether the field at path
+// may hold a string x such that 'x op str' is true
+// in one of the blocks of s
+func (s *SparseIndex) matchString(path []string, op expr.CmpOp, str string) bool {
+	if !s.hasStrings {
+		return true
+	}
+	if r := s.searchString(path); r != nil {
+		return r.compare(op, str)
+	}
+	// a comparison with a string
+	// only matches string values
+	return false
+}
+
 // Fields returns the number of individually
 // indexed fields.
 func (s *SparseIndex) Fields() int { return len(s.indices) }
@@ -183,6 +277,30 @@ func (s *SparseIndex) Encode(dst *ion.Buffer, st *ion.Symtab) {
 		dst.EndStruct()
 	}
 	dst.EndList()
+	if s.hasStrings {
+		dst.BeginField(st.Intern("strings"))
+		dst.BeginList(-1)
+		for i := range s.strings {
+			r := &s.strings[i]
+			dst.BeginStruct(-1)
+			dst.BeginField(st.Intern("path"))
+			dst.BeginList(-1)
+			for _, p := range r.path {
+				dst.WriteSymbol(st.Intern(p))
+			}
+			dst.EndList()
+			dst.BeginField(st.Intern("min"))
+			dst.WriteString(r.min)
+			dst.BeginField(st.Intern("max"))
+			dst.WriteString(r.max)
+			if r.prefix {
+				dst.BeginField(st.Intern("prefix"))
+				dst.WriteBool(true)
+			}
+			dst.EndStruct()
+		}
+		dst.EndList()
+	}
 	dst.EndStruct()
 }
 
@@ -225,6 +343,30 @@ func (d *TrailerDecoder) decodeSparse(s *SparseIndex, v ion.Datum) error {
 				return nil
 			})
 			return err
+		case "strings":
+			s.hasStrings = true
+			return f.UnpackList(func(v ion.Datum) error {
+				var r stringIndex
+				err := v.UnpackStruct(func(f ion.Field) error {
+					var err error
+					switch f.Label {
+					case "path":
+						r.path, err = d.path(f.Datum)
+					case "min":
+						r.min, err = f.String()
+					case "max":
+						r.max, err = f.String()
+					case "prefix":
+						r.prefix, err = f.Bool()
+					}
+					return err
+				})
+				if err != nil {
+					return err
+				}
+				s.strings = append(s.strings, r)
+				return nil
+			})
 		}
 		return nil
 	})
@@ -122,3 +122,51 @@ func TestSparseCorruption(t *testing.T) {
 		t.Fatal("consts was corrupted")
 	}
 }
+
+func TestSparseAppendBlocks(t *testing.T) {
+	start := date.Now().Truncate(time.Microsecond)
+	paths := [][]string{{"x"}, {"y"}}
+	block := func(i int) (date.Time, date.Time) {
+		min := start.Add(time.Duration(i) * time.Hour)
+		return min, min.Add(time.Minute)
+	}
+	var next SparseIndex
+	for i := 0; i < 4; i++ {
+		min, max := block(i)
+		for _, p := range paths {
+			next.push(p, min, max)
+		}
+		next.bump()
+	}
+	for _, r := range [][2]int{{0, 4}, {1, 3}, {2, 4}, {3, 3}} {
+		i, j := r[0], r[1]
+		var s SparseIndex
+		for _, p := range paths {
+			s.indices = append(s.indices, timeIndex{path: p})
+		}
+		if !s.AppendBlocks(&next, i, j) {
+			t.Fatalf("AppendBlocks(%d, %d) failed", i, j)
+		}
+		if s.Blocks() != j-i {
+			t.Errorf("AppendBlocks(%d, %d): %d blocks, want %d", i, j, s.Blocks(), j-i)
+		}
+		for _, p := range paths {
+			got := s.Get(p)
+			if got.Blocks() != j-i {
+				t.Errorf("AppendBlocks(%d, %d): %v has %d blocks, want %d", i, j, p, got.Blocks(), j-i)
+				continue
+			}
+			if i == j {
+				continue
+			}
+			want, _ := block(i)
+			if min, _ := got.Min(); !min.Equal(want) {
+				t.Errorf("AppendBlocks(%d, %d): %v min %s, want %s", i, j, p, min, want)
+			}
+			_, want = block(j - 1)
+			if max, _ := got.Max(); !max.Equal(want) {
+				t.Errorf("AppendBlocks(%d, %d): %v max %s, want %s", i, j, p, max, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,562 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blockfmt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/ion"
+
+	"github.com/dchest/siphash"
+)
+
+// MaxStatsColumns is the maximum number of
+// distinct columns tracked by Stats;
+// columns seen after the limit has been
+// reached are not recorded.
+const MaxStatsColumns = 128
+
+// MaxStatsString is the maximum length of
+// a string that is considered when computing
+// ColumnStats.Min and ColumnStats.Max.
+const MaxStatsString = 64
+
+// Stats holds column statistics for the rows
+// that have been ingested into an index.
+//
+// Statistics are intended to be used for
+// cost estimation; they are accumulated
+// incrementally and rows that are later
+// removed from the index are not subtracted,
+// so they are approximate.
+//
+// The zero value of Stats is ready to use.
+type Stats struct {
+	// Rows is the number of rows.
+	Rows int64
+	// Columns is the list of columns.
+	// Columns are sorted by Path
+	// after a call to Merge.
+	Columns []ColumnStats
+
+	index map[string]int // path -> Columns[i]; built lazily
+	path  []byte         // scratch path for Add
+	tmp   []byte         // scratch buffer for hashing
+}
+
+// ColumnStats holds the statistics
+// for one column of Stats.
+type ColumnStats struct {
+	// Path is the path of the column;
+	// the fields of nested structures
+	// are joined to their parents with '.'
+	Path string
+	// Count is the number of
+	// non-null values of the column.
+	Count int64
+	// Nulls is the number of
+	// null values of the column.
+	Nulls int64
+
+	min, max scalar
+	ndv      hll
+}
+
+// Min returns the smallest value of the
+// column, or ion.Empty if the column
+// does not have any ordered values.
+//
+// Only numbers, timestamps, and strings
+// are ordered. Values of different types
+// are ordered as numbers < timestamps < strings,
+// so Min and Max have different types if the
+// column has values of more than one of these types.
+// Strings longer than MaxStatsString are ignored.
+func (c *ColumnStats) Min() ion.Datum { return c.min.datum() }
+
+// Max returns the largest value of the column,
+// or ion.Empty if the column does not have any
+// ordered values. See also Min.
+func (c *ColumnStats) Max() ion.Datum { return c.max.datum() }
+
+// Distinct returns an estimate of the number of
+// distinct non-null scalar values of the column.
+// Lists and structures are not counted.
+func (c *ColumnStats) Distinct() int64 {
+	return min(c.ndv.estimate(), c.Count)
+}
+
+const (
+	classNone = iota
+	classNumber
+	classTime
+	classString
+)
+
+// scalar is an ordered value
+type scalar struct {
+	class int8
+	isint bool // number is i rather than f
+	i     int64
+	f     float64
+	t     date.Time
+	s     string
+}
+
+func (s *scalar) float() float64 {
+	if s.isint {
+		return float64(s.i)
+	}
+	return s.f
+}
+
+func (s *scalar) datum() ion.Datum {
+	switch s.class {
+	case classNumber:
+		if s.isint {
+			return ion.Int(s.i)
+		}
+		return ion.Float(s.f)
+	case classTime:
+		return ion.Timestamp(s.t)
+	case classString:
+		return ion.String(s.s)
+	}
+	return ion.Empty
+}
+
+func (s *scalar) setDatum(d ion.Datum) error {
+	var err error
+	switch d.Type() {
+	case ion.IntType, ion.UintType:
+		s.class, s.isint = classNumber, true
+		s.i, err = d.Int()
+	case ion.FloatType:
+		s.class = classNumber
+		s.f, err = d.Float()
+	case ion.TimestampType:
+		s.class = classTime
+		s.t, err = d.Timestamp()
+	case ion.StringType, ion.SymbolType:
+		s.class = classString
+		s.s, err = d.String()
+	default:
+		return fmt.Errorf("unexpected min/max type %s", d.Type())
+	}
+	return err
+}
+
+// compareScalar compares two scalars
+// with class != classNone
+func compareScalar(x, y *scalar) int {
+	if x.class != y.class {
+		return int(x.class) - int(y.class)
+	}
+	switch x.class {
+	case classNumber:
+		if x.isint && y.isint {
+			return cmpOrdered(x.i, y.i)
+		}
+		return cmpOrdered(x.float(), y.float())
+	case classTime:
+		if x.t.Before(y.t) {
+			return -1
+		}
+		if x.t.After(y.t) {
+			return 1
+		}
+		return 0
+	default:
+		return strings.Compare(x.s, y.s)
+	}
+}
+
+func cmpOrdered[T int64 | float64](x, y T) int {
+	if x < y {
+		return -1
+	}
+	if x > y {
+		return 1
+	}
+	return 0
+}
+
+func (c *ColumnStats) observe(v *scalar) {
+	if c.min.class == classNone || compareScalar(v, &c.min) < 0 {
+		c.min = *v
+	}
+	if c.max.class == classNone || compareScalar(v, &c.max) > 0 {
+		c.max = *v
+	}
+}
+
+// observeString is equivalent to observe
+// for a string, but it only copies str
+// when it becomes the min or max
+func (c *ColumnStats) observeString(str []byte) {
+	if len(str) > MaxStatsString {
+		return
+	}
+	if c.min.class == classNone || (c.min.class == classString && string(str) < c.min.s) {
+		c.min = scalar{class: classString, s: string(str)}
+	}
+	if c.max.class < classString || string(str) > c.max.s {
+		c.max = scalar{class: classString, s: string(str)}
+	}
+}
+
+// hllBits is the number of bits of
+// the hash that select an hll register
+const hllBits = 10
+
+// hll is a HyperLogLog sketch; see
+// "HyperLogLog: the analysis of a near-optimal
+// cardinality estimation algorithm"
+// http://algo.inria.fr/flajolet/Publications/FlFuGaMe07.pdf
+type hll [1 << hllBits]uint8
+
+func (h *hll) add(hash uint64) {
+	i := hash >> (64 - hllBits)
+	rank := uint8(1)
+	for w := hash << hllBits; rank <= 64-hllBits && w&(1<<63) == 0; w <<= 1 {
+		rank++
+	}
+	h[i] = max(h[i], rank)
+}
+
+func (h *hll) merge(from *hll) {
+	for i := range h {
+		h[i] = max(h[i], from[i])
+	}
+}
+
+func (h *hll) empty() bool {
+	for i := range h {
+		if h[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *hll) estimate() int64 {
+	m := float64(len(h))
+	sum := 0.0
+	zeros := 0
+	for i := range h {
+		sum += 1.0 / float64(uint64(1)<<h[i])
+		if h[i] == 0 {
+			zeros++
+		}
+	}
+	e := 0.7213 / (1.0 + 1.079/m) * m * m / sum
+	if e <= 5*m/2 && zeros != 0 {
+		// small range correction
+		e = m * math.Log(m/float64(zeros))
+	}
+	return int64(e + 0.5)
+}
+
+func (s *Stats) column(path []byte) *ColumnStats {
+	if i, ok := s.index[string(path)]; ok {
+		return &s.Columns[i]
+	}
+	if len(s.Columns) >= MaxStatsColumns {
+		return nil
+	}
+	if s.index == nil {
+		s.index = make(map[string]int)
+	}
+	s.index[string(path)] = len(s.Columns)
+	s.Columns = append(s.Columns, ColumnStats{Path: string(path)})
+	return &s.Columns[len(s.Columns)-1]
+}
+
+func (s *Stats) reindex() {
+	clear(s.index)
+	if s.index == nil {
+		s.index = make(map[string]int, len(s.Columns))
+	}
+	for i := range s.Columns {
+		s.index[s.Columns[i].Path] = i
+	}
+}
+
+// Column returns the statistics for
+// the column with the given path, or
+// nil if the column is not present.
+func (s *Stats) Column(path string) *ColumnStats {
+	if s.index == nil && len(s.Columns) > 0 {
+		s.reindex()
+	}
+	if i, ok := s.index[path]; ok {
+		return &s.Columns[i]
+	}
+	return nil
+}
+
+// Add adds the structure at the start of rec,
+// whose symbols are defined in st, to the statistics.
+func (s *Stats) Add(st *ion.Symtab, rec []byte) error {
+	if ion.TypeOf(rec) != ion.StructType {
+		return nil
+	}
+	if s.index == nil && len(s.Columns) > 0 {
+		s.reindex()
+	}
+	s.Rows++
+	s.path = s.path[:0]
+	return s.walk(st, rec)
+}
+
+func (s *Stats) walk(st *ion.Symtab, rec []byte) error {
+	body, _ := ion.Contents(rec)
+	if body == nil {
+		return fmt.Errorf("blockfmt.Stats.Add: invalid structure")
+	}
+	prefix := len(s.path)
+	for len(body) > 0 {
+		sym, rest, err := ion.ReadLabel(body)
+		if err != nil {
+			return err
+		}
+		size := ion.SizeOf(rest)
+		if size <= 0 || size > len(rest) {
+			return fmt.Errorf("blockfmt.Stats.Add: invalid field size %d", size)
+		}
+		val := rest[:size]
+		body = rest[size:]
+		t := ion.TypeOf(val)
+		if t == ion.NullType && val[0] != 0x0f {
+			continue // nop pad
+		}
+		s.path = s.path[:prefix]
+		if prefix > 0 {
+			s.path = append(s.path, '.')
+		}
+		s.path = append(s.path, st.Get(sym)...)
+		c := s.column(s.path)
+		if c == nil {
+			continue
+		}
+		if err := s.value(st, c, val); err != nil {
+			return err
+		}
+		if t == ion.StructType {
+			if err := s.walk(st, val); err != nil {
+				return err
+			}
+		}
+	}
+	s.path = s.path[:prefix]
+	return nil
+}
+
+// value adds one value of a column
+func (s *Stats) value(st *ion.Symtab, c *ColumnStats, val []byte) error {
+	if val[0]&0x0f == 0x0f {
+		c.Nulls++
+		return nil
+	}
+	c.Count++
+	var err error
+	var v scalar
+	tmp := s.tmp[:0]
+	switch ion.TypeOf(val) {
+	case ion.StructType, ion.ListType, ion.SexpType:
+		return nil
+	case ion.BoolType:
+		var b bool
+		b, _, err = ion.ReadBool(val)
+		tmp = append(tmp, 'b')
+		if b {
+			tmp = append(tmp, 1)
+		}
+	case ion.IntType, ion.UintType, ion.FloatType:
+		err = readNumber(val, &v)
+		if !v.isint && v.f == math.Trunc(v.f) && math.Abs(v.f) < 1<<63 {
+			// integral floats are
+			// the same value as integers
+			v.isint, v.i = true, int64(v.f)
+		}
+		if v.isint {
+			tmp = binary.LittleEndian.AppendUint64(append(tmp, 'i'), uint64(v.i))
+		} else {
+			tmp = binary.LittleEndian.AppendUint64(append(tmp, 'f'), math.Float64bits(v.f))
+		}
+		c.observe(&v)
+	case ion.TimestampType:
+		v.class = classTime
+		v.t, _, err = ion.ReadTime(val)
+		tmp = binary.LittleEndian.AppendUint64(append(tmp, 't'), uint64(v.t.UnixNano()))
+		c.observe(&v)
+	case ion.StringType:
+		var str []byte
+		str, _, err = ion.ReadStringShared(val)
+		tmp = append(append(tmp, 's'), str...)
+		c.observeString(str)
+	case ion.SymbolType:
+		var sym ion.Symbol
+		sym, _, err = ion.ReadSymbol(val)
+		str := st.Get(sym)
+		tmp = append(append(tmp, 's'), str...)
+		c.observeString(tmp[1:])
+	default:
+		tmp = append(append(tmp, 'r'), val...)
+	}
+	if err != nil {
+		return fmt.Errorf("blockfmt.Stats.Add: %w", err)
+	}
+	c.ndv.add(siphash.Hash(0, 0, tmp))
+	s.tmp = tmp
+	return nil
+}
+
+func readNumber(val []byte, v *scalar) error {
+	var err error
+	v.class = classNumber
+	switch ion.TypeOf(val) {
+	case ion.UintType:
+		var u uint64
+		u, _, err = ion.ReadUint(val)
+		if u > math.MaxInt64 {
+			v.f = float64(u)
+		} else {
+			v.isint, v.i = true, int64(u)
+		}
+	case ion.IntType:
+		v.isint = true
+		v.i, _, err = ion.ReadInt(val)
+	default:
+		v.f, _, err = ion.ReadFloat64(val)
+	}
+	return err
+}
+
+// Merge adds the statistics of from to s
+// and sorts the columns of s by Path.
+func (s *Stats) Merge(from *Stats) {
+	if s.index == nil && len(s.Columns) > 0 {
+		s.reindex()
+	}
+	s.Rows += from.Rows
+	for i := range from.Columns {
+		src := &from.Columns[i]
+		c := s.column([]byte(src.Path))
+		if c == nil {
+			continue
+		}
+		c.Count += src.Count
+		c.Nulls += src.Nulls
+		if src.min.class != classNone {
+			c.observe(&src.min)
+			c.observe(&src.max)
+		}
+		c.ndv.merge(&src.ndv)
+	}
+	slices.SortFunc(s.Columns, func(x, y ColumnStats) int {
+		return strings.Compare(x.Path, y.Path)
+	})
+	s.reindex()
+}
+
+// Encode encodes s as a structure with a "rows"
+// field and a "columns" list of structures.
+func (s *Stats) Encode(dst *ion.Buffer, st *ion.Symtab) {
+	dst.BeginStruct(-1)
+	dst.BeginField(st.Intern("rows"))
+	dst.WriteInt(s.Rows)
+	dst.BeginField(st.Intern("columns"))
+	dst.BeginList(-1)
+	for i := range s.Columns {
+		c := &s.Columns[i]
+		dst.BeginStruct(-1)
+		dst.BeginField(st.Intern("path"))
+		dst.WriteString(c.Path)
+		dst.BeginField(st.Intern("count"))
+		dst.WriteInt(c.Count)
+		dst.BeginField(st.Intern("nulls"))
+		dst.WriteInt(c.Nulls)
+		if c.min.class != classNone {
+			dst.BeginField(st.Intern("min"))
+			c.Min().Encode(dst, st)
+			dst.BeginField(st.Intern("max"))
+			c.Max().Encode(dst, st)
+		}
+		if !c.ndv.empty() {
+			dst.BeginField(st.Intern("ndv"))
+			dst.WriteBlob(c.ndv[:])
+		}
+		dst.EndStruct()
+	}
+	dst.EndList()
+	dst.EndStruct()
+}
+
+// Decode decodes statistics encoded with Encode.
+func (s *Stats) Decode(d ion.Datum) error {
+	s.Rows = 0
+	s.Columns = s.Columns[:0]
+	err := d.UnpackStruct(func(f ion.Field) error {
+		var err error
+		switch f.Label {
+		case "rows":
+			s.Rows, err = f.Int()
+		case "columns":
+			err = f.UnpackList(func(d ion.Datum) error {
+				var c ColumnStats
+				err := d.UnpackStruct(func(f ion.Field) error {
+					var err error
+					switch f.Label {
+					case "path":
+						c.Path, err = f.String()
+					case "count":
+						c.Count, err = f.Int()
+					case "nulls":
+						c.Nulls, err = f.Int()
+					case "min":
+						err = c.min.setDatum(f.Datum)
+					case "max":
+						err = c.max.setDatum(f.Datum)
+					case "ndv":
+						var b []byte
+						b, err = f.Blob()
+						if err == nil && len(b) != len(c.ndv) {
+							err = fmt.Errorf("ndv: unexpected size %d", len(b))
+						}
+						copy(c.ndv[:], b)
+					}
+					return err
+				})
+				if err != nil {
+					return err
+				}
+				s.Columns = append(s.Columns, c)
+				return nil
+			})
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("blockfmt.Stats.Decode: %w", err)
+	}
+	s.index = nil // rebuilt on demand
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blockfmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+func addStats(t *testing.T, s *Stats, rows []string) {
+	var st ion.Symtab
+	var buf ion.Buffer
+	for i := range rows {
+		d, err := ion.FromJSON(&st, json.NewDecoder(strings.NewReader(rows[i])))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+		d.Encode(&buf, &st)
+		if err := s.Add(&st, buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestStatsAdd(t *testing.T) {
+	rows := []string{
+		`{"x": 1, "y": {"z": "foo", "w": null}}`,
+		`{"x": 2.5, "y": {"z": "bar"}, "l": [1, 2]}`,
+		`{"x": -3, "y": null, "t": "2023-01-02T03:04:05Z"}`,
+		`{"x": 2.0, "y": {"z": "foo"}, "t": "abc"}`,
+	}
+	var s Stats
+	addStats(t, &s, rows[:2])
+	var other Stats
+	addStats(t, &other, rows[2:])
+	s.Merge(&other)
+
+	if s.Rows != 4 {
+		t.Errorf("got %d rows", s.Rows)
+	}
+	var got []string
+	for i := range s.Columns {
+		c := &s.Columns[i]
+		lo, hi := "-", "-"
+		if d := c.Min(); !d.IsEmpty() {
+			lo = d.JSON()
+		}
+		if d := c.Max(); !d.IsEmpty() {
+			hi = d.JSON()
+		}
+		got = append(got, fmt.Sprintf("%s %d %d %s %s %d", c.Path, c.Count, c.Nulls, lo, hi, c.Distinct()))
+	}
+	want := []string{
+		"l 1 0 - - 0",
+		`t 2 0 "2023-01-02T03:04:05Z" "abc" 2`,
+		"x 4 0 -3 2.5 4",
+		"y 3 1 - - 0",
+		"y.w 0 1 - - 0",
+		`y.z 3 0 "bar" "foo" 2`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// round-trip through the encoding
+	var st ion.Symtab
+	var buf ion.Buffer
+	s.Encode(&buf, &st)
+	d, _, err := ion.ReadDatum(&st, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec Stats
+	if err := dec.Decode(d); err != nil {
+		t.Fatal(err)
+	}
+	if dec.Rows != s.Rows || len(dec.Columns) != len(s.Columns) {
+		t.Fatalf("decoded %d rows, %d columns", dec.Rows, len(dec.Columns))
+	}
+	for i := range s.Columns {
+		if dec.Columns[i] != s.Columns[i] {
+			t.Errorf("column %d: got %#v, want %#v", i, dec.Columns[i], s.Columns[i])
+		}
+	}
+	if c := dec.Column("y.z"); c == nil || c.Count != 3 {
+		t.Errorf("Column(y.z) = %v", c)
+	}
+}
+
+func TestStatsDistinct(t *testing.T) {
+	const n = 20000
+	var s, other Stats
+	rows := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		// every value appears twice
+		rows = append(rows, fmt.Sprintf(`{"id": %d, "s": "str%d"}`, i/2, i/2))
+	}
+	addStats(t, &s, rows[:n/2])
+	addStats(t, &other, rows[n/2:])
+	s.Merge(&other)
+	for _, path := range []string{"id", "s"} {
+		c := s.Column(path)
+		if c == nil {
+			t.Fatalf("no column %s", path)
+		}
+		est := c.Distinct()
+		// the standard error with 1024
+		// registers is about 3%
+		if est < n/2*90/100 || est > n/2*110/100 {
+			t.Errorf("%s: distinct estimate %d, want about %d", path, est, n/2)
+		}
+	}
+
+	// merging is idempotent
+	before := s.Column("id").Distinct()
+	s.Merge(&other)
+	if after := s.Column("id").Distinct(); after != before {
+		t.Errorf("distinct estimate changed from %d to %d", before, after)
+	}
+}
+
+func TestStatsCollectorError(t *testing.T) {
+	var st ion.Symtab
+	var buf ion.Buffer
+	d, err := ion.FromJSON(&st, json.NewDecoder(strings.NewReader(`{"x": 1}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Encode(&buf, &st)
+	// a structure whose only field is truncated
+	bad := []byte{0xd2, 0x8a, 0x25}
+
+	lst := make([]statsCollector, 2)
+	lst[0].add(&st, buf.Bytes())
+	lst[1].add(&st, bad)
+	lst[1].add(&st, buf.Bytes())
+	if lst[1].err == nil {
+		t.Fatal("no error from a truncated structure")
+	}
+	c := Converter{Stats: &Stats{Rows: 3}}
+	c.mergeStats(lst)
+	if c.StatsErr() != lst[1].err {
+		t.Errorf("StatsErr() = %v, want %v", c.StatsErr(), lst[1].err)
+	}
+	// the statistics of the other stream
+	// must not be merged either
+	if c.Stats.Rows != 3 || len(c.Stats.Columns) != 0 {
+		t.Errorf("statistics changed after an error: %+v", c.Stats)
+	}
+
+	c = Converter{Stats: &Stats{}}
+	c.mergeStats(lst[:1])
+	if c.StatsErr() != nil || c.Stats.Rows != 1 {
+		t.Errorf("got %d rows, error %v", c.Stats.Rows, c.StatsErr())
+	}
+}
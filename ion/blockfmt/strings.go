@@ -0,0 +1,301 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blockfmt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// MaxStringRange is the maximum length of the
+// bounds of the string ranges of a SparseIndex.
+// Strings longer than MaxStringRange are
+// recorded by their prefix.
+const MaxStringRange = 64
+
+// maxStringRanges is the maximum number of fields
+// for which string ranges are collected; if an object
+// has more fields with string values, then none of
+// the ranges are recorded
+const maxStringRanges = MaxStatsColumns
+
+// stringIndex is the range of the string values
+// of the field at path in the rows of a SparseIndex
+//
+// The strings are ordered by their bytes, which
+// is the order used by comparisons in queries.
+type stringIndex struct {
+	path     []string
+	min, max string
+	// prefix is set if max is only a prefix of
+	// the largest string, which may be any string
+	// that starts with max
+	prefix bool
+}
+
+// truncate returns the longest prefix of str that is
+// no longer than MaxStringRange and ends at the end
+// of a character, and whether str was truncated
+func truncate(str []byte) ([]byte, bool) {
+	if len(str) <= MaxStringRange {
+		return str, false
+	}
+	n := MaxStringRange
+	for n > 0 && !utf8.RuneStart(str[n]) {
+		n--
+	}
+	return str[:n], true
+}
+
+// observe adds str to the range of s,
+// which must already hold at least one string
+func (s *stringIndex) observe(str []byte) {
+	lo, trunc := truncate(str)
+	// a prefix of str is never larger than str,
+	// so it is a valid lower bound
+	if string(lo) < s.min {
+		s.min = string(lo)
+	}
+	if !trunc && !s.prefix {
+		if string(str) > s.max {
+			s.max = string(str)
+		}
+		return
+	}
+	if !s.above(lo, trunc) {
+		s.max, s.prefix = string(lo), trunc
+	}
+}
+
+// above returns whether the upper bound of s is
+// at least as large as the upper bound str (which
+// stands for any string starting with str if prefix is set)
+func (s *stringIndex) above(str []byte, prefix bool) bool {
+	if !s.prefix {
+		if !prefix {
+			return s.max >= string(str)
+		}
+		// s.max is larger than any string
+		// starting with str if it does not
+		// start with str itself
+		return s.max > string(str) && !strings.HasPrefix(s.max, string(str))
+	}
+	n := min(len(s.max), len(str))
+	if c := strings.Compare(s.max[:n], string(str[:n])); c != 0 {
+		return c > 0
+	}
+	// one bound is a prefix of the other; s covers
+	// str if s.max is the shorter one or str is not
+	// a prefix (in which case str starts with s.max)
+	return len(s.max) <= len(str) || !prefix
+}
+
+// union adds the range of other to s
+func (s *stringIndex) union(other *stringIndex) {
+	if other.min < s.min {
+		s.min = other.min
+	}
+	if !s.above([]byte(other.max), other.prefix) {
+		s.max, s.prefix = other.max, other.prefix
+	}
+}
+
+// compare returns whether some string in
+// the range of s may satisfy 'x op str'
+func (s *stringIndex) compare(op expr.CmpOp, str string) bool {
+	switch op {
+	case expr.Less:
+		return s.min < str
+	case expr.LessEquals:
+		return s.min <= str
+	case expr.Greater, expr.GreaterEquals:
+		if s.prefix {
+			// any string starting with s.max
+			// may be in the range
+			n := min(len(s.max), len(str))
+			return str[:n] <= s.max
+		}
+		if op == expr.Greater {
+			return s.max > str
+		}
+		return s.max >= str
+	case expr.Equals:
+		return s.min <= str && s.compare(expr.GreaterEquals, str)
+	case expr.NotEquals:
+		return s.prefix || s.min != str || s.max != str
+	}
+	return true
+}
+
+// unionStrings returns the union of the string ranges
+// in a and b, which must both be sorted by path;
+// the result does not alias a or b
+func unionStrings(a, b []stringIndex) []stringIndex {
+	out := make([]stringIndex, 0, max(len(a), len(b)))
+	for len(a) > 0 && len(b) > 0 {
+		c := pathcmp(a[0].path, b[0].path)
+		switch {
+		case c < 0:
+			out = append(out, a[0])
+			a = a[1:]
+		case c > 0:
+			out = append(out, b[0])
+			b = b[1:]
+		default:
+			r := a[0]
+			r.union(&b[0])
+			out = append(out, r)
+			a, b = a[1:], b[1:]
+		}
+	}
+	out = append(out, a...)
+	return append(out, b...)
+}
+
+// stringRanges collects the ranges of
+// the string values of the fields of rows
+type stringRanges struct {
+	ranges   map[string]*stringIndex // by '\x00'-separated path
+	overflow bool                    // more than maxStringRanges fields
+	path     []byte                  // scratch path for add
+}
+
+// add adds the string values of the structure
+// at the start of rec, whose symbols are defined
+// in st, to the ranges
+func (r *stringRanges) add(st *ion.Symtab, rec []byte) error {
+	if r.overflow || ion.TypeOf(rec) != ion.StructType {
+		return nil
+	}
+	r.path = r.path[:0]
+	return r.walk(st, rec)
+}
+
+func (r *stringRanges) walk(st *ion.Symtab, rec []byte) error {
+	body, _ := ion.Contents(rec)
+	if body == nil {
+		return fmt.Errorf("blockfmt: string ranges: invalid structure")
+	}
+	prefix := len(r.path)
+	for len(body) > 0 {
+		sym, rest, err := ion.ReadLabel(body)
+		if err != nil {
+			return err
+		}
+		size := ion.SizeOf(rest)
+		if size <= 0 || size > len(rest) {
+			return fmt.Errorf("blockfmt: string ranges: invalid field size %d", size)
+		}
+		val := rest[:size]
+		body = rest[size:]
+		r.path = r.path[:prefix]
+		if prefix > 0 {
+			r.path = append(r.path, 0)
+		}
+		r.path = append(r.path, st.Get(sym)...)
+		var str []byte
+		switch ion.TypeOf(val) {
+		case ion.StructType:
+			if err := r.walk(st, val); err != nil {
+				return err
+			}
+			continue
+		case ion.StringType:
+			if val[0]&0x0f == 0x0f {
+				continue // null.string
+			}
+			str, _, err = ion.ReadStringShared(val)
+		case ion.SymbolType:
+			if val[0]&0x0f == 0x0f {
+				continue // null.symbol
+			}
+			var sym ion.Symbol
+			sym, _, err = ion.ReadSymbol(val)
+			str = []byte(st.Get(sym))
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("blockfmt: string ranges: %w", err)
+		}
+		r.observe(str)
+		if r.overflow {
+			return nil
+		}
+	}
+	r.path = r.path[:prefix]
+	return nil
+}
+
+// observe adds str to the range of r.path
+func (r *stringRanges) observe(str []byte) {
+	if s, ok := r.ranges[string(r.path)]; ok {
+		s.observe(str)
+		return
+	}
+	if len(r.ranges) >= maxStringRanges {
+		r.overflow = true
+		return
+	}
+	if r.ranges == nil {
+		r.ranges = make(map[string]*stringIndex)
+	}
+	lo, trunc := truncate(str)
+	r.ranges[string(r.path)] = &stringIndex{
+		path:   strings.Split(string(r.path), "\x00"),
+		min:    string(lo),
+		max:    string(lo),
+		prefix: trunc,
+	}
+}
+
+// merge adds the ranges of from to r
+func (r *stringRanges) merge(from *stringRanges) {
+	if r.overflow || from.overflow {
+		r.overflow = true
+		return
+	}
+	for k, s := range from.ranges {
+		if mine, ok := r.ranges[k]; ok {
+			mine.union(s)
+			continue
+		}
+		if len(r.ranges) >= maxStringRanges {
+			r.overflow = true
+			return
+		}
+		if r.ranges == nil {
+			r.ranges = make(map[string]*stringIndex)
+		}
+		r.ranges[k] = s
+	}
+}
+
+// list returns the ranges sorted by path
+func (r *stringRanges) list() []stringIndex {
+	out := make([]stringIndex, 0, len(r.ranges))
+	for _, s := range r.ranges {
+		out = append(out, *s)
+	}
+	slices.SortFunc(out, func(x, y stringIndex) int {
+		return pathcmp(x.path, y.path)
+	})
+	return out
+}
@@ -0,0 +1,209 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blockfmt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+func TestStringIndex(t *testing.T) {
+	long := strings.Repeat("x", MaxStringRange)
+	observe := func(strs ...string) *stringIndex {
+		lo, trunc := truncate([]byte(strs[0]))
+		s := &stringIndex{min: string(lo), max: string(lo), prefix: trunc}
+		for _, str := range strs[1:] {
+			s.observe([]byte(str))
+		}
+		return s
+	}
+	tcs := []struct {
+		strs     []string
+		min, max string
+		prefix   bool
+	}{
+		{[]string{"b", "a", "c"}, "a", "c", false},
+		{[]string{"b", long + "yy", "a"}, "a", long, true},
+		// a longer string with the same prefix
+		// is still covered by the prefix
+		{[]string{long + "yy", long + "zz"}, long, long, true},
+		{[]string{long + "yy", "y"}, long, "y", false},
+		{[]string{"y", long + "yy"}, long, "y", false},
+		{[]string{"x", long + "yy"}, "x", long, true},
+		// truncated on a character boundary
+		{[]string{long[1:] + "ü"}, long[1:], long[1:], true},
+	}
+	for i := range tcs {
+		s := observe(tcs[i].strs...)
+		if s.min != tcs[i].min || s.max != tcs[i].max || s.prefix != tcs[i].prefix {
+			t.Errorf("%v: got (%q, %q, %v)", tcs[i].strs, s.min, s.max, s.prefix)
+		}
+	}
+
+	s := observe("bar", "foo")
+	p := observe("bar", long+"yy")
+	cmps := []struct {
+		s     *stringIndex
+		op    expr.CmpOp
+		str   string
+		match bool
+	}{
+		{s, expr.Equals, "bar", true},
+		{s, expr.Equals, "baz", true},
+		{s, expr.Equals, "ba", false},
+		{s, expr.Equals, "fooo", false},
+		{s, expr.Less, "bar", false},
+		{s, expr.LessEquals, "bar", true},
+		{s, expr.Greater, "foo", false},
+		{s, expr.GreaterEquals, "foo", true},
+		{s, expr.NotEquals, "bar", true},
+		{observe("bar"), expr.NotEquals, "bar", false},
+		{p, expr.Equals, long + "zzz", true},
+		{p, expr.Greater, long + "zzz", true},
+		{p, expr.Greater, "y", false},
+		{p, expr.Equals, "xy", false},
+	}
+	for i := range cmps {
+		c := &cmps[i]
+		if got := c.s.compare(c.op, c.str); got != c.match {
+			t.Errorf("(%q, %q) %s %q: got %v", c.s.min, c.s.max, c.op, c.str, got)
+		}
+	}
+
+	u := observe("z")
+	u.union(p)
+	if u.min != "bar" || u.max != "z" || u.prefix {
+		t.Errorf("union: got (%q, %q, %v)", u.min, u.max, u.prefix)
+	}
+	u = observe("m")
+	u.union(p)
+	if u.min != "bar" || u.max != long || !u.prefix {
+		t.Errorf("union: got (%q, %q, %v)", u.min, u.max, u.prefix)
+	}
+}
+
+func TestStringRanges(t *testing.T) {
+	var st ion.Symtab
+	var buf ion.Buffer
+	var sr stringRanges
+	rows := []string{
+		`{"a": "foo", "b": {"c": "x"}, "n": 1}`,
+		`{"a": "bar", "b": {"c": null}, "s": "q"}`,
+		`{"a": 3, "b": "y"}`,
+	}
+	for _, row := range rows {
+		d, err := ion.FromJSON(&st, json.NewDecoder(strings.NewReader(row)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+		d.Encode(&buf, &st)
+		if err := sr.add(&st, buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var si SparseIndex
+	si.setStrings(sr.list())
+	want := []string{
+		"a: bar foo",
+		"b: y y",
+		"b.c: x x",
+		"s: q q",
+	}
+	var got []string
+	for _, s := range si.strings {
+		got = append(got, fmt.Sprintf("%s: %s %s", strings.Join(s.path, "."), s.min, s.max))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got %q", got)
+	}
+	testSparseRoundtrip(t, &si)
+	if si.matchString([]string{"n"}, expr.Equals, "1") {
+		t.Error("n has no string values")
+	}
+	if !si.matchString([]string{"a"}, expr.Greater, "baz") {
+		t.Error("a > 'baz' should match")
+	}
+
+	// appending an index without
+	// string ranges forgets them
+	var other SparseIndex
+	other.bump()
+	si.bump()
+	si.Append(&other)
+	if si.HasStrings() || !si.matchString([]string{"n"}, expr.Equals, "1") {
+		t.Error("string ranges were kept")
+	}
+	testSparseRoundtrip(t, &si)
+}
+
+func TestConvertStringRanges(t *testing.T) {
+	inputs := func() []Input {
+		return []Input{{
+			R: io.NopCloser(strings.NewReader(`{"name": "bob", "age": 30}` + "\n" + `{"name": "alice"}`)),
+			F: MustSuffixToFormat(".json"),
+		}, {
+			R: io.NopCloser(strings.NewReader(`{"name": "carol", "city": "paris"}`)),
+			F: MustSuffixToFormat(".json"),
+		}}
+	}
+	for _, parallel := range []int{1, 2} {
+		t.Run(fmt.Sprintf("parallel=%d", parallel), func(t *testing.T) {
+			var out BufferUploader
+			out.PartSize = 4096
+			c := Converter{
+				Output:       &out,
+				Comp:         "zstd",
+				Inputs:       inputs(),
+				Align:        4096,
+				FlushMeta:    4096,
+				Parallel:     parallel,
+				StringRanges: true,
+			}
+			if err := c.Run(); err != nil {
+				t.Fatal(err)
+			}
+			si := &c.Trailer().Sparse
+			min, max, prefix, ok := si.StringRange([]string{"name"})
+			if !ok || min != "alice" || max != "carol" || prefix {
+				t.Errorf("name: got (%q, %q, %v, %v)", min, max, prefix, ok)
+			}
+			if _, _, _, ok := si.StringRange([]string{"age"}); ok {
+				t.Error("age has no strings")
+			}
+			var f Filter
+			f.Compile(&expr.Comparison{Op: expr.Greater, Left: expr.Ident("name"), Right: expr.String("dave")})
+			if f.MatchesAny(si) {
+				t.Error("name > 'dave' should not match")
+			}
+			f.Compile(&expr.Comparison{Op: expr.Equals, Left: expr.Ident("city"), Right: expr.String("paris")})
+			if !f.MatchesAny(si) {
+				t.Error("city = 'paris' should match")
+			}
+			// the negation cannot be pruned,
+			// since age is not a string
+			f.Compile(&expr.Not{Expr: &expr.Comparison{Op: expr.Equals, Left: expr.Ident("age"), Right: expr.String("x")}})
+			if !f.MatchesAny(si) {
+				t.Error("!(age = 'x') should match")
+			}
+		})
+	}
+}
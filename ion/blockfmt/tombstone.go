@@ -0,0 +1,108 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package blockfmt
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// Tombstone records the deletion of the rows
+// of some objects of an index that match a predicate.
+//
+// Deleting rows does not modify the packed
+// objects of the index; instead, the rows that
+// match a tombstone are excluded from queries
+// as they are scanned, until the objects are
+// rewritten without them and the tombstone
+// is removed.
+type Tombstone struct {
+	// Created is the time at which
+	// the rows were deleted.
+	Created date.Time
+	// Where matches the deleted rows.
+	// Rows for which Where does not
+	// evaluate to TRUE are not deleted.
+	Where expr.Node
+	// Objects is the sorted list of the paths
+	// of the objects that hold the deleted rows.
+	// Rows of other objects (for example, rows
+	// ingested after the deletion) are not deleted.
+	Objects []string
+}
+
+// Covers returns whether the rows of
+// the object at path p are deleted by t.
+func (t *Tombstone) Covers(p string) bool {
+	_, ok := slices.BinarySearch(t.Objects, p)
+	return ok
+}
+
+// Encode encodes t into dst.
+func (t *Tombstone) Encode(dst *ion.Buffer, st *ion.Symtab) {
+	dst.BeginStruct(-1)
+	dst.BeginField(st.Intern("created"))
+	dst.WriteTime(t.Created)
+	dst.BeginField(st.Intern("where"))
+	t.Where.Encode(dst, st)
+	dst.BeginField(st.Intern("objects"))
+	dst.BeginList(-1)
+	for i := range t.Objects {
+		dst.WriteString(t.Objects[i])
+	}
+	dst.EndList()
+	dst.EndStruct()
+}
+
+// Decode decodes a tombstone
+// produced by Encode into t.
+func (t *Tombstone) Decode(d ion.Datum) error {
+	*t = Tombstone{}
+	err := d.UnpackStruct(func(f ion.Field) error {
+		var err error
+		switch f.Label {
+		case "created":
+			t.Created, err = f.Timestamp()
+		case "where":
+			t.Where, err = expr.Decode(f.Datum)
+		case "objects":
+			err = f.UnpackList(func(d ion.Datum) error {
+				p, err := d.String()
+				t.Objects = append(t.Objects, p)
+				return err
+			})
+		default:
+			err = fmt.Errorf("unexpected field %q", f.Label)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if t.Where == nil {
+		return fmt.Errorf("tombstone missing \"where\"")
+	}
+	return nil
+}
+
+// Deleted returns idx.Tombstones.
+// (It implements pir.DeletedIndex.)
+func (idx *Index) Deleted() []Tombstone {
+	return idx.Tombstones
+}
@@ -66,6 +66,17 @@ type Trailer struct {
 	// For example, BlockShift of 20 means that
 	// blocks are 1MB (1 << 20) bytes each.
 	BlockShift int
+	// Dict is the ID of the Dictionary used
+	// to compress blocks, or the empty string
+	// if blocks are compressed without a dictionary.
+	// (See Dictionary.ID.)
+	Dict string
+	// Dictionary is the dictionary with the ID Dict,
+	// if it is available. Encode only writes Dict,
+	// but the trailer at the end of an object also
+	// holds the dictionary, so Dictionary is always
+	// set for a trailer that is read from an object.
+	Dictionary *Dictionary
 	// Blocks is the list of descriptors
 	// for each block.
 	Blocks []Blockdesc
@@ -79,6 +90,12 @@ type Trailer struct {
 // using the provided symbol table.
 // Note that Encode may add new symbols to the symbol table.
 func (t *Trailer) Encode(dst *ion.Buffer, st *ion.Symtab) {
+	t.encode(dst, st, false)
+}
+
+// encode encodes t, including t.Dictionary
+// if withDict is set
+func (t *Trailer) encode(dst *ion.Buffer, st *ion.Symtab, withDict bool) {
 	dst.BeginStruct(-1)
 
 	// we're encoding trailer version 1
@@ -94,6 +111,15 @@ func (t *Trailer) Encode(dst *ion.Buffer, st *ion.Symtab) {
 	dst.BeginField(st.Intern("blockshift"))
 	dst.WriteInt(int64(t.BlockShift))
 
+	if t.Dict != "" {
+		dst.BeginField(st.Intern("dict"))
+		dst.WriteString(t.Dict)
+		if withDict && t.Dictionary != nil {
+			dst.BeginField(st.Intern("dictionary"))
+			t.Dictionary.Encode(dst, st)
+		}
+	}
+
 	if t.Sparse.blocks != len(t.Blocks) {
 		panic("Trailer.Encode: Sparse #blocks don't match trailer blocks")
 	}
@@ -139,6 +165,12 @@ func countList(d ion.Datum) (int, error) {
 // memory-efficient way than decoding the trailers
 // individually.
 type TrailerDecoder struct {
+	// Dictionaries are the dictionaries that
+	// the decoded trailers may refer to by ID;
+	// Trailer.Dictionary is set to the one with
+	// the ID of Trailer.Dict, if there is one.
+	Dictionaries []*Dictionary
+
 	blockcap int
 	blocks   []Blockdesc
 	spans    []timespan
@@ -148,6 +180,23 @@ type TrailerDecoder struct {
 	rangecap   int
 	timeRanges []TimeRange
 	algo       string
+	dict       string
+	dictptr    *Dictionary
+}
+
+// lookupDict returns the dictionary in
+// d.Dictionaries with the given ID, or nil
+func (d *TrailerDecoder) lookupDict(id string) *Dictionary {
+	if d.dictptr != nil && d.dictptr.ID() == id {
+		return d.dictptr
+	}
+	for _, dict := range d.Dictionaries {
+		if dict.ID() == id {
+			d.dictptr = dict
+			return dict
+		}
+	}
+	return nil
 }
 
 // makeBlocks returns a []Blockdesc of len n, using the
@@ -324,6 +373,23 @@ func (d *TrailerDecoder) Decode(v ion.Datum, dst *Trailer) error {
 				return err
 			}
 			dst.BlockShift = int(shift)
+		case "dict":
+			id, err := f.StringShared()
+			if err != nil {
+				return err
+			}
+			// as with algo, the dictionary
+			// is usually shared by all blocks
+			if string(id) != d.dict {
+				d.dict = string(id)
+			}
+			dst.Dict = d.dict
+		case "dictionary":
+			dict := new(Dictionary)
+			if err := dict.Decode(f.Datum); err != nil {
+				return err
+			}
+			dst.Dictionary = dict
 		case "sparse":
 			seenSparse = true
 			return d.decodeSparse(&dst.Sparse, f.Datum)
@@ -402,6 +468,9 @@ func (d *TrailerDecoder) Decode(v ion.Datum, dst *Trailer) error {
 	if err != nil {
 		return fmt.Errorf("Trailer.Decode: %w", err)
 	}
+	if dst.Dict != "" && dst.Dictionary == nil {
+		dst.Dictionary = d.lookupDict(dst.Dict)
+	}
 	return nil
 }
 
@@ -28,13 +28,28 @@ import (
 // content written to diag means that the src had
 // no errors.
 func Validate(src io.Reader, t *Trailer, diag io.Writer) int {
-	d := Decoder{}
+	return validate(src, t, diag, false)
+}
+
+// ValidateChecksums works identically to Validate,
+// but it also verifies the checksums of the compressed
+// frames in src for compression algorithms that store them.
+func ValidateChecksums(src io.Reader, t *Trailer, diag io.Writer) int {
+	return validate(src, t, diag, true)
+}
+
+func validate(src io.Reader, t *Trailer, diag io.Writer, checksums bool) int {
+	d := Decoder{Checksums: checksums}
 	if t.Sparse.Blocks() != len(t.Blocks) {
-		fmt.Fprintf(diag, "sparse has %d blocks; trailer has %d", t.Sparse.Blocks(), len(t.Blocks))
+		fmt.Fprintf(diag, "sparse has %d blocks; trailer has %d\n", t.Sparse.Blocks(), len(t.Blocks))
 	}
 	d.Set(t)
 	w := checkWriter{dst: diag, blocks: t.Blocks, sparse: &t.Sparse}
-	d.Copy(&w, src)
+	// the trailer follows the data blocks
+	_, err := d.Copy(&w, io.LimitReader(src, t.Offset))
+	if err != nil {
+		fmt.Fprintf(diag, "decoding block %d chunk %d: %s\n", w.block, w.chunk, err)
+	}
 	return w.rows
 }
 
@@ -121,6 +136,9 @@ func (c *checkWriter) walkStruct(fields []byte) {
 }
 
 func (c *checkWriter) Write(block []byte) (int, error) {
+	if c.block >= len(c.blocks) {
+		return 0, fmt.Errorf("more than %d blocks of data", len(c.blocks))
+	}
 	// every block should begin with a BVM
 	if !c.seenBVM && (len(block) < 4 || !ion.IsBVM(block)) {
 		c.errorf("block %d chunk %d doesn't begin with a BVM", c.block, c.chunk)
@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -931,3 +932,74 @@ func BenchmarkChunkerWrite(b *testing.B) {
 		})
 	}
 }
+
+// blockWriter records each aligned write
+// to the chunker as a separate block
+type blockWriter struct {
+	blocks [][]byte
+}
+
+func (b *blockWriter) Write(p []byte) (int, error) {
+	b.blocks = append(b.blocks, slices.Clone(p))
+	return len(p), nil
+}
+
+func TestChunkerEmptyAsMissing(t *testing.T) {
+	rows := []string{
+		`{"a": "", "b": "x", "c": {"d": "", "e": 1}, "f": [""], "g": {}, "h": null}`,
+		`{"a": "y"}`,
+		`{"a": ""}`,
+	}
+	want := []string{
+		`{"b": "x", "c": {"e": 1}, "f": [""], "g": {}, "h": null}`,
+		`{"a": "y"}`,
+		`{}`,
+	}
+	var out blockWriter
+	cn := ion.Chunker{
+		W:              &out,
+		Align:          1024,
+		RangeAlign:     1024,
+		EmptyAsMissing: true,
+	}
+	for _, row := range rows {
+		d, err := ion.FromJSON(&cn.Symbols, json.NewDecoder(strings.NewReader(row)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.Encode(&cn.Buffer, &cn.Symbols)
+		if err := cn.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cn.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	var st ion.Symtab
+	var got []ion.Datum
+	for _, block := range out.blocks {
+		for len(block) > 0 {
+			var d ion.Datum
+			var err error
+			d, block, err = ion.ReadDatum(&st, block)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !d.IsNull() {
+				got = append(got, d)
+			}
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		w, err := ion.FromJSON(&st, json.NewDecoder(strings.NewReader(want[i])))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ion.Equal(got[i], w) {
+			t.Errorf("row %d: got %#v, want %s", i, got[i], want[i])
+		}
+	}
+}
@@ -99,6 +99,26 @@ type Chunker struct {
 
 	// compression is disabled
 	noCompress bool
+
+	// CheckUTF8, if set, causes ReadFrom to
+	// reject input containing strings that
+	// are not valid UTF-8 with a *UTF8Error.
+	CheckUTF8 bool
+
+	// EmptyAsMissing, if set, causes Commit to remove
+	// the fields with empty string values from each
+	// object (including the structures nested in its
+	// fields), so that those fields are MISSING.
+	// Empty lists and structures are kept.
+	EmptyAsMissing bool
+	emptybuf       Buffer // scratch buffer for EmptyAsMissing
+
+	// OnCommit, if non-nil, is called by Commit
+	// with each object committed to the chunker
+	// and the symbol table that the object uses.
+	// The object must not be retained after
+	// OnCommit returns.
+	OnCommit func(st *Symtab, obj []byte) error
 }
 
 // Set sets the buffer used by c to b and resets c to
@@ -406,11 +426,21 @@ func (c *Chunker) Commit() error {
 	if len(c.Buffer.segs) != 0 {
 		panic("ion.Chunker.Commit inside object")
 	}
+	if c.EmptyAsMissing {
+		if err := c.dropEmpty(); err != nil {
+			return err
+		}
+	}
 	cur := c.Buffer.Bytes()
 	lastsize := len(cur) - c.lastoff
 	if lastsize > c.Align {
 		return err2big(c.Align)
 	}
+	if c.OnCommit != nil {
+		if err := c.OnCommit(&c.Symbols, cur[c.lastoff:]); err != nil {
+			return err
+		}
+	}
 	c.compressed = false
 	if len(cur) <= c.Align && c.adjustSyms() {
 		c.lastoff = c.Buffer.Size()
@@ -506,6 +536,12 @@ func (c *Chunker) ReadFrom(r io.Reader, cons []Field) (int64, error) {
 				return n, err
 			}
 		}
+		if c.CheckUTF8 {
+			if uerr := checkUTF8(&st, this); uerr != nil {
+				uerr.Offset += n
+				return n, uerr
+			}
+		}
 		dat, _, err := ReadDatum(&st, this)
 		if err != nil {
 			return n, err
@@ -181,6 +181,10 @@ func (d Datum) Equal(x Datum) bool {
 			x, _ := x.Timestamp()
 			return d.Equal(x)
 		}
+	case DecimalType:
+		if x.Type() == DecimalType {
+			return decimalEqual(d.buf, x.buf)
+		}
 	}
 	return false
 }
@@ -1279,10 +1283,6 @@ func decodeFloatDatum(_ *Symtab, b []byte) (Datum, []byte, error) {
 	return rawDatum(nil, b), rest, nil
 }
 
-func decodeDecimalDatum(_ *Symtab, b []byte) (Datum, []byte, error) {
-	return Empty, nil, fmt.Errorf("ion: decimal decoding unimplemented")
-}
-
 func decodeTimestampDatum(_ *Symtab, b []byte) (Datum, []byte, error) {
 	_, rest, err := ReadTime(b)
 	if err != nil {
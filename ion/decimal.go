@@ -0,0 +1,223 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package ion
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ReadDecimal reads an ion decimal into coef
+// and returns the exponent and the subsequent
+// message bytes. The value of the decimal
+// is coef * 10^exp.
+func ReadDecimal(coef *big.Int, msg []byte) (int, []byte, error) {
+	if t := TypeOf(msg); t != DecimalType {
+		return 0, nil, bad(t, DecimalType, "ReadDecimal")
+	}
+	body, rest := Contents(msg)
+	if body == nil {
+		return 0, nil, errInvalidIon
+	}
+	coef.SetInt64(0)
+	if len(body) == 0 {
+		return 0, rest, nil // 0d0
+	}
+	exp, body, ok := readiv(body)
+	if !ok {
+		return 0, nil, errInvalidIon
+	}
+	if len(body) == 0 {
+		return exp, rest, nil
+	}
+	neg := body[0]&0x80 != 0
+	if body[0]&0x7f == 0 {
+		coef.SetBytes(body[1:])
+	} else {
+		// avoid mutating msg when clearing the sign bit
+		var tmp [16]byte
+		mag := append(tmp[:0], body...)
+		mag[0] &= 0x7f
+		coef.SetBytes(mag)
+	}
+	if neg {
+		coef.Neg(coef)
+	}
+	return exp, rest, nil
+}
+
+// WriteDecimal writes coef * 10^exp
+// as an ion decimal to the buffer.
+func (b *Buffer) WriteDecimal(coef *big.Int, exp int) {
+	if coef.Sign() == 0 && exp == 0 {
+		b.buf = append(b.buf, byte(DecimalType<<4))
+		b.shift()
+		return
+	}
+	var tmp [32]byte
+	body := appendiv(tmp[:0], exp)
+	if coef.Sign() != 0 {
+		// sign-and-magnitude integer; add a leading
+		// byte if the sign bit would be clobbered
+		mag := coef.Bytes()
+		pos := len(body)
+		if mag[0]&0x80 != 0 {
+			body = append(body, 0)
+		}
+		body = append(body, mag...)
+		if coef.Sign() < 0 {
+			body[pos] |= 0x80
+		}
+	}
+	b.begin(DecimalType, len(body))
+	copy(b.grow(len(body)), body)
+	b.shift()
+}
+
+// appendiv appends i to dst as a signed varint
+func appendiv(dst []byte, i int) []byte {
+	mag := uint(i)
+	sign := byte(0)
+	if i < 0 {
+		mag = uint(-i)
+		sign = 0x40
+	}
+	// the first byte holds 6 bits of magnitude
+	// and each subsequent byte holds 7 bits
+	n := 1
+	for m := mag >> 6; m != 0; m >>= 7 {
+		n++
+	}
+	pos := len(dst)
+	for j := 0; j < n; j++ {
+		dst = append(dst, 0)
+	}
+	for j := n - 1; j > 0; j-- {
+		dst[pos+j] = byte(mag & 0x7f)
+		mag >>= 7
+	}
+	dst[pos] = byte(mag) | sign
+	dst[pos+n-1] |= 0x80
+	return dst
+}
+
+// Decimal returns a Datum representing coef * 10^exp.
+func Decimal(coef *big.Int, exp int) Datum {
+	var buf Buffer
+	buf.WriteDecimal(coef, exp)
+	return rawDatum(nil, buf.Bytes())
+}
+
+// FormatDecimal formats coef * 10^exp
+// in decimal notation without an exponent,
+// preserving the number of fractional digits
+// (for example, 1000000 * 10^-2 is "10000.00").
+func FormatDecimal(coef *big.Int, exp int) string {
+	digits := new(big.Int).Abs(coef).String()
+	var sb strings.Builder
+	if coef.Sign() < 0 {
+		sb.WriteByte('-')
+	}
+	switch {
+	case exp >= 0:
+		sb.WriteString(digits)
+		if coef.Sign() != 0 {
+			sb.WriteString(strings.Repeat("0", exp))
+		}
+	case len(digits) > -exp:
+		sb.WriteString(digits[:len(digits)+exp])
+		sb.WriteByte('.')
+		sb.WriteString(digits[len(digits)+exp:])
+	default:
+		sb.WriteString("0.")
+		sb.WriteString(strings.Repeat("0", -exp-len(digits)))
+		sb.WriteString(digits)
+	}
+	return sb.String()
+}
+
+// decimalEqual returns whether the decimals
+// a and b have the same value, regardless of precision
+// (1.0 and 1.00 are equal)
+func decimalEqual(a, b []byte) bool {
+	var ca, cb big.Int
+	ea, _, err := ReadDecimal(&ca, a)
+	if err != nil {
+		return false
+	}
+	eb, _, err := ReadDecimal(&cb, b)
+	if err != nil {
+		return false
+	}
+	// scale both coefficients to the smaller exponent
+	if ea > eb {
+		ca.Mul(&ca, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(ea-eb)), nil))
+	} else if eb > ea {
+		cb.Mul(&cb, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(eb-ea)), nil))
+	}
+	return ca.Cmp(&cb) == 0
+}
+
+func decodeDecimalDatum(_ *Symtab, b []byte) (Datum, []byte, error) {
+	var coef big.Int
+	_, rest, err := ReadDecimal(&coef, b)
+	if err != nil {
+		return Empty, rest, fmt.Errorf("ion: %w", err)
+	}
+	return rawDatum(nil, b), rest, nil
+}
+
+// Rat returns the value of d as an exact
+// rational number if d is a decimal, an integer,
+// or a finite float.
+func (d Datum) Rat() (*big.Rat, error) {
+	switch d.Type() {
+	case DecimalType:
+		var coef big.Int
+		exp, _, err := ReadDecimal(&coef, d.buf)
+		if err != nil {
+			return nil, err
+		}
+		return decimalRat(&coef, exp), nil
+	case IntType, UintType:
+		var i big.Int
+		if d.IsUint() {
+			u, _ := d.Uint()
+			i.SetUint64(u)
+		} else {
+			v, _ := d.Int()
+			i.SetInt64(v)
+		}
+		return new(big.Rat).SetInt(&i), nil
+	case FloatType:
+		f, _ := d.Float()
+		r := new(big.Rat).SetFloat64(f)
+		if r == nil {
+			return nil, fmt.Errorf("ion: %g is not a finite number", f)
+		}
+		return r, nil
+	}
+	return nil, d.bad("", DecimalType)
+}
+
+// decimalRat returns coef * 10^exp
+func decimalRat(coef *big.Int, exp int) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(exp, -exp))), nil)
+	if exp >= 0 {
+		return new(big.Rat).SetInt(new(big.Int).Mul(coef, scale))
+	}
+	return new(big.Rat).SetFrac(coef, scale)
+}
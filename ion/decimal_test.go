@@ -0,0 +1,110 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package ion
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestDecimalRoundTrip(t *testing.T) {
+	huge, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	testcases := []struct {
+		coef *big.Int
+		exp  int
+		text string
+	}{
+		{big.NewInt(0), 0, "0"},
+		{big.NewInt(0), -2, "0.00"},
+		{big.NewInt(1), -2, "0.01"},
+		{big.NewInt(-1), -2, "-0.01"},
+		{big.NewInt(1000000), -2, "10000.00"},
+		{big.NewInt(128), 0, "128"},     // sign bit of first byte
+		{big.NewInt(-255), -1, "-25.5"}, // sign bit of first byte
+		{big.NewInt(12), 3, "12000"},
+		{big.NewInt(5), -100, "0." + strings.Repeat("0", 99) + "5"},
+		{huge, -10, "-12345678901234567890.1234567890"},
+	}
+	for i := range testcases {
+		tc := &testcases[i]
+		var buf Buffer
+		buf.WriteDecimal(tc.coef, tc.exp)
+		var coef big.Int
+		exp, rest, err := ReadDecimal(&coef, buf.Bytes())
+		if err != nil {
+			t.Fatalf("case %d: %s", i, err)
+		}
+		if len(rest) != 0 {
+			t.Errorf("case %d: %d bytes left over", i, len(rest))
+		}
+		if exp != tc.exp || coef.Cmp(tc.coef) != 0 {
+			t.Errorf("case %d: got %s * 10^%d, want %s * 10^%d", i, &coef, exp, tc.coef, tc.exp)
+		}
+		if got := FormatDecimal(&coef, exp); got != tc.text {
+			t.Errorf("case %d: formatted as %q, want %q", i, got, tc.text)
+		}
+		d, _, err := ReadDatum(nil, buf.Bytes())
+		if err != nil {
+			t.Fatalf("case %d: ReadDatum: %s", i, err)
+		}
+		if got := d.JSON(); got != tc.text {
+			t.Errorf("case %d: JSON is %q, want %q", i, got, tc.text)
+		}
+		if !d.Equal(Decimal(tc.coef, tc.exp)) {
+			t.Errorf("case %d: datum not equal to itself", i)
+		}
+	}
+}
+
+func TestDecimalEqual(t *testing.T) {
+	a := Decimal(big.NewInt(10), -1)  // 1.0
+	b := Decimal(big.NewInt(100), -2) // 1.00
+	c := Decimal(big.NewInt(101), -2) // 1.01
+	if !a.Equal(b) || !b.Equal(a) {
+		t.Error("1.0 != 1.00")
+	}
+	if a.Equal(c) {
+		t.Error("1.0 == 1.01")
+	}
+	if a.Equal(Int(1)) {
+		t.Error("decimals should not be equal to integers")
+	}
+}
+
+func TestDatumRat(t *testing.T) {
+	testcases := []struct {
+		in   Datum
+		want string
+	}{
+		{Decimal(big.NewInt(125), -2), "5/4"},
+		{Decimal(big.NewInt(-3), 2), "-300/1"},
+		{Int(-7), "-7/1"},
+		{Uint(7), "7/1"},
+		{Float(0.5), "1/2"},
+	}
+	for i := range testcases {
+		r, err := testcases[i].in.Rat()
+		if err != nil {
+			t.Fatalf("case %d: %s", i, err)
+		}
+		if got := r.String(); got != testcases[i].want {
+			t.Errorf("case %d: got %s, want %s", i, got, testcases[i].want)
+		}
+	}
+	if _, err := String("1").Rat(); err == nil {
+		t.Error("expected an error for a string")
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package ion
+
+import (
+	"fmt"
+)
+
+// isEmptyString returns whether v is
+// an empty (but not null) string
+func isEmptyString(v []byte) bool {
+	if TypeOf(v) != StringType || v[0]&0x0f == 0x0f {
+		return false
+	}
+	body, _ := Contents(v)
+	return body != nil && len(body) == 0
+}
+
+// hasEmptyStrings returns whether the structure
+// v or one of the structures in its fields has a
+// field with an empty string value
+func hasEmptyStrings(v []byte) bool {
+	body, _ := Contents(v)
+	for len(body) > 0 {
+		_, rest, err := ReadLabel(body)
+		if err != nil {
+			return false
+		}
+		size := SizeOf(rest)
+		if size <= 0 || size > len(rest) {
+			return false
+		}
+		val := rest[:size]
+		body = rest[size:]
+		if isEmptyString(val) ||
+			(TypeOf(val) == StructType && hasEmptyStrings(val)) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropEmptyStrings writes the structure v into dst
+// without the fields that have empty string values,
+// both in v and in the structures in the fields of v;
+// lists and structures that become empty are kept
+func dropEmptyStrings(dst *Buffer, v []byte) error {
+	body, _ := Contents(v)
+	if body == nil {
+		return fmt.Errorf("ion: invalid structure")
+	}
+	dst.BeginStruct(-1)
+	for len(body) > 0 {
+		sym, rest, err := ReadLabel(body)
+		if err != nil {
+			return err
+		}
+		size := SizeOf(rest)
+		if size <= 0 || size > len(rest) {
+			return fmt.Errorf("ion: invalid field size %d", size)
+		}
+		val := rest[:size]
+		body = rest[size:]
+		if isEmptyString(val) {
+			continue
+		}
+		dst.BeginField(sym)
+		if TypeOf(val) == StructType && hasEmptyStrings(val) {
+			if err := dropEmptyStrings(dst, val); err != nil {
+				return err
+			}
+			continue
+		}
+		dst.UnsafeAppend(val)
+	}
+	dst.EndStruct()
+	return nil
+}
+
+// dropEmpty removes the fields with empty string
+// values from the objects written since the last
+// call to Commit (see Chunker.EmptyAsMissing)
+func (c *Chunker) dropEmpty() error {
+	cur := c.Buffer.Bytes()[c.lastoff:]
+	found := false
+	for v := cur; len(v) > 0; {
+		size := SizeOf(v)
+		if size <= 0 || size > len(v) {
+			return fmt.Errorf("ion.Chunker: invalid object size %d", size)
+		}
+		if TypeOf(v) == StructType && hasEmptyStrings(v[:size]) {
+			found = true
+			break
+		}
+		v = v[size:]
+	}
+	if !found {
+		return nil
+	}
+	c.emptybuf.Reset()
+	for v := cur; len(v) > 0; {
+		size := SizeOf(v)
+		if TypeOf(v) == StructType {
+			if err := dropEmptyStrings(&c.emptybuf, v[:size]); err != nil {
+				return err
+			}
+		} else {
+			c.emptybuf.UnsafeAppend(v[:size])
+		}
+		v = v[size:]
+	}
+	c.Buffer.buf = append(c.Buffer.buf[:c.lastoff], c.emptybuf.Bytes()...)
+	return nil
+}
@@ -63,9 +63,11 @@ func TestTicketsToJSON(t *testing.T) {
 
 func TestToJSON(t *testing.T) {
 	cases := []struct {
-		item     Datum
-		want     string
-		annotate bool
+		item        Datum
+		want        string
+		annotate    bool
+		columns     []string
+		omitMissing bool
 	}{
 		{
 			item: NewStruct(nil,
@@ -86,7 +88,32 @@ func TestToJSON(t *testing.T) {
 			// x::y -> {"$ion_annotation$x":y}
 			item:     Annotation(nil, "foo", Int(10)),
 			annotate: true,
-			want:     `{"$ion_annotation$foo":10}}`,
+			want:     `{"$ion_annotation$foo":10}`,
+		},
+		{
+			// MISSING columns are written as null
+			item: NewStruct(nil,
+				[]Field{
+					{Label: "a", Datum: Null},
+					{Label: "b", Datum: NewList(nil, []Datum{Null, Int(1)}).Datum()},
+					{Label: "c", Datum: NewStruct(nil, []Field{{Label: "d", Datum: Null}}).Datum()},
+				},
+			).Datum(),
+			columns: []string{"x", "a", "b", "c", "d"},
+			want:    `{"a": null, "b": [null, 1], "c": {"d": null}, "x": null, "d": null}`,
+		},
+		{
+			// ... or omitted
+			item: NewStruct(nil,
+				[]Field{
+					{Label: "a", Datum: Null},
+					{Label: "b", Datum: NewList(nil, []Datum{Null, Int(1)}).Datum()},
+					{Label: "c", Datum: NewStruct(nil, []Field{{Label: "d", Datum: Null}}).Datum()},
+				},
+			).Datum(),
+			columns:     []string{"x", "a", "b", "c", "d"},
+			omitMissing: true,
+			want:        `{"a": null, "b": [null, 1], "c": {"d": null}}`,
 		},
 	}
 	contents := func(item Datum) []byte {
@@ -103,7 +130,7 @@ func TestToJSON(t *testing.T) {
 		want := cases[i].want
 		t.Run(fmt.Sprintf("case-%d", i), func(t *testing.T) {
 			var dst bytes.Buffer
-			if !cases[i].annotate {
+			if !cases[i].annotate && cases[i].columns == nil {
 				_, err := ToJSON(&dst, bufio.NewReader(bytes.NewReader(mem)))
 				if err != nil {
 					t.Fatal(err)
@@ -118,6 +145,8 @@ func TestToJSON(t *testing.T) {
 			dst.Reset()
 			w := NewJSONWriter(&dst, '\n')
 			w.ShowAnnotations = true
+			w.Columns = cases[i].columns
+			w.OmitMissing = cases[i].omitMissing
 			_, err := w.Write(mem)
 			if err != nil {
 				t.Fatal(err)
@@ -127,11 +156,60 @@ func TestToJSON(t *testing.T) {
 				t.Errorf("got %q", got)
 				t.Errorf("want %q", want)
 			}
-
+			// annotations used to be closed with "}}"
+			if !json.Valid([]byte(got)) {
+				t.Errorf("invalid JSON %q", got)
+			}
 		})
 	}
 }
 
+func TestAppendJSON(t *testing.T) {
+	item := NewStruct(nil,
+		[]Field{
+			{Label: "blob", Datum: Blob([]byte{0x0, 0x1, 0x2})},
+			{Label: "list", Datum: NewList(nil, []Datum{Int(1), String("x"), NewList(nil, nil).Datum()}).Datum()},
+			{Label: "empty", Datum: NewStruct(nil, nil).Datum()},
+			{Label: "null", Datum: Null},
+		},
+	).Datum()
+	var buf Buffer
+	var st Symtab
+	item.Encode(&buf, &st)
+
+	cases := []struct {
+		indent, want string
+	}{
+		{
+			want: `{"blob":"AAEC","list":[1,"x",[]],"empty":{},"null":null}`,
+		},
+		{
+			indent: "  ",
+			want: `{
+  "blob": "AAEC",
+  "list": [
+    1,
+    "x",
+    []
+  ],
+  "empty": {},
+  "null": null
+}`,
+		},
+	}
+	for i := range cases {
+		prefix := []byte("prefix:")
+		got, err := AppendJSON(prefix, &st, buf.Bytes(), cases[i].indent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "prefix:" + cases[i].want; string(got) != want {
+			t.Errorf("got %q", got)
+			t.Errorf("want %q", want)
+		}
+	}
+}
+
 func TestJSONArray(t *testing.T) {
 	st0 := NewStruct(nil,
 		[]Field{
@@ -206,6 +284,35 @@ func TestJSONArray(t *testing.T) {
 	}
 }
 
+func TestJSONArrayAnnotations(t *testing.T) {
+	var final bytes.Buffer
+	w := NewJSONWriter(&final, ',')
+	w.ShowAnnotations = true
+	for _, d := range []Datum{
+		NewStruct(nil, []Field{{Label: "x", Datum: Int(1)}}).Datum(),
+		Annotation(nil, "query_error", String("oops")),
+	} {
+		var tmp Buffer
+		var st Symtab
+		d.Encode(&tmp, &st)
+		split := tmp.Size()
+		st.Marshal(&tmp, true)
+		out := append(tmp.Bytes()[split:], tmp.Bytes()[:split]...)
+		_, err := w.Write(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := final.String()
+	if got != `[{"x": 1},{"$ion_annotation$query_error":"oops"}]` {
+		t.Fatal("got", got)
+	}
+}
+
 func TestTaxiToJSON(t *testing.T) {
 	buf := testdata(t, "nyc-taxi.block")
 	var dst bytes.Buffer
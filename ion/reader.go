@@ -16,9 +16,11 @@ package ion
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"io"
+	"math/big"
 	"slices"
 	"strconv"
 
@@ -58,7 +60,10 @@ type jswriter interface {
 	WriteString(s string) (int, error)
 }
 
-func toJSON(st *Symtab, w jswriter, buf []byte, s *scratch, annotate bool) (int, []byte, error) {
+// toJSON writes the JSON text of the ion value in buf;
+// if buf holds a structure, the fields in columns that
+// it does not contain are written as null
+func toJSON(st *Symtab, w jswriter, buf []byte, s *scratch, annotate bool, columns []string) (int, []byte, error) {
 	switch TypeOf(buf) {
 	case NullType:
 		if buf[0]&0x0f != 0x0f {
@@ -113,7 +118,13 @@ func toJSON(st *Symtab, w jswriter, buf []byte, s *scratch, annotate bool) (int,
 		n, err := w.Write(s.f64(f))
 		return n, rest, err
 	case DecimalType:
-		return 0, buf, fmt.Errorf("ToJSON: decimal not implemented")
+		var coef big.Int
+		exp, rest, err := ReadDecimal(&coef, buf)
+		if err != nil {
+			return 0, rest, fmt.Errorf("ToJSON: %w", err)
+		}
+		n, err := w.WriteString(FormatDecimal(&coef, exp))
+		return n, rest, err
 	case TimestampType:
 		t, rest, err := ReadTime(buf)
 		if err != nil {
@@ -158,22 +169,26 @@ func toJSON(st *Symtab, w jswriter, buf []byte, s *scratch, annotate bool) (int,
 		}
 		nn := 1
 		first := true
+		s.depth++
 		for len(body) > 0 {
-			var n int
-			if !first {
-				n, err = w.WriteString(", ")
-				nn += n
-				if err != nil {
-					return nn, rest, err
-				}
+			n, err := s.elem(w, first)
+			nn += n
+			if err != nil {
+				return nn, rest, err
 			}
-			n, body, err = toJSON(st, w, body, s, true)
+			n, body, err = toJSON(st, w, body, s, true, nil)
 			nn += n
 			if err != nil {
 				return nn, rest, err
 			}
 			first = false
 		}
+		s.depth--
+		n, err := s.end(w, first)
+		nn += n
+		if err != nil {
+			return nn, rest, err
+		}
 		err = w.WriteByte(']')
 		nn++
 		return nn, rest, err
@@ -190,14 +205,14 @@ func toJSON(st *Symtab, w jswriter, buf []byte, s *scratch, annotate bool) (int,
 		n := 0
 		var sym Symbol
 		first := true
+		s.depth++
+		var seen []bool
+		if len(columns) > 0 {
+			s.seen = slices.Grow(s.seen[:0], len(columns))[:len(columns)]
+			clear(s.seen)
+			seen = s.seen
+		}
 		for len(body) > 0 {
-			if !first {
-				n, err = w.WriteString(", ")
-				nn += n
-				if err != nil {
-					return 0, rest, err
-				}
-			}
 			sym, body, err = ReadLabel(body)
 			if err != nil {
 				return nn, rest, err
@@ -206,23 +221,45 @@ func toJSON(st *Symtab, w jswriter, buf []byte, s *scratch, annotate bool) (int,
 			if name == "" {
 				name = "$" + strconv.Itoa(int(sym))
 			}
-			n, err = w.Write(s.string(name))
+			if seen != nil {
+				if i := slices.Index(columns, name); i >= 0 {
+					seen[i] = true
+				}
+			}
+			n, err = s.label(w, name, first)
 			nn += n
 			if err != nil {
 				return nn, rest, err
 			}
-			n, err = w.WriteString(": ")
+			n, body, err = toJSON(st, w, body, s, true, nil)
 			nn += n
 			if err != nil {
 				return nn, rest, err
 			}
-			n, body, err = toJSON(st, w, body, s, true)
+			first = false
+		}
+		for i := range seen {
+			if seen[i] {
+				continue
+			}
+			n, err = s.label(w, columns[i], first)
+			nn += n
+			if err != nil {
+				return nn, rest, err
+			}
+			n, err = w.WriteString("null")
 			nn += n
 			if err != nil {
 				return nn, rest, err
 			}
 			first = false
 		}
+		s.depth--
+		n, err = s.end(w, first)
+		nn += n
+		if err != nil {
+			return nn, rest, err
+		}
 		err = w.WriteByte('}')
 		nn++
 		return nn, rest, err
@@ -252,13 +289,13 @@ func toJSON(st *Symtab, w jswriter, buf []byte, s *scratch, annotate bool) (int,
 				if err != nil {
 					return nn, rest, err
 				}
-				n, _, err = toJSON(st, w, body, s, true)
+				n, _, err = toJSON(st, w, body, s, true, nil)
 				nn += n
 				if err != nil {
 					return nn, rest, err
 				}
-				n, err = w.WriteString("}}")
-				nn += n
+				err = w.WriteByte('}')
+				nn++
 				if err != nil {
 					return nn, rest, err
 				}
@@ -277,6 +314,91 @@ func toJSON(st *Symtab, w jswriter, buf []byte, s *scratch, annotate bool) (int,
 // helper for formatting json objects
 type scratch struct {
 	buf []byte
+
+	// compact omits the spaces following
+	// the separators of lists and structures,
+	// and indent, if non-empty, causes each
+	// of their elements to be written on its
+	// own line (see AppendJSON)
+	compact bool
+	indent  string
+	depth   int // nesting depth of the current element
+
+	seen []bool // columns present in the current row
+}
+
+// label writes the separator preceding a
+// structure field followed by its name
+func (s *scratch) label(w jswriter, name string, first bool) (int, error) {
+	nn, err := s.elem(w, first)
+	if err != nil {
+		return nn, err
+	}
+	n, err := w.Write(s.string(name))
+	nn += n
+	if err != nil {
+		return nn, err
+	}
+	n, err = w.WriteString(s.colon())
+	nn += n
+	return nn, err
+}
+
+// elem writes the separator and the whitespace
+// preceding an element of a list or structure
+func (s *scratch) elem(w jswriter, first bool) (int, error) {
+	nn := 0
+	if !first {
+		sep := ", "
+		if s.compact || s.indent != "" {
+			sep = ","
+		}
+		n, err := w.WriteString(sep)
+		nn += n
+		if err != nil {
+			return nn, err
+		}
+	}
+	if s.indent == "" {
+		return nn, nil
+	}
+	n, err := s.newline(w)
+	return nn + n, err
+}
+
+// end writes the whitespace preceding the end
+// of a list or structure; empty is set if the
+// list or structure had no elements
+func (s *scratch) end(w jswriter, empty bool) (int, error) {
+	if s.indent == "" || empty {
+		return 0, nil
+	}
+	return s.newline(w)
+}
+
+func (s *scratch) newline(w jswriter) (int, error) {
+	err := w.WriteByte('\n')
+	if err != nil {
+		return 0, err
+	}
+	nn := 1
+	for i := 0; i < s.depth; i++ {
+		n, err := w.WriteString(s.indent)
+		nn += n
+		if err != nil {
+			return nn, err
+		}
+	}
+	return nn, nil
+}
+
+// colon returns the separator between
+// the name and the value of a structure field
+func (s *scratch) colon() string {
+	if s.compact {
+		return ":"
+	}
+	return ": "
 }
 
 func (s *scratch) f32(f float32) []byte {
@@ -389,7 +511,7 @@ func ToJSON(w io.Writer, r *bufio.Reader) (int, error) {
 				return n, err
 			}
 		}
-		n, _, err = toJSON(&st, js, this, &s, false)
+		n, _, err = toJSON(&st, js, this, &s, false, nil)
 		nn += n
 		if peeked {
 			r.Discard(size)
@@ -425,6 +547,20 @@ func ToJSON(w io.Writer, r *bufio.Reader) (int, error) {
 	return n, nil
 }
 
+// AppendJSON appends the JSON text of the ion value
+// at the start of buf to dst, using the same conversions
+// as ToJSON. Symbols and structure fields are resolved
+// with st. If indent is empty, the text is compact
+// (without any whitespace); otherwise each element of a
+// list or structure is written on its own line, indented
+// with one copy of indent per level of nesting.
+func AppendJSON(dst []byte, st *Symtab, buf []byte, indent string) ([]byte, error) {
+	s := scratch{compact: indent == "", indent: indent}
+	w := bytes.NewBuffer(dst)
+	_, _, err := toJSON(st, w, buf, &s, true, nil)
+	return w.Bytes(), err
+}
+
 // JSONWriter is an io.WriteCloser
 // that performs inline translation
 // of chunks of ion data into JSON objects.
@@ -446,6 +582,21 @@ type JSONWriter struct {
 	// will always begin with "$ion_annotation$"
 	// followed by the annotation label.
 	ShowAnnotations bool
+	// Columns, if non-nil, are the fields
+	// expected in every top-level structure
+	// (for example, the result columns of a query).
+	// Unless OmitMissing is set, the columns that
+	// are MISSING from a structure are written
+	// as null following its other fields, so that
+	// every JSON object has the same keys.
+	Columns []string
+	// OmitMissing causes MISSING columns to be
+	// left out of the JSON objects entirely,
+	// which is also how structures are written
+	// when Columns is nil. Fields that are
+	// present with a null value are always
+	// written as null.
+	OmitMissing bool
 
 	s  scratch
 	b  *bufio.Writer
@@ -506,7 +657,7 @@ func (w *JSONWriter) invisible(src []byte) bool {
 		return true
 	}
 	sym, _, _, _ := ReadAnnotation(src)
-	return sym != SystemSymSymbolTable
+	return sym == SystemSymSymbolTable
 }
 
 // Write implements io.Writer
@@ -515,6 +666,10 @@ func (w *JSONWriter) invisible(src []byte) bool {
 func (w *JSONWriter) Write(src []byte) (int, error) {
 	p := len(src)
 	var size int
+	var columns []string
+	if !w.OmitMissing {
+		columns = w.Columns
+	}
 	for len(src) > 0 {
 		comma := w.anyout && !w.nd
 		invisible := false
@@ -537,7 +692,7 @@ func (w *JSONWriter) Write(src []byte) (int, error) {
 		} else if !w.anyout && !w.nd && !invisible {
 			w.js.WriteByte('[')
 		}
-		n, _, err := toJSON(&w.st, w.js, src[:size], &w.s, w.ShowAnnotations)
+		n, _, err := toJSON(&w.st, w.js, src[:size], &w.s, w.ShowAnnotations, columns)
 		if err != nil {
 			w.flush()
 			return 0, err
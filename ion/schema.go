@@ -0,0 +1,171 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package ion
+
+import (
+	"fmt"
+	"io"
+)
+
+// SchemaWriter is an io.WriteCloser that
+// re-encodes an ion stream so that all of its
+// rows share a single symbol table that is
+// written up front. See NewSchemaWriter.
+//
+// The output begins with a symbol table holding
+// the declared columns, followed by a header
+//
+//	schema::{columns: ["col0", "col1", ...]}
+//
+// and then the rows, which are encoded using that
+// symbol table. A row with a field that is not one of
+// the columns causes Write to return an error. Symbols
+// that are not columns, such as the fields of nested
+// structures and symbol values, are appended to the
+// symbol table (with a local symbol table that imports
+// the current one) before the first row that uses them,
+// so the symbols of the columns never change.
+//
+// Top-level annotations (such as a query_error::{...}
+// value written when a query fails) end the rows; they
+// are written with their own symbol table, and any
+// row written after them is rejected.
+type SchemaWriter struct {
+	// W is the output io.Writer into
+	// which the stream is written.
+	W io.Writer
+	// Columns are the declared columns
+	// of the rows in the stream.
+	Columns []string
+
+	in, out   Symtab
+	buf, row  Buffer
+	columns   map[string]struct{}
+	started   bool
+	annotated bool
+}
+
+// NewSchemaWriter constructs a SchemaWriter that
+// writes rows with the given columns to w.
+// The header is written by the first call to
+// Write or Close, so an empty stream still
+// declares its columns.
+func NewSchemaWriter(w io.Writer, columns []string) *SchemaWriter {
+	return &SchemaWriter{W: w, Columns: columns}
+}
+
+// header writes the symbol table
+// and the schema:: annotation
+func (w *SchemaWriter) header() {
+	w.started = true
+	w.columns = make(map[string]struct{}, len(w.Columns))
+	for i := range w.Columns {
+		w.columns[w.Columns[i]] = struct{}{}
+	}
+	schema := w.out.Intern("schema")
+	columns := w.out.Intern("columns")
+	for i := range w.Columns {
+		w.out.Intern(w.Columns[i])
+	}
+	w.out.Marshal(&w.buf, true)
+	w.buf.BeginAnnotation(1)
+	w.buf.BeginField(schema)
+	w.buf.BeginStruct(-1)
+	w.buf.BeginField(columns)
+	w.buf.BeginList(-1)
+	for i := range w.Columns {
+		w.buf.WriteString(w.Columns[i])
+	}
+	w.buf.EndList()
+	w.buf.EndStruct()
+	w.buf.EndAnnotation()
+}
+
+// Write implements io.Writer
+//
+// The buffer passed to Write must contain
+// complete ion objects.
+func (w *SchemaWriter) Write(src []byte) (int, error) {
+	n := len(src)
+	w.buf.Reset()
+	if !w.started {
+		w.header()
+	}
+	for len(src) > 0 {
+		d, rest, err := ReadDatum(&w.in, src)
+		if err != nil {
+			return 0, err
+		}
+		src = rest
+		if d.IsEmpty() {
+			continue
+		}
+		if d.IsAnnotation() {
+			var st Symtab
+			var body Buffer
+			d.Encode(&body, &st)
+			st.Marshal(&w.buf, true)
+			w.buf.UnsafeAppend(body.Bytes())
+			w.annotated = true
+			continue
+		}
+		if w.annotated {
+			return 0, fmt.Errorf("ion.SchemaWriter: row after a top-level annotation")
+		}
+		if err := w.check(d); err != nil {
+			return 0, err
+		}
+		max := w.out.MaxID()
+		w.row.Reset()
+		d.Encode(&w.row, &w.out)
+		if w.out.MaxID() != max {
+			w.out.MarshalPart(&w.buf, Symbol(max))
+		}
+		w.buf.UnsafeAppend(w.row.Bytes())
+	}
+	_, err := w.W.Write(w.buf.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// check returns an error if the row d
+// has a field that is not a column
+func (w *SchemaWriter) check(d Datum) error {
+	if d.Type() != StructType {
+		return nil
+	}
+	s, _ := d.Struct()
+	return s.Each(func(f Field) error {
+		if _, ok := w.columns[f.Label]; !ok {
+			return fmt.Errorf("ion.SchemaWriter: field %q is not part of the schema", f.Label)
+		}
+		return nil
+	})
+}
+
+// Close writes the header if no rows
+// have been written. It does not close
+// the underlying io.Writer.
+func (w *SchemaWriter) Close() error {
+	if w.started {
+		return nil
+	}
+	w.buf.Reset()
+	w.header()
+	_, err := w.W.Write(w.buf.Bytes())
+	return err
+}
@@ -0,0 +1,193 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package ion
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// encodeChunk encodes d with its own symbol
+// table, as the chunks of a query result are
+func encodeChunk(d Datum, syms ...string) []byte {
+	var tmp Buffer
+	var st Symtab
+	for _, s := range syms {
+		st.Intern(s)
+	}
+	d.Encode(&tmp, &st)
+	split := tmp.Size()
+	st.Marshal(&tmp, true)
+	return append(tmp.Bytes()[split:], tmp.Bytes()[:split]...)
+}
+
+func TestSchemaWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewSchemaWriter(&out, []string{"x", "y"})
+	rows := []Datum{
+		NewStruct(nil, []Field{{Label: "x", Datum: Int(1)}, {Label: "y", Datum: String("a")}}).Datum(),
+		// a different symbol table with
+		// the same symbols in another order
+		NewStruct(nil, []Field{{Label: "y", Datum: String("b")}}).Datum(),
+	}
+	if _, err := w.Write(encodeChunk(rows[0])); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(encodeChunk(rows[1], "z", "y")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// exactly one symbol table is
+	// written, at the start of the stream
+	buf := out.Bytes()
+	if !IsBVM(buf) {
+		t.Fatal("output does not start with a BVM")
+	}
+	if bytes.Count(buf, []byte{0xe0, 0x01, 0x00, 0xea}) != 1 {
+		t.Fatal("more than one symbol table written")
+	}
+	var st Symtab
+	header, buf, err := ReadDatum(&st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lbl, schema, err := header.Annotation()
+	if err != nil || lbl != "schema" {
+		t.Fatalf("header %v is not a schema:: annotation (%v)", header, err)
+	}
+	var cols []string
+	err = schema.UnpackStruct(func(f Field) error {
+		return f.UnpackList(func(d Datum) error {
+			s, err := d.String()
+			cols = append(cols, s)
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(cols, ",") != "x,y" {
+		t.Fatalf("columns %q", cols)
+	}
+	for i := range rows {
+		var d Datum
+		d, buf, err = ReadDatum(&st, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(d, rows[i]) {
+			t.Errorf("row %d: got %v, want %v", i, d, rows[i])
+		}
+	}
+	if len(buf) != 0 {
+		t.Errorf("%d trailing bytes", len(buf))
+	}
+
+	// a new field is rejected
+	extra := NewStruct(nil, []Field{{Label: "x", Datum: Int(1)}, {Label: "z", Datum: Int(2)}}).Datum()
+	_, err = w.Write(encodeChunk(extra))
+	if err == nil || !strings.Contains(err.Error(), `"z"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+	// ... but the rows in the schema are not
+	if _, err := w.Write(encodeChunk(rows[0])); err != nil {
+		t.Fatal(err)
+	}
+	// annotations end the rows
+	if _, err := w.Write(encodeChunk(Annotation(nil, "query_error", String("oops")))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(encodeChunk(rows[0])); err == nil {
+		t.Error("row after annotation accepted")
+	}
+}
+
+func TestSchemaWriterNewSymbols(t *testing.T) {
+	var out bytes.Buffer
+	w := NewSchemaWriter(&out, []string{"x", "y"})
+	rows := []Datum{
+		NewStruct(nil, []Field{{Label: "x", Datum: Int(1)}}).Datum(),
+		// nested fields and symbol values that
+		// appear mid-stream are not columns
+		NewStruct(nil, []Field{
+			{Label: "x", Datum: NewStruct(nil, []Field{{Label: "inner", Datum: Int(2)}}).Datum()},
+			{Label: "y", Datum: Interned(nil, "sym")},
+		}).Datum(),
+		NewStruct(nil, []Field{
+			{Label: "x", Datum: NewList(nil, []Datum{Interned(nil, "sym"), Interned(nil, "other")}).Datum()},
+		}).Datum(),
+	}
+	for i := range rows {
+		if _, err := w.Write(encodeChunk(rows[i])); err != nil {
+			t.Fatalf("row %d: %s", i, err)
+		}
+	}
+	// new symbols don't make new top-level fields valid
+	_, err := w.Write(encodeChunk(NewStruct(nil, []Field{{Label: "inner", Datum: Int(3)}}).Datum()))
+	if err == nil || !strings.Contains(err.Error(), `"inner"`) {
+		t.Errorf("unexpected error %v", err)
+	}
+	buf := out.Bytes()
+	if bytes.Count(buf, []byte{0xe0, 0x01, 0x00, 0xea}) != 1 {
+		t.Fatal("more than one BVM written")
+	}
+	var st Symtab
+	_, buf, err = ReadDatum(&st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, _ := st.Symbolize("x")
+	y, _ := st.Symbolize("y")
+	for i := range rows {
+		var d Datum
+		d, buf, err = ReadDatum(&st, buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equal(d, rows[i]) {
+			t.Errorf("row %d: got %v, want %v", i, d, rows[i])
+		}
+	}
+	if len(buf) != 0 {
+		t.Errorf("%d trailing bytes", len(buf))
+	}
+	// the symbols of the columns are unchanged
+	if x2, _ := st.Symbolize("x"); x2 != x {
+		t.Errorf("symbol of x changed from %d to %d", x, x2)
+	}
+	if y2, _ := st.Symbolize("y"); y2 != y {
+		t.Errorf("symbol of y changed from %d to %d", y, y2)
+	}
+}
+
+func TestSchemaWriterEmpty(t *testing.T) {
+	var out bytes.Buffer
+	w := NewSchemaWriter(&out, []string{"a"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var st Symtab
+	d, rest, err := ReadDatum(&st, out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lbl, _, err := d.Annotation(); err != nil || lbl != "schema" || len(rest) != 0 {
+		t.Fatalf("got %v (%v), %d trailing bytes", d, err, len(rest))
+	}
+}
@@ -701,6 +701,25 @@ func readint(msg []byte) int64 {
 	return out
 }
 
+// fracnanos returns frac * 10^-digits seconds
+// as a number of nanoseconds, truncating the
+// digits beyond nanosecond precision
+func fracnanos(frac int64, digits int) int64 {
+	if digits < 9 && frac >= 1e9 {
+		return 0 // invalid; avoid overflow below
+	}
+	for ; digits < 9; digits++ {
+		frac *= 10
+	}
+	for ; digits > 9 && frac > 0; digits-- {
+		frac /= 10
+	}
+	if frac >= 1e9 {
+		return 0 // invalid; fraction must be < 1s
+	}
+	return frac
+}
+
 // ReadTime reads a timestamp object
 // and returns the subsequent message bytes.
 func ReadTime(msg []byte) (date.Time, []byte, error) {
@@ -747,15 +766,14 @@ func ReadTime(msg []byte) (date.Time, []byte, error) {
 	if !ok {
 		return out, rest, errInvalidIon
 	}
-	// TODO: use offset + fractional time components
+	// the date and time components are always
+	// stored in UTC, so the offset can be ignored
 	_ = offset
-	switch fracexp {
-	case -6:
-		nsec = int(frac) * 1000 // fractional component is exactly microseconds
-	case -9:
-		nsec = int(frac) // fractional component is exactly nanoseconds
-	default:
-		// unhandled!
+	// the fractional seconds are frac * 10^fracexp;
+	// normalize them to nanoseconds regardless of
+	// the precision they were stored with
+	if fracexp < 0 && frac > 0 {
+		nsec = int(fracnanos(frac, -fracexp))
 	}
 	out = date.Date(int(year), int(month), int(day), int(hour), int(minute), int(second), nsec)
 	return out, rest, nil
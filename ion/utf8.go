@@ -0,0 +1,174 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package ion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// UTF8Error is the error returned when
+// a string is not valid UTF-8.
+type UTF8Error struct {
+	// Offset is the byte offset of the
+	// string datum containing invalid UTF-8.
+	Offset int64
+	// Path is the path to the string within
+	// the top-level datum that contains it,
+	// as in "a.b[2].c", or the empty string
+	// if the top-level datum is the string.
+	Path string
+}
+
+func (e *UTF8Error) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("ion: invalid UTF-8 in string at offset %d", e.Offset)
+	}
+	return fmt.Sprintf("ion: invalid UTF-8 in string %s at offset %d", e.Path, e.Offset)
+}
+
+// ValidateUTF8 checks that every string in the
+// datum at the beginning of msg (including the
+// strings nested inside lists, structures and annotations)
+// is valid UTF-8. If it is not, ValidateUTF8 returns
+// a *UTF8Error with an Offset relative to the start of msg
+// and the Path to the string; field names in the path are
+// resolved with st, which may be nil.
+//
+// A leading binary version marker is skipped, so
+// the symbols in a symbol table that immediately
+// follows it are validated as well.
+//
+// ValidateUTF8 does not otherwise validate the
+// encoding of msg; malformed data is left for the
+// decoder to reject.
+func ValidateUTF8(st *Symtab, msg []byte) error {
+	if err := checkUTF8(st, msg); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkUTF8 is ValidateUTF8 returning a *UTF8Error
+func checkUTF8(st *Symtab, msg []byte) *UTF8Error {
+	c := utf8Checker{st: st}
+	off := c.invalid(msg)
+	if off < 0 {
+		return nil
+	}
+	return &UTF8Error{Offset: int64(off), Path: c.pathString()}
+}
+
+// utf8Checker tracks the path to the
+// value being validated
+type utf8Checker struct {
+	st   *Symtab
+	path []string // field names and "[index]" elements
+}
+
+func (c *utf8Checker) label(sym Symbol) string {
+	if int(sym) < len(systemsyms) {
+		return systemsyms[sym]
+	}
+	if c.st != nil {
+		if str, ok := c.st.Lookup(sym); ok {
+			return str
+		}
+	}
+	return "$" + strconv.Itoa(int(sym))
+}
+
+func (c *utf8Checker) pathString() string {
+	var b strings.Builder
+	for i, elem := range c.path {
+		if i > 0 && !strings.HasPrefix(elem, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(elem)
+	}
+	return b.String()
+}
+
+// invalid returns the offset of the first
+// string with invalid UTF-8 in msg, or -1
+func (c *utf8Checker) invalid(msg []byte) int {
+	if IsBVM(msg) {
+		if off := c.invalid(msg[4:]); off >= 0 {
+			return 4 + off
+		}
+		return -1
+	}
+	size := SizeOf(msg)
+	hdr := HeaderSizeOf(msg)
+	if size <= 0 || hdr <= 0 || size > len(msg) || hdr > size {
+		return -1
+	}
+	body := msg[hdr:size]
+	switch TypeOf(msg) {
+	case StringType:
+		if !utf8.Valid(body) {
+			return 0
+		}
+	case ListType, SexpType:
+		return c.invalidSeq(body, hdr, false)
+	case StructType:
+		return c.invalidSeq(body, hdr, true)
+	case AnnotationType:
+		// skip the annotation labels
+		n, rest, ok := readuv(body)
+		if !ok || int(n) > len(rest) {
+			return -1
+		}
+		inner := hdr + (len(body) - len(rest)) + int(n)
+		if off := c.invalid(msg[inner:size]); off >= 0 {
+			return inner + off
+		}
+	}
+	return -1
+}
+
+// invalidSeq calls invalid for each of the
+// (optionally labeled) values in body, which begins
+// at offset base; the path is left pointing at
+// the invalid value if there is one
+func (c *utf8Checker) invalidSeq(body []byte, base int, labeled bool) int {
+	pos := 0
+	for i := 0; pos < len(body); i++ {
+		elem := ""
+		if labeled {
+			sym, rest, ok := readuv(body[pos:])
+			if !ok {
+				return -1
+			}
+			pos = len(body) - len(rest)
+			elem = c.label(Symbol(sym))
+		} else {
+			elem = "[" + strconv.Itoa(i) + "]"
+		}
+		size := SizeOf(body[pos:])
+		if size <= 0 || pos+size > len(body) {
+			return -1
+		}
+		c.path = append(c.path, elem)
+		if off := c.invalid(body[pos : pos+size]); off >= 0 {
+			return base + pos + off
+		}
+		c.path = c.path[:len(c.path)-1]
+		pos += size
+	}
+	return -1
+}
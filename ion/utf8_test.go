@@ -0,0 +1,127 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package ion
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestValidateUTF8(t *testing.T) {
+	var st Symtab
+	bad := "abc\xffdef"
+	testcases := []struct {
+		datum Datum
+		bad   bool
+		path  string
+	}{
+		{datum: String("hello, 世界")},
+		{datum: String(bad), bad: true},
+		{datum: Int(-1)},
+		{datum: NewList(&st, []Datum{Int(1), String("x"), String(bad)}).Datum(), bad: true, path: "[2]"},
+		{datum: NewStruct(&st, []Field{
+			{Label: "a", Datum: String("ok")},
+			{Label: "b", Datum: NewStruct(&st, []Field{{Label: "c", Datum: String(bad)}}).Datum()},
+		}).Datum(), bad: true, path: "b.c"},
+		{datum: NewStruct(&st, []Field{
+			{Label: "l", Datum: NewList(&st, []Datum{
+				String("ok"),
+				NewStruct(&st, []Field{{Label: "d", Datum: String(bad)}}).Datum(),
+			}).Datum()},
+		}).Datum(), bad: true, path: "l[1].d"},
+		{datum: NewStruct(&st, []Field{{Label: "a", Datum: String("ok")}}).Datum()},
+		{datum: Annotation(&st, "foo", String(bad)), bad: true},
+	}
+	for i := range testcases {
+		var buf Buffer
+		testcases[i].datum.Encode(&buf, &st)
+		err := ValidateUTF8(&st, buf.Bytes())
+		if !testcases[i].bad {
+			if err != nil {
+				t.Errorf("case %d: unexpected error %v", i, err)
+			}
+			continue
+		}
+		var uerr *UTF8Error
+		if !errors.As(err, &uerr) {
+			t.Errorf("case %d: expected a *UTF8Error; got %v", i, err)
+			continue
+		}
+		// the offset should point at the bad string
+		str, _, err := ReadString(buf.Bytes()[uerr.Offset:])
+		if err != nil || str != bad {
+			t.Errorf("case %d: offset %d does not point to the bad string", i, uerr.Offset)
+		}
+		if uerr.Path != testcases[i].path {
+			t.Errorf("case %d: path %q, expected %q", i, uerr.Path, testcases[i].path)
+		}
+	}
+}
+
+func TestChunkerCheckUTF8(t *testing.T) {
+	var st Symtab
+	var buf Buffer
+	for _, s := range []string{"first", "second", "th\xe2\x82ird"} {
+		NewStruct(&st, []Field{{Label: "x", Datum: String(s)}}).Encode(&buf, &st)
+	}
+	// symbol table first, then the rows
+	var data Buffer
+	st.Marshal(&data, true)
+	start := int64(data.Size())
+	data.UnsafeAppend(buf.Bytes())
+
+	cn := Chunker{W: io.Discard, Align: 1024, CheckUTF8: true}
+	_, err := cn.ReadFrom(bytes.NewReader(data.Bytes()), nil)
+	var uerr *UTF8Error
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected *UTF8Error; got %v", err)
+	}
+	str, _, err := ReadString(data.Bytes()[uerr.Offset:])
+	if err != nil || str != "th\xe2\x82ird" {
+		t.Errorf("offset %d (data starts at %d) does not point to the bad string", uerr.Offset, start)
+	}
+	if uerr.Path != "x" {
+		t.Errorf("path %q, expected %q", uerr.Path, "x")
+	}
+
+	// without CheckUTF8, the data is accepted
+	cn = Chunker{W: io.Discard, Align: 1024}
+	_, err = cn.ReadFrom(bytes.NewReader(data.Bytes()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChunkerCheckUTF8Symbols(t *testing.T) {
+	var st Symtab
+	var buf Buffer
+	NewStruct(&st, []Field{{Label: "bad\xff", Datum: String("ok")}}).Encode(&buf, &st)
+	var data Buffer
+	st.Marshal(&data, true)
+	data.UnsafeAppend(buf.Bytes())
+
+	cn := Chunker{W: io.Discard, Align: 1024, CheckUTF8: true}
+	_, err := cn.ReadFrom(bytes.NewReader(data.Bytes()), nil)
+	var uerr *UTF8Error
+	if !errors.As(err, &uerr) {
+		t.Fatalf("expected *UTF8Error; got %v", err)
+	}
+	str, _, err := ReadString(data.Bytes()[uerr.Offset:])
+	if err != nil || str != "bad\xff" {
+		t.Errorf("offset %d does not point to the bad symbol", uerr.Offset)
+	}
+}
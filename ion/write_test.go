@@ -17,6 +17,7 @@ package ion
 import (
 	"bytes"
 	"math/rand"
+	"slices"
 	"testing"
 	"time"
 
@@ -228,6 +229,40 @@ func TestTime(t *testing.T) {
 	}
 }
 
+func TestReadTimePrecision(t *testing.T) {
+	// 2000-01-01T00:00:00 followed by
+	// a fraction_exponent and a coefficient
+	prefix := []byte{0x80, 0x0F, 0xD0, 0x81, 0x81, 0x80, 0x80, 0x80}
+	tcs := []struct {
+		frac []byte
+		nsec int
+	}{
+		{nil, 0},
+		{[]byte{0xC3}, 0},                                 // .000
+		{[]byte{0xC1, 0x05}, 500000000},                   // .5
+		{[]byte{0xC3, 0x01, 0xF4}, 500000000},             // .500
+		{[]byte{0xC6, 0x07, 0xA1, 0x20}, 500000000},       // .500000
+		{[]byte{0xC6, 0x01, 0xE2, 0x40}, 123456000},       // .123456
+		{[]byte{0xC9, 0x01}, 1},                           // .000000001
+		{[]byte{0xCA, 0x49, 0x96, 0x02, 0xD3}, 123456789}, // .1234567891
+	}
+	for i := range tcs {
+		body := append(slices.Clone(prefix), tcs[i].frac...)
+		msg := append([]byte{byte(TimestampType<<4) | byte(len(body))}, body...)
+		got, rest, err := ReadTime(msg)
+		if err != nil {
+			t.Fatalf("case %d: %s", i, err)
+		}
+		if len(rest) != 0 {
+			t.Errorf("case %d: %d bytes left over?", i, len(rest))
+		}
+		want := date.Date(2000, 1, 1, 0, 0, 0, tcs[i].nsec)
+		if !got.Equal(want) {
+			t.Errorf("case %d: got %s, want %s", i, got, want)
+		}
+	}
+}
+
 func TestSizeOf(t *testing.T) {
 	tcs := []struct {
 		mem  []byte
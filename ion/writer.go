@@ -19,6 +19,7 @@ import (
 	"io"
 	"math"
 	"math/bits"
+	"time"
 
 	"github.com/SnellerInc/sneller/date"
 )
@@ -483,8 +484,26 @@ const (
 	TruncToHour
 	TruncToMinute
 	TruncToSecond
+	TruncToMillisecond
+	TruncToMicrosecond
+	TruncToNanosecond
 )
 
+// fracdigits returns the number of digits of
+// the fractional seconds that are kept by t.
+func (t TimeTrunc) fracdigits() int {
+	switch t {
+	case TruncToMillisecond:
+		return 3
+	case TruncToMicrosecond:
+		return 6
+	case TruncToNanosecond:
+		return 9
+	default:
+		return 0
+	}
+}
+
 // truncate returns d with truncation applied.
 func (t TimeTrunc) truncate(d date.Time) date.Time {
 	switch t {
@@ -498,6 +517,12 @@ func (t TimeTrunc) truncate(d date.Time) date.Time {
 		return date.Date(d.Year(), d.Month(), d.Day(), d.Hour(), 0, 0, 0)
 	case TruncToMinute:
 		return date.Date(d.Year(), d.Month(), d.Day(), d.Hour(), d.Minute(), 0, 0)
+	case TruncToMillisecond:
+		return d.Truncate(time.Millisecond)
+	case TruncToMicrosecond:
+		return d.Truncate(time.Microsecond)
+	case TruncToNanosecond:
+		return d
 	case TruncToSecond:
 		fallthrough
 	default:
@@ -526,6 +551,24 @@ func (b *Buffer) WriteTruncatedTime(t date.Time, trunc TimeTrunc) {
 		size = 8
 	}
 
+	// sub-second precisions are written as
+	// fraction_exponent = -digits and a coefficient
+	// holding exactly that many digits
+	var frac uint64
+	var fracsize byte
+	digits := trunc.fracdigits()
+	if digits > 0 {
+		frac = uint64(t.Nanosecond())
+		for i := digits; i < 9; i++ {
+			frac /= 10
+		}
+		fracsize = byte((bits.Len64(frac) + 8) / 8)
+		if frac == 0 {
+			fracsize = 0
+		}
+		size = 8 + 1 + fracsize
+	}
+
 	buf := b.grow(int(size) + 1)
 	defer b.shift()
 
@@ -558,7 +601,14 @@ func (b *Buffer) WriteTruncatedTime(t date.Time, trunc TimeTrunc) {
 	}
 
 	buf[8] = byte(t.Second() | 0x80)
-	// case: trunc == TruncToSecond
+	if digits == 0 {
+		return
+	}
+
+	buf[9] = 0xC0 | byte(digits) // fraction_exponent = -digits
+	for i := byte(0); i < fracsize; i++ {
+		buf[9+fracsize-i] = byte(frac >> (8 * i))
+	}
 }
 
 // Bytes returns the current contents of the buffer.
@@ -71,3 +71,40 @@ func TestWriteTruncatedTimeMatchesWriteTime(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteTruncatedTimeFraction(t *testing.T) {
+	ts := date.Date(2021, 8, 22, 14, 42, 32, 123456789)
+	prefix := []byte{0x80, 0x0f, 0xe5, 0x88, 0x96, 0x8e, 0xaa, 0xa0}
+
+	testcases := []struct {
+		trunc TimeTrunc
+		ion   []byte
+	}{
+		{trunc: TruncToMillisecond,
+			ion: []byte{0x6a, 0xc3, 0x7b}},
+		{trunc: TruncToMicrosecond,
+			ion: []byte{0x6c, 0xc6, 0x01, 0xe2, 0x40}},
+		{trunc: TruncToNanosecond,
+			ion: []byte{0x6d, 0xc9, 0x07, 0x5b, 0xcd, 0x15}},
+	}
+
+	var buf Buffer
+	for i := range testcases {
+		buf.Reset()
+		buf.WriteTruncatedTime(ts, testcases[i].trunc)
+		want := append([]byte{testcases[i].ion[0]}, prefix...)
+		want = append(want, testcases[i].ion[1:]...)
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Logf("got:      % 02x", buf.Bytes())
+			t.Logf("expected: % 02x", want)
+			t.Errorf("case #%d: wrongly encoded ion", i)
+		}
+		got, _, err := ReadTime(buf.Bytes())
+		if err != nil {
+			t.Fatalf("case #%d: %s", i, err)
+		}
+		if exp := testcases[i].trunc.truncate(ts); !got.Equal(exp) {
+			t.Errorf("case #%d: read %s, expected %s", i, got, exp)
+		}
+	}
+}
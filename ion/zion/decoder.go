@@ -101,6 +101,16 @@ func (d *Decoder) Reset() {
 	d.tmp = d.tmp[:0]
 	d.dst = nil
 	d.fault = noFault
+	d.shape.VerifyChecksums = false
+}
+
+// SetVerifyChecksums determines whether or not
+// the checksums of compressed buckets are verified
+// during decoding. Verification is disabled by default,
+// since it adds measurably to the cost of decoding.
+// Reset disables verification.
+func (d *Decoder) SetVerifyChecksums(v bool) {
+	d.shape.VerifyChecksums = v
 }
 
 // SetWildcard tells the decoder to decode
@@ -17,6 +17,7 @@ package zion
 import (
 	"fmt"
 	"math"
+	"slices"
 
 	"github.com/SnellerInc/sneller/ion"
 	"github.com/SnellerInc/sneller/ion/zion/zll"
@@ -50,6 +51,43 @@ type Encoder struct {
 	buck  [zll.NumBuckets]bucket
 	hints [zll.NumBuckets]zll.BucketHints
 	seed  uint32
+
+	// hot is the list of fields set by WithHotFields;
+	// hotbuckets is the bitmap of the buckets
+	// that hold them with the current symbol table
+	hot        []string
+	hotbuckets uint32
+}
+
+// EncoderOption is an optional argument
+// to NewEncoder to indicate optional
+// Encoder configuration.
+type EncoderOption func(e *Encoder)
+
+// WithHotFields is an option that can be passed
+// to NewEncoder to indicate the top-level fields
+// that are read by nearly every query (a timestamp, for example).
+// The buckets that hold these fields are compressed
+// with a faster-to-decompress (but less compact)
+// encoding, which lowers the cost of decoding them.
+//
+// The placement of fields into buckets is unchanged,
+// so the output remains readable by any Decoder.
+func WithHotFields(fields ...string) EncoderOption {
+	return func(e *Encoder) {
+		e.hot = slices.Clone(fields)
+	}
+}
+
+// NewEncoder constructs an Encoder with the given options.
+// The zero value of Encoder is equivalent to the result
+// of NewEncoder called without options.
+func NewEncoder(opts ...EncoderOption) *Encoder {
+	e := &Encoder{}
+	for _, o := range opts {
+		o(e)
+	}
+	return e
 }
 
 // SetSymbols sets the current state of the
@@ -129,6 +167,7 @@ func (e *Encoder) Encode(src, dst []byte) ([]byte, error) {
 		return nil, err
 	}
 	for i := 0; i < zll.NumBuckets; i++ {
+		e.hints[i].Hot = e.hotbuckets&(1<<i) != 0
 		dst, err = e.Algo.Compress(&e.hints[i], e.buck[i].mem, dst)
 		if err != nil {
 			return nil, err
@@ -144,6 +183,12 @@ func (e *Encoder) precompute() {
 		n := len(e.sym2bucket)
 		e.sym2bucket = append(e.sym2bucket, uint8(zll.SymbolBucket(0, uint8(e.seed), ion.Symbol(n))))
 	}
+	e.hotbuckets = 0
+	for _, name := range e.hot {
+		if sym, ok := e.st.Symbolize(name); ok {
+			e.hotbuckets |= 1 << e.sym2bucket[sym]
+		}
+	}
 }
 
 func skipOne(mem []byte) ([]byte, error) {
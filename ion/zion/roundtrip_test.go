@@ -293,6 +293,69 @@ func TestDecodePart(t *testing.T) {
 	}
 }
 
+func TestHotFields(t *testing.T) {
+	var in strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&in, "{\"timestamp\": \"2023-01-%02dT00:00:%02dZ\", \"msg\": \"message %d\"}\n", i%28+1, i%60, i%7)
+	}
+	encode := func(enc *Encoder) *testBuffer {
+		tb := &testBuffer{enc: *enc}
+		cn := ion.Chunker{
+			W:     tb,
+			Align: 16 * 1024,
+		}
+		err := jsonrl.Convert(strings.NewReader(in.String()), &cn, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = cn.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tb
+	}
+	size := func(tb *testBuffer) int {
+		n := 0
+		for i := range tb.output {
+			n += len(tb.output[i])
+		}
+		return n
+	}
+	for _, algo := range validAlgs {
+		t.Run(algo.String(), func(t *testing.T) {
+			plain := NewEncoder()
+			plain.Algo = algo
+			want := encode(plain)
+			hot := NewEncoder(WithHotFields("timestamp"))
+			hot.Algo = algo
+			got := encode(hot)
+			if size(got) < size(want) {
+				t.Errorf("hot buckets compressed to %d bytes, %d without hot fields", size(got), size(want))
+			}
+			// the output is decoded the same way
+			// as data without hot fields
+			var dec, dec2 Decoder
+			dec2.SetComponents([]string{"timestamp"})
+			for i := range got.output {
+				out, err := dec.Decode(got.output[i], nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(out, trimnop(got.input[i])) {
+					t.Fatalf("chunk %d: output and input not identical", i)
+				}
+				_, err = dec2.Decode(got.output[i], nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if dec2.buckets.Decomps != i+1 {
+					t.Fatalf("chunk %d: %d buckets decompressed", i, dec2.buckets.Decomps)
+				}
+			}
+		})
+	}
+}
+
 type countWriter struct {
 	enc   Encoder
 	dec   Decoder
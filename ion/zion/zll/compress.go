@@ -26,7 +26,9 @@ import (
 )
 
 var dec *zstd.Decoder
+var checkdec *zstd.Decoder
 var enc *zstd.Encoder
+var hotenc *zstd.Encoder
 
 var iguanaPool = sync.Pool{
 	New: func() any { return &iguana.Decoder{} },
@@ -123,14 +125,32 @@ type BucketHints struct {
 	// (This may be zero even when the top-level type
 	// is only a list type iif the top-level lists are all empty.)
 	ListTypeSet uint16
+	// Hot indicates that the bucket holds fields
+	// that are read by most queries, so it should be
+	// compressed in a way that is cheap to decompress
+	// at the expense of the compression ratio.
+	// (The output is still readable by any decoder.)
+	Hot bool
+}
+
+func (h *BucketHints) hot() bool {
+	return h != nil && h.Hot
 }
 
 func init() {
 	dec, _ = zstd.NewReader(nil,
 		zstd.WithDecoderConcurrency(runtime.GOMAXPROCS(0)),
 		zstd.IgnoreChecksum(true))
+	checkdec, _ = zstd.NewReader(nil,
+		zstd.WithDecoderConcurrency(runtime.GOMAXPROCS(0)))
 	enc, _ = zstd.NewWriter(nil,
 		zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	// the speed of zstd decompression hardly depends on
+	// the compression level, but skipping the entropy coding
+	// of literals lets the decoder copy them as they are
+	hotenc, _ = zstd.NewWriter(nil,
+		zstd.WithEncoderLevel(zstd.SpeedBetterCompression),
+		zstd.WithNoEntropyCompression(true))
 }
 
 // MaxBucketSize is the maximum size of a compressed bucket.
@@ -196,12 +216,22 @@ func (a BucketAlgo) Compress(hints *BucketHints, src, dst []byte) ([]byte, error
 		fallthrough
 
 	case CompressIguanaV0:
+		threshold := float32(iguana.DefaultEntropyRejectionThreshold)
+		if hints.hot() {
+			// reject entropy coding of every stream
+			// so that decompression is just LZ decoding
+			threshold = 0
+		}
 		enc := iguanaEnc()
-		dst, err = enc.Compress(src, dst, iguana.DefaultEntropyRejectionThreshold)
+		dst, err = enc.Compress(src, dst, threshold)
 		dropIguanaEnc(enc)
 
 	case CompressZstd:
-		dst = enc.EncodeAll(src, dst)
+		if hints.hot() {
+			dst = hotenc.EncodeAll(src, dst)
+		} else {
+			dst = enc.EncodeAll(src, dst)
+		}
 
 	default:
 		panic("BucketAlgo.Compress: unknown BucketAlgo")
@@ -237,6 +267,12 @@ func FrameSize(src []byte) (int, error) {
 // Decompress returns the new dst, the number of compressed bytes consumed,
 // and the first error encountered, if any.
 func (a BucketAlgo) Decompress(src, dst []byte) ([]byte, int, error) {
+	return a.decompress(src, dst, false)
+}
+
+// decompress implements Decompress; if verify is set,
+// then frame checksums are checked where they are present.
+func (a BucketAlgo) decompress(src, dst []byte, verify bool) ([]byte, int, error) {
 	if len(src) < 3 {
 		return nil, 0, fmt.Errorf("zion.decompress: illegal frame size")
 	}
@@ -252,7 +288,11 @@ func (a BucketAlgo) Decompress(src, dst []byte) ([]byte, int, error) {
 	var out []byte
 	switch a {
 	case CompressZstd:
-		out, err = dec.DecodeAll(src[3:size], dst)
+		zdec := dec
+		if verify {
+			zdec = checkdec
+		}
+		out, err = zdec.DecodeAll(src[3:size], dst)
 	case CompressIguanaV0Specialized:
 		if size < 4 {
 			return nil, 0, fmt.Errorf("size %d does not fit bucket specialization byte", size)
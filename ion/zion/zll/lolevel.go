@@ -48,6 +48,10 @@ type Shape struct {
 	// algorithm used to compress buckets. All the other bits
 	// are reserved and should be zero.
 	Seed uint32
+	// VerifyChecksums, if set, causes the checksums
+	// of compressed frames to be verified during decompression
+	// for bucket compression algorithms that store them.
+	VerifyChecksums bool
 }
 
 // Algo returns the bucket compression algorithm
@@ -105,7 +109,7 @@ func (s *Shape) Decode(src []byte) ([]byte, error) {
 	}
 	s.Start = 0
 	var skip int
-	s.Bits, skip, err = s.Algo().Decompress(src, s.Bits[:0])
+	s.Bits, skip, err = s.Algo().decompress(src, s.Bits[:0], s.VerifyChecksums)
 	if err != nil {
 		return nil, err
 	}
@@ -251,7 +255,7 @@ func (b *Buckets) decompSelected() error {
 			skip, err = FrameSize(parts)
 		} else {
 			b.Pos[i] = int32(len(b.Decompressed))
-			b.Decompressed, skip, err = algo.Decompress(parts, b.Decompressed)
+			b.Decompressed, skip, err = algo.decompress(parts, b.Decompressed, b.Shape.VerifyChecksums)
 			b.Decomps++
 		}
 		if err != nil {
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/SnellerInc/sneller/expr"
 	"github.com/SnellerInc/sneller/ion"
 )
 
@@ -51,6 +52,29 @@ func DecodeDatum(v ion.Datum) (*Tree, error) {
 			t.Data = f.Datum.Clone()
 		case "root":
 			return t.Root.decode(f.Datum)
+		case "profile":
+			var err error
+			t.profile, err = f.Bool()
+			return err
+		case "prefetch":
+			n, err := f.Int()
+			t.prefetch = int(n)
+			return err
+		case "blocksize":
+			var err error
+			t.blocksize, err = f.Int()
+			return err
+		case "page":
+			t.Page = new(Page)
+			return t.Page.decode(f.Datum)
+		case "results":
+			return f.UnpackList(func(v ion.Datum) error {
+				s, err := v.String()
+				if err == nil {
+					t.Results = append(t.Results, expr.Identity(s))
+				}
+				return err
+			})
 		}
 		return nil
 	})
@@ -136,8 +160,12 @@ func empty(name string) (Op, bool) {
 		op = &HashAggregate{}
 	case "order":
 		op = &OrderBy{}
+	case "row_number":
+		op = &RowNumber{}
 	case "distinct":
 		op = &Distinct{}
+	case "setop":
+		op = &SetOp{}
 	case "project":
 		op = &Project{}
 	case "filter":
@@ -158,6 +186,10 @@ func empty(name string) (Op, bool) {
 		op = &UnpivotAtDistinct{}
 	case "explain":
 		op = &Explain{}
+	case "describe":
+		op = &Describe{}
+	case "rowcount":
+		op = &RowCount{}
 	case "substitute":
 		op = &Substitute{}
 	default:
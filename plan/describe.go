@@ -0,0 +1,105 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package plan
+
+import (
+	"fmt"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+	"github.com/SnellerInc/sneller/vm"
+)
+
+// Describe is a leaf executor for
+// 'DESCRIBE table' queries; it produces
+// one row per field of the table schema
+// that is recorded in the table index.
+type Describe struct {
+	Table  expr.Node
+	Schema blockfmt.Schema
+}
+
+// describe returns the Tree for 'DESCRIBE table'
+func describe(q *expr.Query, env Env) (*Tree, error) {
+	idxr, ok := env.(Indexer)
+	if !ok {
+		return nil, fmt.Errorf("DESCRIBE: table index not available")
+	}
+	idx, err := index(idxr, q.Body)
+	if err != nil {
+		return nil, err
+	}
+	if idx == nil {
+		return nil, fmt.Errorf("DESCRIBE: no index for table %s", expr.ToString(q.Body))
+	}
+	bi, ok := idx.(*blockfmt.Index)
+	if !ok {
+		return nil, fmt.Errorf("DESCRIBE: cannot describe %s", expr.ToString(q.Body))
+	}
+	op := &Describe{Table: q.Body}
+	// Merge sorts the fields by path
+	op.Schema.Merge(&bi.Schema)
+	return &Tree{Root: Node{Input: -1, Op: op}}, nil
+}
+
+func (d *Describe) String() string { return "DESCRIBE " + expr.ToString(d.Table) }
+func (d *Describe) input() Op      { return nil }
+func (d *Describe) setinput(Op)    { panic("Describe: cannot setinput()") }
+
+func (d *Describe) encode(dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) error {
+	dst.BeginStruct(-1)
+	settype("describe", dst, st)
+	dst.BeginField(st.Intern("table"))
+	d.Table.Encode(dst, st)
+	dst.BeginField(st.Intern("schema"))
+	d.Schema.Encode(dst, st)
+	dst.EndStruct()
+	return nil
+}
+
+func (d *Describe) SetField(f ion.Field) error {
+	var err error
+	switch f.Label {
+	case "table":
+		d.Table, err = expr.Decode(f.Datum)
+	case "schema":
+		err = d.Schema.Decode(f.Datum)
+	default:
+		return errUnexpectedField
+	}
+	return err
+}
+
+func (d *Describe) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
+	var b ion.Buffer
+	var st ion.Symtab
+	field := st.Intern("field")
+	typ := st.Intern("type")
+	count := st.Intern("count")
+	st.Marshal(&b, true)
+	for i := range d.Schema.Fields {
+		f := &d.Schema.Fields[i]
+		b.BeginStruct(-1)
+		b.BeginField(field)
+		b.WriteString(f.Path)
+		b.BeginField(typ)
+		b.WriteString(f.Types())
+		b.BeginField(count)
+		b.WriteInt(f.Total())
+		b.EndStruct()
+	}
+	return writeIon(&b, dst)
+}
@@ -15,16 +15,37 @@
 package plan
 
 import (
+	"errors"
+
 	"github.com/SnellerInc/sneller/vm"
 )
 
-func (t *Tree) exec(dst vm.QuerySink, ep *ExecParams) error {
+func (t *Tree) exec(dst vm.QuerySink, ep *ExecParams) (err error) {
 	ep.get = func(i int) *Input {
 		if t.Inputs[i] == nil {
 			panic("nil input?")
 		}
 		return t.Inputs[i]
 	}
+	ep.Stats.Tables = t.fieldStats()
+	if ep.Profile {
+		ep.prof = newProfile(t)
+		defer func() {
+			ep.Stats.Ops = ep.prof.results()
+		}()
+	}
+	// sub-queries executed locally share
+	// the taps of the outermost Tree
+	if ep.Tap != nil && ep.taps == nil {
+		ep.taps = newTaps(t, ep.Tap)
+		defer func() {
+			err = errors.Join(err, ep.taps.close())
+			ep.taps = nil
+		}()
+	}
+	if t.Page != nil {
+		return t.execPage(dst, ep)
+	}
 	return t.Root.exec(dst, ep)
 }
 
@@ -34,5 +55,5 @@ func (n *Node) exec(dst vm.QuerySink, ep *ExecParams) error {
 	if i >= 0 {
 		src = ep.get(i)
 	}
-	return n.Op.exec(dst, src, ep)
+	return n.Op.exec(ep.tap(n.Op, dst), src, ep)
 }
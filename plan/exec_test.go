@@ -26,10 +26,13 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/SnellerInc/sneller/date"
 	"github.com/SnellerInc/sneller/expr"
@@ -96,7 +99,7 @@ func (t *testenv) str2json(arg expr.Node) (*Input, error) {
 		io.Closer
 	}{strings.NewReader(string(str)), io.NopCloser(nil)}
 	name := uuid() + ".zion"
-	up, err := blockfmt.NewDirFS(t.tmp).Create(name)
+	up, err := t.fsys().Create(name)
 	if err != nil {
 		return nil, err
 	}
@@ -125,6 +128,7 @@ func (t *testenv) str2json(arg expr.Node) (*Input, error) {
 					Path: filepath.Join(name),
 				},
 				Trailer: *tr,
+				Rows:    c.Rows(),
 			},
 			Blocks: blocks,
 		}},
@@ -1094,6 +1098,20 @@ where Make in (
 				`{"Ticket": 1104820732}`,
 			},
 		},
+		{
+			// test LIMIT and OFFSET without ORDER BY;
+			// the rows are arbitrary, but their number is not
+			query: `select count(*) from (select Ticket from parking limit 5 offset 1000)`,
+			expectedRows: []string{
+				`{"count": 5}`,
+			},
+		},
+		{
+			query: `select count(*) from (select Ticket from parking where Ticket > 0 limit 10 offset 1020)`,
+			expectedRows: []string{
+				`{"count": 3}`,
+			},
+		},
 		{
 			// test projection of a computed number
 			// that is sometimes an integer and sometimes a float
@@ -1202,6 +1220,61 @@ where Make in (
 				`{"count": 9583}`,
 			},
 		},
+		{
+			// each tombstone only deletes the rows
+			// of the objects it covers
+			query: `select count(*) from parking ++ nyc_taxi`,
+			indexer: testindexer{
+				"parking": &blockfmt.Index{
+					Tombstones: []blockfmt.Tombstone{{
+						Where:   expr.Compare(expr.Equals, expr.Ident("Make"), expr.String("HOND")),
+						Objects: []string{"testdata/parking.zion"},
+					}},
+				},
+				"nyc_taxi": &blockfmt.Index{
+					Tombstones: []blockfmt.Tombstone{{
+						Where:   expr.Compare(expr.Equals, expr.Ident("VendorID"), expr.String("VTS")),
+						Objects: []string{"testdata/other.zion"},
+					}},
+				},
+			},
+			expectedRows: []string{
+				`{"count": 9461}`,
+			},
+		},
+		{
+			// table names containing patterns are globs
+			query: `select count(*) from parking ++ "nyc_tax?"`,
+			indexer: testindexer{
+				"parking":  testindex{},
+				"nyc_taxi": testindex{},
+			},
+			expectedRows: []string{
+				`{"count": 9583}`,
+			},
+		},
+		{
+			// the matched tables have different schemas;
+			// the fields of one table are MISSING in the other
+			query: `select count(*) as rows, count(Make) as makes, count(VendorID) as vendors from "[np][ya]*[gi]"`,
+			indexer: testindexer{
+				"parking":  testindex{},
+				"nyc_taxi": testindex{},
+			},
+			expectedRows: []string{
+				`{"rows": 9583, "makes": 1019, "vendors": 8560}`,
+			},
+		},
+		{
+			query: `select Make, VendorID, Ticket from "[np][ya]*[gi]" where Ticket = 1103341116`,
+			indexer: testindexer{
+				"parking":  testindex{},
+				"nyc_taxi": testindex{},
+			},
+			expectedRows: []string{
+				`{"Make": "HOND", "Ticket": 1103341116}`,
+			},
+		},
 		{
 			query: `select earliest(foo), latest(foo) from parking ++ nyc_taxi`,
 			indexer: testindexer{
@@ -1223,6 +1296,23 @@ where Make in (
 				"PROJECT `2000-01-01T00:00:00Z` AS \"min\", `2000-03-01T00:00:00Z` AS \"max\"",
 			},
 		},
+		{
+			query: `describe parking`,
+			indexer: testindexer{
+				"parking": &blockfmt.Index{
+					Schema: blockfmt.Schema{
+						Fields: []blockfmt.SchemaField{
+							{Path: "Ticket", Count: [16]int64{ion.IntType: 1023}},
+							{Path: "Color", Count: [16]int64{ion.NullType: 2, ion.StringType: 1021}},
+						},
+					},
+				},
+			},
+			expectedRows: []string{
+				`{"field": "Color", "type": "null|string", "count": 1023}`,
+				`{"field": "Ticket", "type": "int", "count": 1023}`,
+			},
+		},
 		{
 			query: `select earliest(foo), latest(foo) from table_glob("*a*")`,
 			indexer: testindexer{
@@ -1324,6 +1414,36 @@ where Make in (
 				`{"total": 1023, "fields": {"ViolationCode": {"string": 1023, "string-min-length": 3, "string-max-length": 8}, "Longitude": {"int": 1023, "int-min-value": 99999, "int-max-value": 99999}, "Fine": {"int": 1012, "int-min-value": 25, "int-max-value": 363}, "ViolationDescr": {"string": 1023, "string-min-length": 4, "string-max-length": 20}, "Agency": {"int": 1023, "int-min-value": 1, "int-max-value": 57}, "PlateExpiry": {"int": 956, "int-min-value": 1, "int-max-value": 201905}, "BodyStyle": {"string": 1015, "string-min-length": 2, "string-max-length": 2}, "Ticket": {"int": 1023, "int-min-value": 1103341116, "int-max-value": 4272473892}, "Location": {"string": 1022, "string-min-length": 7, "string-max-length": 31}, "Color": {"string": 1016, "string-min-length": 2, "string-max-length": 2}, "MeterId": {"string": 125, "string-min-length": 2, "string-max-length": 7}, "Route": {"string": 1001, "string-min-length": 2, "string-max-length": 5}, "IssueData": {"timestamp": 1023}, "MarkedTime": {"string": 7, "string-min-length": 4, "string-max-length": 4}, "Latitude": {"int": 1023, "int-min-value": 99999, "int-max-value": 99999}, "Make": {"string": 1019, "string-min-length": 2, "string-max-length": 4}, "RPState": {"string": 1023, "string-min-length": 2, "string-max-length": 2}, "IssueTime": {"int": 1022, "int-min-value": 18, "int-max-value": 2355}}}`,
 			},
 		},
+		{
+			// numbering restarts at 1 for each partition
+			query: `SELECT Make, Ticket, ROW_NUMBER() OVER (PARTITION BY Make ORDER BY Ticket DESC) AS rn FROM parking WHERE Make IN ('BENZ', 'BUIC') ORDER BY Make, rn LIMIT 100`,
+			expectedRows: []string{
+				`{"Make": "BENZ", "Ticket": 1113970384, "rn": 1}`,
+				`{"Make": "BENZ", "Ticket": 1113965193, "rn": 2}`,
+				`{"Make": "BENZ", "Ticket": 1113964795, "rn": 3}`,
+				`{"Make": "BUIC", "Ticket": 4272155996, "rn": 1}`,
+				`{"Make": "BUIC", "Ticket": 4272037840, "rn": 2}`,
+				`{"Make": "BUIC", "Ticket": 4271997612, "rn": 3}`,
+				`{"Make": "BUIC", "Ticket": 1111967205, "rn": 4}`,
+			},
+		},
+		{
+			// QUALIFY keeps the first row of each partition
+			query: `SELECT Make, Ticket FROM parking WHERE Make IN ('BENZ', 'BUIC') QUALIFY ROW_NUMBER() OVER (PARTITION BY Make ORDER BY Ticket DESC) = 1 ORDER BY Make LIMIT 10`,
+			expectedRows: []string{
+				`{"Make": "BENZ", "Ticket": 1113970384}`,
+				`{"Make": "BUIC", "Ticket": 4272155996}`,
+			},
+		},
+		{
+			// QUALIFY on a window computed over groups
+			query: `SELECT Make, Color, COUNT(*) AS c FROM parking WHERE Make IN ('AUDI', 'BENZ', 'BUIC') GROUP BY Make, Color QUALIFY ROW_NUMBER() OVER (PARTITION BY Make ORDER BY COUNT(*) DESC) = 1 ORDER BY Make`,
+			expectedRows: []string{
+				`{"Make": "AUDI", "Color": "BK", "c": 5}`,
+				`{"Make": "BENZ", "Color": "BK", "c": 2}`,
+				`{"Make": "BUIC", "Color": "WT", "c": 2}`,
+			},
+		},
 		{
 			query: `SELECT Make, Color, COUNT(*), ROW_NUMBER() OVER (PARTITION BY Make ORDER BY COUNT(*) DESC) FROM parking GROUP BY Make, Color ORDER BY Make, Color`,
 			expectedRows: []string{
@@ -1619,6 +1739,48 @@ where Make in (
 				`{"Make": "VOLV", "Color": "SL", "count": 3, "row_number": 1}`,
 			},
 		},
+		{
+			query: `SELECT VendorID FROM nyc_taxi INTERSECT SELECT VendorID FROM nyc_taxi WHERE passenger_count > 4 ORDER BY VendorID`,
+			expectedRows: []string{
+				`{"VendorID": "CMT"}`,
+				`{"VendorID": "VTS"}`,
+			},
+		},
+		{
+			// the right-hand side has many more distinct
+			// rows than a subquery can produce
+			query: `SELECT key, val FROM UNPIVOT nyc_taxi AS val AT key
+			        EXCEPT
+			        SELECT key, val FROM UNPIVOT nyc_taxi AS val AT key WHERE key <> 'VendorID'
+			        ORDER BY val`,
+			expectedRows: []string{
+				`{"key": "VendorID", "val": "CMT"}`,
+				`{"key": "VendorID", "val": "DDS"}`,
+				`{"key": "VendorID", "val": "VTS"}`,
+			},
+		},
+		{
+			query: `SELECT VendorID FROM nyc_taxi EXCEPT ALL SELECT VendorID FROM nyc_taxi WHERE VendorID <> 'DDS'`,
+			rows:  152,
+		},
+		{
+			query: `SELECT VendorID FROM nyc_taxi WHERE VendorID = 'DDS' UNION ALL SELECT VendorID FROM nyc_taxi WHERE VendorID = 'DDS'`,
+			rows:  304,
+		},
+		{
+			// LIMIT and OFFSET are pushed into both
+			// branches and applied again to the result
+			query: `SELECT VendorID FROM nyc_taxi WHERE VendorID = 'DDS' UNION ALL SELECT VendorID FROM nyc_taxi LIMIT 200 OFFSET 100`,
+			rows:  200,
+		},
+		{
+			query: `SELECT VendorID FROM nyc_taxi WHERE VendorID <> 'DDS' UNION SELECT VendorID FROM nyc_taxi WHERE VendorID <> 'VTS' ORDER BY VendorID`,
+			expectedRows: []string{
+				`{"VendorID": "CMT"}`,
+				`{"VendorID": "DDS"}`,
+				`{"VendorID": "VTS"}`,
+			},
+		},
 	}
 
 	for i := range tcs {
@@ -1793,6 +1955,99 @@ func BenchmarkPlan(b *testing.B) {
 	}
 }
 
+// rowsindex is an Index with statistics
+// for one of the tables of BenchmarkJoinOrder
+type rowsindex struct {
+	testindex
+	rows     int64
+	distinct map[string]int64
+}
+
+func (r *rowsindex) Rows() (int64, bool) { return r.rows, true }
+
+func (r *rowsindex) Distinct(path []string) (int64, bool) {
+	n, ok := r.distinct[strings.Join(path, ".")]
+	return n, ok
+}
+
+// jsonenv is a testenv with
+// tables that hold the given JSON text
+type jsonenv struct {
+	*testenv
+	tables map[string]string
+}
+
+func (j *jsonenv) Stat(tbl expr.Node, h *Hints) (*Input, error) {
+	if id, ok := tbl.(expr.Ident); ok {
+		if text, ok := j.tables[string(id)]; ok {
+			return j.str2json(expr.String(text))
+		}
+	}
+	return j.testenv.Stat(tbl, h)
+}
+
+// BenchmarkJoinOrder compares a join written
+// with the small table first, which holds
+// the large table in memory, with the same join
+// reordered using the statistics of the tables
+func BenchmarkJoinOrder(b *testing.B) {
+	const bigrows, smallrows = 1000, 10
+	var big, small strings.Builder
+	for i := 0; i < bigrows; i++ {
+		fmt.Fprintf(&big, "{\"k\": %d, \"v\": %d}\n", i%(smallrows*4), i)
+	}
+	for i := 0; i < smallrows; i++ {
+		fmt.Fprintf(&small, "{\"id\": %d}\n", i)
+	}
+	tables := map[string]string{"big": big.String(), "small": small.String()}
+	sel, err := partiql.Parse([]byte("SELECT COUNT(*), SUM(b.v), SUM(s.id) FROM small s JOIN big b ON s.id = b.k"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	stats := testindexer{
+		"big":   &rowsindex{rows: bigrows, distinct: map[string]int64{"k": smallrows * 4}},
+		"small": &rowsindex{rows: smallrows, distinct: map[string]int64{"id": smallrows}},
+	}
+	// both orders must produce the same result
+	want := ""
+	for _, bc := range []struct {
+		name    string
+		indexer Indexer
+	}{
+		{"written", nil},
+		{"reordered", stats},
+	} {
+		env := &jsonenv{testenv: &testenv{t: b, indexer: bc.indexer}, tables: tables}
+		env.fsys()
+		tree, err := New(sel.Clone(), env)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var out bytes.Buffer
+		err = Exec(&ExecParams{Plan: tree, Output: &out, Runner: env})
+		if err != nil {
+			b.Fatal(err)
+		}
+		var got strings.Builder
+		ion.ToJSON(&got, bufio.NewReader(&out))
+		if want == "" {
+			want = got.String()
+		} else if got.String() != want {
+			b.Fatalf("%s: got %s, want %s", bc.name, got.String(), want)
+		}
+		b.Run(bc.name, func(b *testing.B) {
+			var out bytes.Buffer
+			for i := 0; i < b.N; i++ {
+				out.Reset()
+				err := Exec(&ExecParams{Plan: tree, Output: &out, Runner: env})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func buf2json(st *ion.Symtab, buf *ion.Buffer) string {
 	var otmp ion.Buffer
 	st.Marshal(&otmp, true)
@@ -1887,7 +2142,7 @@ func testRemoteEquivalent(t *testing.T, tree *Tree,
 	if remoteerr != nil {
 		t.Errorf("remote error: %s", remoteerr)
 	}
-	if ep.Stats != *wantstat {
+	if !reflect.DeepEqual(ep.Stats, *wantstat) {
 		t.Errorf("got stats %#v", &ep.Stats)
 		t.Errorf("wanted stats %#v", wantstat)
 	}
@@ -1906,6 +2161,14 @@ func (e *splitEnv) ListTables(db string) ([]string, error) {
 	return tl.ListTables(db)
 }
 
+func (e *splitEnv) Index(p expr.Node) (Index, error) {
+	idx, ok := e.Env.(Indexer)
+	if !ok {
+		return nil, nil
+	}
+	return idx.Index(p)
+}
+
 func (e *splitEnv) Geometry() *Geometry {
 	return e.geom
 }
@@ -2005,7 +2268,7 @@ func testSplitEquivalent(t *testing.T, text string, e *testenv, expected []strin
 	// inputs across union maps, so stats for
 	// split queries are not expected to match the
 	// original query
-	if ep.Stats != *wantstat {
+	if !reflect.DeepEqual(ep.Stats, *wantstat) {
 		t.Logf("got stats %#v", &ep.Stats)
 		t.Logf("wanted stats %#v", wantstat)
 	}
@@ -2167,6 +2430,121 @@ func TestClientCancel(t *testing.T) {
 	}
 }
 
+// loopRunner runs the same input repeatedly
+// until an error is encountered
+type loopRunner struct {
+	*testenv
+}
+
+func (r loopRunner) Run(dst vm.QuerySink, src *Input, ep *ExecParams) error {
+	for {
+		err := r.testenv.Run(dst, src, ep)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// fieldsenv is a testenv that
+// pushes the fields down to its inputs
+type fieldsenv struct {
+	*testenv
+}
+
+func (f *fieldsenv) Stat(tbl expr.Node, h *Hints) (*Input, error) {
+	in, err := f.testenv.Stat(tbl, h)
+	if err == nil && !h.AllFields {
+		in.Fields = h.Fields
+	}
+	return in, err
+}
+
+func TestExecTableStats(t *testing.T) {
+	env := &fieldsenv{&testenv{t: t}}
+	testcases := []struct {
+		query string
+		want  []TableStats
+	}{
+		{
+			query: "SELECT Make, Color FROM parking WHERE Ticket > 0",
+			want:  []TableStats{{Table: "parking", Fields: []string{"Color", "Make", "Ticket"}}},
+		},
+		{
+			query: "SELECT * FROM parking LIMIT 1",
+			want:  []TableStats{{Table: "parking", AllFields: true}},
+		},
+		{
+			// no fields at all is not all of them
+			query: "SELECT COUNT(*) FROM parking",
+			want:  []TableStats{{Table: "parking", Fields: []string{}}},
+		},
+		{
+			query: "SELECT COUNT(*) FROM parking WHERE Make IN (SELECT DISTINCT Make FROM parking2 WHERE Color = 'BK')",
+			want: []TableStats{
+				{Table: "parking", Fields: []string{"Make"}},
+				{Table: "parking2", Fields: []string{"Color", "Make"}},
+			},
+		},
+	}
+	for i := range testcases {
+		tc := &testcases[i]
+		s, err := partiql.Parse([]byte(tc.query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree, err := New(s, env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		ep := &ExecParams{Plan: tree, Output: &out, Runner: env}
+		if err := Exec(ep); err != nil {
+			t.Fatalf("%s: %s", tc.query, err)
+		}
+		if !reflect.DeepEqual(ep.Stats.Tables, tc.want) {
+			t.Errorf("%s: got %#v, want %#v", tc.query, ep.Stats.Tables, tc.want)
+		}
+		// the stats survive encoding
+		var buf ion.Buffer
+		ep.Stats.Marshal(&buf)
+		var dec ExecStats
+		if err := dec.UnmarshalBinary(buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(dec.Tables, tc.want) {
+			t.Errorf("%s: decoded %#v, want %#v", tc.query, dec.Tables, tc.want)
+		}
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	env := &testenv{t: t}
+	s, err := partiql.Parse([]byte(`select count(*) from parking`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := New(s, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	ep := &ExecParams{
+		Plan:    tree,
+		Output:  &out,
+		Runner:  loopRunner{env},
+		Context: ctx,
+	}
+	err = Exec(ep)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got error %v; expected context.DeadlineExceeded", err)
+	}
+	if ep.Stats.BytesScanned == 0 {
+		t.Error("expected partial stats")
+	}
+}
+
 type testindexer map[string]Index
 
 func (t testindexer) Index(e expr.Node) (Index, error) {
@@ -2199,3 +2577,638 @@ func dates(min, max string) [2]date.Time {
 	}
 	return [2]date.Time{dmin, dmax}
 }
+
+func TestExecProfile(t *testing.T) {
+	env := &testenv{t: t}
+	count := func(text string) int64 {
+		var out bytes.Buffer
+		s, err := partiql.Parse([]byte(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree, err := New(s, env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Exec(&ExecParams{Plan: tree, Output: &out, Runner: env})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var st ion.Symtab
+		row, _, err := ion.ReadDatum(&st, out.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := row.Field("count").Int()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	all := count(`SELECT COUNT(*) FROM parking`)
+	black := count(`SELECT COUNT(*) FROM parking WHERE Color = 'BK'`)
+
+	const text = `SELECT Make, COUNT(*) FROM parking WHERE Color = 'BK' GROUP BY Make ORDER BY Make`
+	s, err := partiql.Parse([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, err := New(s, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// planning consumes the query
+	s, err = partiql.Parse([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	split, err := NewSplit(s, &splitEnv{
+		Env: env,
+		geom: &Geometry{
+			Peers: []Transport{&LocalTransport{}, &LocalTransport{}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(t *testing.T, tree *Tree, ops []OpStats) {
+		t.Logf("plan:\n%s", tree)
+		var filter, agg *OpStats
+		for i := range ops {
+			t.Logf("%d %d %s %s", ops[i].ID, ops[i].Rows, ops[i].Time, ops[i].Name)
+			if i > 0 && ops[i].ID <= ops[i-1].ID {
+				t.Errorf("ids not ascending: %d after %d", ops[i].ID, ops[i-1].ID)
+			}
+			switch {
+			case strings.HasPrefix(ops[i].Name, "WHERE"):
+				filter = &ops[i]
+			case strings.HasPrefix(ops[i].Name, "HASH AGGREGATE"):
+				agg = &ops[i] // the last one is the partial aggregate
+			}
+		}
+		if filter == nil || agg == nil {
+			t.Fatal("missing WHERE or HASH AGGREGATE")
+		}
+		if filter.Rows != all {
+			t.Errorf("WHERE processed %d rows, want %d", filter.Rows, all)
+		}
+		if agg.Rows != black {
+			t.Errorf("HASH AGGREGATE processed %d rows, want %d", agg.Rows, black)
+		}
+		if filter.Time < agg.Time {
+			t.Errorf("WHERE time %s less than HASH AGGREGATE time %s", filter.Time, agg.Time)
+		}
+	}
+	for _, tc := range []struct {
+		name string
+		tree *Tree
+	}{
+		{"local", local},
+		{"split", split},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			ep := &ExecParams{
+				Plan:    tc.tree,
+				Output:  &out,
+				Runner:  env,
+				Profile: true,
+			}
+			if err := Exec(ep); err != nil {
+				t.Fatal(err)
+			}
+			check(t, tc.tree, ep.Stats.Ops)
+		})
+	}
+	t.Run("remote", func(t *testing.T) {
+		local, remote := net.Pipe()
+		errc := make(chan error, 1)
+		go func() {
+			errc <- Serve(remote, env)
+		}()
+		c := Client{Pipe: local}
+		var out bytes.Buffer
+		ep := &ExecParams{
+			Plan:    split,
+			Output:  &out,
+			Context: context.Background(),
+			Runner:  env,
+			Profile: true,
+		}
+		if err := c.Exec(ep); err != nil {
+			t.Fatal(err)
+		}
+		c.Close()
+		if err := <-errc; err != nil {
+			t.Fatal(err)
+		}
+		check(t, split, ep.Stats.Ops)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		var out bytes.Buffer
+		ep := &ExecParams{Plan: local, Output: &out, Runner: env}
+		if err := Exec(ep); err != nil {
+			t.Fatal(err)
+		}
+		if ep.Stats.Ops != nil {
+			t.Errorf("unexpected profile %v", ep.Stats.Ops)
+		}
+	})
+}
+
+// readTap reads the rows of a tap file
+func readTap(t *testing.T, path string) []ion.Datum {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st ion.Symtab
+	var out []ion.Datum
+	for len(buf) > 0 {
+		var d ion.Datum
+		d, buf, err = ion.ReadDatum(&st, buf)
+		if err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		if !d.IsEmpty() {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func TestExecTap(t *testing.T) {
+	env := &testenv{t: t}
+	const text = `SELECT Make, COUNT(*) FROM parking WHERE Color = 'BK' GROUP BY Make ORDER BY Make`
+	s, err := partiql.Parse([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, err := New(s, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err = partiql.Parse([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	split, err := NewSplit(s, &splitEnv{
+		Env: env,
+		geom: &Geometry{
+			Peers: []Transport{&LocalTransport{}, &LocalTransport{}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		tree *Tree
+	}{
+		{"local", local},
+		{"split", split},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ops, _ := numberOps(tc.tree)
+			var leaf, filter int
+			for i := range ops {
+				switch ops[i].(type) {
+				case *Leaf:
+					leaf = i
+				case *Filter:
+					filter = i
+				}
+			}
+			tap := &Tap{Dir: t.TempDir()}
+			var out bytes.Buffer
+			ep := &ExecParams{Plan: tc.tree, Output: &out, Runner: env, Tap: tap}
+			if err := Exec(ep); err != nil {
+				t.Fatal(err)
+			}
+			// parking has 1024 rows,
+			// 221 of which are black
+			scanned := readTap(t, tap.TapFile(leaf))
+			if len(scanned) != 1024 {
+				t.Errorf("got %d scanned rows, want 1024", len(scanned))
+			}
+			black := readTap(t, tap.TapFile(filter))
+			if len(black) != 221 {
+				t.Errorf("got %d filtered rows, want 221", len(black))
+			}
+			for i := range black {
+				if c, _ := black[i].Field("Color").String(); c != "BK" {
+					t.Fatalf("unexpected row %#v", black[i])
+				}
+			}
+			// the output of the root is the query output
+			root := readTap(t, tap.TapFile(0))
+			var st ion.Symtab
+			var want []ion.Datum
+			for buf := out.Bytes(); len(buf) > 0; {
+				var d ion.Datum
+				d, buf, err = ion.ReadDatum(&st, buf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !d.IsEmpty() {
+					want = append(want, d)
+				}
+			}
+			if len(root) != len(want) {
+				t.Fatalf("got %d output rows, want %d", len(root), len(want))
+			}
+			for i := range want {
+				if !root[i].Equal(want[i]) {
+					t.Errorf("row %d: got %#v, want %#v", i, root[i], want[i])
+				}
+			}
+
+			// only the selected ops are tapped
+			tap = &Tap{Dir: t.TempDir(), Ops: []int{filter}}
+			ep = &ExecParams{Plan: tc.tree, Output: &out, Runner: env, Tap: tap}
+			if err := Exec(ep); err != nil {
+				t.Fatal(err)
+			}
+			files, err := os.ReadDir(tap.Dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 || files[0].Name() != filepath.Base(tap.TapFile(filter)) {
+				t.Errorf("unexpected files %v", files)
+			}
+		})
+	}
+}
+
+// parallelRunner records the parallelism
+// passed to each call to Run
+type parallelRunner struct {
+	*testenv
+	lock     sync.Mutex
+	parallel []int
+}
+
+func (r *parallelRunner) Run(dst vm.QuerySink, src *Input, ep *ExecParams) error {
+	r.lock.Lock()
+	r.parallel = append(r.parallel, ep.Parallel)
+	r.lock.Unlock()
+	return r.testenv.Run(dst, src, ep)
+}
+
+func TestExecParallel(t *testing.T) {
+	env := &testenv{t: t}
+	s, err := partiql.Parse([]byte(`SELECT COUNT(*) FROM parking
+WHERE Make IN (SELECT DISTINCT Make FROM parking WHERE Color = 'BK')
+AND Color IN (SELECT DISTINCT Color FROM parking WHERE Make = 'BMW')`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := New(s, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(tp Transport, parallel int) []int {
+		r := &parallelRunner{testenv: env}
+		var out bytes.Buffer
+		err := tp.Exec(&ExecParams{
+			Plan:     tree,
+			Output:   &out,
+			Runner:   r,
+			Parallel: parallel,
+			Context:  context.Background(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.parallel
+	}
+	// the sub-queries run concurrently,
+	// so they share the parallelism
+	got := run(&LocalTransport{}, 4)
+	slices.Sort(got)
+	if want := []int{2, 2, 4}; !slices.Equal(got, want) {
+		t.Errorf("parallel = %v, want %v", got, want)
+	}
+	// LocalTransport.Threads caps ExecParams.Parallel
+	got = run(&LocalTransport{Threads: 1}, 4)
+	if want := []int{1, 1, 1}; !slices.Equal(got, want) {
+		t.Errorf("parallel = %v, want %v", got, want)
+	}
+}
+
+func TestExecBlockSize(t *testing.T) {
+	env := &testenv{t: t}
+	// the table needs to be large enough
+	// for its blocks to hold several chunks
+	var rows strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&rows, `{"x": %d, "s": "row %d"} `, i, i)
+	}
+	text := fmt.Sprintf(`SELECT x, s FROM JSON('%s') WHERE x %% 7 = 3`, rows.String())
+	s, err := partiql.Parse([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := New(s, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := 0
+	for _, b := range tree.Inputs[0].Descs[0].Trailer.Blocks {
+		chunks = max(chunks, b.Chunks)
+	}
+	if chunks < 2 {
+		t.Fatalf("blocks have only %d chunk(s)", chunks)
+	}
+	// output returns the sorted output of tree as JSON
+	// and the number of bytes scanned
+	output := func(runner Runner, blocksize int64) ([]string, int64) {
+		var out bytes.Buffer
+		ep := &ExecParams{
+			Plan:      tree,
+			Output:    &out,
+			Runner:    runner,
+			Parallel:  4,
+			BlockSize: blocksize,
+		}
+		if err := Exec(ep); err != nil {
+			t.Fatalf("%T: block size %d: %s", runner, blocksize, err)
+		}
+		var lst []string
+		var st ion.Symtab
+		buf := out.Bytes()
+		for len(buf) > 0 {
+			var d ion.Datum
+			d, buf, err = ion.ReadDatum(&st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !d.IsEmpty() {
+				lst = append(lst, d.JSON())
+			}
+		}
+		slices.Sort(lst)
+		return lst, ep.Stats.BytesScanned
+	}
+	want, scanned := output(env, 0)
+	if len(want) != 143 {
+		t.Fatalf("got %d rows", len(want))
+	}
+	// hiding the *blockfmt.DirFS disables mmap,
+	// so the blocks are divided as they are read
+	streaming := &FSRunner{FS: struct{ *blockfmt.DirFS }{env.fsys()}}
+	// a block size of 1 byte divides the
+	// blocks into ranges of one chunk each
+	for _, runner := range []Runner{env, streaming} {
+		for _, size := range []int64{1, 4096, -1} {
+			got, n := output(runner, size)
+			if !slices.Equal(got, want) {
+				t.Errorf("%T: block size %d: got %d rows, want %d", runner, size, len(got), len(want))
+			}
+			if n != scanned {
+				t.Errorf("%T: block size %d: scanned %d bytes, want %d", runner, size, n, scanned)
+			}
+		}
+	}
+}
+
+func TestExecPage(t *testing.T) {
+	env := &testenv{t: t}
+	s, err := partiql.Parse([]byte(`SELECT Ticket, Make FROM parking WHERE Color = 'BK'`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := New(s, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// scan the table three times,
+	// as three separate descriptors
+	in := tree.Inputs[0]
+	in.Descs = append(append(in.Descs, in.Descs...), in.Descs...)
+
+	// rows returns the output of tree as JSON
+	rows := func(tree *Tree, parallel int) ([]string, *ScanPosition) {
+		var out bytes.Buffer
+		ep := &ExecParams{
+			Plan:     tree,
+			Output:   &out,
+			Runner:   env,
+			Parallel: parallel,
+		}
+		if err := Exec(ep); err != nil {
+			t.Fatal(err)
+		}
+		var lst []string
+		var st ion.Symtab
+		buf := out.Bytes()
+		for len(buf) > 0 {
+			var d ion.Datum
+			d, buf, err = ion.ReadDatum(&st, buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !d.IsEmpty() {
+				lst = append(lst, d.JSON())
+			}
+		}
+		return lst, ep.Stats.Next
+	}
+	all, _ := rows(tree, 4)
+
+	const size = 100
+	var got []string
+	var first []string
+	pos := ScanPosition{}
+	for pages := 0; ; pages++ {
+		tree.Page = &Page{Start: pos, Size: size}
+		// the page survives serialization
+		var buf ion.Buffer
+		var st ion.Symtab
+		if err := tree.Encode(&buf, &st); err != nil {
+			t.Fatal(err)
+		}
+		dec, err := Decode(&st, buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, next := rows(dec, 1+pages%4)
+		if pages == 0 {
+			first = page
+		}
+		got = append(got, page...)
+		if next == nil {
+			if len(page) > size {
+				t.Fatalf("last page has %d rows", len(page))
+			}
+			break
+		}
+		if len(page) != size {
+			t.Fatalf("page %d at %v has %d rows", pages, pos, len(page))
+		}
+		pos = *next
+	}
+	// each row of the query is in exactly one page
+	slices.Sort(got)
+	slices.Sort(all)
+	if !slices.Equal(got, all) {
+		t.Errorf("got %d rows from pages, want %d", len(got), len(all))
+	}
+	// pages do not depend on the parallelism
+	tree.Page = &Page{Size: size}
+	again, _ := rows(tree, 3)
+	if !slices.Equal(again, first) {
+		t.Error("first page changed")
+	}
+
+	s, err = partiql.Parse([]byte(`SELECT Make, COUNT(*) FROM parking GROUP BY Make`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err = New(s, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Page = &Page{Size: size}
+	err = Exec(&ExecParams{Plan: tree, Output: io.Discard, Runner: env})
+	if err == nil || !strings.Contains(err.Error(), "pagination is not supported") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// prefetchRunner records the prefetch depth
+// passed to each call to Run
+type prefetchRunner struct {
+	*testenv
+	lock     sync.Mutex
+	prefetch []int
+}
+
+func (r *prefetchRunner) Run(dst vm.QuerySink, src *Input, ep *ExecParams) error {
+	r.lock.Lock()
+	r.prefetch = append(r.prefetch, ep.Prefetch)
+	r.lock.Unlock()
+	return r.testenv.Run(dst, src, ep)
+}
+
+func TestExecPrefetch(t *testing.T) {
+	env := &testenv{t: t}
+	s, err := partiql.Parse([]byte(`SELECT COUNT(*) FROM parking
+WHERE Make IN (SELECT DISTINCT Make FROM parking WHERE Color = 'BK')`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := New(s, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &prefetchRunner{testenv: env}
+	ep := &ExecParams{
+		Plan:     tree,
+		Output:   io.Discard,
+		Runner:   r,
+		Prefetch: 3,
+		Context:  context.Background(),
+	}
+	if err := Exec(ep); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{3, 3}; !slices.Equal(r.prefetch, want) {
+		t.Errorf("prefetch = %v, want %v", r.prefetch, want)
+	}
+
+	// the prefetch depth is sent to remote runners
+	local, remote := net.Pipe()
+	errc := make(chan error, 1)
+	r = &prefetchRunner{testenv: env}
+	go func() {
+		errc <- Serve(remote, r)
+	}()
+	c := Client{Pipe: local}
+	ep = &ExecParams{
+		Plan:     tree,
+		Output:   io.Discard,
+		Runner:   env,
+		Prefetch: 5,
+		Context:  context.Background(),
+	}
+	if err := c.Exec(ep); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{5, 5}; !slices.Equal(r.prefetch, want) {
+		t.Errorf("remote prefetch = %v, want %v", r.prefetch, want)
+	}
+}
+
+func TestCountFromMetadata(t *testing.T) {
+	env := &testenv{t: t}
+	const table = `JSON('{"x": 1} {"x": 2} {"x": 3} {"y": 4}')`
+	run := func(text string, split bool) (*Tree, int64) {
+		t.Helper()
+		s, err := partiql.Parse([]byte(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tree *Tree
+		if split {
+			tree, err = NewSplit(s, &splitEnv{
+				Env: env,
+				geom: &Geometry{
+					Peers: []Transport{&LocalTransport{}, &LocalTransport{}},
+				},
+			})
+		} else {
+			tree, err = New(s, env)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		testPlanSerialize(t, tree)
+		var out bytes.Buffer
+		err = Exec(&ExecParams{Plan: tree, Output: &out, Runner: env})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var st ion.Symtab
+		row, _, err := ion.ReadDatum(&st, out.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := row.Field("n").Int()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tree, n
+	}
+	tcs := []struct {
+		query    string
+		count    int64
+		metadata bool
+	}{
+		{`SELECT COUNT(*) AS n FROM ` + table, 4, true},
+		{`SELECT COUNT(*) + 1 AS n FROM ` + table, 5, true},
+		{`SELECT (SELECT COUNT(*) FROM ` + table + `) AS n`, 4, true},
+		{`SELECT COUNT(*) AS n FROM ` + table + ` WHERE x > 1`, 2, false},
+		{`SELECT COUNT(x) AS n FROM ` + table, 3, false},
+		{`SELECT COUNT(*) AS n FROM ` + table + ` GROUP BY x IS MISSING ORDER BY n DESC LIMIT 1`, 3, false},
+	}
+	for i := range tcs {
+		for _, split := range []bool{false, true} {
+			tree, n := run(tcs[i].query, split)
+			if n != tcs[i].count {
+				t.Errorf("%s (split=%v): got %d, want %d", tcs[i].query, split, n, tcs[i].count)
+			}
+			str := tree.String()
+			if strings.Contains(str, "FROM METADATA") != tcs[i].metadata {
+				t.Errorf("%s (split=%v): unexpected plan\n%s", tcs[i].query, split, str)
+			}
+			if tcs[i].metadata && tree.MaxScanned() != 0 {
+				t.Errorf("%s (split=%v): %d bytes scanned", tcs[i].query, split, tree.MaxScanned())
+			}
+		}
+	}
+}
@@ -15,6 +15,8 @@
 package plan
 
 import (
+	"slices"
+
 	"github.com/SnellerInc/sneller/expr"
 	"github.com/SnellerInc/sneller/ion"
 	"github.com/SnellerInc/sneller/vm"
@@ -26,6 +28,13 @@ import (
 type Filter struct {
 	Nonterminal
 	Expr expr.Node
+	// Deleted, if non-empty, holds the
+	// sorted paths of the objects covered by
+	// each tombstone, so that TOMBSTONE(i, x)
+	// in Expr is x for the rows of the objects
+	// in Deleted[i] and FALSE for other rows.
+	// (From must be the Leaf of the table.)
+	Deleted [][]string
 }
 
 func (f *Filter) String() string {
@@ -33,6 +42,9 @@ func (f *Filter) String() string {
 }
 
 func (f *Filter) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
+	if len(f.Deleted) > 0 {
+		return f.execDeleted(dst, src, ep)
+	}
 	filt := ep.rewrite(f.Expr)
 	if ep.Rewriter != nil {
 		push(filt, f.From)
@@ -41,14 +53,106 @@ func (f *Filter) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 	if err != nil {
 		return err
 	}
-	return f.From.exec(filter, src, ep)
+	return f.From.exec(ep.profile(f, filter), src, ep)
 }
 
+// execDeleted executes the filter separately
+// for each group of objects in src that are
+// covered by the same tombstones
+func (f *Filter) execDeleted(dst vm.QuerySink, src *Input, ep *ExecParams) error {
+	type group struct {
+		in      Input
+		covered tombstones
+	}
+	var groups []*group
+	bykey := make(map[string]*group)
+	key := make([]byte, len(f.Deleted))
+	for i := range src.Descs {
+		covered := make(tombstones, len(f.Deleted))
+		for j := range f.Deleted {
+			_, covered[j] = slices.BinarySearch(f.Deleted[j], src.Descs[i].Path)
+			key[j] = 0
+			if covered[j] {
+				key[j] = 1
+			}
+		}
+		g := bykey[string(key)]
+		if g == nil {
+			g = &group{in: Input{Fields: src.Fields}, covered: covered}
+			groups = append(groups, g)
+			bykey[string(key)] = g
+		}
+		g.in.Descs = append(g.in.Descs, src.Descs[i])
+	}
+	if len(groups) == 0 {
+		groups = append(groups, &group{
+			in:      Input{Fields: src.Fields},
+			covered: make(tombstones, len(f.Deleted)),
+		})
+	}
+	for _, g := range groups {
+		filt := expr.Rewrite(g.covered, expr.Copy(f.Expr))
+		filt = expr.Simplify(ep.rewrite(filt), expr.NoHint)
+		if ep.Rewriter != nil {
+			push(filt, f.From)
+		}
+		filter, err := vm.NewFilter(filt, noClose{dst})
+		if err == nil {
+			err = f.From.exec(ep.profile(f, filter), &g.in, ep)
+		}
+		if err != nil {
+			dst.Close()
+			return err
+		}
+	}
+	return dst.Close()
+}
+
+// tombstones is an expr.Rewriter that
+// replaces TOMBSTONE(i, x) with x if the
+// ith tombstone is true and FALSE otherwise
+type tombstones []bool
+
+func (t tombstones) Rewrite(e expr.Node) expr.Node {
+	b, ok := e.(*expr.Builtin)
+	if !ok || b.Func != expr.Tombstone || len(b.Args) != 2 {
+		return e
+	}
+	i, ok := b.Args[0].(expr.Integer)
+	if !ok || int(i) < 0 || int(i) >= len(t) || !t[i] {
+		return expr.Bool(false)
+	}
+	return b.Args[1]
+}
+
+func (t tombstones) Walk(e expr.Node) expr.Rewriter { return t }
+
+// noClose is a vm.QuerySink that
+// is not closed by the sinks that
+// write into it
+type noClose struct {
+	vm.QuerySink
+}
+
+func (noClose) Close() error { return nil }
+
 func (f *Filter) encode(dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) error {
 	dst.BeginStruct(-1)
 	settype("filter", dst, st)
 	dst.BeginField(st.Intern("expr"))
 	ep.rewrite(f.Expr).Encode(dst, st)
+	if len(f.Deleted) > 0 {
+		dst.BeginField(st.Intern("deleted"))
+		dst.BeginList(-1)
+		for i := range f.Deleted {
+			dst.BeginList(-1)
+			for j := range f.Deleted[i] {
+				dst.WriteString(f.Deleted[i][j])
+			}
+			dst.EndList()
+		}
+		dst.EndList()
+	}
 	dst.EndStruct()
 	return nil
 }
@@ -61,6 +165,17 @@ func (f *Filter) SetField(sf ion.Field) error {
 			return err
 		}
 		f.Expr = e
+	case "deleted":
+		return sf.UnpackList(func(d ion.Datum) error {
+			var lst []string
+			err := d.UnpackList(func(d ion.Datum) error {
+				p, err := d.String()
+				lst = append(lst, p)
+				return err
+			})
+			f.Deleted = append(f.Deleted, lst)
+			return err
+		})
 	default:
 		return errUnexpectedField
 	}
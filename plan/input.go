@@ -369,6 +369,25 @@ func (in *Input) Append(other *Input) {
 func (in *Input) Encode(dst *ion.Buffer, st *ion.Symtab) {
 	// TODO: compress very large Input lists
 	dst.BeginStruct(-1)
+	// the descriptors only refer to their
+	// dictionaries by ID, so the dictionaries
+	// are encoded first so that they can be
+	// resolved while decoding the descriptors
+	var dicts []*blockfmt.Dictionary
+	for i := range in.Descs {
+		d := in.Descs[i].Trailer.Dictionary
+		if d != nil && !slices.Contains(dicts, d) {
+			dicts = append(dicts, d)
+		}
+	}
+	if len(dicts) > 0 {
+		dst.BeginField(st.Intern("dicts"))
+		dst.BeginList(-1)
+		for i := range dicts {
+			dicts[i].Encode(dst, st)
+		}
+		dst.EndList()
+	}
 	dst.BeginField(st.Intern("descs"))
 	dst.BeginList(-1)
 	for i := range in.Descs {
@@ -403,15 +422,25 @@ func (d *Descriptor) Encode(dst *ion.Buffer, st *ion.Symtab) {
 }
 
 func (in *Input) decode(v ion.Datum) error {
+	var dicts []*blockfmt.Dictionary
 	err := v.UnpackStruct(func(f ion.Field) error {
 		switch f.Label {
 		case "descs":
-			var td blockfmt.TrailerDecoder
+			td := blockfmt.TrailerDecoder{Dictionaries: dicts}
 			in.Descs = in.Descs[:0]
 			return f.UnpackList(func(v ion.Datum) error {
 				in.Descs = append(in.Descs, Descriptor{})
 				return in.Descs[len(in.Descs)-1].Decode(&td, v)
 			})
+		case "dicts":
+			return f.UnpackList(func(v ion.Datum) error {
+				d := new(blockfmt.Dictionary)
+				if err := d.Decode(v); err != nil {
+					return err
+				}
+				dicts = append(dicts, d)
+				return nil
+			})
 		case "fields":
 			if f.IsNull() {
 				in.Fields = nil
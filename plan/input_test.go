@@ -72,3 +72,53 @@ func TestInputFilter(t *testing.T) {
 		t.Fatal("not equal")
 	}
 }
+
+func TestInputDictionaries(t *testing.T) {
+	dict := &blockfmt.Dictionary{Version: 1, Data: []byte("not really a dictionary")}
+	desc := func(path string, dict *blockfmt.Dictionary) Descriptor {
+		d := Descriptor{
+			Descriptor: blockfmt.Descriptor{
+				ObjectInfo: blockfmt.ObjectInfo{Path: path},
+				Trailer:    blockfmt.Trailer{Algo: "zstd", BlockShift: 20},
+			},
+		}
+		if dict != nil {
+			d.Trailer.Dict = dict.ID()
+			d.Trailer.Dictionary = dict
+		}
+		return d
+	}
+	orig := &Input{
+		Descs: []Descriptor{
+			desc("path/0", dict),
+			desc("path/1", nil),
+			desc("path/2", dict),
+		},
+	}
+	var buf ion.Buffer
+	var st ion.Symtab
+	orig.Encode(&buf, &st)
+	d, _, err := ion.ReadDatum(&st, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(Input)
+	if err := got.decode(d); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Descs) != 3 {
+		t.Fatalf("decoded %d descriptors", len(got.Descs))
+	}
+	// the dictionary is encoded once
+	// and shared by the descriptors
+	d0, d2 := got.Descs[0].Trailer.Dictionary, got.Descs[2].Trailer.Dictionary
+	if d0 == nil || d0 != d2 {
+		t.Fatal("dictionary not resolved")
+	}
+	if d0.ID() != dict.ID() || d0.Version != dict.Version {
+		t.Fatalf("decoded dictionary %s version %d", d0.ID(), d0.Version)
+	}
+	if got.Descs[1].Trailer.Dictionary != nil {
+		t.Fatal("unexpected dictionary")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package plan
+
+import (
+	"strings"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+// LintSchema returns an expr.LintSchema that provides
+// the fields recorded in the schemas of the table
+// indexes of env, or nil if env is not an Indexer.
+func LintSchema(env Env) expr.LintSchema {
+	idx, ok := env.(Indexer)
+	if !ok {
+		return nil
+	}
+	return &lintSchema{idx: idx}
+}
+
+type lintSchema struct {
+	idx Indexer
+}
+
+func (l *lintSchema) HasField(table expr.Node, path []string) (has, ok bool) {
+	i, err := index(l.idx, table)
+	if err != nil || i == nil {
+		return false, false
+	}
+	return hasField(i, path)
+}
+
+// hasField returns whether the rows
+// of the index i have the field path
+func hasField(i Index, path []string) (has, ok bool) {
+	if i.HasPartition(path[0]) {
+		return true, true
+	}
+	switch i := i.(type) {
+	case *blockfmt.Index:
+		s := &i.Schema
+		// indexes written before schemas were
+		// recorded have no fields at all, and
+		// fields beyond the limit are not recorded
+		if len(s.Fields) == 0 || len(s.Fields) >= blockfmt.MaxSchemaFields {
+			return false, false
+		}
+		for n := 1; n <= len(path); n++ {
+			f := s.Field(strings.Join(path[:n], "."))
+			if f == nil {
+				return false, true
+			}
+			if n < len(path) && f.Count[ion.StructType] == 0 {
+				// the field is never a structure
+				return false, true
+			}
+		}
+		return true, true
+	case multiIndex:
+		// the field exists if any index has it,
+		// and is missing only if every index
+		// is known not to have it
+		known := len(i) > 0
+		for j := range i {
+			has, ok := hasField(i[j], path)
+			if has {
+				return true, true
+			}
+			known = known && ok
+		}
+		return false, known
+	}
+	return false, false
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+)
+
+func TestLintSchema(t *testing.T) {
+	var idx blockfmt.Index
+	var st ion.Symtab
+	var buf ion.Buffer
+	for _, row := range []string{
+		`{"x": 1, "y": {"z": "foo"}, "l": [{"a": 1}]}`,
+		`{"x": 2, "y": "flat"}`,
+	} {
+		d, err := ion.FromJSON(&st, json.NewDecoder(strings.NewReader(row)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Reset()
+		d.Encode(&buf, &st)
+		if err := idx.Schema.Add(&st, buf.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// decoded schemas have no lookup table
+	decoded := &blockfmt.Index{Schema: blockfmt.Schema{Fields: idx.Schema.Fields}}
+
+	cases := []struct {
+		path    string
+		has, ok bool
+	}{
+		{"x", true, true},
+		{"y", true, true},
+		{"y.z", true, true},
+		{"y.w", false, true},
+		{"x.z", false, true},
+		{"l.a", false, true},
+		{"missing", false, true},
+	}
+	for _, i := range []Index{&idx, decoded, multiIndex{decoded, &idx}} {
+		for _, c := range cases {
+			has, ok := hasField(i, strings.Split(c.path, "."))
+			if has != c.has || ok != c.ok {
+				t.Errorf("%T %s: got (%v, %v), want (%v, %v)", i, c.path, has, ok, c.has, c.ok)
+			}
+		}
+	}
+	// an index without a schema doesn't know its fields
+	if _, ok := hasField(&blockfmt.Index{}, []string{"x"}); ok {
+		t.Error("empty schema should be unknown")
+	}
+	mixed := multiIndex{&blockfmt.Index{}, &idx}
+	if has, ok := hasField(mixed, []string{"y", "z"}); !has || !ok {
+		t.Error("y.z should be found in one of the indexes")
+	}
+	if _, ok := hasField(mixed, []string{"missing"}); ok {
+		t.Error("missing should be unknown")
+	}
+}
@@ -82,12 +82,13 @@ func lowerLimit(in *pir.Limit, from Op) (Op, error) {
 		f.Limit = in.Count
 		return f, nil
 	}
-	if in.Offset != 0 {
-		return nil, reject("OFFSET without GROUP BY/ORDER BY not implemented")
-	}
+	// without an ordering, any rows may be skipped,
+	// so the Limit may stop the scan as soon as
+	// Count+Offset rows have been produced
 	return &Limit{
 		Nonterminal: Nonterminal{From: from},
 		Num:         in.Count,
+		Offset:      in.Offset,
 	}, nil
 }
 
@@ -164,6 +165,40 @@ func makeOrdering(node expr.Order) vm.SortOrdering {
 	return ordering
 }
 
+func (w *walker) lowerSetOp(in *pir.SetOp, from Op, env Env) (Op, error) {
+	out := &SetOp{
+		Nonterminal: Nonterminal{From: from},
+		Union:       in.Union,
+		Except:      in.Except,
+		All:         in.All,
+		Columns:     make([]string, len(in.Columns)),
+		Right:       &Node{},
+	}
+	for i := range in.Columns {
+		id, ok := in.Columns[i].(expr.Ident)
+		if !ok {
+			return nil, fmt.Errorf("unexpected set operation column %s", expr.ToString(in.Columns[i]))
+		}
+		out.Columns[i] = string(id)
+	}
+	if in.Count != nil {
+		id, ok := in.Count.(expr.Ident)
+		if !ok {
+			return nil, fmt.Errorf("unexpected set operation count %s", expr.ToString(in.Count))
+		}
+		out.Count = string(id)
+	}
+	// the right-hand side has its own input;
+	// restore the input of this node afterwards
+	latest := w.latest
+	err := w.toNode(out.Right, in.Right, env)
+	w.latest = latest
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func lowerOrder(in *pir.Order, from Op) (Op, error) {
 	if ha, ok := from.(*HashAggregate); ok {
 		// hash aggregates can accept ORDER BY directly
@@ -244,6 +279,22 @@ slowpath:
 	}, nil
 }
 
+func lowerRowNumber(in *pir.RowNumber, from Op) (Op, error) {
+	columns := make([]vm.SortColumn, len(in.OrderBy))
+	for i := range in.OrderBy {
+		columns[i] = vm.SortColumn{
+			Node:     in.OrderBy[i].Column,
+			Ordering: makeOrdering(in.OrderBy[i]),
+		}
+	}
+	return &RowNumber{
+		Nonterminal: Nonterminal{From: from},
+		PartitionBy: in.PartitionBy,
+		OrderBy:     columns,
+		Result:      in.Result,
+	}, nil
+}
+
 func lowerBind(in *pir.Bind, from Op) (Op, error) {
 	return &Project{
 		Nonterminal: Nonterminal{From: from},
@@ -324,6 +375,7 @@ func lowerOutputIndex(n *pir.OutputIndex, env Env, input Op) (Op, error) {
 		Table:       parts[1],
 		Basename:    n.Basename,
 		Key:         e.Key(),
+		Create:      n.Create,
 	}, nil
 }
 
@@ -514,10 +566,16 @@ func (w *walker) walkBuild(in pir.Step, env Env) (Op, error) {
 		})
 
 		if it.Filter != nil {
-			out = &Filter{
+			f := &Filter{
 				Nonterminal: Nonterminal{From: out},
 				Expr:        it.Filter,
 			}
+			if di, ok := it.Index.(pir.DeletedIndex); ok {
+				for _, t := range di.Deleted() {
+					f.Deleted = append(f.Deleted, t.Objects)
+				}
+			}
+			out = f
 		}
 		return out, nil
 	}
@@ -552,6 +610,10 @@ func (w *walker) walkBuild(in pir.Step, env Env) (Op, error) {
 		return lowerLimit(n, input)
 	case *pir.Order:
 		return lowerOrder(n, input)
+	case *pir.RowNumber:
+		return lowerRowNumber(n, input)
+	case *pir.SetOp:
+		return w.lowerSetOp(n, input, env)
 	case *pir.OutputIndex:
 		return lowerOutputIndex(n, env, input)
 	case *pir.OutputPart:
@@ -615,6 +677,7 @@ func toTree(in *pir.Trace, env Env) (*Tree, error) {
 	if err != nil {
 		return nil, err
 	}
+	countFromMetadata(&t.Root, t.Inputs)
 	return t, nil
 }
 
@@ -684,6 +747,18 @@ func NewSplit(q *expr.Query, env SplitEnv) (*Tree, error) {
 }
 
 func newTree(q *expr.Query, env Env, split bool) (*Tree, error) {
+	if q.Prepare != "" || q.Execute != "" || q.Deallocate != "" {
+		return nil, fmt.Errorf("cannot plan PREPARE, EXECUTE or DEALLOCATE statements")
+	}
+	if q.Delete {
+		return nil, fmt.Errorf("cannot plan DELETE statements")
+	}
+	if params := q.Params(); len(params) > 0 {
+		return nil, fmt.Errorf("query has %d unbound parameters", len(params))
+	}
+	if q.Describe {
+		return describe(q, env)
+	}
 	b, err := pir.Build(q, pirenv{env})
 	if err != nil {
 		return nil, err
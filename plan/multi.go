@@ -25,6 +25,8 @@ import (
 	"github.com/SnellerInc/sneller/date"
 	"github.com/SnellerInc/sneller/expr"
 	"github.com/SnellerInc/sneller/fsutil"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
+	"github.com/SnellerInc/sneller/plan/pir"
 )
 
 type multiIndex []Index
@@ -60,6 +62,69 @@ func (m multiIndex) HasPartition(x string) bool {
 	return true
 }
 
+// Deleted implements pir.DeletedIndex.Deleted.
+//
+// Each tombstone only covers objects of
+// the table it belongs to, so the tombstones
+// of all of the tables can simply be combined.
+func (m multiIndex) Deleted() []blockfmt.Tombstone {
+	var out []blockfmt.Tombstone
+	for i := range m {
+		if di, ok := m[i].(pir.DeletedIndex); ok {
+			out = append(out, di.Deleted()...)
+		}
+	}
+	return out
+}
+
+// stats returns the merged statistics of
+// the indexes, or nil if any of them has
+// no statistics
+func (m multiIndex) stats() *blockfmt.Stats {
+	if len(m) == 0 {
+		return nil
+	}
+	out := new(blockfmt.Stats)
+	for i := range m {
+		bi, ok := m[i].(*blockfmt.Index)
+		if !ok || bi.Stats.Rows == 0 {
+			return nil
+		}
+		out.Merge(&bi.Stats)
+	}
+	return out
+}
+
+// Rows implements pir.StatsIndex.Rows.
+func (m multiIndex) Rows() (int64, bool) {
+	n := int64(0)
+	for i := range m {
+		si, ok := m[i].(pir.StatsIndex)
+		if !ok {
+			return 0, false
+		}
+		rows, ok := si.Rows()
+		if !ok {
+			return 0, false
+		}
+		n += rows
+	}
+	return n, len(m) > 0
+}
+
+// Distinct implements pir.StatsIndex.Distinct.
+func (m multiIndex) Distinct(path []string) (int64, bool) {
+	st := m.stats()
+	if st == nil {
+		return 0, false
+	}
+	c := st.Column(strings.Join(path, "."))
+	if c == nil || c.Count == 0 {
+		return 0, false
+	}
+	return c.Distinct(), true
+}
+
 // TableLister is an interface an Env or Index can
 // optionally implement to support TABLE_GLOB and
 // TABLE_PATTERN expressions.
@@ -139,6 +204,45 @@ func indexGlob(tl TableLister, idx Indexer, e *expr.Builtin) (Index, error) {
 	}
 }
 
+// implicitGlob returns the TABLE_GLOB equivalent of
+// a table path whose table name contains unescaped
+// shell pattern characters (e.g. FROM "logs-*").
+// A table name that only contains escaped pattern
+// characters (e.g. FROM "logs-\\*") is returned as
+// the path of the table with the escapes removed.
+// Otherwise, tbl is returned unchanged.
+func implicitGlob(tbl expr.Node) expr.Node {
+	db, str, ok := splitGlobArg([]expr.Node{tbl})
+	if !ok || fsutil.MetaPrefix(str) == str {
+		return tbl
+	}
+	lit, ok := unescapeGlob(str)
+	if !ok {
+		return expr.Call(expr.TableGlob, tbl)
+	}
+	return mkpath(db, lit)
+}
+
+// unescapeGlob returns the literal string matched by
+// the pattern str if str does not contain any unescaped
+// pattern characters, or false otherwise
+func unescapeGlob(str string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(str); i++ {
+		switch str[i] {
+		case '*', '?', '[':
+			return "", false
+		case '\\':
+			i++
+			if i == len(str) {
+				return "", false
+			}
+		}
+		b.WriteByte(str[i])
+	}
+	return b.String(), true
+}
+
 func mkpath(db, tbl string) expr.Node {
 	if db == "" {
 		return expr.Ident(tbl)
@@ -19,8 +19,40 @@ import (
 	"time"
 
 	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/expr"
 )
 
+func TestImplicitGlob(t *testing.T) {
+	glob := func(e expr.Node) expr.Node { return expr.Call(expr.TableGlob, e) }
+	cases := []struct {
+		in, want expr.Node
+	}{
+		{expr.Ident("logs"), expr.Ident("logs")},
+		{expr.Ident("logs-*"), glob(expr.Ident("logs-*"))},
+		{expr.Ident("logs-[0-9]"), glob(expr.Ident("logs-[0-9]"))},
+		{
+			&expr.Dot{Inner: expr.Ident("db"), Field: "logs-?"},
+			glob(&expr.Dot{Inner: expr.Ident("db"), Field: "logs-?"}),
+		},
+		// escaped pattern characters are literal
+		{expr.Ident(`logs-\*`), expr.Ident("logs-*")},
+		{
+			&expr.Dot{Inner: expr.Ident("db"), Field: `\[x\]`},
+			&expr.Dot{Inner: expr.Ident("db"), Field: "[x]"},
+		},
+		// ... unless there are unescaped ones as well
+		{expr.Ident(`\[*`), glob(expr.Ident(`\[*`))},
+		// a trailing backslash is not an escape
+		{expr.Ident(`logs\`), glob(expr.Ident(`logs\`))},
+	}
+	for i := range cases {
+		got := implicitGlob(cases[i].in)
+		if !expr.Equivalent(got, cases[i].want) {
+			t.Errorf("%s: got %s, want %s", expr.ToString(cases[i].in), expr.ToString(got), expr.ToString(cases[i].want))
+		}
+	}
+}
+
 func TestMultiIndex(t *testing.T) {
 	base := date.Now()
 	now := func(d int) date.Time {
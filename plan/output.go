@@ -17,12 +17,14 @@ package plan
 import (
 	"crypto/rand"
 	"encoding/base32"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"path"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/SnellerInc/sneller/date"
 	"github.com/SnellerInc/sneller/db"
@@ -55,7 +57,7 @@ func (up *uploadSink) Open() (io.WriteCloser, error) {
 	ret := &uploadStream{}
 	ret.W = w
 	ret.Align = up.mw.InputAlign
-	ret.RangeAlign = 100 * ret.Align
+	ret.RangeAlign = db.DefaultRangeMultiple * ret.Align
 	return ret, nil
 }
 
@@ -174,10 +176,12 @@ func (o *OutputPart) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 		name:  name,
 		dst:   dst,
 	}
+	// use the same compression and chunk
+	// alignment as the ingestion of new data
 	us.mw.Output = up
-	us.mw.Algo = "zstd" // FIXME: grab this from elsewhere
-	us.mw.InputAlign = 1 << 20
-	return o.From.exec(us, src, ep)
+	us.mw.Algo = db.DefaultAlgo
+	us.mw.InputAlign = db.DefaultAlign
+	return o.From.exec(ep.profile(o, us), src, ep)
 }
 
 func (o *OutputPart) encode(dst *ion.Buffer, st *ion.Symtab, _ *ExecParams) error {
@@ -212,25 +216,31 @@ func (o *OutputPart) String() string {
 // them into an Index object. OutputIndex writes
 // one output row containing the autogenerated
 // table name.
+//
+// If Create is set, the index is that of the new
+// table DB.Table rather than an autogenerated one,
+// and the query fails if the table already exists.
 type OutputIndex struct {
 	Nonterminal
 	DB, Table string
 	Basename  string
 	Key       *blockfmt.Key
+	Create    bool
 }
 
 // indexSink is a vm.QuerySink that collects
-// the results of OutputPart and writes an Index
-// on the final Close and returns the autogenerated
-// table name as a single output record
+// the results of OutputPart; once they have
+// all been written, commit writes the Index
+// and returns the table name as a single
+// output record
 type indexSink struct {
 	key     *blockfmt.Key
 	store   UploadFS
 	lock    sync.Mutex
 	db, tbl string
+	create  bool
 	idx     *blockfmt.Index
 	dst     vm.QuerySink
-	closed  bool
 }
 
 type indexWriter struct {
@@ -276,17 +286,28 @@ func (is *indexSink) Open() (io.WriteCloser, error) {
 	}, nil
 }
 
-func (is *indexSink) Close() error {
-	if is.closed {
-		return nil
-	}
-	is.closed = true
+func (is *indexSink) Close() error { return nil }
+
+// commit writes the index once every part
+// has been written, so that the table only
+// appears if the query has succeeded
+func (is *indexSink) commit() error {
 	idxmem, err := blockfmt.Sign(is.key, is.idx)
 	if err != nil {
 		return err
 	}
 	idxpath := db.IndexPath(is.db, is.tbl)
-	_, err = is.store.WriteFile(idxpath, idxmem)
+	if is.create {
+		// the table may have been created
+		// while the query was running, so
+		// the index must not be overwritten
+		_, err = is.store.(blockfmt.CreateFS).WriteNewFile(idxpath, idxmem)
+		if errors.Is(err, fs.ErrExist) {
+			err = tableExists(is.db, is.tbl)
+		}
+	} else {
+		_, err = is.store.WriteFile(idxpath, idxmem)
+	}
 	if err != nil {
 		return err
 	}
@@ -310,6 +331,32 @@ func (is *indexSink) Close() error {
 	return w.Close()
 }
 
+// checkNewTable returns an error if the table
+// db.tbl already exists in store or if store
+// cannot create its index without overwriting it
+func checkNewTable(store UploadFS, dbname, tbl string) error {
+	if _, ok := store.(blockfmt.CreateFS); !ok {
+		return fmt.Errorf("CREATE TABLE: %T cannot create files exclusively", store)
+	}
+	for _, p := range []string{
+		db.IndexPath(dbname, tbl),
+		db.DefinitionPath(dbname, tbl),
+	} {
+		_, err := fs.Stat(store, p)
+		if err == nil {
+			return tableExists(dbname, tbl)
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func tableExists(dbname, tbl string) error {
+	return fmt.Errorf("table %s already exists", expr.ToString(expr.MakePath([]string{dbname, tbl})))
+}
+
 func (o *OutputIndex) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 	if o.Basename == "" {
 		return fmt.Errorf("OutputIndex: basename not set")
@@ -320,23 +367,31 @@ func (o *OutputIndex) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 	if !ok {
 		return fmt.Errorf("OutputPart: upload not supported")
 	}
-	tbl := &expr.Dot{
-		Inner: expr.Ident(o.DB),
-		Field: o.Table + "-" + uuid(),
+	name := o.Table + "-" + uuid()
+	if o.Create {
+		name = o.Table
+		if err := checkNewTable(store, o.DB, name); err != nil {
+			return err
+		}
 	}
 	idx := &blockfmt.Index{
-		Name: tbl.Field,
-		Algo: "zstd",
+		Name:    name,
+		Created: date.Now().Truncate(time.Microsecond),
+		Algo:    "zstd",
 	}
 	is := &indexSink{
-		key:   o.Key,
-		store: store,
-		db:    o.DB,
-		tbl:   tbl.Field,
-		idx:   idx,
-		dst:   dst,
+		key:    o.Key,
+		store:  store,
+		db:     o.DB,
+		tbl:    name,
+		create: o.Create,
+		idx:    idx,
+		dst:    dst,
+	}
+	if err := o.From.exec(ep.profile(o, is), src, ep); err != nil {
+		return err
 	}
-	return o.From.exec(is, src, ep)
+	return is.commit()
 }
 
 func (o *OutputIndex) SetField(f ion.Field) error {
@@ -348,6 +403,8 @@ func (o *OutputIndex) SetField(f ion.Field) error {
 		o.Table, err = f.String()
 	case "basename":
 		o.Basename, err = f.String()
+	case "create":
+		o.Create, err = f.Bool()
 	case "key":
 		inner, err := f.BlobShared()
 		if err != nil {
@@ -376,11 +433,18 @@ func (o *OutputIndex) encode(dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) er
 	dst.WriteString(o.Basename)
 	dst.BeginField(st.Intern("key"))
 	dst.WriteBlob(o.Key[:])
+	if o.Create {
+		dst.BeginField(st.Intern("create"))
+		dst.WriteBool(true)
+	}
 	dst.EndStruct()
 	return nil
 }
 
 func (o *OutputIndex) String() string {
 	e := expr.MakePath([]string{o.DB, o.Table})
+	if o.Create {
+		return fmt.Sprintf("CREATE TABLE %s AT %s", expr.ToString(e), o.Basename)
+	}
 	return fmt.Sprintf("OUTPUT INDEX %s AT %s", expr.ToString(e), o.Basename)
 }
@@ -18,6 +18,9 @@ import (
 	"bytes"
 	"crypto/rand"
 	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/SnellerInc/sneller/db"
@@ -36,63 +39,14 @@ func TestOutput(t *testing.T) {
 	for i := range cases {
 		c := &cases[i]
 		t.Run(fmt.Sprintf("case-%d", i), func(t *testing.T) {
-			// exec the first query
-			q, err := partiql.Parse([]byte(c.text))
+			env := mkoutenv(t, t.TempDir())
+			tbl, err := execOutput(t, env, c.text)
 			if err != nil {
 				t.Fatal(err)
 			}
-			tmp := t.TempDir()
-			env := mkoutenv(t, tmp)
-			tree, err := New(q, env)
-			if err != nil {
-				t.Fatal(err)
-				return
-			}
-			t.Logf("query: %s", expr.ToString(q))
-			t.Run("serialize-plan", func(t *testing.T) {
-				testPlanSerialize(t, tree)
-			})
-			t.Logf("plan:\n%s", tree)
-			var dst bytes.Buffer
-			err = Exec(&ExecParams{
-				Plan:   tree,
-				Output: &dst,
-				Runner: env,
-				FS:     env.fs,
-			})
-			if err != nil {
-				t.Fatal(err)
-			}
-			// get the path from the output
-			var st ion.Symtab
-			rest, err := st.Unmarshal(dst.Bytes())
-			if err != nil {
-				t.Fatal(err)
-			}
-			var tbl string
-			_, err = ion.UnpackStruct(&st, rest, func(field string, buf []byte) error {
-				var err error
-				if field == "table" {
-					tbl, _, err = ion.ReadString(buf)
-				}
-				return err
-			})
-			if err != nil {
-				t.Fatal(err)
-			} else if tbl == "" {
-				t.Fatal("could not find table in output")
-			}
-			ep, err := expr.ParsePath(tbl)
-			if err != nil {
-				t.Fatal(err)
-			}
-			p, ok := expr.FlatPath(ep)
-			if !ok {
-				t.Fatalf("%s is not a flat path", expr.ToString(ep))
-			}
 			// make sure we can open the
 			// index file
-			idx, err := db.OpenIndex(env.fs, p[0], p[1], env.Key())
+			idx, err := db.OpenIndex(env.fs, tbl[0], tbl[1], env.Key())
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -101,6 +55,113 @@ func TestOutput(t *testing.T) {
 	}
 }
 
+func TestCreateTable(t *testing.T) {
+	env := mkoutenv(t, t.TempDir())
+	text := "CREATE TABLE foo.bar AS SELECT * FROM parking"
+	tbl, err := execOutput(t, env, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl[0] != "foo" || tbl[1] != "bar" {
+		t.Fatalf("created table %s.%s", tbl[0], tbl[1])
+	}
+	idx, err := db.OpenIndex(env.fs, "foo", "bar", env.Key())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Inline) == 0 {
+		t.Fatal("no data in the new table")
+	}
+	// the data is compressed like ingested data
+	for i := range idx.Inline {
+		trailer := &idx.Inline[i].Trailer
+		if trailer.Algo != db.DefaultAlgo || 1<<trailer.BlockShift != db.DefaultAlign {
+			t.Errorf("part %d: algo %q block size %d", i, trailer.Algo, 1<<trailer.BlockShift)
+		}
+	}
+	_, err = execOutput(t, env, text)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("creating the table again: got error %v", err)
+	}
+
+	// a table that is created while the
+	// query is running is not overwritten
+	env = mkoutenv(t, t.TempDir())
+	idxpath := db.IndexPath("foo", "bar")
+	env.fs.(*logfs).onCreate = func() {
+		if _, err := env.fs.WriteFile(idxpath, []byte("other")); err != nil {
+			t.Error(err)
+		}
+	}
+	_, err = execOutput(t, env, text)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("creating the table concurrently: got error %v", err)
+	}
+	buf, err := fs.ReadFile(env.fs, idxpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "other" {
+		t.Fatal("the concurrently created index was overwritten")
+	}
+}
+
+// execOutput executes a query that creates a table
+// and returns the db and table named in its output
+func execOutput(t *testing.T, env *outputenv, text string) ([]string, error) {
+	q, err := partiql.Parse([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := New(q, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("query: %s", expr.ToString(q))
+	t.Run("serialize-plan", func(t *testing.T) {
+		testPlanSerialize(t, tree)
+	})
+	t.Logf("plan:\n%s", tree)
+	var dst bytes.Buffer
+	err = Exec(&ExecParams{
+		Plan:   tree,
+		Output: &dst,
+		Runner: env,
+		FS:     env.fs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// get the path from the output
+	var st ion.Symtab
+	rest, err := st.Unmarshal(dst.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tbl string
+	_, err = ion.UnpackStruct(&st, rest, func(field string, buf []byte) error {
+		var err error
+		if field == "table" {
+			tbl, _, err = ion.ReadString(buf)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if tbl == "" {
+		t.Fatal("could not find table in output")
+	}
+	ep, err := expr.ParsePath(tbl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, ok := expr.FlatPath(ep)
+	if !ok || len(p) != 2 {
+		t.Fatalf("%s is not a table path", expr.ToString(ep))
+	}
+	return p, nil
+}
+
 var _ UploadEnv = (*outputenv)(nil)
 
 type outputenv struct {
@@ -124,6 +185,27 @@ func (o *outputenv) Key() *blockfmt.Key { return o.key }
 type logfs struct {
 	t *testing.T
 	UploadFS
+	// onCreate, if set, is called
+	// once before the first upload
+	onCreate func()
+	once     sync.Once
+}
+
+func (f *logfs) Create(path string) (blockfmt.Uploader, error) {
+	if f.onCreate != nil {
+		f.once.Do(f.onCreate)
+	}
+	return f.UploadFS.Create(path)
+}
+
+func (f *logfs) WriteNewFile(path string, buf []byte) (string, error) {
+	etag, err := f.UploadFS.(blockfmt.CreateFS).WriteNewFile(path, buf)
+	if err != nil {
+		f.t.Logf("creating %q failed: %v", path, err)
+		return "", err
+	}
+	f.t.Logf("creating %q succeeded", path)
+	return etag, nil
 }
 
 func (f *logfs) WriteFile(path string, buf []byte) (string, error) {
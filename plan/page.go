@@ -0,0 +1,195 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/vm"
+)
+
+// A ScanPosition identifies a row of the output
+// of a query that is executed one page at a time
+// (see Tree.Page): the row follows the first Row
+// rows produced from the descriptor Blob of the
+// input of the query.
+type ScanPosition struct {
+	Blob int
+	Row  int64
+}
+
+// A Page is a part of the output of a query.
+type Page struct {
+	// Start is the position of
+	// the first row of the page.
+	Start ScanPosition
+	// Size is the maximum number
+	// of rows in the page.
+	Size int64
+}
+
+func (p *Page) encode(dst *ion.Buffer, st *ion.Symtab) {
+	dst.BeginStruct(-1)
+	dst.BeginField(st.Intern("blob"))
+	dst.WriteInt(int64(p.Start.Blob))
+	dst.BeginField(st.Intern("row"))
+	dst.WriteInt(p.Start.Row)
+	dst.BeginField(st.Intern("size"))
+	dst.WriteInt(p.Size)
+	dst.EndStruct()
+}
+
+func (p *Page) decode(v ion.Datum) error {
+	return v.UnpackStruct(func(f ion.Field) error {
+		var err error
+		var i int64
+		switch f.Label {
+		case "blob":
+			i, err = f.Int()
+			p.Start.Blob = int(i)
+		case "row":
+			p.Start.Row, err = f.Int()
+		case "size":
+			p.Size, err = f.Int()
+		default:
+			return errUnexpectedField
+		}
+		return err
+	})
+}
+
+// CheckPage returns an error if t cannot be
+// executed one page at a time (see Tree.Page).
+func (t *Tree) CheckPage() error {
+	_, err := t.pageInput()
+	return err
+}
+
+// pageInput returns the input of t if the rows
+// of t can be produced from each of the descriptors
+// of the input independently, which is required
+// for t to be executed one page at a time
+func (t *Tree) pageInput() (*Input, error) {
+	if len(t.Inputs) != 1 || t.Root.Input != 0 {
+		return nil, fmt.Errorf("pagination requires a query over exactly one table")
+	}
+	for op := t.Root.Op; op != nil; op = op.input() {
+		switch op.(type) {
+		case *Leaf, *Filter, *Project, *Unnest, *Unpivot:
+		case *Substitute:
+			return nil, fmt.Errorf("pagination is not supported for queries with sub-queries")
+		default:
+			return nil, fmt.Errorf("pagination is not supported for this query")
+		}
+	}
+	return t.Inputs[0], nil
+}
+
+// execPage writes t.Page of the output of t to dst
+// and sets ep.Stats.Next to the position of the row
+// following the page, if there is one.
+//
+// Each descriptor of the input is scanned by one
+// thread, so that its rows are produced in the same
+// order every time the query is executed, and up to
+// ep.Parallel descriptors are scanned at once. The rows
+// of each descriptor (no more than the page needs, plus
+// one to find out whether there is a next page) are
+// buffered and written to dst in the order of the
+// descriptors.
+func (t *Tree) execPage(dst vm.QuerySink, ep *ExecParams) error {
+	in, err := t.pageInput()
+	if err != nil {
+		return err
+	}
+	pos := t.Page.Start
+	need := t.Page.Size
+	for pos.Blob < len(in.Descs) {
+		n := min(ep.Parallel, len(in.Descs)-pos.Blob)
+		bufs := make([]vm.QueryBuffer, n)
+		errlist := make([]error, n)
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for i := 0; i < n; i++ {
+			skip := int64(0)
+			if i == 0 {
+				skip = pos.Row
+			}
+			blob := &Input{
+				Descs:  in.Descs[pos.Blob+i : pos.Blob+i+1],
+				Fields: in.Fields,
+			}
+			subex := ep.clone()
+			subex.Parallel = 1
+			subex.get = func(int) *Input { return blob }
+			go func(i int) {
+				defer wg.Done()
+				errlist[i] = t.Root.exec(vm.NewLimitOffset(need+1, skip, &bufs[i]), subex)
+				ep.Stats.atomicAdd(&subex.Stats)
+			}(i)
+		}
+		wg.Wait()
+		if err := errors.Join(errlist...); err != nil {
+			return err
+		}
+		for i := range bufs {
+			rows, err := countRows(bufs[i].Bytes())
+			if err != nil {
+				return err
+			}
+			// write the rows through a Limit (rather than
+			// copying the buffered chunks) so that only
+			// the rows themselves are written to dst
+			if w := min(rows, need); w > 0 {
+				err := bufs[i].Table().WriteChunks(vm.NewLimit(w, noCloseSink{dst}), 1)
+				if err != nil && !errors.Is(err, io.EOF) {
+					return err
+				}
+			}
+			if rows > need {
+				ep.Stats.Next = &ScanPosition{Blob: pos.Blob, Row: pos.Row + need}
+				return dst.Close()
+			}
+			need -= rows
+			pos = ScanPosition{Blob: pos.Blob + 1}
+		}
+	}
+	return dst.Close()
+}
+
+// countRows returns the number of
+// structures in the ion chunks in buf
+func countRows(buf []byte) (int64, error) {
+	n := int64(0)
+	for len(buf) > 0 {
+		if ion.IsBVM(buf) {
+			buf = buf[4:]
+			continue
+		}
+		if ion.TypeOf(buf) == ion.StructType {
+			n++
+		}
+		size := ion.SizeOf(buf)
+		if size <= 0 || size > len(buf) {
+			return 0, fmt.Errorf("plan: invalid ion in page output")
+		}
+		buf = buf[size:]
+	}
+	return n, nil
+}
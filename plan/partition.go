@@ -67,8 +67,10 @@ func getframe(src []byte) frame {
 }
 
 type server struct {
-	run    Runner
-	initfs func(ion.Datum) (fs.FS, error)
+	run     Runner
+	initfs  func(ion.Datum) (fs.FS, error)
+	threads int
+	spill   string
 
 	pipe io.ReadWriteCloser
 	rd   *bufio.Reader
@@ -99,6 +101,14 @@ type Server struct {
 	// appropriate information necessary to access
 	// file system (e.g., credentials).
 	InitFS func(ion.Datum) (fs.FS, error)
+	// Threads, if positive, is the maximum
+	// number of threads used to execute
+	// each query (see LocalTransport.Threads).
+	Threads int
+	// SpillDir, if set, is the directory in which
+	// queries spill intermediate results that do
+	// not fit in memory (see ExecParams.SpillDir).
+	SpillDir string
 }
 
 // Serve serves queries from [rw] using [run] to
@@ -118,6 +128,8 @@ func (s *Server) Serve(rw io.ReadWriteCloser) error {
 	sv := serverPool.Get().(*server)
 	sv.run = s.Runner
 	sv.initfs = s.InitFS
+	sv.threads = s.Threads
+	sv.spill = s.SpillDir
 	sv.pipe = rw
 	sv.tmp = sv.tmp[:0]
 	sv.writeFail = false
@@ -303,12 +315,16 @@ func (s *server) runQuery(buf []byte, ctx context.Context, cancel func()) error
 		s.senderr(err.Error())
 		return s.ctxerr(ctx, err)
 	}
-	lp := LocalTransport{}
+	lp := LocalTransport{Threads: s.threads}
 	ep := ExecParams{
-		Plan:    t,
-		Output:  s,
-		Context: ctx,
-		Runner:  s.run,
+		Plan:      t,
+		Output:    s,
+		Context:   ctx,
+		Runner:    s.run,
+		Profile:   t.profile,
+		Prefetch:  t.prefetch,
+		BlockSize: t.blocksize,
+		SpillDir:  s.spill,
 	}
 	if s.initfs != nil && !t.Data.IsEmpty() {
 		ep.FS, err = s.initfs(t.Data)
@@ -494,6 +510,9 @@ func (c *Client) decodestat(stat *ExecStats, size int) error {
 		return err
 	}
 	stat.atomicAdd(&tmp)
+	// there is only one fin frame per query
+	stat.Ops = tmp.Ops
+	stat.Tables = tmp.Tables
 	return nil
 }
 
@@ -52,9 +52,11 @@ func (a *aggdistinct) Walk(e expr.Node) expr.Rewriter {
 // aggdistinctpromote replaces aggregates having the DISTINCT clasue with subqueries
 //
 // This transformation is done only if a query has aggregates with and without
-// DISTINCT clause.
+// DISTINCT clause, or DISTINCT aggregates of different expressions
+// (the rows of a single query can only be made distinct on one expression).
 func aggdistinctpromote(s *expr.Select) error {
-	if !hasMixedDistinctAndRegularAggregates(s.Columns) {
+	if !hasMixedDistinctAndRegularAggregates(s.Columns) &&
+		!hasDistinctAggregatesOfDifferentInputs(s.Columns) {
 		return nil
 	}
 
@@ -97,3 +99,26 @@ func hasMixedDistinctAndRegularAggregates(columns []expr.Binding) bool {
 
 	return false
 }
+
+func hasDistinctAggregatesOfDifferentInputs(columns []expr.Binding) bool {
+	var inner expr.Node
+	different := false
+	visit := expr.WalkFunc(func(e expr.Node) bool {
+		agg, ok := e.(*expr.Aggregate)
+		if !ok {
+			return !different
+		}
+		if agg.IsDistinct() {
+			if inner == nil {
+				inner = agg.Inner
+			} else if !expr.Equivalent(inner, agg.Inner) {
+				different = true
+			}
+		}
+		return false
+	})
+	for i := range columns {
+		expr.Walk(visit, columns[i].Expr)
+	}
+	return different
+}
@@ -63,7 +63,7 @@ func matchAny[T any](lst []T, proc func(*T) bool) bool {
 // but in practice they cannot be nested, so
 // let's search for those cases in advance
 // so that we can provide a more helpful error
-func rejectNestedAggregates(columns []expr.Binding, order []expr.Order, fn func(*expr.Aggregate)) error {
+func rejectNestedAggregates(columns []expr.Binding, order []expr.Order, having, qualify expr.Node, fn func(*expr.Aggregate)) error {
 	var err error
 
 	// we have two AST visitors, and we switch
@@ -131,7 +131,16 @@ func rejectNestedAggregates(columns []expr.Binding, order []expr.Order, fn func(
 			return err
 		}
 	}
-	return nil
+	if having != nil {
+		expr.Walk(walkouter, having)
+		if err != nil {
+			return err
+		}
+	}
+	if qualify != nil {
+		expr.Walk(walkouter, qualify)
+	}
+	return err
 }
 
 type flattenerItem struct {
@@ -258,6 +267,16 @@ func flattenBind(columns []expr.Binding) []expr.Binding {
 	return columns
 }
 
+// uncollated returns the expression
+// of a collated GROUP BY column
+// (or e itself if it isn't collated)
+func uncollated(e expr.Node) expr.Node {
+	if b, ok := e.(*expr.Builtin); ok && b.Func == expr.CollateCI {
+		return b.Args[0]
+	}
+	return e
+}
+
 func flattenIntoExprs(x []expr.Binding, y []expr.Node) {
 	flattenIntoFunc(x, len(y), func(j int) *expr.Node {
 		return &y[j]
@@ -290,13 +309,27 @@ func flattenIntoFunc(x []expr.Binding, n int, item func(int) *expr.Node) {
 	}
 }
 
-// splitAggregate generates the aggregation (and HAVING) step(s)
+// allPaths returns whether all of
+// the bound expressions are (possibly collated) paths
+func allPaths(bind []expr.Binding) bool {
+	for i := range bind {
+		if !expr.IsPath(uncollated(bind[i].Expr)) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitAggregate generates the aggregation (and HAVING and QUALIFY) step(s)
 // and rewrites the order and distinct expressions to use
 // the bindings produced by the aggregation step
-func (b *Trace) splitAggregate(order []expr.Order, distinct []expr.Node, columns, groups []expr.Binding, having expr.Node) error {
+//
+// qualify must already reference the expressions
+// of the output columns rather than their names
+func (b *Trace) splitAggregate(order []expr.Order, distinct []expr.Node, columns, groups []expr.Binding, having, qualify expr.Node) error {
 	hasaggregate := false
 	iterall := false // an aggregate needs all columns
-	err := rejectNestedAggregates(columns, order, func(agg *expr.Aggregate) {
+	err := rejectNestedAggregates(columns, order, having, qualify, func(agg *expr.Aggregate) {
 		hasaggregate = true
 		if agg.Op == expr.OpSystemDatashape {
 			iterall = true
@@ -308,15 +341,33 @@ func (b *Trace) splitAggregate(order []expr.Order, distinct []expr.Node, columns
 	if anyHasAggregate(groups) {
 		return fmt.Errorf("GROUP BY cannot contain aggregates")
 	}
-	if !hasaggregate {
+	// HAVING without GROUP BY aggregates
+	// the whole input into a single group
+	implicit := having != nil && len(groups) == 0
+	if !hasaggregate && !implicit && allPaths(groups) {
+		// GROUP BY paths without any aggregates is
+		// just DISTINCT over the grouping columns
 		flattenIntoExprs(groups, distinct)
 		err = b.DistinctFromBindings(groups)
 		if err != nil {
 			return err
 		}
-		return b.Bind(groups)
+		err = b.Bind(groups)
+		if err == nil && having != nil {
+			err = b.Where(flattenOne(columns, having))
+		}
+		return err
 	}
 
+	if !hasaggregate && !implicit {
+		// paths are cheap to evaluate,
+		// so there's no need to rename them
+		for i := range groups {
+			if expr.IsPath(groups[i].Expr) {
+				groups[i].As(groups[i].Result())
+			}
+		}
+	}
 	if iterall {
 		b.top.get("*")
 	}
@@ -356,7 +407,7 @@ func (b *Trace) splitAggregate(order []expr.Order, distinct []expr.Node, columns
 		// then set the output of the grouping expression
 		// to the temporary value for 'bind'
 		for i := range groups {
-			if expr.Equivalent(e, groups[i].Expr) {
+			if expr.Equivalent(e, groups[i].Expr) || expr.Equivalent(e, uncollated(groups[i].Expr)) {
 				if !groups[i].Explicit() {
 					gen := gensym(0, symno)
 					symno++
@@ -377,7 +428,14 @@ func (b *Trace) splitAggregate(order []expr.Order, distinct []expr.Node, columns
 		// for HAVING as we do for projection means
 		// that HAVING can actually introduce new aggregate
 		// bindings... I suppose that's sometimes useful?
-		having = expr.Rewrite(rw, having)
+		having = expr.Rewrite(&agglifter{rewrite: func(e expr.Node, windowok bool) expr.Node {
+			if age, ok := e.(*expr.Aggregate); ok && vm.IsExactSum(age) {
+				// the bytecode has no decimal arithmetic, so
+				// the exact sums are compared as floats
+				return expr.Call(expr.DecimalToFloat, rewrite(e, windowok))
+			}
+			return rewrite(e, windowok)
+		}}, having)
 		if err != nil {
 			return err
 		}
@@ -394,6 +452,15 @@ func (b *Trace) splitAggregate(order []expr.Order, distinct []expr.Node, columns
 		}
 	}
 	rw.windowok = true
+	if qualify != nil {
+		// QUALIFY filters the results of the window
+		// functions, which are computed along with
+		// the aggregates
+		qualify = expr.Rewrite(rw, qualify)
+		if err != nil {
+			return err
+		}
+	}
 	// ORDER BY and DISTINCT ON have the same binding semantics:
 	for i := range order {
 		order[i].Column = expr.Rewrite(rw, order[i].Column)
@@ -401,6 +468,39 @@ func (b *Trace) splitAggregate(order []expr.Order, distinct []expr.Node, columns
 	for i := range distinct {
 		distinct[i] = expr.Rewrite(rw, distinct[i])
 	}
+	if !hasaggregate && !implicit {
+		// as above, but the grouping expressions are
+		// bound before the DISTINCT so that each of them
+		// is computed just once and then referenced
+		// by name in the rest of the query
+		keys := make([]expr.Node, len(groups))
+		for i := range groups {
+			if groups[i].Result() == "" {
+				groups[i].As(gensym(0, symno))
+				symno++
+			}
+			keys[i] = expr.Ident(groups[i].Result())
+			if u := uncollated(groups[i].Expr); u != groups[i].Expr {
+				groups[i].Expr = u
+				keys[i] = expr.Call(expr.CollateCI, keys[i])
+			}
+		}
+		err = b.Bind(groups)
+		if err != nil {
+			return err
+		}
+		err = b.Distinct(keys)
+		if err == nil && having != nil {
+			err = b.Where(having)
+		}
+		return err
+	}
+	if len(aggcols) == 0 {
+		// the implicit group has no aggregates,
+		// but it still needs to produce exactly one row
+		gen := gensym(0, symno)
+		aggcols = append(aggcols, vm.AggBinding{Expr: expr.Count(expr.Star{}), Result: gen})
+	}
 	// now we can push these to the builder
 	// in the correct order of evaluation
 	err = b.Aggregate(aggcols, groups)
@@ -409,6 +509,12 @@ func (b *Trace) splitAggregate(order []expr.Order, distinct []expr.Node, columns
 	}
 	if having != nil {
 		err = b.Where(having)
+		if err != nil {
+			return err
+		}
+	}
+	if qualify != nil {
+		err = b.Where(qualify)
 	}
 	return err
 }
@@ -18,9 +18,11 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"slices"
 
 	"github.com/SnellerInc/sneller/date"
 	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion/blockfmt"
 )
 
 // CompileError is an error associated
@@ -171,6 +173,32 @@ type Index interface {
 	HasPartition(field string) bool
 }
 
+// StatsIndex may optionally be implemented
+// by an Index that records approximate statistics
+// about the rows of its table. Build uses the
+// statistics to choose the order of inner joins.
+type StatsIndex interface {
+	Index
+	// Rows returns the approximate number of rows
+	// in the table, or false if it is not known.
+	Rows() (int64, bool)
+	// Distinct returns the approximate number of
+	// distinct values of the field at path, or
+	// false if it is not known.
+	Distinct(path []string) (int64, bool)
+}
+
+// DeletedIndex may optionally be implemented
+// by an Index of a table that still stores rows
+// that have been deleted. Build excludes the
+// deleted rows from every scan of the table.
+type DeletedIndex interface {
+	Index
+	// Deleted returns the tombstones
+	// of the deleted rows, if any.
+	Deleted() []blockfmt.Tombstone
+}
+
 // Build walks the provided Query
 // and lowers it into the optimized query IR.
 // If the provided SchemaHint is non-nil,
@@ -186,23 +214,34 @@ func Build(q *expr.Query, e Env) (*Trace, error) {
 			return nil, err
 		}
 	}
-	if sel, ok := body.(*expr.Select); ok {
-		t, err := build(nil, sel, e)
-		if err != nil {
-			return nil, err
-		}
-		if q.Into != nil {
-			// expect db.table
-			p, ok := expr.FlatPath(q.Into)
-			if !ok || len(p) != 2 {
-				return nil, fmt.Errorf("unsupported INTO: %q", expr.ToString(q.Into))
+	var t *Trace
+	switch body := body.(type) {
+	case *expr.Select:
+		t, err = build(nil, body, e)
+	case *expr.Union:
+		t, err = buildSetOp(nil, body, e)
+	default:
+		return nil, errorf(body, "cannot pir.Build %T", body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if q.Into != nil {
+		// expect db.table
+		p, ok := expr.FlatPath(q.Into)
+		if !ok || len(p) != 2 {
+			if q.Create {
+				return nil, fmt.Errorf("unsupported CREATE TABLE: %q", expr.ToString(q.Into))
 			}
+			return nil, fmt.Errorf("unsupported INTO: %q", expr.ToString(q.Into))
+		}
+		if q.Create {
+			t.CreateTable(q.Into, path.Join("db", p[0], p[1]))
+		} else {
 			t.Into(q.Into, path.Join("db", p[0], p[1]))
 		}
-		return t, nil
 	}
-	// TODO: body can be UNION ALL, UNION, etc.
-	return nil, errorf(body, "cannot pir.Build %T", body)
+	return t, nil
 }
 
 func build(parent *Trace, s *expr.Select, e Env) (*Trace, error) {
@@ -330,6 +369,9 @@ func pickOutputs(s *expr.Select) {
 			use(s.Columns[i].Result())
 			continue
 		}
+		if _, ok := s.Columns[i].Expr.(expr.Star); ok {
+			continue
+		}
 		// do not *implicitly* assign the same
 		// result name more than once;
 		// if we see that, then append _%d until
@@ -369,8 +411,11 @@ func (h *hoistwalk) Walk(e expr.Node) expr.Rewriter {
 	if _, ok := e.(*expr.Table); ok {
 		return nil
 	}
-	if b, ok := e.(*expr.Builtin); ok && b.Func == expr.InSubquery {
-		return nil
+	if b, ok := e.(*expr.Builtin); ok {
+		switch b.Func {
+		case expr.InSubquery, expr.AnySubquery, expr.AllSubquery:
+			return nil
+		}
 	}
 	if _, ok := e.(*expr.Select); ok {
 		return nil
@@ -449,6 +494,8 @@ func (h *hoistwalk) Rewrite(e expr.Node) expr.Node {
 		switch b.Func {
 		case expr.InSubquery:
 			return h.rewriteInSubquery(b)
+		case expr.AnySubquery, expr.AllSubquery:
+			return h.rewriteQuantified(b)
 		case expr.ScalarReplacement, expr.ListReplacement, expr.StructReplacement:
 			return b
 		default:
@@ -573,6 +620,141 @@ func (h *hoistwalk) rewriteInSubquery(b *expr.Builtin) expr.Node {
 	}
 }
 
+// rewriteQuantified rewrites
+//
+//	x <op> ANY (SELECT ...)
+//	x <op> ALL (SELECT ...)
+//
+// into a comparison against the MIN or MAX of the
+// sub-query for ordinal comparisons and a comparison
+// against the sub-query results otherwise
+//
+// (= ANY is not rewritten into IN, since it must
+// produce NULL rather than FALSE when there is no
+// match and the sub-query produces NULLs)
+func (h *hoistwalk) rewriteQuantified(b *expr.Builtin) expr.Node {
+	op := expr.CmpOp(b.Args[0].(expr.Integer))
+	all := b.Func == expr.AllSubquery
+	x := h.rewriteScalarArg(b.Args[1])
+	sel := b.Args[2].(*expr.Select)
+	// build a copy of the sub-query so that
+	// we can still wrap the original one below
+	t, err := build(h.parent, expr.Copy(sel).(*expr.Select), h.env)
+	if err != nil {
+		h.err = err
+		return b
+	}
+	if cols := len(t.FinalBindings()); cols != 1 {
+		h.err = errorf(sel, "ANY or ALL sub-query should have 1 column; have %d", cols)
+		return b
+	}
+	if op.Ordinal() {
+		return h.compareBound(op, all, x, sel, t)
+	}
+	index := len(h.in)
+	switch t.Class() {
+	case SizeZero:
+		return expr.Bool(all)
+	case SizeOne, SizeExactSmall, SizeColumnCardinality:
+		h.in = append(h.in, t)
+		fn := expr.AnyReplacement
+		if all {
+			fn = expr.AllReplacement
+		}
+		return expr.Call(fn, b.Args[0], x, expr.Integer(index))
+	default:
+		h.err = errorf(sel, "sub-query cardinality too large: %s", expr.ToString(sel))
+		return b
+	}
+}
+
+// compareBound implements an ordinal comparison
+// quantified with ANY or ALL as a comparison against
+// the smallest or largest result of the sub-query;
+// the number of results and non-NULL results determine
+// the result for an empty sub-query and for NULLs
+func (h *hoistwalk) compareBound(op expr.CmpOp, all bool, x expr.Node, sel *expr.Select, t *Trace) expr.Node {
+	largest := (op == expr.Greater || op == expr.GreaterEquals) == all
+	col := expr.Ident(t.FinalBindings()[0].Result())
+	ft := t.FinalTypes()[0]
+	columns := []expr.Binding{
+		expr.Bind(expr.Count(expr.Star{}), "rows"),
+		expr.Bind(expr.CountNonNull(col), "nonnull"),
+	}
+	// MIN and MAX only consider numbers and
+	// EARLIEST and LATEST only consider timestamps,
+	// so we need both if the results may be either
+	var bounds []string
+	if ft&expr.NumericType != 0 {
+		agg := expr.Min(col)
+		if largest {
+			agg = expr.Max(col)
+		}
+		columns = append(columns, expr.Bind(agg, "bound"))
+		bounds = append(bounds, "bound")
+	}
+	if ft&expr.TimeType != 0 {
+		agg := expr.Earliest(col)
+		if largest {
+			agg = expr.Latest(col)
+		}
+		columns = append(columns, expr.Bind(agg, "time_bound"))
+		bounds = append(bounds, "time_bound")
+	}
+	if len(bounds) == 0 {
+		h.err = errorf(sel, "ordered comparison with ANY or ALL requires a sub-query producing numbers or timestamps")
+		return sel
+	}
+	bt, err := build(h.parent, &expr.Select{
+		Columns: columns,
+		From:    &expr.Table{Binding: expr.Bind(sel, "")},
+	}, h.env)
+	if err != nil {
+		h.err = err
+		return sel
+	}
+	index := expr.Integer(len(h.in))
+	h.in = append(h.in, bt)
+	field := func(f string) expr.Node {
+		return &expr.Dot{Inner: expr.Call(expr.StructReplacement, index), Field: f}
+	}
+	bound := func() expr.Node {
+		if len(bounds) == 1 {
+			return field(bounds[0])
+		}
+		return expr.Coalesce([]expr.Node{field(bounds[0]), field(bounds[1])})
+	}
+	cmp := func() expr.Node {
+		return expr.Compare(op, expr.Copy(x), bound())
+	}
+	empty := expr.Compare(expr.Equals, field("rows"), expr.Integer(0))
+	nulls := expr.Compare(expr.Less, field("nonnull"), field("rows"))
+	if all {
+		// TRUE for no rows; FALSE if any value
+		// fails the comparison; otherwise NULL
+		// if there are any NULLs
+		return &expr.Case{
+			Limbs: []expr.CaseLimb{
+				{When: empty, Then: expr.Bool(true)},
+				{When: &expr.Not{Expr: cmp()}, Then: expr.Bool(false)},
+				{When: nulls, Then: expr.Null{}},
+			},
+			Else: cmp(),
+		}
+	}
+	// FALSE for no rows; TRUE if any value
+	// passes the comparison; otherwise NULL
+	// if there are any NULLs
+	return &expr.Case{
+		Limbs: []expr.CaseLimb{
+			{When: empty, Then: expr.Bool(false)},
+			{When: cmp(), Then: expr.Bool(true)},
+			{When: nulls, Then: expr.Null{}},
+		},
+		Else: cmp(),
+	}
+}
+
 // an SFW expression on either side of a comparison
 // or arithmetic operation must be coerced to a scalar:
 func (h *hoistwalk) rewriteScalarArg(e expr.Node) expr.Node {
@@ -676,10 +858,10 @@ func (w *windowHoist) Rewrite(e expr.Node) expr.Node {
 	if !ok {
 		return e
 	}
-	// if we have COUNT(DISTINCT ...) along with
-	// other aggregates, we can rewrite it to
-	// work more like a window function:
-	if agg.Op == expr.OpCountDistinct &&
+	// if we have COUNT(DISTINCT ...), SUM(DISTINCT ...),
+	// etc. along with other aggregates, we can rewrite
+	// it to work more like a window function:
+	if agg.IsDistinct() &&
 		len(w.outer.GroupBy) > 0 &&
 		!hasOnlyOneAggregate(w.outer) {
 		agg.Over = &expr.Window{
@@ -700,10 +882,15 @@ func (w *windowHoist) Rewrite(e expr.Node) expr.Node {
 
 	partitions := agg.Over.PartitionBy
 	self := copyForWindow(w.outer)
-	if agg.Op == expr.OpCountDistinct {
+	if agg.IsDistinct() {
 		self.GroupBy = append(self.GroupBy, expr.Bind(agg.Inner, "$__distinct"))
-		agg.Op = expr.OpCount
-		agg.Inner = expr.Star{}
+		if agg.Op == expr.OpCountDistinct {
+			agg.Op = expr.OpCount
+			agg.Inner = expr.Star{}
+		} else {
+			agg.Distinct = false
+			agg.Inner = expr.Ident("$__distinct")
+		}
 	}
 	// outerkey is the lookup expression to yield in the outer query
 	outerkey := partitions[0]
@@ -810,24 +997,124 @@ func (b *Trace) hoistWindows(s *expr.Select, e Env) error {
 			return rw.err
 		}
 	}
+	if s.Qualify != nil {
+		s.Qualify = expr.Rewrite(rw, s.Qualify)
+	}
+	return rw.err
+}
+
+// rowNumbers replaces each ROW_NUMBER() OVER (...)
+// in the SELECT list, QUALIFY and ORDER BY clauses of s
+// with a reference to a RowNumber step, provided
+// that s contains no other aggregates
+func (b *Trace) rowNumbers(s *expr.Select) error {
+	var windows []*expr.Aggregate
+	other := false
+	visit := expr.WalkFunc(func(e expr.Node) bool {
+		if other {
+			return false
+		}
+		if _, ok := e.(*expr.Select); ok {
+			return false
+		}
+		agg, ok := e.(*expr.Aggregate)
+		if !ok {
+			return true
+		}
+		if agg.Op != expr.OpRowNumber || agg.Over == nil || agg.Filter != nil {
+			other = true
+			return false
+		}
+		if !slices.ContainsFunc(windows, func(w *expr.Aggregate) bool { return w.Equals(agg) }) {
+			windows = append(windows, agg)
+		}
+		return false
+	})
+	for i := range s.Columns {
+		expr.Walk(visit, s.Columns[i].Expr)
+	}
+	for i := range s.OrderBy {
+		expr.Walk(visit, s.OrderBy[i].Column)
+	}
+	if s.Qualify != nil {
+		expr.Walk(visit, s.Qualify)
+	}
+	if other || len(windows) == 0 {
+		return nil
+	}
+	names := make([]string, len(windows))
+	for i := range windows {
+		names[i] = gensym(4, i)
+		// copy the window since pathwalk rewrites it
+		w := expr.Copy(windows[i]).(*expr.Aggregate).Over
+		err := b.RowNumber(w, names[i])
+		if err != nil {
+			return err
+		}
+	}
+	rw := &rowNumberRewriter{windows: windows, names: names}
+	for i := range s.Columns {
+		s.Columns[i].Expr = expr.Rewrite(rw, s.Columns[i].Expr)
+	}
+	for i := range s.OrderBy {
+		s.OrderBy[i].Column = expr.Rewrite(rw, s.OrderBy[i].Column)
+	}
+	if s.Qualify != nil {
+		s.Qualify = expr.Rewrite(rw, s.Qualify)
+	}
 	return nil
 }
 
+type rowNumberRewriter struct {
+	windows []*expr.Aggregate
+	names   []string
+}
+
+func (r *rowNumberRewriter) Walk(e expr.Node) expr.Rewriter {
+	if _, ok := e.(*expr.Select); ok {
+		return nil
+	}
+	return r
+}
+
+func (r *rowNumberRewriter) Rewrite(e expr.Node) expr.Node {
+	agg, ok := e.(*expr.Aggregate)
+	if !ok {
+		return e
+	}
+	for i := range r.windows {
+		if r.windows[i].Equals(agg) {
+			return expr.Ident(r.names[i])
+		}
+	}
+	return e
+}
+
 func (b *Trace) walkSelect(s *expr.Select, e Env) error {
 	// perform normalizations
 	pickOutputs(s)
+	err := lateral(s)
+	if err != nil {
+		return err
+	}
 	selectall := isselectall(s)
 	s.Columns = flattenBind(s.Columns)
-	err := b.hoistWindows(s, e)
+	err = b.hoistWindows(s, e)
 	if err != nil {
 		return err
 	}
+	if s.Qualify != nil {
+		// QUALIFY may reference the output columns
+		s.Qualify = flattenOne(s.Columns, s.Qualify)
+	}
 	normalizeOrderBy(s)
 	err = aggdistinctpromote(s)
 	if err != nil {
 		return err
 	}
 
+	reorderJoins(s, e)
+
 	// Walk in binding order:
 	// FROM -> WHERE -> (SELECT / GROUP BY / ORDER BY)
 	err = b.walkFrom(s.From, e)
@@ -842,12 +1129,28 @@ func (b *Trace) walkSelect(s *expr.Select, e Env) error {
 		}
 	}
 
+	// ROW_NUMBER() without GROUP BY numbers
+	// the rows themselves rather than groups
+	if s.GroupBy == nil && s.Having == nil {
+		err = b.rowNumbers(s)
+		if err != nil {
+			return err
+		}
+	}
+
 	// if we are doing aggregation anywhere, then split it:
-	if s.Having != nil || s.GroupBy != nil || anyHasAggregate(s.Columns) || anyOrderHasAggregate(s.OrderBy) {
+	if s.Having != nil || s.GroupBy != nil || anyHasAggregate(s.Columns) || anyOrderHasAggregate(s.OrderBy) || (s.Qualify != nil && hasAggregate(s.Qualify)) {
 		// s.OrderBy and s.Columns are rewritten to reference
 		// the generated aggregate expression
-		// (and also HAVING is taken care of)
-		err = b.splitAggregate(s.OrderBy, s.DistinctExpr, s.Columns, s.GroupBy, s.Having)
+		// (and also HAVING and QUALIFY are taken care of)
+		err = b.splitAggregate(s.OrderBy, s.DistinctExpr, s.Columns, s.GroupBy, s.Having, s.Qualify)
+		if err != nil {
+			return err
+		}
+	} else if s.Qualify != nil {
+		// QUALIFY applies to the results
+		// of the RowNumber step(s)
+		err = b.Where(s.Qualify)
 		if err != nil {
 			return err
 		}
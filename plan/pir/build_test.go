@@ -73,6 +73,10 @@ func TestBuildError(t *testing.T) {
 			input: `select x || 'foo' from (select count(x) as x from y)`,
 			rx:    `ill-typed`,
 		},
+		{
+			input: `select count(x) from foo having sum(count(x)) > 0`,
+			rx:    `nested aggregate`,
+		},
 		{
 			// similar to above, but with a CTE
 			input: `with outer AS (select count(x) as x from y) select x || 'foo' from (select x from outer)`,
@@ -183,11 +187,52 @@ func TestBuildError(t *testing.T) {
 			input: `SELECT DISTINCT z, x, AVG(y) AS y FROM table GROUP BY x, AVG(y), z`,
 			rx:    `GROUP BY cannot contain aggregate`,
 		},
+		{
+			input: `SELECT x, y FROM table1 INTERSECT SELECT z FROM table2`,
+			rx:    `same number of columns`,
+		},
+		{
+			input: `SELECT * FROM table1 EXCEPT SELECT z FROM table2`,
+			rx:    `SELECT \* not supported`,
+		},
 		{
 			// regression test: rewriter returned nil
 			input: `SELECT 1 + (SELECT 1 + (SELECT X) FROM table1) FROM table2`,
 			rx:    `path X references an unbound variable`,
 		},
+		{
+			input: `SELECT t.x, s.n FROM t AS t, LATERAL (SELECT COUNT(*) AS n FROM u AS u WHERE u.a < t.x) AS s`,
+			rx:    `must compare t.x for equality`,
+		},
+		{
+			input: `SELECT t.x, s.n FROM t AS t, LATERAL (SELECT COUNT(*) AS n FROM u AS u WHERE u.a = t.x AND u.b = t.y) AS s`,
+			rx:    `may only reference one column`,
+		},
+		{
+			input: `SELECT t.x, s.a FROM t AS t, LATERAL (SELECT u.a FROM u AS u WHERE u.a = t.x) AS s`,
+			rx:    `must either be an aggregate or iterate a path`,
+		},
+		{
+			input: `SELECT t.x, s.n FROM t AS t, LATERAL (SELECT COUNT(*) AS n FROM t.lst AS l) AS s`,
+			rx:    `LATERAL aggregate over t.lst is not supported`,
+		},
+		{
+			input: `SELECT * FROM t AS t, LATERAL (SELECT l.a FROM t.lst AS l) AS s`,
+			rx:    `SELECT \* is not supported`,
+		},
+		{
+			input: `SELECT t.x, s.b FROM t AS t, LATERAL (SELECT l.a FROM t.lst AS l) AS s`,
+			rx:    `has no column "b"`,
+		},
+
+		{
+			input: `SELECT {'a': x} AS x FROM a UNION ALL SELECT CAST(y AS INTEGER) AS x FROM b`,
+			rx:    `UNION ALL column "x" is a structure on the left and a scalar on the right`,
+		},
+		{
+			input: `SELECT [x] AS x FROM a UNION SELECT {'b': y} AS x FROM b`,
+			rx:    `UNION column "x" is a list on the left and a structure on the right`,
+		},
 	}
 	for i := range tests {
 		in := tests[i].input
@@ -240,6 +285,13 @@ func (t *testindex) HasPartition(x string) bool {
 	return slices.Contains(t.parts, x)
 }
 
+func (t *testindex) Deleted() []blockfmt.Tombstone {
+	if t.idx == nil {
+		return nil
+	}
+	return t.idx.Deleted()
+}
+
 func (e *testenv) Schema(expr.Node) expr.Hint {
 	return e.hint
 }
@@ -295,6 +347,74 @@ func TestBuild(t *testing.T) {
 		return basetime.Add(time.Duration(hours) * time.Hour)
 	}
 	tests := []buildTestcase{
+		{
+			// the projections are merged, and the merged
+			// expression must be simplified knowing nothing
+			// about the type of the table column x
+			input: "SELECT CAST(x AS FLOAT) AS y FROM (SELECT CAST(x AS INTEGER) AS x FROM a)",
+			expect: []string{
+				"ITERATE a FIELDS [x]",
+				"PROJECT CAST(CAST(x AS INTEGER) AS FLOAT) AS y",
+			},
+		},
+		{
+			// the INTEGER column is widened to FLOAT,
+			// the mixed-type column is left alone
+			input: "SELECT CAST(x AS INTEGER) AS x, y FROM a UNION SELECT z / 2.0, CAST(w AS STRING) FROM b",
+			expect: []string{
+				"ITERATE a FIELDS [x, y]",
+				"PROJECT CAST(CAST(x AS INTEGER) AS FLOAT) AS x, y AS y",
+				"UNION ALL [x, y] WITH (",
+				"	ITERATE b FIELDS [w, z]",
+				"	PROJECT CAST(z / 2 AS FLOAT) AS x, CAST(w AS STRING) AS y",
+				")",
+				"FILTER DISTINCT [x, y]",
+				"PROJECT x AS x, y AS y",
+			},
+			split: []string{
+				"UNION MAP a (",
+				"	ITERATE PART a FIELDS [x, y]",
+				"	PROJECT CAST(CAST(x AS INTEGER) AS FLOAT) AS x, y AS y)",
+				"UNION ALL [x, y] WITH (",
+				"	UNION MAP b (",
+				"		ITERATE PART b FIELDS [w, z]",
+				"		PROJECT CAST(z / 2 AS FLOAT) AS x, CAST(w AS STRING) AS y)",
+				")",
+				"FILTER DISTINCT [x, y]",
+				"PROJECT x AS x, y AS y",
+			},
+		},
+		{
+			// without ORDER BY, each branch of UNION ALL
+			// needs to produce at most LIMIT+OFFSET rows
+			input: "SELECT x FROM a UNION ALL SELECT y AS x FROM b LIMIT 10 OFFSET 5",
+			expect: []string{
+				"ITERATE a FIELDS [x]",
+				"LIMIT 15",
+				"PROJECT x AS x",
+				"UNION ALL [x] WITH (",
+				"	ITERATE b FIELDS [y]",
+				"	LIMIT 15",
+				"	PROJECT y AS x",
+				")",
+				"LIMIT 10 OFFSET 5",
+				"PROJECT x AS x",
+			},
+		},
+		{
+			input: "SELECT x FROM a UNION ALL SELECT y AS x FROM b ORDER BY x LIMIT 10",
+			expect: []string{
+				"ITERATE a FIELDS [x]",
+				"PROJECT x AS x",
+				"UNION ALL [x] WITH (",
+				"	ITERATE b FIELDS [y]",
+				"	PROJECT y AS x",
+				")",
+				"PROJECT x AS x",
+				"ORDER BY x ASC NULLS FIRST",
+				"LIMIT 10",
+			},
+		},
 		{
 			input: "SELECT cols FROM UNPIVOT (SELECT key FROM input) AT cols GROUP BY cols",
 			expect: []string{
@@ -451,6 +571,19 @@ func TestBuild(t *testing.T) {
 			},
 			results: []expr.TypeSet{stringType, countType},
 		},
+		{
+			input: "select x, count(x) from foo group by x collate ci",
+			expect: []string{
+				"ITERATE foo FIELDS [x]",
+				"AGGREGATE COUNT(x) AS \"count\" BY x COLLATE ci AS x",
+			},
+			split: []string{
+				"UNION MAP foo (",
+				"	ITERATE PART foo FIELDS [x]",
+				"	AGGREGATE COUNT(x) AS $_2_0 BY x COLLATE ci AS x)",
+				"AGGREGATE SUM_COUNT($_2_0) AS \"count\" BY x COLLATE ci AS x",
+			},
+		},
 		{
 			input: `select avg(x), y from foo group by y`,
 			expect: []string{
@@ -767,6 +900,36 @@ where out.Make = 'CHRY' and entry.BodyStyle = 'PA'`,
 				"PROJECT $_0_0 AS c, $_0_1 AS y",
 			},
 		},
+		{
+			// HAVING without GROUP BY filters
+			// the single aggregate row
+			input: `select sum(x) as s from table having sum(x) > 0`,
+			expect: []string{
+				"ITERATE table FIELDS [x]",
+				"AGGREGATE SUM(x) AS $_0_0",
+				"FILTER $_0_0 > 0",
+				"PROJECT $_0_0 AS s",
+			},
+		},
+		{
+			// the aggregate only appears in HAVING
+			input: `select 'x' as r from table having count(*) > 0`,
+			expect: []string{
+				"ITERATE table FIELDS []",
+				"AGGREGATE COUNT(*) AS $_0_0",
+				"FILTER $_0_0 > 0",
+				"PROJECT 'x' AS r",
+			},
+		},
+		{
+			input: `select y from table group by y having count(x) > 1`,
+			expect: []string{
+				"ITERATE table FIELDS [x, y]",
+				"AGGREGATE COUNT(x) AS $_0_0 BY y AS $_0_1",
+				"FILTER $_0_0 > 1",
+				"PROJECT $_0_1 AS y",
+			},
+		},
 		{
 			// composite aggregate expression with
 			// 'having' referencing part of the composite expression
@@ -832,6 +995,73 @@ where out.Make = 'CHRY' and entry.BodyStyle = 'PA'`,
 				"LIMIT 10 OFFSET 64",
 			},
 		},
+		{
+			// without an ORDER BY, each mapping step
+			// only needs LIMIT+OFFSET rows
+			input: `select x from foo where y > 0 limit 10 offset 64`,
+			expect: []string{
+				"ITERATE foo FIELDS [x, y] WHERE y > 0",
+				"LIMIT 10 OFFSET 64",
+				"PROJECT x AS x",
+			},
+			split: []string{
+				"UNION MAP foo (",
+				"	ITERATE PART foo FIELDS [x, y] WHERE y > 0",
+				"	LIMIT 74)",
+				"LIMIT 10 OFFSET 64",
+				"PROJECT x AS x",
+			},
+		},
+		{
+			// ROW_NUMBER without GROUP BY numbers the rows
+			// in the reduction step; the LIMIT cannot be
+			// pushed into the mapping step
+			input: `select x, row_number() over (partition by y order by z desc) as rn from foo where z > 0 limit 10`,
+			expect: []string{
+				"ITERATE foo FIELDS [x, y, z] WHERE z > 0",
+				"ROW_NUMBER PARTITION BY y ORDER BY z DESC NULLS FIRST AS $_4_0",
+				"LIMIT 10",
+				"PROJECT x AS x, $_4_0 AS rn",
+			},
+			split: []string{
+				"UNION MAP foo (",
+				"	ITERATE PART foo FIELDS [x, y, z] WHERE z > 0)",
+				"ROW_NUMBER PARTITION BY y ORDER BY z DESC NULLS FIRST AS $_4_0",
+				"LIMIT 10",
+				"PROJECT x AS x, $_4_0 AS rn",
+			},
+		},
+		{
+			// QUALIFY filters the numbered rows
+			// before the LIMIT and the final projection
+			input: `select x, row_number() over (partition by y order by z desc) as rn from foo qualify rn = 1 limit 10`,
+			expect: []string{
+				"ITERATE foo FIELDS [x, y, z]",
+				"ROW_NUMBER PARTITION BY y ORDER BY z DESC NULLS FIRST AS $_4_0",
+				"FILTER $_4_0 = 1",
+				"LIMIT 10",
+				"PROJECT x AS x, $_4_0 AS rn",
+			},
+			split: []string{
+				"UNION MAP foo (",
+				"	ITERATE PART foo FIELDS [x, y, z])",
+				"ROW_NUMBER PARTITION BY y ORDER BY z DESC NULLS FIRST AS $_4_0",
+				"FILTER $_4_0 = 1",
+				"LIMIT 10",
+				"PROJECT x AS x, $_4_0 AS rn",
+			},
+		},
+		{
+			// with GROUP BY, QUALIFY filters the
+			// window results after the aggregation
+			input: `select x, count(*) as c from foo group by x qualify rank() over (order by count(*) desc) <= 3 and c > 1`,
+			expect: []string{
+				"ITERATE foo FIELDS [x]",
+				"AGGREGATE COUNT(*) AS $_0_1, RANK() OVER (ORDER BY COUNT(*) DESC NULLS FIRST) AS $_0_2 BY x AS $_0_0",
+				"FILTER $_0_2 <= 3 AND $_0_1 > 1",
+				"PROJECT $_0_0 AS x, $_0_1 AS c",
+			},
+		},
 		{
 			// check that a limit after DISTINCT is pushed
 			// into the mapping *and* reduction steps
@@ -955,6 +1185,38 @@ select * from (select c from ccount) where c is not missing`,
 				"PROJECT $_0_0 AS \"count\", `2022-02-22T22:22:22Z` AS \"max\"",
 			},
 		},
+		{
+			// deleted rows are filtered
+			// out of every scan of the table
+			input: `select x from table where y > 0`,
+			index: withTombstones(mkindex(nil), expr.Compare(expr.Equals, expr.Ident("user_id"), expr.Integer(5))),
+			expect: []string{
+				"ITERATE table FIELDS [user_id, x, y] WHERE TOMBSTONE(0, user_id = 5) IS NOT TRUE AND y > 0",
+				"PROJECT x AS x",
+			},
+		},
+		{
+			input: `select t.x from table as t`,
+			index: withTombstones(mkindex(nil),
+				expr.Compare(expr.Equals, expr.Ident("user_id"), expr.Integer(5)),
+				expr.Compare(expr.Less, expr.Ident("age"), expr.Integer(18))),
+			expect: []string{
+				"ITERATE table AS t FIELDS [age, user_id, x] WHERE TOMBSTONE(0, user_id = 5) OR TOMBSTONE(1, age < 18) IS NOT TRUE",
+				"PROJECT x AS x",
+			},
+		},
+		{
+			// the index can't be used to eliminate
+			// aggregates when rows have been deleted
+			input: `select EARLIEST(t.ts), LATEST(t.ts) from table`,
+			index: withTombstones(mkindex([][]blockfmt.Range{{
+				timeRange("t.ts", now(0), now(1)),
+			}}), expr.Compare(expr.Equals, expr.Ident("user_id"), expr.Integer(5))),
+			expect: []string{
+				"ITERATE table FIELDS [t, user_id] WHERE TOMBSTONE(0, user_id = 5) IS NOT TRUE",
+				"AGGREGATE EARLIEST(t.ts) AS \"min\", LATEST(t.ts) AS \"max\"",
+			},
+		},
 		{
 			input: `
 SELECT m, d, h, COUNT(*)
@@ -1023,6 +1285,41 @@ ORDER BY m, d, h`,
 				"PROJECT x AS x, HASH_REPLACEMENT(0, 'scalar', '$_0_0', x) AS z",
 			},
 		},
+		{
+			input: `SELECT f.x, s.n, s.m FROM foo AS f, LATERAL (SELECT COUNT(*) AS n, MAX(b.z) AS m FROM bar AS b WHERE b.y = f.x) AS s`,
+			expect: []string{
+				"WITH (",
+				"	ITERATE bar AS b FIELDS [y, z]",
+				"	AGGREGATE COUNT(*) AS n, MAX(z) AS m BY y AS $_0_0",
+				") AS REPLACEMENT(0)",
+				"ITERATE foo AS f FIELDS [x]",
+				"PROJECT x AS x, CASE WHEN HASH_REPLACEMENT(0, 'struct', '$_0_0', x).n IS NOT NULL THEN HASH_REPLACEMENT(0, 'struct', '$_0_0', x).n ELSE 0 END AS n, CASE WHEN HASH_REPLACEMENT(0, 'struct', '$_0_0', x).m IS NOT NULL THEN HASH_REPLACEMENT(0, 'struct', '$_0_0', x).m ELSE NULL END AS m",
+			},
+		},
+		{
+			// without LATERAL, the sub-query
+			// cannot reference f, so f.x is
+			// a path in bar
+			input: `SELECT f.x, s.n FROM foo AS f, (SELECT COUNT(*) AS n FROM bar AS b WHERE b.y = f.x) AS s`,
+			expect: []string{
+				"WITH (",
+				"	ITERATE bar AS b FIELDS [f, y] WHERE y = f.x",
+				"	AGGREGATE COUNT(*) AS n",
+				") AS REPLACEMENT(0)",
+				"ITERATE foo AS f FIELDS [x]",
+				"ITERATE FIELD SCALAR_REPLACEMENT(0) AS s",
+				"PROJECT x AS x, s.n AS n",
+			},
+		},
+		{
+			input: `SELECT f.x, s.v FROM foo AS f, LATERAL (SELECT l.a + f.x AS v FROM f.lst AS l WHERE l.b > 0) AS s`,
+			expect: []string{
+				"ITERATE foo AS f FIELDS [lst, x]",
+				"ITERATE FIELD lst AS l",
+				"FILTER l.b > 0",
+				"PROJECT x AS x, l.a + x AS v",
+			},
+		},
 		{
 			input: `select x, (select a, b, c from bar where x = y limit 1) as z from foo`,
 			expect: []string{
@@ -1125,12 +1422,68 @@ ORDER BY m, d, h`,
 				"PROJECT x AS x, !(IN_REPLACEMENT(x, 0)) AS no_other",
 			},
 		},
+		{
+			// = ANY is evaluated against the sub-query results
+			input: `SELECT x FROM input WHERE x = ANY (SELECT key FROM other LIMIT 5)`,
+			expect: []string{
+				"WITH (",
+				"	ITERATE other FIELDS [key]",
+				"	LIMIT 5",
+				"	PROJECT key AS key",
+				") AS REPLACEMENT(0)",
+				"ITERATE input FIELDS [x] WHERE ANY_REPLACEMENT(0, x, 0)",
+				"PROJECT x AS x",
+			},
+		},
+		{
+			// <> ALL is evaluated against the sub-query results
+			input: `SELECT x FROM input WHERE x <> ALL (SELECT DISTINCT key FROM other)`,
+			expect: []string{
+				"WITH (",
+				"	ITERATE other FIELDS [key]",
+				"	FILTER DISTINCT [key]",
+				"	PROJECT key AS key",
+				") AS REPLACEMENT(0)",
+				"ITERATE input FIELDS [x] WHERE ALL_REPLACEMENT(1, x, 0)",
+				"PROJECT x AS x",
+			},
+		},
+		{
+			// > ALL is a comparison against MAX (or LATEST)
+			input: `SELECT x FROM input WHERE x > ALL (SELECT key FROM other)`,
+			expect: []string{
+				"WITH (",
+				"	ITERATE other FIELDS [key]",
+				"	AGGREGATE COUNT(*) AS rows, SUM_INT(CASE WHEN key IS NOT NULL THEN 1 ELSE 0 END) AS nonnull, MAX(key) AS bound, LATEST(key) AS time_bound",
+				") AS REPLACEMENT(0)",
+				"ITERATE input FIELDS [x] WHERE CASE WHEN STRUCT_REPLACEMENT(0).rows = 0 THEN TRUE" +
+					" WHEN !(CASE WHEN STRUCT_REPLACEMENT(0).bound IS NOT NULL THEN x > STRUCT_REPLACEMENT(0).bound" +
+					" WHEN STRUCT_REPLACEMENT(0).time_bound IS NOT NULL THEN x > STRUCT_REPLACEMENT(0).time_bound ELSE MISSING END) THEN FALSE" +
+					" WHEN STRUCT_REPLACEMENT(0).nonnull < STRUCT_REPLACEMENT(0).rows THEN NULL" +
+					" ELSE CASE WHEN STRUCT_REPLACEMENT(0).bound IS NOT NULL THEN x > STRUCT_REPLACEMENT(0).bound" +
+					" WHEN STRUCT_REPLACEMENT(0).time_bound IS NOT NULL THEN x > STRUCT_REPLACEMENT(0).time_bound ELSE MISSING END END",
+				"PROJECT x AS x",
+			},
+		},
 		{
 			input: `SELECT y, z FROM table GROUP BY x+1 AS y, z`,
 			expect: []string{
 				"ITERATE table FIELDS [x, z]",
-				"FILTER DISTINCT [x + 1, z]",
 				"PROJECT x + 1 AS y, z AS z",
+				"FILTER DISTINCT [y, z]",
+				"PROJECT y AS y, z AS z",
+			},
+		},
+		{
+			// the grouping expression is evaluated once
+			// and shared between the key and the output;
+			// HAVING on the key is pushed into the WHERE
+			input: "SELECT DATE_TRUNC(DAY, ts) AS d FROM table GROUP BY DATE_TRUNC(DAY, ts) HAVING DATE_TRUNC(DAY, ts) > `2022-01-01T00:00:00Z`",
+			expect: []string{
+				"ITERATE table FIELDS [ts] WHERE DATE_TRUNC_DAY(ts) > `2022-01-01T00:00:00Z`",
+				"PROJECT DATE_TRUNC_DAY(ts) AS $_0_0",
+				"FILTER DISTINCT [$_0_0]",
+				"PROJECT $_0_0 AS d",
 			},
 		},
 		{
@@ -1151,6 +1504,26 @@ ORDER BY m, d, h`,
 				"PROJECT $_0_0 AS grp0",
 			},
 		},
+		{
+			// the sort key is computed by the ORDER BY
+			// itself, so it is not part of the output
+			input: `SELECT id FROM table ORDER BY UPPER(name), id DESC LIMIT 10`,
+			expect: []string{
+				"ITERATE table FIELDS [id, name]",
+				"ORDER BY UPPER(name) ASC NULLS FIRST, id DESC NULLS FIRST",
+				"LIMIT 10",
+				"PROJECT id AS id",
+			},
+			split: []string{
+				"UNION MAP table (",
+				"	ITERATE PART table FIELDS [id, name]",
+				"	ORDER BY UPPER(name) ASC NULLS FIRST, id DESC NULLS FIRST",
+				"	LIMIT 10)",
+				"ORDER BY UPPER(name) ASC NULLS FIRST, id DESC NULLS FIRST",
+				"LIMIT 10",
+				"PROJECT id AS id",
+			},
+		},
 		{
 			input: `select x, COUNT(y) OVER (PARTITION BY z) AS wind FROM foo`,
 			expect: []string{
@@ -1274,6 +1647,44 @@ ORDER BY m, d, h`,
 				"PROJECT $_0_0 AS group0, $_0_1 AS group1, HASH_REPLACEMENT(0, 'scalar', '$__key', [$_0_0, $_0_1], 0) AS dist, $_0_2 AS sx",
 			},
 		},
+		{
+			input: `SELECT SUM(DISTINCT x) AS s, AVG(DISTINCT x) AS a FROM input`,
+			expect: []string{
+				"ITERATE input FIELDS [x]",
+				"FILTER DISTINCT [x]",
+				"AGGREGATE SUM(x) AS s, AVG(x) AS a",
+			},
+		},
+		{
+			input: `SELECT g, SUM(DISTINCT x) AS s, COUNT(*) AS c FROM input GROUP BY g`,
+			expect: []string{
+				"WITH (",
+				"	ITERATE input FIELDS [g, x]",
+				"	FILTER DISTINCT [g, x]",
+				"	AGGREGATE SUM(x) AS $__val BY g AS $__key",
+				") AS REPLACEMENT(0)",
+				"ITERATE input FIELDS [g]",
+				"AGGREGATE COUNT(*) AS $_0_1 BY g AS $_0_0",
+				"PROJECT $_0_0 AS g, HASH_REPLACEMENT(0, 'scalar', '$__key', $_0_0, NULL) AS s, $_0_1 AS c",
+			},
+		},
+		{
+			input: `SELECT SUM(DISTINCT x) AS sx, SUM(DISTINCT y) AS sy FROM input`,
+			expect: []string{
+				"WITH (",
+				"	ITERATE input FIELDS [x]",
+				"	FILTER DISTINCT [x]",
+				"	AGGREGATE SUM(x) AS \"sum\"",
+				") AS REPLACEMENT(0)",
+				"WITH (",
+				"	ITERATE input FIELDS [y]",
+				"	FILTER DISTINCT [y]",
+				"	AGGREGATE SUM(y) AS \"sum\"",
+				") AS REPLACEMENT(1)",
+				"ITERATE input FIELDS []",
+				"PROJECT SCALAR_REPLACEMENT(0) AS sx, SCALAR_REPLACEMENT(1) AS sy",
+			},
+		},
 		{
 			// test that duplicate inputs
 			// are removed and replaced
@@ -1379,6 +1790,38 @@ z = (SELECT a FROM bar LIMIT 1)`,
 				"PROJECT a AS a, b AS b, c AS c",
 			},
 		},
+		{
+			// '*' mixed with computed columns
+			input: `SELECT *, a + b AS c FROM tbl WHERE d = 3`,
+			expect: []string{
+				"ITERATE tbl FIELDS * WHERE d = 3",
+				"PROJECT *, a + b AS c",
+			},
+		},
+		{
+			// the projection with '*' is merged into the outer one
+			input: `SELECT x, c FROM (SELECT *, a + b AS c FROM tbl)`,
+			expect: []string{
+				"ITERATE tbl FIELDS [a, b, x]",
+				"PROJECT x AS x, a + b AS c",
+			},
+		},
+		{
+			// fields passed through '*' are
+			// referenced via the pseudo-table
+			input: `SELECT f.x, f.c FROM (SELECT *, t.a + 1 AS c FROM tbl AS t) f`,
+			expect: []string{
+				"ITERATE tbl AS t FIELDS [a, x]",
+				"PROJECT x AS x, a + 1 AS c",
+			},
+		},
+		{
+			input: `SELECT f.x FROM (SELECT * FROM tbl AS t) f`,
+			expect: []string{
+				"ITERATE tbl AS t FIELDS [x]",
+				"PROJECT x AS x",
+			},
+		},
 		{
 			// full GROUP BY elimination on a partition
 			input: `SELECT SUM(x), COUNT(y), z FROM tbl GROUP BY z`,
@@ -1574,6 +2017,24 @@ GROUP BY a.grp
 				"PROJECT CASE WHEN A IS NOT NULL THEN A WHEN X IS NOT NULL THEN X ELSE NULL END AS X, CASE WHEN A IS NOT NULL THEN A WHEN X IS NOT NULL THEN X ELSE MISSING END < CASE WHEN A IS NOT NULL THEN A WHEN X IS NOT NULL THEN X ELSE MISSING END < CASE WHEN A IS NOT NULL THEN A WHEN X IS NOT NULL THEN X ELSE MISSING END AS _2",
 			},
 		},
+		{
+			// '++' is UNION ALL of its tables: the limit is
+			// applied to each part of the union (as LIMIT+OFFSET)
+			// and then re-applied to the merged result
+			input: "SELECT x FROM a ++ b ++ c WHERE y > 0 LIMIT 10 OFFSET 5",
+			expect: []string{
+				"ITERATE (a ++ b ++ c) FIELDS [x, y] WHERE y > 0",
+				"LIMIT 10 OFFSET 5",
+				"PROJECT x AS x",
+			},
+			split: []string{
+				"UNION MAP (a ++ b ++ c) (",
+				"	ITERATE PART (a ++ b ++ c) FIELDS [x, y] WHERE y > 0",
+				"	LIMIT 15)",
+				"LIMIT 10 OFFSET 5",
+				"PROJECT x AS x",
+			},
+		},
 	}
 
 	for i := range tests {
@@ -1696,6 +2157,15 @@ func mkindex(rs [][]blockfmt.Range) *blockfmt.Index {
 	}
 }
 
+// withTombstones adds a tombstone
+// to idx for each of the predicates
+func withTombstones(idx *blockfmt.Index, preds ...expr.Node) *blockfmt.Index {
+	for i := range preds {
+		idx.Tombstones = append(idx.Tombstones, blockfmt.Tombstone{Where: preds[i]})
+	}
+	return idx
+}
+
 func timeRange(path string, min, max date.Time) blockfmt.Range {
 	p := strings.Split(path, ".")
 	return blockfmt.NewRange(p, ion.Timestamp(min), ion.Timestamp(max))
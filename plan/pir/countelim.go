@@ -19,42 +19,61 @@ import (
 	"github.com/SnellerInc/sneller/vm"
 )
 
-// walk a set of expressions and see if we can
-// match the index of a single aggregate which is
-// a COUNT(DISTINCT ...) aggregate
+// walk a set of expressions and see if every
+// aggregate is a DISTINCT aggregate
+// (COUNT(DISTINCT ...), SUM(DISTINCT ...), etc.)
+// of the same expression without a FILTER
 //
-// returns (index, true) if there is exactly one match,
-// or (-1, false) if there are no matches, more than one match,
-// or there are other aggregate expressions present
-func singleCountDistinct(agg vm.Aggregation) (int, bool) {
-	idx := -1
+// returns (inner, true) if all of the aggregates match,
+// or (nil, false) if there are no aggregates or any
+// aggregate does not match
+func singleDistinct(agg vm.Aggregation) (expr.Node, bool) {
+	var inner expr.Node
 	for i := range agg {
-		if agg[i].Expr.Op != expr.OpCountDistinct || idx != -1 {
-			return -1, false
+		e := agg[i].Expr
+		if !e.IsDistinct() || e.Filter != nil {
+			return nil, false
+		}
+		if inner == nil {
+			inner = e.Inner
+		} else if !expr.Equivalent(inner, e.Inner) {
+			return nil, false
 		}
-		idx = i
 	}
-	return idx, idx >= 0
+	return inner, inner != nil
 }
 
-// convert SELECT COUNT(DISTINCT x), y...
-// into SELECT COUNT(x), y... FROM (SELECT DISTINCT x, y...)
-// since we do not natively support COUNT DISTINCT
+// convert SELECT COUNT(DISTINCT x), SUM(DISTINCT x), y...
+// into SELECT COUNT(x), SUM(x), y... FROM (SELECT DISTINCT x, y...)
+// since we do not natively support DISTINCT aggregates
+//
+// The distinct values are materialized by the
+// Distinct step, so the memory needed is proportional
+// to the number of distinct (x, y...) tuples;
+// when the query is split, each partial query
+// ships its distinct tuples to the final query,
+// which removes the duplicates across partitions.
 func countdistinct2count(b *Trace) {
 	for s := b.top; s != nil; s = s.parent() {
 		a, ok := s.(*Aggregate)
 		if !ok {
 			continue
 		}
-		idx, ok := singleCountDistinct(a.Agg)
+		inner, ok := singleDistinct(a.Agg)
 		if !ok {
 			continue
 		}
-		// rewrite CountDistinct -> Count
-		cd := a.Agg[idx].Expr
-		cd.Op = expr.OpCount
+		// rewrite the DISTINCT aggregates
+		// into ordinary aggregates
+		for i := range a.Agg {
+			e := a.Agg[i].Expr
+			if e.Op == expr.OpCountDistinct {
+				e.Op = expr.OpCount
+			}
+			e.Distinct = false
+		}
 		distinct := &Distinct{
-			Columns: []expr.Node{cd.Inner},
+			Columns: []expr.Node{inner},
 		}
 		// make the other columns distinct as well
 		for i := range a.GroupBy {
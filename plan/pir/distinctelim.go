@@ -35,6 +35,23 @@ func isSubset(a, b []expr.Node) bool {
 	return true
 }
 
+// distinctPaths returns whether the columns of d
+// are still paths when the bindings of b are
+// substituted into them
+func distinctPaths(b *Bind, d *Distinct) bool {
+	cols := make([]expr.Node, len(d.Columns))
+	for i := range cols {
+		cols[i] = expr.Copy(d.Columns[i])
+	}
+	flattenIntoExprs(b.bind, cols)
+	for i := range cols {
+		if !expr.IsPath(cols[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // AGGREGATE ... GROUP BY cols... -> FILTER DISTINCT cols... is redundant;
 // we can eliminate the FILTER DISTINCT step
 //
@@ -54,8 +71,10 @@ outer:
 		}
 		par := d.parent()
 
-		// if we have BIND -> DISTINCT, then rearrange:
-		if sel, ok := par.(*Bind); ok {
+		// if we have BIND -> DISTINCT, then rearrange,
+		// unless that would mean computing the same
+		// expression in both the DISTINCT and the BIND:
+		if sel, ok := par.(*Bind); ok && distinctPaths(sel, d) {
 			flattenIntoExprs(sel.bind, d.Columns)
 			d.setparent(sel.parent())
 			sel.setparent(d)
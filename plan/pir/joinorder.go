@@ -0,0 +1,317 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package pir
+
+import (
+	"slices"
+
+	"github.com/SnellerInc/sneller/expr"
+)
+
+// The first table of a chain of inner joins is
+// scanned and the other tables are each built into
+// a hash table that the scanned rows are looked up in,
+// so the tables should be joined with the largest one
+// first (since it is never held in memory) and with
+// the joins that produce the fewest rows before the
+// others (since every intermediate row is looked up
+// in the following hash tables).
+//
+// reorderJoins picks such an order greedily using the
+// statistics of the tables (see StatsIndex): it starts
+// with the table with the most rows and then repeatedly
+// adds the table whose join with the tables joined so far
+// is estimated to produce the fewest rows. The written
+// order is kept if any of the tables has no statistics.
+
+// joinEdge is the ON condition of one of the
+// inner joins, which joins tables[a] and tables[b]
+type joinEdge struct {
+	a, b int
+	on   expr.Node
+	// ndv is the estimated number
+	// of distinct values of the key
+	ndv float64
+}
+
+// reorderJoins sets s.From to the inner joins of
+// s.From in the order described above, if s.From
+// is a chain of inner joins whose order can be changed
+func reorderJoins(s *expr.Select, env Env) {
+	j, ok := s.From.(*expr.Join)
+	if !ok || env == nil || isselectall(s) {
+		return
+	}
+	tables, on, ok := flattenJoins(j)
+	if !ok {
+		return
+	}
+	names := make([]string, len(tables))
+	for i := range tables {
+		names[i] = tables[i].Result()
+		if names[i] == "" || slices.Contains(names[:i], names[i]) {
+			return
+		}
+	}
+	rows := make([]float64, len(tables))
+	stats := make([]StatsIndex, len(tables))
+	for i := range tables {
+		if _, ok := tables[i].Expr.(*expr.Select); ok {
+			return
+		}
+		idx, err := env.Index(tables[i].Expr)
+		if err != nil || idx == nil {
+			return
+		}
+		si, ok := idx.(StatsIndex)
+		if !ok {
+			return
+		}
+		n, ok := si.Rows()
+		if !ok || n <= 0 {
+			return
+		}
+		rows[i] = float64(n)
+		stats[i] = si
+	}
+	edges := make([]joinEdge, len(on))
+	for i := range on {
+		e, ok := joinEdgeOf(on[i], names, stats, rows)
+		if !ok {
+			return
+		}
+		edges[i] = e
+	}
+	order, used, ok := joinOrder(rows, edges)
+	if !ok {
+		return
+	}
+	// edge i joins table i+1 in the written order
+	written := true
+	for i := range order {
+		if order[i] != i || (i > 0 && used[i-1] != i-1) {
+			written = false
+			break
+		}
+	}
+	if written {
+		return
+	}
+	// a joined table has to be referenced
+	// other than by its own join condition,
+	// or else the join cannot be performed;
+	// the scanned table has no such restriction
+	for i := range used {
+		if !referenced(s, edges, used[i], names[order[i+1]]) {
+			return
+		}
+	}
+	var from expr.From = &expr.Table{Binding: tables[order[0]]}
+	for i := range used {
+		from = &expr.Join{
+			Kind:  expr.InnerJoin,
+			Left:  from,
+			Right: tables[order[i+1]],
+			On:    edges[used[i]].on,
+		}
+	}
+	s.From = from
+}
+
+// flattenJoins returns the tables and the ON conditions
+// of the inner joins of f in the written order, or false
+// if f is not a chain of inner joins of tables
+func flattenJoins(f *expr.Join) ([]expr.Binding, []expr.Node, bool) {
+	var tables []expr.Binding
+	var on []expr.Node
+	for {
+		// the columns of USING are resolved in
+		// the left-most table, so they are kept as-is
+		if f.Kind != expr.InnerJoin || f.On == nil || len(f.Using) > 0 {
+			return nil, nil, false
+		}
+		tables = append(tables, f.Right)
+		on = append(on, f.On)
+		switch l := f.Left.(type) {
+		case *expr.Join:
+			f = l
+		case *expr.Table:
+			tables = append(tables, l.Binding)
+			slices.Reverse(tables)
+			slices.Reverse(on)
+			return tables, on, true
+		default:
+			return nil, nil, false
+		}
+	}
+}
+
+// referenced returns whether a field of the table
+// name is referenced in s or in the ON condition
+// of one of the edges other than edges[skip]
+func referenced(s *expr.Select, edges []joinEdge, skip int, name string) bool {
+	found := false
+	visit := expr.WalkFunc(func(e expr.Node) bool {
+		if found {
+			return false
+		}
+		if d, ok := e.(*expr.Dot); ok {
+			found = d.Inner == expr.Ident(name)
+			return false
+		}
+		return true
+	})
+	walk := func(e expr.Node) {
+		if e != nil {
+			expr.Walk(visit, e)
+		}
+	}
+	for i := range s.Columns {
+		walk(s.Columns[i].Expr)
+	}
+	for i := range s.OrderBy {
+		walk(s.OrderBy[i].Column)
+	}
+	for i := range s.GroupBy {
+		walk(s.GroupBy[i].Expr)
+	}
+	for i := range s.DistinctExpr {
+		walk(s.DistinctExpr[i])
+	}
+	walk(s.Where)
+	walk(s.Having)
+	walk(s.Qualify)
+	for i := range edges {
+		if i != skip {
+			walk(edges[i].on)
+		}
+	}
+	return found
+}
+
+// joinEdgeOf returns the joinEdge of the ON condition
+// on, which must be a conjunction of equalities of
+// an expression of one table with an expression of
+// another table (the same two tables for every equality)
+func joinEdgeOf(on expr.Node, names []string, stats []StatsIndex, rows []float64) (joinEdge, bool) {
+	e := joinEdge{a: -1, b: -1, on: on}
+	nda, ndb := 1.0, 1.0
+	for _, c := range conjunctions(on, nil) {
+		cmp, ok := c.(*expr.Comparison)
+		if !ok || cmp.Op != expr.Equals {
+			return e, false
+		}
+		l, r := tableOf(cmp.Left, names), tableOf(cmp.Right, names)
+		if l < 0 || r < 0 || l == r {
+			return e, false
+		}
+		if e.a < 0 {
+			e.a, e.b = l, r
+		}
+		switch {
+		case l == e.a && r == e.b:
+		case l == e.b && r == e.a:
+			l, r = r, l
+			cmp = &expr.Comparison{Op: expr.Equals, Left: cmp.Right, Right: cmp.Left}
+		default:
+			return e, false
+		}
+		nda *= distinct(cmp.Left, stats[l], rows[l])
+		ndb *= distinct(cmp.Right, stats[r], rows[r])
+	}
+	// the rows that join with one another
+	// have keys that are present on both sides,
+	// and the keys of the side with fewer distinct
+	// keys are assumed to be present on the other
+	e.ndv = max(min(nda, rows[e.a]), min(ndb, rows[e.b]))
+	return e, true
+}
+
+// tableOf returns the index of the only table
+// referenced by e, or -1 if there isn't one
+func tableOf(e expr.Node, names []string) int {
+	for i := range names {
+		if onlyReferences(e, names[i]) && !doesNotReference(e, names[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// distinct returns the estimated number of
+// distinct values of the key e of a table;
+// without statistics, the key is assumed to be unique
+func distinct(e expr.Node, si StatsIndex, rows float64) float64 {
+	path, ok := expr.FlatPath(e)
+	if !ok || len(path) < 2 {
+		return rows
+	}
+	n, ok := si.Distinct(path[1:])
+	if !ok || n <= 0 {
+		return rows
+	}
+	return float64(n)
+}
+
+// joinOrder returns the order of the tables and
+// the edges used to join each table after the
+// first one, or false if the tables are not connected
+func joinOrder(rows []float64, edges []joinEdge) (order, used []int, ok bool) {
+	joined := make([]bool, len(rows))
+	first := 0
+	for i := range rows {
+		if rows[i] > rows[first] {
+			first = i
+		}
+	}
+	joined[first] = true
+	order = append(order, first)
+	size := rows[first]
+	for len(order) < len(rows) {
+		best, bestsize := -1, 0.0
+		for i := range edges {
+			e := &edges[i]
+			if joined[e.a] == joined[e.b] {
+				continue
+			}
+			t := e.a
+			if joined[t] {
+				t = e.b
+			}
+			est := size * rows[t] / e.ndv
+			if best < 0 || est < bestsize || (est == bestsize && t < other(&edges[best], joined)) {
+				best, bestsize = i, est
+			}
+		}
+		if best < 0 {
+			return nil, nil, false
+		}
+		t := other(&edges[best], joined)
+		joined[t] = true
+		order = append(order, t)
+		used = append(used, best)
+		size = bestsize
+	}
+	return order, used, true
+}
+
+// other returns the table of e
+// that has not been joined yet
+func other(e *joinEdge, joined []bool) int {
+	if joined[e.a] {
+		return e.b
+	}
+	return e.a
+}
@@ -0,0 +1,185 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package pir
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/date"
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/expr/partiql"
+	"github.com/SnellerInc/sneller/tests"
+)
+
+// statsindex is a StatsIndex with the
+// given number of rows and distinct values
+type statsindex struct {
+	rows     int64
+	distinct map[string]int64
+}
+
+func (s *statsindex) TimeRange([]string) (min, max date.Time, ok bool) { return }
+func (s *statsindex) HasPartition(string) bool                         { return false }
+func (s *statsindex) Rows() (int64, bool)                              { return s.rows, true }
+
+func (s *statsindex) Distinct(path []string) (int64, bool) {
+	n, ok := s.distinct[strings.Join(path, ".")]
+	return n, ok
+}
+
+type statsenv map[string]Index
+
+func (s statsenv) Schema(expr.Node) expr.Hint { return nil }
+
+func (s statsenv) Index(tbl expr.Node) (Index, error) {
+	id, ok := tbl.(expr.Ident)
+	if !ok {
+		return nil, nil
+	}
+	return s[string(id)], nil
+}
+
+func TestJoinOrder(t *testing.T) {
+	env := statsenv{
+		"big":    &statsindex{rows: 1000000, distinct: map[string]int64{"cust": 5000, "prod": 100}},
+		"cust":   &statsindex{rows: 5000, distinct: map[string]int64{"id": 5000, "region": 10}},
+		"prod":   &statsindex{rows: 10, distinct: map[string]int64{"id": 10}},
+		"region": &statsindex{rows: 10, distinct: map[string]int64{"id": 10}},
+		"nostat": &testindex{},
+	}
+	testcases := []struct {
+		input  string
+		expect []string
+	}{
+		{
+			// the large table is scanned
+			// rather than held in memory
+			input: "SELECT c.name, b.total FROM cust c JOIN big b ON c.id = b.cust",
+			expect: []string{
+				"WITH (",
+				"	ITERATE cust AS c FIELDS [id, name]",
+				"	PROJECT id AS $__key, [name] AS $__val",
+				") AS REPLACEMENT(0)",
+				"ITERATE big AS b FIELDS [cust, total]",
+				"ITERATE FIELD HASH_REPLACEMENT(0, 'joinlist', '$__key', cust) AS c",
+				"PROJECT c[0] AS name, total AS total",
+			},
+		},
+		{
+			// already in the right order
+			input: "SELECT c.name, b.total FROM big b JOIN cust c ON c.id = b.cust",
+			expect: []string{
+				"WITH (",
+				"	ITERATE cust AS c FIELDS [id, name]",
+				"	PROJECT id AS $__key, [name] AS $__val",
+				") AS REPLACEMENT(0)",
+				"ITERATE big AS b FIELDS [cust, total]",
+				"ITERATE FIELD HASH_REPLACEMENT(0, 'joinlist', '$__key', cust) AS c",
+				"PROJECT c[0] AS name, total AS total",
+			},
+		},
+		{
+			// only some rows of big have a matching
+			// row in prod, so prod is joined first;
+			// region can only be joined after cust
+			input: "SELECT r.name, p.name, b.total FROM region r JOIN cust c ON r.id = c.region JOIN big b ON c.id = b.cust JOIN prod p ON p.id = b.prod",
+			expect: []string{
+				"WITH (",
+				"	ITERATE region AS r FIELDS [id, name]",
+				"	PROJECT id AS $__key, [name] AS $__val",
+				") AS REPLACEMENT(0)",
+				"WITH (",
+				"	ITERATE prod AS p FIELDS [id, name]",
+				"	PROJECT id AS $__key, [name] AS $__val",
+				") AS REPLACEMENT(1)",
+				"WITH (",
+				"	ITERATE cust AS c FIELDS [id, region]",
+				"	PROJECT id AS $__key, [region] AS $__val",
+				") AS REPLACEMENT(2)",
+				"ITERATE big AS b FIELDS [cust, prod, total]",
+				"ITERATE FIELD HASH_REPLACEMENT(1, 'joinlist', '$__key', prod) AS p",
+				"ITERATE FIELD HASH_REPLACEMENT(2, 'joinlist', '$__key', cust) AS c",
+				"ITERATE FIELD HASH_REPLACEMENT(0, 'joinlist', '$__key', c[0]) AS r",
+				"PROJECT r[0] AS name, p[0] AS name_2, total AS total",
+			},
+		},
+		{
+			// no statistics: the written order is kept
+			input: "SELECT n.x, b.total FROM nostat n JOIN big b ON n.id = b.cust",
+			expect: []string{
+				"WITH (",
+				"	ITERATE big AS b FIELDS [cust, total]",
+				"	PROJECT cust AS $__key, [total] AS $__val",
+				") AS REPLACEMENT(0)",
+				"ITERATE nostat AS n FIELDS [id, x]",
+				"ITERATE FIELD HASH_REPLACEMENT(0, 'joinlist', '$__key', id) AS b",
+				"PROJECT x AS x, b[0] AS total",
+			},
+		},
+		{
+			// keys that are expressions
+			input: "SELECT c.name, b.total FROM cust c JOIN big b ON c.id = b.cust + 1",
+			expect: []string{
+				"WITH (",
+				"	ITERATE cust AS c FIELDS [id, name]",
+				"	PROJECT id AS $__key, [name] AS $__val",
+				") AS REPLACEMENT(0)",
+				"ITERATE big AS b FIELDS [cust, total]",
+				"ITERATE FIELD HASH_REPLACEMENT(0, 'joinlist', '$__key', cust + 1) AS c",
+				"PROJECT c[0] AS name, total AS total",
+			},
+		},
+		{
+			// c is only referenced by the join condition,
+			// so it has to stay the scanned table
+			input: "SELECT COUNT(*), SUM(b.total) FROM cust c JOIN big b ON c.id = b.cust",
+			expect: []string{
+				"WITH (",
+				"	ITERATE big AS b FIELDS [cust, total]",
+				"	PROJECT cust AS $__key, [total] AS $__val",
+				") AS REPLACEMENT(0)",
+				"ITERATE cust AS c FIELDS [id]",
+				"ITERATE FIELD HASH_REPLACEMENT(0, 'joinlist', '$__key', id) AS b",
+				"AGGREGATE COUNT(*) AS \"count\", SUM(b[0]) AS \"sum\"",
+			},
+		},
+	}
+	for i := range testcases {
+		tc := &testcases[i]
+		q, err := partiql.Parse([]byte(tc.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Build(q, env)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.input, err)
+		}
+		b = NoSplit(b)
+		var out strings.Builder
+		b.Describe(&out)
+		got := out.String()
+		want := strings.Join(tc.expect, "\n") + "\n"
+		if got != want {
+			t.Errorf("query: %s", tc.input)
+			diff, ok := tests.Diff(want, got)
+			if ok {
+				t.Error("\n" + diff)
+			} else {
+				t.Errorf("got %s", got)
+			}
+		}
+	}
+}
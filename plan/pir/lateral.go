@@ -0,0 +1,393 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package pir
+
+import (
+	"github.com/SnellerInc/sneller/expr"
+)
+
+const (
+	lateralKey    = "$__lateral_key"
+	lateralResult = "$__lateral"
+)
+
+// lateral rewrites a sub-query joined to a table
+// that references the table, as in
+//
+//	SELECT ... FROM t AS t, LATERAL (SELECT ... t.x ...) AS s
+//
+// into an equivalent query without a dependent join.
+// Only a sub-query marked LATERAL (see expr.Join.Lateral)
+// may reference the tables on the left-hand side.
+//
+// Two shapes of sub-queries are supported:
+//
+//   - A sub-query that filters and projects a path
+//     rooted at the table is equivalent to iterating
+//     that path directly:
+//
+//     FROM t AS t, LATERAL (SELECT x.y AS y FROM t.lst AS x) AS s
+//     => FROM t AS t, t.lst AS x (with s.y => x.y)
+//
+//   - An aggregate sub-query without GROUP BY that
+//     compares one column of the table for equality
+//     is computed as a correlated sub-query:
+//
+//     FROM t AS t, LATERAL (SELECT COUNT(*) AS n FROM u AS u WHERE u.k = t.k) AS s
+//     => FROM (SELECT *, t.k AS $__lateral_key, (SELECT ... WHERE u.k = $__lateral_key) AS $__lateral FROM t AS t) AS t
+//
+// Sub-queries that do not reference the
+// table on the left-hand side are left alone.
+func lateral(s *expr.Select) error {
+	j, ok := s.From.(*expr.Join)
+	if !ok || !j.Lateral {
+		return nil
+	}
+	sub, ok := j.Right.Expr.(*expr.Select)
+	if !ok {
+		return nil
+	}
+	tables := j.Left.Tables()
+	for i := range tables {
+		name := tables[i].Result()
+		if name == "" || shadows(sub, name) {
+			continue
+		}
+		refs, err := outerRefs(sub, name)
+		if err != nil {
+			return err
+		}
+		if len(refs) == 0 {
+			continue
+		}
+		left, ok := j.Left.(*expr.Table)
+		if !ok {
+			return errorf(sub, "LATERAL sub-query may only be joined to a single table")
+		}
+		if j.Right.Result() == "" {
+			return errorf(sub, "LATERAL sub-query requires an alias")
+		}
+		pickOutputs(sub)
+		if from, ok := sub.From.(*expr.Table); ok && rootedAt(from.Expr, name) {
+			return lateralIterate(s, left, j.Right.Result(), sub)
+		}
+		return lateralAggregate(s, left, j.Right.Result(), sub, refs)
+	}
+	return nil
+}
+
+// lateralIterate performs the rewrite
+//
+//	FROM t, LATERAL (SELECT ... FROM t.lst AS x WHERE w) AS s
+//	=> FROM t, t.lst AS x WHERE w
+func lateralIterate(s *expr.Select, left *expr.Table, as string, sub *expr.Select) error {
+	from := sub.From.(*expr.Table)
+	if anyHasAggregate(sub.Columns) || sub.Having != nil || sub.Qualify != nil {
+		return errorf(sub, "LATERAL aggregate over %s is not supported", expr.ToString(from.Expr))
+	}
+	if sub.GroupBy != nil || sub.HasDistinct() || sub.OrderBy != nil || sub.Limit != nil || sub.Offset != nil {
+		return errorf(sub, "LATERAL sub-query over %s cannot use GROUP BY, DISTINCT, ORDER BY or LIMIT", expr.ToString(from.Expr))
+	}
+	if from.Result() == left.Result() {
+		return errorf(sub, "LATERAL sub-query rebinds %q", from.Result())
+	}
+	for i := range s.Columns {
+		if s.Columns[i].Expr == (expr.Star{}) {
+			return errorf(s, "SELECT * is not supported with a LATERAL sub-query over %s", expr.ToString(from.Expr))
+		}
+	}
+	rw := &lateralRewriter{name: as}
+	rw.column = func(path []string) (expr.Node, error) {
+		for i := range sub.Columns {
+			if sub.Columns[i].Result() == path[0] {
+				return dotted(expr.Copy(sub.Columns[i].Expr), path[1:]), nil
+			}
+		}
+		for i := range sub.Columns {
+			if sub.Columns[i].Expr == (expr.Star{}) {
+				return expr.MakePath(append([]string{from.Result()}, path...)), nil
+			}
+		}
+		return nil, errorf(expr.MakePath(append([]string{as}, path...)), "LATERAL sub-query %q has no column %q", as, path[0])
+	}
+	if err := rw.apply(s); err != nil {
+		return err
+	}
+	s.From = &expr.Join{
+		Kind:  expr.CrossJoin,
+		Left:  left,
+		Right: from.Binding,
+	}
+	if sub.Where != nil {
+		if s.Where == nil {
+			s.Where = sub.Where
+		} else {
+			s.Where = expr.And(sub.Where, s.Where)
+		}
+	}
+	return nil
+}
+
+// lateralAggregate performs the rewrite
+//
+//	FROM t, LATERAL (SELECT agg ... WHERE y = t.x) AS s
+//	=> FROM (SELECT *, t.x AS $__lateral_key, (SELECT agg ... WHERE y = $__lateral_key) AS $__lateral FROM t) AS t
+//
+// so that the sub-query is decorrelated into a HASH_REPLACEMENT
+func lateralAggregate(s *expr.Select, left *expr.Table, as string, sub *expr.Select, refs []expr.Node) error {
+	name := left.Result()
+	if !anyHasAggregate(sub.Columns) {
+		return errorf(sub, "LATERAL sub-query must either be an aggregate or iterate a path of %q", name)
+	}
+	if sub.GroupBy != nil || sub.Having != nil || sub.Qualify != nil {
+		return errorf(sub, "LATERAL aggregate sub-query cannot use GROUP BY, HAVING or QUALIFY")
+	}
+	if len(refs) != 1 {
+		return errorf(sub, "LATERAL aggregate sub-query may only reference one column of %q", name)
+	}
+	key := refs[0]
+	krw := &lateralRewriter{name: name}
+	krw.column = func([]string) (expr.Node, error) {
+		return expr.Ident(lateralKey), nil
+	}
+	if err := krw.apply(sub); err != nil {
+		return err
+	}
+	if rest, _ := outerRefs(sub, name); len(rest) > 0 || !lateralEquality(sub) {
+		return errorf(sub, "LATERAL aggregate sub-query must compare %s for equality in WHERE", expr.ToString(key))
+	}
+
+	// an alias is resolved against the derived table
+	// below, but a table without an alias is referenced
+	// with unqualified paths
+	alias := ""
+	if left.Explicit() {
+		alias = name
+	} else {
+		path, _ := expr.FlatPath(key)
+		key = expr.MakePath(path[1:])
+	}
+	ref := func(field string) expr.Node {
+		if alias == "" {
+			return expr.Ident(field)
+		}
+		return expr.MakePath([]string{alias, field})
+	}
+	rw := &lateralRewriter{name: as}
+	rw.column = func(path []string) (expr.Node, error) {
+		for i := range sub.Columns {
+			if sub.Columns[i].Result() != path[0] {
+				continue
+			}
+			// a single column is replaced with a scalar,
+			// and multiple columns with a structure
+			col := ref(lateralResult)
+			if len(sub.Columns) > 1 {
+				col = &expr.Dot{Inner: col, Field: path[0]}
+			}
+			// rows without a match produce no value, but
+			// aggregates over zero rows are NULL, except
+			// for COUNT, which is zero
+			if agg, ok := sub.Columns[i].Expr.(*expr.Aggregate); ok && (agg.Op == expr.OpCount || agg.Op == expr.OpCountDistinct) {
+				col = expr.Coalesce([]expr.Node{col, expr.Integer(0)})
+			} else {
+				col = expr.Coalesce([]expr.Node{col})
+			}
+			return dotted(col, path[1:]), nil
+		}
+		return nil, errorf(expr.MakePath(append([]string{as}, path...)), "LATERAL sub-query %q has no column %q", as, path[0])
+	}
+	if err := rw.apply(s); err != nil {
+		return err
+	}
+	// SELECT * includes the columns of the sub-query,
+	// but not the intermediate results
+	star := false
+	var cols []expr.Binding
+	for i := range s.Columns {
+		cols = append(cols, s.Columns[i])
+		if s.Columns[i].Expr != (expr.Star{}) {
+			continue
+		}
+		star = true
+		for j := range sub.Columns {
+			c := sub.Columns[j].Result()
+			v, err := rw.column([]string{c})
+			if err != nil {
+				return err
+			}
+			cols = append(cols, expr.Bind(v, c))
+		}
+	}
+	if star {
+		cols = append(cols,
+			expr.Bind(expr.Missing{}, lateralKey),
+			expr.Bind(expr.Missing{}, lateralResult))
+		s.Columns = cols
+	}
+	derived := &expr.Select{
+		Columns: []expr.Binding{
+			expr.Bind(expr.Star{}, ""),
+			expr.Bind(key, lateralKey),
+			expr.Bind(sub, lateralResult),
+		},
+		From: left,
+	}
+	s.From = &expr.Table{Binding: expr.Bind(derived, alias)}
+	return nil
+}
+
+// lateralEquality returns whether the only references
+// to lateralKey in s are top-level conjunctions
+// of the WHERE clause of the form
+//
+//	$__lateral_key = y
+func lateralEquality(s *expr.Select) bool {
+	found := false
+	for _, e := range conjunctions(s.Where, nil) {
+		if _, ok := decorrelateCmp(lateralKey, e); ok {
+			found = true
+		} else if hasReference(lateralKey, e) {
+			return false
+		}
+	}
+	if !found {
+		return false
+	}
+	where := s.Where
+	s.Where = nil
+	defer func() { s.Where = where }()
+	return !hasReference(lateralKey, s)
+}
+
+// shadows returns whether the FROM clause
+// of s binds name itself
+func shadows(s *expr.Select, name string) bool {
+	if s.From == nil {
+		return false
+	}
+	tables := s.From.Tables()
+	for i := range tables {
+		if tables[i].Result() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func rootedAt(e expr.Node, name string) bool {
+	path, ok := expr.FlatPath(e)
+	return ok && len(path) > 1 && path[0] == name
+}
+
+// outerRefs returns the distinct paths
+// in s (but not its sub-queries) that
+// are rooted at name
+func outerRefs(s *expr.Select, name string) ([]expr.Node, error) {
+	var refs []expr.Node
+	var err error
+	visit := expr.WalkFunc(func(e expr.Node) bool {
+		if err != nil {
+			return false
+		}
+		if sel, ok := e.(*expr.Select); ok && sel != s {
+			return false
+		}
+		if expr.IsIdentifier(e, name) {
+			err = errorf(e, "cannot reference %q from a LATERAL sub-query except through its fields", name)
+			return false
+		}
+		if !rootedAt(e, name) {
+			return true
+		}
+		for i := range refs {
+			if refs[i].Equals(e) {
+				return false
+			}
+		}
+		refs = append(refs, e)
+		return false
+	})
+	expr.Walk(visit, s)
+	return refs, err
+}
+
+// dotted returns e.path[0].path[1]...
+func dotted(e expr.Node, path []string) expr.Node {
+	for i := range path {
+		e = &expr.Dot{Inner: e, Field: path[i]}
+	}
+	return e
+}
+
+// lateralRewriter replaces paths rooted at name
+// with the result of column(path[1:])
+type lateralRewriter struct {
+	name   string
+	column func(path []string) (expr.Node, error)
+	err    error
+}
+
+func (l *lateralRewriter) apply(s *expr.Select) error {
+	for i := range s.Columns {
+		s.Columns[i].Expr = expr.Rewrite(l, s.Columns[i].Expr)
+	}
+	s.Where = expr.Rewrite(l, s.Where)
+	for i := range s.GroupBy {
+		s.GroupBy[i].Expr = expr.Rewrite(l, s.GroupBy[i].Expr)
+	}
+	s.Having = expr.Rewrite(l, s.Having)
+	s.Qualify = expr.Rewrite(l, s.Qualify)
+	for i := range s.OrderBy {
+		s.OrderBy[i].Column = expr.Rewrite(l, s.OrderBy[i].Column)
+	}
+	for i := range s.DistinctExpr {
+		s.DistinctExpr[i] = expr.Rewrite(l, s.DistinctExpr[i])
+	}
+	return l.err
+}
+
+func (l *lateralRewriter) Walk(e expr.Node) expr.Rewriter {
+	if l.err != nil {
+		return nil
+	}
+	if _, ok := e.(*expr.Select); ok {
+		return nil
+	}
+	if rootedAt(e, l.name) {
+		return nil
+	}
+	return l
+}
+
+func (l *lateralRewriter) Rewrite(e expr.Node) expr.Node {
+	if l.err != nil {
+		return e
+	}
+	if expr.IsIdentifier(e, l.name) {
+		l.err = errorf(e, "cannot reference %q except through its columns", l.name)
+		return e
+	}
+	if !rootedAt(e, l.name) {
+		return e
+	}
+	path, _ := expr.FlatPath(e)
+	ret, err := l.column(path[1:])
+	if err != nil {
+		l.err = err
+		return e
+	}
+	return ret
+}
@@ -63,6 +63,17 @@ func Split(b *Trace) (*Trace, error) {
 		}
 		reduce.Replacements[i] = in
 	}
+	// the right-hand side of a set operation
+	// is a separate query that is split on its own
+	for s := reduce.top; s != nil; s = s.parent() {
+		if so, ok := s.(*SetOp); ok {
+			right, err := Split(so.Right)
+			if err != nil {
+				return nil, err
+			}
+			so.Right = right
+		}
+	}
 	postoptimize(reduce)
 	return reduce, nil
 }
@@ -152,11 +163,18 @@ func splitOne(s Step, mapping, reduce *Trace) (bool, error) {
 	// reduction
 	switch n := s.(type) {
 	case *Limit:
-		// clone LIMIT so that we do it in both places
-		mapping.top = n
+		// clone LIMIT so that we do it in both places;
+		// 'LIMIT x OFFSET y' becomes 'LIMIT x+y'
+		// in the mapping step, since the offset
+		// can only be applied to the merged result
 		l2 := n.clone()
 		l2.setparent(reduce.top)
 		reduce.top = l2
+		if n.Offset != 0 {
+			n.Count += n.Offset
+			n.Offset = 0
+		}
+		mapping.top = n
 		return false, nil
 	case *Distinct:
 		// similar to Limit, clone the op
@@ -172,7 +190,7 @@ func splitOne(s Step, mapping, reduce *Trace) (bool, error) {
 		reduce.top = d2
 		// no longer in mapping step
 		return false, nil
-	case *Order:
+	case *Order, *RowNumber, *SetOp:
 		mapping.top = par
 		n.setparent(reduce.top)
 		reduce.top = n
@@ -252,9 +270,14 @@ func windowMatch(e expr.Node, srcagg, dstagg vm.Aggregation, groups []expr.Bindi
 func reduceAggregate(a *Aggregate, mapping, reduce *Trace) error {
 
 	needsFinalProjection := false
+	// exact[i] is set if a.Agg[i] is a SUM of
+	// decimals whose partial results are exact
+	exact := make([]bool, len(a.Agg))
 	for i := range a.Agg {
+		exact[i] = vm.IsExactSum(a.Agg[i].Expr)
 		switch a.Agg[i].Expr.Op {
-		case expr.OpApproxCountDistinct, expr.OpSum, expr.OpApproxPercentile, expr.OpApproxMedian:
+		case expr.OpApproxCountDistinct, expr.OpSum, expr.OpApproxPercentile, expr.OpApproxMedian,
+			expr.OpStringAgg, expr.OpTopK, expr.OpMode, expr.OpUser:
 			// Opcode becomes its partial counterpart
 			a.Agg[i].Expr.Role = expr.AggregateRolePartial
 
@@ -351,6 +374,11 @@ func reduceAggregate(a *Aggregate, mapping, reduce *Trace) error {
 			newagg = &expr.Aggregate{Op: age.Op, Inner: innerref}
 		case expr.OpSum:
 			newagg = &expr.Aggregate{Op: age.Op, Role: expr.AggregateRoleMerge, Inner: innerref}
+			if i < len(exact) && exact[i] {
+				// the partial results are decimals,
+				// so the merge is exact as well
+				newagg.Inner = &expr.Cast{From: innerref, To: expr.DecimalType}
+			}
 		case expr.OpApproxPercentile:
 			newagg = &expr.Aggregate{
 				Op:    expr.OpApproxPercentile,
@@ -369,6 +397,38 @@ func reduceAggregate(a *Aggregate, mapping, reduce *Trace) error {
 				Role:      expr.AggregateRoleMerge,
 				Precision: age.Precision,
 				Inner:     innerref}
+		case expr.OpStringAgg:
+			// the partial results carry the values
+			// of the ORDER BY columns, so only
+			// the orderings need to be preserved
+			order := make([]expr.Order, len(age.OrderBy))
+			for j := range age.OrderBy {
+				order[j] = expr.Order{
+					Column:    expr.Missing{},
+					Desc:      age.OrderBy[j].Desc,
+					NullsLast: age.OrderBy[j].NullsLast,
+				}
+			}
+			newagg = &expr.Aggregate{
+				Op:        expr.OpStringAgg,
+				Role:      expr.AggregateRoleMerge,
+				Separator: age.Separator,
+				MaxLength: age.MaxLength,
+				OrderBy:   order,
+				Inner:     innerref}
+		case expr.OpTopK, expr.OpMode:
+			newagg = &expr.Aggregate{
+				Op:         age.Op,
+				Role:       expr.AggregateRoleMerge,
+				K:          age.K,
+				SketchSize: age.SketchSize,
+				Inner:      innerref}
+		case expr.OpUser:
+			newagg = &expr.Aggregate{
+				Op:    expr.OpUser,
+				Role:  expr.AggregateRoleMerge,
+				Name:  age.Name,
+				Inner: innerref}
 		case expr.OpSystemDatashape:
 			newagg = &expr.Aggregate{
 				Op:    expr.OpSystemDatashapeMerge,
@@ -430,6 +490,11 @@ func reduceAggregate(a *Aggregate, mapping, reduce *Trace) error {
 		for i := range a.GroupBy {
 			name := a.GroupBy[i].Result()
 			group[i] = expr.Identity(name)
+			if uncollated(a.GroupBy[i].Expr) != a.GroupBy[i].Expr {
+				// partial groups that differ only
+				// in case must still be merged
+				group[i].Expr = expr.Call(expr.CollateCI, group[i].Expr)
+			}
 		}
 		red.GroupBy = group
 	}
@@ -72,7 +72,7 @@ func (b *Trace) optimize() error {
 	projectpushdown(b) // merge adjacent projections
 	liftprojectagg(b)  // eliminate a trivial projection after an aggregate
 	distinctelim(b)
-	countdistinct2count(b) // turn count(distinct x) -> count(x) from (select distinct ...)
+	countdistinct2count(b) // turn count(distinct x) -> count(x) from (select distinct ...), likewise for sum and avg
 	strengthReduce(b)      // strength-reduce kernels, replacing generic subtraces with their case-specific optimized variants
 	filterelim(b)          // eliminate WHERE TRUE
 	filterpushdown(b)      // merge adjacent filters
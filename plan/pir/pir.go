@@ -333,7 +333,17 @@ func (pt *pseudoTable) describe(w io.Writer) {
 // resolved correctly
 func (pt *pseudoTable) strip(p []string) ([]string, error) {
 	if len(p) > 1 && p[0] == pt.name {
-		return p[1:], nil
+		p = p[1:]
+		// if the sub-SELECT passes through the
+		// fields of its input (SELECT *, ...), then
+		// the stripped path has to be tracked by the input
+		src, _ := pt.parent().get(p[0])
+		if it, ok := src.(*IterTable); ok && it.Bind != "" {
+			return it.strip(append([]string{it.Bind}, p...))
+		}
+		if rt, ok := src.(reftracker); ok {
+			return rt.strip(p)
+		}
 	}
 	return p, nil
 }
@@ -372,6 +382,17 @@ func (b *binds) walk(v expr.Visitor) {
 	}
 }
 
+// hasStar returns whether the bindings include '*',
+// which passes through every binding of the input
+func (b *binds) hasStar() bool {
+	for i := range b.bind {
+		if _, ok := b.bind[i].Expr.(expr.Star); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (b *binds) equal(b2 *binds) bool {
 	return slices.EqualFunc(b.bind, b2.bind, expr.Binding.Equals)
 }
@@ -579,7 +600,7 @@ func (b *Bind) get(x string) (Step, expr.Node) {
 			return b, b.bind[i].Expr
 		}
 	}
-	if !b.complete {
+	if !b.complete || b.hasStar() {
 		return b.parent().get(x)
 	}
 	return nil, nil
@@ -699,6 +720,72 @@ func (o *Order) walk(v expr.Visitor) {
 	}
 }
 
+// RowNumber is a step that sorts its input rows
+// by PartitionBy and then OrderBy and numbers
+// the rows within each partition, starting at 1,
+// as in
+//
+//	ROW_NUMBER() OVER (PARTITION BY ... ORDER BY ...) AS Result
+type RowNumber struct {
+	parented
+	PartitionBy []expr.Node
+	OrderBy     []expr.Order
+	Result      string
+}
+
+func (r *RowNumber) equals(x Step) bool {
+	r2, ok := x.(*RowNumber)
+	return ok && (r == r2 || r.Result == r2.Result &&
+		slices.EqualFunc(r.PartitionBy, r2.PartitionBy, expr.Equal) &&
+		slices.EqualFunc(r.OrderBy, r2.OrderBy, expr.Order.Equals))
+}
+
+func (r *RowNumber) get(x string) (Step, expr.Node) {
+	if x == r.Result {
+		return r, nil
+	}
+	return r.parent().get(x)
+}
+
+func (r *RowNumber) describe(dst io.Writer) {
+	io.WriteString(dst, "ROW_NUMBER")
+	for i := range r.PartitionBy {
+		if i == 0 {
+			io.WriteString(dst, " PARTITION BY ")
+		} else {
+			io.WriteString(dst, ", ")
+		}
+		io.WriteString(dst, expr.ToString(r.PartitionBy[i]))
+	}
+	for i := range r.OrderBy {
+		if i == 0 {
+			io.WriteString(dst, " ORDER BY ")
+		} else {
+			io.WriteString(dst, ", ")
+		}
+		io.WriteString(dst, expr.ToString(&r.OrderBy[i]))
+	}
+	fmt.Fprintf(dst, " AS %s\n", r.Result)
+}
+
+func (r *RowNumber) rewrite(rw func(expr.Node, bool) expr.Node) {
+	for i := range r.PartitionBy {
+		r.PartitionBy[i] = rw(r.PartitionBy[i], false)
+	}
+	for i := range r.OrderBy {
+		r.OrderBy[i].Column = rw(r.OrderBy[i].Column, false)
+	}
+}
+
+func (r *RowNumber) walk(v expr.Visitor) {
+	for i := range r.PartitionBy {
+		expr.Walk(v, r.PartitionBy[i])
+	}
+	for i := range r.OrderBy {
+		expr.Walk(v, r.OrderBy[i].Column)
+	}
+}
+
 type Limit struct {
 	parented
 	noexprs
@@ -755,9 +842,15 @@ func (o *OutputPart) get(x string) (Step, expr.Node) {
 // returning a single row like
 //
 //	{"table": "db.table-XXXXXX"}
+//
+// or, if Create is set, {"table": "db.table"}
 type OutputIndex struct {
 	Table    expr.Node
 	Basename string
+	// Create is set if the index is that of
+	// the new table Table (see expr.Query.Create)
+	// rather than a table named after Table
+	Create bool
 	parented
 	noexprs
 }
@@ -765,10 +858,14 @@ type OutputIndex struct {
 func (o *OutputIndex) equals(x Step) bool {
 	o2, ok := x.(*OutputIndex)
 	return ok && (o == o2 || o.Table.Equals(o2.Table) &&
-		o.Basename == o2.Basename)
+		o.Basename == o2.Basename && o.Create == o2.Create)
 }
 
 func (o *OutputIndex) describe(dst io.Writer) {
+	if o.Create {
+		fmt.Fprintf(dst, "CREATE TABLE %s AT %s\n", expr.ToString(o.Table), o.Basename)
+		return
+	}
 	fmt.Fprintf(dst, "OUTPUT INDEX %s AT %s\n", expr.ToString(o.Table), o.Basename)
 }
 
@@ -896,9 +993,46 @@ func (b *Trace) Begin(f *expr.Table, e Env) error {
 		it.Index = idx
 	}
 	b.top = it
+	if di, ok := it.Index.(DeletedIndex); ok {
+		// each predicate is wrapped in TOMBSTONE(i, ...)
+		// so that it is only applied to the objects
+		// covered by the ith tombstone (see plan.Filter)
+		var del expr.Node
+		for i, t := range di.Deleted() {
+			w := expr.Copy(t.Where)
+			if it.Bind != "" {
+				w = expr.Rewrite(qualifier(it.Bind), w)
+			}
+			w = expr.Call(expr.Tombstone, expr.Integer(i), w)
+			if del == nil {
+				del = w
+			} else {
+				del = expr.Or(del, w)
+			}
+		}
+		if del != nil {
+			// rows for which the predicate is
+			// NULL or MISSING have not been deleted
+			return b.Where(expr.Is(del, expr.IsNotTrue))
+		}
+	}
 	return nil
 }
 
+// qualifier rewrites the free identifiers
+// in an expression to be paths relative
+// to the table bound to the given name
+type qualifier string
+
+func (q qualifier) Rewrite(e expr.Node) expr.Node {
+	if id, ok := e.(expr.Ident); ok {
+		return &expr.Dot{Inner: expr.Ident(q), Field: string(id)}
+	}
+	return e
+}
+
+func (q qualifier) Walk(e expr.Node) expr.Rewriter { return q }
+
 func (b *Trace) beginUnionMap(src *Trace, terminal Step) {
 	// we know that the result of a
 	// parallelized query ought to be
@@ -1027,6 +1161,11 @@ func (b *Trace) Bind(bindings ...[]expr.Binding) error {
 	// then add it to the current binding set
 	for _, bind := range bindings {
 		for i := range bind {
+			if _, ok := bind[i].Expr.(expr.Star); ok {
+				b.top.get("*")
+				bi.bind = append(bi.bind, bind[i])
+				continue
+			}
 			if exp, err := b.pathwalk(bind[i].Expr); err != nil {
 				return err
 			} else {
@@ -1043,6 +1182,11 @@ func (b *Trace) Bind(bindings ...[]expr.Binding) error {
 	// clobber the current binding set
 	bi.complete = true
 	b.final = bi.bind
+	if bi.hasStar() {
+		// the output bindings
+		// are not known in advance
+		b.final = nil
+	}
 	return b.push()
 }
 
@@ -1127,6 +1271,31 @@ func (b *Trace) Order(cols []expr.Order) error {
 	return b.push()
 }
 
+// RowNumber pushes a row numbering step
+// for the window w to the stack
+func (b *Trace) RowNumber(w *expr.Window, result string) error {
+	rn := &RowNumber{Result: result}
+	b.cur = b.top
+	for i := range w.PartitionBy {
+		col, err := b.pathwalk(w.PartitionBy[i])
+		if err != nil {
+			return err
+		}
+		rn.PartitionBy = append(rn.PartitionBy, col)
+	}
+	for i := range w.OrderBy {
+		col, err := b.pathwalk(w.OrderBy[i].Column)
+		if err != nil {
+			return err
+		}
+		ord := w.OrderBy[i]
+		ord.Column = col
+		rn.OrderBy = append(rn.OrderBy, ord)
+	}
+	b.cur = rn
+	return b.push()
+}
+
 // LimitOffset pushes a limit operation to the stack
 func (b *Trace) LimitOffset(limit, offset int64) error {
 	l := &Limit{Count: limit, Offset: offset}
@@ -1201,11 +1370,23 @@ func (b *Trace) innerJoin(bind *expr.Binding, on expr.Node, env Env) error {
 // Into handles the INTO clause by pushing
 // the appropriate OutputIndex and OutputPart nodes.
 func (b *Trace) Into(table expr.Node, basepath string) {
+	b.output(table, basepath, false)
+}
+
+// CreateTable handles CREATE TABLE ... AS like
+// Into, except that the rows are written into
+// a new table with exactly the given name.
+func (b *Trace) CreateTable(table expr.Node, basepath string) {
+	b.output(table, basepath, true)
+}
+
+func (b *Trace) output(table expr.Node, basepath string, create bool) {
 	op := &OutputPart{Basename: basepath}
 	op.setparent(b.top)
 	oi := &OutputIndex{
 		Table:    table,
 		Basename: basepath,
+		Create:   create,
 	}
 	oi.setparent(op)
 	b.top = oi
@@ -1228,15 +1409,20 @@ func (b *Trace) FinalBindings() []expr.Binding {
 // Note that the return value may be nil if the
 // query does not produce a know (finite) result-set
 func (b *Trace) FinalTypes() []expr.TypeSet {
-	if b.finalTypes != nil {
-		return b.finalTypes
+	if b.finalTypes == nil {
+		b.finalTypes = b.types()
 	}
+	return b.finalTypes
+}
+
+// types computes the type sets of
+// the current output bindings
+func (b *Trace) types() []expr.TypeSet {
 	hint := &stepHint{b.top}
 	out := make([]expr.TypeSet, len(b.final))
 	for i := range b.final {
 		out[i] = expr.TypeOf(expr.Identifier(b.final[i].Result()), hint)
 	}
-	b.finalTypes = out
 	return out
 }
 
@@ -20,6 +20,7 @@ import (
 
 var rules = []func(t *Trace) error{
 	checkSortSize,
+	checkDistinctAggregates,
 }
 
 func checkAggregateWorkInProgress(e expr.Node) error {
@@ -68,6 +69,24 @@ func checkNoAggregateInCondition(e expr.Node, context string) error {
 	return err
 }
 
+// checkDistinctAggregates checks that every
+// DISTINCT aggregate has been rewritten into
+// an ordinary aggregate of distinct rows
+func checkDistinctAggregates(t *Trace) error {
+	for s := t.top; s != nil; s = s.parent() {
+		a, ok := s.(*Aggregate)
+		if !ok {
+			continue
+		}
+		for i := range a.Agg {
+			if e := a.Agg[i].Expr; e.IsDistinct() {
+				return errorf(e, "cannot compute %s along with the other aggregates of this query", expr.ToString(e))
+			}
+		}
+	}
+	return nil
+}
+
 func checkSortSize(t *Trace) error {
 	final := t.Final()
 	if b, ok := final.(*Bind); ok {
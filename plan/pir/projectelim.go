@@ -98,6 +98,9 @@ func projectelim(b *Trace) {
 	if firstbind == nil {
 		return
 	}
+	if b, ok := firstbind.(*Bind); ok && b.hasStar() {
+		return // we're using everything from previous steps
+	}
 	firstbind.walk(walkfn(walk))
 	parent := firstbind
 loop:
@@ -105,6 +108,9 @@ loop:
 		switch s := s.(type) {
 		case *Bind:
 			s.bind = filterSlice(s.bind, func(b *expr.Binding) bool {
+				if _, ok := b.Expr.(expr.Star); ok {
+					return true
+				}
 				_, ok := used[b.Result()]
 				return ok
 			})
@@ -194,18 +200,21 @@ func (b *bindflattener) Walk(e expr.Node) expr.Rewriter {
 func projectpushdown(scope *Trace) {
 outer:
 	for s := scope.top; s != nil; s = s.parent() {
-		var rewrite func(bf *bindflattener)
+		var rewrite func(bf *bindflattener, h expr.Hint)
 		switch s := s.(type) {
 		case *Bind:
-			rewrite = func(bf *bindflattener) {
-				h := &stepHint{s.parent()}
+			if s.hasStar() {
+				// '*' has to see the bindings
+				// of the parent as they are
+				continue
+			}
+			rewrite = func(bf *bindflattener, h expr.Hint) {
 				for i := range s.bind {
 					s.bind[i].Expr = expr.Simplify(expr.Rewrite(bf, s.bind[i].Expr), h)
 				}
 			}
 		case *Aggregate:
-			rewrite = func(bf *bindflattener) {
-				h := &stepHint{s.parent()}
+			rewrite = func(bf *bindflattener, h expr.Hint) {
 				for i := range s.Agg {
 					s.Agg[i].Expr = expr.Simplify(expr.Rewrite(bf, s.Agg[i].Expr), h).(*expr.Aggregate)
 				}
@@ -225,8 +234,11 @@ outer:
 			if !ok {
 				continue outer
 			}
+			// the rewritten expressions refer to the
+			// bindings of the parent of pb, so they
+			// have to be simplified in that scope
 			rw := bindflattener{from: pb.bind}
-			rewrite(&rw)
+			rewrite(&rw, &stepHint{pb.parent()})
 			s.setparent(pb.parent())
 		}
 	}
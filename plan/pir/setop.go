@@ -0,0 +1,438 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package pir
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/vm"
+)
+
+// SetOp is a step that filters rows according
+// to the semantics of INTERSECT and EXCEPT,
+// or that appends the rows of another query
+// according to the semantics of UNION ALL.
+//
+// Right is the right-hand side of the set operation.
+// For INTERSECT and EXCEPT, it produces one row for
+// each distinct row on the right-hand side, with Columns
+// bound to the values of the row and Count bound to
+// the number of times the row occurs. For UNION ALL,
+// it produces the rows that are appended to the output,
+// with Columns bound to their values, and Count is nil.
+// (UNION is UNION ALL followed by a Distinct step.)
+type SetOp struct {
+	parented
+	Union       bool
+	Except, All bool
+	Columns     []expr.Node
+	Count       expr.Node
+	Right       *Trace
+}
+
+func (s *SetOp) equals(x Step) bool {
+	s2, ok := x.(*SetOp)
+	return ok && (s == s2 || s.Union == s2.Union && s.Except == s2.Except && s.All == s2.All &&
+		slices.EqualFunc(s.Columns, s2.Columns, expr.Node.Equals) &&
+		(s.Count == s2.Count || s.Count != nil && s2.Count != nil && s.Count.Equals(s2.Count)) &&
+		s.Right.Equals(s2.Right))
+}
+
+func (s *SetOp) op() expr.UnionType {
+	switch {
+	case s.Union:
+		return expr.UnionAll
+	case s.Except && s.All:
+		return expr.ExceptAll
+	case s.Except:
+		return expr.Except
+	case s.All:
+		return expr.IntersectAll
+	default:
+		return expr.Intersect
+	}
+}
+
+func (s *SetOp) describe(dst io.Writer) {
+	var buf bytes.Buffer
+	s.Right.Describe(&buf)
+	inner := bytes.TrimSuffix(buf.Bytes(), []byte{'\n'})
+	inner = bytes.ReplaceAll(inner, []byte{'\n'}, []byte{'\n', '\t'})
+	if s.Count == nil {
+		fmt.Fprintf(dst, "%s %s WITH (\n\t", s.op(), formatFields(toStrings(s.Columns)))
+	} else {
+		fmt.Fprintf(dst, "%s %s COUNT %s WITH (\n\t", s.op(), formatFields(toStrings(s.Columns)), expr.ToString(s.Count))
+	}
+	dst.Write(inner)
+	io.WriteString(dst, "\n)\n")
+}
+
+func (s *SetOp) rewrite(rw func(expr.Node, bool) expr.Node) {
+	for i := range s.Columns {
+		s.Columns[i] = rw(s.Columns[i], false)
+	}
+}
+
+func (s *SetOp) walk(v expr.Visitor) {
+	for i := range s.Columns {
+		expr.Walk(v, s.Columns[i])
+	}
+}
+
+func buildSetOp(parent *Trace, u *expr.Union, e Env) (*Trace, error) {
+	// as in standard SQL, ORDER BY, LIMIT and OFFSET
+	// following the last query apply to the result
+	// of the whole set operation
+	body, last := stripLast(u)
+	if last.OrderBy == nil && last.Limit != nil {
+		// without ORDER BY, any LIMIT+OFFSET rows from
+		// each branch of UNION ALL are enough, so each
+		// branch can stop early; the limit is re-applied
+		// to the concatenated rows below
+		n := int64(*last.Limit)
+		if last.Offset != nil {
+			n += int64(*last.Offset)
+		}
+		body = limitUnionAll(body, n)
+	}
+	b := &Trace{Parent: parent}
+	err := b.walkSetOp(body.(*expr.Union), e)
+	if err != nil {
+		return nil, err
+	}
+	if last.OrderBy != nil {
+		err = b.Order(last.OrderBy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if last.Limit != nil {
+		off := int64(0)
+		if last.Offset != nil {
+			off = int64(*last.Offset)
+		}
+		err = b.LimitOffset(int64(*last.Limit), off)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = b.optimize()
+	return b, err
+}
+
+// stripLast returns a copy of n in which the last
+// SELECT has no ORDER BY, LIMIT or OFFSET clauses,
+// and the original last SELECT
+func stripLast(n expr.Node) (expr.Node, *expr.Select) {
+	switch n := n.(type) {
+	case *expr.Union:
+		right, last := stripLast(n.Right)
+		return &expr.Union{Type: n.Type, Left: n.Left, Right: right}, last
+	case *expr.Select:
+		c := *n
+		c.OrderBy, c.Limit, c.Offset = nil, nil, nil
+		return &c, n
+	default:
+		return n, &expr.Select{}
+	}
+}
+
+// limitUnionAll returns a copy of n in which each
+// SELECT that is a branch of UNION ALL produces
+// at most n rows
+func limitUnionAll(n expr.Node, limit int64) expr.Node {
+	switch n := n.(type) {
+	case *expr.Union:
+		if n.Type != expr.UnionAll {
+			return n
+		}
+		return &expr.Union{
+			Type:  n.Type,
+			Left:  limitUnionAll(n.Left, limit),
+			Right: limitUnionAll(n.Right, limit),
+		}
+	case *expr.Select:
+		if n.Limit != nil && int64(*n.Limit) <= limit {
+			return n
+		}
+		c := *n
+		lim := expr.Integer(limit)
+		c.Limit = &lim
+		return &c
+	default:
+		return n
+	}
+}
+
+// walkSetOp walks an INTERSECT or EXCEPT expression.
+//
+// The right-hand side of the operator is computed as a
+// separate trace that counts the number of occurrences of
+// each distinct row, and the rows on the left-hand side are
+// filtered according to that count:
+//
+//	SELECT x, y FROM a INTERSECT SELECT z, w FROM b
+//
+// becomes
+//
+//	SELECT DISTINCT x, y FROM a
+//
+// filtered by the rows of
+//
+//	SELECT z AS x, w AS y, COUNT(*) AS $__count
+//	FROM b GROUP BY z, w
+//
+// The right-hand side is not limited in size;
+// see plan.SetOp.
+func (b *Trace) walkSetOp(u *expr.Union, e Env) error {
+	if u.Type == expr.UnionAll || u.Type == expr.UnionDistinct {
+		return b.walkUnion(u, e)
+	}
+	rt, err := b.walkSetOperands(u, e)
+	if err != nil {
+		return err
+	}
+
+	// count the number of occurences of each row
+	// on the right, using the names of the columns
+	// on the left so that rows can be compared
+	outputs := setOpColumns(b.final)
+	cols := make([]expr.Node, len(outputs))
+	groups := make([]expr.Binding, len(outputs))
+	for i := range outputs {
+		cols[i] = outputs[i].Expr
+		groups[i] = expr.Bind(expr.Ident(rt.final[i].Result()), outputs[i].Result())
+	}
+	count := vm.Aggregation{{Expr: expr.Count(expr.Star{}), Result: "$__count"}}
+	if err := rt.Aggregate(count, groups); err != nil {
+		return err
+	}
+	if err := rt.optimize(); err != nil {
+		return err
+	}
+
+	all := u.Type == expr.IntersectAll || u.Type == expr.ExceptAll
+	if !all {
+		if err := b.Distinct(cols); err != nil {
+			return err
+		}
+	}
+	b.cur = &SetOp{
+		Except:  u.Type == expr.Except || u.Type == expr.ExceptAll,
+		All:     all,
+		Columns: cols,
+		Count:   expr.Ident("$__count"),
+		Right:   rt,
+	}
+	if err := b.push(); err != nil {
+		return err
+	}
+	return b.Bind(outputs)
+}
+
+// walkUnion walks a UNION or UNION ALL expression.
+//
+// The right-hand side of the operator is computed as a
+// separate trace, and its rows are appended to the rows
+// on the left-hand side. UNION additionally removes
+// duplicates from the combined rows:
+//
+//	SELECT x, y FROM a UNION SELECT z, w FROM b
+//
+// becomes
+//
+//	SELECT DISTINCT x, y FROM a
+//
+// over the rows of a followed by the rows of
+//
+//	SELECT z AS x, w AS y FROM b
+//
+// Each column is coerced so that both sides
+// produce consistent types; see unionCoercion.
+func (b *Trace) walkUnion(u *expr.Union, e Env) error {
+	rt, err := b.walkSetOperands(u, e)
+	if err != nil {
+		return err
+	}
+	outputs := setOpColumns(b.final)
+	ltypes, rtypes := b.types(), rt.types()
+	left := make([]expr.Binding, len(outputs))
+	right := make([]expr.Binding, len(outputs))
+	cols := make([]expr.Node, len(outputs))
+	coerced := false
+	for i := range outputs {
+		name := outputs[i].Result()
+		if !unionCompatible(ltypes[i], rtypes[i]) {
+			return errorf(u, "%s column %q is %s on the left and %s on the right",
+				u.Type, name, unionKind(ltypes[i]), unionKind(rtypes[i]))
+		}
+		lhs := expr.Node(expr.Ident(name))
+		if c := unionCoercion(lhs, ltypes[i], rtypes[i]); c != nil {
+			lhs, coerced = c, true
+		}
+		rhs := expr.Node(expr.Ident(rt.final[i].Result()))
+		if c := unionCoercion(rhs, rtypes[i], ltypes[i]); c != nil {
+			rhs = c
+		}
+		left[i] = expr.Bind(lhs, name)
+		right[i] = expr.Bind(rhs, name)
+		cols[i] = outputs[i].Expr
+	}
+	if coerced {
+		if err := b.Bind(left); err != nil {
+			return err
+		}
+	}
+	if err := rt.Bind(right); err != nil {
+		return err
+	}
+	if err := rt.optimize(); err != nil {
+		return err
+	}
+	b.cur = &SetOp{
+		Union:   true,
+		All:     true,
+		Columns: cols,
+		Right:   rt,
+	}
+	if err := b.push(); err != nil {
+		return err
+	}
+	if u.Type == expr.UnionDistinct {
+		if err := b.Distinct(cols); err != nil {
+			return err
+		}
+	}
+	return b.Bind(outputs)
+}
+
+// unionCoercion returns the expression that coerces
+// the column e of type t on one side of a UNION to be
+// consistent with the corresponding column of type
+// other on the other side, or nil if e can be used as-is.
+//
+// The coercion rules are as follows:
+//
+//   - NULL and MISSING are never coerced
+//   - numbers are widened: if both columns are numeric
+//     and either of them may be a FLOAT, then integers
+//     are converted to FLOAT
+//   - any other combination of scalar types is left
+//     alone and produces a column of mixed type
+//
+// Columns that are known to be structures on one side
+// and lists or scalars on the other (and so on) are
+// rejected by unionCompatible before coercion.
+func unionCoercion(e expr.Node, t, other expr.TypeSet) expr.Node {
+	const absent = expr.NullType | expr.MissingType
+	nullable := t&expr.NullType != 0
+	t, other = t&^absent, other&^absent
+	if t == 0 || other == 0 || !t.Only(expr.NumericType) || !other.Only(expr.NumericType) {
+		return nil
+	}
+	if !t.AnyOf(expr.IntegerType) || !(t | other).AnyOf(expr.FloatType) {
+		return nil
+	}
+	var ret expr.Node = &expr.Cast{From: e, To: expr.FloatType}
+	if nullable {
+		ret = &expr.Case{
+			Limbs: []expr.CaseLimb{{When: expr.Is(e, expr.IsNull), Then: expr.Null{}}},
+			Else:  ret,
+		}
+	}
+	return ret
+}
+
+// unionKind returns the kind of values of
+// type t (ignoring NULL and MISSING), which is
+// "a structure", "a list", "a scalar" or ""
+// if t is not of a single kind
+func unionKind(t expr.TypeSet) string {
+	t &^= expr.NullType | expr.MissingType
+	switch {
+	case t == 0:
+		return ""
+	case t.Only(expr.StructType):
+		return "a structure"
+	case t.Only(expr.ListType):
+		return "a list"
+	case !t.AnyOf(expr.StructType | expr.ListType):
+		return "a scalar"
+	default:
+		return ""
+	}
+}
+
+// unionCompatible returns false if the columns of
+// types t and other on either side of a UNION are
+// known to hold different kinds of values
+// (structures, lists or scalars)
+func unionCompatible(t, other expr.TypeSet) bool {
+	k, o := unionKind(t), unionKind(other)
+	return k == "" || o == "" || k == o
+}
+
+// walkSetOperands walks the operands of u
+// into b and into a new trace for the right-hand
+// side, which is returned
+func (b *Trace) walkSetOperands(u *expr.Union, e Env) (*Trace, error) {
+	if err := b.walkSetOperand(u.Left, u.Type, e); err != nil {
+		return nil, err
+	}
+	rt := &Trace{Parent: b}
+	if err := rt.walkSetOperand(u.Right, u.Type, e); err != nil {
+		return nil, err
+	}
+	if len(rt.final) != len(b.final) {
+		return nil, errorf(u, "each %s query must have the same number of columns (%d vs. %d)",
+			u.Type, len(b.final), len(rt.final))
+	}
+	return rt, nil
+}
+
+// walkSetOperand walks one of the operands of a set operation
+func (b *Trace) walkSetOperand(n expr.Node, op expr.UnionType, e Env) error {
+	switch n := n.(type) {
+	case *expr.Select:
+		n = expr.Simplify(expr.Copy(n), expr.NoHint).(*expr.Select)
+		if err := expr.Check(n); err != nil {
+			return err
+		}
+		if isselectall(n) {
+			return errorf(n, "SELECT * not supported in %s", op)
+		}
+		pickOutputs(n)
+		return b.walkSelect(n, e)
+	case *expr.Union:
+		return b.walkSetOp(n, e)
+	default:
+		return errorf(n, "unexpected %s operand %T", op, n)
+	}
+}
+
+// setOpColumns returns identity bindings
+// for the output columns of binds
+func setOpColumns(binds []expr.Binding) []expr.Binding {
+	out := make([]expr.Binding, len(binds))
+	for i := range binds {
+		name := binds[i].Result()
+		out[i] = expr.Bind(expr.Ident(name), name)
+	}
+	return out
+}
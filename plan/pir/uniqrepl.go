@@ -136,7 +136,8 @@ func (u *uniqueReplacementAux) Walk(n expr.Node) expr.Rewriter {
 		return nil
 
 	case *expr.Builtin:
-		if v.Func == expr.InSubquery {
+		switch v.Func {
+		case expr.InSubquery, expr.AnySubquery, expr.AllSubquery:
 			return nil
 		}
 	}
@@ -182,6 +182,15 @@ func stat(env Env, tbl expr.Node, h *Hints) (*Input, error) {
 			}
 			return statGlob(tl, env, e, h)
 		}
+	case expr.Ident, *expr.Dot:
+		tbl = implicitGlob(e)
+		if bi, ok := tbl.(*expr.Builtin); ok {
+			tl, ok := env.(TableLister)
+			if !ok {
+				return nil, fmt.Errorf("listing not supported")
+			}
+			return statGlob(tl, env, bi, h)
+		}
 	}
 	return env.Stat(tbl, h)
 }
@@ -234,6 +243,15 @@ func index(idx Indexer, tbl expr.Node) (Index, error) {
 			}
 			return indexGlob(tl, idx, e)
 		}
+	case expr.Ident, *expr.Dot:
+		tbl = implicitGlob(e)
+		if bi, ok := tbl.(*expr.Builtin); ok {
+			tl, ok := idx.(TableLister)
+			if !ok {
+				return nil, fmt.Errorf("listing not supported")
+			}
+			return indexGlob(tl, idx, bi)
+		}
 	}
 	return idx.Index(tbl)
 }
@@ -338,10 +356,10 @@ func (s *SimpleAggregate) exec(dst vm.QuerySink, src *Input, ep *ExecParams) err
 
 		switch sysagg {
 		case expr.OpSystemDatashape:
-			return s.From.exec(vm.NewSystemDatashape(dst), src, ep)
+			return s.From.exec(ep.profile(s, vm.NewSystemDatashape(dst)), src, ep)
 
 		case expr.OpSystemDatashapeMerge:
-			return s.From.exec(vm.NewSystemDatashapeMerge(dst), src, ep)
+			return s.From.exec(ep.profile(s, vm.NewSystemDatashapeMerge(dst)), src, ep)
 		}
 	}
 
@@ -350,7 +368,7 @@ func (s *SimpleAggregate) exec(dst vm.QuerySink, src *Input, ep *ExecParams) err
 		return err
 	}
 	a.SetSkipEmpty(s.NonEmpty)
-	return s.From.exec(a, src, ep)
+	return s.From.exec(ep.profile(s, a), src, ep)
 }
 
 func settype(name string, dst *ion.Buffer, st *ion.Symtab) {
@@ -452,7 +470,7 @@ func (l *Leaf) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 	if filt != nil {
 		src = src.Filter(filt)
 	}
-	err := ep.Runner.Run(dst, src, ep)
+	err := ep.Runner.Run(vm.ContextSink(ep.Context, dst), src, ep)
 	if errors.Is(err, io.EOF) {
 		err = nil
 	}
@@ -612,14 +630,22 @@ func (n DummyOutput) SetField(f ion.Field) error {
 type Limit struct {
 	Nonterminal
 	Num int64
+	// Offset is the number of rows skipped
+	// before the first row is produced;
+	// the input of a Limit is unordered,
+	// so any Offset rows may be skipped
+	Offset int64
 }
 
 func (l *Limit) String() string {
+	if l.Offset != 0 {
+		return fmt.Sprintf("LIMIT %d OFFSET %d", l.Num, l.Offset)
+	}
 	return fmt.Sprintf("LIMIT %d", l.Num)
 }
 
 func (l *Limit) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
-	return l.From.exec(vm.NewLimit(l.Num, dst), src, ep)
+	return l.From.exec(ep.profile(l, vm.NewLimitOffset(l.Num, l.Offset, dst)), src, ep)
 }
 
 func (l *Limit) encode(dst *ion.Buffer, st *ion.Symtab, _ *ExecParams) error {
@@ -627,6 +653,10 @@ func (l *Limit) encode(dst *ion.Buffer, st *ion.Symtab, _ *ExecParams) error {
 	settype("limit", dst, st)
 	dst.BeginField(st.Intern("limit"))
 	dst.WriteInt(l.Num)
+	if l.Offset != 0 {
+		dst.BeginField(st.Intern("offset"))
+		dst.WriteInt(l.Offset)
+	}
 	dst.EndStruct()
 	return nil
 }
@@ -639,6 +669,12 @@ func (l *Limit) SetField(f ion.Field) error {
 			return err
 		}
 		l.Num = i
+	case "offset":
+		i, err := f.Int()
+		if err != nil {
+			return err
+		}
+		l.Offset = i
 	default:
 		return errUnexpectedField
 	}
@@ -668,7 +704,7 @@ func (c *CountStar) String() string {
 
 func (c *CountStar) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 	qs := countSink{dst: dst, as: c.name(), nonempty: c.NonEmpty}
-	return c.From.exec(&qs, src, ep)
+	return c.From.exec(ep.profile(c, &qs), src, ep)
 }
 
 type countSink struct {
@@ -898,6 +934,9 @@ func (h *HashAggregate) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error
 		ha.Limit(h.Limit)
 	}
 	ha.SetSkipEmpty(h.NonEmpty)
+	if ep.SpillDir != "" {
+		ha.SetSpill(ep.SpillDir, ep.SpillThreshold)
+	}
 	for i := range h.OrderBy {
 		col := h.OrderBy[i].Column
 		ordering := h.OrderBy[i].Ordering
@@ -910,7 +949,7 @@ func (h *HashAggregate) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error
 			ha.OrderByWindow(col-len(h.Agg)-len(h.By), ordering)
 		}
 	}
-	return h.From.exec(ha, src, ep)
+	return h.From.exec(ep.profile(h, ha), src, ep)
 }
 
 // OrderBy implements ORDER BY clause (without GROUP BY).
@@ -984,7 +1023,7 @@ func (o *OrderBy) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 		w:     writer,
 		dst:   dst,
 	}
-	return o.From.exec(sorter, src, ep)
+	return o.From.exec(ep.profile(o, sorter), src, ep)
 }
 
 type orderSink struct {
@@ -1012,11 +1051,7 @@ func (o *OrderBy) encode(dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) error
 	dst.BeginField(st.Intern("columns"))
 	dst.BeginList(-1)
 	for i := range o.Columns {
-		dst.BeginList(-1)
-		ep.rewrite(o.Columns[i].Node).Encode(dst, st)
-		dst.WriteBool(o.Columns[i].Ordering.Direction == vm.SortDescending)
-		dst.WriteBool(o.Columns[i].Ordering.NullsOrder == vm.SortNullsLast)
-		dst.EndList()
+		encodeSortColumn(dst, st, ep.rewrite(o.Columns[i].Node), o.Columns[i].Ordering)
 	}
 	dst.EndList()
 
@@ -1037,41 +1072,10 @@ func (o *OrderBy) SetField(f ion.Field) error {
 	switch f.Label {
 	case "columns":
 		return f.UnpackList(func(v ion.Datum) error {
-			var col vm.SortColumn
-			var err error
-			i, err := v.Iterator()
-			if err != nil {
-				return err
-			}
-			v, err = i.Next()
-			if err == nil {
-				col.Node, err = expr.Decode(v)
-			}
-			if err != nil {
-				return err
-			}
-			var desc bool
-			desc, err = i.Bool()
+			col, err := decodeSortColumn(v)
 			if err != nil {
 				return err
 			}
-			if desc {
-				col.Ordering.Direction = vm.SortDescending
-			} else {
-				col.Ordering.Direction = vm.SortAscending
-			}
-
-			var nullsLast bool
-			nullsLast, err = i.Bool()
-			if err != nil {
-				return err
-			}
-			if nullsLast {
-				col.Ordering.NullsOrder = vm.SortNullsLast
-			} else {
-				col.Ordering.NullsOrder = vm.SortNullsFirst
-			}
-
 			o.Columns = append(o.Columns, col)
 			return nil
 		})
@@ -1093,6 +1097,170 @@ func (o *OrderBy) SetField(f ion.Field) error {
 	return nil
 }
 
+func encodeSortColumn(dst *ion.Buffer, st *ion.Symtab, node expr.Node, ordering vm.SortOrdering) {
+	dst.BeginList(-1)
+	node.Encode(dst, st)
+	dst.WriteBool(ordering.Direction == vm.SortDescending)
+	dst.WriteBool(ordering.NullsOrder == vm.SortNullsLast)
+	dst.EndList()
+}
+
+func decodeSortColumn(v ion.Datum) (vm.SortColumn, error) {
+	var col vm.SortColumn
+	i, err := v.Iterator()
+	if err != nil {
+		return col, err
+	}
+	v, err = i.Next()
+	if err == nil {
+		col.Node, err = expr.Decode(v)
+	}
+	if err != nil {
+		return col, err
+	}
+	desc, err := i.Bool()
+	if err != nil {
+		return col, err
+	}
+	if desc {
+		col.Ordering.Direction = vm.SortDescending
+	} else {
+		col.Ordering.Direction = vm.SortAscending
+	}
+	nullsLast, err := i.Bool()
+	if err != nil {
+		return col, err
+	}
+	if nullsLast {
+		col.Ordering.NullsOrder = vm.SortNullsLast
+	} else {
+		col.Ordering.NullsOrder = vm.SortNullsFirst
+	}
+	return col, nil
+}
+
+// RowNumber implements
+//
+//	ROW_NUMBER() OVER (PARTITION BY ... ORDER BY ...)
+//
+// without GROUP BY by sorting the rows and
+// adding a field that holds the position
+// of each row within its partition.
+type RowNumber struct {
+	Nonterminal
+	PartitionBy []expr.Node
+	OrderBy     []vm.SortColumn
+	Result      string
+}
+
+func (r *RowNumber) String() string {
+	var b strings.Builder
+	b.WriteString("ROW_NUMBER() OVER (")
+	if len(r.PartitionBy) > 0 {
+		b.WriteString("PARTITION BY ")
+		for i := range r.PartitionBy {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(expr.ToString(r.PartitionBy[i]))
+		}
+	}
+	if len(r.OrderBy) > 0 {
+		if len(r.PartitionBy) > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString("ORDER BY ")
+		for i := range r.OrderBy {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(expr.ToString(r.OrderBy[i].Node))
+			b.WriteByte(' ')
+			b.WriteString(r.OrderBy[i].Ordering.String())
+		}
+	}
+	b.WriteString(") AS ")
+	b.WriteString(r.Result)
+	return b.String()
+}
+
+func (r *RowNumber) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
+	writer, err := dst.Open()
+	if err != nil {
+		return err
+	}
+	orderBy := make([]vm.SortColumn, len(r.OrderBy))
+	for i := range orderBy {
+		orderBy[i].Node = ep.rewrite(r.OrderBy[i].Node)
+		orderBy[i].Ordering = r.OrderBy[i].Ordering
+	}
+	rn, err := vm.NewRowNumber(writer, ep.rewriteAll(r.PartitionBy), orderBy, r.Result, ep.Parallel)
+	if err != nil {
+		writer.Close()
+		return err
+	}
+	sorter := &orderSink{
+		Order: rn,
+		w:     writer,
+		dst:   dst,
+	}
+	return r.From.exec(ep.profile(r, sorter), src, ep)
+}
+
+func (r *RowNumber) encode(dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) error {
+	dst.BeginStruct(-1)
+	settype("row_number", dst, st)
+	if len(r.PartitionBy) > 0 {
+		dst.BeginField(st.Intern("partition"))
+		dst.BeginList(-1)
+		for i := range r.PartitionBy {
+			ep.rewrite(r.PartitionBy[i]).Encode(dst, st)
+		}
+		dst.EndList()
+	}
+	if len(r.OrderBy) > 0 {
+		dst.BeginField(st.Intern("columns"))
+		dst.BeginList(-1)
+		for i := range r.OrderBy {
+			encodeSortColumn(dst, st, ep.rewrite(r.OrderBy[i].Node), r.OrderBy[i].Ordering)
+		}
+		dst.EndList()
+	}
+	dst.BeginField(st.Intern("result"))
+	dst.WriteString(r.Result)
+	dst.EndStruct()
+	return nil
+}
+
+func (r *RowNumber) SetField(f ion.Field) error {
+	var err error
+	switch f.Label {
+	case "partition":
+		err = f.UnpackList(func(v ion.Datum) error {
+			e, err := expr.Decode(v)
+			if err != nil {
+				return err
+			}
+			r.PartitionBy = append(r.PartitionBy, e)
+			return nil
+		})
+	case "columns":
+		err = f.UnpackList(func(v ion.Datum) error {
+			col, err := decodeSortColumn(v)
+			if err != nil {
+				return err
+			}
+			r.OrderBy = append(r.OrderBy, col)
+			return nil
+		})
+	case "result":
+		r.Result, err = f.String()
+	default:
+		return errUnexpectedField
+	}
+	return err
+}
+
 type Distinct struct {
 	Nonterminal
 	Fields []expr.Node
@@ -1107,7 +1275,7 @@ func (d *Distinct) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 	if d.Limit > 0 {
 		df.Limit(d.Limit)
 	}
-	return d.From.exec(df, src, ep)
+	return d.From.exec(ep.profile(d, df), src, ep)
 }
 
 func (d *Distinct) encode(dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) error {
@@ -1163,6 +1331,143 @@ func (d *Distinct) String() string {
 	return str.String()
 }
 
+// SetOp implements INTERSECT and EXCEPT (see vm.SetOp),
+// as well as UNION ALL.
+type SetOp struct {
+	Nonterminal
+	Union       bool
+	Except, All bool
+	// Columns are the columns that are compared.
+	Columns []string
+	// Count is the column in the output of Right
+	// that holds the number of occurrences of a row.
+	// Count is not used by UNION ALL.
+	Count string
+	// Right is the right-hand side of the set
+	// operation; it is executed before the input
+	// of the SetOp so that it can be hashed.
+	// For UNION ALL, its rows are written directly
+	// into the output of the SetOp.
+	Right *Node
+}
+
+// noCloseSink is a vm.QuerySink that
+// does not close the underlying sink
+type noCloseSink struct {
+	vm.QuerySink
+}
+
+func (n noCloseSink) Close() error { return nil }
+
+func (s *SetOp) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
+	if s.Union {
+		dst = ep.profile(s, dst)
+		subex := ep.clone()
+		err := s.Right.exec(noCloseSink{dst}, subex)
+		ep.Stats.atomicAdd(&subex.Stats)
+		if err != nil {
+			return err
+		}
+		return s.From.exec(ep.tap(s.From, dst), src, ep)
+	}
+	table := vm.NewSetOpTable(s.Columns, s.Count)
+	subex := ep.clone()
+	err := s.Right.exec(table, subex)
+	ep.Stats.atomicAdd(&subex.Stats)
+	if err != nil {
+		return err
+	}
+	return s.From.exec(ep.profile(s, vm.NewSetOp(s.Except, s.All, table, dst)), src, ep)
+}
+
+func (s *SetOp) encode(dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) error {
+	dst.BeginStruct(-1)
+	settype("setop", dst, st)
+	if s.Union {
+		dst.BeginField(st.Intern("union"))
+		dst.WriteBool(true)
+	}
+	if s.Except {
+		dst.BeginField(st.Intern("except"))
+		dst.WriteBool(true)
+	}
+	if s.All {
+		dst.BeginField(st.Intern("all"))
+		dst.WriteBool(true)
+	}
+	dst.BeginField(st.Intern("columns"))
+	dst.BeginList(-1)
+	for i := range s.Columns {
+		dst.WriteString(s.Columns[i])
+	}
+	dst.EndList()
+	if s.Count != "" {
+		dst.BeginField(st.Intern("count"))
+		dst.WriteString(s.Count)
+	}
+	dst.BeginField(st.Intern("right"))
+	if err := s.Right.encode(dst, st, ep); err != nil {
+		return err
+	}
+	dst.EndStruct()
+	return nil
+}
+
+func (s *SetOp) SetField(f ion.Field) error {
+	var err error
+	switch f.Label {
+	case "union":
+		s.Union, err = f.Bool()
+	case "except":
+		s.Except, err = f.Bool()
+	case "all":
+		s.All, err = f.Bool()
+	case "columns":
+		err = f.UnpackList(func(v ion.Datum) error {
+			str, err := v.String()
+			if err != nil {
+				return err
+			}
+			s.Columns = append(s.Columns, str)
+			return nil
+		})
+	case "count":
+		s.Count, err = f.String()
+	case "right":
+		s.Right = &Node{}
+		err = s.Right.decode(f.Datum)
+	default:
+		return errUnexpectedField
+	}
+	return err
+}
+
+func (s *SetOp) op() string {
+	op := "INTERSECT"
+	if s.Union {
+		op = "UNION"
+	} else if s.Except {
+		op = "EXCEPT"
+	}
+	if s.All {
+		op += " ALL"
+	}
+	return op
+}
+
+// String implements fmt.Stringer
+func (s *SetOp) String() string {
+	var dst strings.Builder
+	if s.Count == "" {
+		tabfprintf(&dst, 0, "%s [%s] WITH (\n", s.op(), strings.Join(s.Columns, ", "))
+	} else {
+		tabfprintf(&dst, 0, "%s [%s] COUNT %s WITH (\n", s.op(), strings.Join(s.Columns, ", "), s.Count)
+	}
+	s.Right.describe(1, &dst)
+	dst.WriteString(")")
+	return dst.String()
+}
+
 type Unpivot struct {
 	Nonterminal
 	As *string
@@ -1218,7 +1523,7 @@ func (u *Unpivot) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 	if err != nil {
 		return err
 	}
-	return u.From.exec(vmu, src, ep)
+	return u.From.exec(ep.profile(u, vmu), src, ep)
 }
 
 func encoderec(p Op, dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) error {
@@ -1262,6 +1567,32 @@ func (t *Tree) encode(dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) error {
 		dst.BeginField(st.Intern("data"))
 		t.Data.Encode(dst, st)
 	}
+	if ep.Profile {
+		dst.BeginField(st.Intern("profile"))
+		dst.WriteBool(true)
+	}
+	if ep.Prefetch != 0 {
+		dst.BeginField(st.Intern("prefetch"))
+		dst.WriteInt(int64(ep.Prefetch))
+	}
+	if ep.BlockSize != 0 {
+		dst.BeginField(st.Intern("blocksize"))
+		dst.WriteInt(ep.BlockSize)
+	}
+	if t.Page != nil {
+		dst.BeginField(st.Intern("page"))
+		t.Page.encode(dst, st)
+	}
+	if len(t.Results) > 0 {
+		// only the names of the results are
+		// needed to produce the output
+		dst.BeginField(st.Intern("results"))
+		dst.BeginList(-1)
+		for i := range t.Results {
+			dst.WriteString(t.Results[i].Result())
+		}
+		dst.EndList()
+	}
 	dst.BeginField(st.Intern("root"))
 	if err := t.Root.encode(dst, st, ep); err != nil {
 		return err
@@ -1308,7 +1639,7 @@ func (u *UnpivotAtDistinct) exec(dst vm.QuerySink, src *Input, ep *ExecParams) e
 	if err != nil {
 		return err
 	}
-	return u.From.exec(vmu, src, ep)
+	return u.From.exec(ep.profile(u, vmu), src, ep)
 }
 
 func (u *UnpivotAtDistinct) SetField(f ion.Field) error {
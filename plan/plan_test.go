@@ -18,7 +18,9 @@ import (
 	"slices"
 	"testing"
 
+	"github.com/SnellerInc/sneller/expr"
 	"github.com/SnellerInc/sneller/expr/partiql"
+	"github.com/SnellerInc/sneller/ints"
 	"github.com/SnellerInc/sneller/ion/blockfmt"
 )
 
@@ -80,3 +82,35 @@ func TestNewSplit(t *testing.T) {
 		})
 	}
 }
+
+func TestTreeETags(t *testing.T) {
+	desc := func(etag string, blocks ints.Intervals) Descriptor {
+		return Descriptor{
+			Descriptor: blockfmt.Descriptor{
+				ObjectInfo: blockfmt.ObjectInfo{Path: "db/t/" + etag, ETag: etag},
+			},
+			Blocks: blocks,
+		}
+	}
+	mktree := func(etag string) *Tree {
+		return &Tree{
+			Inputs: []*Input{{
+				Descs: []Descriptor{
+					desc(etag, ints.Intervals{{0, 1}}),
+					desc(`"a"`, ints.Intervals{{0, 2}}),
+					desc(`"skipped"`, nil),
+				},
+			}, {
+				Descs: []Descriptor{desc(`"a"`, ints.Intervals{{1, 2}})},
+			}},
+			Root: Node{
+				Input: 0,
+				Op:    &Leaf{Orig: &expr.Table{Binding: expr.Bind(expr.Ident("t"), "")}},
+			},
+		}
+	}
+	t0 := mktree(`"b"`)
+	if got, want := t0.ETags(), []string{`"a"`, `"b"`}; !slices.Equal(got, want) {
+		t.Errorf("ETags() = %v, want %v", got, want)
+	}
+}
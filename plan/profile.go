@@ -0,0 +1,125 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package plan
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/SnellerInc/sneller/vm"
+)
+
+// profile collects the per-operator statistics
+// of a Tree that is executed with ExecParams.Profile set
+type profile struct {
+	ops  []Op
+	ids  map[Op]int
+	used []atomic.Bool // ops[i] has statistics
+	vm   []vm.OpProfile
+}
+
+// numberOps assigns an id to each op in t
+// by walking each Node from its root op to its
+// leaf op, visiting the Nodes referenced by an op
+// immediately after the op itself. Consequently,
+// the ops of a Tree whose root is the input of an
+// op with id n are numbered exactly as the same
+// ops are numbered in t, less n+1 (see merge).
+func numberOps(t *Tree) ([]Op, map[Op]int) {
+	var ops []Op
+	ids := make(map[Op]int)
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		for op := n.Op; op != nil; op = op.input() {
+			ids[op] = len(ops)
+			ops = append(ops, op)
+			switch op := op.(type) {
+			case *Substitute:
+				for i := range op.Inner {
+					walk(op.Inner[i])
+				}
+			case *SetOp:
+				walk(op.Right)
+			}
+		}
+	}
+	walk(&t.Root)
+	return ops, ids
+}
+
+func newProfile(t *Tree) *profile {
+	p := &profile{}
+	p.ops, p.ids = numberOps(t)
+	p.used = make([]atomic.Bool, len(p.ops))
+	p.vm = make([]vm.OpProfile, len(p.ops))
+	return p
+}
+
+// sink wraps the QuerySink produced by op
+func (p *profile) sink(op Op, dst vm.QuerySink) vm.QuerySink {
+	id, ok := p.ids[op]
+	if !ok {
+		return dst
+	}
+	p.used[id].Store(true)
+	return vm.ProfileSink(dst, &p.vm[id])
+}
+
+// merge adds the statistics of a sub-query
+// rooted at the input of the op with id n-1
+func (p *profile) merge(lst []OpStats, n int) {
+	for i := range lst {
+		id := lst[i].ID + n
+		if id < 0 || id >= len(p.ops) {
+			continue
+		}
+		p.used[id].Store(true)
+		atomic.AddInt64(&p.vm[id].Rows, lst[i].Rows)
+		atomic.AddInt64(&p.vm[id].Nanos, int64(lst[i].Time))
+	}
+}
+
+func (p *profile) results() []OpStats {
+	var out []OpStats
+	for i := range p.ops {
+		if !p.used[i].Load() {
+			continue
+		}
+		name, _, _ := strings.Cut(p.ops[i].String(), "\n")
+		out = append(out, OpStats{
+			ID:   i,
+			Name: name,
+			Rows: atomic.LoadInt64(&p.vm[i].Rows),
+			Time: time.Duration(atomic.LoadInt64(&p.vm[i].Nanos)),
+		})
+	}
+	return out
+}
+
+// profile returns dst wrapped so that it records
+// the statistics of op if ep.Profile is set and
+// the output of the input of op if it is tapped
+func (ep *ExecParams) profile(op Op, dst vm.QuerySink) vm.QuerySink {
+	if ep.prof != nil {
+		dst = ep.prof.sink(op, dst)
+	}
+	// the output of s.Right is also written
+	// into a UNION, so it taps s.From itself
+	if s, ok := op.(*SetOp); !ok || !s.Union {
+		dst = ep.tap(op.input(), dst)
+	}
+	return dst
+}
@@ -34,7 +34,7 @@ func (p *Project) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 	if err != nil {
 		return err
 	}
-	return p.From.exec(proj, src, ep)
+	return p.From.exec(ep.profile(p, proj), src, ep)
 }
 
 func (p *Project) encode(dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) error {
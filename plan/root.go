@@ -16,6 +16,8 @@ package plan
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"runtime"
@@ -58,9 +60,10 @@ func (r *FSRunner) Run(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 		in[i].blks = src.Descs[i].Blocks.Clone()
 	}
 	tbl := readerTable{
-		fs:     r.FS,
-		in:     in,
-		fields: src.Fields,
+		fs:        r.FS,
+		in:        in,
+		fields:    src.Fields,
+		blocksize: ep.BlockSize,
 	}
 	// fast-path for local files: use mmap for reading
 	if dfs, ok := r.FS.(*blockfmt.DirFS); ok {
@@ -87,12 +90,39 @@ type readerInput struct {
 }
 
 type readerTable struct {
-	fs      fs.FS
-	in      []readerInput
-	fields  []string
-	idx     int
-	lock    sync.Mutex
-	scanned int64
+	fs        fs.FS
+	in        []readerInput
+	fields    []string
+	blocksize int64 // see ExecParams.BlockSize
+	parallel  int   // see WriteChunks
+	idx       int
+	lock      sync.Mutex
+	scanned   int64
+	// ranges holds the parts of the blocks that have
+	// been divided into ranges of chunks and that
+	// have not been scanned yet
+	ranges []chunkRange
+}
+
+// chunkRange is a range of the decompressed
+// chunks of a block; the first part always
+// begins with a complete symbol table
+type chunkRange struct {
+	parts [][]byte
+	size  int64
+}
+
+// nextRange returns the next range of
+// chunks in f.ranges, if there is one
+func (f *readerTable) nextRange() (chunkRange, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if len(f.ranges) == 0 {
+		return chunkRange{}, false
+	}
+	r := f.ranges[len(f.ranges)-1]
+	f.ranges = f.ranges[:len(f.ranges)-1]
+	return r, true
 }
 
 func (f *readerTable) next() (in *readerInput, off int) {
@@ -129,23 +159,144 @@ func vmMalloc(size int) []byte {
 	return vm.Malloc()[:size]
 }
 
+// divide decompresses block off of in and splits it
+// into ranges of chunks of at most f.blocksize bytes,
+// which are either written to dst or handed off to
+// the other threads so that they can be scanned in
+// parallel; at most f.parallel ranges are held in
+// memory waiting for another thread at any one time
+func (f *readerTable) divide(d *blockfmt.Decoder, dst io.Writer, in *readerInput, off int) error {
+	rs := rangeSplitter{
+		f:     f,
+		dst:   dst,
+		shift: in.desc.Trailer.BlockShift,
+		n:     max(int(f.blocksize>>in.desc.Trailer.BlockShift), 1),
+	}
+	pos, end := in.desc.Trailer.BlockRange(off)
+	var err error
+	if in.mapped != nil {
+		_, err = d.CopyBytes(&rs, in.mapped[pos:end])
+	} else {
+		var src io.ReadCloser
+		src, err = fsutil.OpenRange(f.fs, in.desc.Path, in.desc.ETag, pos, end-pos)
+		if err != nil {
+			return err
+		}
+		_, err = d.Copy(&rs, src)
+		src.Close()
+	}
+	if err != nil {
+		return err
+	}
+	return rs.flush()
+}
+
+// writeRange writes the chunks in r
+func (f *readerTable) writeRange(dst io.Writer, r chunkRange) error {
+	for _, p := range r.parts {
+		if _, err := dst.Write(p); err != nil {
+			return err
+		}
+	}
+	atomic.AddInt64(&f.scanned, r.size)
+	return nil
+}
+
+// rangeSplitter collects the decompressed
+// chunks of a block into chunkRanges
+//
+// only the first chunk of a block is guaranteed
+// to begin with a complete symbol table; the others
+// may begin with additions to the symbol table or
+// none at all, so the symbol table that is in effect
+// at the start of each range is prepended to it
+type rangeSplitter struct {
+	f     *readerTable
+	dst   io.Writer
+	shift int
+	n     int // chunks per range
+	st    ion.Symtab
+	cur   chunkRange
+	tmp   ion.Buffer
+}
+
+func (r *rangeSplitter) Write(p []byte) (int, error) {
+	bvm := ion.IsBVM(p)
+	if len(r.cur.parts) == 0 && !bvm {
+		r.tmp.Reset()
+		r.st.Marshal(&r.tmp, true)
+		r.cur.parts = append(r.cur.parts, slices.Clone(r.tmp.Bytes()))
+	}
+	if bvm || ion.TypeOf(p) == ion.AnnotationType {
+		if _, err := r.st.Unmarshal(p); err != nil {
+			return 0, err
+		}
+	}
+	// p belongs to the decoder, so it is copied
+	r.cur.parts = append(r.cur.parts, slices.Clone(p))
+	r.cur.size += int64(1) << r.shift
+	if int(r.cur.size>>r.shift) == r.n {
+		if err := r.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// flush hands off the current range to another
+// thread or, if enough ranges are already waiting,
+// writes it to r.dst
+func (r *rangeSplitter) flush() error {
+	if len(r.cur.parts) == 0 {
+		return nil
+	}
+	cur := r.cur
+	r.cur = chunkRange{}
+	r.f.lock.Lock()
+	handoff := len(r.f.ranges) < r.f.parallel
+	if handoff {
+		r.f.ranges = append(r.f.ranges, cur)
+	}
+	r.f.lock.Unlock()
+	if handoff {
+		return nil
+	}
+	return r.f.writeRange(r.dst, cur)
+}
+
 func (f *readerTable) write(dst io.Writer) error {
 	var d blockfmt.Decoder
 	d.Malloc = vmMalloc
 	d.Free = vm.Free
 	d.Fields = f.fields
 	for {
+		if r, ok := f.nextRange(); ok {
+			if err := f.writeRange(dst, r); err != nil {
+				return err
+			}
+			continue
+		}
 		in, off := f.next()
 		if in == nil {
+			// another thread may have divided
+			// the last block in the meantime
+			if r, ok := f.nextRange(); ok {
+				if err := f.writeRange(dst, r); err != nil {
+					return err
+				}
+				continue
+			}
 			break
 		}
 		d.Set(&in.desc.Trailer)
-		pos := in.desc.Trailer.Blocks[off].Offset
-		end := in.desc.Trailer.Offset
-		if off < len(in.desc.Trailer.Blocks)-1 {
-			end = in.desc.Trailer.Blocks[off+1].Offset
+		size := in.desc.Trailer.DecompressedSize(off)
+		if f.blocksize > 0 && size > f.blocksize {
+			if err := f.divide(&d, dst, in, off); err != nil {
+				return err
+			}
+			continue
 		}
-		size := int64(in.desc.Trailer.Blocks[off].Chunks) << d.BlockShift
+		pos, end := in.desc.Trailer.BlockRange(off)
 		var err error
 		if in.mapped != nil {
 			_, err = d.CopyBytes(dst, in.mapped[pos:end])
@@ -175,6 +326,7 @@ func (f *readerTable) Bytes() int64 {
 }
 
 func (f *readerTable) WriteChunks(dst vm.QuerySink, parallel int) error {
+	f.parallel = parallel
 	return vm.SplitInput(dst, parallel, f.write)
 }
 
@@ -191,6 +343,11 @@ type ExecParams struct {
 	// Parallel determines the (local) parallelism
 	// of plan execution. If Parallel is unset, then
 	// runtime.GOMAXPROCS(0) is used instead.
+	//
+	// Parallel is an upper bound on the number of
+	// goroutines that evaluate the query at once;
+	// stages that run concurrently (such as the
+	// sub-queries of a Substitute) share it.
 	Parallel int
 	// Rewriter is a rewrite that should be applied
 	// to each expression in the query plan before
@@ -208,8 +365,42 @@ type ExecParams struct {
 	// This may implement UploadFS, which is
 	// required to enable support for SELECT INTO.
 	FS fs.FS
-
-	get func(i int) *Input
+	// Profile, if set, causes the statistics
+	// of each operator of the query plan to be
+	// collected into Stats.Ops. Collecting the
+	// statistics has a small cost per batch of rows.
+	Profile bool
+	// Prefetch, if positive, is the number of
+	// blocks of input that the Runner should fetch
+	// ahead of the blocks being processed, which
+	// overrides the default of the Runner.
+	// If Prefetch is negative, prefetching is disabled.
+	// Runners that don't prefetch ignore Prefetch.
+	Prefetch int
+	// BlockSize is the maximum number of (decompressed)
+	// bytes of a block of input that are scanned by one
+	// thread. Larger blocks are divided at the boundaries
+	// of their chunks and the parts are scanned in parallel.
+	// If BlockSize is zero or negative, blocks are never divided.
+	// Runners that don't divide blocks ignore BlockSize.
+	BlockSize int64
+	// SpillDir, if set, is the directory in which
+	// hash aggregates spill their groups when they
+	// outgrow SpillThreshold bytes of memory
+	// (see vm.HashAggregate.SetSpill).
+	SpillDir string
+	// SpillThreshold is the spill threshold of
+	// hash aggregates. If SpillThreshold is <= 0,
+	// vm.DefaultAggregateSpillThreshold is used.
+	SpillThreshold int
+	// Tap, if set, selects the ops whose output
+	// is written to files for debugging (see Tap).
+	// Tapping has no cost if Tap is nil.
+	Tap *Tap
+
+	get  func(i int) *Input
+	prof *profile
+	taps *taps
 }
 
 type multiRewriter struct {
@@ -300,22 +491,47 @@ func (ep *ExecParams) rewriteBind(lst []expr.Binding) []expr.Binding {
 	return newlst
 }
 
+// share returns the parallelism available to
+// each of n stages that are executed concurrently
+func (ep *ExecParams) share(n int) int {
+	if n <= 1 {
+		return ep.Parallel
+	}
+	return max(ep.Parallel/n, 1)
+}
+
 // clone everything except ep.Stats
 func (ep *ExecParams) clone() *ExecParams {
 	return &ExecParams{
-		Plan:     ep.Plan,
-		Output:   ep.Output,
-		Parallel: ep.Parallel,
-		Context:  ep.Context,
-		Rewriter: ep.Rewriter,
-		Runner:   ep.Runner,
-		FS:       ep.FS,
-		get:      ep.get,
+		Plan:           ep.Plan,
+		Output:         ep.Output,
+		Parallel:       ep.Parallel,
+		Context:        ep.Context,
+		Rewriter:       ep.Rewriter,
+		Runner:         ep.Runner,
+		FS:             ep.FS,
+		Profile:        ep.Profile,
+		Prefetch:       ep.Prefetch,
+		BlockSize:      ep.BlockSize,
+		SpillDir:       ep.SpillDir,
+		SpillThreshold: ep.SpillThreshold,
+		Tap:            ep.Tap,
+		get:            ep.get,
+		prof:           ep.prof,
+		taps:           ep.taps,
 	}
 }
 
 // Exec executes a query plan using the
 // parameters provided in [ep].
+//
+// If ep.Context is canceled or reaches its deadline
+// before the query completes, execution is stopped,
+// the output is closed, and the returned error satisfies
+// errors.Is(err, ep.Context.Err()) so that callers can
+// distinguish timeouts (context.DeadlineExceeded)
+// from other failures. The stats collected up to
+// that point remain available in ep.Stats.
 func Exec(ep *ExecParams) error {
 	if ep.Parallel == 0 {
 		ep.Parallel = runtime.GOMAXPROCS(0)
@@ -324,6 +540,11 @@ func Exec(ep *ExecParams) error {
 		ep.Context = context.Background()
 	}
 	err := (&LocalTransport{}).Exec(ep)
+	if err != nil {
+		if ctxerr := ep.Context.Err(); ctxerr != nil && !errors.Is(err, ctxerr) {
+			err = fmt.Errorf("%w: %s", ctxerr, err)
+		}
+	}
 	return err
 }
 
@@ -333,7 +554,8 @@ type LocalTransport struct {
 	// Threads is the number of threads
 	// used for query evaluation.
 	// If Threads is <= 0, then runtime.GOMAXPROCS
-	// is used.
+	// is used. If Threads is positive, it also
+	// caps ExecParams.Parallel.
 	Threads int
 }
 
@@ -350,7 +572,7 @@ func (l *LocalTransport) Encode(dst *ion.Buffer, st *ion.Symtab) {
 // Exec implements Transport.Exec
 func (l *LocalTransport) Exec(ep *ExecParams) error {
 	s := vm.LockedSink(ep.Output)
-	if ep.Parallel == 0 {
+	if ep.Parallel == 0 || (l.Threads > 0 && ep.Parallel > l.Threads) {
 		ep.Parallel = l.Threads
 	}
 	if ep.Parallel == 0 {
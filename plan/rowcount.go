@@ -0,0 +1,191 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package plan
+
+import (
+	"strconv"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/vm"
+)
+
+// RowCount is a leaf executor for
+// 'SELECT COUNT(*) FROM table' when the
+// number of rows in the table is known
+// from the table metadata, so the table
+// does not have to be scanned.
+type RowCount struct {
+	Table    expr.Node // presentation only
+	As       string    // output count name
+	NonEmpty bool      // don't output count=0
+	Count    int64     // number of rows
+}
+
+func (r *RowCount) name() string {
+	if r.As != "" {
+		return r.As
+	}
+	return "_1"
+}
+
+func (r *RowCount) String() string {
+	s := "COUNT(*) AS " + r.name() + " = " + strconv.FormatInt(r.Count, 10) + " FROM METADATA " + expr.ToString(r.Table)
+	if r.NonEmpty {
+		return "NONEMPTY " + s
+	}
+	return s
+}
+
+func (r *RowCount) input() Op   { return nil }
+func (r *RowCount) setinput(Op) { panic("RowCount: cannot setinput()") }
+
+func (r *RowCount) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
+	var b ion.Buffer
+	var st ion.Symtab
+	field := st.Intern(r.name())
+	st.Marshal(&b, true)
+	if !r.NonEmpty || r.Count != 0 {
+		b.BeginStruct(-1)
+		b.BeginField(field)
+		b.WriteInt(r.Count)
+		b.EndStruct()
+	}
+	return writeIon(&b, dst)
+}
+
+func (r *RowCount) encode(dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) error {
+	dst.BeginStruct(-1)
+	settype("rowcount", dst, st)
+	dst.BeginField(st.Intern("table"))
+	r.Table.Encode(dst, st)
+	dst.BeginField(st.Intern("as"))
+	dst.WriteString(r.As)
+	dst.BeginField(st.Intern("nonempty"))
+	dst.WriteBool(r.NonEmpty)
+	dst.BeginField(st.Intern("count"))
+	dst.WriteInt(r.Count)
+	dst.EndStruct()
+	return nil
+}
+
+func (r *RowCount) SetField(f ion.Field) error {
+	var err error
+	switch f.Label {
+	case "table":
+		r.Table, err = expr.Decode(f.Datum)
+	case "as":
+		r.As, err = f.String()
+	case "nonempty":
+		r.NonEmpty, err = f.Bool()
+	case "count":
+		r.Count, err = f.Int()
+	default:
+		return errUnexpectedField
+	}
+	return err
+}
+
+// rows returns the number of rows in the
+// blocks referenced by in, or false if it
+// is not known. The number of rows is only
+// known if every block of each object is
+// referenced and the object descriptors
+// record the number of rows in the object.
+// (An input without any objects is cheap
+// to scan, so rows returns false for it, too.)
+func (in *Input) rows() (int64, bool) {
+	if len(in.Descs) == 0 {
+		return 0, false
+	}
+	n := int64(0)
+	for i := range in.Descs {
+		d := &in.Descs[i]
+		if d.Rows <= 0 || d.Blocks.Len() != len(d.Trailer.Blocks) {
+			return 0, false
+		}
+		n += d.Rows
+	}
+	return n, true
+}
+
+// countedLeaf returns the CountStar and Leaf
+// of op if op is COUNT(*) of every row of a table,
+// either directly or through a UnionMap
+func countedLeaf(op Op) (*CountStar, *Leaf) {
+	if u, ok := op.(*UnionMap); ok {
+		op = u.From
+	}
+	c, ok := op.(*CountStar)
+	if !ok {
+		return nil, nil
+	}
+	l, ok := c.From.(*Leaf)
+	if !ok || l.Filter != nil || len(l.OnEqual) > 0 {
+		return nil, nil
+	}
+	return c, l
+}
+
+// countFromMetadata replaces COUNT(*) of every
+// row of a table in n (and in the nodes it contains)
+// with a RowCount computed from the object
+// descriptors of the table, if possible.
+func countFromMetadata(n *Node, inputs []*Input) {
+	var parent Op
+	for op := n.Op; op != nil; parent, op = op, op.input() {
+		switch op := op.(type) {
+		case *Substitute:
+			for i := range op.Inner {
+				countFromMetadata(op.Inner[i], inputs)
+			}
+		case *SetOp:
+			countFromMetadata(op.Right, inputs)
+		}
+		if n.Input < 0 || n.Input >= len(inputs) {
+			continue
+		}
+		c, l := countedLeaf(op)
+		if c == nil {
+			switch op.(type) {
+			case *UnionMap, *UnionPartition:
+				// the ops below are executed
+				// on parts of the input
+				return
+			}
+			continue
+		}
+		rows, ok := inputs[n.Input].rows()
+		if !ok {
+			return
+		}
+		rc := &RowCount{
+			Table:    l.Orig.Expr,
+			As:       c.As,
+			NonEmpty: c.NonEmpty,
+			Count:    rows,
+		}
+		if parent == nil {
+			n.Op = rc
+		} else {
+			parent.setinput(rc)
+		}
+		// the input is not read, but it is
+		// kept in the tree so that the result
+		// is invalidated when the table changes
+		n.Input = -1
+		return
+	}
+}
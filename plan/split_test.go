@@ -54,6 +54,12 @@ func (twosplit) Geometry() *Geometry {
 	}
 }
 
+func init() {
+	// the planner only needs the name
+	// of a user-defined aggregate
+	expr.RegisterAggregate("split_udaf", nil, nil, nil, nil)
+}
+
 func TestSplit(t *testing.T) {
 	env := emptyenv{}
 	tcs := []struct {
@@ -79,6 +85,16 @@ func TestSplit(t *testing.T) {
 				`AGGREGATE MAX($_2_0) AS "max"`,
 			},
 		},
+		{
+			// the partial sums of decimals are merged exactly
+			query: `SELECT SUM(CAST(n AS DECIMAL)) AS s FROM table`,
+			lines: []string{
+				`table`,
+				`AGGREGATE SUM.PARTIAL(CAST(n AS DECIMAL)) AS $_2_0`,
+				`UNION MAP`,
+				`AGGREGATE SUM.MERGE(CAST($_2_0 AS DECIMAL)) AS s`,
+			},
+		},
 		{
 			query: `SELECT AVG(n) AS avg FROM table`,
 			lines: []string{
@@ -118,6 +134,51 @@ func TestSplit(t *testing.T) {
 				`PROJECT CASE WHEN $_0_0 = 0 THEN NULL ELSE SQRT($_0_1 / $_0_0 - ($_0_2 / $_0_0 * ($_0_2 / $_0_0))) END AS "stddev"`,
 			},
 		},
+		{
+			query: `SELECT COUNT(*) AS n, STRING_AGG(x, ',' ORDER BY y DESC NULLS LAST) AS s FROM table`,
+			lines: []string{
+				`table`,
+				`AGGREGATE COUNT(*) AS $_2_0, STRING_AGG.PARTIAL(x, ',' ORDER BY y DESC NULLS LAST) AS $_2_1`,
+				`UNION MAP`,
+				`AGGREGATE SUM_COUNT($_2_0) AS n, STRING_AGG.MERGE($_2_1, ',' ORDER BY MISSING DESC NULLS LAST) AS s`,
+			},
+		},
+		{
+			query: `SELECT g, STRING_AGG(x, '|') AS s FROM table GROUP BY g ORDER BY s LIMIT 10`,
+			lines: []string{
+				`table`,
+				`HASH AGGREGATE STRING_AGG.PARTIAL(x, '|') AS $_2_0 GROUP BY g AS g`,
+				`UNION MAP`,
+				`HASH AGGREGATE STRING_AGG.MERGE($_2_0, '|') AS s GROUP BY g AS g ORDER BY STRING_AGG.MERGE($_2_0, '|') AS s LIMIT 10`,
+			},
+		},
+		{
+			query: `SELECT TOPK(x, 3, 50) AS t FROM table`,
+			lines: []string{
+				`table`,
+				`AGGREGATE TOPK.PARTIAL(x, 3, 50) AS $_2_0`,
+				`UNION MAP`,
+				`AGGREGATE TOPK.MERGE($_2_0, 3, 50) AS t`,
+			},
+		},
+		{
+			query: `SELECT MODE(x) AS m FROM table`,
+			lines: []string{
+				`table`,
+				`AGGREGATE MODE.PARTIAL(x) AS $_2_0`,
+				`UNION MAP`,
+				`AGGREGATE MODE.MERGE($_2_0) AS m`,
+			},
+		},
+		{
+			query: `SELECT SPLIT_UDAF(x) AS u FROM table`,
+			lines: []string{
+				`table`,
+				`AGGREGATE SPLIT_UDAF.PARTIAL(x) AS $_2_0`,
+				`UNION MAP`,
+				`AGGREGATE SPLIT_UDAF.MERGE($_2_0) AS u`,
+			},
+		},
 	}
 
 	for i := range tcs {
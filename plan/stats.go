@@ -17,7 +17,9 @@ package plan
 import (
 	"fmt"
 	"sync/atomic"
+	"time"
 
+	"github.com/SnellerInc/sneller/expr"
 	"github.com/SnellerInc/sneller/ion"
 	"github.com/SnellerInc/sneller/vm"
 )
@@ -38,6 +40,98 @@ type ExecStats struct {
 	// BytesScanned is the number
 	// of bytes scanned.
 	BytesScanned int64
+	// Ops, if ExecParams.Profile was set,
+	// contains the statistics of each
+	// of the operators of the query plan
+	// that processed rows, in order of OpStats.ID.
+	Ops []OpStats
+	// Next, if the query was executed
+	// with Tree.Page set and it has more
+	// rows than fit in the page, is the
+	// position of the first of those rows.
+	Next *ScanPosition
+	// Tables describes the fields read from
+	// each of the tables of the query, in the
+	// order of Tree.Inputs; it can be used to check
+	// whether the fields referenced by the query
+	// were pushed down to the scan of each table.
+	Tables []TableStats
+}
+
+// TableStats describes the projection
+// pushdown of one of the tables of a query.
+type TableStats struct {
+	// Table is the table expression.
+	Table string
+	// Fields are the top-level fields of the
+	// table that are read by the query in
+	// lexicographical order, unless AllFields is set.
+	Fields []string
+	// AllFields is set if every field of the
+	// table is read, either because the query
+	// references all of them (i.e. via "*") or
+	// because the fields could not be pushed down.
+	AllFields bool
+}
+
+// fieldStats returns the TableStats
+// of each of the inputs of t
+func (t *Tree) fieldStats() []TableStats {
+	if len(t.Inputs) == 0 {
+		return nil
+	}
+	out := make([]TableStats, len(t.Inputs))
+	for i, in := range t.Inputs {
+		out[i].Fields = in.Fields
+		out[i].AllFields = in.Fields == nil
+	}
+	var walk func(*Node)
+	walk = func(n *Node) {
+		for op := n.Op; op != nil; op = op.input() {
+			switch op := op.(type) {
+			case *Leaf:
+				if i := n.Input; i >= 0 && i < len(out) && out[i].Table == "" && op.Orig != nil {
+					out[i].Table = expr.ToString(op.Orig.Expr)
+				}
+			case *Substitute:
+				for j := range op.Inner {
+					walk(op.Inner[j])
+				}
+			case *SetOp:
+				walk(op.Right)
+			}
+		}
+	}
+	walk(&t.Root)
+	return out
+}
+
+// OpStats are the statistics of
+// one operator of a query plan.
+type OpStats struct {
+	// ID identifies the operator.
+	// The ops of each Node of the plan
+	// are numbered in reverse execution order,
+	// so the op that produces the output of
+	// the query has ID 0. The ops of a Node
+	// referenced by an op (for example, the
+	// sub-queries of a SetOp) are numbered
+	// immediately after that op.
+	ID int
+	// Name is the description of the
+	// operator as it appears in the plan.
+	Name string
+	// Rows is the number of rows
+	// written into the operator.
+	Rows int64
+	// Time is the time spent processing the
+	// rows written into the operator, summed
+	// across all of the threads of execution.
+	// Since rows are pushed from the leaves
+	// of the plan toward the root, this
+	// includes the time spent in the operators
+	// with smaller IDs in the same Node.
+	Time time.Duration
 }
 
 // CachedTable is an interface optionally
@@ -53,6 +147,8 @@ type CachedTable interface {
 	Bytes() int64
 }
 
+// atomicAdd adds the counters in tmp to e;
+// tmp.Ops are not merged (see profile.merge)
 func (e *ExecStats) atomicAdd(tmp *ExecStats) {
 	atomic.AddInt64(&e.CacheHits, tmp.CacheHits)
 	atomic.AddInt64(&e.CacheMisses, tmp.CacheMisses)
@@ -92,9 +188,108 @@ func (e *ExecStats) Encode(dst *ion.Buffer, st *ion.Symtab) {
 		dst.BeginField(st.Intern("scanned"))
 		dst.WriteInt(e.BytesScanned)
 	}
+	if len(e.Ops) > 0 {
+		dst.BeginField(st.Intern("ops"))
+		dst.BeginList(-1)
+		for i := range e.Ops {
+			e.Ops[i].encode(dst, st)
+		}
+		dst.EndList()
+	}
+	if e.Next != nil {
+		dst.BeginField(st.Intern("next_blob"))
+		dst.WriteInt(int64(e.Next.Blob))
+		dst.BeginField(st.Intern("next_row"))
+		dst.WriteInt(e.Next.Row)
+	}
+	if len(e.Tables) > 0 {
+		dst.BeginField(st.Intern("tables"))
+		dst.BeginList(-1)
+		for i := range e.Tables {
+			e.Tables[i].encode(dst, st)
+		}
+		dst.EndList()
+	}
 	dst.EndStruct()
 }
 
+func (t *TableStats) encode(dst *ion.Buffer, st *ion.Symtab) {
+	dst.BeginStruct(-1)
+	dst.BeginField(st.Intern("table"))
+	dst.WriteString(t.Table)
+	if t.AllFields {
+		dst.BeginField(st.Intern("all_fields"))
+		dst.WriteBool(true)
+	} else {
+		dst.BeginField(st.Intern("fields"))
+		dst.BeginList(-1)
+		for i := range t.Fields {
+			dst.WriteString(t.Fields[i])
+		}
+		dst.EndList()
+	}
+	dst.EndStruct()
+}
+
+func (t *TableStats) decode(buf []byte, st *ion.Symtab) error {
+	_, err := ion.UnpackStruct(st, buf, func(name string, body []byte) error {
+		var err error
+		switch name {
+		case "table":
+			t.Table, _, err = ion.ReadString(body)
+		case "all_fields":
+			t.AllFields, _, err = ion.ReadBool(body)
+		case "fields":
+			t.Fields = []string{}
+			_, err = ion.UnpackList(body, func(body []byte) error {
+				s, _, err := ion.ReadString(body)
+				t.Fields = append(t.Fields, s)
+				return err
+			})
+		default:
+			return errUnexpectedField
+		}
+		return err
+	})
+	return err
+}
+
+func (o *OpStats) encode(dst *ion.Buffer, st *ion.Symtab) {
+	dst.BeginStruct(-1)
+	dst.BeginField(st.Intern("id"))
+	dst.WriteInt(int64(o.ID))
+	dst.BeginField(st.Intern("name"))
+	dst.WriteString(o.Name)
+	dst.BeginField(st.Intern("rows"))
+	dst.WriteInt(o.Rows)
+	dst.BeginField(st.Intern("nanos"))
+	dst.WriteInt(int64(o.Time))
+	dst.EndStruct()
+}
+
+func (o *OpStats) decode(buf []byte, st *ion.Symtab) error {
+	_, err := ion.UnpackStruct(st, buf, func(name string, body []byte) error {
+		var err error
+		var i int64
+		switch name {
+		case "id":
+			i, _, err = ion.ReadInt(body)
+			o.ID = int(i)
+		case "name":
+			o.Name, _, err = ion.ReadString(body)
+		case "rows":
+			o.Rows, _, err = ion.ReadInt(body)
+		case "nanos":
+			i, _, err = ion.ReadInt(body)
+			o.Time = time.Duration(i)
+		default:
+			return errUnexpectedField
+		}
+		return err
+	})
+	return err
+}
+
 func (e *ExecStats) Decode(buf []byte, st *ion.Symtab) error {
 	_, err := ion.UnpackStruct(st, buf, func(name string, body []byte) error {
 		var err error
@@ -105,6 +300,30 @@ func (e *ExecStats) Decode(buf []byte, st *ion.Symtab) error {
 			e.CacheMisses, _, err = ion.ReadInt(body)
 		case "scanned":
 			e.BytesScanned, _, err = ion.ReadInt(body)
+		case "ops":
+			e.Ops = e.Ops[:0]
+			_, err = ion.UnpackList(body, func(body []byte) error {
+				e.Ops = append(e.Ops, OpStats{})
+				return e.Ops[len(e.Ops)-1].decode(body, st)
+			})
+		case "next_blob":
+			if e.Next == nil {
+				e.Next = new(ScanPosition)
+			}
+			var i int64
+			i, _, err = ion.ReadInt(body)
+			e.Next.Blob = int(i)
+		case "next_row":
+			if e.Next == nil {
+				e.Next = new(ScanPosition)
+			}
+			e.Next.Row, _, err = ion.ReadInt(body)
+		case "tables":
+			e.Tables = e.Tables[:0]
+			_, err = ion.UnpackList(body, func(body []byte) error {
+				e.Tables = append(e.Tables, TableStats{})
+				return e.Tables[len(e.Tables)-1].decode(body, st)
+			})
 		default:
 			return errUnexpectedField
 		}
@@ -132,6 +351,17 @@ func init() {
 		"hits",
 		"misses",
 		"scanned",
+		"ops",
+		"id",
+		"name",
+		"rows",
+		"nanos",
+		"next_blob",
+		"next_row",
+		"tables",
+		"table",
+		"fields",
+		"all_fields",
 	} {
 		statsSymtab.Intern(s)
 	}
@@ -73,6 +73,62 @@ func (r *replacement) toScalarList() ion.Bag {
 	return ret
 }
 
+// toQuantified produces x <op> ANY (...) or x <op> ALL (...)
+// for op = or <> from the first column of each row
+func (r *replacement) toQuantified(op expr.CmpOp, all bool, x expr.Node) expr.Node {
+	if len(r.rows) == 0 {
+		return expr.Bool(all)
+	}
+	var set ion.Bag
+	var distinct []ion.Datum
+	nulls := false
+	for i := range r.rows {
+		f, ok := first(&r.rows[i])
+		if !ok || f.Datum.IsNull() {
+			nulls = true
+			continue
+		}
+		set.AddDatum(f.Datum)
+		if len(distinct) < 2 && (len(distinct) == 0 || !distinct[0].Equal(f.Datum)) {
+			distinct = append(distinct, f.Datum)
+		}
+	}
+	if (op == expr.Equals) != all {
+		// = ANY and <> ALL are (NOT) IN,
+		// except that they are NULL rather than
+		// FALSE if there are NULLs in the results
+		in := &expr.Member{Arg: x, Set: set}
+		if nulls {
+			return expr.IfThenElse(in, expr.Bool(!all), expr.Null{})
+		}
+		if all {
+			return &expr.Not{Expr: in}
+		}
+		return in
+	}
+	// = ALL and <> ANY are determined by the first
+	// two distinct values: x cannot be equal to both
+	if len(distinct) == 0 {
+		return expr.Null{}
+	}
+	ret := expr.Compare(op, expr.Copy(x), mustConst(distinct[0]))
+	if len(distinct) > 1 {
+		cmp := expr.Compare(op, expr.Copy(x), mustConst(distinct[1]))
+		if all {
+			ret = expr.And(ret, cmp)
+		} else {
+			ret = expr.Or(ret, cmp)
+		}
+	}
+	if nulls {
+		if all {
+			return expr.IfThenElse(&expr.Not{Expr: ret}, expr.Bool(false), expr.Null{})
+		}
+		return expr.IfThenElse(ret, expr.Bool(true), expr.Null{})
+	}
+	return ret
+}
+
 func (r *replacement) toList() expr.Constant {
 	lst := new(expr.List)
 	for i := range r.rows {
@@ -90,6 +146,9 @@ func (r *replacement) toStruct() expr.Constant {
 
 func (r *replacement) toHashLookup(kind, label string, x, elseval expr.Node) expr.Node {
 	if len(r.rows) == 0 {
+		if elseval != nil {
+			return elseval
+		}
 		return expr.Missing{}
 	}
 	var conv rowConverter
@@ -358,6 +417,10 @@ func (r *replacer) Rewrite(e expr.Node) expr.Node {
 			Arg: b.Args[0],
 			Set: r.inputs[id].toScalarList(),
 		}
+	case expr.AnyReplacement, expr.AllReplacement:
+		op := expr.CmpOp(b.Args[0].(expr.Integer))
+		id := int(b.Args[2].(expr.Integer))
+		return r.inputs[id].toQuantified(op, b.Func == expr.AllReplacement, b.Args[1])
 	case expr.HashReplacement:
 		id := int(b.Args[0].(expr.Integer))
 		kind := string(b.Args[1].(expr.String))
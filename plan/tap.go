@@ -0,0 +1,220 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package plan
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/vm"
+)
+
+// Tap selects the ops of a query plan whose
+// output is written to files as it is produced,
+// so that the rows passed from each stage of
+// a query to the next can be inspected.
+//
+// The ops are identified by the ids that appear
+// in OpStats.ID (see ExecParams.Profile). The output
+// of the op with id n is written to Dir/op<n>.ion
+// as a standalone ion stream, so it can be read
+// back like any other ion data (for example, to
+// replay the rest of the query on it).
+// The file of an op that is executed but produces
+// no rows is empty.
+//
+// Only the ops that are executed locally are tapped;
+// the ops of sub-queries sent to remote peers are not.
+type Tap struct {
+	// Dir is the directory in which
+	// the files are created.
+	Dir string
+	// Ops are the ids of the tapped ops.
+	// If Ops is empty, every op is tapped.
+	Ops []int
+}
+
+// TapFile returns the path of the file
+// holding the output of the op with the given id.
+func (t *Tap) TapFile(id int) string {
+	return filepath.Join(t.Dir, fmt.Sprintf("op%d.ion", id))
+}
+
+// taps is the state of a Tap
+// while a Tree is executed
+type taps struct {
+	tap *Tap
+	ids map[Op]int
+
+	lock  sync.Mutex
+	files map[int]*tapFile
+	err   error // first error creating a file
+}
+
+func newTaps(t *Tree, tap *Tap) *taps {
+	_, ids := numberOps(t)
+	return &taps{tap: tap, ids: ids, files: make(map[int]*tapFile)}
+}
+
+// sink wraps the QuerySink into which op writes its output
+func (t *taps) sink(op Op, dst vm.QuerySink) vm.QuerySink {
+	id, ok := t.ids[op]
+	if !ok || (len(t.tap.Ops) > 0 && !slices.Contains(t.tap.Ops, id)) {
+		return dst
+	}
+	f := t.file(id)
+	if f == nil {
+		return dst
+	}
+	return &tapSink{dst: dst, file: f}
+}
+
+// file returns the file holding the output of
+// the op with the given id, creating it if necessary
+func (t *taps) file(id int) *tapFile {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if f := t.files[id]; f != nil {
+		return f
+	}
+	if t.err != nil {
+		return nil
+	}
+	err := os.MkdirAll(t.tap.Dir, 0750)
+	if err != nil {
+		t.err = err
+		return nil
+	}
+	out, err := os.Create(t.tap.TapFile(id))
+	if err != nil {
+		t.err = err
+		return nil
+	}
+	f := &tapFile{out: out}
+	t.files[id] = f
+	return f
+}
+
+// close closes all of the files and returns
+// the first error encountered while writing them
+func (t *taps) close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	err := t.err
+	for _, f := range t.files {
+		err = errors.Join(err, f.close())
+	}
+	return err
+}
+
+// tapFile is a file into which the
+// chunks of several streams are written
+type tapFile struct {
+	lock sync.Mutex
+	out  *os.File
+	buf  ion.Buffer
+	err  error
+}
+
+// write writes body preceded by st, which makes
+// each chunk valid regardless of the chunks of
+// other streams that precede it in the file
+func (f *tapFile) write(st *ion.Symtab, body []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.buf.Reset()
+	st.Marshal(&f.buf, true)
+	_, f.err = f.out.Write(f.buf.Bytes())
+	if f.err == nil {
+		_, f.err = f.out.Write(body)
+	}
+	return f.err
+}
+
+func (f *tapFile) close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	err := f.out.Close()
+	if f.err != nil {
+		return f.err
+	}
+	return err
+}
+
+// tapSink is a QuerySink that copies
+// the data written into it to a tapFile
+type tapSink struct {
+	dst  vm.QuerySink
+	file *tapFile
+}
+
+func (t *tapSink) Open() (io.WriteCloser, error) {
+	w, err := t.dst.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &tapWriter{WriteCloser: w, file: t.file}, nil
+}
+
+func (t *tapSink) Close() error { return t.dst.Close() }
+
+// tapWriter is the io.WriteCloser returned from
+// tapSink.Open; since it does not implement the
+// fast paths of the writers returned by the vm,
+// the rows written into it are always serialized
+type tapWriter struct {
+	io.WriteCloser
+	file *tapFile
+	st   ion.Symtab // the current symbol table of the stream
+}
+
+func (t *tapWriter) Write(p []byte) (int, error) {
+	body := p
+	if len(p) >= 4 && ion.IsBVM(p) || len(p) > 0 && ion.TypeOf(p) == ion.AnnotationType {
+		rest, err := t.st.Unmarshal(p)
+		if err != nil {
+			return 0, fmt.Errorf("tap: %w", err)
+		}
+		body = rest
+	}
+	if err := t.file.write(&t.st, body); err != nil {
+		return 0, fmt.Errorf("tap: %w", err)
+	}
+	return t.WriteCloser.Write(p)
+}
+
+// EndSegment implements vm.EndSegmentWriter.EndSegment
+func (t *tapWriter) EndSegment() {
+	vm.HintEndSegment(t.WriteCloser)
+}
+
+// tap returns dst wrapped so that the output
+// that op writes into it is written to a file
+// if ep.Tap is set and selects op
+func (ep *ExecParams) tap(op Op, dst vm.QuerySink) vm.QuerySink {
+	if ep.taps == nil || op == nil {
+		return dst
+	}
+	return ep.taps.sink(op, dst)
+}
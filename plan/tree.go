@@ -17,6 +17,7 @@ package plan
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 
@@ -54,8 +55,34 @@ type Tree struct {
 	// Root is the root node of the plan tree.
 	Root Node
 
+	// Results are the output bindings of the
+	// query, if they are known (i.e. the query
+	// does not select *), and ResultTypes are
+	// their types. Encode only preserves the
+	// names of the Results.
 	Results     []expr.Binding
 	ResultTypes []expr.TypeSet
+
+	// Page, if non-nil, restricts the output of
+	// the tree to one page of its rows, and
+	// ExecStats.Next is set to the position of
+	// the first row of the following page.
+	// Only a (non-split) query over one table
+	// that neither orders, aggregates nor limits
+	// its rows can be executed one page at a time;
+	// its rows are then produced in the same order
+	// every time it is executed on the same inputs.
+	Page *Page
+
+	// profile is set if the Tree was decoded
+	// from a query executed with ExecParams.Profile
+	profile bool
+	// prefetch is ExecParams.Prefetch
+	// of the query that was encoded
+	prefetch int
+	// blocksize is ExecParams.BlockSize
+	// of the query that was encoded
+	blocksize int64
 }
 
 func tabify(n int, dst *strings.Builder) {
@@ -110,10 +137,13 @@ func (t *Tree) MaxScanned() int64 {
 			ret += t.Inputs[i].Size()
 		}
 		for op := n.Op; op != nil; op = op.input() {
-			if s, ok := op.(*Substitute); ok {
-				for j := range s.Inner {
-					walk(s.Inner[j])
+			switch op := op.(type) {
+			case *Substitute:
+				for j := range op.Inner {
+					walk(op.Inner[j])
 				}
+			case *SetOp:
+				walk(op.Right)
 			}
 		}
 	}
@@ -121,6 +151,21 @@ func (t *Tree) MaxScanned() int64 {
 	return ret
 }
 
+// ETags returns the sorted list of the distinct
+// ETags of the objects with blocks read by t.
+func (t *Tree) ETags() []string {
+	var out []string
+	for _, in := range t.Inputs {
+		for i := range in.Descs {
+			if !in.Descs[i].Empty() {
+				out = append(out, in.Descs[i].ETag)
+			}
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
 // Substitute is an Op that substitutes the result
 // of executing a list of Nodes into its input Op.
 type Substitute struct {
@@ -139,8 +184,10 @@ func (s *Substitute) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 	var wg sync.WaitGroup
 	wg.Add(len(s.Inner))
 	errlist := make([]error, len(s.Inner))
+	parallel := ep.share(len(s.Inner))
 	for i := range s.Inner {
 		subex := ep.clone()
+		subex.Parallel = parallel
 		go func(i int) {
 			defer wg.Done()
 			errlist[i] = s.Inner[i].exec(&rp[i], subex)
@@ -153,7 +200,7 @@ func (s *Substitute) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 	}
 	ep.AddRewrite(&replacer{inputs: rp, simpl: expr.Simplifier(expr.NoHint)})
 	defer ep.PopRewrite()
-	return s.From.exec(dst, src, ep)
+	return s.From.exec(ep.tap(s.From, dst), src, ep)
 }
 
 func (s *Substitute) encode(dst *ion.Buffer, st *ion.Symtab, ep *ExecParams) error {
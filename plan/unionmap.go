@@ -156,6 +156,10 @@ func (u *UnionMap) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 			// subep.get will be clobbered by Exec here:
 			errors[i] = tp.Exec(subep)
 			ep.Stats.atomicAdd(&subep.Stats)
+			if ep.prof != nil {
+				// the sub-query is numbered from u.From
+				ep.prof.merge(subep.Stats.Ops, ep.prof.ids[u]+1)
+			}
 		}(i)
 	}
 	wg.Wait()
@@ -85,5 +85,5 @@ func (u *Unnest) exec(dst vm.QuerySink, src *Input, ep *ExecParams) error {
 	if err != nil {
 		return err
 	}
-	return u.From.exec(op, src, ep)
+	return u.From.exec(ep.profile(u, op), src, ep)
 }
@@ -0,0 +1,324 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package regexp2
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DsDelim is a data structure for finding delimiters: a DFA that
+// matches a regex at a given position of a string and yields the
+// end of the leftmost-first match of Go's regexp at that position.
+// Unlike the DFAs of DsTiny and DsLarge, the accepting states keep
+// their outgoing edges, so the DFA continues after a match until
+// no thread of higher priority than the match is left.
+//
+// The data is a sequence of uint32s:
+//
+//	[0]  byte offset of the start state at the start of the string
+//	[1]  byte offset of the start state elsewhere
+//
+// followed by the states, each of which is
+//
+//	[0]  flags: bit 0 is set if the match ends here, and bit 1
+//	     is set if the match ends here at the end of the string
+//	[1]  number of edges
+//	[2:] the edges as (min, max, byte offset of the next state),
+//	     where min and max are UTF-8 ints and the edges are sorted by min
+//
+// A code-point without an edge ends the match.
+type DsDelim struct {
+	data []uint32
+}
+
+const (
+	delimMatch      = 1 << 0 // the match ends in the state
+	delimMatchAtEnd = 1 << 1 // the match ends in the state at the end of the string
+)
+
+type delimState struct {
+	pcs    []uint32 // threads in priority order
+	match  bool     // a thread has matched
+	id     int      // index of the state in delimBuilder.order
+	offset uint32   // byte offset of the state in the data
+	edges  []uint32 // (min, max, id of the next state)
+}
+
+type delimBuilder struct {
+	prog   *syntax.Prog
+	states map[string]*delimState
+	order  []*delimState
+
+	// scratch space for computing states
+	seen []bool
+	pcs  []uint32
+	key  []byte
+}
+
+// NewDsDelim creates a data structure that finds the matches of regex
+// at a given position; the only assertions regex may contain are ^
+// and $ (without the m flag). An error is returned if the DFA has more
+// than maxNodes states.
+func NewDsDelim(regex *regexp.Regexp, maxNodes int) (*DsDelim, error) {
+	prog := extractProg(regex)
+	for i := range prog.Inst {
+		inst := &prog.Inst[i]
+		if inst.Op == syntax.InstEmptyWidth {
+			switch syntax.EmptyOp(inst.Arg) {
+			case syntax.EmptyBeginText, syntax.EmptyEndText:
+			default:
+				return nil, fmt.Errorf("regex %q contains an unsupported assertion", regex.String())
+			}
+		}
+	}
+	b := &delimBuilder{
+		prog:   prog,
+		states: make(map[string]*delimState),
+		seen:   make([]bool, len(prog.Inst)),
+	}
+	classes := b.classes()
+
+	// the start states are followed by the states in the order they are found
+	start := [2]*delimState{b.start(true), b.start(false)}
+	for i := 0; i < len(b.order); i++ {
+		s := b.order[i]
+		var last *delimState
+		for j, lo := range classes {
+			hi := rune(utf8.MaxRune)
+			if j+1 < len(classes) {
+				hi = classes[j+1] - 1
+			}
+			next := b.next(s.pcs, lo)
+			if len(next.pcs) == 0 && !next.match {
+				last = nil // no edge
+				continue
+			}
+			if next == last {
+				s.edges[len(s.edges)-2] = utf8int(hi) // extend the previous edge
+				continue
+			}
+			s.edges = append(s.edges, utf8int(lo), utf8int(hi), uint32(next.id))
+			last = next
+		}
+		if len(b.order) > maxNodes {
+			return nil, fmt.Errorf("DFA exceeds max number of nodes %v::NewDsDelim", maxNodes)
+		}
+	}
+
+	offset := uint32(8)
+	for _, s := range b.order {
+		s.offset = offset
+		offset += 8 + uint32(len(s.edges))*4
+	}
+	result := &DsDelim{data: []uint32{start[0].offset, start[1].offset}}
+	for _, s := range b.order {
+		flags := uint32(0)
+		if s.match {
+			flags |= delimMatch | delimMatchAtEnd
+		} else if b.matchAtEnd(s.pcs) {
+			flags |= delimMatchAtEnd
+		}
+		result.data = append(result.data, flags, uint32(len(s.edges)/3))
+		for i := 0; i < len(s.edges); i += 3 {
+			result.data = append(result.data, s.edges[i], s.edges[i+1], b.order[s.edges[i+2]].offset)
+		}
+	}
+	return result, nil
+}
+
+// classes returns the first code-points of the ranges
+// of code-points that the instructions of the program
+// do not distinguish, in ascending order
+func (b *delimBuilder) classes() []rune {
+	bounds := []rune{0}
+	add := func(lo, hi rune) {
+		bounds = append(bounds, lo)
+		if hi < utf8.MaxRune {
+			bounds = append(bounds, hi+1)
+		}
+	}
+	for i := range b.prog.Inst {
+		inst := &b.prog.Inst[i]
+		switch inst.Op {
+		case syntax.InstRune:
+			if len(inst.Rune) == 1 {
+				r := inst.Rune[0]
+				add(r, r)
+				if syntax.Flags(inst.Arg)&syntax.FoldCase != 0 {
+					for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+						add(f, f)
+					}
+				}
+				break
+			}
+			for j := 0; j+1 < len(inst.Rune); j += 2 {
+				add(inst.Rune[j], inst.Rune[j+1])
+			}
+		case syntax.InstRune1:
+			add(inst.Rune[0], inst.Rune[0])
+		case syntax.InstRuneAnyNotNL:
+			add('\n', '\n')
+		}
+	}
+	slices.Sort(bounds)
+	return slices.Compact(bounds)
+}
+
+// add appends the threads reached from pc to b.pcs in priority
+// order; it returns true if one of them is a match, in which
+// case the threads of lower priority must not be added
+func (b *delimBuilder) add(pc uint32, begin bool) bool {
+	if b.seen[pc] {
+		return false
+	}
+	b.seen[pc] = true
+	inst := &b.prog.Inst[pc]
+	switch inst.Op {
+	case syntax.InstAlt, syntax.InstAltMatch:
+		return b.add(inst.Out, begin) || b.add(inst.Arg, begin)
+	case syntax.InstCapture, syntax.InstNop:
+		return b.add(inst.Out, begin)
+	case syntax.InstEmptyWidth:
+		if syntax.EmptyOp(inst.Arg) == syntax.EmptyBeginText {
+			return begin && b.add(inst.Out, begin)
+		}
+		// $ waits for the end of the string
+		b.pcs = append(b.pcs, pc)
+		return false
+	case syntax.InstMatch:
+		return true
+	case syntax.InstFail:
+		return false
+	default:
+		b.pcs = append(b.pcs, pc)
+		return false
+	}
+}
+
+// matchAtEnd returns true if one of the threads
+// matches when the end of the string is reached
+func (b *delimBuilder) matchAtEnd(pcs []uint32) bool {
+	clear(b.seen)
+	var end func(pc uint32) bool
+	end = func(pc uint32) bool {
+		if b.seen[pc] {
+			return false
+		}
+		b.seen[pc] = true
+		inst := &b.prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			return end(inst.Out) || end(inst.Arg)
+		case syntax.InstCapture, syntax.InstNop:
+			return end(inst.Out)
+		case syntax.InstEmptyWidth:
+			// the string is not empty, so ^ does not match at its end
+			return syntax.EmptyOp(inst.Arg) == syntax.EmptyEndText && end(inst.Out)
+		case syntax.InstMatch:
+			return true
+		}
+		return false
+	}
+	for _, pc := range pcs {
+		if inst := &b.prog.Inst[pc]; inst.Op == syntax.InstEmptyWidth && end(inst.Out) {
+			return true
+		}
+	}
+	return false
+}
+
+// start returns the start state at the
+// start of the string if begin is set,
+// or the start state elsewhere otherwise
+func (b *delimBuilder) start(begin bool) *delimState {
+	clear(b.seen)
+	b.pcs = b.pcs[:0]
+	return b.state(b.add(uint32(b.prog.Start), begin))
+}
+
+// next returns the state following the threads in from on r
+func (b *delimBuilder) next(from []uint32, r rune) *delimState {
+	clear(b.seen)
+	b.pcs = b.pcs[:0]
+	match := false
+	for _, pc := range from {
+		inst := &b.prog.Inst[pc]
+		if inst.Op != syntax.InstEmptyWidth && delimMatchRune(inst, r) && b.add(inst.Out, false) {
+			match = true
+			break
+		}
+	}
+	return b.state(match)
+}
+
+// state returns the state with the threads in b.pcs
+func (b *delimBuilder) state(match bool) *delimState {
+	b.key = b.key[:0]
+	if match {
+		b.key = append(b.key, 1)
+	}
+	for _, pc := range b.pcs {
+		b.key = binary.LittleEndian.AppendUint32(b.key, pc)
+	}
+	if s, ok := b.states[string(b.key)]; ok {
+		return s
+	}
+	s := &delimState{pcs: slices.Clone(b.pcs), match: match, id: len(b.order)}
+	b.states[string(b.key)] = s
+	b.order = append(b.order, s)
+	return s
+}
+
+func delimMatchRune(inst *syntax.Inst, r rune) bool {
+	switch inst.Op {
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return r != '\n'
+	default:
+		return inst.MatchRune(r)
+	}
+}
+
+// utf8int returns the UTF-8 int of r; unlike runeToUtf8int,
+// it encodes surrogates too, so that the UTF-8 ints of
+// code-points are in the same order as the code-points
+func utf8int(r rune) uint32 {
+	v := uint32(r)
+	switch {
+	case v < 0x80:
+		return v
+	case v < 0x800:
+		return 0xC080 | (v>>6)<<8 | v&0x3F
+	case v < 0x10000:
+		return 0xE08080 | (v>>12)<<16 | (v>>6&0x3F)<<8 | v&0x3F
+	default:
+		return 0xF0808080 | (v>>18)<<24 | (v>>12&0x3F)<<16 | (v>>6&0x3F)<<8 | v&0x3F
+	}
+}
+
+func (d *DsDelim) Data() []byte {
+	result := make([]byte, len(d.data)*4)
+	for i, v := range d.data {
+		binary.LittleEndian.PutUint32(result[i*4:i*4+4], v)
+	}
+	return result
+}
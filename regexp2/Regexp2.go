@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"regexp"
 	"regexp/syntax"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode/utf8"
@@ -32,6 +33,10 @@ const MaxNodesAutomaton = 3000
 // MaxCharInRegex is the maximum number of characters in a regex string
 const MaxCharInRegex = 1000
 
+// MaxRepeat is the maximum count in a counted repetition
+// such as {m,n} (the same limit as regexp/syntax imposes)
+const MaxRepeat = 1000
+
 // IsSupported determines whether expr is a supported regex; return nil if supported, error otherwise
 func IsSupported(expr string) error {
 	nRunesExpr := utf8.RuneCountInString(expr)
@@ -58,9 +63,36 @@ func Compile(expr string, regexType RegexType) (regex *regexp.Regexp, err error)
 	if regexType == SimilarTo || regexType == GolangSimilarTo {
 		exprRunes := []rune(expr)
 		newRegexRunes := make([]rune, 0, len(exprRunes))
-		for index, r := range exprRunes {
+		for index := 0; index < len(exprRunes); index++ {
+			r := exprRunes[index]
 			escaped := (index > 0) && (exprRunes[index-1] == escapeChar)
 			switch r {
+			case '[': // bracket expression: copied verbatim, since '^' negates
+				// and '.', '$', '%' and '_' are ordinary characters inside of it
+				if escaped {
+					newRegexRunes = append(newRegexRunes, r)
+					break
+				}
+				n := bracket(exprRunes[index:])
+				if n == 0 {
+					// unterminated; let regexp.Compile complain about it
+					newRegexRunes = append(newRegexRunes, exprRunes[index:]...)
+					index = len(exprRunes)
+					break
+				}
+				newRegexRunes = append(newRegexRunes, exprRunes[index:index+n]...)
+				index += n - 1
+			case '{': // counted repetition {m}, {m,} or {m,n}
+				if escaped {
+					newRegexRunes = append(newRegexRunes, r)
+					break
+				}
+				n, err := quantifier(exprRunes[index:])
+				if err != nil {
+					return nil, err
+				}
+				newRegexRunes = append(newRegexRunes, exprRunes[index:index+n]...)
+				index += n - 1
 			case '.', '^', '$': // characters '.', '^' and '$' are NOT meta-characters in "SIMILAR TO",
 				if escaped {
 					// found an escaped char, do not escape it again
@@ -101,20 +133,89 @@ func Compile(expr string, regexType RegexType) (regex *regexp.Regexp, err error)
 		if !strings.HasSuffix(exprOrg, "$") {
 			expr = "(" + expr + ")$" // NOTE brackets are necessary
 		}
-	case RegexpCi:
-		if !strings.HasPrefix(exprOrg, "(?i)") {
-			expr = "(?i)" + expr
-		}
-	case Regexp:
+	case Regexp, RegexpCi:
 		if !strings.HasPrefix(exprOrg, "^") {
 			expr = "(.|\n)*(" + expr + ")" // NOTE brackets are necessary
 		}
+		if regexType == RegexpCi {
+			// ~* matches exactly like ~, but case-insensitively
+			expr = "(?i)" + expr
+		}
 	case GolangRegexp:
 		// do nothing
 	}
 	return regexp.Compile(expr)
 }
 
+// quantifier validates the counted repetition
+// at the start of expr and returns its length
+func quantifier(expr []rune) (int, error) {
+	end := slices.Index(expr, '}')
+	if end < 0 {
+		return 0, fmt.Errorf("missing '}' in quantifier %q", string(expr))
+	}
+	str := string(expr[1:end])
+	lo, hi, bounded := strings.Cut(str, ",")
+	m, err := repeatCount(lo)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quantifier {%s}: %w", str, err)
+	}
+	n := m
+	if bounded && hi != "" {
+		n, err = repeatCount(hi)
+		if err != nil {
+			return 0, fmt.Errorf("invalid quantifier {%s}: %w", str, err)
+		}
+		if m > n {
+			return 0, fmt.Errorf("invalid quantifier {%s}: minimum %d exceeds maximum %d", str, m, n)
+		}
+	}
+	return end + 1, nil
+}
+
+// bracket returns the length of the bracket expression
+// at the start of expr (including any POSIX character
+// classes such as [:alpha:] within it), or 0 if the
+// bracket expression is not terminated
+func bracket(expr []rune) int {
+	i := 1
+	if i < len(expr) && expr[i] == '^' {
+		i++
+	}
+	if i < len(expr) && expr[i] == ']' {
+		i++ // a leading ']' is an ordinary character
+	}
+	for ; i < len(expr); i++ {
+		switch expr[i] {
+		case escapeChar:
+			i++
+		case '[':
+			if i+1 < len(expr) && expr[i+1] == ':' {
+				for j := i + 2; j+1 < len(expr); j++ {
+					if expr[j] == ':' && expr[j+1] == ']' {
+						i = j + 1
+						break
+					}
+				}
+			}
+		case ']':
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func repeatCount(str string) (int, error) {
+	if str == "" || strings.TrimLeft(str, "0123456789") != "" {
+		return 0, fmt.Errorf("%q is not a repetition count", str)
+	}
+	n, err := strconv.Atoi(str)
+	if err != nil || n > MaxRepeat {
+		return 0, fmt.Errorf("repetition count %s exceeds the maximum %d", str, MaxRepeat)
+	}
+	return n, nil
+}
+
 // extractProg extracts the internal syntax.Prog from regexp.Regexp instance using reflection
 func extractProg(regex *regexp.Regexp) *syntax.Prog {
 	return (*syntax.Prog)(reflect.ValueOf(regex).Elem().FieldByName("prog").UnsafePointer())
@@ -16,6 +16,7 @@ package regexp2
 
 import (
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -29,3 +30,120 @@ func TestExtractProg(t *testing.T) {
 		t.Errorf("extracting prog from regexp failed")
 	}
 }
+
+func TestSimilarToQuantifier(t *testing.T) {
+	testcases := []struct {
+		expr  string
+		match []string
+		fail  []string
+		err   string
+	}{
+		{expr: `a{2}`, match: []string{"aa"}, fail: []string{"a", "aaa"}},
+		{expr: `a{2,}b`, match: []string{"aab", "aaaaab"}, fail: []string{"ab"}},
+		{expr: `(ab){1,2}_`, match: []string{"abx", "ababx"}, fail: []string{"ababab", "x"}},
+		{expr: `a{0,1000}`, match: []string{"", "aaa"}},
+		{expr: `a\{2}`, match: []string{"a{2}"}, fail: []string{"aa"}},
+		{expr: `a{3,2}`, err: "minimum 3 exceeds maximum 2"},
+		{expr: `a{1001}`, err: "exceeds the maximum 1000"},
+		{expr: `a{1,1001}`, err: "exceeds the maximum 1000"},
+		{expr: `a{,2}`, err: "is not a repetition count"},
+		{expr: `a{x}`, err: "is not a repetition count"},
+		{expr: `a{1,2`, err: "missing '}'"},
+	}
+	for _, tc := range testcases {
+		rx, err := Compile(tc.expr, GolangSimilarTo)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: got error %v, want %q", tc.expr, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tc.expr, err)
+			continue
+		}
+		for _, str := range tc.match {
+			if !rx.MatchString(str) {
+				t.Errorf("%s: %q did not match", tc.expr, str)
+			}
+		}
+		for _, str := range tc.fail {
+			if rx.MatchString(str) {
+				t.Errorf("%s: %q matched", tc.expr, str)
+			}
+		}
+	}
+}
+
+func TestSimilarToQuantifierTooManyNodes(t *testing.T) {
+	rx, err := Compile(`(abcdef){500,}`, SimilarTo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CompileDFA(rx, MaxNodesAutomaton); err == nil {
+		t.Fatal("expected the automaton to exceed the node limit")
+	}
+}
+
+func TestPosixClasses(t *testing.T) {
+	classes := []string{
+		"alnum", "alpha", "ascii", "blank", "cntrl", "digit", "graph",
+		"lower", "print", "punct", "space", "upper", "word", "xdigit",
+	}
+	for _, class := range classes {
+		for _, pattern := range []string{
+			"[[:" + class + ":]]",
+			"[[:^" + class + ":]]",
+			"[^[:" + class + ":]]",
+			"x[^[:" + class + ":]_]+y",
+		} {
+			for _, typ := range []RegexType{Regexp, RegexpCi, SimilarTo} {
+				rx, err := Compile(pattern, typ)
+				if err != nil {
+					t.Errorf("%s (type %d): %s", pattern, typ, err)
+					continue
+				}
+				if _, err := CompileDFA(rx, MaxNodesAutomaton); err != nil {
+					t.Errorf("%s (type %d): compiling DFA: %s", pattern, typ, err)
+				}
+			}
+		}
+	}
+	for _, typ := range []RegexType{Regexp, SimilarTo} {
+		_, err := Compile("[[:bogus:]]", typ)
+		if err == nil || !strings.Contains(err.Error(), "[:bogus:]") {
+			t.Errorf("type %d: unexpected error %v", typ, err)
+		}
+	}
+}
+
+func TestSimilarToBracket(t *testing.T) {
+	testcases := []struct {
+		expr        string
+		match, fail []string
+	}{
+		{expr: `[^a]`, match: []string{"b", "^"}, fail: []string{"a"}},
+		{expr: `[%_]+`, match: []string{"%", "_%"}, fail: []string{"a"}},
+		{expr: `[.$]`, match: []string{".", "$"}, fail: []string{"a"}},
+		{expr: `[]a]`, match: []string{"]", "a"}, fail: []string{"b"}},
+		{expr: `[^[:digit:]]%`, match: []string{"a1", "Ω"}, fail: []string{"1a"}},
+		{expr: `\[a]`, match: []string{"[a]"}, fail: []string{"a"}},
+	}
+	for _, tc := range testcases {
+		rx, err := Compile(tc.expr, GolangSimilarTo)
+		if err != nil {
+			t.Errorf("%s: %s", tc.expr, err)
+			continue
+		}
+		for _, str := range tc.match {
+			if !rx.MatchString(str) {
+				t.Errorf("%s: %q did not match", tc.expr, str)
+			}
+		}
+		for _, str := range tc.fail {
+			if rx.MatchString(str) {
+				t.Errorf("%s: %q matched", tc.expr, str)
+			}
+		}
+	}
+}
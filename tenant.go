@@ -19,6 +19,7 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -61,6 +62,11 @@ type TenantEnv struct {
 type TenantRunner struct {
 	Events *os.File
 	Cache  *dcache.Cache
+	// Prefetch is the default number of blocks
+	// fetched into the cache ahead of the blocks
+	// being scanned (see dcache.MultiTable.Prefetch);
+	// it is overridden by plan.ExecParams.Prefetch.
+	Prefetch int
 }
 
 func (r *TenantRunner) Post() {
@@ -82,19 +88,7 @@ func (r *TenantRunner) Run(dst vm.QuerySink, in *plan.Input, ep *plan.ExecParams
 	ctx, task := trace.NewTask(ctx, "run-segments")
 	defer task.End()
 	trace.Log(ctx, "query-id", ep.Plan.ID)
-	segs := make([]dcache.Segment, 0, in.Blocks())
-	for i := range in.Descs {
-		in.Descs[i].Blocks.Each(func(off int) {
-			seg := &tenantSegment{
-				fs:     ep.FS,
-				ctx:    ctx, // inherit current task
-				desc:   in.Descs[i].Descriptor,
-				block:  off,
-				fields: in.Fields,
-			}
-			segs = append(segs, seg)
-		})
-	}
+	segs := segments(ctx, ep.FS, in, nil)
 	if len(segs) == 0 {
 		return nil
 	}
@@ -103,11 +97,56 @@ func (r *TenantRunner) Run(dst vm.QuerySink, in *plan.Input, ep *plan.ExecParams
 		flags = dcache.FlagNoFill
 	}
 	tbl := r.Cache.MultiTable(ctx, segs, flags)
+	tbl.Prefetch = r.Prefetch
+	if ep.Prefetch != 0 {
+		tbl.Prefetch = max(ep.Prefetch, 0)
+	}
 	err := tbl.WriteChunks(dst, ep.Parallel)
 	ep.Stats.Observe(tbl)
 	return err
 }
 
+// Warm fetches the blocks read by the inputs of t
+// into the cache without executing t, so that a
+// subsequent execution of t is served from the cache.
+// At most limit bytes of new cache entries are
+// filled if limit is positive (see dcache.Cache.Warm).
+// The returned error lists each block that
+// could not be cached.
+func (r *TenantRunner) Warm(ctx context.Context, src fs.FS, t *plan.Tree, limit int64) error {
+	var segs []dcache.Segment
+	for _, in := range t.Inputs {
+		segs = segments(ctx, src, in, segs)
+	}
+	var errlist []error
+	for i, err := range r.Cache.Warm(ctx, segs, limit) {
+		if err != nil {
+			seg := segs[i].(*tenantSegment)
+			errlist = append(errlist, fmt.Errorf("%s block %d: %w", seg.desc.Path, seg.block, err))
+		}
+	}
+	return errors.Join(errlist...)
+}
+
+// segments appends the segments of each
+// block of the input to dst
+func segments(ctx context.Context, src fs.FS, in *plan.Input, dst []dcache.Segment) []dcache.Segment {
+	dst = slices.Grow(dst, in.Blocks())
+	for i := range in.Descs {
+		in.Descs[i].Blocks.Each(func(off int) {
+			seg := &tenantSegment{
+				fs:     src,
+				ctx:    ctx, // inherit current task
+				desc:   in.Descs[i].Descriptor,
+				block:  off,
+				fields: in.Fields,
+			}
+			dst = append(dst, seg)
+		})
+	}
+	return dst
+}
+
 // tenantSegment implements dcache.Segment
 type tenantSegment struct {
 	fs     fs.FS
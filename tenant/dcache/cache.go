@@ -13,11 +13,12 @@
 //  limitations under the License.
 
 // Package dcache provides a cache
-// for table data by storing files in a directory.
+// for table data by storing files in a directory
+// or in any other blob.Cache implementation.
 //
 // Typically, a caller will arrange
 // for a new cache to be set up in
-// a directory with NewCache(dir),
+// a directory with New(dir, onFill),
 // and then use Cache.Table as the
 // vm.Table implementation to be
 // returned to the query planner.
@@ -27,13 +28,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/fs"
-	"os"
-	"path/filepath"
 	"runtime"
 	"sync"
 	"sync/atomic"
 
+	"github.com/SnellerInc/sneller/blob"
 	"github.com/SnellerInc/sneller/vm"
 )
 
@@ -57,7 +56,14 @@ type Cache struct {
 	// by the cache.
 	Logger Logger
 
-	dir    string
+	// MaxPrefetch, if positive, is the maximum
+	// number of segments being prefetched at once
+	// by all the MultiTables of the cache
+	// (see MultiTable.Prefetch).
+	// Otherwise, 4*GOMAXPROCS is used.
+	MaxPrefetch int
+
+	store  blob.Cache
 	onFill func()
 
 	// we don't allow concurrent cache fills;
@@ -68,15 +74,12 @@ type Cache struct {
 
 	queue queue
 	wg    sync.WaitGroup // waiting for c.worker()
-
-	// we do, however, allow concurrent reads
-	// from the same mapping; we keep mappings
-	// open as long as there is at least one
-	// active user; otherwise we remove them
-	rocache map[string]*mapping
+	bg    sync.WaitGroup // waiting for prefetches
 
 	// statistics; accessed atomically
-	hits, misses, failures int64
+	hits, misses, failures, live int64
+	// number of prefetches in flight; accessed atomically
+	prefetching int64
 }
 
 type Logger interface {
@@ -89,14 +92,12 @@ func (c *Cache) errorf(f string, args ...interface{}) {
 	}
 }
 
-// LiveHits returns the number of mappings
-// open for reading at the moment it is called.
+// LiveHits returns the number of cache hits
+// being read at the moment it is called.
 // (Note that this is fundamentally racy; this
 // is only here for telemetry and testing purposes.)
 func (c *Cache) LiveHits() int {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	return len(c.rocache)
+	return int(atomic.LoadInt64(&c.live))
 }
 
 // Accesses returns the total number
@@ -129,15 +130,17 @@ func (c *Cache) Failures() int64 {
 	return atomic.LoadInt64(&c.failures)
 }
 
-type mapping struct {
-	file       *os.File // file handle
-	id, target string   // actual filepath of populated entry
-	mem        []byte   // actual mapping
-	populated  bool     // memory is populated
-
-	// reference count; can only be accessed
-	// when the parent cache lock is locked
-	refcount int
+// entry is a cache entry acquired
+// from the backing store
+type entry struct {
+	id        string
+	mem       []byte // len(mem) = size of data
+	populated bool   // memory is populated
+
+	// release is set for populated entries;
+	// commit is set for entries being filled
+	release func()
+	commit  func(bool) error
 }
 
 // New makes a new cache that keeps
@@ -146,11 +149,23 @@ type mapping struct {
 // called each time the cache is about
 // to fill a new cache entry.
 func New(dir string, onFill func()) *Cache {
+	return NewStore(NewDir(dir), onFill)
+}
+
+// NewStore makes a new cache that keeps
+// cache data in the provided store.
+// The provided onFill function will be
+// called each time the cache is about
+// to fill a new cache entry.
+//
+// The returned Cache coalesces concurrent
+// accesses to the same Segment, so the store
+// only ever sees one fill for a given ETag at a time.
+func NewStore(store blob.Cache, onFill func()) *Cache {
 	c := &Cache{
-		dir:      dir,
+		store:    store,
 		onFill:   onFill,
 		inflight: make(map[string]struct{}),
-		rocache:  make(map[string]*mapping),
 	}
 	c.queue.reserved = make(map[string]*reservation)
 	parallel := runtime.GOMAXPROCS(0)
@@ -168,22 +183,14 @@ func New(dir string, onFill func()) *Cache {
 	return c
 }
 
-// acquire id exclusively;
-// if a read-only mapping is already present
-// for that ID, then return that mapping and
-// unlock the entry immediately
-func (c *Cache) lockID(id string) *mapping {
+// acquire id exclusively
+func (c *Cache) lockID(id string) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 	for _, ok := c.inflight[id]; ok; _, ok = c.inflight[id] {
 		c.cond.Wait()
 	}
-	if mp := c.rocache[id]; mp != nil {
-		mp.refcount++
-		return mp
-	}
 	c.inflight[id] = struct{}{}
-	return nil
 }
 
 // drop exclusive lock on id
@@ -198,35 +205,9 @@ func (c *Cache) unlockID(id string) {
 	c.cond.Broadcast()
 }
 
-// drop exclusive lock on id and set
-// its read-only mapping to mp
-func (c *Cache) unlockIDMapped(id string, mp *mapping) {
-	if !mp.populated {
-		panic("unlockIDMapped on non-populated mapping")
-	}
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	before := len(c.inflight)
-	delete(c.inflight, id)
-	after := len(c.inflight)
-	if after != before-1 {
-		panic("duplicate unlockID in unlockIDMapped " + id)
-	}
-	c.rocache[id] = mp
-	c.cond.Broadcast()
-}
-
-func mkdir(name string, mode os.FileMode) bool {
-	err := os.Mkdir(name, mode)
-	return err == nil || errors.Is(err, fs.ErrExist)
-}
-
-// how this is *currently* implemented:
-// we create a file "ID.tmp" that holds the
-// cache data while the entry is being populated,
-// and then we re-name it to just "ID" if we
-// successfully populate the entire entry
-// (this is all-or-nothing)
+// acquire returns the cache entry for s,
+// or nil if the entry is neither present
+// nor could be created
 //
 // we use Cache.lockID()/Cache.unlockID()
 // to prevent multiple cache *fills* simultaneously
@@ -234,67 +215,28 @@ func mkdir(name string, mode os.FileMode) bool {
 // a particular Segment, any other accesses of that
 // Segment will block until we have populated the entire entry
 // or otherwise aborted the query)
-func (c *Cache) mmap(s Segment, flags Flag) *mapping {
+func (c *Cache) acquire(s Segment, flags Flag) *entry {
 	id := s.ETag()
-	var target string
-	var predir string
-	if len(id) >= 2 {
-		// add 1 level of indirection so that a subsequent
-		// readdir opertion need not lock the entire directory
-		predir = filepath.Join(c.dir, id[:1])
-		rest := id[1:]
-		if s.Ephemeral() {
-			rest = "eph:" + rest
-		}
-		target = filepath.Join(predir, rest)
-	} else {
-		rest := id
-		if s.Ephemeral() {
-			rest = "eph:" + rest
-		}
-		target = filepath.Join(c.dir, rest)
-	}
-	if m := c.lockID(id); m != nil {
-		atomic.AddInt64(&c.hits, 1)
-		return m
-	}
-	f, err := os.Open(target)
+	size := s.Size()
+	c.lockID(id)
+	mem, release, err := c.store.Get(id, size)
 	if err == nil {
-		fi, err := f.Stat()
-		if err != nil {
-			c.unlockID(id)
-			f.Close()
-			c.errorf("Cache.mmap: stat: %s", err)
-			atomic.AddInt64(&c.failures, 1)
-			return nil
-		}
-		size := s.Size()
-		if size <= fi.Size() {
-			buf, err := mmap(f, fi.Size(), true)
-			if err != nil {
-				f.Close()
-				// should we os.Remove() here too?
-				c.unlockID(id)
-				c.errorf("Cache.mmap: mmap: %s", err)
-				atomic.AddInt64(&c.failures, 1)
-				return nil
-			}
-			atomic.AddInt64(&c.hits, 1)
-			mp := &mapping{
-				file:   f,
-				id:     id,
-				target: target,
-				// have the slice arrange so that
-				// cap(mem) = filesystem size,
-				// len(mem) = range of actual data
-				mem:       buf[:size],
-				populated: true,
-				refcount:  1,
-			}
-			c.unlockIDMapped(id, mp)
-			return mp
+		c.unlockID(id)
+		atomic.AddInt64(&c.hits, 1)
+		atomic.AddInt64(&c.live, 1)
+		return &entry{
+			id:        id,
+			mem:       mem,
+			populated: true,
+			release:   release,
 		}
 	}
+	if !errors.Is(err, blob.ErrNotFound) {
+		c.unlockID(id)
+		c.errorf("Cache.acquire: %s", err)
+		atomic.AddInt64(&c.failures, 1)
+		return nil
+	}
 	if flags&FlagNoFill != 0 {
 		atomic.AddInt64(&c.misses, 1)
 		c.unlockID(id)
@@ -302,114 +244,45 @@ func (c *Cache) mmap(s Segment, flags Flag) *mapping {
 	}
 	c.onFill()
 	// we are creating a new entry
-	f, err = os.Create(target + ".tmp")
-	if errors.Is(err, fs.ErrNotExist) &&
-		predir != "" && mkdir(predir, 0750) {
-		// we don't insert the mkdir in this path
-		// ordinarily because this isn't something
-		// we ever deliberately delete:
-		f, err = os.Create(target + ".tmp")
-	}
-	if err != nil {
-		// couldn't even create the file
-		c.unlockID(id)
-		c.errorf("Cache.mmap: couldn't create temporary backing: %s", err)
-		atomic.AddInt64(&c.failures, 1)
-		return nil
-	}
-	size := s.Size()
-	err = resize(f, size+slack)
+	buf, commit, err := c.store.Put(id, size, s.Ephemeral())
 	if err != nil {
 		// out of memory or disk space;
-		// don't cache at all and make sure
-		// the file doesn't stick around
-		f.Close()
-		os.Remove(f.Name())
+		// don't cache at all
 		c.unlockID(id)
 		atomic.AddInt64(&c.failures, 1)
-		c.errorf("Cache.mmap: fallocate: %s", err)
-		return nil
-	}
-	buf, err := mmap(f, size+slack, false)
-	if err != nil {
-		f.Close()
-		os.Remove(f.Name())
-		c.unlockID(id)
-		atomic.AddInt64(&c.failures, 1)
-		c.errorf("Cache.mmap: mapping new entry: %s", err)
+		c.errorf("Cache.acquire: %s", err)
 		return nil
 	}
 	atomic.AddInt64(&c.misses, 1)
-	return &mapping{
-		file: f,
-		id:   id,
-		// cap(mem) = fallocated space,
-		// len(mem) = size of data
-		mem:       buf[:size],
-		target:    target,
-		populated: false,
-		refcount:  1,
+	return &entry{
+		id:     id,
+		mem:    buf,
+		commit: commit,
 	}
 }
 
-// take a mapping that was not populated
-// and relink it so that it is a populated mapping
-func (c *Cache) finalize(mp *mapping, pop bool) {
-	if mp.populated {
-		panic("finalize of populated mapping")
+// finalize takes an entry that was not
+// populated and either commits it to the store
+// or discards it, and then drops the lock on its id
+func (c *Cache) finalize(e *entry, pop bool) {
+	if e.populated {
+		panic("finalize of populated entry")
 	}
-	name := mp.file.Name()
-	if pop {
-		// unpopulated -> populated means
-		// renaming id.tmp -> id so that
-		// it can be acquired directly from the filesystem
-		if err := os.Rename(name, mp.target); err != nil {
-			c.errorf("Cache.finalize: %s", err)
-		}
-	} else {
-		if err := os.Remove(name); err != nil {
-			c.errorf("Cache.finalize: deleting failed fill: %s", err)
-		}
+	if err := e.commit(pop); err != nil {
+		c.errorf("Cache.finalize: %s", err)
 	}
-	c.unlockID(mp.id)
+	e.mem = nil
+	c.unlockID(e.id)
 }
 
-func (c *Cache) unmap(mp *mapping) {
-	if mp.mem == nil {
-		return
-	}
-	if mp.populated {
-		// this is a read-only cache entry;
-		// have to decref and see if we can
-		// really close the entry
-		c.lock.Lock()
-		rmp := c.rocache[mp.id]
-		if rmp != mp {
-			panic("cache entry changed?")
-		}
-		mp.refcount--
-		dead := false
-		if mp.refcount == 0 {
-			dead = true
-			delete(c.rocache, mp.id)
-		}
-		c.lock.Unlock()
-		if !dead {
-			return
-		}
+// done releases a populated entry
+func (c *Cache) done(e *entry) {
+	if !e.populated {
+		panic("done with unpopulated entry")
 	}
-	// we're going to panic here if unmap fails
-	// because letting it simply fail would leak
-	// mappings endlessly into our address space;
-	// if we encounter this we've got a terrible bug
-	if err := unmap(mp.file, mp.mem[:cap(mp.mem)]); err != nil {
-		panic("dcache.Cache.unmap: " + err.Error())
-	}
-	if err := mp.file.Close(); err != nil {
-		c.errorf("closing %s: %s", mp.file.Name(), err)
-	}
-	mp.file = nil
-	mp.mem = nil
+	e.release()
+	e.mem = nil
+	atomic.AddInt64(&c.live, -1)
 }
 
 // Segment describes a particular region
@@ -531,18 +404,10 @@ func (t *Table) write(w io.Writer) error {
 
 // slow-path: read data from the segment into the cache
 // and write it out to the destination at the same time
-func readThrough(seg Segment, mp *mapping, w io.Writer) (bool, error) {
-	rd, err := seg.Open()
-	if err != nil {
-		if errors.Is(err, io.EOF) {
-			err = io.ErrUnexpectedEOF
-		}
-		return false, fmt.Errorf("cache segment for read-through: %w", err)
-	}
-	defer rd.Close()
+func readThrough(seg Segment, e *entry, w io.Writer) (bool, error) {
 	var buf []byte
-	if mp != nil {
-		buf = mp.mem
+	if e != nil {
+		buf = e.mem
 	} else {
 		// FIXME: we can't use WriteTo on compressed
 		// objects
@@ -556,14 +421,27 @@ func readThrough(seg Segment, mp *mapping, w io.Writer) (bool, error) {
 		// no backing; just use a regular buffer
 		buf = make([]byte, size, size+16)
 	}
-	_, err = io.ReadFull(rd, buf)
+	if err := readSegment(seg, buf); err != nil {
+		return false, err
+	}
+	return e != nil, seg.Decode(w, buf)
+}
+
+// readSegment reads the contents of seg into buf
+func readSegment(seg Segment, buf []byte) error {
+	rd, err := seg.Open()
 	if err != nil {
 		if errors.Is(err, io.EOF) {
 			err = io.ErrUnexpectedEOF
 		}
-		return false, err
+		return fmt.Errorf("cache segment for read-through: %w", err)
+	}
+	defer rd.Close()
+	_, err = io.ReadFull(rd, buf)
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
 	}
-	return mp != nil, seg.Decode(w, buf)
+	return err
 }
 
 // WriteChunks implements vm.Table.WriteChunks
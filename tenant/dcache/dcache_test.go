@@ -31,6 +31,9 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/SnellerInc/sneller/blob"
 )
 
 type testSegment struct {
@@ -600,6 +603,66 @@ func TestCancel(t *testing.T) {
 	assertUnlocked(t, cache, seg)
 }
 
+// countingStore is a blob.Cache that
+// counts the number of fills it sees
+type countingStore struct {
+	blob.Cache
+	puts int64
+}
+
+func (s *countingStore) Put(id string, size int64, ephemeral bool) ([]byte, func(bool) error, error) {
+	atomic.AddInt64(&s.puts, 1)
+	return s.Cache.Put(id, size, ephemeral)
+}
+
+func TestStore(t *testing.T) {
+	parallel := 10
+	cc := make(chan error, parallel)
+	store := &countingStore{Cache: blob.NewMemory(1 << 20)}
+	cache := NewStore(store, func() {})
+	cache.Logger = &testLogger{out: t}
+	seg := randseg(100, 4000, 80927)
+	for i := 0; i < parallel; i++ {
+		go func() {
+			out := seg.testrep(2)
+			tbl := cache.MultiTable(context.Background(), []Segment{seg, seg}, 0)
+			err := tbl.WriteChunks(out, parallel)
+			if err != nil {
+				cc <- err
+				return
+			}
+			cc <- out.check()
+		}()
+	}
+	for i := 0; i < parallel; i++ {
+		err := <-cc
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	// a later access should be served
+	// from the store without another fill
+	out := seg.testout()
+	err := cache.Table(seg, 0).WriteChunks(out, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := out.check(); err != nil {
+		t.Fatal(err)
+	}
+	cache.Close()
+	assertUnlocked(t, cache, seg)
+	if n := atomic.LoadInt64(&store.puts); n != 1 {
+		t.Errorf("%d fills of the store; expected 1", n)
+	}
+	if miss := cache.Misses(); miss != 1 {
+		t.Errorf("%d cache misses?", miss)
+	}
+	if n := cache.LiveHits(); n != 0 {
+		t.Errorf("%d cache entries live?", n)
+	}
+}
+
 // even when allocating a cache entry fails,
 // we should still succeed in reading the original data
 // from the Segment
@@ -738,3 +801,144 @@ func testMulti(t *testing.T, flags Flag, hits, misses int64) {
 		want += mo.possible[i].raw
 	}
 }
+
+func TestPrefetch(t *testing.T) {
+	testFiles(t)
+	segs := []*testSegment{
+		randseg(1000, 2, 3500),
+		randseg(1352, 3, 15872),
+		randseg(1352, 3, 15872),
+		randseg(1400, 3, 20000),
+	}
+	mo := &multiOutput{}
+	all := make([]Segment, len(segs))
+	for i := range segs {
+		mo.possible = append(mo.possible, segs[i].testout())
+		all[i] = segs[i]
+	}
+	c := New(t.TempDir(), func() {})
+	defer c.Close()
+
+	// while the first segment is processed,
+	// the rest of them should be fetched
+	cached := func(seg Segment) bool {
+		_, release, err := c.store.Get(seg.ETag(), seg.Size())
+		if err != nil {
+			return false
+		}
+		release()
+		return true
+	}
+	var once sync.Once
+	mo.possible[0].inject.unlink = func() {
+		once.Do(func() {
+			deadline := time.Now().Add(10 * time.Second)
+			for _, seg := range all[1:] {
+				for !cached(seg) {
+					if time.Now().After(deadline) {
+						t.Error("segments were not prefetched")
+						return
+					}
+					time.Sleep(time.Millisecond)
+				}
+			}
+		})
+	}
+	tbl := c.MultiTable(context.Background(), all, 0)
+	tbl.Prefetch = len(all)
+	if err := tbl.WriteChunks(mo, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := mo.check(); err != nil {
+		t.Fatal(err)
+	}
+	if h, m := tbl.Hits(), tbl.Misses(); h != 3 || m != 1 {
+		t.Errorf("got %d hits and %d misses; expected 3 and 1", h, m)
+	}
+
+	// with FlagNoFill, there is nowhere
+	// to keep the prefetched data
+	seg0, seg1 := randseg(1000, 2, 3500), randseg(1352, 3, 15872)
+	mo = &multiOutput{possible: []*testSegOutput{seg0.testout(), seg1.testout()}}
+	tbl = c.MultiTable(context.Background(), []Segment{seg0, seg1}, FlagNoFill)
+	tbl.Prefetch = 1
+	if err := tbl.WriteChunks(mo, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := mo.check(); err != nil {
+		t.Fatal(err)
+	}
+	if cached(seg1) {
+		t.Error("segment prefetched with FlagNoFill")
+	}
+}
+
+func TestWarm(t *testing.T) {
+	testFiles(t)
+	segs := []*testSegment{
+		randseg(1000, 2, 3500),
+		randseg(1352, 3, 15872),
+		randseg(1352, 3, 15872),
+		randseg(1400, 3, 20000),
+	}
+	bad := randseg(1000, 2, 5000)
+	bad.inject.err = errors.New("injected error")
+	all := []Segment{segs[0], segs[1], segs[2], segs[3], bad}
+
+	c := New(t.TempDir(), func() {})
+	defer c.Close()
+	const limit = 45000
+	errs := c.Warm(context.Background(), all, limit)
+	if !errors.Is(errs[4], bad.inject.err) {
+		t.Errorf("got error %v for bad segment", errs[4])
+	}
+	var warmed []*testSegment
+	filled := int64(0)
+	for i, seg := range segs {
+		switch {
+		case errs[i] == nil:
+			warmed = append(warmed, seg)
+			filled += seg.Size()
+		case !errors.Is(errs[i], ErrWarmLimit):
+			t.Errorf("segment %d: unexpected error %v", i, errs[i])
+		}
+	}
+	if filled > limit || len(warmed) < 2 || len(warmed) == len(segs) {
+		t.Fatalf("filled %d bytes for %d segments", filled, len(warmed))
+	}
+
+	// warming again is a no-op for cached segments
+	// and shouldn't count against the limit
+	lst := make([]Segment, len(warmed))
+	for i := range warmed {
+		lst[i] = warmed[i]
+	}
+	for i, err := range c.Warm(context.Background(), lst, 1) {
+		if err != nil {
+			t.Errorf("re-warming segment %d: %v", i, err)
+		}
+	}
+
+	// the warmed segments should now be cache hits
+	hits := c.Hits()
+	for _, seg := range warmed {
+		out := seg.testout()
+		if err := c.Table(seg, FlagNoFill).WriteChunks(out, 1); err != nil {
+			t.Fatal(err)
+		}
+		if err := out.check(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := c.Hits() - hits; got != int64(len(warmed)) {
+		t.Errorf("got %d hits; expected %d", got, len(warmed))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i, err := range c.Warm(ctx, all, 0) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("segment %d: got %v with a canceled context", i, err)
+		}
+	}
+}
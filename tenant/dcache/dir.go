@@ -0,0 +1,232 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package dcache
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/SnellerInc/sneller/blob"
+)
+
+// Dir is a blob.Cache that stores
+// each cache entry as a file in a directory.
+// Dir is the default backing store for a Cache.
+type Dir struct {
+	dir string
+
+	// we allow concurrent reads
+	// from the same mapping; we keep mappings
+	// open as long as there is at least one
+	// active user; otherwise we remove them
+	lock    sync.Mutex
+	rocache map[string]*mapping
+}
+
+var _ blob.Cache = &Dir{}
+
+type mapping struct {
+	file *os.File // file handle
+	mem  []byte   // actual mapping
+
+	// reference count; can only be accessed
+	// when the parent lock is locked
+	refcount int
+}
+
+// NewDir constructs a Dir that keeps
+// cache entries in files inside 'dir'.
+func NewDir(dir string) *Dir {
+	return &Dir{
+		dir:     dir,
+		rocache: make(map[string]*mapping),
+	}
+}
+
+func mkdir(name string, mode os.FileMode) bool {
+	err := os.Mkdir(name, mode)
+	return err == nil || errors.Is(err, fs.ErrExist)
+}
+
+// path returns the directory and filename
+// for the entry with the given id
+func (d *Dir) path(id string, ephemeral bool) (predir, target string) {
+	if len(id) >= 2 {
+		// add 1 level of indirection so that a subsequent
+		// readdir opertion need not lock the entire directory
+		predir = filepath.Join(d.dir, id[:1])
+		rest := id[1:]
+		if ephemeral {
+			rest = "eph:" + rest
+		}
+		return predir, filepath.Join(predir, rest)
+	}
+	rest := id
+	if ephemeral {
+		rest = "eph:" + rest
+	}
+	return "", filepath.Join(d.dir, rest)
+}
+
+// Get implements blob.Cache.Get
+//
+// Entries are looked up both with and
+// without the ephemeral marker, since the
+// caller does not know how an entry was stored.
+func (d *Dir) Get(id string, size int64) ([]byte, func(), error) {
+	d.lock.Lock()
+	if mp := d.rocache[id]; mp != nil && int64(len(mp.mem)) >= size {
+		mp.refcount++
+		d.lock.Unlock()
+		return mp.mem[:size], d.release(id, mp), nil
+	}
+	d.lock.Unlock()
+	_, target := d.path(id, false)
+	f, err := os.Open(target)
+	if errors.Is(err, fs.ErrNotExist) {
+		_, target = d.path(id, true)
+		f, err = os.Open(target)
+	}
+	if err != nil {
+		return nil, nil, blob.ErrNotFound
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("stat: %w", err)
+	}
+	if fi.Size() < size {
+		f.Close()
+		return nil, nil, blob.ErrNotFound
+	}
+	buf, err := mmap(f, fi.Size(), true)
+	if err != nil {
+		f.Close()
+		// should we os.Remove() here too?
+		return nil, nil, fmt.Errorf("mmap: %w", err)
+	}
+	mp := &mapping{
+		file:     f,
+		mem:      buf,
+		refcount: 1,
+	}
+	d.lock.Lock()
+	if prev := d.rocache[id]; prev != nil {
+		// raced with another reader;
+		// prefer the existing mapping
+		prev.refcount++
+		d.lock.Unlock()
+		d.unmap(mp)
+		mp = prev
+	} else {
+		d.rocache[id] = mp
+		d.lock.Unlock()
+	}
+	return mp.mem[:size], d.release(id, mp), nil
+}
+
+func (d *Dir) release(id string, mp *mapping) func() {
+	return func() {
+		// this is a read-only cache entry;
+		// have to decref and see if we can
+		// really close the entry
+		d.lock.Lock()
+		if d.rocache[id] != mp {
+			panic("cache entry changed?")
+		}
+		mp.refcount--
+		dead := mp.refcount == 0
+		if dead {
+			delete(d.rocache, id)
+		}
+		d.lock.Unlock()
+		if dead {
+			d.unmap(mp)
+		}
+	}
+}
+
+// Put implements blob.Cache.Put
+//
+// We create a file "ID.tmp" that holds the
+// cache data while the entry is being populated,
+// and then we re-name it to just "ID" if we
+// successfully populate the entire entry
+// (this is all-or-nothing).
+func (d *Dir) Put(id string, size int64, ephemeral bool) ([]byte, func(bool) error, error) {
+	predir, target := d.path(id, ephemeral)
+	f, err := os.Create(target + ".tmp")
+	if errors.Is(err, fs.ErrNotExist) &&
+		predir != "" && mkdir(predir, 0750) {
+		// we don't insert the mkdir in this path
+		// ordinarily because this isn't something
+		// we ever deliberately delete:
+		f, err = os.Create(target + ".tmp")
+	}
+	if err != nil {
+		// couldn't even create the file
+		return nil, nil, fmt.Errorf("couldn't create temporary backing: %w", err)
+	}
+	err = resize(f, size+slack)
+	if err != nil {
+		// out of memory or disk space;
+		// don't cache at all and make sure
+		// the file doesn't stick around
+		f.Close()
+		os.Remove(f.Name())
+		return nil, nil, fmt.Errorf("fallocate: %w", err)
+	}
+	buf, err := mmap(f, size+slack, false)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, nil, fmt.Errorf("mapping new entry: %w", err)
+	}
+	mp := &mapping{file: f, mem: buf}
+	commit := func(ok bool) error {
+		name := f.Name()
+		var err error
+		if ok {
+			// unpopulated -> populated means
+			// renaming id.tmp -> id so that
+			// it can be acquired directly from the filesystem
+			err = os.Rename(name, target)
+		} else if err = os.Remove(name); err != nil {
+			err = fmt.Errorf("deleting failed fill: %w", err)
+		}
+		d.unmap(mp)
+		return err
+	}
+	// cap(buf) = fallocated space,
+	// len(buf) = size of data
+	return buf[:size], commit, nil
+}
+
+func (d *Dir) unmap(mp *mapping) {
+	// we're going to panic here if unmap fails
+	// because letting it simply fail would leak
+	// mappings endlessly into our address space;
+	// if we encounter this we've got a terrible bug
+	if err := unmap(mp.file, mp.mem[:cap(mp.mem)]); err != nil {
+		panic("dcache.Dir.unmap: " + err.Error())
+	}
+	mp.file.Close()
+	mp.file = nil
+	mp.mem = nil
+}
@@ -17,6 +17,7 @@ package dcache
 import (
 	"context"
 	"io"
+	"sync"
 	"sync/atomic"
 
 	"github.com/SnellerInc/sneller/vm"
@@ -27,6 +28,22 @@ type MultiTable struct {
 	Stats
 	inner []*Table
 
+	// Prefetch is the number of segments that are
+	// fetched into the cache ahead of the segments
+	// being processed by WriteChunks, which hides
+	// the latency of reading many small segments.
+	// Zero disables prefetching.
+	//
+	// Prefetched data only ever lives in the cache,
+	// so prefetching is disabled for tables created
+	// with FlagNoFill, and the number of prefetches
+	// in flight is bounded by Cache.MaxPrefetch
+	// across all the tables of the cache.
+	Prefetch int
+	flags    Flag
+	cache    *Cache
+	pf       *prefetcher
+
 	// NOTE: we don't actually look for
 	// cancellation inside Segment.Decode, etc.
 	// because of coalescing; we don't want a cancellation
@@ -45,40 +62,48 @@ func (c *Cache) MultiTable(ctx context.Context, segs []Segment, flags Flag) *Mul
 	for i := range segs {
 		inner[i] = c.Table(segs[i], flags)
 	}
-	return &MultiTable{inner: inner, ctx: ctx, donec: ctx.Done()}
+	return &MultiTable{inner: inner, flags: flags, cache: c, ctx: ctx, donec: ctx.Done()}
 }
 
 // acquire a reference to one of the input tables
-func (m *MultiTable) get() *Table {
+func (m *MultiTable) get() (*Table, int) {
 	n := atomic.AddInt32(&m.next, 1) - 1
 	if int(n) >= len(m.inner) {
-		return nil
+		return nil, -1
 	}
 	// don't continue if we are canceled:
 	if m.donec != nil {
 		select {
 		case <-m.donec:
-			return nil
+			return nil, -1
 		default:
 		}
 	}
 	t := m.inner[n]
-	return t
+	return t, int(n)
 }
 
 func (m *MultiTable) write(w io.Writer) error {
 	var ret chan error
 	for {
-		t := m.get()
+		t, n := m.get()
 		if t == nil {
 			break
 		}
+		if m.pf != nil {
+			m.pf.schedule(m, n)
+		}
 		if ret == nil {
 			ret = make(chan error, 1)
 		}
 		t.cache.queue.send(t.seg, w, t.flags, &m.Stats, ret)
 		err := <-ret
 		if err != nil {
+			if m.pf != nil {
+				// typically an early exit due to LIMIT;
+				// don't fetch anything else
+				m.pf.stop()
+			}
 			return err
 		}
 	}
@@ -104,10 +129,71 @@ func (m *MultiTable) open(parallel int) int {
 
 // WriteChunks implements vm.Table.WriteChunks
 func (m *MultiTable) WriteChunks(dst vm.QuerySink, parallel int) error {
+	m.pf = nil
+	if m.Prefetch > 0 && m.flags&FlagNoFill == 0 {
+		m.pf = &prefetcher{}
+	}
 	err := vm.SplitInput(dst, m.open(parallel), m.write)
+	if m.pf != nil {
+		// prefetches that have not started yet are dropped;
+		// the ones in progress still populate the cache
+		m.pf.stop()
+	}
 	m.next = 0
 	if err != nil {
 		return err
 	}
 	return m.ctx.Err()
 }
+
+// prefetcher keeps track of the segments of
+// a MultiTable that are prefetched during
+// one call to WriteChunks
+type prefetcher struct {
+	lock    sync.Mutex
+	ahead   int // segments before ahead have been scheduled
+	stopped bool
+}
+
+// schedule prefetches the segments of m
+// that follow segment n, which is about
+// to be processed
+func (p *prefetcher) schedule(m *MultiTable, n int) {
+	end := min(n+1+m.Prefetch, len(m.inner))
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for i := max(p.ahead, n+1); i < end && !p.stopped; i++ {
+		if !m.cache.startPrefetch() {
+			// segment i will be scheduled by a
+			// later call, or it will simply be
+			// read when it is processed
+			return
+		}
+		p.ahead = i + 1
+		go func(i int) {
+			defer m.cache.endPrefetch()
+			if p.skip(m, i) {
+				return
+			}
+			t := m.inner[i]
+			// errors are ignored here, as the
+			// segment is read again (and the error
+			// reported) when it is processed
+			t.cache.warm(t.seg, 0, nil)
+		}(i)
+	}
+}
+
+// skip returns true if segment i of m
+// doesn't need to be prefetched anymore
+func (p *prefetcher) skip(m *MultiTable, i int) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.stopped || int(atomic.LoadInt32(&m.next)) > i
+}
+
+func (p *prefetcher) stop() {
+	p.lock.Lock()
+	p.stopped = true
+	p.lock.Unlock()
+}
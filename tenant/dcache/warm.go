@@ -0,0 +1,131 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package dcache
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrWarmLimit is returned by Cache.Warm for
+	// segments that were not cached because caching
+	// them would have exceeded the warming limit.
+	ErrWarmLimit = errors.New("dcache: warm limit exceeded")
+
+	errNoEntry = errors.New("dcache: couldn't allocate cache entry")
+)
+
+// Warm populates the cache with the contents of segs
+// without decoding them, so that subsequent queries
+// that read segs are served from the cache.
+// Segments that are already cached are left alone.
+//
+// If limit is positive, Warm fills at most limit
+// bytes of new cache entries; the remaining segments
+// are skipped with ErrWarmLimit. (Each cache fill
+// may cause older entries to be evicted, so the limit
+// bounds how much of the working set can be displaced.)
+//
+// The returned slice holds the result for each segment:
+// nil if the segment is cached, or the reason it isn't.
+func (c *Cache) Warm(ctx context.Context, segs []Segment, limit int64) []error {
+	errs := make([]error, len(segs))
+	parallel := min((runtime.GOMAXPROCS(0)+1)/2, len(segs))
+	var wg sync.WaitGroup
+	var used int64
+	next := int32(-1)
+	wg.Add(parallel)
+	for p := 0; p < parallel; p++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt32(&next, 1))
+				if i >= len(segs) {
+					return
+				}
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = c.warm(segs[i], limit, &used)
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// warm populates the cache entry for s,
+// adding the number of bytes filled to *used
+func (c *Cache) warm(s Segment, limit int64, used *int64) error {
+	if e := c.acquire(s, FlagNoFill); e != nil {
+		c.done(e)
+		return nil
+	}
+	if limit > 0 && !reserve(used, s.Size(), limit) {
+		return ErrWarmLimit
+	}
+	e := c.acquire(s, 0)
+	if e == nil {
+		return errNoEntry
+	}
+	if e.populated {
+		// we were beaten to the fill
+		// by a concurrent query
+		c.done(e)
+		return nil
+	}
+	err := readSegment(s, e.mem)
+	c.finalize(e, err == nil)
+	return err
+}
+
+// startPrefetch reserves one of the prefetch slots
+// of the cache; endPrefetch must be called when the
+// prefetch is done if startPrefetch returns true
+func (c *Cache) startPrefetch() bool {
+	limit := int64(c.MaxPrefetch)
+	if limit <= 0 {
+		limit = 4 * int64(runtime.GOMAXPROCS(0))
+	}
+	if !reserve(&c.prefetching, 1, limit) {
+		return false
+	}
+	c.bg.Add(1)
+	return true
+}
+
+func (c *Cache) endPrefetch() {
+	atomic.AddInt64(&c.prefetching, -1)
+	c.bg.Done()
+}
+
+// reserve adds n to *used if the
+// result would not exceed limit
+func reserve(used *int64, n, limit int64) bool {
+	for {
+		u := atomic.LoadInt64(used)
+		if u+n > limit {
+			return false
+		}
+		if atomic.CompareAndSwapInt64(used, u, u+n) {
+			return true
+		}
+	}
+}
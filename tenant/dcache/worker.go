@@ -125,18 +125,18 @@ func (r *reservation) miss() {
 func (c *Cache) Close() {
 	close(c.queue.out)
 	c.wg.Wait()
+	c.bg.Wait()
 }
 
-func (c *Cache) asyncReadThrough(res *reservation, mp *mapping) bool {
+func (c *Cache) asyncReadThrough(res *reservation, e *entry) bool {
 	if !c.queue.tryBackground() {
 		return false
 	}
 	go func() {
 		defer c.queue.endBackground()
-		pop, err := readThrough(res.seg, mp, res)
-		if mp != nil {
-			c.finalize(mp, pop)
-			c.unmap(mp)
+		pop, err := readThrough(res.seg, e, res)
+		if e != nil {
+			c.finalize(e, pop)
 		}
 		res.close(err)
 	}()
@@ -148,7 +148,7 @@ func (c *Cache) worker() {
 	q := &c.queue
 outer:
 	for res := range q.out {
-		mp := c.mmap(res.seg, res.flags)
+		e := c.acquire(res.seg, res.flags)
 
 		// remove from reserved map
 		// so that res.out is safe to access
@@ -158,20 +158,19 @@ outer:
 
 		var err error
 		pop := false
-		if mp != nil && mp.populated {
+		if e != nil && e.populated {
 			res.hit()
-			err = res.seg.Decode(res, mp.mem)
-			c.unmap(mp)
+			err = res.seg.Decode(res, e.mem)
+			c.done(e)
 		} else {
 			res.miss()
-			if c.asyncReadThrough(res, mp) {
+			if c.asyncReadThrough(res, e) {
 				// res.close() will be called elsewhere
 				continue outer
 			}
-			pop, err = readThrough(res.seg, mp, res)
-			if mp != nil {
-				c.finalize(mp, pop)
-				c.unmap(mp)
+			pop, err = readThrough(res.seg, e, res)
+			if e != nil {
+				c.finalize(e, pop)
 			}
 		}
 		res.close(err)
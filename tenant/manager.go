@@ -227,13 +227,15 @@ const DefaultCacheDir = "/tmp/tenant-cache"
 //	HOME=$HOME
 //	LANG=C.UTF-8
 //	CACHEDIR=<cache>
+//	QUERY_THREADS=$QUERY_THREADS
+//	PREFETCH=$PREFETCH
 func DefaultEnv(cache string, id tnproto.ID) []string {
 	x := []string{
 		"LANG=C.UTF-8",
 		"CACHEDIR=" + cache,
 	}
 	for _, evar := range []string{
-		"PATH", "SHELL", "LANG", "HOME",
+		"PATH", "SHELL", "LANG", "HOME", "QUERY_THREADS", "PREFETCH",
 	} {
 		if val := os.Getenv(evar); val != "" {
 			x = append(x, fmt.Sprintf("%s=%s", evar, val))
@@ -748,6 +750,9 @@ func (m *Manager) Quit(id tnproto.ID, key tnproto.Key) bool {
 // tenant error pipe returned from Manager.Do.
 // Check blocks until the other end of the pipe
 // has been closed, and then closes this end of the pipe.
+// If the query failed, Check returns the error
+// reported by the tenant, and stats holds
+// the statistics collected before the failure.
 func Check(rc io.ReadCloser, stats *plan.ExecStats) error {
 	defer rc.Close()
 	msg, err := io.ReadAll(rc)
@@ -758,11 +763,16 @@ func Check(rc io.ReadCloser, stats *plan.ExecStats) error {
 		return &tnproto.RemoteError{Text: "tenant crashed"}
 	}
 	if ion.TypeOf(msg) == ion.StringType {
-		str, _, err := ion.ReadString(msg)
-		if err == nil {
-			return &tnproto.RemoteError{Text: str}
+		str, rest, err := ion.ReadString(msg)
+		if err != nil {
+			return &tnproto.RemoteError{Text: "(malformed error response)"}
+		}
+		// the error may be followed by the statistics
+		// collected before the query failed
+		if len(rest) > 0 {
+			stats.UnmarshalBinary(rest)
 		}
-		return &tnproto.RemoteError{Text: "(malformed error response)"}
+		return &tnproto.RemoteError{Text: str}
 	}
 	err = stats.UnmarshalBinary(msg)
 	if err == nil {
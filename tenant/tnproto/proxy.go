@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/ion/arrow"
 	"github.com/SnellerInc/sneller/plan"
 	"github.com/SnellerInc/sneller/usock"
 )
@@ -51,6 +52,16 @@ const (
 	// OutputChunkedJSONArray outputs a single
 	// JSON array object using HTTP chunked encoding
 	OutputChunkedJSONArray
+	// OutputChunkedArrow outputs an Arrow IPC
+	// stream using HTTP chunked encoding
+	OutputChunkedArrow
+	// OutputChunkedIonSchema outputs an ion data
+	// stream whose symbol table starts with the
+	// result columns of the query and is only ever
+	// appended to (see ion.SchemaWriter)
+	// using HTTP chunked encoding; it can only be
+	// used for queries with known result columns
+	OutputChunkedIonSchema
 )
 
 func (o OutputFormat) String() string {
@@ -63,6 +74,10 @@ func (o OutputFormat) String() string {
 		return "chunked-json"
 	case OutputChunkedJSONArray:
 		return "chunked-json-array"
+	case OutputChunkedArrow:
+		return "chunked-arrow"
+	case OutputChunkedIonSchema:
+		return "chunked-ion-schema"
 	default:
 		return fmt.Sprintf("unknown format %c", byte(o))
 	}
@@ -73,7 +88,7 @@ func (o OutputFormat) String() string {
 // handled by the net/http package when
 // the parent's HTTP handler returns,
 // hence we do not call http.NewChunkedWriter(...).Close()
-func (o OutputFormat) writer(dst io.WriteCloser) io.WriteCloser {
+func (o OutputFormat) writer(dst io.WriteCloser, t *plan.Tree) io.WriteCloser {
 	switch o {
 	case OutputRaw:
 		return dst
@@ -83,6 +98,10 @@ func (o OutputFormat) writer(dst io.WriteCloser) io.WriteCloser {
 		return httpChunkedJSON(dst)
 	case OutputChunkedJSONArray:
 		return httpJSONArray(dst)
+	case OutputChunkedArrow:
+		return httpArrow(dst)
+	case OutputChunkedIonSchema:
+		return httpIonSchema(dst, t)
 	default:
 		panic(fmt.Sprintf("bad output format: %s", o))
 	}
@@ -239,7 +258,8 @@ func (b *Buffer) Prepare(t *plan.Tree, f OutputFormat) error {
 // then the query terminated successfully.
 // Otherwise, the data returned via the ReadCloser
 // will consist of error text describing how the
-// query failed to execute.
+// query failed to execute, followed by the statistics
+// collected before the failure.
 // Closing the returned ReadCloser before reading
 // the response implicitly cancels the query execution.
 //
@@ -368,7 +388,7 @@ func (s *Server) Serve(ctl *net.UnixConn) error {
 					conn.Close()
 					return err
 				}
-				go s.serveDirect(t, ofmt.writer(conn), errorWriter)
+				go s.serveDirect(t, ofmt.writer(conn, t), errorWriter)
 			}
 		} else {
 			if conn != nil {
@@ -387,39 +407,85 @@ func (s *Server) serveProxy(conn net.Conn) {
 	}
 }
 
+// ErrTimeout is the error reported by a tenant
+// for a query that was stopped with [Timeout].
+var ErrTimeout = errors.New("query timed out")
+
+// Timeout asks the tenant to stop executing the query
+// associated with the error pipe returned from
+// [Buffer.DirectExec].
+//
+// Unlike closing the error pipe, which abandons the
+// query altogether, the tenant terminates the query output
+// with a query_error::{...} value describing [ErrTimeout]
+// and the statistics collected so far, and then writes
+// the error and the statistics to the error pipe
+// as it would for any other failed query.
+func Timeout(errpipe io.ReadCloser) error {
+	w, ok := errpipe.(io.Writer)
+	if !ok {
+		return fmt.Errorf("tnproto.Timeout: cannot write to %T", errpipe)
+	}
+	_, err := w.Write([]byte{'T'})
+	return err
+}
+
 // pipectx returns a context.Context that is canceled
-// when the pipe is closed
+// when the pipe is closed or when the caller
+// writes to it (see Timeout), in which case
+// context.Cause returns ErrTimeout
 func pipectx(errpipe net.Conn) context.Context {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	dctx, dcancel := context.WithTimeout(context.Background(), 15*time.Minute)
+	ctx, cancel := context.WithCancelCause(dctx)
 	go func() {
-		defer cancel()
+		defer dcancel()
 		var buf [1]byte
 		dl, _ := ctx.Deadline()
 		// make sure that the call to Read returns
 		// no later than the cancellation deadline:
 		errpipe.SetReadDeadline(dl)
-		errpipe.Read(buf[:])
+		n, _ := errpipe.Read(buf[:])
 		// either we are already canceled (errpipe closed)
 		// or we got EOF (and should cancel) or we got
-		// unexpected data (and should probably still cancel)
+		// data, which means the caller timed out the query
+		if n > 0 {
+			cancel(ErrTimeout)
+		} else {
+			cancel(nil)
+		}
 	}()
 	return ctx
 }
 
-func sendError(conn io.WriteCloser, err error) {
+// sendError writes query_error::{error_message: "..."}
+// into conn; if stats is non-nil, the statistics
+// are included as the hits, misses and scanned fields
+func sendError(conn io.WriteCloser, err error, stats *plan.ExecStats) {
 	var st ion.Symtab
 	var buf ion.Buffer
 
-	// send
-	//   query_error::{error_message: "..."}
 	errsym := st.Intern("query_error")
 	message := st.Intern("error_message")
+	var hits, misses, scanned ion.Symbol
+	if stats != nil {
+		hits = st.Intern("hits")
+		misses = st.Intern("misses")
+		scanned = st.Intern("scanned")
+	}
 	st.Marshal(&buf, true)
 	buf.BeginAnnotation(1)
 	buf.BeginField(errsym)
 	buf.BeginStruct(-1)
 	buf.BeginField(message)
 	buf.WriteString(err.Error())
+	if stats != nil {
+		buf.BeginField(hits)
+		buf.WriteInt(stats.CacheHits)
+		buf.BeginField(misses)
+		buf.WriteInt(stats.CacheMisses)
+		buf.BeginField(scanned)
+		buf.WriteInt(stats.BytesScanned)
+	}
 	buf.EndStruct()
 	buf.EndAnnotation()
 
@@ -446,7 +512,7 @@ func (s *Server) serveDirect(t *plan.Tree, conn io.WriteCloser, errpipe net.Conn
 			panic(e)
 		}
 	}()
-	pl := plan.LocalTransport{}
+	pl := plan.LocalTransport{Threads: s.Threads}
 	ep := plan.ExecParams{
 		Plan:    t,
 		Output:  conn,
@@ -456,7 +522,7 @@ func (s *Server) serveDirect(t *plan.Tree, conn io.WriteCloser, errpipe net.Conn
 	if s.InitFS != nil && !t.Data.IsEmpty() {
 		fs, err := s.InitFS(t.Data)
 		if err != nil {
-			sendError(conn, err)
+			sendError(conn, err, nil)
 			conn.Close()
 			return
 		}
@@ -464,16 +530,20 @@ func (s *Server) serveDirect(t *plan.Tree, conn io.WriteCloser, errpipe net.Conn
 	}
 	err := pl.Exec(&ep)
 	if err != nil {
-		sendError(conn, err)
+		if errors.Is(context.Cause(ctx), ErrTimeout) {
+			err = ErrTimeout
+		}
+		sendError(conn, err, &ep.Stats)
 	}
 	// must close the connection before
 	// indicating the query status to the caller
 	conn.Close()
 	if err != nil {
+		// the error is followed by the statistics
+		// collected up to the point of failure
 		outbuf.WriteString(err.Error())
-	} else {
-		ep.Stats.Marshal(&outbuf)
 	}
+	ep.Stats.Marshal(&outbuf)
 	errpipe.Write(outbuf.Bytes())
 }
 
@@ -545,3 +615,49 @@ func (a *arrayWriter) Close() error {
 	}
 	return err
 }
+
+type arrowWriter struct {
+	*arrow.Writer
+	final io.Closer
+}
+
+func httpArrow(dst io.WriteCloser) io.WriteCloser {
+	return &arrowWriter{
+		Writer: arrow.NewWriter(httputil.NewChunkedWriter(dst)),
+		final:  dst,
+	}
+}
+
+func (a *arrowWriter) Close() error {
+	err := a.Writer.Close()
+	err2 := a.final.Close()
+	if err == nil {
+		err = err2
+	}
+	return err
+}
+
+type schemaWriter struct {
+	*ion.SchemaWriter
+	final io.Closer
+}
+
+func httpIonSchema(dst io.WriteCloser, t *plan.Tree) io.WriteCloser {
+	columns := make([]string, len(t.Results))
+	for i := range t.Results {
+		columns[i] = t.Results[i].Result()
+	}
+	return &schemaWriter{
+		SchemaWriter: ion.NewSchemaWriter(httputil.NewChunkedWriter(dst), columns),
+		final:        dst,
+	}
+}
+
+func (s *schemaWriter) Close() error {
+	err := s.SchemaWriter.Close()
+	err2 := s.final.Close()
+	if err == nil {
+		err = err2
+	}
+	return err
+}
@@ -25,6 +25,7 @@ import (
 	"io"
 	"io/fs"
 	"math"
+	"math/big"
 	"math/bits"
 	"math/rand"
 	"os"
@@ -539,6 +540,12 @@ func CanShuffleSymtab(q *expr.Query) bool {
 
 // CanShuffleInput determines whether the inputs to the query can be shuffled
 func CanShuffleInput(q *expr.Query) bool {
+	if u, ok := q.Body.(*expr.Union); ok {
+		// ORDER BY in the last query of a set
+		// operation applies to the whole result
+		last, ok := u.Right.(*expr.Select)
+		return ok && last.OrderBy != nil
+	}
 	sel, ok := q.Body.(*expr.Select)
 	if !ok {
 		return false
@@ -1066,6 +1073,22 @@ func IonizeRow(b []byte, outst *ion.Symtab, symbolize func() bool) ([]ion.Datum,
 
 	parseSpecialFPValues := func(s string) ion.Datum {
 		const prefix = "float64:"
+		// JSON has no decimals, so "decimal:1.25"
+		// stands for the ion decimal 1.25
+		if num, ok := strings.CutPrefix(s, "decimal:"); ok {
+			coef, exp, ok := parseDecimal(num)
+			if !ok {
+				panic(fmt.Sprintf("invalid decimal %q", s))
+			}
+			return ion.Decimal(coef, exp)
+		}
+		// timestamps converted from JSON always have
+		// microsecond precision, so "timestamp:..."
+		// stands for an ion timestamp with exactly
+		// as many fractional digits as the text
+		if text, ok := strings.CutPrefix(s, "timestamp:"); ok {
+			return parseTimestamp(text)
+		}
 		if fn, ok := strings.CutPrefix(s, prefix); ok {
 			switch fn {
 			case "inf", "+inf":
@@ -1138,6 +1161,48 @@ func IonizeRow(b []byte, outst *ion.Symtab, symbolize func() bool) ([]ion.Datum,
 	return lst, nil
 }
 
+// parseTimestamp parses a timestamp with 0, 3, 6 or 9
+// fractional digits into an ion timestamp of the
+// corresponding precision
+func parseTimestamp(s string) ion.Datum {
+	t, ok := date.Parse([]byte(s))
+	if !ok {
+		panic(fmt.Sprintf("invalid timestamp %q", s))
+	}
+	var trunc ion.TimeTrunc
+	_, frac, _ := strings.Cut(s, ".")
+	switch len(frac) - len(strings.TrimLeft(frac, "0123456789")) {
+	case 0:
+		trunc = ion.TruncToSecond
+	case 3:
+		trunc = ion.TruncToMillisecond
+	case 6:
+		trunc = ion.TruncToMicrosecond
+	case 9:
+		trunc = ion.TruncToNanosecond
+	default:
+		panic(fmt.Sprintf("unsupported precision of timestamp %q", s))
+	}
+	var buf ion.Buffer
+	buf.WriteTruncatedTime(t, trunc)
+	d, _, err := ion.ReadDatum(nil, buf.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// parseDecimal parses [-]digits[.digits]
+// into coef * 10^exp
+func parseDecimal(s string) (*big.Int, int, bool) {
+	whole, frac, _ := strings.Cut(s, ".")
+	coef, ok := new(big.Int).SetString(whole+frac, 10)
+	if !ok || strings.ContainsAny(frac, "+-") {
+		return nil, 0, false
+	}
+	return coef, -len(frac), true
+}
+
 func flatten(lst []ion.Datum, st *ion.Symtab) []byte {
 	var outbuf ion.Buffer
 	for i := range lst {
@@ -660,6 +660,7 @@ var ignoredMacrosList = []string{
 	"BC_ARITH_OP_I64_IMPL_K",
 	"BC_ARITH_REVERSE_OP_F64_IMM_IMPL",
 	"BC_ARITH_REVERSE_OP_I64_IMM_IMPL",
+	"BC_ARRAY_ELEMENT_SIZE",
 	"BC_CALC_ADVANCE",
 	"BC_CALC_STRING_TLV_AND_HLEN",
 	"BC_CALC_VALUE_HLEN",
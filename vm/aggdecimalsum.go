@@ -0,0 +1,359 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// IsExactSum returns true if agg is a SUM whose
+// argument is known to evaluate to an ion decimal
+// (or MISSING or NULL), as in SUM(CAST(x AS DECIMAL)).
+// Such sums are computed exactly (see decimalSumSpec),
+// and their partial results are decimals as well.
+func IsExactSum(agg *expr.Aggregate) bool {
+	if agg.Op != expr.OpSum || agg.Over != nil {
+		return false
+	}
+	t := expr.TypeOf(agg.Inner, nil)
+	return t&expr.DecimalType != 0 &&
+		t&^(expr.DecimalType|expr.MissingType|expr.NullType) == 0
+}
+
+// decimalSumSpec holds the states of the SUMs of decimals;
+// each state accumulates its inputs in a 128-bit integer
+// scaled to the largest number of fractional digits seen
+// so far, so (unlike the floating-point SUM) the result
+// does not depend on the number or the order of the inputs.
+// The bytecode has no decimal arithmetic, so the exact
+// sums are goAggregates
+type decimalSumSpec struct {
+	// err is the first error
+	// encountered by merge (see failed)
+	err error
+
+	goAggStates[decimalSum]
+}
+
+func newDecimalSumSpec() *decimalSumSpec {
+	return &decimalSumSpec{}
+}
+
+// update adds the input of one lane to the state referenced
+// by data; mem is a decimal, which is also the partial result
+// produced by another aggregate for the Merge role
+func (s *decimalSumSpec) update(data, mem []byte, role expr.AggregateRole, bc *bytecode, lane int) error {
+	if ion.TypeOf(mem) != ion.DecimalType {
+		return nil
+	}
+	var coef big.Int
+	exp, _, err := ion.ReadDecimal(&coef, mem)
+	if err != nil {
+		return err
+	}
+	v, ok := int128FromBig(&coef)
+	if !ok {
+		return errDecimalOverflow
+	}
+	return s.state(data, true).add(v, -exp)
+}
+
+func (s *decimalSumSpec) merge(dst, src []byte) {
+	from := s.state(src, false)
+	if from == nil {
+		return
+	}
+	into := s.state(dst, false)
+	if into == nil {
+		copy(dst[:goAggDataSize], src)
+		return
+	}
+	if err := into.merge(from); err != nil {
+		s.fail(err)
+	}
+}
+
+// fail records the first error
+// encountered by merge
+func (s *decimalSumSpec) fail(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *decimalSumSpec) failed() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.err
+}
+
+func (s *decimalSumSpec) intern(st *ion.Symtab) {}
+
+// write writes the sum referenced by data as a decimal,
+// or NULL if there was no input; the partial result
+// is the same as the final one
+func (s *decimalSumSpec) write(b *ion.Buffer, data []byte, partial bool) {
+	st := s.state(data, false)
+	if st == nil || !st.valid {
+		b.WriteNull()
+		return
+	}
+	coef, exp := st.result()
+	b.WriteDecimal(coef, exp)
+}
+
+// compare orders the sums referenced by a and b;
+// NULL sorts before any number
+func (s *decimalSumSpec) compare(a, b []byte) int {
+	left, right := s.state(a, false), s.state(b, false)
+	lok := left != nil && left.valid
+	rok := right != nil && right.valid
+	switch {
+	case !lok && !rok:
+		return 0
+	case !lok:
+		return -1
+	case !rok:
+		return 1
+	}
+	return left.cmp(right)
+}
+
+// aggregateDecimalSum compiles the input of a SUM for which
+// IsExactSum holds: the decimal value, or the partial
+// result for the Merge role
+func (p *prog) aggregateDecimalSum(agg *expr.Aggregate, filter *value, slot aggregateslot) (*value, error) {
+	v, err := p.serialized(agg.Inner)
+	if err != nil {
+		return nil, err
+	}
+	mask := p.mask(v)
+	if filter != nil {
+		mask = p.and(mask, filter)
+	}
+	return p.ssa2imm(saggmergevalue, v, mask, slot), nil
+}
+
+func (p *prog) aggregateSlotDecimalSum(agg *expr.Aggregate, bucket, mask *value, slot aggregateslot) (*value, error) {
+	v, err := p.serialized(agg.Inner)
+	if err != nil {
+		return nil, err
+	}
+	return p.ssa3imm(saggslotmergevalue, bucket, v, p.and(mask, p.mask(v)), slot), nil
+}
+
+// decimalToFloatFn is the scalarFunc of DECIMAL_TO_FLOAT,
+// which lets the bytecode compare the results of exact
+// sums with other numbers; NULL is returned as-is,
+// and the other values that are not decimals are MISSING
+type decimalToFloatFn struct{}
+
+func (decimalToFloatFn) bind() scalarImpl {
+	var coef big.Int
+	var r big.Rat
+	var out ion.Buffer
+	return func(x *scalarCaller, args []vRegData, lane int) (vmref, error) {
+		mem := x.arg(&args[0], lane)
+		if len(mem) == 0 {
+			return vmref{}, nil
+		}
+		if mem[0]&0x0f == 0x0f {
+			return x.value(mem), nil
+		}
+		if ion.TypeOf(mem) != ion.DecimalType {
+			return vmref{}, nil
+		}
+		exp, _, err := ion.ReadDecimal(&coef, mem)
+		if err != nil {
+			return vmref{}, err
+		}
+		if exp < 0 {
+			r.SetFrac(&coef, pow10(-exp))
+		} else {
+			r.SetInt(coef.Mul(&coef, pow10(exp)))
+		}
+		f, _ := r.Float64()
+		out.Reset()
+		out.WriteFloat64(f)
+		return x.value(out.Bytes()), nil
+	}
+}
+
+var errDecimalOverflow = fmt.Errorf("exact DECIMAL SUM overflows 128 bits")
+
+// decimalSum is the state of an exact SUM:
+// the result is sum * 10^-scale
+type decimalSum struct {
+	sum   int128
+	scale int
+	valid bool // at least one input
+}
+
+// result returns the coefficient and
+// the exponent of the decimal value of d
+func (d *decimalSum) result() (*big.Int, int) {
+	coef := new(big.Int)
+	d.sum.big(coef)
+	scale := d.scale
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	return coef, -scale
+}
+
+// cmp compares the values of two valid sums
+func (d *decimalSum) cmp(o *decimalSum) int {
+	var a, b big.Int
+	d.sum.big(&a)
+	o.sum.big(&b)
+	if d.scale < o.scale {
+		a.Mul(&a, pow10(o.scale-d.scale))
+	} else if d.scale > o.scale {
+		b.Mul(&b, pow10(d.scale-o.scale))
+	}
+	return a.Cmp(&b)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func (d *decimalSum) add(v int128, scale int) error {
+	if !d.valid {
+		d.sum, d.scale, d.valid = v, scale, true
+		return nil
+	}
+	var ok bool
+	if scale > d.scale {
+		d.sum, ok = d.sum.mulpow10(scale - d.scale)
+		if !ok {
+			return errDecimalOverflow
+		}
+		d.scale = scale
+	} else if scale < d.scale {
+		v, ok = v.mulpow10(d.scale - scale)
+		if !ok {
+			return errDecimalOverflow
+		}
+	}
+	d.sum, ok = d.sum.add(v)
+	if !ok {
+		return errDecimalOverflow
+	}
+	return nil
+}
+
+func (d *decimalSum) merge(o *decimalSum) error {
+	if !o.valid {
+		return nil
+	}
+	return d.add(o.sum, o.scale)
+}
+
+// int128 is a two's complement 128-bit integer
+type int128 struct {
+	hi int64
+	lo uint64
+}
+
+func (a int128) neg() int128 {
+	lo, borrow := bits.Sub64(0, a.lo, 0)
+	return int128{hi: -a.hi - int64(borrow), lo: lo}
+}
+
+// add returns a+b and false on overflow
+func (a int128) add(b int128) (int128, bool) {
+	lo, carry := bits.Add64(a.lo, b.lo, 0)
+	out := int128{hi: a.hi + b.hi + int64(carry), lo: lo}
+	// signed overflow iff the operands have
+	// the same sign and the result does not
+	if (a.hi < 0) == (b.hi < 0) && (out.hi < 0) != (a.hi < 0) {
+		return out, false
+	}
+	return out, true
+}
+
+// mulpow10 returns a*10^n and false on overflow
+func (a int128) mulpow10(n int) (int128, bool) {
+	if a.hi == 0 && a.lo == 0 {
+		return a, true
+	}
+	negative := a.hi < 0
+	if negative {
+		a = a.neg()
+		if a.hi < 0 {
+			return a, false // math.MinInt128
+		}
+	}
+	for n > 0 {
+		k := min(n, 19) // 10^19 fits in a uint64
+		m := uint64(1)
+		for i := 0; i < k; i++ {
+			m *= 10
+		}
+		n -= k
+		hi1, lo := bits.Mul64(a.lo, m)
+		hi2, hi := bits.Mul64(uint64(a.hi), m)
+		hi, carry := bits.Add64(hi, hi1, 0)
+		if hi2 != 0 || carry != 0 || int64(hi) < 0 {
+			return a, false
+		}
+		a = int128{hi: int64(hi), lo: lo}
+	}
+	if negative {
+		a = a.neg()
+	}
+	return a, true
+}
+
+func int128FromBig(b *big.Int) (int128, bool) {
+	if b.BitLen() > 127 {
+		return int128{}, false
+	}
+	var mag [16]byte
+	b.FillBytes(mag[:])
+	out := int128{
+		hi: int64(binary.BigEndian.Uint64(mag[:8])),
+		lo: binary.BigEndian.Uint64(mag[8:]),
+	}
+	if b.Sign() < 0 {
+		out = out.neg()
+	}
+	return out, true
+}
+
+// big stores a into dst
+func (a int128) big(dst *big.Int) {
+	negative := a.hi < 0
+	if negative {
+		a = a.neg() // MinInt128 still has the right unsigned magnitude
+	}
+	var mag [16]byte
+	binary.BigEndian.PutUint64(mag[:8], uint64(a.hi))
+	binary.BigEndian.PutUint64(mag[8:], a.lo)
+	dst.SetBytes(mag[:])
+	if negative {
+		dst.Neg(dst)
+	}
+}
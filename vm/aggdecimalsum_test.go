@@ -0,0 +1,247 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// decimalRows returns a chunk containing one
+// row {x: coef * 10^exp, g: group} per item in rows
+func decimalRows(t *testing.T, rows [][3]int64) []byte {
+	var st ion.Symtab
+	var buf, chunk ion.Buffer
+	for _, r := range rows {
+		buf.BeginStruct(-1)
+		buf.BeginField(st.Intern("x"))
+		buf.WriteDecimal(big.NewInt(r[0]), int(r[1]))
+		buf.BeginField(st.Intern("g"))
+		buf.WriteInt(r[2])
+		buf.EndStruct()
+	}
+	st.Marshal(&chunk, true)
+	chunk.UnsafeAppend(buf.Bytes())
+	if chunk.Size() > PageSize {
+		t.Fatal("test input does not fit in a page")
+	}
+	return chunk.Bytes()
+}
+
+func decimalSumOf(e expr.Node, role expr.AggregateRole) *expr.Aggregate {
+	return &expr.Aggregate{
+		Op:    expr.OpSum,
+		Role:  role,
+		Inner: &expr.Cast{From: e, To: expr.DecimalType},
+	}
+}
+
+func TestDecimalSumExact(t *testing.T) {
+	const perChunk = 10000
+	rows := make([][3]int64, perChunk)
+	for i := range rows {
+		rows[i] = [3]int64{1, -2, 0} // 0.01
+	}
+	tbl := &looptable{count: 100, chunk: decimalRows(t, rows)}
+
+	var out QueryBuffer
+	ds, err := NewAggregate(Aggregation{{
+		Expr:   decimalSumOf(path(t, "x"), expr.AggregateRoleFinal),
+		Result: "sum",
+	}}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = CopyRows(ds, tbl, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ds.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := readRows(t, out.Bytes())
+	if len(got) != 1 {
+		t.Fatalf("got %d rows", len(got))
+	}
+	f, ok := got[0].FieldByName("sum")
+	if !ok {
+		t.Fatal("missing sum")
+	}
+	if s := f.Datum.JSON(); s != "10000.00" {
+		t.Errorf("got %s, want 10000.00", s)
+	}
+}
+
+func TestDecimalSumGrouped(t *testing.T) {
+	chunk := decimalRows(t, [][3]int64{
+		{125, -2, 0}, // 1.25
+		{-3, 0, 0},   // -3
+		{1, -4, 0},   // 0.0001
+		{7, 1, 1},    // 70
+		{5, -1, 1},   // 0.5
+	})
+	run := func(agg *expr.Aggregate, input Table) *QueryBuffer {
+		out := new(QueryBuffer)
+		ds, err := NewHashAggregate(Aggregation{{
+			Expr:   agg,
+			Result: "sum",
+		}}, nil, Selection{expr.Bind(path(t, "g"), "g")}, out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = CopyRows(ds, input, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ds.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	check := func(out *QueryBuffer) {
+		t.Helper()
+		got := make(map[string]string)
+		for _, row := range readRows(t, out.Bytes()) {
+			g, _ := row.FieldByName("g")
+			sum, _ := row.FieldByName("sum")
+			got[g.Datum.JSON()] = sum.Datum.JSON()
+		}
+		want := map[string]string{
+			"0": "-5.2497", // 3 * (1.25 - 3 + 0.0001)
+			"1": "211.5",   // 3 * (70 + 0.5)
+		}
+		for k, v := range want {
+			if got[k] != v {
+				t.Errorf("group %s: got %q, want %q", k, got[k], v)
+			}
+		}
+	}
+	check(run(decimalSumOf(path(t, "x"), expr.AggregateRoleFinal), &looptable{count: 3, chunk: chunk}))
+
+	// the partial sums are merged exactly
+	partial := run(decimalSumOf(path(t, "x"), expr.AggregateRolePartial), &looptable{count: 3, chunk: chunk})
+	for _, row := range readRows(t, partial.Bytes()) {
+		if f, ok := row.FieldByName("sum"); !ok || f.Datum.Type() != ion.DecimalType {
+			t.Errorf("partial row %s has no exact sum", row.Datum().JSON())
+		}
+	}
+	check(run(decimalSumOf(expr.Ident("sum"), expr.AggregateRoleMerge), partial.Table()))
+}
+
+func TestDecimalSumMixed(t *testing.T) {
+	var st ion.Symtab
+	var buf, chunk ion.Buffer
+	for _, r := range []struct {
+		g int64
+		x ion.Datum
+	}{
+		{0, ion.Decimal(big.NewInt(125), -2)},
+		{0, ion.Int(2)},
+		{1, ion.Decimal(big.NewInt(5), -1)},
+		{1, ion.Float(1.5)},
+		{2, ion.Int(3)},
+		{2, ion.String("x")},
+	} {
+		buf.BeginStruct(-1)
+		buf.BeginField(st.Intern("x"))
+		r.x.Encode(&buf, &st)
+		buf.BeginField(st.Intern("g"))
+		buf.WriteInt(r.g)
+		buf.EndStruct()
+	}
+	st.Marshal(&chunk, true)
+	chunk.UnsafeAppend(buf.Bytes())
+
+	var out QueryBuffer
+	ha, err := NewHashAggregate(Aggregation{
+		{Expr: decimalSumOf(path(t, "x"), expr.AggregateRoleFinal), Result: "sum"},
+		{Expr: expr.Sum(path(t, "x")), Result: "fsum"},
+		{Expr: expr.Count(expr.Star{}), Result: "count"},
+	}, nil, Selection{expr.Bind(path(t, "g"), "g")}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = CopyRows(ha, &looptable{count: 1, chunk: chunk.Bytes()}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ha.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][3]string)
+	for _, row := range readRows(t, out.Bytes()) {
+		var res [3]string
+		for i, name := range []string{"sum", "fsum", "count"} {
+			f, _ := row.FieldByName(name)
+			res[i] = f.Datum.JSON()
+		}
+		g, _ := row.FieldByName("g")
+		got[g.Datum.JSON()] = res
+	}
+	want := map[string][3]string{
+		// the cast ignores the other numbers,
+		// and the plain SUM ignores decimals
+		"0": {"1.25", "2", "2"},
+		"1": {"0.5", "1.5", "2"},
+		"2": {"null", "3", "2"},
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("group %s: got %v, want %v", k, got[k], v)
+		}
+	}
+}
+
+func TestDecimalSumOverflow(t *testing.T) {
+	max := int128{hi: math.MaxInt64, lo: math.MaxUint64}
+	if _, ok := max.add(int128{lo: 1}); ok {
+		t.Error("MaxInt128 + 1 did not overflow")
+	}
+	if v, ok := max.neg().add(int128{hi: -1, lo: math.MaxUint64}); !ok || v != (int128{hi: math.MinInt64}) {
+		t.Errorf("-MaxInt128 - 1 = %v, %v", v, ok)
+	}
+	ten := int128{lo: 10}
+	if v, ok := ten.neg().mulpow10(36); !ok {
+		t.Error("-10^37 overflowed")
+	} else {
+		var b big.Int
+		v.big(&b)
+		if b.String() != "-1"+strings.Repeat("0", 37) {
+			t.Errorf("-10^37 = %s", &b)
+		}
+	}
+	if _, ok := ten.mulpow10(38); ok {
+		t.Error("10^39 did not overflow")
+	}
+	var d decimalSum
+	if err := d.add(int128{lo: 1}, 0); err != nil {
+		t.Fatal(err)
+	}
+	// rescaling 1 to 40 fractional digits overflows
+	if err := d.add(int128{lo: 1}, 40); err != errDecimalOverflow {
+		t.Errorf("expected overflow; got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sync"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// goAggDataSize is the number of bytes used by an aggregate
+// whose state lives in Go (see goAggregate): a handle (index+1)
+// of its state, or zero if the aggregate has not seen any input yet.
+//
+// The bytecode passes the inputs of each row through the
+// merge-state buffer, like the aggregates that merge their
+// internal state, and aggregateLocal.writeRows and
+// aggtable.writeRows hand them over to goAggregate.update.
+const goAggDataSize = 8
+
+// goAggregate is implemented by the aggregates
+// whose state is unbounded (STRING_AGG, TOPK),
+// so it cannot live in the aggregate buffer
+type goAggregate interface {
+	// update adds the input of one lane to the state
+	// referenced by data; mem is the input passed through
+	// the merge-state buffer
+	update(data, mem []byte, role expr.AggregateRole, bc *bytecode, lane int) error
+	// merge adds the state referenced by src
+	// to the state referenced by dst
+	merge(dst, src []byte)
+	// intern adds the symbols used by write to st
+	intern(st *ion.Symtab)
+	// write writes the result (or the partial result)
+	// of the aggregate referenced by data
+	write(b *ion.Buffer, data []byte, partial bool)
+	// compare orders the final results
+	// referenced by a and b
+	compare(a, b []byte) int
+	// reset drops all the states
+	reset()
+}
+
+// goLanesAggregate is implemented by the goAggregates
+// that are updated with the inputs of several lanes
+// at once (user-defined aggregates); update is used
+// only for the inputs of a single lane
+type goLanesAggregate interface {
+	goAggregate
+	// updateLanes adds the inputs of the lanes in mask
+	// to the state referenced by data; mem[i] is the
+	// input of lane i passed through the merge-state buffer,
+	// and st is the symbol table of the inputs
+	updateLanes(data []byte, mem *[bcLaneCount][]byte, mask uint16, role expr.AggregateRole, st *ion.Symtab) error
+}
+
+// goAggFailer is implemented by the goAggregates
+// that may fail to write their results
+type goAggFailer interface {
+	// failed returns the first error
+	// encountered by merge or write
+	failed() error
+}
+
+// updateGoAggLanes passes the inputs of the lanes in pending
+// to g, grouped by the offsets of the states in dst
+// (the lanes of a hash aggregate update different groups)
+func updateGoAggLanes(g goLanesAggregate, dst []byte, mem *[bcLaneCount][]byte, offsets *[bcLaneCount]uint32, pending uint16, role expr.AggregateRole, st *ion.Symtab) error {
+	for pending != 0 {
+		first := bits.TrailingZeros16(pending)
+		mask := uint16(0)
+		for i := first; i < bcLaneCount; i++ {
+			if pending&(1<<i) != 0 && offsets[i] == offsets[first] {
+				mask |= 1 << i
+			}
+		}
+		pending &^= mask
+		if err := g.updateLanes(dst[offsets[first]:], mem, mask, role, st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// goAggStates holds the states of a goAggregate
+// referenced by the handles in the aggregate buffer
+type goAggStates[T any] struct {
+	// init, if non-nil, allocates a new state
+	init func() *T
+
+	lock   sync.Mutex
+	states []*T
+}
+
+// state returns the state referenced by data;
+// if there is none, a new one is allocated when
+// alloc is set, otherwise nil is returned
+func (g *goAggStates[T]) state(data []byte, alloc bool) *T {
+	h := binary.LittleEndian.Uint64(data)
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if h != 0 {
+		return g.states[h-1]
+	}
+	if !alloc {
+		return nil
+	}
+	var st *T
+	if g.init != nil {
+		st = g.init()
+	} else {
+		st = new(T)
+	}
+	g.states = append(g.states, st)
+	binary.LittleEndian.PutUint64(data, uint64(len(g.states)))
+	return st
+}
+
+func (g *goAggStates[T]) reset() {
+	g.lock.Lock()
+	g.states = nil
+	g.lock.Unlock()
+}
+
+func resetGoAggs(ops []AggregateOp) {
+	for i := range ops {
+		if ops[i].goagg != nil {
+			ops[i].goagg.reset()
+		}
+	}
+}
+
+// goAggsFailed returns the first error
+// encountered while merging or writing
+// the results of the goAggregates of ops
+func goAggsFailed(ops []AggregateOp) error {
+	for i := range ops {
+		if f, ok := ops[i].goagg.(goAggFailer); ok {
+			if err := f.failed(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func internGoAggs(ops []AggregateOp, st *ion.Symtab) {
+	for i := range ops {
+		if ops[i].goagg != nil {
+			ops[i].goagg.intern(st)
+		}
+	}
+}
+
+// goAggInput compiles the input of TOPK or of a
+// user-defined aggregate: the value (with symbols
+// converted to strings) or the partial result
+// for the Merge role
+func (p *prog) goAggInput(agg *expr.Aggregate) (*value, error) {
+	if agg.Role == expr.AggregateRoleMerge {
+		v, err := compile(p, agg.Inner)
+		if err != nil {
+			return nil, err
+		}
+		return p.ssa2(stoblob, v, p.mask(v)), nil
+	}
+	v, err := p.serialized(agg.Inner)
+	if err != nil {
+		return nil, err
+	}
+	return p.unsymbolized(v), nil
+}
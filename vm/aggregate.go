@@ -58,6 +58,10 @@ const (
 	AggregateOpMaxTS
 	AggregateOpCount
 	AggregateOpApproxCountDistinct
+	AggregateOpStringAgg
+	AggregateOpTopK
+	AggregateOpUser
+	AggregateOpSumD
 )
 
 func (o AggregateOpFn) String() string {
@@ -102,6 +106,14 @@ func (o AggregateOpFn) String() string {
 		return "AggregateOpApproxCountDistinct"
 	case AggregateOpTDigest:
 		return "AggregateOpTDigest"
+	case AggregateOpStringAgg:
+		return "AggregateOpStringAgg"
+	case AggregateOpTopK:
+		return "AggregateOpTopK"
+	case AggregateOpUser:
+		return "AggregateOpUser"
+	case AggregateOpSumD:
+		return "AggregateOpSumD"
 	default:
 		return fmt.Sprintf("<AggregateOpFn=%d>", int(o))
 	}
@@ -121,6 +133,10 @@ const aggregateOpMergeBufferSize = aggregateOpMergeBufferRowsCount * aggregateOp
 // aggtable.writeRows implementations for the item's interpretation.
 const aggregateOpMergeBufferItemSize = 3 * 4
 
+// aggregateOpMergeBufferInactive is the size stored in the extra
+// buffer of Aggregate for the lanes not written by the bytecode.
+const aggregateOpMergeBufferInactive = ^uint32(0)
+
 // AggregateOp describes aggregate operation
 type AggregateOp struct {
 	fn AggregateOpFn
@@ -133,12 +149,20 @@ type AggregateOp struct {
 
 	// misc used by AggregateOpTDigest to contain the percentile values p
 	misc float32
+
+	// goagg holds the parameters and the states of the aggregates
+	// whose state lives in Go (AggregateOpStringAgg, AggregateOpTopK,
+	// AggregateOpUser and AggregateOpSumD)
+	goagg goAggregate
 }
 
 // The operation needs to pass its whole internal state to the master
 // machine (the buffer is used to perform actual aggregation).
+//
+// The aggregates with Go state pass their inputs
+// to Go the same way for every role.
 func (a AggregateOp) mergestate() bool {
-	return a.role == expr.AggregateRoleMerge
+	return a.role == expr.AggregateRoleMerge || a.goagg != nil
 }
 
 func (a AggregateOp) savestate() bool {
@@ -182,6 +206,10 @@ var aggregateOpInfoTable = [...]aggregateOpInfo{
 
 	AggregateOpTDigest:             {isAtomic: false, initFunc: tDigestInit},
 	AggregateOpApproxCountDistinct: {isAtomic: false, initFunc: aggApproxCountDistinctInit},
+	AggregateOpStringAgg:           {isAtomic: false, initUInt64: 0},
+	AggregateOpTopK:                {isAtomic: false, initUInt64: 0},
+	AggregateOpUser:                {isAtomic: false, initUInt64: 0},
+	AggregateOpSumD:                {isAtomic: false, initUInt64: 0},
 }
 
 func (a *AggregateOp) dataSize() int {
@@ -230,11 +258,31 @@ func (a *AggregateOp) dataSize() int {
 
 	case AggregateOpApproxCountDistinct:
 		return 1 << a.precision
+
+	case AggregateOpStringAgg, AggregateOpTopK, AggregateOpUser, AggregateOpSumD:
+		return goAggDataSize
 	}
 
 	return 0
 }
 
+// aggregateOpOffset returns the offset of the data of ops[n]
+// in the aggregate buffer; the data of the aggregates that
+// merge their state is preceded by the merge-state buffer
+func aggregateOpOffset(ops []AggregateOp, n int) int {
+	off := 0
+	for i := range ops[:n] {
+		if ops[i].mergestate() {
+			off += aggregateOpMergeBufferSize
+		}
+		off += ops[i].dataSize()
+	}
+	if ops[n].mergestate() {
+		off += aggregateOpMergeBufferSize
+	}
+	return off
+}
+
 func initAggregateValues(data []byte, aggregateOps []AggregateOp) {
 	for i := range aggregateOps {
 		// First value is initialized to `initUInt64`.
@@ -383,6 +431,11 @@ func mergeAggregatedValues(dst, src []byte, aggregateOps []AggregateOp) {
 			dst = dst[n:]
 			src = src[n:]
 
+		case AggregateOpStringAgg, AggregateOpTopK, AggregateOpUser, AggregateOpSumD:
+			op.goagg.merge(dst, src)
+			dst = dst[goAggDataSize:]
+			src = src[goAggDataSize:]
+
 		default:
 			panic(fmt.Sprintf("unsupported operation %s", aggregateOps[i].fn))
 		}
@@ -470,6 +523,10 @@ func mergeAggregatedValuesAtomically(dst, src []byte, aggregateOps []AggregateOp
 
 // writeAggregatedValue writes the final result of the Aggregation to the ion.Buffer
 func writeAggregatedValue(b *ion.Buffer, data []byte, op AggregateOp) int {
+	if op.goagg != nil {
+		op.goagg.write(b, data, op.savestate())
+		return goAggDataSize
+	}
 	if op.savestate() {
 		d := op.dataSize()
 		b.WriteBlob(data[:d])
@@ -618,6 +675,9 @@ type aggregateLocal struct {
 	rowCount    uint64
 	partialData []byte
 	mergestate  bool
+
+	// st is the symbol table of the input
+	st *ion.Symtab
 }
 
 // AggBinding is a binding
@@ -667,6 +727,7 @@ func (q *Aggregate) Open() (io.WriteCloser, error) {
 // aggregation into the next QuerySink
 func (q *Aggregate) Close() error {
 	defer q.prog.reset()
+	defer resetGoAggs(q.aggregateOps)
 	if q.skipEmpty && q.rowcount == 0 {
 		return flushEmpty(q.rest)
 	}
@@ -677,6 +738,7 @@ func (q *Aggregate) Close() error {
 	for i := range q.bind {
 		st.Intern(q.bind[i].Result)
 	}
+	internGoAggs(q.aggregateOps, &st)
 
 	data := q.AggregatedData
 
@@ -695,6 +757,9 @@ func (q *Aggregate) Close() error {
 		data = data[consumed:]
 	}
 	b.EndStruct()
+	if err := goAggsFailed(q.aggregateOps); err != nil {
+		return err
+	}
 
 	// now that we have the whole buffer,
 	// write it to the output
@@ -717,6 +782,7 @@ func (q *Aggregate) Close() error {
 }
 
 func (p *aggregateLocal) symbolize(st *symtab, aux *auxbindings) error {
+	p.st = &st.Symtab
 	return recompile(st, p.parent.prog, &p.prog, &p.bc, aux, "aggregateLocal")
 }
 
@@ -733,7 +799,9 @@ func (p *aggregateLocal) writeRows(delims []vmref, rp *rowParams) error {
 		panic("WriteRows() called before Symbolize()")
 	}
 
-	p.bc.prepare(rp)
+	if err := p.bc.prepare(rp, delims); err != nil {
+		return err
+	}
 
 	if p.mergestate {
 		n := len(delims)
@@ -748,6 +816,8 @@ func (p *aggregateLocal) writeRows(delims []vmref, rp *rowParams) error {
 			}
 			n = len(delims)
 
+			// the bytecode writes only the active lanes
+			p.resetMergeBuffers()
 			rowsCount := evalaggregate(&p.bc, chunk, p.partialData)
 			if p.bc.err != 0 {
 				return bytecodeerror("aggregate", &p.bc)
@@ -761,11 +831,36 @@ func (p *aggregateLocal) writeRows(delims []vmref, rp *rowParams) error {
 				if op.mergestate() {
 					positions := dst[:aggregateOpMergeBufferSize]
 					dst = dst[aggregateOpMergeBufferSize:]
+					if lanes, ok := op.goagg.(goLanesAggregate); ok {
+						var mem [bcLaneCount][]byte
+						var offsets [bcLaneCount]uint32
+						pending := uint16(0)
+						for i := range chunk {
+							offset := binary.LittleEndian.Uint32(positions[4*i:])
+							size := binary.LittleEndian.Uint32(positions[4*i+64:])
+							if size != aggregateOpMergeBufferInactive {
+								mem[i] = vmref{offset, size}.mem()
+								pending |= 1 << i
+							}
+						}
+						if err := updateGoAggLanes(lanes, dst, &mem, &offsets, pending, op.role, p.st); err != nil {
+							return err
+						}
+						dst = dst[n:]
+						continue
+					}
 					for i := range chunk {
-						offset := binary.LittleEndian.Uint32(positions[8*i:])
-						size := binary.LittleEndian.Uint32(positions[8*i+64:])
+						offset := binary.LittleEndian.Uint32(positions[4*i:])
+						size := binary.LittleEndian.Uint32(positions[4*i+64:])
+						if size == aggregateOpMergeBufferInactive {
+							continue
+						}
 						v := vmref{offset, size}
-						if !mergeAggregateBuffers(dst, v.mem(), op) {
+						if op.goagg != nil {
+							if err := op.goagg.update(dst, v.mem(), op.role, &p.bc, i); err != nil {
+								return err
+							}
+						} else if !mergeAggregateBuffers(dst, v.mem(), op) {
 							panic(fmt.Sprintf("aggregate %s expected to merge its buffer", op.fn))
 						}
 					}
@@ -784,6 +879,22 @@ func (p *aggregateLocal) writeRows(delims []vmref, rp *rowParams) error {
 	return nil
 }
 
+// resetMergeBuffers marks all of the lanes
+// of the merge-state buffers as inactive
+func (p *aggregateLocal) resetMergeBuffers() {
+	dst := p.partialData
+	for _, op := range p.parent.aggregateOps {
+		if op.mergestate() {
+			sizes := dst[64:aggregateOpMergeBufferSize]
+			for i := 0; i < aggregateOpMergeBufferRowsCount; i++ {
+				binary.LittleEndian.PutUint32(sizes[4*i:], aggregateOpMergeBufferInactive)
+			}
+			dst = dst[aggregateOpMergeBufferSize:]
+		}
+		dst = dst[op.dataSize():]
+	}
+}
+
 func (p *aggregateLocal) EndSegment() {
 	p.bc.dropScratch() // restored in recompile()
 }
@@ -840,6 +951,7 @@ func (q *Aggregate) compileAggregate(aggregates Aggregation) error {
 	mem := make([]*value, len(aggregates))
 	ops := make([]AggregateOp, len(aggregates))
 	offset := aggregateslot(0)
+	keyslot := 0 // next vstack slot for the ORDER BY columns of STRING_AGG
 
 	for i := range aggregates {
 		agg := aggregates[i].Expr
@@ -885,6 +997,43 @@ func (q *Aggregate) compileAggregate(aggregates Aggregation) error {
 				mem[i] = p.aggregateMergeState(v, offset)
 			}
 
+		case expr.OpStringAgg:
+			ops[i].fn = AggregateOpStringAgg
+			ops[i].role = agg.Role
+			spec := newStringAggSpec(agg, keyslot)
+			ops[i].goagg = spec
+			if agg.Role != expr.AggregateRoleMerge {
+				keyslot += len(agg.OrderBy)
+			}
+			var err error
+			mem[i], err = p.aggregateStringAgg(agg, spec, filter, offset)
+			if err != nil {
+				return fmt.Errorf("don't know how to aggregate %q: %w", agg.Inner, err)
+			}
+
+		case expr.OpTopK, expr.OpMode:
+			ops[i].fn = AggregateOpTopK
+			ops[i].role = agg.Role
+			ops[i].goagg = newTopKSpec(agg)
+			var err error
+			mem[i], err = p.aggregateTopK(agg, filter, offset)
+			if err != nil {
+				return fmt.Errorf("don't know how to aggregate %q: %w", agg.Inner, err)
+			}
+
+		case expr.OpUser:
+			spec, err := newUDAFSpec(agg)
+			if err != nil {
+				return err
+			}
+			ops[i].fn = AggregateOpUser
+			ops[i].role = agg.Role
+			ops[i].goagg = spec
+			mem[i], err = p.aggregateUDF(agg, filter, offset)
+			if err != nil {
+				return fmt.Errorf("don't know how to aggregate %q: %w", agg.Inner, err)
+			}
+
 		case expr.OpBoolAnd, expr.OpBoolOr:
 			argv, err := compile(p, agg.Inner)
 			if err != nil {
@@ -900,6 +1049,17 @@ func (q *Aggregate) compileAggregate(aggregates Aggregation) error {
 			}
 
 		default:
+			if IsExactSum(agg) {
+				ops[i].fn = AggregateOpSumD
+				ops[i].role = agg.Role
+				ops[i].goagg = newDecimalSumSpec()
+				var err error
+				mem[i], err = p.aggregateDecimalSum(agg, filter, offset)
+				if err != nil {
+					return fmt.Errorf("don't know how to aggregate %q: %w", agg.Inner, err)
+				}
+				break
+			}
 			argv, err := p.compileAsNumber(agg.Inner)
 			if err != nil {
 				return fmt.Errorf("don't know how to aggregate %q: %w", agg.Inner, err)
@@ -108,3 +108,64 @@ func TestAggregateSSANYCQueries(t *testing.T) {
 		})
 	}
 }
+
+// TestAggregateMergeStateLanes tests that an Aggregate
+// merges the partial states of every lane of a chunk,
+// not only the state in the first lane
+func TestAggregateMergeStateLanes(t *testing.T) {
+	const partials = 8
+	acd := func(role expr.AggregateRole, inner expr.Node) Aggregation {
+		return Aggregation{{
+			Expr: &expr.Aggregate{
+				Op:        expr.OpApproxCountDistinct,
+				Role:      role,
+				Precision: expr.ApproxCountDistinctDefaultPrecision,
+				Inner:     inner,
+			},
+			Result: "acd",
+		}}
+	}
+	run := func(agg Aggregation, tbl *chunkTable) []ion.Struct {
+		var qb QueryBuffer
+		a, err := NewAggregate(agg, &qb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = CopyRows(a, tbl, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = a.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return readRows(t, qb.Bytes())
+	}
+	result := func(rows []ion.Struct) ion.Datum {
+		if len(rows) != 1 {
+			t.Fatalf("got %d rows", len(rows))
+		}
+		f, ok := rows[0].FieldByName("acd")
+		if !ok {
+			t.Fatal("no result")
+		}
+		return f.Datum
+	}
+
+	// compute a partial state for each range of values
+	// and merge all of them from the same chunk
+	var all, states []ion.Datum
+	for i := 0; i < partials; i++ {
+		var values []ion.Datum
+		for j := 0; j < 100; j++ {
+			values = append(values, ion.Int(int64(i*100+j)))
+		}
+		all = append(all, values...)
+		states = append(states, result(run(acd(expr.AggregateRolePartial, expr.Ident("x")), setOpRows(t, values, 0))))
+	}
+	got := result(run(acd(expr.AggregateRoleMerge, expr.Ident("x")), setOpRows(t, states, 0)))
+	want := result(run(acd(expr.AggregateRoleFinal, expr.Ident("x")), setOpRows(t, all, 0)))
+	if !got.Equal(want) {
+		t.Errorf("got %v from the merged states; want %v", got, want)
+	}
+}
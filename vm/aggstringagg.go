@@ -0,0 +1,337 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// stringAggSpec holds the parameters and the states of a STRING_AGG;
+// the state is unbounded (up to the maximum length of the result),
+// so STRING_AGG is a goAggregate
+type stringAggSpec struct {
+	sep   string
+	limit int
+	order []SortOrdering
+
+	// slot is the index of the first vstack slot holding
+	// the values of the ORDER BY columns (the Final and
+	// Partial roles only; the partial results carry them)
+	slot int
+
+	goAggStates[stringAggState]
+}
+
+// stringAggFragment is one input string
+// plus the values of the ORDER BY columns
+type stringAggFragment struct {
+	text string
+	keys [][]byte
+}
+
+type stringAggState struct {
+	frags []stringAggFragment
+	// size is the sum of the lengths of frags
+	// plus one separator for each fragment
+	size int
+
+	final    string
+	finished bool
+}
+
+func newStringAggSpec(agg *expr.Aggregate, slot int) *stringAggSpec {
+	s := &stringAggSpec{
+		sep:   agg.Separator,
+		limit: agg.MaxLength,
+		order: make([]SortOrdering, len(agg.OrderBy)),
+		slot:  slot,
+	}
+	if s.limit <= 0 {
+		s.limit = expr.StringAggDefaultMaxLength
+	}
+	for i := range agg.OrderBy {
+		s.order[i].Direction = SortAscending
+		if agg.OrderBy[i].Desc {
+			s.order[i].Direction = SortDescending
+		}
+		if agg.OrderBy[i].NullsLast {
+			s.order[i].NullsOrder = SortNullsLast
+		}
+	}
+	return s
+}
+
+// threshold is the size of the fragments
+// that is enough to produce the result
+func (s *stringAggSpec) threshold() int {
+	return s.limit + len(s.sep)
+}
+
+// update adds the input of one lane to the state referenced
+// by data; mem is the input string, or the partial result
+// produced by another STRING_AGG for the Merge role
+func (s *stringAggSpec) update(data, mem []byte, role expr.AggregateRole, bc *bytecode, lane int) error {
+	st := s.state(data, true)
+	if role == expr.AggregateRoleMerge {
+		return s.decode(st, mem)
+	}
+	f := stringAggFragment{text: string(mem)}
+	if len(s.order) > 0 {
+		f.keys = make([][]byte, len(s.order))
+		for k := range f.keys {
+			offset, size := bc.getVRegOffsetAndSize((s.slot+k)*int(vRegSize>>3), lane)
+			if size == 0 {
+				f.keys[k] = []byte{0x0f} // MISSING sorts as NULL
+				continue
+			}
+			f.keys[k] = slices.Clone(vmref{offset, size}.mem())
+		}
+	}
+	s.add(st, f)
+	return nil
+}
+
+// add appends f to st, dropping the fragments
+// that cannot be a part of the result
+func (s *stringAggSpec) add(st *stringAggState, f stringAggFragment) {
+	if f.text == "" && s.sep == "" {
+		return // the result is not affected
+	}
+	if len(s.order) == 0 && st.size >= s.threshold() {
+		return // any of the inputs may be the first ones
+	}
+	st.frags = append(st.frags, f)
+	st.size += len(f.text) + len(s.sep)
+	if len(s.order) > 0 && st.size >= 2*s.threshold() {
+		s.trim(st)
+	}
+}
+
+// trim orders the fragments of st and drops the ones
+// following the shortest prefix that produces the result
+func (s *stringAggSpec) trim(st *stringAggState) {
+	slices.SortStableFunc(st.frags, func(a, b stringAggFragment) int {
+		for k := range s.order {
+			if c := s.order[k].Compare(a.keys[k], b.keys[k]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	size := 0
+	for i := range st.frags {
+		size += len(st.frags[i].text) + len(s.sep)
+		if size >= s.threshold() {
+			clear(st.frags[i+1:])
+			st.frags = st.frags[:i+1]
+			break
+		}
+	}
+	st.size = size
+}
+
+// merge adds the state referenced by src
+// to the state referenced by dst
+func (s *stringAggSpec) merge(dst, src []byte) {
+	from := s.state(src, false)
+	if from == nil {
+		return
+	}
+	into := s.state(dst, false)
+	if into == nil {
+		copy(dst[:goAggDataSize], src)
+		return
+	}
+	for i := range from.frags {
+		s.add(into, from.frags[i])
+	}
+}
+
+func (s *stringAggSpec) intern(st *ion.Symtab) {}
+
+// write writes the result of the aggregate referenced by data;
+// a partial result is a blob containing a list of [text, keys...]
+// lists; an empty list means there was only empty input
+func (s *stringAggSpec) write(b *ion.Buffer, data []byte, partial bool) {
+	st := s.state(data, false)
+	if st == nil {
+		b.WriteNull()
+		return
+	}
+	if !partial {
+		b.WriteString(s.result(st))
+		return
+	}
+	if len(s.order) > 0 {
+		s.trim(st)
+	}
+	var list ion.Buffer
+	list.BeginList(-1)
+	for i := range st.frags {
+		list.BeginList(-1)
+		list.WriteString(st.frags[i].text)
+		for _, k := range st.frags[i].keys {
+			list.UnsafeAppend(k)
+		}
+		list.EndList()
+	}
+	list.EndList()
+	b.WriteBlob(list.Bytes())
+}
+
+// decode adds a partial result produced by write to st
+func (s *stringAggSpec) decode(st *stringAggState, mem []byte) error {
+	_, err := ion.UnpackList(mem, func(item []byte) error {
+		f := stringAggFragment{}
+		if len(s.order) > 0 {
+			f.keys = make([][]byte, len(s.order))
+		}
+		n := 0
+		_, err := ion.UnpackList(item, func(v []byte) error {
+			v = v[:ion.SizeOf(v)]
+			if n == 0 {
+				str, _, err := ion.ReadStringShared(v)
+				f.text = string(str)
+				n++
+				return err
+			}
+			if n <= len(f.keys) {
+				f.keys[n-1] = slices.Clone(v)
+			}
+			n++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if n != len(f.keys)+1 {
+			return fmt.Errorf("STRING_AGG: partial result has %d ORDER BY values; expected %d", n-1, len(f.keys))
+		}
+		s.add(st, f)
+		return nil
+	})
+	return err
+}
+
+// result returns the final result of st
+func (s *stringAggSpec) result(st *stringAggState) string {
+	if st.finished {
+		return st.final
+	}
+	if len(s.order) > 0 {
+		s.trim(st)
+	}
+	var sb strings.Builder
+	for i := range st.frags {
+		if i > 0 {
+			// a truncated result never ends with
+			// (a part of) the separator
+			n := sb.Len() + len(s.sep)
+			if n > s.limit || (n == s.limit && st.frags[i].text != "") {
+				break
+			}
+			sb.WriteString(s.sep)
+		}
+		sb.WriteString(st.frags[i].text)
+		if sb.Len() >= s.limit {
+			break
+		}
+	}
+	st.final = truncateUTF8(sb.String(), s.limit)
+	st.finished = true
+	return st.final
+}
+
+// compare orders the final results referenced
+// by a and b; NULL sorts before any string
+func (s *stringAggSpec) compare(a, b []byte) int {
+	left := s.state(a, false)
+	right := s.state(b, false)
+	switch {
+	case left == nil && right == nil:
+		return 0
+	case left == nil:
+		return -1
+	case right == nil:
+		return 1
+	}
+	return strings.Compare(s.result(left), s.result(right))
+}
+
+// truncateUTF8 truncates str to at most n bytes
+// without splitting a multi-byte character
+func truncateUTF8(str string, n int) string {
+	if len(str) <= n {
+		return str
+	}
+	for n > 0 && !utf8.RuneStart(str[n]) {
+		n--
+	}
+	return str[:n]
+}
+
+// stringAggInputs compiles the inputs of STRING_AGG: it returns
+// the input string (or the partial result for the Merge role)
+// and the stores of the ORDER BY columns into the vstack
+func (p *prog) stringAggInputs(agg *expr.Aggregate, spec *stringAggSpec) (*value, []*value, error) {
+	v, err := compile(p, agg.Inner)
+	if err != nil {
+		return nil, nil, err
+	}
+	if agg.Role == expr.AggregateRoleMerge {
+		return p.ssa2(stoblob, v, p.mask(v)), nil, nil
+	}
+	var mem []*value
+	for k := range agg.OrderBy {
+		key, err := p.serialized(agg.OrderBy[k].Column)
+		if err != nil {
+			return nil, nil, err
+		}
+		m, err := p.store(p.initMem(), p.unsymbolized(key), stackSlotFromIndex(regV, spec.slot+k))
+		if err != nil {
+			return nil, nil, err
+		}
+		mem = append(mem, m)
+	}
+	return p.coerceStr(v), mem, nil
+}
+
+func (p *prog) aggregateStringAgg(agg *expr.Aggregate, spec *stringAggSpec, filter *value, slot aggregateslot) (*value, error) {
+	str, mem, err := p.stringAggInputs(agg, spec)
+	if err != nil {
+		return nil, err
+	}
+	mask := p.mask(str)
+	if filter != nil {
+		mask = p.and(mask, filter)
+	}
+	mem = append(mem, p.ssa2imm(saggmergestate, str, mask, slot))
+	return p.mergeMem(mem...), nil
+}
+
+func (p *prog) aggregateSlotStringAgg(agg *expr.Aggregate, spec *stringAggSpec, bucket, mask *value, slot aggregateslot) (*value, error) {
+	str, mem, err := p.stringAggInputs(agg, spec)
+	if err != nil {
+		return nil, err
+	}
+	mem = append(mem, p.ssa3imm(saggslotmergestate, bucket, str, p.and(mask, p.mask(str)), slot))
+	return p.mergeMem(mem...), nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// readRows decodes the output of a QueryBuffer
+func readRows(t *testing.T, out []byte) []ion.Struct {
+	var st ion.Symtab
+	var rows []ion.Struct
+	for len(out) > 0 {
+		if ion.IsBVM(out) || ion.TypeOf(out) == ion.AnnotationType {
+			var err error
+			out, err = st.Unmarshal(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		d, rest, err := ion.ReadDatum(&st, out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = rest
+		if d.IsNull() {
+			continue // padding
+		}
+		s, err := d.Struct()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, s)
+	}
+	return rows
+}
+
+// structString returns the string field name of s
+func structString(s ion.Struct, name string) (string, bool) {
+	f, ok := s.FieldByName(name)
+	if !ok {
+		return "", false
+	}
+	str, err := f.String()
+	return str, err == nil
+}
+
+// TestStringAggParallel tests that STRING_AGG with
+// a maximum length produces the same result when its
+// input is split between several workers and when its
+// partial results are merged
+func TestStringAggParallel(t *testing.T) {
+	buf, err := os.ReadFile("../testdata/nyc-taxi.block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const copies = 4
+	const limit = 500
+
+	// compute the expected result for each vendor
+	payments := make(map[string][]string)
+	count := make(map[string]int)
+	for _, row := range readRows(t, buf) {
+		vendor, ok := structString(row, "VendorID")
+		if !ok {
+			continue
+		}
+		count[vendor] += copies
+		if payment, ok := structString(row, "payment_type"); ok {
+			for i := 0; i < copies; i++ {
+				payments[vendor] = append(payments[vendor], payment)
+			}
+		}
+	}
+	join := func(lst []string) string {
+		slices.Sort(lst)
+		slices.Reverse(lst)
+		// the payment types are never empty, so a
+		// truncated result never ends with a comma
+		return strings.TrimSuffix(truncateUTF8(strings.Join(lst, ","), limit), ",")
+	}
+	var all []string
+	want := make(map[string]string)
+	for vendor, lst := range payments {
+		all = append(all, lst...)
+		want[vendor] = join(lst)
+	}
+	wantAll := join(all)
+	if n := len(strings.Join(all, ",")); n <= limit {
+		t.Fatalf("the expected result has %d bytes; not truncated?", n)
+	}
+
+	stringAgg := func(role expr.AggregateRole, inner expr.Node) *expr.Aggregate {
+		return &expr.Aggregate{
+			Op:        expr.OpStringAgg,
+			Role:      role,
+			Inner:     inner,
+			Separator: ",",
+			MaxLength: limit,
+			OrderBy: []expr.Order{{
+				Column: path(t, "payment_type"),
+				Desc:   true,
+			}},
+		}
+	}
+
+	t.Run("group-by", func(t *testing.T) {
+		var qb QueryBuffer
+		agg := Aggregation{
+			{Expr: &expr.Aggregate{Op: expr.OpCount, Inner: expr.Star{}}, Result: "count"},
+			{Expr: stringAgg(expr.AggregateRoleFinal, path(t, "payment_type")), Result: "payments"},
+		}
+		ha, err := NewHashAggregate(agg, nil, Selection{{Expr: path(t, "VendorID")}}, &qb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		intable := &looptable{chunk: buf, count: copies}
+		err = intable.WriteChunks(ha, copies)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ha.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows := readRows(t, qb.Bytes())
+		if len(rows) != len(count) {
+			t.Fatalf("got %d rows; want %d", len(rows), len(count))
+		}
+		for _, row := range rows {
+			vendor, _ := structString(row, "VendorID")
+			got, _ := structString(row, "payments")
+			if got != want[vendor] {
+				t.Errorf("%s: got %q", vendor, got)
+				t.Errorf("%s: want %q", vendor, want[vendor])
+			}
+			f, _ := row.FieldByName("count")
+			if n, _ := f.Int(); n != int64(count[vendor]) {
+				t.Errorf("%s: got count %d; want %d", vendor, n, count[vendor])
+			}
+		}
+	})
+	t.Run("split", func(t *testing.T) {
+		// compute a partial result for each copy...
+		var partials QueryBuffer
+		for i := 0; i < copies; i++ {
+			partial, err := NewAggregate(Aggregation{
+				{Expr: stringAgg(expr.AggregateRolePartial, path(t, "payment_type")), Result: "partial"},
+			}, &partials)
+			if err != nil {
+				t.Fatal(err)
+			}
+			intable := &looptable{chunk: buf, count: 1}
+			err = intable.WriteChunks(partial, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = partial.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		// ... and merge them in parallel
+		var qb QueryBuffer
+		final := stringAgg(expr.AggregateRoleMerge, path(t, "partial"))
+		final.OrderBy[0].Column = expr.Missing{}
+		merge, err := NewAggregate(Aggregation{{Expr: final, Result: "payments"}}, &qb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = CopyRows(merge, partials.Table(), 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = merge.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows := readRows(t, qb.Bytes())
+		if len(rows) != 1 {
+			t.Fatalf("got %d rows", len(rows))
+		}
+		got, _ := structString(rows[0], "payments")
+		if got != wantAll {
+			t.Errorf("got  %q", got)
+			t.Errorf("want %q", wantAll)
+		}
+	})
+}
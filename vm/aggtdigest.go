@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"slices"
 
 	"github.com/SnellerInc/sneller/internal/percentile"
 )
@@ -169,6 +170,102 @@ func tDigestMerge(dst, src tDigestDS) error {
 	return nil
 }
 
+// tDigestWeightLimit computes the weight limit of the centroid
+// preceded by centroids with the given sum of weights; it uses
+// the same float32 operations as the assembly implementation
+func tDigestWeightLimit(totalWeight, weightSum float32) float32 {
+	const (
+		compression  = 16
+		halfPi       = float32(math.Pi / 2)
+		sixteenDivPi = float32(compression / math.Pi)
+		piDivSixteen = float32(math.Pi / compression)
+	)
+	x := (weightSum/totalWeight)*2 - 1
+	x = float32(math.Asin(float64(x)))
+	x = min((x+halfPi)*sixteenDivPi+1, compression)
+	x = float32(math.Sin(float64(x*piDivSixteen - halfPi)))
+	return ((x + 1) * 0.5) * totalWeight
+}
+
+// tDigestAdd adds values (with a weight of 1 each) to data
+// in the same way as the assembly implementation of opAggTDigest:
+// the new centroids are merged with the existing ones and the
+// result is compressed into at most 32 centroids
+func tDigestAdd(data tDigestDS, values []float32) {
+	slices.Sort(values)
+	n := len(values)
+	lenIn := data.getLen()
+	inf := float32(math.Inf(1))
+	if lenIn == 0 {
+		data.putWeightSum(float32(n))
+		data.putMeanMax(values[n-1])
+		data.putMeanMin(values[0])
+		data.putLen(n)
+		for i := 0; i < 32; i++ {
+			w, m := float32(0), inf
+			if i < 16 {
+				w = 1
+			}
+			if i < n {
+				m = values[i]
+			}
+			data.putWeight(w, i)
+			data.putMean(m, i)
+		}
+		return
+	}
+
+	totalWeight := data.getWeightSum() + float32(n)
+	data.putWeightSum(totalWeight)
+	data.putMeanMax(max(data.getMeanMax(), values[n-1]))
+	data.putMeanMin(min(data.getMeanMin(), values[0]))
+
+	// merge the sorted centroids with the sorted values
+	var wIn, mIn [3 * 16]float32
+	inLen := 0
+	for i, j := 0, 0; i < lenIn || j < n; inLen++ {
+		if i < lenIn && (j == n || data.getMean(i) <= values[j]) {
+			wIn[inLen], mIn[inLen] = data.getWeight(i), data.getMean(i)
+			i++
+		} else {
+			wIn[inLen], mIn[inLen] = 1, values[j]
+			j++
+		}
+	}
+
+	// compress the centroids
+	var wOut, mOut [3 * 16]float32
+	weightSum := wIn[0]
+	weightLimit := tDigestWeightLimit(totalWeight, 0)
+	wOut[0], mOut[0] = wIn[0], mIn[0]
+	outLen := 1
+	for i := 1; i < inLen; i++ {
+		wi, mi := wIn[i], mIn[i]
+		prefix := weightSum
+		weightSum += wi
+		if weightSum <= weightLimit {
+			// merge the centroid with the last one
+			y := wOut[outLen-1] + wi
+			mOut[outLen-1] += ((mi - mOut[outLen-1]) * wi) / y
+			wOut[outLen-1] = y
+		} else {
+			weightLimit = tDigestWeightLimit(totalWeight, prefix)
+			wOut[outLen], mOut[outLen] = wi, mi
+			outLen++
+		}
+	}
+
+	data.putLen(outLen)
+	for i := 0; i < 32; i++ {
+		w, m := float32(0), inf
+		if i < outLen {
+			w, m = wOut[i], mOut[i]
+		}
+		data.putWeight(w, i)
+		data.putMean(m, i)
+	}
+}
+
 // calcPercentiles calculates approximate percentiles using the tDigest data
 func calcPercentiles(data tDigestDS, p []float32) ([]float32, error) {
 	t, err := createTDigest(data)
@@ -0,0 +1,400 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// topkSpec holds the parameters and the states of a TOPK
+// or a MODE; the state is a topkSketch keyed by the
+// canonical encoding of the values (see topkKey),
+// so TOPK is a goAggregate
+//
+// MODE is TOPK(expr, 1) that produces the value itself
+type topkSpec struct {
+	k, size int
+	mode    bool
+
+	// symbols of the fields of the result
+	value, count ion.Symbol
+	// out is the symbol table of the result
+	out *ion.Symtab
+
+	// keys is the symbol table of the
+	// field names in the keys of the sketches
+	keylock sync.Mutex
+	keys    ion.Symtab
+
+	goAggStates[topkState]
+}
+
+type topkState struct {
+	sketch *topkSketch
+
+	final    []topkItem
+	finished bool
+}
+
+func newTopKSpec(agg *expr.Aggregate) *topkSpec {
+	s := &topkSpec{
+		k:    agg.K,
+		size: agg.TopKSketchSize(),
+		mode: agg.Op == expr.OpMode,
+	}
+	if s.mode {
+		s.k = 1
+	}
+	s.init = func() *topkState {
+		return &topkState{sketch: newTopkSketch(s.size)}
+	}
+	return s
+}
+
+func (s *topkSpec) name() string {
+	if s.mode {
+		return "MODE"
+	}
+	return "TOPK"
+}
+
+func (s *topkSpec) update(data, mem []byte, role expr.AggregateRole, bc *bytecode, lane int) error {
+	var lanes [bcLaneCount][]byte
+	lanes[lane] = mem
+	return s.updateLanes(data, &lanes, 1<<lane, role, nil)
+}
+
+// updateLanes adds the values of the lanes in mask to the
+// state referenced by data, or merges the partial results
+// produced by another TOPK for the Merge role; the symbols
+// in the values are resolved with st, and values
+// containing symbols cannot be read if st is nil
+func (s *topkSpec) updateLanes(data []byte, mem *[bcLaneCount][]byte, mask uint16, role expr.AggregateRole, st *ion.Symtab) error {
+	state := s.state(data, true)
+	if role == expr.AggregateRoleMerge {
+		for i := range mem {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			if err := s.decode(state, mem[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if st == nil {
+		st = &ion.Symtab{}
+	}
+	var key ion.Buffer
+	for i := range mem {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		d, _, err := ion.ReadDatum(st, mem[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", s.name(), err)
+		}
+		key.Reset()
+		s.keylock.Lock()
+		topkKey(&key, &s.keys, d)
+		s.keylock.Unlock()
+		state.sketch.Add(string(key.Bytes()), 1)
+	}
+	return nil
+}
+
+// topkKey writes the canonical encoding of d into dst
+// with the field names interned in st, so equal values
+// read with different symbol tables have the same encoding:
+// integral numbers are written as integers (so 1 and 1.0
+// are the same value), and symbols are written as strings
+func topkKey(dst *ion.Buffer, st *ion.Symtab, d ion.Datum) {
+	switch d.Type() {
+	case ion.FloatType:
+		f, _ := d.Float()
+		if i := int64(f); float64(i) == f {
+			dst.WriteInt(i)
+		} else if u := uint64(f); f >= 0 && float64(u) == f {
+			dst.WriteUint(u)
+		} else {
+			dst.WriteFloat64(f)
+		}
+	case ion.IntType:
+		i, _ := d.Int()
+		dst.WriteInt(i)
+	case ion.UintType:
+		u, _ := d.Uint()
+		dst.WriteUint(u)
+	case ion.SymbolType:
+		str, _ := d.String()
+		dst.WriteString(str)
+	case ion.StructType:
+		// fields are ordered by symbol,
+		// so the order of the fields of d
+		// does not matter either
+		fields, _ := d.Struct()
+		dst.BeginStruct(-1)
+		fields.Each(func(f ion.Field) error {
+			dst.BeginField(st.Intern(f.Label))
+			topkKey(dst, st, f.Datum)
+			return nil
+		})
+		dst.EndStruct()
+	case ion.ListType:
+		items, _ := d.List()
+		dst.BeginList(-1)
+		items.Each(func(d ion.Datum) error {
+			topkKey(dst, st, d)
+			return nil
+		})
+		dst.EndList()
+	default:
+		d.Encode(dst, st)
+	}
+}
+
+func (s *topkSpec) merge(dst, src []byte) {
+	from := s.state(src, false)
+	if from == nil {
+		return
+	}
+	into := s.state(dst, false)
+	if into == nil {
+		copy(dst[:goAggDataSize], src)
+		return
+	}
+	into.sketch.Merge(from.sketch)
+}
+
+func (s *topkSpec) intern(st *ion.Symtab) {
+	s.value = st.Intern("value")
+	s.count = st.Intern("count")
+	s.keylock.Lock()
+	for i := 0; i < s.keys.MaxID(); i++ {
+		st.Intern(s.keys.Get(ion.Symbol(i)))
+	}
+	s.keylock.Unlock()
+	s.out = st
+}
+
+// writeValue writes the value encoded in key
+// with the symbols of the result
+func (s *topkSpec) writeValue(b *ion.Buffer, key string) {
+	s.keylock.Lock()
+	defer s.keylock.Unlock()
+	d, _, err := ion.ReadDatum(&s.keys, []byte(key))
+	if err != nil {
+		// keys are only produced by topkKey
+		panic(fmt.Sprintf("%s: invalid key: %s", s.name(), err))
+	}
+	topkKey(b, s.out, d)
+}
+
+// write writes the result of the aggregate referenced by data:
+// a list of {value, count} structures ordered by descending count
+// (or the most frequent value for MODE); a partial result is a blob
+// containing the symbol table of the values and a list of
+// [value, count, error] lists describing the whole sketch
+func (s *topkSpec) write(b *ion.Buffer, data []byte, partial bool) {
+	st := s.state(data, false)
+	if st == nil || st.sketch.Len() == 0 {
+		b.WriteNull()
+		return
+	}
+	if partial {
+		var list ion.Buffer
+		s.keylock.Lock()
+		s.keys.Marshal(&list, true)
+		s.keylock.Unlock()
+		list.BeginList(-1)
+		for _, it := range st.sketch.Items() {
+			list.BeginList(-1)
+			list.UnsafeAppend([]byte(it.Value))
+			list.WriteUint(it.Count)
+			list.WriteUint(it.Err)
+			list.EndList()
+		}
+		list.EndList()
+		b.WriteBlob(list.Bytes())
+		return
+	}
+	if s.mode {
+		s.writeValue(b, s.result(st)[0].Value)
+		return
+	}
+	b.BeginList(-1)
+	for _, it := range s.result(st) {
+		b.BeginStruct(-1)
+		if s.value < s.count {
+			b.BeginField(s.value)
+			s.writeValue(b, it.Value)
+			b.BeginField(s.count)
+			b.WriteUint(it.Count)
+		} else {
+			b.BeginField(s.count)
+			b.WriteUint(it.Count)
+			b.BeginField(s.value)
+			s.writeValue(b, it.Value)
+		}
+		b.EndStruct()
+	}
+	b.EndList()
+}
+
+// decode adds a partial result produced by write to st;
+// the values are encoded again with the symbols of s.keys
+func (s *topkSpec) decode(st *topkState, mem []byte) error {
+	var syms ion.Symtab
+	body, err := syms.Unmarshal(mem)
+	if err != nil {
+		return fmt.Errorf("%s: partial result: %w", s.name(), err)
+	}
+	var items []topkItem
+	var key ion.Buffer
+	_, err = ion.UnpackList(body, func(item []byte) error {
+		var it topkItem
+		n := 0
+		_, err := ion.UnpackList(item, func(v []byte) error {
+			var err error
+			switch n {
+			case 0:
+				var d ion.Datum
+				d, _, err = ion.ReadDatum(&syms, v)
+				if err != nil {
+					return err
+				}
+				key.Reset()
+				s.keylock.Lock()
+				topkKey(&key, &s.keys, d)
+				s.keylock.Unlock()
+				it.Value = string(key.Bytes())
+			case 1:
+				it.Count, _, err = ion.ReadUint(v)
+			case 2:
+				it.Err, _, err = ion.ReadUint(v)
+			}
+			n++
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if n != 3 {
+			return fmt.Errorf("%s: partial result item has %d fields; expected 3", s.name(), n)
+		}
+		items = append(items, it)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	other := newTopkSketch(s.size)
+	other.Load(items)
+	st.sketch.Merge(other)
+	return nil
+}
+
+// result returns the final result of st
+func (s *topkSpec) result(st *topkState) []topkItem {
+	if !st.finished {
+		if s.mode {
+			st.final = []topkItem{mode(st.sketch)}
+		} else {
+			st.final = st.sketch.Top(s.k)
+		}
+		st.finished = true
+	}
+	return st.final
+}
+
+// mode returns the most frequent value of a non-empty sketch;
+// values with the same count are ordered like ORDER BY,
+// so the smallest one is picked
+func mode(sketch *topkSketch) topkItem {
+	order := SortOrdering{Direction: SortAscending}
+	items := sketch.Items()
+	best := items[0]
+	for _, it := range items[1:] {
+		if it.Count > best.Count ||
+			(it.Count == best.Count && order.Compare([]byte(it.Value), []byte(best.Value)) < 0) {
+			best = it
+		}
+	}
+	return best
+}
+
+// compare orders the final results referenced by a and b
+// by their values and counts (only by the values for MODE);
+// NULL sorts before any result
+func (s *topkSpec) compare(a, b []byte) int {
+	left := s.state(a, false)
+	right := s.state(b, false)
+	switch {
+	case left == nil && right == nil:
+		return 0
+	case left == nil:
+		return -1
+	case right == nil:
+		return 1
+	}
+	lr, rr := s.result(left), s.result(right)
+	order := SortOrdering{Direction: SortAscending}
+	if s.mode {
+		return order.Compare([]byte(lr[0].Value), []byte(rr[0].Value))
+	}
+	for i := 0; i < len(lr) && i < len(rr); i++ {
+		if c := order.Compare([]byte(lr[i].Value), []byte(rr[i].Value)); c != 0 {
+			return c
+		}
+		if lr[i].Count != rr[i].Count {
+			if lr[i].Count < rr[i].Count {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(lr) - len(rr)
+}
+
+func (p *prog) aggregateTopK(agg *expr.Aggregate, filter *value, slot aggregateslot) (*value, error) {
+	v, err := p.goAggInput(agg)
+	if err != nil {
+		return nil, err
+	}
+	mask := p.mask(v)
+	if filter != nil {
+		mask = p.and(mask, filter)
+	}
+	if agg.Role == expr.AggregateRoleMerge {
+		return p.ssa2imm(saggmergestate, v, mask, slot), nil
+	}
+	return p.ssa2imm(saggmergevalue, v, mask, slot), nil
+}
+
+func (p *prog) aggregateSlotTopK(agg *expr.Aggregate, bucket, mask *value, slot aggregateslot) (*value, error) {
+	v, err := p.goAggInput(agg)
+	if err != nil {
+		return nil, err
+	}
+	mask = p.and(mask, p.mask(v))
+	if agg.Role == expr.AggregateRoleMerge {
+		return p.ssa3imm(saggslotmergestate, bucket, v, mask, slot), nil
+	}
+	return p.ssa3imm(saggslotmergevalue, bucket, v, mask, slot), nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// TestTopKPartialSymbols tests that TOPK counts equal values
+// read with different symbol tables (and equal numbers of
+// different types) as the same value, including when they
+// are merged from the partial result of another TOPK
+func TestTopKPartialSymbols(t *testing.T) {
+	encode := func(st *ion.Symtab, text string) []byte {
+		d, err := ion.FromJSON(st, json.NewDecoder(strings.NewReader(text)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf ion.Buffer
+		d.Encode(&buf, st)
+		return buf.Bytes()
+	}
+	agg := &expr.Aggregate{Op: expr.OpTopK, K: 3, Inner: expr.Ident("x")}
+
+	// the partial result is computed with
+	// "z" before "y" in the symbol table
+	var st0 ion.Symtab
+	st0.Intern("z")
+	partial := newTopKSpec(agg)
+	data := make([]byte, goAggDataSize)
+	lanes := [bcLaneCount][]byte{
+		encode(&st0, `{"y": 1, "z": "a"}`),
+		encode(&st0, `1.0`),
+		encode(&st0, `[{"z": 2.5}]`),
+	}
+	err := partial.updateLanes(data, &lanes, 0x7, expr.AggregateRolePartial, &st0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blob ion.Buffer
+	partial.write(&blob, data, true)
+	mem, _, err := ion.ReadBytesShared(blob.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ... and merged with values read
+	// with "y" before "z"
+	var st1 ion.Symtab
+	st1.Intern("y")
+	final := newTopKSpec(agg)
+	result := make([]byte, goAggDataSize)
+	lanes = [bcLaneCount][]byte{
+		encode(&st1, `{"z": "a", "y": 1.0}`),
+		encode(&st1, `{"y": 1, "z": "a"}`),
+		encode(&st1, `1`),
+	}
+	err = final.updateLanes(result, &lanes, 0x7, expr.AggregateRoleFinal, &st1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lanes = [bcLaneCount][]byte{mem}
+	err = final.updateLanes(result, &lanes, 0x1, expr.AggregateRoleMerge, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out ion.Symtab
+	var buf ion.Buffer
+	final.intern(&out)
+	final.write(&buf, result, false)
+	d, _, err := ion.ReadDatum(&out, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"value": {"y": 1, "z": "a"}, "count": 3}, {"value": 1, "count": 2}, {"value": [{"z": 2.5}], "count": 1}]`
+	var wantst ion.Symtab
+	wantd, err := ion.FromJSON(&wantst, json.NewDecoder(strings.NewReader(want)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Equal(wantd) {
+		t.Errorf("got %s, want %s", d.JSON(), want)
+	}
+}
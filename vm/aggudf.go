@@ -0,0 +1,277 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"fmt"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// udafSpec holds the states of a user-defined aggregate
+// function (see expr.RegisterAggregate); the states are
+// opaque to the vm, so it is a goAggregate that passes
+// the inputs of all the lanes of a group at once
+type udafSpec struct {
+	name string
+	fn   *expr.AggregateFunc
+
+	// err is the first error encountered
+	// by merge or write (see failed)
+	err error
+	// empty is the result of an aggregate
+	// without any input
+	empty []byte
+
+	goAggStates[udafState]
+}
+
+type udafState struct {
+	state expr.AggregateState
+
+	// final is the encoded result of the state
+	final    []byte
+	finished bool
+}
+
+func newUDAFSpec(agg *expr.Aggregate) (*udafSpec, error) {
+	fn, ok := expr.LookupAggregate(agg.Name)
+	if !ok {
+		return nil, fmt.Errorf("unknown aggregate function %q", agg.Name)
+	}
+	s := &udafSpec{name: agg.Name, fn: fn}
+	s.init = func() *udafState {
+		return &udafState{state: fn.Init()}
+	}
+	return s, nil
+}
+
+func (p *prog) aggregateUDF(agg *expr.Aggregate, filter *value, slot aggregateslot) (*value, error) {
+	v, err := p.goAggInput(agg)
+	if err != nil {
+		return nil, err
+	}
+	mask := p.mask(v)
+	if filter != nil {
+		mask = p.and(mask, filter)
+	}
+	if agg.Role == expr.AggregateRoleMerge {
+		return p.ssa2imm(saggmergestate, v, mask, slot), nil
+	}
+	return p.ssa2imm(saggmergevalue, v, mask, slot), nil
+}
+
+func (p *prog) aggregateSlotUDF(agg *expr.Aggregate, bucket, mask *value, slot aggregateslot) (*value, error) {
+	v, err := p.goAggInput(agg)
+	if err != nil {
+		return nil, err
+	}
+	mask = p.and(mask, p.mask(v))
+	if agg.Role == expr.AggregateRoleMerge {
+		return p.ssa3imm(saggslotmergestate, bucket, v, mask, slot), nil
+	}
+	return p.ssa3imm(saggslotmergevalue, bucket, v, mask, slot), nil
+}
+
+// recover turns a panic of the implementation
+// of the aggregate into an error
+func (s *udafSpec) recover(err *error) {
+	if e := recover(); e != nil {
+		*err = fmt.Errorf("%s: panic: %v", s.name, e)
+	}
+}
+
+func (s *udafSpec) errorf(err error) error {
+	return fmt.Errorf("%s: %w", s.name, err)
+}
+
+// fail records the first error
+// encountered by merge or write
+func (s *udafSpec) fail(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *udafSpec) failed() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.err
+}
+
+func (s *udafSpec) update(data, mem []byte, role expr.AggregateRole, bc *bytecode, lane int) error {
+	var lanes [bcLaneCount][]byte
+	lanes[lane] = mem
+	return s.updateLanes(data, &lanes, 1<<lane, role, nil)
+}
+
+// updateLanes passes the values of the lanes in mask
+// to Accumulate, or merges the partial results produced
+// by another aggregate for the Merge role; the symbols
+// in the values are resolved with st, and values
+// containing symbols cannot be read if st is nil
+func (s *udafSpec) updateLanes(data []byte, mem *[bcLaneCount][]byte, mask uint16, role expr.AggregateRole, st *ion.Symtab) (err error) {
+	defer s.recover(&err)
+	state := s.state(data, true)
+	if role == expr.AggregateRoleMerge {
+		for i := range mem {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			other := s.fn.Init()
+			if err := other.UnmarshalBinary(mem[i]); err != nil {
+				return s.errorf(err)
+			}
+			if err := s.fn.Merge(state.state, other); err != nil {
+				return s.errorf(err)
+			}
+		}
+		return nil
+	}
+	if st == nil {
+		st = &ion.Symtab{}
+	}
+	var values [bcLaneCount]ion.Datum
+	for i := range mem {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		values[i], _, err = ion.ReadDatum(st, mem[i])
+		if err != nil {
+			return s.errorf(err)
+		}
+	}
+	if mask == 0 {
+		return nil
+	}
+	if err := s.fn.Accumulate(state.state, values[:], mask); err != nil {
+		return s.errorf(err)
+	}
+	return nil
+}
+
+func (s *udafSpec) merge(dst, src []byte) {
+	from := s.state(src, false)
+	if from == nil {
+		return
+	}
+	into := s.state(dst, false)
+	if into == nil {
+		copy(dst[:goAggDataSize], src)
+		return
+	}
+	var err error
+	func() {
+		defer s.recover(&err)
+		err = s.fn.Merge(into.state, from.state)
+	}()
+	if err != nil {
+		s.fail(s.errorf(err))
+	}
+}
+
+func (s *udafSpec) intern(st *ion.Symtab) {}
+
+// write writes the result of the aggregate referenced
+// by data; a partial result is a blob containing the
+// marshaled state, or NULL if there was no input
+func (s *udafSpec) write(b *ion.Buffer, data []byte, partial bool) {
+	st := s.state(data, false)
+	if !partial {
+		b.UnsafeAppend(s.result(st))
+		return
+	}
+	if st == nil {
+		b.WriteNull()
+		return
+	}
+	var buf []byte
+	var err error
+	func() {
+		defer s.recover(&err)
+		buf, err = st.state.MarshalBinary()
+	}()
+	if err != nil {
+		s.fail(s.errorf(err))
+		b.WriteNull()
+		return
+	}
+	b.WriteBlob(buf)
+}
+
+// result returns the encoded result of st,
+// which is nil if the aggregate had no input
+func (s *udafSpec) result(st *udafState) []byte {
+	if st == nil {
+		if s.empty == nil {
+			s.empty = s.finalize(s.fn.Init)
+		}
+		return s.empty
+	}
+	if !st.finished {
+		st.final = s.finalize(func() expr.AggregateState { return st.state })
+		st.finished = true
+	}
+	return st.final
+}
+
+// finalize calls Finalize for the state returned by
+// state and encodes the result; a failure is recorded
+// and produces NULL
+func (s *udafSpec) finalize(state func() expr.AggregateState) []byte {
+	var d ion.Datum
+	var err error
+	func() {
+		defer s.recover(&err)
+		d, err = s.fn.Finalize(state())
+	}()
+	if err != nil {
+		s.fail(s.errorf(err))
+		d = ion.Null
+	}
+	switch d.Type() {
+	case ion.InvalidType:
+		d = ion.Null // MISSING is not supported
+	case ion.SymbolType:
+		str, _ := d.String()
+		d = ion.String(str)
+	case ion.StructType, ion.ListType, ion.SexpType:
+		// these could reference symbols that
+		// are not in the symbol table of the output
+		s.fail(fmt.Errorf("%s: unsupported result type %s", s.name, d.Type()))
+		d = ion.Null
+	}
+	var b ion.Buffer
+	var st ion.Symtab
+	d.Encode(&b, &st)
+	return b.Bytes()
+}
+
+// compare orders the final results referenced by a and b
+func (s *udafSpec) compare(a, b []byte) int {
+	var order SortOrdering
+	return order.Compare(s.result(s.state(a, false)), s.result(s.state(b, false)))
+}
+
+func (s *udafSpec) reset() {
+	s.goAggStates.reset()
+	s.lock.Lock()
+	s.err = nil
+	s.empty = nil
+	s.lock.Unlock()
+}
@@ -0,0 +1,255 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/testquery"
+)
+
+// productState is the state of UDAF_PRODUCT,
+// the product of the integer inputs
+type productState struct {
+	product, count int64
+}
+
+func (p *productState) MarshalBinary() ([]byte, error) {
+	buf := binary.LittleEndian.AppendUint64(nil, uint64(p.product))
+	return binary.LittleEndian.AppendUint64(buf, uint64(p.count)), nil
+}
+
+func (p *productState) UnmarshalBinary(buf []byte) error {
+	if len(buf) != 16 {
+		return fmt.Errorf("bad state size %d", len(buf))
+	}
+	p.product = int64(binary.LittleEndian.Uint64(buf))
+	p.count = int64(binary.LittleEndian.Uint64(buf[8:]))
+	return nil
+}
+
+func init() {
+	expr.RegisterAggregate("udaf_product",
+		func() expr.AggregateState {
+			return &productState{product: 1}
+		},
+		func(state expr.AggregateState, values []ion.Datum, mask uint16) error {
+			p := state.(*productState)
+			for i := range values {
+				if mask&(1<<i) == 0 {
+					if !values[i].IsEmpty() {
+						return fmt.Errorf("lane %d is inactive but has a value", i)
+					}
+					continue
+				}
+				n, err := values[i].Int()
+				if err != nil {
+					continue
+				}
+				switch n {
+				case 13:
+					return errors.New("thirteen is not allowed")
+				case 17:
+					panic("seventeen")
+				}
+				p.product *= n
+				p.count++
+			}
+			return nil
+		},
+		func(dst, src expr.AggregateState) error {
+			d, s := dst.(*productState), src.(*productState)
+			d.product *= s.product
+			d.count += s.count
+			return nil
+		},
+		func(state expr.AggregateState) (ion.Datum, error) {
+			p := state.(*productState)
+			if p.count == 0 {
+				return ion.Null, nil
+			}
+			return ion.Int(p.product), nil
+		})
+}
+
+// fieldSumState is the state of UDAF_FIELD_SUM,
+// the sum of the integer fields "a" of the structure
+// inputs and of the integer items of the list inputs
+type fieldSumState struct {
+	sum, count int64
+}
+
+func (f *fieldSumState) MarshalBinary() ([]byte, error) {
+	buf := binary.LittleEndian.AppendUint64(nil, uint64(f.sum))
+	return binary.LittleEndian.AppendUint64(buf, uint64(f.count)), nil
+}
+
+func (f *fieldSumState) UnmarshalBinary(buf []byte) error {
+	if len(buf) != 16 {
+		return fmt.Errorf("bad state size %d", len(buf))
+	}
+	f.sum = int64(binary.LittleEndian.Uint64(buf))
+	f.count = int64(binary.LittleEndian.Uint64(buf[8:]))
+	return nil
+}
+
+func init() {
+	expr.RegisterAggregate("udaf_field_sum",
+		func() expr.AggregateState {
+			return &fieldSumState{}
+		},
+		func(state expr.AggregateState, values []ion.Datum, mask uint16) error {
+			f := state.(*fieldSumState)
+			for i := range values {
+				if mask&(1<<i) == 0 {
+					continue
+				}
+				f.count++
+				if s, err := values[i].Struct(); err == nil {
+					if field, ok := s.FieldByName("a"); ok {
+						n, _ := field.Int()
+						f.sum += n
+					}
+					continue
+				}
+				lst, err := values[i].List()
+				if err != nil {
+					return fmt.Errorf("unexpected %s", values[i].Type())
+				}
+				err = lst.Each(func(d ion.Datum) error {
+					n, _ := d.Int()
+					f.sum += n
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(dst, src expr.AggregateState) error {
+			d, s := dst.(*fieldSumState), src.(*fieldSumState)
+			d.sum += s.sum
+			d.count += s.count
+			return nil
+		},
+		func(state expr.AggregateState) (ion.Datum, error) {
+			f := state.(*fieldSumState)
+			if f.count == 0 {
+				return ion.Empty, nil // produces NULL
+			}
+			return ion.Int(f.sum), nil
+		})
+}
+
+func TestAggregateUDF(t *testing.T) {
+	run := func(t *testing.T, text string, flags testquery.RunFlags) error {
+		tc, err := testquery.ReadCase(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tc.Execute(flags)
+	}
+	var input strings.Builder
+	for i := 1; i <= 40; i++ {
+		// the product of the odd numbers in
+		// each group of ten is 9!! = 945
+		if i%2 == 1 {
+			fmt.Fprintf(&input, "{\"g\": %d, \"n\": %d}\n", (i-1)/10, (i-1)%10+1)
+		} else {
+			fmt.Fprintf(&input, "{\"g\": %d, \"n\": \"x\"}\n", (i-1)/10)
+		}
+	}
+	cases := []struct {
+		query, output string
+	}{
+		{
+			query:  `SELECT udaf_product(n) AS p, UDAF_PRODUCT(n) FILTER (WHERE n < 5) AS f, udaf_product(m) AS e FROM input`,
+			output: `{"p": 797493650625, "f": 81, "e": null}`,
+		},
+		{
+			query: `SELECT g, udaf_product(n) AS p FROM input GROUP BY g ORDER BY udaf_product(n) DESC, g LIMIT 10`,
+			output: `{"g": 0, "p": 945}
+{"g": 1, "p": 945}
+{"g": 2, "p": 945}
+{"g": 3, "p": 945}`,
+		},
+	}
+	for _, c := range cases {
+		text := c.query + "\n---\n" + input.String() + "---\n" + c.output + "\n"
+		for _, flags := range []testquery.RunFlags{0, testquery.FlagParallel, testquery.FlagSplit} {
+			if err := run(t, text, flags); err != nil {
+				t.Errorf("%s (flags %d): %s", c.query, flags, err)
+			}
+		}
+	}
+
+	// structures and lists are passed to Accumulate
+	// with the symbols of their fields resolved
+	input.Reset()
+	for i := 0; i < 4; i++ {
+		fmt.Fprintf(&input, `{"g": %d, "x": {"b": 100, "a": %d}}`+"\n", i%2, i)
+		fmt.Fprintf(&input, `{"g": %d, "x": [%d, 10]}`+"\n", i%2, i)
+		fmt.Fprintf(&input, `{"g": %d, "x": {"c": 1000}}`+"\n", i%2)
+	}
+	cases = []struct {
+		query, output string
+	}{
+		{
+			query:  `SELECT udaf_field_sum(x) AS s, udaf_field_sum(y) AS e FROM input`,
+			output: `{"s": 52, "e": null}`,
+		},
+		{
+			query: `SELECT g, udaf_field_sum(x) AS s FROM input GROUP BY g ORDER BY g`,
+			output: `{"g": 0, "s": 24}
+{"g": 1, "s": 28}`,
+		},
+	}
+	for _, c := range cases {
+		text := c.query + "\n---\n" + input.String() + "---\n" + c.output + "\n"
+		for _, flags := range []testquery.RunFlags{0, testquery.FlagParallel, testquery.FlagSplit} {
+			if err := run(t, text, flags); err != nil {
+				t.Errorf("%s (flags %d): %s", c.query, flags, err)
+			}
+		}
+	}
+
+	errors := []struct {
+		n    int
+		want string
+	}{
+		{13, "UDAF_PRODUCT: thirteen is not allowed"},
+		{17, "UDAF_PRODUCT: panic: seventeen"},
+	}
+	for _, e := range errors {
+		err := run(t, fmt.Sprintf(`
+SELECT udaf_product(n) AS p FROM input
+---
+{"n": 1}
+{"n": %d}
+---
+{"p": 1}
+`, e.n), testquery.FlagParallel)
+		if err == nil || !strings.Contains(err.Error(), e.want) {
+			t.Errorf("n=%d: got error %v, want %q", e.n, err, e.want)
+		}
+	}
+}
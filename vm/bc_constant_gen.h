@@ -1,443 +1,469 @@
 // Code generated automatically; DO NOT EDIT
 
 // uint64 constants
-#define CONSTD_1() CONST_GET_PTR(constpool, 0)
-#define CONSTQ_1() CONST_GET_PTR(constpool, 0)
-CONST_DATA_U64(constpool, 0, $1) // 0x0000000000000001
+#define CONSTD_0() CONST_GET_PTR(constpool, 0)
+#define CONSTQ_0() CONST_GET_PTR(constpool, 0)
+CONST_DATA_U64(constpool, 0, $0) // 0x0000000000000000
 
-#define CONSTD_2() CONST_GET_PTR(constpool, 8)
-#define CONSTQ_2() CONST_GET_PTR(constpool, 8)
-CONST_DATA_U64(constpool, 8, $2) // 0x0000000000000002
+#define CONSTD_1() CONST_GET_PTR(constpool, 8)
+#define CONSTQ_1() CONST_GET_PTR(constpool, 8)
+CONST_DATA_U64(constpool, 8, $1) // 0x0000000000000001
 
-#define CONSTD_3() CONST_GET_PTR(constpool, 16)
-#define CONSTQ_3() CONST_GET_PTR(constpool, 16)
-CONST_DATA_U64(constpool, 16, $3) // 0x0000000000000003
+#define CONSTD_2() CONST_GET_PTR(constpool, 16)
+#define CONSTQ_2() CONST_GET_PTR(constpool, 16)
+CONST_DATA_U64(constpool, 16, $2) // 0x0000000000000002
 
-#define CONSTD_4() CONST_GET_PTR(constpool, 24)
-#define CONSTQ_4() CONST_GET_PTR(constpool, 24)
-CONST_DATA_U64(constpool, 24, $4) // 0x0000000000000004
+#define CONSTD_3() CONST_GET_PTR(constpool, 24)
+#define CONSTQ_3() CONST_GET_PTR(constpool, 24)
+CONST_DATA_U64(constpool, 24, $3) // 0x0000000000000003
 
-#define CONSTD_7() CONST_GET_PTR(constpool, 32)
-#define CONSTQ_7() CONST_GET_PTR(constpool, 32)
-CONST_DATA_U64(constpool, 32, $7) // 0x0000000000000007
+#define CONSTD_4() CONST_GET_PTR(constpool, 32)
+#define CONSTQ_4() CONST_GET_PTR(constpool, 32)
+CONST_DATA_U64(constpool, 32, $4) // 0x0000000000000004
 
-#define CONSTD_8() CONST_GET_PTR(constpool, 40)
-#define CONSTQ_8() CONST_GET_PTR(constpool, 40)
-CONST_DATA_U64(constpool, 40, $8) // 0x0000000000000008
+#define CONSTD_6() CONST_GET_PTR(constpool, 40)
+#define CONSTQ_6() CONST_GET_PTR(constpool, 40)
+CONST_DATA_U64(constpool, 40, $6) // 0x0000000000000006
 
-#define CONSTD_10() CONST_GET_PTR(constpool, 48)
-#define CONSTQ_10() CONST_GET_PTR(constpool, 48)
-CONST_DATA_U64(constpool, 48, $10) // 0x000000000000000a
+#define CONSTD_7() CONST_GET_PTR(constpool, 48)
+#define CONSTQ_7() CONST_GET_PTR(constpool, 48)
+CONST_DATA_U64(constpool, 48, $7) // 0x0000000000000007
 
-#define CONSTD_12() CONST_GET_PTR(constpool, 56)
-#define CONSTQ_12() CONST_GET_PTR(constpool, 56)
-CONST_DATA_U64(constpool, 56, $12) // 0x000000000000000c
+#define CONSTD_8() CONST_GET_PTR(constpool, 56)
+#define CONSTQ_8() CONST_GET_PTR(constpool, 56)
+CONST_DATA_U64(constpool, 56, $8) // 0x0000000000000008
 
-#define CONSTQ_24() CONST_GET_PTR(constpool, 64)
-CONST_DATA_U64(constpool, 64, $24) // 0x0000000000000018
+#define CONSTD_0x0A() CONST_GET_PTR(constpool, 64)
+#define CONSTD_10() CONST_GET_PTR(constpool, 64)
+#define CONSTQ_10() CONST_GET_PTR(constpool, 64)
+CONST_DATA_U64(constpool, 64, $10) // 0x000000000000000a
 
-#define CONSTB_32() CONST_GET_PTR(constpool, 72)
-#define CONSTD_32() CONST_GET_PTR(constpool, 72)
-#define CONSTQ_32() CONST_GET_PTR(constpool, 72)
-CONST_DATA_U64(constpool, 72, $32) // 0x0000000000000020
+#define CONSTD_0x0B() CONST_GET_PTR(constpool, 72)
+#define CONSTQ_11() CONST_GET_PTR(constpool, 72)
+CONST_DATA_U64(constpool, 72, $11) // 0x000000000000000b
 
-#define CONSTD_48() CONST_GET_PTR(constpool, 80)
-#define CONSTQ_48() CONST_GET_PTR(constpool, 80)
-CONST_DATA_U64(constpool, 80, $48) // 0x0000000000000030
+#define CONSTD_12() CONST_GET_PTR(constpool, 80)
+#define CONSTQ_12() CONST_GET_PTR(constpool, 80)
+CONST_DATA_U64(constpool, 80, $12) // 0x000000000000000c
 
-#define CONSTQ_60() CONST_GET_PTR(constpool, 88)
-CONST_DATA_U64(constpool, 88, $60) // 0x000000000000003c
+#define CONSTD_0x0F() CONST_GET_PTR(constpool, 88)
+#define CONSTD_15() CONST_GET_PTR(constpool, 88)
+#define CONSTQ_15() CONST_GET_PTR(constpool, 88)
+CONST_DATA_U64(constpool, 88, $15) // 0x000000000000000f
 
-#define CONSTD_0x40() CONST_GET_PTR(constpool, 96)
-#define CONSTD_64() CONST_GET_PTR(constpool, 96)
-#define CONSTQ_64() CONST_GET_PTR(constpool, 96)
-CONST_DATA_U64(constpool, 96, $64) // 0x0000000000000040
+#define CONSTQ_24() CONST_GET_PTR(constpool, 96)
+CONST_DATA_U64(constpool, 96, $24) // 0x0000000000000018
 
-#define CONSTD_100() CONST_GET_PTR(constpool, 104)
-#define CONSTQ_100() CONST_GET_PTR(constpool, 104)
-CONST_DATA_U64(constpool, 104, $100) // 0x0000000000000064
+#define CONSTB_32() CONST_GET_PTR(constpool, 104)
+#define CONSTD_32() CONST_GET_PTR(constpool, 104)
+#define CONSTQ_32() CONST_GET_PTR(constpool, 104)
+CONST_DATA_U64(constpool, 104, $32) // 0x0000000000000020
 
-#define CONSTD_0x7F() CONST_GET_PTR(constpool, 112)
-#define CONSTQ_0x7F() CONST_GET_PTR(constpool, 112)
-CONST_DATA_U64(constpool, 112, $127) // 0x000000000000007f
+#define CONSTD_48() CONST_GET_PTR(constpool, 112)
+#define CONSTQ_48() CONST_GET_PTR(constpool, 112)
+CONST_DATA_U64(constpool, 112, $48) // 0x0000000000000030
 
-#define CONSTD_0x80() CONST_GET_PTR(constpool, 120)
-#define CONSTD_128() CONST_GET_PTR(constpool, 120)
-#define CONSTQ_0x80() CONST_GET_PTR(constpool, 120)
-CONST_DATA_U64(constpool, 120, $128) // 0x0000000000000080
+#define CONSTQ_60() CONST_GET_PTR(constpool, 120)
+CONST_DATA_U64(constpool, 120, $60) // 0x000000000000003c
 
-#define CONSTQ_0xDD() CONST_GET_PTR(constpool, 128)
-CONST_DATA_U64(constpool, 128, $221) // 0x00000000000000dd
+#define CONSTQ_0x3F() CONST_GET_PTR(constpool, 128)
+CONST_DATA_U64(constpool, 128, $63) // 0x000000000000003f
 
-#define CONSTQ_0xEE() CONST_GET_PTR(constpool, 136)
-CONST_DATA_U64(constpool, 136, $238) // 0x00000000000000ee
+#define CONSTD_0x40() CONST_GET_PTR(constpool, 136)
+#define CONSTD_64() CONST_GET_PTR(constpool, 136)
+#define CONSTQ_64() CONST_GET_PTR(constpool, 136)
+CONST_DATA_U64(constpool, 136, $64) // 0x0000000000000040
 
-#define CONSTQ_306() CONST_GET_PTR(constpool, 144)
-CONST_DATA_U64(constpool, 144, $306) // 0x0000000000000132
+#define CONSTQ_65() CONST_GET_PTR(constpool, 144)
+CONST_DATA_U64(constpool, 144, $65) // 0x0000000000000041
 
-#define CONSTQ_365() CONST_GET_PTR(constpool, 152)
-CONST_DATA_U64(constpool, 152, $365) // 0x000000000000016d
+#define CONSTD_100() CONST_GET_PTR(constpool, 152)
+#define CONSTQ_100() CONST_GET_PTR(constpool, 152)
+CONST_DATA_U64(constpool, 152, $100) // 0x0000000000000064
 
-#define CONSTQ_400() CONST_GET_PTR(constpool, 160)
-CONST_DATA_U64(constpool, 160, $400) // 0x0000000000000190
+#define CONSTD_0x7F() CONST_GET_PTR(constpool, 160)
+#define CONSTQ_0x7F() CONST_GET_PTR(constpool, 160)
+CONST_DATA_U64(constpool, 160, $127) // 0x000000000000007f
 
-#define CONSTQ_1000() CONST_GET_PTR(constpool, 168)
-CONST_DATA_U64(constpool, 168, $1000) // 0x00000000000003e8
+#define CONSTD_0x80() CONST_GET_PTR(constpool, 168)
+#define CONSTD_128() CONST_GET_PTR(constpool, 168)
+#define CONSTQ_0x80() CONST_GET_PTR(constpool, 168)
+CONST_DATA_U64(constpool, 168, $128) // 0x0000000000000080
 
-#define CONSTQ_1461() CONST_GET_PTR(constpool, 176)
-CONST_DATA_U64(constpool, 176, $1461) // 0x00000000000005b5
+#define CONSTD_0b11000000() CONST_GET_PTR(constpool, 176)
+#define CONSTQ_0xC0() CONST_GET_PTR(constpool, 176)
+CONST_DATA_U64(constpool, 176, $192) // 0x00000000000000c0
 
-#define CONSTQ_0x1FFF() CONST_GET_PTR(constpool, 184)
-CONST_DATA_U64(constpool, 184, $8191) // 0x0000000000001fff
+#define CONSTQ_0xDD() CONST_GET_PTR(constpool, 184)
+CONST_DATA_U64(constpool, 184, $221) // 0x00000000000000dd
 
-#define CONSTQ_10000() CONST_GET_PTR(constpool, 192)
-CONST_DATA_U64(constpool, 192, $10000) // 0x0000000000002710
+#define CONSTQ_0xEE() CONST_GET_PTR(constpool, 192)
+CONST_DATA_U64(constpool, 192, $238) // 0x00000000000000ee
 
-#define CONSTQ_15625() CONST_GET_PTR(constpool, 200)
-CONST_DATA_U64(constpool, 200, $15625) // 0x0000000000003d09
+#define CONSTD_0xFF() CONST_GET_PTR(constpool, 200)
+#define CONSTQ_0xFF() CONST_GET_PTR(constpool, 200)
+CONST_DATA_U64(constpool, 200, $255) // 0x00000000000000ff
 
-#define CONSTQ_0x0000000000008060() CONST_GET_PTR(constpool, 208)
-CONST_DATA_U64(constpool, 208, $32864) // 0x0000000000008060
+#define CONSTQ_306() CONST_GET_PTR(constpool, 208)
+CONST_DATA_U64(constpool, 208, $306) // 0x0000000000000132
 
-#define CONSTQ_36524() CONST_GET_PTR(constpool, 216)
-CONST_DATA_U64(constpool, 216, $36524) // 0x0000000000008eac
+#define CONSTQ_365() CONST_GET_PTR(constpool, 216)
+CONST_DATA_U64(constpool, 216, $365) // 0x000000000000016d
 
-#define CONSTQ_45965() CONST_GET_PTR(constpool, 224)
-CONST_DATA_U64(constpool, 224, $45965) // 0x000000000000b38d
+#define CONSTQ_400() CONST_GET_PTR(constpool, 224)
+CONST_DATA_U64(constpool, 224, $400) // 0x0000000000000190
 
-#define CONSTQ_0xFFFF() CONST_GET_PTR(constpool, 232)
-CONST_DATA_U64(constpool, 232, $65535) // 0x000000000000ffff
+#define CONSTQ_1000() CONST_GET_PTR(constpool, 232)
+CONST_DATA_U64(constpool, 232, $1000) // 0x00000000000003e8
 
-#define CONSTQ_0x0001003C() CONST_GET_PTR(constpool, 240)
-CONST_DATA_U64(constpool, 240, $65596) // 0x000000000001003c
+#define CONSTQ_1461() CONST_GET_PTR(constpool, 240)
+CONST_DATA_U64(constpool, 240, $1461) // 0x00000000000005b5
 
-#define CONSTQ_0x0001013C() CONST_GET_PTR(constpool, 248)
-CONST_DATA_U64(constpool, 248, $65852) // 0x000000000001013c
+#define CONSTQ_0x1FFF() CONST_GET_PTR(constpool, 248)
+CONST_DATA_U64(constpool, 248, $8191) // 0x0000000000001fff
 
-#define CONSTQ_146097() CONST_GET_PTR(constpool, 256)
-CONST_DATA_U64(constpool, 256, $146097) // 0x0000000000023ab1
+#define CONSTQ_10000() CONST_GET_PTR(constpool, 256)
+CONST_DATA_U64(constpool, 256, $10000) // 0x0000000000002710
 
-#define CONSTQ_1000000() CONST_GET_PTR(constpool, 264)
-CONST_DATA_U64(constpool, 264, $1000000) // 0x00000000000f4240
+#define CONSTQ_15625() CONST_GET_PTR(constpool, 264)
+CONST_DATA_U64(constpool, 264, $15625) // 0x0000000000003d09
 
-#define CONSTD_0x00808080() CONST_GET_PTR(constpool, 272)
-#define CONSTQ_0x0000000000808080() CONST_GET_PTR(constpool, 272)
-CONST_DATA_U64(constpool, 272, $8421504) // 0x0000000000808080
+#define CONSTQ_0x0000000000008060() CONST_GET_PTR(constpool, 272)
+CONST_DATA_U64(constpool, 272, $32864) // 0x0000000000008060
 
-#define CONSTQ_0xFFFFFF() CONST_GET_PTR(constpool, 280)
-CONST_DATA_U64(constpool, 280, $16777215) // 0x0000000000ffffff
+#define CONSTQ_36524() CONST_GET_PTR(constpool, 280)
+CONST_DATA_U64(constpool, 280, $36524) // 0x0000000000008eac
 
-#define CONSTQ_18764999() CONST_GET_PTR(constpool, 288)
-CONST_DATA_U64(constpool, 288, $18764999) // 0x00000000011e54c7
+#define CONSTQ_45965() CONST_GET_PTR(constpool, 288)
+CONST_DATA_U64(constpool, 288, $45965) // 0x000000000000b38d
 
-#define CONSTQ_60000000() CONST_GET_PTR(constpool, 296)
-CONST_DATA_U64(constpool, 296, $60000000) // 0x0000000003938700
+#define CONSTQ_0xFFFF() CONST_GET_PTR(constpool, 296)
+CONST_DATA_U64(constpool, 296, $65535) // 0x000000000000ffff
 
-#define CONSTQ_100000000() CONST_GET_PTR(constpool, 304)
-CONST_DATA_U64(constpool, 304, $100000000) // 0x0000000005f5e100
+#define CONSTQ_0x0001003C() CONST_GET_PTR(constpool, 304)
+CONST_DATA_U64(constpool, 304, $65596) // 0x000000000001003c
 
-#define CONSTQ_274877907() CONST_GET_PTR(constpool, 312)
-CONST_DATA_U64(constpool, 312, $274877907) // 0x0000000010624dd3
+#define CONSTQ_0x0001013C() CONST_GET_PTR(constpool, 312)
+CONST_DATA_U64(constpool, 312, $65852) // 0x000000000001013c
 
-#define CONSTQ_376287347() CONST_GET_PTR(constpool, 320)
-CONST_DATA_U64(constpool, 320, $376287347) // 0x00000000166db073
+#define CONSTQ_146097() CONST_GET_PTR(constpool, 320)
+CONST_DATA_U64(constpool, 320, $146097) // 0x0000000000023ab1
 
-#define CONSTQ_0b00000000_00000000_00000000_00000000_00011111_00000000_00000000_00011111() CONST_GET_PTR(constpool, 328)
-CONST_DATA_U64(constpool, 328, $520093727) // 0x000000001f00001f
+#define CONSTQ_1000000() CONST_GET_PTR(constpool, 328)
+CONST_DATA_U64(constpool, 328, $1000000) // 0x00000000000f4240
 
-#define CONSTQ_600479951() CONST_GET_PTR(constpool, 336)
-CONST_DATA_U64(constpool, 336, $600479951) // 0x0000000023ca98cf
+#define CONSTD_0x00808080() CONST_GET_PTR(constpool, 336)
+#define CONSTQ_0x0000000000808080() CONST_GET_PTR(constpool, 336)
+CONST_DATA_U64(constpool, 336, $8421504) // 0x0000000000808080
 
-#define CONSTB_57() CONST_GET_PTR(constpool, 347)
-#define CONSTQ_963315389() CONST_GET_PTR(constpool, 344)
-CONST_DATA_U64(constpool, 344, $963315389) // 0x00000000396b06bd
+#define CONSTQ_0xFFFFFF() CONST_GET_PTR(constpool, 344)
+CONST_DATA_U64(constpool, 344, $16777215) // 0x0000000000ffffff
 
-#define CONSTQ_963321983() CONST_GET_PTR(constpool, 352)
-CONST_DATA_U64(constpool, 352, $963321983) // 0x00000000396b207f
+#define CONSTQ_18764999() CONST_GET_PTR(constpool, 352)
+CONST_DATA_U64(constpool, 352, $18764999) // 0x00000000011e54c7
 
-#define CONSTQ_1125899907() CONST_GET_PTR(constpool, 360)
-CONST_DATA_U64(constpool, 360, $1125899907) // 0x00000000431bde83
+#define CONSTQ_40031997() CONST_GET_PTR(constpool, 360)
+CONST_DATA_U64(constpool, 360, $40031997) // 0x000000000262d6fd
 
-#define CONSTQ_1281023895() CONST_GET_PTR(constpool, 368)
-CONST_DATA_U64(constpool, 368, $1281023895) // 0x000000004c5adf97
+#define CONSTQ_60000000() CONST_GET_PTR(constpool, 368)
+CONST_DATA_U64(constpool, 368, $60000000) // 0x0000000003938700
 
-#define CONSTQ_1374389535() CONST_GET_PTR(constpool, 376)
-CONST_DATA_U64(constpool, 376, $1374389535) // 0x0000000051eb851f
+#define CONSTQ_100000000() CONST_GET_PTR(constpool, 376)
+CONST_DATA_U64(constpool, 376, $100000000) // 0x0000000005f5e100
 
-#define CONSTQ_1441151881() CONST_GET_PTR(constpool, 384)
-CONST_DATA_U64(constpool, 384, $1441151881) // 0x0000000055e63b89
+#define CONSTQ_274877907() CONST_GET_PTR(constpool, 384)
+CONST_DATA_U64(constpool, 384, $274877907) // 0x0000000010624dd3
 
-#define CONSTQ_2290649225() CONST_GET_PTR(constpool, 392)
-CONST_DATA_U64(constpool, 392, $2290649225) // 0x0000000088888889
+#define CONSTQ_376287347() CONST_GET_PTR(constpool, 392)
+CONST_DATA_U64(constpool, 392, $376287347) // 0x00000000166db073
 
-#define CONSTQ_2562048517() CONST_GET_PTR(constpool, 400)
-CONST_DATA_U64(constpool, 400, $2562048517) // 0x0000000098b5c205
+#define CONSTQ_0b00000000_00000000_00000000_00000000_00011111_00000000_00000000_00011111() CONST_GET_PTR(constpool, 400)
+CONST_DATA_U64(constpool, 400, $520093727) // 0x000000001f00001f
 
-#define CONSTQ_3037000499() CONST_GET_PTR(constpool, 408)
-CONST_DATA_U64(constpool, 408, $3037000499) // 0x00000000b504f333
+#define CONSTQ_600479951() CONST_GET_PTR(constpool, 408)
+CONST_DATA_U64(constpool, 408, $600479951) // 0x0000000023ca98cf
 
-#define CONSTQ_0x00000000C6808080() CONST_GET_PTR(constpool, 416)
-CONST_DATA_U64(constpool, 416, $3330310272) // 0x00000000c6808080
+#define CONSTB_57() CONST_GET_PTR(constpool, 419)
+#define CONSTQ_963315389() CONST_GET_PTR(constpool, 416)
+CONST_DATA_U64(constpool, 416, $963315389) // 0x00000000396b06bd
 
-#define CONSTQ_3518437209() CONST_GET_PTR(constpool, 424)
-CONST_DATA_U64(constpool, 424, $3518437209) // 0x00000000d1b71759
+#define CONSTQ_963321983() CONST_GET_PTR(constpool, 424)
+CONST_DATA_U64(constpool, 424, $963321983) // 0x00000000396b207f
 
-#define CONSTQ_3593175255() CONST_GET_PTR(constpool, 432)
-CONST_DATA_U64(constpool, 432, $3593175255) // 0x00000000d62b80d7
+#define CONSTQ_1125899907() CONST_GET_PTR(constpool, 432)
+CONST_DATA_U64(constpool, 432, $1125899907) // 0x00000000431bde83
 
-#define CONSTQ_3600000000() CONST_GET_PTR(constpool, 440)
-CONST_DATA_U64(constpool, 440, $3600000000) // 0x00000000d693a400
+#define CONSTQ_1281023895() CONST_GET_PTR(constpool, 440)
+CONST_DATA_U64(constpool, 440, $1281023895) // 0x000000004c5adf97
 
-#define CONSTD_0xFFFFFFFF() CONST_GET_PTR(constpool, 448)
-#define CONSTD_NEG_1() CONST_GET_PTR(constpool, 448)
-#define CONSTQ_0xFFFFFFFF() CONST_GET_PTR(constpool, 448)
-CONST_DATA_U64(constpool, 448, $4294967295) // 0x00000000ffffffff
+#define CONSTQ_1374389535() CONST_GET_PTR(constpool, 448)
+CONST_DATA_U64(constpool, 448, $1374389535) // 0x0000000051eb851f
 
-#define CONSTD_20() CONST_GET_PTR(constpool, 460)
-#define CONSTQ_86400000000() CONST_GET_PTR(constpool, 456)
-CONST_DATA_U64(constpool, 456, $86400000000) // 0x000000141dd76000
+#define CONSTQ_1441151881() CONST_GET_PTR(constpool, 456)
+CONST_DATA_U64(constpool, 456, $1441151881) // 0x0000000055e63b89
 
-#define CONSTD_0x7F7F7F7F() CONST_GET_PTR(constpool, 464)
-#define CONSTQ_0x0000007F7F7F7F7F() CONST_GET_PTR(constpool, 464)
-CONST_DATA_U64(constpool, 464, $547599908735) // 0x0000007f7f7f7f7f
+#define CONSTQ_2290649225() CONST_GET_PTR(constpool, 464)
+CONST_DATA_U64(constpool, 464, $2290649225) // 0x0000000088888889
 
-#define CONSTQ_1970_01_01_TO_0000_03_01_US_OFFSET_SHR_13() CONST_GET_PTR(constpool, 472)
-CONST_DATA_U64(constpool, 472, $7588139062500) // 0x000006e6c05554e4
+#define CONSTQ_3037000499() CONST_GET_PTR(constpool, 472)
+CONST_DATA_U64(constpool, 472, $3037000499) // 0x00000000b504f333
 
-#define CONSTQ_35184372088832() CONST_GET_PTR(constpool, 480)
-CONST_DATA_U64(constpool, 480, $35184372088832) // 0x0000200000000000
+#define CONSTQ_0x00000000C6808080() CONST_GET_PTR(constpool, 480)
+CONST_DATA_U64(constpool, 480, $3330310272) // 0x00000000c6808080
 
-#define CONSTQ_0x0000FFFFFFFFFFFF() CONST_GET_PTR(constpool, 488)
-CONST_DATA_U64(constpool, 488, $281474976710655) // 0x0000ffffffffffff
+#define CONSTQ_3518437209() CONST_GET_PTR(constpool, 488)
+CONST_DATA_U64(constpool, 488, $3518437209) // 0x00000000d1b71759
 
-#define CONSTQ_1970_01_01_TO_0000_03_01_US_OFFSET() CONST_GET_PTR(constpool, 496)
-CONST_DATA_U64(constpool, 496, $62162035200000000) // 0x00dcd80aaa9c8000
+#define CONSTQ_3593175255() CONST_GET_PTR(constpool, 496)
+CONST_DATA_U64(constpool, 496, $3593175255) // 0x00000000d62b80d7
 
-#define CONSTQ_0x3D86800000000000() CONST_GET_PTR(constpool, 504)
-CONST_DATA_U64(constpool, 504, $4433371620681187328) // 0x3d86800000000000
+#define CONSTQ_3600000000() CONST_GET_PTR(constpool, 504)
+CONST_DATA_U64(constpool, 504, $3600000000) // 0x00000000d693a400
 
-#define CONSTQ_0x3D96800000000000() CONST_GET_PTR(constpool, 512)
-CONST_DATA_U64(constpool, 512, $4437875220308557824) // 0x3d96800000000000
+#define CONSTD_0xFFFFFFFF() CONST_GET_PTR(constpool, 512)
+#define CONSTD_NEG_1() CONST_GET_PTR(constpool, 512)
+#define CONSTQ_0xFFFFFFFF() CONST_GET_PTR(constpool, 512)
+CONST_DATA_U64(constpool, 512, $4294967295) // 0x00000000ffffffff
 
-#define CONSTQ_0x5555555555555555() CONST_GET_PTR(constpool, 520)
-CONST_DATA_U64(constpool, 520, $6148914691236517205) // 0x5555555555555555
+#define CONSTD_20() CONST_GET_PTR(constpool, 524)
+#define CONSTQ_86400000000() CONST_GET_PTR(constpool, 520)
+CONST_DATA_U64(constpool, 520, $86400000000) // 0x000000141dd76000
 
-#define CONSTF64_ABS_BITS() CONST_GET_PTR(constpool, 528)
-#define CONSTQ_0x7FFFFFFFFFFFFFFF() CONST_GET_PTR(constpool, 528)
-CONST_DATA_U64(constpool, 528, $9223372036854775807) // 0x7fffffffffffffff
+#define CONSTD_0x7F7F7F7F() CONST_GET_PTR(constpool, 528)
+#define CONSTQ_0x0000007F7F7F7F7F() CONST_GET_PTR(constpool, 528)
+CONST_DATA_U64(constpool, 528, $547599908735) // 0x0000007f7f7f7f7f
 
-#define CONSTF64_SIGN_BIT() CONST_GET_PTR(constpool, 536)
-#define CONSTQ_0x8000000000000000() CONST_GET_PTR(constpool, 536)
-CONST_DATA_U64(constpool, 536, $9223372036854775808) // 0x8000000000000000
+#define CONSTQ_1970_01_01_TO_0000_03_01_US_OFFSET_SHR_13() CONST_GET_PTR(constpool, 536)
+CONST_DATA_U64(constpool, 536, $7588139062500) // 0x000006e6c05554e4
 
-#define CONSTQ_0xFFFFFFFFFFFFFFFF() CONST_GET_PTR(constpool, 544)
-#define CONSTQ_NEG_1() CONST_GET_PTR(constpool, 544)
-CONST_DATA_U64(constpool, 544, $18446744073709551615) // 0xffffffffffffffff
+#define CONSTQ_35184372088832() CONST_GET_PTR(constpool, 544)
+CONST_DATA_U64(constpool, 544, $35184372088832) // 0x0000200000000000
 
-// uint32 constants
-#define CONSTD_6() CONST_GET_PTR(constpool, 552)
-CONST_DATA_U32(constpool, 552, $6) // 0x00000006
+#define CONSTQ_0x0000FFFFFFFFFFFF() CONST_GET_PTR(constpool, 552)
+CONST_DATA_U64(constpool, 552, $281474976710655) // 0x0000ffffffffffff
 
-#define CONSTD_0x0B() CONST_GET_PTR(constpool, 556)
-CONST_DATA_U32(constpool, 556, $11) // 0x0000000b
+#define CONSTQ_1970_01_01_TO_0000_03_01_US_OFFSET() CONST_GET_PTR(constpool, 560)
+CONST_DATA_U64(constpool, 560, $62162035200000000) // 0x00dcd80aaa9c8000
 
-#define CONSTD_0x0D() CONST_GET_PTR(constpool, 560)
-#define CONSTD_13() CONST_GET_PTR(constpool, 560)
-CONST_DATA_U32(constpool, 560, $13) // 0x0000000d
+#define CONSTQ_0x3D86800000000000() CONST_GET_PTR(constpool, 568)
+CONST_DATA_U64(constpool, 568, $4433371620681187328) // 0x3d86800000000000
 
-#define CONSTD_0x0E() CONST_GET_PTR(constpool, 564)
-#define CONSTD_14() CONST_GET_PTR(constpool, 564)
-CONST_DATA_U32(constpool, 564, $14) // 0x0000000e
+#define CONSTQ_0x3D96800000000000() CONST_GET_PTR(constpool, 576)
+CONST_DATA_U64(constpool, 576, $4437875220308557824) // 0x3d96800000000000
 
-#define CONSTD_0x0F() CONST_GET_PTR(constpool, 568)
-#define CONSTD_15() CONST_GET_PTR(constpool, 568)
-CONST_DATA_U32(constpool, 568, $15) // 0x0000000f
+#define CONSTQ_0x5555555555555555() CONST_GET_PTR(constpool, 584)
+CONST_DATA_U64(constpool, 584, $6148914691236517205) // 0x5555555555555555
 
-#define CONSTD_16() CONST_GET_PTR(constpool, 572)
-#define CONSTD_FALSE_BYTE() CONST_GET_PTR(constpool, 572)
-CONST_DATA_U32(constpool, 572, $16) // 0x00000010
+#define CONSTF64_ABS_BITS() CONST_GET_PTR(constpool, 592)
+#define CONSTQ_0x7FFFFFFFFFFFFFFF() CONST_GET_PTR(constpool, 592)
+CONST_DATA_U64(constpool, 592, $9223372036854775807) // 0x7fffffffffffffff
 
-#define CONSTD_TRUE_BYTE() CONST_GET_PTR(constpool, 576)
-CONST_DATA_U32(constpool, 576, $17) // 0x00000011
+#define CONSTF64_SIGN_BIT() CONST_GET_PTR(constpool, 600)
+#define CONSTQ_0x8000000000000000() CONST_GET_PTR(constpool, 600)
+CONST_DATA_U64(constpool, 600, $9223372036854775808) // 0x8000000000000000
 
-#define CONSTD_0x2E() CONST_GET_PTR(constpool, 580)
-CONST_DATA_U32(constpool, 580, $46) // 0x0000002e
+#define CONSTQ_0xFFFFFFFFFFFFFFFF() CONST_GET_PTR(constpool, 608)
+#define CONSTQ_NEG_1() CONST_GET_PTR(constpool, 608)
+CONST_DATA_U64(constpool, 608, $18446744073709551615) // 0xffffffffffffffff
 
-#define CONSTD_131() CONST_GET_PTR(constpool, 584)
-CONST_DATA_U32(constpool, 584, $131) // 0x00000083
+// uint32 constants
+#define CONSTD_0x0D() CONST_GET_PTR(constpool, 616)
+#define CONSTD_13() CONST_GET_PTR(constpool, 616)
+CONST_DATA_U32(constpool, 616, $13) // 0x0000000d
 
-#define CONSTD_0xB0() CONST_GET_PTR(constpool, 588)
-CONST_DATA_U32(constpool, 588, $176) // 0x000000b0
+#define CONSTD_0x0E() CONST_GET_PTR(constpool, 620)
+#define CONSTD_14() CONST_GET_PTR(constpool, 620)
+CONST_DATA_U32(constpool, 620, $14) // 0x0000000e
 
-#define CONSTD_0b11000000() CONST_GET_PTR(constpool, 592)
-CONST_DATA_U32(constpool, 592, $192) // 0x000000c0
+#define CONSTD_16() CONST_GET_PTR(constpool, 624)
+#define CONSTD_FALSE_BYTE() CONST_GET_PTR(constpool, 624)
+CONST_DATA_U32(constpool, 624, $16) // 0x00000010
 
-#define CONSTD_0xD0() CONST_GET_PTR(constpool, 596)
-CONST_DATA_U32(constpool, 596, $208) // 0x000000d0
+#define CONSTD_TRUE_BYTE() CONST_GET_PTR(constpool, 628)
+CONST_DATA_U32(constpool, 628, $17) // 0x00000011
 
-#define CONSTD_0b11100000() CONST_GET_PTR(constpool, 600)
-CONST_DATA_U32(constpool, 600, $224) // 0x000000e0
+#define CONSTD_0x2E() CONST_GET_PTR(constpool, 632)
+CONST_DATA_U32(constpool, 632, $46) // 0x0000002e
 
-#define CONSTD_0b11110000() CONST_GET_PTR(constpool, 604)
-CONST_DATA_U32(constpool, 604, $240) // 0x000000f0
+#define CONSTB_97() CONST_GET_PTR(constpool, 636)
+#define CONSTD_97() CONST_GET_PTR(constpool, 636)
+CONST_DATA_U32(constpool, 636, $97) // 0x00000061
 
-#define CONSTD_0b11111000() CONST_GET_PTR(constpool, 608)
-CONST_DATA_U32(constpool, 608, $248) // 0x000000f8
+#define CONSTD_131() CONST_GET_PTR(constpool, 640)
+CONST_DATA_U32(constpool, 640, $131) // 0x00000083
 
-#define CONSTD_0xFF() CONST_GET_PTR(constpool, 612)
-CONST_DATA_U32(constpool, 612, $255) // 0x000000ff
+#define CONSTD_0xB0() CONST_GET_PTR(constpool, 644)
+CONST_DATA_U32(constpool, 644, $176) // 0x000000b0
 
-#define CONSTD_5243() CONST_GET_PTR(constpool, 616)
-CONST_DATA_U32(constpool, 616, $5243) // 0x0000147b
+#define CONSTD_0xD0() CONST_GET_PTR(constpool, 648)
+CONST_DATA_U32(constpool, 648, $208) // 0x000000d0
 
-#define CONSTD_6554() CONST_GET_PTR(constpool, 620)
-CONST_DATA_U32(constpool, 620, $6554) // 0x0000199a
+#define CONSTD_0b11100000() CONST_GET_PTR(constpool, 652)
+CONST_DATA_U32(constpool, 652, $224) // 0x000000e0
 
-#define CONSTD_0x3FFF() CONST_GET_PTR(constpool, 624)
-CONST_DATA_U32(constpool, 624, $16383) // 0x00003fff
+#define CONSTD_0b11110000() CONST_GET_PTR(constpool, 656)
+CONST_DATA_U32(constpool, 656, $240) // 0x000000f0
 
-#define CONSTD_16388() CONST_GET_PTR(constpool, 628)
-CONST_DATA_U32(constpool, 628, $16388) // 0x00004004
+#define CONSTD_0b11111000() CONST_GET_PTR(constpool, 660)
+CONST_DATA_U32(constpool, 660, $248) // 0x000000f8
 
-#define CONSTD_0x10101() CONST_GET_PTR(constpool, 632)
-CONST_DATA_U32(constpool, 632, $65793) // 0x00010101
+#define CONSTD_5243() CONST_GET_PTR(constpool, 664)
+CONST_DATA_U32(constpool, 664, $5243) // 0x0000147b
 
-#define CONSTD_0x10801() CONST_GET_PTR(constpool, 636)
-CONST_DATA_U32(constpool, 636, $67585) // 0x00010801
+#define CONSTD_6554() CONST_GET_PTR(constpool, 668)
+CONST_DATA_U32(constpool, 668, $6554) // 0x0000199a
 
-#define CONSTD_0x400001() CONST_GET_PTR(constpool, 640)
-CONST_DATA_U32(constpool, 640, $4194305) // 0x00400001
+#define CONSTD_0x3FFF() CONST_GET_PTR(constpool, 672)
+CONST_DATA_U32(constpool, 672, $16383) // 0x00003fff
 
-#define CONSTD_0x007F007F() CONST_GET_PTR(constpool, 644)
-CONST_DATA_U32(constpool, 644, $8323199) // 0x007f007f
+#define CONSTD_16388() CONST_GET_PTR(constpool, 676)
+CONST_DATA_U32(constpool, 676, $16388) // 0x00004004
 
-#define CONSTD_0x01010101() CONST_GET_PTR(constpool, 648)
-CONST_DATA_U32(constpool, 648, $16843009) // 0x01010101
+#define CONSTD_0x10101() CONST_GET_PTR(constpool, 680)
+CONST_DATA_U32(constpool, 680, $65793) // 0x00010101
 
-#define CONSTD_134217727() CONST_GET_PTR(constpool, 652)
-CONST_DATA_U32(constpool, 652, $134217727) // 0x07ffffff
+#define CONSTD_0x10801() CONST_GET_PTR(constpool, 684)
+CONST_DATA_U32(constpool, 684, $67585) // 0x00010801
 
-#define CONSTD_0x0F0F0F0F() CONST_GET_PTR(constpool, 656)
-CONST_DATA_U32(constpool, 656, $252645135) // 0x0f0f0f0f
+#define CONSTD_0x400001() CONST_GET_PTR(constpool, 688)
+CONST_DATA_U32(constpool, 688, $4194305) // 0x00400001
 
-#define CONSTD_0x3FFFFFFF() CONST_GET_PTR(constpool, 660)
-CONST_DATA_U32(constpool, 660, $1073741823) // 0x3fffffff
+#define CONSTD_0x007F007F() CONST_GET_PTR(constpool, 692)
+CONST_DATA_U32(constpool, 692, $8323199) // 0x007f007f
 
-#define CONSTD_UTF8_4B_MASK() CONST_GET_PTR(constpool, 664)
-CONST_DATA_U32(constpool, 664, $2155905264) // 0x808080f0
+#define CONSTD_0x01010101() CONST_GET_PTR(constpool, 696)
+CONST_DATA_U32(constpool, 696, $16843009) // 0x01010101
 
-#define CONSTD_UTF8_3B_MASK() CONST_GET_PTR(constpool, 668)
-CONST_DATA_U32(constpool, 668, $2155929600) // 0x8080e000
+#define CONSTD_0x01100110() CONST_GET_PTR(constpool, 700)
+CONST_DATA_U32(constpool, 700, $17826064) // 0x01100110
 
-#define CONSTD_UTF8_2B_MASK() CONST_GET_PTR(constpool, 672)
-CONST_DATA_U32(constpool, 672, $2160066560) // 0x80c00000
+#define CONSTD_134217727() CONST_GET_PTR(constpool, 704)
+CONST_DATA_U32(constpool, 704, $134217727) // 0x07ffffff
 
-#define CONSTD_0b11001110_01110011_10011100_11100111() CONST_GET_PTR(constpool, 676)
-CONST_DATA_U32(constpool, 676, $3463683303) // 0xce739ce7
+#define CONSTD_0x0F000F00() CONST_GET_PTR(constpool, 708)
+CONST_DATA_U32(constpool, 708, $251662080) // 0x0f000f00
 
-#define CONSTD_0xFFFF0000() CONST_GET_PTR(constpool, 680)
-CONST_DATA_U32(constpool, 680, $4294901760) // 0xffff0000
+#define CONSTD_0x0F0F0F0F() CONST_GET_PTR(constpool, 712)
+CONST_DATA_U32(constpool, 712, $252645135) // 0x0f0f0f0f
 
-// uint8 constants
-#define CONSTB_97() CONST_GET_PTR(constpool, 684)
-CONST_DATA_U8(constpool, 684, $97) // 0x61
+#define CONSTD_0x3FFFFFFF() CONST_GET_PTR(constpool, 716)
+CONST_DATA_U32(constpool, 716, $1073741823) // 0x3fffffff
+
+#define CONSTD_UTF8_4B_MASK() CONST_GET_PTR(constpool, 720)
+CONST_DATA_U32(constpool, 720, $2155905264) // 0x808080f0
+
+#define CONSTD_UTF8_3B_MASK() CONST_GET_PTR(constpool, 724)
+CONST_DATA_U32(constpool, 724, $2155929600) // 0x8080e000
 
-#define CONSTB_122() CONST_GET_PTR(constpool, 685)
-CONST_DATA_U8(constpool, 685, $122) // 0x7a
+#define CONSTD_UTF8_2B_MASK() CONST_GET_PTR(constpool, 728)
+CONST_DATA_U32(constpool, 728, $2160066560) // 0x80c00000
+
+#define CONSTD_0xAAAAAAAB() CONST_GET_PTR(constpool, 732)
+CONST_DATA_U32(constpool, 732, $2863311531) // 0xaaaaaaab
+
+#define CONSTD_0b11001110_01110011_10011100_11100111() CONST_GET_PTR(constpool, 736)
+CONST_DATA_U32(constpool, 736, $3463683303) // 0xce739ce7
+
+#define CONSTD_0xFFFF0000() CONST_GET_PTR(constpool, 740)
+CONST_DATA_U32(constpool, 740, $4294901760) // 0xffff0000
+
+// uint8 constants
+#define CONSTB_122() CONST_GET_PTR(constpool, 744)
+CONST_DATA_U8(constpool, 744, $122) // 0x7a
 
 // float32 constants
-#define CONSTF32_16_RECI() CONST_GET_PTR(constpool, 686)
-CONST_DATA_U32(constpool, 686, $0x000000003d800000) // float32(0.062500)
+#define CONSTF32_16_RECI() CONST_GET_PTR(constpool, 745)
+CONST_DATA_U32(constpool, 745, $0x000000003d800000) // float32(0.062500)
 
-#define CONSTF32_PI_TIMES_16_RECI() CONST_GET_PTR(constpool, 690)
-CONST_DATA_U32(constpool, 690, $0x000000003e490fdb) // float32(0.196350)
+#define CONSTF32_PI_TIMES_16_RECI() CONST_GET_PTR(constpool, 749)
+CONST_DATA_U32(constpool, 749, $0x000000003e490fdb) // float32(0.196350)
 
-#define CONSTF32_PI_RECI() CONST_GET_PTR(constpool, 694)
-CONST_DATA_U32(constpool, 694, $0x000000003ea2f983) // float32(0.318310)
+#define CONSTF32_PI_RECI() CONST_GET_PTR(constpool, 753)
+CONST_DATA_U32(constpool, 753, $0x000000003ea2f983) // float32(0.318310)
 
-#define CONSTF32_2_RECI() CONST_GET_PTR(constpool, 698)
-CONST_DATA_U32(constpool, 698, $0x000000003f000000) // float32(0.500000)
+#define CONSTF32_2_RECI() CONST_GET_PTR(constpool, 757)
+CONST_DATA_U32(constpool, 757, $0x000000003f000000) // float32(0.500000)
 
-#define CONSTF32_1() CONST_GET_PTR(constpool, 702)
-CONST_DATA_U32(constpool, 702, $0x000000003f800000) // float32(1.000000)
+#define CONSTF32_1() CONST_GET_PTR(constpool, 761)
+CONST_DATA_U32(constpool, 761, $0x000000003f800000) // float32(1.000000)
 
-#define CONSTF32_HALF_PI() CONST_GET_PTR(constpool, 706)
-CONST_DATA_U32(constpool, 706, $0x000000003fc90fdb) // float32(1.570796)
+#define CONSTF32_HALF_PI() CONST_GET_PTR(constpool, 765)
+CONST_DATA_U32(constpool, 765, $0x000000003fc90fdb) // float32(1.570796)
 
-#define CONSTF32_2() CONST_GET_PTR(constpool, 710)
-CONST_DATA_U32(constpool, 710, $0x0000000040000000) // float32(2.000000)
+#define CONSTF32_2() CONST_GET_PTR(constpool, 769)
+CONST_DATA_U32(constpool, 769, $0x0000000040000000) // float32(2.000000)
 
-#define CONSTF32_16_TIMES_PI_RECI() CONST_GET_PTR(constpool, 714)
-CONST_DATA_U32(constpool, 714, $0x0000000040a2f983) // float32(5.092958)
+#define CONSTF32_16_TIMES_PI_RECI() CONST_GET_PTR(constpool, 773)
+CONST_DATA_U32(constpool, 773, $0x0000000040a2f983) // float32(5.092958)
 
-#define CONSTF32_16() CONST_GET_PTR(constpool, 718)
-CONST_DATA_U32(constpool, 718, $0x0000000041800000) // float32(16.000000)
+#define CONSTF32_16() CONST_GET_PTR(constpool, 777)
+CONST_DATA_U32(constpool, 777, $0x0000000041800000) // float32(16.000000)
 
-#define CONSTF32_POSITIVE_INF() CONST_GET_PTR(constpool, 722)
-CONST_DATA_U32(constpool, 722, $0x000000007f800000) // float32(+Inf)
+#define CONSTF32_POSITIVE_INF() CONST_GET_PTR(constpool, 781)
+CONST_DATA_U32(constpool, 781, $0x000000007f800000) // float32(+Inf)
 
-#define CONSTF32_NEGATIVE_INF() CONST_GET_PTR(constpool, 726)
-CONST_DATA_U32(constpool, 726, $0x00000000ff800000) // float32(-Inf)
+#define CONSTF32_NEGATIVE_INF() CONST_GET_PTR(constpool, 785)
+CONST_DATA_U32(constpool, 785, $0x00000000ff800000) // float32(-Inf)
 
 // float64 constants
-#define CONSTF64_PI_DIV_180() CONST_GET_PTR(constpool, 730)
-CONST_DATA_U64(constpool, 730, $0x3f91df46a2529d39) // float64(0.017453)
+#define CONSTF64_PI_DIV_180() CONST_GET_PTR(constpool, 789)
+CONST_DATA_U64(constpool, 789, $0x3f91df46a2529d39) // float64(0.017453)
 
-#define CONSTF64_HALF() CONST_GET_PTR(constpool, 738)
-CONST_DATA_U64(constpool, 738, $0x3fe0000000000000) // float64(0.500000)
+#define CONSTF64_HALF() CONST_GET_PTR(constpool, 797)
+CONST_DATA_U64(constpool, 797, $0x3fe0000000000000) // float64(0.500000)
 
-#define CONSTF64_0p9999() CONST_GET_PTR(constpool, 746)
-CONST_DATA_U64(constpool, 746, $0x3fefff2e48e8a71e) // float64(0.999900)
+#define CONSTF64_0p9999() CONST_GET_PTR(constpool, 805)
+CONST_DATA_U64(constpool, 805, $0x3fefff2e48e8a71e) // float64(0.999900)
 
-#define CONSTF64_1() CONST_GET_PTR(constpool, 754)
-CONST_DATA_U64(constpool, 754, $0x3ff0000000000000) // float64(1.000000)
+#define CONSTF64_1() CONST_GET_PTR(constpool, 813)
+CONST_DATA_U64(constpool, 813, $0x3ff0000000000000) // float64(1.000000)
 
-#define CONSTF64_4() CONST_GET_PTR(constpool, 762)
-CONST_DATA_U64(constpool, 762, $0x4010000000000000) // float64(4.000000)
+#define CONSTF64_4() CONST_GET_PTR(constpool, 821)
+CONST_DATA_U64(constpool, 821, $0x4010000000000000) // float64(4.000000)
 
-#define CONSTF64_7() CONST_GET_PTR(constpool, 770)
-CONST_DATA_U64(constpool, 770, $0x401c000000000000) // float64(7.000000)
+#define CONSTF64_7() CONST_GET_PTR(constpool, 829)
+CONST_DATA_U64(constpool, 829, $0x401c000000000000) // float64(7.000000)
 
-#define CONSTF64_11() CONST_GET_PTR(constpool, 778)
-CONST_DATA_U64(constpool, 778, $0x4026000000000000) // float64(11.000000)
+#define CONSTF64_11() CONST_GET_PTR(constpool, 837)
+CONST_DATA_U64(constpool, 837, $0x4026000000000000) // float64(11.000000)
 
-#define CONSTF64_12() CONST_GET_PTR(constpool, 786)
-CONST_DATA_U64(constpool, 786, $0x4028000000000000) // float64(12.000000)
+#define CONSTF64_12() CONST_GET_PTR(constpool, 845)
+CONST_DATA_U64(constpool, 845, $0x4028000000000000) // float64(12.000000)
 
-#define CONSTF64_65536() CONST_GET_PTR(constpool, 794)
-CONST_DATA_U64(constpool, 794, $0x40f0000000000000) // float64(65536.000000)
+#define CONSTF64_65536() CONST_GET_PTR(constpool, 853)
+CONST_DATA_U64(constpool, 853, $0x40f0000000000000) // float64(65536.000000)
 
-#define CONSTF64_MICROSECONDS_IN_1_DAY_SHR_13() CONST_GET_PTR(constpool, 802)
-CONST_DATA_U64(constpool, 802, $0x41641dd760000000) // float64(10546875.000000)
+#define CONSTF64_MICROSECONDS_IN_1_DAY_SHR_13() CONST_GET_PTR(constpool, 861)
+CONST_DATA_U64(constpool, 861, $0x41641dd760000000) // float64(10546875.000000)
 
-#define CONSTF64_12742000() CONST_GET_PTR(constpool, 810)
-CONST_DATA_U64(constpool, 810, $0x41684dae00000000) // float64(12742000.000000)
+#define CONSTF64_12742000() CONST_GET_PTR(constpool, 869)
+CONST_DATA_U64(constpool, 869, $0x41684dae00000000) // float64(12742000.000000)
 
-#define CONSTF64_100000000() CONST_GET_PTR(constpool, 818)
-CONST_DATA_U64(constpool, 818, $0x4197d78400000000) // float64(100000000.000000)
+#define CONSTF64_100000000() CONST_GET_PTR(constpool, 877)
+CONST_DATA_U64(constpool, 877, $0x4197d78400000000) // float64(100000000.000000)
 
-#define CONSTF64_152587890625() CONST_GET_PTR(constpool, 826)
-CONST_DATA_U64(constpool, 826, $0x4241c37937e08000) // float64(152587890625.000000)
+#define CONSTF64_152587890625() CONST_GET_PTR(constpool, 885)
+CONST_DATA_U64(constpool, 885, $0x4241c37937e08000) // float64(152587890625.000000)
 
-#define CONSTF64_281474976710656_DIV_360() CONST_GET_PTR(constpool, 834)
-CONST_DATA_U64(constpool, 834, $0x4266c16c16c16c17) // float64(781874935307.377808)
+#define CONSTF64_281474976710656_DIV_360() CONST_GET_PTR(constpool, 893)
+CONST_DATA_U64(constpool, 893, $0x4266c16c16c16c17) // float64(781874935307.377808)
 
-#define CONSTF64_281474976710656_DIV_4PI() CONST_GET_PTR(constpool, 842)
-CONST_DATA_U64(constpool, 842, $0x42b45f306dc9c883) // float64(22399066950088.511719)
+#define CONSTF64_281474976710656_DIV_4PI() CONST_GET_PTR(constpool, 901)
+CONST_DATA_U64(constpool, 901, $0x42b45f306dc9c883) // float64(22399066950088.511719)
 
-#define CONSTF64_140737488355328() CONST_GET_PTR(constpool, 850)
-CONST_DATA_U64(constpool, 850, $0x42e0000000000000) // float64(140737488355328.000000)
+#define CONSTF64_140737488355328() CONST_GET_PTR(constpool, 909)
+CONST_DATA_U64(constpool, 909, $0x42e0000000000000) // float64(140737488355328.000000)
 
-#define CONSTF64_POSITIVE_INF() CONST_GET_PTR(constpool, 858)
-CONST_DATA_U64(constpool, 858, $0x7ff0000000000000) // float64(+Inf)
+#define CONSTF64_POSITIVE_INF() CONST_GET_PTR(constpool, 917)
+CONST_DATA_U64(constpool, 917, $0x7ff0000000000000) // float64(+Inf)
 
-#define CONSTF64_NAN() CONST_GET_PTR(constpool, 866)
-CONST_DATA_U64(constpool, 866, $0x7ff8000000000001) // float64(NaN)
+#define CONSTF64_NAN() CONST_GET_PTR(constpool, 925)
+CONST_DATA_U64(constpool, 925, $0x7ff8000000000001) // float64(NaN)
 
-#define CONSTF64_MINUS_0p9999() CONST_GET_PTR(constpool, 874)
-CONST_DATA_U64(constpool, 874, $0xbfefff2e48e8a71e) // float64(-0.999900)
+#define CONSTF64_MINUS_0p9999() CONST_GET_PTR(constpool, 933)
+CONST_DATA_U64(constpool, 933, $0xbfefff2e48e8a71e) // float64(-0.999900)
 
-#define CONSTF64_NEGATIVE_INF() CONST_GET_PTR(constpool, 882)
-CONST_DATA_U64(constpool, 882, $0xfff0000000000000) // float64(-Inf)
+#define CONSTF64_NEGATIVE_INF() CONST_GET_PTR(constpool, 941)
+CONST_DATA_U64(constpool, 941, $0xfff0000000000000) // float64(-Inf)
 
-CONST_GLOBAL(constpool, $890)
+CONST_GLOBAL(constpool, $949)
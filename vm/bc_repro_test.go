@@ -0,0 +1,411 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+var bcRecordFlag = flag.String("bcrecord", "", "directory in which in-flight opcode executions are recorded (see bcRepro)")
+var bcReproFlag = flag.String("bcrepro", "", "opcode execution recorded with -bcrecord to replay in TestBCRepro")
+
+// bcRepro is a self-contained description of a single
+// opcode execution performed by bctestContext: the opcode,
+// the active lanes, the contents of each input register and
+// immediate, the dictionary, and the data buffer that the
+// registers point into.
+//
+// When the tests run with -bcrecord=dir, the bcRepro of each
+// opcode execution is written to dir before the opcode runs
+// and removed once it has returned, so the files left behind
+// by a crash are the executions that were in flight. Each of
+// them can be replayed deterministically with
+//
+//	go test -run TestBCRepro -bcrepro=dir/<file>.json
+type bcRepro struct {
+	Op    string       `json:"op"`
+	Lanes uint16       `json:"lanes"`
+	Args  []bcReproArg `json:"args"`
+	Dict  [][]byte     `json:"dict,omitempty"`
+	Data  []byte       `json:"data,omitempty"`
+}
+
+// bcReproArg is a single opcode argument.
+//
+// Kind is one of "k", "b", "v", "s", "i64" and "f64" for
+// registers, and "dict", "imm" and "sym" for the arguments
+// that are encoded in the bytecode. Input registers are stored
+// in Reg, with the offsets of the lanes that reference the
+// data buffer made relative to its start; masks and encoded
+// arguments are stored in Imm (as raw bits for floats).
+// Output arguments only have a Kind.
+type bcReproArg struct {
+	Kind string   `json:"kind"`
+	Reg  []uint64 `json:"reg,omitempty"`
+	Imm  uint64   `json:"imm,omitempty"`
+}
+
+// rebase adds delta to the offsets of the
+// lanes that reference memory in [lo, hi)
+func rebase(offsets, sizes *[bcLaneCount]uint32, lo, hi, delta uint32) {
+	for i := range offsets {
+		if sizes[i] != 0 && offsets[i] >= lo && offsets[i]+sizes[i] <= hi {
+			offsets[i] += delta
+		}
+	}
+}
+
+// dataBase returns the displacement of c.data
+func (c *bctestContext) dataBase() uint32 {
+	if cap(c.data) == 0 {
+		return 0
+	}
+	base, ok := vmdispl(c.data[:cap(c.data)])
+	if !ok {
+		panic("bctestContext.data is not vm memory")
+	}
+	return base
+}
+
+// repro returns the bcRepro of an execution of op;
+// the arguments are interpreted as in c.execute
+func (c *bctestContext) repro(op bcop, testArgs []any, activeLanes kRegData) *bcRepro {
+	info := &opinfo[op]
+	r := &bcRepro{
+		Op:    info.text,
+		Lanes: activeLanes.mask,
+		Data:  c.data,
+	}
+	for i := range c.dict {
+		r.Dict = append(r.Dict, []byte(c.dict[i]))
+	}
+	base := c.dataBase()
+	lo, hi, delta := base, base+uint32(len(c.data)), -base
+
+	retvals, argvals := testArgs[:len(info.out)], testArgs[len(info.out):]
+	for i := range retvals {
+		kind := strings.ToLower(info.out[i].String())
+		switch retvals[i].(type) {
+		case *i64RegData:
+			kind = "i64"
+		case *f64RegData:
+			kind = "f64"
+		}
+		r.Args = append(r.Args, bcReproArg{Kind: kind})
+	}
+	for i, arg := range argvals {
+		var a bcReproArg
+		switch info.in[i] {
+		case bcK:
+			a.Kind = "k"
+			switch v := arg.(type) {
+			case *kRegData:
+				a.Imm = uint64(v.mask)
+			default:
+				a.Imm = toi64(v)
+			}
+		case bcB:
+			b := *arg.(*bRegData)
+			rebase(&b.offsets, &b.sizes, lo, hi, delta)
+			a.Kind, a.Reg = "b", append([]uint64{}, bRegAsUInt64Slice(&b)...)
+		case bcV:
+			v := *arg.(*vRegData)
+			rebase(&v.offsets, &v.sizes, lo, hi, delta)
+			a.Kind, a.Reg = "v", append([]uint64{}, vRegAsUInt64Slice(&v)...)
+		case bcS:
+			switch v := arg.(type) {
+			case *sRegData:
+				s := *v
+				rebase(&s.offsets, &s.sizes, lo, hi, delta)
+				a.Kind, a.Reg = "s", append([]uint64{}, sRegAsUInt64Slice(&s)...)
+			case *i64RegData:
+				a.Kind, a.Reg = "i64", append([]uint64{}, i64RegAsUInt64Slice(v)...)
+			case *f64RegData:
+				a.Kind, a.Reg = "f64", append([]uint64{}, f64RegAsUInt64Slice(v)...)
+			}
+		case bcDictSlot:
+			a.Kind, a.Imm = "dict", toi64(arg)
+		case bcImmF64:
+			a.Kind, a.Imm = "imm", math.Float64bits(tof64(arg))
+		case bcImmI8, bcImmI16, bcImmI32, bcImmI64, bcImmU8, bcImmU16, bcImmU32, bcImmU64:
+			a.Kind, a.Imm = "imm", toi64(arg)
+		case bcSymbolID:
+			a.Kind, a.Imm = "sym", uint64(arg.(ion.Symbol))
+		}
+		r.Args = append(r.Args, a)
+	}
+	return r
+}
+
+var bcRecordSeq atomic.Int64
+
+// record writes the bcRepro of an execution of op to the
+// -bcrecord directory and returns a function that removes it
+func (c *bctestContext) record(op bcop, testArgs []any, activeLanes kRegData) func() {
+	buf, err := json.Marshal(c.repro(op, testArgs, activeLanes))
+	if err != nil {
+		panic(err)
+	}
+	name := fmt.Sprintf("%s-%d-%d.json", opinfo[op].text, os.Getpid(), bcRecordSeq.Add(1))
+	file := filepath.Join(*bcRecordFlag, name)
+	if err := os.WriteFile(file, buf, 0644); err != nil {
+		panic(err)
+	}
+	return func() { os.Remove(file) }
+}
+
+func lookupOp(text string) (bcop, bool) {
+	for op := range opinfo {
+		if opinfo[op].text == text {
+			return bcop(op), true
+		}
+	}
+	return 0, false
+}
+
+// args sets up c with the dictionary and data of r
+// and returns the opcode and the arguments to execute
+func (r *bcRepro) args(c *bctestContext) (bcop, []any, kRegData, error) {
+	op, ok := lookupOp(r.Op)
+	if !ok {
+		return 0, nil, kRegData{}, fmt.Errorf("unknown opcode %q", r.Op)
+	}
+	info := &opinfo[op]
+	if len(r.Args) != len(info.out)+len(info.in) {
+		return 0, nil, kRegData{}, fmt.Errorf("opcode %s requires %d arguments, %d given", r.Op, len(info.out)+len(info.in), len(r.Args))
+	}
+
+	c.clear()
+	for i := range r.Dict {
+		c.dict = append(c.dict, string(r.Dict[i]))
+	}
+	c.ensureData()
+	c.data = append(c.data, r.Data...)
+	base := c.dataBase()
+	lo, hi := uint32(0), uint32(len(r.Data))
+
+	reg := func(dst []uint64, a *bcReproArg) error {
+		if len(a.Reg) != len(dst) {
+			return fmt.Errorf("%s register has %d words, expected %d", a.Kind, len(a.Reg), len(dst))
+		}
+		copy(dst, a.Reg)
+		return nil
+	}
+	args := make([]any, len(r.Args))
+	for i := range r.Args {
+		a := &r.Args[i]
+		var err error
+		switch a.Kind {
+		case "k":
+			args[i] = &kRegData{mask: uint16(a.Imm)}
+		case "b":
+			b := &bRegData{}
+			if i >= len(info.out) {
+				err = reg(bRegAsUInt64Slice(b), a)
+				rebase(&b.offsets, &b.sizes, lo, hi, base)
+			}
+			args[i] = b
+		case "v":
+			v := &vRegData{}
+			if i >= len(info.out) {
+				err = reg(vRegAsUInt64Slice(v), a)
+				rebase(&v.offsets, &v.sizes, lo, hi, base)
+			}
+			args[i] = v
+		case "s":
+			s := &sRegData{}
+			if i >= len(info.out) {
+				err = reg(sRegAsUInt64Slice(s), a)
+				rebase(&s.offsets, &s.sizes, lo, hi, base)
+			}
+			args[i] = s
+		case "i64":
+			v := &i64RegData{}
+			if i >= len(info.out) {
+				err = reg(i64RegAsUInt64Slice(v), a)
+			}
+			args[i] = v
+		case "f64":
+			v := &f64RegData{}
+			if i >= len(info.out) {
+				err = reg(f64RegAsUInt64Slice(v), a)
+			}
+			args[i] = v
+		case "dict":
+			args[i] = uint16(a.Imm)
+		case "imm":
+			if info.in[i-len(info.out)] == bcImmF64 {
+				args[i] = math.Float64frombits(a.Imm)
+			} else {
+				args[i] = a.Imm
+			}
+		case "sym":
+			args[i] = ion.Symbol(a.Imm)
+		default:
+			err = fmt.Errorf("unknown argument kind %q", a.Kind)
+		}
+		if err != nil {
+			return 0, nil, kRegData{}, fmt.Errorf("argument #%d: %w", i, err)
+		}
+	}
+	return op, args, kRegData{mask: r.Lanes}, nil
+}
+
+// formatOutputs formats the outputs of op in args
+func formatOutputs(op bcop, args []any) []string {
+	var out []string
+	for i := range opinfo[op].out {
+		switch v := args[i].(type) {
+		case *kRegData:
+			out = append(out, fmt.Sprintf("k: %016b", v.mask))
+		case *i64RegData:
+			out = append(out, fmt.Sprintf("i64: %v", v.values))
+		case *f64RegData:
+			out = append(out, fmt.Sprintf("f64: %v", v.values))
+		case *sRegData:
+			out = append(out, fmt.Sprintf("s: offsets %v sizes %v", v.offsets, v.sizes))
+		case *vRegData:
+			out = append(out, fmt.Sprintf("v: offsets %v sizes %v typeL %x", v.offsets, v.sizes, v.typeL))
+		}
+	}
+	return out
+}
+
+func loadRepro(file string) (*bcRepro, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	r := &bcRepro{}
+	if err := json.Unmarshal(buf, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return r, nil
+}
+
+// TestBCRepro replays the opcode execution
+// recorded in the -bcrepro file with each executor
+func TestBCRepro(t *testing.T) {
+	if *bcReproFlag == "" {
+		t.Skip("no -bcrepro file given")
+	}
+	r, err := loadRepro(*bcReproFlag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ctx bctestContext
+	defer ctx.free()
+	executors := bcexecutors(&ctx)
+	names := make([]string, 0, len(executors))
+	for name := range executors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		op, args, lanes, err := r.args(&ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("%s: running %s with lanes %016b", name, r.Op, lanes.mask)
+		if err := executors[name](op, args, lanes); err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		for _, out := range formatOutputs(op, args) {
+			t.Logf("%s: %s", name, out)
+		}
+	}
+}
+
+func TestBCReproRoundTrip(t *testing.T) {
+	var ctx bctestContext
+	defer ctx.free()
+
+	ctx.setDict("abc")
+	inputS := ctx.sRegFromStrings([]string{"abc", "xabc", "ab", "", "abcd", "ABC"})
+	inputA := i64RegData{values: [bcLaneCount]int64{1, 2, 3, -4, math.MaxInt64}}
+	inputB := i64RegData{values: [bcLaneCount]int64{5, 6, 7, 8, 1}}
+	inputK := kRegData{mask: 0x3f}
+
+	for _, tc := range []struct {
+		op   bcop
+		args func() []any
+	}{
+		{
+			op: opContainsPrefixCs,
+			args: func() []any {
+				return []any{&sRegData{}, &kRegData{}, &inputS, 0, &inputK}
+			},
+		},
+		{
+			op: opaddmuli64imm,
+			args: func() []any {
+				return []any{&i64RegData{}, &kRegData{}, &inputA, &inputB, int64(-3), &inputK}
+			},
+		},
+	} {
+		t.Run(opinfo[tc.op].text, func(t *testing.T) {
+			want := tc.args()
+			r := ctx.repro(tc.op, want, inputK)
+			if err := ctx.executeOpcodeGo(tc.op, want, inputK); err != nil {
+				t.Fatal(err)
+			}
+			buf, err := json.Marshal(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var r2 bcRepro
+			if err := json.Unmarshal(buf, &r2); err != nil {
+				t.Fatal(err)
+			}
+
+			var ctx2 bctestContext
+			defer ctx2.free()
+			op, got, lanes, err := r2.args(&ctx2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if op != tc.op || lanes != inputK {
+				t.Fatalf("got op %s lanes %x", opinfo[op].text, lanes.mask)
+			}
+			if err := ctx2.executeOpcodeGo(op, got, lanes); err != nil {
+				t.Fatal(err)
+			}
+			for i := range opinfo[op].out {
+				switch w := want[i].(type) {
+				case *kRegData:
+					verifyKRegOutput(t, got[i].(*kRegData), w)
+				case *i64RegData:
+					verifyI64RegOutput(t, got[i].(*i64RegData), w)
+				case *sRegData:
+					// the outputs reference different buffers,
+					// so compare the strings rather than offsets
+					verifyStrings(t, sRegStrings(got[i].(*sRegData), lanes), sRegStrings(w, lanes))
+				}
+			}
+		})
+	}
+}
@@ -64,8 +64,9 @@ func appendZerosToUInt64Slice(slice []uint64, count int) []uint64 {
 //
 // This matches specification from bc_amd64.h
 type bctestContext struct {
-	data []byte   // SI = VIRT_BASE; the input buffer
-	dict []string // dictionary for bytecode
+	data    []byte   // SI = VIRT_BASE; the input buffer
+	dict    []string // dictionary for bytecode
+	scratch []byte   // scratch buffer of opcodes that produce strings
 }
 
 //go:noescape
@@ -76,6 +77,10 @@ func (c *bctestContext) free() {
 		Free(c.data)
 		c.data = nil
 	}
+	if c.scratch != nil {
+		Free(c.scratch)
+		c.scratch = nil
+	}
 }
 
 func (c *bctestContext) clear() {
@@ -218,6 +223,25 @@ func (c *bctestContext) setDict(value string) {
 // it deserializes content from virtual stack back to output arguments passed
 // in testArgs.
 func (c *bctestContext) executeOpcode(op bcop, testArgs []any, activeLanes kRegData) error {
+	return c.execute(op, testArgs, activeLanes, func(bc *bytecode) {
+		bctest_run_aux(bc, c, uint64(activeLanes.mask))
+	})
+}
+
+// executeOpcodeGo is executeOpcode that runs
+// the portable implementation of the opcode
+func (c *bctestContext) executeOpcodeGo(op bcop, testArgs []any, activeLanes kRegData) error {
+	if opinfo[op].portable == nil {
+		return fmt.Errorf("opcode %s has no portable implementation", opinfo[op].text)
+	}
+	return c.execute(op, testArgs, activeLanes, func(bc *bytecode) {
+		var alt bytecode
+		bc.vmState.validLanes = activeLanes
+		eval(bc, &alt, true)
+	})
+}
+
+func (c *bctestContext) execute(op bcop, testArgs []any, activeLanes kRegData, run func(bc *bytecode)) error {
 	info := &opinfo[op]
 
 	if len(info.in)+len(info.out) != len(testArgs) {
@@ -338,8 +362,18 @@ func (c *bctestContext) executeOpcode(op bcop, testArgs []any, activeLanes kRegD
 		dict:     c.dict,
 		vstack:   vStack,
 	}
+	if info.scratch > 0 {
+		if c.scratch == nil {
+			c.scratch = Malloc()
+		}
+		bc.scratch = c.scratch[:0]
+		bc.scratchoff, _ = vmdispl(c.scratch[:1])
+	}
 
-	bctest_run_aux(&bc, c, uint64(activeLanes.mask))
+	if *bcRecordFlag != "" {
+		defer c.record(op, testArgs, activeLanes)()
+	}
+	run(&bc)
 
 	if bc.err != 0 {
 		return fmt.Errorf("bytecode error: %s (%d)", bc.err.Error(), bc.err)
@@ -104,7 +104,7 @@ func vRegDataFromVStackCast(vstack *[]uint64, count int) (out []vRegData) {
 	outhdr := (*asmutils.SliceHeader)(unsafe.Pointer(&out))
 	outhdr.Data = hdr.Data
 	outhdr.Len = count
-	outhdr.Cap = hdr.Cap / vRegSize
+	outhdr.Cap = hdr.Cap * 8 / vRegSize
 	return
 }
 
@@ -0,0 +1,34 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"testing"
+)
+
+func TestVRegDataFromVStackCast(t *testing.T) {
+	const count = 4
+	vstack := make([]uint64, count*vRegSize/8)
+	out := vRegDataFromVStackCast(&vstack, count)
+	if len(out) != count || cap(out) != count {
+		t.Fatalf("len = %d, cap = %d; want %d", len(out), cap(out), count)
+	}
+	// the registers alias the stack
+	out = out[:count]
+	out[count-1].headerSize[bcLaneCount-1] = 0xff
+	if got := vstack[len(vstack)-1]; got == 0 {
+		t.Errorf("last register is not at the end of the stack: %x", got)
+	}
+}
@@ -216,9 +216,15 @@ func (b bcerr) Error() string {
 	}
 }
 
-func (b *bytecode) prepare(rp *rowParams) {
+// prepare prepares b for the evaluation of delims;
+// it also evaluates the scalar calls of b
+func (b *bytecode) prepare(rp *rowParams, delims []vmref) error {
 	b.auxvals = rp.auxbound
 	b.auxpos = 0
+	if b.scalars != nil {
+		return b.scalars.run(b, rp, delims)
+	}
+	return nil
 }
 
 type interpreterState struct {
@@ -275,6 +281,10 @@ type bytecode struct {
 	// currently only used by the interpreter to hold states that are otherwise
 	// passed / retrieved in registers
 	vmState interpreterState
+
+	// scalars evaluates the scalar calls
+	// of the program; see scalarCalls
+	scalars *scalarCaller
 }
 
 type bcFormatFlags uint
@@ -575,6 +585,9 @@ func (b *bytecode) allocStacks() {
 }
 
 func (b *bytecode) dropScratch() {
+	if b.scalars != nil {
+		b.scalars.dropScratch()
+	}
 	b.epoch = -1
 	b.scratch = nil
 	// this will trigger a fault if it is used:
@@ -599,5 +612,8 @@ func (b *bytecode) restoreScratch(st *symtab) {
 }
 
 func (b *bytecode) reset() {
+	if b.scalars != nil {
+		b.scalars.reset()
+	}
 	*b = bytecode{}
 }
@@ -171,6 +171,7 @@ var opinfo = [_maxbcop]bcopinfo{
 	opcmpeqvimm:               {text: "cmpeq.v@imm", out: bcargs[3:4] /* {bcK} */, in: bcargs[33:36] /* {bcV, bcLitRef, bcK} */},
 	opdateaddmonth:            {text: "dateaddmonth", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[1:4] /* {bcS, bcS, bcK} */},
 	opdateaddmonthimm:         {text: "dateaddmonth.imm", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[26:29] /* {bcS, bcImmI64, bcK} */},
+	opdateaddmonthclampimm:    {text: "dateaddmonthclamp.imm", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[26:29] /* {bcS, bcImmI64, bcK} */},
 	opdateaddyear:             {text: "dateaddyear", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[1:4] /* {bcS, bcS, bcK} */},
 	opdateaddquarter:          {text: "dateaddquarter", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[1:4] /* {bcS, bcS, bcK} */},
 	opdatebin:                 {text: "datebin", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[0:4] /* {bcImmI64, bcS, bcS, bcK} */},
@@ -228,6 +229,7 @@ var opinfo = [_maxbcop]bcopinfo{
 	opboxi64:                  {text: "box.i64", out: bcargs[9:10] /* {bcV} */, in: bcargs[2:4] /* {bcS, bcK} */, scratch: 9 * 16},
 	opboxk:                    {text: "box.k", out: bcargs[9:10] /* {bcV} */, in: bcargs[6:8] /* {bcK, bcK} */, scratch: 16},
 	opboxstr:                  {text: "box.str", out: bcargs[9:10] /* {bcV} */, in: bcargs[2:4] /* {bcS, bcK} */, scratch: PageSize},
+	opboxblob:                 {text: "box.blob", out: bcargs[9:10] /* {bcV} */, in: bcargs[2:4] /* {bcS, bcK} */, scratch: PageSize},
 	opboxlist:                 {text: "box.list", out: bcargs[9:10] /* {bcV} */, in: bcargs[2:4] /* {bcS, bcK} */, scratch: PageSize},
 	opmakelist:                {text: "makelist", out: bcargs[9:11] /* {bcV, bcK} */, in: bcargs[3:4] /* {bcK} */, va: bcargs[9:11] /* {bcV, bcK} */, scratch: PageSize},
 	opmakestruct:              {text: "makestruct", out: bcargs[9:11] /* {bcV, bcK} */, in: bcargs[3:4] /* {bcK} */, va: bcargs[82:85] /* {bcSymbolID, bcV, bcK} */, scratch: PageSize},
@@ -279,6 +281,7 @@ var opinfo = [_maxbcop]bcopinfo{
 	oparraysize:               {text: "arraysize", out: bcargs[1:2] /* {bcS} */, in: bcargs[2:4] /* {bcS, bcK} */},
 	oparrayposition:           {text: "arrayposition", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[66:69] /* {bcS, bcV, bcK} */},
 	oparraysum:                {text: "arraysum", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[2:4] /* {bcS, bcK} */},
+	oparrayslice:              {text: "arrayslice", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[26:29] /* {bcS, bcImmI64, bcK} */},
 	opvectorinnerproduct:      {text: "vectorinnerproduct", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[1:4] /* {bcS, bcS, bcK} */},
 	opvectorinnerproductimm:   {text: "bcvectorinnerproductimm", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[22:25] /* {bcS, bcDictSlot, bcK} */},
 	opvectorl1distance:        {text: "vectorl1distance", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[1:4] /* {bcS, bcS, bcK} */},
@@ -322,6 +325,7 @@ var opinfo = [_maxbcop]bcopinfo{
 	opContainsPatternCi:       {text: "contains_pattern_ci", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[22:25] /* {bcS, bcDictSlot, bcK} */},
 	opContainsPatternUTF8Ci:   {text: "contains_pattern_utf8_ci", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[22:25] /* {bcS, bcDictSlot, bcK} */},
 	opIsSubnetOfIP4:           {text: "is_subnet_of_ip4", out: bcargs[3:4] /* {bcK} */, in: bcargs[22:25] /* {bcS, bcDictSlot, bcK} */},
+	opIsSubnetOfIP6:           {text: "is_subnet_of_ip6", out: bcargs[3:4] /* {bcK} */, in: bcargs[22:25] /* {bcS, bcDictSlot, bcK} */},
 	opDfaT6:                   {text: "dfa_tiny6", out: bcargs[3:4] /* {bcK} */, in: bcargs[22:25] /* {bcS, bcDictSlot, bcK} */},
 	opDfaT7:                   {text: "dfa_tiny7", out: bcargs[3:4] /* {bcK} */, in: bcargs[22:25] /* {bcS, bcDictSlot, bcK} */},
 	opDfaT8:                   {text: "dfa_tiny8", out: bcargs[3:4] /* {bcK} */, in: bcargs[22:25] /* {bcS, bcDictSlot, bcK} */},
@@ -332,6 +336,12 @@ var opinfo = [_maxbcop]bcopinfo{
 	opAggTDigest:              {text: "aggtdigest.f64", in: bcargs[85:88] /* {bcAggSlot, bcS, bcK} */},
 	opslower:                  {text: "slower", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[2:4] /* {bcS, bcK} */, scratch: PageSize},
 	opsupper:                  {text: "supper", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[2:4] /* {bcS, bcK} */, scratch: PageSize},
+	optohex:                   {text: "tohex", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[9:11] /* {bcV, bcK} */, scratch: PageSize},
+	opfromhex:                 {text: "fromhex", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[2:4] /* {bcS, bcK} */, scratch: PageSize},
+	optobase64:                {text: "tobase64", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[58:61] /* {bcV, bcImmU16, bcK} */, scratch: PageSize},
+	opfrombase64:              {text: "frombase64", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[15:18] /* {bcS, bcImmU16, bcK} */, scratch: PageSize},
+	opstrreplace:              {text: "strreplace", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[43:47] /* {bcS, bcS, bcS, bcK} */, scratch: PageSize},
+	opSplitPartDfa:            {text: "split_part_dfa", out: bcargs[2:4] /* {bcS, bcK} */, in: bcargs[47:51] /* {bcS, bcDictSlot, bcS, bcK} */},
 	opaggapproxcount:          {text: "aggapproxcount", in: bcargs[29:33] /* {bcAggSlot, bcH, bcImmU16, bcK} */},
 	opaggslotapproxcount:      {text: "aggslotapproxcount", in: bcargs[99:104] /* {bcAggSlot, bcL, bcH, bcImmU16, bcK} */},
 	oppowuintf64:              {text: "powuint.f64", out: bcargs[1:2] /* {bcS} */, in: bcargs[26:29] /* {bcS, bcImmI64, bcK} */},
@@ -518,171 +528,181 @@ const (
 	opcmpeqvimm               bcop = 165
 	opdateaddmonth            bcop = 166
 	opdateaddmonthimm         bcop = 167
-	opdateaddyear             bcop = 168
-	opdateaddquarter          bcop = 169
-	opdatebin                 bcop = 170
-	opdatediffmicrosecond     bcop = 171
-	opdatediffparam           bcop = 172
-	opdatediffmqy             bcop = 173
-	opdateextractmicrosecond  bcop = 174
-	opdateextractmillisecond  bcop = 175
-	opdateextractsecond       bcop = 176
-	opdateextractminute       bcop = 177
-	opdateextracthour         bcop = 178
-	opdateextractday          bcop = 179
-	opdateextractdow          bcop = 180
-	opdateextractdoy          bcop = 181
-	opdateextractmonth        bcop = 182
-	opdateextractquarter      bcop = 183
-	opdateextractyear         bcop = 184
-	opdatetounixepoch         bcop = 185
-	opdatetounixmicro         bcop = 186
-	opdatetruncmillisecond    bcop = 187
-	opdatetruncsecond         bcop = 188
-	opdatetruncminute         bcop = 189
-	opdatetrunchour           bcop = 190
-	opdatetruncday            bcop = 191
-	opdatetruncdow            bcop = 192
-	opdatetruncmonth          bcop = 193
-	opdatetruncquarter        bcop = 194
-	opdatetruncyear           bcop = 195
-	opunboxts                 bcop = 196
-	opboxts                   bcop = 197
-	opwidthbucketf64          bcop = 198
-	opwidthbucketi64          bcop = 199
-	optimebucketts            bcop = 200
-	opgeohash                 bcop = 201
-	opgeohashimm              bcop = 202
-	opgeotilex                bcop = 203
-	opgeotiley                bcop = 204
-	opgeotilees               bcop = 205
-	opgeotileesimm            bcop = 206
-	opgeodistance             bcop = 207
-	opalloc                   bcop = 208
-	opconcatstr               bcop = 209
-	opfindsym                 bcop = 210
-	opfindsym2                bcop = 211
-	opblendv                  bcop = 212
-	opblendf64                bcop = 213
-	opunpack                  bcop = 214
-	opunsymbolize             bcop = 215
-	opunboxktoi64             bcop = 216
-	opunboxcoercef64          bcop = 217
-	opunboxcoercei64          bcop = 218
-	opunboxcvtf64             bcop = 219
-	opunboxcvti64             bcop = 220
-	opboxf64                  bcop = 221
-	opboxi64                  bcop = 222
-	opboxk                    bcop = 223
-	opboxstr                  bcop = 224
-	opboxlist                 bcop = 225
-	opmakelist                bcop = 226
-	opmakestruct              bcop = 227
-	ophashvalue               bcop = 228
-	ophashvalueplus           bcop = 229
-	ophashmember              bcop = 230
-	ophashlookup              bcop = 231
-	opaggandk                 bcop = 232
-	opaggork                  bcop = 233
-	opaggslotsumf             bcop = 234
-	opaggsumf                 bcop = 235
-	opaggsumi                 bcop = 236
-	opaggminf                 bcop = 237
-	opaggmini                 bcop = 238
-	opaggmaxf                 bcop = 239
-	opaggmaxi                 bcop = 240
-	opaggandi                 bcop = 241
-	opaggori                  bcop = 242
-	opaggxori                 bcop = 243
-	opaggcount                bcop = 244
-	opaggmergestate           bcop = 245
-	opaggbucket               bcop = 246
-	opaggslotandk             bcop = 247
-	opaggslotork              bcop = 248
-	opaggslotsumi             bcop = 249
-	opaggslotavgf             bcop = 250
-	opaggslotavgi             bcop = 251
-	opaggslotminf             bcop = 252
-	opaggslotmini             bcop = 253
-	opaggslotmaxf             bcop = 254
-	opaggslotmaxi             bcop = 255
-	opaggslotandi             bcop = 256
-	opaggslotori              bcop = 257
-	opaggslotxori             bcop = 258
-	opaggslotcount            bcop = 259
-	opaggslotcountv2          bcop = 260
-	opaggslotmergestate       bcop = 261
-	oplitref                  bcop = 262
-	opauxval                  bcop = 263
-	opsplit                   bcop = 264
-	optuple                   bcop = 265
-	opmovk                    bcop = 266
-	opzerov                   bcop = 267
-	opmovv                    bcop = 268
-	opmovvk                   bcop = 269
-	opmovf64                  bcop = 270
-	opmovi64                  bcop = 271
-	opobjectsize              bcop = 272
-	oparraysize               bcop = 273
-	oparrayposition           bcop = 274
-	oparraysum                bcop = 275
-	opvectorinnerproduct      bcop = 276
-	opvectorinnerproductimm   bcop = 277
-	opvectorl1distance        bcop = 278
-	opvectorl1distanceimm     bcop = 279
-	opvectorl2distance        bcop = 280
-	opvectorl2distanceimm     bcop = 281
-	opvectorcosinedistance    bcop = 282
-	opvectorcosinedistanceimm bcop = 283
-	opCmpStrEqCs              bcop = 284
-	opCmpStrEqCi              bcop = 285
-	opCmpStrEqUTF8Ci          bcop = 286
-	opCmpStrFuzzyA3           bcop = 287
-	opCmpStrFuzzyUnicodeA3    bcop = 288
-	opHasSubstrFuzzyA3        bcop = 289
-	opHasSubstrFuzzyUnicodeA3 bcop = 290
-	opSkip1charLeft           bcop = 291
-	opSkip1charRight          bcop = 292
-	opSkipNcharLeft           bcop = 293
-	opSkipNcharRight          bcop = 294
-	opTrimWsLeft              bcop = 295
-	opTrimWsRight             bcop = 296
-	opTrim4charLeft           bcop = 297
-	opTrim4charRight          bcop = 298
-	opoctetlength             bcop = 299
-	opcharlength              bcop = 300
-	opSubstr                  bcop = 301
-	opSplitPart               bcop = 302
-	opContainsPrefixCs        bcop = 303
-	opContainsPrefixCi        bcop = 304
-	opContainsPrefixUTF8Ci    bcop = 305
-	opContainsSuffixCs        bcop = 306
-	opContainsSuffixCi        bcop = 307
-	opContainsSuffixUTF8Ci    bcop = 308
-	opContainsSubstrCs        bcop = 309
-	opContainsSubstrCi        bcop = 310
-	opContainsSubstrUTF8Ci    bcop = 311
-	opEqPatternCs             bcop = 312
-	opEqPatternCi             bcop = 313
-	opEqPatternUTF8Ci         bcop = 314
-	opContainsPatternCs       bcop = 315
-	opContainsPatternCi       bcop = 316
-	opContainsPatternUTF8Ci   bcop = 317
-	opIsSubnetOfIP4           bcop = 318
-	opDfaT6                   bcop = 319
-	opDfaT7                   bcop = 320
-	opDfaT8                   bcop = 321
-	opDfaT6Z                  bcop = 322
-	opDfaT7Z                  bcop = 323
-	opDfaT8Z                  bcop = 324
-	opDfaLZ                   bcop = 325
-	opAggTDigest              bcop = 326
-	opslower                  bcop = 327
-	opsupper                  bcop = 328
-	opaggapproxcount          bcop = 329
-	opaggslotapproxcount      bcop = 330
-	oppowuintf64              bcop = 331
-	_maxbcop                       = 332
+	opdateaddmonthclampimm    bcop = 168
+	opdateaddyear             bcop = 169
+	opdateaddquarter          bcop = 170
+	opdatebin                 bcop = 171
+	opdatediffmicrosecond     bcop = 172
+	opdatediffparam           bcop = 173
+	opdatediffmqy             bcop = 174
+	opdateextractmicrosecond  bcop = 175
+	opdateextractmillisecond  bcop = 176
+	opdateextractsecond       bcop = 177
+	opdateextractminute       bcop = 178
+	opdateextracthour         bcop = 179
+	opdateextractday          bcop = 180
+	opdateextractdow          bcop = 181
+	opdateextractdoy          bcop = 182
+	opdateextractmonth        bcop = 183
+	opdateextractquarter      bcop = 184
+	opdateextractyear         bcop = 185
+	opdatetounixepoch         bcop = 186
+	opdatetounixmicro         bcop = 187
+	opdatetruncmillisecond    bcop = 188
+	opdatetruncsecond         bcop = 189
+	opdatetruncminute         bcop = 190
+	opdatetrunchour           bcop = 191
+	opdatetruncday            bcop = 192
+	opdatetruncdow            bcop = 193
+	opdatetruncmonth          bcop = 194
+	opdatetruncquarter        bcop = 195
+	opdatetruncyear           bcop = 196
+	opunboxts                 bcop = 197
+	opboxts                   bcop = 198
+	opwidthbucketf64          bcop = 199
+	opwidthbucketi64          bcop = 200
+	optimebucketts            bcop = 201
+	opgeohash                 bcop = 202
+	opgeohashimm              bcop = 203
+	opgeotilex                bcop = 204
+	opgeotiley                bcop = 205
+	opgeotilees               bcop = 206
+	opgeotileesimm            bcop = 207
+	opgeodistance             bcop = 208
+	opalloc                   bcop = 209
+	opconcatstr               bcop = 210
+	opfindsym                 bcop = 211
+	opfindsym2                bcop = 212
+	opblendv                  bcop = 213
+	opblendf64                bcop = 214
+	opunpack                  bcop = 215
+	opunsymbolize             bcop = 216
+	opunboxktoi64             bcop = 217
+	opunboxcoercef64          bcop = 218
+	opunboxcoercei64          bcop = 219
+	opunboxcvtf64             bcop = 220
+	opunboxcvti64             bcop = 221
+	opboxf64                  bcop = 222
+	opboxi64                  bcop = 223
+	opboxk                    bcop = 224
+	opboxstr                  bcop = 225
+	opboxblob                 bcop = 226
+	opboxlist                 bcop = 227
+	opmakelist                bcop = 228
+	opmakestruct              bcop = 229
+	ophashvalue               bcop = 230
+	ophashvalueplus           bcop = 231
+	ophashmember              bcop = 232
+	ophashlookup              bcop = 233
+	opaggandk                 bcop = 234
+	opaggork                  bcop = 235
+	opaggslotsumf             bcop = 236
+	opaggsumf                 bcop = 237
+	opaggsumi                 bcop = 238
+	opaggminf                 bcop = 239
+	opaggmini                 bcop = 240
+	opaggmaxf                 bcop = 241
+	opaggmaxi                 bcop = 242
+	opaggandi                 bcop = 243
+	opaggori                  bcop = 244
+	opaggxori                 bcop = 245
+	opaggcount                bcop = 246
+	opaggmergestate           bcop = 247
+	opaggbucket               bcop = 248
+	opaggslotandk             bcop = 249
+	opaggslotork              bcop = 250
+	opaggslotsumi             bcop = 251
+	opaggslotavgf             bcop = 252
+	opaggslotavgi             bcop = 253
+	opaggslotminf             bcop = 254
+	opaggslotmini             bcop = 255
+	opaggslotmaxf             bcop = 256
+	opaggslotmaxi             bcop = 257
+	opaggslotandi             bcop = 258
+	opaggslotori              bcop = 259
+	opaggslotxori             bcop = 260
+	opaggslotcount            bcop = 261
+	opaggslotcountv2          bcop = 262
+	opaggslotmergestate       bcop = 263
+	oplitref                  bcop = 264
+	opauxval                  bcop = 265
+	opsplit                   bcop = 266
+	optuple                   bcop = 267
+	opmovk                    bcop = 268
+	opzerov                   bcop = 269
+	opmovv                    bcop = 270
+	opmovvk                   bcop = 271
+	opmovf64                  bcop = 272
+	opmovi64                  bcop = 273
+	opobjectsize              bcop = 274
+	oparraysize               bcop = 275
+	oparrayposition           bcop = 276
+	oparraysum                bcop = 277
+	oparrayslice              bcop = 278
+	opvectorinnerproduct      bcop = 279
+	opvectorinnerproductimm   bcop = 280
+	opvectorl1distance        bcop = 281
+	opvectorl1distanceimm     bcop = 282
+	opvectorl2distance        bcop = 283
+	opvectorl2distanceimm     bcop = 284
+	opvectorcosinedistance    bcop = 285
+	opvectorcosinedistanceimm bcop = 286
+	opCmpStrEqCs              bcop = 287
+	opCmpStrEqCi              bcop = 288
+	opCmpStrEqUTF8Ci          bcop = 289
+	opCmpStrFuzzyA3           bcop = 290
+	opCmpStrFuzzyUnicodeA3    bcop = 291
+	opHasSubstrFuzzyA3        bcop = 292
+	opHasSubstrFuzzyUnicodeA3 bcop = 293
+	opSkip1charLeft           bcop = 294
+	opSkip1charRight          bcop = 295
+	opSkipNcharLeft           bcop = 296
+	opSkipNcharRight          bcop = 297
+	opTrimWsLeft              bcop = 298
+	opTrimWsRight             bcop = 299
+	opTrim4charLeft           bcop = 300
+	opTrim4charRight          bcop = 301
+	opoctetlength             bcop = 302
+	opcharlength              bcop = 303
+	opSubstr                  bcop = 304
+	opSplitPart               bcop = 305
+	opContainsPrefixCs        bcop = 306
+	opContainsPrefixCi        bcop = 307
+	opContainsPrefixUTF8Ci    bcop = 308
+	opContainsSuffixCs        bcop = 309
+	opContainsSuffixCi        bcop = 310
+	opContainsSuffixUTF8Ci    bcop = 311
+	opContainsSubstrCs        bcop = 312
+	opContainsSubstrCi        bcop = 313
+	opContainsSubstrUTF8Ci    bcop = 314
+	opEqPatternCs             bcop = 315
+	opEqPatternCi             bcop = 316
+	opEqPatternUTF8Ci         bcop = 317
+	opContainsPatternCs       bcop = 318
+	opContainsPatternCi       bcop = 319
+	opContainsPatternUTF8Ci   bcop = 320
+	opIsSubnetOfIP4           bcop = 321
+	opIsSubnetOfIP6           bcop = 322
+	opDfaT6                   bcop = 323
+	opDfaT7                   bcop = 324
+	opDfaT8                   bcop = 325
+	opDfaT6Z                  bcop = 326
+	opDfaT7Z                  bcop = 327
+	opDfaT8Z                  bcop = 328
+	opDfaLZ                   bcop = 329
+	opAggTDigest              bcop = 330
+	opslower                  bcop = 331
+	opsupper                  bcop = 332
+	optohex                   bcop = 333
+	opfromhex                 bcop = 334
+	optobase64                bcop = 335
+	opfrombase64              bcop = 336
+	opstrreplace              bcop = 337
+	opSplitPartDfa            bcop = 338
+	opaggapproxcount          bcop = 339
+	opaggslotapproxcount      bcop = 340
+	oppowuintf64              bcop = 341
+	_maxbcop                       = 342
 )
 
 type opreplace struct{ from, to bcop }
@@ -692,4 +712,4 @@ var patchAVX512Level2 []opreplace = []opreplace{
 	{from: opaggslotcountv2, to: opaggslotcount},
 }
 
-// checksum: b14d1d5a711062c44a41c305c22ce2f2
+// checksum: 5c5e6aff9a4a04656802d682509fac8c
@@ -0,0 +1,118 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"context"
+	"io"
+)
+
+// ContextSink returns a QuerySink that forwards
+// data to dst until ctx is done. Once ctx is done,
+// writes to the sink fail with ctx.Err(), which causes
+// the caller to stop producing data. If ctx can never
+// be canceled, dst is returned as-is.
+//
+// Writers returned from the sink are checked for
+// cancellation before each chunk of data is processed.
+func ContextSink(ctx context.Context, dst QuerySink) QuerySink {
+	if ctx == nil || ctx.Done() == nil {
+		return dst
+	}
+	return &ctxSink{ctx: ctx, dst: dst}
+}
+
+type ctxSink struct {
+	ctx context.Context
+	dst QuerySink
+}
+
+func (c *ctxSink) Open() (io.WriteCloser, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	w, err := c.dst.Open()
+	if err != nil {
+		return nil, err
+	}
+	// preserve the fast paths for *rowSplitter
+	// by wrapping the rowConsumer rather than the writer
+	if rs, ok := w.(*rowSplitter); ok {
+		cc := ctxConsumer{rowConsumer: rs.rowConsumer, ctx: c.ctx}
+		if zc, ok := rs.rowConsumer.(zionConsumer); ok {
+			rs.rowConsumer = &ctxZionConsumer{ctxConsumer: cc, zc: zc}
+		} else {
+			rs.rowConsumer = &cc
+		}
+		return rs, nil
+	}
+	return &ctxWriter{WriteCloser: w, ctx: c.ctx}, nil
+}
+
+func (c *ctxSink) Close() error { return c.dst.Close() }
+
+// ctxWriter is the io.WriteCloser returned
+// from ctxSink.Open for writers that do not
+// consume rows directly
+type ctxWriter struct {
+	io.WriteCloser
+	ctx context.Context
+}
+
+func (c *ctxWriter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.WriteCloser.Write(p)
+}
+
+// ctxConsumer is a rowConsumer that stops
+// accepting rows once ctx is done
+type ctxConsumer struct {
+	rowConsumer // inherit symbolize, next, Close
+	ctx         context.Context
+}
+
+func (c *ctxConsumer) writeRows(delims []vmref, params *rowParams) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.rowConsumer.writeRows(delims, params)
+}
+
+// EndSegment implements EndSegmentWriter.EndSegment
+func (c *ctxConsumer) EndSegment() {
+	if esw, ok := c.rowConsumer.(EndSegmentWriter); ok {
+		esw.EndSegment()
+	}
+}
+
+// ctxZionConsumer is a ctxConsumer for
+// rowConsumers that also implement zionConsumer
+type ctxZionConsumer struct {
+	ctxConsumer
+	zc zionConsumer
+}
+
+func (c *ctxZionConsumer) zionOk(fields []string) bool {
+	return c.zc.zionOk(fields)
+}
+
+func (c *ctxZionConsumer) writeZion(state *zionState) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.zc.writeZion(state)
+}
@@ -17,6 +17,7 @@ package vm
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 
 	"github.com/SnellerInc/sneller/ion"
 )
@@ -223,16 +224,14 @@ func compareIonValues(raw1, raw2 []byte,
 				x2 = ionParseFloat64(raw2)
 			}
 
-			if x1 < x2 {
-				return -1
-			} else if x1 > x2 {
-				return 1
-			}
-			return 0
+			return int(cmpf64(x1, x2))
 
 		case ion.TimestampType:
-			// same length and same fraction_exponent:
-			if len(raw1) == 13 && len(raw2) == 13 && raw1[9] == raw2[9] {
+			// same layout and same fraction_exponent:
+			// the bytes can be compared directly; otherwise
+			// the fractions have to be normalized first
+			if len(raw1) == 13 && len(raw2) == 13 && raw1[9] == raw2[9] &&
+				ionHasSimplifiedLayout(raw1) && ionHasSimplifiedLayout(raw2) {
 				return bytes.Compare(raw1, raw2)
 			}
 
@@ -261,6 +260,9 @@ func compareIonValues(raw1, raw2 []byte,
 			s1, _ := ion.Contents(raw1)
 			s2, _ := ion.Contents(raw2)
 			return int(bytes.Compare(s1, s2))
+
+		case ion.DecimalType:
+			return compareDecimals(raw1, raw2)
 		}
 	}
 
@@ -276,6 +278,9 @@ func compareIonValues(raw1, raw2 []byte,
 	}
 
 	// fallback to a generic runtime comparison (likely: mixed numeric, unlikely: bug)
+	if type1 == ion.DecimalType || type2 == ion.DecimalType {
+		return compareDecimals(raw1, raw2)
+	}
 	switch type1 {
 	case ion.IntType:
 		return compareNegintWithIonValue(ionParseIntMagnitude(raw1), raw2)
@@ -301,6 +306,33 @@ func compareIonValues(raw1, raw2 []byte,
 	}
 }
 
+// compareDecimals compares two numbers,
+// at least one of which is a decimal;
+// the comparison is exact unless the other
+// number is an infinite or NaN float
+func compareDecimals(raw1, raw2 []byte) int {
+	d1, _, err1 := ion.ReadDatum(nil, raw1)
+	d2, _, err2 := ion.ReadDatum(nil, raw2)
+	if err1 != nil || err2 != nil {
+		panic("Wrong Ion number encoding")
+	}
+	r1, err1 := d1.Rat()
+	r2, err2 := d2.Rat()
+	if err1 == nil && err2 == nil {
+		return r1.Cmp(r2)
+	}
+	return int(cmpf64(ratOrFloat(d1, r1), ratOrFloat(d2, r2)))
+}
+
+func ratOrFloat(d ion.Datum, r *big.Rat) float64 {
+	if r == nil {
+		f, _ := d.Float()
+		return f
+	}
+	f, _ := r.Float64()
+	return f
+}
+
 func boolFromLen(L byte) bool {
 	if L == ionBoolFalse {
 		return false
@@ -326,21 +358,9 @@ func compareZeroWithIonValue(raw2 []byte) int {
 
 	case ion.FloatType:
 		if L == ionFloat32 {
-			x := ionParseFloat32(raw2)
-			if 0.0 > x {
-				return 1
-			} else if 0.0 < x {
-				return -1
-			}
-			return 0
+			return int(cmpf64(0, float64(ionParseFloat32(raw2))))
 		} else if L == ionFloat64 {
-			x := ionParseFloat64(raw2)
-			if 0.0 > x {
-				return 1
-			} else if 0.0 < x {
-				return -1
-			}
-			return 0
+			return int(cmpf64(0, ionParseFloat64(raw2)))
 		} else if L == ionFloatPositiveZero {
 			return 0
 		}
@@ -379,13 +399,7 @@ func compareNegintWithIonValue(x uint64, raw2 []byte) int {
 			panic("Wrong Ion float encoding")
 		}
 
-		x := -float64(x)
-		if x < y {
-			return -1
-		} else if x > y {
-			return 1
-		}
-		return 0
+		return int(cmpf64(-float64(x), y))
 
 	default:
 		panic(fmt.Sprintf("Unsupported Ion type 0x%02x", T))
@@ -423,13 +437,7 @@ func comparePosintWithIonValue(x uint64, raw2 []byte) int {
 			panic("Wrong Ion float encoding")
 		}
 
-		x := float64(x)
-		if x < y {
-			return -1
-		} else if x > y {
-			return 1
-		}
-		return 0
+		return int(cmpf64(float64(x), y))
 
 	default:
 		panic(fmt.Sprintf("Unsupported Ion type 0x%02x", T))
@@ -469,11 +477,5 @@ func compareFloat64WithIonValue(x float64, raw2 []byte) int {
 		panic(fmt.Sprintf("Unsupported Ion type 0x%02x", T))
 	}
 
-	// compare numeric values
-	if x < y {
-		return -1
-	} else if x > y {
-		return 1
-	}
-	return 0
+	return int(cmpf64(x, y))
 }
@@ -336,6 +336,27 @@ func TestIonValueCompare(t *testing.T) {
 		testRelations(t, testcases)
 	}
 
+	// decimal
+	{
+		ionDecimalVal1p5 := []byte{0x52, 0xc1, 0x0f}        // 1.5
+		ionDecimalVal1p50 := []byte{0x53, 0xc2, 0x00, 0x96} // 1.50
+		ionDecimalValn2p5 := []byte{0x52, 0xc1, 0x99}       // -2.5
+		ionDecimalVal42 := []byte{0x52, 0x80, 0x2a}         // 42
+		testcases := []Testcase{
+			{ionDecimalVal1p5, ionDecimalVal1p50, 0},  // 1.5 == 1.50
+			{ionDecimalValn2p5, ionDecimalVal1p5, -1}, // -2.5 < 1.5
+			{ionDecimalVal1p5, ionDecimalValn2p5, 1},  // 1.5 > -2.5
+			{ionDecimalVal42, ionPosintVal42, 0},      // 42d0 == 42
+			{ionPosintVal42, ionDecimalVal1p5, 1},     // 42 > 1.5
+			{ionNegintVal81, ionDecimalValn2p5, -1},   // -81 < -2.5
+			{ionDecimalVal1p5, ionFloat64Valp1, 1},    // 1.5 > float64(1.0)
+			{ionFloat32Valp10, ionDecimalVal1p5, 1},   // float32(10.0) > 1.5
+			{ionNull, ionDecimalVal1p5, -1},           // null < 1.5
+			{ionDecimalVal1p5, ionStrCat, -1},         // 1.5 < "cat"
+		}
+
+		testRelations(t, testcases)
+	}
 }
 
 func testRelations(t *testing.T, testcases []Testcase) {
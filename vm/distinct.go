@@ -68,16 +68,16 @@ func NewDistinct(on []expr.Node, dst QuerySink) (*DistinctFilter, error) {
 	p.begin()
 	var hash, pred *value
 	for i := range on {
-		val, err := p.serialized(on[i])
+		_, key, err := p.groupKey(on[i])
 		if err != nil {
 			return nil, err
 		}
 		if hash == nil {
-			pred = p.mask(val)
-			hash = p.hash(val)
+			pred = p.mask(key)
+			hash = p.hash(key)
 		} else {
-			pred = p.and(pred, p.mask(val))
-			hash = p.hashplus(hash, val)
+			pred = p.and(pred, p.mask(key))
+			hash = p.hashplus(hash, key)
 		}
 	}
 	// the final state of the bytecode will be
@@ -178,7 +178,9 @@ func (d *deduper) writeRows(delims []vmref, rp *rowParams) error {
 	} else {
 		d.hashes = make([]uint64, len(delims))
 	}
-	d.bc.prepare(rp)
+	if err := d.bc.prepare(rp, delims); err != nil {
+		return err
+	}
 	var count int
 	if globalOptimizationLevel >= OptimizationLevelAVX512V1 {
 		count = evaldedup(&d.bc, delims, d.hashes, d.local, d.hashslot)
@@ -2249,11 +2249,16 @@ next:
 //
 // We don't really need a specific code for adding years, as `year == month * 12`. This
 // means that we can just convert years to months and add `year * 12` months and be done.
+//
+// The `dateaddmonthclamp.imm` variant clamps the day of the month to the last day of the
+// resulting month (INTERVAL arithmetic), whereas the other variants let the days that
+// don't fit the resulting month overflow into the next month (DATE_ADD).
 
 // ts[0].k[1] = dateaddmonth(ts[2], i64[3]).k[4]
 TEXT bcdateaddmonth(SB), NOSPLIT|NOFRAME, $0
   BC_UNPACK_5xSLOT(0, OUT(DX), OUT(R15), OUT(BX), OUT(CX), OUT(R8))
   BC_LOAD_I64_FROM_SLOT(OUT(Z20), OUT(Z21), IN(CX))
+  XORL R11, R11
 
   ADDQ $(BC_SLOT_SIZE*5), VIRT_PCREG
   JMP dateaddmonth_tail(SB)
@@ -2262,6 +2267,16 @@ TEXT bcdateaddmonth(SB), NOSPLIT|NOFRAME, $0
 TEXT bcdateaddmonthimm(SB), NOSPLIT|NOFRAME, $0
   BC_UNPACK_3xSLOT_ZI64_SLOT(0, OUT(DX), OUT(R15), OUT(BX), OUT(Z20), OUT(R8))
   VMOVDQA64 Z20, Z21
+  XORL R11, R11
+
+  ADDQ $(BC_SLOT_SIZE*4+8), VIRT_PCREG
+  JMP dateaddmonth_tail(SB)
+
+// ts[0].k[1] = dateaddmonthclamp.imm(ts[2], i64@imm[3]).k[4]
+TEXT bcdateaddmonthclampimm(SB), NOSPLIT|NOFRAME, $0
+  BC_UNPACK_3xSLOT_ZI64_SLOT(0, OUT(DX), OUT(R15), OUT(BX), OUT(Z20), OUT(R8))
+  VMOVDQA64 Z20, Z21
+  MOVL $1, R11
 
   ADDQ $(BC_SLOT_SIZE*4+8), VIRT_PCREG
   JMP dateaddmonth_tail(SB)
@@ -2277,6 +2292,7 @@ TEXT bcdateaddyear(SB), NOSPLIT|NOFRAME, $0
   VPSRLQ $1, Z21, Z5
   VPADDQ Z4, Z20, Z20
   VPADDQ Z5, Z21, Z21
+  XORL R11, R11
 
   ADDQ $(BC_SLOT_SIZE*5), VIRT_PCREG
   JMP dateaddmonth_tail(SB)
@@ -2291,6 +2307,7 @@ TEXT bcdateaddquarter(SB), NOSPLIT|NOFRAME, $0
   VPSLLQ $1, Z21, Z5
   VPADDQ Z4, Z20, Z20
   VPADDQ Z5, Z21, Z21
+  XORL R11, R11
 
   ADDQ $(BC_SLOT_SIZE*5), VIRT_PCREG
   JMP dateaddmonth_tail(SB)
@@ -2310,12 +2327,33 @@ TEXT bcdateaddquarter(SB), NOSPLIT|NOFRAME, $0
     CONSTF64_MICROSECONDS_IN_1_DAY_SHR_13() = 10546875
 */
 
+// A DWORD table designed for VPERMD that can be used to map months starting from March
+// (plus one) into the last day of the month (starting from zero), excluding leap day.
+CONST_DATA_U32(last_day_of_month_from_march,  0, $0)  // Zero index is unused
+CONST_DATA_U32(last_day_of_month_from_march,  4, $30) // March
+CONST_DATA_U32(last_day_of_month_from_march,  8, $29) // April
+CONST_DATA_U32(last_day_of_month_from_march, 12, $30) // May
+CONST_DATA_U32(last_day_of_month_from_march, 16, $29) // June
+CONST_DATA_U32(last_day_of_month_from_march, 20, $30) // July
+CONST_DATA_U32(last_day_of_month_from_march, 24, $30) // August
+CONST_DATA_U32(last_day_of_month_from_march, 28, $29) // September
+CONST_DATA_U32(last_day_of_month_from_march, 32, $30) // October
+CONST_DATA_U32(last_day_of_month_from_march, 36, $29) // November
+CONST_DATA_U32(last_day_of_month_from_march, 40, $30) // December
+CONST_DATA_U32(last_day_of_month_from_march, 44, $30) // January
+CONST_DATA_U32(last_day_of_month_from_march, 48, $27) // February
+CONST_DATA_U32(last_day_of_month_from_march, 52, $0)
+CONST_DATA_U32(last_day_of_month_from_march, 56, $0)
+CONST_DATA_U32(last_day_of_month_from_march, 60, $0)
+CONST_GLOBAL(last_day_of_month_from_march, $64)
+
 // Tail instruction implementing DATE_ADD(MONTH, interval, timestamp).
 //
 // Inputs:
 //   BX      - timestamp value slot
 //   R8      - predicate slot
 //   Z20/Z21 - number of months to add
+//   R11     - non-zero to clamp the day of the month to the last day of the month
 TEXT dateaddmonth_tail(SB), NOSPLIT|NOFRAME, $0
   BC_LOAD_K1_K2_FROM_SLOT(OUT(K1), OUT(K2), IN(R8))
   BC_LOAD_I64_FROM_SLOT(OUT(Z2), OUT(Z3), IN(BX))
@@ -2368,6 +2406,43 @@ TEXT dateaddmonth_tail(SB), NOSPLIT|NOFRAME, $0
   VPSUBQ Z12, Z10, Z10
   VPSUBQ Z13, Z11, Z11
 
+  TESTL R11, R11
+  JZ compose
+
+  // --- Clamp the day of the month ---
+
+  // Z16/Z17 <- Last day of the month [0, 30], excluding leap day.
+  VMOVDQU64 CONST_GET_PTR(last_day_of_month_from_march, 0), Z18
+  VPADDQ.BCST CONSTQ_1(), Z10, Z12
+  VPADDQ.BCST CONSTQ_1(), Z11, Z13
+  VPERMD Z18, Z12, Z16
+  VPERMD Z18, Z13, Z17
+
+  // Z6/Z7 <- Number of days of the year (it ends with February, so this is 366 in leap years).
+  VPADDQ.BCST CONSTQ_1(), Z8, Z18
+  VPADDQ.BCST CONSTQ_1(), Z9, Z19
+  VPXORQ Z6, Z6, Z6
+  VPXORQ Z7, Z7, Z7
+  BC_COMPOSE_YEAR_TO_DAYS(Z6, Z7, Z18, Z19, Z22, Z23, Z24, Z25, Z26, Z27)
+  VPXORQ Z12, Z12, Z12
+  VPXORQ Z13, Z13, Z13
+  BC_COMPOSE_YEAR_TO_DAYS(Z12, Z13, Z8, Z9, Z22, Z23, Z24, Z25, Z26, Z27)
+  VPSUBQ Z12, Z6, Z6
+  VPSUBQ Z13, Z7, Z7
+
+  // Z16/Z17 <- Last day of the month, including leap day (February is the 11th month).
+  VPCMPEQQ.BCST CONSTQ_11(), Z10, K3
+  VPCMPEQQ.BCST CONSTQ_11(), Z11, K4
+  VPSUBQ.BCST CONSTQ_365(), Z6, Z6
+  VPSUBQ.BCST CONSTQ_365(), Z7, Z7
+  VPADDQ Z6, Z16, K3, Z16
+  VPADDQ Z7, Z17, K4, Z17
+
+  // Z14/Z15 <- Day of the month clamped to the last day of the month.
+  VPMINUQ Z16, Z14, Z14
+  VPMINUQ Z17, Z15, Z15
+
+compose:
   // --- Compose the timestamp ---
 
   // Z6/Z7 <- Number of days of the last year (months + day of month).
@@ -3247,6 +3322,25 @@ TEXT bcdatetruncyear(SB), NOSPLIT|NOFRAME, $0
   BC_STORE_I64_TO_SLOT(IN(Z2), IN(Z3), IN(DX))
   NEXT_ADVANCE(BC_SLOT_SIZE*3)
 
+// Powers of 10 from 10^0 to 10^15.
+CONST_DATA_U64(consts_pow10_q, 0, $1)
+CONST_DATA_U64(consts_pow10_q, 8, $10)
+CONST_DATA_U64(consts_pow10_q, 16, $100)
+CONST_DATA_U64(consts_pow10_q, 24, $1000)
+CONST_DATA_U64(consts_pow10_q, 32, $10000)
+CONST_DATA_U64(consts_pow10_q, 40, $100000)
+CONST_DATA_U64(consts_pow10_q, 48, $1000000)
+CONST_DATA_U64(consts_pow10_q, 56, $10000000)
+CONST_DATA_U64(consts_pow10_q, 64, $100000000)
+CONST_DATA_U64(consts_pow10_q, 72, $1000000000)
+CONST_DATA_U64(consts_pow10_q, 80, $10000000000)
+CONST_DATA_U64(consts_pow10_q, 88, $100000000000)
+CONST_DATA_U64(consts_pow10_q, 96, $1000000000000)
+CONST_DATA_U64(consts_pow10_q, 104, $10000000000000)
+CONST_DATA_U64(consts_pow10_q, 112, $100000000000000)
+CONST_DATA_U64(consts_pow10_q, 120, $1000000000000000)
+CONST_GLOBAL(consts_pow10_q, $128)
+
 // ts[0].k[1] = unboxts(v[2]).k[3]
 TEXT bcunboxts(SB), NOSPLIT|NOFRAME, $0
   BC_UNPACK_2xSLOT(BC_SLOT_SIZE*2, OUT(BX), OUT(R8))
@@ -3281,8 +3375,10 @@ TEXT bcunboxts(SB), NOSPLIT|NOFRAME, $0
   VPBROADCASTD CONSTD_8(), Z23
 
   // Z4/Z5 <- First 8 bytes of the timestamp cleared so only bytes that
-  //          are within the length are non-zero, other bytes cleared.
-  VPMINUD.Z Z23, Z3, K1, Z16
+  //          are within the length are non-zero, other bytes cleared
+  //          (the gathered bytes follow the timezone offset byte).
+  VPSUBD.BCST CONSTD_1(), Z3, Z16
+  VPMINUD.Z Z23, Z16, K1, Z16
   VPSUBD.Z Z16, Z23, K1, Z16
   VPSLLD $3, Z16, Z16
   VEXTRACTI32X8 $1, Z16, Y17
@@ -3303,7 +3399,7 @@ TEXT bcunboxts(SB), NOSPLIT|NOFRAME, $0
   VPSRLQ $8, Z4, Z4
   VPSRLQ $8, Z5, Z5
 
-  // KUNPCKBW K3, K4, K5
+  KUNPCKBW K3, K4, K5                              // K5 <- Lanes having a year of at least 2 bytes
   VPSLLQ $7, Z6, K3, Z6
   VPSLLQ $7, Z7, K4, Z7
   VPTERNLOGQ $TLOG_BLEND_BA, Z20, Z4, K3, Z6
@@ -3313,6 +3409,7 @@ TEXT bcunboxts(SB), NOSPLIT|NOFRAME, $0
 
   VPTESTNMQ Z21, Z4, K3, K3
   VPTESTNMQ Z21, Z5, K4, K4
+  KUNPCKBW K3, K4, K6                              // K6 <- Lanes having a year of 3 bytes
   VPSLLQ $7, Z6, K3, Z6
   VPSLLQ $7, Z7, K4, Z7
   VPTERNLOGQ $TLOG_BLEND_BA, Z20, Z4, K3, Z6
@@ -3351,11 +3448,89 @@ TEXT bcunboxts(SB), NOSPLIT|NOFRAME, $0
   VPMADDWD Z19, Z4, Z4
   VPMADDWD Z19, Z5, Z5
 
-  // Z18 <- Load last 4 bytes of the timestamp if it contains microseconds.
-  VPCMPD.BCST $VPCMP_IMM_GT, CONSTD_10(), Z3, K1, K3
-  VPADDD Z2, Z3, Z19
-  VPXORD X18, X18, X18
-  VPGATHERDD (-4)(VIRT_BASE)(Z19*1), K3, Z18
+  // Z18/Z19 <- Fractional seconds converted to microseconds (zero if there are none).
+  //
+  // The fraction_exponent follows the seconds, which are at [6 + year length], and it's
+  // followed by the coefficient till the end of the timestamp. The fraction is normalized
+  // to microseconds regardless of the precision it was stored with (digits that follow
+  // microseconds are truncated). Fractions having more than 8 bytes or more than 15 digits
+  // are ignored.
+  VPBROADCASTD CONSTD_7(), Z16
+  VPADDD.BCST CONSTD_1(), Z16, K5, Z16
+  VPADDD.BCST CONSTD_1(), Z16, K6, Z16             // Z16 <- offset of the fraction_exponent
+  VPSUBD Z16, Z3, Z17                              // Z17 <- length of fraction_exponent + coefficient
+  VPCMPD.BCST $VPCMP_IMM_GT, CONSTD_0(), Z17, K1, K3
+  VPCMPD.BCST $VPCMP_IMM_LE, CONSTD_8(), Z17, K3, K3 // K3 <- Lanes that contain a fraction we can handle
+
+  // Z18/Z19 <- Last 8 bytes of the timestamp, byte-swapped so the last byte is the least significant.
+  VPADDD Z2, Z3, Z24
+  VEXTRACTI32X8 $1, Z24, Y25
+  KMOVB K3, K4
+  KSHIFTRW $8, K3, K5
+  VPXORQ X18, X18, X18
+  VPXORQ X19, X19, X19
+  VPGATHERDQ (-8)(VIRT_BASE)(Y24*1), K4, Z18
+  VPGATHERDQ (-8)(VIRT_BASE)(Y25*1), K5, Z19
+  VPSHUFB CONST_GET_PTR(bswap64, 0), Z18, Z18
+  VPSHUFB CONST_GET_PTR(bswap64, 0), Z19, Z19
+
+  // Z16/Z17 <- Number of bits of the coefficient (zero in lanes without a fraction).
+  VPSLLD $3, Z17, Z17
+  VPSUBD.BCST.Z CONSTD_8(), Z17, K3, Z17
+  VEXTRACTI32X8 $1, Z17, Y16
+  VPMOVZXDQ Y17, Z17
+  VPMOVZXDQ Y16, Z16
+
+  // Z24/Z25 <- fraction_exponent byte.
+  VPSRLVQ Z17, Z18, Z24
+  VPSRLVQ Z16, Z19, Z25
+  VPANDQ.BCST CONSTQ_0xFF(), Z24, Z24
+  VPANDQ.BCST CONSTQ_0xFF(), Z25, Z25
+
+  // K3/K4 <- Lanes that have a negative single byte exponent (0xC0 | -exponent) of at most 15.
+  VPCMPUQ.BCST $VPCMP_IMM_GE, CONSTQ_0xC0(), Z24, K3
+  VPCMPUQ.BCST $VPCMP_IMM_GE, CONSTQ_0xC0(), Z25, K4
+  VPANDQ.BCST CONSTQ_0x3F(), Z24, Z24
+  VPANDQ.BCST CONSTQ_0x3F(), Z25, Z25
+  VPCMPUQ.BCST $VPCMP_IMM_LE, CONSTQ_15(), Z24, K3, K3
+  VPCMPUQ.BCST $VPCMP_IMM_LE, CONSTQ_15(), Z25, K4, K4
+
+  // Z18/Z19 <- Coefficient (the sign bit and the bytes that precede it are cleared).
+  VPBROADCASTQ CONSTQ_65(), Z26
+  VPSUBQ Z17, Z26, Z17
+  VPSUBQ Z16, Z26, Z16
+  VPSLLVQ Z17, Z18, Z18
+  VPSLLVQ Z16, Z19, Z19
+  VPSRLVQ Z17, Z18, Z18
+  VPSRLVQ Z16, Z19, Z19
+
+  // Z18/Z19 <- Coefficient multiplied by 10^(6 - digits) if there are at most 6 digits.
+  VPBROADCASTQ CONSTQ_6(), Z26
+  VMOVDQU64 CONST_GET_PTR(consts_pow10_q, 0), Z27
+  VPSUBQ Z24, Z26, Z28
+  VPSUBQ Z25, Z26, Z29
+  VPMAXSQ.BCST CONSTQ_0(), Z28, Z28
+  VPMAXSQ.BCST CONSTQ_0(), Z29, Z29
+  VPERMI2Q CONST_GET_PTR(consts_pow10_q, 64), Z27, Z28
+  VPERMI2Q CONST_GET_PTR(consts_pow10_q, 64), Z27, Z29
+  VPMULLQ Z28, Z18, Z18
+  VPMULLQ Z29, Z19, Z19
+
+  // Z18/Z19 <- Coefficient divided by 10^(digits - 6) if there are more than 6 digits.
+  VPSUBQ Z26, Z24, Z28
+  VPSUBQ Z26, Z25, Z29
+  VPMAXSQ.BCST CONSTQ_0(), Z28, Z28
+  VPMAXSQ.BCST CONSTQ_0(), Z29, Z29
+  VPERMI2Q CONST_GET_PTR(consts_pow10_q, 64), Z27, Z28
+  VPERMI2Q CONST_GET_PTR(consts_pow10_q, 64), Z27, Z29
+  VCVTUQQ2PD Z28, Z28
+  VCVTUQQ2PD Z29, Z29
+  VCVTUQQ2PD Z18, Z18
+  VCVTUQQ2PD Z19, Z19
+  VDIVPD Z28, Z18, Z18
+  VDIVPD Z29, Z19, Z19
+  VCVTTPD2UQQ.Z Z18, K3, Z18
+  VCVTTPD2UQQ.Z Z19, K4, Z19
 
   // Z8/Z9 <- Month - 3.
   VPSUBQ.BCST CONSTQ_3(), Z8, Z8
@@ -3387,9 +3562,6 @@ TEXT bcunboxts(SB), NOSPLIT|NOFRAME, $0
   // Z8/Z9 <- Number of days of all years, including days in the last month.
   BC_COMPOSE_YEAR_TO_DAYS(Z8, Z9, Z6, Z7, Z10, Z11, Z12, Z13, Z14, Z15)
 
-  // Z18 <- Convert last 4 bytes of the timestamp to microseconds (it's either a value or zero).
-  VPSHUFB CONST_GET_PTR(bswap24_zero_last_byte, 0), Z18, Z18
-
   VPBROADCASTQ CONSTQ_86400000000(), Z25
   VPBROADCASTQ CONSTQ_1000000(), Z26
   VPBROADCASTQ CONSTQ_1970_01_01_TO_0000_03_01_US_OFFSET(), Z27
@@ -3399,13 +3571,10 @@ TEXT bcunboxts(SB), NOSPLIT|NOFRAME, $0
   VPMULLQ Z25, Z9, Z9
 
   // Z8/Z9 <- Combined microseconds of all days and microseconds of the remaining day.
-  VEXTRACTI32X8 $1, Z18, Y19
   VPMULLQ Z26, Z4, Z4
   VPMULLQ Z26, Z5, Z5
   BC_UNPACK_2xSLOT(0, OUT(DX), OUT(R8))
 
-  VPMOVZXDQ Y18, Z18
-  VPMOVZXDQ Y19, Z19
   VPADDQ Z4, Z8, Z8
   VPADDQ Z5, Z9, Z9
   VPADDQ Z18, Z8, Z8
@@ -3538,10 +3707,10 @@ TEXT bcboxts(SB), NOSPLIT|NOFRAME, $0
   //   - Second [0, 59] (one byte)
   //   - Microsecond [0, 999999] (1 byte for fraction_exponent 0xC6, 3 bytes for coefficient - UInt)
 
-  // Z8/Z9 - Hour [0, 23].
-  VPSRLQ $12, Z4, Z8
-  VPSRLQ $12, Z5, Z9
-  BC_DIV_U64_WITH_CONST_RECIPROCAL_BCST(Z8, Z9, Z8, Z9, CONSTQ_2562048517(), 51)
+  // Z8/Z9 - Hour [0, 23] (3600000000 is only divisible by 2^10, so don't pre-shift by more).
+  VPSRLQ $10, Z4, Z8
+  VPSRLQ $10, Z5, Z9
+  BC_DIV_U64_WITH_CONST_RECIPROCAL_BCST(Z8, Z9, Z8, Z9, CONSTQ_40031997(), 47)
 
   // Z4/Z5 - (Minutes * 60000000) + (Second * 1000000) + Microseconds.
   VPMULLQ.BCST CONSTQ_3600000000(), Z8, Z12
@@ -5106,6 +5275,10 @@ TEXT bcunsymbolize(SB), NOSPLIT|NOFRAME, $0
   KMOVW K2, K3
   BC_LOAD_VALUE_SLICE_FROM_SLOT(OUT(Z0), OUT(Z1), IN(BX))
   BC_LOAD_VALUE_HLEN_FROM_SLOT(OUT(Z3), IN(BX))
+  VMOVDQA32.Z Z0, K1, Z0                         // zero the inactive lanes, so that
+  VMOVDQA32.Z Z1, K1, Z1                         // the output is valid to store
+  VMOVDQA32.Z Z2, K1, Z2                         // directly into an output slot
+  VMOVDQA32.Z Z3, K1, Z3
   JZ next
 
   VPGATHERDD 1(VIRT_BASE)(Z0*1), K3, Z6          // Z6 <- SymbolID bytes (without TLV, which was skipped)
@@ -5693,6 +5866,13 @@ TEXT bcboxstr(SB), NOSPLIT|NOFRAME, $0
   VPSLLD.BCST $4, CONSTD_8(), Z20 // ION type of a boxed string is 0x8
   JMP boxslice_tail(SB)
 
+// v[0] = box.blob(slice[1]).k[2]
+//
+// scratch: PageSize
+TEXT bcboxblob(SB), NOSPLIT|NOFRAME, $0
+  VPSLLD.BCST $4, CONSTD_0x0A(), Z20 // ION type of a boxed blob is 0xA
+  JMP boxslice_tail(SB)
+
 // v[0] = box.list(slice[1]).k[2]
 //
 // scratch: PageSize
@@ -8320,6 +8500,143 @@ done:
 
   NEXT_ADVANCE(BC_SLOT_SIZE*4)
 
+// Calculates the size of the ion values at offsets Z2 of the lanes in K2
+// and stores it to Z12 (clobbers Z5-Z9, Z13, Z14, and K3); the values are
+// the elements of a list, so they are never NOP pads nor annotations.
+//
+// Constants:
+//   Z22 <- dword(1)
+//   Z23 <- dword(14)
+//   Z24 <- dword(0xF)
+//   Z25 <- dword(32)
+//   Z26 <- dword(0x7F)
+//   Z27 <- dword(0x00808080)
+//   Z30 <- bswap32 predicate for VPSHUFB
+#define BC_ARRAY_ELEMENT_SIZE()                                                         \
+  KMOVW K2, K3                                                                          \
+  VPXORD X5, X5, X5                                                                     \
+  VPGATHERDD 0(VIRT_BASE)(Z2*1), K3, Z5                /* Z5 <- first 4 bytes       */ \
+  VPSHUFB Z30, Z5, Z5                                  /* Z5 <- bswap32(bytes)      */ \
+  VPSRLD $24, Z5, Z9                                   /* Z9 <- Type|L byte         */ \
+  VPANDD Z27, Z5, Z6                                   /* Z6 <- bytes & 0x00808080  */ \
+  VPANDND Z5, Z27, Z7                                  /* Z7 <- bytes & 0xFF7F7F7F  */ \
+  VPCMPUD $VPCMP_IMM_GE, Z25, Z9, K2, K3               /* K3 <- Type != NULL|BOOL   */ \
+  VPLZCNTD Z6, Z6                                      /* Z6 <- lzcnt32(Z6)         */ \
+  VPANDD.Z Z24, Z9, K3, Z8                             /* Z8 <- L or 0 (NULL|BOOL)  */ \
+  VPSLLD $8, Z7, Z7                                    /* Z7 <- Z7 << 8             */ \
+  VPCMPEQD Z23, Z8, K2, K3                             /* K3 <- lanes where L == 14 */ \
+  VPSUBD Z6, Z25, Z13                                  /* Z13 <- bits to discard    */ \
+  VPSRLD.Z $3, Z6, K3, Z12                             /* Z12 <- size of Length     */ \
+  VPSRLVD Z13, Z7, K3, Z8                              /* Z8 <- Length data         */ \
+  VPADDD Z22, Z12, Z12                                 /* Z12 <- header length      */ \
+  VPSRLD $1, Z8, Z13                                                                    \
+  VPSRLD $2, Z8, Z14                                                                    \
+  VPTERNLOGD $TLOG_BLEND_AB, Z26, Z13, Z8                                               \
+  VPTERNLOGD.BCST $TLOG_BLEND_AB, CONSTD_0x3FFF(), Z14, Z8 /* Z8 <- Length          */ \
+  VPADDD Z8, Z12, Z12                                  /* Z12 <- value length       */
+
+// s[0].k[1] = arrayslice(s[2], i64@imm[3]).k[4]
+//
+// Take the list slice in s[2] and put the slice of its elements
+// selected by the immediate in s[0]; the low 32 bits of the immediate
+// are the offset of the first element and the high 32 bits are the
+// offset past the last element (or 0x7FFFFFFF for the end of the list).
+// Negative offsets count from the end of the list, which is the only
+// case where the whole list is scanned. Lanes where an offset is out of
+// range are MISSING and the slice is empty when the first offset is not
+// less than the second one.
+TEXT bcarrayslice(SB), NOSPLIT|NOFRAME, $0
+  BC_UNPACK_SLOT(BC_SLOT_SIZE*2, OUT(BX))
+  BC_UNPACK_SLOT(BC_SLOT_SIZE*3 + BC_IMM64_SIZE, OUT(R8))
+  BC_LOAD_SLICE_FROM_SLOT(OUT(Z0), OUT(Z1), IN(BX))
+  BC_LOAD_K1_FROM_SLOT(OUT(K1), IN(R8))
+
+  BC_UNPACK_RU64(BC_SLOT_SIZE*3, OUT(CX))
+  MOVQ CX, DX
+  SHRQ $32, DX
+  VPBROADCASTD CX, Z20                                 // Z20 <- offset of the first element
+  VPBROADCASTD DX, Z21                                 // Z21 <- offset past the last element
+
+  VPADDD Z0, Z1, Z1                                    // Z1 <- end of the list
+  VPBROADCASTD CONSTD_1(), Z22                         // Z22 <- dword(1)
+  VPBROADCASTD CONSTD_14(), Z23                        // Z23 <- dword(14)
+  VPBROADCASTD CONSTD_0x0F(), Z24                      // Z24 <- dword(0xF)
+  VPBROADCASTD CONSTD_32(), Z25                        // Z25 <- dword(32)
+  VPBROADCASTD CONSTD_0x7F(), Z26                      // Z26 <- dword(0x7F)
+  VPBROADCASTD CONSTD_0x00808080(), Z27                // Z27 <- dword(0x808080)
+  VBROADCASTI32X4 CONST_GET_PTR(bswap32, 0), Z30       // Z30 <- bswap32 predicate for VPSHUFB
+  VPXORD X3, X3, X3                                    // Z3 <- dword(0)
+
+  CMPL DX, $0x7FFFFFFF
+  JEQ count
+  MOVL CX, BX
+  ORL DX, BX
+  JNS walk                                             // both offsets are relative to the start
+
+count:
+  VPXORD X17, X17, X17                                 // Z17 <- number of elements
+  VMOVDQA32 Z0, Z2                                     // Z2 <- offset of the current element
+  VPCMPUD $VPCMP_IMM_LT, Z1, Z2, K1, K2                // K2 <- lanes having more elements
+  KTESTW K2, K2
+  JZ count_done
+
+count_loop:
+  BC_ARRAY_ELEMENT_SIZE()
+  VPADDD Z12, Z2, K2, Z2                               // Z2 <- advance to the next element
+  VPADDD Z22, Z17, K2, Z17                             // Z17 <- increment the number of elements
+  VPCMPUD $VPCMP_IMM_LT, Z1, Z2, K2, K2                // K2 <- lanes having more elements
+  KTESTW K2, K2
+  JNZ count_loop
+
+count_done:
+  CMPL DX, $0x7FFFFFFF
+  JNE resolve
+  VMOVDQA32 Z17, Z21                                   // Z21 <- end of the list
+
+resolve:
+  VPCMPD $VPCMP_IMM_LT, Z3, Z20, K2
+  VPADDD Z17, Z20, K2, Z20                             // Z20 <- first offset counted from the start
+  VPCMPD $VPCMP_IMM_LT, Z3, Z21, K2
+  VPADDD Z17, Z21, K2, Z21                             // Z21 <- second offset counted from the start
+
+walk:
+  VPCMPD $VPCMP_IMM_GE, Z3, Z20, K1, K1                // K1 <- lanes where the first offset is in range
+  VPCMPD $VPCMP_IMM_GE, Z3, Z21, K1, K1                // K1 <- lanes where the second offset is in range
+  VPMAXSD Z20, Z21, Z21                                // Z21 <- number of elements to walk
+
+  VPXORD X18, X18, X18                                 // Z18 <- index of the current element
+  VMOVDQA32 Z0, Z2                                     // Z2 <- offset of the current element
+  VMOVDQA32 Z0, Z16                                    // Z16 <- offset of the first element of the slice
+  VPCMPD $VPCMP_IMM_LT, Z21, Z18, K1, K2               // K2 <- lanes to walk
+  KTESTW K2, K2
+  JZ done
+
+walk_loop:
+  VPCMPUD $VPCMP_IMM_LT, Z1, Z2, K2, K3                // K3 <- lanes having more elements
+  KANDNW K2, K3, K4                                    // K4 <- lanes where an offset is past the end
+  KANDNW K1, K4, K1                                    // K1 <- discard lanes out of range
+  KANDW K3, K2, K2
+  KTESTW K2, K2
+  JZ done
+
+  BC_ARRAY_ELEMENT_SIZE()
+  VPADDD Z12, Z2, K2, Z2                               // Z2 <- advance to the next element
+  VPADDD Z22, Z18, K2, Z18                             // Z18 <- increment the index
+  VPCMPEQD Z20, Z18, K2, K3                            // K3 <- lanes reaching the first element
+  VMOVDQA32 Z2, K3, Z16                                // Z16 <- offset of the first element of the slice
+  VPCMPD $VPCMP_IMM_LT, Z21, Z18, K2, K2               // K2 <- lanes to walk
+  KTESTW K2, K2
+  JNZ walk_loop
+
+done:
+  BC_UNPACK_2xSLOT(0, OUT(DX), OUT(R8))
+  VPSUBD.Z Z16, Z2, K1, Z17                            // Z17 <- length of the slice
+  VMOVDQA32.Z Z16, K1, Z16
+  BC_STORE_SLICE_TO_SLOT(IN(Z16), IN(Z17), IN(DX))
+  BC_STORE_K_TO_SLOT(IN(K1), IN(R8))
+
+  NEXT_ADVANCE(BC_SLOT_SIZE*4 + BC_IMM64_SIZE)
+
 // f64[0].k[1] = vectorinnerproduct(s[2], s[3]).k[4]
 TEXT bcvectorinnerproduct(SB), NOSPLIT|NOFRAME, $0
   BC_UNPACK_3xSLOT(BC_SLOT_SIZE*2, OUT(BX), OUT(CX), OUT(R8))
@@ -11890,6 +12207,348 @@ next:
   NEXT_ADVANCE(BC_SLOT_SIZE*3 + BC_DICT_SIZE)
 //; #endregion bcIsSubnetOfIP4
 
+// k[0] = is_subnet_of_ip6(slice[1], dict[2]).k[3]
+//
+// Determines whether the string at slice[1] is an IP address between (and
+// including) the 128-bit addresses at dict[2], which holds the min address
+// followed by the max address, both in network byte order. The strings are
+// parsed one lane at a time like net/netip.ParseAddr does, except that an IPv4
+// address may have leading zeros like in is_subnet_of_ip4; an IPv4 address is
+// compared as the IPv4-mapped IPv6 address ::ffff:a.b.c.d.
+//
+// Spill area layout:
+//   [0..15]    parsed address (network byte order)
+//   [16]       index of the current lane
+//   [20]       output mask
+//   [24]       position of an embedded IPv4 address in the parsed address
+//   [28]       position of the ellipsis while parsing an embedded IPv4 address
+//   [32..63]   min (hi, lo) and max (hi, lo) as native QWORDs
+//   [64..127]  string offsets
+//   [128..191] string lengths
+TEXT bcIsSubnetOfIP6(SB), NOSPLIT|NOFRAME, $0
+  BC_UNPACK_SLOT_DICT_SLOT(BC_SLOT_SIZE*1, OUT(BX), OUT(R14), OUT(R8))
+  BC_LOAD_SLICE_FROM_SLOT(OUT(Z2), OUT(Z3), IN(BX))
+  BC_LOAD_K1_FROM_SLOT(OUT(K1), IN(R8))
+
+  MOVQ 0(R14), R14                                     // R14 <- min and max addresses
+  MOVBEQQ 0(R14), BX
+  MOVQ BX, BC_SPILL_AREA(32)
+  MOVBEQQ 8(R14), BX
+  MOVQ BX, BC_SPILL_AREA(40)
+  MOVBEQQ 16(R14), BX
+  MOVQ BX, BC_SPILL_AREA(48)
+  MOVBEQQ 24(R14), BX
+  MOVQ BX, BC_SPILL_AREA(56)
+
+  VMOVDQU32 Z2, BC_SPILL_AREA(64)
+  VMOVDQU32 Z3, BC_SPILL_AREA(128)
+  MOVL $0, BC_SPILL_AREA(20)                           // [] <- no lane matches yet
+  KMOVW K1, R8                                         // R8 <- lanes to process
+
+  TESTL R8, R8
+  JZ next
+
+lane_iter:
+  TZCNTL R8, R14                                       // R14 <- index of the lane to process
+  BLSRL R8, R8                                         // R8 <- clear the index of the iterator
+  MOVL R14, BC_SPILL_AREA(16)
+  MOVL BC_SPILL_AREA_INDEX(128, R14*4), CX             // CX <- remaining length
+  MOVL BC_SPILL_AREA_INDEX(64, R14*4), R14
+  ADDQ VIRT_BASE, R14                                  // R14 <- string pointer
+
+  // IPv4 address (leading zeros allowed)
+  // ------------------------------------
+
+  XORL R15, R15                                        // R15 <- address
+  XORL R11, R11                                        // R11 <- number of dots
+
+ip4_field:
+  XORL BX, BX                                          // BX <- value of the field
+  XORL DX, DX                                          // DX <- number of digits of the field
+
+ip4_char:
+  TESTL CX, CX
+  JZ ip4_end
+  MOVBLZX 0(R14), R13
+  INCQ R14
+  DECL CX
+  CMPL R13, $'.'
+  JEQ ip4_dot
+  SUBL $'0', R13
+  CMPL R13, $9
+  JA ip6_begin
+  CMPL DX, $3
+  JEQ ip6_begin
+  INCL DX
+  LEAL 0(BX)(BX*4), BX
+  LEAL 0(R13)(BX*2), BX                                // BX <- BX*10 + digit
+  JMP ip4_char
+
+ip4_dot:
+  TESTL DX, DX
+  JZ ip6_begin
+  CMPL BX, $255
+  JA ip6_begin
+  CMPL R11, $3
+  JEQ ip6_begin
+  INCL R11
+  SHLL $8, R15
+  ORL BX, R15
+  JMP ip4_field
+
+ip4_end:
+  TESTL DX, DX
+  JZ ip6_begin
+  CMPL BX, $255
+  JA ip6_begin
+  CMPL R11, $3
+  JNE ip6_begin
+  SHLL $8, R15
+  ORL BX, R15
+  XORL R11, R11                                        // R11 <- address (hi)
+  MOVQ $0x0000ffff00000000, BX
+  ORQ R15, BX                                          // BX <- address (lo)
+  JMP compare
+
+  // IPv6 address
+  // ------------
+
+ip6_begin:
+  MOVL BC_SPILL_AREA(16), DX
+  MOVL BC_SPILL_AREA_INDEX(128, DX*4), CX              // CX <- remaining length
+  MOVL BC_SPILL_AREA_INDEX(64, DX*4), R14
+  ADDQ VIRT_BASE, R14                                  // R14 <- string pointer
+
+  // cut the zone, which must not be empty, at the first '%'
+  XORL DX, DX
+zone_scan:
+  CMPL DX, CX
+  JEQ zone_done
+  CMPB 0(R14)(DX*1), $'%'
+  JEQ zone_found
+  INCL DX
+  JMP zone_scan
+zone_found:
+  LEAL 1(DX), BX
+  CMPL BX, CX
+  JEQ lane_next
+  MOVL DX, CX
+zone_done:
+
+  MOVQ $0, BC_SPILL_AREA(0)
+  MOVQ $0, BC_SPILL_AREA(8)
+  XORL DX, DX                                          // DX <- position in the parsed address
+  MOVL $-1, R15                                        // R15 <- position of the ellipsis
+
+  CMPL CX, $2
+  JB groups
+  CMPW 0(R14), $0x3a3a                                 // leading "::"
+  JNE groups
+  XORL R15, R15
+  ADDQ $2, R14
+  SUBL $2, CX
+  JZ ip6_done
+
+groups:
+  CMPL DX, $16
+  JAE ip6_done
+  MOVL CX, R13                                         // R13 <- remaining length at the start of the group
+  XORL R11, R11                                        // R11 <- value of the group
+
+hex_char:
+  TESTL CX, CX
+  JZ hex_end
+  MOVBLZX 0(R14), BX
+  SUBL $'0', BX
+  CMPL BX, $9
+  JBE hex_digit
+  ADDL $'0', BX
+  ORL $0x20, BX                                        // BX <- lowercase character
+  SUBL $('a'-10), BX
+  CMPL BX, $10
+  JB hex_end
+  CMPL BX, $15
+  JA hex_end
+
+hex_digit:
+  SUBL CX, R13
+  CMPL R13, $4                                         // at most 4 digits
+  LEAL 0(R13)(CX*1), R13
+  JAE lane_next
+  SHLL $4, R11
+  ORL BX, R11
+  INCQ R14
+  DECL CX
+  JMP hex_char
+
+hex_end:
+  CMPL R13, CX                                         // at least 1 digit
+  JEQ lane_next
+  TESTL CX, CX
+  JZ group_store
+  CMPB 0(R14), $'.'
+  JEQ ip4_embedded
+
+group_store:
+  ROLW $8, R11
+  MOVW R11, BC_SPILL_AREA_INDEX(0, DX*1)
+  ADDL $2, DX
+  TESTL CX, CX
+  JZ ip6_done
+  CMPB 0(R14), $':'
+  JNE lane_next
+  CMPL CX, $1
+  JEQ lane_next
+  INCQ R14
+  DECL CX
+  CMPB 0(R14), $':'
+  JNE groups
+  TESTL R15, R15                                       // at most one ellipsis
+  JNS lane_next
+  MOVL DX, R15
+  INCQ R14
+  DECL CX
+  JZ ip6_done
+  JMP groups
+
+  // an IPv4 address (without leading zeros) can replace
+  // the last 2 groups, either after 6 groups or after
+  // an ellipsis
+ip4_embedded:
+  TESTL R15, R15
+  JNS ip4_embedded_room
+  CMPL DX, $12
+  JNE lane_next
+ip4_embedded_room:
+  CMPL DX, $12
+  JA lane_next
+
+  MOVL R13, BX
+  SUBL CX, BX
+  SUBQ BX, R14                                         // R14 <- start of the group
+  MOVL R13, CX
+  MOVL DX, BC_SPILL_AREA(24)
+  MOVL R15, BC_SPILL_AREA(28)
+
+  XORL R15, R15                                        // R15 <- address
+  XORL R11, R11                                        // R11 <- number of dots
+
+ip4_embedded_field:
+  XORL BX, BX                                          // BX <- value of the field
+  XORL DX, DX                                          // DX <- number of digits of the field
+
+ip4_embedded_char:
+  TESTL CX, CX
+  JZ ip4_embedded_end
+  MOVBLZX 0(R14), R13
+  INCQ R14
+  DECL CX
+  CMPL R13, $'.'
+  JEQ ip4_embedded_dot
+  SUBL $'0', R13
+  CMPL R13, $9
+  JA lane_next
+  CMPL DX, $1                                          // no leading zeros
+  JNE ip4_embedded_digit
+  TESTL BX, BX
+  JZ lane_next
+
+ip4_embedded_digit:
+  INCL DX
+  LEAL 0(BX)(BX*4), BX
+  LEAL 0(R13)(BX*2), BX                                // BX <- BX*10 + digit
+  CMPL BX, $255
+  JA lane_next
+  JMP ip4_embedded_char
+
+ip4_embedded_dot:
+  TESTL DX, DX
+  JZ lane_next
+  TESTL CX, CX
+  JZ lane_next
+  CMPL R11, $3
+  JEQ lane_next
+  INCL R11
+  SHLL $8, R15
+  ORL BX, R15
+  JMP ip4_embedded_field
+
+ip4_embedded_end:
+  CMPL R11, $3
+  JNE lane_next
+  SHLL $8, R15
+  ORL BX, R15
+  BSWAPL R15
+  MOVL BC_SPILL_AREA(24), DX
+  MOVL R15, BC_SPILL_AREA_INDEX(0, DX*1)
+  ADDL $4, DX
+  MOVL BC_SPILL_AREA(28), R15
+
+ip6_done:
+  TESTL CX, CX                                         // the whole string must be used
+  JNZ lane_next
+  CMPL DX, $16
+  JEQ ip6_full
+  TESTL R15, R15                                       // a short address needs an ellipsis
+  JS lane_next
+
+  // expand the ellipsis: move the groups that follow it
+  // to the end of the address and fill the gap with zeros
+  MOVL $16, R13
+  SUBL DX, R13                                         // R13 <- size of the gap
+  MOVL DX, R11
+
+expand_move:
+  CMPL R11, R15
+  JLE expand_clear
+  DECL R11
+  MOVBLZX BC_SPILL_AREA_INDEX(0, R11*1), BX
+  LEAL 0(R11)(R13*1), CX
+  MOVB BX, BC_SPILL_AREA_INDEX(0, CX*1)
+  JMP expand_move
+
+expand_clear:
+  TESTL R13, R13
+  JZ ip6_load
+  MOVB $0, BC_SPILL_AREA_INDEX(0, R15*1)
+  INCL R15
+  DECL R13
+  JMP expand_clear
+
+ip6_full:
+  TESTL R15, R15                                       // the ellipsis must stand for at least one group
+  JNS lane_next
+
+ip6_load:
+  MOVBEQQ BC_SPILL_AREA(0), R11                        // R11 <- address (hi)
+  MOVBEQQ BC_SPILL_AREA(8), BX                         // BX <- address (lo)
+
+compare:
+  CMPQ R11, BC_SPILL_AREA(32)
+  JA above_min
+  JB lane_next
+  CMPQ BX, BC_SPILL_AREA(40)
+  JB lane_next
+above_min:
+  CMPQ R11, BC_SPILL_AREA(48)
+  JB match
+  JA lane_next
+  CMPQ BX, BC_SPILL_AREA(56)
+  JA lane_next
+match:
+  MOVL BC_SPILL_AREA(16), R14
+  BTSL R14, BC_SPILL_AREA(20)
+
+lane_next:
+  TESTL R8, R8
+  JNZ lane_iter
+
+next:
+  KMOVW BC_SPILL_AREA(20), K1
+  BC_UNPACK_SLOT(0, OUT(DX))
+  BC_STORE_K_TO_SLOT(IN(K1), IN(DX))
+  NEXT_ADVANCE(BC_SLOT_SIZE*3 + BC_DICT_SIZE)
+
 //; #region bcDfaT6
 //; DfaT6 Deterministic Finite Automaton (DFA) with 6-bits lookup-key and unicode wildcard
 //
@@ -12716,6 +13375,21 @@ next:
 
 #include "evalbc_strcase.h"
 
+// TO_HEX/FROM_HEX/TO_BASE64/FROM_BASE64 functions
+// --------------------------------------------------
+
+#include "evalbc_transcode.h"
+
+// REPLACE function
+// --------------------------------------------------
+
+#include "evalbc_replace.h"
+
+// SPLIT_PART function
+// --------------------------------------------------
+
+#include "evalbc_splitpart.h"
+
 // APPROX_COUNT_DISTINCT
 // --------------------------------------------------
 
@@ -44,7 +44,7 @@ CONST_DATA_U64(cmpv_predicate_sort_nulls_first, 8, $0xFFFFFFFFFFFFFF84)
 CONST_GLOBAL(cmpv_predicate_sort_nulls_first, $16)
 
 // Compare with sorting semantics, NULLs are sorted after any other value.
-CONST_DATA_U64(cmpv_predicate_sort_nulls_last, 0, $0x84838FF28282818F)
+CONST_DATA_U64(cmpv_predicate_sort_nulls_last, 0, $0x8483FF828282818F)
 CONST_DATA_U64(cmpv_predicate_sort_nulls_last, 8, $0xFFFFFFFFFFFFFF84)
 CONST_GLOBAL(cmpv_predicate_sort_nulls_last, $16)
 
@@ -0,0 +1,213 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// REPLACE function
+// --------------------------------------------------
+//
+// The lanes are replaced one at a time and their outputs are appended to the
+// scratch buffer. The spill area holds the inputs and the state of the lane:
+//
+//   [0..63]    input offsets
+//   [64..127]  input lengths
+//   [128..191] offsets of the strings to search for
+//   [192..255] lengths of the strings to search for
+//   [256..319] offsets of the replacements
+//   [320..383] lengths of the replacements
+//   [384]      pointer to the replacement of the current lane
+//   [392]      length of the replacement of the current lane
+//   [400]      pointer to the output of the current lane
+//   [408]      pointer to the end of the scratch buffer
+//   [416]      pointer to the end of the input of the current lane
+
+// REPLACE_COPY copies Len bytes from Src to R15, 64 bytes at a time,
+// and advances both pointers; it aborts if the scratch buffer is too small
+//
+// Len and Tmp are clobbered
+#define REPLACE_COPY(Src, Len, Tmp, LoopLabel, TailLabel)   \
+  LEAQ 0(R15)(Len*1), Tmp                                   \
+  CMPQ Tmp, BC_SPILL_AREA(408)                              \
+  JA error_handler_more_scratch                             \
+LoopLabel:                                                  \
+  CMPQ Len, $64                                             \
+  JB TailLabel                                              \
+  VMOVDQU8 0(Src), Z10                                      \
+  VMOVDQU8 Z10, 0(R15)                                      \
+  ADDQ $64, Src                                             \
+  ADDQ $64, R15                                             \
+  SUBQ $64, Len                                             \
+  JMP LoopLabel                                             \
+TailLabel:                                                  \
+  MOVQ $-1, Tmp                                             \
+  BZHIQ Len, Tmp, Tmp                                       \
+  KMOVQ Tmp, K4                                             \
+  VMOVDQU8.Z 0(Src), K4, Z10                                \
+  VMOVDQU8 Z10, K4, 0(R15)                                  \
+  ADDQ Len, Src                                             \
+  ADDQ Len, R15
+
+// slice[0].k[1] = strreplace(slice[2], slice[3], slice[4]).k[5]
+//
+// Replaces the non-overlapping occurrences of slice[3] in slice[2]
+// with slice[4]; the candidates are found by comparing the first byte
+// of slice[3] with up to 64 bytes of the input at a time. The input is
+// returned as is if slice[3] is empty.
+//
+// scratch: PageSize
+TEXT bcstrreplace(SB), NOSPLIT|NOFRAME, $0
+  BC_UNPACK_3xSLOT(BC_SLOT_SIZE*2, OUT(BX), OUT(CX), OUT(DX))
+  BC_UNPACK_SLOT(BC_SLOT_SIZE*5, OUT(R8))
+  BC_LOAD_K1_FROM_SLOT(OUT(K1), IN(R8))
+  BC_LOAD_SLICE_FROM_SLOT_MASKED(OUT(Z2), OUT(Z3), IN(BX), IN(K1))
+  BC_LOAD_SLICE_FROM_SLOT_MASKED(OUT(Z4), OUT(Z5), IN(CX), IN(K1))
+  BC_LOAD_SLICE_FROM_SLOT_MASKED(OUT(Z6), OUT(Z7), IN(DX), IN(K1))
+
+  VMOVDQU32 Z2, BC_SPILL_AREA(0)
+  VMOVDQU32 Z3, BC_SPILL_AREA(64)
+  VMOVDQU32 Z4, BC_SPILL_AREA(128)
+  VMOVDQU32 Z5, BC_SPILL_AREA(192)
+  VMOVDQU32 Z6, BC_SPILL_AREA(256)
+  VMOVDQU32 Z7, BC_SPILL_AREA(320)
+
+  MOVL bytecode_scratchoff(VIRT_BCPTR), BX
+  ADDQ VIRT_BASE, BX
+  MOVQ bytecode_scratch+8(VIRT_BCPTR), R15
+  ADDQ BX, R15                                         // R15 <- output pointer
+  ADDQ bytecode_scratch+16(VIRT_BCPTR), BX
+  MOVQ BX, BC_SPILL_AREA(408)                          // [] <- end of the scratch buffer
+
+  VPXORD Z8, Z8, Z8                                    // Z8 <- output offsets
+  VPXORD Z9, Z9, Z9                                    // Z9 <- output lengths
+  KMOVW K1, R8                                         // R8 <- lanes to replace
+  TESTL R8, R8
+  JZ done
+
+lane_iter:
+  TZCNTL R8, DX                                        // DX <- index of the lane to replace
+  BLSRL R8, R8                                         // R8 <- clear the index of the iterator
+  MOVL $1, BX
+  SHLXL DX, BX, BX
+  KMOVW BX, K5                                         // K5 <- the lane
+  MOVL BC_SPILL_AREA_INDEX(0, DX*4), R14               // R14 <- input offset
+  MOVL BC_SPILL_AREA_INDEX(64, DX*4), CX               // CX <- input length
+  MOVL BC_SPILL_AREA_INDEX(192, DX*4), R11             // R11 <- length of the string to search for
+  TESTL R11, R11
+  JZ lane_unchanged
+
+  MOVL BC_SPILL_AREA_INDEX(128, DX*4), R13
+  ADDQ VIRT_BASE, R13                                  // R13 <- pointer to the string to search for
+  MOVL BC_SPILL_AREA_INDEX(256, DX*4), BX
+  ADDQ VIRT_BASE, BX
+  MOVQ BX, BC_SPILL_AREA(384)
+  MOVL BC_SPILL_AREA_INDEX(320, DX*4), BX
+  MOVQ BX, BC_SPILL_AREA(392)
+  MOVQ R15, BC_SPILL_AREA(400)
+  ADDQ VIRT_BASE, R14                                  // R14 <- input pointer
+  ADDQ R14, CX
+  MOVQ CX, BC_SPILL_AREA(416)                          // [] <- end of the input
+  VPBROADCASTB 0(R13), Z11                             // Z11 <- first byte to search for
+
+window:
+  MOVQ BC_SPILL_AREA(416), DX
+  SUBQ R14, DX                                         // DX <- remaining input length
+  CMPQ DX, R11
+  JB lane_tail
+  SUBQ R11, DX
+  INCQ DX                                              // DX <- positions where a match can start
+  MOVL $64, BX
+  CMPQ DX, BX
+  CMOVQGT BX, DX                                       // DX <- positions in this window
+  MOVQ $-1, BX
+  BZHIQ DX, BX, BX
+  KMOVQ BX, K2
+  VMOVDQU8.Z 0(R14), K2, Z10
+  VPCMPEQB Z11, Z10, K2, K3                            // K3 <- candidates
+  KORTESTQ K3, K3
+  JZ skip_window
+
+candidate:
+  KMOVQ K3, DX
+  TZCNTQ DX, DX                                        // DX <- index of the candidate
+  ADDQ DX, R14                                         // R14 <- pointer to the candidate
+  MOVL $1, BX                                          // (the first byte is known to match)
+
+compare:
+  CMPQ BX, R11
+  JAE match
+  MOVBLZX 0(R14)(BX*1), CX
+  CMPB CL, 0(R13)(BX*1)
+  JNE mismatch
+  INCQ BX
+  JMP compare
+
+match:
+  // copy the input up to the match and then the replacement
+  SUBQ DX, R14
+  REPLACE_COPY(R14, DX, BX, copy_input, copy_input_tail)
+  ADDQ R11, R14
+  MOVQ BC_SPILL_AREA(384), CX
+  MOVQ BC_SPILL_AREA(392), DX
+  REPLACE_COPY(CX, DX, BX, copy_replacement, copy_replacement_tail)
+  JMP window
+
+mismatch:
+  SUBQ DX, R14
+
+next_candidate:
+  KMOVQ K3, BX
+  BLSRQ BX, BX
+  KMOVQ BX, K3
+  JNZ candidate
+
+skip_window:
+  // none of the positions of the window match
+  MOVQ BC_SPILL_AREA(416), DX
+  SUBQ R14, DX
+  SUBQ R11, DX
+  INCQ DX
+  MOVL $64, BX
+  CMPQ DX, BX
+  CMOVQGT BX, DX
+  REPLACE_COPY(R14, DX, BX, copy_window, copy_window_tail)
+  JMP window
+
+lane_tail:
+  REPLACE_COPY(R14, DX, BX, copy_tail, copy_tail_tail)
+  MOVQ BC_SPILL_AREA(400), BX
+  MOVQ R15, DX
+  SUBQ BX, DX
+  VPBROADCASTD DX, K5, Z9
+  SUBQ VIRT_BASE, BX
+  VPBROADCASTD BX, K5, Z8
+  JMP lane_next
+
+lane_unchanged:
+  VPBROADCASTD R14, K5, Z8
+  VPBROADCASTD CX, K5, Z9
+
+lane_next:
+  TESTL R8, R8
+  JNZ lane_iter
+
+  MOVL bytecode_scratchoff(VIRT_BCPTR), BX
+  ADDQ VIRT_BASE, BX
+  SUBQ BX, R15
+  MOVQ R15, bytecode_scratch+8(VIRT_BCPTR)             // [] <- update the length of the scratch buffer
+
+done:
+  BC_UNPACK_2xSLOT(0, OUT(DX), OUT(R8))
+  BC_STORE_SLICE_TO_SLOT(IN(Z8), IN(Z9), IN(DX))
+  BC_STORE_K_TO_SLOT(IN(K1), IN(R8))
+  NEXT_ADVANCE(BC_SLOT_SIZE*6)
+
+  _BC_ERROR_HANDLER_MORE_SCRATCH()
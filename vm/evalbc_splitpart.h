@@ -0,0 +1,184 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// SPLIT_PART with a regular expression delimiter
+// --------------------------------------------------
+//
+// The lanes are split one at a time. The spill area holds the inputs
+// and the state of the lane:
+//
+//   [0..63]    input offsets
+//   [64..127]  input lengths
+//   [128..255] field indexes
+//   [256]      pointer to the DFA (see regexp2.DsDelim)
+//   [264]      pointer to the end of the input of the current lane
+//   [272]      pointer to the start of the current field
+//   [280]      number of the current field, counted down to 1
+
+// SPLIT_PART_RUNE reads the code-point at Ptr as a UTF-8 int into Val
+// and its size into Size; the size is derived from the first byte alone
+// (the number of its leading ones, from 1 to 4) and limited to the end
+// of the input
+//
+// CX is clobbered
+#define SPLIT_PART_RUNE(Ptr, Val, Size)                     \
+  MOVBLZX 0(Ptr), CX                                        \
+  NOTL CX                                                   \
+  SHLL $24, CX                                              \
+  LZCNTL CX, Size                                           \
+  MOVL $1, CX                                               \
+  CMPL Size, CX                                             \
+  CMOVLLT CX, Size                                          \
+  MOVL $4, CX                                               \
+  CMPL Size, CX                                             \
+  CMOVLGT CX, Size                                          \
+  MOVQ BC_SPILL_AREA(264), CX                               \
+  SUBQ Ptr, CX                                              \
+  CMPQ Size, CX                                             \
+  CMOVQGT CX, Size                                          \
+  MOVL 0(Ptr), Val                                          \
+  BSWAPL Val                                                \
+  MOVL Size, CX                                             \
+  SHLL $3, CX                                               \
+  NEGL CX                                                   \
+  ADDL $32, CX                                              \
+  SHRXL CX, Val, Val
+
+// slice[0].k[1] = split_part_dfa(slice[2], dict[3], i64[4]).k[5]
+//
+// Returns the i64[4]-th (one-based) field of slice[2] split on the
+// non-empty matches of the delimiter DFA in dict[3]; at each position
+// the DFA is run as long as it has edges for the input, and the last
+// matching state it reaches is the end of the delimiter.
+TEXT bcSplitPartDfa(SB), NOSPLIT|NOFRAME, $0
+  BC_UNPACK_SLOT(BC_SLOT_SIZE*2, OUT(BX))
+  BC_UNPACK_DICT(BC_SLOT_SIZE*3, OUT(R14))
+  BC_UNPACK_2xSLOT(BC_SLOT_SIZE*3+BC_DICT_SIZE, OUT(CX), OUT(R8))
+  BC_LOAD_K1_FROM_SLOT(OUT(K1), IN(R8))
+  BC_LOAD_SLICE_FROM_SLOT_MASKED(OUT(Z2), OUT(Z3), IN(BX), IN(K1))
+  BC_LOAD_I64_FROM_SLOT(OUT(Z4), OUT(Z5), IN(CX))
+
+  VMOVDQU32 Z2, BC_SPILL_AREA(0)
+  VMOVDQU32 Z3, BC_SPILL_AREA(64)
+  VMOVDQU64 Z4, BC_SPILL_AREA(128)
+  VMOVDQU64 Z5, BC_SPILL_AREA(192)
+  MOVQ 0(R14), R14
+  MOVQ R14, BC_SPILL_AREA(256)
+
+  VPXORD Z8, Z8, Z8                                    // Z8 <- output offsets
+  VPXORD Z9, Z9, Z9                                    // Z9 <- output lengths
+  KXORW K2, K2, K2                                     // K2 <- output lanes
+  KMOVW K1, R8                                         // R8 <- lanes to split
+  TESTL R8, R8
+  JZ done
+
+lane_iter:
+  TZCNTL R8, DX                                        // DX <- index of the lane to split
+  BLSRL R8, R8                                         // R8 <- clear the index of the iterator
+  MOVL $1, BX
+  SHLXL DX, BX, BX
+  KMOVW BX, K5                                         // K5 <- the lane
+  MOVQ BC_SPILL_AREA_INDEX(128, DX*8), BX              // BX <- field index
+  TESTQ BX, BX
+  JLE lane_next
+  MOVQ BX, BC_SPILL_AREA(280)
+  MOVL BC_SPILL_AREA_INDEX(0, DX*4), R14
+  ADDQ VIRT_BASE, R14                                  // R14 <- input pointer
+  MOVL BC_SPILL_AREA_INDEX(64, DX*4), CX
+  ADDQ R14, CX
+  MOVQ CX, BC_SPILL_AREA(264)                          // [] <- end of the input
+  MOVQ R14, BC_SPILL_AREA(272)                         // [] <- start of the first field
+  MOVQ BC_SPILL_AREA(256), R11
+  MOVL 0(R11), CX
+  ADDQ CX, R11                                         // R11 <- start state at the start of the input
+
+search:
+  CMPQ R14, BC_SPILL_AREA(264)
+  JAE last_field
+  MOVQ R14, R15                                        // R15 <- position of the DFA
+  MOVQ R14, DX                                         // DX <- end of the delimiter
+
+step:
+  CMPQ R15, BC_SPILL_AREA(264)
+  JAE input_end
+  SPLIT_PART_RUNE(R15, BX, R13)
+  MOVL 4(R11), CX                                      // CX <- number of edges
+  ADDQ $8, R11                                         // R11 <- first edge
+
+edge:
+  TESTL CX, CX
+  JZ step_done
+  CMPL BX, 0(R11)
+  JB step_done                                         // (the edges are sorted by their minimum)
+  CMPL BX, 4(R11)
+  JBE transition
+  ADDQ $12, R11
+  DECL CX
+  JMP edge
+
+transition:
+  MOVL 8(R11), R11
+  ADDQ BC_SPILL_AREA(256), R11                         // R11 <- next state
+  ADDQ R13, R15
+  TESTL $1, 0(R11)
+  JZ step
+  MOVQ R15, DX                                         // the delimiter ends here
+  JMP step
+
+input_end:
+  TESTL $2, 0(R11)
+  JZ step_done
+  MOVQ R15, DX                                         // the delimiter ends at the end of the input
+
+step_done:
+  CMPQ DX, R14
+  JA delimiter
+  SPLIT_PART_RUNE(R14, BX, R13)
+  ADDQ R13, R14                                        // no delimiter here; try the next code-point
+  JMP next_search
+
+delimiter:
+  DECQ BC_SPILL_AREA(280)
+  JZ field_end                                         // the delimiter ends the field
+  MOVQ DX, R14
+  MOVQ DX, BC_SPILL_AREA(272)                          // [] <- start of the next field
+
+next_search:
+  MOVQ BC_SPILL_AREA(256), R11
+  MOVL 4(R11), CX
+  ADDQ CX, R11                                         // R11 <- start state elsewhere
+  JMP search
+
+last_field:
+  CMPQ BC_SPILL_AREA(280), $1
+  JNE lane_next                                        // there are not enough fields
+  MOVQ BC_SPILL_AREA(264), R14
+
+field_end:
+  MOVQ BC_SPILL_AREA(272), BX
+  SUBQ BX, R14
+  VPBROADCASTD R14, K5, Z9
+  SUBQ VIRT_BASE, BX
+  VPBROADCASTD BX, K5, Z8
+  KORW K5, K2, K2
+
+lane_next:
+  TESTL R8, R8
+  JNZ lane_iter
+
+done:
+  BC_UNPACK_2xSLOT(0, OUT(DX), OUT(R8))
+  BC_STORE_SLICE_TO_SLOT(IN(Z8), IN(Z9), IN(DX))
+  BC_STORE_K_TO_SLOT(IN(K2), IN(R8))
+  NEXT_ADVANCE(BC_SLOT_SIZE*5+BC_DICT_SIZE)
@@ -20,11 +20,14 @@ import (
 	"math/rand"
 	"net"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 	"unicode/utf8"
 
+	"github.com/SnellerInc/sneller/date"
 	"github.com/SnellerInc/sneller/internal/stringext"
 	"github.com/SnellerInc/sneller/ion"
 	"github.com/SnellerInc/sneller/regexp2"
@@ -1294,6 +1297,41 @@ func TestRegexMatchUT1(t *testing.T) {
 		{`1.1.1.1a`, `(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?).){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)`, false, regexp2.SimilarTo},
 		{`10.1000.10.10`, `(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?).){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)`, false, regexp2.SimilarTo},
 		{`0.0.0.0`, `(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?).){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)`, true, regexp2.SimilarTo},
+
+		// POSIX character classes (which only ever match ASCII)
+		{`abc`, `^[[:alpha:]]+$`, true, regexp2.Regexp},
+		{`abΩ`, `^[[:alpha:]]+$`, false, regexp2.Regexp},
+		{`Ωa`, `[[:alpha:]]`, true, regexp2.Regexp},
+		{`ΩΩ`, `[[:alpha:]]`, false, regexp2.Regexp},
+		{`x1y`, `[[:digit:]]`, true, regexp2.Regexp},
+		{`x٣y`, `[[:digit:]]`, false, regexp2.Regexp}, // ARABIC-INDIC DIGIT THREE
+		{"a\tb", `a[[:space:]]b`, true, regexp2.Regexp},
+		{"a\u00a0b", `a[[:space:]]b`, false, regexp2.Regexp},
+		{`a!b`, `a[[:punct:]]b`, true, regexp2.Regexp},
+		{`a¡b`, `a[[:punct:]]b`, false, regexp2.Regexp},
+		{`aBc`, `[[:upper:]]`, true, regexp2.Regexp},
+		{`aΩc`, `[[:upper:]]`, false, regexp2.Regexp},
+		{`abc`, `[[:lower:]]{3}`, true, regexp2.SimilarTo},
+		{`aBc`, `[[:lower:]]{3}`, false, regexp2.SimilarTo},
+		{`a1`, `[[:alpha:]][[:digit:]]`, true, regexp2.SimilarTo},
+		{`1a`, `[[:alpha:]][[:digit:]]`, false, regexp2.SimilarTo},
+		{`ab_`, `%[[:punct:]]`, true, regexp2.SimilarTo},
+		{`a b`, `_[[:space:]]_`, true, regexp2.SimilarTo},
+		{`ΩÄ`, `[[:^ascii:]]+`, true, regexp2.SimilarTo},
+		{`Ωa`, `[[:^ascii:]]+`, false, regexp2.SimilarTo},
+
+		// negated POSIX character classes
+		{`123`, `[^[:digit:]]`, false, regexp2.Regexp},
+		{`12x3`, `[^[:digit:]]`, true, regexp2.Regexp},
+		{`12Ω3`, `[^[:digit:]]`, true, regexp2.Regexp},
+		{`12Ω3`, `[[:^digit:]]`, true, regexp2.Regexp},
+		{`abc`, `^[^[:upper:]]+$`, true, regexp2.Regexp},
+		{`aΩc`, `^[^[:upper:]]+$`, true, regexp2.Regexp},
+		{`aCc`, `^[^[:upper:]]+$`, false, regexp2.Regexp},
+		{`Ω`, `[^[:alpha:][:digit:]]`, true, regexp2.SimilarTo},
+		{`a`, `[^[:alpha:][:digit:]]`, false, regexp2.SimilarTo},
+		{`a-`, `[[:alpha:]][^[:space:]]`, true, regexp2.SimilarTo},
+		{`a `, `[[:alpha:]][^[:space:]]`, false, regexp2.SimilarTo},
 	}
 
 	run := func(ut unitTest, inputK kRegData) {
@@ -4640,6 +4678,54 @@ func TestBytecodeToInt(t *testing.T) {
 	verifyI64RegOutput(t, &outputS, &i64RegData{values: [16]int64{0, 255, 0x1133, -42, 12345678}})
 }
 
+func TestBytecodeUnboxTimestampPrecision(t *testing.T) {
+	t.Parallel()
+	var ctx bctestContext
+	defer ctx.free()
+
+	truncs := []ion.TimeTrunc{
+		ion.TruncToMinute,
+		ion.TruncToSecond,
+		ion.TruncToMillisecond,
+		ion.TruncToMicrosecond,
+		ion.TruncToNanosecond,
+	}
+	var buf ion.Buffer
+	for i := 0; i < 100; i++ {
+		values := make([]any, bcLaneCount)
+		want := i64RegData{}
+		for j := range values {
+			ts := date.Unix(rand.Int63n(1<<33), rand.Int63n(1e9))
+			if j%4 == 0 {
+				// make some fractions end with zeros
+				ts = ts.Truncate(time.Duration(1000 << j))
+			}
+			trunc := truncs[rand.Intn(len(truncs))]
+			buf.Reset()
+			buf.WriteTruncatedTime(ts, trunc)
+			values[j] = slices.Clone(buf.Bytes())
+			exp, _, err := ion.ReadTime(buf.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			want.values[j] = exp.UnixMicro()
+		}
+
+		inputV := ctx.vRegFromValues(values, nil)
+		inputK := kRegData{mask: 0xFFFF}
+		for _, run := range []func(bcop, []any, kRegData) error{ctx.executeOpcode, ctx.executeOpcodeGo} {
+			outputS := i64RegData{}
+			outputK := kRegData{}
+			if err := run(opunboxts, []any{&outputS, &outputK, &inputV, &inputK}, inputK); err != nil {
+				t.Fatal(err)
+			}
+			verifyKRegOutput(t, &outputK, &inputK)
+			verifyI64RegOutput(t, &outputS, &want)
+		}
+		ctx.clear()
+	}
+}
+
 func TestBytecodeIsNull(t *testing.T) {
 	t.Parallel()
 	var ctx bctestContext
@@ -0,0 +1,612 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+// TO_HEX/FROM_HEX/TO_BASE64/FROM_BASE64 functions
+// --------------------------------------------------
+//
+// All the instructions allocate the output of every active lane at once and
+// then convert the lanes one at a time. The spill area holds the input slices
+// and the output offsets while the lanes are converted:
+//
+//   [0..63]    input offsets
+//   [64..127]  input lengths
+//   [128..191] output offsets
+//   [192]      output mask (cleared for lanes that cannot be decoded)
+//   [196]      index of the current lane
+
+CONST_DATA_U64(hex_digits, 0, $0x3736353433323130)
+CONST_DATA_U64(hex_digits, 8, $0x6665646362613938)
+CONST_GLOBAL(hex_digits, $16)
+
+// the standard alphabet followed by the URL-safe alphabet
+CONST_DATA_U64(base64_alphabet, 0, $0x4847464544434241)
+CONST_DATA_U64(base64_alphabet, 8, $0x504f4e4d4c4b4a49)
+CONST_DATA_U64(base64_alphabet, 16, $0x5857565554535251)
+CONST_DATA_U64(base64_alphabet, 24, $0x6665646362615a59)
+CONST_DATA_U64(base64_alphabet, 32, $0x6e6d6c6b6a696867)
+CONST_DATA_U64(base64_alphabet, 40, $0x767574737271706f)
+CONST_DATA_U64(base64_alphabet, 48, $0x333231307a797877)
+CONST_DATA_U64(base64_alphabet, 56, $0x2f2b393837363534)
+CONST_DATA_U64(base64_alphabet, 64, $0x4847464544434241)
+CONST_DATA_U64(base64_alphabet, 72, $0x504f4e4d4c4b4a49)
+CONST_DATA_U64(base64_alphabet, 80, $0x5857565554535251)
+CONST_DATA_U64(base64_alphabet, 88, $0x6665646362615a59)
+CONST_DATA_U64(base64_alphabet, 96, $0x6e6d6c6b6a696867)
+CONST_DATA_U64(base64_alphabet, 104, $0x767574737271706f)
+CONST_DATA_U64(base64_alphabet, 112, $0x333231307a797877)
+CONST_DATA_U64(base64_alphabet, 120, $0x5f2d393837363534)
+CONST_GLOBAL(base64_alphabet, $128)
+
+// the values of the characters of the standard alphabet followed by
+// the values of the characters of the URL-safe alphabet (0xFF if none)
+CONST_DATA_U64(base64_values, 0, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 8, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 16, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 24, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 32, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 40, $0x3fffffff3effffff)
+CONST_DATA_U64(base64_values, 48, $0x3b3a393837363534)
+CONST_DATA_U64(base64_values, 56, $0xffffffffffff3d3c)
+CONST_DATA_U64(base64_values, 64, $0x06050403020100ff)
+CONST_DATA_U64(base64_values, 72, $0x0e0d0c0b0a090807)
+CONST_DATA_U64(base64_values, 80, $0x161514131211100f)
+CONST_DATA_U64(base64_values, 88, $0xffffffffff191817)
+CONST_DATA_U64(base64_values, 96, $0x201f1e1d1c1b1aff)
+CONST_DATA_U64(base64_values, 104, $0x2827262524232221)
+CONST_DATA_U64(base64_values, 112, $0x302f2e2d2c2b2a29)
+CONST_DATA_U64(base64_values, 120, $0xffffffffff333231)
+CONST_DATA_U64(base64_values, 128, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 136, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 144, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 152, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 160, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 168, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 176, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 184, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 192, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 200, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 208, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 216, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 224, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 232, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 240, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 248, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 256, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 264, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 272, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 280, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 288, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 296, $0xffff3effffffffff)
+CONST_DATA_U64(base64_values, 304, $0x3b3a393837363534)
+CONST_DATA_U64(base64_values, 312, $0xffffffffffff3d3c)
+CONST_DATA_U64(base64_values, 320, $0x06050403020100ff)
+CONST_DATA_U64(base64_values, 328, $0x0e0d0c0b0a090807)
+CONST_DATA_U64(base64_values, 336, $0x161514131211100f)
+CONST_DATA_U64(base64_values, 344, $0x3fffffffff191817)
+CONST_DATA_U64(base64_values, 352, $0x201f1e1d1c1b1aff)
+CONST_DATA_U64(base64_values, 360, $0x2827262524232221)
+CONST_DATA_U64(base64_values, 368, $0x302f2e2d2c2b2a29)
+CONST_DATA_U64(base64_values, 376, $0xffffffffff333231)
+CONST_DATA_U64(base64_values, 384, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 392, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 400, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 408, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 416, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 424, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 432, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 440, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 448, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 456, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 464, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 472, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 480, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 488, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 496, $0xffffffffffffffff)
+CONST_DATA_U64(base64_values, 504, $0xffffffffffffffff)
+CONST_GLOBAL(base64_values, $512)
+
+// slice[0].k[1] = tohex(v[2]).k[3]
+//
+// Encodes the contents of the strings and blobs in v[2] in hexadecimal
+// (lowercase), 16 bytes at a time; other values are MISSING
+//
+// scratch: PageSize
+TEXT bctohex(SB), NOSPLIT|NOFRAME, $0
+  BC_UNPACK_2xSLOT(BC_SLOT_SIZE*2, OUT(BX), OUT(R8))
+  BC_LOAD_K1_FROM_SLOT(OUT(K1), IN(R8))
+
+  BC_LOAD_VALUE_TYPEL_FROM_SLOT(OUT(Z6), IN(BX))
+  VPSRLD $4, Z6, Z7
+  VPORD.BCST CONSTD_2(), Z7, Z7
+  VPCMPEQD.BCST CONSTD_0x0A(), Z7, K1, K1              // K1 <- strings (0x8) and blobs (0xA)
+  VPANDD.BCST CONSTD_0x0F(), Z6, Z6
+  VPCMPD.BCST $VPCMP_IMM_NE, CONSTD_0x0F(), Z6, K1, K1 // K1 <- not NULL
+  BC_LOAD_VALUE_HLEN_FROM_SLOT(OUT(Z6), IN(BX))
+  VPADDD.Z BC_VSTACK_PTR(BX, 0), Z6, K1, Z2            // Z2 <- offsets of the contents
+  VMOVDQU32 BC_VSTACK_PTR(BX, 64), Z3
+  VPSUBD.Z Z6, Z3, K1, Z3                              // Z3 <- lengths of the contents
+
+  VPADDD Z3, Z3, Z4                                    // Z4 <- output lengths
+
+  // R15 (DstSum), Z5 (DstOff), Z7 (DstLen), Z6 (DstEnd), K1 (DstMask)
+  BC_HORIZONTAL_LENGTH_SUM(OUT(R15), OUT(Z5), OUT(Z7), OUT(Z6), OUT(K1), IN(Z4), IN(K1), X9, K2)
+  BC_ALLOC_SLICE(OUT(Z8), IN(R15), R8, R13)            // Z8 <- offset of the allocated buffer
+  VPADDD.Z Z5, Z8, K1, Z8                              // Z8 <- output offsets
+
+  VMOVDQU32 Z2, BC_SPILL_AREA(0)
+  VMOVDQU32 Z3, BC_SPILL_AREA(64)
+  VMOVDQU32 Z8, BC_SPILL_AREA(128)
+  KMOVW K1, R8                                         // R8 <- lanes to convert
+
+  VBROADCASTI32X4 CONST_GET_PTR(hex_digits, 0), Z10    // Z10 <- hexadecimal digits
+  VPBROADCASTD CONSTD_0x0F000F00(), Z11
+  MOVQ $-1, DX
+
+  TESTL R8, R8
+  JZ done
+
+lane_iter:
+  TZCNTL R8, R14                                       // R14 <- index of the lane to convert
+  BLSRL R8, R8                                         // R8 <- clear the index of the iterator
+  MOVL BC_SPILL_AREA_INDEX(64, R14*4), CX              // CX <- input length
+  MOVL BC_SPILL_AREA_INDEX(128, R14*4), R15
+  MOVL BC_SPILL_AREA_INDEX(0, R14*4), R14
+  ADDQ VIRT_BASE, R15                                  // R15 <- output pointer
+  ADDQ VIRT_BASE, R14                                  // R14 <- input pointer
+
+  TESTL CX, CX
+  JZ lane_next
+
+chunk:
+  MOVL $16, BX
+  CMPL CX, BX
+  CMOVLLT CX, BX                                       // BX <- number of bytes to convert
+  BZHIQ BX, DX, R11
+  KMOVW R11, K2                                        // K2 <- input mask
+  LEAL 0(BX)(BX*1), R11
+  BZHIQ R11, DX, R11
+  KMOVD R11, K3                                        // K3 <- output mask
+
+  VPMOVZXBW.Z 0(R14), K2, Y12                          // Y12 <- bytes as 16-bit words
+  VPSRLW $4, Y12, Y13                                  // Y13 <- high nibbles in the low bytes
+  VPSLLW $8, Y12, Y12
+  VPANDD Y11, Y12, Y12                                 // Y12 <- low nibbles in the high bytes
+  VPORD Y13, Y12, Y12
+  VPSHUFB Y12, Y10, Y12                                // Y12 <- hexadecimal digits
+  VMOVDQU8 Y12, K3, 0(R15)
+
+  ADDQ BX, R14
+  LEAQ 0(R15)(BX*2), R15
+  SUBL BX, CX
+  JNZ chunk
+
+lane_next:
+  TESTL R8, R8
+  JNZ lane_iter
+
+done:
+  BC_UNPACK_2xSLOT(0, OUT(DX), OUT(R8))
+  BC_STORE_SLICE_TO_SLOT(IN(Z8), IN(Z7), IN(DX))
+  BC_STORE_K_TO_SLOT(IN(K1), IN(R8))
+  NEXT_ADVANCE(BC_SLOT_SIZE*4)
+
+  _BC_ERROR_HANDLER_MORE_SCRATCH()
+
+// slice[0].k[1] = fromhex(slice[2]).k[3]
+//
+// Decodes the hexadecimal strings in slice[2], 32 characters at a time;
+// strings of odd length and strings with characters that are not
+// hexadecimal digits cannot be decoded
+//
+// scratch: PageSize
+TEXT bcfromhex(SB), NOSPLIT|NOFRAME, $0
+  BC_UNPACK_2xSLOT(BC_SLOT_SIZE*2, OUT(BX), OUT(R8))
+  BC_LOAD_K1_FROM_SLOT(OUT(K1), IN(R8))
+  BC_LOAD_SLICE_FROM_SLOT_MASKED(OUT(Z2), OUT(Z3), IN(BX), IN(K1))
+
+  VPTESTNMD.BCST CONSTD_1(), Z3, K1, K1                // K1 <- strings of even length
+  VPSRLD $1, Z3, Z4                                    // Z4 <- output lengths
+
+  // R15 (DstSum), Z5 (DstOff), Z7 (DstLen), Z6 (DstEnd), K1 (DstMask)
+  BC_HORIZONTAL_LENGTH_SUM(OUT(R15), OUT(Z5), OUT(Z7), OUT(Z6), OUT(K1), IN(Z4), IN(K1), X9, K2)
+  BC_ALLOC_SLICE(OUT(Z8), IN(R15), R8, R13)            // Z8 <- offset of the allocated buffer
+  VPADDD.Z Z5, Z8, K1, Z8                              // Z8 <- output offsets
+
+  VMOVDQU32 Z2, BC_SPILL_AREA(0)
+  VMOVDQU32 Z3, BC_SPILL_AREA(64)
+  VMOVDQU32 Z8, BC_SPILL_AREA(128)
+  KMOVW K1, BC_SPILL_AREA(192)
+  KMOVW K1, R8                                         // R8 <- lanes to convert
+
+  VPBROADCASTB CONSTD_48(), Y10                        // Y10 <- '0'
+  VPBROADCASTB CONSTD_10(), Y11                        // Y11 <- 10
+  VPBROADCASTB CONSTD_32(), Y12                        // Y12 <- 0x20 (lowercase bit)
+  VPBROADCASTB CONSTD_97(), Y13                        // Y13 <- 'a'
+  VPBROADCASTB CONSTD_6(), Y14                         // Y14 <- 6
+  VPBROADCASTD CONSTD_0x01100110(), Y15                // Y15 <- [16, 1] weights of the digits
+  MOVQ $-1, DX
+
+  TESTL R8, R8
+  JZ done
+
+lane_iter:
+  TZCNTL R8, R14                                       // R14 <- index of the lane to convert
+  BLSRL R8, R8                                         // R8 <- clear the index of the iterator
+  MOVL R14, BC_SPILL_AREA(196)
+  MOVL BC_SPILL_AREA_INDEX(64, R14*4), CX              // CX <- input length
+  MOVL BC_SPILL_AREA_INDEX(128, R14*4), R15
+  MOVL BC_SPILL_AREA_INDEX(0, R14*4), R14
+  ADDQ VIRT_BASE, R15                                  // R15 <- output pointer
+  ADDQ VIRT_BASE, R14                                  // R14 <- input pointer
+
+  TESTL CX, CX
+  JZ lane_next
+
+chunk:
+  MOVL $32, BX
+  CMPL CX, BX
+  CMOVLLT CX, BX                                       // BX <- number of characters to convert
+  BZHIQ BX, DX, R11
+  KMOVD R11, K2                                        // K2 <- input mask
+
+  VMOVDQU8.Z 0(R14), K2, Y16                           // Y16 <- characters
+  VPSUBB Y10, Y16, Y17                                 // Y17 <- values of decimal digits
+  VPCMPUB $VPCMP_IMM_LT, Y11, Y17, K2, K3              // K3 <- decimal digits
+  VPORD Y12, Y16, Y18
+  VPSUBB Y13, Y18, Y18
+  VPCMPUB $VPCMP_IMM_LT, Y14, Y18, K2, K4              // K4 <- letters 'a'..'f' (either case)
+  KORD K3, K4, K5
+  KXORD K2, K5, K5
+  KTESTD K5, K5
+  JNZ invalid
+
+  VPADDB Y11, Y18, K4, Y17                             // Y17 <- values of the digits
+  VPMADDUBSW Y15, Y17, Y17                             // Y17 <- 16*hi + lo as 16-bit words
+  VPMOVWB Y17, X17                                     // X17 <- decoded bytes
+
+  SHRL $1, BX                                          // BX <- number of decoded bytes
+  BZHIQ BX, DX, R11
+  KMOVW R11, K3                                        // K3 <- output mask
+  VMOVDQU8 X17, K3, 0(R15)
+
+  ADDQ BX, R15
+  LEAQ 0(R14)(BX*2), R14
+  SUBL BX, CX
+  SUBL BX, CX
+  JNZ chunk
+  JMP lane_next
+
+invalid:
+  MOVL BC_SPILL_AREA(196), R14
+  BTRL R14, BC_SPILL_AREA(192)                         // [] <- the lane is MISSING
+
+lane_next:
+  TESTL R8, R8
+  JNZ lane_iter
+
+done:
+  KMOVW BC_SPILL_AREA(192), K1
+  VMOVDQA32.Z Z7, K1, Z7
+  BC_UNPACK_2xSLOT(0, OUT(DX), OUT(R8))
+  BC_STORE_SLICE_TO_SLOT(IN(Z8), IN(Z7), IN(DX))
+  BC_STORE_K_TO_SLOT(IN(K1), IN(R8))
+  NEXT_ADVANCE(BC_SLOT_SIZE*4)
+
+  _BC_ERROR_HANDLER_MORE_SCRATCH()
+
+// slice[0].k[1] = tobase64(v[2], imm16[3]).k[4]
+//
+// Encodes the contents of the strings and blobs in v[2] in base64, 3 bytes
+// at a time; other values are MISSING. If imm16[3] is 1, the URL-safe alphabet
+// is used without padding, otherwise the standard alphabet is used with padding.
+//
+// scratch: PageSize
+TEXT bctobase64(SB), NOSPLIT|NOFRAME, $0
+  BC_UNPACK_SLOT(BC_SLOT_SIZE*2, OUT(BX))
+  BC_UNPACK_SLOT(BC_SLOT_SIZE*3 + BC_IMM16_SIZE, OUT(R8))
+  BC_LOAD_K1_FROM_SLOT(OUT(K1), IN(R8))
+
+  BC_LOAD_VALUE_TYPEL_FROM_SLOT(OUT(Z6), IN(BX))
+  VPSRLD $4, Z6, Z7
+  VPORD.BCST CONSTD_2(), Z7, Z7
+  VPCMPEQD.BCST CONSTD_0x0A(), Z7, K1, K1              // K1 <- strings (0x8) and blobs (0xA)
+  VPANDD.BCST CONSTD_0x0F(), Z6, Z6
+  VPCMPD.BCST $VPCMP_IMM_NE, CONSTD_0x0F(), Z6, K1, K1 // K1 <- not NULL
+  BC_LOAD_VALUE_HLEN_FROM_SLOT(OUT(Z6), IN(BX))
+  VPADDD.Z BC_VSTACK_PTR(BX, 0), Z6, K1, Z2            // Z2 <- offsets of the contents
+  VMOVDQU32 BC_VSTACK_PTR(BX, 64), Z3
+  VPSUBD.Z Z6, Z3, K1, Z3                              // Z3 <- lengths of the contents
+
+  // the number of groups of 3 bytes (including a partial group)
+  // is (n+2)/3, computed as ((n+2)*0xAAAAAAAB) >> 33
+  VPADDD.BCST CONSTD_2(), Z3, Z4
+  VPBROADCASTD CONSTD_0xAAAAAAAB(), Z5
+  VPSRLQ $32, Z4, Z6
+  VPMULUDQ Z5, Z4, Z4
+  VPMULUDQ Z5, Z6, Z6
+  VPSRLQ $33, Z4, Z4
+  VPSRLQ $1, Z6, Z6
+  MOVL $0xAAAA, R8
+  KMOVW R8, K2
+  VMOVDQA32 Z6, K2, Z4                                 // Z4 <- number of groups
+
+  BC_UNPACK_RU16(BC_SLOT_SIZE*3, OUT(R13))             // R13 <- 1 for the URL-safe alphabet
+  TESTL R13, R13
+  JNZ url_lengths
+  VPSLLD $2, Z4, Z4                                    // Z4 <- 4 characters per group (padded)
+  JMP alloc
+url_lengths:
+  VPADDD Z3, Z4, Z4                                    // Z4 <- 4 characters per 3 bytes (unpadded)
+
+alloc:
+  // R15 (DstSum), Z5 (DstOff), Z7 (DstLen), Z6 (DstEnd), K1 (DstMask)
+  BC_HORIZONTAL_LENGTH_SUM(OUT(R15), OUT(Z5), OUT(Z7), OUT(Z6), OUT(K1), IN(Z4), IN(K1), X9, K2)
+  BC_ALLOC_SLICE(OUT(Z8), IN(R15), R8, R11)            // Z8 <- offset of the allocated buffer
+  VPADDD.Z Z5, Z8, K1, Z8                              // Z8 <- output offsets
+
+  VMOVDQU32 Z2, BC_SPILL_AREA(0)
+  VMOVDQU32 Z3, BC_SPILL_AREA(64)
+  VMOVDQU32 Z8, BC_SPILL_AREA(128)
+  KMOVW K1, R8                                         // R8 <- lanes to convert
+
+  LEAQ CONST_GET_PTR(base64_alphabet, 0), DX
+  SHLL $6, R13
+  ADDQ R13, DX                                         // DX <- alphabet
+
+  TESTL R8, R8
+  JZ done
+
+lane_iter:
+  TZCNTL R8, R14                                       // R14 <- index of the lane to convert
+  BLSRL R8, R8                                         // R8 <- clear the index of the iterator
+  MOVL BC_SPILL_AREA_INDEX(64, R14*4), CX              // CX <- input length
+  MOVL BC_SPILL_AREA_INDEX(128, R14*4), R15
+  MOVL BC_SPILL_AREA_INDEX(0, R14*4), R14
+  ADDQ VIRT_BASE, R15                                  // R15 <- output pointer
+  ADDQ VIRT_BASE, R14                                  // R14 <- input pointer
+
+group:
+  CMPL CX, $3
+  JB tail
+  MOVBLZX 0(R14), BX
+  SHLL $16, BX
+  MOVBLZX 1(R14), R11
+  SHLL $8, R11
+  ORL R11, BX
+  MOVBLZX 2(R14), R11
+  ORL R11, BX                                          // BX <- 24 bits of the group
+
+  MOVL BX, R11
+  SHRL $18, R11
+  MOVBLZX 0(DX)(R11*1), R11
+  MOVB R11, 0(R15)
+  MOVL BX, R11
+  SHRL $12, R11
+  ANDL $63, R11
+  MOVBLZX 0(DX)(R11*1), R11
+  MOVB R11, 1(R15)
+  MOVL BX, R11
+  SHRL $6, R11
+  ANDL $63, R11
+  MOVBLZX 0(DX)(R11*1), R11
+  MOVB R11, 2(R15)
+  ANDL $63, BX
+  MOVBLZX 0(DX)(BX*1), BX
+  MOVB BX, 3(R15)
+
+  ADDQ $3, R14
+  ADDQ $4, R15
+  SUBL $3, CX
+  JMP group
+
+tail:
+  TESTL CX, CX
+  JZ lane_next
+  MOVBLZX 0(R14), BX
+  SHLL $16, BX
+  CMPL CX, $1
+  JEQ tail_bits
+  MOVBLZX 1(R14), R11
+  SHLL $8, R11
+  ORL R11, BX                                          // BX <- 8 or 16 bits of the partial group
+
+tail_bits:
+  MOVL BX, R11
+  SHRL $18, R11
+  MOVBLZX 0(DX)(R11*1), R11
+  MOVB R11, 0(R15)
+  MOVL BX, R11
+  SHRL $12, R11
+  ANDL $63, R11
+  MOVBLZX 0(DX)(R11*1), R11
+  MOVB R11, 1(R15)
+  CMPL CX, $1
+  JEQ tail_pad
+  SHRL $6, BX
+  ANDL $63, BX
+  MOVBLZX 0(DX)(BX*1), BX
+  MOVB BX, 2(R15)
+
+tail_pad:
+  TESTL R13, R13                                       // no padding in the URL-safe alphabet
+  JNZ lane_next
+  MOVB $'=', 3(R15)
+  CMPL CX, $2
+  JEQ lane_next
+  MOVB $'=', 2(R15)
+
+lane_next:
+  TESTL R8, R8
+  JNZ lane_iter
+
+done:
+  BC_UNPACK_2xSLOT(0, OUT(DX), OUT(R8))
+  BC_STORE_SLICE_TO_SLOT(IN(Z8), IN(Z7), IN(DX))
+  BC_STORE_K_TO_SLOT(IN(K1), IN(R8))
+  NEXT_ADVANCE(BC_SLOT_SIZE*4 + BC_IMM16_SIZE)
+
+  _BC_ERROR_HANDLER_MORE_SCRATCH()
+
+// slice[0].k[1] = frombase64(slice[2], imm16[3]).k[4]
+//
+// Decodes the base64 strings in slice[2], 4 characters at a time, with the
+// URL-safe alphabet if imm16[3] is 1 and with the standard alphabet otherwise.
+// Trailing padding is ignored; strings with characters that are not in the
+// alphabet and strings with a dangling character cannot be decoded.
+//
+// scratch: PageSize
+TEXT bcfrombase64(SB), NOSPLIT|NOFRAME, $0
+  BC_UNPACK_SLOT(BC_SLOT_SIZE*2, OUT(BX))
+  BC_LOAD_SLICE_FROM_SLOT(OUT(Z2), OUT(Z3), IN(BX))
+  BC_UNPACK_SLOT(BC_SLOT_SIZE*3 + BC_IMM16_SIZE, OUT(R8))
+  BC_LOAD_K1_FROM_SLOT(OUT(K1), IN(R8))
+
+  // strip the padding of every lane
+  VMOVDQU32 Z2, BC_SPILL_AREA(0)
+  VMOVDQA32.Z Z3, K1, Z3
+  VMOVDQU32 Z3, BC_SPILL_AREA(64)
+  KMOVW K1, R8
+
+  TESTL R8, R8
+  JZ padding_done
+
+padding_lane:
+  TZCNTL R8, R13
+  BLSRL R8, R8
+  MOVL BC_SPILL_AREA_INDEX(64, R13*4), CX
+  MOVL BC_SPILL_AREA_INDEX(0, R13*4), R14
+  ADDQ VIRT_BASE, R14
+
+padding_char:
+  TESTL CX, CX
+  JZ padding_next
+  CMPB -1(R14)(CX*1), $'='
+  JNE padding_next
+  DECL CX
+  JMP padding_char
+
+padding_next:
+  MOVL CX, BC_SPILL_AREA_INDEX(64, R13*4)
+  TESTL R8, R8
+  JNZ padding_lane
+
+padding_done:
+  VMOVDQU32 BC_SPILL_AREA(64), Z3                      // Z3 <- lengths without padding
+  VPANDD.BCST CONSTD_3(), Z3, Z4
+  VPCMPD.BCST $VPCMP_IMM_NE, CONSTD_1(), Z4, K1, K1    // K1 <- no dangling character
+  VPADDD Z3, Z3, Z4
+  VPADDD Z3, Z4, Z4
+  VPSRLD $2, Z4, Z4                                    // Z4 <- output lengths (3 bytes per 4 characters)
+
+  // R15 (DstSum), Z5 (DstOff), Z7 (DstLen), Z6 (DstEnd), K1 (DstMask)
+  BC_HORIZONTAL_LENGTH_SUM(OUT(R15), OUT(Z5), OUT(Z7), OUT(Z6), OUT(K1), IN(Z4), IN(K1), X9, K2)
+  BC_ALLOC_SLICE(OUT(Z8), IN(R15), R8, R13)            // Z8 <- offset of the allocated buffer
+  VPADDD.Z Z5, Z8, K1, Z8                              // Z8 <- output offsets
+
+  VMOVDQU32 Z8, BC_SPILL_AREA(128)
+  KMOVW K1, BC_SPILL_AREA(192)
+  KMOVW K1, R8                                         // R8 <- lanes to convert
+
+  BC_UNPACK_RU16(BC_SLOT_SIZE*3, OUT(DX))              // DX <- 1 for the URL-safe alphabet
+  SHLL $8, DX
+  LEAQ CONST_GET_PTR(base64_values, 0), R11
+  ADDQ R11, DX                                         // DX <- values of the characters
+
+  TESTL R8, R8
+  JZ done
+
+lane_iter:
+  TZCNTL R8, R14                                       // R14 <- index of the lane to convert
+  BLSRL R8, R8                                         // R8 <- clear the index of the iterator
+  MOVL R14, BC_SPILL_AREA(196)
+  MOVL BC_SPILL_AREA_INDEX(64, R14*4), CX              // CX <- input length
+  MOVL BC_SPILL_AREA_INDEX(128, R14*4), R15
+  MOVL BC_SPILL_AREA_INDEX(0, R14*4), R14
+  ADDQ VIRT_BASE, R15                                  // R15 <- output pointer
+  ADDQ VIRT_BASE, R14                                  // R14 <- input pointer
+
+group:
+  CMPL CX, $4
+  JB tail
+  XORL BX, BX
+  MOVL $4, R13
+
+group_char:
+  MOVBLZX 0(R14), R11
+  MOVBLZX 0(DX)(R11*1), R11
+  CMPL R11, $63
+  JA invalid
+  SHLL $6, BX
+  ORL R11, BX                                          // BX <- 6 bits per character
+  INCQ R14
+  DECL R13
+  JNZ group_char
+
+  BSWAPL BX
+  SHRL $8, BX
+  MOVW BX, 0(R15)
+  SHRL $16, BX
+  MOVB BX, 2(R15)
+  ADDQ $3, R15
+  SUBL $4, CX
+  JMP group
+
+  // the last group has either 2 characters (1 byte)
+  // or 3 characters (2 bytes)
+tail:
+  TESTL CX, CX
+  JZ lane_next
+  XORL BX, BX
+  MOVL CX, R13
+
+tail_char:
+  MOVBLZX 0(R14), R11
+  MOVBLZX 0(DX)(R11*1), R11
+  CMPL R11, $63
+  JA invalid
+  SHLL $6, BX
+  ORL R11, BX                                          // BX <- 6 bits per character
+  INCQ R14
+  DECL R13
+  JNZ tail_char
+
+  CMPL CX, $3
+  JNE tail_1
+  SHRL $2, BX
+  ROLW $8, BX
+  MOVW BX, 0(R15)
+  JMP lane_next
+
+tail_1:
+  SHRL $4, BX
+  MOVB BX, 0(R15)
+  JMP lane_next
+
+invalid:
+  MOVL BC_SPILL_AREA(196), R14
+  BTRL R14, BC_SPILL_AREA(192)                         // [] <- the lane is MISSING
+
+lane_next:
+  TESTL R8, R8
+  JNZ lane_iter
+
+done:
+  KMOVW BC_SPILL_AREA(192), K1
+  VMOVDQA32.Z Z7, K1, Z7
+  BC_UNPACK_2xSLOT(0, OUT(DX), OUT(R8))
+  BC_STORE_SLICE_TO_SLOT(IN(Z8), IN(Z7), IN(DX))
+  BC_STORE_K_TO_SLOT(IN(K1), IN(R8))
+  NEXT_ADVANCE(BC_SLOT_SIZE*4 + BC_IMM16_SIZE)
+
+  _BC_ERROR_HANDLER_MORE_SCRATCH()
@@ -19,7 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"net"
+	"net/netip"
 	"slices"
 	"unicode/utf8"
 
@@ -148,9 +148,6 @@ func compile(p *prog, e expr.Node) (*value, error) {
 			}
 			// NOTE: We do not implement the escape char from the SQL SIMILAR TO syntax, backslash is the only used escape-char
 			regexStr := n.Pattern
-			if err := regexp2.IsSupported(regexStr); err != nil {
-				return nil, fmt.Errorf("regex %v is not supported: %v", regexStr, err)
-			}
 			regexType := regexp2.SimilarTo
 			if n.Op == expr.RegexpMatch {
 				regexType = regexp2.Regexp
@@ -161,17 +158,26 @@ func compile(p *prog, e expr.Node) (*value, error) {
 			if err != nil {
 				return nil, err
 			}
-			dfaStore, err := regexp2.CompileDFA(regex, regexp2.MaxNodesAutomaton)
+			dfaStore, err := compileRegex(regexStr, regex)
 			if err != nil {
-				return nil, fmt.Errorf("Error: %v; construction of DFA from regex %v failed", err, regex)
+				errorf("regex %q cannot be matched with a DFA (%v); matching it with Go's regexp", regexStr, err)
+				return p.regexMatchGo(n.Expr, regex)
 			}
 
-			const escRune = '\\' // backslash is the only used escape-char
-			if regexPrefixStr := stringext.LiteralPrefix(regexStr, escRune); regexPrefixStr != "" {
-				contains := p.contains(left, stringext.Needle(regexPrefixStr), true)
-				return p.regexMatch(left, dfaStore, p.mask(contains))
+			mask := p.mask(left)
+			if prefix := regexLiteralPrefix(regexStr, regexType); prefix != "" {
+				// only the strings that contain the prefix can match
+				mask = p.mask(p.contains(left, stringext.Needle(prefix), true))
+			}
+			inner, err := p.regexMatch(left, dfaStore, mask)
+			if err != nil {
+				return nil, err
 			}
-			return p.regexMatch(left, dfaStore, p.mask(left))
+			// the bool-typed result is just the opcode mask
+			ret := p.ssa1(snotmissing, inner)
+			// the missing-ness of the result is the string-ness of the argument
+			ret.notMissing = p.mask(left)
+			return ret, nil
 		}
 		return nil, fmt.Errorf("unimplemented StringMatch operation")
 	case *expr.UnaryArith:
@@ -268,6 +274,13 @@ func compile(p *prog, e expr.Node) (*value, error) {
 			return nil, err
 		}
 		return p.index(inner, n.Offset), nil
+	case *expr.Slice:
+		inner, err := compile(p, n.Inner)
+		if err != nil {
+			return nil, err
+		}
+		l := p.arraySlice(inner, n.From, n.To, n.ToEnd)
+		return p.ssa2(sboxlist, l, p.mask(l)), nil
 	case *expr.IsKey:
 		inner, err := compile(p, n.Expr)
 		if err != nil {
@@ -507,6 +520,15 @@ func compilefuncaux(p *prog, b *expr.Builtin, args []expr.Node) (*value, error)
 		return p.dateAdd(part, val[0], val[1]), nil
 	}
 
+	if fn == expr.DateAddMonthClamp {
+		val, err := compileargs(p, args, compileNumber, compileTime)
+		if err != nil {
+			return nil, err
+		}
+
+		return p.dateAddMonthClamp(val[0], val[1]), nil
+	}
+
 	if fn.IsDateDiff() {
 		part, _ := fn.TimePart()
 		val, err := compileargs(p, args, compileTime, compileTime)
@@ -725,6 +747,17 @@ func compilefuncaux(p *prog, b *expr.Builtin, args []expr.Node) (*value, error)
 		}
 		return p.concat(sargs...), nil
 
+	case expr.ConcatWs:
+		sargs := make([]*value, len(args))
+		for i := range args {
+			sarg, err := p.compileAsString(args[i])
+			if err != nil {
+				return nil, err
+			}
+			sargs[i] = sarg
+		}
+		return p.concatWs(sargs[0], sargs[1:]...), nil
+
 	case expr.Least, expr.Greatest:
 		least := fn == expr.Least
 		count := len(args)
@@ -733,6 +766,21 @@ func compilefuncaux(p *prog, b *expr.Builtin, args []expr.Node) (*value, error)
 			return nil, fmt.Errorf("expects at least one argument")
 		}
 
+		numeric, generic := true, count == 1
+		for i := range args {
+			t := expr.TypeOf(args[i], nil)
+			if !t.Only(expr.NumericType | expr.MissingType) {
+				numeric = false
+			}
+			if t&expr.NumericType == 0 {
+				generic = true
+			}
+		}
+		if !numeric && generic {
+			// no lane can be computed as numbers
+			return p.leastGreatest(least, args, nil)
+		}
+
 		val, err := p.compileAsNumber(args[0])
 		if err != nil {
 			return nil, err
@@ -751,7 +799,10 @@ func compilefuncaux(p *prog, b *expr.Builtin, args []expr.Node) (*value, error)
 			}
 		}
 
-		return val, nil
+		if numeric {
+			return val, nil
+		}
+		return p.leastGreatest(least, args, val)
 
 	case expr.WidthBucket:
 		v, err := compileargs(p, args, compileNumber, compileNumber, compileNumber, compileNumber)
@@ -848,11 +899,19 @@ func compilefuncaux(p *prog, b *expr.Builtin, args []expr.Node) (*value, error)
 		maxStr, _ := args[1].(expr.String)
 		lhs := v[2]
 
+		min, err := netip.ParseAddr(string(minStr))
+		if err != nil {
+			return nil, err
+		}
+		max, err := netip.ParseAddr(string(maxStr))
+		if err != nil {
+			return nil, err
+		}
+		if !min.Is4() || !max.Is4() {
+			return p.isSubnetOfIP6(lhs, min.As16(), max.As16()), nil
+		}
 		// the min/max are byte wise min/max values encoded as a string with dot as a separator.
-		min := (*[4]byte)(net.ParseIP(string(minStr)).To4())
-		max := (*[4]byte)(net.ParseIP(string(maxStr)).To4())
-
-		return p.isSubnetOfIP4(lhs, *min, *max), nil
+		return p.isSubnetOfIP4(lhs, min.As4(), max.As4()), nil
 
 	case expr.OctetLength:
 		v, err := compileargs(p, args, compileString)
@@ -889,9 +948,78 @@ func compilefuncaux(p *prog, b *expr.Builtin, args []expr.Node) (*value, error)
 		delimiterStr := args[1].(expr.String)
 		splitPartIndex := v[2]
 
+		if expr.SplitPartRegexp(delimiterStr) {
+			return p.splitPartRegexp(lhs, string(delimiterStr), splitPartIndex)
+		}
 		return p.splitPart(lhs, delimiterStr[0], splitPartIndex), nil
 
+	case expr.RegexpExtract:
+		if len(args) != 2 && len(args) != 3 {
+			return nil, fmt.Errorf("REGEXP_EXTRACT expects 2 or 3 arguments, but found %d", len(args))
+		}
+		pattern, ok := args[1].(expr.String)
+		if !ok {
+			return nil, fmt.Errorf("REGEXP_EXTRACT: pattern %s is not a string literal", expr.ToString(args[1]))
+		}
+		group := expr.Integer(0)
+		if len(args) == 3 {
+			group, ok = args[2].(expr.Integer)
+			if !ok {
+				return nil, fmt.Errorf("REGEXP_EXTRACT: group %s is not an integer literal", expr.ToString(args[2]))
+			}
+		}
+		return p.regexpExtract(args[0], string(pattern), int(group))
+
+	case expr.Replace, expr.Translate:
+		if len(args) != 3 {
+			return nil, fmt.Errorf("%s expects 3 arguments, but found %d", fn, len(args))
+		}
+		if fn == expr.Replace {
+			return p.replace(args[0], args[1], args[2])
+		}
+		return p.scalarCall(translateFn{}, args...)
+
+	case expr.Soundex:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("SOUNDEX expects 1 argument, but found %d", len(args))
+		}
+		return p.scalarCall(soundexFn{}, args...)
+
+	case expr.Difference:
+		if len(args) != 2 {
+			return nil, fmt.Errorf("DIFFERENCE expects 2 arguments, but found %d", len(args))
+		}
+		return p.scalarCall(differenceFn{}, args...)
+
+	case expr.ToHex, expr.FromHex, expr.ToBase64, expr.FromBase64:
+		return p.transcode(fn, args)
+
+	case expr.ToJSON:
+		return p.toJSON(args)
+
+	case expr.NullIfNonFinite:
+		return p.nullIfNonFinite(args)
+
+	case expr.AssertFinite:
+		return p.assertFinite(args)
+
+	case expr.IsJSON, expr.IsJSONObject, expr.IsJSONArray:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s expects 1 argument, got %d", fn, len(args))
+		}
+		return p.isJSON(args[0], fn)
+
+	case expr.Overlaps:
+		v, err := compileargs(p, args, compileTime, compileTime, compileTime, compileTime)
+		if err != nil {
+			return nil, err
+		}
+		return p.overlaps(v[0], v[1], v[2], v[3]), nil
+
 	case expr.Unspecified:
+		if udf, ok := expr.LookupScalar(b.Name()); ok {
+			return p.scalarUDF(b.Name(), udf, args)
+		}
 		return nil, fmt.Errorf("unhandled builtin %q", b.Name())
 
 	case expr.ToUnixEpoch:
@@ -910,6 +1038,14 @@ func compilefuncaux(p *prog, b *expr.Builtin, args []expr.Node) (*value, error)
 
 		return p.dateToUnixMicro(v[0]), nil
 
+	case expr.DateExtractEpoch:
+		v, err := compileargs(p, args, compileTime)
+		if err != nil {
+			return nil, err
+		}
+
+		return p.dateExtractEpoch(v[0]), nil
+
 	case expr.GeoHash, expr.GeoTileES:
 		v, err := compileargs(p, args, compileNumber, compileNumber, compileNumber)
 		if err != nil {
@@ -1031,6 +1167,18 @@ func compilefuncaux(p *prog, b *expr.Builtin, args []expr.Node) (*value, error)
 		}
 		return v, nil
 
+	case expr.CollateCI:
+		// the collation only affects grouping
+		// (see groupKey); the value is unchanged
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s expects 1 argument, got %d", fn, len(args))
+		}
+		return compile(p, args[0])
+	case expr.DecimalToFloat:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s expects 1 argument, got %d", fn, len(args))
+		}
+		return p.scalarCall(decimalToFloatFn{}, args[0])
 	case expr.MakeList:
 		if len(args) == 0 {
 			return nil, fmt.Errorf("%s failed to perform constant propagation (empty list must be a constant)", fn)
@@ -1554,6 +1702,31 @@ func (p *prog) serialized(e expr.Node) (*value, error) {
 	}
 }
 
+// groupKey compiles a GROUP BY or DISTINCT column,
+// returning the unsymbolized value of the column
+// and the value that identifies its group when hashed.
+// For a case-insensitive column (x COLLATE ci), strings
+// are hashed in lower case, so a group keeps the value
+// of the first row that was assigned to it.
+func (p *prog) groupKey(e expr.Node) (col, key *value, err error) {
+	ci := false
+	if b, ok := e.(*expr.Builtin); ok && b.Func == expr.CollateCI {
+		e, ci = b.Args[0], true
+	}
+	col, err = p.serialized(e)
+	if err != nil {
+		return nil, nil, err
+	}
+	// we always want to hash the *unsymbolized* value
+	col = p.unsymbolized(col)
+	if !ci {
+		return col, col, nil
+	}
+	low := p.lower(p.coerceStr(col))
+	folded := p.ssa2(sboxstr, low, p.mask(low))
+	return col, p.ssa4(sblendv, col, p.mask(col), folded, p.mask(folded)), nil
+}
+
 // turn an arbitrary expression into a store-able value
 func (p *prog) compileStore(mem *value, e expr.Node, slot stackslot, unsymbolize bool) (*value, error) {
 	v, err := p.serialized(e)
@@ -1671,11 +1844,6 @@ func (p *prog) compileGenericCase(c *expr.Case) (*value, error) {
 			panic("unexpected return type in compileGenericCase()")
 		}
 
-		thenK := p.and(p.mask(thenV), when)
-		if thenK.op == skfalse {
-			continue
-		}
-
 		if elseV != nil {
 			if matched != nil {
 				matched = p.or(matched, when)
@@ -1684,11 +1852,22 @@ func (p *prog) compileGenericCase(c *expr.Case) (*value, error) {
 			}
 		}
 
+		// lanes matching <WHEN> take this limb
+		// even if it is MISSING, so they must not
+		// keep the results of the following limbs
+		thenK := p.and(p.mask(thenV), when)
+		if thenK.op == skfalse {
+			if outV != nil {
+				outK = p.andn(when, outK)
+			}
+			continue
+		}
+
 		if outV == nil {
 			outV = thenV
 			outK = thenK
 		} else {
-			outV = p.ssa4(sblendv, outV, outK, thenV, thenK)
+			outV = p.ssa4(sblendv, outV, p.andn(when, outK), thenV, thenK)
 			outK = outV
 		}
 	}
@@ -1701,6 +1880,9 @@ func (p *prog) compileGenericCase(c *expr.Case) (*value, error) {
 		} else {
 			outV = elseV
 			outK = elseK
+			if matched != nil {
+				outK = p.andn(matched, elseK)
+			}
 		}
 	}
 
@@ -1763,8 +1945,11 @@ func (p *prog) compileNumericCase(c *expr.Case) (*value, error) {
 
 		if outV == nil {
 			outV, outK = thenV, thenK
+		} else if thenK.op == skfalse {
+			// a MISSING limb still takes the lanes matching <WHEN>
+			outK = p.andn(when, outK)
 		} else {
-			outV = p.ssa4(sblendf64, outV, outK, thenV, thenK)
+			outV = p.ssa4(sblendf64, outV, p.andn(when, outK), thenV, thenK)
 			outK = outV
 		}
 	}
@@ -1813,8 +1998,12 @@ func (p *prog) compileCast(c *expr.Cast) (*value, error) {
 			return from, nil
 		case stFloat:
 			return p.ssa2(sroundi, from, p.mask(from)), nil
+		case stTime:
+			return p.dateToEpochMillis(from), nil
 		case stValue:
-			return p.ssa2(sunboxcvti64, from, p.mask(from)), nil
+			num := p.ssa2(sunboxcvti64, from, p.mask(from))
+			ms := p.dateToEpochMillis(from)
+			return p.ssa4(sblendi64, num, p.mask(num), ms, p.mask(ms)), nil
 		default:
 			return p.missing(), nil
 		}
@@ -1848,8 +2037,13 @@ func (p *prog) compileCast(c *expr.Cast) (*value, error) {
 		switch from.primary() {
 		case stTime:
 			return from, nil
+		case stInt:
+			return p.epochMillisToDate(from), nil
 		case stValue:
-			return p.checkTag(from, c.To), nil
+			ts := p.checkTag(from, c.To)
+			ms := p.epochMillisToDate(p.checkTag(from, expr.IntegerType))
+			boxed := p.ssa2(sboxts, ms, p.mask(ms))
+			return p.ssa4(sblendv, ts, p.mask(ts), boxed, p.mask(boxed)), nil
 		default:
 			return p.missing(), nil
 		}
@@ -1871,6 +2065,85 @@ func (p *prog) compileCast(c *expr.Cast) (*value, error) {
 	}
 }
 
+// leastGreatest compiles LEAST or GREATEST of arguments
+// of any type; the arguments are ordered as in ORDER BY,
+// so booleans sort before numbers, numbers before
+// timestamps and timestamps before strings
+//
+// The result is NULL if any argument is NULL, and
+// MISSING if any argument is MISSING or has a type
+// that cannot be ordered (decimal, list, struct, etc.)
+//
+// If num is non-nil, it is the result of the numeric
+// min/max of the arguments, and only the lanes that
+// it does not compute are compared as boxed values
+func (p *prog) leastGreatest(least bool, args []expr.Node, num *value) (*value, error) {
+	// NULL sorts first for LEAST and last for GREATEST,
+	// so that a NULL argument is always selected
+	op := ssortcmpvnl
+	if least {
+		op = ssortcmpvnf
+	}
+	var boxed *value
+	rest := p.validLanes()
+	if num != nil {
+		switch num.primary() {
+		case stInt:
+			boxed = p.ssa2(sboxint, num, p.mask(num))
+		case stFloat:
+			boxed = p.ssa2(sboxfloat, num, p.mask(num))
+		}
+		if boxed != nil {
+			rest = p.andn(p.mask(num), rest)
+		}
+	}
+	val, err := p.serialized(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		return p.checkTag(val, expr.SortableType), nil
+	}
+	for i := 1; i < len(args); i++ {
+		rhs, err := p.serialized(args[i])
+		if err != nil {
+			return nil, err
+		}
+		// cmp is missing unless both values can be ordered
+		cmp := p.ssa3(op, val, rhs, p.and(rest, p.and(p.mask(val), p.mask(rhs))))
+		var take *value
+		var tsop ssaop
+		if least {
+			take = p.ssa2imm(scmpgtimmi, cmp, p.mask(cmp), int64(0))
+			tsop = compareOpInfoTable[comparegt].cmpts
+		} else {
+			take = p.ssa2imm(scmpltimmi, cmp, p.mask(cmp), int64(0))
+			tsop = compareOpInfoTable[comparelt].cmpts
+		}
+		// timestamps are ordered regardless of their precision
+		if mayBeTimestamp(val) && mayBeTimestamp(rhs) {
+			lts, lk := p.coerceTimestamp(val)
+			rts, rk := p.coerceTimestamp(rhs)
+			both := p.and(lk, rk)
+			take = p.or(p.ssa3(tsop, lts, rts, both), p.andn(both, take))
+		}
+		val = p.ssa4(sblendv, val, p.mask(cmp), rhs, take)
+	}
+	if boxed != nil {
+		val = p.ssa4(sblendv, val, p.mask(val), boxed, p.mask(boxed))
+	}
+	return val, nil
+}
+
+// mayBeTimestamp returns true if v is
+// a timestamp literal or a boxed value
+func mayBeTimestamp(v *value) bool {
+	if v.op == sliteral {
+		return isTimestampImmediate(v.imm)
+	}
+	return v.primary() == stValue
+}
+
 func (p *prog) checkTag(from *value, typ expr.TypeSet) *value {
 	primary := from.primary()
 	switch primary {
@@ -178,7 +178,9 @@ func (w *wherebc) writeRows(delims []vmref, rp *rowParams) error {
 		return w.dst.writeRows(delims, &w.params)
 	}
 
-	w.bc.prepare(rp)
+	if err := w.bc.prepare(rp, delims); err != nil {
+		return err
+	}
 	var valid int
 	if globalOptimizationLevel >= OptimizationLevelAVX512V1 {
 		valid = evalfilterbc(&w.bc, delims)
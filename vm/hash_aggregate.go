@@ -51,6 +51,8 @@ type HashAggregate struct {
 	order []aggOrderFn
 
 	windows []window
+
+	spill *aggSpill
 }
 
 type aggOrderFn func(*aggtable, int, int) int
@@ -131,9 +133,15 @@ func (h *HashAggregate) groupFn(n int, ordering SortOrdering) aggOrderFn {
 func (h *HashAggregate) aggFn(n int, ordering SortOrdering) aggOrderFn {
 	return func(agt *aggtable, i, j int) int {
 		op := h.aggregateOps[n]
-		lmem := agt.valueof(&agt.pairs[i])
-		rmem := agt.valueof(&agt.pairs[j])
-		dir := aggcmp(op.fn, lmem, rmem)
+		offset := aggregateOpOffset(h.aggregateOps, n)
+		lmem := agt.valueof(&agt.pairs[i])[offset:]
+		rmem := agt.valueof(&agt.pairs[j])[offset:]
+		var dir int
+		if op.goagg != nil {
+			dir = op.goagg.compare(lmem, rmem)
+		} else {
+			dir = aggcmp(op.fn, lmem, rmem)
+		}
 		if ordering.Direction == SortDescending {
 			return -dir
 		}
@@ -207,17 +215,15 @@ func NewHashAggregate(agg, windows Aggregation, by Selection, dst QuerySink) (*H
 	for i, column := range by {
 		field := column.Expr
 
-		col, err := prog.serialized(field)
+		col, key, err := prog.groupKey(field)
 		if err != nil {
 			return nil, err
 		}
-		// we always want to hash the *unsymbolized* value
-		col = prog.unsymbolized(col)
 
 		if allColumnsHash == nil {
-			allColumnsHash = prog.hash(col)
+			allColumnsHash = prog.hash(key)
 		} else {
-			allColumnsHash = prog.hashplus(allColumnsHash, col)
+			allColumnsHash = prog.hashplus(allColumnsHash, key)
 		}
 
 		if allColumnsMask == nil {
@@ -239,6 +245,7 @@ func NewHashAggregate(agg, windows Aggregation, by Selection, dst QuerySink) (*H
 	ops := make([]AggregateOp, len(h.agg))
 	bucket := prog.aggbucket(mem, allColumnsHash, allColumnsMask)
 	offset := aggregateslot(0)
+	keyslot := len(by) // next vstack slot for the ORDER BY columns of STRING_AGG
 
 	for i := range h.agg {
 		var filter *value
@@ -288,7 +295,44 @@ func NewHashAggregate(agg, windows Aggregation, by Selection, dst QuerySink) (*H
 			case expr.AggregateRoleFinal, expr.AggregateRolePartial:
 				out[i] = prog.aggregateSlotApproxCountDistinct(mem, bucket, argv, mask, offset, precision)
 			case expr.AggregateRoleMerge:
-				out[i] = prog.aggregateSlotMergeState(bucket, argv, mask, offset+aggregateslot(ops[i].dataSize()))
+				out[i] = prog.aggregateSlotMergeState(bucket, argv, mask, offset)
+			}
+
+		case expr.OpStringAgg:
+			ops[i].fn = AggregateOpStringAgg
+			ops[i].role = a.Role
+			spec := newStringAggSpec(a, keyslot)
+			ops[i].goagg = spec
+			if a.Role != expr.AggregateRoleMerge {
+				keyslot += len(a.OrderBy)
+			}
+			var err error
+			out[i], err = prog.aggregateSlotStringAgg(a, spec, bucket, mask, offset)
+			if err != nil {
+				return nil, fmt.Errorf("don't know how to aggregate %q: %w", a.Inner, err)
+			}
+
+		case expr.OpTopK, expr.OpMode:
+			ops[i].fn = AggregateOpTopK
+			ops[i].role = a.Role
+			ops[i].goagg = newTopKSpec(a)
+			var err error
+			out[i], err = prog.aggregateSlotTopK(a, bucket, mask, offset)
+			if err != nil {
+				return nil, fmt.Errorf("don't know how to aggregate %q: %w", a.Inner, err)
+			}
+
+		case expr.OpUser:
+			spec, err := newUDAFSpec(a)
+			if err != nil {
+				return nil, err
+			}
+			ops[i].fn = AggregateOpUser
+			ops[i].role = a.Role
+			ops[i].goagg = spec
+			out[i], err = prog.aggregateSlotUDF(a, bucket, mask, offset)
+			if err != nil {
+				return nil, fmt.Errorf("don't know how to aggregate %q: %w", a.Inner, err)
 			}
 
 		case expr.OpBoolAnd, expr.OpBoolOr:
@@ -308,6 +352,17 @@ func NewHashAggregate(agg, windows Aggregation, by Selection, dst QuerySink) (*H
 			}
 
 		default:
+			if IsExactSum(a) {
+				ops[i].fn = AggregateOpSumD
+				ops[i].role = a.Role
+				ops[i].goagg = newDecimalSumSpec()
+				var err error
+				out[i], err = prog.aggregateSlotDecimalSum(a, bucket, mask, offset)
+				if err != nil {
+					return nil, fmt.Errorf("don't know how to aggregate %q: %w", a.Inner, err)
+				}
+				break
+			}
 			argv, err := prog.compileAsNumber(h.agg[i].Expr.Inner)
 			if err != nil {
 				return nil, fmt.Errorf("don't know how to aggregate %q: %w", h.agg[i].Expr.Inner, err)
@@ -327,7 +382,7 @@ func NewHashAggregate(agg, windows Aggregation, by Selection, dst QuerySink) (*H
 					ops[i].fn = AggregateOpSumF
 					ops[i].role = a.Role
 					if a.Role == expr.AggregateRoleMerge {
-						out[i] = prog.aggregateSlotMergeState(bucket, argv, mask, offset+aggregateslot(ops[i].dataSize()))
+						out[i] = prog.aggregateSlotMergeState(bucket, argv, mask, offset)
 					}
 				} else {
 					ops[i].fn = AggregateOpSumI
@@ -408,8 +463,10 @@ func (h *HashAggregate) Open() (io.WriteCloser, error) {
 	return splitter(at), nil
 }
 
-func (h *HashAggregate) sort() []int {
-	ret := make([]int, len(h.final.pairs))
+// sort returns the indices of the groups of t
+// in the order of the ORDER BY functions of h
+func (h *HashAggregate) sort(t *aggtable) []int {
+	ret := make([]int, len(t.pairs))
 	for i := range ret {
 		ret[i] = i
 	}
@@ -417,19 +474,81 @@ func (h *HashAggregate) sort() []int {
 		return ret
 	}
 	slices.SortFunc(ret, func(i, j int) int {
-		for k := range h.order {
-			dir := h.order[k](h.final, i, j)
-			if dir != 0 {
-				return dir
-			}
-		}
-		return 0
+		return h.compare(t, i, j)
 	})
 	return ret
 }
 
+// compare compares the groups i and j of t
+// using the ORDER BY functions of h
+func (h *HashAggregate) compare(t *aggtable, i, j int) int {
+	for k := range h.order {
+		dir := h.order[k](t, i, j)
+		if dir != 0 {
+			return dir
+		}
+	}
+	return 0
+}
+
+// finalize applies the finalization
+// of the aggregates to the values of t
+func (h *HashAggregate) finalize(t *aggtable, offset []int) {
+	hasfinalize := false
+	for i := range t.pairs {
+		p := &t.pairs[i]
+		valmem := t.valueof(p)
+		for j := range h.aggregateOps {
+			op := h.aggregateOps[j]
+			if finalize := aggregateOpInfoTable[op.fn].finalizeFunc; finalize != nil && !op.savestate() {
+				finalize(valmem[offset[j]:])
+				hasfinalize = true
+			}
+		}
+
+		if !hasfinalize {
+			break // no finalize found in the first iteration, exit early
+		}
+	}
+}
+
+// aggOutput holds the symbols of the output
+// columns of a HashAggregate
+type aggOutput struct {
+	bysyms, aggsyms, windowsyms []ion.Symbol
+
+	// offset[i] is the offset of the i'th aggregate
+	offset []int
+}
+
+// write appends the groups of t in the given order to dst
+func (h *HashAggregate) write(dst *ion.Buffer, t *aggtable, order []int, out *aggOutput) {
+	for _, n := range order {
+		p := &t.pairs[n]
+		dst.BeginStruct(-1)
+		valmem := t.valueof(p)
+		for j, sym := range out.bysyms {
+			dst.BeginField(sym)
+			dst.UnsafeAppend(t.repridx(p, j))
+		}
+		for j, sym := range out.aggsyms {
+			dst.BeginField(sym)
+			writeAggregatedValue(dst, valmem[out.offset[j]:], h.aggregateOps[j])
+		}
+		for j, sym := range out.windowsyms {
+			dst.BeginField(sym)
+			dst.WriteUint(uint64(h.windows[j].final[n]))
+		}
+		dst.EndStruct()
+	}
+}
+
 func (h *HashAggregate) Close() error {
 	defer h.prog.reset()
+	defer resetGoAggs(h.aggregateOps)
+	if h.spill != nil {
+		defer h.spill.close()
+	}
 	c := atomic.LoadInt64(&h.children)
 	if c != 0 {
 		return fmt.Errorf("HashAggregate.Close(): have %d children outstanding", c)
@@ -437,90 +556,54 @@ func (h *HashAggregate) Close() error {
 	if h.final == nil {
 		return fmt.Errorf("HashAggregate.final == nil, didn't compute any aggregates?")
 	}
+	if h.spill != nil && h.spill.err != nil {
+		return h.spill.err
+	}
 	if h.skipEmpty && h.rowcount == 0 {
 		return flushEmpty(h.dst)
 	}
 
 	var outst ion.Symtab
 	var outbuf ion.Buffer
-
-	var aggsyms []ion.Symbol
-	var bysyms []ion.Symbol
-	var windowsyms []ion.Symbol
+	var out aggOutput
 
 	for i := range h.by {
-		bysyms = append(bysyms, outst.Intern(h.by[i].Result()))
+		out.bysyms = append(out.bysyms, outst.Intern(h.by[i].Result()))
 	}
 	for i := range h.agg {
-		aggsyms = append(aggsyms, outst.Intern(h.agg[i].Result))
+		out.aggsyms = append(out.aggsyms, outst.Intern(h.agg[i].Result))
 	}
 	for i := range h.windows {
-		windowsyms = append(windowsyms, outst.Intern(h.windows[i].result))
+		out.windowsyms = append(out.windowsyms, outst.Intern(h.windows[i].result))
 	}
+	internGoAggs(h.aggregateOps, &outst)
 	outst.Marshal(&outbuf, true)
 
-	hasfinalize := false
-	for i := range h.final.pairs {
-		p := &h.final.pairs[i]
-		valmem := h.final.valueof(p)
-		offset := 0
-		for j := range h.aggregateOps {
-			op := h.aggregateOps[j]
-			if finalize := aggregateOpInfoTable[op.fn].finalizeFunc; finalize != nil && !op.savestate() {
-				buf := valmem[offset:]
-				finalize(buf)
-				hasfinalize = true
-			}
-			offset += op.dataSize()
-			if op.mergestate() {
-				offset += aggregateOpMergeBufferSize
-			}
-		}
+	// turn the i'th 'agg' output
+	// into an offset
+	out.offset = make([]int, len(h.aggregateOps))
+	for i := range out.offset {
+		out.offset[i] = aggregateOpOffset(h.aggregateOps, i)
+	}
 
-		if !hasfinalize {
-			break // no finalize found in the first iteration, exit early
-		}
+	if h.spill.spilled() {
+		// the groups are never all in memory at once
+		return h.writeSpilled(&outst, &out)
 	}
+	h.finalize(h.final, out.offset)
 
 	// compute final window results
 	for i := range h.windows {
 		h.windows[i].run(h.final)
 	}
 	// compute ORDER BY + LIMIT
-	order := h.sort()
+	order := h.sort(h.final)
 	if h.limit > 0 && len(order) > h.limit {
 		order = order[:h.limit]
 	}
-
-	// turn the i'th 'agg' output
-	// into an offset
-	offset := make([]int, len(h.aggregateOps))
-	off := 0
-	for i, op := range h.aggregateOps {
-		offset[i] = off
-		if op.mergestate() {
-			off += aggregateOpMergeBufferSize
-		}
-		off += op.dataSize()
-	}
-
-	for _, n := range order {
-		p := &h.final.pairs[n]
-		outbuf.BeginStruct(-1)
-		valmem := h.final.valueof(p)
-		for j, sym := range bysyms {
-			outbuf.BeginField(sym)
-			outbuf.UnsafeAppend(h.final.repridx(p, j))
-		}
-		for j, sym := range aggsyms {
-			outbuf.BeginField(sym)
-			writeAggregatedValue(&outbuf, valmem[offset[j]:], h.aggregateOps[j])
-		}
-		for j, sym := range windowsyms {
-			outbuf.BeginField(sym)
-			outbuf.WriteUint(uint64(h.windows[j].final[n]))
-		}
-		outbuf.EndStruct()
+	h.write(&outbuf, h.final, order, &out)
+	if err := goAggsFailed(h.aggregateOps); err != nil {
+		return err
 	}
 
 	h.final = nil
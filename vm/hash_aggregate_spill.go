@@ -0,0 +1,488 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+const (
+	// DefaultAggregateSpillThreshold is the default
+	// number of bytes of groups or values that an
+	// aggregate table holds before its groups are spilled
+	// (see HashAggregate.SetSpill)
+	DefaultAggregateSpillThreshold = MaxAggregateMemory / 2
+
+	// aggSpillPartitions is the number of files
+	// that the spilled groups are partitioned into;
+	// each partition is merged separately at the end
+	aggSpillPartitions = 16
+
+	// aggSpillChunkSize is the size of the output
+	// that is buffered before it is written to
+	// the destination of a spilled aggregate
+	aggSpillChunkSize = 1 << 20
+)
+
+// aggSpill holds the groups of a HashAggregate
+// that have been spilled to disk
+//
+// Each spilled group is a record composed of the hash
+// of the group, the ion values of the grouping columns
+// and the aggregate values of the group, and the records
+// are appended to the file of the partition of the hash.
+// The same group may be spilled more than once (by different
+// tables or by the same table after it has been emptied),
+// so the records of a partition are merged when it is loaded.
+type aggSpill struct {
+	dir       string
+	threshold int
+
+	lock  sync.Mutex
+	files [aggSpillPartitions]*os.File
+	err   error // the first error encountered by write
+
+	// peak is the largest number of groups
+	// loaded at once after spilling (see load)
+	peak int
+}
+
+// SetSpill enables spilling the aggregated groups to
+// temporary files in dir: once the groups or the values of
+// an aggregate table (each thread aggregates into its own table)
+// occupy more than threshold bytes, the groups are written to
+// disk and the table is emptied. The spilled groups are merged
+// one partition at a time when the aggregate is closed, so
+// high-cardinality aggregations do not run into MaxAggregateBuckets
+// and MaxAggregateMemory. When the output is ordered, each partition
+// is sorted into a file of its own, and the sorted files are merged.
+//
+// Window functions need all of the groups at once,
+// so SetSpill does nothing if h has window functions.
+//
+// If threshold <= 0, DefaultAggregateSpillThreshold is used.
+// Aggregations that stay below the threshold never touch the disk.
+func (h *HashAggregate) SetSpill(dir string, threshold int) {
+	if len(h.windows) > 0 {
+		return
+	}
+	if threshold <= 0 {
+		threshold = DefaultAggregateSpillThreshold
+	}
+	threshold = min(threshold, MaxAggregateMemory/2)
+	h.spill = &aggSpill{dir: dir, threshold: threshold}
+}
+
+// aggSpillPartition returns the partition of the hash h;
+// the hash stored in a tree may be rotated by 32 bits
+// (see radixTree64.insertSlow), so the partition must
+// not depend on the rotation
+func aggSpillPartition(h uint64) int {
+	return int((uint32(h) ^ uint32(h>>32)) % aggSpillPartitions)
+}
+
+// full returns whether the groups of a should be spilled
+func (s *aggSpill) full(a *aggtable) bool {
+	return len(a.repr) > s.threshold ||
+		len(a.tree.values) > s.threshold ||
+		len(a.pairs) > MaxAggregateBuckets/2
+}
+
+// spilled returns whether any groups have been spilled
+func (s *aggSpill) spilled() bool {
+	if s == nil {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i := range s.files {
+		if s.files[i] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// write writes the groups of a to the partitions
+// and empties a
+func (s *aggSpill) write(a *aggtable) error {
+	var parts [aggSpillPartitions][]byte
+	width := len(a.parent.by)
+	size := a.tree.vsize - aggregateTagSize
+	for i := range a.pairs {
+		p := &a.pairs[i]
+		h := a.hashof(p)
+		n := aggSpillPartition(h)
+		buf := binary.LittleEndian.AppendUint64(parts[n], h)
+		buf = append(buf, a.fullrepr(p, width)...)
+		buf = append(buf, a.valueof(p)[:size]...)
+		parts[n] = buf
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	for i := range parts {
+		if len(parts[i]) == 0 {
+			continue
+		}
+		if s.files[i] == nil {
+			f, err := os.CreateTemp(s.dir, "hashagg-*.spill")
+			if err != nil {
+				s.err = err
+				return err
+			}
+			s.files[i] = f
+		}
+		if _, err := s.files[i].Write(parts[i]); err != nil {
+			s.err = fmt.Errorf("spilling aggregate groups: %w", err)
+			return s.err
+		}
+	}
+	a.tree = newRadixTree(len(a.parent.initialData))
+	a.repr = a.repr[:0]
+	a.pairs = a.pairs[:0]
+	a.spilled = true
+	return nil
+}
+
+// merge merges the records of partition n into a
+func (s *aggSpill) merge(n int, a *aggtable) error {
+	f := s.files[n]
+	if f == nil {
+		return nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	buf, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	width := len(a.parent.by)
+	size := a.tree.vsize - aggregateTagSize
+	for len(buf) > 0 {
+		if len(buf) < aggregateTagSize {
+			return fmt.Errorf("%s: truncated record", f.Name())
+		}
+		h := binary.LittleEndian.Uint64(buf)
+		buf = buf[aggregateTagSize:]
+		repr := 0
+		for i := 0; i < width; i++ {
+			if repr >= len(buf) {
+				return fmt.Errorf("%s: truncated record", f.Name())
+			}
+			repr += ion.SizeOf(buf[repr:])
+		}
+		if repr+size > len(buf) {
+			return fmt.Errorf("%s: truncated record", f.Name())
+		}
+		a.mergeGroup(h, buf[:repr], buf[repr:repr+size])
+		buf = buf[repr+size:]
+	}
+	return nil
+}
+
+// load returns a table holding the groups of partition n,
+// including the groups of mem that belong to the partition
+func (s *aggSpill) load(n int, mem *aggtable) (*aggtable, error) {
+	h := mem.parent
+	a := &aggtable{
+		parent:       h,
+		tree:         newRadixTree(len(h.initialData)),
+		aggregateOps: h.aggregateOps,
+	}
+	width := len(h.by)
+	for i := range mem.pairs {
+		p := &mem.pairs[i]
+		if hash := mem.hashof(p); aggSpillPartition(hash) == n {
+			a.mergeGroup(hash, mem.fullrepr(p, width), mem.valueof(p))
+		}
+	}
+	if err := s.merge(n, a); err != nil {
+		return nil, err
+	}
+	s.peak = max(s.peak, len(a.pairs))
+	return a, nil
+}
+
+// writeSpilled writes the groups of h.final and the spilled
+// groups to h.dst; each partition of the groups is merged
+// separately, so the groups are never all in memory at once
+func (h *HashAggregate) writeSpilled(st *ion.Symtab, out *aggOutput) error {
+	dst, err := h.dst.Open()
+	if err != nil {
+		return err
+	}
+	w := &aggWriter{dst: dst, st: st}
+	w.reset()
+	if h.order == nil {
+		err = h.writePartitions(w, out)
+	} else {
+		err = h.mergeRuns(w, out)
+	}
+	if err == nil {
+		err = goAggsFailed(h.aggregateOps)
+	}
+	if err == nil {
+		err = w.flush()
+	}
+	h.final = nil
+	if err != nil {
+		dst.Close()
+		return err
+	}
+	err = dst.Close()
+	err2 := h.dst.Close()
+	if err == nil {
+		err = err2
+	}
+	return err
+}
+
+// writePartitions writes the groups of each
+// partition in turn when the output is not ordered
+func (h *HashAggregate) writePartitions(w *aggWriter, out *aggOutput) error {
+	rows := 0
+	for n := 0; n < aggSpillPartitions; n++ {
+		t, err := h.spill.load(n, h.final)
+		if err != nil {
+			return err
+		}
+		order := h.sort(t)
+		if h.limit > 0 && rows+len(order) > h.limit {
+			order = order[:h.limit-rows]
+		}
+		h.finalize(t, out.offset)
+		h.write(&w.buf, t, order, out)
+		rows += len(order)
+		if err := w.flush(); err != nil {
+			return err
+		}
+		if h.limit > 0 && rows >= h.limit {
+			break
+		}
+	}
+	return nil
+}
+
+// mergeRuns writes the groups in the order of h.order;
+// the groups of each partition are sorted and written
+// to a run, and the runs are merged, so only the first
+// group of each run is in memory while merging
+func (h *HashAggregate) mergeRuns(w *aggWriter, out *aggOutput) error {
+	var runs []*aggRun
+	defer func() {
+		for i := range runs {
+			runs[i].close()
+		}
+	}()
+	for n := 0; n < aggSpillPartitions; n++ {
+		t, err := h.spill.load(n, h.final)
+		if err != nil {
+			return err
+		}
+		if len(t.pairs) == 0 {
+			continue
+		}
+		h.finalize(t, out.offset)
+		order := h.sort(t)
+		if h.limit > 0 && len(order) > h.limit {
+			order = order[:h.limit]
+		}
+		r, err := h.spill.run(t, order)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, r)
+	}
+	heads := &aggtable{
+		parent:       h,
+		tree:         newRadixTree(len(h.initialData)),
+		aggregateOps: h.aggregateOps,
+		pairs:        make([]hpair, len(runs)),
+	}
+	var active []int
+	for i := range runs {
+		ok, err := runs[i].next(heads, i)
+		if err != nil {
+			return err
+		}
+		if ok {
+			active = append(active, i)
+		}
+	}
+	rows := 0
+	for len(active) > 0 && (h.limit <= 0 || rows < h.limit) {
+		first := 0
+		for j := 1; j < len(active); j++ {
+			if h.compare(heads, active[j], active[first]) < 0 {
+				first = j
+			}
+		}
+		k := active[first]
+		h.write(&w.buf, heads, []int{k}, out)
+		rows++
+		if w.buf.Size() >= aggSpillChunkSize {
+			if err := w.flush(); err != nil {
+				return err
+			}
+		}
+		ok, err := runs[k].next(heads, k)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			active = slices.Delete(active, first, first+1)
+		}
+	}
+	return nil
+}
+
+// run writes the finalized groups of t
+// in the given order to a new run
+func (s *aggSpill) run(t *aggtable, order []int) (*aggRun, error) {
+	f, err := os.CreateTemp(s.dir, "hashagg-*.run")
+	if err != nil {
+		return nil, err
+	}
+	r := &aggRun{f: f}
+	bw := bufio.NewWriter(f)
+	width := len(t.parent.by)
+	size := t.tree.vsize - aggregateTagSize
+	var hdr [4]byte
+	for _, i := range order {
+		p := &t.pairs[i]
+		repr := t.fullrepr(p, width)
+		binary.LittleEndian.PutUint32(hdr[:], uint32(len(repr)))
+		bw.Write(hdr[:])
+		bw.Write(repr)
+		bw.Write(t.valueof(p)[:size])
+	}
+	err = bw.Flush()
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		r.close()
+		return nil, fmt.Errorf("spilling aggregate groups: %w", err)
+	}
+	r.r = bufio.NewReader(f)
+	return r, nil
+}
+
+// aggRun is a file of finalized groups in sorted order;
+// each group is a record composed of the size of the
+// ion values of the grouping columns, the values
+// themselves and the aggregate values of the group
+type aggRun struct {
+	f   *os.File
+	r   *bufio.Reader
+	buf []byte
+}
+
+// next reads the next group of r into the group k of heads,
+// or returns false if there are no groups left
+func (r *aggRun) next(heads *aggtable, k int) (bool, error) {
+	var hdr [4]byte
+	_, err := io.ReadFull(r.r, hdr[:])
+	if err == io.EOF {
+		return false, nil
+	}
+	n := int(binary.LittleEndian.Uint32(hdr[:]))
+	size := heads.tree.vsize - aggregateTagSize
+	if err == nil {
+		r.buf = slices.Grow(r.buf[:0], n+size)[:n+size]
+		_, err = io.ReadFull(r.r, r.buf)
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: truncated record", r.f.Name())
+	}
+	heads.setHead(k, r.buf[:n], r.buf[n:])
+	return true, nil
+}
+
+func (r *aggRun) close() {
+	r.f.Close()
+	os.Remove(r.f.Name())
+}
+
+// setHead replaces group k of a table that holds the first
+// group of each run (see mergeRuns); the memory of the groups
+// that have been replaced is reclaimed once it is large enough
+func (a *aggtable) setHead(k int, repr, value []byte) {
+	if len(a.repr)+len(a.tree.values) > aggSpillChunkSize {
+		old := *a
+		oldtree := *a.tree
+		old.tree = &oldtree
+		old.pairs = slices.Clone(a.pairs)
+		a.repr = nil
+		a.tree.values = nil
+		width := len(a.parent.by)
+		for i := range a.pairs {
+			if i != k {
+				p := &old.pairs[i]
+				a.setHead(i, old.fullrepr(p, width), old.valueof(p))
+			}
+		}
+	}
+	p := &a.pairs[k]
+	p.reprloc = int32(len(a.repr))
+	a.repr = append(a.repr, repr...)
+	p.hloc = int32(len(a.tree.values))
+	a.tree.values = append(a.tree.values, make([]byte, aggregateTagSize+a.tree.vsize)...)
+	copy(a.tree.values[int(p.hloc)+aggregateTagSize:], value)
+}
+
+// aggWriter buffers the output of a spilled aggregate
+type aggWriter struct {
+	dst io.Writer
+	st  *ion.Symtab
+	buf ion.Buffer
+}
+
+func (w *aggWriter) reset() {
+	w.buf.Reset()
+	w.st.Marshal(&w.buf, true)
+}
+
+// flush writes the buffered rows to w.dst
+func (w *aggWriter) flush() error {
+	_, err := w.dst.Write(w.buf.Bytes())
+	w.reset()
+	return err
+}
+
+// close removes the files of the spill
+func (s *aggSpill) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, f := range s.files {
+		if f != nil {
+			f.Close()
+			os.Remove(f.Name())
+			s.files[i] = nil
+		}
+	}
+}
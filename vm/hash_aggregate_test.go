@@ -22,6 +22,7 @@ import (
 	"os"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
@@ -87,6 +88,17 @@ var haggTests = []struct {
 			{name: "max", values: []ion.Datum{ion.Uint(6), ion.Uint(4), ion.Uint(5)}},
 		},
 	},
+	{
+		// select VendorID, min(passenger_count), max(passenger_count) group by VendorID order by max(passenger_count)
+		agg:      Aggregation{mkagg(expr.OpMin, "passenger_count", "min"), mkagg(expr.OpMax, "passenger_count", "max")},
+		group:    path(nil, "VendorID"),
+		aggorder: []int{1},
+		output: []testcol{
+			{name: "VendorID", values: []ion.Datum{ion.String("DDS"), ion.String("CMT"), ion.String("VTS")}},
+			{name: "min", values: []ion.Datum{ion.Uint(1), ion.Uint(1), ion.Uint(1)}},
+			{name: "max", values: []ion.Datum{ion.Uint(4), ion.Uint(5), ion.Uint(6)}},
+		},
+	},
 	{
 		agg:      Aggregation{mkagg(expr.OpCount, "payment_type", "count")},
 		group:    path(nil, "payment_type"),
@@ -245,3 +257,152 @@ func toJSON(st *ion.Symtab, d ion.Datum) string {
 	}
 	return sb.String()
 }
+
+func TestHashAggregateSpill(t *testing.T) {
+	// each group appears in several chunks,
+	// so the groups are spilled more than once
+	const groups = 20000
+	values := make([]ion.Datum, 3*groups)
+	for i := range values {
+		values[i] = ion.Int(int64((i * 7) % groups))
+	}
+	agg := Aggregation{
+		mkagg(expr.OpCount, "i", "count"),
+		mkagg(expr.OpSum, "i", "sum"),
+		mkagg(expr.OpMax, "i", "max"),
+		{
+			Expr: &expr.Aggregate{
+				Op:    expr.OpTopK,
+				K:     2,
+				Inner: path(t, "i"),
+			},
+			Result: "topk",
+		},
+	}
+	run := func(dir string, limit int, order bool) []string {
+		var qb QueryBuffer
+		ha, err := NewHashAggregate(agg, nil, Selection{expr.Bind(path(t, "x"), "x")}, &qb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dir != "" {
+			ha.SetSpill(dir, 64*1024)
+		}
+		if limit > 0 {
+			ha.Limit(limit)
+		}
+		if order {
+			err = ha.OrderByGroup(0, defaultSortOrdering)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		err = CopyRows(ha, setOpRows(t, values, 0), 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dir != "" && !ha.spill.spilled() {
+			t.Fatal("no groups were spilled")
+		}
+		err = ha.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dir != "" {
+			ents, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ents) > 0 {
+				t.Fatalf("%d spill files left after Close", len(ents))
+			}
+		}
+		var rows []string
+		for _, row := range readRows(t, qb.Bytes()) {
+			rows = append(rows, row.Datum().JSON())
+		}
+		return rows
+	}
+	want := run("", 0, false)
+	if len(want) != groups {
+		t.Fatalf("got %d groups without spilling, want %d", len(want), groups)
+	}
+	slices.Sort(want)
+
+	dir := t.TempDir()
+	got := run(dir, 0, false)
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("got %d groups, want %d", len(got), len(want))
+		for i := range got {
+			if i < len(want) && got[i] != want[i] {
+				t.Fatalf("group %d: got %s, want %s", i, got[i], want[i])
+			}
+		}
+	}
+
+	// each returned group is complete
+	got = run(dir, 100, false)
+	if len(got) != 100 {
+		t.Fatalf("got %d groups with LIMIT 100", len(got))
+	}
+	for _, g := range got {
+		if _, ok := slices.BinarySearch(want, g); !ok {
+			t.Errorf("unexpected group %s", g)
+		}
+	}
+
+	// ORDER BY merges the sorted partitions
+	ordered := run("", 0, true)
+	got = run(dir, 0, true)
+	if !slices.Equal(got, ordered) {
+		t.Error("ORDER BY results differ after spilling")
+	}
+	got = run(dir, 100, true)
+	if !slices.Equal(got, ordered[:100]) {
+		t.Error("ORDER BY ... LIMIT 100 results differ after spilling")
+	}
+}
+
+// ORDER BY does not load all of the spilled groups at once
+func TestHashAggregateSpillOrderMemory(t *testing.T) {
+	const groups = 50000
+	values := make([]ion.Datum, groups)
+	for i := range values {
+		values[i] = ion.Int(int64(groups - i))
+	}
+	var qb QueryBuffer
+	agg := Aggregation{mkagg(expr.OpCount, "i", "count")}
+	ha, err := NewHashAggregate(agg, nil, Selection{expr.Bind(path(t, "x"), "x")}, &qb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// spill every few thousand groups
+	ha.SetSpill(t.TempDir(), 32*1024)
+	err = ha.OrderByGroup(0, defaultSortOrdering)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = CopyRows(ha, setOpRows(t, values, 0), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spill := ha.spill
+	err = ha.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spill.peak == 0 || spill.peak > groups/4 {
+		t.Errorf("loaded up to %d of %d groups at once", spill.peak, groups)
+	}
+	rows := readRows(t, qb.Bytes())
+	if len(rows) != groups {
+		t.Fatalf("got %d rows, want %d", len(rows), groups)
+	}
+	for i := range rows {
+		want := fmt.Sprintf(`{"x": %d, "count": 1}`, i+1)
+		if got := rows[i].Datum().JSON(); got != want {
+			t.Fatalf("row %d: got %s, want %s", i, got, want)
+		}
+	}
+}
@@ -381,6 +381,7 @@ func init() {
 	opinfo[opsrai64imm].portable = bcsrai64immgo
 	opinfo[opsrli64].portable = bcsrli64go
 	opinfo[opsrli64imm].portable = bcsrli64immgo
+	opinfo[opwidthbucketi64].portable = bcwidthbucketi64go
 
 	opinfo[opcmpv].portable = bccmpvgo
 	opinfo[opsortcmpvnf].portable = bccmpvgo
@@ -433,6 +434,7 @@ func init() {
 	opinfo[oparraysize].portable = bcarraysizego
 	opinfo[oparrayposition].portable = bcarraypositiongo
 	opinfo[oparraysum].portable = bcarraysumgo
+	opinfo[oparrayslice].portable = bcarrayslicego
 	opinfo[opvectorinnerproduct].portable = bcvectorinnerproductgo
 	opinfo[opvectorinnerproductimm].portable = bcvectorinnerproductimmgo
 	opinfo[opvectorl1distance].portable = bcvectorl1distancego
@@ -477,6 +479,14 @@ func init() {
 	opinfo[opcharlength].portable = func(bc *bytecode, pc int) int { return bcLengthGo(bc, pc, opcharlength) }
 	opinfo[opSubstr].portable = bcSubstrGo
 	opinfo[opSplitPart].portable = bcSplitPartGo
+	opinfo[opSplitPartDfa].portable = bcSplitPartDfaGo
+	opinfo[opslower].portable = bcLowerGo
+	opinfo[opsupper].portable = bcUpperGo
+	opinfo[optohex].portable = bcToHexGo
+	opinfo[opfromhex].portable = bcFromHexGo
+	opinfo[optobase64].portable = bcToBase64Go
+	opinfo[opfrombase64].portable = bcFromBase64Go
+	opinfo[opstrreplace].portable = bcStrReplaceGo
 
 	opinfo[opContainsPrefixCs].portable = func(bc *bytecode, pc int) int { return bcContainsPreSufSubGo(bc, pc, opContainsPrefixCs) }
 	opinfo[opContainsPrefixCi].portable = func(bc *bytecode, pc int) int { return bcContainsPreSufSubGo(bc, pc, opContainsPrefixCi) }
@@ -496,6 +506,7 @@ func init() {
 	opinfo[opContainsPatternUTF8Ci].portable = func(bc *bytecode, pc int) int { return bcContainsPatternGo(bc, pc, opContainsPatternUTF8Ci) }
 
 	opinfo[opIsSubnetOfIP4].portable = bcIsSubnetOfIP4Go
+	opinfo[opIsSubnetOfIP6].portable = bcIsSubnetOfIP6Go
 
 	opinfo[opDfaT6].portable = func(bc *bytecode, pc int) int { return bcDFAGo(bc, pc, opDfaT6) }
 	opinfo[opDfaT7].portable = func(bc *bytecode, pc int) int { return bcDFAGo(bc, pc, opDfaT7) }
@@ -518,6 +529,7 @@ func init() {
 
 	opinfo[opdateaddmonth].portable = bcdateaddmonthgo
 	opinfo[opdateaddmonthimm].portable = bcdateaddmonthimmgo
+	opinfo[opdateaddmonthclampimm].portable = bcdateaddmonthclampimmgo
 	opinfo[opdateaddquarter].portable = bcdateaddquartergo
 	opinfo[opdateaddyear].portable = bcdateaddyeargo
 	opinfo[opdatebin].portable = bcdatebingo
@@ -546,6 +558,7 @@ func init() {
 	opinfo[opdatetruncmonth].portable = bcdatetruncmonthgo
 	opinfo[opdatetruncquarter].portable = bcdatetruncquartergo
 	opinfo[opdatetruncyear].portable = bcdatetruncyeargo
+	opinfo[optimebucketts].portable = bctimebuckettsgo
 
 	opinfo[opaggminf].portable = bcaggminfgo
 	opinfo[opaggmaxf].portable = bcaggmaxfgo
@@ -560,6 +573,7 @@ func init() {
 	opinfo[opcvtfloorf64toi64].portable = bccvtfloorf64toi64
 	opinfo[opcvtceilf64toi64].portable = bccvtceilf64toi64
 	opinfo[opcvttruncf64toi64].portable = bccvttruncf64toi64
+	opinfo[opcvti64tostr].portable = bccvti64tostrgo
 
 	opinfo[ophashvalue].portable = bchashvaluego
 	opinfo[ophashvalueplus].portable = bchashvalueplusgo
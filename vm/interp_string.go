@@ -15,9 +15,15 @@
 package vm
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/binary"
+	"encoding/hex"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/SnellerInc/sneller/internal/stringext"
+	"github.com/SnellerInc/sneller/ion"
 )
 
 func bcCmpStrGo(bc *bytecode, pc int, op bcop) int {
@@ -229,6 +235,32 @@ func bcSplitPartGo(bc *bytecode, pc int) int {
 	return pc + 12
 }
 
+func bcSplitPartDfaGo(bc *bytecode, pc int) int {
+	dstS := argptr[sRegData](bc, pc)
+	dstK := argptr[kRegData](bc, pc+2)
+	srcS := argptr[sRegData](bc, pc+4)
+	ds := []byte(bc.dict[bcword(bc, pc+6)])
+	idx := argptr[i64RegData](bc, pc+8).values
+	srcK := argptr[kRegData](bc, pc+10).mask
+	outK := uint16(0)
+
+	var tmpS sRegData
+	for i := 0; i < bcLaneCount; i++ {
+		if (srcK & (1 << i)) == 0 {
+			continue
+		}
+		from, to, ok := splitPartDfa(ds, vmref{srcS.offsets[i], srcS.sizes[i]}.mem(), idx[i])
+		if ok {
+			outK |= 1 << i
+			tmpS.offsets[i] = srcS.offsets[i] + uint32(from)
+			tmpS.sizes[i] = uint32(to - from)
+		}
+	}
+	*dstS = tmpS
+	dstK.mask = outK
+	return pc + 12
+}
+
 func bcContainsPreSufSubGo(bc *bytecode, pc int, op bcop) int {
 	dstS := argptr[sRegData](bc, pc)
 	dstK := argptr[kRegData](bc, pc+2)
@@ -311,6 +343,28 @@ func bcIsSubnetOfIP4Go(bc *bytecode, pc int) int {
 	return pc + 8
 }
 
+func bcIsSubnetOfIP6Go(bc *bytecode, pc int) int {
+	dstK := argptr[kRegData](bc, pc)
+	srcS := argptr[sRegData](bc, pc+2)
+	dict := bc.dict[bcword(bc, pc+4)]
+	inputK := argptr[kRegData](bc, pc+6).mask
+	outputK := uint16(0)
+
+	var min, max [16]byte
+	copy(min[:], dict[:16])
+	copy(max[:], dict[16:])
+	for i := 0; i < bcLaneCount; i++ {
+		if ((inputK >> i) & 1) == 1 {
+			data := vmref{srcS.offsets[i], srcS.sizes[i]}.mem()
+			if isSubnetOfIP6(data, &min, &max) {
+				outputK |= 1 << i
+			}
+		}
+	}
+	dstK.mask = outputK
+	return pc + 8
+}
+
 func bcDFAGo(bc *bytecode, pc int, op bcop) int {
 	srcS := argptr[sRegData](bc, pc+2)
 	inputK := argptr[kRegData](bc, pc+6).mask
@@ -318,3 +372,216 @@ func bcDFAGo(bc *bytecode, pc int, op bcop) int {
 	*argptr[kRegData](bc, pc) = DfaGoImpl(op, vmm[:], inputK, srcS.offsets, srcS.sizes, dsByte)
 	return pc + 8
 }
+
+// bcChangeCaseGo implements slower and supper by mapping
+// each code-point of the input through fn and writing
+// the result into the scratch buffer
+func bcChangeCaseGo(bc *bytecode, pc int, fn func(rune) rune) int {
+	dstS := argptr[sRegData](bc, pc)
+	dstK := argptr[kRegData](bc, pc+2)
+	srcS := argptr[sRegData](bc, pc+4)
+	inputK := argptr[kRegData](bc, pc+6).mask
+
+	tmpS := sRegData{}
+	for i := 0; i < bcLaneCount; i++ {
+		if ((inputK >> i) & 1) == 0 {
+			continue
+		}
+		data := vmref{srcS.offsets[i], srcS.sizes[i]}.mem()
+		p := len(bc.scratch)
+		// a case change can grow a code-point by at most one byte
+		if cap(bc.scratch)-p < len(data)*3/2+utf8.UTFMax {
+			bc.err = bcerrMoreScratch
+			return pc + 8
+		}
+		out := bc.scratch[:p]
+		for len(data) > 0 {
+			r, size := utf8.DecodeRune(data)
+			if r == utf8.RuneError && size == 1 {
+				// preserve invalid bytes as-is
+				out = append(out, data[0])
+			} else {
+				out = utf8.AppendRune(out, fn(r))
+			}
+			data = data[size:]
+		}
+		bc.scratch = out
+		tmpS.offsets[i], _ = vmdispl(bc.scratch[p:cap(bc.scratch)])
+		tmpS.sizes[i] = uint32(len(out) - p)
+	}
+	*dstS = tmpS
+	dstK.mask = inputK
+	return pc + 8
+}
+
+func bcLowerGo(bc *bytecode, pc int) int { return bcChangeCaseGo(bc, pc, unicode.ToLower) }
+func bcUpperGo(bc *bytecode, pc int) int { return bcChangeCaseGo(bc, pc, unicode.ToUpper) }
+
+func bcToHexGo(bc *bytecode, pc int) int {
+	mask := argptr[kRegData](bc, pc+6).mask
+	bcEncodeGo(bc, pc, mask, hex.EncodedLen, func(dst, src []byte) { hex.Encode(dst, src) })
+	return pc + 8
+}
+
+func bcToBase64Go(bc *bytecode, pc int) int {
+	enc := base64Encoding(bcword(bc, pc+6) != 0)
+	mask := argptr[kRegData](bc, pc+8).mask
+	bcEncodeGo(bc, pc, mask, enc.EncodedLen, enc.Encode)
+	return pc + 10
+}
+
+// bcEncodeGo implements tohex and tobase64 by encoding
+// the contents of the strings and blobs of the input
+// with encode into size(len(contents)) bytes of the
+// scratch buffer
+func bcEncodeGo(bc *bytecode, pc int, mask uint16, size func(int) int, encode func(dst, src []byte)) {
+	dstS := argptr[sRegData](bc, pc)
+	dstK := argptr[kRegData](bc, pc+2)
+	srcV := argptr[vRegData](bc, pc+4)
+
+	tmpS := sRegData{}
+	outK := uint16(0)
+	for i := 0; i < bcLaneCount; i++ {
+		if ((mask >> i) & 1) == 0 {
+			continue
+		}
+		// strings and blobs, except NULLs
+		t := srcV.typeL[i]
+		if ion.Type(t>>4|2) != ion.BlobType || t&0xf == 0xf {
+			continue
+		}
+		hlen := uint32(srcV.headerSize[i])
+		src := vmref{srcV.offsets[i] + hlen, srcV.sizes[i] - hlen}.mem()
+		n := size(len(src))
+		p := len(bc.scratch)
+		if cap(bc.scratch)-p < n {
+			bc.err = bcerrMoreScratch
+			return
+		}
+		bc.scratch = bc.scratch[:p+n]
+		encode(bc.scratch[p:], src)
+		tmpS.offsets[i], _ = vmdispl(bc.scratch[p:cap(bc.scratch)])
+		tmpS.sizes[i] = uint32(n)
+		outK |= 1 << i
+	}
+	*dstS = tmpS
+	dstK.mask = outK
+}
+
+func bcFromHexGo(bc *bytecode, pc int) int {
+	mask := argptr[kRegData](bc, pc+6).mask
+	size := func(src []byte) (int, bool) {
+		return hex.DecodedLen(len(src)), len(src)%2 == 0
+	}
+	decode := func(dst, src []byte) bool {
+		_, err := hex.Decode(dst, src)
+		return err == nil
+	}
+	bcDecodeGo(bc, pc, mask, size, decode)
+	return pc + 8
+}
+
+func bcFromBase64Go(bc *bytecode, pc int) int {
+	enc := base64.RawStdEncoding
+	if bcword(bc, pc+6) != 0 {
+		enc = base64.RawURLEncoding
+	}
+	mask := argptr[kRegData](bc, pc+8).mask
+	// the input is accepted with or without padding
+	size := func(src []byte) (int, bool) {
+		src = bytes.TrimRight(src, "=")
+		n := enc.DecodedLen(len(src))
+		return n, enc.EncodedLen(n) == len(src)
+	}
+	decode := func(dst, src []byte) bool {
+		_, err := enc.Decode(dst, bytes.TrimRight(src, "="))
+		return err == nil
+	}
+	bcDecodeGo(bc, pc, mask, size, decode)
+	return pc + 10
+}
+
+func bcStrReplaceGo(bc *bytecode, pc int) int {
+	dstS := argptr[sRegData](bc, pc)
+	dstK := argptr[kRegData](bc, pc+2)
+	srcS := *argptr[sRegData](bc, pc+4) // copied since argv may alias dstS
+	fromS := argptr[sRegData](bc, pc+6)
+	toS := argptr[sRegData](bc, pc+8)
+	mask := argptr[kRegData](bc, pc+10).mask
+
+	tmpS := sRegData{}
+	for i := 0; i < bcLaneCount; i++ {
+		if ((mask >> i) & 1) == 0 {
+			continue
+		}
+		from := vmref{fromS.offsets[i], fromS.sizes[i]}.mem()
+		if len(from) == 0 {
+			tmpS.offsets[i] = srcS.offsets[i]
+			tmpS.sizes[i] = srcS.sizes[i]
+			continue
+		}
+		src := vmref{srcS.offsets[i], srcS.sizes[i]}.mem()
+		to := vmref{toS.offsets[i], toS.sizes[i]}.mem()
+		n := len(src) + bytes.Count(src, from)*(len(to)-len(from))
+		p := len(bc.scratch)
+		if cap(bc.scratch)-p < n {
+			bc.err = bcerrMoreScratch
+			return pc + 12
+		}
+		dst := bc.scratch[p:p]
+		for {
+			j := bytes.Index(src, from)
+			if j < 0 {
+				break
+			}
+			dst = append(dst, src[:j]...)
+			dst = append(dst, to...)
+			src = src[j+len(from):]
+		}
+		dst = append(dst, src...)
+		bc.scratch = bc.scratch[:p+n]
+		if n > 0 {
+			tmpS.offsets[i], _ = vmdispl(dst)
+		}
+		tmpS.sizes[i] = uint32(n)
+	}
+	*dstS = tmpS
+	dstK.mask = mask
+	return pc + 12
+}
+
+// bcDecodeGo implements fromhex and frombase64 by decoding
+// each input string with decode into the number of bytes
+// returned by size, if the string can be decoded
+func bcDecodeGo(bc *bytecode, pc int, mask uint16, size func(src []byte) (int, bool), decode func(dst, src []byte) bool) {
+	dstS := argptr[sRegData](bc, pc)
+	dstK := argptr[kRegData](bc, pc+2)
+	srcS := argptr[sRegData](bc, pc+4)
+
+	tmpS := sRegData{}
+	outK := uint16(0)
+	for i := 0; i < bcLaneCount; i++ {
+		if ((mask >> i) & 1) == 0 {
+			continue
+		}
+		src := vmref{srcS.offsets[i], srcS.sizes[i]}.mem()
+		n, ok := size(src)
+		if !ok {
+			continue
+		}
+		p := len(bc.scratch)
+		if cap(bc.scratch)-p < n {
+			bc.err = bcerrMoreScratch
+			return
+		}
+		if !decode(bc.scratch[p:p+n], src) {
+			continue
+		}
+		bc.scratch = bc.scratch[:p+n]
+		tmpS.offsets[i], _ = vmdispl(bc.scratch[p:cap(bc.scratch)])
+		tmpS.sizes[i] = uint32(n)
+		outK |= 1 << i
+	}
+	*dstS = tmpS
+	dstK.mask = outK
+}
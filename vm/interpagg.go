@@ -55,6 +55,10 @@ func init() {
 	opinfo[opaggslotmergestate].portable = bcaggslotmergestatego
 
 	opinfo[opaggapproxcount].portable = bcaggapproxcountgo
+	opinfo[opaggslotapproxcount].portable = bcaggslotapproxcountgo
+	opinfo[opAggTDigest].portable = bcaggtdigestgo
+
+	opinfo[opaggbucket].portable = bcaggbucketgo
 }
 
 type f64AggState struct {
@@ -107,7 +111,7 @@ func bcaggapproxcountgo(bc *bytecode, pc int) int {
 	for lane := 0; lane < bcLaneCount; lane++ {
 		if srcmask&(1<<lane) != 0 {
 			dx := h.lo[lane]                           // DX = higher 64-bit of the 128-bit hash
-			cx := dx >> r11                            // CX - hash
+			cx := dx << r11                            // CX - hash
 			cx = (uint64)(bits.LeadingZeros64(cx) + 1) // CX = lzcnt(hash) + 1
 			dx = dx >> r13                             // DX - bucket id
 			// update HLL register
@@ -120,6 +124,86 @@ func bcaggapproxcountgo(bc *bytecode, pc int) int {
 	return pc + 10
 }
 
+func bcaggslotapproxcountgo(bc *bytecode, pc int) int {
+	imm := bcword32(bc, pc+0)
+	buckets := argptr[bRegData](bc, pc+4).offsets
+	h := argptr[hRegData](bc, pc+6)
+	bucketbits := bcword(bc, pc+8)
+	srcmask := argptr[kRegData](bc, pc+10).mask
+	values := hashAggValues(bc)
+
+	for lane := 0; lane < bcLaneCount; lane++ {
+		if srcmask&(1<<lane) != 0 {
+			mem := values[imm+uint32(aggregateTagSize)+buckets[lane]:]
+			hash := h.lo[lane]
+			val := uint8(bits.LeadingZeros64(hash<<bucketbits) + 1)
+			id := hash >> (64 - bucketbits) // HLL bucket id
+			mem[id] = max(mem[id], val)
+		}
+	}
+	return pc + 12
+}
+
+func bcaggtdigestgo(bc *bytecode, pc int) int {
+	imm := bcword32(bc, pc+0)
+	src := argptr[f64RegData](bc, pc+4)
+	srcmask := argptr[kRegData](bc, pc+6).mask
+	if srcmask == 0 {
+		return pc + 8
+	}
+
+	var buf [bcLaneCount]float32
+	values := buf[:0]
+	for lane := 0; lane < bcLaneCount; lane++ {
+		if srcmask&(1<<lane) != 0 {
+			values = append(values, float32(src.values[lane]))
+		}
+	}
+	data := unsafe.Slice((*byte)(unsafe.Add(bc.vmState.aggPtr, imm)), tDigestDataSize)
+	tDigestAdd(data, values)
+	return pc + 8
+}
+
+// bcaggbucketgo locates the radix tree entries of the hashes
+// in each active lane; if any of them is missing, it sets
+// bcerrNeedRadix so that the caller can insert the missing
+// entries and restart the evaluation
+func bcaggbucketgo(bc *bytecode, pc int) int {
+	dest := argptr[bRegData](bc, pc+0)
+	hslot := bcword(bc, pc+2)
+	h := argptr[hRegData](bc, pc+2)
+	srcmask := argptr[kRegData](bc, pc+4).mask
+	tree := (*radixTree64)(bc.vmState.aggPtr)
+
+	var buckets [bcLaneCount]uint32
+	missing := uint16(0)
+	for lane := 0; lane < bcLaneCount; lane++ {
+		if srcmask&(1<<lane) == 0 {
+			continue
+		}
+		off := tree.Offset(h.lo[lane])
+		if off < 0 {
+			missing |= 1 << lane
+			continue
+		}
+		if int(off) > len(tree.values) {
+			bc.err = bcerrTreeCorrupt
+			bc.errpc = int32(pc - 2)
+			return pc + 6
+		}
+		buckets[lane] = uint32(off)
+	}
+	if missing != 0 {
+		bc.err = bcerrNeedRadix
+		bc.errinfo = int(hslot)
+		bc.missingBucketMask = missing
+		return pc + 6
+	}
+
+	dest.offsets = buckets
+	return pc + 6
+}
+
 func bcaggslotcountgo(bc *bytecode, pc int) int {
 	imm := bcword32(bc, pc+0)
 	buckets := argptr[bRegData](bc, pc+4).offsets
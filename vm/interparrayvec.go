@@ -164,6 +164,73 @@ func bcarraysumgo(bc *bytecode, pc int) int {
 	return pc + 8
 }
 
+func bcarrayslicego(bc *bytecode, pc int) int {
+	src := argptr[sRegData](bc, pc+4)
+	imm := bcword64(bc, pc+6)
+	msk := argptr[kRegData](bc, pc+14).mask
+
+	from, to := int(int32(imm)), int(int32(imm>>32))
+	dst := sRegData{}
+	retmask := uint16(0)
+
+	for i := 0; i < bcLaneCount; i++ {
+		if (msk & (1 << i)) == 0 {
+			continue
+		}
+		list := vmref{src.offsets[i], src.sizes[i]}.mem()
+		start, end, ok := arraySliceBounds(list, from, to)
+		if ok {
+			dst.offsets[i] = src.offsets[i] + uint32(start)
+			dst.sizes[i] = uint32(end - start)
+			retmask |= 1 << i
+		}
+	}
+
+	*argptr[sRegData](bc, pc) = dst
+	*argptr[kRegData](bc, pc+2) = kRegData{retmask}
+
+	return pc + 16
+}
+
+// arraySliceBounds returns the byte offsets of the elements
+// of list from offset from up to offset to (see bcarrayslice)
+func arraySliceBounds(list []byte, from, to int) (int, int, bool) {
+	if from < 0 || to < 0 || to == arraySliceToEnd {
+		n := countValuesInList(list)
+		if n < 0 {
+			return 0, 0, false
+		}
+		if to == arraySliceToEnd {
+			to = n
+		}
+		if from < 0 {
+			from += n
+		}
+		if to < 0 {
+			to += n
+		}
+		if from < 0 || to < 0 {
+			return 0, 0, false
+		}
+	}
+	to = max(from, to)
+	pos, start := 0, 0
+	for i := 0; i < to; i++ {
+		if pos >= len(list) {
+			return 0, 0, false
+		}
+		size := ion.SizeOf(list[pos:])
+		if size <= 0 {
+			return 0, 0, false
+		}
+		pos += size
+		if i+1 == from {
+			start = pos
+		}
+	}
+	return start, pos, true
+}
+
 func bcvectorinnerproductgo(bc *bytecode, pc int) int {
 	src1 := argptr[sRegData](bc, pc+4)
 	src2 := argptr[sRegData](bc, pc+6)
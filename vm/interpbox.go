@@ -24,6 +24,7 @@ func init() {
 	opinfo[opboxi64].portable = bcboxi64go
 	opinfo[opboxts].portable = bcboxtsgo
 	opinfo[opboxstr].portable = bcboxstrgo
+	opinfo[opboxblob].portable = bcboxblobgo
 	opinfo[opboxlist].portable = bcboxlistgo
 	opinfo[opboxk].portable = bcboxkgo
 	opinfo[opmakestruct].portable = bcmakestructgo
@@ -69,6 +70,16 @@ func bcboxlistgo(bc *bytecode, pc int) int {
 }
 
 func bcboxstrgo(bc *bytecode, pc int) int {
+	return bcboxbytesgo(bc, pc, (*ion.Buffer).WriteStringBytes)
+}
+
+func bcboxblobgo(bc *bytecode, pc int) int {
+	return bcboxbytesgo(bc, pc, (*ion.Buffer).WriteBlob)
+}
+
+// bcboxbytesgo boxes the slices of a string or blob
+// with write, which determines the type of the values
+func bcboxbytesgo(bc *bytecode, pc int, write func(*ion.Buffer, []byte)) int {
 	dst := argptr[vRegData](bc, pc)
 	src := argptr[sRegData](bc, pc+2)
 	mask := argptr[kRegData](bc, pc+4).mask
@@ -82,7 +93,7 @@ func bcboxstrgo(bc *bytecode, pc int) int {
 			continue
 		}
 		str := vmref{src.offsets[i], src.sizes[i]}.mem()
-		buf.WriteStringBytes(str)
+		write(&buf, str)
 		result := buf.Bytes()[p:]
 		start, ok := vmdispl(result)
 		if !ok {
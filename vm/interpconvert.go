@@ -16,6 +16,7 @@ package vm
 
 import (
 	"math"
+	"strconv"
 )
 
 func bccvtktof64(bc *bytecode, pc int) int {
@@ -155,3 +156,33 @@ func bccvttruncf64toi64(bc *bytecode, pc int) int {
 	destk.mask = srcmask
 	return pc + 8
 }
+
+func bccvti64tostrgo(bc *bytecode, pc int) int {
+	dest := argptr[sRegData](bc, pc+0)
+	destk := argptr[kRegData](bc, pc+2)
+	arg0 := argptr[i64RegData](bc, pc+4)
+	srcmask := argptr[kRegData](bc, pc+6).mask
+	r := sRegData{}
+
+	// the longest possible output is 20 bytes per lane
+	// (19 digits plus a sign), which matches the scratch
+	// reserved for this op
+	p := len(bc.scratch)
+	if cap(bc.scratch)-p < 20*bcLaneCount {
+		bc.err = bcerrMoreScratch
+		return pc + 8
+	}
+	for lane := 0; lane < bcLaneCount; lane++ {
+		if srcmask&(1<<lane) == 0 {
+			continue
+		}
+		start := len(bc.scratch)
+		bc.scratch = strconv.AppendInt(bc.scratch, arg0.values[lane], 10)
+		r.offsets[lane], _ = vmdispl(bc.scratch[start:])
+		r.sizes[lane] = uint32(len(bc.scratch) - start)
+	}
+
+	*dest = r
+	destk.mask = srcmask
+	return pc + 8
+}
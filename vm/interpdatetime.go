@@ -76,6 +76,32 @@ func bcdateaddmonthimmgo(bc *bytecode, pc int) int {
 	return pc + 16
 }
 
+func bcdateaddmonthclampimmgo(bc *bytecode, pc int) int {
+	val1 := argptr[tsRegData](bc, pc+4)
+	val2 := int64(bcword64(bc, pc+6))
+
+	dst := tsRegData{}
+	msk := argptr[kRegData](bc, pc+14).mask
+	retmask := uint16(0)
+
+	for i := 0; i < bcLaneCount; i++ {
+		if (msk & (1 << i)) == 0 {
+			continue
+		}
+
+		result, ok := fastdate.Timestamp(val1.values[i]).AddMonthClamp(val2)
+		if ok {
+			dst.values[i] = int64(result)
+			retmask |= 1 << i
+		}
+	}
+
+	*argptr[tsRegData](bc, pc) = dst
+	*argptr[kRegData](bc, pc+2) = kRegData{retmask}
+
+	return pc + 16
+}
+
 func bcdateaddquartergo(bc *bytecode, pc int) int {
 	val1 := argptr[tsRegData](bc, pc+4)
 	val2 := argptr[i64RegData](bc, pc+6)
@@ -589,3 +615,20 @@ func bcdatetruncyeargo(bc *bytecode, pc int) int {
 	*argptr[tsRegData](bc, pc) = dst
 	return pc + 6
 }
+
+func bctimebuckettsgo(bc *bytecode, pc int) int {
+	ts := argptr[i64RegData](bc, pc+2)
+	interval := argptr[i64RegData](bc, pc+4)
+	msk := argptr[kRegData](bc, pc+6).mask
+
+	dst := i64RegData{}
+	for i := 0; i < bcLaneCount; i++ {
+		if (msk&(1<<i)) == 0 || interval.values[i] == 0 {
+			continue
+		}
+		dst.values[i] = ts.values[i] - (ts.values[i] % interval.values[i])
+	}
+
+	*argptr[i64RegData](bc, pc) = dst
+	return pc + 8
+}
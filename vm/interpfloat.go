@@ -50,6 +50,7 @@ func init() {
 	opinfo[oprpmodf64imm].portable = bcrpmodf64immgo
 	opinfo[opsignf64].portable = bcsignf64go
 	opinfo[opbroadcastf64].portable = bcbroadcastf64go
+	opinfo[opwidthbucketf64].portable = bcwidthbucketf64go
 }
 
 func bcabsf64go(bc *bytecode, pc int) int {
@@ -614,3 +615,59 @@ func bcsignf64go(bc *bytecode, pc int) int {
 	destk.mask = argmask
 	return pc + 8
 }
+
+// subRD, divRD and mulRD compute the result rounded
+// toward negative infinity like the {rd-sae} variants
+// of the instructions used by width_bucket do
+func subRD(a, b float64) float64 {
+	s := a - b
+	// the rounding error of s is exact (TwoSum)
+	bb := s - a
+	if (a-(s-bb))+(-b-bb) < 0 {
+		return math.Nextafter(s, math.Inf(-1))
+	}
+	return s
+}
+
+func divRD(a, b float64) float64 {
+	q := a / b
+	// q*b-a is exact and has the sign of the
+	// rounding error of q multiplied by b
+	r := math.FMA(q, b, -a)
+	if (b > 0 && r > 0) || (b < 0 && r < 0) {
+		return math.Nextafter(q, math.Inf(-1))
+	}
+	return q
+}
+
+func mulRD(a, b float64) float64 {
+	p := a * b
+	if math.FMA(a, b, -p) < 0 {
+		return math.Nextafter(p, math.Inf(-1))
+	}
+	return p
+}
+
+func bcwidthbucketf64go(bc *bytecode, pc int) int {
+	dest := argptr[f64RegData](bc, pc+0)
+	val := argptr[f64RegData](bc, pc+2)
+	minval := argptr[f64RegData](bc, pc+4)
+	maxval := argptr[f64RegData](bc, pc+6)
+	count := argptr[f64RegData](bc, pc+8)
+	mask := argptr[kRegData](bc, pc+10).mask
+	r := f64RegData{}
+
+	for lane := 0; lane < bcLaneCount; lane++ {
+		if mask&(1<<lane) == 0 {
+			continue
+		}
+		v := divRD(subRD(val.values[lane], minval.values[lane]), subRD(maxval.values[lane], minval.values[lane]))
+		v = math.Floor(mulRD(v, count.values[lane]))
+		// restrict the output to [0, count+1]
+		v = math.Min(v, count.values[lane]) + 1
+		r.values[lane] = math.Max(v, 0)
+	}
+
+	*dest = r
+	return pc + 12
+}
@@ -0,0 +1,246 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"math"
+	"strconv"
+)
+
+func init() {
+	opinfo[opgeohash].portable = bcgeohashgo
+	opinfo[opgeohashimm].portable = bcgeohashimmgo
+	opinfo[opgeotilex].portable = bcgeotilexgo
+	opinfo[opgeotiley].portable = bcgeotileygo
+	opinfo[opgeotilees].portable = bcgeotileesgo
+	opinfo[opgeotileesimm].portable = bcgeotileesimmgo
+	opinfo[opgeodistance].portable = bcgeodistancego
+}
+
+const geohashChars = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// geohashBits returns the 60 interleaved bits of
+// the geohash of the given coordinates; the most
+// significant bit is a bit of the longitude
+func geohashBits(lat, lon float64) uint64 {
+	// scale both coordinates to 46 bits of precision
+	// and keep the 30 bits used by a 12-character hash
+	latbits := uint64(int64(math.Floor(lat/(180.0/(1<<46))))+(1<<45)) >> 16
+	lonbits := uint64(int64(math.Floor(lon/(360.0/(1<<46))))+(1<<45)) >> 16
+	bits := uint64(0)
+	for i := 29; i >= 0; i-- {
+		bits = (bits << 2) | ((lonbits>>i)&1)<<1 | (latbits>>i)&1
+	}
+	return bits
+}
+
+func geohashgo(bc *bytecode, pc int, precision func(lane int) int64) int {
+	dest := argptr[sRegData](bc, pc+0)
+	lat := argptr[f64RegData](bc, pc+2)
+	lon := argptr[f64RegData](bc, pc+4)
+	mask := argptr[kRegData](bc, pc+8).mask
+	r := sRegData{}
+
+	// the longest possible output is 12 bytes per lane
+	if cap(bc.scratch)-len(bc.scratch) < 16*bcLaneCount {
+		bc.err = bcerrMoreScratch
+		return pc + 10
+	}
+	for lane := 0; lane < bcLaneCount; lane++ {
+		if mask&(1<<lane) == 0 {
+			continue
+		}
+		chars := int(min(max(precision(lane), 1), 12))
+		bits := geohashBits(lat.values[lane], lon.values[lane])
+		start := len(bc.scratch)
+		for i := 0; i < chars; i++ {
+			bc.scratch = append(bc.scratch, geohashChars[(bits>>(55-5*i))&31])
+		}
+		r.offsets[lane], _ = vmdispl(bc.scratch[start:])
+		r.sizes[lane] = uint32(chars)
+	}
+
+	*dest = r
+	return pc + 10
+}
+
+func bcgeohashgo(bc *bytecode, pc int) int {
+	precision := argptr[i64RegData](bc, pc+6)
+	return geohashgo(bc, pc, func(lane int) int64 { return precision.values[lane] })
+}
+
+func bcgeohashimmgo(bc *bytecode, pc int) int {
+	precision := int64(bcword(bc, pc+6))
+	return geohashgo(bc, pc, func(int) int64 { return precision })
+}
+
+// geotileShift returns the number of bits a tile
+// coordinate with 48 bits of precision has to be
+// shifted by to get the given precision
+func geotileShift(precision int64) uint {
+	return uint(48 - min(max(precision, 0), 32))
+}
+
+// fastSin computes sin(x) for x in (-15, 15) with
+// the polynomial and evaluation order of BC_FAST_SIN_4ULP
+// so that the results match the assembly bit-for-bit
+func fastSin(x float64) float64 {
+	q := math.RoundToEven(x * 0.31830988618379069)
+	r := math.FMA(q, -3.1415926535897931, x)
+	r = math.FMA(q, -1.2246467991473532e-16, r)
+	if int64(q)&1 != 0 {
+		r = -r
+	}
+	s := r * r
+	s2 := s * s
+	s4 := s2 * s2
+	u := math.FMA(s, -7.9725595500903787e-18, 2.810099727108632e-15)
+	v := math.FMA(s, -7.6471221911815883e-13, 2.810099727108632e-15)
+	v = math.FMA(s2, u, v)
+	u = math.FMA(s, -2.5052108376350205e-8, 2.7557319223919875e-6)
+	w := math.FMA(s, -1.9841269841269616e-4, 0.0083333333333333297)
+	w = math.FMA(s2, u, w)
+	w = math.FMA(s4, v, w)
+	w = math.FMA(s, w, -0.16666666666666666)
+	return math.FMA(s, w*r, r)
+}
+
+// geotilexBits projects the longitude to
+// a tile coordinate with 48 bits of precision
+func geotilexBits(lon float64) uint64 {
+	x := math.FMA(lon, (1<<48)/360.0, 1<<47)
+	return uint64(max(x, 0))
+}
+
+// geotileyBits projects the latitude to
+// a tile coordinate with 48 bits of precision
+func geotileyBits(lat float64) uint64 {
+	s := fastSin(lat * (math.Pi / 180))
+	// truncate to avoid infinity in border cases
+	s = min(max(s, -0.9999), 0.9999)
+	y := math.FMA(-(1<<48)/(4*math.Pi), sleefLn((1+s)/(1-s)), 1<<47)
+	return min(uint64(max(y, 0)), 1<<48-1)
+}
+
+func bcgeotilexgo(bc *bytecode, pc int) int {
+	dest := argptr[i64RegData](bc, pc+0)
+	lon := argptr[f64RegData](bc, pc+2)
+	precision := argptr[i64RegData](bc, pc+4)
+	mask := argptr[kRegData](bc, pc+6).mask
+	r := i64RegData{}
+
+	for lane := 0; lane < bcLaneCount; lane++ {
+		if mask&(1<<lane) != 0 {
+			x := min(geotilexBits(lon.values[lane]), 1<<48-1)
+			r.values[lane] = int64(x >> geotileShift(precision.values[lane]))
+		}
+	}
+
+	*dest = r
+	return pc + 8
+}
+
+func bcgeotileygo(bc *bytecode, pc int) int {
+	dest := argptr[i64RegData](bc, pc+0)
+	lat := argptr[f64RegData](bc, pc+2)
+	precision := argptr[i64RegData](bc, pc+4)
+	mask := argptr[kRegData](bc, pc+6).mask
+	r := i64RegData{}
+
+	for lane := 0; lane < bcLaneCount; lane++ {
+		if mask&(1<<lane) != 0 {
+			y := geotileyBits(lat.values[lane])
+			r.values[lane] = int64(y >> geotileShift(precision.values[lane]))
+		}
+	}
+
+	*dest = r
+	return pc + 8
+}
+
+// geotileesgo encodes the tile as "precision/x/y",
+// which is compatible with Elastic Search
+func geotileesgo(bc *bytecode, pc int, precision func(lane int) int64) int {
+	dest := argptr[sRegData](bc, pc+0)
+	lat := argptr[f64RegData](bc, pc+2)
+	lon := argptr[f64RegData](bc, pc+4)
+	mask := argptr[kRegData](bc, pc+8).mask
+	r := sRegData{}
+
+	// the longest possible output is 2+1+10+1+10 bytes per lane
+	if cap(bc.scratch)-len(bc.scratch) < 32*bcLaneCount {
+		bc.err = bcerrMoreScratch
+		return pc + 10
+	}
+	for lane := 0; lane < bcLaneCount; lane++ {
+		if mask&(1<<lane) == 0 {
+			continue
+		}
+		p := min(max(precision(lane), 0), 32)
+		shift := geotileShift(p)
+		x := geotilexBits(lon.values[lane]) >> shift
+		y := geotileyBits(lat.values[lane]) >> shift
+		start := len(bc.scratch)
+		bc.scratch = strconv.AppendInt(bc.scratch, p, 10)
+		bc.scratch = append(bc.scratch, '/')
+		bc.scratch = strconv.AppendUint(bc.scratch, x, 10)
+		bc.scratch = append(bc.scratch, '/')
+		bc.scratch = strconv.AppendUint(bc.scratch, y, 10)
+		r.offsets[lane], _ = vmdispl(bc.scratch[start:])
+		r.sizes[lane] = uint32(len(bc.scratch) - start)
+	}
+
+	*dest = r
+	return pc + 10
+}
+
+func bcgeotileesgo(bc *bytecode, pc int) int {
+	precision := argptr[i64RegData](bc, pc+6)
+	return geotileesgo(bc, pc, func(lane int) int64 { return precision.values[lane] })
+}
+
+func bcgeotileesimmgo(bc *bytecode, pc int) int {
+	precision := int64(bcword(bc, pc+6))
+	return geotileesgo(bc, pc, func(int) int64 { return precision })
+}
+
+func bcgeodistancego(bc *bytecode, pc int) int {
+	dest := argptr[f64RegData](bc, pc+0)
+	destk := argptr[kRegData](bc, pc+2)
+	lat1 := argptr[f64RegData](bc, pc+4)
+	lon1 := argptr[f64RegData](bc, pc+6)
+	lat2 := argptr[f64RegData](bc, pc+8)
+	lon2 := argptr[f64RegData](bc, pc+10)
+	mask := argptr[kRegData](bc, pc+12).mask
+	r := f64RegData{}
+
+	// haversine formula with the mean diameter of the Earth in meters
+	const radians = math.Pi / 180
+	for lane := 0; lane < bcLaneCount; lane++ {
+		if mask&(1<<lane) == 0 {
+			continue
+		}
+		a := lat1.values[lane] * radians
+		b := lat2.values[lane] * radians
+		dlat := fastSin((b - a) * 0.5)
+		dlon := fastSin((lon2.values[lane] - lon1.values[lane]) * radians * 0.5)
+		q := math.FMA(dlat, dlat, sleefCos(a)*sleefCos(b)*dlon*dlon)
+		r.values[lane] = 12742000 * sleefASin(math.Sqrt(q))
+	}
+
+	*dest = r
+	destk.mask = mask
+	return pc + 14
+}
@@ -925,3 +925,31 @@ func bcsrli64immgo(bc *bytecode, pc int) int {
 	*argptr[i64RegData](bc, pc) = dst
 	return pc + 14
 }
+
+// NOTE: like the assembly version, this has some
+// precision loss when the arithmetic exceeds 2^53
+func bcwidthbucketi64go(bc *bytecode, pc int) int {
+	dest := argptr[i64RegData](bc, pc+0)
+	val := argptr[i64RegData](bc, pc+2)
+	minval := argptr[i64RegData](bc, pc+4)
+	maxval := argptr[i64RegData](bc, pc+6)
+	count := argptr[i64RegData](bc, pc+8)
+	mask := argptr[kRegData](bc, pc+10).mask
+	r := i64RegData{}
+
+	for lane := 0; lane < bcLaneCount; lane++ {
+		if mask&(1<<lane) == 0 {
+			continue
+		}
+		if val.values[lane] < minval.values[lane] {
+			continue // underflow bucket is zero
+		}
+		v := float64(uint64(val.values[lane] - minval.values[lane]))
+		rng := float64(uint64(maxval.values[lane] - minval.values[lane]))
+		b := int64(mulRD(divRD(v, rng), float64(count.values[lane])))
+		r.values[lane] = min(b, count.values[lane]) + 1
+	}
+
+	*dest = r
+	return pc + 12
+}
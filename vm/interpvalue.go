@@ -366,7 +366,16 @@ func bcunsymbolizego(bc *bytecode, pc int) int {
 	dst := *src
 
 	for i := 0; i < bcLaneCount; i++ {
-		if (msk&(1<<i)) == 0 || src.sizes[i] == 0 {
+		if (msk & (1 << i)) == 0 {
+			// inactive lanes are zeroed, so that the output
+			// is valid to store directly into an output slot
+			dst.offsets[i] = 0
+			dst.sizes[i] = 0
+			dst.typeL[i] = 0
+			dst.headerSize[i] = 0
+			continue
+		}
+		if src.sizes[i] == 0 {
 			continue
 		}
 
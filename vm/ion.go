@@ -124,6 +124,15 @@ func ionParseSimplifiedTimestamp(raw []byte) (uint64, bool) {
 	return 0, false
 }
 
+// ionHasSimplifiedLayout returns true if the first
+// 8 bytes of the timestamp raw (which must be longer
+// than 8 bytes) are laid out like the timestamps
+// accepted by ionParseSimplifiedTimestamp.
+func ionHasSimplifiedLayout(raw []byte) bool {
+	val := binary.BigEndian.Uint64(raw[1:9])
+	return (val & 0xff80808080808080) == 0x8000808080808080
+}
+
 // simplifiedTimestampToTime interprets integer value as raw Ion timestamp.
 func simplifiedTimestampToTime(ts uint64) date.Time {
 	tmp := ts >> (5 * 8)
@@ -0,0 +1,33 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import "github.com/SnellerInc/sneller/expr"
+
+// isJSON compiles arg IS JSON [OBJECT|ARRAY]
+func (p *prog) isJSON(arg expr.Node, op expr.BuiltinOp) (*value, error) {
+	return p.scalarPredicate(isJSONFn(op), arg)
+}
+
+// isJSONFn is the scalarFunc of IS JSON, IS JSON OBJECT
+// or IS JSON ARRAY; values other than strings are not JSON
+type isJSONFn expr.BuiltinOp
+
+func (f isJSONFn) bind() scalarImpl {
+	return func(x *scalarCaller, args []vRegData, lane int) (vmref, error) {
+		str, ok := x.str(&args[0], lane)
+		return x.boolean(ok && expr.ValidJSON(expr.BuiltinOp(f), str)), nil
+	}
+}
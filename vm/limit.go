@@ -23,10 +23,15 @@ import (
 // limits the number of rows written
 // to the next QuerySink.
 //
-// See NewLimit
+// See NewLimit and NewLimitOffset
 type Limit struct {
-	remaining int64
-	dst       QuerySink
+	// consumed is the number of rows
+	// that have been claimed by writers,
+	// including the skipped ones
+	consumed int64
+	offset   int64
+	end      int64 // offset + limit
+	dst      QuerySink
 }
 
 type limiter struct {
@@ -38,9 +43,26 @@ type limiter struct {
 // NewLimit constructs a Limit that will
 // write no more than 'n' rows to 'dst'.
 func NewLimit(n int64, dst QuerySink) *Limit {
+	return NewLimitOffset(n, 0, dst)
+}
+
+// NewLimitOffset constructs a Limit that will
+// skip the first 'offset' rows and then write
+// no more than 'n' rows to 'dst'.
+//
+// Since the rows are written to the Limit by
+// parallel writers, the rows that are skipped
+// are whichever rows arrive first; the result
+// is only deterministic if the input is written
+// by a single writer in a deterministic order.
+// Once offset+n rows have been consumed, each
+// writer returns io.EOF on its next write so
+// that the producers can stop early.
+func NewLimitOffset(n, offset int64, dst QuerySink) *Limit {
 	return &Limit{
-		dst:       dst,
-		remaining: n,
+		dst:    dst,
+		offset: offset,
+		end:    offset + n,
 	}
 }
 
@@ -74,40 +96,44 @@ func (l *limiter) symbolize(st *symtab, aux *auxbindings) error {
 
 func (l *limiter) next() rowConsumer { return l.dst }
 
+// finish closes the output early so that
+// the next sub-query can begin finalization
+// as early as possible
+func (l *limiter) finish() error {
+	l.done = true
+	err := l.dst.Close()
+	if err == nil {
+		err = io.EOF
+	}
+	return err
+}
+
 func (l *limiter) writeRows(rows []vmref, rp *rowParams) error {
 	if l.done {
 		return io.EOF
 	}
 	c := int64(len(rows))
-	avail := atomic.AddInt64(&l.parent.remaining, -c)
-	if avail < 0 {
-		// adjust c so that we only
-		// write the rows we are interested
-		// in writing
-		c += avail
-		if c <= 0 {
-			// close early so that the next
-			// sub-query can begin finalization
-			// as early as possible
-			l.done = true
-			err := l.dst.Close()
-			if err == nil {
-				err = io.EOF
-			}
-			return err
-		}
+	// claim the rows [end-c, end) of the output
+	end := atomic.AddInt64(&l.parent.consumed, c)
+	start := end - c
+	if start >= l.parent.end {
+		return l.finish()
+	}
+	// adjust the rows so that we only
+	// write the rows we are interested
+	// in writing
+	lo := max(l.parent.offset-start, 0)
+	hi := min(c, l.parent.end-start)
+	if lo >= hi {
+		return nil // all of the rows are skipped
 	}
 	// limit aux rows as well
 	for j := range rp.auxbound {
-		rp.auxbound[j] = rp.auxbound[j][:c]
+		rp.auxbound[j] = rp.auxbound[j][lo:hi]
 	}
-	err := l.dst.writeRows(rows[:c], rp)
-	if avail == 0 && err == nil {
-		l.done = true
-		err = l.dst.Close()
-		if err == nil {
-			err = io.EOF
-		}
+	err := l.dst.writeRows(rows[lo:hi], rp)
+	if end >= l.parent.end && err == nil {
+		err = l.finish()
 	}
 	return err
 }
@@ -16,7 +16,10 @@ package vm
 
 import (
 	"os"
+	"slices"
 	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
 )
 
 func TestLimit(t *testing.T) {
@@ -49,3 +52,66 @@ func TestLimit(t *testing.T) {
 		}
 	}
 }
+
+func TestLimitOffset(t *testing.T) {
+	buf, err := os.ReadFile("../testdata/parking.10n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// tickets returns the tickets of the rows
+	// produced with a single writer, which
+	// are written in a deterministic order
+	tickets := func(t *testing.T, limit, offset int64) []ion.Datum {
+		var dst QueryBuffer
+		var sink QuerySink = &dst
+		if limit >= 0 {
+			sink = NewLimitOffset(limit, offset, sink)
+		}
+		s, err := NewProjection(selection("Ticket as t"), sink)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = CopyRows(s, buftbl(buf), 1)
+		if err != nil {
+			t.Fatalf("LIMIT %d OFFSET %d: %s", limit, offset, err)
+		}
+		var st ion.Symtab
+		var out []ion.Datum
+		for rest := dst.Bytes(); len(rest) > 0; {
+			var d ion.Datum
+			d, rest, err = ion.ReadDatum(&st, rest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.IsEmpty() || d.IsNull() {
+				continue
+			}
+			out = append(out, d)
+		}
+		return out
+	}
+	all := tickets(t, -1, 0)
+	if len(all) != 1023 {
+		t.Fatalf("got %d rows without a limit?", len(all))
+	}
+	cases := []struct {
+		limit, offset int64
+	}{
+		{10, 0},
+		{10, 5},
+		{1, 1022},
+		{5, 1021},
+		{100, 1023},
+		{300, 500},
+		{1023, 1},
+	}
+	for _, c := range cases {
+		got := tickets(t, c.limit, c.offset)
+		lo := min(c.offset, int64(len(all)))
+		hi := min(c.offset+c.limit, int64(len(all)))
+		want := all[lo:hi]
+		if !slices.EqualFunc(got, want, ion.Datum.Equal) {
+			t.Errorf("LIMIT %d OFFSET %d: got %d rows, want %d", c.limit, c.offset, len(got), len(want))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// nonFinite returns the lanes of the float f
+// that hold NaN or an infinity; x * 0 is NaN
+// exactly when x is not finite
+func (p *prog) nonFinite(f, k *value) *value {
+	z := p.ssa2imm(smulimmf, f, k, 0.0)
+	return p.ssa2(sisnanf, z, p.mask(z))
+}
+
+// nullIfNonFinite compiles NULLIF_NONFINITE(arg)
+func (p *prog) nullIfNonFinite(args []expr.Node) (*value, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("NULLIF_NONFINITE expects 1 argument, got %d", len(args))
+	}
+	v, err := p.serialized(args[0])
+	if err != nil {
+		return nil, err
+	}
+	v = p.unsymbolized(v)
+	nonfinite := p.nonFinite(p.coerceF64(v))
+	if nonfinite.op == skfalse {
+		return v, nil
+	}
+	null, err := p.serialized(expr.Null{})
+	if err != nil {
+		return nil, err
+	}
+	out := p.ssa4(sblendv, v, p.mask(v), null, nonfinite)
+	return p.makevk(out, out), nil
+}
+
+// assertFinite compiles ASSERT_FINITE(arg); since the
+// bytecode cannot fail the query on a value, the values
+// are checked by a scalarCall
+func (p *prog) assertFinite(args []expr.Node) (*value, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("ASSERT_FINITE expects 1 argument, got %d", len(args))
+	}
+	return p.scalarCall(assertFiniteFn{}, args[0])
+}
+
+// assertFiniteFn is the scalarFunc of ASSERT_FINITE;
+// it returns a copy of the value, or an error if
+// it is a NaN or infinite float
+type assertFiniteFn struct{}
+
+func (assertFiniteFn) bind() scalarImpl {
+	return func(x *scalarCaller, args []vRegData, lane int) (vmref, error) {
+		mem := x.arg(&args[0], lane)
+		if len(mem) == 0 {
+			return vmref{}, nil
+		}
+		if ion.TypeOf(mem) == ion.FloatType {
+			f, _, err := ion.ReadFloat64(mem)
+			if err != nil {
+				return vmref{}, err
+			}
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return vmref{}, fmt.Errorf("ASSERT_FINITE: found non-finite value %v", f)
+			}
+		}
+		return x.value(mem), nil
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/testquery"
+)
+
+func TestAssertFinite(t *testing.T) {
+	run := func(t *testing.T, text string) error {
+		tc, err := testquery.ReadCase(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tc.Execute(testquery.FlagParallel)
+	}
+	err := run(t, `
+SELECT ASSERT_FINITE(val) AS v FROM input
+---
+{"val": 1.5}
+{"val": 2}
+{"val": "foo"}
+{}
+---
+{"v": 1.5}
+{"v": 2}
+{"v": "foo"}
+{}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = run(t, `
+SELECT SUM(ASSERT_FINITE(val)) AS s FROM input
+---
+{"val": 1.5}
+{"val": 2}
+---
+{"s": 3.5}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, val := range []string{"float64:NaN", "float64:+inf", "float64:-inf"} {
+		err := run(t, fmt.Sprintf(`
+SELECT SUM(ASSERT_FINITE(val)) AS s FROM input
+---
+{"val": 1}
+{"val": %q}
+---
+{"s": 1}
+`, val))
+		if err == nil || !strings.Contains(err.Error(), "ASSERT_FINITE: found non-finite value") {
+			t.Errorf("%s: got error %v", val, err)
+		}
+	}
+}
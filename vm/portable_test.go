@@ -0,0 +1,577 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"math/rand"
+	"net/netip"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/regexp2"
+
+	"golang.org/x/sys/cpu"
+)
+
+// bcexecutors returns the ways an opcode can be executed
+// on this machine; the portable implementation is always
+// available and the assembly one requires AVX-512
+func bcexecutors(ctx *bctestContext) map[string]func(op bcop, args []any, mask kRegData) error {
+	m := map[string]func(op bcop, args []any, mask kRegData) error{
+		"go": ctx.executeOpcodeGo,
+	}
+	if cpu.X86.HasAVX512 {
+		m["asm"] = ctx.executeOpcode
+	}
+	return m
+}
+
+// sRegStrings returns the strings referenced
+// by the active lanes of s
+func sRegStrings(s *sRegData, mask kRegData) []string {
+	out := make([]string, bcLaneCount)
+	for i := range out {
+		if mask.getBit(i) {
+			out[i] = string(vmref{s.offsets[i], s.sizes[i]}.mem())
+		}
+	}
+	return out
+}
+
+func verifyStrings(t *testing.T, got, want []string) {
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("lane %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBytecodeChangeCase(t *testing.T) {
+	t.Parallel()
+	var ctx bctestContext
+	defer ctx.free()
+
+	input := []string{
+		"", "a", "Ab", "hello, World!", "ǅ", "ß", "straße",
+		"ÀÉÎÕÜ", "àéîõü", "Σίσυφος", "ÇAY", "ⱥⱦ", "\xff\xfeAb",
+		strings.Repeat("x", 100), "İ", "ı",
+	}
+	lower := []string{
+		"", "a", "ab", "hello, world!", "ǆ", "ß", "straße",
+		"àéîõü", "àéîõü", "σίσυφος", "çay", "ⱥⱦ", "\xff\xfeab",
+		strings.Repeat("x", 100), "i", "",
+	}
+	upper := []string{
+		"", "A", "AB", "HELLO, WORLD!", "Ǆ", "ß", "STRAßE",
+		"ÀÉÎÕÜ", "ÀÉÎÕÜ", "ΣΊΣΥΦΟΣ", "ÇAY", "ȺȾ", "\xff\xfeAB",
+		strings.Repeat("X", 100), "İ", "",
+	}
+
+	inputS := ctx.sRegFromStrings(input)
+	inputK := kRegData{mask: 0x7fff} // leave the last lane inactive
+	for _, tc := range []struct {
+		op   bcop
+		want []string
+	}{
+		{opslower, lower},
+		{opsupper, upper},
+	} {
+		var outS sRegData
+		var outK kRegData
+		if err := ctx.executeOpcodeGo(tc.op, []any{&outS, &outK, &inputS, &inputK}, inputK); err != nil {
+			t.Fatalf("%s: %s", opinfo[tc.op].text, err)
+		}
+		verifyKRegOutput(t, &outK, &inputK)
+		verifyStrings(t, sRegStrings(&outS, inputK), tc.want)
+	}
+}
+
+func TestBytecodeI64ToStr(t *testing.T) {
+	t.Parallel()
+	var ctx bctestContext
+	defer ctx.free()
+
+	inputS := i64RegData{values: [16]int64{
+		0, 1, -1, 9, 10, -10, 12345, -98765,
+		1 << 31, -1 << 31, 1<<63 - 1, -1 << 63, 999999999999, 1000000000000,
+	}}
+	inputK := kRegData{mask: 0x3fff}
+	want := []string{
+		"0", "1", "-1", "9", "10", "-10", "12345", "-98765",
+		"2147483648", "-2147483648", "9223372036854775807", "-9223372036854775808",
+		"999999999999", "1000000000000", "", "",
+	}
+	for name, exec := range bcexecutors(&ctx) {
+		var outS sRegData
+		var outK kRegData
+		if err := exec(opcvti64tostr, []any{&outS, &outK, &inputS, &inputK}, inputK); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		verifyKRegOutput(t, &outK, &inputK)
+		verifyStrings(t, sRegStrings(&outS, inputK), want)
+	}
+}
+
+func TestBytecodeTimeBucket(t *testing.T) {
+	t.Parallel()
+	var ctx bctestContext
+	defer ctx.free()
+
+	ts := i64RegData{values: [16]int64{0, 5, 10, 11, 99, 100, 1000001, 123456789, 7, 7}}
+	interval := i64RegData{values: [16]int64{10, 10, 10, 10, 10, 10, 1000, 3600, 1, 0}}
+	inputK := kRegData{mask: 0x3ff}
+	want := i64RegData{values: [16]int64{0, 0, 10, 10, 90, 100, 1000000, 123454800, 7, 0}}
+	for name, exec := range bcexecutors(&ctx) {
+		var out i64RegData
+		if err := exec(optimebucketts, []any{&out, &ts, &interval, &inputK}, inputK); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		verifyI64RegOutput(t, &out, &want)
+	}
+}
+
+func TestBytecodeWidthBucket(t *testing.T) {
+	t.Parallel()
+	var ctx bctestContext
+	defer ctx.free()
+
+	t.Run("i64", func(t *testing.T) {
+		val := i64RegData{values: [16]int64{-1, 0, 1, 4, 5, 9, 10, 11, 100, 50, -50, 3}}
+		minval := i64RegData{values: [16]int64{0, 0, 0, 0, 0, 0, 0, 0, 0, -100, -100, 0}}
+		maxval := i64RegData{values: [16]int64{10, 10, 10, 10, 10, 10, 10, 10, 10, 100, 100, 4}}
+		count := i64RegData{values: [16]int64{5, 5, 5, 5, 5, 5, 5, 5, 5, 4, 4, 3}}
+		inputK := kRegData{mask: 0xfff}
+		// the bucket is computed with rounding toward negative
+		// infinity, so 4 is placed below the bucket boundary
+		want := i64RegData{values: [16]int64{0, 1, 1, 2, 3, 5, 6, 6, 6, 4, 2, 3}}
+		for name, exec := range bcexecutors(&ctx) {
+			var out i64RegData
+			if err := exec(opwidthbucketi64, []any{&out, &val, &minval, &maxval, &count, &inputK}, inputK); err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			verifyI64RegOutput(t, &out, &want)
+		}
+	})
+	t.Run("f64", func(t *testing.T) {
+		val := f64RegData{values: [16]float64{-1, 0, 0.5, 4, 5, 9.99, 10, 11, 100, 50, -50, 3}}
+		minval := f64RegData{values: [16]float64{0, 0, 0, 0, 0, 0, 0, 0, 0, -100, -100, 0}}
+		maxval := f64RegData{values: [16]float64{10, 10, 10, 10, 10, 10, 10, 10, 10, 100, 100, 4}}
+		count := f64RegData{values: [16]float64{5, 5, 5, 5, 5, 5, 5, 5, 5, 4, 4, 3}}
+		inputK := kRegData{mask: 0xfff}
+		want := f64RegData{values: [16]float64{0, 1, 1, 2, 3, 5, 6, 6, 6, 4, 2, 3}}
+		for name, exec := range bcexecutors(&ctx) {
+			var out f64RegData
+			if err := exec(opwidthbucketf64, []any{&out, &val, &minval, &maxval, &count, &inputK}, inputK); err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			verifyF64RegOutput(t, &out, &want)
+		}
+	})
+}
+
+func TestBytecodeHex(t *testing.T) {
+	t.Parallel()
+	var ctx bctestContext
+	defer ctx.free()
+
+	t.Run("tohex", func(t *testing.T) {
+		var values []any
+		var want []string
+		inputK := kRegData{}
+		for i, n := range []int{0, 1, 2, 15, 16, 17, 31, 32, 33, 100, 1000} {
+			b := make([]byte, n)
+			for j := range b {
+				b[j] = byte(j*37 + n)
+			}
+			var d ion.Datum = ion.String(string(b))
+			if i%2 == 1 {
+				d = ion.Blob(b)
+			}
+			values = append(values, d)
+			want = append(want, hex.EncodeToString(b))
+			inputK.setBit(i)
+		}
+		// neither strings nor blobs
+		values = append(values, ion.Int(12), ion.Null, ion.Bool(true))
+		want = append(want, "", "", "")
+		inputK.mask |= 0x7 << (len(values) - 3)
+		wantK := kRegData{mask: inputK.mask &^ (0x7 << (len(values) - 3))}
+
+		inputV := ctx.vRegFromValues(values, nil)
+		for name, exec := range bcexecutors(&ctx) {
+			var outS sRegData
+			var outK kRegData
+			if err := exec(optohex, []any{&outS, &outK, &inputV, &inputK}, inputK); err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			verifyKRegOutput(t, &outK, &wantK)
+			verifyStrings(t, sRegStrings(&outS, wantK), want)
+		}
+	})
+	t.Run("fromhex", func(t *testing.T) {
+		input := []string{
+			"", "00", "0aFf", "deadBEEF", "0123456789abcdefABCDEF",
+			strings.Repeat("3a", 16), strings.Repeat("7f", 17), strings.Repeat("c0", 100),
+			"0", "abc", "0g", "G0", "0:", "/0", "@a", "`a",
+		}
+		inputS := ctx.sRegFromStrings(input)
+		inputK := kRegData{mask: 0xffff}
+		want := make([]string, len(input))
+		wantK := kRegData{}
+		for i := range input {
+			b, err := hex.DecodeString(input[i])
+			if err == nil {
+				want[i] = string(b)
+				wantK.setBit(i)
+			}
+		}
+		for name, exec := range bcexecutors(&ctx) {
+			var outS sRegData
+			var outK kRegData
+			if err := exec(opfromhex, []any{&outS, &outK, &inputS, &inputK}, inputK); err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			verifyKRegOutput(t, &outK, &wantK)
+			verifyStrings(t, sRegStrings(&outS, wantK), want)
+		}
+	})
+}
+
+func TestBytecodeBase64(t *testing.T) {
+	t.Parallel()
+	var ctx bctestContext
+	defer ctx.free()
+
+	t.Run("tobase64", func(t *testing.T) {
+		var values []any
+		var inputs [][]byte
+		for i, n := range []int{0, 1, 2, 3, 4, 5, 6, 47, 48, 49, 100, 1000} {
+			b := make([]byte, n)
+			for j := range b {
+				b[j] = byte(j*101 + n)
+			}
+			var d ion.Datum = ion.String(string(b))
+			if i%2 == 1 {
+				d = ion.Blob(b)
+			}
+			values = append(values, d)
+			inputs = append(inputs, b)
+		}
+		values = append(values, ion.Int(12))
+		inputV := ctx.vRegFromValues(values, nil)
+		inputK := kRegData{mask: 0x1fff}
+		wantK := kRegData{mask: 0x0fff}
+		for _, url := range []bool{false, true} {
+			enc := base64Encoding(url)
+			want := make([]string, len(values))
+			for i := range inputs {
+				want[i] = enc.EncodeToString(inputs[i])
+			}
+			imm := uint16(0)
+			if url {
+				imm = 1
+			}
+			for name, exec := range bcexecutors(&ctx) {
+				var outS sRegData
+				var outK kRegData
+				if err := exec(optobase64, []any{&outS, &outK, &inputV, imm, &inputK}, inputK); err != nil {
+					t.Fatalf("%s: %s", name, err)
+				}
+				verifyKRegOutput(t, &outK, &wantK)
+				verifyStrings(t, sRegStrings(&outS, wantK), want)
+			}
+		}
+	})
+	t.Run("frombase64", func(t *testing.T) {
+		input := []string{
+			"", "Zg", "Zg==", "Zm8", "Zm8=", "Zm9v", "Zm9vYg===",
+			"+/+/", "-_-_", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("base64", 40))),
+			"Z", "Zm9v!", "Zm9vY", "Zm=9v", " Zm9v", "Zg\x00=",
+		}
+		inputS := ctx.sRegFromStrings(input)
+		inputK := kRegData{mask: 0xffff}
+		for _, url := range []bool{false, true} {
+			enc := base64.RawStdEncoding
+			imm := uint16(0)
+			if url {
+				enc = base64.RawURLEncoding
+				imm = 1
+			}
+			want := make([]string, len(input))
+			wantK := kRegData{}
+			for i := range input {
+				b, err := enc.DecodeString(strings.TrimRight(input[i], "="))
+				if err == nil {
+					want[i] = string(b)
+					wantK.setBit(i)
+				}
+			}
+			for name, exec := range bcexecutors(&ctx) {
+				var outS sRegData
+				var outK kRegData
+				if err := exec(opfrombase64, []any{&outS, &outK, &inputS, imm, &inputK}, inputK); err != nil {
+					t.Fatalf("%s: %s", name, err)
+				}
+				verifyKRegOutput(t, &outK, &wantK)
+				verifyStrings(t, sRegStrings(&outS, wantK), want)
+			}
+		}
+	})
+}
+
+func TestBytecodeStrReplace(t *testing.T) {
+	t.Parallel()
+	var ctx bctestContext
+	defer ctx.free()
+
+	long := strings.Repeat("abcxyz", 30)
+	cases := []struct{ str, from, to string }{
+		{"", "a", "b"},
+		{"hello world", "o", "0"},
+		{"hello world", "world", "there"},
+		{"hello world", "x", "y"},
+		{"aaaa", "aa", "a"},
+		{"aaa", "aa", "b"},
+		{"abcab", "ab", ""},
+		{"abcab", "", "x"},
+		{"a.b.c", ".", "€"},
+		{"abab", "abab", "x"},
+		{"aba", "abab", "x"},
+		{long, "xyz", "-"},
+		{long, "c", strings.Repeat("C", 70)},
+		{strings.Repeat("a", 130) + "b", "ab", "!"},
+		{strings.Repeat("ab", 40), "ba", ""},
+		{"ǅǅ", "ǅ", "dž"},
+	}
+	input := make([]string, len(cases))
+	from := make([]string, len(cases))
+	to := make([]string, len(cases))
+	want := make([]string, len(cases))
+	for i := range cases {
+		input[i] = cases[i].str
+		from[i] = cases[i].from
+		to[i] = cases[i].to
+		if cases[i].from == "" {
+			want[i] = cases[i].str
+		} else {
+			want[i] = strings.ReplaceAll(cases[i].str, cases[i].from, cases[i].to)
+		}
+	}
+	inputS := ctx.sRegFromStrings(input)
+	fromS := ctx.sRegFromStrings(from)
+	toS := ctx.sRegFromStrings(to)
+	inputK := kRegData{mask: 0xffff}
+	for name, exec := range bcexecutors(&ctx) {
+		var outS sRegData
+		var outK kRegData
+		if err := exec(opstrreplace, []any{&outS, &outK, &inputS, &fromS, &toS, &inputK}, inputK); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		verifyKRegOutput(t, &outK, &inputK)
+		verifyStrings(t, sRegStrings(&outS, inputK), want)
+	}
+}
+
+func TestBytecodeSplitPartDfa(t *testing.T) {
+	t.Parallel()
+	var ctx bctestContext
+	defer ctx.free()
+
+	// splitPart returns the idx-th field of str split on
+	// the non-empty matches of rx, like Go's strings.Split
+	splitPart := func(rx *regexp.Regexp, str string, idx int) (string, bool) {
+		start := 0
+		for _, loc := range rx.FindAllStringIndex(str, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			if idx == 1 {
+				return str[start:loc[0]], true
+			}
+			idx--
+			start = loc[1]
+		}
+		if idx != 1 {
+			return "", false
+		}
+		return str[start:], true
+	}
+	patterns := []string{
+		`\s+`,
+		`[,;]`,
+		`::`,
+		`;|,$`,
+		`^a|b`,
+		`a|ab`,
+		`x*`,
+		`(?i)ſ`,
+		`[^a-z]+`,
+		`\p{Greek}+`,
+		`é|€€`,
+	}
+	input := []string{
+		"",
+		"a  b\t\tc",
+		"a,,b;c,",
+		"a::b::",
+		"abcabab",
+		"xaxxbx",
+		"Straße SS ſs",
+		"αβγ.δ€€€é",
+		strings.Repeat("a b", 30),
+		"a;b,c;d,e",
+		"::::",
+		"ab",
+		"Ωx ωy",
+		"€",
+		"a,",
+		" ",
+	}
+	inputS := ctx.sRegFromStrings(input)
+	inputK := kRegData{mask: 0xffff}
+	for _, pattern := range patterns {
+		rx := regexp.MustCompile(pattern)
+		ds, err := regexp2.NewDsDelim(rx, regexp2.MaxNodesAutomaton)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx.setDict(string(ds.Data()))
+		for first := -1; first <= 3; first++ {
+			var idx i64RegData
+			var wantK kRegData
+			want := make([]string, bcLaneCount)
+			for i := range input {
+				idx.values[i] = int64(first + i%4)
+				if str, ok := splitPart(rx, input[i], first+i%4); ok {
+					wantK.setBit(i)
+					want[i] = str
+				}
+			}
+			for name, exec := range bcexecutors(&ctx) {
+				var outS sRegData
+				var outK kRegData
+				if err := exec(opSplitPartDfa, []any{&outS, &outK, &inputS, uint16(0), &idx, &inputK}, inputK); err != nil {
+					t.Fatalf("%s: %s", name, err)
+				}
+				if outK != wantK {
+					t.Errorf("%s: pattern %q: got lanes %016b, want %016b", name, pattern, outK.mask, wantK.mask)
+				}
+				got := sRegStrings(&outS, wantK)
+				for i := range want {
+					if got[i] != want[i] {
+						t.Errorf("%s: pattern %q: SPLIT_PART(%q, %d) = %q, want %q", name, pattern, input[i], idx.values[i], got[i], want[i])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBytecodeIsSubnetOfIP6(t *testing.T) {
+	t.Parallel()
+	var ctx bctestContext
+	defer ctx.free()
+
+	run := func(t *testing.T, input []string, min, max string) kRegData {
+		lo := netip.MustParseAddr(min).As16()
+		hi := netip.MustParseAddr(max).As16()
+		ctx.setDict(string(lo[:]) + string(hi[:]))
+		inputS := ctx.sRegFromStrings(input)
+		inputK := kRegData{}
+		wantK := kRegData{}
+		for i := range input {
+			inputK.setBit(i)
+			if isSubnetOfIP6([]byte(input[i]), &lo, &hi) {
+				wantK.setBit(i)
+			}
+		}
+		for name, exec := range bcexecutors(&ctx) {
+			var outK kRegData
+			if err := exec(opIsSubnetOfIP6, []any{&outK, &inputS, uint16(0), &inputK}, inputK); err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			if outK != wantK {
+				t.Errorf("%s: range %s-%s: got %016b, want %016b", name, min, max, outK.mask, wantK.mask)
+				for i := range input {
+					if outK.getBit(i) != wantK.getBit(i) {
+						t.Logf("%q: got %v", input[i], outK.getBit(i))
+					}
+				}
+			}
+		}
+		return wantK
+	}
+
+	t.Run("cases", func(t *testing.T) {
+		input := []string{
+			"fe80::1", "fe80::1%eth0", "FE80:0:0:0:0:0:0:ffff", "fe80::", "fe7f:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+			"::ffff:10.1.2.3", "10.1.2.3", "010.001.002.003", "::10.1.2.3", "::ffff:10.1.2.300",
+			" fe80::1", "fe80::1%", "fe80:::1", "fe80::1::1", "fe80:0:0:0:0:0:0:0:1", "fe80::00001",
+		}
+		got := run(t, input, "fe80::", "fe80::ffff")
+		if got.mask != 0b1111 {
+			t.Errorf("fe80::/112: got %016b", got.mask)
+		}
+		got = run(t, input, "::ffff:10.0.0.0", "::ffff:10.255.255.255")
+		if got.mask != 0b1110_0000 {
+			t.Errorf("::ffff:10.0.0.0/104: got %016b", got.mask)
+		}
+		run(t, input, "::", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")
+	})
+	t.Run("random", func(t *testing.T) {
+		const alphabet = "0123456789abcdefABCDEF:.%"
+		r := rand.New(rand.NewSource(0))
+		input := make([]string, bcLaneCount)
+		for i := 0; i < 2000; i++ {
+			for j := range input {
+				if r.Intn(2) == 0 {
+					// a valid address
+					var b [16]byte
+					r.Read(b[:])
+					for k := range b {
+						if r.Intn(3) == 0 {
+							b[k] = 0
+						}
+					}
+					addr := netip.AddrFrom16(b)
+					if r.Intn(4) == 0 {
+						addr = netip.AddrFrom4([4]byte(b[:4]))
+					}
+					input[j] = addr.String()
+					if r.Intn(4) == 0 {
+						// likely no longer valid
+						s := []byte(input[j])
+						s[r.Intn(len(s))] = alphabet[r.Intn(len(alphabet))]
+						input[j] = string(s)
+					}
+					continue
+				}
+				s := make([]byte, r.Intn(42))
+				for k := range s {
+					s[k] = alphabet[r.Intn(len(alphabet))]
+				}
+				input[j] = string(s)
+			}
+			run(t, input, "::", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")
+			if t.Failed() {
+				break
+			}
+		}
+	})
+}
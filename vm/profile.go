@@ -0,0 +1,158 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// OpProfile accumulates the statistics
+// of a QuerySink returned by ProfileSink.
+//
+// The fields of OpProfile are updated atomically
+// as the streams opened from the sink are closed.
+type OpProfile struct {
+	// Rows is the number of rows
+	// written into the sink.
+	Rows int64
+	// Nanos is the number of nanoseconds spent
+	// writing to and closing the sink, including
+	// the time spent by any QuerySinks it writes to.
+	Nanos int64
+}
+
+// ProfileSink returns a QuerySink that forwards
+// data to dst and accumulates statistics about
+// the rows written to dst in p.
+//
+// Writers returned from the sink read the clock
+// once per batch of rows, so the overhead of
+// profiling is small but not zero; callers
+// should not use ProfileSink unless the
+// statistics are actually required.
+func ProfileSink(dst QuerySink, p *OpProfile) QuerySink {
+	return &profSink{dst: dst, prof: p}
+}
+
+type profSink struct {
+	dst  QuerySink
+	prof *OpProfile
+}
+
+func (p *profSink) Open() (io.WriteCloser, error) {
+	w, err := p.dst.Open()
+	if err != nil {
+		return nil, err
+	}
+	// preserve the fast paths for *rowSplitter
+	// by wrapping the rowConsumer rather than the writer
+	if rs, ok := w.(*rowSplitter); ok {
+		pc := profConsumer{rowConsumer: rs.rowConsumer, prof: p.prof}
+		if zc, ok := rs.rowConsumer.(zionConsumer); ok {
+			rs.rowConsumer = &profZionConsumer{profConsumer: pc, zc: zc}
+		} else {
+			rs.rowConsumer = &pc
+		}
+		return rs, nil
+	}
+	return &profWriter{WriteCloser: w, prof: p.prof}, nil
+}
+
+func (p *profSink) Close() error {
+	start := time.Now()
+	err := p.dst.Close()
+	atomic.AddInt64(&p.prof.Nanos, int64(time.Since(start)))
+	return err
+}
+
+// profWriter is the io.WriteCloser returned
+// from profSink.Open for writers that do not
+// consume rows directly; it only measures time
+type profWriter struct {
+	io.WriteCloser
+	prof  *OpProfile
+	nanos int64
+}
+
+func (p *profWriter) Write(b []byte) (int, error) {
+	start := time.Now()
+	n, err := p.WriteCloser.Write(b)
+	p.nanos += int64(time.Since(start))
+	return n, err
+}
+
+func (p *profWriter) Close() error {
+	start := time.Now()
+	err := p.WriteCloser.Close()
+	atomic.AddInt64(&p.prof.Nanos, p.nanos+int64(time.Since(start)))
+	p.nanos = 0
+	return err
+}
+
+// profConsumer is a rowConsumer that counts
+// rows and measures the time spent writing them;
+// the counters are published when it is closed
+type profConsumer struct {
+	rowConsumer // inherit symbolize, next
+	prof        *OpProfile
+	rows, nanos int64
+}
+
+func (p *profConsumer) writeRows(delims []vmref, params *rowParams) error {
+	start := time.Now()
+	err := p.rowConsumer.writeRows(delims, params)
+	p.nanos += int64(time.Since(start))
+	p.rows += int64(len(delims))
+	return err
+}
+
+func (p *profConsumer) Close() error {
+	start := time.Now()
+	err := p.rowConsumer.Close()
+	atomic.AddInt64(&p.prof.Rows, p.rows)
+	atomic.AddInt64(&p.prof.Nanos, p.nanos+int64(time.Since(start)))
+	p.rows, p.nanos = 0, 0
+	return err
+}
+
+// EndSegment implements EndSegmentWriter.EndSegment
+func (p *profConsumer) EndSegment() {
+	if esw, ok := p.rowConsumer.(EndSegmentWriter); ok {
+		esw.EndSegment()
+	}
+}
+
+// profZionConsumer is a profConsumer for
+// rowConsumers that also implement zionConsumer
+type profZionConsumer struct {
+	profConsumer
+	zc zionConsumer
+}
+
+func (p *profZionConsumer) zionOk(fields []string) bool {
+	return p.zc.zionOk(fields)
+}
+
+func (p *profZionConsumer) writeZion(state *zionState) error {
+	if n, err := state.shape.Count(); err == nil {
+		p.rows += int64(n)
+	}
+	start := time.Now()
+	err := p.zc.writeZion(state)
+	p.nanos += int64(time.Since(start))
+	return err
+}
@@ -36,6 +36,8 @@ import (
 	"github.com/SnellerInc/sneller/plan"
 	"github.com/SnellerInc/sneller/testquery"
 	"github.com/SnellerInc/sneller/vm"
+
+	"golang.org/x/sys/cpu"
 )
 
 var _ blockfmt.ZionWriter = &vm.TeeWriter{}
@@ -282,6 +284,15 @@ func BenchmarkTestQueries(b *testing.B) {
 func TestQueries(t *testing.T) {
 	vm.SetOptimizationLevel(vm.OptimizationLevelNone)
 	t.Run("portable", testQueries)
+	t.Run("noavx512", func(t *testing.T) {
+		// make sure that the portable interpreter
+		// does not fall back to the assembly
+		// interpreter for any op
+		saved := cpu.X86
+		t.Cleanup(func() { cpu.X86 = saved })
+		noAVX512()
+		testQueries(t)
+	})
 
 	detectedOptLevel := vm.DetectOptimizationLevel()
 	if detectedOptLevel != vm.OptimizationLevelNone {
@@ -290,6 +301,31 @@ func TestQueries(t *testing.T) {
 	}
 }
 
+// noAVX512 clears the AVX-512 features
+// of the CPU as seen by the vm package
+func noAVX512() {
+	cpu.X86.HasAVX512 = false
+	cpu.X86.HasAVX512F = false
+	cpu.X86.HasAVX512CD = false
+	cpu.X86.HasAVX512ER = false
+	cpu.X86.HasAVX512PF = false
+	cpu.X86.HasAVX512VL = false
+	cpu.X86.HasAVX512BW = false
+	cpu.X86.HasAVX512DQ = false
+	cpu.X86.HasAVX512IFMA = false
+	cpu.X86.HasAVX512VBMI = false
+	cpu.X86.HasAVX5124VNNIW = false
+	cpu.X86.HasAVX5124FMAPS = false
+	cpu.X86.HasAVX512VPOPCNTDQ = false
+	cpu.X86.HasAVX512VPCLMULQDQ = false
+	cpu.X86.HasAVX512VNNI = false
+	cpu.X86.HasAVX512GFNI = false
+	cpu.X86.HasAVX512VAES = false
+	cpu.X86.HasAVX512VBMI2 = false
+	cpu.X86.HasAVX512BITALG = false
+	cpu.X86.HasAVX512BF16 = false
+}
+
 func testQueries(t *testing.T) {
 	test, err := findQueries("./testdata/queries/", testQueryKind, *symLinkFlag)
 	if err != nil {
@@ -319,6 +319,8 @@ type aggtable struct {
 	// for updating buckets in the tree
 	prog prog
 	bc   bytecode
+	// st is the symbol table of the input
+	st *ion.Symtab
 
 	// total row count added
 	rows int64
@@ -341,6 +343,11 @@ type aggtable struct {
 	// has an hpair entry that holds
 	// the representation of each value
 	pairs []hpair
+
+	// spilled is set when the groups
+	// of the table have been spilled
+	// (see HashAggregate.SetSpill)
+	spilled bool
 }
 
 // for an aggtable, get the hash of the value
@@ -513,6 +520,27 @@ func (a *aggtable) initentry(buf []byte) {
 //go:noescape
 func evalhashaggbc(bc *bytecode, delims []vmref, tree *radixTree64) int
 
+// resetMergeBuffers marks all of the lanes of the
+// merge-state buffers as inactive, so that the lanes
+// of an aggregate that the bytecode does not update
+// (because its input is MISSING from every row of
+// the chunk) are not merged again
+func (a *aggtable) resetMergeBuffers() {
+	if len(a.tree.values) < aggregateTagSize+len(a.parent.initialData) {
+		return // no groups yet
+	}
+	dst := a.tree.values[aggregateTagSize:]
+	for _, op := range a.aggregateOps {
+		if op.mergestate() {
+			for i := 0; i < aggregateOpMergeBufferRowsCount; i++ {
+				binary.LittleEndian.PutUint32(dst[4*i:], ^uint32(0))
+			}
+			dst = dst[aggregateOpMergeBufferSize:]
+		}
+		dst = dst[op.dataSize():]
+	}
+}
+
 func (a *aggtable) fasteval(delims []vmref) int {
 	if a.bc.compiled == nil {
 		panic("aggtable.bc.compiled == nil")
@@ -530,6 +558,7 @@ func (a *aggtable) EndSegment() {
 }
 
 func (a *aggtable) symbolize(st *symtab, aux *auxbindings) error {
+	a.st = &st.Symtab
 	return recompile(st, &a.parent.prog, &a.prog, &a.bc, aux, "aggtable")
 }
 
@@ -555,7 +584,9 @@ func (a *aggtable) writeRows(delims []vmref, rp *rowParams) error {
 	// in a.repr[] for each aggregated item.
 	projectedGroupByCount := len(a.parent.by)
 	vRegSizeInUInt64Units := int(vRegSize >> 3)
-	a.bc.prepare(rp)
+	if err := a.bc.prepare(rp, delims); err != nil {
+		return err
+	}
 
 	// for each value that did not have a location,
 	// create a newly-initialized slot and then
@@ -626,11 +657,19 @@ func (a *aggtable) writeRows(delims []vmref, rp *rowParams) error {
 
 	// the main loop
 	for len(delims) > 0 {
+		if spill := a.parent.spill; spill != nil && spill.full(a) {
+			if err := spill.write(a); err != nil {
+				return err
+			}
+		}
 		chunk := delims
 		if a.mergestate && len(chunk) > aggregateOpMergeBufferRowsCount {
 			chunk = chunk[:aggregateOpMergeBufferRowsCount]
 		}
 
+		if a.mergestate {
+			a.resetMergeBuffers()
+		}
 		n := a.fasteval(chunk)
 		if a.bc.err != 0 && a.bc.err != bcerrNeedRadix {
 			return bytecodeerror("hash aggregate", &a.bc)
@@ -652,6 +691,10 @@ func (a *aggtable) writeRows(delims []vmref, rp *rowParams) error {
 			continue
 		}
 
+		// the merge-state buffer of each aggregate precedes
+		// its data; the bytecode writes the buffer at the
+		// offset of the aggregate relative to the values
+		// of the tree (i.e. into the first entry)
 		dst := a.tree.values[aggregateTagSize:]
 		for i := range a.aggregateOps {
 			op := a.aggregateOps[i]
@@ -661,7 +704,29 @@ func (a *aggtable) writeRows(delims []vmref, rp *rowParams) error {
 				continue
 			}
 
-			positions := dst[n : n+aggregateOpMergeBufferSize]
+			positions := dst[:aggregateOpMergeBufferSize]
+			dst = dst[aggregateOpMergeBufferSize:]
+			if lanes, ok := op.goagg.(goLanesAggregate); ok {
+				var mem [bcLaneCount][]byte
+				var buckets [bcLaneCount]uint32
+				pending := uint16(0)
+				for i := range chunk {
+					bucket := binary.LittleEndian.Uint32(positions[4*i+0*64:])
+					if int32(bucket) == -1 {
+						continue
+					}
+					offset := binary.LittleEndian.Uint32(positions[4*i+1*64:])
+					size := binary.LittleEndian.Uint32(positions[4*i+2*64:])
+					mem[i] = vmref{offset, size}.mem()
+					buckets[i] = bucket
+					pending |= 1 << i
+				}
+				if err := updateGoAggLanes(lanes, dst, &mem, &buckets, pending, op.role, a.st); err != nil {
+					return err
+				}
+				dst = dst[n:]
+				continue
+			}
 			for i := range chunk {
 				bucket := binary.LittleEndian.Uint32(positions[4*i+0*64:])
 				if int32(bucket) == -1 {
@@ -670,11 +735,15 @@ func (a *aggtable) writeRows(delims []vmref, rp *rowParams) error {
 				offset := binary.LittleEndian.Uint32(positions[4*i+1*64:])
 				size := binary.LittleEndian.Uint32(positions[4*i+2*64:])
 				v := vmref{offset, size}
-				if !mergeAggregateBuffers(dst[bucket:], v.mem(), op) {
+				if op.goagg != nil {
+					if err := op.goagg.update(dst[bucket:], v.mem(), op.role, &a.bc, i); err != nil {
+						return err
+					}
+				} else if !mergeAggregateBuffers(dst[bucket:], v.mem(), op) {
 					panic(fmt.Sprintf("aggregate %s expected to merge its buffer", op.fn))
 				}
 			}
-			dst = dst[n+aggregateOpMergeBufferSize:]
+			dst = dst[n:]
 		}
 	}
 
@@ -701,12 +770,27 @@ func (a *aggtable) Close() error {
 	// than doing a single merge, but it is
 	// faster since we are potentially performing
 	// multiple merges simultaneously
-	for parent.final != nil {
-		tmp := parent.final
-		parent.final = nil
+	for {
+		for parent.final != nil {
+			tmp := parent.final
+			parent.final = nil
+			parent.lock.Unlock()
+			a.merge(tmp)
+			parent.lock.Lock()
+		}
+		// a table that has spilled its groups
+		// spills the rest of them as well, since
+		// the spilled groups are merged at the end
+		spill := parent.spill
+		if spill == nil || len(a.pairs) == 0 || !(a.spilled || spill.full(a)) {
+			break
+		}
 		parent.lock.Unlock()
-		a.merge(tmp)
+		err := spill.write(a)
 		parent.lock.Lock()
+		if err != nil {
+			break // reported by HashAggregate.Close
+		}
 	}
 
 	parent.final = a
@@ -725,22 +809,24 @@ func (a *aggtable) merge(r *aggtable) {
 	for i := range r.pairs {
 		p := &r.pairs[i]
 		// get value from rhs
-		hash := r.hashof(p)
-		repr := r.fullrepr(p, len(a.parent.by))
-		value := r.valueof(p)
-
-		// regular insert slow path for lhs
-		off, ok := a.tree.insertSlow(hash)
-		if ok {
-			reprloc := int32(len(a.repr))
-			a.repr = append(a.repr, repr...)
-			a.pairs = append(a.pairs, hpair{
-				reprloc: reprloc,
-				hloc:    off,
-			})
-			a.initentry(a.tree.values[off+8:])
-		}
+		a.mergeGroup(r.hashof(p), r.fullrepr(p, len(a.parent.by)), r.valueof(p))
+	}
+}
 
-		mergeAggregatedValues(a.tree.values[off+8:], value, a.aggregateOps)
+// mergeGroup merges the aggregate values of the group
+// with the given hash and representation into the table
+func (a *aggtable) mergeGroup(hash uint64, repr, value []byte) {
+	// regular insert slow path for lhs
+	off, ok := a.tree.insertSlow(hash)
+	if ok {
+		reprloc := int32(len(a.repr))
+		a.repr = append(a.repr, repr...)
+		a.pairs = append(a.pairs, hpair{
+			reprloc: reprloc,
+			hloc:    off,
+		})
+		a.initentry(a.tree.values[off+8:])
 	}
+
+	mergeAggregatedValues(a.tree.values[off+8:], value, a.aggregateOps)
 }
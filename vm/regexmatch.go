@@ -0,0 +1,97 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"regexp"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/regexp2"
+)
+
+// MaxRegexNodes is the maximum number of states of
+// the automaton built for a regular expression
+// (SIMILAR TO, ~ and ~*) that is matched by the bytecode.
+// Expressions that need a larger automaton are matched
+// by Go's regexp package one lane at a time instead,
+// which is much slower but produces the same results.
+//
+// MaxRegexNodes can be set during init().
+var MaxRegexNodes = regexp2.MaxNodesAutomaton
+
+// compileRegex returns the DFA matching pattern,
+// or the error explaining why the DFA cannot be used;
+// regex is pattern compiled with regexp2.Compile
+func compileRegex(pattern string, regex *regexp.Regexp) (*regexp2.DFAStore, error) {
+	if err := regexp2.IsSupported(pattern); err != nil {
+		return nil, err
+	}
+	return regexp2.CompileDFA(regex, MaxRegexNodes)
+}
+
+// regexLiteralPrefix returns a string that is contained
+// in every string that matches pattern (compiled as typ),
+// or the empty string if there is no such literal prefix
+//
+// Case-insensitive patterns have no literal prefix,
+// since the prefix is matched case-sensitively.
+func regexLiteralPrefix(pattern string, typ regexp2.RegexType) string {
+	switch typ {
+	case regexp2.Regexp:
+		typ = regexp2.GolangRegexp
+	case regexp2.SimilarTo:
+		typ = regexp2.GolangSimilarTo
+	default:
+		return ""
+	}
+	rx, err := regexp2.Compile(pattern, typ)
+	if err != nil {
+		return ""
+	}
+	prefix, _ := rx.LiteralPrefix()
+	return prefix
+}
+
+// regexMatchGo compiles a match of arg against regex
+// that is evaluated by Go's regexp rather than by a DFA;
+// the result is MISSING if arg is not a string
+func (p *prog) regexMatchGo(arg expr.Node, regex *regexp.Regexp) (*value, error) {
+	// the DFA matches regex at the start of the text
+	rx, err := regexp.Compile("^(?:" + regex.String() + ")")
+	if err != nil {
+		return nil, err
+	}
+	v, err := p.scalarCall(&regexMatchFn{rx: rx}, arg)
+	if err != nil {
+		return nil, err
+	}
+	return p.isTrue(v), nil
+}
+
+// regexMatchFn is the scalarFunc of a regular
+// expression match evaluated by Go's regexp
+type regexMatchFn struct {
+	rx *regexp.Regexp
+}
+
+func (f *regexMatchFn) bind() scalarImpl {
+	return func(x *scalarCaller, args []vRegData, lane int) (vmref, error) {
+		str, ok := x.str(&args[0], lane)
+		if !ok {
+			return vmref{}, nil
+		}
+		return x.boolean(f.rx.Match(str)), nil
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/regexp2"
+	"github.com/SnellerInc/sneller/testquery"
+	"github.com/SnellerInc/sneller/vm"
+)
+
+// TestRegexMatchFallback tests that the regular expressions
+// matched with Go's regexp when their automaton is too large
+// produce the same matches as the DFA
+func TestRegexMatchFallback(t *testing.T) {
+	strs := []string{
+		"a", "ab", "aa", "aab", "xaby", "AB", "aB", "cde", "ababcde", "12", "x1234",
+		"x\ny", "xy\n", "abc123", "Ωab", "abΩ", "b", "a.b",
+	}
+	testcases := []struct {
+		op       string
+		typ      regexp2.RegexType
+		patterns []string
+	}{
+		{
+			op:  "~",
+			typ: regexp2.Regexp,
+			patterns: []string{
+				"a+b", "^ab", "(ab|cd)*e", "[0-9]{2,3}", "x.y", "^a*$", "b$",
+				"[[:alpha:]]+[0-9]", "^(a|x)[^b]*$", "Ω",
+			},
+		},
+		{
+			op:       "~*",
+			typ:      regexp2.RegexpCi,
+			patterns: []string{"(ab)", "(a+b)", "^ab$", "[a-c]{2}", "(x.y)"},
+		},
+		{
+			op:  "SIMILAR TO",
+			typ: regexp2.SimilarTo,
+			patterns: []string{
+				"(%ab_)", "(a%)", "(ab|cd)+e", "[0-9]{2,}", "(x_y)", "(%Ω%)", "a.b", "(_b)",
+			},
+		},
+	}
+	var rows strings.Builder
+	for _, str := range strs {
+		buf, err := json.Marshal(map[string]string{"str": str})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows.Write(buf)
+		rows.WriteByte('\n')
+	}
+	run := func(t *testing.T, query string, want int, mode string) {
+		text := fmt.Sprintf("%s\n---\n%s---\n{\"c\": %d}\n", query, rows.String(), want)
+		tc, err := testquery.ReadCase(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tc.Execute(0); err != nil {
+			t.Errorf("%s (%s): %s", query, mode, err)
+		}
+	}
+
+	saved := vm.MaxRegexNodes
+	defer func() { vm.MaxRegexNodes = saved }()
+	for _, tc := range testcases {
+		for _, pattern := range tc.patterns {
+			rx, err := regexp2.Compile(pattern, tc.typ)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the DFA matches rx at the start of the text
+			ref := regexp.MustCompile("^(?:" + rx.String() + ")")
+			want := 0
+			for _, str := range strs {
+				if ref.MatchString(str) {
+					want++
+				}
+			}
+			query := fmt.Sprintf("SELECT COUNT(*) AS c FROM input WHERE str %s '%s'", tc.op, pattern)
+			vm.MaxRegexNodes = saved
+			run(t, query, want, "DFA")
+			vm.MaxRegexNodes = 1
+			run(t, query, want, "Go")
+		}
+	}
+}
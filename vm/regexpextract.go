@@ -0,0 +1,257 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/SnellerInc/sneller/expr"
+)
+
+// regexpExtract compiles REGEXP_EXTRACT(arg, pattern, group)
+func (p *prog) regexpExtract(arg expr.Node, pattern string, group int) (*value, error) {
+	rx, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if group < 0 || group > rx.NumSubexp() {
+		return nil, fmt.Errorf("REGEXP_EXTRACT: group %d out of range", group)
+	}
+	return p.scalarCall(&regexpExtractFn{pattern: pattern, rx: rx, group: group}, arg)
+}
+
+// regexpExtractFn is the scalarFunc of REGEXP_EXTRACT
+type regexpExtractFn struct {
+	pattern string
+	rx      *regexp.Regexp
+	group   int
+}
+
+func (f *regexpExtractFn) bind() scalarImpl {
+	m := &regexpMatcher{rx: f.rx}
+	if f.group == 0 {
+		m.dfa = newSpanDFA(f.pattern)
+	}
+	return func(x *scalarCaller, args []vRegData, lane int) (vmref, error) {
+		str, ok := x.str(&args[0], lane)
+		if !ok {
+			return vmref{}, nil
+		}
+		start, end := m.match(str, f.group)
+		if start < 0 {
+			return vmref{}, nil
+		}
+		return x.string(str[start:end]), nil
+	}
+}
+
+// regexpMatcher finds the matches of a regular
+// expression, using a spanDFA for the matches
+// anchored at the start of the text until the
+// DFA grows too large
+type regexpMatcher struct {
+	rx  *regexp.Regexp
+	dfa *spanDFA
+}
+
+// match returns the span of group in str,
+// or (-1, -1) if str does not match or the group does not
+// participate in the match; the DFA can only be used for group 0
+func (m *regexpMatcher) match(str []byte, group int) (int, int) {
+	if m.dfa != nil {
+		if end, ok := m.dfa.end(str); ok {
+			if end < 0 {
+				return -1, -1
+			}
+			return 0, end
+		}
+		m.dfa = nil // too many states; use the regexp from now on
+	}
+	loc := m.rx.FindSubmatchIndex(str)
+	if loc == nil || loc[2*group] < 0 {
+		return -1, -1
+	}
+	return loc[2*group], loc[2*group+1]
+}
+
+// spanDFAMaxStates is the maximum number of states
+// of a spanDFA before it is abandoned
+const spanDFAMaxStates = 256
+
+// spanDFA is a lazily-built DFA for a regular expression that
+// is anchored at the start of the text. Since the match can
+// only start at offset 0, tracking the end of the match is
+// enough to produce the span of the whole match (group 0).
+//
+// Each state holds the NFA threads in priority order, and
+// the threads of lower priority than a match are dropped,
+// so the DFA produces the leftmost-first match of Go's regexp
+// rather than the longest one.
+type spanDFA struct {
+	prog   *syntax.Prog
+	start  *spanState
+	states map[string]*spanState
+
+	// scratch space for computing states
+	seen []bool
+	pcs  []uint32
+	key  []byte
+}
+
+type spanState struct {
+	pcs   []uint32 // threads waiting for a rune
+	match bool     // a thread has matched
+	next  [utf8.RuneSelf]*spanState
+}
+
+// newSpanDFA returns a spanDFA for pattern,
+// or nil if pattern is not supported
+func newSpanDFA(pattern string) *spanDFA {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil
+	}
+	if prog.StartCond()&syntax.EmptyBeginText == 0 {
+		return nil // not anchored
+	}
+	for i := range prog.Inst {
+		if prog.Inst[i].Op == syntax.InstEmptyWidth && syntax.EmptyOp(prog.Inst[i].Arg) != syntax.EmptyBeginText {
+			return nil // only ^ is supported
+		}
+	}
+	d := &spanDFA{
+		prog:   prog,
+		states: make(map[string]*spanState),
+		seen:   make([]bool, len(prog.Inst)),
+	}
+	d.start = d.state(nil, 0, true)
+	return d
+}
+
+// add appends the threads reached from pc to d.pcs in priority
+// order; it returns true if one of them is a match, in which
+// case the threads of lower priority must not be added
+func (d *spanDFA) add(pc uint32, start bool) bool {
+	if d.seen[pc] {
+		return false
+	}
+	d.seen[pc] = true
+	i := &d.prog.Inst[pc]
+	switch i.Op {
+	case syntax.InstAlt, syntax.InstAltMatch:
+		return d.add(i.Out, start) || d.add(i.Arg, start)
+	case syntax.InstCapture, syntax.InstNop:
+		return d.add(i.Out, start)
+	case syntax.InstEmptyWidth:
+		// ^ only matches at the start of the text
+		return start && d.add(i.Out, start)
+	case syntax.InstMatch:
+		return true
+	case syntax.InstFail:
+		return false
+	default:
+		d.pcs = append(d.pcs, pc)
+		return false
+	}
+}
+
+func matchRune(i *syntax.Inst, r rune) bool {
+	switch i.Op {
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return r != '\n'
+	default:
+		return i.MatchRune(r)
+	}
+}
+
+// state returns the state following the threads
+// in from on r (or the initial state if start is set),
+// or nil if the DFA has too many states
+func (d *spanDFA) state(from []uint32, r rune, start bool) *spanState {
+	clear(d.seen)
+	d.pcs = d.pcs[:0]
+	match := false
+	if start {
+		match = d.add(uint32(d.prog.Start), true)
+	} else {
+		for _, pc := range from {
+			i := &d.prog.Inst[pc]
+			if matchRune(i, r) && d.add(i.Out, false) {
+				match = true
+				break
+			}
+		}
+	}
+	d.key = d.key[:0]
+	if match {
+		d.key = append(d.key, 1)
+	}
+	for _, pc := range d.pcs {
+		d.key = binary.LittleEndian.AppendUint32(d.key, pc)
+	}
+	if s, ok := d.states[string(d.key)]; ok {
+		return s
+	}
+	if len(d.states) >= spanDFAMaxStates {
+		return nil
+	}
+	s := &spanState{pcs: slices.Clone(d.pcs), match: match}
+	d.states[string(d.key)] = s
+	return s
+}
+
+// end returns the end of the match in str, or -1 if str
+// does not match; ok is false if the DFA has grown too large,
+// in which case it should not be used anymore
+func (d *spanDFA) end(str []byte) (end int, ok bool) {
+	s := d.start
+	end = -1
+	if s.match {
+		end = 0
+	}
+	for i := 0; i < len(str) && len(s.pcs) > 0; {
+		r, size := rune(str[i]), 1
+		if r < utf8.RuneSelf {
+			next := s.next[r]
+			if next == nil {
+				next = d.state(s.pcs, r, false)
+				s.next[r] = next
+			}
+			s = next
+		} else {
+			r, size = utf8.DecodeRune(str[i:])
+			s = d.state(s.pcs, r, false)
+		}
+		if s == nil {
+			return -1, false
+		}
+		i += size
+		if s.match {
+			end = i
+		}
+	}
+	return end, true
+}
@@ -0,0 +1,109 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+)
+
+func TestSpanDFA(t *testing.T) {
+	patterns := []string{
+		`^abc`,
+		`^[a-z]+`,
+		`^(a|ab)`,
+		`^(ab|a)`,
+		`^a*(ab)?`,
+		`^a+?`,
+		`^a*?b`,
+		`^(?i)k(ey)?=[^;]*`,
+		`^[0-9]{2,4}-`,
+		`^.*x`,
+		`^(?s).*x`,
+		`^ſ+Ω?`,
+		`^`,
+		`\Ab+`,
+	}
+	inputs := []string{
+		"", "a", "ab", "aab", "abc", "abcd", "b", "aaab",
+		"key=value;x", "KEY=;", "k=1", "1234-", "12-34-", "123456-",
+		"x", "axbx\nx", "a\nbx", "ſſΩa", "ſ\xffΩ", "bbb", "\xff",
+	}
+	for _, pattern := range patterns {
+		d := newSpanDFA(pattern)
+		if d == nil {
+			t.Errorf("%s: no span DFA", pattern)
+			continue
+		}
+		rx := regexp.MustCompile(pattern)
+		for _, in := range inputs {
+			want := -1
+			if loc := rx.FindIndex([]byte(in)); loc != nil {
+				want = loc[1]
+			}
+			got, ok := d.end([]byte(in))
+			if !ok {
+				t.Fatalf("%s: %q: too many states", pattern, in)
+			}
+			if got != want {
+				t.Errorf("%s: %q: got end %d, want %d", pattern, in, got, want)
+			}
+		}
+	}
+}
+
+func TestSpanDFAUnsupported(t *testing.T) {
+	patterns := []string{
+		`abc`,
+		`^a|b`,
+		`^abc$`,
+		`(?m)^abc`,
+		`^a\b`,
+	}
+	for _, pattern := range patterns {
+		if newSpanDFA(pattern) != nil {
+			t.Errorf("%s: unexpected span DFA", pattern)
+		}
+	}
+}
+
+func TestSpanDFATooManyStates(t *testing.T) {
+	// the DFA for this pattern has 2^11 states
+	d := newSpanDFA(`^[ab]*a[ab]{10}c`)
+	if d == nil {
+		t.Fatal("no span DFA")
+	}
+	rnd := rand.New(rand.NewSource(0))
+	in := make([]byte, 4096)
+	for i := range in {
+		in[i] = "ab"[rnd.Intn(2)]
+	}
+	if _, ok := d.end(in); ok {
+		t.Fatal("expected the DFA to be abandoned")
+	}
+	// the matcher falls back to the regexp
+	m := &regexpMatcher{
+		rx:  regexp.MustCompile(`^[ab]*a[ab]{10}c`),
+		dfa: d,
+	}
+	in = append(in, "a0123456789c"...)
+	if start, end := m.match(in, 0); start != -1 || end != -1 {
+		t.Errorf("got span [%d, %d)", start, end)
+	}
+	if m.dfa != nil {
+		t.Error("the span DFA was not dropped")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/SnellerInc/sneller/expr"
+)
+
+// replace compiles REPLACE(str, from, to), which
+// returns str with every (non-overlapping) occurrence
+// of from replaced with to, or MISSING if one of the
+// arguments is not a string
+func (p *prog) replace(str, from, to expr.Node) (*value, error) {
+	v, err := compileargs(p, []expr.Node{str, from, to}, compileString, compileString, compileString)
+	if err != nil {
+		return nil, err
+	}
+	for i := range v {
+		v[i] = p.coerceStr(v[i])
+	}
+	k := p.and(p.mask(v[0]), p.and(p.mask(v[1]), p.mask(v[2])))
+	return p.ssa4(sstrreplace, v[0], v[1], v[2], k), nil
+}
+
+// translateFn is the scalarFunc of TRANSLATE(str, from, to);
+// it returns str with each character of from replaced
+// with the character at the same position in to, or
+// removed if to is shorter than from
+type translateFn struct{}
+
+func (translateFn) bind() scalarImpl {
+	var buf []byte
+	var t translation
+	return func(x *scalarCaller, args []vRegData, lane int) (vmref, error) {
+		str, ok := x.str(&args[0], lane)
+		if !ok {
+			return vmref{}, nil
+		}
+		from, ok := x.str(&args[1], lane)
+		if !ok {
+			return vmref{}, nil
+		}
+		to, ok := x.str(&args[2], lane)
+		if !ok {
+			return vmref{}, nil
+		}
+		// the sets are usually constant,
+		// so the map is only rebuilt when
+		// they change
+		if !t.valid || !bytes.Equal(from, t.from) || !bytes.Equal(to, t.to) {
+			t.set(from, to)
+		}
+		buf = buf[:0]
+		for len(str) > 0 {
+			r, size := utf8.DecodeRune(str)
+			if m, ok := t.runes[r]; !ok {
+				buf = append(buf, str[:size]...)
+			} else if m >= 0 {
+				buf = utf8.AppendRune(buf, m)
+			}
+			str = str[size:]
+		}
+		return x.string(buf), nil
+	}
+}
+
+// translation maps the characters of the from
+// set of TRANSLATE to those of the to set;
+// the characters that are removed map to -1
+type translation struct {
+	valid    bool
+	from, to []byte
+	runes    map[rune]rune
+}
+
+func (t *translation) set(from, to []byte) {
+	t.valid = true
+	t.from = append(t.from[:0], from...)
+	t.to = append(t.to[:0], to...)
+	if t.runes == nil {
+		t.runes = make(map[rune]rune)
+	}
+	clear(t.runes)
+	for len(from) > 0 {
+		r, size := utf8.DecodeRune(from)
+		from = from[size:]
+		m := rune(-1)
+		if len(to) > 0 {
+			m, size = utf8.DecodeRune(to)
+			to = to[size:]
+		}
+		// the first occurrence of a
+		// character in from is used
+		if _, ok := t.runes[r]; !ok {
+			t.runes[r] = m
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"io"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// RowNumberLimit is the maximum number of rows
+// that can be numbered by a QuerySink returned
+// from NewRowNumber.
+const RowNumberLimit = 100000
+
+// rowNumbering assigns sequence numbers
+// to the sorted output rows of an Order
+type rowNumbering struct {
+	partitions int    // leading sort columns that form the partition key
+	result     string // output field name
+	prev       []byte // partition key of the previous row
+	num        int64  // number of the previous row
+}
+
+// NewRowNumber constructs a QuerySink that implements
+//
+//	ROW_NUMBER() OVER (PARTITION BY partition ORDER BY order) AS result
+//
+// The input rows are sorted by the partition columns
+// and then by the order columns, and each row is written
+// to dst with an additional field named result that holds
+// the 1-based position of the row within its partition.
+// Rows for which a partition or order column is MISSING
+// are numbered as if the column were NULL.
+//
+// The sink returns an error from Close if it has
+// received more than RowNumberLimit rows.
+func NewRowNumber(dst io.Writer, partition []expr.Node, order []SortColumn, result string, parallelism int) (*Order, error) {
+	columns := make([]SortColumn, 0, len(partition)+len(order))
+	for i := range partition {
+		columns = append(columns, SortColumn{
+			Node: partition[i],
+			Ordering: SortOrdering{
+				Direction:  SortAscending,
+				NullsOrder: SortNullsFirst,
+			},
+		})
+	}
+	columns = append(columns, order...)
+	// keep one extra row so that
+	// finalizeKtop can detect overflow
+	s, err := NewOrder(dst, columns, &SortLimit{Limit: RowNumberLimit + 1}, parallelism)
+	if err != nil {
+		return nil, err
+	}
+	s.number = &rowNumbering{
+		partitions: len(partition),
+		result:     result,
+	}
+	return s, nil
+}
+
+// next returns the number of the row
+// with the given ordering fields
+func (r *rowNumbering) next(k *kheap, order []byte) int64 {
+	size := 0
+	for i := 0; i < r.partitions; i++ {
+		size += ion.SizeOf(order[size:])
+	}
+	key := order[:size]
+	if r.num == 0 || !r.samePartition(k, key) {
+		r.num = 0
+	}
+	r.prev = key
+	r.num++
+	return r.num
+}
+
+func (r *rowNumbering) samePartition(k *kheap, key []byte) bool {
+	prev := r.prev
+	for i := 0; i < r.partitions; i++ {
+		ps, ks := ion.SizeOf(prev), ion.SizeOf(key)
+		if k.fields[i].Compare(prev[:ps], key[:ks]) != 0 {
+			return false
+		}
+		prev, key = prev[ps:], key[ks:]
+	}
+	return true
+}
+
+// encode writes rec into dst along with its row number;
+// an existing field with the same name as the
+// row number is replaced
+func (r *rowNumbering) encode(dst *ion.Buffer, st *ion.Symtab, k *kheap, rec *krecord) error {
+	s, err := rec.data.Struct()
+	if err != nil {
+		return err
+	}
+	dst.BeginStruct(-1)
+	err = s.Each(func(f ion.Field) error {
+		if f.Label == r.result {
+			return nil
+		}
+		dst.BeginField(st.Intern(f.Label))
+		f.Datum.Encode(dst, st)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	dst.BeginField(st.Intern(r.result))
+	dst.WriteInt(r.next(k, rec.order))
+	dst.EndStruct()
+	return nil
+}
@@ -0,0 +1,247 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// scalarCalls holds the calls of a program to the
+// functions that are evaluated in Go (see scalarFunc).
+//
+// The calls are not evaluated by the program itself:
+// a separate find program computes their arguments,
+// the functions are called one lane at a time, and
+// the results are passed to the program as auxiliary
+// bindings (see bytecode.prepare), so that the rest
+// of the program can still be evaluated by the
+// assembly interpreter.
+type scalarCalls struct {
+	find  prog     // stores the arguments of the calls into V registers
+	mem   []*value // the stores of find
+	calls []scalarCall
+}
+
+type scalarCall struct {
+	fn   scalarFunc
+	reg  int // V register holding the first argument
+	args int // number of arguments
+}
+
+// scalarFunc is a function that is called
+// on the values of its arguments in one lane
+// at a time rather than by the bytecode
+type scalarFunc interface {
+	// bind returns the implementation of the
+	// function used by one scalarCaller, which
+	// may keep state across calls
+	bind() scalarImpl
+}
+
+// scalarImpl returns the result of a scalarFunc for
+// the given lane of args; MISSING is returned as
+// an empty vmref, and an error fails the query
+type scalarImpl func(x *scalarCaller, args []vRegData, lane int) (vmref, error)
+
+// scalarCallBinding returns the name of the auxiliary
+// binding holding the results of the nth call
+// of a program; the leading NUL byte keeps the name from
+// shadowing the fields of the input
+func scalarCallBinding(n int) string {
+	return "\x00scalar_call." + strconv.Itoa(n)
+}
+
+// scalarCall adds the call of fn on args to the
+// scalar calls of p and returns the value holding
+// its results
+func (p *prog) scalarCall(fn scalarFunc, args ...expr.Node) (*value, error) {
+	x := p.scalars
+	if x == nil {
+		x = &scalarCalls{}
+		x.find.begin()
+		p.scalars = x
+	}
+	n := len(x.calls)
+	call := scalarCall{fn: fn, reg: len(x.mem), args: len(args)}
+	for i := range args {
+		mem, err := x.find.compileStore(x.find.initMem(), args[i], stackSlotFromIndex(regV, call.reg+i), true)
+		if err != nil {
+			return nil, err
+		}
+		x.mem = append(x.mem, mem)
+	}
+	x.calls = append(x.calls, call)
+	x.find.returnValue(x.find.mergeMem(x.mem...))
+	return p.dot(scalarCallBinding(n), p.validLanes()), nil
+}
+
+// scalarPredicate is scalarCall for a function
+// that returns TRUE or FALSE in every valid lane
+func (p *prog) scalarPredicate(fn scalarFunc, args ...expr.Node) (*value, error) {
+	v, err := p.scalarCall(fn, args...)
+	if err != nil {
+		return nil, err
+	}
+	return p.isTrue(v), nil
+}
+
+// scalarCaller evaluates the scalarCalls
+// of a program on behalf of its bytecode
+type scalarCaller struct {
+	src  *scalarCalls
+	prog prog // symbolized src.find
+	bc   bytecode
+
+	// aux is the list of bindings of the program:
+	// the input bindings followed by the results
+	aux     auxbindings
+	auxvals [][]vmref
+	results [][]vmref
+
+	// impls[i] implements src.calls[i].fn
+	impls []scalarImpl
+	// mem holds the results
+	mem slab
+	// bools holds TRUE and FALSE in mem,
+	// once they are needed
+	bools []byte
+
+	// st is the symbol table of the input
+	st *ion.Symtab
+}
+
+// symbolize updates the find program of x and
+// returns the bindings that the program is compiled with
+func (x *scalarCaller) symbolize(st *symtab, src *scalarCalls, aux *auxbindings, callerName string) (*auxbindings, error) {
+	if x.src != src {
+		x.src = src
+		x.impls = make([]scalarImpl, len(src.calls))
+		for i := range src.calls {
+			x.impls[i] = src.calls[i].fn.bind()
+		}
+		x.results = make([][]vmref, len(src.calls))
+	}
+	x.st = &st.Symtab
+	err := recompile(st, &src.find, &x.prog, &x.bc, aux, callerName+" scalar call findbc")
+	if err != nil {
+		return nil, err
+	}
+	x.aux.set(aux)
+	for i := range src.calls {
+		x.aux.push(scalarCallBinding(i))
+	}
+	return &x.aux, nil
+}
+
+// run computes the results of the calls for delims and
+// appends them to the auxiliary values of the program
+func (x *scalarCaller) run(dst *bytecode, rp *rowParams, delims []vmref) error {
+	x.mem.resetNoFree()
+	x.bools = nil
+	for i := range x.results {
+		x.results[i] = sanitizeAux(x.results[i], len(delims))
+	}
+	if err := x.bc.prepare(rp, delims); err != nil {
+		return err
+	}
+	calls := x.src.calls
+	nregs := len(x.src.mem)
+	for pos := 0; pos < len(delims); pos += bcLaneCount {
+		lanes := min(bcLaneCount, len(delims)-pos)
+		if err := evalfind(&x.bc, delims[pos:pos+lanes], nregs); err != nil {
+			return fmt.Errorf("scalar call: %w", err)
+		}
+		regs := vRegDataFromVStackCast(&x.bc.vstack, nregs)
+		for i := range calls {
+			args := regs[calls[i].reg : calls[i].reg+calls[i].args]
+			for j := 0; j < lanes; j++ {
+				ref, err := x.impls[i](x, args, j)
+				if err != nil {
+					return err
+				}
+				x.results[i][pos+j] = ref
+			}
+		}
+	}
+	x.auxvals = append(append(x.auxvals[:0], rp.auxbound...), x.results...)
+	dst.auxvals = x.auxvals
+	return nil
+}
+
+// arg returns the value of reg in the given lane
+func (x *scalarCaller) arg(reg *vRegData, lane int) []byte {
+	return vmref{reg.offsets[lane], reg.sizes[lane]}.mem()
+}
+
+// str returns the contents of the value of reg in
+// the given lane if it is a string
+func (x *scalarCaller) str(reg *vRegData, lane int) ([]byte, bool) {
+	mem := x.arg(reg, lane)
+	if len(mem) == 0 || ion.TypeOf(mem) != ion.StringType {
+		return nil, false
+	}
+	str, _, err := ion.ReadStringShared(mem)
+	return str, err == nil
+}
+
+// boolean returns a reference to the ion boolean b
+func (x *scalarCaller) boolean(b bool) vmref {
+	if x.bools == nil {
+		x.bools = x.mem.malloc(2)
+		x.bools[0] = byte(ion.BoolType<<4) | 1
+		x.bools[1] = byte(ion.BoolType << 4)
+	}
+	off, _ := vmdispl(x.bools)
+	if !b {
+		off++
+	}
+	return vmref{off, 1}
+}
+
+// string returns a reference to a copy
+// of the ion string with the contents str
+func (x *scalarCaller) string(str []byte) vmref {
+	size := uint(len(str))
+	buf := x.mem.malloc(1 + ion.Uvsize(size) + len(str))
+	n := ion.UnsafeWriteTag(buf, ion.StringType, size)
+	n += copy(buf[n:], str)
+	off, _ := vmdispl(buf)
+	return vmref{off, uint32(n)}
+}
+
+// value returns a reference to a copy of the ion value mem
+func (x *scalarCaller) value(mem []byte) vmref {
+	buf := x.mem.malloc(len(mem))
+	n := copy(buf, mem)
+	off, _ := vmdispl(buf)
+	return vmref{off, uint32(n)}
+}
+
+func (x *scalarCaller) dropScratch() {
+	x.bc.dropScratch()
+	x.mem.reset()
+	x.bools = nil
+}
+
+func (x *scalarCaller) reset() {
+	x.bc.reset()
+	x.prog.reset()
+	x.mem.reset()
+	x.bools = nil
+}
@@ -81,6 +81,11 @@ type Projection struct {
 	// that this projection is actually
 	// a constant structure
 	constexpr *ion.Struct
+
+	// star indicates that the selection
+	// includes '*', so every field of the
+	// input is preserved (see starproject)
+	star bool
 }
 
 func (s Selection) toConst() (ion.Struct, bool) {
@@ -101,14 +106,30 @@ func (s Selection) toConst() (ion.Struct, bool) {
 // NewProjection implements simple column projection from
 // one set of values to a subset of those values,
 // possibly re-named.
+//
+// If sel includes '*', then every field of the input
+// is preserved and the remaining bindings are added
+// to it (see starproject for the field order).
 func NewProjection(sel Selection, dst QuerySink) (*Projection, error) {
 	p := &Projection{
 		dst: dst,
-		sel: sel,
 	}
-	constexpr, ok := sel.toConst()
-	if ok {
-		p.constexpr = &constexpr
+	for i := range sel {
+		if _, ok := sel[i].Expr.(expr.Star); ok {
+			if p.star {
+				return nil, fmt.Errorf("NewProjection: '*' appears more than once")
+			}
+			p.star = true
+			continue
+		}
+		p.sel = append(p.sel, sel[i])
+	}
+	sel = p.sel
+	if !p.star {
+		constexpr, ok := sel.toConst()
+		if ok {
+			p.constexpr = &constexpr
+		}
 	}
 
 	prg := &p.prog
@@ -122,6 +143,12 @@ func NewProjection(sel Selection, dst QuerySink) (*Projection, error) {
 			return nil, err
 		}
 	}
+	if p.star {
+		// the fields are merged with the
+		// input in Go; see starproject
+		prg.returnValue(prg.mergeMem(mem...))
+		return p, nil
+	}
 	// preserve the initial predicate mask
 	// so that we can use it for projection
 	prg.returnBool(prg.mergeMem(mem...), prg.validLanes())
@@ -154,6 +181,11 @@ func (p *Projection) Open() (io.WriteCloser, error) {
 		return nil, err
 	}
 	rc, ok := dst.(rowConsumer)
+	if p.star {
+		sp := &starproject{parent: p, dst: dst, dstrc: rc}
+		sp.aw.out = sp.dst
+		return splitter(sp), nil
+	}
 	if !ok && p.constexpr != nil {
 		cp := &constproject{datum: p.constexpr, dst: dst}
 		return splitter(cp), nil
@@ -271,7 +303,9 @@ func (p *projector) writeRows(delims []vmref, rp *rowParams) error {
 	// all of the input delimiters must need more buffer space
 	lc := 0
 
-	p.bc.prepare(rp)
+	if err := p.bc.prepare(rp, delims); err != nil {
+		return err
+	}
 	for len(delims) > 0 {
 		auxpos := p.bc.auxpos
 		off, rewrote := p.bcproject(delims, p.aw.buf[p.aw.off:], p.outsel)
@@ -15,12 +15,17 @@
 package vm
 
 import (
+	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"runtime"
+	"slices"
+	"strings"
 	"sync/atomic"
 	"testing"
 
+	"github.com/SnellerInc/sneller/expr"
 	"github.com/SnellerInc/sneller/ion"
 )
 
@@ -210,6 +215,72 @@ func TestSelectNested(t *testing.T) {
 	}
 }
 
+func TestSelectStar(t *testing.T) {
+	var st ion.Symtab
+	var buf, chunk ion.Buffer
+	b, a, z := st.Intern("b"), st.Intern("a"), st.Intern("z")
+	buf.BeginStruct(-1)
+	buf.BeginField(b)
+	buf.WriteInt(2)
+	buf.BeginField(a)
+	buf.WriteInt(1)
+	buf.BeginField(z)
+	buf.WriteInt(0)
+	buf.EndStruct()
+	buf.BeginStruct(-1)
+	buf.BeginField(a)
+	buf.WriteInt(3)
+	buf.BeginField(z)
+	buf.WriteString("x")
+	buf.EndStruct()
+	st.Marshal(&chunk, true)
+	chunk.UnsafeAppend(buf.Bytes())
+
+	// SELECT *, a + 1 AS c, z AS a, b + 1 AS d
+	sel := Selection{
+		{Expr: expr.Star{}},
+		expr.Bind(expr.Add(expr.Identifier("a"), expr.Integer(1)), "c"),
+		expr.Bind(expr.Identifier("z"), "a"),
+		expr.Bind(expr.Add(expr.Identifier("b"), expr.Integer(1)), "d"),
+	}
+	want := [][]string{
+		{`"b": 2`, `"a": 0`, `"z": 0`, `"c": 2`, `"d": 3`},
+		{`"a": "x"`, `"z": "x"`, `"c": 4`},
+	}
+	var first []byte
+	for i := 0; i < 2; i++ {
+		var out QueryBuffer
+		dst, err := NewProjection(sel, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = CopyRows(dst, buftbl(chunk.Bytes()), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		skipok(out.Bytes(), t)
+		if i == 0 {
+			first = out.Bytes()
+		} else if !bytes.Equal(first, out.Bytes()) {
+			t.Error("output is not deterministic")
+		}
+		rows := readRows(t, out.Bytes())
+		if len(rows) != len(want) {
+			t.Fatalf("got %d rows, want %d", len(rows), len(want))
+		}
+		for j := range rows {
+			var got []string
+			rows[j].Each(func(f ion.Field) error {
+				got = append(got, fmt.Sprintf("%q: %s", f.Label, strings.TrimSpace(toJSON(&st, f.Datum))))
+				return nil
+			})
+			if !slices.Equal(got, want[j]) {
+				t.Errorf("row %d: got %v, want %v", j, got, want[j])
+			}
+		}
+	}
+}
+
 // dumb table that yields the same chunk 'count' times
 // (useful for benchmarking)
 type looptable struct {
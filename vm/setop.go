@@ -0,0 +1,356 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"fmt"
+	"hash/maphash"
+	"io"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// setOpParts is the number of partitions of a SetOpTable
+const setOpParts = 64
+
+// SetOpTable is a QuerySink that collects the rows
+// on the right-hand side of INTERSECT and EXCEPT.
+//
+// Each input row contains the columns of the set
+// operation and the number of times the row occurs.
+// Rows are keyed by the typed values of their columns
+// and partitioned by the hash of the key, so writers
+// running in parallel rarely contend for a lock.
+// There is no limit on the number of rows in the table.
+type SetOpTable struct {
+	columns []string
+	count   string
+	seed    maphash.Seed
+	parts   [setOpParts]setOpPart
+}
+
+type setOpPart struct {
+	lock sync.Mutex
+	rows map[string]*setOpEntry
+}
+
+type setOpEntry struct {
+	count int64 // occurrences on the right-hand side
+	seen  int64 // occurrences on the left-hand side so far
+}
+
+// NewSetOpTable constructs a SetOpTable for rows
+// with the given columns. The number of occurrences
+// of each row is read from the count field.
+func NewSetOpTable(columns []string, count string) *SetOpTable {
+	return &SetOpTable{
+		columns: columns,
+		count:   count,
+		seed:    maphash.MakeSeed(),
+	}
+}
+
+func (t *SetOpTable) part(key []byte) *setOpPart {
+	return &t.parts[maphash.Bytes(t.seed, key)%setOpParts]
+}
+
+func (t *SetOpTable) lookup(key []byte) *setOpEntry {
+	return t.part(key).rows[string(key)]
+}
+
+// Open implements QuerySink.Open
+func (t *SetOpTable) Open() (io.WriteCloser, error) {
+	return splitter(&setOpBuilder{
+		table: t,
+		keyer: newSetOpKeyer(t.columns, t.count),
+	}), nil
+}
+
+// Close implements QuerySink.Close
+func (t *SetOpTable) Close() error { return nil }
+
+// Len returns the number of distinct rows in t
+func (t *SetOpTable) Len() int {
+	n := 0
+	for i := range t.parts {
+		n += len(t.parts[i].rows)
+	}
+	return n
+}
+
+// setOpKeyer computes the keys of rows
+// in a SetOpTable or a SetOp
+type setOpKeyer struct {
+	columns []string
+	count   string
+
+	st     *symtab
+	aux    []int // aux binding of each column or -1
+	fields [][]byte
+	cnt    []byte
+	key    ion.Buffer
+	keyst  ion.Symtab
+}
+
+func newSetOpKeyer(columns []string, count string) setOpKeyer {
+	return setOpKeyer{
+		columns: columns,
+		count:   count,
+		aux:     make([]int, len(columns)),
+		fields:  make([][]byte, len(columns)),
+	}
+}
+
+func (k *setOpKeyer) symbolize(st *symtab, aux *auxbindings) {
+	k.st = st
+	for i := range k.columns {
+		k.aux[i], _ = aux.id(k.columns[i])
+	}
+}
+
+// row computes the key of row i and,
+// if the keyer has a count field, its count
+func (k *setOpKeyer) row(delims []vmref, rp *rowParams, i int) (int64, error) {
+	clear(k.fields)
+	k.cnt = nil
+	_, err := ion.UnpackStructBody(&k.st.Symtab, delims[i].mem(), func(name string, val []byte) error {
+		if k.count != "" && name == k.count {
+			k.cnt = val
+			return nil
+		}
+		for j := range k.columns {
+			if k.columns[j] == name {
+				k.fields[j] = val
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	// aux bindings shadow the fields of the row
+	for j := range k.aux {
+		if k.aux[j] >= 0 {
+			k.fields[j] = rp.auxbound[k.aux[j]][i].mem()
+		}
+	}
+	k.key.Reset()
+	for _, val := range k.fields {
+		if len(val) == 0 {
+			k.key.UnsafeAppend([]byte{0}) // MISSING
+			continue
+		}
+		d, _, err := ion.ReadDatum(&k.st.Symtab, val)
+		if err != nil {
+			return 0, err
+		}
+		k.key.UnsafeAppend([]byte{1})
+		appendGroupKey(&k.key, &k.keyst, d)
+	}
+	if k.cnt == nil {
+		return 0, nil
+	}
+	d, _, err := ion.ReadDatum(&k.st.Symtab, k.cnt)
+	if err != nil {
+		return 0, err
+	}
+	n, err := d.Int()
+	if err != nil {
+		return 0, fmt.Errorf("vm.SetOpTable: unexpected count: %w", err)
+	}
+	return n, nil
+}
+
+// setOpBuilder is the goroutine-local
+// component of SetOpTable
+type setOpBuilder struct {
+	table *SetOpTable
+	keyer setOpKeyer
+}
+
+var _ rowConsumer = &setOpBuilder{}
+
+func (b *setOpBuilder) symbolize(st *symtab, aux *auxbindings) error {
+	b.keyer.symbolize(st, aux)
+	return nil
+}
+
+func (b *setOpBuilder) next() rowConsumer { return nil }
+
+func (b *setOpBuilder) writeRows(delims []vmref, rp *rowParams) error {
+	for i := range delims {
+		n, err := b.keyer.row(delims, rp, i)
+		if err != nil {
+			return err
+		}
+		key := b.keyer.key.Bytes()
+		p := b.table.part(key)
+		p.lock.Lock()
+		if p.rows == nil {
+			p.rows = make(map[string]*setOpEntry)
+		}
+		e := p.rows[string(key)]
+		if e == nil {
+			e = &setOpEntry{}
+			p.rows[string(key)] = e
+		}
+		e.count += n
+		p.lock.Unlock()
+	}
+	return nil
+}
+
+func (b *setOpBuilder) Close() error { return nil }
+
+// SetOp is a QuerySink that filters rows according
+// to the semantics of INTERSECT and EXCEPT given
+// the rows on the right-hand side of the operation.
+//
+// INTERSECT passes the rows that are present in the
+// table and EXCEPT passes the rows that are not;
+// the caller is expected to remove duplicate rows.
+// INTERSECT ALL passes the first count copies of
+// each row, and EXCEPT ALL passes all of the copies
+// except for the first count copies.
+//
+// Rows are passed to the output as they arrive;
+// the table must not be written to while
+// a SetOp reads from it.
+type SetOp struct {
+	except, all bool
+	table       *SetOpTable
+	dst         QuerySink
+}
+
+// NewSetOp constructs a SetOp that
+// writes the selected rows into dst.
+func NewSetOp(except, all bool, table *SetOpTable, dst QuerySink) *SetOp {
+	return &SetOp{
+		except: except,
+		all:    all,
+		table:  table,
+		dst:    dst,
+	}
+}
+
+// Open implements QuerySink.Open
+func (s *SetOp) Open() (io.WriteCloser, error) {
+	dst, err := s.dst.Open()
+	if err != nil {
+		return nil, err
+	}
+	return splitter(&setOpFilter{
+		parent: s,
+		keyer:  newSetOpKeyer(s.table.columns, ""),
+		dst:    asRowConsumer(dst),
+	}), nil
+}
+
+// Close implements QuerySink.Close
+func (s *SetOp) Close() error {
+	return s.dst.Close()
+}
+
+// keep determines whether or not a row
+// with the given table entry should be emitted
+func (s *SetOp) keep(e *setOpEntry) bool {
+	if e == nil {
+		return s.except
+	}
+	if !s.all {
+		return !s.except
+	}
+	n := atomic.AddInt64(&e.seen, 1) - 1
+	if s.except {
+		return n >= e.count
+	}
+	return n < e.count
+}
+
+// setOpFilter is the goroutine-local
+// component of SetOp
+type setOpFilter struct {
+	parent *SetOp
+	keyer  setOpKeyer
+	dst    rowConsumer
+	params rowParams
+}
+
+var _ rowConsumer = &setOpFilter{}
+
+func (f *setOpFilter) symbolize(st *symtab, aux *auxbindings) error {
+	f.keyer.symbolize(st, aux)
+	return f.dst.symbolize(st, aux)
+}
+
+func (f *setOpFilter) next() rowConsumer { return f.dst }
+
+func (f *setOpFilter) writeRows(delims []vmref, rp *rowParams) error {
+	aux := shrink(f.params.auxbound, len(rp.auxbound))
+	for j := range aux {
+		aux[j] = rp.auxbound[j][:len(delims)]
+	}
+	outpos := 0
+	for i := range delims {
+		if _, err := f.keyer.row(delims, rp, i); err != nil {
+			return err
+		}
+		if !f.parent.keep(f.parent.table.lookup(f.keyer.key.Bytes())) {
+			continue
+		}
+		delims[outpos] = delims[i]
+		for j := range aux {
+			aux[j][outpos] = aux[j][i]
+		}
+		outpos++
+	}
+	if outpos == 0 {
+		return nil
+	}
+	for j := range aux {
+		aux[j] = aux[j][:outpos]
+	}
+	f.params.auxbound = aux
+	return f.dst.writeRows(delims[:outpos], &f.params)
+}
+
+func (f *setOpFilter) Close() error {
+	return f.dst.Close()
+}
+
+// appendGroupKey appends an encoding of the grouping
+// value d to dst that does not depend on the symbol table
+// or on the representation of numbers and strings
+func appendGroupKey(dst *ion.Buffer, st *ion.Symtab, d ion.Datum) {
+	switch d.Type() {
+	case ion.SymbolType:
+		str, _ := d.String()
+		dst.WriteString(str)
+	case ion.FloatType:
+		f, _ := d.Float()
+		if i := int64(f); float64(i) == f && f >= math.MinInt64 && f < math.MaxInt64 {
+			dst.WriteInt(i)
+		} else {
+			dst.WriteFloat64(f)
+		}
+	default:
+		st.Reset()
+		d.Encode(dst, st)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"io"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// chunkTable is a Table that yields each of its chunks once
+type chunkTable struct {
+	chunks [][]byte
+	next   int64
+}
+
+func (c *chunkTable) run(dst io.Writer) error {
+	tmp := Malloc()
+	defer Free(tmp)
+	for {
+		i := atomic.AddInt64(&c.next, 1) - 1
+		if i >= int64(len(c.chunks)) {
+			return nil
+		}
+		_, err := dst.Write(tmp[:copy(tmp, c.chunks[i])])
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *chunkTable) WriteChunks(dst QuerySink, parallel int) error {
+	return SplitInput(dst, parallel, c.run)
+}
+
+// setOpRows returns a table with one row per datum;
+// each row has the field i set to its position, the field x
+// set to the datum (unless it is empty) and, if count is
+// positive, a count field set to the given count
+func setOpRows(t *testing.T, values []ion.Datum, count int64) *chunkTable {
+	const perChunk = 1000
+	tbl := &chunkTable{}
+	for len(values) > 0 {
+		n := min(len(values), perChunk)
+		var st ion.Symtab
+		var buf, chunk ion.Buffer
+		for i, v := range values[:n] {
+			buf.BeginStruct(-1)
+			buf.BeginField(st.Intern("i"))
+			buf.WriteInt(int64(i))
+			if !v.IsEmpty() {
+				buf.BeginField(st.Intern("x"))
+				v.Encode(&buf, &st)
+			}
+			if count > 0 {
+				buf.BeginField(st.Intern("$__count"))
+				buf.WriteInt(count)
+			}
+			buf.EndStruct()
+		}
+		st.Marshal(&chunk, true)
+		chunk.UnsafeAppend(buf.Bytes())
+		if chunk.Size() > PageSize {
+			t.Fatal("test input does not fit in a page")
+		}
+		tbl.chunks = append(tbl.chunks, chunk.Bytes())
+		values = values[n:]
+	}
+	return tbl
+}
+
+func setOpTable(t *testing.T, right []ion.Datum, count int64) *SetOpTable {
+	table := NewSetOpTable([]string{"x"}, "$__count")
+	err := CopyRows(table, setOpRows(t, right, count), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func TestSetOpLarge(t *testing.T) {
+	// the right-hand side is not limited in size
+	const size = 25000
+	right := make([]ion.Datum, size)
+	for i := range right {
+		right[i] = ion.Int(int64(i))
+	}
+	table := setOpTable(t, right, 1)
+	if n := table.Len(); n != size {
+		t.Fatalf("table has %d rows, want %d", n, size)
+	}
+	left := make([]ion.Datum, 2*size)
+	for i := range left {
+		left[i] = ion.Int(int64(i))
+	}
+	for _, except := range []bool{false, true} {
+		var c Count
+		err := CopyRows(NewSetOp(except, false, table, &c), setOpRows(t, left, 0), 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Value() != size {
+			t.Errorf("except=%v: got %d rows, want %d", except, c.Value(), size)
+		}
+	}
+}
+
+func TestSetOpTypedKeys(t *testing.T) {
+	table := setOpTable(t, []ion.Datum{
+		ion.Int(1),
+		ion.String("a"),
+		ion.Float(2.5),
+		ion.Null,
+	}, 1)
+	left := []ion.Datum{
+		ion.Float(1),           // equal to 1
+		ion.Interned(nil, "a"), // equal to "a"
+		ion.Float(2.5),         // equal to 2.5
+		ion.Null,               // NULL is not distinct from NULL
+		ion.String("1"),        // not equal to 1
+		ion.Int(2),             // not present
+		{},                     // MISSING is not NULL
+		ion.String("a "),       // not equal to "a"
+		ion.Float(1.00001),     // not equal to 1
+	}
+	run := func(except bool) []string {
+		var out QueryBuffer
+		err := CopyRows(NewSetOp(except, false, table, &out), setOpRows(t, left, 0), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, row := range readRows(t, out.Bytes()) {
+			f, ok := row.FieldByName("x")
+			if !ok {
+				got = append(got, "MISSING")
+				continue
+			}
+			got = append(got, f.Datum.JSON())
+		}
+		slices.Sort(got)
+		return got
+	}
+	want := []string{`"a"`, "1", "2.5", "null"}
+	if got := run(false); !slices.Equal(got, want) {
+		t.Errorf("INTERSECT: got %v, want %v", got, want)
+	}
+	want = []string{`"1"`, `"a "`, "1.00001", "2", "MISSING"}
+	if got := run(true); !slices.Equal(got, want) {
+		t.Errorf("EXCEPT: got %v, want %v", got, want)
+	}
+}
+
+func TestSetOpAll(t *testing.T) {
+	// x=1 occurs 3 times on the right-hand side
+	// and 1000 times on the left-hand side
+	table := setOpTable(t, []ion.Datum{ion.Int(1)}, 3)
+	left := make([]ion.Datum, 1000)
+	for i := range left {
+		left[i] = ion.Int(1)
+	}
+	for _, tc := range []struct {
+		except bool
+		want   int64
+	}{
+		{false, 3},
+		{true, 997},
+	} {
+		var c Count
+		err := CopyRows(NewSetOp(tc.except, true, table, &c), setOpRows(t, left, 0), 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Value() != tc.want {
+			t.Errorf("except=%v: got %d rows, want %d", tc.except, c.Value(), tc.want)
+		}
+		// reset the number of rows seen
+		// so that the table can be reused
+		for i := range table.parts {
+			for _, e := range table.parts[i].rows {
+				e.seen = 0
+			}
+		}
+	}
+}
@@ -186,7 +186,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 37: /* cmpeq.f64 */
+	case 39: /* cmpeq.f64 */
 		if len(v.args) == 3 {
 			// (cmpeq.f64 x x k) -> k
 			if x := v.args[0]; true {
@@ -197,7 +197,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 39: /* cmpeq.i64 */
+	case 41: /* cmpeq.i64 */
 		if len(v.args) == 3 {
 			// (cmpeq.i64 x x k) -> k
 			if x := v.args[0]; true {
@@ -208,7 +208,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 49: /* cmple.f64 */
+	case 51: /* cmple.f64 */
 		if len(v.args) == 3 {
 			// (cmple.f64 x x k) -> k
 			if x := v.args[0]; true {
@@ -219,7 +219,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 51: /* cmple.i64 */
+	case 53: /* cmple.i64 */
 		if len(v.args) == 3 {
 			// (cmple.i64 x x k) -> k
 			if x := v.args[0]; true {
@@ -230,7 +230,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 53: /* cmpge.f64 */
+	case 55: /* cmpge.f64 */
 		if len(v.args) == 3 {
 			// (cmpge.f64 x x k) -> k
 			if x := v.args[0]; true {
@@ -241,7 +241,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 55: /* cmpge.i64 */
+	case 57: /* cmpge.i64 */
 		if len(v.args) == 3 {
 			// (cmpge.i64 x x k) -> k
 			if x := v.args[0]; true {
@@ -252,32 +252,32 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 72: /* cvt.k@i64 */
+	case 75: /* cvt.k@i64 */
 		if len(v.args) == 2 {
 			// (cvt.k@i64 (init) _) -> (broadcast.i 1)
 			if _tmp23 := v.args[0]; _tmp23.op == 1 {
-				return /* clobber v */ p.setssa(v, 148, 1), true
+				return /* clobber v */ p.setssa(v, 159, 1), true
 			}
 			// (cvt.k@i64 (false) _) -> (broadcast.i 0)
 			if _tmp24 := v.args[0]; _tmp24.op == 7 {
-				return /* clobber v */ p.setssa(v, 148, 0), true
+				return /* clobber v */ p.setssa(v, 159, 0), true
 			}
 		}
-	case 73: /* cvt.k@f64 */
+	case 76: /* cvt.k@f64 */
 		if len(v.args) == 2 {
 			// (cvt.k@f64 (init) _) -> (broadcast.f 1)
 			if _tmp25 := v.args[0]; _tmp25.op == 1 {
-				return /* clobber v */ p.setssa(v, 147, 1), true
+				return /* clobber v */ p.setssa(v, 158, 1), true
 			}
 			// (cvt.k@f64 (false) _) -> (broadcast.f 0)
 			if _tmp26 := v.args[0]; _tmp26.op == 7 {
-				return /* clobber v */ p.setssa(v, 147, 0), true
+				return /* clobber v */ p.setssa(v, 158, 0), true
 			}
 		}
-	case 74: /* cvt.i64@k */
+	case 77: /* cvt.i64@k */
 		if len(v.args) == 2 {
 			// (cvt.i64@k _tmp0:(broadcast.i imm) k) -> (and.k "p.choose(imm != 0)" k)
-			if _tmp0 := v.args[0]; _tmp0.op == 148 {
+			if _tmp0 := v.args[0]; _tmp0.op == 159 {
 				if k := v.args[1]; true {
 					if imm := toi64(_tmp0.imm); true {
 						return /* clobber v */ p.setssa(v, 8, nil, p.choose(imm != 0), k), true
@@ -285,7 +285,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 135: /* store.v */
+	case 145: /* store.v */
 		if len(v.args) == 3 {
 			// (store.v mem ov k:(false) slot), "ov != k" -> (store.v mem k k slot)
 			if mem := v.args[0]; true {
@@ -293,14 +293,14 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 					if k := v.args[2]; k.op == 7 {
 						if slot := v.imm; true {
 							if ov != k {
-								return /* clobber v */ p.setssa(v, 135, slot, mem, k, k), true
+								return /* clobber v */ p.setssa(v, 145, slot, mem, k, k), true
 							}
 						}
 					}
 				}
 			}
 		}
-	case 142: /* make.vk */
+	case 152: /* make.vk */
 		if len(v.args) == 2 {
 			// (make.vk val k), "p.mask(val) == k" -> val
 			if val := v.args[0]; true {
@@ -311,7 +311,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 143: /* floatk */
+	case 153: /* floatk */
 		if len(v.args) == 2 {
 			// (floatk f k), "p.mask(f) == k" -> f
 			if f := v.args[0]; true {
@@ -322,20 +322,20 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 144: /* notmissing */
+	case 154: /* notmissing */
 		if len(v.args) == 1 {
 			// (notmissing k) -> k
 			if k := v.args[0]; true {
 				return k, true
 			}
 		}
-	case 145: /* blend.v */
+	case 155: /* blend.v */
 		if len(v.args) == 4 {
 			// (blend.v x k _ (false)) -> (make.vk x k)
 			if x := v.args[0]; true {
 				if k := v.args[1]; true {
 					if _tmp27 := v.args[3]; _tmp27.op == 7 {
-						return /* clobber v */ p.setssa(v, 142, nil, x, k), true
+						return /* clobber v */ p.setssa(v, 152, nil, x, k), true
 					}
 				}
 			}
@@ -343,41 +343,41 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 			if _tmp28 := v.args[1]; _tmp28.op == 7 {
 				if y := v.args[2]; true {
 					if k := v.args[3]; true {
-						return /* clobber v */ p.setssa(v, 142, nil, y, k), true
+						return /* clobber v */ p.setssa(v, 152, nil, y, k), true
 					}
 				}
 			}
 			// (blend.v _ _ y (init)) -> (make.vk y (init))
 			if y := v.args[2]; true {
 				if _tmp29 := v.args[3]; _tmp29.op == 1 {
-					return /* clobber v */ p.setssa(v, 142, nil, y, p.values[0]), true
+					return /* clobber v */ p.setssa(v, 152, nil, y, p.values[0]), true
 				}
 			}
 		}
-	case 181: /* add.f */
+	case 192: /* add.f */
 		if len(v.args) == 3 {
 			// (add.f _tmp1:(broadcast.f imm) f k) -> (add.imm.f f k imm)
-			if _tmp1 := v.args[0]; _tmp1.op == 147 {
+			if _tmp1 := v.args[0]; _tmp1.op == 158 {
 				if f := v.args[1]; true {
 					if k := v.args[2]; true {
 						if imm := tof64(_tmp1.imm); true {
-							return /* clobber v */ p.setssa(v, 183, imm, f, k), true
+							return /* clobber v */ p.setssa(v, 194, imm, f, k), true
 						}
 					}
 				}
 			}
 			// (add.f f _tmp2:(broadcast.f imm) k) -> (add.imm.f f k imm)
 			if f := v.args[0]; true {
-				if _tmp2 := v.args[1]; _tmp2.op == 147 {
+				if _tmp2 := v.args[1]; _tmp2.op == 158 {
 					if k := v.args[2]; true {
 						if imm := tof64(_tmp2.imm); true {
-							return /* clobber v */ p.setssa(v, 183, imm, f, k), true
+							return /* clobber v */ p.setssa(v, 194, imm, f, k), true
 						}
 					}
 				}
 			}
 		}
-	case 183: /* add.imm.f */
+	case 194: /* add.imm.f */
 		if len(v.args) == 2 {
 			// (add.imm.f f _ 0) -> f
 			if f := v.args[0]; true {
@@ -386,7 +386,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 184: /* add.imm.i */
+	case 195: /* add.imm.i */
 		if len(v.args) == 2 {
 			// (add.imm.i i _ 0) -> i
 			if i := v.args[0]; true {
@@ -395,30 +395,30 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 185: /* sub.f */
+	case 196: /* sub.f */
 		if len(v.args) == 3 {
 			// (sub.f _tmp3:(broadcast.f imm) f k) -> (rsub.imm.f f k imm)
-			if _tmp3 := v.args[0]; _tmp3.op == 147 {
+			if _tmp3 := v.args[0]; _tmp3.op == 158 {
 				if f := v.args[1]; true {
 					if k := v.args[2]; true {
 						if imm := tof64(_tmp3.imm); true {
-							return /* clobber v */ p.setssa(v, 191, imm, f, k), true
+							return /* clobber v */ p.setssa(v, 202, imm, f, k), true
 						}
 					}
 				}
 			}
 			// (sub.f f _tmp4:(broadcast.f imm) k) -> (sub.imm.f f k imm)
 			if f := v.args[0]; true {
-				if _tmp4 := v.args[1]; _tmp4.op == 147 {
+				if _tmp4 := v.args[1]; _tmp4.op == 158 {
 					if k := v.args[2]; true {
 						if imm := tof64(_tmp4.imm); true {
-							return /* clobber v */ p.setssa(v, 187, imm, f, k), true
+							return /* clobber v */ p.setssa(v, 198, imm, f, k), true
 						}
 					}
 				}
 			}
 		}
-	case 187: /* sub.imm.f */
+	case 198: /* sub.imm.f */
 		if len(v.args) == 2 {
 			// (sub.imm.f f _ 0) -> f
 			if f := v.args[0]; true {
@@ -427,7 +427,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 188: /* sub.imm.i */
+	case 199: /* sub.imm.i */
 		if len(v.args) == 2 {
 			// (sub.imm.i i _ 0) -> i
 			if i := v.args[0]; true {
@@ -436,52 +436,52 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 191: /* rsub.imm.f */
+	case 202: /* rsub.imm.f */
 		if len(v.args) == 2 {
 			// (rsub.imm.f f k 0) -> (neg.f f k)
 			if f := v.args[0]; true {
 				if k := v.args[1]; true {
 					if tof64(v.imm) == 0 {
-						return /* clobber v */ p.setssa(v, 151, nil, f, k), true
+						return /* clobber v */ p.setssa(v, 162, nil, f, k), true
 					}
 				}
 			}
 		}
-	case 192: /* rsub.imm.i */
+	case 203: /* rsub.imm.i */
 		if len(v.args) == 2 {
 			// (rsub.imm.i i k 0) -> (neg.i i k)
 			if i := v.args[0]; true {
 				if k := v.args[1]; true {
 					if toi64(v.imm) == 0 {
-						return /* clobber v */ p.setssa(v, 152, nil, i, k), true
+						return /* clobber v */ p.setssa(v, 163, nil, i, k), true
 					}
 				}
 			}
 		}
-	case 193: /* mul.f */
+	case 204: /* mul.f */
 		if len(v.args) == 3 {
 			// (mul.f f _tmp5:(broadcast.f imm) k) -> (mul.imm.f f k imm)
 			if f := v.args[0]; true {
-				if _tmp5 := v.args[1]; _tmp5.op == 147 {
+				if _tmp5 := v.args[1]; _tmp5.op == 158 {
 					if k := v.args[2]; true {
 						if imm := tof64(_tmp5.imm); true {
-							return /* clobber v */ p.setssa(v, 195, imm, f, k), true
+							return /* clobber v */ p.setssa(v, 206, imm, f, k), true
 						}
 					}
 				}
 			}
 			// (mul.f _tmp6:(broadcast.f imm) f k) -> (mul.imm.f f k imm)
-			if _tmp6 := v.args[0]; _tmp6.op == 147 {
+			if _tmp6 := v.args[0]; _tmp6.op == 158 {
 				if f := v.args[1]; true {
 					if k := v.args[2]; true {
 						if imm := tof64(_tmp6.imm); true {
-							return /* clobber v */ p.setssa(v, 195, imm, f, k), true
+							return /* clobber v */ p.setssa(v, 206, imm, f, k), true
 						}
 					}
 				}
 			}
 		}
-	case 195: /* mul.imm.f */
+	case 206: /* mul.imm.f */
 		if len(v.args) == 2 {
 			// (mul.imm.f f _ 1) -> f
 			if f := v.args[0]; true {
@@ -490,7 +490,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 196: /* mul.imm.i */
+	case 207: /* mul.imm.i */
 		if len(v.args) == 2 {
 			// (mul.imm.i i _ 1) -> i
 			if i := v.args[0]; true {
@@ -499,30 +499,30 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 197: /* div.f */
+	case 208: /* div.f */
 		if len(v.args) == 3 {
 			// (div.f f _tmp7:(broadcast.f imm) k) -> (div.imm.f f k imm)
 			if f := v.args[0]; true {
-				if _tmp7 := v.args[1]; _tmp7.op == 147 {
+				if _tmp7 := v.args[1]; _tmp7.op == 158 {
 					if k := v.args[2]; true {
 						if imm := tof64(_tmp7.imm); true {
-							return /* clobber v */ p.setssa(v, 199, imm, f, k), true
+							return /* clobber v */ p.setssa(v, 210, imm, f, k), true
 						}
 					}
 				}
 			}
 			// (div.f _tmp8:(broadcast.f imm) f k) -> (rdiv.imm.f f k imm)
-			if _tmp8 := v.args[0]; _tmp8.op == 147 {
+			if _tmp8 := v.args[0]; _tmp8.op == 158 {
 				if f := v.args[1]; true {
 					if k := v.args[2]; true {
 						if imm := tof64(_tmp8.imm); true {
-							return /* clobber v */ p.setssa(v, 201, imm, f, k), true
+							return /* clobber v */ p.setssa(v, 212, imm, f, k), true
 						}
 					}
 				}
 			}
 		}
-	case 226: /* or.imm.i */
+	case 237: /* or.imm.i */
 		if len(v.args) == 2 {
 			// (or.imm.i i _ 0) -> i
 			if i := v.args[0]; true {
@@ -531,7 +531,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 230: /* sll.imm.i */
+	case 241: /* sll.imm.i */
 		if len(v.args) == 2 {
 			// (sll.imm.i i _ 0) -> i
 			if i := v.args[0]; true {
@@ -540,7 +540,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 232: /* sra.imm.i */
+	case 243: /* sra.imm.i */
 		if len(v.args) == 2 {
 			// (sra.imm.i i _ 0) -> i
 			if i := v.args[0]; true {
@@ -549,7 +549,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 234: /* srl.imm.i */
+	case 245: /* srl.imm.i */
 		if len(v.args) == 2 {
 			// (srl.imm.i i _ 0) -> i
 			if i := v.args[0]; true {
@@ -558,7 +558,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 242: /* aggand.k */
+	case 253: /* aggand.k */
 		if len(v.args) == 3 {
 			// (aggand.k mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -567,7 +567,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 243: /* aggor.k */
+	case 254: /* aggor.k */
 		if len(v.args) == 3 {
 			// (aggor.k mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -576,7 +576,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 244: /* aggsum.f */
+	case 255: /* aggsum.f */
 		if len(v.args) == 3 {
 			// (aggsum.f mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -585,7 +585,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 245: /* aggsum.i */
+	case 256: /* aggsum.i */
 		if len(v.args) == 3 {
 			// (aggsum.i mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -594,7 +594,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 248: /* aggmin.f */
+	case 259: /* aggmin.f */
 		if len(v.args) == 3 {
 			// (aggmin.f mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -603,7 +603,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 249: /* aggmin.i */
+	case 260: /* aggmin.i */
 		if len(v.args) == 3 {
 			// (aggmin.i mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -612,7 +612,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 250: /* aggmax.f */
+	case 261: /* aggmax.f */
 		if len(v.args) == 3 {
 			// (aggmax.f mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -621,7 +621,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 251: /* aggmax.i */
+	case 262: /* aggmax.i */
 		if len(v.args) == 3 {
 			// (aggmax.i mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -630,7 +630,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 252: /* aggmin.ts */
+	case 263: /* aggmin.ts */
 		if len(v.args) == 3 {
 			// (aggmin.ts mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -639,7 +639,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 253: /* aggmax.ts */
+	case 264: /* aggmax.ts */
 		if len(v.args) == 3 {
 			// (aggmax.ts mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -648,7 +648,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 254: /* aggand.i */
+	case 265: /* aggand.i */
 		if len(v.args) == 3 {
 			// (aggand.i mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -657,7 +657,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 255: /* aggor.i */
+	case 266: /* aggor.i */
 		if len(v.args) == 3 {
 			// (aggor.i mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -666,7 +666,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 256: /* aggxor.i */
+	case 267: /* aggxor.i */
 		if len(v.args) == 3 {
 			// (aggxor.i mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -675,7 +675,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 257: /* aggcount */
+	case 268: /* aggcount */
 		if len(v.args) == 2 {
 			// (aggcount mem (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -684,7 +684,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 260: /* aggslotand.k */
+	case 272: /* aggslotand.k */
 		if len(v.args) == 4 {
 			// (aggslotand.k mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -693,7 +693,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 261: /* aggslotor.k */
+	case 273: /* aggslotor.k */
 		if len(v.args) == 4 {
 			// (aggslotor.k mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -702,7 +702,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 262: /* aggslotsum.f */
+	case 274: /* aggslotsum.f */
 		if len(v.args) == 4 {
 			// (aggslotsum.f mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -711,7 +711,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 263: /* aggslotsum.i */
+	case 275: /* aggslotsum.i */
 		if len(v.args) == 4 {
 			// (aggslotsum.i mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -720,7 +720,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 266: /* aggslotmin.f */
+	case 278: /* aggslotmin.f */
 		if len(v.args) == 4 {
 			// (aggslotmin.f mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -729,7 +729,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 267: /* aggslotmin.i */
+	case 279: /* aggslotmin.i */
 		if len(v.args) == 4 {
 			// (aggslotmin.i mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -738,7 +738,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 268: /* aggslotmax.f */
+	case 280: /* aggslotmax.f */
 		if len(v.args) == 4 {
 			// (aggslotmax.f mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -747,7 +747,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 269: /* aggslotmax.i */
+	case 281: /* aggslotmax.i */
 		if len(v.args) == 4 {
 			// (aggslotmax.i mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -756,7 +756,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 270: /* aggslotmin.ts */
+	case 282: /* aggslotmin.ts */
 		if len(v.args) == 4 {
 			// (aggslotmin.ts mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -765,7 +765,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 271: /* aggslotmax.ts */
+	case 283: /* aggslotmax.ts */
 		if len(v.args) == 4 {
 			// (aggslotmax.ts mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -774,7 +774,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 272: /* aggslotand.i */
+	case 284: /* aggslotand.i */
 		if len(v.args) == 4 {
 			// (aggslotand.i mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -783,7 +783,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 273: /* aggslotor.i */
+	case 285: /* aggslotor.i */
 		if len(v.args) == 4 {
 			// (aggslotor.i mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -792,7 +792,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 274: /* aggslotxor.i */
+	case 286: /* aggslotxor.i */
 		if len(v.args) == 4 {
 			// (aggslotxor.i mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -801,7 +801,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 275: /* aggslotcount */
+	case 287: /* aggslotcount */
 		if len(v.args) == 3 {
 			// (aggslotcount mem _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -810,36 +810,36 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 335: /* boxint */
+	case 349: /* boxint */
 		if len(v.args) == 2 {
 			// (boxint _tmp9:(broadcast.i lit) _) -> (literal lit)
-			if _tmp9 := v.args[0]; _tmp9.op == 148 {
+			if _tmp9 := v.args[0]; _tmp9.op == 159 {
 				if lit := toi64(_tmp9.imm); true {
-					return /* clobber v */ p.setssa(v, 129, lit), true
+					return /* clobber v */ p.setssa(v, 139, lit), true
 				}
 			}
 		}
-	case 336: /* boxfloat */
+	case 350: /* boxfloat */
 		if len(v.args) == 2 {
 			// (boxfloat _tmp10:(broadcast.f lit) _) -> (literal lit)
-			if _tmp10 := v.args[0]; _tmp10.op == 147 {
+			if _tmp10 := v.args[0]; _tmp10.op == 158 {
 				if lit := tof64(_tmp10.imm); true {
-					return /* clobber v */ p.setssa(v, 129, lit), true
+					return /* clobber v */ p.setssa(v, 139, lit), true
 				}
 			}
 		}
-	case 338: /* boxts */
+	case 353: /* boxts */
 		if len(v.args) == 2 {
 			// (boxts _tmp11:(broadcast.ts lit) _), "ts := date.UnixMicro(int64(lit)); true" -> (literal ts)
-			if _tmp11 := v.args[0]; _tmp11.op == 276 {
+			if _tmp11 := v.args[0]; _tmp11.op == 288 {
 				if lit := toi64(_tmp11.imm); true {
 					if ts := date.UnixMicro(int64(lit)); true {
-						return /* clobber v */ p.setssa(v, 129, ts), true
+						return /* clobber v */ p.setssa(v, 139, ts), true
 					}
 				}
 			}
 		}
-	case 345: /* aggapproxcount */
+	case 360: /* aggapproxcount */
 		if len(v.args) == 2 {
 			// (aggapproxcount mem (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -848,7 +848,7 @@ func rewrite1(p *prog, v *value) (*value, bool) {
 				}
 			}
 		}
-	case 346: /* aggslotapproxcount */
+	case 361: /* aggslotapproxcount */
 		if len(v.args) == 4 {
 			// (aggslotapproxcount mem _ _ (false) _) -> mem
 			if mem := v.args[0]; true {
@@ -112,6 +112,10 @@ type Order struct {
 
 	// lock for writing to the heap
 	recordsLock sync.Mutex
+
+	// if non-nil, the output rows
+	// are numbered (see NewRowNumber)
+	number *rowNumbering
 }
 
 // NewOrder constructs a new Order QuerySink that
@@ -215,6 +219,9 @@ func (s *Order) finalizeKtop() error {
 		return err
 	}
 
+	if s.number != nil && len(s.kheap.heaporder) > RowNumberLimit {
+		return fmt.Errorf("ROW_NUMBER: input exceeds %d rows", RowNumberLimit)
+	}
 	off := s.limit.Offset
 	if off >= len(s.kheap.heaporder) {
 		return flush() // symbol table + no data
@@ -236,7 +243,14 @@ func (s *Order) finalizeKtop() error {
 	}
 	for i := range final {
 		rec := &final[i]
-		rec.data.Encode(&tmp, &globalst)
+		if s.number != nil {
+			err := s.number.encode(&tmp, &globalst, &s.kheap, rec)
+			if err != nil {
+				return err
+			}
+		} else {
+			rec.data.Encode(&tmp, &globalst)
+		}
 		if tmp.Size() >= flushAt {
 			err := flush()
 			if err != nil {
@@ -284,7 +298,10 @@ func bcfind(sort *Order, findbc *bytecode, delims []vmref, rp *rowParams) (out [
 
 	findbc.ensureVStackSize(minimumVStackSize)
 	findbc.allocStacks()
-	findbc.prepare(rp)
+	err = findbc.prepare(rp, delims)
+	if err != nil {
+		return
+	}
 	err = evalfind(findbc, delims, len(sort.columns))
 	if err != nil {
 		return
@@ -363,7 +380,9 @@ func (s *sortstateKtop) bcfind(delims []vmref, rp *rowParams) ([]vRegData, error
 }
 
 func (s *sortstateKtop) bcfilter(delims []vmref, rp *rowParams) ([]vmref, error) {
-	s.filtbc.prepare(rp)
+	if err := s.filtbc.prepare(rp, delims); err != nil {
+		return nil, fmt.Errorf("ktop prefilter: %w", err)
+	}
 	valid := evalfilterbc(&s.filtbc, delims)
 	if s.filtbc.err != 0 {
 		return nil, fmt.Errorf("ktop prefilter: %w", s.filtbc.err)
@@ -613,7 +632,11 @@ outer:
 			delim := getdelim(fieldsView, rowID, j, len(cols))
 			cols[j] = delim.mem()
 			if len(cols[j]) == 0 {
-				continue outer // MISSING
+				if s.parent.number == nil {
+					continue outer // MISSING
+				}
+				// numbered rows are never dropped
+				cols[j] = []byte{0x0f} // MISSING sorts as NULL
 			}
 		}
 		datptr := s.kheap.insert(cols)
@@ -517,6 +517,44 @@ func TestSortWithLimit(t *testing.T) {
 	compareIonWithExpectations(t, output.Bytes(), expected)
 }
 
+func TestRowNumber(t *testing.T) {
+	input, err := multiColumnTestIon()
+	if err != nil {
+		t.Fatal(err)
+	}
+	partition := []expr.Node{parsePath("name1")}
+	order := []SortColumn{
+		makeOrdering("coef", SortDescending, SortNullsFirst),
+	}
+	output := new(bytes.Buffer)
+	rn, err := NewRowNumber(output, partition, order, "rn", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = CopyRows(rn, buftbl(input), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ROW_NUMBER() OVER (PARTITION BY name1 ORDER BY coef DESC)
+	expected := []string{
+		"'Ann', 1.400000, true, 761, 1",
+		"'Ann', 0.300000, false, 42, 2",
+		"'John', 1.500000, true, 11, 1",
+		"'John', 0.800000, true, 42, 2",
+		"'John', 0.700000, true, 89, 3",
+		"'Kate', 0.900000, false, 65, 1",
+		"'Kate', 0.800000, false, 11, 2",
+		"'Kate', 0.100000, true, 42, 3",
+		"'Kate', 0.000000, true, 11, 4",
+		"'Mark', 0.200000, false, 42, 1",
+	}
+	compareIonWithExpectations(t, output.Bytes(), expected)
+}
+
 func limitTestIon(rowsCount int) (result []byte, err error) {
 	var buf ion.Buffer
 	var st ion.Symtab
@@ -0,0 +1,130 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// soundexCodes maps the (uppercase) ASCII letters
+// to their Soundex digits; vowels (and Y) map to '0',
+// which separates letters with the same digit,
+// while H and W map to 0 and do not
+var soundexCodes = [26]byte{
+	'0', '1', '2', '3', '0', '1', '2', 0, '0', '2', '2', '4', '5',
+	'5', '0', '1', '2', '6', '2', '3', '0', '1', 0, '2', '0', '2',
+}
+
+// soundex appends the 4-character Soundex code of str
+// to dst; the characters other than ASCII letters are
+// ignored, and if there are no letters, nothing is appended
+func soundex(dst, str []byte) []byte {
+	i := 0
+	for i < len(str) && asciiUpper(str[i]) == 0 {
+		i++
+	}
+	if i == len(str) {
+		return dst
+	}
+	first := asciiUpper(str[i])
+	dst = append(dst, first)
+	n := 1
+	last := soundexCodes[first-'A']
+	for i++; i < len(str) && n < 4; i++ {
+		c := asciiUpper(str[i])
+		if c == 0 {
+			continue
+		}
+		code := soundexCodes[c-'A']
+		if code == 0 {
+			continue
+		}
+		if code != '0' && code != last {
+			dst = append(dst, code)
+			n++
+		}
+		last = code
+	}
+	for ; n < 4; n++ {
+		dst = append(dst, '0')
+	}
+	return dst
+}
+
+// asciiUpper returns the uppercase
+// of the ASCII letter c, or 0 if
+// c is not an ASCII letter
+func asciiUpper(c byte) byte {
+	switch {
+	case c >= 'A' && c <= 'Z':
+		return c
+	case c >= 'a' && c <= 'z':
+		return c - 'a' + 'A'
+	}
+	return 0
+}
+
+// soundexFn is the scalarFunc of SOUNDEX(str);
+// it returns the Soundex code of str, or MISSING
+// if str is not a string
+type soundexFn struct{}
+
+func (soundexFn) bind() scalarImpl {
+	var buf []byte
+	return func(x *scalarCaller, args []vRegData, lane int) (vmref, error) {
+		str, ok := x.str(&args[0], lane)
+		if !ok {
+			return vmref{}, nil
+		}
+		buf = soundex(buf[:0], str)
+		return x.string(buf), nil
+	}
+}
+
+// differenceFn is the scalarFunc of DIFFERENCE(a, b);
+// it returns the number of positions (from 0 to 4) at
+// which the Soundex codes of a and b are equal, or
+// MISSING if either of them is not a string
+type differenceFn struct{}
+
+func (differenceFn) bind() scalarImpl {
+	var buf []byte
+	return func(x *scalarCaller, args []vRegData, lane int) (vmref, error) {
+		a, ok := x.str(&args[0], lane)
+		if !ok {
+			return vmref{}, nil
+		}
+		b, ok := x.str(&args[1], lane)
+		if !ok {
+			return vmref{}, nil
+		}
+		buf = soundex(buf[:0], a)
+		split := len(buf)
+		buf = soundex(buf, b)
+		ca, cb := buf[:split], buf[split:]
+		n := 0
+		for i := range ca {
+			if i < len(cb) && ca[i] == cb[i] {
+				n++
+			}
+		}
+		// n is at most 4, so its ion
+		// encoding is 0x20 or 0x21 n
+		if n == 0 {
+			return x.value([]byte{byte(ion.UintType << 4)}), nil
+		}
+		return x.value([]byte{byte(ion.UintType<<4) | 1, byte(n)}), nil
+	}
+}
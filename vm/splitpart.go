@@ -0,0 +1,111 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"regexp"
+
+	"github.com/SnellerInc/sneller/regexp2"
+)
+
+// splitPartRegexp splits v on the matches of the regular
+// expression pattern and returns the field index. Field
+// indexes start with 1.
+func (p *prog) splitPartRegexp(v *value, pattern string, index *value) (*value, error) {
+	if err := regexp2.IsSupported(pattern); err != nil {
+		return nil, fmt.Errorf("SPLIT_PART: %w", err)
+	}
+	rx, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("SPLIT_PART: %w", err)
+	}
+	ds, err := regexp2.NewDsDelim(rx, regexp2.MaxNodesAutomaton)
+	if err != nil {
+		return nil, fmt.Errorf("SPLIT_PART: %w", err)
+	}
+	v = p.coerceStr(v)
+	indexInt, indexMask := p.coerceI64(index)
+	mask := p.and(v, indexMask)
+	return p.ssa3imm(sSplitPartDfa, v, indexInt, mask, string(ds.Data())), nil
+}
+
+// splitPartDfa returns the span of the idx-th (one-based)
+// field of str, which is split on the non-empty matches of
+// the delimiter DFA ds (see regexp2.DsDelim); ok is false
+// if the field does not exist
+func splitPartDfa(ds, str []byte, idx int64) (from, to int, ok bool) {
+	if idx <= 0 {
+		return 0, 0, false
+	}
+	word := func(off uint32) uint32 {
+		return binary.LittleEndian.Uint32(ds[off:])
+	}
+	// runeAt returns the UTF-8 int of the code-point at str[pos:]
+	// and its size, which is derived from the first byte alone
+	runeAt := func(pos int) (uint32, int) {
+		size := bits.LeadingZeros8(^str[pos])
+		size = min(max(size, 1), 4, len(str)-pos)
+		v := uint32(0)
+		for _, c := range str[pos : pos+size] {
+			v = v<<8 | uint32(c)
+		}
+		return v, size
+	}
+	state := word(0)
+	for pos := 0; pos < len(str); state = word(4) {
+		// find the end of the match at pos
+		end := pos
+		for i := pos; ; {
+			if i == len(str) {
+				if word(state)&2 != 0 {
+					end = i
+				}
+				break
+			}
+			r, size := runeAt(i)
+			edge, n := state+8, word(state+4)
+			for ; n > 0 && r >= word(edge); n, edge = n-1, edge+12 {
+				if r <= word(edge+4) {
+					break
+				}
+			}
+			if n == 0 || r < word(edge) {
+				break
+			}
+			state = word(edge + 8)
+			i += size
+			if word(state)&1 != 0 {
+				end = i
+			}
+		}
+		if end == pos {
+			_, size := runeAt(pos)
+			pos += size
+			continue
+		}
+		if idx == 1 {
+			return from, pos, true
+		}
+		idx--
+		from, pos = end, end
+	}
+	if idx != 1 {
+		return 0, 0, false
+	}
+	return from, len(str), true
+}
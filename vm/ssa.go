@@ -95,6 +95,10 @@ type prog struct {
 
 	// finalizers that must be run when this prog is GC'd
 	finalize []func()
+
+	// scalars holds the calls of the program
+	// that are evaluated in Go, if any
+	scalars *scalarCalls
 }
 
 func (p *prog) reset() {
@@ -232,6 +236,7 @@ func (p *prog) begin() {
 
 	p.symbolized = false
 	p.resolved = p.resolved[:0]
+	p.scalars = nil
 }
 
 func (p *prog) val() *value {
@@ -752,6 +757,9 @@ func (p *prog) tolist(v *value) *value {
 	case stListMasked, stListAndValueMasked:
 		return v
 	case stValue, stValueMasked:
+		if v.op == sboxlist {
+			return v.args[0] // no need to unpack a list we have just boxed
+		}
 		return p.ssa2(stolist, v, p.mask(v))
 	default:
 		return p.errorf("cannot convert value %s to list", v)
@@ -802,8 +810,15 @@ func (p *prog) isNotFalse(v *value) *value {
 // NOTE: array access is linear- rather than
 // constant-time, so accessing large offsets
 // can be very slow.
+//
+// A negative index counts from the end of the list,
+// which requires scanning the whole list.
 func (p *prog) index(v *value, i int) *value {
 	l := p.tolist(v)
+	if i < 0 {
+		l = p.arraySlice(l, i, i+1, i == -1)
+		i = 0
+	}
 	for i >= 0 {
 		// NOTE: CSE will take care of
 		// ensuring that the access of
@@ -816,6 +831,31 @@ func (p *prog) index(v *value, i int) *value {
 	return l
 }
 
+// arraySliceToEnd is the offset past the last element
+// of a slice that extends to the end of the list
+const arraySliceToEnd = math.MaxInt32
+
+// arraySlice evaluates v[from:to] for constant offsets,
+// or v[from:] if toEnd is set, where negative offsets
+// count from the end of the list; the result is MISSING
+// if either offset is out of range
+func (p *prog) arraySlice(v *value, from, to int, toEnd bool) *value {
+	// offsets that don't fit in 32 bits are out of range
+	// for any list, so they can be clamped to ones that
+	// are out of range as well
+	clamp := func(i int) int32 {
+		return int32(min(max(i, math.MinInt32), arraySliceToEnd-1))
+	}
+	if toEnd {
+		to = arraySliceToEnd
+	} else {
+		to = int(clamp(to))
+	}
+	imm := uint64(uint32(clamp(from))) | uint64(uint32(to))<<32
+	l := p.tolist(v)
+	return p.ssa2imm(sarrayslice, l, l, imm)
+}
+
 func (s ssatype) ordnum() int {
 	switch s {
 	case stBool:
@@ -881,13 +921,22 @@ func (p *prog) equals(left, right *value) *value {
 				// only need this for string comparison
 				left = p.unsymbolized(left)
 			}
+			if isTimestampImmediate(right.imm) {
+				// the encoding of the timestamp depends on its precision
+				lts, lk := p.coerceTimestamp(left)
+				rts, rk := p.coerceTimestamp(right)
+				ret := p.ssa1(snotmissing, p.ssa3(scmpeqts, lts, rts, p.and(lk, rk)))
+				ret.notMissing = p.mask(left)
+				return ret
+			}
 			return p.ssa2imm(sequalconst, left, p.mask(left), right.imm)
 		}
 		switch right.primary() {
 		case stValue:
 			left = p.unsymbolized(left)
 			right = p.unsymbolized(right)
-			return p.ssa3(scmpeqv, left, right, p.ssa2(sand, p.mask(left), p.mask(right)))
+			eq := p.ssa3(scmpeqv, left, right, p.ssa2(sand, p.mask(left), p.mask(right)))
+			return p.timestampsCompared(left, right, eq, scmpeqts)
 		case stInt:
 			lefti, k := p.coerceI64(left)
 			return p.ssa3(scmpeqi, lefti, right, p.and(k, p.mask(right)))
@@ -1237,6 +1286,35 @@ func (p *prog) concat(args ...*value) *value {
 	return p.ssava(sstrconcat, values)
 }
 
+// concatWs concatenates the args that are strings
+// with sep between them; the remaining args are
+// skipped along with their separators
+func (p *prog) concatWs(sep *value, args ...*value) *value {
+	sep = p.coerceStr(sep)
+	ksep := p.mask(sep)
+	empty := p.constant("")
+	// strOrEmpty is str in the lanes of k
+	// and the empty string in the other lanes
+	// where the separator is a string
+	strOrEmpty := func(str, k *value) *value {
+		v := p.ssa4(sblendv, empty, ksep, p.ssa2(sboxstr, str, k), k)
+		return p.coerceStr(v)
+	}
+
+	values := make([]*value, 0, len(args)*2)
+	prev := p.ssa0(skfalse) // lanes with a preceding string
+	for i, arg := range args {
+		s := p.coerceStr(arg)
+		k := p.and(p.mask(s), ksep)
+		if i > 0 {
+			values = append(values, strOrEmpty(sep, p.and(k, prev)))
+		}
+		values = append(values, strOrEmpty(s, k))
+		prev = p.or(prev, k)
+	}
+	return p.concat(values...)
+}
+
 func (p *prog) makeList(args ...*value) *value {
 	var values []*value = make([]*value, 0, len(args)*2+1)
 
@@ -1887,13 +1965,31 @@ func (p *prog) compare(left, right *value, op compareOp) *value {
 	if lType == stValue && rType == stValue {
 		mask := p.and(p.mask(left), p.mask(right))
 		cmpv := p.ssa3(scmpv, left, right, mask)
-		return p.ssa2imm(info.cmpiimm, cmpv, p.mask(cmpv), int64(0))
+		ret := p.ssa2imm(info.cmpiimm, cmpv, p.mask(cmpv), int64(0))
+		return p.timestampsCompared(left, right, ret, info.cmpts)
 	}
 
 	// Uncomparable...
 	return p.missing()
 }
 
+// timestampsCompared returns the result of the comparison
+// ret of the values left and right, with the lanes where both
+// values are timestamps replaced by the result of comparing
+// the timestamps with op
+//
+// (the values are compared by their encoding, which for
+// timestamps depends on their precision, so timestamps
+// denoting the same instant would not compare equal)
+func (p *prog) timestampsCompared(left, right, ret *value, op ssaop) *value {
+	lts, lk := p.coerceTimestamp(left)
+	rts, rk := p.coerceTimestamp(right)
+	both := p.and(lk, rk)
+	out := p.ssa1(snotmissing, p.or(p.ssa3(op, lts, rts, both), p.andn(both, ret)))
+	out.notMissing = p.notMissing(ret)
+	return out
+}
+
 // Less computes 'left < right'
 func (p *prog) less(left, right *value) *value {
 	return p.compare(left, right, comparelt)
@@ -2115,7 +2211,7 @@ func (p *prog) ceil(child *value) *value {
 }
 
 func (p *prog) sqrt(child *value) *value {
-	return p.makeUnaryArithmeticOpFp(ssqrtf, child)
+	return p.missingIfNaN(p.makeUnaryArithmeticOpFp(ssqrtf, child))
 }
 
 func (p *prog) cbrt(child *value) *value {
@@ -2139,19 +2235,19 @@ func (p *prog) exp10(child *value) *value {
 }
 
 func (p *prog) ln(child *value) *value {
-	return p.makeUnaryArithmeticOpFp(slnf, child)
+	return p.missingIfNaN(p.makeUnaryArithmeticOpFp(slnf, child))
 }
 
 func (p *prog) ln1p(child *value) *value {
-	return p.makeUnaryArithmeticOpFp(sln1pf, child)
+	return p.missingIfNaN(p.makeUnaryArithmeticOpFp(sln1pf, child))
 }
 
 func (p *prog) log2(child *value) *value {
-	return p.makeUnaryArithmeticOpFp(slog2f, child)
+	return p.missingIfNaN(p.makeUnaryArithmeticOpFp(slog2f, child))
 }
 
 func (p *prog) log10(child *value) *value {
-	return p.makeUnaryArithmeticOpFp(slog10f, child)
+	return p.missingIfNaN(p.makeUnaryArithmeticOpFp(slog10f, child))
 }
 
 func (p *prog) sin(child *value) *value {
@@ -2178,6 +2274,22 @@ func (p *prog) atan(child *value) *value {
 	return p.makeUnaryArithmeticOpFp(satanf, child)
 }
 
+// missingIfNaN removes the lanes of the result
+// of a float operation that became NaN, so that
+// math functions evaluated outside of their domain
+// (i.e. SQRT(-1)) yield MISSING rather than NaN;
+// NaN inputs still propagate to the output as NaN
+func (p *prog) missingIfNaN(v *value) *value {
+	k := p.mask(v)
+	nan := p.ssa2(sisnanf, v, k)
+	for _, arg := range v.args {
+		if arg.primary() == stFloat {
+			nan = p.andn(p.ssa2(sisnanf, arg, k), nan)
+		}
+	}
+	return p.floatk(v, p.andn(nan, k))
+}
+
 // Binary arithmetic operators and functions
 func (p *prog) makeBinaryArithmeticOpImm(regOpF, regOpI ssaop, v *value, imm any) *value {
 	if isIntValue(v) && isIntImmediate(imm) {
@@ -2253,7 +2365,13 @@ func (p *prog) div(left, right *value) *value {
 }
 
 func (p *prog) mod(left, right *value) *value {
-	return p.makeBinaryArithmeticOp(smodf, smodi, smodimmf, smodimmi, srmodimmf, srmodimmi, left, right)
+	v := p.makeBinaryArithmeticOp(smodf, smodi, smodimmf, smodimmi, srmodimmf, srmodimmi, left, right)
+	if v.primary() == stFloat {
+		// x % 0 and x % y where x is infinite are NaN;
+		// the integer ops already yield MISSING for x % 0
+		v = p.missingIfNaN(v)
+	}
+	return v
 }
 
 func (p *prog) pmod(left, right *value) *value {
@@ -2322,7 +2440,7 @@ func (p *prog) hypot(left, right *value) *value {
 }
 
 func (p *prog) pow(left, right *value) *value {
-	return p.makeBinaryArithmeticOpFp(spowf, left, right)
+	return p.missingIfNaN(p.makeBinaryArithmeticOpFp(spowf, left, right))
 }
 
 func (p *prog) powuint(arg *value, exp int64) *value {
@@ -2403,6 +2521,20 @@ func (p *prog) dateAdd(part expr.Timepart, arg0, arg1 *value) *value {
 	return p.errorf("unhandled date part %v in DateAdd()", part)
 }
 
+// dateAddMonthClamp adds arg0 months to arg1,
+// clamping the day of the month to the last
+// day of the resulting month
+//
+// The number of months comes from an INTERVAL
+// literal, so it is always a constant.
+func (p *prog) dateAddMonthClamp(arg0, arg1 *value) *value {
+	if arg0.op != sliteral || !isIntImmediate(arg0.imm) {
+		return p.errorf("DATE_ADD_MONTH_CLAMP: the number of months must be a constant")
+	}
+	arg1Time, arg1Mask := p.coerceTimestamp(arg1)
+	return p.ssa2imm(sdateaddmonthclampimm, arg1Time, arg1Mask, toi64(arg0.imm))
+}
+
 func (p *prog) dateBin(stride int64, arg0, arg1 *value) *value {
 	t0, m0 := p.coerceTimestamp(arg0)
 	t1, m1 := p.coerceTimestamp(arg1)
@@ -2457,6 +2589,8 @@ func (p *prog) dateExtract(part expr.Timepart, val *value) *value {
 		return p.ssa2(sdateextractdow, v, m)
 	case expr.DOY:
 		return p.ssa2(sdateextractdoy, v, m)
+	case expr.Week:
+		return p.dateExtractWeek(v, m)
 	case expr.Month:
 		return p.ssa2(sdateextractmonth, v, m)
 	case expr.Quarter:
@@ -2468,6 +2602,30 @@ func (p *prog) dateExtract(part expr.Timepart, val *value) *value {
 	}
 }
 
+// dateExtractWeek computes the ISO 8601 week number
+// of the timestamp v, which is the week containing
+// the Thursday of the (Monday-based) week of v; that
+// Thursday is always in the year that owns the week,
+// so the week number follows from its day of year
+func (p *prog) dateExtractWeek(v, m *value) *value {
+	dow := p.ssa2(sdateextractdow, v, m)
+	// days since Monday in [0, 6]
+	monday := p.ssa2imm(smodimmi, p.ssa2imm(saddimmi, dow, m, 6), m, 7)
+	// days to the Thursday in [-3, 3]
+	shift := p.ssa2imm(srsubimmi, monday, m, 3)
+	thursday := p.ssa3imm(sdateaddmulimm, v, shift, m, expr.TimePartMultiplier[expr.Day])
+	doy := p.ssa2(sdateextractdoy, thursday, p.mask(thursday))
+	return p.ssa2imm(sdivimmi, p.ssa2imm(saddimmi, doy, m, 6), m, 7)
+}
+
+// dateExtractEpoch computes the number of seconds
+// (including the fractional part) since the Unix epoch
+func (p *prog) dateExtractEpoch(val *value) *value {
+	v, m := p.coerceTimestamp(val)
+	micro := p.ssa2(sdatetounixmicro, v, m)
+	return p.ssa2imm(sdivimmf, p.ssa2(scvti64tof64, micro, m), m, 1000000.0)
+}
+
 func (p *prog) dateToUnixEpoch(val *value) *value {
 	v, m := p.coerceTimestamp(val)
 	return p.ssa2(sdatetounixepoch, v, m)
@@ -2478,6 +2636,64 @@ func (p *prog) dateToUnixMicro(val *value) *value {
 	return p.ssa2(sdatetounixmicro, v, m)
 }
 
+// overlaps computes (s1, e1) OVERLAPS (s2, e2)
+// (see expr.TimeOverlaps) by comparing the
+// timestamps in each of the four orders of
+// the bounds of the two periods
+func (p *prog) overlaps(s1, e1, s2, e2 *value) *value {
+	s1, k1 := p.coerceTimestamp(s1)
+	e1, k2 := p.coerceTimestamp(e1)
+	s2, k3 := p.coerceTimestamp(s2)
+	e2, k4 := p.coerceTimestamp(e2)
+	type period struct {
+		start, end *value
+		ordered    *value // start and end are in this order
+	}
+	periods := func(s, e *value) [2]period {
+		return [2]period{
+			{start: s, end: e, ordered: p.lessEqual(s, e)},
+			{start: e, end: s, ordered: p.less(e, s)},
+		}
+	}
+	ret := p.ssa0(skfalse)
+	for _, a := range periods(s1, e1) {
+		for _, b := range periods(s2, e2) {
+			match := p.or(p.or(
+				p.and(p.greater(a.start, b.start), p.less(a.start, b.end)),
+				p.and(p.less(a.start, b.start), p.less(b.start, a.end))),
+				p.equals(a.start, b.start))
+			ret = p.or(ret, p.and(p.and(a.ordered, b.ordered), match))
+		}
+	}
+	ret = p.ssa1(snotmissing, ret)
+	ret.notMissing = p.and(p.and(k1, k2), p.and(k3, k4))
+	return ret
+}
+
+// dateToEpochMillis computes the number of milliseconds
+// since the Unix epoch; the sub-millisecond part of the
+// timestamp is truncated (towards the earlier millisecond)
+func (p *prog) dateToEpochMillis(val *value) *value {
+	v, m := p.coerceTimestamp(val)
+	v = p.ssa2(sdatetruncmillisecond, v, m)
+	return p.ssa2imm(sdivimmi, p.ssa2(sdatetounixmicro, v, m), m, 1000)
+}
+
+var (
+	minEpochMillis = date.Date(1, 1, 1, 0, 0, 0, 0).UnixMicro() / 1000
+	maxEpochMillis = date.Date(9999, 12, 31, 23, 59, 59, 999000000).UnixMicro() / 1000
+)
+
+// epochMillisToDate interprets an integer as the number
+// of milliseconds since the Unix epoch; integers that do
+// not fit in a timestamp between the years 1 and 9999
+// produce MISSING
+func (p *prog) epochMillisToDate(val *value) *value {
+	v, m := p.coerceI64(val)
+	m = p.and(p.ssa2imm(scmpgeimmi, v, m, minEpochMillis), p.ssa2imm(scmpleimmi, v, m, maxEpochMillis))
+	return p.ssa3imm(sdateaddmulimm, p.ssa0imm(sbroadcastts, int64(0)), v, m, 1000)
+}
+
 func (p *prog) dateTrunc(part expr.Timepart, val *value) *value {
 	if part == expr.Microsecond {
 		return val
@@ -4075,6 +4291,40 @@ func emitaggslotapproxcount(v *value, c *compilestate) {
 	)
 }
 
+func emitaggmergevalue(v *value, c *compilestate) {
+	val := v.args[0]
+	mask := v.args[1]
+
+	if val.op == skfalse {
+		v.setfalse()
+		return
+	}
+
+	c.emit(v, ssainfo[v.op].bc,
+		v.imm.(aggregateslot),
+		c.slotOf(val, regV),
+		c.slotOf(mask, regK),
+	)
+}
+
+func emitaggslotmergevalue(v *value, c *compilestate) {
+	bucket := v.args[0]
+	val := v.args[1]
+	mask := v.args[2]
+
+	if val.op == skfalse {
+		v.setfalse()
+		return
+	}
+
+	c.emit(v, ssainfo[v.op].bc,
+		v.imm.(aggregateslot),
+		c.slotOf(bucket, regL),
+		c.slotOf(val, regV),
+		c.slotOf(mask, regK),
+	)
+}
+
 func (p *prog) emit1(v *value, c *compilestate) {
 	defer func() {
 		if err := recover(); err != nil {
@@ -4106,12 +4356,17 @@ func (p *prog) reserveslots(c *compilestate) {
 // a reserved stack slot.
 func (p *prog) eliminateOutputMoves(c *compilestate) {
 	out := 0
+	stored := make(map[int]bool)
 	for _, v := range p.values {
 		if v.op == sstorev {
 			src := v.args[1]
 			msk := v.args[2]
-			if p.mask(src) == msk && ssainfo[src.op].safeValueMask {
+			// a value can only be assigned one slot, so
+			// a value that is stored into more than one
+			// slot still needs the moves after the first
+			if p.mask(src) == msk && ssainfo[src.op].safeValueMask && !stored[src.id] {
 				c.stack.assignPermanentSlot(regV, src.id, stackslot(v.imm.(int)))
+				stored[src.id] = true
 				continue
 			}
 		}
@@ -4150,7 +4405,7 @@ func (p *prog) compile(dst *bytecode, st *symtab, callerName string) error {
 	dst.compiled = c.asm.grabCode()
 
 	reserve := c.asm.scratchuse + len(c.litbuf)
-	if reserve > PageSize || strings.HasSuffix(callerName, "sort findbc") {
+	if reserve > PageSize || strings.HasSuffix(callerName, "findbc") {
 		reserve = PageSize
 	}
 	dst.savedlit = c.litbuf
@@ -4281,9 +4536,20 @@ func (p *prog) unsymbolized(v *value) *value {
 // to use the given symbol table given the template
 // ssa program (src) and the symbolized program (dst);
 // recompile also takes care of restoring a saved scratch
-// buffer for final if it has been temporarily dropped
+// buffer for final if it has been temporarily dropped and
+// of compiling the arguments of scalar calls
 func recompile(st *symtab, src, dst *prog, final *bytecode, aux *auxbindings, callerName string) error {
 	final.symtab = st.symrefs
+	if src.scalars != nil {
+		if final.scalars == nil {
+			final.scalars = new(scalarCaller)
+		}
+		var err error
+		aux, err = final.scalars.symbolize(st, src.scalars, aux, callerName)
+		if err != nil {
+			return err
+		}
+	}
 	if !dst.isStale(st, aux) {
 		// the scratch buffer may be invalid,
 		// so ensure that it is populated correctly:
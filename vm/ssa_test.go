@@ -996,3 +996,37 @@ func FuzzEncodeDecodePattern(f *testing.F) {
 		}
 	})
 }
+
+func TestLeastGreatestNumeric(t *testing.T) {
+	x, y := expr.Ident("x"), expr.Ident("y")
+	testcases := []struct {
+		expr           expr.Node
+		numeric, boxed bool
+	}{
+		// arguments known to be numbers only use the numeric ops
+		{expr.Call(expr.Least, expr.Add(x, expr.Integer(1)), expr.Float(2.5)), true, false},
+		// arguments of unknown types compare only
+		// the lanes that are not all numbers as boxed values
+		{expr.Call(expr.Greatest, x, y), true, true},
+		// arguments that cannot be numbers are never numeric
+		{expr.Call(expr.Least, x, expr.String("a")), false, true},
+	}
+	for i := range testcases {
+		tc := &testcases[i]
+		p := new(prog)
+		p.begin()
+		v, err := compile(p, tc.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.returnBK(p.validLanes(), p.mask(v))
+		var buf strings.Builder
+		p.writeTo(&buf)
+		text := buf.String()
+		numeric := strings.Contains(text, "minvalue.") || strings.Contains(text, "maxvalue.")
+		boxed := strings.Contains(text, "sortcmpv@")
+		if numeric != tc.numeric || boxed != tc.boxed {
+			t.Errorf("%s: numeric=%v boxed=%v, want %v and %v:\n%s", expr.ToString(tc.expr), numeric, boxed, tc.numeric, tc.boxed, text)
+		}
+	}
+}
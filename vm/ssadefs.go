@@ -51,6 +51,8 @@ const (
 	scmpvimmi64
 	scmpvf64
 	scmpvimmf64
+	ssortcmpvnf
+	ssortcmpvnl
 
 	scmpltstr
 	scmplestr
@@ -87,6 +89,8 @@ const (
 	scmpgei
 	scmpgeimmi
 
+	sisnanf // mask = isnan(x)
+
 	scmpeqts
 	scmpltts
 	scmplets
@@ -124,6 +128,13 @@ const (
 	slowerstr
 	supperstr
 
+	stohex      // hexadecimal encoding of string or blob contents
+	sfromhex    // bytes encoded in hexadecimal by a string
+	stobase64   // base64 encoding of string or blob contents
+	sfrombase64 // bytes encoded in base64 by a string
+
+	sstrreplace // string with the occurrences of a string replaced with another
+
 	// #region raw string comparison
 	sStrCmpEqCs              // Ascii string compare equality case-sensitive
 	sStrCmpEqCi              // Ascii string compare equality case-insensitive
@@ -155,6 +166,7 @@ const (
 	sStrContainsPatternUTF8Ci // String contains pattern case-insensitive
 
 	sIsSubnetOfIP4 // IP subnet matching
+	sIsSubnetOfIP6 // IPv6 range matching
 
 	sStrSkip1CharLeft  // String skip 1 unicode code-point from left
 	sStrSkip1CharRight // String skip 1 unicode code-point from right
@@ -165,6 +177,7 @@ const (
 	scharacterlength // count number of character in a string
 	sSubStr          // select a substring
 	sSplitPart       // Presto split_part
+	sSplitPartDfa    // split_part with a regular expression delimiter
 
 	sDfaT6  // DFA tiny 6-bit
 	sDfaT7  // DFA tiny 7-bit
@@ -205,6 +218,7 @@ const (
 	// blend ops (just conditional moves)
 	sblendv
 	sblendf64
+	sblendi64
 
 	// broadcasts a constant to all lanes
 	sbroadcastf // out = broadcast(float64(imm))
@@ -325,6 +339,7 @@ const (
 	saggxori
 	saggcount
 	saggmergestate
+	saggmergevalue // passes whole values to Go (TOPK)
 
 	saggbucket
 	saggslotandk
@@ -353,6 +368,7 @@ const (
 	sdateaddmulimm
 	sdateaddmonth
 	sdateaddmonthimm
+	sdateaddmonthclampimm
 	sdateaddquarter
 	sdateaddyear
 	sdatebin
@@ -396,6 +412,7 @@ const (
 	sarraysize
 	sarrayposition
 	sarraysum
+	sarrayslice
 
 	svectorinnerproduct
 	svectorinnerproductimm
@@ -410,6 +427,7 @@ const (
 	sboxint   // box an integer
 	sboxfloat // box a float
 	sboxstr   // box a string
+	sboxblob  // box a blob
 	sboxts    // box a timestamp (unpacked)
 
 	smakelist
@@ -426,6 +444,7 @@ const (
 	sAggSlotTDigest
 
 	saggslotmergestate
+	saggslotmergevalue
 
 	_ssamax
 )
@@ -702,6 +721,8 @@ var _ssainfo = [_ssamax]ssaopinfo{
 	scmpvimmi64: {text: "cmpv.i64.imm", argtypes: []ssatype{stValue, stBool}, rettype: stInt | stBool, immfmt: fmti64, bc: opcmpvi64imm},
 	scmpvf64:    {text: "cmpv.f64", argtypes: []ssatype{stValue, stFloat, stBool}, rettype: stInt | stBool, bc: opcmpvf64},
 	scmpvimmf64: {text: "cmpv.f64.imm", argtypes: []ssatype{stValue, stBool}, rettype: stInt | stBool, immfmt: fmtf64, bc: opcmpvf64imm},
+	ssortcmpvnf: {text: "sortcmpv@nf", argtypes: value2Args, rettype: stInt | stBool, bc: opsortcmpvnf},
+	ssortcmpvnl: {text: "sortcmpv@nl", argtypes: value2Args, rettype: stInt | stBool, bc: opsortcmpvnl},
 	scmpltstr:   {text: "cmplt.str", cost: costMedium, argtypes: str2Args, rettype: stBool, bc: opcmpltstr},
 	scmplestr:   {text: "cmple.str", cost: costMedium, argtypes: str2Args, rettype: stBool, bc: opcmplestr},
 	scmpgtstr:   {text: "cmpgt.str", cost: costMedium, argtypes: str2Args, rettype: stBool, bc: opcmpgtstr},
@@ -738,6 +759,8 @@ var _ssainfo = [_ssamax]ssaopinfo{
 	scmpgei:    {text: "cmpge.i64", argtypes: argsIntIntBool, rettype: stBool, bc: opcmpgei64},
 	scmpgeimmi: {text: "cmpge.i64@imm", argtypes: int1Args, rettype: stBool, immfmt: fmti64, bc: opcmpgei64imm},
 
+	sisnanf: {text: "isnan.f", argtypes: fp1Args, rettype: stBool, bc: opisnanf},
+
 	scmpeqts: {text: "cmpeq.ts", rettype: stBool, argtypes: []ssatype{stTime, stTime, stBool}, bc: opcmpeqi64},
 	scmpltts: {text: "cmplt.ts", rettype: stBool, argtypes: []ssatype{stTime, stTime, stBool}, bc: opcmplti64},
 	scmplets: {text: "cmple.ts", rettype: stBool, argtypes: []ssatype{stTime, stTime, stBool}, bc: opcmplei64},
@@ -782,6 +805,15 @@ var _ssainfo = [_ssamax]ssaopinfo{
 	slowerstr: {text: "lower.str", argtypes: str1Args, rettype: stStringMasked, bc: opslower},
 	supperstr: {text: "upper.str", argtypes: str1Args, rettype: stStringMasked, bc: opsupper},
 
+	// the immediate of tobase64 and frombase64 is true
+	// for the URL-safe alphabet
+	stohex:      {text: "tohex", cost: costHeavy, argtypes: []ssatype{stValue, stBool}, rettype: stStringMasked, bc: optohex},
+	sfromhex:    {text: "fromhex", cost: costHeavy, argtypes: str1Args, rettype: stBlobMasked, bc: opfromhex},
+	stobase64:   {text: "tobase64", cost: costHeavy, argtypes: []ssatype{stValue, stBool}, rettype: stStringMasked, immfmt: fmtbool, bc: optobase64},
+	sfrombase64: {text: "frombase64", cost: costHeavy, argtypes: str1Args, rettype: stBlobMasked, immfmt: fmtbool, bc: opfrombase64},
+
+	sstrreplace: {text: "strreplace", cost: costHeavy, argtypes: []ssatype{stString, stString, stString, stBool}, rettype: stStringMasked, bc: opstrreplace},
+
 	sStrCmpEqCs:      {text: "cmp_str_eq_cs", argtypes: str1Args, rettype: stBool, immfmt: fmtdict, bc: opCmpStrEqCs},
 	sStrCmpEqCi:      {text: "cmp_str_eq_ci", argtypes: str1Args, rettype: stBool, immfmt: fmtdict, bc: opCmpStrEqCi},
 	sStrCmpEqUTF8Ci:  {text: "cmp_str_eq_utf8_ci", argtypes: str1Args, rettype: stBool, immfmt: fmtdict, bc: opCmpStrEqUTF8Ci},
@@ -821,6 +853,7 @@ var _ssainfo = [_ssamax]ssaopinfo{
 
 	// ip matching
 	sIsSubnetOfIP4: {text: "is_subnet_of_ip4", cost: costMedium, argtypes: str1Args, rettype: stBool, immfmt: fmtdict, bc: opIsSubnetOfIP4},
+	sIsSubnetOfIP6: {text: "is_subnet_of_ip6", cost: costHeavy, argtypes: str1Args, rettype: stBool, immfmt: fmtdict, bc: opIsSubnetOfIP6},
 
 	// s, k = skip_1char_left s, k -- skip one unicode character at the beginning (left) of a string slice
 	sStrSkip1CharLeft: {text: "skip_1char_left", argtypes: str1Args, rettype: stStringMasked, bc: opSkip1charLeft},
@@ -835,6 +868,7 @@ var _ssainfo = [_ssamax]ssaopinfo{
 	scharacterlength: {text: "characterlength", argtypes: str1Args, rettype: stInt, bc: opcharlength},
 	sSubStr:          {text: "substr", argtypes: []ssatype{stString, stInt, stInt, stBool}, rettype: stString, bc: opSubstr},
 	sSplitPart:       {text: "split_part", argtypes: []ssatype{stString, stInt, stBool}, rettype: stStringMasked, immfmt: fmtdict, bc: opSplitPart},
+	sSplitPartDfa:    {text: "split_part_dfa", cost: costHeavy, argtypes: []ssatype{stString, stInt, stBool}, rettype: stStringMasked, immfmt: fmtdict, bc: opSplitPartDfa},
 
 	sDfaT6:  {text: "dfa_tiny6", cost: costXHeavy, argtypes: str1Args, rettype: stBool, immfmt: fmtdict, bc: opDfaT6},
 	sDfaT7:  {text: "dfa_tiny7", cost: costXHeavy, argtypes: str1Args, rettype: stBool, immfmt: fmtdict, bc: opDfaT7},
@@ -892,6 +926,8 @@ var _ssainfo = [_ssamax]ssaopinfo{
 
 	sblendv:   {text: "blend.v", rettype: stValueMasked, argtypes: []ssatype{stValue, stBool, stValue, stBool}, bc: opblendv, disjunctive: true, safeValueMask: true},
 	sblendf64: {text: "blend.f64", rettype: stFloatMasked, argtypes: []ssatype{stFloat, stBool, stFloat, stBool}, bc: opblendf64, disjunctive: true},
+	// the blend moves whole 64-bit lanes, so it works for integers as well
+	sblendi64: {text: "blend.i64", rettype: stIntMasked, argtypes: []ssatype{stInt, stBool, stInt, stBool}, bc: opblendf64, disjunctive: true},
 
 	sbroadcastf: {text: "broadcast.f", rettype: stFloat, argtypes: []ssatype{}, immfmt: fmtf64, bc: opbroadcastf64},
 	sbroadcasti: {text: "broadcast.i", rettype: stInt, argtypes: []ssatype{}, immfmt: fmti64, bc: opbroadcasti64},
@@ -1036,6 +1072,7 @@ var _ssainfo = [_ssamax]ssaopinfo{
 	sboxint:   {text: "boxint", cost: costHeavy, argtypes: []ssatype{stInt, stBool}, rettype: stValue, bc: opboxi64, safeValueMask: true},
 	sboxfloat: {text: "boxfloat", cost: costHeavy, argtypes: []ssatype{stFloat, stBool}, rettype: stValue, bc: opboxf64, safeValueMask: true},
 	sboxstr:   {text: "boxstr", cost: costXHeavy, argtypes: []ssatype{stString, stBool}, rettype: stValue, bc: opboxstr, safeValueMask: true},
+	sboxblob:  {text: "boxblob", cost: costXHeavy, argtypes: []ssatype{stBlob, stBool}, rettype: stValue, bc: opboxblob, safeValueMask: true},
 
 	// timestamp operations
 	sbroadcastts:            {text: "broadcast.ts", rettype: stTime, argtypes: []ssatype{}, immfmt: fmti64, bc: opbroadcasti64},
@@ -1045,6 +1082,7 @@ var _ssainfo = [_ssamax]ssaopinfo{
 	sdateaddmulimm:          {text: "dateaddmul.imm", rettype: stTimeMasked, argtypes: []ssatype{stTime, stInt, stBool}, immfmt: fmti64, bc: opaddmuli64imm},
 	sdateaddmonth:           {text: "dateaddmonth", rettype: stTimeMasked, argtypes: []ssatype{stTime, stInt, stBool}, bc: opdateaddmonth},
 	sdateaddmonthimm:        {text: "dateaddmonth.imm", rettype: stTimeMasked, argtypes: []ssatype{stTime, stBool}, immfmt: fmti64, bc: opdateaddmonthimm},
+	sdateaddmonthclampimm:   {text: "dateaddmonthclamp.imm", rettype: stTimeMasked, argtypes: []ssatype{stTime, stBool}, immfmt: fmti64, bc: opdateaddmonthclampimm},
 	sdateaddquarter:         {text: "dateaddquarter", rettype: stTimeMasked, argtypes: []ssatype{stTime, stInt, stBool}, bc: opdateaddquarter},
 	sdateaddyear:            {text: "dateaddyear", rettype: stTimeMasked, argtypes: []ssatype{stTime, stInt, stBool}, bc: opdateaddyear},
 	sdatebin:                {text: "datebin", rettype: stTimeMasked, argtypes: []ssatype{stTime, stTime, stBool}, immfmt: fmti64, bc: opdatebin},
@@ -1099,6 +1137,7 @@ var _ssainfo = [_ssamax]ssaopinfo{
 	sarraysize:     {text: "arraysize", argtypes: []ssatype{stList, stBool}, rettype: stInt, bc: oparraysize},
 	sarrayposition: {text: "arrayposition", argtypes: []ssatype{stList, stValue, stBool}, rettype: stIntMasked, bc: oparrayposition},
 	sarraysum:      {text: "arraysum", argtypes: []ssatype{stList, stBool}, rettype: stFloatMasked, bc: oparraysum},
+	sarrayslice:    {text: "arrayslice", argtypes: []ssatype{stList, stBool}, rettype: stListMasked, immfmt: fmti64, bc: oparrayslice},
 
 	svectorinnerproduct:   {text: "vectorinnerproduct", cost: costHeavy, argtypes: []ssatype{stList, stList, stBool}, rettype: stFloatMasked, bc: opvectorinnerproduct},
 	svectorl1distance:     {text: "vectorl1distance", cost: costHeavy, argtypes: []ssatype{stList, stList, stBool}, rettype: stFloatMasked, bc: opvectorl1distance},
@@ -1125,6 +1164,27 @@ var _ssainfo = [_ssamax]ssaopinfo{
 		immfmt:   fmtaggslot,
 		priority: prioMem,
 	},
+	// aggmergevalue and aggslotmergevalue are aggmergestate
+	// and aggslotmergestate applied to whole (boxed) values;
+	// the value registers begin with the same offsets and
+	// sizes as the blob registers
+	saggmergevalue: {
+		text:     "aggmergevalue",
+		argtypes: []ssatype{stValue, stBool},
+		rettype:  stMem,
+		bc:       opaggmergestate,
+		immfmt:   fmtaggslot,
+		emit:     emitaggmergevalue,
+	},
+	saggslotmergevalue: {
+		text:     "aggslotmergevalue",
+		argtypes: []ssatype{stBucket, stValue, stBool},
+		rettype:  stMem,
+		bc:       opaggslotmergestate,
+		immfmt:   fmtaggslot,
+		priority: prioMem,
+		emit:     emitaggslotmergevalue,
+	},
 
 	saggapproxcount: {
 		text:     "aggapproxcount",
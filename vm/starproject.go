@@ -0,0 +1,234 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// starproject is the goroutine-local component
+// of a Projection that includes '*' (i.e. SELECT *, x AS y)
+//
+// The computed fields are evaluated by the bytecode
+// and then merged with the fields of each input row.
+// Since structures are always encoded with their fields
+// in symbol ID order, the fields of the output are ordered
+// as follows:
+//
+//   - the input fields keep their relative order
+//   - a computed field replaces the input field
+//     with the same name (or removes it, if the
+//     computed value is MISSING)
+//   - the names of the computed fields are interned
+//     in SELECT order, so computed fields whose names
+//     do not appear in the symbol table of the input
+//     follow every input field, in SELECT order
+//
+// The output depends only on the input rows and their
+// symbol table, so identical queries over identical
+// data produce identical output.
+type starproject struct {
+	parent *Projection
+	st     *symtab
+	prog   prog
+	bc     bytecode
+	aw     alignedWriter
+	prep   bool // aw contains current symbol table
+	dst    io.WriteCloser
+	outsel []syminfo // output symbol IDs (sorted); slot is the index in sel
+	params rowParams // always starts empty
+	aux    auxbindings
+
+	dstrc rowConsumer // if dst is a RowConsumer, this is set
+}
+
+func (p *starproject) next() rowConsumer { return p.dstrc }
+
+func (p *starproject) symbolize(st *symtab, aux *auxbindings) error {
+	err := recompile(st, &p.parent.prog, &p.prog, &p.bc, aux, "starproject")
+	if err != nil {
+		return err
+	}
+	sel := p.parent.sel
+	p.outsel = slices.Grow(p.outsel[:0], len(sel))[:len(sel)]
+	for i := range sel {
+		sym := st.Intern(sel[i].Result())
+		p.outsel[i].slot = uint16(i)
+		p.outsel[i].value = sym
+		p.outsel[i].encoded, p.outsel[i].mask, p.outsel[i].size = encoded(sym)
+	}
+	slices.SortStableFunc(p.outsel, func(x, y syminfo) int {
+		return int(x.value) - int(y.value)
+	})
+	// if a name is bound more than once,
+	// then the last binding wins
+	out := p.outsel[:0]
+	for i := range p.outsel {
+		if len(out) > 0 && out[len(out)-1].value == p.outsel[i].value {
+			out[len(out)-1] = p.outsel[i]
+			continue
+		}
+		out = append(out, p.outsel[i])
+	}
+	p.outsel = out
+	p.st = st
+	p.prep = false
+	if p.dstrc != nil {
+		p.aux.reset()
+		return p.dstrc.symbolize(st, &p.aux)
+	}
+	return nil
+}
+
+// merge writes the fields of row merged with the
+// computed fields in lane of regs into dst and
+// returns the number of bytes written; if dst is
+// nil, then merge only computes the size
+func (p *starproject) merge(dst []byte, row []byte, regs []vRegData, lane int) (int, error) {
+	n := 0
+	out := p.outsel
+	field := func(sel *syminfo) {
+		reg := &regs[sel.slot]
+		if reg.sizes[lane] == 0 {
+			return // MISSING
+		}
+		mem := vmref{reg.offsets[lane], reg.sizes[lane]}.mem()
+		if dst == nil {
+			n += int(sel.size) + len(mem)
+			return
+		}
+		n += encodeSymbol(dst, n, sel.value)
+		n += copy(dst[n:], mem)
+	}
+	for len(row) > 0 {
+		sym, rest, err := ion.ReadLabel(row)
+		if err != nil {
+			return 0, err
+		}
+		size := len(row) - len(rest) + ion.SizeOf(rest)
+		if size <= 0 || size > len(row) {
+			return 0, fmt.Errorf("starproject: corrupt field")
+		}
+		for len(out) > 0 && out[0].value < sym {
+			field(&out[0])
+			out = out[1:]
+		}
+		if len(out) > 0 && out[0].value == sym {
+			// replaced by the computed field
+			field(&out[0])
+			out = out[1:]
+		} else if dst == nil {
+			n += size
+		} else {
+			n += copy(dst[n:], row[:size])
+		}
+		row = row[size:]
+	}
+	for i := range out {
+		field(&out[i])
+	}
+	return n, nil
+}
+
+func (p *starproject) writeRows(delims []vmref, rp *rowParams) error {
+	if len(delims) == 0 {
+		return nil
+	}
+	if p.st == nil {
+		panic("WriteRows() called before Symbolize()")
+	}
+	if p.aw.buf == nil {
+		p.aw.init(p.dst)
+	}
+	if !p.prep {
+		err := p.aw.setpre(p.st)
+		if err != nil {
+			return err
+		}
+		p.prep = true
+	}
+	if err := p.bc.prepare(rp, delims); err != nil {
+		return err
+	}
+	base, _ := vmdispl(p.aw.buf)
+	off := p.aw.off
+	done := 0 // rows that have been written out
+	for pos := 0; pos < len(delims); pos += bcLaneCount {
+		lanes := min(bcLaneCount, len(delims)-pos)
+		if err := evalfind(&p.bc, delims[pos:pos+lanes], len(p.parent.sel)); err != nil {
+			return fmt.Errorf("projection: bytecode error: %w", err)
+		}
+		regs := vRegDataFromVStackCast(&p.bc.vstack, len(p.parent.sel))
+		for lane := 0; lane < lanes; lane++ {
+			i := pos + lane
+			row := delims[i].mem()
+			size, err := p.merge(nil, row, regs, lane)
+			if err != nil {
+				return err
+			}
+			total := int(getTLVSize(uint(size))) + size
+			if total > len(p.aw.buf)-off {
+				err := p.emit(delims[done:i], off)
+				if err != nil {
+					return err
+				}
+				off, done = p.aw.off, i
+				if total > len(p.aw.buf)-off {
+					return fmt.Errorf("row too big (%d bytes > buffer size %d bytes)", total, len(p.aw.buf))
+				}
+			}
+			off += encodeTLVUnsafe(p.aw.buf, off, ion.StructType, uint(size))
+			p.merge(p.aw.buf[off:off+size], row, regs, lane)
+			delims[i] = vmref{base + uint32(off), uint32(size)}
+			off += size
+		}
+	}
+	return p.emit(delims[done:], off)
+}
+
+// emit passes the rows that have been written
+// into p.aw.buf[:off] to the destination
+func (p *starproject) emit(rows []vmref, off int) error {
+	if p.dstrc != nil {
+		if len(rows) == 0 {
+			return nil
+		}
+		err := p.dstrc.writeRows(rows, &p.params)
+		if err != nil {
+			return fmt.Errorf("Projection.dst.WriteRows: %w", err)
+		}
+		return nil
+	}
+	p.aw.off = off
+	_, err := p.aw.flush()
+	if err != nil {
+		return fmt.Errorf("Projection.flush(): %w", err)
+	}
+	return nil
+}
+
+func (p *starproject) Close() error {
+	p.bc.reset()
+	return p.aw.Close()
+}
+
+func (p *starproject) EndSegment() {
+	p.aw.maybeDrop()
+	p.bc.dropScratch()
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"bytes"
+	"net/netip"
+)
+
+// isSubnetOfIP6 compiles IS_SUBNET_OF(min, max, str)
+// for a range of IPv6 addresses; the range is passed
+// to the bytecode as 32 bytes in network order
+func (p *prog) isSubnetOfIP6(str *value, min, max [16]byte) *value {
+	str = p.coerceStr(str)
+	return p.ssa2imm(sIsSubnetOfIP6, str, p.mask(str), string(min[:])+string(max[:]))
+}
+
+// parseIP4 parses an IPv4 address in dotted-decimal notation;
+// like opIsSubnetOfIP4, it accepts components with leading zeros
+func parseIP4(str []byte) (ip [4]byte, ok bool) {
+	n, v, digits := 0, 0, 0
+	for _, c := range str {
+		switch {
+		case c >= '0' && c <= '9':
+			if digits == 3 {
+				return ip, false
+			}
+			v = v*10 + int(c-'0')
+			digits++
+		case c == '.':
+			if digits == 0 || v > 255 || n == len(ip)-1 {
+				return ip, false
+			}
+			ip[n] = byte(v)
+			n, v, digits = n+1, 0, 0
+		default:
+			return ip, false
+		}
+	}
+	if digits == 0 || v > 255 || n != len(ip)-1 {
+		return ip, false
+	}
+	ip[n] = byte(v)
+	return ip, true
+}
+
+// parseIP6 parses an IPv6 address, or an IPv4 address,
+// which is returned as the IPv4-mapped IPv6 address ::ffff:a.b.c.d
+// so that it can be compared with the addresses of IPv6 ranges
+func parseIP6(str []byte) ([16]byte, bool) {
+	if ip, ok := parseIP4(str); ok {
+		return netip.AddrFrom4(ip).As16(), true
+	}
+	addr, err := netip.ParseAddr(string(str))
+	if err != nil {
+		return [16]byte{}, false
+	}
+	return addr.As16(), true
+}
+
+// isSubnetOfIP6 returns whether str is an IP address
+// between (and including) min and max; malformed
+// addresses are never in the range
+func isSubnetOfIP6(str []byte, min, max *[16]byte) bool {
+	ip, ok := parseIP6(str)
+	return ok && bytes.Compare(ip[:], min[:]) >= 0 && bytes.Compare(ip[:], max[:]) <= 0
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"fmt"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// jsonIndent is the indentation of TO_JSON(x, 'pretty')
+const jsonIndent = "  "
+
+// toJSON compiles TO_JSON(arg[, format])
+func (p *prog) toJSON(args []expr.Node) (*value, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, fmt.Errorf("TO_JSON expects 1 or 2 arguments, got %d", len(args))
+	}
+	fn := &toJSONFn{}
+	if len(args) == 2 {
+		pretty, err := expr.JSONPretty(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if pretty {
+			fn.indent = jsonIndent
+		}
+	}
+	return p.scalarCall(fn, args[0])
+}
+
+// toJSONFn is the scalarFunc of TO_JSON; it returns
+// the JSON text of a value as a string, or MISSING
+// if the value is MISSING, and it fails the query
+// if the value is not valid ion
+type toJSONFn struct {
+	indent string
+}
+
+func (f *toJSONFn) bind() scalarImpl {
+	var text []byte
+	return func(x *scalarCaller, args []vRegData, lane int) (vmref, error) {
+		mem := x.arg(&args[0], lane)
+		if len(mem) == 0 {
+			return vmref{}, nil
+		}
+		var err error
+		text, err = ion.AppendJSON(text[:0], x.st, mem, f.indent)
+		if err != nil {
+			return vmref{}, fmt.Errorf("TO_JSON: %w", err)
+		}
+		return x.string(text), nil
+	}
+}
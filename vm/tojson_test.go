@@ -0,0 +1,56 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// TestToJSONInvalid tests that TO_JSON reports
+// the values it cannot encode rather than
+// returning MISSING for them
+func TestToJSONInvalid(t *testing.T) {
+	var ctx bctestContext
+	defer ctx.free()
+
+	values := []string{
+		"\x21\x05", // the integer 5
+		"\x82a",    // a truncated string
+		"\xd3\x8a", // a truncated structure
+		"\xa3\x01", // a truncated blob
+	}
+	s := ctx.sRegFromStrings(values)
+	var args [1]vRegData
+	args[0].offsets = s.offsets
+	args[0].sizes = s.sizes
+	x := scalarCaller{st: &ion.Symtab{}}
+	defer x.mem.reset()
+	impl := (&toJSONFn{}).bind()
+	for i := range values {
+		_, err := impl(&x, args[:], i)
+		if i == 0 {
+			if err != nil {
+				t.Fatalf("%x: %s", values[i], err)
+			}
+			continue
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), "TO_JSON: ") {
+			t.Errorf("%x: got error %v", values[i], err)
+		}
+	}
+}
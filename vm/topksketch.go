@@ -0,0 +1,265 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"slices"
+	"strings"
+)
+
+// topkItem is a value tracked by a topkSketch.
+type topkItem struct {
+	// Value is the value being counted.
+	Value string
+	// Count is the estimated frequency
+	// of Value; it never underestimates
+	// the true frequency.
+	Count uint64
+	// Err is the maximum overestimation
+	// of Count, so the true frequency
+	// is in [Count-Err, Count].
+	Err uint64
+}
+
+// topkSketch is an approximate "most frequent
+// values" sketch based on the Space-Saving
+// algorithm (Metwally, Agrawal and El Abbadi, 2005),
+// which is a variant of the Misra-Gries frequent
+// items algorithm; it is the state of TOPK and MODE.
+//
+// A topkSketch tracks at most Size distinct values.
+// If N is the total weight added to a sketch
+// (including all the sketches merged into it),
+// then for every value reported by the sketch:
+//
+//	true count <= Count <= true count + Err
+//	Err <= N / Size
+//
+// Consequently, every value that occurs more
+// than N/Size times is guaranteed to be tracked
+// by the sketch, and the top k values reported
+// by the sketch are exact whenever the k-th
+// most frequent value occurs more than N/Size
+// times more often than the (k+1)-th one.
+// A larger sketch gives tighter bounds at the
+// cost of memory proportional to Size.
+//
+// The zero value of topkSketch is not usable;
+// use newTopkSketch to create one.
+type topkSketch struct {
+	size  int
+	items []topkItem     // min-heap ordered by Count
+	index map[string]int // value -> position in items
+}
+
+// newTopkSketch creates a new topkSketch that
+// tracks at most size distinct values.
+// newTopkSketch panics if size is not positive.
+func newTopkSketch(size int) *topkSketch {
+	if size <= 0 {
+		panic("newTopkSketch: size must be positive")
+	}
+	return &topkSketch{
+		size:  size,
+		index: make(map[string]int),
+	}
+}
+
+// Size returns the maximum number of
+// distinct values tracked by s.
+func (s *topkSketch) Size() int { return s.size }
+
+// Len returns the number of distinct
+// values currently tracked by s.
+func (s *topkSketch) Len() int { return len(s.items) }
+
+// Reset removes all the values from s.
+func (s *topkSketch) Reset() {
+	s.items = s.items[:0]
+	clear(s.index)
+}
+
+func (s *topkSketch) full() bool { return len(s.items) >= s.size }
+
+// min returns the smallest tracked count,
+// which bounds the count of any value that
+// is not tracked by a full sketch
+func (s *topkSketch) min() uint64 {
+	if !s.full() {
+		return 0
+	}
+	return s.items[0].Count
+}
+
+// Add adds weight occurrences of value to s.
+func (s *topkSketch) Add(value string, weight uint64) {
+	if i, ok := s.index[value]; ok {
+		s.items[i].Count += weight
+		s.down(i)
+		return
+	}
+	if !s.full() {
+		s.push(topkItem{Value: value, Count: weight})
+		return
+	}
+	// evict the least frequent value;
+	// the new value inherits its count
+	// as the error bound
+	min := s.items[0].Count
+	delete(s.index, s.items[0].Value)
+	s.items[0] = topkItem{Value: value, Count: min + weight, Err: min}
+	s.index[value] = 0
+	s.down(0)
+}
+
+// Merge adds the contents of o to s.
+//
+// Values tracked by only one of the sketches
+// are assumed to have occurred in the other
+// sketch as often as its least frequent value,
+// which preserves the error guarantee of the
+// merged sketch (see Agarwal et al., 2012,
+// "Mergeable Summaries").
+func (s *topkSketch) Merge(o *topkSketch) {
+	if len(o.items) == 0 {
+		return
+	}
+	smin, omin := s.min(), o.min()
+	all := make([]topkItem, 0, len(s.items)+len(o.items))
+	for i := range s.items {
+		it := s.items[i]
+		if j, ok := o.index[it.Value]; ok {
+			it.Count += o.items[j].Count
+			it.Err += o.items[j].Err
+		} else {
+			it.Count += omin
+			it.Err += omin
+		}
+		all = append(all, it)
+	}
+	for i := range o.items {
+		it := o.items[i]
+		if _, ok := s.index[it.Value]; ok {
+			continue
+		}
+		it.Count += smin
+		it.Err += smin
+		all = append(all, it)
+	}
+	s.Reset()
+	s.load(all)
+}
+
+// Load replaces the contents of s with items,
+// which would typically have been produced by
+// Items on a sketch of the same size.
+// If there are more items than the size of s,
+// only the most frequent ones are retained.
+func (s *topkSketch) Load(items []topkItem) {
+	s.Reset()
+	s.load(slices.Clone(items))
+}
+
+func (s *topkSketch) load(items []topkItem) {
+	if len(items) > s.size {
+		slices.SortFunc(items, compareTopkItems)
+		items = items[:s.size]
+	}
+	s.items = append(s.items, items...)
+	for i := len(s.items)/2 - 1; i >= 0; i-- {
+		s.down(i)
+	}
+	for i := range s.items {
+		s.index[s.items[i].Value] = i
+	}
+}
+
+// Items returns all the items tracked by s
+// in no particular order.
+func (s *topkSketch) Items() []topkItem {
+	return slices.Clone(s.items)
+}
+
+// Top returns at most k of the most frequent
+// values tracked by s, ordered by descending
+// Count (and then by ascending Err and Value).
+func (s *topkSketch) Top(k int) []topkItem {
+	out := slices.Clone(s.items)
+	slices.SortFunc(out, compareTopkItems)
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
+
+// compareTopkItems orders items from the
+// most to the least frequent one
+func compareTopkItems(a, b topkItem) int {
+	if a.Count != b.Count {
+		if a.Count > b.Count {
+			return -1
+		}
+		return 1
+	}
+	if a.Err != b.Err {
+		if a.Err < b.Err {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a.Value, b.Value)
+}
+
+func (s *topkSketch) push(it topkItem) {
+	s.items = append(s.items, it)
+	i := len(s.items) - 1
+	s.index[it.Value] = i
+	s.up(i)
+}
+
+func (s *topkSketch) swap(i, j int) {
+	s.items[i], s.items[j] = s.items[j], s.items[i]
+	s.index[s.items[i].Value] = i
+	s.index[s.items[j].Value] = j
+}
+
+func (s *topkSketch) up(i int) {
+	for i > 0 {
+		p := (i - 1) / 2
+		if s.items[p].Count <= s.items[i].Count {
+			break
+		}
+		s.swap(i, p)
+		i = p
+	}
+}
+
+func (s *topkSketch) down(i int) {
+	n := len(s.items)
+	for {
+		c := 2*i + 1
+		if c >= n {
+			break
+		}
+		if r := c + 1; r < n && s.items[r].Count < s.items[c].Count {
+			c = r
+		}
+		if s.items[i].Count <= s.items[c].Count {
+			break
+		}
+		s.swap(i, c)
+		i = c
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestTopkSketchExact(t *testing.T) {
+	s := newTopkSketch(4)
+	for i, v := range []string{"a", "b", "a", "c", "a", "b"} {
+		s.Add(v, uint64(i%2+1))
+	}
+	want := []topkItem{
+		{Value: "b", Count: 4},
+		{Value: "a", Count: 3},
+		{Value: "c", Count: 2},
+	}
+	got := s.Top(10)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := s.Top(1); len(got) != 1 || got[0].Value != "b" {
+		t.Fatalf("Top(1) = %v", got)
+	}
+}
+
+// zipf returns a stream of n values drawn from
+// a skewed distribution along with the true counts
+func topkZipf(seed int64, n int) ([]string, map[string]uint64) {
+	rng := rand.New(rand.NewSource(seed))
+	z := rand.NewZipf(rng, 1.2, 1, 10000)
+	out := make([]string, n)
+	counts := make(map[string]uint64)
+	for i := range out {
+		out[i] = fmt.Sprintf("v%d", z.Uint64())
+		counts[out[i]]++
+	}
+	return out, counts
+}
+
+func checkTopkBounds(t *testing.T, s *topkSketch, counts map[string]uint64, n int) {
+	t.Helper()
+	bound := uint64(n / s.Size())
+	tracked := make(map[string]bool)
+	for _, it := range s.Items() {
+		tracked[it.Value] = true
+		c := counts[it.Value]
+		if it.Count < c || it.Count-it.Err > c {
+			t.Errorf("%s: true count %d not in [%d, %d]", it.Value, c, it.Count-it.Err, it.Count)
+		}
+		if it.Err > bound {
+			t.Errorf("%s: error %d exceeds %d", it.Value, it.Err, bound)
+		}
+	}
+	for v, c := range counts {
+		if c > bound && !tracked[v] {
+			t.Errorf("%s (count %d > %d) not tracked", v, c, bound)
+		}
+	}
+}
+
+func TestTopkSketchBounds(t *testing.T) {
+	const n = 100000
+	values, counts := topkZipf(1, n)
+	for _, size := range []int{10, 50, 200} {
+		s := newTopkSketch(size)
+		for _, v := range values {
+			s.Add(v, 1)
+		}
+		if s.Len() != size {
+			t.Errorf("size %d: len %d", size, s.Len())
+		}
+		checkTopkBounds(t, s, counts, n)
+	}
+}
+
+func TestTopkSketchMerge(t *testing.T) {
+	const n = 100000
+	const parts = 7
+	values, counts := topkZipf(2, n)
+	for _, size := range []int{10, 50, 200} {
+		sketches := make([]*topkSketch, parts)
+		for i := range sketches {
+			sketches[i] = newTopkSketch(size)
+		}
+		for i, v := range values {
+			sketches[i%parts].Add(v, 1)
+		}
+		// round-trip through Items and Load
+		// to simulate a distributed merge
+		s := newTopkSketch(size)
+		for i := range sketches {
+			o := newTopkSketch(size)
+			o.Load(sketches[i].Items())
+			s.Merge(o)
+		}
+		if s.Len() != size {
+			t.Errorf("size %d: len %d", size, s.Len())
+		}
+		checkTopkBounds(t, s, counts, n)
+
+		top := s.Top(3)
+		for i, want := range []string{"v0", "v1", "v2"} {
+			if top[i].Value != want {
+				t.Errorf("size %d: top[%d] = %s, want %s", size, i, top[i].Value, want)
+			}
+		}
+	}
+}
+
+func TestTopkSketchLoadTruncates(t *testing.T) {
+	s := newTopkSketch(2)
+	s.Load([]topkItem{
+		{Value: "a", Count: 1},
+		{Value: "b", Count: 5},
+		{Value: "c", Count: 3},
+	})
+	got := s.Top(5)
+	if len(got) != 2 || got[0].Value != "b" || got[1].Value != "c" {
+		t.Fatalf("unexpected items %v", got)
+	}
+	// "a" must evict the minimum ("c")
+	s.Add("a", 1)
+	got = s.Top(5)
+	want := []topkItem{{Value: "b", Count: 5}, {Value: "a", Count: 4, Err: 3}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/SnellerInc/sneller/expr"
+)
+
+// transcode compiles TO_HEX, FROM_HEX, TO_BASE64 or FROM_BASE64
+func (p *prog) transcode(op expr.BuiltinOp, args []expr.Node) (*value, error) {
+	url := false
+	switch op {
+	case expr.ToBase64, expr.FromBase64:
+		if len(args) != 1 && len(args) != 2 {
+			return nil, fmt.Errorf("%s expects 1 or 2 arguments, got %d", op, len(args))
+		}
+		if len(args) == 2 {
+			var err error
+			url, err = expr.Base64URL(op, args[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+	default:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s expects 1 argument, got %d", op, len(args))
+		}
+	}
+	switch op {
+	case expr.ToHex, expr.ToBase64:
+		// the contents of strings and blobs are encoded
+		v, err := p.serialized(args[0])
+		if err != nil {
+			return nil, err
+		}
+		v = p.unsymbolized(v)
+		if op == expr.ToHex {
+			return p.ssa2(stohex, v, p.mask(v)), nil
+		}
+		return p.ssa2imm(stobase64, v, p.mask(v), url), nil
+	default:
+		s, err := p.compileAsString(args[0])
+		if err != nil {
+			return nil, err
+		}
+		var b *value
+		if op == expr.FromHex {
+			b = p.ssa2(sfromhex, s, p.mask(s))
+		} else {
+			b = p.ssa2imm(sfrombase64, s, p.mask(s), url)
+		}
+		return p.ssa2(sboxblob, b, p.mask(b)), nil
+	}
+}
+
+// base64Encoding returns the encoding of TO_BASE64:
+// the URL-safe alphabet is used without padding,
+// as is customary for URLs and tokens
+func base64Encoding(url bool) *base64.Encoding {
+	if url {
+		return base64.RawURLEncoding
+	}
+	return base64.StdEncoding
+}
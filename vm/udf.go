@@ -0,0 +1,79 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm
+
+import (
+	"fmt"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+)
+
+// scalarUDF compiles a call of the user-defined
+// function name (see expr.RegisterScalar)
+func (p *prog) scalarUDF(name string, fn expr.Scalar, args []expr.Node) (*value, error) {
+	return p.scalarCall(&udfFn{name: name, fn: fn}, args...)
+}
+
+// udfFn is the scalarFunc of a user-defined function
+type udfFn struct {
+	name string
+	fn   expr.Scalar
+}
+
+func (f *udfFn) bind() scalarImpl {
+	var args []ion.Datum
+	var out ion.Buffer
+	var outst ion.Symtab
+	return func(x *scalarCaller, regs []vRegData, lane int) (ref vmref, err error) {
+		args = args[:0]
+		for i := range regs {
+			mem := x.arg(&regs[i], lane)
+			if len(mem) == 0 {
+				args = append(args, ion.Empty)
+				continue
+			}
+			d, _, err := ion.ReadDatum(x.st, mem)
+			if err != nil {
+				return vmref{}, fmt.Errorf("%s: %w", f.name, err)
+			}
+			args = append(args, d)
+		}
+		defer func() {
+			if e := recover(); e != nil {
+				ref, err = vmref{}, fmt.Errorf("%s: panic: %v", f.name, e)
+			}
+		}()
+		d, err := f.fn(args)
+		if err != nil {
+			return vmref{}, fmt.Errorf("%s: %w", f.name, err)
+		}
+		if d.IsEmpty() {
+			return vmref{}, nil
+		}
+		switch d.Type() {
+		case ion.SymbolType:
+			str, _ := d.String()
+			d = ion.String(str)
+		case ion.StructType, ion.ListType, ion.SexpType:
+			// these could reference symbols that
+			// are not in the symbol table of the input
+			return vmref{}, fmt.Errorf("%s: unsupported result type %s", f.name, d.Type())
+		}
+		out.Reset()
+		d.Encode(&out, &outst)
+		return x.value(out.Bytes()), nil
+	}
+}
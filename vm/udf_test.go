@@ -0,0 +1,110 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package vm_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/expr"
+	"github.com/SnellerInc/sneller/ion"
+	"github.com/SnellerInc/sneller/testquery"
+)
+
+func init() {
+	expr.RegisterScalar("udf_repeat", expr.Signature{
+		Args: []expr.TypeSet{expr.StringType, expr.IntegerType},
+		Ret:  expr.StringType,
+	}, func(args []ion.Datum) (ion.Datum, error) {
+		str, err := args[0].String()
+		if err != nil {
+			return ion.Empty, nil
+		}
+		n, err := args[1].Int()
+		if err != nil || n < 0 {
+			return ion.Empty, nil
+		}
+		return ion.String(strings.Repeat(str, int(n))), nil
+	})
+	expr.RegisterScalar("udf_fail", expr.Signature{
+		Args: []expr.TypeSet{expr.NumericType},
+		Ret:  expr.NumericType,
+	}, func(args []ion.Datum) (ion.Datum, error) {
+		n, err := args[0].Int()
+		if err != nil {
+			return ion.Empty, nil
+		}
+		if n == 2 {
+			return ion.Empty, errors.New("two is not allowed")
+		}
+		if n == 3 {
+			panic("three")
+		}
+		return ion.Int(n * 10), nil
+	})
+}
+
+func TestScalarUDF(t *testing.T) {
+	run := func(t *testing.T, text string) error {
+		tc, err := testquery.ReadCase(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tc.Execute(testquery.FlagParallel)
+	}
+	err := run(t, `
+SELECT udf_repeat(s, n) AS r, udf_fail(n * 5) AS f FROM input
+---
+{"s": "ab", "n": 2}
+{"s": "x", "n": 0}
+{"s": "abc", "n": -1}
+{"n": 1}
+{"s": "xyz", "n": 1}
+{"s": "ab"}
+---
+{"r": "abab", "f": 100}
+{"r": "", "f": 0}
+{"f": -50}
+{"f": 50}
+{"r": "xyz", "f": 50}
+{}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errors := []struct {
+		n    int
+		want string
+	}{
+		{2, "UDF_FAIL: two is not allowed"},
+		{3, "UDF_FAIL: panic: three"},
+	}
+	for _, e := range errors {
+		err := run(t, fmt.Sprintf(`
+SELECT udf_fail(n) AS f FROM input
+---
+{"n": 1}
+{"n": %d}
+---
+{"f": 10}
+{"f": 0}
+`, e.n))
+		if err == nil || !strings.Contains(err.Error(), e.want) {
+			t.Errorf("n=%d: got error %v, want %q", e.n, err, e.want)
+		}
+	}
+}
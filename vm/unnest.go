@@ -166,7 +166,9 @@ func (u *unnesting) writeRows(delims []vmref, rp *rowParams) error {
 		panic("WriteRows() called before Symbolize()")
 	}
 
-	u.splat.prepare(rp)
+	if err := u.splat.prepare(rp, delims); err != nil {
+		return err
+	}
 	consumed := 0
 	for consumed < len(delims) {
 		// provide as much space as possible:
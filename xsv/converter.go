@@ -46,11 +46,27 @@ type RowChopper interface {
 // reader using the specified chopper/hints
 // to determine the individual fields and
 // writes it to the ION chunker
+//
+// If hint.Header is set, then the first record
+// is a header; when hint.Fields is empty, the
+// fields are inferred from the header and
+// the records following it.
 func Convert(r io.Reader, dst *ion.Chunker, ch RowChopper, hint *Hint, cons []ion.Field) error {
 	// cannot convert without hints
-	if hint == nil || len(hint.Fields) == 0 {
+	if hint == nil || (len(hint.Fields) == 0 && !hint.Header) {
 		return ErrNoHints
 	}
+	if hint.Header {
+		var err error
+		ch, hint, err = readHeader(r, ch, hint)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// empty input
+				return nil
+			}
+			return err
+		}
+	}
 
 	// make sure constant field IDs are interned
 	prev := ion.Symbol(0)
@@ -15,8 +15,11 @@
 package xsv
 
 import (
-	"encoding/csv"
+	"bufio"
+	"bytes"
+	"errors"
 	"io"
+	"slices"
 )
 
 // CsvChopper reads a CSV formatted file
@@ -29,39 +32,177 @@ type CsvChopper struct {
 	// Separator allows specifying a custom
 	// separator (defaults to comma)
 	Separator Delim
+	// Quote allows specifying a custom
+	// quote character (defaults to a double quote)
+	Quote Delim
 
 	r      io.Reader
-	cr     *csv.Reader
+	br     *bufio.Reader
 	lineNr int
+	line   []byte // line buffer for long lines
+	text   []byte // unquoted text of the current record
+	ends   []int  // end of each field in text
+	fields []string
 }
 
+var errSameQuote = errors.New("xsv: the separator and quote characters must differ")
+
 // GetNext fetches one CSV record and
 // returns the individual columns. Due
 // to quoting a CSV record may span multiple
 // lines of text.
 func (c *CsvChopper) GetNext(r io.Reader) ([]string, error) {
 	c.init(r)
+	sep, quote := c.delims()
+	if sep == quote {
+		return nil, errSameQuote
+	}
 	for {
-		fields, err := c.cr.Read()
+		err := c.read(sep, quote)
 		if err != nil {
 			return nil, err
 		}
 		c.lineNr++
 		if c.lineNr > c.SkipRecords {
-			return fields, nil
+			return c.fields, nil
 		}
 	}
 }
 
+func (c *CsvChopper) delims() (sep, quote byte) {
+	sep, quote = ',', '"'
+	if c.Separator != 0 {
+		sep = byte(c.Separator)
+	}
+	if c.Quote != 0 {
+		quote = byte(c.Quote)
+	}
+	return sep, quote
+}
+
 func (c *CsvChopper) init(r io.Reader) {
 	if c.r != r {
 		c.r = r
-		c.cr = csv.NewReader(c.r)
-		c.cr.FieldsPerRecord = -1
-		c.cr.ReuseRecord = true
-		c.cr.LazyQuotes = true
-		if c.Separator != 0 {
-			c.cr.Comma = rune(c.Separator)
+		c.lineNr = 0
+		c.br = bufio.NewReader(c.r)
+	}
+}
+
+// readLine returns the next line of text
+// including its terminating newline (if any);
+// a trailing CRLF is normalized to LF
+func (c *CsvChopper) readLine() ([]byte, error) {
+	line, err := c.br.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		c.line = append(c.line[:0], line...)
+		for errors.Is(err, bufio.ErrBufferFull) {
+			line, err = c.br.ReadSlice('\n')
+			c.line = append(c.line, line...)
 		}
+		line = c.line
+	}
+	if len(line) > 0 && errors.Is(err, io.EOF) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n := len(line); n >= 2 && line[n-2] == '\r' && line[n-1] == '\n' {
+		line[n-2] = '\n'
+		line = line[:n-1]
+	}
+	return line, nil
+}
+
+func endOfLine(line []byte) bool {
+	return len(line) == 0 || (len(line) == 1 && line[0] == '\n')
+}
+
+// read reads the next non-empty record into c.fields
+//
+// Like encoding/csv with LazyQuotes set, a quote
+// that appears in an unquoted field or that
+// isn't followed by a separator in a quoted field
+// is interpreted literally, and an unterminated
+// quoted field extends to the end of the input.
+func (c *CsvChopper) read(sep, quote byte) error {
+	var line []byte
+	var err error
+	for {
+		line, err = c.readLine()
+		if err != nil {
+			return err
+		}
+		if !endOfLine(line) {
+			break
+		}
+	}
+	c.text = c.text[:0]
+	c.ends = c.ends[:0]
+	done := false
+	for !done {
+		if len(line) == 0 || line[0] != quote {
+			i := bytes.IndexByte(line, sep)
+			if i < 0 {
+				line = bytes.TrimSuffix(line, []byte{'\n'})
+				i, done = len(line), true
+			}
+			c.text = append(c.text, line[:i]...)
+			c.ends = append(c.ends, len(c.text))
+			if !done {
+				line = line[i+1:]
+			}
+			continue
+		}
+		// quoted field
+		line = line[1:]
+		for {
+			i := bytes.IndexByte(line, quote)
+			if i < 0 {
+				// the field continues on the next line
+				c.text = append(c.text, line...)
+				line, err = c.readLine()
+				if errors.Is(err, io.EOF) {
+					c.ends = append(c.ends, len(c.text))
+					done = true
+					break
+				}
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			c.text = append(c.text, line[:i]...)
+			line = line[i+1:]
+			if len(line) > 0 && line[0] == quote {
+				// escaped quote
+				c.text = append(c.text, quote)
+				line = line[1:]
+				continue
+			}
+			if len(line) > 0 && line[0] == sep {
+				line = line[1:]
+				c.ends = append(c.ends, len(c.text))
+				break
+			}
+			if endOfLine(line) {
+				c.ends = append(c.ends, len(c.text))
+				done = true
+				break
+			}
+			// stray quote
+			c.text = append(c.text, quote)
+		}
+	}
+
+	// create a single string and slice it to
+	// reduce the amount of allocations.
+	text := string(c.text)
+	c.fields = slices.Grow(c.fields[:0], len(c.ends))
+	start := 0
+	for _, end := range c.ends {
+		c.fields = append(c.fields, text[start:end])
+		start = end
 	}
+	return nil
 }
@@ -91,7 +91,7 @@ func TestConvertCSV(t *testing.T) {
 				t.Fatalf("cannot parse hints in %q: %s", hintsFile, err)
 			}
 
-			ch := CsvChopper{SkipRecords: h.SkipRecords, Separator: h.Separator}
+			ch := CsvChopper{SkipRecords: h.SkipRecords, Separator: h.Separator, Quote: h.Quote}
 			testConvert(t, csvFile, &ch, h)
 		})
 	}
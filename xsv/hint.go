@@ -59,6 +59,18 @@ type Hint struct {
 	// Separator allows specifying a custom
 	// separator (only applicable for CSV)
 	Separator Delim `json:"separator,omitempty"`
+	// Quote allows specifying a custom quote
+	// character (only applicable for CSV)
+	Quote Delim `json:"quote,omitempty"`
+	// Header indicates that the first record
+	// (after SkipRecords) contains the column names.
+	// If Fields is empty, then the fields are inferred
+	// from the header and the records following it;
+	// otherwise the header is skipped.
+	Header bool `json:"header,omitempty"`
+	// AllStrings disables type inference,
+	// so that every inferred field is a string.
+	AllStrings bool `json:"all_strings,omitempty"`
 	// MissingValues is an optional list of
 	// strings which represent missing values.
 	// Entries in Fields may override this on a
@@ -147,7 +159,12 @@ func (fh *FieldHint) UnmarshalJSON(data []byte) error {
 		// the symbol will be added later
 		fh.fieldParts[i] = fieldPart{name: v}
 	}
+	return fh.init()
+}
 
+// init validates the hint and picks the
+// conversion function for its type
+func (fh *FieldHint) init() error {
 	// determine type
 	t := fh.Type
 	if t == "" {
@@ -254,6 +271,16 @@ func (fh *FieldHint) isRootField() bool {
 // Some values may be included in the sparse index. Set the 'no_index' field
 // to `true` to prevent this behavior for the field.
 //
+// Instead of listing the fields, the hints may set 'header' to
+// indicate that the first record contains the column names:
+//
+//	{"header": true, "separator": ";", "quote": "'"}
+//
+// The type of each column is then inferred from the values in
+// the records following the header (int, number, bool, datetime
+// or string). Set 'all_strings' to ingest every column as a string
+// instead. Column names are used literally (they are not split at dots).
+//
 // Supported types:
 //   - string -> set 'allow_empty' if you want empty strings to be ingested
 //   - number -> either float or int
@@ -0,0 +1,200 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package xsv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/SnellerInc/sneller/date"
+)
+
+// inferSample is the number of records
+// following the header that are used
+// to infer the type of each column
+const inferSample = 1000
+
+// candidate types for a column
+const (
+	inferInt = 1 << iota
+	inferNumber
+	inferBool
+	inferDateTime
+
+	inferAll = inferInt | inferNumber | inferBool | inferDateTime
+)
+
+// readHeader reads the header record and
+// returns the chopper and hint that should
+// be used for the remaining records. When
+// the hint doesn't specify any fields, then
+// the fields are inferred from the header
+// and the first records following it.
+func readHeader(r io.Reader, ch RowChopper, hint *Hint) (RowChopper, *Hint, error) {
+	header, err := ch.GetNext(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(hint.Fields) > 0 {
+		return ch, hint, nil
+	}
+	header = slices.Clone(header)
+	rc := &replayChopper{ch: ch}
+	for len(rc.rows) < inferSample {
+		row, err := ch.GetNext(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return nil, nil, err
+			}
+			rc.err = err
+			break
+		}
+		rc.rows = append(rc.rows, slices.Clone(row))
+	}
+	fields, err := inferFields(hint, header, rc.rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	// don't modify the caller's hint,
+	// because it may be shared by
+	// multiple files
+	h := *hint
+	h.Fields = fields
+	return rc, &h, nil
+}
+
+// inferFields determines the field hints
+// from the column names in the header
+// and a sample of the records
+func inferFields(hint *Hint, header []string, sample [][]string) ([]FieldHint, error) {
+	fields := make([]FieldHint, len(header))
+	used := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			name = fmt.Sprintf("_%d", i+1)
+		}
+		for n := i + 1; used[name]; n++ {
+			name = fmt.Sprintf("%s_%d", strings.TrimSpace(header[i]), n)
+		}
+		used[name] = true
+
+		fh := &fields[i]
+		fh.Name = name
+		fh.Type = TypeString
+		if !hint.AllStrings {
+			fh.Type = inferType(sample, i, hint.MissingValues)
+		}
+		// column names are used literally,
+		// so dots don't create nested fields
+		fh.fieldParts = []fieldPart{{name: name}}
+		if err := fh.init(); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
+// inferType returns the most specific type
+// that matches all non-empty values of
+// the column in the sample
+func inferType(sample [][]string, col int, missing []string) string {
+	mask := inferAll
+	seen := false
+	for _, row := range sample {
+		if col >= len(row) {
+			continue
+		}
+		text := row[col]
+		if text == "" || slices.Contains(missing, text) {
+			continue
+		}
+		seen = true
+		mask &= valueTypes(text)
+		if mask == 0 {
+			break
+		}
+	}
+	switch {
+	case !seen || mask == 0:
+		return TypeString
+	case mask&inferInt != 0:
+		return TypeInt
+	case mask&inferNumber != 0:
+		return TypeNumber
+	case mask&inferBool != 0:
+		return TypeBool
+	default:
+		return TypeDateTime
+	}
+}
+
+// valueTypes returns the candidate
+// types for a single value
+func valueTypes(text string) int {
+	if leadingZero(text) {
+		// values like "007" are typically
+		// identifiers rather than numbers
+		return 0
+	}
+	if _, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return inferInt | inferNumber
+	}
+	// reject NaN and Inf, which don't contain digits
+	if _, err := strconv.ParseFloat(text, 64); err == nil &&
+		strings.ContainsAny(text, "0123456789") {
+		return inferNumber
+	}
+	switch strings.ToLower(text) {
+	case "true", "false":
+		if _, err := strconv.ParseBool(text); err == nil {
+			return inferBool
+		}
+	}
+	if _, ok := date.Parse([]byte(text)); ok {
+		return inferDateTime
+	}
+	return 0
+}
+
+func leadingZero(text string) bool {
+	text = strings.TrimPrefix(text, "-")
+	return len(text) > 1 && text[0] == '0' && text[1] >= '0' && text[1] <= '9'
+}
+
+// replayChopper returns the records
+// that were read during inference before
+// continuing with the underlying chopper
+type replayChopper struct {
+	ch   RowChopper
+	rows [][]string
+	err  error
+}
+
+func (rc *replayChopper) GetNext(r io.Reader) ([]string, error) {
+	if len(rc.rows) > 0 {
+		row := rc.rows[0]
+		rc.rows = rc.rows[1:]
+		return row, nil
+	}
+	if rc.err != nil {
+		return nil, rc.err
+	}
+	return rc.ch.GetNext(r)
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 Sneller, Inc.
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+
+package xsv
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/SnellerInc/sneller/ion"
+)
+
+func TestInferType(t *testing.T) {
+	tcs := []struct {
+		values []string
+		want   string
+	}{
+		{[]string{"1", "-2", "0"}, TypeInt},
+		{[]string{"1", "2.5", ""}, TypeNumber},
+		{[]string{"1e3", ".5"}, TypeNumber},
+		{[]string{"1", "NaN"}, TypeString},
+		{[]string{"Inf"}, TypeString},
+		{[]string{"007", "1"}, TypeString},
+		{[]string{"0.7", "-0"}, TypeNumber},
+		{[]string{"true", "FALSE", "True"}, TypeBool},
+		{[]string{"true", "1"}, TypeString},
+		{[]string{"2023-01-02T03:04:05Z", "2023-01-02T03:04:05.123+01:00"}, TypeDateTime},
+		{[]string{"2023-01-02T03:04:05Z", "yesterday"}, TypeString},
+		{[]string{"", "-"}, TypeString},
+		{[]string{"1", "-"}, TypeInt},
+		{nil, TypeString},
+	}
+	for _, tc := range tcs {
+		var sample [][]string
+		for _, v := range tc.values {
+			sample = append(sample, []string{v})
+		}
+		got := inferType(sample, 0, []string{"-"})
+		if got != tc.want {
+			t.Errorf("%q: got %s, want %s", tc.values, got, tc.want)
+		}
+	}
+}
+
+func TestInferFields(t *testing.T) {
+	header := []string{"a", "", "a", " b.c ", "a_3"}
+	sample := [][]string{{"1", "x", "true", "1.5"}}
+	fields, err := inferFields(&Hint{}, header, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names, types []string
+	for i := range fields {
+		names = append(names, fields[i].Name)
+		types = append(types, fields[i].Type)
+		if !fields[i].isRootField() {
+			t.Errorf("field %q is not a root field", fields[i].Name)
+		}
+	}
+	wantNames := []string{"a", "_2", "a_3", "b.c", "a_3_5"}
+	if !slices.Equal(names, wantNames) {
+		t.Errorf("got names %q, want %q", names, wantNames)
+	}
+	wantTypes := []string{TypeInt, TypeString, TypeBool, TypeNumber, TypeString}
+	if !slices.Equal(types, wantTypes) {
+		t.Errorf("got types %q, want %q", types, wantTypes)
+	}
+
+	fields, err = inferFields(&Hint{AllStrings: true}, header, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range fields {
+		if fields[i].Type != TypeString {
+			t.Errorf("field %q has type %s", fields[i].Name, fields[i].Type)
+		}
+	}
+}
+
+func TestConvertHeader(t *testing.T) {
+	// the inferred fields must not be
+	// stored in the shared hint
+	h := &Hint{Header: true}
+	tcs := []struct {
+		text, want string
+	}{
+		{"a,b\n1,x\n", "{\"a\": 1, \"b\": \"x\"}\n"},
+		{"a,b\nx,1\n", "{\"a\": \"x\", \"b\": 1}\n"},
+	}
+	for _, tc := range tcs {
+		var out strings.Builder
+		dst := ion.Chunker{Align: alignment, W: ion.NewJSONWriter(&out, '\n')}
+		err := Convert(strings.NewReader(tc.text), &dst, &CsvChopper{}, h, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := dst.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != tc.want {
+			t.Errorf("%q: got %q, want %q", tc.text, got, tc.want)
+		}
+	}
+	if len(h.Fields) != 0 {
+		t.Errorf("hint was modified: %v", h.Fields)
+	}
+
+	// an empty input (or just a header)
+	// produces no records
+	for _, text := range []string{"", "a,b\n"} {
+		dst := ion.Chunker{Align: alignment, W: io.Discard}
+		err := Convert(strings.NewReader(text), &dst, &CsvChopper{}, h, nil)
+		if err != nil {
+			t.Errorf("%q: %s", text, err)
+		}
+	}
+
+	err := Convert(strings.NewReader("a\n"), &ion.Chunker{Align: alignment, W: io.Discard}, &CsvChopper{}, &Hint{}, nil)
+	if err != ErrNoHints {
+		t.Errorf("got error %v without header or fields", err)
+	}
+}